@@ -59,6 +59,15 @@ func main() {
 
 	// Initialize API client
 	apiClient := telegram.NewAPIClient(cfg.API.BaseURL, cfg.API.APIKey, cfg.API.Timeout)
+	if cfg.API.InternalAddr != "" {
+		internalAPI, err := telegram.NewInternalAPIClient(cfg.API.InternalAddr, cfg.API.InternalCertFile, cfg.API.InternalKeyFile, cfg.API.InternalCAFile, cfg.API.InternalServerName, cfg.API.Timeout)
+		if err != nil {
+			log.Fatalf("Failed to initialize internal API client: %v", err)
+		}
+		defer internalAPI.Close()
+		apiClient.SetInternalAPI(internalAPI)
+		log.Printf("Using internal API at %s", cfg.API.InternalAddr)
+	}
 
 	// Initialize rate limiter
 	rateLimiter := telegram.NewRateLimiter(redisClient)
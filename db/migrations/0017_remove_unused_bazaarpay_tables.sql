@@ -1,4 +1,5 @@
 -- Remove unused BazaarPay tables that are no longer referenced by the application
+-- +migrate phase: post-deploy
 -- This migration is safe to run multiple times thanks to IF EXISTS guards.
 
 BEGIN;
@@ -1,4 +1,5 @@
 -- Remove legacy/unused tables and their dependent objects to keep schema lean
+-- +migrate phase: post-deploy
 
 BEGIN;
 
@@ -0,0 +1,48 @@
+-- Share Campaigns Migration
+-- Lets vendors create share links in bulk for marketing campaigns.
+-- Campaign links can point at a vendor image (garment) instead of a conversion
+-- and live longer than the 1-5 minute links users create for their results.
+
+BEGIN;
+
+-- share_campaigns table - groups bulk-created share links under a campaign tag
+CREATE TABLE IF NOT EXISTS share_campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id UUID NOT NULL REFERENCES vendors(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (vendor_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_campaigns_vendor_id ON share_campaigns(vendor_id);
+
+-- Add trigger for share_campaigns updated_at
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_share_campaigns_updated_at') THEN
+CREATE TRIGGER trg_share_campaigns_updated_at
+BEFORE UPDATE ON share_campaigns
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+    END IF;
+END $$;
+
+-- Campaign links may target an image directly
+ALTER TABLE shared_links ADD COLUMN IF NOT EXISTS campaign_id UUID REFERENCES share_campaigns(id) ON DELETE SET NULL;
+ALTER TABLE shared_links ADD COLUMN IF NOT EXISTS image_id UUID REFERENCES images(id) ON DELETE CASCADE;
+ALTER TABLE shared_links ALTER COLUMN conversion_id DROP NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_shared_links_target') THEN
+        ALTER TABLE shared_links ADD CONSTRAINT chk_shared_links_target
+            CHECK (conversion_id IS NOT NULL OR image_id IS NOT NULL);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_shared_links_campaign_id ON shared_links(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_shared_links_image_id ON shared_links(image_id);
+
+COMMIT;
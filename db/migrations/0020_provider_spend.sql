@@ -0,0 +1,20 @@
+-- Provider Spend Migration
+-- Tracks per-call spend on AI providers so the worker can enforce
+-- monthly budget caps and degrade before the bill runs away.
+
+BEGIN;
+
+-- provider_spend table - one row per billed provider call
+CREATE TABLE IF NOT EXISTS provider_spend (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider TEXT NOT NULL,
+    conversion_id UUID REFERENCES conversions(id) ON DELETE SET NULL,
+    cost NUMERIC(12,4) NOT NULL CHECK (cost >= 0),
+    degraded BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_spend_provider_created_at ON provider_spend(provider, created_at);
+CREATE INDEX IF NOT EXISTS idx_provider_spend_conversion_id ON provider_spend(conversion_id);
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Image Moderation Migration
+-- Records the verdict of the moderation stage the worker runs on user and
+-- cloth images before calling Gemini, so admins can review rejections.
+
+BEGIN;
+
+-- image_moderation_verdicts table - one row per moderated image
+CREATE TABLE IF NOT EXISTS image_moderation_verdicts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversion_id UUID REFERENCES conversions(id) ON DELETE SET NULL,
+    image_id UUID REFERENCES images(id) ON DELETE SET NULL,
+    image_kind TEXT NOT NULL CHECK (image_kind IN ('user', 'cloth')),
+    provider TEXT NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    nsfw_score NUMERIC(5,4) NOT NULL DEFAULT 0,
+    face_count INTEGER NOT NULL DEFAULT -1,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    review_status TEXT NOT NULL DEFAULT 'none' CHECK (review_status IN ('none', 'pending', 'confirmed', 'overturned')),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_moderation_verdicts_review_status ON image_moderation_verdicts(review_status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_image_moderation_verdicts_conversion_id ON image_moderation_verdicts(conversion_id);
+CREATE INDEX IF NOT EXISTS idx_image_moderation_verdicts_image_id ON image_moderation_verdicts(image_id);
+
+COMMIT;
@@ -0,0 +1,123 @@
+-- Audit Log Hash Chain Migration
+-- Adds a monotonically increasing sequence for cursor-based streaming to a SIEM
+-- and chains every entry to the previous one with a SHA-256 hash so edits or
+-- deletions of past entries are detectable.
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE SEQUENCE IF NOT EXISTS audit_logs_seq_seq;
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS seq BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS entry_hash TEXT;
+-- Snapshot of user_id at insert time; user_id itself is nulled when a user is deleted
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hashed_user_id TEXT;
+
+-- audit_log_entry_hash computes the chained hash of one entry.
+-- The Go verifier in internal/admin mirrors this layout exactly.
+CREATE OR REPLACE FUNCTION audit_log_entry_hash(
+    p_prev_hash TEXT,
+    p_seq BIGINT,
+    p_id UUID,
+    p_user_id TEXT,
+    p_actor_type TEXT,
+    p_action TEXT,
+    p_resource TEXT,
+    p_resource_id TEXT,
+    p_metadata JSONB,
+    p_created_at TIMESTAMPTZ
+) RETURNS TEXT AS $$
+    SELECT encode(digest(concat_ws('|',
+        p_prev_hash,
+        p_seq::TEXT,
+        p_id::TEXT,
+        COALESCE(p_user_id, ''),
+        p_actor_type,
+        p_action,
+        COALESCE(p_resource, ''),
+        COALESCE(p_resource_id, ''),
+        p_metadata::TEXT,
+        to_char(p_created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    ), 'sha256'), 'hex');
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Backfill existing entries in creation order
+DO $$
+DECLARE
+    r RECORD;
+    v_prev TEXT := repeat('0', 64);
+    v_seq BIGINT;
+BEGIN
+    FOR r IN SELECT * FROM audit_logs WHERE entry_hash IS NULL ORDER BY created_at, id LOOP
+        v_seq := nextval('audit_logs_seq_seq');
+        UPDATE audit_logs
+        SET seq = v_seq,
+            hashed_user_id = r.user_id::TEXT,
+            prev_hash = v_prev,
+            entry_hash = audit_log_entry_hash(v_prev, v_seq, r.id, r.user_id::TEXT, r.actor_type, r.action,
+                                              r.resource, r.resource_id, r.metadata, r.created_at)
+        WHERE id = r.id
+        RETURNING entry_hash INTO v_prev;
+    END LOOP;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_seq ON audit_logs(seq);
+
+-- Chain new entries. The advisory lock serializes writers until commit so the
+-- sequence order always matches the chain order.
+CREATE OR REPLACE FUNCTION audit_logs_hash_chain() RETURNS TRIGGER AS $$
+DECLARE
+    v_prev TEXT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('audit_logs_hash_chain'));
+
+    SELECT entry_hash INTO v_prev FROM audit_logs ORDER BY seq DESC LIMIT 1;
+    IF v_prev IS NULL THEN
+        v_prev := repeat('0', 64);
+    END IF;
+
+    NEW.seq := nextval('audit_logs_seq_seq');
+    NEW.created_at := COALESCE(NEW.created_at, NOW());
+    NEW.hashed_user_id := NEW.user_id::TEXT;
+    NEW.prev_hash := v_prev;
+    NEW.entry_hash := audit_log_entry_hash(v_prev, NEW.seq, NEW.id, NEW.hashed_user_id, NEW.actor_type, NEW.action,
+                                           NEW.resource, NEW.resource_id, NEW.metadata, NEW.created_at);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_hash_chain ON audit_logs;
+CREATE TRIGGER trg_audit_logs_hash_chain
+    BEFORE INSERT ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_hash_chain();
+
+-- Entries are append-only once chained. Only the ON DELETE SET NULL foreign keys
+-- may change a row, which leaves the hashed columns untouched.
+CREATE OR REPLACE FUNCTION audit_logs_prevent_update() RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.seq, NEW.id, NEW.hashed_user_id, NEW.actor_type, NEW.action, NEW.resource, NEW.resource_id,
+        NEW.metadata, NEW.created_at, NEW.prev_hash, NEW.entry_hash)
+       IS DISTINCT FROM
+       (OLD.seq, OLD.id, OLD.hashed_user_id, OLD.actor_type, OLD.action, OLD.resource, OLD.resource_id,
+        OLD.metadata, OLD.created_at, OLD.prev_hash, OLD.entry_hash) THEN
+        RAISE EXCEPTION 'audit_logs entries are append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_prevent_update ON audit_logs;
+CREATE TRIGGER trg_audit_logs_prevent_update
+    BEFORE UPDATE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_prevent_update();
+
+-- Forwarder cursors so pushes to the SIEM resume after restarts
+CREATE TABLE IF NOT EXISTS audit_forward_cursors (
+    name TEXT PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
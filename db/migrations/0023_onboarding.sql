@@ -0,0 +1,44 @@
+-- Onboarding Migration
+-- Records the signup credit grant and first-conversion fast lane per user so
+-- growth can compare activation rates of onboarded cohorts with the rest.
+
+BEGIN;
+
+-- onboarding_grants table - one row per user that went through onboarding
+CREATE TABLE IF NOT EXISTS onboarding_grants (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    credits INTEGER NOT NULL DEFAULT 0,
+    granted_at TIMESTAMPTZ,
+    fast_lane_conversion_id UUID REFERENCES conversions(id) ON DELETE SET NULL,
+    fast_lane_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_onboarding_grants_granted_at ON onboarding_grants(granted_at);
+
+-- grant_onboarding_credits sets the user's free conversion allowance once.
+-- Returns false when the user was already granted credits.
+CREATE OR REPLACE FUNCTION grant_onboarding_credits(p_user_id UUID, p_credits INTEGER)
+RETURNS BOOLEAN AS $$
+BEGIN
+    INSERT INTO onboarding_grants (user_id, credits, granted_at)
+    VALUES (p_user_id, p_credits, NOW())
+    ON CONFLICT (user_id) DO UPDATE
+        SET credits = EXCLUDED.credits, granted_at = EXCLUDED.granted_at
+        WHERE onboarding_grants.granted_at IS NULL;
+
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    UPDATE users
+    SET free_conversions_limit = p_credits,
+        free_quota_remaining = GREATEST(0, p_credits - free_conversions_used),
+        updated_at = NOW()
+    WHERE id = p_user_id;
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
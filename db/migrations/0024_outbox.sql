@@ -0,0 +1,36 @@
+-- Transactional Outbox Migration
+-- Cross-module events are written to outbox_events in the same transaction as
+-- the business change and published by the outbox dispatcher, so a crash
+-- between the commit and the notification no longer loses the event.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'published', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+-- Dispatcher polling: due pending events in insertion order
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due
+    ON outbox_events(next_attempt_at, created_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate
+    ON outbox_events(aggregate_type, aggregate_id);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dead
+    ON outbox_events(created_at)
+    WHERE status = 'dead';
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Vendor Storefront Migration
+-- Adds a public slug to vendors so verified vendors can expose their profile
+-- and public albums at /api/public/vendors/:slug for embedding on external sites.
+
+BEGIN;
+
+ALTER TABLE vendors ADD COLUMN IF NOT EXISTS slug TEXT;
+
+-- Backfill existing vendors from their business name (or "vendor" for names
+-- without latin characters), suffixed with the id prefix to keep slugs unique
+UPDATE vendors
+SET slug = COALESCE(NULLIF(trim(BOTH '-' FROM lower(regexp_replace(
+        COALESCE(business_name, display_name, company_name, ''),
+        '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'vendor') || '-' || left(id::text, 8)
+WHERE slug IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vendors_slug ON vendors(slug);
+
+-- Storefront album listing: public albums of a vendor, newest first
+CREATE INDEX IF NOT EXISTS idx_albums_vendor_public
+    ON albums(vendor_id, created_at DESC)
+    WHERE is_public = true;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Conversion Logs Migration
+-- Persists key worker log lines per conversion (stage transitions, provider
+-- status codes, retry reasons) so admins can correlate failures afterwards.
+-- The worker keeps at most a fixed number of entries per conversion and
+-- prunes entries past the retention window.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_logs (
+    id BIGSERIAL PRIMARY KEY,
+    conversion_id UUID NOT NULL REFERENCES conversions(id) ON DELETE CASCADE,
+    job_id TEXT,
+    worker_id TEXT,
+    stage TEXT NOT NULL,
+    level TEXT NOT NULL DEFAULT 'info' CHECK (level IN ('info', 'warn', 'error')),
+    message TEXT NOT NULL,
+    status_code INTEGER,
+    attempt INTEGER,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversion_logs_conversion_id ON conversion_logs(conversion_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_conversion_logs_created_at ON conversion_logs(created_at);
+
+COMMIT;
@@ -0,0 +1,14 @@
+-- Image Trash Migration
+-- Deleting a vendor image sets deleted_at instead of removing the row so the
+-- vendor can restore it within the restore window. Trashed images keep their
+-- files and still count towards storage usage until they are purged.
+
+BEGIN;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Trash listing per vendor and the purge scan
+CREATE INDEX IF NOT EXISTS idx_images_vendor_trash ON images(vendor_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_deleted_at ON images(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
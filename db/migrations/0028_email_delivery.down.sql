@@ -0,0 +1,10 @@
+-- Email Delivery Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_notification_deliveries_provider_message_id;
+
+ALTER TABLE notification_deliveries DROP COLUMN IF EXISTS provider_message_id;
+ALTER TABLE notification_deliveries DROP COLUMN IF EXISTS provider;
+
+COMMIT;
@@ -0,0 +1,14 @@
+-- Email Delivery Migration
+-- Email deliveries record which provider accepted the message and the message
+-- ID it assigned, so bounces and complaints can be traced back to a delivery.
+
+BEGIN;
+
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS provider_message_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_provider_message_id
+    ON notification_deliveries(provider, provider_message_id)
+    WHERE provider_message_id IS NOT NULL;
+
+COMMIT;
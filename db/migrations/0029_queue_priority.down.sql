@@ -0,0 +1,12 @@
+-- Queue Priority Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_worker_jobs_pending_created_at;
+
+ALTER TABLE worker_jobs DROP COLUMN IF EXISTS boosted_at;
+ALTER TABLE worker_jobs DROP COLUMN IF EXISTS boosted_by;
+
+ALTER TABLE payment_plans DROP COLUMN IF EXISTS queue_priority;
+
+COMMIT;
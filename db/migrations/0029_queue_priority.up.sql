@@ -0,0 +1,25 @@
+-- Queue Priority Migration
+-- Conversions are queued with the priority of the user's active plan, so
+-- paying customers are processed first. Pending jobs age while they wait
+-- (see WORKER_QUEUE_AGING_*), which keeps free users from starving. Admins can
+-- boost a single pending job; the boost is recorded on the job.
+
+BEGIN;
+
+ALTER TABLE payment_plans ADD COLUMN IF NOT EXISTS queue_priority INTEGER NOT NULL DEFAULT 5;
+
+UPDATE payment_plans SET queue_priority = CASE name
+    WHEN 'basic' THEN 10
+    WHEN 'premium' THEN 15
+    WHEN 'advanced' THEN 15
+    WHEN 'enterprise' THEN 18
+    ELSE 5
+END;
+
+ALTER TABLE worker_jobs ADD COLUMN IF NOT EXISTS boosted_by UUID;
+ALTER TABLE worker_jobs ADD COLUMN IF NOT EXISTS boosted_at TIMESTAMPTZ;
+
+-- Dequeue orders pending jobs by aged priority, which the priority index can't serve
+CREATE INDEX IF NOT EXISTS idx_worker_jobs_pending_created_at ON worker_jobs(created_at) WHERE status = 'pending';
+
+COMMIT;
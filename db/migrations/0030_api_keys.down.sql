@@ -0,0 +1,9 @@
+-- API Keys Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS api_keys;
+
+ALTER TABLE payment_plans DROP COLUMN IF EXISTS api_access;
+
+COMMIT;
@@ -0,0 +1,33 @@
+-- API Keys Migration
+-- Users on plans with API access create scoped keys for B2B integrations.
+-- Only the SHA-256 of a key is stored; its prefix identifies it in listings.
+-- Keys with the webhook scope receive their user's conversion events at
+-- webhook_url, signed with webhook_secret.
+
+BEGIN;
+
+ALTER TABLE payment_plans ADD COLUMN IF NOT EXISTS api_access BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE payment_plans SET api_access = name IN ('basic', 'premium', 'advanced', 'enterprise');
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    rate_limit_per_minute INTEGER NOT NULL,
+    webhook_url TEXT,
+    webhook_secret VARCHAR(64),
+    request_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_webhooks ON api_keys(user_id) WHERE webhook_url IS NOT NULL AND revoked_at IS NULL;
+
+COMMIT;
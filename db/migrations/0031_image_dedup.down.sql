@@ -0,0 +1,13 @@
+-- Image Deduplication Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_images_perceptual_hash;
+DROP INDEX IF EXISTS idx_images_content_hash;
+
+ALTER TABLE vendors DROP COLUMN IF EXISTS image_dedupe_enabled;
+
+ALTER TABLE images DROP COLUMN IF EXISTS perceptual_hash;
+ALTER TABLE images DROP COLUMN IF EXISTS content_hash;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Image Deduplication Migration
+-- Uploads store a SHA-256 of the file and a perceptual hash (dHash) of the
+-- decoded image. Uploading a file whose hash matches one of the owner's live
+-- images returns that image instead of storing a new copy. Vendors can turn
+-- this off for their uploads.
+
+BEGIN;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS content_hash CHAR(64);
+ALTER TABLE images ADD COLUMN IF NOT EXISTS perceptual_hash BIGINT;
+
+ALTER TABLE vendors ADD COLUMN IF NOT EXISTS image_dedupe_enabled BOOLEAN NOT NULL DEFAULT true;
+
+-- Duplicate lookups only consider live images
+CREATE INDEX IF NOT EXISTS idx_images_content_hash ON images(content_hash) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_images_perceptual_hash ON images(perceptual_hash) WHERE deleted_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Worker Drain Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE worker_jobs DROP COLUMN IF EXISTS restart_count;
+
+COMMIT;
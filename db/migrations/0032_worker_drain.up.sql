@@ -0,0 +1,10 @@
+-- Worker Drain Migration
+-- On shutdown a worker stops taking jobs, waits for in-flight jobs to finish
+-- and puts the ones that didn't back in the queue. restart_count records how
+-- many times a job was requeued this way.
+
+BEGIN;
+
+ALTER TABLE worker_jobs ADD COLUMN IF NOT EXISTS restart_count INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- System Settings Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS system_settings;
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- System Settings Migration
+-- Admin-editable settings read by the running services. Values are stored as
+-- text and parsed according to type; instances reload them when an admin
+-- changes one, without a restart.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS system_settings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key VARCHAR(100) NOT NULL UNIQUE,
+    value TEXT NOT NULL DEFAULT '',
+    type VARCHAR(20) NOT NULL DEFAULT 'string' CHECK (type IN ('string', 'boolean', 'integer', 'array')),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Settings applied at runtime; the seed script adds the rest
+INSERT INTO system_settings (key, value, type) VALUES
+    ('maintenance_mode', 'false', 'boolean'),
+    ('rate_limit_enabled', 'true', 'boolean'),
+    ('rate_limit_per_minute', '60', 'integer'),
+    ('api_rate_limit', '1000', 'integer'),
+    ('max_file_size', '50MB', 'string'),
+    ('conversion_timeout', '300', 'integer')
+ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
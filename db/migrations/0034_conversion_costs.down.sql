@@ -0,0 +1,7 @@
+-- Conversion Costs Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS conversion_costs;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Conversion Costs Migration
+-- Records what the AI provider billed for each conversion (tokens and
+-- generated images) with the user's plan at the time, so admins can break
+-- spend down per user, plan and provider.
+
+BEGIN;
+
+-- conversion_costs table - one row per successful provider call
+CREATE TABLE IF NOT EXISTS conversion_costs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversion_id UUID REFERENCES conversions(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    plan_name TEXT NOT NULL DEFAULT 'free',
+    provider TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    output_tokens INTEGER NOT NULL DEFAULT 0 CHECK (output_tokens >= 0),
+    images INTEGER NOT NULL DEFAULT 0 CHECK (images >= 0),
+    cost NUMERIC(12,6) NOT NULL CHECK (cost >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversion_costs_created_at ON conversion_costs(created_at);
+CREATE INDEX IF NOT EXISTS idx_conversion_costs_user_id_created_at ON conversion_costs(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_conversion_costs_conversion_id ON conversion_costs(conversion_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Audit Log Archives Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS audit_log_archives;
+
+COMMIT;
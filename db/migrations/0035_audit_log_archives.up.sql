@@ -0,0 +1,22 @@
+-- Audit Log Archives Migration
+-- Audit log entries older than the retention period are moved to object
+-- storage in sequence order. Each archive records the hash of its last entry
+-- so the remaining chain can still be verified from its new start.
+
+BEGIN;
+
+-- audit_log_archives table - one row per archived batch of audit_logs
+CREATE TABLE IF NOT EXISTS audit_log_archives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_seq BIGINT NOT NULL,
+    to_seq BIGINT NOT NULL,
+    entry_count INTEGER NOT NULL CHECK (entry_count > 0),
+    last_entry_hash TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_seq <= to_seq)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_archives_to_seq ON audit_log_archives(to_seq);
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Admin Two-Factor Authentication Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_two_factor_recovery_codes;
+DROP TABLE IF EXISTS user_two_factor;
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- Admin Two-Factor Authentication Migration
+-- Admins sign in with a password plus a TOTP code from an authenticator app.
+-- The secret is stored once enrollment starts and only takes effect after the
+-- first code is confirmed. Recovery codes are stored as SHA-256 hashes.
+
+BEGIN;
+
+-- user_two_factor table - one TOTP enrollment per user
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- user_two_factor_recovery_codes table - single-use codes for a lost authenticator
+CREATE TABLE IF NOT EXISTS user_two_factor_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Image Variants Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS image_variants;
+
+COMMIT;
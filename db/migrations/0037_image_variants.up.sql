@@ -0,0 +1,25 @@
+-- Image Variants Migration
+-- Result images are re-encoded as WebP/AVIF and resized to responsive widths
+-- in the background. Each variant is looked up by the storage-relative path
+-- of its source image so the storage endpoints can negotiate a format.
+
+BEGIN;
+
+-- image_variants table - alternative encodings and sizes of stored images
+CREATE TABLE IF NOT EXISTS image_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    image_id UUID REFERENCES images(id) ON DELETE CASCADE,
+    source_path TEXT NOT NULL,
+    path TEXT NOT NULL UNIQUE,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('jpeg', 'png', 'webp', 'avif')),
+    content_type VARCHAR(50) NOT NULL,
+    width INTEGER NOT NULL CHECK (width > 0),
+    height INTEGER NOT NULL CHECK (height > 0),
+    file_size BIGINT NOT NULL CHECK (file_size >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_variants_source_path ON image_variants(source_path);
+CREATE INDEX IF NOT EXISTS idx_image_variants_image_id ON image_variants(image_id);
+
+COMMIT;
@@ -0,0 +1,17 @@
+-- Vendor Album Curation Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_images_album_position;
+DROP INDEX IF EXISTS idx_albums_vendor_position;
+
+ALTER TABLE images DROP COLUMN IF EXISTS is_featured;
+ALTER TABLE images DROP COLUMN IF EXISTS position;
+
+ALTER TABLE albums DROP COLUMN IF EXISTS version;
+ALTER TABLE albums DROP COLUMN IF EXISTS cover_image_id;
+ALTER TABLE albums DROP COLUMN IF EXISTS position;
+
+ALTER TABLE vendors DROP COLUMN IF EXISTS album_order_version;
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Vendor Album Curation Migration
+-- Vendors order their albums and the images inside each album, pin featured
+-- images and choose album covers. Changes carry the version they were based
+-- on: vendors.album_order_version guards the album order and albums.version
+-- guards the image order, cover and pins of one album.
+
+BEGIN;
+
+ALTER TABLE vendors ADD COLUMN IF NOT EXISTS album_order_version INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE albums ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE albums ADD COLUMN IF NOT EXISTS cover_image_id UUID REFERENCES images(id) ON DELETE SET NULL;
+ALTER TABLE albums ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS is_featured BOOLEAN NOT NULL DEFAULT false;
+
+-- Curated album listing: position order; albums never positioned (0) come
+-- first, newest first among equal positions
+CREATE INDEX IF NOT EXISTS idx_albums_vendor_position
+    ON albums(vendor_id, position, created_at DESC);
+
+-- Curated image listing: pinned images first, then position order
+CREATE INDEX IF NOT EXISTS idx_images_album_position
+    ON images(album_id, is_featured DESC, position, created_at DESC);
+
+COMMIT;
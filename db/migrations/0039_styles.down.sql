@@ -0,0 +1,7 @@
+-- Styles Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS styles;
+
+COMMIT;
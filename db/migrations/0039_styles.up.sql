@@ -0,0 +1,27 @@
+-- Styles Migration
+-- Catalog of conversion styles offered to users. Conversions keep recording
+-- the style by name in conversions.style_name; key is the stable identifier
+-- used by seed fixtures and clients.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS styles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    description TEXT,
+    prompt TEXT NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_styles_active_sort ON styles(is_active, sort_order);
+
+DROP TRIGGER IF EXISTS trg_styles_updated_at ON styles;
+CREATE TRIGGER trg_styles_updated_at
+BEFORE UPDATE ON styles
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Conversion Retry Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE conversions DROP CONSTRAINT IF EXISTS conversions_failure_kind_check;
+ALTER TABLE conversions
+    DROP COLUMN IF EXISTS failure_kind,
+    DROP COLUMN IF EXISTS retry_count;
+
+COMMIT;
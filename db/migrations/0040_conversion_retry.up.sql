@@ -0,0 +1,17 @@
+-- Conversion Retry Migration
+-- Failed conversions can be retried by their owner. retry_count caps the
+-- number of retries per conversion and failure_kind records whether the last
+-- failure was caused by the provider/server (retried without charging quota)
+-- or by the request itself (retried as a new conversion).
+
+BEGIN;
+
+ALTER TABLE conversions
+    ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS failure_kind TEXT;
+
+ALTER TABLE conversions DROP CONSTRAINT IF EXISTS conversions_failure_kind_check;
+ALTER TABLE conversions ADD CONSTRAINT conversions_failure_kind_check
+    CHECK (failure_kind IS NULL OR failure_kind IN ('provider', 'request'));
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Telegram Account Linking Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS telegram_link_codes;
+DROP INDEX IF EXISTS idx_users_telegram_user_id;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS telegram_linked_at,
+    DROP COLUMN IF EXISTS telegram_user_id;
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- Telegram Account Linking Migration
+-- Users link their Telegram account to a backend user with a short-lived
+-- code issued by the backend and entered in the bot (or sent as a /start
+-- deep link). The linked telegram_user_id lets the bot sign the user in
+-- without a password. Link codes are stored as SHA-256 hashes.
+
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS telegram_user_id BIGINT,
+    ADD COLUMN IF NOT EXISTS telegram_linked_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_telegram_user_id ON users(telegram_user_id)
+    WHERE telegram_user_id IS NOT NULL;
+
+-- telegram_link_codes table - single-use codes that link a Telegram account
+CREATE TABLE IF NOT EXISTS telegram_link_codes (
+    code_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_link_codes_user_id ON telegram_link_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_telegram_link_codes_expires_at ON telegram_link_codes(expires_at);
+
+COMMIT;
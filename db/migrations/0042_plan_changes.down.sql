@@ -0,0 +1,15 @@
+-- Plan Changes Migration (rollback)
+-- The plan_name check is not restored since existing rows may use newer plans.
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_user_plans_previous_user_plan_id;
+ALTER TABLE user_plans DROP CONSTRAINT IF EXISTS user_plans_change_direction_check;
+ALTER TABLE user_plans
+    DROP COLUMN IF EXISTS proration_balance_cents,
+    DROP COLUMN IF EXISTS proration_credit_cents,
+    DROP COLUMN IF EXISTS proration_charge_cents,
+    DROP COLUMN IF EXISTS change_direction,
+    DROP COLUMN IF EXISTS previous_user_plan_id;
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Plan Changes Migration
+-- Users can upgrade or downgrade their active plan mid-cycle. A change closes
+-- the current user_plans row and opens a new one for the same billing cycle,
+-- so user_plans keeps the history of changes. The prorated charge and credit
+-- of each change are recorded on the new row; proration_balance_cents is the
+-- running amount (positive = owed, negative = credit) settled on renewal.
+
+BEGIN;
+
+ALTER TABLE user_plans
+    ADD COLUMN IF NOT EXISTS previous_user_plan_id UUID REFERENCES user_plans(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS change_direction TEXT,
+    ADD COLUMN IF NOT EXISTS proration_charge_cents BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS proration_credit_cents BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS proration_balance_cents BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE user_plans DROP CONSTRAINT IF EXISTS user_plans_change_direction_check;
+ALTER TABLE user_plans ADD CONSTRAINT user_plans_change_direction_check
+    CHECK (change_direction IS NULL OR change_direction IN ('upgrade', 'downgrade'));
+
+-- Plan names come from payment_plans, which has outgrown the original list
+ALTER TABLE user_plans DROP CONSTRAINT IF EXISTS user_plans_plan_name_check;
+
+CREATE INDEX IF NOT EXISTS idx_user_plans_previous_user_plan_id ON user_plans(previous_user_plan_id)
+    WHERE previous_user_plan_id IS NOT NULL;
+
+COMMIT;
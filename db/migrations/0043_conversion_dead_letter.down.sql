@@ -0,0 +1,13 @@
+-- Conversion Dead Letter Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_conversions_dead_lettered_at;
+
+ALTER TABLE conversions
+    DROP COLUMN IF EXISTS dead_letter_requeues,
+    DROP COLUMN IF EXISTS provider_response,
+    DROP COLUMN IF EXISTS provider_status_code,
+    DROP COLUMN IF EXISTS dead_lettered_at;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Conversion Dead Letter Migration
+-- Conversions that fail after the worker exhausted its provider retries are
+-- dead-lettered with the captured provider response, so admins can inspect
+-- them and requeue them once the provider recovers.
+
+BEGIN;
+
+ALTER TABLE conversions
+    ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS provider_status_code INTEGER,
+    ADD COLUMN IF NOT EXISTS provider_response TEXT,
+    ADD COLUMN IF NOT EXISTS dead_letter_requeues INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_conversions_dead_lettered_at
+    ON conversions(dead_lettered_at DESC)
+    WHERE dead_lettered_at IS NOT NULL;
+
+COMMIT;
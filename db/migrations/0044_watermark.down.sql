@@ -0,0 +1,11 @@
+-- Watermark Migration (rollback)
+
+BEGIN;
+
+DELETE FROM system_settings
+WHERE key IN ('watermark_enabled', 'watermark_text', 'watermark_position', 'watermark_opacity');
+
+UPDATE payment_plans
+SET features = array_remove(features, 'watermark_removal');
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Watermark Migration
+-- Result images of users without watermark_removal in their plan get a
+-- watermark; paid plans include the feature and the overlay is configured
+-- through system settings.
+
+BEGIN;
+
+UPDATE payment_plans
+SET features = array_append(features, 'watermark_removal')
+WHERE name IN ('basic', 'advanced')
+  AND NOT ('watermark_removal' = ANY(features));
+
+INSERT INTO system_settings (key, value, type) VALUES
+    ('watermark_enabled', 'true', 'boolean'),
+    ('watermark_text', 'AI Styler', 'string'),
+    ('watermark_position', 'bottom-right', 'string'),
+    ('watermark_opacity', '50', 'integer')
+ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
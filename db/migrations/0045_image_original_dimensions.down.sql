@@ -0,0 +1,9 @@
+-- Image Original Dimensions Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS original_height,
+    DROP COLUMN IF EXISTS original_width;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Image Original Dimensions Migration
+-- Uploads are rotated upright according to their EXIF orientation before
+-- they are stored; width/height describe the stored image and these columns
+-- keep the dimensions as uploaded.
+
+BEGIN;
+
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS original_width INTEGER,
+    ADD COLUMN IF NOT EXISTS original_height INTEGER;
+
+COMMIT;
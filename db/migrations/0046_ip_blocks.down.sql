@@ -0,0 +1,7 @@
+-- IP Blocks Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS ip_blocks;
+
+COMMIT;
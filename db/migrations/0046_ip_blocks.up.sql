@@ -0,0 +1,21 @@
+-- IP Blocks Migration
+-- Addresses and CIDR ranges refused by the IP filter middleware. Admins add
+-- blocks through /api/admin/ip-blocks; the middleware adds temporary blocks
+-- for IPs that repeatedly hit rate limits or fail authentication.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ip_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    network CIDR NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL CHECK (source IN ('manual', 'auto')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ip_blocks_expires_at ON ip_blocks(expires_at);
+CREATE INDEX IF NOT EXISTS idx_ip_blocks_created_at ON ip_blocks(created_at DESC);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Conversion Feedback Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS conversion_feedback;
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Conversion Feedback Migration
+-- User ratings (1-5) of conversion results with optional issue flags. The
+-- style and the provider that produced the result are copied from the
+-- conversion so admin analytics can compare them without joining the logs.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversion_id UUID NOT NULL UNIQUE REFERENCES conversions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    issues TEXT[] NOT NULL DEFAULT '{}',
+    comment TEXT NOT NULL DEFAULT '',
+    style_name TEXT,
+    provider TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversion_feedback_created_at ON conversion_feedback(created_at);
+CREATE INDEX IF NOT EXISTS idx_conversion_feedback_user_id ON conversion_feedback(user_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Image Uploads Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS image_uploads;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Image Uploads Migration
+-- Direct uploads issued by POST /api/images/presign. Clients PUT the file to
+-- a one-time signed URL under uploads/staging/ and finalize it with
+-- POST /api/images/confirm, which creates the image. Unconfirmed uploads
+-- expire and their staged files are deleted.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS image_uploads (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    vendor_id UUID REFERENCES vendors(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    file_size BIGINT NOT NULL,
+    mime_type TEXT NOT NULL,
+    is_public BOOLEAN NOT NULL DEFAULT false,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    object_key TEXT NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'expired')),
+    image_id UUID REFERENCES images(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_image_uploads_owner CHECK (user_id IS NOT NULL OR vendor_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_uploads_pending_expires_at ON image_uploads(expires_at) WHERE status = 'pending';
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Notification Digests Migration (rollback)
+-- The 'digest' notification_type value is kept, enum values can't be dropped.
+
+BEGIN;
+
+DELETE FROM notifications WHERE type = 'digest';
+
+DROP TABLE IF EXISTS notification_digest_items;
+
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS digest_frequency;
+
+COMMIT;
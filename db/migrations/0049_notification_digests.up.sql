@@ -0,0 +1,30 @@
+-- Notification Digests Migration
+-- Users can receive their low-priority email and WebSocket notifications as an
+-- hourly or daily digest instead of one by one. Held back notifications are
+-- queued in notification_digest_items until the digest covering them is sent.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'digest';
+
+BEGIN;
+
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS digest_frequency VARCHAR(10) NOT NULL DEFAULT 'off'
+        CHECK (digest_frequency IN ('off', 'hourly', 'daily'));
+
+CREATE TABLE IF NOT EXISTS notification_digest_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel notification_channel NOT NULL,
+    digest_id UUID REFERENCES notifications(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    CONSTRAINT uq_notification_digest_items_notification UNIQUE (notification_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_digest_items_pending
+    ON notification_digest_items(user_id, channel, created_at) WHERE digest_id IS NULL;
+
+COMMENT ON COLUMN notification_preferences.digest_frequency IS 'off, hourly or daily; low-priority email/WebSocket notifications are batched at this frequency';
+
+COMMIT;
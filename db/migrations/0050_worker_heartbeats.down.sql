@@ -0,0 +1,9 @@
+-- Worker Heartbeats Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_worker_jobs_processing_started;
+
+DROP TABLE IF EXISTS worker_heartbeats;
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- Worker Heartbeats Migration
+-- Every worker loop periodically records its status, current job and last
+-- error so the admin worker status endpoint can show the workers of all
+-- instances. Rows of stopped instances are deleted on shutdown; rows of
+-- crashed instances stop being refreshed and are pruned later.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS worker_heartbeats (
+    worker_id TEXT PRIMARY KEY,
+    instance_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    current_job_id UUID,
+    jobs_processed BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_error_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_worker_heartbeats_instance_id ON worker_heartbeats(instance_id);
+CREATE INDEX IF NOT EXISTS idx_worker_heartbeats_last_seen ON worker_heartbeats(last_seen);
+
+-- In-flight jobs are listed by status and start time
+CREATE INDEX IF NOT EXISTS idx_worker_jobs_processing_started
+    ON worker_jobs(started_at) WHERE status = 'processing';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- OTP Deliveries Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS otp_deliveries;
+
+COMMIT;
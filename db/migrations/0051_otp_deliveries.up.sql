@@ -0,0 +1,24 @@
+-- OTP Deliveries Migration
+-- OTP messages accepted by a provider that reported a message ID are recorded
+-- with the carrier prefix of the phone number, so signed delivery reports from
+-- the provider can mark them delivered or failed and carrier issues show up in
+-- the per-carrier delivery stats. The phone number itself is not stored.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS otp_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(32) NOT NULL,
+    provider_message_id VARCHAR(64) NOT NULL,
+    carrier_prefix VARCHAR(8),
+    status VARCHAR(16) NOT NULL DEFAULT 'sent' CHECK (status IN ('sent', 'delivered', 'failed')),
+    status_detail TEXT,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    reported_at TIMESTAMPTZ,
+    CONSTRAINT uq_otp_deliveries_provider_message UNIQUE (provider, provider_message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_deliveries_sent_at ON otp_deliveries(sent_at);
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Conversion Presets Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE conversions
+    DROP COLUMN IF EXISTS options,
+    DROP COLUMN IF EXISTS preset_id;
+
+DROP TABLE IF EXISTS conversion_presets;
+
+COMMIT;
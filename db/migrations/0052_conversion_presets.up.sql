@@ -0,0 +1,31 @@
+-- Conversion Presets Migration
+-- Users save their preferred conversion options (style, quality, output size
+-- and watermark text) as named presets, at most one of them the default. A
+-- conversion created from a preset keeps a reference to it and a snapshot of
+-- the options it was created with, so later edits to the preset do not change
+-- queued or retried conversions.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_presets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    style_name VARCHAR(100),
+    quality VARCHAR(16) CHECK (quality IN ('standard', 'high', 'ultra')),
+    output_size INTEGER CHECK (output_size BETWEEN 256 AND 4096),
+    watermark_text VARCHAR(100),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_conversion_presets_user_name UNIQUE (user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversion_presets_user_default
+    ON conversion_presets(user_id) WHERE is_default;
+
+ALTER TABLE conversions
+    ADD COLUMN IF NOT EXISTS preset_id UUID REFERENCES conversion_presets(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS options JSONB;
+
+COMMIT;
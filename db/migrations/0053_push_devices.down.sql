@@ -0,0 +1,7 @@
+-- Push Devices Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS push_devices;
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Push Devices Migration
+-- Devices register their FCM registration token to receive push
+-- notifications. A token identifies one app install, so registering a token
+-- another user held moves it to the new user. Tokens FCM reports as no longer
+-- valid are disabled instead of deleted, and tokens not refreshed for months
+-- are pruned.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS push_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    platform VARCHAR(16) NOT NULL CHECK (platform IN ('android', 'ios', 'web')),
+    app_version VARCHAR(50),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    disabled_at TIMESTAMPTZ,
+    disabled_reason VARCHAR(100),
+    CONSTRAINT uq_push_devices_token UNIQUE (token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_user_active
+    ON push_devices(user_id, last_seen_at DESC) WHERE disabled_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_last_seen
+    ON push_devices(last_seen_at);
+
+COMMIT;
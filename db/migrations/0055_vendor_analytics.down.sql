@@ -0,0 +1,7 @@
+-- Vendor Analytics Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_conversions_cloth_image_created_at;
+
+COMMIT;
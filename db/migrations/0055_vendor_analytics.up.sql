@@ -0,0 +1,10 @@
+-- Vendor Analytics Migration
+-- Vendor analytics count the conversions of each garment image over a time
+-- range, so conversions are indexed by garment and creation time together.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_conversions_cloth_image_created_at
+    ON conversions(cloth_image_id, created_at);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Conversion Garments Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS conversion_garments;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Conversion Garments Migration
+-- A conversion can try on an outfit of several garments (top, bottom,
+-- accessory...). The garments are kept in layering order; cloth_image_id
+-- stays the first garment so single-garment readers keep working.
+-- Single-garment conversions have no rows here.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_garments (
+    conversion_id UUID NOT NULL REFERENCES conversions(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL CHECK (position > 0),
+    image_id UUID NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    slot TEXT CHECK (slot IN ('top', 'bottom', 'dress', 'outerwear', 'shoes', 'accessory')),
+    PRIMARY KEY (conversion_id, position),
+    UNIQUE (conversion_id, image_id)
+);
+
+-- Vendor analytics count conversions per garment image
+CREATE INDEX IF NOT EXISTS idx_conversion_garments_image_id ON conversion_garments(image_id);
+
+COMMIT;
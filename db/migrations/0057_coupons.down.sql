@@ -0,0 +1,8 @@
+-- Coupons Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+
+COMMIT;
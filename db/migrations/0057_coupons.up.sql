@@ -0,0 +1,48 @@
+-- Coupons Migration
+-- Discount codes for plan payments. A coupon takes a percent or a fixed
+-- amount off the plan price and may be limited to some plans, to a number of
+-- redemptions overall and per user, and to a validity window. Codes are
+-- stored upper-case so they match case-insensitively.
+-- coupon_redemptions links each discounted payment to its coupon. A
+-- redemption is pending while its payment is, and released when the payment
+-- fails or is cancelled; redemptions_count counts pending and redeemed ones.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL CONSTRAINT coupons_code_key UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type TEXT NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value BIGINT NOT NULL CHECK (discount_value > 0),
+    max_redemptions INTEGER CHECK (max_redemptions IS NULL OR max_redemptions > 0),
+    max_redemptions_per_user INTEGER NOT NULL DEFAULT 1 CHECK (max_redemptions_per_user > 0),
+    redemptions_count INTEGER NOT NULL DEFAULT 0 CHECK (redemptions_count >= 0),
+    plan_ids UUID[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT coupons_percent_check CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    CONSTRAINT coupons_window_check CHECK (starts_at IS NULL OR expires_at IS NULL OR expires_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupons_created_at ON coupons(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    discount_amount BIGINT NOT NULL CHECK (discount_amount >= 0),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'redeemed', 'released')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT coupon_redemptions_payment_key UNIQUE (payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Login Devices Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_login_devices;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS device_fingerprint;
+
+COMMIT;
@@ -0,0 +1,23 @@
+-- Login Devices Migration
+-- Sessions remember the device fingerprint and country they signed in from.
+-- user_login_devices keeps every device and country pair a user signed in
+-- with, independent of session cleanup, so a login from a device or country
+-- that is not in the list can raise a security alert. country is '' when
+-- the address could not be resolved.
+
+BEGIN;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS device_fingerprint TEXT,
+    ADD COLUMN IF NOT EXISTS country CHAR(2);
+
+CREATE TABLE IF NOT EXISTS user_login_devices (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, fingerprint, country)
+);
+
+COMMIT;
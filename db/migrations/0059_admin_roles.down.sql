@@ -0,0 +1,8 @@
+-- Admin Roles Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS admin_role_assignments;
+DROP TABLE IF EXISTS admin_roles;
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Admin Roles Migration
+-- Admin access is granted through roles: each role holds a set of
+-- permissions such as users:read or payments:refund, and admins are assigned
+-- one or more roles. The built-in support, finance and super_admin roles are
+-- seeded here and cannot be edited; super_admin holds the '*' wildcard so it
+-- keeps every permission added later. Existing admins become super admins so
+-- nobody loses access when the checks are turned on.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS admin_roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS admin_role_assignments (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES admin_roles(name) ON DELETE CASCADE,
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_role_assignments_role ON admin_role_assignments(role);
+
+INSERT INTO admin_roles (name, description, permissions, built_in) VALUES
+    ('super_admin', 'Full access to every admin feature', ARRAY['*'], TRUE),
+    ('support', 'Customer support: users, vendors, conversions and moderation',
+        ARRAY['users:read', 'users:write', 'vendors:read', 'conversions:read', 'conversions:write',
+              'images:read', 'moderation:review', 'payments:read', 'stats:read'], TRUE),
+    ('finance', 'Payments, refunds, coupons and plans',
+        ARRAY['users:read', 'vendors:read', 'plans:read', 'plans:write', 'payments:read',
+              'payments:write', 'payments:refund', 'audit:read', 'stats:read'], TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO admin_role_assignments (user_id, role)
+SELECT id, 'super_admin' FROM users WHERE role = 'admin'
+ON CONFLICT DO NOTHING;
+
+COMMIT;
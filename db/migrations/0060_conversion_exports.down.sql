@@ -0,0 +1,7 @@
+-- Conversion Exports Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS conversion_exports;
+
+COMMIT;
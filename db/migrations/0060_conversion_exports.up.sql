@@ -0,0 +1,28 @@
+-- Conversion Exports Migration
+-- Users can download their conversion results as a ZIP archive. Requests are
+-- queued as pending rows and built in the background; a ready export points
+-- at the archive in object storage until expires_at. A processing row whose
+-- started_at is old belongs to a crashed instance and is claimed again.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    object_key TEXT,
+    conversion_count INTEGER NOT NULL DEFAULT 0,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversion_exports_user ON conversion_exports(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversion_exports_queue ON conversion_exports(created_at)
+    WHERE status IN ('pending', 'processing');
+
+COMMIT;
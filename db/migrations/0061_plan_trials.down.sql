@@ -0,0 +1,11 @@
+-- Plan Trials Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS plan_trials;
+DROP INDEX IF EXISTS idx_user_plans_trial_expiry;
+ALTER TABLE user_plans DROP COLUMN IF EXISTS is_trial;
+ALTER TABLE payment_plans DROP CONSTRAINT IF EXISTS payment_plans_trial_days_check;
+ALTER TABLE payment_plans DROP COLUMN IF EXISTS trial_days;
+
+COMMIT;
@@ -0,0 +1,32 @@
+-- Plan Trials Migration
+-- Plans with trial_days > 0 can be tried for free. A trial is a user_plans row
+-- flagged is_trial that expires after the trial period; the expiry worker moves
+-- expired trials to the default plan. plan_trials records one trial per phone
+-- number, so deleting and recreating an account does not grant another trial.
+
+BEGIN;
+
+ALTER TABLE payment_plans ADD COLUMN IF NOT EXISTS trial_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_plans DROP CONSTRAINT IF EXISTS payment_plans_trial_days_check;
+ALTER TABLE payment_plans ADD CONSTRAINT payment_plans_trial_days_check CHECK (trial_days >= 0);
+
+ALTER TABLE user_plans ADD COLUMN IF NOT EXISTS is_trial BOOLEAN NOT NULL DEFAULT false;
+
+-- Active trials are scanned by the expiry worker
+CREATE INDEX IF NOT EXISTS idx_user_plans_trial_expiry ON user_plans(expires_at)
+    WHERE is_trial AND status = 'active';
+
+CREATE TABLE IF NOT EXISTS plan_trials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    phone TEXT NOT NULL UNIQUE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    plan_name TEXT NOT NULL,
+    user_plan_id UUID REFERENCES user_plans(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    expired_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_trials_user_id ON plan_trials(user_id);
+
+COMMIT;
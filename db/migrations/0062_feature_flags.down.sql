@@ -0,0 +1,7 @@
+-- Feature Flags Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS feature_flags;
+
+COMMIT;
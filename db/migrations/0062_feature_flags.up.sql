@@ -0,0 +1,27 @@
+-- Feature Flags Migration
+-- Flags gate features per user. A flag that is enabled applies to the users
+-- and plans it targets and to rollout_percent of everyone else, picked by a
+-- stable hash of the flag key and user ID. Admins manage flags through
+-- /api/admin/feature-flags.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(64) PRIMARY KEY CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    user_ids UUID[] NOT NULL DEFAULT '{}',
+    plans TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Serves conversions with GEMINI_CANDIDATE_MODEL instead of GEMINI_MODEL
+INSERT INTO feature_flags (key, description)
+VALUES ('new_provider', 'Convert images with the candidate AI model')
+ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
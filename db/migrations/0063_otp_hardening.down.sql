@@ -0,0 +1,11 @@
+-- OTP Hardening Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS otp_events;
+DROP TABLE IF EXISTS otp_guards;
+
+DROP INDEX IF EXISTS idx_otps_phone_verified_at;
+ALTER TABLE otps DROP COLUMN IF EXISTS verified_at;
+
+COMMIT;
@@ -0,0 +1,41 @@
+-- OTP Hardening Migration
+-- otps.verified_at marks codes that were verified. consumed_at is also set
+-- on codes replaced by a newer one or burned after too many wrong guesses, so
+-- it no longer proves the phone was verified. Successful verifications were
+-- the only updates that counted attempts, which backfills verified_at.
+-- otp_guards keeps the resend cooldown and failed verifications per phone,
+-- and otp_events records sends, failures and lockouts for the security team.
+
+BEGIN;
+
+ALTER TABLE otps ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+
+UPDATE otps SET verified_at = consumed_at
+WHERE consumed_at IS NOT NULL AND attempt_count > 0 AND verified_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_otps_phone_verified_at ON otps(phone, verified_at) WHERE verified_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS otp_guards (
+    phone TEXT PRIMARY KEY,
+    sends INTEGER NOT NULL DEFAULT 0,
+    last_sent_at TIMESTAMPTZ,
+    next_send_at TIMESTAMPTZ,
+    failures INTEGER NOT NULL DEFAULT 0,
+    first_failure_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS otp_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    phone TEXT NOT NULL,
+    event VARCHAR(30) NOT NULL CHECK (event IN ('sent', 'resend_blocked', 'verified', 'verify_failed', 'locked', 'locked_attempt')),
+    ip TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_events_phone_created_at ON otp_events(phone, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_otp_events_event_created_at ON otp_events(event, created_at DESC);
+
+COMMIT;
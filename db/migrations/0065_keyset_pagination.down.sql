@@ -0,0 +1,15 @@
+-- Keyset Pagination Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_users_keyset;
+DROP INDEX IF EXISTS idx_vendors_keyset;
+DROP INDEX IF EXISTS idx_payments_keyset;
+DROP INDEX IF EXISTS idx_user_conversions_keyset;
+DROP INDEX IF EXISTS idx_conversions_user_keyset;
+DROP INDEX IF EXISTS idx_images_keyset;
+DROP INDEX IF EXISTS idx_images_user_keyset;
+DROP INDEX IF EXISTS idx_audit_logs_keyset;
+DROP INDEX IF EXISTS idx_image_moderation_verdicts_keyset;
+
+COMMIT;
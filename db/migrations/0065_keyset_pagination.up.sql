@@ -0,0 +1,18 @@
+-- Keyset Pagination Migration
+-- List endpoints accept a cursor and continue after the (created_at, id) of
+-- the last row of the previous page instead of using OFFSET. These indexes
+-- serve that order so later pages cost the same as the first.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_users_keyset ON users(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_vendors_keyset ON vendors(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_keyset ON payments(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_user_conversions_keyset ON user_conversions(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_conversions_user_keyset ON conversions(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_images_keyset ON images(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_images_user_keyset ON images(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_keyset ON audit_logs(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_image_moderation_verdicts_keyset ON image_moderation_verdicts(created_at DESC, id DESC);
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Admin Activity Feed Migration (rollback)
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_conversions_admin_activity ON conversions;
+DROP TRIGGER IF EXISTS trg_audit_logs_admin_activity ON audit_logs;
+DROP TRIGGER IF EXISTS trg_vendors_admin_activity ON vendors;
+DROP TRIGGER IF EXISTS trg_payments_admin_activity ON payments;
+DROP FUNCTION IF EXISTS notify_admin_activity();
+
+COMMIT;
@@ -0,0 +1,61 @@
+-- Admin Activity Feed Migration
+-- Completed payments, new vendors, audit log entries and failed conversions
+-- are announced on the admin_activity channel with pg_notify. Every instance
+-- listens and streams them to the admins connected to its activity feed.
+-- Payloads carry a few identifying columns only, well below the 8000 byte
+-- notification limit.
+
+BEGIN;
+
+CREATE OR REPLACE FUNCTION notify_admin_activity()
+RETURNS TRIGGER AS $$
+DECLARE
+    data JSONB;
+BEGIN
+    CASE TG_ARGV[0]
+    WHEN 'payment_completed' THEN
+        data := jsonb_build_object('id', NEW.id, 'userId', NEW.user_id, 'planId', NEW.plan_id,
+            'amount', NEW.amount, 'currency', NEW.currency);
+    WHEN 'vendor_registered' THEN
+        data := jsonb_build_object('id', NEW.id, 'userId', NEW.user_id,
+            'displayName', left(NEW.display_name, 200), 'companyName', left(NEW.company_name, 200));
+    WHEN 'audit_entry' THEN
+        data := jsonb_build_object('id', NEW.id, 'userId', NEW.user_id, 'actorType', NEW.actor_type,
+            'action', left(NEW.action, 200), 'resource', left(NEW.resource, 200), 'resourceId', left(NEW.resource_id, 200));
+    WHEN 'conversion_failed' THEN
+        data := jsonb_build_object('id', NEW.id, 'userId', NEW.user_id, 'vendorId', NEW.vendor_id,
+            'errorCode', NEW.error_code, 'failureKind', NEW.failure_kind);
+    END CASE;
+
+    PERFORM pg_notify('admin_activity', jsonb_build_object('kind', TG_ARGV[0], 'data', data)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_payments_admin_activity ON payments;
+CREATE TRIGGER trg_payments_admin_activity
+AFTER UPDATE OF status ON payments
+FOR EACH ROW
+WHEN (NEW.status = 'completed' AND OLD.status IS DISTINCT FROM 'completed')
+EXECUTE FUNCTION notify_admin_activity('payment_completed');
+
+DROP TRIGGER IF EXISTS trg_vendors_admin_activity ON vendors;
+CREATE TRIGGER trg_vendors_admin_activity
+AFTER INSERT ON vendors
+FOR EACH ROW
+EXECUTE FUNCTION notify_admin_activity('vendor_registered');
+
+DROP TRIGGER IF EXISTS trg_audit_logs_admin_activity ON audit_logs;
+CREATE TRIGGER trg_audit_logs_admin_activity
+AFTER INSERT ON audit_logs
+FOR EACH ROW
+EXECUTE FUNCTION notify_admin_activity('audit_entry');
+
+DROP TRIGGER IF EXISTS trg_conversions_admin_activity ON conversions;
+CREATE TRIGGER trg_conversions_admin_activity
+AFTER UPDATE OF status ON conversions
+FOR EACH ROW
+WHEN (NEW.status = 'failed' AND OLD.status IS DISTINCT FROM 'failed')
+EXECUTE FUNCTION notify_admin_activity('conversion_failed');
+
+COMMIT;
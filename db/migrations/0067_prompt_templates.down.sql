@@ -0,0 +1,9 @@
+-- Prompt Templates Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS prompt_template_runs;
+DROP TABLE IF EXISTS prompt_template_versions;
+DROP TABLE IF EXISTS prompt_templates;
+
+COMMIT;
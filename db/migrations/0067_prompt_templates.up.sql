@@ -0,0 +1,65 @@
+-- Prompt Templates Migration
+-- The prompt conversions send to the AI provider is kept as versioned
+-- templates. Versions are immutable; a template serves its active version, or
+-- splits conversions between versions by weight while an experiment runs.
+-- prompt_template_runs records the version each conversion was made with, so
+-- success and safety-block rates can be compared per version. Admins manage
+-- templates through /api/admin/prompt-templates.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    key VARCHAR(64) PRIMARY KEY CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    active_version INTEGER NOT NULL DEFAULT 1,
+    experiment_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS prompt_template_versions (
+    template_key VARCHAR(64) NOT NULL REFERENCES prompt_templates(key) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version > 0),
+    body TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    weight INTEGER NOT NULL DEFAULT 0 CHECK (weight BETWEEN 0 AND 100),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (template_key, version)
+);
+
+CREATE TABLE IF NOT EXISTS prompt_template_runs (
+    conversion_id UUID PRIMARY KEY REFERENCES conversions(id) ON DELETE CASCADE,
+    template_key VARCHAR(64) NOT NULL,
+    version INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_template_runs_version
+    ON prompt_template_runs(template_key, version, created_at);
+
+-- Version 1 is the prompt conversions were made with before templates
+-- (prompt.DefaultConversionTemplate)
+INSERT INTO prompt_templates (key, description)
+VALUES ('conversion', 'Virtual try-on prompt sent with the user and garment images')
+ON CONFLICT (key) DO NOTHING;
+
+INSERT INTO prompt_template_versions (template_key, version, body, notes)
+VALUES ('conversion', 1, $prompt$You are an automated garment fitting service for an e-commerce platform. This is a technical product visualization service for displaying clothing items on mannequins or fashion models in product catalogs.
+
+Technical task: Apply the garment from image 2 onto the person/mannequin in image 1. This is a standard commercial product visualization workflow.
+
+Technical specifications:
+- Image 1 contains a professional fashion model or mannequin used for product photography
+- Image 2 contains a retail clothing item (garment) to be visualized
+- This is a legitimate commercial product visualization service
+- Maintain technical accuracy: body proportions, garment fit, fabric texture
+- Preserve lighting conditions and background from the original scene
+- This is automated product photography, not personal content
+
+Output requirement: Return ONLY the base64-encoded PNG image data as a raw string. No text, no markdown, no explanations, no headers. Only the base64 string.{{outfitInstruction}}{{styleInstruction}}{{qualityInstruction}}$prompt$, 'Initial prompt')
+ON CONFLICT (template_key, version) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Support Tickets Migration (rollback)
+
+BEGIN;
+
+UPDATE admin_roles
+SET permissions = array_remove(array_remove(permissions, 'support:read'), 'support:write'), updated_at = NOW()
+WHERE name = 'support';
+
+DROP TABLE IF EXISTS support_ticket_messages;
+DROP TABLE IF EXISTS support_tickets;
+
+COMMIT;
@@ -0,0 +1,39 @@
+-- Support Tickets Migration
+-- Users open support tickets, optionally about one of their conversions, and
+-- exchange messages with support on them; a message may attach an uploaded
+-- image. Tickets are open while they wait for support, answered once support
+-- replied and closed when done. The built-in support role gets the new
+-- support:read and support:write permissions for /api/admin/support/tickets.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS support_tickets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'answered', 'closed')),
+    conversion_id UUID REFERENCES conversions(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_support_tickets_user ON support_tickets(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_support_tickets_status ON support_tickets(status, created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS support_ticket_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket_id UUID NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    author_type TEXT NOT NULL CHECK (author_type IN ('user', 'admin')),
+    author_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    attachment_image_id UUID REFERENCES images(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_support_ticket_messages_ticket ON support_ticket_messages(ticket_id, created_at);
+
+UPDATE admin_roles
+SET permissions = permissions || ARRAY['support:read', 'support:write'], updated_at = NOW()
+WHERE name = 'support' AND NOT permissions @> ARRAY['support:read'];
+
+COMMIT;
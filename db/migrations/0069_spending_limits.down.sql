@@ -0,0 +1,8 @@
+-- Spending Limits Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_payments_user_created;
+DROP TABLE IF EXISTS user_spending_limits;
+
+COMMIT;
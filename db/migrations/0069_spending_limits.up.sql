@@ -0,0 +1,23 @@
+-- Spending Limits Migration
+-- Users may cap what they spend on plans each calendar month, and admins may
+-- enforce a cap of their own on an account, for example while fraud is
+-- investigated. The lower cap applies; payments that would pass it are
+-- declined before they are created.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_spending_limits (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    monthly_limit BIGINT CHECK (monthly_limit > 0),
+    enforced_limit BIGINT CHECK (enforced_limit >= 0),
+    enforced_reason TEXT,
+    enforced_by UUID,
+    enforced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Monthly spending is summed per user over recent payments
+CREATE INDEX IF NOT EXISTS idx_payments_user_created ON payments(user_id, created_at);
+
+COMMIT;
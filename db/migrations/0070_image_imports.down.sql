@@ -0,0 +1,7 @@
+-- Image Imports Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS image_imports;
+
+COMMIT;
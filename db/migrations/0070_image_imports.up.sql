@@ -0,0 +1,35 @@
+-- Image Imports Migration
+-- Bulk imports of vendor images started with POST /api/vendors/me/images/import
+-- from a ZIP archive or a list of public URLs. A background worker claims
+-- pending imports, stores each file like a vendor upload and records the
+-- per-file results; archives wait in storage under imports/ until then.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS image_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id UUID NOT NULL REFERENCES vendors(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(10) NOT NULL CHECK (source IN ('zip', 'urls')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    urls TEXT[] NOT NULL DEFAULT '{}',
+    archive_path TEXT,
+    is_public BOOLEAN NOT NULL DEFAULT false,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    auto_tag BOOLEAN NOT NULL DEFAULT false,
+    total INTEGER NOT NULL DEFAULT 0,
+    imported INTEGER NOT NULL DEFAULT 0,
+    duplicates INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]',
+    error_message TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_imports_vendor_created ON image_imports(vendor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_image_imports_queue ON image_imports(created_at) WHERE status IN ('pending', 'processing');
+
+COMMIT;
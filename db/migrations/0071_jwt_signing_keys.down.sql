@@ -0,0 +1,7 @@
+-- JWT Signing Keys Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS jwt_signing_keys;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- JWT Signing Keys Migration
+-- Rotating keys access and refresh tokens are signed with. Tokens name their
+-- key in the kid header; the public halves of RS256 and EdDSA keys are served
+-- at /.well-known/jwks.json. Private keys are encrypted with a key derived
+-- from JWT_SECRET. Keys are kept until no token they signed can still be valid.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id UUID PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL CHECK (algorithm IN ('HS256', 'RS256', 'EdDSA')),
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_expires_at ON jwt_signing_keys(expires_at);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- User Data Exports Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_data_exports;
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- User Data Exports Migration
+-- Users can download a copy of their account data (profile, conversion
+-- history, payments, notification preferences) with GET /api/users/me/export.
+-- Requests are queued as pending rows and built in the background like
+-- conversion exports; a ready export points at the ZIP under takeout/ in
+-- object storage until expires_at.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    object_key TEXT,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_data_exports_user ON user_data_exports(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_data_exports_queue ON user_data_exports(created_at)
+    WHERE status IN ('pending', 'processing');
+
+COMMIT;
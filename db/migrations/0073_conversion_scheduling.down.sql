@@ -0,0 +1,57 @@
+-- Conversion Scheduling Migration (rollback)
+
+BEGIN;
+
+DROP FUNCTION IF EXISTS get_conversion_with_details(UUID);
+
+CREATE FUNCTION get_conversion_with_details(p_conversion_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    user_image_id UUID,
+    cloth_image_id UUID,
+    status TEXT,
+    result_image_id UUID,
+    error_message TEXT,
+    processing_time_ms INTEGER,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    user_image_url TEXT,
+    cloth_image_url TEXT,
+    result_image_url TEXT,
+    progress SMALLINT,
+    error_code TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        c.id,
+        c.user_id,
+        c.user_image_id,
+        c.cloth_image_id,
+        c.status,
+        c.result_image_id,
+        c.error_message,
+        c.processing_time_ms,
+        c.created_at,
+        c.updated_at,
+        c.completed_at,
+        ui.original_url as user_image_url,
+        ci.original_url as cloth_image_url,
+        ri.original_url as result_image_url,
+        c.progress,
+        c.error_code
+    FROM conversions c
+    LEFT JOIN images ui ON c.user_image_id = ui.id
+    LEFT JOIN images ci ON c.cloth_image_id = ci.id
+    LEFT JOIN images ri ON c.result_image_id = ri.id
+    WHERE c.id = p_conversion_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_conversions_schedule_due;
+ALTER TABLE conversions DROP COLUMN IF EXISTS schedule_released_at;
+ALTER TABLE conversions DROP COLUMN IF EXISTS scheduled_at;
+
+COMMIT;
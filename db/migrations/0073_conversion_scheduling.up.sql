@@ -0,0 +1,66 @@
+-- Conversion Scheduling Migration
+-- Conversions may be created with a scheduled_at, e.g. to run batches
+-- off-peak. They stay pending without a worker job until the scheduler
+-- claims them once due, setting schedule_released_at, and enqueues them.
+-- Cancelling a scheduled conversion before then means it never runs.
+
+BEGIN;
+
+ALTER TABLE conversions ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMPTZ;
+ALTER TABLE conversions ADD COLUMN IF NOT EXISTS schedule_released_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_conversions_schedule_due ON conversions(scheduled_at)
+    WHERE status = 'pending' AND scheduled_at IS NOT NULL AND schedule_released_at IS NULL;
+
+-- The result columns change, so the function is recreated rather than replaced
+DROP FUNCTION IF EXISTS get_conversion_with_details(UUID);
+
+CREATE FUNCTION get_conversion_with_details(p_conversion_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    user_image_id UUID,
+    cloth_image_id UUID,
+    status TEXT,
+    result_image_id UUID,
+    error_message TEXT,
+    processing_time_ms INTEGER,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    user_image_url TEXT,
+    cloth_image_url TEXT,
+    result_image_url TEXT,
+    progress SMALLINT,
+    error_code TEXT,
+    scheduled_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        c.id,
+        c.user_id,
+        c.user_image_id,
+        c.cloth_image_id,
+        c.status,
+        c.result_image_id,
+        c.error_message,
+        c.processing_time_ms,
+        c.created_at,
+        c.updated_at,
+        c.completed_at,
+        ui.original_url as user_image_url,
+        ci.original_url as cloth_image_url,
+        ri.original_url as result_image_url,
+        c.progress,
+        c.error_code,
+        c.scheduled_at
+    FROM conversions c
+    LEFT JOIN images ui ON c.user_image_id = ui.id
+    LEFT JOIN images ci ON c.cloth_image_id = ci.id
+    LEFT JOIN images ri ON c.result_image_id = ri.id
+    WHERE c.id = p_conversion_id;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Signup Reputation Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_user_login_devices_fingerprint;
+DROP TABLE IF EXISTS signup_reputation;
+
+COMMIT;
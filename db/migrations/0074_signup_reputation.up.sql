@@ -0,0 +1,41 @@
+-- Signup Reputation Migration
+-- Every new user account is scored for free-quota abuse from the phone
+-- number range, device, network (ASN) and abuse of accounts on the same
+-- device. Risky accounts get a limited or no free quota and wait in
+-- the admin review queue as pending; full_credits is the free quota
+-- restored when they are approved or released.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS signup_reputation (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    phone_prefix TEXT NOT NULL DEFAULT '',
+    device_fingerprint TEXT,
+    ip TEXT,
+    asn TEXT,
+    score INTEGER NOT NULL DEFAULT 0,
+    level TEXT NOT NULL CHECK (level IN ('low', 'medium', 'high')),
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    signals JSONB NOT NULL DEFAULT '{}',
+    full_credits INTEGER NOT NULL DEFAULT 0,
+    allowed_credits INTEGER,
+    review_status TEXT NOT NULL DEFAULT 'none'
+        CHECK (review_status IN ('none', 'pending', 'approved', 'rejected', 'released')),
+    release_at TIMESTAMPTZ,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_signup_reputation_phone_prefix ON signup_reputation(phone_prefix, created_at);
+CREATE INDEX IF NOT EXISTS idx_signup_reputation_device ON signup_reputation(device_fingerprint) WHERE device_fingerprint IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_signup_reputation_asn ON signup_reputation(asn, created_at) WHERE asn IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_signup_reputation_review ON signup_reputation(review_status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_signup_reputation_release ON signup_reputation(release_at) WHERE review_status = 'pending';
+
+-- Devices that signed in to other accounts count as shared too
+CREATE INDEX IF NOT EXISTS idx_user_login_devices_fingerprint ON user_login_devices(fingerprint);
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Vendor Verification Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS vendor_verification_documents;
+DROP TABLE IF EXISTS vendor_verifications;
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Vendor Verification Migration
+-- Vendors apply for verification by uploading business documents. A
+-- verification is pending while the vendor uploads, in_review once an admin
+-- picks it up, and ends approved or rejected with a reason; the decision
+-- sets vendors.is_verified. A vendor has at most one open verification and
+-- may apply again after a rejection.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vendor_verifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id UUID NOT NULL REFERENCES vendors(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'in_review', 'approved', 'rejected')),
+    rejection_reason TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_started_at TIMESTAMPTZ,
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT vendor_verifications_rejection_reason CHECK (status <> 'rejected' OR rejection_reason IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vendor_verifications_open ON vendor_verifications(vendor_id)
+    WHERE status IN ('pending', 'in_review');
+CREATE INDEX IF NOT EXISTS idx_vendor_verifications_vendor ON vendor_verifications(vendor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vendor_verifications_queue ON vendor_verifications(status, created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS vendor_verification_documents (
+    id UUID PRIMARY KEY,
+    verification_id UUID NOT NULL REFERENCES vendor_verifications(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('business_license', 'national_id', 'tax_certificate', 'other')),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendor_verification_documents_verification
+    ON vendor_verification_documents(verification_id, created_at);
+
+COMMIT;
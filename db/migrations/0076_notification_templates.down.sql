@@ -0,0 +1,24 @@
+-- Notification Templates Migration (rollback)
+
+BEGIN;
+
+DELETE FROM notification_templates
+WHERE locale <> 'en'
+   OR type NOT IN (SELECT unnest(enum_range(NULL::notification_type))::TEXT);
+
+ALTER TABLE notification_templates
+    DROP CONSTRAINT IF EXISTS notification_templates_type_channel_locale_key,
+    DROP CONSTRAINT IF EXISTS notification_templates_channel_check,
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS locale;
+
+UPDATE notification_templates SET subject = LEFT(subject, 255);
+
+ALTER TABLE notification_templates
+    ALTER COLUMN subject DROP DEFAULT,
+    ALTER COLUMN subject TYPE VARCHAR(255),
+    ALTER COLUMN type TYPE notification_type USING type::notification_type,
+    ALTER COLUMN channel TYPE notification_channel USING channel::notification_channel,
+    ADD CONSTRAINT notification_templates_type_channel_key UNIQUE (type, channel);
+
+COMMIT;
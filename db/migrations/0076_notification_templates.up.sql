@@ -0,0 +1,32 @@
+-- Notification Templates Migration
+-- Templates are now managed by admins per type, channel and language.
+-- Bodies are Go templates rendered with the notification as .notification;
+-- types without an active template keep using the built-in templates.
+
+BEGIN;
+
+-- Types grow with every feature; the enums only held the original ones
+ALTER TABLE notification_templates
+    ALTER COLUMN type TYPE TEXT USING type::TEXT,
+    ALTER COLUMN channel TYPE TEXT USING channel::TEXT,
+    ALTER COLUMN subject TYPE TEXT,
+    ALTER COLUMN subject SET DEFAULT '';
+
+-- Only email, SMS and Telegram messages are rendered from templates
+DELETE FROM notification_templates WHERE channel NOT IN ('email', 'sms', 'telegram');
+
+ALTER TABLE notification_templates
+    ADD COLUMN locale TEXT NOT NULL DEFAULT 'en',
+    ADD COLUMN updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT notification_templates_channel_check CHECK (channel IN ('email', 'sms', 'telegram')),
+    DROP CONSTRAINT IF EXISTS notification_templates_type_channel_key,
+    ADD CONSTRAINT notification_templates_type_channel_locale_key UNIQUE (type, channel, locale);
+
+ALTER TABLE notification_templates ALTER COLUMN locale DROP DEFAULT;
+
+-- The seeded templates were never rendered and use variables
+-- ({{.conversionId}}) the sender does not pass; keep them for admins to
+-- rewrite but don't let them replace the built-in texts.
+UPDATE notification_templates SET is_active = false;
+
+COMMIT;
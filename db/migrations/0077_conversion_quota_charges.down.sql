@@ -0,0 +1,7 @@
+-- Conversion Quota Charges Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS conversion_quota_charges;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Conversion Quota Charges Migration
+-- Records which allowance each conversion was charged to, so conversions that
+-- fail because of the provider can be refunded, at most once.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS conversion_quota_charges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversion_id UUID NOT NULL REFERENCES conversions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('plan', 'free')),
+    charged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    refunded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversion_quota_charges_conversion
+    ON conversion_quota_charges(conversion_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Gemini Settings Migration (rollback)
+
+BEGIN;
+
+DELETE FROM system_settings
+WHERE key IN ('gemini_model', 'gemini_temperature', 'gemini_max_output_tokens', 'gemini_noise_level', 'gemini_timeout');
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Gemini Settings Migration
+-- Provider parameters admins tune at runtime. An empty model or noise level
+-- and a zero timeout keep the GEMINI_* configuration; temperature and output
+-- tokens start at the values conversions used so far.
+
+BEGIN;
+
+INSERT INTO system_settings (key, value, type) VALUES
+    ('gemini_model', '', 'string'),
+    ('gemini_temperature', '0.4', 'string'),
+    ('gemini_max_output_tokens', '32768', 'integer'),
+    ('gemini_noise_level', '', 'string'),
+    ('gemini_timeout', '0', 'integer')
+ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
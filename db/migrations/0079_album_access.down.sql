@@ -0,0 +1,8 @@
+-- Album Access Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS album_shares;
+DROP TABLE IF EXISTS album_invites;
+
+COMMIT;
@@ -0,0 +1,34 @@
+-- Album Access Migration
+-- Vendors share private albums with user accounts, directly or through invite
+-- links. A share lets the user list the album's images and get signed URLs
+-- for them. Invite tokens are stored as SHA-256 hashes.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS album_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    album_id UUID NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    max_uses INTEGER CHECK (max_uses IS NULL OR max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_invites_album_id ON album_invites(album_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS album_shares (
+    album_id UUID NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_id UUID REFERENCES album_invites(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (album_id, user_id)
+);
+
+-- Image access checks look shares up by viewer
+CREATE INDEX IF NOT EXISTS idx_album_shares_user_id ON album_shares(user_id);
+
+COMMIT;
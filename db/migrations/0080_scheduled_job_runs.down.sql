@@ -0,0 +1,7 @@
+-- Scheduled Job Runs Migration (rollback)
+
+BEGIN;
+
+DROP TABLE IF EXISTS scheduled_job_runs;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Scheduled Job Runs Migration
+-- Run history of the jobs the worker scheduler runs on cron schedules. One
+-- replica runs each scheduled slot; its run is recorded as running and
+-- finished with the outcome. The latest 100 runs of each job are kept.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL,
+    instance_id TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_job_started
+    ON scheduled_job_runs(job_name, started_at DESC);
+
+COMMIT;
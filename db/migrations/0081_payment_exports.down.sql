@@ -0,0 +1,8 @@
+-- Payment Exports Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_payments_paid_or_created;
+DROP TABLE IF EXISTS payment_exports;
+
+COMMIT;
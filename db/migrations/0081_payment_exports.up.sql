@@ -0,0 +1,35 @@
+-- Payment Exports Migration
+-- Admins export payments for accounting reconciliation with
+-- GET /api/admin/payments/export. Ranges too long to stream are queued here
+-- and built in the background; a ready export points at its CSV under
+-- payment-exports/ in object storage until expires_at.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS payment_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'accounting')),
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    gateway TEXT NOT NULL DEFAULT '',
+    payment_status TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    object_key TEXT,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    CHECK (to_date >= from_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_exports_queue ON payment_exports(created_at)
+    WHERE status IN ('pending', 'processing');
+
+-- Exports select payments by the time they were paid
+CREATE INDEX IF NOT EXISTS idx_payments_paid_or_created ON payments((COALESCE(paid_at, created_at)));
+
+COMMIT;
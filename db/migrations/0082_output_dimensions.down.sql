@@ -0,0 +1,7 @@
+-- Output Dimensions Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE payment_plans DROP COLUMN IF EXISTS max_output_edge;
+
+COMMIT;
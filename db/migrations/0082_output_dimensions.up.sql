@@ -0,0 +1,20 @@
+-- Output Dimensions Migration
+-- Conversions can request an output width, height and aspect ratio. The
+-- longest requested edge is limited by the user's plan; users without an
+-- active plan are limited to 1024 pixels.
+
+BEGIN;
+
+ALTER TABLE payment_plans ADD COLUMN IF NOT EXISTS max_output_edge INTEGER NOT NULL DEFAULT 1024;
+
+UPDATE payment_plans SET max_output_edge = CASE name
+    WHEN 'basic' THEN 2048
+    WHEN 'premium' THEN 4096
+    WHEN 'advanced' THEN 4096
+    WHEN 'enterprise' THEN 4096
+    ELSE 1024
+END;
+
+COMMENT ON COLUMN payment_plans.max_output_edge IS 'longest edge in pixels a conversion of this plan may request';
+
+COMMIT;
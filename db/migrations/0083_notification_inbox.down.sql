@@ -0,0 +1,12 @@
+-- Notification Inbox Migration (rollback)
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_notifications_user_created;
+
+DROP TRIGGER IF EXISTS trigger_notification_unread_count ON notifications;
+DROP FUNCTION IF EXISTS update_notification_unread_count();
+
+DROP TABLE IF EXISTS notification_unread_counts;
+
+COMMIT;
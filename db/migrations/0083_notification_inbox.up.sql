@@ -0,0 +1,67 @@
+-- Notification Inbox Migration
+-- Users page through their notifications with read flags. The unread count
+-- of each user is kept in notification_unread_counts by a trigger on
+-- notifications, so the badge shown on every page load reads one row instead
+-- of counting. Digests repeat notifications already in the inbox and are not
+-- counted.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS notification_unread_counts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    unread_count INTEGER NOT NULL DEFAULT 0 CHECK (unread_count >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION update_notification_unread_count()
+RETURNS TRIGGER AS $$
+DECLARE
+    old_unread INTEGER := 0;
+    new_unread INTEGER := 0;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.user_id IS NOT NULL AND OLD.read_at IS NULL AND OLD.type <> 'digest' THEN
+        old_unread := 1;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.user_id IS NOT NULL AND NEW.read_at IS NULL AND NEW.type <> 'digest' THEN
+        new_unread := 1;
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND OLD.user_id IS NOT DISTINCT FROM NEW.user_id THEN
+        new_unread := new_unread - old_unread;
+        old_unread := 0;
+    END IF;
+
+    IF old_unread <> 0 THEN
+        UPDATE notification_unread_counts
+        SET unread_count = GREATEST(unread_count - old_unread, 0), updated_at = NOW()
+        WHERE user_id = OLD.user_id;
+    END IF;
+    IF new_unread <> 0 THEN
+        INSERT INTO notification_unread_counts (user_id, unread_count)
+        VALUES (NEW.user_id, GREATEST(new_unread, 0))
+        ON CONFLICT (user_id) DO UPDATE
+        SET unread_count = GREATEST(notification_unread_counts.unread_count + new_unread, 0), updated_at = NOW();
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_notification_unread_count ON notifications;
+CREATE TRIGGER trigger_notification_unread_count
+    AFTER INSERT OR DELETE OR UPDATE OF user_id, read_at ON notifications
+    FOR EACH ROW
+    EXECUTE FUNCTION update_notification_unread_count();
+
+-- Count the notifications that are unread today
+INSERT INTO notification_unread_counts (user_id, unread_count)
+SELECT user_id, COUNT(*)
+FROM notifications
+WHERE user_id IS NOT NULL AND read_at IS NULL AND type <> 'digest'
+GROUP BY user_id
+ON CONFLICT (user_id) DO UPDATE SET unread_count = EXCLUDED.unread_count, updated_at = NOW();
+
+-- The inbox pages in created_at DESC, id DESC order per user
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC, id DESC);
+
+COMMIT;
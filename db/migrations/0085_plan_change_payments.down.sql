@@ -0,0 +1,9 @@
+-- Plan Change Payments Migration (rollback)
+
+BEGIN;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS plan_credit_cents;
+DROP INDEX IF EXISTS idx_plan_change_payments_user_id;
+DROP TABLE IF EXISTS plan_change_payments;
+
+COMMIT;
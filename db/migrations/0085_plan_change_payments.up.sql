@@ -0,0 +1,33 @@
+-- Plan Change Payments Migration
+-- An upgrade that leaves an amount due is paid through the gateway before it
+-- applies. plan_change_payments holds the change until its payment is verified;
+-- changes of abandoned or failed payments never apply. A downgrade credit stays
+-- in user_plans.proration_balance_cents and is taken off the next upgrade or
+-- plan purchase; payments.plan_credit_cents is the credit a purchase used.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS plan_change_payments (
+    payment_id UUID PRIMARY KEY REFERENCES payments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_user_plan_id UUID NOT NULL REFERENCES user_plans(id) ON DELETE CASCADE,
+    to_plan_name TEXT NOT NULL,
+    monthly_conversions_limit INTEGER NOT NULL,
+    price_per_month_cents BIGINT NOT NULL,
+    change_direction TEXT NOT NULL CHECK (change_direction IN ('upgrade', 'downgrade')),
+    proration_charge_cents BIGINT NOT NULL,
+    proration_credit_cents BIGINT NOT NULL,
+    proration_balance_cents BIGINT NOT NULL,
+    conversions_used INTEGER NOT NULL,
+    billing_cycle_start_date TIMESTAMPTZ NOT NULL,
+    billing_cycle_end_date TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_change_payments_user_id ON plan_change_payments(user_id);
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS plan_credit_cents BIGINT NOT NULL DEFAULT 0 CHECK (plan_credit_cents >= 0);
+
+COMMIT;
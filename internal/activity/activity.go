@@ -0,0 +1,184 @@
+// Package activity streams key events to the admin dashboard. Database
+// triggers announce new rows with pg_notify, so events written by any
+// package on any instance reach the admins connected to every instance.
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event kinds
+const (
+	KindPaymentCompleted   = "payment_completed"
+	KindVendorRegistered   = "vendor_registered"
+	KindAuditEntry         = "audit_entry"
+	KindConversionFailures = "conversion_failure_spike"
+
+	// kindConversionFailed is announced for every failed conversion and
+	// only published as KindConversionFailures when failures spike
+	kindConversionFailed = "conversion_failed"
+)
+
+// Feed defaults
+const (
+	DefaultSpikeThreshold   = 10
+	DefaultSpikeWindow      = 5 * time.Minute
+	DefaultSubscriberBuffer = 64
+)
+
+// Event is one entry of the activity feed
+type Event struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// Notification is a row announced by a database trigger
+type Notification struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Source delivers the notifications of the database triggers until ctx is done
+type Source interface {
+	Listen(ctx context.Context) (<-chan Notification, error)
+}
+
+// Config configures the feed
+type Config struct {
+	// SpikeThreshold failed conversions within SpikeWindow publish a
+	// conversion failure spike, at most once per window
+	SpikeThreshold int
+	SpikeWindow    time.Duration
+
+	// SubscriberBuffer events are queued per subscriber; a subscriber that
+	// falls further behind misses events rather than slowing the feed
+	SubscriberBuffer int
+}
+
+// Feed fans events out to the subscribed admin connections
+type Feed struct {
+	config Config
+
+	mutex       sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	// Failure spike detection, only touched by Run
+	failures  []time.Time
+	lastSpike time.Time
+	now       func() time.Time
+}
+
+// NewFeed creates an activity feed
+func NewFeed(config Config) *Feed {
+	if config.SpikeThreshold <= 0 {
+		config.SpikeThreshold = DefaultSpikeThreshold
+	}
+	if config.SpikeWindow <= 0 {
+		config.SpikeWindow = DefaultSpikeWindow
+	}
+	if config.SubscriberBuffer <= 0 {
+		config.SubscriberBuffer = DefaultSubscriberBuffer
+	}
+	return &Feed{
+		config:      config,
+		subscribers: make(map[chan Event]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Subscribe returns a channel receiving every event published from now on
+// and a function that ends the subscription and closes the channel
+func (f *Feed) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, f.config.SubscriberBuffer)
+	f.mutex.Lock()
+	f.subscribers[events] = struct{}{}
+	f.mutex.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			f.mutex.Lock()
+			delete(f.subscribers, events)
+			f.mutex.Unlock()
+			close(events)
+		})
+	}
+}
+
+// Publish sends event to every subscriber
+func (f *Feed) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = f.now()
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for events := range f.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Run publishes the notifications of source until ctx is cancelled,
+// listening again after a second when the source fails
+func (f *Feed) Run(ctx context.Context, source Source) {
+	for {
+		notifications, err := source.Listen(ctx)
+		if err != nil {
+			log.Printf("Failed to listen for admin activity: %v", err)
+		} else {
+			for notification := range notifications {
+				f.handle(notification)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// handle publishes a notification, counting failed conversions towards a spike
+func (f *Feed) handle(notification Notification) {
+	if notification.Kind != kindConversionFailed {
+		f.Publish(Event{Kind: notification.Kind, Data: notification.Data})
+		return
+	}
+
+	now := f.now()
+	cutoff := now.Add(-f.config.SpikeWindow)
+	kept := f.failures[:0]
+	for _, failedAt := range f.failures {
+		if failedAt.After(cutoff) {
+			kept = append(kept, failedAt)
+		}
+	}
+	f.failures = append(kept, now)
+
+	if len(f.failures) < f.config.SpikeThreshold || now.Sub(f.lastSpike) < f.config.SpikeWindow {
+		return
+	}
+	f.lastSpike = now
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"failures":      len(f.failures),
+		"windowSeconds": int(f.config.SpikeWindow.Seconds()),
+		"last":          notification.Data,
+	})
+	f.Publish(Event{Kind: KindConversionFailures, Data: data})
+}
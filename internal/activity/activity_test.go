@@ -0,0 +1,142 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	notifications []Notification
+}
+
+func (f *fakeSource) Listen(ctx context.Context) (<-chan Notification, error) {
+	notifications := make(chan Notification, len(f.notifications))
+	for _, notification := range f.notifications {
+		notifications <- notification
+	}
+	close(notifications)
+	return notifications, nil
+}
+
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event")
+		return Event{}
+	}
+}
+
+func TestFeed_PublishFansOut(t *testing.T) {
+	feed := NewFeed(Config{})
+	first, unsubscribeFirst := feed.Subscribe()
+	second, unsubscribeSecond := feed.Subscribe()
+	defer unsubscribeSecond()
+
+	feed.Publish(Event{Kind: KindVendorRegistered})
+	for _, events := range []<-chan Event{first, second} {
+		event := receive(t, events)
+		if event.Kind != KindVendorRegistered || event.ID == "" || event.CreatedAt.IsZero() {
+			t.Errorf("Expected a vendor event with an ID and time, got %+v", event)
+		}
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, ok := <-first; ok {
+		t.Error("Expected the channel closed after unsubscribing")
+	}
+	feed.Publish(Event{Kind: KindAuditEntry})
+	if event := receive(t, second); event.Kind != KindAuditEntry {
+		t.Errorf("Expected the remaining subscriber to get the audit entry, got %+v", event)
+	}
+}
+
+func TestFeed_SlowSubscriberMissesEvents(t *testing.T) {
+	feed := NewFeed(Config{SubscriberBuffer: 2})
+	events, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 5; i++ {
+		feed.Publish(Event{Kind: KindAuditEntry})
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 buffered events, got %d", len(events))
+	}
+}
+
+func TestFeed_ConversionFailureSpike(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	feed := NewFeed(Config{SpikeThreshold: 3, SpikeWindow: time.Minute})
+	feed.now = func() time.Time { return now }
+	events, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+
+	failed := Notification{Kind: kindConversionFailed, Data: json.RawMessage(`{"id":"c1"}`)}
+	feed.handle(failed)
+	now = now.Add(2 * time.Minute)
+	feed.handle(failed)
+	feed.handle(failed)
+	if len(events) != 0 {
+		t.Fatalf("Expected no spike for failures outside the window, got %d events", len(events))
+	}
+
+	feed.handle(failed)
+	event := receive(t, events)
+	if event.Kind != KindConversionFailures {
+		t.Fatalf("Expected a failure spike, got %+v", event)
+	}
+	var data struct {
+		Failures      int `json:"failures"`
+		WindowSeconds int `json:"windowSeconds"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil || data.Failures != 3 || data.WindowSeconds != 60 {
+		t.Errorf("Expected 3 failures in 60 seconds, got %s (%v)", event.Data, err)
+	}
+
+	feed.handle(failed)
+	if len(events) != 0 {
+		t.Error("Expected at most one spike per window")
+	}
+	now = now.Add(time.Minute)
+	feed.handle(failed)
+	feed.handle(failed)
+	feed.handle(failed)
+	if event := receive(t, events); event.Kind != KindConversionFailures {
+		t.Errorf("Expected another spike in the next window, got %+v", event)
+	}
+}
+
+func TestFeed_Run(t *testing.T) {
+	feed := NewFeed(Config{})
+	events, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		feed.Run(ctx, &fakeSource{notifications: []Notification{
+			{Kind: KindPaymentCompleted, Data: json.RawMessage(`{"amount":1000}`)},
+			{Kind: kindConversionFailed},
+		}})
+		close(done)
+	}()
+
+	event := receive(t, events)
+	if event.Kind != KindPaymentCompleted || string(event.Data) != `{"amount":1000}` {
+		t.Errorf("Expected the payment event, got %+v", event)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Run to return once cancelled")
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected a single failed conversion not to be published, got %d events", len(events))
+	}
+}
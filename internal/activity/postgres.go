@@ -0,0 +1,73 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NotifyChannel is the channel the admin activity triggers notify
+const NotifyChannel = "admin_activity"
+
+// PostgresSource listens for the notifications of the admin activity triggers
+type PostgresSource struct {
+	dsn string
+}
+
+// NewPostgresSource creates a source listening on its own connection to dsn
+func NewPostgresSource(dsn string) *PostgresSource {
+	return &PostgresSource{dsn: dsn}
+}
+
+// Listen delivers notifications until ctx is done. The listener reconnects
+// by itself; notifications sent while it is disconnected are lost.
+func (s *PostgresSource) Listen(ctx context.Context) (<-chan Notification, error) {
+	listener := pq.NewListener(s.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Admin activity listener: %v", err)
+		}
+	})
+	if err := listener.Listen(NotifyChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", NotifyChannel, err)
+	}
+
+	notifications := make(chan Notification)
+	go func() {
+		defer close(notifications)
+		defer listener.Close()
+
+		ping := time.NewTicker(time.Minute)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				// Detects connections dropped without an error
+				go listener.Ping()
+			case n := <-listener.Notify:
+				// nil after a reconnect
+				if n == nil {
+					continue
+				}
+				var notification Notification
+				if err := json.Unmarshal([]byte(n.Extra), &notification); err != nil {
+					log.Printf("Malformed admin activity notification: %v", err)
+					continue
+				}
+				select {
+				case notifications <- notification:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return notifications, nil
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ai-styler/internal/activity"
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Activity feed connection settings
+const (
+	// ActivityTokenProtocol is the WebSocket subprotocol browsers offer with
+	// the access token, as new WebSocket(url, ["bearer", token])
+	ActivityTokenProtocol = "bearer"
+
+	activityHeartbeatInterval = 30 * time.Second
+	activityWriteTimeout      = 10 * time.Second
+)
+
+// activityPermissions is the permission an admin needs for each event kind
+var activityPermissions = map[string]Permission{
+	activity.KindPaymentCompleted:   PermPaymentsRead,
+	activity.KindVendorRegistered:   PermVendorsRead,
+	activity.KindAuditEntry:         PermAuditRead,
+	activity.KindConversionFailures: PermConversionsRead,
+}
+
+// SetActivityFeed enables the live activity feed
+func (s *Service) SetActivityFeed(feed *activity.Feed) {
+	s.activity = feed
+}
+
+// SubscribeActivity subscribes an admin to the activity feed events their
+// permissions cover. The returned function ends the subscription.
+func (s *Service) SubscribeActivity(ctx context.Context, adminID string) (<-chan activity.Event, func(), error) {
+	if s.activity == nil {
+		return nil, nil, common.NewAPIError(http.StatusServiceUnavailable, common.ErrCodeServiceUnavailable, "activity feed is not enabled", nil)
+	}
+
+	access, err := s.GetAdminAccess(ctx, adminID)
+	if err != nil {
+		return nil, nil, err
+	}
+	allowed := make(map[string]bool, len(activityPermissions))
+	for kind, perm := range activityPermissions {
+		if access.Allows(perm) {
+			allowed[kind] = true
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, nil, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, "no activity feed permission", nil)
+	}
+
+	feed, unsubscribe := s.activity.Subscribe()
+	events := make(chan activity.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		for event := range feed {
+			if !allowed[event.Kind] {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return events, func() {
+		unsubscribe()
+		close(done)
+	}, nil
+}
+
+// StreamActivity handles GET /admin/activity. It upgrades to a WebSocket
+// sending every activity feed event the admin may see as a JSON message,
+// with a heartbeat message when the feed is quiet. Browsers can't set the
+// Authorization header on WebSockets and pass the access token as the
+// second offered subprotocol instead.
+func (h *Handler) StreamActivity(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	events, unsubscribe, err := h.service.SubscribeActivity(c.Request.Context(), fmt.Sprint(adminID))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer unsubscribe()
+
+	server := websocket.Server{
+		// The token rather than cookies authorizes the connection, so pages
+		// on other origins can't open it on an admin's behalf
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			protocols := config.Protocol
+			config.Protocol = nil
+			for _, protocol := range protocols {
+				if protocol == ActivityTokenProtocol {
+					config.Protocol = []string{ActivityTokenProtocol}
+				}
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			streamActivity(ws, events)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// streamActivity writes events to ws until the client disconnects
+func streamActivity(ws *websocket.Conn, events <-chan activity.Event) {
+	// Clients only send close frames; reading notices the disconnect
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	heartbeat := time.NewTicker(activityHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var message interface{}
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			message = event
+		case now := <-heartbeat.C:
+			message = map[string]interface{}{"kind": "heartbeat", "createdAt": now.UTC()}
+		}
+
+		ws.SetWriteDeadline(time.Now().Add(activityWriteTimeout))
+		if err := websocket.JSON.Send(ws, message); err != nil {
+			return
+		}
+	}
+}
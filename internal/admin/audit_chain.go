@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditChainGenesisHash is the prev_hash of the first entry in the audit chain
+var AuditChainGenesisHash = strings.Repeat("0", 64)
+
+// auditChainTimeLayout matches the to_char format used by audit_log_entry_hash
+const auditChainTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// ComputeAuditEntryHash computes the chained hash of an audit log entry.
+// It mirrors the audit_log_entry_hash SQL function from migration 0022.
+func ComputeAuditEntryHash(prevHash string, entry AuditLogStreamEntry) string {
+	resourceID := ""
+	if entry.ResourceID != nil {
+		resourceID = *entry.ResourceID
+	}
+
+	payload := strings.Join([]string{
+		prevHash,
+		strconv.FormatInt(entry.Seq, 10),
+		entry.ID,
+		entry.HashedUserID,
+		entry.ActorType,
+		entry.Action,
+		entry.Resource,
+		resourceID,
+		string(entry.Metadata),
+		entry.CreatedAt.UTC().Truncate(time.Microsecond).Format(auditChainTimeLayout),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain checks that every entry links to its predecessor and that
+// its stored hash matches its contents. expectedPrev is the hash the first
+// entry should link to, or empty when it is unknown. It returns the sequence
+// number of the first broken entry, or nil when the chain is intact.
+func VerifyAuditChain(entries []AuditLogStreamEntry, expectedPrev string) *int64 {
+	prev := expectedPrev
+	for i := range entries {
+		entry := entries[i]
+		if prev != "" && entry.PrevHash != prev {
+			return &entry.Seq
+		}
+		if ComputeAuditEntryHash(entry.PrevHash, entry) != entry.EntryHash {
+			return &entry.Seq
+		}
+		prev = entry.EntryHash
+	}
+	return nil
+}
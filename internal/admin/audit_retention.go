@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Audit retention defaults
+const (
+	DefaultAuditRetentionInterval = 24 * time.Hour
+	DefaultAuditArchiveBatchSize  = 5000
+	DefaultAuditArchivePrefix     = "audit-archive"
+	auditArchiveContentType       = "application/x-ndjson"
+)
+
+// AuditArchiveWriter stores archived audit log batches, e.g. a storage.ObjectWriter
+type AuditArchiveWriter interface {
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// AuditRetentionConfig configures the audit log retention job
+type AuditRetentionConfig struct {
+	RetentionDays int           // entries older than this are archived; 0 keeps them forever
+	Interval      time.Duration // how often the job runs
+	BatchSize     int           // entries per archive object
+	Prefix        string        // object key prefix for archives
+
+	// RequireForwarded keeps entries the SIEM forwarder has not sent yet
+	RequireForwarded bool
+}
+
+// SetAuditArchive enables archiving of expired audit logs to writer
+func (s *Service) SetAuditArchive(writer AuditArchiveWriter, config AuditRetentionConfig) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultAuditRetentionInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultAuditArchiveBatchSize
+	}
+	if config.Prefix == "" {
+		config.Prefix = DefaultAuditArchivePrefix
+	}
+	s.auditArchive = writer
+	s.auditRetention = config
+}
+
+// ArchiveExpiredAuditLogs moves audit log entries older than the retention
+// period to object storage and deletes them, oldest first. Only a prefix of
+// the chain is ever removed, so the remaining entries stay verifiable from the
+// last archived hash. It returns the number of entries archived.
+func (s *Service) ArchiveExpiredAuditLogs(ctx context.Context) (int, error) {
+	if s.auditArchive == nil || s.auditRetention.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.auditRetention.RetentionDays)
+	lastSeq, err := s.store.GetLastAuditSeqBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	if s.auditRetention.RequireForwarded {
+		forwarded, err := s.store.GetAuditForwardedSeq(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if forwarded < lastSeq {
+			lastSeq = forwarded
+		}
+	}
+
+	archived := 0
+	for lastSeq > 0 {
+		entries, err := s.store.StreamAuditLogs(ctx, 0, nil, s.auditRetention.BatchSize)
+		if err != nil {
+			return archived, fmt.Errorf("failed to read expired audit logs: %w", err)
+		}
+
+		n := 0
+		for n < len(entries) && entries[n].Seq <= lastSeq {
+			n++
+		}
+		if n == 0 {
+			break
+		}
+
+		if err := s.archiveAuditBatch(ctx, entries[:n]); err != nil {
+			return archived, err
+		}
+		archived += n
+
+		if n < len(entries) || entries[n-1].Seq == lastSeq {
+			break
+		}
+	}
+	return archived, nil
+}
+
+// archiveAuditBatch writes entries as one JSONL object and then removes them
+// from Postgres. The object is written first so a failed delete only leaves a
+// duplicate archive behind, never a gap.
+func (s *Service) archiveAuditBatch(ctx context.Context, entries []AuditLogStreamEntry) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode audit log entry: %w", err)
+		}
+	}
+
+	first, last := entries[0], entries[len(entries)-1]
+	// e.g. audit-archive/2025/01/00000000000000000001-00000000000000005000.jsonl
+	key := fmt.Sprintf("%s/%s/%020d-%020d.jsonl", strings.TrimRight(s.auditRetention.Prefix, "/"),
+		first.CreatedAt.UTC().Format("2006/01"), first.Seq, last.Seq)
+	if err := s.auditArchive.WriteObject(ctx, key, buf.Bytes(), auditArchiveContentType); err != nil {
+		return fmt.Errorf("failed to write audit log archive: %w", err)
+	}
+
+	return s.store.ArchiveAuditLogs(ctx, AuditLogArchive{
+		FromSeq:       first.Seq,
+		ToSeq:         last.Seq,
+		EntryCount:    len(entries),
+		LastEntryHash: last.EntryHash,
+		ObjectKey:     key,
+	})
+}
+
+// StartAuditRetention archives expired audit logs every interval until ctx is cancelled
+func (s *Service) StartAuditRetention(ctx context.Context) {
+	if s.auditArchive == nil || s.auditRetention.RetentionDays <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.auditRetention.Interval)
+	defer ticker.Stop()
+
+	log.Printf("Audit log retention started: archiving entries older than %d days every %v",
+		s.auditRetention.RetentionDays, s.auditRetention.Interval)
+
+	for {
+		archived, err := s.ArchiveExpiredAuditLogs(ctx)
+		if err != nil {
+			log.Printf("Error archiving audit logs: %v", err)
+		}
+		if archived > 0 {
+			log.Printf("Archived %d audit log entries", archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Audit log retention stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
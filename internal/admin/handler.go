@@ -1,7 +1,15 @@
 package admin
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
+
+	"ai-styler/internal/common"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,8 +29,8 @@ func NewHandler(service AdminService) *Handler {
 // GetUsers handles GET /admin/users
 func (h *Handler) GetUsers(c *gin.Context) {
 	var req UserListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -36,7 +44,7 @@ func (h *Handler) GetUsers(c *gin.Context) {
 
 	response, err := h.service.GetUsers(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -47,17 +55,17 @@ func (h *Handler) GetUsers(c *gin.Context) {
 func (h *Handler) GetUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	user, err := h.service.GetUser(c.Request.Context(), userID)
 	if err != nil {
 		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			common.RespondError(c, http.StatusNotFound, "user not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -68,19 +76,19 @@ func (h *Handler) GetUser(c *gin.Context) {
 func (h *Handler) UpdateUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	var req UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	user, err := h.service.UpdateUser(c.Request.Context(), userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -91,13 +99,13 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 func (h *Handler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	err := h.service.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -108,21 +116,21 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 func (h *Handler) SuspendUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	err := h.service.SuspendUser(c.Request.Context(), userID, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -133,26 +141,55 @@ func (h *Handler) SuspendUser(c *gin.Context) {
 func (h *Handler) ActivateUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	err := h.service.ActivateUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "user activated successfully"})
 }
 
+// ImpersonateUser handles POST /admin/users/:id/impersonate
+func (h *Handler) ImpersonateUser(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
+		return
+	}
+
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ImpersonateUserRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.ImpersonateUser(c.Request.Context(), fmt.Sprint(adminID), userID, req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // Vendor management handlers
 
 // GetVendors handles GET /admin/vendors
 func (h *Handler) GetVendors(c *gin.Context) {
 	var req VendorListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -166,7 +203,7 @@ func (h *Handler) GetVendors(c *gin.Context) {
 
 	response, err := h.service.GetVendors(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -177,17 +214,17 @@ func (h *Handler) GetVendors(c *gin.Context) {
 func (h *Handler) GetVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	vendor, err := h.service.GetVendor(c.Request.Context(), vendorID)
 	if err != nil {
 		if err.Error() == "vendor not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "vendor not found"})
+			common.RespondError(c, http.StatusNotFound, "vendor not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -198,19 +235,19 @@ func (h *Handler) GetVendor(c *gin.Context) {
 func (h *Handler) UpdateVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	var req UpdateVendorRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	vendor, err := h.service.UpdateVendor(c.Request.Context(), vendorID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -221,13 +258,13 @@ func (h *Handler) UpdateVendor(c *gin.Context) {
 func (h *Handler) DeleteVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	err := h.service.DeleteVendor(c.Request.Context(), vendorID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -238,21 +275,21 @@ func (h *Handler) DeleteVendor(c *gin.Context) {
 func (h *Handler) SuspendVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	err := h.service.SuspendVendor(c.Request.Context(), vendorID, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -263,13 +300,13 @@ func (h *Handler) SuspendVendor(c *gin.Context) {
 func (h *Handler) ActivateVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	err := h.service.ActivateVendor(c.Request.Context(), vendorID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -280,13 +317,13 @@ func (h *Handler) ActivateVendor(c *gin.Context) {
 func (h *Handler) VerifyVendor(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	err := h.service.VerifyVendor(c.Request.Context(), vendorID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -298,8 +335,8 @@ func (h *Handler) VerifyVendor(c *gin.Context) {
 // GetPlans handles GET /admin/plans
 func (h *Handler) GetPlans(c *gin.Context) {
 	var req PlanListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -313,7 +350,7 @@ func (h *Handler) GetPlans(c *gin.Context) {
 
 	response, err := h.service.GetPlans(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -324,17 +361,17 @@ func (h *Handler) GetPlans(c *gin.Context) {
 func (h *Handler) GetPlan(c *gin.Context) {
 	planID := c.Param("id")
 	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "plan ID is required")
 		return
 	}
 
 	plan, err := h.service.GetPlan(c.Request.Context(), planID)
 	if err != nil {
 		if err.Error() == "plan not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
+			common.RespondError(c, http.StatusNotFound, "plan not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -344,14 +381,14 @@ func (h *Handler) GetPlan(c *gin.Context) {
 // CreatePlan handles POST /admin/plans
 func (h *Handler) CreatePlan(c *gin.Context) {
 	var req CreatePlanRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	plan, err := h.service.CreatePlan(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -362,19 +399,19 @@ func (h *Handler) CreatePlan(c *gin.Context) {
 func (h *Handler) UpdatePlan(c *gin.Context) {
 	planID := c.Param("id")
 	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "plan ID is required")
 		return
 	}
 
 	var req UpdatePlanRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	plan, err := h.service.UpdatePlan(c.Request.Context(), planID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -385,13 +422,13 @@ func (h *Handler) UpdatePlan(c *gin.Context) {
 func (h *Handler) DeletePlan(c *gin.Context) {
 	planID := c.Param("id")
 	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "plan ID is required")
 		return
 	}
 
 	err := h.service.DeletePlan(c.Request.Context(), planID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -403,8 +440,8 @@ func (h *Handler) DeletePlan(c *gin.Context) {
 // GetPayments handles GET /admin/payments
 func (h *Handler) GetPayments(c *gin.Context) {
 	var req PaymentListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -418,7 +455,7 @@ func (h *Handler) GetPayments(c *gin.Context) {
 
 	response, err := h.service.GetPayments(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -429,17 +466,17 @@ func (h *Handler) GetPayments(c *gin.Context) {
 func (h *Handler) GetPayment(c *gin.Context) {
 	paymentID := c.Param("id")
 	if paymentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "payment ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "payment ID is required")
 		return
 	}
 
 	payment, err := h.service.GetPayment(c.Request.Context(), paymentID)
 	if err != nil {
 		if err.Error() == "payment not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			common.RespondError(c, http.StatusNotFound, "payment not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -451,8 +488,8 @@ func (h *Handler) GetPayment(c *gin.Context) {
 // GetConversions handles GET /admin/conversions
 func (h *Handler) GetConversions(c *gin.Context) {
 	var req ConversionListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -466,7 +503,7 @@ func (h *Handler) GetConversions(c *gin.Context) {
 
 	response, err := h.service.GetConversions(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -477,30 +514,114 @@ func (h *Handler) GetConversions(c *gin.Context) {
 func (h *Handler) GetConversion(c *gin.Context) {
 	conversionID := c.Param("id")
 	if conversionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "conversion ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "conversion ID is required")
+		return
+	}
+
+	var req ConversionDetailRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
-	conversion, err := h.service.GetConversion(c.Request.Context(), conversionID)
+	conversion, err := h.service.GetConversion(c.Request.Context(), conversionID, req.Logs)
 	if err != nil {
 		if err.Error() == "conversion not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "conversion not found"})
+			common.RespondError(c, http.StatusNotFound, "conversion not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, conversion)
 }
 
+// BoostConversion handles POST /admin/conversions/:id/boost
+func (h *Handler) BoostConversion(c *gin.Context) {
+	conversionID := c.Param("id")
+	if conversionID == "" {
+		common.RespondError(c, http.StatusBadRequest, "conversion ID is required")
+		return
+	}
+
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req BoostConversionRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	boost, err := h.service.BoostConversion(c.Request.Context(), fmt.Sprint(adminID), conversionID, req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, boost)
+}
+
+// GetDeadLetterConversions handles GET /admin/conversions/dead-letter
+func (h *Handler) GetDeadLetterConversions(c *gin.Context) {
+	var req DeadLetterListRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetDeadLetterConversions(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RequeueConversion handles POST /admin/conversions/:id/requeue
+func (h *Handler) RequeueConversion(c *gin.Context) {
+	conversionID := c.Param("id")
+	if conversionID == "" {
+		common.RespondError(c, http.StatusBadRequest, "conversion ID is required")
+		return
+	}
+
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// The body is optional
+	var req RequeueConversionRequest
+	if c.Request.ContentLength != 0 {
+		if err := common.BindJSON(c, &req); err != nil {
+			common.RespondErr(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	requeue, err := h.service.RequeueConversion(c.Request.Context(), fmt.Sprint(adminID), conversionID, req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, requeue)
+}
+
 // Image management handlers
 
 // GetImages handles GET /admin/images
 func (h *Handler) GetImages(c *gin.Context) {
 	var req ImageListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -514,7 +635,7 @@ func (h *Handler) GetImages(c *gin.Context) {
 
 	response, err := h.service.GetImages(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -525,17 +646,17 @@ func (h *Handler) GetImages(c *gin.Context) {
 func (h *Handler) GetImage(c *gin.Context) {
 	imageID := c.Param("id")
 	if imageID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "image ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "image ID is required")
 		return
 	}
 
 	image, err := h.service.GetImage(c.Request.Context(), imageID)
 	if err != nil {
 		if err.Error() == "image not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+			common.RespondError(c, http.StatusNotFound, "image not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -547,8 +668,8 @@ func (h *Handler) GetImage(c *gin.Context) {
 // GetAuditLogs handles GET /admin/audit-logs
 func (h *Handler) GetAuditLogs(c *gin.Context) {
 	var req AuditLogListRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -562,26 +683,207 @@ func (h *Handler) GetAuditLogs(c *gin.Context) {
 
 	response, err := h.service.GetAuditLogs(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamAuditLogs handles GET /admin/audit-logs/stream
+func (h *Handler) StreamAuditLogs(c *gin.Context) {
+	var req AuditLogStreamRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.StreamAuditLogs(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// auditExportFlushEvery is how many exported entries are buffered before flushing
+const auditExportFlushEvery = 500
+
+// auditExportCSVHeader lists the CSV export columns
+var auditExportCSVHeader = []string{
+	"seq", "id", "user_id", "hashed_user_id", "actor_type", "action", "resource",
+	"resource_id", "metadata", "created_at", "prev_hash", "entry_hash",
+}
+
+// ExportAuditLogs handles GET /admin/audit-logs/export
+// It streams every entry matching the list filters as CSV or JSONL.
+func (h *Handler) ExportAuditLogs(c *gin.Context) {
+	var req AuditLogExportRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.Format == "" {
+		req.Format = AuditExportFormatCSV
+	}
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+	started := false
+	start := func() error {
+		started = true
+		contentType := "text/csv; charset=utf-8"
+		if req.Format == AuditExportFormatJSONL {
+			contentType = "application/x-ndjson"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-logs-%s.%s"`,
+			time.Now().UTC().Format("20060102-150405"), req.Format))
+		c.Status(http.StatusOK)
+		if req.Format == AuditExportFormatCSV {
+			return csvWriter.Write(auditExportCSVHeader)
+		}
+		return nil
+	}
+
+	// Headers are only sent with the first entry so errors before it still
+	// get a proper error response
+	exported := 0
+	err := h.service.ExportAuditLogs(c.Request.Context(), req, func(entry AuditLogStreamEntry) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if req.Format == AuditExportFormatJSONL {
+			err = encoder.Encode(entry)
+		} else {
+			err = csvWriter.Write(auditExportCSVRecord(entry))
+		}
+		if err != nil {
+			return err
+		}
+
+		exported++
+		if exported%auditExportFlushEvery == 0 {
+			csvWriter.Flush()
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			common.RespondErr(c, http.StatusInternalServerError, err)
+			return
+		}
+		// The status is already sent; the client sees a truncated file
+		log.Printf("Audit log export aborted after %d entries: %v", exported, err)
+	} else if !started {
+		start()
+	}
+
+	csvWriter.Flush()
+	c.Writer.Flush()
+}
+
+// auditExportCSVRecord renders an entry as a CSV row
+func auditExportCSVRecord(entry AuditLogStreamEntry) []string {
+	userID, resourceID := "", ""
+	if entry.UserID != nil {
+		userID = *entry.UserID
+	}
+	if entry.ResourceID != nil {
+		resourceID = *entry.ResourceID
+	}
+
+	return []string{
+		strconv.FormatInt(entry.Seq, 10), entry.ID, userID, entry.HashedUserID, entry.ActorType, entry.Action,
+		entry.Resource, resourceID, string(entry.Metadata), entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.PrevHash, entry.EntryHash,
+	}
+}
+
+// Moderation review handlers
+
+// GetModerationVerdicts handles GET /admin/moderation
+func (h *Handler) GetModerationVerdicts(c *gin.Context) {
+	var req ModerationListRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetModerationVerdicts(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAPIKeys handles GET /admin/api-keys
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	var req APIKeyListRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetAPIKeys(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReviewModerationVerdict handles POST /admin/moderation/:id/review
+func (h *Handler) ReviewModerationVerdict(c *gin.Context) {
+	verdictID := c.Param("id")
+	if verdictID == "" {
+		common.RespondError(c, http.StatusBadRequest, "verdict ID is required")
+		return
+	}
+
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ReviewModerationRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	verdict, err := h.service.ReviewModerationVerdict(c.Request.Context(), fmt.Sprint(adminID), verdictID, req)
+	if err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verdict)
+}
+
 // Quota management handlers
 
 // RevokeUserQuota handles POST /admin/users/:id/revoke-quota
 func (h *Handler) RevokeUserQuota(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	var req RevokeQuotaRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -590,7 +892,7 @@ func (h *Handler) RevokeUserQuota(c *gin.Context) {
 
 	err := h.service.RevokeUserQuota(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -601,7 +903,7 @@ func (h *Handler) RevokeUserQuota(c *gin.Context) {
 func (h *Handler) RevokeVendorQuota(c *gin.Context) {
 	vendorID := c.Param("id")
 	if vendorID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
@@ -610,14 +912,14 @@ func (h *Handler) RevokeVendorQuota(c *gin.Context) {
 		Amount    int    `json:"amount" binding:"required,min=1"`
 		Reason    string `json:"reason" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	err := h.service.RevokeVendorQuota(c.Request.Context(), vendorID, req.QuotaType, req.Amount, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -628,13 +930,13 @@ func (h *Handler) RevokeVendorQuota(c *gin.Context) {
 func (h *Handler) RevokeUserPlan(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "user ID is required")
 		return
 	}
 
 	var req RevokePlanRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -643,7 +945,7 @@ func (h *Handler) RevokeUserPlan(c *gin.Context) {
 
 	err := h.service.RevokeUserPlan(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -656,7 +958,7 @@ func (h *Handler) RevokeUserPlan(c *gin.Context) {
 func (h *Handler) GetSystemStats(c *gin.Context) {
 	stats, err := h.service.GetSystemStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -667,7 +969,7 @@ func (h *Handler) GetSystemStats(c *gin.Context) {
 func (h *Handler) GetUserStats(c *gin.Context) {
 	total, active, err := h.service.GetUserStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -681,7 +983,7 @@ func (h *Handler) GetUserStats(c *gin.Context) {
 func (h *Handler) GetVendorStats(c *gin.Context) {
 	total, active, err := h.service.GetVendorStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -695,7 +997,7 @@ func (h *Handler) GetVendorStats(c *gin.Context) {
 func (h *Handler) GetPaymentStats(c *gin.Context) {
 	total, revenue, err := h.service.GetPaymentStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -709,7 +1011,7 @@ func (h *Handler) GetPaymentStats(c *gin.Context) {
 func (h *Handler) GetConversionStats(c *gin.Context) {
 	total, pending, failed, err := h.service.GetConversionStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -724,7 +1026,7 @@ func (h *Handler) GetConversionStats(c *gin.Context) {
 func (h *Handler) GetImageStats(c *gin.Context) {
 	total, err := h.service.GetImageStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -732,3 +1034,202 @@ func (h *Handler) GetImageStats(c *gin.Context) {
 		"total": total,
 	})
 }
+
+// GetConversionCosts handles GET /admin/stats/costs
+func (h *Handler) GetConversionCosts(c *gin.Context) {
+	var req ConversionCostRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetConversionCosts(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetFeedbackStats handles GET /admin/stats/feedback
+func (h *Handler) GetFeedbackStats(c *gin.Context) {
+	var req FeedbackStatsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetFeedbackStats(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOnboardingCohorts handles GET /admin/stats/onboarding-cohorts
+func (h *Handler) GetOnboardingCohorts(c *gin.Context) {
+	var req OnboardingCohortRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.GetOnboardingCohorts(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Role management handlers
+
+// GetPermissions handles GET /admin/permissions
+func (h *Handler) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": Permissions})
+}
+
+// GetMyAccess handles GET /admin/me/permissions
+func (h *Handler) GetMyAccess(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	access, err := h.service.GetAdminAccess(c.Request.Context(), fmt.Sprint(adminID))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, access)
+}
+
+// GetRoles handles GET /admin/roles
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// CreateRole handles POST /admin/roles
+func (h *Handler) CreateRole(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateRoleRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole handles PUT /admin/roles/:name
+func (h *Handler) UpdateRole(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), fmt.Sprint(adminID), c.Param("name"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole handles DELETE /admin/roles/:name
+func (h *Handler) DeleteRole(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteRole(c.Request.Context(), fmt.Sprint(adminID), c.Param("name")); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted successfully"})
+}
+
+// GetUserRoles handles GET /admin/users/:id/roles
+func (h *Handler) GetUserRoles(c *gin.Context) {
+	access, err := h.service.GetAdminAccess(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, access)
+}
+
+// AssignUserRole handles POST /admin/users/:id/roles
+func (h *Handler) AssignUserRole(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	access, err := h.service.AssignRole(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, access)
+}
+
+// RemoveUserRole handles DELETE /admin/users/:id/roles/:role
+func (h *Handler) RemoveUserRole(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	access, err := h.service.RemoveRole(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), c.Param("role"))
+	if err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, access)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -97,8 +98,9 @@ func TestHandler_GetUser_NotFound(t *testing.T) {
 
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusInternalServerError {
-		t.Fatalf("Expected status 500, got %d", w.Code)
+	// Wrapped store not-found errors map to 404 through the shared error envelope
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", w.Code)
 	}
 }
 
@@ -170,8 +172,11 @@ func TestHandler_UpdateUser_InvalidRole(t *testing.T) {
 
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusInternalServerError {
-		t.Fatalf("Expected status 500, got %d", w.Code)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "validation_error") {
+		t.Errorf("Expected a validation error, got %s", w.Body.String())
 	}
 }
 
@@ -340,8 +345,11 @@ func TestHandler_CreatePlan_InvalidPrice(t *testing.T) {
 
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusInternalServerError {
-		t.Fatalf("Expected status 500, got %d", w.Code)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "validation_error") {
+		t.Errorf("Expected a validation error, got %s", w.Body.String())
 	}
 }
 
@@ -387,3 +395,200 @@ func TestHandler_GetSystemStats(t *testing.T) {
 		t.Fatalf("Expected active users 80, got %d", stats.ActiveUsers)
 	}
 }
+
+func TestHandler_ImpersonationMiddleware(t *testing.T) {
+	store := NewMockStore()
+	service, handler := WireAdminServiceWithMocks(store)
+	auditLogger := &recordingAuditLogger{}
+	service.auditLogger = auditLogger
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "user1")
+		if c.GetHeader("X-Test-Impersonator") != "" {
+			c.Set(ImpersonatorContextKey, c.GetHeader("X-Test-Impersonator"))
+		}
+		c.Next()
+	})
+	router.Use(handler.ImpersonationMiddleware())
+	router.GET("/profile", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	// Regular session
+	req, _ := http.NewRequest("GET", "/profile", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get(ImpersonationHeader) != "" {
+		t.Errorf("Expected no impersonation header, got %q", w.Header().Get(ImpersonationHeader))
+	}
+	if len(auditLogger.actions) != 0 {
+		t.Errorf("Expected no audit actions, got %v", auditLogger.actions)
+	}
+
+	// Impersonated session
+	req, _ = http.NewRequest("GET", "/profile", nil)
+	req.Header.Set("X-Test-Impersonator", "admin1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get(ImpersonationHeader) != "true" {
+		t.Errorf("Expected impersonation header, got %q", w.Header().Get(ImpersonationHeader))
+	}
+	if w.Header().Get(ImpersonatorIDHeader) != "admin1" {
+		t.Errorf("Expected impersonator admin1, got %q", w.Header().Get(ImpersonatorIDHeader))
+	}
+	if len(auditLogger.actions) != 1 || auditLogger.actions[0] != ActionImpersonateRequest {
+		t.Errorf("Expected impersonated request audit action, got %v", auditLogger.actions)
+	}
+}
+
+func TestHandler_ExportAuditLogs(t *testing.T) {
+	store := NewMockStore()
+	_, handler := WireAdminServiceWithMocks(store)
+	for _, action := range []string{"a", "b", "a"} {
+		appendAuditStreamEntry(store, action)
+	}
+
+	router := setupTestRouter()
+	router.GET("/admin/audit-logs/export", handler.ExportAuditLogs)
+
+	// CSV is the default format and applies the list filters
+	req, _ := http.NewRequest("GET", "/admin/audit-logs/export?action=a", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV content type, got %s", w.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "seq,id,user_id") || !strings.HasPrefix(lines[2], "3,loga,") {
+		t.Errorf("Expected header and 2 rows, got %q", w.Body.String())
+	}
+
+	// JSONL
+	req, _ = http.NewRequest("GET", "/admin/audit-logs/export?format=jsonl", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	var entry AuditLogStreamEntry
+	if len(lines) != 3 || json.Unmarshal([]byte(lines[1]), &entry) != nil || entry.Seq != 2 {
+		t.Errorf("Expected 3 JSON lines, got %q", w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), ".jsonl") {
+		t.Errorf("Expected jsonl attachment, got %s", w.Header().Get("Content-Disposition"))
+	}
+
+	// Unknown format
+	req, _ = http.NewRequest("GET", "/admin/audit-logs/export?format=xml", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandler_DeadLetterConversions(t *testing.T) {
+	store := NewMockStore()
+	_, handler := WireAdminServiceWithMocks(store)
+
+	statusCode := 503
+	store.deadLetters["conv1"] = DeadLetterConversion{ID: "conv1", ProviderStatusCode: &statusCode}
+	store.deadLetters["conv2"] = DeadLetterConversion{ID: "conv2"}
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_user_id", "admin1")
+		c.Next()
+	})
+	conversions := router.Group("/admin/conversions")
+	conversions.GET("/dead-letter", handler.GetDeadLetterConversions)
+	conversions.GET("/:id", handler.GetConversion)
+	conversions.POST("/:id/requeue", handler.RequeueConversion)
+
+	req, _ := http.NewRequest("GET", "/admin/conversions/dead-letter?statusCode=503", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response DeadLetterListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Total != 1 || response.Conversions[0].ID != "conv1" {
+		t.Fatalf("Expected only conv1, got %+v", response)
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/conversions/conv1/requeue", bytes.NewBufferString(`{"priority":80}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var requeue ConversionRequeue
+	if err := json.Unmarshal(w.Body.Bytes(), &requeue); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requeue.Priority != 80 || requeue.RequeuedBy != "admin1" {
+		t.Errorf("Expected priority 80 requeued by admin1, got %+v", requeue)
+	}
+
+	// The body is optional; unknown conversions are not found
+	req, _ = http.NewRequest("POST", "/admin/conversions/missing/requeue", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_ImpersonateUser_Rejected(t *testing.T) {
+	store := NewMockStore()
+	service, handler := WireAdminServiceWithMocks(store)
+	service.SetImpersonationIssuer(&mockImpersonationIssuer{}, 0)
+
+	store.users["admin1"] = AdminUser{ID: "admin1", Role: "admin", IsActive: true}
+	store.users["admin2"] = AdminUser{ID: "admin2", Role: "admin", IsActive: true}
+	store.users["inactive"] = AdminUser{ID: "inactive", Role: "user", IsActive: false}
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_user_id", "admin1")
+		c.Next()
+	})
+	router.POST("/admin/users/:id/impersonate", handler.ImpersonateUser)
+
+	tests := []struct {
+		name   string
+		userID string
+		body   string
+		status int
+	}{
+		{"missing reason", "inactive", `{}`, http.StatusBadRequest},
+		{"self", "admin1", `{"reason":"support ticket"}`, http.StatusForbidden},
+		{"other admin", "admin2", `{"reason":"support ticket"}`, http.StatusForbidden},
+		{"inactive user", "inactive", `{"reason":"support ticket"}`, http.StatusForbidden},
+		{"unknown user", "missing", `{"reason":"support ticket"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", "/admin/users/"+tt.userID+"/impersonate", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
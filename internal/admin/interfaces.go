@@ -2,6 +2,10 @@ package admin
 
 import (
 	"context"
+	"io"
+	"time"
+
+	"ai-styler/internal/activity"
 )
 
 // Store defines the interface for admin data operations
@@ -36,6 +40,10 @@ type Store interface {
 	GetConversions(ctx context.Context, req ConversionListRequest) (ConversionListResponse, error)
 	GetConversion(ctx context.Context, conversionID string) (AdminConversion, error)
 	GetConversionStats(ctx context.Context) (int, int, int, error) // total, pending, failed
+	GetConversionLogs(ctx context.Context, conversionID string, limit int) ([]ConversionLogEntry, error)
+	BoostConversionJob(ctx context.Context, conversionID string, priority int, adminID string) (ConversionBoost, error)
+	GetDeadLetterConversions(ctx context.Context, req DeadLetterListRequest) (DeadLetterListResponse, error)
+	RequeueDeadLetterConversion(ctx context.Context, conversionID string, priority int, adminID string) (ConversionRequeue, error)
 
 	// Image operations
 	GetImages(ctx context.Context, req ImageListRequest) (ImageListResponse, error)
@@ -45,6 +53,20 @@ type Store interface {
 	// Audit log operations
 	GetAuditLogs(ctx context.Context, req AuditLogListRequest) (AuditLogListResponse, error)
 	CreateAuditLog(ctx context.Context, log AuditLog) error
+	StreamAuditLogs(ctx context.Context, afterSeq int64, since *time.Time, limit int) ([]AuditLogStreamEntry, error)
+	ExportAuditLogs(ctx context.Context, req AuditLogListRequest, fn func(AuditLogStreamEntry) error) error
+	GetLastAuditSeqBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	GetAuditForwardedSeq(ctx context.Context) (int64, error)
+	ArchiveAuditLogs(ctx context.Context, archive AuditLogArchive) error
+	GetLatestAuditArchive(ctx context.Context) (*AuditLogArchive, error)
+
+	// Moderation operations
+	GetModerationVerdicts(ctx context.Context, req ModerationListRequest) (ModerationListResponse, error)
+	GetModerationVerdict(ctx context.Context, verdictID string) (ModerationVerdict, error)
+	ReviewModerationVerdict(ctx context.Context, verdictID, reviewerID, status, note string) (ModerationVerdict, error)
+
+	// API key operations
+	GetAPIKeys(ctx context.Context, req APIKeyListRequest) (APIKeyListResponse, error)
 
 	// Quota operations
 	RevokeUserQuota(ctx context.Context, userID string, quotaType string, amount int, reason string) error
@@ -53,6 +75,9 @@ type Store interface {
 
 	// Statistics
 	GetSystemStats(ctx context.Context) (AdminStats, error)
+	GetOnboardingCohorts(ctx context.Context, from, to time.Time, activationDays int) ([]OnboardingCohort, error)
+	GetConversionCosts(ctx context.Context, from, to time.Time, groupBy string) ([]ConversionCostGroup, error)
+	GetFeedbackStats(ctx context.Context, from, to time.Time) ([]FeedbackStatsGroup, error)
 }
 
 // NotificationService defines the interface for sending notifications
@@ -67,6 +92,11 @@ type AuditLogger interface {
 	LogAction(ctx context.Context, userID *string, actorType, action, resource string, resourceID *string, metadata map[string]interface{}) error
 }
 
+// ImpersonationTokenIssuer issues short-lived access tokens that let an admin act as a user
+type ImpersonationTokenIssuer interface {
+	IssueImpersonationToken(ctx context.Context, userID, phone, role, impersonatorID string, ttl time.Duration) (string, time.Time, error)
+}
+
 // AdminService defines the main admin service interface
 type AdminService interface {
 	// User management
@@ -76,6 +106,8 @@ type AdminService interface {
 	DeleteUser(ctx context.Context, userID string) error
 	SuspendUser(ctx context.Context, userID string, reason string) error
 	ActivateUser(ctx context.Context, userID string) error
+	ImpersonateUser(ctx context.Context, adminID, userID string, req ImpersonateUserRequest) (ImpersonationResponse, error)
+	LogImpersonatedRequest(ctx context.Context, adminID, userID string, metadata map[string]interface{})
 
 	// Vendor management
 	GetVendors(ctx context.Context, req VendorListRequest) (VendorListResponse, error)
@@ -96,10 +128,16 @@ type AdminService interface {
 	// Payment management
 	GetPayments(ctx context.Context, req PaymentListRequest) (PaymentListResponse, error)
 	GetPayment(ctx context.Context, paymentID string) (AdminPayment, error)
+	ExportPayments(ctx context.Context, adminID string, req PaymentExportRequest, start func(filename string) io.Writer) (*PaymentExport, error)
+	GetPaymentExport(ctx context.Context, exportID string) (PaymentExport, error)
+	OpenPaymentExport(ctx context.Context, exportID string) ([]byte, string, error)
 
 	// Conversion management
 	GetConversions(ctx context.Context, req ConversionListRequest) (ConversionListResponse, error)
-	GetConversion(ctx context.Context, conversionID string) (AdminConversion, error)
+	GetConversion(ctx context.Context, conversionID string, logLimit int) (AdminConversion, error)
+	BoostConversion(ctx context.Context, adminID, conversionID string, req BoostConversionRequest) (ConversionBoost, error)
+	GetDeadLetterConversions(ctx context.Context, req DeadLetterListRequest) (DeadLetterListResponse, error)
+	RequeueConversion(ctx context.Context, adminID, conversionID string, req RequeueConversionRequest) (ConversionRequeue, error)
 
 	// Image management
 	GetImages(ctx context.Context, req ImageListRequest) (ImageListResponse, error)
@@ -107,6 +145,15 @@ type AdminService interface {
 
 	// Audit trail
 	GetAuditLogs(ctx context.Context, req AuditLogListRequest) (AuditLogListResponse, error)
+	StreamAuditLogs(ctx context.Context, req AuditLogStreamRequest) (AuditLogStreamResponse, error)
+	ExportAuditLogs(ctx context.Context, req AuditLogExportRequest, fn func(AuditLogStreamEntry) error) error
+
+	// Moderation review
+	GetModerationVerdicts(ctx context.Context, req ModerationListRequest) (ModerationListResponse, error)
+	ReviewModerationVerdict(ctx context.Context, adminID, verdictID string, req ReviewModerationRequest) (ModerationVerdict, error)
+
+	// API key usage
+	GetAPIKeys(ctx context.Context, req APIKeyListRequest) (APIKeyListResponse, error)
 
 	// Quota management
 	RevokeUserQuota(ctx context.Context, req RevokeQuotaRequest) error
@@ -120,4 +167,19 @@ type AdminService interface {
 	GetPaymentStats(ctx context.Context) (int, int64, error)
 	GetConversionStats(ctx context.Context) (int, int, int, error)
 	GetImageStats(ctx context.Context) (int, error)
+	GetOnboardingCohorts(ctx context.Context, req OnboardingCohortRequest) (OnboardingCohortResponse, error)
+	GetConversionCosts(ctx context.Context, req ConversionCostRequest) (ConversionCostResponse, error)
+	GetFeedbackStats(ctx context.Context, req FeedbackStatsRequest) (FeedbackStatsResponse, error)
+
+	// Roles and permissions
+	GetAdminAccess(ctx context.Context, adminID string) (AdminAccess, error)
+	ListRoles(ctx context.Context) ([]AdminRole, error)
+	CreateRole(ctx context.Context, adminID string, req CreateRoleRequest) (AdminRole, error)
+	UpdateRole(ctx context.Context, adminID, name string, req UpdateRoleRequest) (AdminRole, error)
+	DeleteRole(ctx context.Context, adminID, name string) error
+	AssignRole(ctx context.Context, adminID, userID string, req AssignRoleRequest) (AdminAccess, error)
+	RemoveRole(ctx context.Context, adminID, userID, role string) (AdminAccess, error)
+
+	// Live activity feed
+	SubscribeActivity(ctx context.Context, adminID string) (<-chan activity.Event, func(), error)
 }
@@ -0,0 +1,320 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-styler/internal/common"
+)
+
+// listQuery builds the data and count queries of an admin list endpoint from
+// one filter definition, so the total always matches the listed rows
+type listQuery struct {
+	columns    string // SELECT list of the data query
+	from       string // tables both queries read; joins used by filters belong here
+	joins      string // joins only the data query needs for its columns
+	groupBy    string // GROUP BY of the data query, for aggregated columns
+	conditions []string
+	args       []interface{}
+}
+
+func newListQuery(columns, from string) *listQuery {
+	return &listQuery{columns: columns, from: from, args: []interface{}{}}
+}
+
+// join adds a join only the data query needs; the count query skips it, so
+// it must not drop or duplicate rows of from
+func (q *listQuery) join(clause string) *listQuery {
+	q.joins += " " + clause
+	return q
+}
+
+// filter adds a condition to both queries. Every %d verb in condition is
+// replaced by the placeholder of the matching argument.
+func (q *listQuery) filter(condition string, args ...interface{}) *listQuery {
+	if len(args) > 0 {
+		placeholders := make([]interface{}, len(args))
+		for i := range args {
+			placeholders[i] = len(q.args) + i + 1
+		}
+		condition = fmt.Sprintf(condition, placeholders...)
+	}
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// count returns the query counting the rows matching the filters
+func (q *listQuery) count() (string, []interface{}) {
+	return "SELECT COUNT(*) FROM " + q.from + where(q.conditions), q.args
+}
+
+// page returns the data query of a page ordered by createdAtColumn and
+// idColumn, after cursor or from offset (see common.PageClause)
+func (q *listQuery) page(createdAtColumn, idColumn string, cursor *common.Cursor, pageSize, offset int) (string, []interface{}) {
+	conditions := q.conditions[:len(q.conditions):len(q.conditions)]
+	args := q.args[:len(q.args):len(q.args)]
+	if condition, cursorArgs := cursor.Condition(createdAtColumn, idColumn, len(args)+1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+	clause, pageArgs := common.PageClause(createdAtColumn, idColumn, cursor, pageSize, offset, len(args)+1)
+	return q.selectFrom(conditions) + clause, append(args, pageArgs...)
+}
+
+// offsetPage returns the data query of limit rows from offset, ordered by orderBy
+func (q *listQuery) offsetPage(orderBy string, limit, offset int) (string, []interface{}) {
+	args := q.args[:len(q.args):len(q.args)]
+	clause := fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", orderBy, len(args)+1, len(args)+2)
+	return q.selectFrom(q.conditions) + clause, append(args, limit, offset)
+}
+
+// all returns the data query of every matching row, ordered by orderBy
+func (q *listQuery) all(orderBy string) (string, []interface{}) {
+	return q.selectFrom(q.conditions) + " ORDER BY " + orderBy, q.args
+}
+
+func (q *listQuery) selectFrom(conditions []string) string {
+	query := "SELECT " + q.columns + " FROM " + q.from + q.joins + where(conditions)
+	if q.groupBy != "" {
+		query += " GROUP BY " + q.groupBy
+	}
+	return query
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// lastLoginJoin adds the most recent session of every user as s
+const lastLoginJoin = `LEFT JOIN (
+			SELECT DISTINCT ON (user_id) user_id, last_used_at
+			FROM sessions
+			WHERE revoked_at IS NULL
+			ORDER BY user_id, last_used_at DESC
+		) s ON s.user_id = `
+
+func userListQuery(req UserListRequest) *listQuery {
+	q := newListQuery(`
+			u.id, u.phone, u.name, u.avatar_url, u.bio, u.role,
+			u.is_phone_verified, u.free_conversions_used, u.free_conversions_limit,
+			u.created_at, u.updated_at, u.is_active,
+			COALESCE(s.last_used_at, u.created_at) as last_login_at`,
+		"users u").
+		join(lastLoginJoin + "u.id")
+
+	if req.Role != "" {
+		q.filter("u.role = $%d", req.Role)
+	}
+	if req.Search != "" {
+		searchTerm := "%" + req.Search + "%"
+		q.filter("(u.phone ILIKE $%d OR u.name ILIKE $%d)", searchTerm, searchTerm)
+	}
+	if req.IsActive != nil {
+		q.filter("u.is_active = $%d", *req.IsActive)
+	}
+	return q
+}
+
+func vendorListQuery(req VendorListRequest) *listQuery {
+	q := newListQuery(`
+			v.id, v.user_id, v.business_name, v.avatar_url, v.bio,
+			v.contact_info, v.social_links, v.is_verified, v.is_active,
+			v.free_images_used, v.free_images_limit, v.created_at, v.updated_at,
+			COALESCE(s.last_used_at, v.created_at) as last_login_at`,
+		"vendors v JOIN users u ON v.user_id = u.id").
+		join(lastLoginJoin + "v.user_id")
+
+	if req.Search != "" {
+		searchTerm := "%" + req.Search + "%"
+		q.filter("(v.business_name ILIKE $%d OR u.phone ILIKE $%d)", searchTerm, searchTerm)
+	}
+	if req.IsActive != nil {
+		q.filter("v.is_active = $%d", *req.IsActive)
+	}
+	if req.IsVerified != nil {
+		q.filter("v.is_verified = $%d", *req.IsVerified)
+	}
+	return q
+}
+
+func planListQuery(req PlanListRequest) *listQuery {
+	q := newListQuery(`
+			p.id, p.name, p.display_name, p.description, p.price_per_month_cents,
+			p.monthly_conversions_limit, p.features, p.is_active, p.trial_days, p.created_at, p.updated_at,
+			COUNT(up.id) as subscriber_count`,
+		"payment_plans p").
+		join("LEFT JOIN user_plans up ON p.id = up.plan_id AND up.status = 'active'")
+	q.groupBy = "p.id"
+
+	if req.IsActive != nil {
+		q.filter("p.is_active = $%d", *req.IsActive)
+	}
+	return q
+}
+
+func paymentListQuery(req PaymentListRequest) *listQuery {
+	q := newListQuery(`
+			p.id, p.user_id, u.phone, p.plan_id, pp.name as plan_name,
+			p.amount, p.currency, p.status, p.payment_method, p.gateway,
+			p.gateway_track_id, p.gateway_ref_number, p.gateway_card_number,
+			p.description, p.created_at, p.updated_at, p.paid_at, p.expires_at`,
+		"payments p").
+		join("JOIN users u ON p.user_id = u.id").
+		join("JOIN payment_plans pp ON p.plan_id = pp.id")
+
+	if req.Status != "" {
+		q.filter("p.status = $%d", req.Status)
+	}
+	if req.UserID != "" {
+		q.filter("p.user_id = $%d", req.UserID)
+	}
+	if req.PlanID != "" {
+		q.filter("p.plan_id = $%d", req.PlanID)
+	}
+	if req.DateFrom != "" {
+		q.filter("p.created_at >= $%d", req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.filter("p.created_at <= $%d", req.DateTo)
+	}
+	return q
+}
+
+func conversionListQuery(req ConversionListRequest) *listQuery {
+	q := newListQuery(`
+			uc.id, uc.user_id, u.phone, uc.conversion_type, uc.input_file_url,
+			uc.output_file_url, uc.style_name, uc.status, uc.error_message,
+			uc.processing_time_ms, uc.file_size_bytes, uc.created_at, uc.completed_at`,
+		"user_conversions uc").
+		join("JOIN users u ON uc.user_id = u.id")
+
+	if req.Status != "" {
+		q.filter("uc.status = $%d", req.Status)
+	}
+	if req.UserID != "" {
+		q.filter("uc.user_id = $%d", req.UserID)
+	}
+	if req.Type != "" {
+		q.filter("uc.conversion_type = $%d", req.Type)
+	}
+	if req.DateFrom != "" {
+		q.filter("uc.created_at >= $%d", req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.filter("uc.created_at <= $%d", req.DateTo)
+	}
+	return q
+}
+
+func deadLetterListQuery(req DeadLetterListRequest) *listQuery {
+	q := newListQuery(`
+			c.id, c.user_id, u.phone, COALESCE(c.trace_id, ''), c.style_name, c.error_message, c.failure_kind,
+			c.provider_status_code, c.provider_response,
+			(SELECT COUNT(*) FROM conversion_logs cl
+			 WHERE cl.conversion_id = c.id AND cl.stage = 'provider' AND cl.level <> 'info'),
+			c.retry_count, c.dead_letter_requeues, c.created_at, c.dead_lettered_at`,
+		"conversions c").
+		join("JOIN users u ON c.user_id = u.id").
+		filter("c.dead_lettered_at IS NOT NULL AND c.status = 'failed'")
+
+	if req.UserID != "" {
+		q.filter("c.user_id = $%d", req.UserID)
+	}
+	if req.StatusCode != 0 {
+		q.filter("c.provider_status_code = $%d", req.StatusCode)
+	}
+	if req.TraceID != "" {
+		q.filter("c.trace_id = $%d", req.TraceID)
+	}
+	return q
+}
+
+func imageListQuery(req ImageListRequest) *listQuery {
+	q := newListQuery(`
+			i.id, i.vendor_id, v.business_name, i.album_id, a.name as album_name,
+			i.file_name, i.original_url, i.thumbnail_url, i.file_size, i.mime_type,
+			i.width, i.height, i.is_free, i.is_public, i.tags, i.created_at, i.updated_at`,
+		"images i").
+		join("JOIN vendors v ON i.vendor_id = v.id").
+		join("LEFT JOIN albums a ON i.album_id = a.id")
+
+	if req.VendorID != "" {
+		q.filter("i.vendor_id = $%d", req.VendorID)
+	}
+	if req.IsPublic != nil {
+		q.filter("i.is_public = $%d", *req.IsPublic)
+	}
+	if req.IsFree != nil {
+		q.filter("i.is_free = $%d", *req.IsFree)
+	}
+	if req.DateFrom != "" {
+		q.filter("i.created_at >= $%d", req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.filter("i.created_at <= $%d", req.DateTo)
+	}
+	return q
+}
+
+// auditLogQuery selects columns of the audit log entries matching the list
+// filters; the list and the export share it
+func auditLogQuery(req AuditLogListRequest, columns string) *listQuery {
+	q := newListQuery(columns, "audit_logs")
+
+	if req.UserID != "" {
+		q.filter("user_id = $%d", req.UserID)
+	}
+	if req.Action != "" {
+		q.filter("action = $%d", req.Action)
+	}
+	if req.Resource != "" {
+		q.filter("resource = $%d", req.Resource)
+	}
+	if req.DateFrom != "" {
+		q.filter("created_at >= $%d", req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.filter("created_at <= $%d", req.DateTo)
+	}
+	return q
+}
+
+func apiKeyListQuery(req APIKeyListRequest) *listQuery {
+	q := newListQuery(`
+			k.id, k.user_id, u.phone, k.name, k.key_prefix, k.scopes, k.rate_limit_per_minute,
+			k.webhook_url IS NOT NULL, k.request_count, k.last_used_at, k.expires_at, k.revoked_at, k.created_at`,
+		"api_keys k").
+		join("JOIN users u ON u.id = k.user_id")
+
+	if req.UserID != "" {
+		q.filter("k.user_id = $%d", req.UserID)
+	}
+	if req.Active != nil {
+		active := "k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())"
+		if *req.Active {
+			q.filter(active)
+		} else {
+			q.filter("NOT (" + active + ")")
+		}
+	}
+	return q
+}
+
+func moderationListQuery(req ModerationListRequest) *listQuery {
+	q := newListQuery(moderationVerdictColumns, "image_moderation_verdicts")
+
+	if req.ReviewStatus != "" {
+		q.filter("review_status = $%d", req.ReviewStatus)
+	}
+	if req.Allowed != nil {
+		q.filter("allowed = $%d", *req.Allowed)
+	}
+	if req.ImageKind != "" {
+		q.filter("image_kind = $%d", req.ImageKind)
+	}
+	return q
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// listFilter sets one filter of a list request and names the arguments it adds
+type listFilter[R any] struct {
+	name string
+	set  func(*R)
+	args []interface{}
+}
+
+var placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// checkPlaceholders fails unless query numbers its placeholders 1..n in order
+func checkPlaceholders(t *testing.T, query string, n int) {
+	t.Helper()
+	matches := placeholderPattern.FindAllStringSubmatch(query, -1)
+	if len(matches) != n {
+		t.Fatalf("Expected %d placeholders, got %d in %s", n, len(matches), query)
+	}
+	for i, match := range matches {
+		if match[1] != strconv.Itoa(i+1) {
+			t.Fatalf("Expected placeholder $%d, got $%s in %s", i+1, match[1], query)
+		}
+	}
+}
+
+// testListQuery builds the queries of every combination of filters and checks
+// that the count and data queries share the same conditions and arguments
+func testListQuery[R any](t *testing.T, build func(R) *listQuery, orderBy string, filters []listFilter[R]) {
+	for mask := 0; mask < 1<<len(filters); mask++ {
+		var req R
+		var names []string
+		args := []interface{}{}
+		for i, filter := range filters {
+			if mask&(1<<i) != 0 {
+				filter.set(&req)
+				names = append(names, filter.name)
+				args = append(args, filter.args...)
+			}
+		}
+
+		t.Run(strings.Join(append([]string{"filters"}, names...), "_"), func(t *testing.T) {
+			q := build(req)
+
+			countQuery, countArgs := q.count()
+			if !strings.HasPrefix(countQuery, "SELECT COUNT(*) FROM "+q.from) || strings.Contains(countQuery, q.columns) {
+				t.Errorf("Expected a count without the data columns, got %s", countQuery)
+			}
+			if q.joins != "" && strings.Contains(countQuery, q.joins) {
+				t.Errorf("Expected the count to skip the data joins, got %s", countQuery)
+			}
+			if !reflect.DeepEqual(countArgs, args) {
+				t.Errorf("Expected arguments %v, got %v", args, countArgs)
+			}
+			checkPlaceholders(t, countQuery, len(args))
+
+			_, conditions, _ := strings.Cut(countQuery, " WHERE ")
+			var query string
+			var queryArgs []interface{}
+			limit := 20
+			if orderBy == "" {
+				query, queryArgs = q.page("created_at", "id", nil, 20, 40)
+				limit++ // one more row tells whether another page follows
+			} else {
+				query, queryArgs = q.offsetPage(orderBy, 20, 40)
+			}
+			if !strings.HasPrefix(query, "SELECT "+q.columns+" FROM "+q.from+q.joins) {
+				t.Errorf("Expected the data columns and joins, got %s", query)
+			}
+			if conditions != "" && !strings.Contains(query, " WHERE "+conditions) {
+				t.Errorf("Expected the conditions %q in %s", conditions, query)
+			}
+			if !reflect.DeepEqual(queryArgs, append(args, limit, 40)) {
+				t.Errorf("Expected arguments %v and the page, got %v", args, queryArgs)
+			}
+			checkPlaceholders(t, query, len(args)+2)
+
+			if orderBy == "" {
+				cursor := &common.Cursor{CreatedAt: time.Now(), ID: "7f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}
+				query, queryArgs = q.page("created_at", "id", cursor, 20, 0)
+				if len(queryArgs) != len(args)+3 {
+					t.Errorf("Expected the cursor and limit after %d arguments, got %v", len(args), queryArgs)
+				}
+				checkPlaceholders(t, query, len(args)+3)
+			}
+		})
+	}
+}
+
+func TestListQuery(t *testing.T) {
+	active, inactive := true, false
+
+	t.Run("users", func(t *testing.T) {
+		testListQuery(t, userListQuery, "", []listFilter[UserListRequest]{
+			{"role", func(r *UserListRequest) { r.Role = "vendor" }, []interface{}{"vendor"}},
+			{"search", func(r *UserListRequest) { r.Search = "ali" }, []interface{}{"%ali%", "%ali%"}},
+			{"active", func(r *UserListRequest) { r.IsActive = &active }, []interface{}{true}},
+		})
+	})
+
+	t.Run("vendors", func(t *testing.T) {
+		testListQuery(t, vendorListQuery, "", []listFilter[VendorListRequest]{
+			{"search", func(r *VendorListRequest) { r.Search = "shop" }, []interface{}{"%shop%", "%shop%"}},
+			{"active", func(r *VendorListRequest) { r.IsActive = &inactive }, []interface{}{false}},
+			{"verified", func(r *VendorListRequest) { r.IsVerified = &active }, []interface{}{true}},
+		})
+	})
+
+	t.Run("plans", func(t *testing.T) {
+		testListQuery(t, planListQuery, "p.created_at DESC", []listFilter[PlanListRequest]{
+			{"active", func(r *PlanListRequest) { r.IsActive = &active }, []interface{}{true}},
+		})
+	})
+
+	t.Run("payments", func(t *testing.T) {
+		testListQuery(t, paymentListQuery, "", []listFilter[PaymentListRequest]{
+			{"status", func(r *PaymentListRequest) { r.Status = "completed" }, []interface{}{"completed"}},
+			{"user", func(r *PaymentListRequest) { r.UserID = "user-1" }, []interface{}{"user-1"}},
+			{"plan", func(r *PaymentListRequest) { r.PlanID = "plan-1" }, []interface{}{"plan-1"}},
+			{"from", func(r *PaymentListRequest) { r.DateFrom = "2024-01-01" }, []interface{}{"2024-01-01"}},
+			{"to", func(r *PaymentListRequest) { r.DateTo = "2024-02-01" }, []interface{}{"2024-02-01"}},
+		})
+	})
+
+	t.Run("conversions", func(t *testing.T) {
+		testListQuery(t, conversionListQuery, "", []listFilter[ConversionListRequest]{
+			{"status", func(r *ConversionListRequest) { r.Status = "failed" }, []interface{}{"failed"}},
+			{"user", func(r *ConversionListRequest) { r.UserID = "user-1" }, []interface{}{"user-1"}},
+			{"type", func(r *ConversionListRequest) { r.Type = "style" }, []interface{}{"style"}},
+			{"from", func(r *ConversionListRequest) { r.DateFrom = "2024-01-01" }, []interface{}{"2024-01-01"}},
+			{"to", func(r *ConversionListRequest) { r.DateTo = "2024-02-01" }, []interface{}{"2024-02-01"}},
+		})
+	})
+
+	t.Run("dead letters", func(t *testing.T) {
+		testListQuery(t, deadLetterListQuery, "c.dead_lettered_at DESC", []listFilter[DeadLetterListRequest]{
+			{"user", func(r *DeadLetterListRequest) { r.UserID = "user-1" }, []interface{}{"user-1"}},
+			{"status", func(r *DeadLetterListRequest) { r.StatusCode = 429 }, []interface{}{429}},
+		})
+	})
+
+	t.Run("images", func(t *testing.T) {
+		testListQuery(t, imageListQuery, "", []listFilter[ImageListRequest]{
+			{"vendor", func(r *ImageListRequest) { r.VendorID = "vendor-1" }, []interface{}{"vendor-1"}},
+			{"public", func(r *ImageListRequest) { r.IsPublic = &active }, []interface{}{true}},
+			{"free", func(r *ImageListRequest) { r.IsFree = &inactive }, []interface{}{false}},
+			{"from", func(r *ImageListRequest) { r.DateFrom = "2024-01-01" }, []interface{}{"2024-01-01"}},
+			{"to", func(r *ImageListRequest) { r.DateTo = "2024-02-01" }, []interface{}{"2024-02-01"}},
+		})
+	})
+
+	t.Run("audit logs", func(t *testing.T) {
+		build := func(req AuditLogListRequest) *listQuery { return auditLogQuery(req, "id, action") }
+		testListQuery(t, build, "", []listFilter[AuditLogListRequest]{
+			{"user", func(r *AuditLogListRequest) { r.UserID = "user-1" }, []interface{}{"user-1"}},
+			{"action", func(r *AuditLogListRequest) { r.Action = "login" }, []interface{}{"login"}},
+			{"resource", func(r *AuditLogListRequest) { r.Resource = "user" }, []interface{}{"user"}},
+			{"from", func(r *AuditLogListRequest) { r.DateFrom = "2024-01-01" }, []interface{}{"2024-01-01"}},
+			{"to", func(r *AuditLogListRequest) { r.DateTo = "2024-02-01" }, []interface{}{"2024-02-01"}},
+		})
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		testListQuery(t, apiKeyListQuery, "k.last_used_at DESC NULLS LAST, k.created_at DESC", []listFilter[APIKeyListRequest]{
+			{"user", func(r *APIKeyListRequest) { r.UserID = "user-1" }, []interface{}{"user-1"}},
+			{"active", func(r *APIKeyListRequest) { r.Active = &inactive }, nil},
+		})
+	})
+
+	t.Run("moderation", func(t *testing.T) {
+		testListQuery(t, moderationListQuery, "", []listFilter[ModerationListRequest]{
+			{"review", func(r *ModerationListRequest) { r.ReviewStatus = "pending" }, []interface{}{"pending"}},
+			{"allowed", func(r *ModerationListRequest) { r.Allowed = &inactive }, []interface{}{false}},
+			{"kind", func(r *ModerationListRequest) { r.ImageKind = "input" }, []interface{}{"input"}},
+		})
+	})
+}
+
+func TestListQueryPlanGroupsSubscribers(t *testing.T) {
+	q := planListQuery(PlanListRequest{IsActive: new(bool)})
+
+	query, _ := q.offsetPage("p.created_at DESC", 20, 0)
+	if !strings.Contains(query, " WHERE p.is_active = $1 GROUP BY p.id ORDER BY p.created_at DESC") {
+		t.Errorf("Expected the subscriber count grouped per plan, got %s", query)
+	}
+	if countQuery, _ := q.count(); countQuery != "SELECT COUNT(*) FROM payment_plans p WHERE p.is_active = $1" {
+		t.Errorf("Expected the plans to be counted without grouping, got %s", countQuery)
+	}
+}
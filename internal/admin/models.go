@@ -1,25 +1,14 @@
 package admin
 
 import (
+	"encoding/json"
 	"time"
+
+	"ai-styler/internal/domain"
 )
 
 // AdminUser represents a user from admin perspective
-type AdminUser struct {
-	ID                   string     `json:"id"`
-	Phone                string     `json:"phone"`
-	Name                 *string    `json:"name,omitempty"`
-	AvatarURL            *string    `json:"avatarUrl,omitempty"`
-	Bio                  *string    `json:"bio,omitempty"`
-	Role                 string     `json:"role"`
-	IsPhoneVerified      bool       `json:"isPhoneVerified"`
-	FreeConversionsUsed  int        `json:"freeConversionsUsed"`
-	FreeConversionsLimit int        `json:"freeConversionsLimit"`
-	CreatedAt            time.Time  `json:"createdAt"`
-	UpdatedAt            time.Time  `json:"updatedAt"`
-	LastLoginAt          *time.Time `json:"lastLoginAt,omitempty"`
-	IsActive             bool       `json:"isActive"`
-}
+type AdminUser = domain.User
 
 // AdminVendor represents a vendor from admin perspective
 type AdminVendor struct {
@@ -70,6 +59,7 @@ type AdminPlan struct {
 	MonthlyConversionsLimit int       `json:"monthlyConversionsLimit"`
 	Features                []string  `json:"features"`
 	IsActive                bool      `json:"isActive"`
+	TrialDays               int       `json:"trialDays"`
 	CreatedAt               time.Time `json:"createdAt"`
 	UpdatedAt               time.Time `json:"updatedAt"`
 	SubscriberCount         int       `json:"subscriberCount"`
@@ -112,6 +102,102 @@ type AdminConversion struct {
 	FileSizeBytes    *int64     `json:"fileSizeBytes,omitempty"`
 	CreatedAt        time.Time  `json:"createdAt"`
 	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+
+	// Most recent worker log entries, newest first (detail endpoint only)
+	Logs []ConversionLogEntry `json:"logs,omitempty"`
+}
+
+// BoostConversionRequest sets the queue priority of a pending conversion
+type BoostConversionRequest struct {
+	Priority int    `json:"priority" binding:"required,min=1,max=100"`
+	Reason   string `json:"reason"`
+}
+
+// ConversionBoost is the result of boosting a pending conversion's queue job
+type ConversionBoost struct {
+	ConversionID     string    `json:"conversionId"`
+	JobID            string    `json:"jobId"`
+	PreviousPriority int       `json:"previousPriority"`
+	Priority         int       `json:"priority"`
+	BoostedBy        string    `json:"boostedBy"`
+	BoostedAt        time.Time `json:"boostedAt"`
+}
+
+// DeadLetterListRequest represents the request to list dead-lettered conversions
+type DeadLetterListRequest struct {
+	Page       int    `json:"page" form:"page"`
+	PageSize   int    `json:"pageSize" form:"pageSize"`
+	UserID     string `json:"userId" form:"userId"`
+	StatusCode int    `json:"statusCode" form:"statusCode"` // provider status code, 0 for any
+	TraceID    string `json:"traceId" form:"traceId"`       // trace ID a user quoted to support
+}
+
+// DeadLetterConversion is a conversion the worker gave up on, with its failure diagnostics
+type DeadLetterConversion struct {
+	ID                 string    `json:"id"`
+	UserID             string    `json:"userId"`
+	UserPhone          string    `json:"userPhone"`
+	TraceID            string    `json:"traceId,omitempty"`
+	StyleName          *string   `json:"styleName,omitempty"`
+	ErrorMessage       *string   `json:"errorMessage,omitempty"`
+	FailureKind        *string   `json:"failureKind,omitempty"`
+	ProviderStatusCode *int      `json:"providerStatusCode,omitempty"`
+	ProviderResponse   *string   `json:"providerResponse,omitempty"`
+	ProviderAttempts   int       `json:"providerAttempts"` // failed provider calls in the conversion log
+	RetryCount         int       `json:"retryCount"`       // retries by the user
+	Requeues           int       `json:"requeues"`         // earlier requeues by admins
+	CreatedAt          time.Time `json:"createdAt"`
+	DeadLetteredAt     time.Time `json:"deadLetteredAt"`
+}
+
+// DeadLetterListResponse represents the response for dead-lettered conversion listing
+type DeadLetterListResponse struct {
+	Conversions []DeadLetterConversion `json:"conversions"`
+	Total       int                    `json:"total"`
+	Page        int                    `json:"page"`
+	PageSize    int                    `json:"pageSize"`
+	TotalPages  int                    `json:"totalPages"`
+}
+
+// RequeueConversionRequest pushes a dead-lettered conversion back to the worker
+type RequeueConversionRequest struct {
+	Priority int    `json:"priority" binding:"omitempty,min=1,max=100"` // 0 keeps the priority of the last job
+	Reason   string `json:"reason"`
+}
+
+// ConversionRequeue is the result of requeueing a dead-lettered conversion
+type ConversionRequeue struct {
+	ConversionID string    `json:"conversionId"`
+	JobID        string    `json:"jobId"`
+	Priority     int       `json:"priority"`
+	Requeues     int       `json:"requeues"`
+	RequeuedBy   string    `json:"requeuedBy"`
+	RequeuedAt   time.Time `json:"requeuedAt"`
+}
+
+// Conversion log limits of the conversion detail endpoint
+const (
+	DefaultConversionLogLimit = 50
+	MaxConversionLogLimit     = 200
+)
+
+// ConversionLogEntry represents a persisted worker log line of a conversion
+type ConversionLogEntry struct {
+	ID         int64                  `json:"id"`
+	JobID      *string                `json:"jobId,omitempty"`
+	WorkerID   *string                `json:"workerId,omitempty"`
+	Stage      string                 `json:"stage"`
+	Level      string                 `json:"level"`
+	Message    string                 `json:"message"`
+	StatusCode *int                   `json:"statusCode,omitempty"`
+	Attempt    *int                   `json:"attempt,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// ConversionDetailRequest represents the query of the conversion detail endpoint
+type ConversionDetailRequest struct {
+	Logs int `json:"logs" form:"logs"` // number of log entries, 0 for the default
 }
 
 // AdminImage represents a vendor image from admin perspective
@@ -165,9 +251,10 @@ type AdminStats struct {
 type UserListRequest struct {
 	Page     int    `json:"page" form:"page"`
 	PageSize int    `json:"pageSize" form:"pageSize"`
-	Role     string `json:"role" form:"role"`
+	Role     string `json:"role" form:"role" binding:"omitempty,oneof=user vendor admin"`
 	Search   string `json:"search" form:"search"`
 	IsActive *bool  `json:"isActive" form:"isActive"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // UserListResponse represents the response for user listing
@@ -177,6 +264,7 @@ type UserListResponse struct {
 	Page       int         `json:"page"`
 	PageSize   int         `json:"pageSize"`
 	TotalPages int         `json:"totalPages"`
+	NextCursor string      `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // VendorListRequest represents the request to list vendors
@@ -186,6 +274,7 @@ type VendorListRequest struct {
 	Search     string `json:"search" form:"search"`
 	IsActive   *bool  `json:"isActive" form:"isActive"`
 	IsVerified *bool  `json:"isVerified" form:"isVerified"`
+	Cursor     string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // VendorListResponse represents the response for vendor listing
@@ -195,6 +284,7 @@ type VendorListResponse struct {
 	Page       int           `json:"page"`
 	PageSize   int           `json:"pageSize"`
 	TotalPages int           `json:"totalPages"`
+	NextCursor string        `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // PlanListRequest represents the request to list plans
@@ -217,11 +307,12 @@ type PlanListResponse struct {
 type PaymentListRequest struct {
 	Page     int    `json:"page" form:"page"`
 	PageSize int    `json:"pageSize" form:"pageSize"`
-	Status   string `json:"status" form:"status"`
+	Status   string `json:"status" form:"status" binding:"omitempty,oneof=pending completed failed cancelled expired"`
 	UserID   string `json:"userId" form:"userId"`
 	PlanID   string `json:"planId" form:"planId"`
 	DateFrom string `json:"dateFrom" form:"dateFrom"`
 	DateTo   string `json:"dateTo" form:"dateTo"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // PaymentListResponse represents the response for payment listing
@@ -231,6 +322,7 @@ type PaymentListResponse struct {
 	Page       int            `json:"page"`
 	PageSize   int            `json:"pageSize"`
 	TotalPages int            `json:"totalPages"`
+	NextCursor string         `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // ConversionListRequest represents the request to list conversions
@@ -242,6 +334,7 @@ type ConversionListRequest struct {
 	Type     string `json:"type" form:"type"`
 	DateFrom string `json:"dateFrom" form:"dateFrom"`
 	DateTo   string `json:"dateTo" form:"dateTo"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // ConversionListResponse represents the response for conversion listing
@@ -251,6 +344,7 @@ type ConversionListResponse struct {
 	Page        int               `json:"page"`
 	PageSize    int               `json:"pageSize"`
 	TotalPages  int               `json:"totalPages"`
+	NextCursor  string            `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // ImageListRequest represents the request to list images
@@ -262,6 +356,7 @@ type ImageListRequest struct {
 	IsFree   *bool  `json:"isFree" form:"isFree"`
 	DateFrom string `json:"dateFrom" form:"dateFrom"`
 	DateTo   string `json:"dateTo" form:"dateTo"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // ImageListResponse represents the response for image listing
@@ -271,6 +366,7 @@ type ImageListResponse struct {
 	Page       int          `json:"page"`
 	PageSize   int          `json:"pageSize"`
 	TotalPages int          `json:"totalPages"`
+	NextCursor string       `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // AuditLogListRequest represents the request to list audit logs
@@ -282,6 +378,7 @@ type AuditLogListRequest struct {
 	Resource string `json:"resource" form:"resource"`
 	DateFrom string `json:"dateFrom" form:"dateFrom"`
 	DateTo   string `json:"dateTo" form:"dateTo"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // AuditLogListResponse represents the response for audit log listing
@@ -291,6 +388,157 @@ type AuditLogListResponse struct {
 	Page       int        `json:"page"`
 	PageSize   int        `json:"pageSize"`
 	TotalPages int        `json:"totalPages"`
+	NextCursor string     `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// AuditLogStreamRequest represents the request to stream audit logs for SIEM export.
+// Since is either a sequence cursor returned as nextCursor or an RFC3339 timestamp.
+type AuditLogStreamRequest struct {
+	Since string `json:"since" form:"since"`
+	Limit int    `json:"limit" form:"limit"`
+}
+
+// AuditLogStreamEntry represents a hash-chained audit log entry
+type AuditLogStreamEntry struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	UserID     *string         `json:"userId,omitempty"`
+	ActorType  string          `json:"actorType"`
+	Action     string          `json:"action"`
+	Resource   string          `json:"resource"`
+	ResourceID *string         `json:"resourceId,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	PrevHash   string          `json:"prevHash"`
+	EntryHash  string          `json:"entryHash"`
+
+	// HashedUserID is the user ID the entry was chained with; UserID is
+	// cleared when the user is deleted.
+	HashedUserID string `json:"hashedUserId,omitempty"`
+}
+
+// AuditLogStreamResponse represents a page of the audit log stream
+type AuditLogStreamResponse struct {
+	Entries       []AuditLogStreamEntry `json:"entries"`
+	NextCursor    string                `json:"nextCursor"`
+	HasMore       bool                  `json:"hasMore"`
+	ChainVerified bool                  `json:"chainVerified"`
+	BrokenAtSeq   *int64                `json:"brokenAtSeq,omitempty"`
+}
+
+// Audit log export formats
+const (
+	AuditExportFormatCSV   = "csv"
+	AuditExportFormatJSONL = "jsonl"
+)
+
+// AuditLogExportRequest represents the request to export audit logs. It
+// takes the list filters; pagination is ignored.
+type AuditLogExportRequest struct {
+	AuditLogListRequest
+	Format string `json:"format" form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+// AuditLogArchive records a batch of audit log entries moved to object storage
+type AuditLogArchive struct {
+	ID            string    `json:"id"`
+	FromSeq       int64     `json:"fromSeq"`
+	ToSeq         int64     `json:"toSeq"`
+	EntryCount    int       `json:"entryCount"`
+	LastEntryHash string    `json:"lastEntryHash"`
+	ObjectKey     string    `json:"objectKey"`
+	ArchivedAt    time.Time `json:"archivedAt"`
+}
+
+// OnboardingCohortRequest represents the request for the onboarding cohort report
+type OnboardingCohortRequest struct {
+	DateFrom       string `json:"dateFrom" form:"dateFrom" binding:"omitempty,datetime=2006-01-02"`
+	DateTo         string `json:"dateTo" form:"dateTo" binding:"omitempty,datetime=2006-01-02"`
+	ActivationDays int    `json:"activationDays" form:"activationDays"`
+}
+
+// OnboardingCohort compares activation of users who received the onboarding
+// grant at signup with those who did not, for one weekly signup cohort
+type OnboardingCohort struct {
+	CohortStart               time.Time `json:"cohortStart"`
+	Signups                   int       `json:"signups"`
+	GrantedSignups            int       `json:"grantedSignups"`
+	GrantedActivated          int       `json:"grantedActivated"`
+	GrantedActivationRate     float64   `json:"grantedActivationRate"`
+	ControlSignups            int       `json:"controlSignups"`
+	ControlActivated          int       `json:"controlActivated"`
+	ControlActivationRate     float64   `json:"controlActivationRate"`
+	FastLaneConversions       int       `json:"fastLaneConversions"`
+	AvgHoursToFirstConversion float64   `json:"avgHoursToFirstConversion"`
+}
+
+// OnboardingCohortResponse represents the onboarding cohort report
+type OnboardingCohortResponse struct {
+	Cohorts        []OnboardingCohort `json:"cohorts"`
+	ActivationDays int                `json:"activationDays"`
+	DateFrom       time.Time          `json:"dateFrom"`
+	DateTo         time.Time          `json:"dateTo"`
+}
+
+// Conversion cost groupings
+const (
+	CostGroupByUser     = "user"
+	CostGroupByPlan     = "plan"
+	CostGroupByProvider = "provider"
+)
+
+// ConversionCostRequest represents the request for the provider spend report
+type ConversionCostRequest struct {
+	DateFrom string `json:"dateFrom" form:"dateFrom" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"dateTo" form:"dateTo" binding:"omitempty,datetime=2006-01-02"`
+	GroupBy  string `json:"groupBy" form:"groupBy" binding:"omitempty,oneof=user plan provider"`
+}
+
+// ConversionCostGroup is the provider spend of one user, plan or provider
+type ConversionCostGroup struct {
+	Key          string  `json:"key"`
+	Conversions  int     `json:"conversions"`
+	PromptTokens int64   `json:"promptTokens"`
+	OutputTokens int64   `json:"outputTokens"`
+	Images       int64   `json:"images"`
+	Cost         float64 `json:"cost"`
+}
+
+// FeedbackStatsRequest represents the request for the conversion rating report
+type FeedbackStatsRequest struct {
+	DateFrom string `json:"dateFrom" form:"dateFrom" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"dateTo" form:"dateTo" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// FeedbackStatsGroup aggregates the ratings of one style and provider pair
+type FeedbackStatsGroup struct {
+	StyleName     string         `json:"styleName"`
+	Provider      string         `json:"provider"`
+	Ratings       int            `json:"ratings"`
+	AverageRating float64        `json:"averageRating"`
+	LowRatings    int            `json:"lowRatings"` // rated 1 or 2
+	Issues        map[string]int `json:"issues"`
+}
+
+// FeedbackStatsResponse represents the conversion rating report. Groups are
+// ordered worst rated first to point at the prompts most in need of tuning.
+type FeedbackStatsResponse struct {
+	Groups        []FeedbackStatsGroup `json:"groups"`
+	TotalRatings  int                  `json:"totalRatings"`
+	AverageRating float64              `json:"averageRating"`
+	Issues        map[string]int       `json:"issues"`
+	DateFrom      time.Time            `json:"dateFrom"`
+	DateTo        time.Time            `json:"dateTo"`
+}
+
+// ConversionCostResponse represents the provider spend report
+type ConversionCostResponse struct {
+	Groups           []ConversionCostGroup `json:"groups"`
+	GroupBy          string                `json:"groupBy"`
+	TotalCost        float64               `json:"totalCost"`
+	TotalConversions int                   `json:"totalConversions"`
+	DateFrom         time.Time             `json:"dateFrom"`
+	DateTo           time.Time             `json:"dateTo"`
 }
 
 // UpdateUserRequest represents the request to update a user
@@ -298,9 +546,9 @@ type UpdateUserRequest struct {
 	Name                 *string `json:"name,omitempty"`
 	AvatarURL            *string `json:"avatarUrl,omitempty"`
 	Bio                  *string `json:"bio,omitempty"`
-	Role                 *string `json:"role,omitempty"`
+	Role                 *string `json:"role,omitempty" binding:"omitempty,oneof=user vendor admin"`
 	IsPhoneVerified      *bool   `json:"isPhoneVerified,omitempty"`
-	FreeConversionsLimit *int    `json:"freeConversionsLimit,omitempty"`
+	FreeConversionsLimit *int    `json:"freeConversionsLimit,omitempty" binding:"omitempty,gte=0"`
 	IsActive             *bool   `json:"isActive,omitempty"`
 }
 
@@ -313,7 +561,7 @@ type UpdateVendorRequest struct {
 	SocialLinks     *SocialLinks `json:"socialLinks,omitempty"`
 	IsVerified      *bool        `json:"isVerified,omitempty"`
 	IsActive        *bool        `json:"isActive,omitempty"`
-	FreeImagesLimit *int         `json:"freeImagesLimit,omitempty"`
+	FreeImagesLimit *int         `json:"freeImagesLimit,omitempty" binding:"omitempty,gte=0"`
 }
 
 // CreatePlanRequest represents the request to create a plan
@@ -321,20 +569,22 @@ type CreatePlanRequest struct {
 	Name                    string   `json:"name" binding:"required"`
 	DisplayName             string   `json:"displayName" binding:"required"`
 	Description             string   `json:"description"`
-	PricePerMonthCents      int64    `json:"pricePerMonthCents" binding:"required"`
-	MonthlyConversionsLimit int      `json:"monthlyConversionsLimit" binding:"required"`
+	PricePerMonthCents      int64    `json:"pricePerMonthCents" binding:"required,gte=0"`
+	MonthlyConversionsLimit int      `json:"monthlyConversionsLimit" binding:"required,gte=0"`
 	Features                []string `json:"features"`
 	IsActive                bool     `json:"isActive"`
+	TrialDays               int      `json:"trialDays" binding:"gte=0,lte=90"` // 0 offers no trial
 }
 
 // UpdatePlanRequest represents the request to update a plan
 type UpdatePlanRequest struct {
 	DisplayName             *string  `json:"displayName,omitempty"`
 	Description             *string  `json:"description,omitempty"`
-	PricePerMonthCents      *int64   `json:"pricePerMonthCents,omitempty"`
-	MonthlyConversionsLimit *int     `json:"monthlyConversionsLimit,omitempty"`
+	PricePerMonthCents      *int64   `json:"pricePerMonthCents,omitempty" binding:"omitempty,gte=0"`
+	MonthlyConversionsLimit *int     `json:"monthlyConversionsLimit,omitempty" binding:"omitempty,gte=0"`
 	Features                []string `json:"features,omitempty"`
 	IsActive                *bool    `json:"isActive,omitempty"`
+	TrialDays               *int     `json:"trialDays,omitempty" binding:"omitempty,gte=0,lte=90"`
 }
 
 // RevokeQuotaRequest represents the request to revoke quota
@@ -351,6 +601,98 @@ type RevokePlanRequest struct {
 	Reason string `json:"reason" binding:"required"`
 }
 
+// ImpersonateUserRequest represents a request to act on behalf of a user
+type ImpersonateUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ImpersonationResponse carries the scoped access token issued for an impersonation
+type ImpersonationResponse struct {
+	AccessToken    string    `json:"accessToken"`
+	TokenType      string    `json:"tokenType"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	UserID         string    `json:"userId"`
+	ImpersonatorID string    `json:"impersonatorId"`
+}
+
+// ModerationVerdict represents a recorded image moderation verdict
+type ModerationVerdict struct {
+	ID           string     `json:"id"`
+	ConversionID *string    `json:"conversionId,omitempty"`
+	ImageID      *string    `json:"imageId,omitempty"`
+	ImageKind    string     `json:"imageKind"`
+	Provider     string     `json:"provider"`
+	Allowed      bool       `json:"allowed"`
+	NSFWScore    float64    `json:"nsfwScore"`
+	FaceCount    int        `json:"faceCount"`
+	Reasons      []string   `json:"reasons"`
+	ReviewStatus string     `json:"reviewStatus"`
+	ReviewedBy   *string    `json:"reviewedBy,omitempty"`
+	ReviewNote   *string    `json:"reviewNote,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// ModerationListRequest represents the request to list moderation verdicts
+type ModerationListRequest struct {
+	Page         int    `json:"page" form:"page"`
+	PageSize     int    `json:"pageSize" form:"pageSize"`
+	ReviewStatus string `json:"reviewStatus" form:"reviewStatus" binding:"omitempty,oneof=none pending confirmed overturned"`
+	Allowed      *bool  `json:"allowed" form:"allowed"`
+	ImageKind    string `json:"imageKind" form:"imageKind"`
+	Cursor       string `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
+}
+
+// ModerationListResponse represents the response for moderation verdict listing
+type ModerationListResponse struct {
+	Verdicts   []ModerationVerdict `json:"verdicts"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"pageSize"`
+	TotalPages int                 `json:"totalPages"`
+	NextCursor string              `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// AdminAPIKey represents an API key with its usage for admin management
+type AdminAPIKey struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"userId"`
+	UserPhone          string     `json:"userPhone"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rateLimitPerMinute"`
+	HasWebhook         bool       `json:"hasWebhook"`
+	RequestCount       int64      `json:"requestCount"`
+	LastUsedAt         *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt          *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// APIKeyListRequest represents the request to list API keys
+type APIKeyListRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+	UserID   string `json:"userId" form:"userId"`
+	Active   *bool  `json:"active" form:"active"`
+}
+
+// APIKeyListResponse represents the response for API key listing
+type APIKeyListResponse struct {
+	Keys       []AdminAPIKey `json:"keys"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"pageSize"`
+	TotalPages int           `json:"totalPages"`
+}
+
+// ReviewModerationRequest represents an admin decision on a moderation verdict
+type ReviewModerationRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=confirmed overturned"`
+	Note     string `json:"note"`
+}
+
 // Constants
 const (
 	// Actor types
@@ -367,6 +709,26 @@ const (
 	ActionActivate = "activate"
 	ActionVerify   = "verify"
 
+	// Impersonation actions
+	ActionImpersonate        = "impersonate"
+	ActionImpersonateRequest = "impersonated_request"
+
+	// Moderation actions
+	ActionReview = "review"
+
+	// Queue actions
+	ActionBoost   = "boost"
+	ActionRequeue = "requeue"
+
+	// Payment export action
+	ActionExport = "export"
+
+	// Moderation review statuses
+	ModerationReviewNone       = "none"
+	ModerationReviewPending    = "pending"
+	ModerationReviewConfirmed  = "confirmed"
+	ModerationReviewOverturned = "overturned"
+
 	// Resources
 	ResourceUser       = "user"
 	ResourceVendor     = "vendor"
@@ -375,6 +737,7 @@ const (
 	ResourceQuota      = "quota"
 	ResourceImage      = "image"
 	ResourceConversion = "conversion"
+	ResourceModeration = "moderation_verdict"
 )
 
 // Helper function for creating string pointers
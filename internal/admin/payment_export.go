@@ -0,0 +1,739 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Payment export formats
+const (
+	PaymentExportFormatCSV        = "csv"        // one row per payment
+	PaymentExportFormatAccounting = "accounting" // journal lines with daily totals per gateway
+)
+
+// Payment export statuses of background exports
+const (
+	PaymentExportStatusPending    = "pending"
+	PaymentExportStatusProcessing = "processing"
+	PaymentExportStatusReady      = "ready"
+	PaymentExportStatusFailed     = "failed"
+)
+
+// Payment export defaults
+const (
+	DefaultPaymentExportStreamMaxDays = 31
+	DefaultPaymentExportMaxDays       = 366
+	DefaultPaymentExportRetention     = 24 * time.Hour
+	DefaultPaymentExportPollInterval  = 10 * time.Second
+
+	// PaymentExportObjectPrefix is the object storage prefix of background exports
+	PaymentExportObjectPrefix = "payment-exports"
+
+	// paymentExportStaleAfter is how long an export may stay processing before
+	// another instance takes it over
+	paymentExportStaleAfter    = 30 * time.Minute
+	paymentExportFlushEvery    = 500
+	paymentExportDateLayout    = "2006-01-02"
+	paymentExportContentType   = "text/csv; charset=utf-8"
+	paymentExportDefaultStatus = "completed" // settled payments are what accounting reconciles
+)
+
+var (
+	// ErrPaymentExportNotFound is returned for exports that do not exist or have expired
+	ErrPaymentExportNotFound = fmt.Errorf("payment export %w", common.ErrNotFound)
+	// ErrPaymentExportsUnavailable is returned when payment exports are not configured
+	ErrPaymentExportsUnavailable = errors.New("payment exports are not available")
+)
+
+// paymentExportCSVHeader lists the columns of the csv format
+var paymentExportCSVHeader = []string{
+	"date", "paid_at", "payment_id", "user_id", "plan_id", "plan_name", "gateway",
+	"status", "track_id", "settlement_id", "amount", "currency",
+}
+
+// paymentExportAccountingHeader lists the columns of the accounting format
+var paymentExportAccountingHeader = []string{
+	"date", "entry", "gateway", "reference", "settlement_id", "description", "count", "amount", "currency",
+}
+
+// PaymentExportRequest represents the request to export payments. From and
+// To are days in the export timezone, both included.
+type PaymentExportRequest struct {
+	From    string `json:"from" form:"from" binding:"required"`
+	To      string `json:"to" form:"to" binding:"required"`
+	Gateway string `json:"gateway" form:"gateway"`
+	Status  string `json:"status" form:"status" binding:"omitempty,oneof=pending completed failed cancelled expired"`
+	Format  string `json:"format" form:"format" binding:"omitempty,oneof=csv accounting"`
+	// Async builds the export in the background even when it could be streamed
+	Async bool `json:"async" form:"async"`
+}
+
+// PaymentExportFilter selects the payments of an export by the time they
+// were paid, or created when unpaid, in [From, To)
+type PaymentExportFilter struct {
+	From    time.Time
+	To      time.Time
+	Gateway string
+	Status  string
+}
+
+// PaymentExportRow is one exported payment
+type PaymentExportRow struct {
+	ID           string
+	UserID       string
+	PlanID       string
+	PlanName     string
+	Amount       int64
+	Currency     string
+	Status       string
+	Gateway      string
+	TrackID      string
+	SettlementID string // the gateway's reference number of the settled payment
+	PaidAt       time.Time
+}
+
+// PaymentExport is a payment export built in the background for a range too
+// long to stream
+type PaymentExport struct {
+	ID            string     `json:"id"`
+	RequestedBy   string     `json:"requestedBy"`
+	Format        string     `json:"format"`
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Gateway       string     `json:"gateway,omitempty"`
+	PaymentStatus string     `json:"paymentStatus,omitempty"`
+	Status        string     `json:"status"`
+	ObjectKey     string     `json:"-"`
+	RowCount      int        `json:"rowCount"`
+	FileSize      int64      `json:"fileSize"`
+	Error         *string    `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+// PaymentExportStore reads exported payments and stores background exports
+type PaymentExportStore interface {
+	// ExportPayments calls fn for every payment matching filter, in the
+	// order they were paid
+	ExportPayments(ctx context.Context, filter PaymentExportFilter, fn func(PaymentExportRow) error) error
+	CreatePaymentExport(ctx context.Context, export PaymentExport) (PaymentExport, error)
+	GetPaymentExport(ctx context.Context, exportID string) (PaymentExport, error)
+	// ClaimPaymentExport marks the oldest pending export, or one processing
+	// for longer than staleAfter, as processing and returns it; nil when none
+	ClaimPaymentExport(ctx context.Context, staleAfter time.Duration) (*PaymentExport, error)
+	CompletePaymentExport(ctx context.Context, export PaymentExport) error
+	FailPaymentExport(ctx context.Context, exportID, message string) error
+}
+
+// PaymentExportStorage stores background exports, e.g. a storage.ObjectWriter
+// with its reader
+type PaymentExportStorage interface {
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// PaymentExportConfig configures payment exports
+type PaymentExportConfig struct {
+	Location      *time.Location // days of the export and its daily totals; UTC when nil
+	StreamMaxDays int            // longest range streamed as a direct download
+	MaxDays       int            // longest range that can be exported
+	Retention     time.Duration  // how long a background export can be downloaded
+	PollInterval  time.Duration  // how often the worker looks for pending exports
+}
+
+// paymentExports exports payments for accounting
+type paymentExports struct {
+	store   PaymentExportStore
+	storage PaymentExportStorage // nil streams only
+	config  PaymentExportConfig
+	wake    chan struct{}
+}
+
+// SetPaymentExports enables payment exports. Without storage only ranges up
+// to StreamMaxDays can be exported; with it longer ranges are built by
+// StartPaymentExportWorker.
+func (s *Service) SetPaymentExports(store PaymentExportStore, storage PaymentExportStorage, config PaymentExportConfig) {
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+	if config.MaxDays <= 0 {
+		config.MaxDays = DefaultPaymentExportMaxDays
+	}
+	if config.StreamMaxDays <= 0 {
+		config.StreamMaxDays = DefaultPaymentExportStreamMaxDays
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultPaymentExportRetention
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPaymentExportPollInterval
+	}
+	s.paymentExports = &paymentExports{
+		store:   store,
+		storage: storage,
+		config:  config,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// ExportPayments streams the payments of req to the writer start returns,
+// called once before anything is written, so errors before it can still be
+// answered properly. Ranges longer than StreamMaxDays, or any range with
+// req.Async, are queued instead and the queued export is returned.
+func (s *Service) ExportPayments(ctx context.Context, adminID string, req PaymentExportRequest, start func(filename string) io.Writer) (*PaymentExport, error) {
+	if s.paymentExports == nil {
+		return nil, ErrPaymentExportsUnavailable
+	}
+	e := s.paymentExports
+
+	if req.Format == "" {
+		req.Format = PaymentExportFormatCSV
+	}
+	if req.Status == "" {
+		req.Status = paymentExportDefaultStatus
+	}
+	filter, days, err := e.filter(req.From, req.To, req.Gateway, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Async || days > e.config.StreamMaxDays {
+		if e.storage == nil {
+			return nil, fmt.Errorf("%w: ranges over %d days need background exports, which are not available",
+				common.ErrValidation, e.config.StreamMaxDays)
+		}
+		export, err := e.store.CreatePaymentExport(ctx, PaymentExport{
+			RequestedBy:   adminID,
+			Format:        req.Format,
+			From:          req.From,
+			To:            req.To,
+			Gateway:       req.Gateway,
+			PaymentStatus: req.Status,
+		})
+		if err != nil {
+			return nil, err
+		}
+		_ = s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionExport, ResourcePayment, &export.ID, map[string]interface{}{
+			"from": req.From, "to": req.To, "gateway": req.Gateway, "format": req.Format,
+		})
+
+		// Wake the worker rather than waiting for the next poll
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+		return &export, nil
+	}
+
+	var w *paymentExportWriter
+	open := func() error {
+		if w == nil {
+			w = newPaymentExportWriter(start(paymentExportFilename(req)), req.Format, e.config.Location)
+			return w.header()
+		}
+		return nil
+	}
+	err = e.store.ExportPayments(ctx, filter, func(row PaymentExportRow) error {
+		if err := open(); err != nil {
+			return err
+		}
+		return w.write(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := open(); err != nil {
+		return nil, err
+	}
+	_ = s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionExport, ResourcePayment, nil, map[string]interface{}{
+		"from": req.From, "to": req.To, "gateway": req.Gateway, "format": req.Format, "rows": w.rows,
+	})
+	return nil, w.close()
+}
+
+// GetPaymentExport returns a background export
+func (s *Service) GetPaymentExport(ctx context.Context, exportID string) (PaymentExport, error) {
+	if s.paymentExports == nil {
+		return PaymentExport{}, ErrPaymentExportsUnavailable
+	}
+	return s.paymentExports.store.GetPaymentExport(ctx, exportID)
+}
+
+// OpenPaymentExport returns a ready background export with its file name
+func (s *Service) OpenPaymentExport(ctx context.Context, exportID string) ([]byte, string, error) {
+	if s.paymentExports == nil || s.paymentExports.storage == nil {
+		return nil, "", ErrPaymentExportsUnavailable
+	}
+	e := s.paymentExports
+
+	export, err := e.store.GetPaymentExport(ctx, exportID)
+	if err != nil {
+		return nil, "", err
+	}
+	if export.Status != PaymentExportStatusReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return nil, "", ErrPaymentExportNotFound
+	}
+	data, err := e.storage.ReadObject(ctx, export.ObjectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, paymentExportFilename(PaymentExportRequest{From: export.From, To: export.To, Format: export.Format}), nil
+}
+
+// StartPaymentExportWorker builds queued payment exports until ctx is cancelled
+func (s *Service) StartPaymentExportWorker(ctx context.Context) {
+	if s.paymentExports == nil || s.paymentExports.storage == nil {
+		return
+	}
+	e := s.paymentExports
+
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.processQueued(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// filter resolves an inclusive range of days to a filter and its length in days
+func (e *paymentExports) filter(from, to, gateway, status string) (PaymentExportFilter, int, error) {
+	fromDay, err := time.ParseInLocation(paymentExportDateLayout, from, e.config.Location)
+	if err != nil {
+		return PaymentExportFilter{}, 0, fmt.Errorf("%w: from must be a date like 2024-01-31", common.ErrValidation)
+	}
+	toDay, err := time.ParseInLocation(paymentExportDateLayout, to, e.config.Location)
+	if err != nil {
+		return PaymentExportFilter{}, 0, fmt.Errorf("%w: to must be a date like 2024-01-31", common.ErrValidation)
+	}
+	if toDay.Before(fromDay) {
+		return PaymentExportFilter{}, 0, fmt.Errorf("%w: to is before from", common.ErrValidation)
+	}
+
+	end := toDay.AddDate(0, 0, 1)
+	days := 0
+	for day := fromDay; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days++
+		if days > e.config.MaxDays {
+			return PaymentExportFilter{}, 0, fmt.Errorf("%w: ranges are limited to %d days", common.ErrValidation, e.config.MaxDays)
+		}
+	}
+	return PaymentExportFilter{From: fromDay, To: end, Gateway: gateway, Status: status}, days, nil
+}
+
+// processQueued builds exports until none is left to claim
+func (e *paymentExports) processQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := e.store.ClaimPaymentExport(ctx, paymentExportStaleAfter)
+		if err != nil {
+			log.Printf("Payment exports: failed to claim an export: %v", err)
+			return
+		}
+		if export == nil {
+			return
+		}
+
+		built, err := e.build(ctx, *export)
+		if err != nil {
+			log.Printf("Payment exports: export %s failed: %v", export.ID, err)
+			if err := e.store.FailPaymentExport(ctx, export.ID, "failed to build the export"); err != nil {
+				log.Printf("Payment exports: failed to record failure of %s: %v", export.ID, err)
+			}
+			continue
+		}
+		if err := e.store.CompletePaymentExport(ctx, built); err != nil {
+			log.Printf("Payment exports: failed to complete export %s: %v", export.ID, err)
+		}
+	}
+}
+
+// build writes the export file to object storage
+func (e *paymentExports) build(ctx context.Context, export PaymentExport) (PaymentExport, error) {
+	filter, _, err := e.filter(export.From, export.To, export.Gateway, export.PaymentStatus)
+	if err != nil {
+		return PaymentExport{}, err
+	}
+
+	var buf bytes.Buffer
+	w := newPaymentExportWriter(&buf, export.Format, e.config.Location)
+	if err := w.header(); err != nil {
+		return PaymentExport{}, err
+	}
+	if err := e.store.ExportPayments(ctx, filter, w.write); err != nil {
+		return PaymentExport{}, err
+	}
+	if err := w.close(); err != nil {
+		return PaymentExport{}, err
+	}
+
+	key := fmt.Sprintf("%s/%s.csv", PaymentExportObjectPrefix, export.ID)
+	if err := e.storage.WriteObject(ctx, key, buf.Bytes(), paymentExportContentType); err != nil {
+		return PaymentExport{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(e.config.Retention)
+	export.Status = PaymentExportStatusReady
+	export.ObjectKey = key
+	export.RowCount = w.rows
+	export.FileSize = int64(buf.Len())
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return export, nil
+}
+
+// paymentExportFilename names the downloaded file of an export
+func paymentExportFilename(req PaymentExportRequest) string {
+	return fmt.Sprintf("payments-%s-%s-%s.csv", req.Format, req.From, req.To)
+}
+
+// paymentTotalKey groups accounting totals
+type paymentTotalKey struct {
+	gateway  string
+	currency string
+}
+
+// paymentTotal sums the payments of a group
+type paymentTotal struct {
+	count  int
+	amount int64
+}
+
+// paymentExportWriter renders payments, given in the order they were paid,
+// as CSV. The accounting format follows each day with its totals per gateway
+// and ends with the totals of the whole range.
+type paymentExportWriter struct {
+	out     io.Writer
+	csv     *csv.Writer
+	format  string
+	loc     *time.Location
+	rows    int
+	day     string
+	daily   map[paymentTotalKey]*paymentTotal
+	overall map[paymentTotalKey]*paymentTotal
+}
+
+func newPaymentExportWriter(out io.Writer, format string, loc *time.Location) *paymentExportWriter {
+	return &paymentExportWriter{
+		out:     out,
+		csv:     csv.NewWriter(out),
+		format:  format,
+		loc:     loc,
+		daily:   make(map[paymentTotalKey]*paymentTotal),
+		overall: make(map[paymentTotalKey]*paymentTotal),
+	}
+}
+
+func (w *paymentExportWriter) header() error {
+	if w.format == PaymentExportFormatAccounting {
+		return w.csv.Write(paymentExportAccountingHeader)
+	}
+	return w.csv.Write(paymentExportCSVHeader)
+}
+
+func (w *paymentExportWriter) write(row PaymentExportRow) error {
+	paidAt := row.PaidAt.In(w.loc)
+	day := paidAt.Format(paymentExportDateLayout)
+	amount := strconv.FormatInt(row.Amount, 10)
+
+	var err error
+	if w.format == PaymentExportFormatAccounting {
+		if w.day != "" && day != w.day {
+			if err := w.writeTotals(w.day, "daily_total", w.daily); err != nil {
+				return err
+			}
+			w.daily = make(map[paymentTotalKey]*paymentTotal)
+		}
+		w.day = day
+		key := paymentTotalKey{gateway: row.Gateway, currency: row.Currency}
+		for _, totals := range []map[paymentTotalKey]*paymentTotal{w.daily, w.overall} {
+			if totals[key] == nil {
+				totals[key] = &paymentTotal{}
+			}
+			totals[key].count++
+			totals[key].amount += row.Amount
+		}
+		err = w.csv.Write([]string{
+			day, "payment", row.Gateway, row.ID, row.SettlementID, row.PlanName, "1", amount, row.Currency,
+		})
+	} else {
+		err = w.csv.Write([]string{
+			day, paidAt.Format(time.RFC3339), row.ID, row.UserID, row.PlanID, row.PlanName, row.Gateway,
+			row.Status, row.TrackID, row.SettlementID, amount, row.Currency,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%paymentExportFlushEvery == 0 {
+		w.flush()
+	}
+	return nil
+}
+
+// writeTotals writes one totals line per gateway and currency
+func (w *paymentExportWriter) writeTotals(day, entry string, totals map[paymentTotalKey]*paymentTotal) error {
+	keys := make([]paymentTotalKey, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].gateway != keys[j].gateway {
+			return keys[i].gateway < keys[j].gateway
+		}
+		return keys[i].currency < keys[j].currency
+	})
+
+	for _, key := range keys {
+		total := totals[key]
+		err := w.csv.Write([]string{
+			day, entry, key.gateway, "", "", "", strconv.Itoa(total.count), strconv.FormatInt(total.amount, 10), key.currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close writes the pending totals and flushes the output
+func (w *paymentExportWriter) close() error {
+	if w.format == PaymentExportFormatAccounting && w.day != "" {
+		if err := w.writeTotals(w.day, "daily_total", w.daily); err != nil {
+			return err
+		}
+		if err := w.writeTotals("", "total", w.overall); err != nil {
+			return err
+		}
+	}
+	w.flush()
+	return w.csv.Error()
+}
+
+func (w *paymentExportWriter) flush() {
+	w.csv.Flush()
+	if flusher, ok := w.out.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ExportPayments handles GET /admin/payments/export
+// It streams the payments of a range as CSV, or answers 202 with a queued
+// export for ranges too long to stream.
+func (h *Handler) ExportPayments(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req PaymentExportRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	started := false
+	export, err := h.service.ExportPayments(c.Request.Context(), fmt.Sprint(adminID), req, func(filename string) io.Writer {
+		started = true
+		c.Header("Content-Type", paymentExportContentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Status(http.StatusOK)
+		return c.Writer
+	})
+	switch {
+	case err != nil && started:
+		// The status is already sent; the client sees a truncated file
+		log.Printf("Payment export aborted: %v", err)
+	case err != nil:
+		common.RespondErr(c, paymentExportStatus(err), err)
+	case export != nil:
+		c.JSON(http.StatusAccepted, export)
+	}
+}
+
+// GetPaymentExport handles GET /admin/payments/exports/:id
+func (h *Handler) GetPaymentExport(c *gin.Context) {
+	export, err := h.service.GetPaymentExport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, paymentExportStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadPaymentExport handles GET /admin/payments/exports/:id/download
+func (h *Handler) DownloadPaymentExport(c *gin.Context) {
+	data, filename, err := h.service.OpenPaymentExport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, paymentExportStatus(err), err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, paymentExportContentType, data)
+}
+
+// paymentExportStatus is the fallback status of payment export errors
+func paymentExportStatus(err error) int {
+	if errors.Is(err, ErrPaymentExportsUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// paymentExportQuery selects the payments of an export in the order they were paid
+func paymentExportQuery(filter PaymentExportFilter) *listQuery {
+	q := newListQuery(`
+			p.id, p.user_id, p.plan_id, COALESCE(pp.name, ''), p.amount, p.currency, p.status,
+			p.gateway, COALESCE(p.gateway_track_id, ''), COALESCE(p.gateway_ref_number, ''),
+			COALESCE(p.paid_at, p.created_at)`,
+		"payments p").
+		join("LEFT JOIN payment_plans pp ON p.plan_id = pp.id").
+		filter("COALESCE(p.paid_at, p.created_at) >= $%d", filter.From).
+		filter("COALESCE(p.paid_at, p.created_at) < $%d", filter.To)
+
+	if filter.Gateway != "" {
+		q.filter("p.gateway = $%d", filter.Gateway)
+	}
+	if filter.Status != "" {
+		q.filter("p.status = $%d", filter.Status)
+	}
+	return q
+}
+
+// ExportPayments calls fn for every payment matching filter without loading
+// them all into memory
+func (s *DBStore) ExportPayments(ctx context.Context, filter PaymentExportFilter, fn func(PaymentExportRow) error) error {
+	query, args := paymentExportQuery(filter).all("COALESCE(p.paid_at, p.created_at), p.id")
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row PaymentExportRow
+		err := rows.Scan(
+			&row.ID, &row.UserID, &row.PlanID, &row.PlanName, &row.Amount, &row.Currency, &row.Status,
+			&row.Gateway, &row.TrackID, &row.SettlementID, &row.PaidAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan payment: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating payments: %w", err)
+	}
+	return nil
+}
+
+const paymentExportColumns = `id, COALESCE(requested_by::text, ''), format, from_date::text, to_date::text, gateway, payment_status,
+	status, COALESCE(object_key, ''), row_count, file_size, error_message, created_at, completed_at, expires_at`
+
+// scanPaymentExport scans a row of paymentExportColumns
+func scanPaymentExport(row interface{ Scan(...interface{}) error }) (PaymentExport, error) {
+	var export PaymentExport
+	err := row.Scan(&export.ID, &export.RequestedBy, &export.Format, &export.From, &export.To, &export.Gateway,
+		&export.PaymentStatus, &export.Status, &export.ObjectKey, &export.RowCount, &export.FileSize, &export.Error,
+		&export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	return export, err
+}
+
+func (s *DBStore) CreatePaymentExport(ctx context.Context, export PaymentExport) (PaymentExport, error) {
+	created, err := scanPaymentExport(s.writer(ctx).QueryRowContext(ctx, `
+		INSERT INTO payment_exports (requested_by, format, from_date, to_date, gateway, payment_status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+paymentExportColumns,
+		export.RequestedBy, export.Format, export.From, export.To, export.Gateway, export.PaymentStatus))
+	if err != nil {
+		return PaymentExport{}, fmt.Errorf("failed to create payment export: %w", err)
+	}
+	return created, nil
+}
+
+func (s *DBStore) GetPaymentExport(ctx context.Context, exportID string) (PaymentExport, error) {
+	export, err := scanPaymentExport(s.writer(ctx).QueryRowContext(ctx, `
+		SELECT `+paymentExportColumns+`
+		FROM payment_exports
+		WHERE id::text = $1`, exportID))
+	switch {
+	case err == sql.ErrNoRows:
+		return PaymentExport{}, ErrPaymentExportNotFound
+	case err != nil:
+		return PaymentExport{}, fmt.Errorf("failed to get payment export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *DBStore) ClaimPaymentExport(ctx context.Context, staleAfter time.Duration) (*PaymentExport, error) {
+	export, err := scanPaymentExport(s.writer(ctx).QueryRowContext(ctx, `
+		UPDATE payment_exports
+		SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM payment_exports
+			WHERE status = 'pending'
+			   OR (status = 'processing' AND started_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+paymentExportColumns, staleAfter.Seconds()))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim payment export: %w", err)
+	}
+	return &export, nil
+}
+
+func (s *DBStore) CompletePaymentExport(ctx context.Context, export PaymentExport) error {
+	_, err := s.writer(ctx).ExecContext(ctx, `
+		UPDATE payment_exports
+		SET status = 'ready', object_key = $2, row_count = $3, file_size = $4,
+		    completed_at = $5, expires_at = $6, error_message = NULL
+		WHERE id = $1`,
+		export.ID, export.ObjectKey, export.RowCount, export.FileSize, export.CompletedAt, export.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete payment export: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) FailPaymentExport(ctx context.Context, exportID, message string) error {
+	_, err := s.writer(ctx).ExecContext(ctx, `
+		UPDATE payment_exports
+		SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1`, exportID, message)
+	if err != nil {
+		return fmt.Errorf("failed to record payment export failure: %w", err)
+	}
+	return nil
+}
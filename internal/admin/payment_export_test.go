@@ -0,0 +1,266 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryPaymentExportStore keeps payments and background exports in memory
+type memoryPaymentExportStore struct {
+	payments []PaymentExportRow // in the order they were paid
+	exports  []PaymentExport
+}
+
+func (m *memoryPaymentExportStore) ExportPayments(ctx context.Context, filter PaymentExportFilter, fn func(PaymentExportRow) error) error {
+	for _, row := range m.payments {
+		if row.PaidAt.Before(filter.From) || !row.PaidAt.Before(filter.To) {
+			continue
+		}
+		if (filter.Gateway != "" && row.Gateway != filter.Gateway) || (filter.Status != "" && row.Status != filter.Status) {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryPaymentExportStore) CreatePaymentExport(ctx context.Context, export PaymentExport) (PaymentExport, error) {
+	export.ID = fmt.Sprintf("export%d", len(m.exports)+1)
+	export.Status = PaymentExportStatusPending
+	m.exports = append(m.exports, export)
+	return export, nil
+}
+
+func (m *memoryPaymentExportStore) GetPaymentExport(ctx context.Context, exportID string) (PaymentExport, error) {
+	for _, export := range m.exports {
+		if export.ID == exportID {
+			return export, nil
+		}
+	}
+	return PaymentExport{}, ErrPaymentExportNotFound
+}
+
+func (m *memoryPaymentExportStore) ClaimPaymentExport(ctx context.Context, staleAfter time.Duration) (*PaymentExport, error) {
+	for i := range m.exports {
+		if m.exports[i].Status == PaymentExportStatusPending {
+			m.exports[i].Status = PaymentExportStatusProcessing
+			export := m.exports[i]
+			return &export, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryPaymentExportStore) CompletePaymentExport(ctx context.Context, export PaymentExport) error {
+	for i := range m.exports {
+		if m.exports[i].ID == export.ID {
+			m.exports[i] = export
+		}
+	}
+	return nil
+}
+
+func (m *memoryPaymentExportStore) FailPaymentExport(ctx context.Context, exportID, message string) error {
+	for i := range m.exports {
+		if m.exports[i].ID == exportID {
+			m.exports[i].Status = PaymentExportStatusFailed
+			m.exports[i].Error = &message
+		}
+	}
+	return nil
+}
+
+// memoryObjectStorage keeps written objects in memory
+type memoryObjectStorage struct {
+	objects map[string][]byte
+}
+
+func (m *memoryObjectStorage) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	return data, nil
+}
+
+func (m *memoryObjectStorage) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	m.objects[key] = data
+	return nil
+}
+
+// newPaymentExportTestRouter mounts the payment export routes of a service
+// exporting Tehran days
+func newPaymentExportTestRouter(t *testing.T, storage PaymentExportStorage) (*gin.Engine, *Service, *memoryPaymentExportStore) {
+	t.Helper()
+	tehran := time.FixedZone("IRST", 3*60*60+30*60)
+	paid := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 0, 0, 0, tehran) }
+	store := &memoryPaymentExportStore{payments: []PaymentExportRow{
+		{ID: "p1", UserID: "u1", PlanName: "basic", Amount: 1000, Currency: "IRR", Status: "completed", Gateway: "zibal", SettlementID: "r1", PaidAt: paid(1, 9)},
+		{ID: "p2", UserID: "u2", PlanName: "pro", Amount: 5000, Currency: "IRR", Status: "completed", Gateway: "zarinpal", SettlementID: "r2", PaidAt: paid(1, 10)},
+		{ID: "p3", UserID: "u1", PlanName: "basic", Amount: 1000, Currency: "IRR", Status: "completed", Gateway: "zibal", SettlementID: "r3", PaidAt: paid(1, 23)},
+		{ID: "p4", UserID: "u3", PlanName: "basic", Amount: 1000, Currency: "IRR", Status: "failed", Gateway: "zibal", PaidAt: paid(2, 1)},
+		{ID: "p5", UserID: "u3", PlanName: "basic", Amount: 1000, Currency: "IRR", Status: "completed", Gateway: "zibal", SettlementID: "r5", PaidAt: paid(2, 2)},
+	}}
+
+	service, handler := WireAdminServiceWithMocks(NewMockStore())
+	service.SetPaymentExports(store, storage, PaymentExportConfig{Location: tehran, StreamMaxDays: 7, MaxDays: 90})
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_user_id", "admin1")
+		c.Next()
+	})
+	router.GET("/admin/payments/export", handler.ExportPayments)
+	router.GET("/admin/payments/exports/:id", handler.GetPaymentExport)
+	router.GET("/admin/payments/exports/:id/download", handler.DownloadPaymentExport)
+	return router, service, store
+}
+
+func serve(router *gin.Engine, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_ExportPaymentsStreams(t *testing.T) {
+	router, _, _ := newPaymentExportTestRouter(t, nil)
+
+	// Completed payments by default, with Tehran dates
+	w := serve(router, "/admin/payments/export?from=2024-03-01&to=2024-03-02")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV download, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "payments-csv-2024-03-01-2024-03-02.csv") {
+		t.Errorf("Expected the range in the file name, got %s", w.Header().Get("Content-Disposition"))
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "date,paid_at,payment_id") ||
+		lines[3] != "2024-03-01,2024-03-01T23:00:00+03:30,p3,u1,,basic,zibal,completed,,r3,1000,IRR" {
+		t.Errorf("Expected header and 4 completed payments, got %q", w.Body.String())
+	}
+
+	// The accounting format closes each day with its totals per gateway
+	w = serve(router, "/admin/payments/export?from=2024-03-01&to=2024-03-02&format=accounting&gateway=zibal")
+	want := []string{
+		"date,entry,gateway,reference,settlement_id,description,count,amount,currency",
+		"2024-03-01,payment,zibal,p1,r1,basic,1,1000,IRR",
+		"2024-03-01,payment,zibal,p3,r3,basic,1,1000,IRR",
+		"2024-03-01,daily_total,zibal,,,,2,2000,IRR",
+		"2024-03-02,payment,zibal,p5,r5,basic,1,1000,IRR",
+		"2024-03-02,daily_total,zibal,,,,1,1000,IRR",
+		",total,zibal,,,,3,3000,IRR",
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != strings.Join(want, "\n") {
+		t.Errorf("Expected accounting lines\n%s\ngot\n%s", strings.Join(want, "\n"), got)
+	}
+
+	// An empty range still downloads the header
+	w = serve(router, "/admin/payments/export?from=2024-04-01&to=2024-04-01&format=accounting")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != strings.Join(paymentExportAccountingHeader, ",") {
+		t.Errorf("Expected only the header, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_ExportPaymentsRejects(t *testing.T) {
+	router, _, _ := newPaymentExportTestRouter(t, nil)
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"missing range", "", http.StatusBadRequest},
+		{"malformed date", "from=2024-03-01&to=03/02/2024", http.StatusBadRequest},
+		{"reversed range", "from=2024-03-02&to=2024-03-01", http.StatusBadRequest},
+		{"unknown format", "from=2024-03-01&to=2024-03-02&format=xlsx", http.StatusBadRequest},
+		{"range over the maximum", "from=2024-01-01&to=2024-06-01", http.StatusBadRequest},
+		{"long range without storage", "from=2024-03-01&to=2024-03-31", http.StatusBadRequest},
+		{"background export without storage", "from=2024-03-01&to=2024-03-02&async=true", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(router, "/admin/payments/export?"+tt.query); w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ExportPaymentsInBackground(t *testing.T) {
+	storage := &memoryObjectStorage{objects: map[string][]byte{}}
+	router, service, store := newPaymentExportTestRouter(t, storage)
+
+	w := serve(router, "/admin/payments/export?from=2024-02-15&to=2024-03-31&format=accounting")
+	var export PaymentExport
+	if w.Code != http.StatusAccepted || json.Unmarshal(w.Body.Bytes(), &export) != nil || export.Status != PaymentExportStatusPending {
+		t.Fatalf("Expected a queued export, got %d %s", w.Code, w.Body.String())
+	}
+	if export.RequestedBy != "admin1" || export.PaymentStatus != "completed" {
+		t.Errorf("Expected the admin's export of completed payments, got %+v", export)
+	}
+
+	if w := serve(router, "/admin/payments/exports/"+export.ID+"/download"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before the export is ready, got %d", w.Code)
+	}
+
+	service.paymentExports.processQueued(context.Background())
+
+	w = serve(router, "/admin/payments/exports/"+export.ID)
+	if json.Unmarshal(w.Body.Bytes(), &export) != nil || export.Status != PaymentExportStatusReady || export.RowCount != 4 {
+		t.Fatalf("Expected a ready export of 4 payments, got %s", w.Body.String())
+	}
+	if stored := store.exports[0]; stored.ObjectKey != PaymentExportObjectPrefix+"/"+export.ID+".csv" {
+		t.Errorf("Expected the export under %s/, got %q", PaymentExportObjectPrefix, stored.ObjectKey)
+	}
+
+	w = serve(router, "/admin/payments/exports/"+export.ID+"/download")
+	if w.Code != http.StatusOK || !strings.HasSuffix(strings.TrimSpace(w.Body.String()), ",total,zibal,,,,3,3000,IRR") {
+		t.Errorf("Expected the accounting file, got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := serve(router, "/admin/payments/exports/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown export, got %d", w.Code)
+	}
+}
+
+func TestHandler_ExportPaymentsUnavailable(t *testing.T) {
+	_, handler := WireAdminServiceWithMocks(NewMockStore())
+	router := setupTestRouter()
+	router.GET("/admin/payments/export", func(c *gin.Context) {
+		c.Set("admin_user_id", "admin1")
+		handler.ExportPayments(c)
+	})
+
+	w := serve(router, "/admin/payments/export?from=2024-03-01&to=2024-03-02")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestPaymentExportQuery(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	query, args := paymentExportQuery(PaymentExportFilter{From: from, To: from.AddDate(0, 0, 1), Gateway: "zibal", Status: "completed"}).
+		all("COALESCE(p.paid_at, p.created_at), p.id")
+
+	checkPlaceholders(t, query, 4)
+	if len(args) != 4 || args[2] != "zibal" || args[3] != "completed" {
+		t.Errorf("Expected range, gateway and status arguments, got %v", args)
+	}
+	if !errors.Is(ErrPaymentExportNotFound, common.ErrNotFound) {
+		t.Error("Expected ErrPaymentExportNotFound to be a not found error")
+	}
+}
@@ -0,0 +1,498 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission grants access to a group of admin endpoints
+type Permission string
+
+// Admin permissions
+const (
+	PermAll Permission = "*" // every permission, held by super_admin
+
+	PermUsersRead        Permission = "users:read"
+	PermUsersWrite       Permission = "users:write"
+	PermUsersImpersonate Permission = "users:impersonate"
+	PermVendorsRead      Permission = "vendors:read"
+	PermVendorsWrite     Permission = "vendors:write"
+	PermPlansRead        Permission = "plans:read"
+	PermPlansWrite       Permission = "plans:write"
+	PermPaymentsRead     Permission = "payments:read"
+	PermPaymentsWrite    Permission = "payments:write"
+	PermPaymentsRefund   Permission = "payments:refund"
+	PermConversionsRead  Permission = "conversions:read"
+	PermConversionsWrite Permission = "conversions:write"
+	PermImagesRead       Permission = "images:read"
+	PermModerationReview Permission = "moderation:review"
+	PermSupportRead      Permission = "support:read"
+	PermSupportWrite     Permission = "support:write"
+	PermAuditRead        Permission = "audit:read"
+	PermAPIKeysRead      Permission = "api_keys:read"
+	PermStatsRead        Permission = "stats:read"
+	PermSettingsRead     Permission = "settings:read"
+	PermSettingsWrite    Permission = "settings:write"
+	PermSystemRead       Permission = "system:read"
+	PermSystemWrite      Permission = "system:write"
+	PermRolesRead        Permission = "roles:read"
+	PermRolesWrite       Permission = "roles:write"
+)
+
+// Built-in roles, seeded by the admin roles migration
+const (
+	RoleSuperAdmin = "super_admin"
+	RoleSupport    = "support"
+	RoleFinance    = "finance"
+)
+
+// Permissions lists every permission a role can hold, with a description
+var Permissions = []PermissionInfo{
+	{PermUsersRead, "View users"},
+	{PermUsersWrite, "Update, suspend, delete users and revoke their quota or plan"},
+	{PermUsersImpersonate, "Sign in as a user"},
+	{PermVendorsRead, "View vendors and their verification documents"},
+	{PermVendorsWrite, "Update, suspend, verify, delete vendors, review verifications and revoke their quota"},
+	{PermPlansRead, "View plans"},
+	{PermPlansWrite, "Create, update and delete plans"},
+	{PermPaymentsRead, "View payments and coupons"},
+	{PermPaymentsWrite, "Create, update and delete coupons"},
+	{PermPaymentsRefund, "Refund payments"},
+	{PermConversionsRead, "View conversions and the dead-letter queue"},
+	{PermConversionsWrite, "Boost and requeue conversions"},
+	{PermImagesRead, "View images"},
+	{PermModerationReview, "Review moderation verdicts"},
+	{PermSupportRead, "View support tickets"},
+	{PermSupportWrite, "Reply to support tickets and change their status"},
+	{PermAuditRead, "View, stream and export the audit trail"},
+	{PermAPIKeysRead, "View API key usage"},
+	{PermStatsRead, "View statistics and reports"},
+	{PermSettingsRead, "View system settings, IP blocks and notification templates"},
+	{PermSettingsWrite, "Change system settings, IP blocks and notification templates"},
+	{PermSystemRead, "View worker, SMS delivery, backup and database query status"},
+	{PermSystemWrite, "Run and restore backups and reset database query metrics"},
+	{PermRolesRead, "View roles and role assignments"},
+	{PermRolesWrite, "Manage roles and assign them to admins"},
+}
+
+// DefaultPermissionCacheTTL is how long an admin's permissions are cached
+const DefaultPermissionCacheTTL = 30 * time.Second
+
+// Role names are lowercase identifiers
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// Admin role audit resources and actions
+const (
+	ResourceAdminRole = "admin_role"
+	ActionAssignRole  = "assign_role"
+	ActionRemoveRole  = "remove_role"
+)
+
+// PermissionInfo describes a permission
+type PermissionInfo struct {
+	Permission  Permission `json:"permission"`
+	Description string     `json:"description"`
+}
+
+// AdminRole is a named set of permissions
+type AdminRole struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"builtIn"`
+	Admins      int          `json:"admins"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// AdminRoleAssignment grants a role to an admin
+type AdminRoleAssignment struct {
+	UserID    string    `json:"userId"`
+	Role      string    `json:"role"`
+	GrantedBy *string   `json:"grantedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AdminAccess is the effective access of an admin
+type AdminAccess struct {
+	UserID      string                `json:"userId"`
+	Roles       []AdminRoleAssignment `json:"roles"`
+	DefaultRole string                `json:"defaultRole,omitempty"` // applied because no role is assigned
+	Permissions []Permission          `json:"permissions"`
+}
+
+// Allows reports whether the access includes perm
+func (a AdminAccess) Allows(perm Permission) bool {
+	for _, p := range a.Permissions {
+		if p == perm || p == PermAll {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateRoleRequest represents a request to create a custom role
+type CreateRoleRequest struct {
+	Name        string       `json:"name" binding:"required"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest represents a request to update a custom role
+type UpdateRoleRequest struct {
+	Description *string      `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// AssignRoleRequest represents a request to assign a role to an admin
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleStore persists admin roles and their assignments
+type RoleStore interface {
+	ListAdminRoles(ctx context.Context) ([]AdminRole, error)
+	GetAdminRole(ctx context.Context, name string) (AdminRole, error)
+	CreateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error)
+	UpdateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error)
+	DeleteAdminRole(ctx context.Context, name string) error
+	GetAdminRoleAssignments(ctx context.Context, userID string) ([]AdminRoleAssignment, error)
+	AssignAdminRole(ctx context.Context, userID, role, grantedBy string) (AdminRoleAssignment, error)
+	RemoveAdminRole(ctx context.Context, userID, role string) error
+	CountAdminRoleAssignments(ctx context.Context, role string) (int, error)
+}
+
+// RBACConfig configures admin permission checks
+type RBACConfig struct {
+	// DefaultRole applies to admins without an assigned role; empty grants them nothing
+	DefaultRole string
+	CacheTTL    time.Duration
+}
+
+// cachedAccess is an admin's access as of loadedAt
+type cachedAccess struct {
+	access   AdminAccess
+	loadedAt time.Time
+}
+
+// rbac checks admin permissions against the role store
+type rbac struct {
+	store  RoleStore
+	config RBACConfig
+
+	mu    sync.Mutex
+	cache map[string]cachedAccess
+}
+
+// SetRoles enables role-based permission checks on admin routes. Without it
+// every admin holds every permission.
+func (s *Service) SetRoles(store RoleStore, config RBACConfig) {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultPermissionCacheTTL
+	}
+	s.rbac = &rbac{store: store, config: config, cache: make(map[string]cachedAccess)}
+}
+
+// GetAdminAccess returns the roles and permissions of an admin, cached for
+// the configured TTL
+func (s *Service) GetAdminAccess(ctx context.Context, adminID string) (AdminAccess, error) {
+	if s.rbac == nil {
+		return AdminAccess{UserID: adminID, Roles: []AdminRoleAssignment{}, Permissions: []Permission{PermAll}}, nil
+	}
+
+	r := s.rbac
+	r.mu.Lock()
+	cached, ok := r.cache[adminID]
+	r.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < r.config.CacheTTL {
+		return cached.access, nil
+	}
+
+	access, err := s.loadAdminAccess(ctx, adminID)
+	if err != nil {
+		return AdminAccess{}, err
+	}
+
+	r.mu.Lock()
+	r.cache[adminID] = cachedAccess{access: access, loadedAt: time.Now()}
+	r.mu.Unlock()
+	return access, nil
+}
+
+// loadAdminAccess merges the permissions of every role assigned to the admin
+func (s *Service) loadAdminAccess(ctx context.Context, adminID string) (AdminAccess, error) {
+	assignments, err := s.rbac.store.GetAdminRoleAssignments(ctx, adminID)
+	if err != nil {
+		return AdminAccess{}, fmt.Errorf("failed to load admin roles: %w", err)
+	}
+
+	access := AdminAccess{UserID: adminID, Roles: assignments, Permissions: []Permission{}}
+	names := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		names = append(names, assignment.Role)
+	}
+	if len(names) == 0 && s.rbac.config.DefaultRole != "" {
+		access.DefaultRole = s.rbac.config.DefaultRole
+		names = append(names, access.DefaultRole)
+	}
+
+	seen := make(map[Permission]bool)
+	for _, name := range names {
+		role, err := s.rbac.store.GetAdminRole(ctx, name)
+		if err != nil {
+			return AdminAccess{}, fmt.Errorf("failed to load admin role %s: %w", name, err)
+		}
+		for _, perm := range role.Permissions {
+			if !seen[perm] {
+				seen[perm] = true
+				access.Permissions = append(access.Permissions, perm)
+			}
+		}
+	}
+	sort.Slice(access.Permissions, func(i, j int) bool { return access.Permissions[i] < access.Permissions[j] })
+	return access, nil
+}
+
+// forget drops the cached access of an admin after their roles change
+func (r *rbac) forget(adminID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if adminID == "" {
+		r.cache = make(map[string]cachedAccess)
+		return
+	}
+	delete(r.cache, adminID)
+}
+
+// roles returns the role store, or an error when roles are not enabled
+func (s *Service) roles() (RoleStore, error) {
+	if s.rbac == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "roles_disabled", "admin roles are not enabled", nil)
+	}
+	return s.rbac.store, nil
+}
+
+// ListRoles lists built-in and custom roles
+func (s *Service) ListRoles(ctx context.Context) ([]AdminRole, error) {
+	store, err := s.roles()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListAdminRoles(ctx)
+}
+
+// CreateRole creates a custom role
+func (s *Service) CreateRole(ctx context.Context, adminID string, req CreateRoleRequest) (AdminRole, error) {
+	store, err := s.roles()
+	if err != nil {
+		return AdminRole{}, err
+	}
+	if !roleNamePattern.MatchString(req.Name) {
+		return AdminRole{}, fmt.Errorf("%w: role name must be 2-50 lowercase letters, digits or underscores", common.ErrValidation)
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return AdminRole{}, err
+	}
+
+	role, err := store.CreateAdminRole(ctx, AdminRole{Name: req.Name, Description: req.Description, Permissions: req.Permissions})
+	if err != nil {
+		return AdminRole{}, err
+	}
+
+	s.logRoleAction(ctx, adminID, ActionCreate, role.Name, map[string]interface{}{"permissions": role.Permissions})
+	return role, nil
+}
+
+// UpdateRole changes the description or permissions of a custom role
+func (s *Service) UpdateRole(ctx context.Context, adminID, name string, req UpdateRoleRequest) (AdminRole, error) {
+	store, err := s.roles()
+	if err != nil {
+		return AdminRole{}, err
+	}
+
+	role, err := store.GetAdminRole(ctx, name)
+	if err != nil {
+		return AdminRole{}, err
+	}
+	if role.BuiltIn {
+		return AdminRole{}, fmt.Errorf("%w: built-in roles cannot be changed", common.ErrConflict)
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if err := validatePermissions(req.Permissions); err != nil {
+			return AdminRole{}, err
+		}
+		role.Permissions = req.Permissions
+	}
+
+	updated, err := store.UpdateAdminRole(ctx, role)
+	if err != nil {
+		return AdminRole{}, err
+	}
+	s.rbac.forget("")
+
+	s.logRoleAction(ctx, adminID, ActionUpdate, name, map[string]interface{}{"permissions": updated.Permissions})
+	return updated, nil
+}
+
+// DeleteRole deletes a custom role and its assignments
+func (s *Service) DeleteRole(ctx context.Context, adminID, name string) error {
+	store, err := s.roles()
+	if err != nil {
+		return err
+	}
+
+	role, err := store.GetAdminRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return fmt.Errorf("%w: built-in roles cannot be deleted", common.ErrConflict)
+	}
+	if name == s.rbac.config.DefaultRole {
+		return fmt.Errorf("%w: the default admin role cannot be deleted", common.ErrConflict)
+	}
+
+	if err := store.DeleteAdminRole(ctx, name); err != nil {
+		return err
+	}
+	s.rbac.forget("")
+
+	s.logRoleAction(ctx, adminID, ActionDelete, name, nil)
+	return nil
+}
+
+// AssignRole assigns a role to an admin user
+func (s *Service) AssignRole(ctx context.Context, adminID, userID string, req AssignRoleRequest) (AdminAccess, error) {
+	store, err := s.roles()
+	if err != nil {
+		return AdminAccess{}, err
+	}
+
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return AdminAccess{}, err
+	}
+	if user.Role != "admin" {
+		return AdminAccess{}, fmt.Errorf("%w: roles can only be assigned to admin users", common.ErrValidation)
+	}
+	if _, err := store.GetAdminRole(ctx, req.Role); err != nil {
+		return AdminAccess{}, err
+	}
+
+	if _, err := store.AssignAdminRole(ctx, userID, req.Role, adminID); err != nil {
+		return AdminAccess{}, err
+	}
+	s.rbac.forget(userID)
+
+	s.logRoleAction(ctx, adminID, ActionAssignRole, req.Role, map[string]interface{}{"user_id": userID})
+	return s.GetAdminAccess(ctx, userID)
+}
+
+// RemoveRole removes a role from an admin user. The last super_admin
+// assignment cannot be removed so the roles stay manageable.
+func (s *Service) RemoveRole(ctx context.Context, adminID, userID, role string) (AdminAccess, error) {
+	store, err := s.roles()
+	if err != nil {
+		return AdminAccess{}, err
+	}
+
+	if role == RoleSuperAdmin {
+		count, err := store.CountAdminRoleAssignments(ctx, RoleSuperAdmin)
+		if err != nil {
+			return AdminAccess{}, err
+		}
+		if count <= 1 {
+			return AdminAccess{}, fmt.Errorf("%w: the last super_admin cannot be removed", common.ErrConflict)
+		}
+	}
+
+	if err := store.RemoveAdminRole(ctx, userID, role); err != nil {
+		return AdminAccess{}, err
+	}
+	s.rbac.forget(userID)
+
+	s.logRoleAction(ctx, adminID, ActionRemoveRole, role, map[string]interface{}{"user_id": userID})
+	return s.GetAdminAccess(ctx, userID)
+}
+
+// logRoleAction records a role change in the audit trail
+func (s *Service) logRoleAction(ctx context.Context, adminID, action, role string, metadata map[string]interface{}) {
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, action, ResourceAdminRole, &role, metadata); err != nil {
+		fmt.Printf("Failed to log audit action: %v\n", err)
+	}
+}
+
+// validatePermissions rejects empty and unknown permissions
+func validatePermissions(perms []Permission) error {
+	if len(perms) == 0 {
+		return fmt.Errorf("%w: a role needs at least one permission", common.ErrValidation)
+	}
+	for _, perm := range perms {
+		if !knownPermission(perm) {
+			return fmt.Errorf("%w: unknown permission %q", common.ErrValidation, perm)
+		}
+	}
+	return nil
+}
+
+func knownPermission(perm Permission) bool {
+	for _, info := range Permissions {
+		if info.Permission == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission allows the request only when the admin holds every
+// listed permission. It must run after AdminAuthMiddleware.
+func (h *Handler) RequirePermission(perms ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := c.Get("admin_user_id")
+		if !ok {
+			common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+			c.Abort()
+			return
+		}
+
+		access, err := h.service.GetAdminAccess(c.Request.Context(), fmt.Sprint(adminID))
+		if err != nil {
+			common.RespondErr(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+		for _, perm := range perms {
+			if !access.Allows(perm) {
+				common.RespondError(c, http.StatusForbidden, fmt.Sprintf("forbidden - %s permission required", perm))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireAccess requires read for GET and HEAD requests and write for the
+// rest, for admin route groups mounted by other packages
+func (h *Handler) RequireAccess(read, write Permission) gin.HandlerFunc {
+	requireRead, requireWrite := h.RequirePermission(read), h.RequirePermission(write)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			requireRead(c)
+			return
+		}
+		requireWrite(c)
+	}
+}
@@ -0,0 +1,268 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-styler/internal/activity"
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryRoleStore implements RoleStore in memory, seeded with the built-in roles
+type memoryRoleStore struct {
+	roles       map[string]AdminRole
+	assignments map[string][]string // user ID -> roles
+	loads       int
+}
+
+func newMemoryRoleStore() *memoryRoleStore {
+	return &memoryRoleStore{
+		roles: map[string]AdminRole{
+			RoleSuperAdmin: {Name: RoleSuperAdmin, Permissions: []Permission{PermAll}, BuiltIn: true},
+			RoleSupport:    {Name: RoleSupport, Permissions: []Permission{PermUsersRead, PermUsersWrite, PermStatsRead}, BuiltIn: true},
+			RoleFinance:    {Name: RoleFinance, Permissions: []Permission{PermPaymentsRead, PermPaymentsRefund, PermStatsRead}, BuiltIn: true},
+		},
+		assignments: map[string][]string{},
+	}
+}
+
+func (m *memoryRoleStore) ListAdminRoles(ctx context.Context) ([]AdminRole, error) {
+	roles := []AdminRole{}
+	for _, role := range m.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (m *memoryRoleStore) GetAdminRole(ctx context.Context, name string) (AdminRole, error) {
+	role, ok := m.roles[name]
+	if !ok {
+		return AdminRole{}, fmt.Errorf("admin role %w", common.ErrNotFound)
+	}
+	return role, nil
+}
+
+func (m *memoryRoleStore) CreateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error) {
+	if _, ok := m.roles[role.Name]; ok {
+		return AdminRole{}, fmt.Errorf("%w: admin role %s already exists", common.ErrConflict, role.Name)
+	}
+	m.roles[role.Name] = role
+	return role, nil
+}
+
+func (m *memoryRoleStore) UpdateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error) {
+	m.roles[role.Name] = role
+	return role, nil
+}
+
+func (m *memoryRoleStore) DeleteAdminRole(ctx context.Context, name string) error {
+	delete(m.roles, name)
+	return nil
+}
+
+func (m *memoryRoleStore) GetAdminRoleAssignments(ctx context.Context, userID string) ([]AdminRoleAssignment, error) {
+	m.loads++
+	assignments := []AdminRoleAssignment{}
+	for _, role := range m.assignments[userID] {
+		assignments = append(assignments, AdminRoleAssignment{UserID: userID, Role: role})
+	}
+	return assignments, nil
+}
+
+func (m *memoryRoleStore) AssignAdminRole(ctx context.Context, userID, role, grantedBy string) (AdminRoleAssignment, error) {
+	m.assignments[userID] = append(m.assignments[userID], role)
+	return AdminRoleAssignment{UserID: userID, Role: role, GrantedBy: &grantedBy}, nil
+}
+
+func (m *memoryRoleStore) RemoveAdminRole(ctx context.Context, userID, role string) error {
+	kept := []string{}
+	for _, r := range m.assignments[userID] {
+		if r != role {
+			kept = append(kept, r)
+		}
+	}
+	m.assignments[userID] = kept
+	return nil
+}
+
+func (m *memoryRoleStore) CountAdminRoleAssignments(ctx context.Context, role string) (int, error) {
+	count := 0
+	for _, roles := range m.assignments {
+		for _, r := range roles {
+			if r == role {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func newRBACTestService(defaultRole string) (*Service, *Handler, *memoryRoleStore) {
+	store := NewMockStore()
+	store.users["root"] = AdminUser{ID: "root", Role: "admin"}
+	store.users["agent"] = AdminUser{ID: "agent", Role: "admin"}
+	store.users["customer"] = AdminUser{ID: "customer", Role: "user"}
+
+	service, handler := WireAdminServiceWithMocks(store)
+	roles := newMemoryRoleStore()
+	roles.assignments["root"] = []string{RoleSuperAdmin}
+	service.SetRoles(roles, RBACConfig{DefaultRole: defaultRole})
+	return service, handler, roles
+}
+
+// asAdmin serves a request as adminID through RequirePermission
+func asAdmin(handler *Handler, adminID, method string, perms ...Permission) int {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_user_id", adminID)
+		c.Next()
+	})
+	router.Handle(method, "/admin/resource", handler.RequireAccess(perms[0], perms[1]), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest(method, "/admin/resource", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	_, handler, roles := newRBACTestService("")
+	roles.assignments["agent"] = []string{RoleSupport}
+
+	tests := []struct {
+		admin, method string
+		perms         []Permission
+		want          int
+	}{
+		{"root", http.MethodDelete, []Permission{PermPaymentsRead, PermPaymentsRefund}, http.StatusOK},
+		{"agent", http.MethodGet, []Permission{PermUsersRead, PermUsersWrite}, http.StatusOK},
+		{"agent", http.MethodPost, []Permission{PermUsersRead, PermUsersWrite}, http.StatusOK},
+		{"agent", http.MethodGet, []Permission{PermPaymentsRead, PermPaymentsRefund}, http.StatusForbidden},
+		{"nobody", http.MethodGet, []Permission{PermStatsRead, PermStatsRead}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := asAdmin(handler, tt.admin, tt.method, tt.perms...); got != tt.want {
+			t.Errorf("%s %s with %v: expected %d, got %d", tt.admin, tt.method, tt.perms, tt.want, got)
+		}
+	}
+}
+
+func TestRequirePermissionWithoutRoles(t *testing.T) {
+	_, handler := WireAdminServiceWithMocks(NewMockStore())
+	if got := asAdmin(handler, "anyone", http.MethodPost, PermRolesRead, PermRolesWrite); got != http.StatusOK {
+		t.Errorf("Expected every admin to be allowed without roles, got %d", got)
+	}
+}
+
+func TestAdminAccessDefaultRole(t *testing.T) {
+	service, _, _ := newRBACTestService(RoleFinance)
+
+	access, err := service.GetAdminAccess(context.Background(), "agent")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if access.DefaultRole != RoleFinance || !access.Allows(PermPaymentsRefund) || access.Allows(PermUsersWrite) {
+		t.Errorf("Expected the finance permissions for an admin without roles, got %+v", access)
+	}
+}
+
+func TestAssignRoleRefreshesAccess(t *testing.T) {
+	service, _, roles := newRBACTestService("")
+	ctx := context.Background()
+
+	access, _ := service.GetAdminAccess(ctx, "agent")
+	if access.Allows(PermUsersRead) {
+		t.Fatalf("Expected no permissions before a role is assigned, got %+v", access)
+	}
+	service.GetAdminAccess(ctx, "agent")
+	if roles.loads != 1 {
+		t.Errorf("Expected the second lookup to be cached, loaded %d times", roles.loads)
+	}
+
+	access, err := service.AssignRole(ctx, "root", "agent", AssignRoleRequest{Role: RoleSupport})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !access.Allows(PermUsersRead) {
+		t.Errorf("Expected the support permissions right after assignment, got %+v", access)
+	}
+
+	if _, err := service.AssignRole(ctx, "root", "customer", AssignRoleRequest{Role: RoleSupport}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected a validation error assigning a role to a non-admin, got %v", err)
+	}
+	if _, err := service.AssignRole(ctx, "root", "agent", AssignRoleRequest{Role: "missing"}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found for an unknown role, got %v", err)
+	}
+}
+
+func TestRoleManagementGuards(t *testing.T) {
+	service, _, _ := newRBACTestService(RoleSupport)
+	ctx := context.Background()
+
+	if _, err := service.RemoveRole(ctx, "root", "root", RoleSuperAdmin); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected the last super_admin to be kept, got %v", err)
+	}
+	if _, err := service.UpdateRole(ctx, "root", RoleFinance, UpdateRoleRequest{Permissions: []Permission{PermStatsRead}}); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected built-in roles to be read-only, got %v", err)
+	}
+	if err := service.DeleteRole(ctx, "root", RoleSupport); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected built-in roles to be kept, got %v", err)
+	}
+
+	if _, err := service.CreateRole(ctx, "root", CreateRoleRequest{Name: "Auditors", Permissions: []Permission{PermAuditRead}}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an invalid role name to be rejected, got %v", err)
+	}
+	if _, err := service.CreateRole(ctx, "root", CreateRoleRequest{Name: "auditor", Permissions: []Permission{PermAll}}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected the wildcard to be reserved for super_admin, got %v", err)
+	}
+	role, err := service.CreateRole(ctx, "root", CreateRoleRequest{Name: "auditor", Permissions: []Permission{PermAuditRead}})
+	if err != nil || role.Name != "auditor" {
+		t.Fatalf("Expected the auditor role to be created, got %+v: %v", role, err)
+	}
+	if err := service.DeleteRole(ctx, "root", "auditor"); err != nil {
+		t.Errorf("Expected custom roles to be deletable, got %v", err)
+	}
+}
+
+func TestSubscribeActivityFiltersByPermission(t *testing.T) {
+	service, _, roles := newRBACTestService("")
+	roles.assignments["agent"] = []string{RoleFinance}
+	ctx := context.Background()
+
+	var apiErr *common.APIError
+	if _, _, err := service.SubscribeActivity(ctx, "agent"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the feed is disabled, got %v", err)
+	}
+
+	feed := activity.NewFeed(activity.Config{})
+	service.SetActivityFeed(feed)
+	if _, _, err := service.SubscribeActivity(ctx, "nobody"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusForbidden {
+		t.Errorf("Expected 403 for an admin without any feed permission, got %v", err)
+	}
+
+	events, unsubscribe, err := service.SubscribeActivity(ctx, "agent")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer unsubscribe()
+
+	feed.Publish(activity.Event{Kind: activity.KindAuditEntry})
+	feed.Publish(activity.Event{Kind: activity.KindPaymentCompleted})
+	select {
+	case event := <-events:
+		if event.Kind != activity.KindPaymentCompleted {
+			t.Errorf("Expected only the payment event for a finance admin, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the payment event")
+	}
+}
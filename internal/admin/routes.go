@@ -1,80 +1,126 @@
 package admin
 
 import (
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
+// Impersonation context keys and response headers
+const (
+	// ImpersonatorContextKey holds the admin ID when a request uses an impersonation token
+	ImpersonatorContextKey = "impersonator_id"
+
+	// ImpersonationHeader flags responses served to an impersonated session so clients can show a banner
+	ImpersonationHeader  = "X-Impersonation"
+	ImpersonatorIDHeader = "X-Impersonator-Id"
+)
+
 // SetupRoutes sets up admin routes
 func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 	// Admin middleware - ensure only admin users can access these routes
 	adminGroup := router.Group("/admin")
 	adminGroup.Use(AdminAuthMiddleware())
 
+	// Every route requires a permission; see rbac.go for the catalog
+	require := handler.RequirePermission
+
+	// Current admin's roles and permissions
+	adminGroup.GET("/me/permissions", handler.GetMyAccess) // GET /admin/me/permissions
+
+	// Live activity feed, filtered to the event kinds the admin's permissions cover
+	adminGroup.GET("/activity", handler.StreamActivity) // GET /admin/activity (WebSocket)
+
 	// User management routes
 	users := adminGroup.Group("/users")
 	{
-		users.GET("", handler.GetUsers)                          // GET /admin/users
-		users.GET("/:id", handler.GetUser)                       // GET /admin/users/:id
-		users.PUT("/:id", handler.UpdateUser)                    // PUT /admin/users/:id
-		users.DELETE("/:id", handler.DeleteUser)                 // DELETE /admin/users/:id
-		users.POST("/:id/suspend", handler.SuspendUser)          // POST /admin/users/:id/suspend
-		users.POST("/:id/activate", handler.ActivateUser)        // POST /admin/users/:id/activate
-		users.POST("/:id/revoke-quota", handler.RevokeUserQuota) // POST /admin/users/:id/revoke-quota
-		users.POST("/:id/revoke-plan", handler.RevokeUserPlan)   // POST /admin/users/:id/revoke-plan
+		users.GET("", require(PermUsersRead), handler.GetUsers)                                // GET /admin/users
+		users.GET("/:id", require(PermUsersRead), handler.GetUser)                             // GET /admin/users/:id
+		users.PUT("/:id", require(PermUsersWrite), handler.UpdateUser)                         // PUT /admin/users/:id
+		users.DELETE("/:id", require(PermUsersWrite), handler.DeleteUser)                      // DELETE /admin/users/:id
+		users.POST("/:id/suspend", require(PermUsersWrite), handler.SuspendUser)               // POST /admin/users/:id/suspend
+		users.POST("/:id/activate", require(PermUsersWrite), handler.ActivateUser)             // POST /admin/users/:id/activate
+		users.POST("/:id/revoke-quota", require(PermUsersWrite), handler.RevokeUserQuota)      // POST /admin/users/:id/revoke-quota
+		users.POST("/:id/revoke-plan", require(PermUsersWrite), handler.RevokeUserPlan)        // POST /admin/users/:id/revoke-plan
+		users.POST("/:id/impersonate", require(PermUsersImpersonate), handler.ImpersonateUser) // POST /admin/users/:id/impersonate
+		users.GET("/:id/roles", require(PermRolesRead), handler.GetUserRoles)                  // GET /admin/users/:id/roles
+		users.POST("/:id/roles", require(PermRolesWrite), handler.AssignUserRole)              // POST /admin/users/:id/roles
+		users.DELETE("/:id/roles/:role", require(PermRolesWrite), handler.RemoveUserRole)      // DELETE /admin/users/:id/roles/:role
 	}
 
 	// Vendor management routes
 	vendors := adminGroup.Group("/vendors")
 	{
-		vendors.GET("", handler.GetVendors)                          // GET /admin/vendors
-		vendors.GET("/:id", handler.GetVendor)                       // GET /admin/vendors/:id
-		vendors.PUT("/:id", handler.UpdateVendor)                    // PUT /admin/vendors/:id
-		vendors.DELETE("/:id", handler.DeleteVendor)                 // DELETE /admin/vendors/:id
-		vendors.POST("/:id/suspend", handler.SuspendVendor)          // POST /admin/vendors/:id/suspend
-		vendors.POST("/:id/activate", handler.ActivateVendor)        // POST /admin/vendors/:id/activate
-		vendors.POST("/:id/verify", handler.VerifyVendor)            // POST /admin/vendors/:id/verify
-		vendors.POST("/:id/revoke-quota", handler.RevokeVendorQuota) // POST /admin/vendors/:id/revoke-quota
+		vendors.GET("", require(PermVendorsRead), handler.GetVendors)                           // GET /admin/vendors
+		vendors.GET("/:id", require(PermVendorsRead), handler.GetVendor)                        // GET /admin/vendors/:id
+		vendors.PUT("/:id", require(PermVendorsWrite), handler.UpdateVendor)                    // PUT /admin/vendors/:id
+		vendors.DELETE("/:id", require(PermVendorsWrite), handler.DeleteVendor)                 // DELETE /admin/vendors/:id
+		vendors.POST("/:id/suspend", require(PermVendorsWrite), handler.SuspendVendor)          // POST /admin/vendors/:id/suspend
+		vendors.POST("/:id/activate", require(PermVendorsWrite), handler.ActivateVendor)        // POST /admin/vendors/:id/activate
+		vendors.POST("/:id/verify", require(PermVendorsWrite), handler.VerifyVendor)            // POST /admin/vendors/:id/verify
+		vendors.POST("/:id/revoke-quota", require(PermVendorsWrite), handler.RevokeVendorQuota) // POST /admin/vendors/:id/revoke-quota
 	}
 
 	// Plan management routes
 	plans := adminGroup.Group("/plans")
 	{
-		plans.GET("", handler.GetPlans)          // GET /admin/plans
-		plans.GET("/:id", handler.GetPlan)       // GET /admin/plans/:id
-		plans.POST("", handler.CreatePlan)       // POST /admin/plans
-		plans.PUT("/:id", handler.UpdatePlan)    // PUT /admin/plans/:id
-		plans.DELETE("/:id", handler.DeletePlan) // DELETE /admin/plans/:id
+		plans.GET("", require(PermPlansRead), handler.GetPlans)           // GET /admin/plans
+		plans.GET("/:id", require(PermPlansRead), handler.GetPlan)        // GET /admin/plans/:id
+		plans.POST("", require(PermPlansWrite), handler.CreatePlan)       // POST /admin/plans
+		plans.PUT("/:id", require(PermPlansWrite), handler.UpdatePlan)    // PUT /admin/plans/:id
+		plans.DELETE("/:id", require(PermPlansWrite), handler.DeletePlan) // DELETE /admin/plans/:id
 	}
 
 	// Payment management routes
 	payments := adminGroup.Group("/payments")
 	{
-		payments.GET("", handler.GetPayments)    // GET /admin/payments
-		payments.GET("/:id", handler.GetPayment) // GET /admin/payments/:id
+		payments.GET("", require(PermPaymentsRead), handler.GetPayments)                                // GET /admin/payments
+		payments.GET("/export", require(PermPaymentsRead), handler.ExportPayments)                      // GET /admin/payments/export
+		payments.GET("/exports/:id", require(PermPaymentsRead), handler.GetPaymentExport)               // GET /admin/payments/exports/:id
+		payments.GET("/exports/:id/download", require(PermPaymentsRead), handler.DownloadPaymentExport) // GET /admin/payments/exports/:id/download
+		payments.GET("/:id", require(PermPaymentsRead), handler.GetPayment)                             // GET /admin/payments/:id
 	}
 
 	// Conversion management routes
 	conversions := adminGroup.Group("/conversions")
 	{
-		conversions.GET("", handler.GetConversions)    // GET /admin/conversions
-		conversions.GET("/:id", handler.GetConversion) // GET /admin/conversions/:id
+		conversions.GET("", require(PermConversionsRead), handler.GetConversions)                       // GET /admin/conversions
+		conversions.GET("/dead-letter", require(PermConversionsRead), handler.GetDeadLetterConversions) // GET /admin/conversions/dead-letter
+		conversions.GET("/:id", require(PermConversionsRead), handler.GetConversion)                    // GET /admin/conversions/:id
+		conversions.POST("/:id/boost", require(PermConversionsWrite), handler.BoostConversion)          // POST /admin/conversions/:id/boost
+		conversions.POST("/:id/requeue", require(PermConversionsWrite), handler.RequeueConversion)      // POST /admin/conversions/:id/requeue
 	}
 
 	// Image management routes
 	images := adminGroup.Group("/images")
 	{
-		images.GET("", handler.GetImages)    // GET /admin/images
-		images.GET("/:id", handler.GetImage) // GET /admin/images/:id
+		images.GET("", require(PermImagesRead), handler.GetImages)    // GET /admin/images
+		images.GET("/:id", require(PermImagesRead), handler.GetImage) // GET /admin/images/:id
 	}
 
 	// Audit trail routes
-	auditLogs := adminGroup.Group("/audit-logs")
+	auditLogs := adminGroup.Group("/audit-logs", require(PermAuditRead))
 	{
-		auditLogs.GET("", handler.GetAuditLogs) // GET /admin/audit-logs
+		auditLogs.GET("", handler.GetAuditLogs)           // GET /admin/audit-logs
+		auditLogs.GET("/stream", handler.StreamAuditLogs) // GET /admin/audit-logs/stream
+		auditLogs.GET("/export", handler.ExportAuditLogs) // GET /admin/audit-logs/export
+	}
+
+	// Moderation review routes
+	moderation := adminGroup.Group("/moderation", require(PermModerationReview))
+	{
+		moderation.GET("", handler.GetModerationVerdicts)               // GET /admin/moderation
+		moderation.POST("/:id/review", handler.ReviewModerationVerdict) // POST /admin/moderation/:id/review
+	}
+
+	// API key usage routes
+	apiKeys := adminGroup.Group("/api-keys", require(PermAPIKeysRead))
+	{
+		apiKeys.GET("", handler.GetAPIKeys) // GET /admin/api-keys
 	}
 
 	// Statistics routes
-	stats := adminGroup.Group("/stats")
+	stats := adminGroup.Group("/stats", require(PermStatsRead))
 	{
 		stats.GET("", handler.GetSystemStats)                 // GET /admin/stats
 		stats.GET("/users", handler.GetUserStats)             // GET /admin/stats/users
@@ -82,6 +128,20 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 		stats.GET("/payments", handler.GetPaymentStats)       // GET /admin/stats/payments
 		stats.GET("/conversions", handler.GetConversionStats) // GET /admin/stats/conversions
 		stats.GET("/images", handler.GetImageStats)           // GET /admin/stats/images
+
+		stats.GET("/onboarding-cohorts", handler.GetOnboardingCohorts) // GET /admin/stats/onboarding-cohorts
+		stats.GET("/costs", handler.GetConversionCosts)                // GET /admin/stats/costs
+		stats.GET("/feedback", handler.GetFeedbackStats)               // GET /admin/stats/feedback
+	}
+
+	// Role management routes
+	adminGroup.GET("/permissions", require(PermRolesRead), handler.GetPermissions) // GET /admin/permissions
+	roles := adminGroup.Group("/roles")
+	{
+		roles.GET("", require(PermRolesRead), handler.GetRoles)             // GET /admin/roles
+		roles.POST("", require(PermRolesWrite), handler.CreateRole)         // POST /admin/roles
+		roles.PUT("/:name", require(PermRolesWrite), handler.UpdateRole)    // PUT /admin/roles/:name
+		roles.DELETE("/:name", require(PermRolesWrite), handler.DeleteRole) // DELETE /admin/roles/:name
 	}
 }
 
@@ -91,7 +151,7 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 		// Get user from context (set by auth middleware)
 		userID, exists := c.Get("user_id")
 		if !exists {
-			c.JSON(401, gin.H{"error": "unauthorized"})
+			common.RespondError(c, 401, "unauthorized")
 			c.Abort()
 			return
 		}
@@ -99,14 +159,14 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 		// Get user role from context (set by auth middleware)
 		userRole, exists := c.Get("user_role")
 		if !exists {
-			c.JSON(401, gin.H{"error": "unauthorized"})
+			common.RespondError(c, 401, "unauthorized")
 			c.Abort()
 			return
 		}
 
 		// Check if user is admin
 		if userRole != "admin" {
-			c.JSON(403, gin.H{"error": "forbidden - admin access required"})
+			common.RespondError(c, 403, "forbidden - admin access required")
 			c.Abort()
 			return
 		}
@@ -134,3 +194,30 @@ func AdminAuditMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ImpersonationMiddleware flags and audits every request made with an impersonation token.
+// It must run after the auth middleware has set user_id and impersonator_id.
+func (h *Handler) ImpersonationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		impersonatorID := c.GetString(ImpersonatorContextKey)
+		if impersonatorID == "" {
+			c.Next()
+			return
+		}
+
+		// Banner flag for clients
+		c.Header(ImpersonationHeader, "true")
+		c.Header(ImpersonatorIDHeader, impersonatorID)
+
+		c.Next()
+
+		metadata := map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.FullPath(),
+			"url":    c.Request.URL.Path,
+			"status": c.Writer.Status(),
+			"ip":     c.ClientIP(),
+		}
+		h.service.LogImpersonatedRequest(c.Request.Context(), impersonatorID, c.GetString("user_id"), metadata)
+	}
+}
@@ -4,13 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-styler/internal/activity"
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
+	"ai-styler/internal/domain"
 )
 
+// DefaultImpersonationTTL is the lifetime of impersonation tokens when none is configured
+const DefaultImpersonationTTL = 15 * time.Minute
+
 // Service provides admin functionality
 type Service struct {
-	store       Store
-	notifier    NotificationService
-	auditLogger AuditLogger
+	store            Store
+	notifier         NotificationService
+	auditLogger      AuditLogger
+	impersonation    ImpersonationTokenIssuer
+	impersonationTTL time.Duration
+	auditArchive     AuditArchiveWriter
+	auditRetention   AuditRetentionConfig
+	rbac             *rbac // nil grants every admin every permission
+	activity         *activity.Feed
+	cache            *cache.TwoTier // lookups dropped on vendor and plan changes
+	paymentExports   *paymentExports
 }
 
 // NewService creates a new admin service
@@ -23,7 +43,17 @@ func NewService(
 		store:       store,
 		notifier:    notifier,
 		auditLogger: auditLogger,
+		cache:       cache.Default(),
+	}
+}
+
+// SetImpersonationIssuer enables user impersonation with tokens that expire after ttl
+func (s *Service) SetImpersonationIssuer(issuer ImpersonationTokenIssuer, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultImpersonationTTL
 	}
+	s.impersonation = issuer
+	s.impersonationTTL = ttl
 }
 
 // User management
@@ -65,15 +95,7 @@ func (s *Service) UpdateUser(ctx context.Context, userID string, req UpdateUserR
 
 	// Validate role if provided
 	if req.Role != nil {
-		validRoles := []string{"user", "vendor", "admin"}
-		valid := false
-		for _, role := range validRoles {
-			if *req.Role == role {
-				valid = true
-				break
-			}
-		}
-		if !valid {
+		if !domain.IsValidRole(*req.Role) {
 			return AdminUser{}, errors.New("invalid role")
 		}
 	}
@@ -201,6 +223,74 @@ func (s *Service) ActivateUser(ctx context.Context, userID string) error {
 	return nil
 }
 
+// ImpersonateUser issues a short-lived access token that lets an admin act as the given user
+func (s *Service) ImpersonateUser(ctx context.Context, adminID, userID string, req ImpersonateUserRequest) (ImpersonationResponse, error) {
+	if adminID == "" {
+		return ImpersonationResponse{}, errors.New("admin ID is required")
+	}
+	if userID == "" {
+		return ImpersonationResponse{}, fmt.Errorf("%w: user ID is required", common.ErrValidation)
+	}
+	if req.Reason == "" {
+		return ImpersonationResponse{}, fmt.Errorf("%w: reason is required", common.ErrValidation)
+	}
+	if adminID == userID {
+		return ImpersonationResponse{}, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, "cannot impersonate yourself", nil)
+	}
+	if s.impersonation == nil {
+		return ImpersonationResponse{}, errors.New("impersonation is not configured")
+	}
+
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return ImpersonationResponse{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role == "admin" {
+		return ImpersonationResponse{}, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, "cannot impersonate another admin", nil)
+	}
+	if !user.IsActive {
+		return ImpersonationResponse{}, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, "cannot impersonate an inactive user", nil)
+	}
+
+	token, expiresAt, err := s.impersonation.IssueImpersonationToken(ctx, user.ID, user.Phone, user.Role, adminID, s.impersonationTTL)
+	if err != nil {
+		return ImpersonationResponse{}, fmt.Errorf("failed to issue impersonation token: %w", err)
+	}
+
+	// Log the action
+	metadata := map[string]interface{}{
+		"user_id":    userID,
+		"admin_id":   adminID,
+		"reason":     req.Reason,
+		"expires_at": expiresAt,
+	}
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionImpersonate, ResourceUser, &userID, metadata); err != nil {
+		// Log error but don't fail the operation
+		fmt.Printf("Failed to log audit action: %v\n", err)
+	}
+
+	return ImpersonationResponse{
+		AccessToken:    token,
+		TokenType:      "Bearer",
+		ExpiresAt:      expiresAt,
+		UserID:         userID,
+		ImpersonatorID: adminID,
+	}, nil
+}
+
+// LogImpersonatedRequest records a request an admin performed while impersonating a user
+func (s *Service) LogImpersonatedRequest(ctx context.Context, adminID, userID string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["admin_id"] = adminID
+	metadata["user_id"] = userID
+
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionImpersonateRequest, ResourceUser, &userID, metadata); err != nil {
+		fmt.Printf("Failed to log impersonated request: %v\n", err)
+	}
+}
+
 // Vendor management
 
 // GetVendors retrieves a list of vendors with pagination and filtering
@@ -243,6 +333,7 @@ func (s *Service) UpdateVendor(ctx context.Context, vendorID string, req UpdateV
 		return AdminVendor{}, errors.New("free images limit cannot be negative")
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, vendorID)
 	vendor, err := s.store.UpdateVendor(ctx, vendorID, req)
 	if err != nil {
 		return AdminVendor{}, fmt.Errorf("failed to update vendor: %w", err)
@@ -267,6 +358,7 @@ func (s *Service) DeleteVendor(ctx context.Context, vendorID string) error {
 		return errors.New("vendor ID is required")
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, vendorID)
 	err := s.store.DeleteVendor(ctx, vendorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete vendor: %w", err)
@@ -297,6 +389,7 @@ func (s *Service) SuspendVendor(ctx context.Context, vendorID string, reason str
 		IsActive: boolPtr(false),
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, vendorID)
 	_, err := s.store.UpdateVendor(ctx, vendorID, req)
 	if err != nil {
 		return fmt.Errorf("failed to suspend vendor: %w", err)
@@ -325,6 +418,7 @@ func (s *Service) ActivateVendor(ctx context.Context, vendorID string) error {
 		IsActive: boolPtr(true),
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, vendorID)
 	_, err := s.store.UpdateVendor(ctx, vendorID, req)
 	if err != nil {
 		return fmt.Errorf("failed to activate vendor: %w", err)
@@ -352,6 +446,7 @@ func (s *Service) VerifyVendor(ctx context.Context, vendorID string) error {
 		IsVerified: boolPtr(true),
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, vendorID)
 	_, err := s.store.UpdateVendor(ctx, vendorID, req)
 	if err != nil {
 		return fmt.Errorf("failed to verify vendor: %w", err)
@@ -538,8 +633,9 @@ func (s *Service) GetConversions(ctx context.Context, req ConversionListRequest)
 	return s.store.GetConversions(ctx, req)
 }
 
-// GetConversion retrieves a specific conversion by ID
-func (s *Service) GetConversion(ctx context.Context, conversionID string) (AdminConversion, error) {
+// GetConversion retrieves a specific conversion by ID with its most recent
+// worker log entries
+func (s *Service) GetConversion(ctx context.Context, conversionID string, logLimit int) (AdminConversion, error) {
 	if conversionID == "" {
 		return AdminConversion{}, errors.New("conversion ID is required")
 	}
@@ -549,9 +645,93 @@ func (s *Service) GetConversion(ctx context.Context, conversionID string) (Admin
 		return AdminConversion{}, fmt.Errorf("failed to get conversion: %w", err)
 	}
 
+	if logLimit <= 0 {
+		logLimit = DefaultConversionLogLimit
+	}
+	if logLimit > MaxConversionLogLimit {
+		logLimit = MaxConversionLogLimit
+	}
+
+	// Logs are diagnostic, the conversion is still returned without them
+	logs, err := s.store.GetConversionLogs(ctx, conversionID, logLimit)
+	if err != nil {
+		fmt.Printf("Failed to get conversion logs: %v\n", err)
+	}
+	conversion.Logs = logs
+
 	return conversion, nil
 }
 
+// BoostConversion moves a pending conversion up the worker queue
+func (s *Service) BoostConversion(ctx context.Context, adminID, conversionID string, req BoostConversionRequest) (ConversionBoost, error) {
+	if conversionID == "" {
+		return ConversionBoost{}, errors.New("conversion ID is required")
+	}
+	if req.Priority <= 0 {
+		return ConversionBoost{}, errors.New("priority must be positive")
+	}
+
+	boost, err := s.store.BoostConversionJob(ctx, conversionID, req.Priority, adminID)
+	if err != nil {
+		return ConversionBoost{}, err
+	}
+
+	metadata := map[string]interface{}{
+		"job_id":            boost.JobID,
+		"previous_priority": boost.PreviousPriority,
+		"priority":          boost.Priority,
+		"reason":            req.Reason,
+	}
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionBoost, ResourceConversion, &conversionID, metadata); err != nil {
+		fmt.Printf("Failed to log audit action: %v\n", err)
+	}
+
+	return boost, nil
+}
+
+// GetDeadLetterConversions lists conversions the worker gave up on, most recent first
+func (s *Service) GetDeadLetterConversions(ctx context.Context, req DeadLetterListRequest) (DeadLetterListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	return s.store.GetDeadLetterConversions(ctx, req)
+}
+
+// RequeueConversion pushes a dead-lettered conversion back to the worker queue.
+// Requeues are not charged against the user's quota.
+func (s *Service) RequeueConversion(ctx context.Context, adminID, conversionID string, req RequeueConversionRequest) (ConversionRequeue, error) {
+	if conversionID == "" {
+		return ConversionRequeue{}, errors.New("conversion ID is required")
+	}
+	if req.Priority < 0 {
+		return ConversionRequeue{}, errors.New("priority must not be negative")
+	}
+
+	requeue, err := s.store.RequeueDeadLetterConversion(ctx, conversionID, req.Priority, adminID)
+	if err != nil {
+		return ConversionRequeue{}, err
+	}
+
+	metadata := map[string]interface{}{
+		"job_id":   requeue.JobID,
+		"priority": requeue.Priority,
+		"requeues": requeue.Requeues,
+		"reason":   req.Reason,
+	}
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionRequeue, ResourceConversion, &conversionID, metadata); err != nil {
+		fmt.Printf("Failed to log audit action: %v\n", err)
+	}
+
+	return requeue, nil
+}
+
 // Image management
 
 // GetImages retrieves a list of images with pagination and filtering
@@ -600,6 +780,152 @@ func (s *Service) GetAuditLogs(ctx context.Context, req AuditLogListRequest) (Au
 	return s.store.GetAuditLogs(ctx, req)
 }
 
+// StreamAuditLogs returns the next page of the hash-chained audit log for SIEM export.
+// The chain is verified within the page so consumers can detect tampering.
+func (s *Service) StreamAuditLogs(ctx context.Context, req AuditLogStreamRequest) (AuditLogStreamResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 500
+	}
+	if req.Limit > 1000 {
+		req.Limit = 1000
+	}
+
+	var afterSeq int64
+	var since *time.Time
+	if req.Since != "" {
+		if seq, err := strconv.ParseInt(req.Since, 10, 64); err == nil {
+			if seq < 0 {
+				return AuditLogStreamResponse{}, fmt.Errorf("%w: since cursor must not be negative", common.ErrValidation)
+			}
+			afterSeq = seq
+		} else if t, err := time.Parse(time.RFC3339, req.Since); err == nil {
+			since = &t
+		} else {
+			return AuditLogStreamResponse{}, fmt.Errorf("%w: since must be a sequence cursor or an RFC3339 timestamp", common.ErrValidation)
+		}
+	}
+
+	// Fetch one extra entry to know whether more are available
+	entries, err := s.store.StreamAuditLogs(ctx, afterSeq, since, req.Limit+1)
+	if err != nil {
+		return AuditLogStreamResponse{}, fmt.Errorf("failed to stream audit logs: %w", err)
+	}
+
+	hasMore := len(entries) > req.Limit
+	if hasMore {
+		entries = entries[:req.Limit]
+	}
+
+	// Reading from the start of the log also proves nothing precedes the first
+	// entry: the genesis hash, or the last archived entry once logs are archived
+	expectedPrev := ""
+	if afterSeq == 0 && since == nil {
+		expectedPrev = AuditChainGenesisHash
+		archive, err := s.store.GetLatestAuditArchive(ctx)
+		if err != nil {
+			return AuditLogStreamResponse{}, fmt.Errorf("failed to stream audit logs: %w", err)
+		}
+		if archive != nil {
+			expectedPrev = archive.LastEntryHash
+		}
+	}
+	brokenAt := VerifyAuditChain(entries, expectedPrev)
+
+	nextCursor := strconv.FormatInt(afterSeq, 10)
+	if len(entries) > 0 {
+		nextCursor = strconv.FormatInt(entries[len(entries)-1].Seq, 10)
+	}
+
+	return AuditLogStreamResponse{
+		Entries:       entries,
+		NextCursor:    nextCursor,
+		HasMore:       hasMore,
+		ChainVerified: brokenAt == nil,
+		BrokenAtSeq:   brokenAt,
+	}, nil
+}
+
+// ExportAuditLogs calls fn for every audit log entry matching the filters, in
+// sequence order
+func (s *Service) ExportAuditLogs(ctx context.Context, req AuditLogExportRequest, fn func(AuditLogStreamEntry) error) error {
+	switch req.Format {
+	case "":
+		req.Format = AuditExportFormatCSV
+	case AuditExportFormatCSV, AuditExportFormatJSONL:
+	default:
+		return fmt.Errorf("%w: format must be csv or jsonl", common.ErrValidation)
+	}
+
+	return s.store.ExportAuditLogs(ctx, req.AuditLogListRequest, fn)
+}
+
+// Moderation review
+
+// GetModerationVerdicts retrieves moderation verdicts for admin review
+func (s *Service) GetModerationVerdicts(ctx context.Context, req ModerationListRequest) (ModerationListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	return s.store.GetModerationVerdicts(ctx, req)
+}
+
+// GetAPIKeys lists API keys with their usage, most recently used first
+func (s *Service) GetAPIKeys(ctx context.Context, req APIKeyListRequest) (APIKeyListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	return s.store.GetAPIKeys(ctx, req)
+}
+
+// ReviewModerationVerdict confirms or overturns a pending moderation rejection
+func (s *Service) ReviewModerationVerdict(ctx context.Context, adminID, verdictID string, req ReviewModerationRequest) (ModerationVerdict, error) {
+	if verdictID == "" {
+		return ModerationVerdict{}, errors.New("verdict ID is required")
+	}
+	if req.Decision != ModerationReviewConfirmed && req.Decision != ModerationReviewOverturned {
+		return ModerationVerdict{}, fmt.Errorf("invalid review decision: %s", req.Decision)
+	}
+
+	verdict, err := s.store.GetModerationVerdict(ctx, verdictID)
+	if err != nil {
+		return ModerationVerdict{}, err
+	}
+	if verdict.ReviewStatus != ModerationReviewPending {
+		return ModerationVerdict{}, errors.New("moderation verdict is not pending review")
+	}
+
+	reviewed, err := s.store.ReviewModerationVerdict(ctx, verdictID, adminID, req.Decision, req.Note)
+	if err != nil {
+		return ModerationVerdict{}, err
+	}
+
+	metadata := map[string]interface{}{
+		"decision":   req.Decision,
+		"note":       req.Note,
+		"image_kind": verdict.ImageKind,
+		"reasons":    verdict.Reasons,
+	}
+	if err := s.auditLogger.LogAction(ctx, &adminID, ActorTypeAdmin, ActionReview, ResourceModeration, &verdictID, metadata); err != nil {
+		fmt.Printf("Failed to log audit action: %v\n", err)
+	}
+
+	return reviewed, nil
+}
+
 // Quota management
 
 // RevokeUserQuota revokes quota from a user
@@ -717,6 +1043,7 @@ func (s *Service) RevokeUserPlan(ctx context.Context, req RevokePlanRequest) err
 		return errors.New("reason is required")
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityUserPlan, req.UserID)
 	err := s.store.RevokeUserPlan(ctx, req.UserID, req.Reason)
 	if err != nil {
 		return fmt.Errorf("failed to revoke user plan: %w", err)
@@ -806,3 +1133,138 @@ func (s *Service) GetImageStats(ctx context.Context) (int, error) {
 
 	return total, nil
 }
+
+// GetOnboardingCohorts compares activation rates of weekly signup cohorts with and
+// without the onboarding grant. Defaults to the last 12 weeks and a 7-day window.
+func (s *Service) GetOnboardingCohorts(ctx context.Context, req OnboardingCohortRequest) (OnboardingCohortResponse, error) {
+	if req.ActivationDays <= 0 {
+		req.ActivationDays = 7
+	}
+	if req.ActivationDays > 90 {
+		req.ActivationDays = 90
+	}
+
+	from, to, err := parseDateRange(req.DateFrom, req.DateTo, 7*12)
+	if err != nil {
+		return OnboardingCohortResponse{}, err
+	}
+
+	cohorts, err := s.store.GetOnboardingCohorts(ctx, from, to, req.ActivationDays)
+	if err != nil {
+		return OnboardingCohortResponse{}, fmt.Errorf("failed to get onboarding cohorts: %w", err)
+	}
+
+	for i := range cohorts {
+		cohorts[i].GrantedActivationRate = activationRate(cohorts[i].GrantedActivated, cohorts[i].GrantedSignups)
+		cohorts[i].ControlActivationRate = activationRate(cohorts[i].ControlActivated, cohorts[i].ControlSignups)
+	}
+
+	return OnboardingCohortResponse{
+		Cohorts:        cohorts,
+		ActivationDays: req.ActivationDays,
+		DateFrom:       from,
+		DateTo:         to,
+	}, nil
+}
+
+// GetConversionCosts breaks provider spend down per user, plan or provider.
+// Defaults to the last 30 days grouped by provider.
+func (s *Service) GetConversionCosts(ctx context.Context, req ConversionCostRequest) (ConversionCostResponse, error) {
+	if req.GroupBy == "" {
+		req.GroupBy = CostGroupByProvider
+	}
+	switch req.GroupBy {
+	case CostGroupByUser, CostGroupByPlan, CostGroupByProvider:
+	default:
+		return ConversionCostResponse{}, fmt.Errorf("%w: groupBy must be user, plan or provider", common.ErrValidation)
+	}
+
+	from, to, err := parseDateRange(req.DateFrom, req.DateTo, 30)
+	if err != nil {
+		return ConversionCostResponse{}, err
+	}
+
+	groups, err := s.store.GetConversionCosts(ctx, from, to, req.GroupBy)
+	if err != nil {
+		return ConversionCostResponse{}, fmt.Errorf("failed to get conversion costs: %w", err)
+	}
+
+	response := ConversionCostResponse{
+		Groups:   groups,
+		GroupBy:  req.GroupBy,
+		DateFrom: from,
+		DateTo:   to,
+	}
+	for _, group := range groups {
+		response.TotalCost += group.Cost
+		response.TotalConversions += group.Conversions
+	}
+	response.TotalCost = math.Round(response.TotalCost*10000) / 10000
+	return response, nil
+}
+
+// GetFeedbackStats reports conversion ratings per style and provider
+func (s *Service) GetFeedbackStats(ctx context.Context, req FeedbackStatsRequest) (FeedbackStatsResponse, error) {
+	from, to, err := parseDateRange(req.DateFrom, req.DateTo, 30)
+	if err != nil {
+		return FeedbackStatsResponse{}, err
+	}
+
+	groups, err := s.store.GetFeedbackStats(ctx, from, to)
+	if err != nil {
+		return FeedbackStatsResponse{}, fmt.Errorf("failed to get feedback stats: %w", err)
+	}
+
+	response := FeedbackStatsResponse{
+		Groups:   groups,
+		Issues:   make(map[string]int),
+		DateFrom: from,
+		DateTo:   to,
+	}
+	var ratingSum float64
+	for _, group := range groups {
+		response.TotalRatings += group.Ratings
+		ratingSum += group.AverageRating * float64(group.Ratings)
+		for issue, count := range group.Issues {
+			response.Issues[issue] += count
+		}
+	}
+	if response.TotalRatings > 0 {
+		response.AverageRating = math.Round(ratingSum/float64(response.TotalRatings)*100) / 100
+	}
+	return response, nil
+}
+
+// parseDateRange parses inclusive YYYY-MM-DD report dates into a half-open
+// range. dateTo defaults to today and dateFrom to defaultDays before it.
+func parseDateRange(dateFrom, dateTo string, defaultDays int) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if dateTo != "" {
+		parsed, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: dateTo must be YYYY-MM-DD", common.ErrValidation)
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultDays)
+	if dateFrom != "" {
+		parsed, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: dateFrom must be YYYY-MM-DD", common.ErrValidation)
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: dateFrom must be before dateTo", common.ErrValidation)
+	}
+	return from, to, nil
+}
+
+// activationRate returns activated/signups rounded to four decimals
+func activationRate(activated, signups int) float64 {
+	if signups == 0 {
+		return 0
+	}
+	return math.Round(float64(activated)/float64(signups)*10000) / 10000
+}
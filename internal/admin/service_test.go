@@ -3,9 +3,12 @@ package admin
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
+
+	"ai-styler/internal/common"
 )
 
 // MockStore implements Store interface for testing
@@ -17,24 +20,38 @@ type MockStore struct {
 	conversions     map[string]AdminConversion
 	images          map[string]AdminImage
 	auditLogs       []AuditLog
+	auditStream     []AuditLogStreamEntry
+	auditArchives   []AuditLogArchive
+	auditForwarded  int64
+	verdicts        map[string]ModerationVerdict
 	userStats       [2]int   // total, active
 	vendorStats     [2]int   // total, active
 	paymentStats    [2]int64 // total, revenue
 	conversionStats [3]int   // total, pending, failed
 	imageStats      int
 	systemStats     AdminStats
+	cohorts         []OnboardingCohort
+	costs           []ConversionCostGroup
+	feedbackStats   []FeedbackStatsGroup
+	conversionLogs  map[string][]ConversionLogEntry
+	lastLogLimit    int
+	jobPriorities   map[string]int
+	deadLetters     map[string]DeadLetterConversion
 }
 
 // NewMockStore creates a new mock store
 func NewMockStore() *MockStore {
 	return &MockStore{
-		users:       make(map[string]AdminUser),
-		vendors:     make(map[string]AdminVendor),
-		plans:       make(map[string]AdminPlan),
-		payments:    make(map[string]AdminPayment),
-		conversions: make(map[string]AdminConversion),
-		images:      make(map[string]AdminImage),
-		auditLogs:   make([]AuditLog, 0),
+		users:         make(map[string]AdminUser),
+		vendors:       make(map[string]AdminVendor),
+		plans:         make(map[string]AdminPlan),
+		payments:      make(map[string]AdminPayment),
+		conversions:   make(map[string]AdminConversion),
+		images:        make(map[string]AdminImage),
+		auditLogs:     make([]AuditLog, 0),
+		verdicts:      make(map[string]ModerationVerdict),
+		jobPriorities: make(map[string]int),
+		deadLetters:   make(map[string]DeadLetterConversion),
 	}
 }
 
@@ -297,6 +314,71 @@ func (m *MockStore) GetConversion(ctx context.Context, conversionID string) (Adm
 	return conversion, nil
 }
 
+func (m *MockStore) GetConversionLogs(ctx context.Context, conversionID string, limit int) ([]ConversionLogEntry, error) {
+	m.lastLogLimit = limit
+	logs := m.conversionLogs[conversionID]
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (m *MockStore) BoostConversionJob(ctx context.Context, conversionID string, priority int, adminID string) (ConversionBoost, error) {
+	previous, exists := m.jobPriorities[conversionID]
+	if !exists {
+		return ConversionBoost{}, fmt.Errorf("pending job for conversion %w", common.ErrNotFound)
+	}
+	m.jobPriorities[conversionID] = priority
+	return ConversionBoost{
+		ConversionID:     conversionID,
+		JobID:            "job-" + conversionID,
+		PreviousPriority: previous,
+		Priority:         priority,
+		BoostedBy:        adminID,
+		BoostedAt:        time.Now(),
+	}, nil
+}
+
+func (m *MockStore) GetDeadLetterConversions(ctx context.Context, req DeadLetterListRequest) (DeadLetterListResponse, error) {
+	conversions := []DeadLetterConversion{}
+	for _, conversion := range m.deadLetters {
+		if req.StatusCode != 0 && (conversion.ProviderStatusCode == nil || *conversion.ProviderStatusCode != req.StatusCode) {
+			continue
+		}
+		conversions = append(conversions, conversion)
+	}
+	return DeadLetterListResponse{
+		Conversions: conversions,
+		Total:       len(conversions),
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		TotalPages:  1,
+	}, nil
+}
+
+func (m *MockStore) RequeueDeadLetterConversion(ctx context.Context, conversionID string, priority int, adminID string) (ConversionRequeue, error) {
+	conversion, exists := m.deadLetters[conversionID]
+	if !exists {
+		if _, exists := m.conversions[conversionID]; exists {
+			return ConversionRequeue{}, fmt.Errorf("%w: conversion is not dead-lettered", common.ErrConflict)
+		}
+		return ConversionRequeue{}, fmt.Errorf("conversion %w", common.ErrNotFound)
+	}
+	delete(m.deadLetters, conversionID)
+	if priority == 0 {
+		priority = m.jobPriorities[conversionID]
+	}
+	m.jobPriorities[conversionID] = priority
+	return ConversionRequeue{
+		ConversionID: conversionID,
+		JobID:        "job-" + conversionID,
+		Priority:     priority,
+		Requeues:     conversion.Requeues + 1,
+		RequeuedBy:   adminID,
+		RequeuedAt:   time.Now(),
+	}, nil
+}
+
 func (m *MockStore) GetConversionStats(ctx context.Context) (int, int, int, error) {
 	return m.conversionStats[0], m.conversionStats[1], m.conversionStats[2], nil
 }
@@ -345,6 +427,111 @@ func (m *MockStore) CreateAuditLog(ctx context.Context, log AuditLog) error {
 	return nil
 }
 
+func (m *MockStore) StreamAuditLogs(ctx context.Context, afterSeq int64, since *time.Time, limit int) ([]AuditLogStreamEntry, error) {
+	entries := make([]AuditLogStreamEntry, 0)
+	for _, entry := range m.auditStream {
+		if entry.Seq <= afterSeq || (since != nil && entry.CreatedAt.Before(*since)) {
+			continue
+		}
+		if len(entries) == limit {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *MockStore) ExportAuditLogs(ctx context.Context, req AuditLogListRequest, fn func(AuditLogStreamEntry) error) error {
+	for _, entry := range m.auditStream {
+		if (req.Action != "" && entry.Action != req.Action) || (req.Resource != "" && entry.Resource != req.Resource) {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockStore) GetLastAuditSeqBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var seq int64
+	for _, entry := range m.auditStream {
+		if entry.CreatedAt.Before(cutoff) && entry.Seq > seq {
+			seq = entry.Seq
+		}
+	}
+	return seq, nil
+}
+
+func (m *MockStore) GetAuditForwardedSeq(ctx context.Context) (int64, error) {
+	return m.auditForwarded, nil
+}
+
+func (m *MockStore) ArchiveAuditLogs(ctx context.Context, archive AuditLogArchive) error {
+	remaining := make([]AuditLogStreamEntry, 0)
+	for _, entry := range m.auditStream {
+		if entry.Seq < archive.FromSeq || entry.Seq > archive.ToSeq {
+			remaining = append(remaining, entry)
+		}
+	}
+	m.auditStream = remaining
+	m.auditArchives = append(m.auditArchives, archive)
+	return nil
+}
+
+func (m *MockStore) GetLatestAuditArchive(ctx context.Context) (*AuditLogArchive, error) {
+	if len(m.auditArchives) == 0 {
+		return nil, nil
+	}
+	archive := m.auditArchives[len(m.auditArchives)-1]
+	return &archive, nil
+}
+
+// Moderation operations
+func (m *MockStore) GetModerationVerdicts(ctx context.Context, req ModerationListRequest) (ModerationListResponse, error) {
+	verdicts := make([]ModerationVerdict, 0)
+	for _, verdict := range m.verdicts {
+		if req.ReviewStatus != "" && verdict.ReviewStatus != req.ReviewStatus {
+			continue
+		}
+		verdicts = append(verdicts, verdict)
+	}
+
+	return ModerationListResponse{
+		Verdicts:   verdicts,
+		Total:      len(verdicts),
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: 1,
+	}, nil
+}
+
+func (m *MockStore) GetAPIKeys(ctx context.Context, req APIKeyListRequest) (APIKeyListResponse, error) {
+	return APIKeyListResponse{Keys: []AdminAPIKey{}, Page: req.Page, PageSize: req.PageSize, TotalPages: 1}, nil
+}
+
+func (m *MockStore) GetModerationVerdict(ctx context.Context, verdictID string) (ModerationVerdict, error) {
+	verdict, exists := m.verdicts[verdictID]
+	if !exists {
+		return ModerationVerdict{}, errors.New("moderation verdict not found")
+	}
+	return verdict, nil
+}
+
+func (m *MockStore) ReviewModerationVerdict(ctx context.Context, verdictID, reviewerID, status, note string) (ModerationVerdict, error) {
+	verdict, exists := m.verdicts[verdictID]
+	if !exists {
+		return ModerationVerdict{}, errors.New("moderation verdict not found")
+	}
+	now := time.Now()
+	verdict.ReviewStatus = status
+	verdict.ReviewedBy = &reviewerID
+	verdict.ReviewNote = &note
+	verdict.ReviewedAt = &now
+	m.verdicts[verdictID] = verdict
+	return verdict, nil
+}
+
 // Quota operations
 func (m *MockStore) RevokeUserQuota(ctx context.Context, userID string, quotaType string, amount int, reason string) error {
 	// Mock implementation
@@ -366,6 +553,18 @@ func (m *MockStore) GetSystemStats(ctx context.Context) (AdminStats, error) {
 	return m.systemStats, nil
 }
 
+func (m *MockStore) GetOnboardingCohorts(ctx context.Context, from, to time.Time, activationDays int) ([]OnboardingCohort, error) {
+	return m.cohorts, nil
+}
+
+func (m *MockStore) GetConversionCosts(ctx context.Context, from, to time.Time, groupBy string) ([]ConversionCostGroup, error) {
+	return m.costs, nil
+}
+
+func (m *MockStore) GetFeedbackStats(ctx context.Context, from, to time.Time) ([]FeedbackStatsGroup, error) {
+	return m.feedbackStats, nil
+}
+
 // Test cases
 
 func TestAdminService_GetUsers(t *testing.T) {
@@ -561,6 +760,98 @@ func TestAdminService_ActivateUser(t *testing.T) {
 	}
 }
 
+// mockImpersonationIssuer implements ImpersonationTokenIssuer for testing
+type mockImpersonationIssuer struct {
+	impersonatorID string
+	ttl            time.Duration
+}
+
+func (m *mockImpersonationIssuer) IssueImpersonationToken(ctx context.Context, userID, phone, role, impersonatorID string, ttl time.Duration) (string, time.Time, error) {
+	m.impersonatorID = impersonatorID
+	m.ttl = ttl
+	return "impersonation-token-" + userID, time.Now().Add(ttl), nil
+}
+
+// recordingAuditLogger captures audit actions for assertions
+type recordingAuditLogger struct {
+	actions []string
+}
+
+func (r *recordingAuditLogger) LogAction(ctx context.Context, userID *string, actorType, action, resource string, resourceID *string, metadata map[string]interface{}) error {
+	r.actions = append(r.actions, action)
+	return nil
+}
+
+func TestAdminService_ImpersonateUser(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	issuer := &mockImpersonationIssuer{}
+	auditLogger := &recordingAuditLogger{}
+	service.auditLogger = auditLogger
+	service.SetImpersonationIssuer(issuer, 10*time.Minute)
+
+	store.users["user1"] = AdminUser{
+		ID:       "user1",
+		Phone:    "1234567890",
+		Role:     "user",
+		IsActive: true,
+	}
+
+	resp, err := service.ImpersonateUser(context.Background(), "admin1", "user1", ImpersonateUserRequest{Reason: "support ticket"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.AccessToken != "impersonation-token-user1" {
+		t.Errorf("Expected impersonation token, got %s", resp.AccessToken)
+	}
+	if resp.ImpersonatorID != "admin1" || issuer.impersonatorID != "admin1" {
+		t.Errorf("Expected impersonator admin1, got %s", resp.ImpersonatorID)
+	}
+	if issuer.ttl != 10*time.Minute {
+		t.Errorf("Expected TTL 10m, got %v", issuer.ttl)
+	}
+	if len(auditLogger.actions) != 1 || auditLogger.actions[0] != ActionImpersonate {
+		t.Errorf("Expected impersonate audit action, got %v", auditLogger.actions)
+	}
+}
+
+func TestAdminService_ImpersonateUser_Rejected(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+
+	store.users["admin2"] = AdminUser{ID: "admin2", Role: "admin", IsActive: true}
+	store.users["inactive"] = AdminUser{ID: "inactive", Role: "user", IsActive: false}
+
+	req := ImpersonateUserRequest{Reason: "support ticket"}
+
+	// Not configured
+	if _, err := service.ImpersonateUser(context.Background(), "admin1", "inactive", req); err == nil {
+		t.Error("Expected error when impersonation is not configured")
+	}
+
+	service.SetImpersonationIssuer(&mockImpersonationIssuer{}, 0)
+	if service.impersonationTTL != DefaultImpersonationTTL {
+		t.Errorf("Expected default TTL, got %v", service.impersonationTTL)
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		req    ImpersonateUserRequest
+	}{
+		{"other admin", "admin2", req},
+		{"inactive user", "inactive", req},
+		{"self", "admin1", req},
+		{"missing reason", "inactive", ImpersonateUserRequest{}},
+		{"unknown user", "missing", req},
+	}
+	for _, tt := range tests {
+		if _, err := service.ImpersonateUser(context.Background(), "admin1", tt.userID, tt.req); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
+
 func TestAdminService_CreatePlan(t *testing.T) {
 	store := NewMockStore()
 	service, _ := WireAdminServiceWithMocks(store)
@@ -780,6 +1071,46 @@ func TestAdminService_GetConversionStats(t *testing.T) {
 	}
 }
 
+func TestAdminService_GetConversionWithLogs(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+
+	store.conversions["conv1"] = AdminConversion{ID: "conv1", Status: "failed"}
+	statusCode := 503
+	store.conversionLogs = map[string][]ConversionLogEntry{
+		"conv1": {
+			{ID: 3, Stage: "failed", Level: "error", Message: "failed to convert image with Gemini"},
+			{ID: 2, Stage: "provider", Level: "warn", Message: "gemini attempt 1 failed, retrying", StatusCode: &statusCode},
+			{ID: 1, Stage: "started", Level: "info", Message: "Job picked up by worker"},
+		},
+	}
+
+	conversion, err := service.GetConversion(context.Background(), "conv1", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(conversion.Logs) != 2 || conversion.Logs[0].Stage != "failed" {
+		t.Fatalf("Expected the 2 newest log entries, got %+v", conversion.Logs)
+	}
+	if conversion.Logs[1].StatusCode == nil || *conversion.Logs[1].StatusCode != 503 {
+		t.Errorf("Expected provider status code 503, got %v", conversion.Logs[1].StatusCode)
+	}
+
+	if _, err := service.GetConversion(context.Background(), "conv1", 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.lastLogLimit != DefaultConversionLogLimit {
+		t.Errorf("Expected default log limit %d, got %d", DefaultConversionLogLimit, store.lastLogLimit)
+	}
+
+	if _, err := service.GetConversion(context.Background(), "conv1", 10000); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.lastLogLimit != MaxConversionLogLimit {
+		t.Errorf("Expected log limit capped at %d, got %d", MaxConversionLogLimit, store.lastLogLimit)
+	}
+}
+
 func TestAdminService_GetImageStats(t *testing.T) {
 	store := NewMockStore()
 	service, _ := WireAdminServiceWithMocks(store)
@@ -797,3 +1128,343 @@ func TestAdminService_GetImageStats(t *testing.T) {
 		t.Fatalf("Expected total 1000, got %d", total)
 	}
 }
+
+func TestAdminService_ReviewModerationVerdict(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	auditLogger := &recordingAuditLogger{}
+	service.auditLogger = auditLogger
+
+	store.verdicts["v1"] = ModerationVerdict{
+		ID:           "v1",
+		ImageKind:    "user",
+		Allowed:      false,
+		Reasons:      []string{"nsfw_content"},
+		ReviewStatus: ModerationReviewPending,
+	}
+	store.verdicts["v2"] = ModerationVerdict{ID: "v2", ImageKind: "cloth", Allowed: true, ReviewStatus: ModerationReviewNone}
+
+	verdict, err := service.ReviewModerationVerdict(context.Background(), "admin1", "v1", ReviewModerationRequest{Decision: ModerationReviewOverturned, Note: "false positive"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if verdict.ReviewStatus != ModerationReviewOverturned {
+		t.Errorf("Expected review status %s, got %s", ModerationReviewOverturned, verdict.ReviewStatus)
+	}
+	if verdict.ReviewedBy == nil || *verdict.ReviewedBy != "admin1" {
+		t.Errorf("Expected reviewer admin1, got %v", verdict.ReviewedBy)
+	}
+	if len(auditLogger.actions) != 1 || auditLogger.actions[0] != ActionReview {
+		t.Errorf("Expected review audit action, got %v", auditLogger.actions)
+	}
+
+	// Already reviewed
+	if _, err := service.ReviewModerationVerdict(context.Background(), "admin1", "v1", ReviewModerationRequest{Decision: ModerationReviewConfirmed}); err == nil {
+		t.Error("Expected error when reviewing a verdict twice")
+	}
+
+	// Allowed verdicts do not need review
+	if _, err := service.ReviewModerationVerdict(context.Background(), "admin1", "v2", ReviewModerationRequest{Decision: ModerationReviewConfirmed}); err == nil {
+		t.Error("Expected error when reviewing an allowed verdict")
+	}
+
+	// Invalid decision
+	store.verdicts["v3"] = ModerationVerdict{ID: "v3", ReviewStatus: ModerationReviewPending}
+	if _, err := service.ReviewModerationVerdict(context.Background(), "admin1", "v3", ReviewModerationRequest{Decision: "maybe"}); err == nil {
+		t.Error("Expected error for invalid decision")
+	}
+}
+
+func TestAdminService_BoostConversion(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	auditLogger := &recordingAuditLogger{}
+	service.auditLogger = auditLogger
+
+	store.jobPriorities["conv1"] = 5
+
+	boost, err := service.BoostConversion(context.Background(), "admin1", "conv1", BoostConversionRequest{Priority: 50, Reason: "vip support ticket"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if boost.PreviousPriority != 5 || boost.Priority != 50 {
+		t.Errorf("Expected priority 5 -> 50, got %d -> %d", boost.PreviousPriority, boost.Priority)
+	}
+	if store.jobPriorities["conv1"] != 50 {
+		t.Errorf("Expected job priority 50, got %d", store.jobPriorities["conv1"])
+	}
+	if len(auditLogger.actions) != 1 || auditLogger.actions[0] != ActionBoost {
+		t.Errorf("Expected boost audit action, got %v", auditLogger.actions)
+	}
+
+	// No pending job
+	_, err = service.BoostConversion(context.Background(), "admin1", "missing", BoostConversionRequest{Priority: 50})
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestAdminService_RequeueConversion(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	auditLogger := &recordingAuditLogger{}
+	service.auditLogger = auditLogger
+
+	store.deadLetters["conv1"] = DeadLetterConversion{ID: "conv1", Requeues: 1}
+	store.jobPriorities["conv1"] = 7
+	store.conversions["conv2"] = AdminConversion{ID: "conv2", Status: "completed"}
+
+	requeue, err := service.RequeueConversion(context.Background(), "admin1", "conv1", RequeueConversionRequest{Reason: "provider recovered"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requeue.Priority != 7 || requeue.Requeues != 2 || requeue.RequeuedBy != "admin1" {
+		t.Errorf("Expected priority 7 on the second requeue by admin1, got %+v", requeue)
+	}
+	if _, exists := store.deadLetters["conv1"]; exists {
+		t.Error("Expected conversion to leave the dead-letter queue")
+	}
+	if len(auditLogger.actions) != 1 || auditLogger.actions[0] != ActionRequeue {
+		t.Errorf("Expected requeue audit action, got %v", auditLogger.actions)
+	}
+
+	// Requeued conversions are no longer dead-lettered
+	if _, err := service.RequeueConversion(context.Background(), "admin1", "conv1", RequeueConversionRequest{}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if _, err := service.RequeueConversion(context.Background(), "admin1", "conv2", RequeueConversionRequest{}); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected conflict for a conversion that is not dead-lettered, got %v", err)
+	}
+}
+
+// appendAuditStreamEntry chains a new entry onto the mock audit stream
+func appendAuditStreamEntry(store *MockStore, action string) {
+	prev := AuditChainGenesisHash
+	if n := len(store.auditStream); n > 0 {
+		prev = store.auditStream[n-1].EntryHash
+	}
+	entry := AuditLogStreamEntry{
+		Seq:          int64(len(store.auditStream) + 1),
+		ID:           "log" + action,
+		HashedUserID: "admin1",
+		ActorType:    "admin",
+		Action:       action,
+		Resource:     ResourceUser,
+		Metadata:     []byte(`{"reason": "test"}`),
+		CreatedAt:    time.Date(2025, time.June, 1, 12, 0, 0, 123456000, time.UTC),
+		PrevHash:     prev,
+	}
+	entry.EntryHash = ComputeAuditEntryHash(prev, entry)
+	store.auditStream = append(store.auditStream, entry)
+}
+
+func TestAdminService_StreamAuditLogs(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	ctx := context.Background()
+
+	for _, action := range []string{"a", "b", "c"} {
+		appendAuditStreamEntry(store, action)
+	}
+
+	first, err := service.StreamAuditLogs(ctx, AuditLogStreamRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(first.Entries) != 2 || !first.HasMore || first.NextCursor != "2" {
+		t.Errorf("Expected 2 entries with more after cursor 2, got %d, %v, %s", len(first.Entries), first.HasMore, first.NextCursor)
+	}
+	if !first.ChainVerified {
+		t.Errorf("Expected chain to verify, broken at %v", first.BrokenAtSeq)
+	}
+
+	second, err := service.StreamAuditLogs(ctx, AuditLogStreamRequest{Since: first.NextCursor, Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(second.Entries) != 1 || second.HasMore || second.Entries[0].Seq != 3 {
+		t.Errorf("Expected final entry 3, got %+v", second.Entries)
+	}
+
+	// Tampering with an entry breaks the chain at that entry
+	store.auditStream[1].Action = "tampered"
+	tampered, err := service.StreamAuditLogs(ctx, AuditLogStreamRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tampered.ChainVerified || tampered.BrokenAtSeq == nil || *tampered.BrokenAtSeq != 2 {
+		t.Errorf("Expected chain broken at seq 2, got %v", tampered.BrokenAtSeq)
+	}
+
+	if _, err := service.StreamAuditLogs(ctx, AuditLogStreamRequest{Since: "yesterday"}); err == nil {
+		t.Error("Expected error for invalid since")
+	}
+}
+
+type mockArchiveWriter map[string][]byte
+
+func (w mockArchiveWriter) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	w[key] = data
+	return nil
+}
+
+func TestAdminService_ArchiveExpiredAuditLogs(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	ctx := context.Background()
+
+	for _, action := range []string{"a", "b", "c"} {
+		appendAuditStreamEntry(store, action)
+	}
+	// The latest entry is still within the retention period
+	recent := &store.auditStream[2]
+	recent.CreatedAt = time.Now().UTC()
+	recent.EntryHash = ComputeAuditEntryHash(recent.PrevHash, *recent)
+	archivedHash := store.auditStream[1].EntryHash
+
+	// Disabled until an archive writer is set
+	if archived, err := service.ArchiveExpiredAuditLogs(ctx); err != nil || archived != 0 {
+		t.Fatalf("Expected nothing archived, got %d (%v)", archived, err)
+	}
+
+	writer := mockArchiveWriter{}
+	service.SetAuditArchive(writer, AuditRetentionConfig{RetentionDays: 90, BatchSize: 1})
+
+	archived, err := service.ArchiveExpiredAuditLogs(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if archived != 2 || len(writer) != 2 || len(store.auditArchives) != 2 {
+		t.Fatalf("Expected 2 entries archived in 2 objects, got %d entries, %d objects", archived, len(writer))
+	}
+	if len(store.auditStream) != 1 || store.auditStream[0].Seq != 3 {
+		t.Errorf("Expected only entry 3 to remain, got %+v", store.auditStream)
+	}
+	if data := writer[store.auditArchives[0].ObjectKey]; !strings.Contains(string(data), `"action":"a"`) {
+		t.Errorf("Expected archive to hold entry a, got %s", data)
+	}
+
+	// The remaining chain verifies from the last archived entry
+	stream, err := service.StreamAuditLogs(ctx, AuditLogStreamRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !stream.ChainVerified || store.auditArchives[1].LastEntryHash != archivedHash {
+		t.Errorf("Expected chain to verify after archiving, broken at %v", stream.BrokenAtSeq)
+	}
+}
+
+func TestAdminService_ArchiveExpiredAuditLogs_RequireForwarded(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+
+	for _, action := range []string{"a", "b", "c"} {
+		appendAuditStreamEntry(store, action)
+	}
+	store.auditForwarded = 1
+
+	service.SetAuditArchive(mockArchiveWriter{}, AuditRetentionConfig{RetentionDays: 90, RequireForwarded: true})
+	archived, err := service.ArchiveExpiredAuditLogs(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if archived != 1 || len(store.auditStream) != 2 {
+		t.Errorf("Expected only the forwarded entry to be archived, got %d", archived)
+	}
+}
+
+func TestAdminService_GetOnboardingCohorts(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	ctx := context.Background()
+
+	store.cohorts = []OnboardingCohort{{
+		Signups:          30,
+		GrantedSignups:   20,
+		GrantedActivated: 9,
+		ControlSignups:   10,
+		ControlActivated: 3,
+	}}
+
+	response, err := service.GetOnboardingCohorts(ctx, OnboardingCohortRequest{DateFrom: "2025-01-01", DateTo: "2025-03-31"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.ActivationDays != 7 {
+		t.Errorf("Expected default activation window of 7 days, got %d", response.ActivationDays)
+	}
+	if !response.DateTo.Equal(time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected dateTo to include the last day, got %v", response.DateTo)
+	}
+	cohort := response.Cohorts[0]
+	if cohort.GrantedActivationRate != 0.45 || cohort.ControlActivationRate != 0.3 {
+		t.Errorf("Expected rates 0.45 and 0.3, got %.4f and %.4f", cohort.GrantedActivationRate, cohort.ControlActivationRate)
+	}
+
+	if _, err := service.GetOnboardingCohorts(ctx, OnboardingCohortRequest{DateFrom: "2025-04-01", DateTo: "2025-03-01"}); err == nil {
+		t.Error("Expected error when dateFrom is after dateTo")
+	}
+	if _, err := service.GetOnboardingCohorts(ctx, OnboardingCohortRequest{DateFrom: "yesterday"}); err == nil {
+		t.Error("Expected error for invalid dateFrom")
+	}
+}
+
+func TestAdminService_GetFeedbackStats(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	ctx := context.Background()
+
+	store.feedbackStats = []FeedbackStatsGroup{
+		{StyleName: "casual", Provider: "gemini", Ratings: 3, AverageRating: 2, LowRatings: 2, Issues: map[string]int{"garment_misfit": 2}},
+		{StyleName: "formal", Provider: "gemini", Ratings: 1, AverageRating: 5, Issues: map[string]int{}},
+	}
+
+	response, err := service.GetFeedbackStats(ctx, FeedbackStatsRequest{DateFrom: "2025-06-01", DateTo: "2025-06-30"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.TotalRatings != 4 || response.AverageRating != 2.75 {
+		t.Errorf("Expected 4 ratings averaging 2.75, got %d and %.2f", response.TotalRatings, response.AverageRating)
+	}
+	if response.Issues["garment_misfit"] != 2 {
+		t.Errorf("Expected 2 garment_misfit issues, got %d", response.Issues["garment_misfit"])
+	}
+
+	if _, err := service.GetFeedbackStats(ctx, FeedbackStatsRequest{DateFrom: "2025-07-01", DateTo: "2025-06-01"}); err == nil {
+		t.Error("Expected error when dateFrom is after dateTo")
+	}
+}
+
+func TestAdminService_GetConversionCosts(t *testing.T) {
+	store := NewMockStore()
+	service, _ := WireAdminServiceWithMocks(store)
+	ctx := context.Background()
+
+	store.costs = []ConversionCostGroup{
+		{Key: "premium", Conversions: 40, Cost: 1.6},
+		{Key: "free", Conversions: 25, Cost: 1.0},
+	}
+
+	response, err := service.GetConversionCosts(ctx, ConversionCostRequest{DateFrom: "2025-06-01", DateTo: "2025-06-30", GroupBy: CostGroupByPlan})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.TotalConversions != 65 || response.TotalCost != 2.6 {
+		t.Errorf("Expected totals of 65 conversions and 2.6, got %d and %.4f", response.TotalConversions, response.TotalCost)
+	}
+	if !response.DateTo.Equal(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected dateTo to include the last day, got %v", response.DateTo)
+	}
+
+	response, err = service.GetConversionCosts(ctx, ConversionCostRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.GroupBy != CostGroupByProvider {
+		t.Errorf("Expected costs to be grouped by provider by default, got %q", response.GroupBy)
+	}
+
+	if _, err := service.GetConversionCosts(ctx, ConversionCostRequest{GroupBy: "vendor"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected validation error for unknown grouping, got %v", err)
+	}
+}
@@ -3,15 +3,21 @@ package admin
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
 )
 
 // DBStore implements the Store interface using PostgreSQL
 type DBStore struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *common.DBRouter // nil reads everything from db
 }
 
 // NewDBStore creates a new database store
@@ -19,64 +25,53 @@ func NewDBStore(db *sql.DB) *DBStore {
 	return &DBStore{db: db}
 }
 
-// User operations
-
-// GetUsers retrieves a list of users with pagination and filtering
-func (s *DBStore) GetUsers(ctx context.Context, req UserListRequest) (UserListResponse, error) {
-	query := `
-		SELECT 
-			u.id, u.phone, u.name, u.avatar_url, u.bio, u.role, 
-			u.is_phone_verified, u.free_conversions_used, u.free_conversions_limit,
-			u.created_at, u.updated_at, u.is_active,
-			COALESCE(s.last_used_at, u.created_at) as last_login_at
-		FROM users u
-		LEFT JOIN (
-			SELECT DISTINCT ON (user_id) user_id, last_used_at
-			FROM sessions 
-			WHERE revoked_at IS NULL
-			ORDER BY user_id, last_used_at DESC
-		) s ON u.id = s.user_id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
+// SetReadReplicas sends list, report and stats queries to the router's read
+// replicas. Writes and the audit chain bookkeeping stay on the primary.
+func (s *DBStore) SetReadReplicas(router *common.DBRouter) {
+	s.replicas = router
+}
 
-	// Add filters
-	if req.Role != "" {
-		query += " AND u.role = $" + strconv.Itoa(argIndex)
-		args = append(args, req.Role)
-		argIndex++
+// reader returns the connection for a read-only admin query
+func (s *DBStore) reader(ctx context.Context) common.DBTX {
+	if s.replicas == nil {
+		return common.Conn(ctx, s.db)
 	}
+	return s.replicas.Reader(ctx)
+}
 
-	if req.Search != "" {
-		query += fmt.Sprintf(" AND (u.phone ILIKE $%d OR u.name ILIKE $%d)", argIndex, argIndex+1)
-		searchTerm := "%" + req.Search + "%"
-		args = append(args, searchTerm, searchTerm)
-		argIndex += 2
+// writer returns the connection for a statement that changes data, so the
+// admin's following reads see the change even before replicas replayed it
+func (s *DBStore) writer(ctx context.Context) common.DBTX {
+	if s.replicas == nil {
+		return common.Conn(ctx, s.db)
 	}
+	return s.replicas.Writer(ctx)
+}
 
-	if req.IsActive != nil {
-		query += " AND u.is_active = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.IsActive)
-		argIndex++
+// User operations
+
+// GetUsers retrieves a list of users with pagination and filtering
+func (s *DBStore) GetUsers(ctx context.Context, req UserListRequest) (UserListResponse, error) {
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return UserListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT u.id, u.phone, u.name, u.avatar_url, u.bio, u.role, u.is_phone_verified, u.free_conversions_used, u.free_conversions_limit, u.created_at, u.updated_at, u.is_active, COALESCE(s.last_used_at, u.created_at) as last_login_at", "SELECT COUNT(*)", 1)
-	countQuery = strings.Replace(countQuery, "LEFT JOIN (", "", 1)
-	countQuery = strings.Replace(countQuery, ") s ON u.id = s.user_id", "", 1)
+	q := userListQuery(req)
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return UserListResponse{}, fmt.Errorf("failed to count users: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return UserListResponse{}, fmt.Errorf("failed to count users: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY u.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("u.created_at", "u.id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return UserListResponse{}, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -106,6 +101,9 @@ func (s *DBStore) GetUsers(ctx context.Context, req UserListRequest) (UserListRe
 		return UserListResponse{}, fmt.Errorf("error iterating users: %w", err)
 	}
 
+	users, nextCursor := common.NextPage(users, req.PageSize, func(item AdminUser) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return UserListResponse{
@@ -114,6 +112,7 @@ func (s *DBStore) GetUsers(ctx context.Context, req UserListRequest) (UserListRe
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -137,7 +136,7 @@ func (s *DBStore) GetUser(ctx context.Context, userID string) (AdminUser, error)
 
 	var user AdminUser
 	var lastLoginAt sql.NullTime
-	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, userID).Scan(
 		&user.ID, &user.Phone, &user.Name, &user.AvatarURL, &user.Bio, &user.Role,
 		&user.IsPhoneVerified, &user.FreeConversionsUsed, &user.FreeConversionsLimit,
 		&user.CreatedAt, &user.UpdatedAt, &user.IsActive, &lastLoginAt,
@@ -217,7 +216,7 @@ func (s *DBStore) UpdateUser(ctx context.Context, userID string, req UpdateUserR
 		WHERE id = $%d
 	`, strings.Join(setParts, ", "), argIndex)
 
-	_, err := s.db.ExecContext(ctx, query, args...)
+	_, err := s.writer(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return AdminUser{}, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -228,7 +227,7 @@ func (s *DBStore) UpdateUser(ctx context.Context, userID string, req UpdateUserR
 // DeleteUser deletes a user
 func (s *DBStore) DeleteUser(ctx context.Context, userID string) error {
 	query := "DELETE FROM users WHERE id = $1"
-	_, err := s.db.ExecContext(ctx, query, userID)
+	_, err := s.writer(ctx).ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -245,7 +244,7 @@ func (s *DBStore) GetUserStats(ctx context.Context) (int, int, error) {
 	`
 
 	var total, active int
-	err := s.db.QueryRowContext(ctx, query).Scan(&total, &active)
+	err := s.reader(ctx).QueryRowContext(ctx, query).Scan(&total, &active)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get user stats: %w", err)
 	}
@@ -257,61 +256,26 @@ func (s *DBStore) GetUserStats(ctx context.Context) (int, int, error) {
 
 // GetVendors retrieves a list of vendors with pagination and filtering
 func (s *DBStore) GetVendors(ctx context.Context, req VendorListRequest) (VendorListResponse, error) {
-	query := `
-		SELECT 
-			v.id, v.user_id, v.business_name, v.avatar_url, v.bio,
-			v.contact_info, v.social_links, v.is_verified, v.is_active,
-			v.free_images_used, v.free_images_limit, v.created_at, v.updated_at,
-			COALESCE(s.last_used_at, v.created_at) as last_login_at
-		FROM vendors v
-		JOIN users u ON v.user_id = u.id
-		LEFT JOIN (
-			SELECT DISTINCT ON (user_id) user_id, last_used_at
-			FROM sessions 
-			WHERE revoked_at IS NULL
-			ORDER BY user_id, last_used_at DESC
-		) s ON v.user_id = s.user_id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.Search != "" {
-		query += fmt.Sprintf(" AND (v.business_name ILIKE $%d OR u.phone ILIKE $%d)", argIndex, argIndex+1)
-		searchTerm := "%" + req.Search + "%"
-		args = append(args, searchTerm, searchTerm)
-		argIndex += 2
-	}
-
-	if req.IsActive != nil {
-		query += fmt.Sprintf(" AND v.is_active = $%d", argIndex)
-		args = append(args, *req.IsActive)
-		argIndex++
-	}
-
-	if req.IsVerified != nil {
-		query += fmt.Sprintf(" AND v.is_verified = $%d", argIndex)
-		args = append(args, *req.IsVerified)
-		argIndex++
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return VendorListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT v.id, v.user_id, v.business_name, v.avatar_url, v.bio, v.contact_info, v.social_links, v.is_verified, v.is_active, v.free_images_used, v.free_images_limit, v.created_at, v.updated_at, COALESCE(s.last_used_at, v.created_at) as last_login_at", "SELECT COUNT(*)", 1)
-	countQuery = strings.Replace(countQuery, "LEFT JOIN (", "", 1)
-	countQuery = strings.Replace(countQuery, ") s ON v.user_id = s.user_id", "", 1)
+	q := vendorListQuery(req)
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return VendorListResponse{}, fmt.Errorf("failed to count vendors: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return VendorListResponse{}, fmt.Errorf("failed to count vendors: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY v.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("v.created_at", "v.id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return VendorListResponse{}, fmt.Errorf("failed to query vendors: %w", err)
 	}
@@ -356,6 +320,9 @@ func (s *DBStore) GetVendors(ctx context.Context, req VendorListRequest) (Vendor
 		return VendorListResponse{}, fmt.Errorf("error iterating vendors: %w", err)
 	}
 
+	vendors, nextCursor := common.NextPage(vendors, req.PageSize, func(item AdminVendor) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return VendorListResponse{
@@ -364,6 +331,7 @@ func (s *DBStore) GetVendors(ctx context.Context, req VendorListRequest) (Vendor
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -390,7 +358,7 @@ func (s *DBStore) GetVendor(ctx context.Context, vendorID string) (AdminVendor,
 	var lastLoginAt sql.NullTime
 	var contactInfoJSON, socialLinksJSON []byte
 
-	err := s.db.QueryRowContext(ctx, query, vendorID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, vendorID).Scan(
 		&vendor.ID, &vendor.UserID, &vendor.BusinessName, &vendor.AvatarURL, &vendor.Bio,
 		&contactInfoJSON, &socialLinksJSON, &vendor.IsVerified, &vendor.IsActive,
 		&vendor.FreeImagesUsed, &vendor.FreeImagesLimit, &vendor.CreatedAt, &vendor.UpdatedAt, &lastLoginAt,
@@ -475,7 +443,7 @@ func (s *DBStore) UpdateVendor(ctx context.Context, vendorID string, req UpdateV
 		WHERE id = $%d
 	`, strings.Join(setParts, ", "), argIndex)
 
-	_, err := s.db.ExecContext(ctx, query, args...)
+	_, err := s.writer(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return AdminVendor{}, fmt.Errorf("failed to update vendor: %w", err)
 	}
@@ -486,7 +454,7 @@ func (s *DBStore) UpdateVendor(ctx context.Context, vendorID string, req UpdateV
 // DeleteVendor deletes a vendor
 func (s *DBStore) DeleteVendor(ctx context.Context, vendorID string) error {
 	query := "DELETE FROM vendors WHERE id = $1"
-	_, err := s.db.ExecContext(ctx, query, vendorID)
+	_, err := s.writer(ctx).ExecContext(ctx, query, vendorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete vendor: %w", err)
 	}
@@ -503,7 +471,7 @@ func (s *DBStore) GetVendorStats(ctx context.Context) (int, int, error) {
 	`
 
 	var total, active int
-	err := s.db.QueryRowContext(ctx, query).Scan(&total, &active)
+	err := s.reader(ctx).QueryRowContext(ctx, query).Scan(&total, &active)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get vendor stats: %w", err)
 	}
@@ -515,49 +483,16 @@ func (s *DBStore) GetVendorStats(ctx context.Context) (int, int, error) {
 
 // GetPlans retrieves a list of plans with pagination and filtering
 func (s *DBStore) GetPlans(ctx context.Context, req PlanListRequest) (PlanListResponse, error) {
-	query := `
-		SELECT 
-			p.id, p.name, p.display_name, p.description, p.price_per_month_cents,
-			p.monthly_conversions_limit, p.features, p.is_active, p.created_at, p.updated_at,
-			COUNT(up.id) as subscriber_count
-		FROM payment_plans p
-		LEFT JOIN user_plans up ON p.id = up.plan_id AND up.status = 'active'
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.IsActive != nil {
-		query += fmt.Sprintf(" AND p.is_active = $%d", argIndex)
-		args = append(args, *req.IsActive)
-		argIndex++
-	}
-
-	query += " GROUP BY p.id, p.name, p.display_name, p.description, p.price_per_month_cents, p.monthly_conversions_limit, p.features, p.is_active, p.created_at, p.updated_at"
-
-	// Get total count
-	countQuery := `
-		SELECT COUNT(*)
-		FROM payment_plans p
-		WHERE 1=1
-	`
-	if req.IsActive != nil {
-		countQuery += " AND p.is_active = $1"
-	}
+	q := planListQuery(req)
 
 	var total int
-	err := s.db.QueryRowContext(ctx, countQuery, args[:argIndex-1]...).Scan(&total)
-	if err != nil {
+	countQuery, countArgs := q.count()
+	if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return PlanListResponse{}, fmt.Errorf("failed to count plans: %w", err)
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY p.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.offsetPage("p.created_at DESC", req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return PlanListResponse{}, fmt.Errorf("failed to query plans: %w", err)
 	}
@@ -570,7 +505,7 @@ func (s *DBStore) GetPlans(ctx context.Context, req PlanListRequest) (PlanListRe
 
 		err := rows.Scan(
 			&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-			&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt, &plan.SubscriberCount,
+			&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.TrialDays, &plan.CreatedAt, &plan.UpdatedAt, &plan.SubscriberCount,
 		)
 		if err != nil {
 			return PlanListResponse{}, fmt.Errorf("failed to scan plan: %w", err)
@@ -606,20 +541,20 @@ func (s *DBStore) GetPlan(ctx context.Context, planID string) (AdminPlan, error)
 	query := `
 		SELECT 
 			p.id, p.name, p.display_name, p.description, p.price_per_month_cents,
-			p.monthly_conversions_limit, p.features, p.is_active, p.created_at, p.updated_at,
+			p.monthly_conversions_limit, p.features, p.is_active, p.trial_days, p.created_at, p.updated_at,
 			COUNT(up.id) as subscriber_count
 		FROM payment_plans p
 		LEFT JOIN user_plans up ON p.id = up.plan_id AND up.status = 'active'
 		WHERE p.id = $1
-		GROUP BY p.id, p.name, p.display_name, p.description, p.price_per_month_cents, p.monthly_conversions_limit, p.features, p.is_active, p.created_at, p.updated_at
+		GROUP BY p.id, p.name, p.display_name, p.description, p.price_per_month_cents, p.monthly_conversions_limit, p.features, p.is_active, p.trial_days, p.created_at, p.updated_at
 	`
 
 	var plan AdminPlan
 	var featuresJSON []byte
 
-	err := s.db.QueryRowContext(ctx, query, planID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, planID).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-		&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt, &plan.SubscriberCount,
+		&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.TrialDays, &plan.CreatedAt, &plan.UpdatedAt, &plan.SubscriberCount,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -640,9 +575,9 @@ func (s *DBStore) GetPlan(ctx context.Context, planID string) (AdminPlan, error)
 // CreatePlan creates a new subscription plan
 func (s *DBStore) CreatePlan(ctx context.Context, req CreatePlanRequest) (AdminPlan, error) {
 	query := `
-		INSERT INTO payment_plans (name, display_name, description, price_per_month_cents, monthly_conversions_limit, features, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, name, display_name, description, price_per_month_cents, monthly_conversions_limit, features, is_active, created_at, updated_at
+		INSERT INTO payment_plans (name, display_name, description, price_per_month_cents, monthly_conversions_limit, features, is_active, trial_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, name, display_name, description, price_per_month_cents, monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at
 	`
 
 	var plan AdminPlan
@@ -650,9 +585,9 @@ func (s *DBStore) CreatePlan(ctx context.Context, req CreatePlanRequest) (AdminP
 	// In a real implementation, you'd use json.Marshal here
 	featuresJSON := []byte("[]")
 
-	err := s.db.QueryRowContext(ctx, query, req.Name, req.DisplayName, req.Description, req.PricePerMonthCents, req.MonthlyConversionsLimit, featuresJSON, req.IsActive).Scan(
+	err := s.writer(ctx).QueryRowContext(ctx, query, req.Name, req.DisplayName, req.Description, req.PricePerMonthCents, req.MonthlyConversionsLimit, featuresJSON, req.IsActive, req.TrialDays).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-		&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt,
+		&plan.MonthlyConversionsLimit, &featuresJSON, &plan.IsActive, &plan.TrialDays, &plan.CreatedAt, &plan.UpdatedAt,
 	)
 	if err != nil {
 		return AdminPlan{}, fmt.Errorf("failed to create plan: %w", err)
@@ -705,6 +640,12 @@ func (s *DBStore) UpdatePlan(ctx context.Context, planID string, req UpdatePlanR
 		argIndex++
 	}
 
+	if req.TrialDays != nil {
+		setParts = append(setParts, fmt.Sprintf("trial_days = $%d", argIndex))
+		args = append(args, *req.TrialDays)
+		argIndex++
+	}
+
 	if len(setParts) == 0 {
 		return s.GetPlan(ctx, planID)
 	}
@@ -718,7 +659,7 @@ func (s *DBStore) UpdatePlan(ctx context.Context, planID string, req UpdatePlanR
 		WHERE id = $%d
 	`, strings.Join(setParts, ", "), argIndex)
 
-	_, err := s.db.ExecContext(ctx, query, args...)
+	_, err := s.writer(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return AdminPlan{}, fmt.Errorf("failed to update plan: %w", err)
 	}
@@ -729,7 +670,7 @@ func (s *DBStore) UpdatePlan(ctx context.Context, planID string, req UpdatePlanR
 // DeletePlan deletes a subscription plan
 func (s *DBStore) DeletePlan(ctx context.Context, planID string) error {
 	query := "DELETE FROM payment_plans WHERE id = $1"
-	_, err := s.db.ExecContext(ctx, query, planID)
+	_, err := s.writer(ctx).ExecContext(ctx, query, planID)
 	if err != nil {
 		return fmt.Errorf("failed to delete plan: %w", err)
 	}
@@ -740,67 +681,26 @@ func (s *DBStore) DeletePlan(ctx context.Context, planID string) error {
 
 // GetPayments retrieves a list of payments with pagination and filtering
 func (s *DBStore) GetPayments(ctx context.Context, req PaymentListRequest) (PaymentListResponse, error) {
-	query := `
-		SELECT 
-			p.id, p.user_id, u.phone, p.plan_id, pp.name as plan_name,
-			p.amount, p.currency, p.status, p.payment_method, p.gateway,
-			p.gateway_track_id, p.gateway_ref_number, p.gateway_card_number,
-			p.description, p.created_at, p.updated_at, p.paid_at, p.expires_at
-		FROM payments p
-		JOIN users u ON p.user_id = u.id
-		JOIN payment_plans pp ON p.plan_id = pp.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.Status != "" {
-		query += fmt.Sprintf(" AND p.status = $%d", argIndex)
-		args = append(args, req.Status)
-		argIndex++
-	}
-
-	if req.UserID != "" {
-		query += fmt.Sprintf(" AND p.user_id = $%d", argIndex)
-		args = append(args, req.UserID)
-		argIndex++
-	}
-
-	if req.PlanID != "" {
-		query += fmt.Sprintf(" AND p.plan_id = $%d", argIndex)
-		args = append(args, req.PlanID)
-		argIndex++
-	}
-
-	if req.DateFrom != "" {
-		query += fmt.Sprintf(" AND p.created_at >= $%d", argIndex)
-		args = append(args, req.DateFrom)
-		argIndex++
-	}
-
-	if req.DateTo != "" {
-		query += fmt.Sprintf(" AND p.created_at <= $%d", argIndex)
-		args = append(args, req.DateTo)
-		argIndex++
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return PaymentListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT p.id, p.user_id, u.phone, p.plan_id, pp.name as plan_name, p.amount, p.currency, p.status, p.payment_method, p.gateway, p.gateway_track_id, p.gateway_ref_number, p.gateway_card_number, p.description, p.created_at, p.updated_at, p.paid_at, p.expires_at", "SELECT COUNT(*)", 1)
-	countQuery = strings.Replace(countQuery, "JOIN users u ON p.user_id = u.id", "", 1)
-	countQuery = strings.Replace(countQuery, "JOIN payment_plans pp ON p.plan_id = pp.id", "", 1)
+	q := paymentListQuery(req)
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return PaymentListResponse{}, fmt.Errorf("failed to count payments: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return PaymentListResponse{}, fmt.Errorf("failed to count payments: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY p.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("p.created_at", "p.id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return PaymentListResponse{}, fmt.Errorf("failed to query payments: %w", err)
 	}
@@ -827,6 +727,9 @@ func (s *DBStore) GetPayments(ctx context.Context, req PaymentListRequest) (Paym
 		return PaymentListResponse{}, fmt.Errorf("error iterating payments: %w", err)
 	}
 
+	payments, nextCursor := common.NextPage(payments, req.PageSize, func(item AdminPayment) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return PaymentListResponse{
@@ -835,6 +738,7 @@ func (s *DBStore) GetPayments(ctx context.Context, req PaymentListRequest) (Paym
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -853,7 +757,7 @@ func (s *DBStore) GetPayment(ctx context.Context, paymentID string) (AdminPaymen
 	`
 
 	var payment AdminPayment
-	err := s.db.QueryRowContext(ctx, query, paymentID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, paymentID).Scan(
 		&payment.ID, &payment.UserID, &payment.UserPhone, &payment.PlanID, &payment.PlanName,
 		&payment.Amount, &payment.Currency, &payment.Status, &payment.PaymentMethod, &payment.Gateway,
 		&payment.GatewayTrackID, &payment.GatewayRefNumber, &payment.GatewayCardNumber,
@@ -880,7 +784,7 @@ func (s *DBStore) GetPaymentStats(ctx context.Context) (int, int64, error) {
 
 	var total int
 	var revenue int64
-	err := s.db.QueryRowContext(ctx, query).Scan(&total, &revenue)
+	err := s.reader(ctx).QueryRowContext(ctx, query).Scan(&total, &revenue)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get payment stats: %w", err)
 	}
@@ -892,64 +796,26 @@ func (s *DBStore) GetPaymentStats(ctx context.Context) (int, int64, error) {
 
 // GetConversions retrieves a list of conversions with pagination and filtering
 func (s *DBStore) GetConversions(ctx context.Context, req ConversionListRequest) (ConversionListResponse, error) {
-	query := `
-		SELECT 
-			uc.id, uc.user_id, u.phone, uc.conversion_type, uc.input_file_url,
-			uc.output_file_url, uc.style_name, uc.status, uc.error_message,
-			uc.processing_time_ms, uc.file_size_bytes, uc.created_at, uc.completed_at
-		FROM user_conversions uc
-		JOIN users u ON uc.user_id = u.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.Status != "" {
-		query += fmt.Sprintf(" AND uc.status = $%d", argIndex)
-		args = append(args, req.Status)
-		argIndex++
-	}
-
-	if req.UserID != "" {
-		query += fmt.Sprintf(" AND uc.user_id = $%d", argIndex)
-		args = append(args, req.UserID)
-		argIndex++
-	}
-
-	if req.Type != "" {
-		query += fmt.Sprintf(" AND uc.conversion_type = $%d", argIndex)
-		args = append(args, req.Type)
-		argIndex++
-	}
-
-	if req.DateFrom != "" {
-		query += fmt.Sprintf(" AND uc.created_at >= $%d", argIndex)
-		args = append(args, req.DateFrom)
-		argIndex++
-	}
-
-	if req.DateTo != "" {
-		query += fmt.Sprintf(" AND uc.created_at <= $%d", argIndex)
-		args = append(args, req.DateTo)
-		argIndex++
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return ConversionListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT uc.id, uc.user_id, u.phone, uc.conversion_type, uc.input_file_url, uc.output_file_url, uc.style_name, uc.status, uc.error_message, uc.processing_time_ms, uc.file_size_bytes, uc.created_at, uc.completed_at", "SELECT COUNT(*)", 1)
-	countQuery = strings.Replace(countQuery, "JOIN users u ON uc.user_id = u.id", "", 1)
+	q := conversionListQuery(req)
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return ConversionListResponse{}, fmt.Errorf("failed to count conversions: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return ConversionListResponse{}, fmt.Errorf("failed to count conversions: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY uc.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("uc.created_at", "uc.id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return ConversionListResponse{}, fmt.Errorf("failed to query conversions: %w", err)
 	}
@@ -976,6 +842,9 @@ func (s *DBStore) GetConversions(ctx context.Context, req ConversionListRequest)
 		return ConversionListResponse{}, fmt.Errorf("error iterating conversions: %w", err)
 	}
 
+	conversions, nextCursor := common.NextPage(conversions, req.PageSize, func(item AdminConversion) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return ConversionListResponse{
@@ -984,6 +853,7 @@ func (s *DBStore) GetConversions(ctx context.Context, req ConversionListRequest)
 		Page:        req.Page,
 		PageSize:    req.PageSize,
 		TotalPages:  totalPages,
+		NextCursor:  nextCursor,
 	}, nil
 }
 
@@ -1000,7 +870,7 @@ func (s *DBStore) GetConversion(ctx context.Context, conversionID string) (Admin
 	`
 
 	var conversion AdminConversion
-	err := s.db.QueryRowContext(ctx, query, conversionID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, conversionID).Scan(
 		&conversion.ID, &conversion.UserID, &conversion.UserPhone, &conversion.ConversionType,
 		&conversion.InputFileURL, &conversion.OutputFileURL, &conversion.StyleName, &conversion.Status,
 		&conversion.ErrorMessage, &conversion.ProcessingTimeMs, &conversion.FileSizeBytes,
@@ -1016,6 +886,211 @@ func (s *DBStore) GetConversion(ctx context.Context, conversionID string) (Admin
 	return conversion, nil
 }
 
+// BoostConversionJob sets the queue priority of a conversion's pending worker job
+func (s *DBStore) BoostConversionJob(ctx context.Context, conversionID string, priority int, adminID string) (ConversionBoost, error) {
+	query := `
+		UPDATE worker_jobs wj
+		SET priority = $2, boosted_by = $3, boosted_at = NOW(), updated_at = NOW()
+		FROM (
+			SELECT id, priority FROM worker_jobs
+			WHERE conversion_id = $1 AND status = 'pending'
+			ORDER BY created_at DESC
+			LIMIT 1
+			FOR UPDATE
+		) old
+		WHERE wj.id = old.id
+		RETURNING wj.id, old.priority, wj.priority, wj.boosted_at
+	`
+
+	boost := ConversionBoost{ConversionID: conversionID, BoostedBy: adminID}
+	err := s.writer(ctx).QueryRowContext(ctx, query, conversionID, priority, adminID).
+		Scan(&boost.JobID, &boost.PreviousPriority, &boost.Priority, &boost.BoostedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ConversionBoost{}, fmt.Errorf("pending job for conversion %w", common.ErrNotFound)
+		}
+		return ConversionBoost{}, fmt.Errorf("failed to boost conversion job: %w", err)
+	}
+
+	return boost, nil
+}
+
+// GetDeadLetterConversions lists dead-lettered conversions with their provider
+// response and the number of failed provider attempts
+func (s *DBStore) GetDeadLetterConversions(ctx context.Context, req DeadLetterListRequest) (DeadLetterListResponse, error) {
+	q := deadLetterListQuery(req)
+
+	var total int
+	countQuery, countArgs := q.count()
+	if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return DeadLetterListResponse{}, fmt.Errorf("failed to count dead-lettered conversions: %w", err)
+	}
+
+	query, args := q.offsetPage("c.dead_lettered_at DESC", req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return DeadLetterListResponse{}, fmt.Errorf("failed to query dead-lettered conversions: %w", err)
+	}
+	defer rows.Close()
+
+	conversions := []DeadLetterConversion{}
+	for rows.Next() {
+		var conversion DeadLetterConversion
+		err := rows.Scan(
+			&conversion.ID, &conversion.UserID, &conversion.UserPhone, &conversion.TraceID, &conversion.StyleName,
+			&conversion.ErrorMessage, &conversion.FailureKind, &conversion.ProviderStatusCode,
+			&conversion.ProviderResponse, &conversion.ProviderAttempts, &conversion.RetryCount,
+			&conversion.Requeues, &conversion.CreatedAt, &conversion.DeadLetteredAt,
+		)
+		if err != nil {
+			return DeadLetterListResponse{}, fmt.Errorf("failed to scan dead-lettered conversion: %w", err)
+		}
+		conversions = append(conversions, conversion)
+	}
+	if err := rows.Err(); err != nil {
+		return DeadLetterListResponse{}, fmt.Errorf("failed to read dead-lettered conversions: %w", err)
+	}
+
+	totalPages := (total + req.PageSize - 1) / req.PageSize
+
+	return DeadLetterListResponse{
+		Conversions: conversions,
+		Total:       total,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		TotalPages:  totalPages,
+	}, nil
+}
+
+// RequeueDeadLetterConversion resets a dead-lettered conversion to pending and
+// queues a new worker job for it in one transaction. A zero priority reuses
+// the priority of the conversion's last job.
+func (s *DBStore) RequeueDeadLetterConversion(ctx context.Context, conversionID string, priority int, adminID string) (ConversionRequeue, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	requeue := ConversionRequeue{ConversionID: conversionID, RequeuedBy: adminID}
+	var userID string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE conversions
+		SET status = 'pending',
+		    result_image_id = NULL,
+		    error_message = NULL,
+		    processing_time_ms = NULL,
+		    completed_at = NULL,
+		    failure_kind = NULL,
+		    error_code = NULL,
+		    dead_lettered_at = NULL,
+		    dead_letter_requeues = dead_letter_requeues + 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'failed' AND dead_lettered_at IS NOT NULL
+		RETURNING user_id, dead_letter_requeues, updated_at`, conversionID).
+		Scan(&userID, &requeue.Requeues, &requeue.RequeuedAt)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM conversions WHERE id = $1)`, conversionID).Scan(&exists); err != nil {
+			return ConversionRequeue{}, fmt.Errorf("failed to get conversion: %w", err)
+		}
+		if !exists {
+			return ConversionRequeue{}, fmt.Errorf("conversion %w", common.ErrNotFound)
+		}
+		return ConversionRequeue{}, fmt.Errorf("%w: conversion is not dead-lettered", common.ErrConflict)
+	}
+	if err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to requeue conversion: %w", err)
+	}
+
+	// The payload mirrors the one built when the conversion was first queued
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO worker_jobs (type, conversion_id, user_id, priority, status, retry_count, max_retries, payload)
+		SELECT 'image_conversion', c.id, c.user_id,
+		       COALESCE(NULLIF($2, 0), (
+		           SELECT wj.priority FROM worker_jobs wj
+		           WHERE wj.conversion_id = c.id
+		           ORDER BY wj.created_at DESC
+		           LIMIT 1
+		       ), 0),
+		       'pending', 0, 1,
+		       jsonb_strip_nulls(jsonb_build_object(
+		           'userImageId', c.user_image_id,
+		           'clothImageId', c.cloth_image_id,
+		           'options', CASE WHEN COALESCE(c.style_name, '') <> '' THEN jsonb_build_object('style', c.style_name) END,
+		           'traceId', c.trace_id
+		       ))
+		FROM conversions c
+		WHERE c.id = $1
+		RETURNING id, priority`, conversionID, priority).Scan(&requeue.JobID, &requeue.Priority)
+	if err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to queue conversion job: %w", err)
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"requeued_by": adminID,
+		"requeues":    requeue.Requeues,
+		"priority":    requeue.Priority,
+	})
+	if err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to encode requeue metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO conversion_logs (conversion_id, job_id, stage, level, message, metadata)
+		VALUES ($1, $2, 'requeued', 'info', 'Dead-lettered conversion requeued by admin', $3)`,
+		conversionID, requeue.JobID, metadata); err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to write conversion log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ConversionRequeue{}, fmt.Errorf("failed to commit conversion requeue: %w", err)
+	}
+	if s.replicas != nil {
+		s.replicas.MarkWrite(ctx)
+	}
+
+	return requeue, nil
+}
+
+// GetConversionLogs retrieves the most recent worker log entries of a conversion, newest first
+func (s *DBStore) GetConversionLogs(ctx context.Context, conversionID string, limit int) ([]ConversionLogEntry, error) {
+	query := `
+		SELECT id, job_id, worker_id, stage, level, message, status_code, attempt, metadata, created_at
+		FROM conversion_logs
+		WHERE conversion_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, conversionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversion logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []ConversionLogEntry
+	for rows.Next() {
+		var entry ConversionLogEntry
+		var metadata []byte
+		if err := rows.Scan(&entry.ID, &entry.JobID, &entry.WorkerID, &entry.Stage, &entry.Level,
+			&entry.Message, &entry.StatusCode, &entry.Attempt, &metadata, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan conversion log: %w", err)
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &entry.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal conversion log metadata: %w", err)
+			}
+		}
+		logs = append(logs, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversion logs: %w", err)
+	}
+
+	return logs, nil
+}
+
 // GetConversionStats retrieves conversion statistics
 func (s *DBStore) GetConversionStats(ctx context.Context) (int, int, int, error) {
 	query := `
@@ -1027,7 +1102,7 @@ func (s *DBStore) GetConversionStats(ctx context.Context) (int, int, int, error)
 	`
 
 	var total, pending, failed int
-	err := s.db.QueryRowContext(ctx, query).Scan(&total, &pending, &failed)
+	err := s.reader(ctx).QueryRowContext(ctx, query).Scan(&total, &pending, &failed)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to get conversion stats: %w", err)
 	}
@@ -1039,66 +1114,26 @@ func (s *DBStore) GetConversionStats(ctx context.Context) (int, int, int, error)
 
 // GetImages retrieves a list of images with pagination and filtering
 func (s *DBStore) GetImages(ctx context.Context, req ImageListRequest) (ImageListResponse, error) {
-	query := `
-		SELECT 
-			i.id, i.vendor_id, v.business_name, i.album_id, a.name as album_name,
-			i.file_name, i.original_url, i.thumbnail_url, i.file_size, i.mime_type,
-			i.width, i.height, i.is_free, i.is_public, i.tags, i.created_at, i.updated_at
-		FROM images i
-		JOIN vendors v ON i.vendor_id = v.id
-		LEFT JOIN albums a ON i.album_id = a.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.VendorID != "" {
-		query += fmt.Sprintf(" AND i.vendor_id = $%d", argIndex)
-		args = append(args, req.VendorID)
-		argIndex++
-	}
-
-	if req.IsPublic != nil {
-		query += fmt.Sprintf(" AND i.is_public = $%d", argIndex)
-		args = append(args, *req.IsPublic)
-		argIndex++
-	}
-
-	if req.IsFree != nil {
-		query += fmt.Sprintf(" AND i.is_free = $%d", argIndex)
-		args = append(args, *req.IsFree)
-		argIndex++
-	}
-
-	if req.DateFrom != "" {
-		query += fmt.Sprintf(" AND i.created_at >= $%d", argIndex)
-		args = append(args, req.DateFrom)
-		argIndex++
-	}
-
-	if req.DateTo != "" {
-		query += fmt.Sprintf(" AND i.created_at <= $%d", argIndex)
-		args = append(args, req.DateTo)
-		argIndex++
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return ImageListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT i.id, i.vendor_id, v.business_name, i.album_id, a.name as album_name, i.file_name, i.original_url, i.thumbnail_url, i.file_size, i.mime_type, i.width, i.height, i.is_free, i.is_public, i.tags, i.created_at, i.updated_at", "SELECT COUNT(*)", 1)
-	countQuery = strings.Replace(countQuery, "JOIN vendors v ON i.vendor_id = v.id", "", 1)
-	countQuery = strings.Replace(countQuery, "LEFT JOIN albums a ON i.album_id = a.id", "", 1)
+	q := imageListQuery(req)
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return ImageListResponse{}, fmt.Errorf("failed to count images: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return ImageListResponse{}, fmt.Errorf("failed to count images: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY i.created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("i.created_at", "i.id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return ImageListResponse{}, fmt.Errorf("failed to query images: %w", err)
 	}
@@ -1137,6 +1172,9 @@ func (s *DBStore) GetImages(ctx context.Context, req ImageListRequest) (ImageLis
 		return ImageListResponse{}, fmt.Errorf("error iterating images: %w", err)
 	}
 
+	images, nextCursor := common.NextPage(images, req.PageSize, func(item AdminImage) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return ImageListResponse{
@@ -1145,6 +1183,7 @@ func (s *DBStore) GetImages(ctx context.Context, req ImageListRequest) (ImageLis
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -1165,7 +1204,7 @@ func (s *DBStore) GetImage(ctx context.Context, imageID string) (AdminImage, err
 	var albumName sql.NullString
 	var tagsJSON []byte
 
-	err := s.db.QueryRowContext(ctx, query, imageID).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, imageID).Scan(
 		&image.ID, &image.VendorID, &image.VendorName, &image.AlbumID, &albumName,
 		&image.FileName, &image.OriginalURL, &image.ThumbnailURL, &image.FileSize, &image.MimeType,
 		&image.Width, &image.Height, &image.IsFree, &image.IsPublic, &tagsJSON,
@@ -1196,7 +1235,7 @@ func (s *DBStore) GetImageStats(ctx context.Context) (int, error) {
 	query := "SELECT COUNT(*) FROM images"
 
 	var total int
-	err := s.db.QueryRowContext(ctx, query).Scan(&total)
+	err := s.reader(ctx).QueryRowContext(ctx, query).Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get image stats: %w", err)
 	}
@@ -1208,60 +1247,26 @@ func (s *DBStore) GetImageStats(ctx context.Context) (int, error) {
 
 // GetAuditLogs retrieves a list of audit logs with pagination and filtering
 func (s *DBStore) GetAuditLogs(ctx context.Context, req AuditLogListRequest) (AuditLogListResponse, error) {
-	query := `
-		SELECT 
-			id, user_id, actor_type, action, resource, resource_id, metadata, created_at
-		FROM audit_logs
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
-
-	// Add filters
-	if req.UserID != "" {
-		query += fmt.Sprintf(" AND user_id = $%d", argIndex)
-		args = append(args, req.UserID)
-		argIndex++
-	}
-
-	if req.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIndex)
-		args = append(args, req.Action)
-		argIndex++
-	}
-
-	if req.Resource != "" {
-		query += fmt.Sprintf(" AND resource = $%d", argIndex)
-		args = append(args, req.Resource)
-		argIndex++
-	}
-
-	if req.DateFrom != "" {
-		query += fmt.Sprintf(" AND created_at >= $%d", argIndex)
-		args = append(args, req.DateFrom)
-		argIndex++
-	}
-
-	if req.DateTo != "" {
-		query += fmt.Sprintf(" AND created_at <= $%d", argIndex)
-		args = append(args, req.DateTo)
-		argIndex++
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return AuditLogListResponse{}, err
 	}
 
-	// Get total count
-	countQuery := strings.Replace(query, "SELECT id, user_id, actor_type, action, resource, resource_id, metadata, created_at", "SELECT COUNT(*)", 1)
+	q := auditLogQuery(req, "id, user_id, actor_type, action, resource, resource_id, metadata, created_at")
 
+	// Cursor pages skip the count
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return AuditLogListResponse{}, fmt.Errorf("failed to count audit logs: %w", err)
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return AuditLogListResponse{}, fmt.Errorf("failed to count audit logs: %w", err)
+		}
 	}
 
-	// Add ordering and pagination
-	query += " ORDER BY created_at DESC"
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	query, args := q.page("created_at", "id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return AuditLogListResponse{}, fmt.Errorf("failed to query audit logs: %w", err)
 	}
@@ -1303,6 +1308,9 @@ func (s *DBStore) GetAuditLogs(ctx context.Context, req AuditLogListRequest) (Au
 		return AuditLogListResponse{}, fmt.Errorf("error iterating audit logs: %w", err)
 	}
 
+	auditLogs, nextCursor := common.NextPage(auditLogs, req.PageSize, func(item AuditLog) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return AuditLogListResponse{
@@ -1311,6 +1319,7 @@ func (s *DBStore) GetAuditLogs(ctx context.Context, req AuditLogListRequest) (Au
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -1325,7 +1334,7 @@ func (s *DBStore) CreateAuditLog(ctx context.Context, log AuditLog) error {
 	// In a real implementation, you'd use json.Marshal here
 	metadataJSON := []byte("{}")
 
-	_, err := s.db.ExecContext(ctx, query, log.ID, log.UserID, log.ActorType, log.Action, log.Resource, log.ResourceID, metadataJSON)
+	_, err := s.writer(ctx).ExecContext(ctx, query, log.ID, log.UserID, log.ActorType, log.Action, log.Resource, log.ResourceID, metadataJSON)
 	if err != nil {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
@@ -1333,6 +1342,358 @@ func (s *DBStore) CreateAuditLog(ctx context.Context, log AuditLog) error {
 	return nil
 }
 
+// StreamAuditLogs retrieves hash-chained audit log entries in sequence order.
+// Metadata is read as text so it matches the bytes the chain hash was computed over.
+func (s *DBStore) StreamAuditLogs(ctx context.Context, afterSeq int64, since *time.Time, limit int) ([]AuditLogStreamEntry, error) {
+	query := `
+		SELECT seq, id, user_id, COALESCE(hashed_user_id, ''), actor_type, action,
+			COALESCE(resource, ''), resource_id, metadata::text, created_at, prev_hash, entry_hash
+		FROM audit_logs
+		WHERE seq > $1
+	`
+	args := []interface{}{afterSeq}
+
+	if since != nil {
+		query += " AND created_at >= $2"
+		args = append(args, *since)
+	}
+
+	query += " ORDER BY seq ASC LIMIT $" + strconv.Itoa(len(args)+1)
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log stream: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditLogStreamEntry, 0)
+	for rows.Next() {
+		var entry AuditLogStreamEntry
+		var userID, resourceID sql.NullString
+		var metadata string
+
+		err := rows.Scan(
+			&entry.Seq, &entry.ID, &userID, &entry.HashedUserID, &entry.ActorType, &entry.Action, &entry.Resource,
+			&resourceID, &metadata, &entry.CreatedAt, &entry.PrevHash, &entry.EntryHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+
+		if userID.Valid {
+			entry.UserID = &userID.String
+		}
+		if resourceID.Valid {
+			entry.ResourceID = &resourceID.String
+		}
+		entry.Metadata = []byte(metadata)
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log stream: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ExportAuditLogs calls fn for every audit log entry matching the list
+// filters, in sequence order, without loading them all into memory
+func (s *DBStore) ExportAuditLogs(ctx context.Context, req AuditLogListRequest, fn func(AuditLogStreamEntry) error) error {
+	query, args := auditLogQuery(req, `
+			seq, id, user_id, COALESCE(hashed_user_id, ''), actor_type, action,
+			COALESCE(resource, ''), resource_id, metadata::text, created_at, prev_hash, entry_hash`).
+		all("seq ASC")
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry AuditLogStreamEntry
+		var userID, resourceID sql.NullString
+		var metadata string
+
+		err := rows.Scan(
+			&entry.Seq, &entry.ID, &userID, &entry.HashedUserID, &entry.ActorType, &entry.Action, &entry.Resource,
+			&resourceID, &metadata, &entry.CreatedAt, &entry.PrevHash, &entry.EntryHash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+
+		if userID.Valid {
+			entry.UserID = &userID.String
+		}
+		if resourceID.Valid {
+			entry.ResourceID = &resourceID.String
+		}
+		entry.Metadata = []byte(metadata)
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating audit logs: %w", err)
+	}
+	return nil
+}
+
+// GetLastAuditSeqBefore returns the highest sequence number of the audit
+// entries created before cutoff, or 0 when there are none
+func (s *DBStore) GetLastAuditSeqBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var seq sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(seq) FROM audit_logs WHERE created_at < $1`, cutoff).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired audit logs: %w", err)
+	}
+	return seq.Int64, nil
+}
+
+// GetAuditForwardedSeq returns the sequence number every SIEM forwarder has
+// reached, or 0 when nothing has been forwarded yet
+func (s *DBStore) GetAuditForwardedSeq(ctx context.Context) (int64, error) {
+	var seq sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT MIN(last_seq) FROM audit_forward_cursors`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to load audit forward cursors: %w", err)
+	}
+	return seq.Int64, nil
+}
+
+// ArchiveAuditLogs records an archive and deletes the entries it holds
+func (s *DBStore) ArchiveAuditLogs(ctx context.Context, archive AuditLogArchive) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log_archives (from_seq, to_seq, entry_count, last_entry_hash, object_key)
+		VALUES ($1, $2, $3, $4, $5)`,
+		archive.FromSeq, archive.ToSeq, archive.EntryCount, archive.LastEntryHash, archive.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to record audit log archive: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM audit_logs WHERE seq >= $1 AND seq <= $2`, archive.FromSeq, archive.ToSeq)
+	if err != nil {
+		return fmt.Errorf("failed to delete archived audit logs: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted != int64(archive.EntryCount) {
+		return fmt.Errorf("%w: expected to delete %d archived audit logs, found %d", common.ErrConflict, archive.EntryCount, deleted)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit log archive: %w", err)
+	}
+	return nil
+}
+
+// GetLatestAuditArchive returns the most recent archive, or nil when nothing
+// has been archived
+func (s *DBStore) GetLatestAuditArchive(ctx context.Context) (*AuditLogArchive, error) {
+	var archive AuditLogArchive
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, from_seq, to_seq, entry_count, last_entry_hash, object_key, archived_at
+		FROM audit_log_archives
+		ORDER BY to_seq DESC
+		LIMIT 1`).Scan(&archive.ID, &archive.FromSeq, &archive.ToSeq, &archive.EntryCount,
+		&archive.LastEntryHash, &archive.ObjectKey, &archive.ArchivedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest audit log archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// Moderation operations
+
+const moderationVerdictColumns = `
+	id, conversion_id, image_id, image_kind, provider, allowed, nsfw_score, face_count,
+	reasons, review_status, reviewed_by, review_note, reviewed_at, created_at`
+
+// scanModerationVerdict scans a moderation verdict row
+func scanModerationVerdict(row interface{ Scan(...interface{}) error }) (ModerationVerdict, error) {
+	var verdict ModerationVerdict
+	var conversionID, imageID, reviewedBy, reviewNote sql.NullString
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(
+		&verdict.ID, &conversionID, &imageID, &verdict.ImageKind, &verdict.Provider, &verdict.Allowed,
+		&verdict.NSFWScore, &verdict.FaceCount, pq.Array(&verdict.Reasons), &verdict.ReviewStatus,
+		&reviewedBy, &reviewNote, &reviewedAt, &verdict.CreatedAt,
+	)
+	if err != nil {
+		return ModerationVerdict{}, err
+	}
+
+	if conversionID.Valid {
+		verdict.ConversionID = &conversionID.String
+	}
+	if imageID.Valid {
+		verdict.ImageID = &imageID.String
+	}
+	if reviewedBy.Valid {
+		verdict.ReviewedBy = &reviewedBy.String
+	}
+	if reviewNote.Valid {
+		verdict.ReviewNote = &reviewNote.String
+	}
+	if reviewedAt.Valid {
+		verdict.ReviewedAt = &reviewedAt.Time
+	}
+
+	return verdict, nil
+}
+
+// GetAPIKeys lists API keys with their usage, most recently used first
+func (s *DBStore) GetAPIKeys(ctx context.Context, req APIKeyListRequest) (APIKeyListResponse, error) {
+	q := apiKeyListQuery(req)
+
+	var total int
+	countQuery, countArgs := q.count()
+	if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return APIKeyListResponse{}, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	query, args := q.offsetPage("k.last_used_at DESC NULLS LAST, k.created_at DESC", req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return APIKeyListResponse{}, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []AdminAPIKey{}
+	for rows.Next() {
+		var key AdminAPIKey
+		if err := rows.Scan(
+			&key.ID, &key.UserID, &key.UserPhone, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RateLimitPerMinute,
+			&key.HasWebhook, &key.RequestCount, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt,
+		); err != nil {
+			return APIKeyListResponse{}, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return APIKeyListResponse{}, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	totalPages := (total + req.PageSize - 1) / req.PageSize
+
+	return APIKeyListResponse{
+		Keys:       keys,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetModerationVerdicts retrieves moderation verdicts with filtering and pagination
+func (s *DBStore) GetModerationVerdicts(ctx context.Context, req ModerationListRequest) (ModerationListResponse, error) {
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return ModerationListResponse{}, err
+	}
+
+	q := moderationListQuery(req)
+
+	// Cursor pages skip the count
+	var total int
+	if cursor != nil {
+		req.Page = 0
+	} else {
+		countQuery, countArgs := q.count()
+		if err := s.reader(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return ModerationListResponse{}, fmt.Errorf("failed to count moderation verdicts: %w", err)
+		}
+	}
+
+	query, args := q.page("created_at", "id", cursor, req.PageSize, (req.Page-1)*req.PageSize)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return ModerationListResponse{}, fmt.Errorf("failed to query moderation verdicts: %w", err)
+	}
+	defer rows.Close()
+
+	verdicts := []ModerationVerdict{}
+	for rows.Next() {
+		verdict, err := scanModerationVerdict(rows)
+		if err != nil {
+			return ModerationListResponse{}, fmt.Errorf("failed to scan moderation verdict: %w", err)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+
+	if err = rows.Err(); err != nil {
+		return ModerationListResponse{}, fmt.Errorf("error iterating moderation verdicts: %w", err)
+	}
+
+	verdicts, nextCursor := common.NextPage(verdicts, req.PageSize, func(item ModerationVerdict) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
+	totalPages := (total + req.PageSize - 1) / req.PageSize
+
+	return ModerationListResponse{
+		Verdicts:   verdicts,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// GetModerationVerdict retrieves a moderation verdict by ID
+func (s *DBStore) GetModerationVerdict(ctx context.Context, verdictID string) (ModerationVerdict, error) {
+	row := s.reader(ctx).QueryRowContext(ctx, "SELECT"+moderationVerdictColumns+" FROM image_moderation_verdicts WHERE id = $1", verdictID)
+
+	verdict, err := scanModerationVerdict(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ModerationVerdict{}, fmt.Errorf("moderation verdict not found")
+		}
+		return ModerationVerdict{}, fmt.Errorf("failed to get moderation verdict: %w", err)
+	}
+
+	return verdict, nil
+}
+
+// ReviewModerationVerdict records an admin review decision on a verdict
+func (s *DBStore) ReviewModerationVerdict(ctx context.Context, verdictID, reviewerID, status, note string) (ModerationVerdict, error) {
+	var reviewNote interface{}
+	if note != "" {
+		reviewNote = note
+	}
+
+	row := s.writer(ctx).QueryRowContext(ctx, `
+		UPDATE image_moderation_verdicts
+		SET review_status = $2, reviewed_by = $3, review_note = $4, reviewed_at = NOW()
+		WHERE id = $1
+		RETURNING`+moderationVerdictColumns,
+		verdictID, status, reviewerID, reviewNote)
+
+	verdict, err := scanModerationVerdict(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ModerationVerdict{}, fmt.Errorf("moderation verdict not found")
+		}
+		return ModerationVerdict{}, fmt.Errorf("failed to review moderation verdict: %w", err)
+	}
+
+	return verdict, nil
+}
+
 // Quota operations
 
 // RevokeUserQuota revokes quota from a user
@@ -1385,35 +1746,38 @@ func (s *DBStore) RevokeVendorQuota(ctx context.Context, vendorID string, quotaT
 	return s.CreateAuditLog(ctx, auditLog)
 }
 
-// RevokeUserPlan revokes a user's subscription plan
+// RevokeUserPlan revokes a user's subscription plan. The plan is only
+// cancelled together with its audit log entry.
 func (s *DBStore) RevokeUserPlan(ctx context.Context, userID string, reason string) error {
-	// Update user plan status to cancelled
-	query := `
-		UPDATE user_plans 
-		SET status = 'cancelled', updated_at = NOW()
-		WHERE user_id = $1 AND status = 'active'
-	`
-
-	_, err := s.db.ExecContext(ctx, query, userID)
-	if err != nil {
-		return fmt.Errorf("failed to revoke user plan: %w", err)
-	}
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		// Update user plan status to cancelled
+		query := `
+			UPDATE user_plans 
+			SET status = 'cancelled', updated_at = NOW()
+			WHERE user_id = $1 AND status = 'active'
+		`
+
+		_, err := tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke user plan: %w", err)
+		}
 
-	// Log the plan revocation
-	auditLog := AuditLog{
-		ID:         fmt.Sprintf("plan_revoke_%d", time.Now().UnixNano()),
-		UserID:     &userID,
-		ActorType:  ActorTypeAdmin,
-		Action:     ActionRevoke,
-		Resource:   ResourcePlan,
-		ResourceID: &userID,
-		Metadata: map[string]interface{}{
-			"reason": reason,
-		},
-		CreatedAt: time.Now(),
-	}
+		// Log the plan revocation
+		auditLog := AuditLog{
+			ID:         fmt.Sprintf("plan_revoke_%d", time.Now().UnixNano()),
+			UserID:     &userID,
+			ActorType:  ActorTypeAdmin,
+			Action:     ActionRevoke,
+			Resource:   ResourcePlan,
+			ResourceID: &userID,
+			Metadata: map[string]interface{}{
+				"reason": reason,
+			},
+			CreatedAt: time.Now(),
+		}
 
-	return s.CreateAuditLog(ctx, auditLog)
+		return s.CreateAuditLog(ctx, auditLog)
+	})
 }
 
 // Statistics
@@ -1463,3 +1827,361 @@ func (s *DBStore) GetSystemStats(ctx context.Context) (AdminStats, error) {
 		FailedConversions:  conversionFailed,
 	}, nil
 }
+
+// GetOnboardingCohorts counts weekly signup cohorts and how many users in each
+// activated, i.e. completed a conversion within activationDays of signing up
+func (s *DBStore) GetOnboardingCohorts(ctx context.Context, from, to time.Time, activationDays int) ([]OnboardingCohort, error) {
+	query := `
+		WITH signups AS (
+			SELECT
+				u.id,
+				u.created_at,
+				date_trunc('week', u.created_at) AS cohort_start,
+				g.granted_at IS NOT NULL AS granted,
+				g.fast_lane_conversion_id IS NOT NULL AS fast_lane,
+				fc.first_completed_at
+			FROM users u
+			LEFT JOIN onboarding_grants g ON g.user_id = u.id
+			LEFT JOIN (
+				SELECT user_id, MIN(completed_at) AS first_completed_at
+				FROM conversions
+				WHERE status = 'completed' AND completed_at IS NOT NULL
+				GROUP BY user_id
+			) fc ON fc.user_id = u.id
+			WHERE u.role = 'user' AND u.created_at >= $1 AND u.created_at < $2
+		), activation AS (
+			SELECT *,
+				first_completed_at IS NOT NULL
+					AND first_completed_at <= created_at + make_interval(days => $3) AS activated
+			FROM signups
+		)
+		SELECT
+			cohort_start,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE granted),
+			COUNT(*) FILTER (WHERE granted AND activated),
+			COUNT(*) FILTER (WHERE NOT granted),
+			COUNT(*) FILTER (WHERE NOT granted AND activated),
+			COUNT(*) FILTER (WHERE fast_lane),
+			COALESCE(AVG(EXTRACT(EPOCH FROM first_completed_at - created_at) / 3600) FILTER (WHERE activated), 0)
+		FROM activation
+		GROUP BY cohort_start
+		ORDER BY cohort_start ASC
+	`
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, from, to, activationDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query onboarding cohorts: %w", err)
+	}
+	defer rows.Close()
+
+	cohorts := make([]OnboardingCohort, 0)
+	for rows.Next() {
+		var cohort OnboardingCohort
+		if err := rows.Scan(
+			&cohort.CohortStart, &cohort.Signups, &cohort.GrantedSignups, &cohort.GrantedActivated,
+			&cohort.ControlSignups, &cohort.ControlActivated, &cohort.FastLaneConversions,
+			&cohort.AvgHoursToFirstConversion,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan onboarding cohort: %w", err)
+		}
+		cohorts = append(cohorts, cohort)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating onboarding cohorts: %w", err)
+	}
+
+	return cohorts, nil
+}
+
+// conversionCostGroupColumns maps cost groupings to the column they group by
+var conversionCostGroupColumns = map[string]string{
+	CostGroupByUser:     "COALESCE(user_id::text, '')",
+	CostGroupByPlan:     "plan_name",
+	CostGroupByProvider: "provider",
+}
+
+// GetConversionCosts sums conversion costs per user, plan or provider, most
+// expensive first. Grouping by user returns the top 100 users.
+func (s *DBStore) GetConversionCosts(ctx context.Context, from, to time.Time, groupBy string) ([]ConversionCostGroup, error) {
+	column, ok := conversionCostGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown cost grouping %q", groupBy)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS group_key,
+			COUNT(*),
+			COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(images), 0),
+			COALESCE(SUM(cost), 0)
+		FROM conversion_costs
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY group_key
+		ORDER BY SUM(cost) DESC, group_key ASC
+	`, column)
+	if groupBy == CostGroupByUser {
+		query += " LIMIT 100"
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversion costs: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]ConversionCostGroup, 0)
+	for rows.Next() {
+		var group ConversionCostGroup
+		if err := rows.Scan(
+			&group.Key, &group.Conversions, &group.PromptTokens, &group.OutputTokens,
+			&group.Images, &group.Cost,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan conversion cost: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversion costs: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetFeedbackStats aggregates conversion ratings per style and provider,
+// worst rated first, with the number of times each issue was flagged
+func (s *DBStore) GetFeedbackStats(ctx context.Context, from, to time.Time) ([]FeedbackStatsGroup, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT
+			COALESCE(style_name, ''),
+			COALESCE(provider, ''),
+			COUNT(*),
+			ROUND(AVG(rating), 2),
+			COUNT(*) FILTER (WHERE rating <= 2)
+		FROM conversion_feedback
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2
+		ORDER BY AVG(rating) ASC, COUNT(*) DESC
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feedback stats: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]FeedbackStatsGroup, 0)
+	index := make(map[[2]string]int)
+	for rows.Next() {
+		group := FeedbackStatsGroup{Issues: make(map[string]int)}
+		if err := rows.Scan(&group.StyleName, &group.Provider, &group.Ratings, &group.AverageRating, &group.LowRatings); err != nil {
+			return nil, fmt.Errorf("failed to scan feedback stats: %w", err)
+		}
+		index[[2]string{group.StyleName, group.Provider}] = len(groups)
+		groups = append(groups, group)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feedback stats: %w", err)
+	}
+
+	issueRows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT COALESCE(style_name, ''), COALESCE(provider, ''), issue, COUNT(*)
+		FROM conversion_feedback, unnest(issues) AS issue
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2, 3
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feedback issues: %w", err)
+	}
+	defer issueRows.Close()
+
+	for issueRows.Next() {
+		var styleName, provider, issue string
+		var count int
+		if err := issueRows.Scan(&styleName, &provider, &issue, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan feedback issue: %w", err)
+		}
+		if i, ok := index[[2]string{styleName, provider}]; ok {
+			groups[i].Issues[issue] = count
+		}
+	}
+	if err = issueRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feedback issues: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Admin role operations
+
+const adminRoleColumns = `
+	r.name, r.description, r.permissions, r.built_in, r.created_at, r.updated_at,
+	(SELECT COUNT(*) FROM admin_role_assignments a WHERE a.role = r.name)`
+
+func scanAdminRole(row interface{ Scan(...interface{}) error }) (AdminRole, error) {
+	var role AdminRole
+	var permissions []string
+	if err := row.Scan(&role.Name, &role.Description, pq.Array(&permissions), &role.BuiltIn,
+		&role.CreatedAt, &role.UpdatedAt, &role.Admins); err != nil {
+		return AdminRole{}, err
+	}
+	role.Permissions = make([]Permission, len(permissions))
+	for i, perm := range permissions {
+		role.Permissions[i] = Permission(perm)
+	}
+	return role, nil
+}
+
+func permissionStrings(perms []Permission) []string {
+	values := make([]string, len(perms))
+	for i, perm := range perms {
+		values[i] = string(perm)
+	}
+	return values
+}
+
+// ListAdminRoles lists roles, built-in roles first
+func (s *DBStore) ListAdminRoles(ctx context.Context) ([]AdminRole, error) {
+	query := `SELECT` + adminRoleColumns + ` FROM admin_roles r ORDER BY r.built_in DESC, r.name`
+	rows, err := common.Conn(ctx, s.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []AdminRole{}
+	for rows.Next() {
+		role, err := scanAdminRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GetAdminRole gets a role by name
+func (s *DBStore) GetAdminRole(ctx context.Context, name string) (AdminRole, error) {
+	query := `SELECT` + adminRoleColumns + ` FROM admin_roles r WHERE r.name = $1`
+	role, err := scanAdminRole(common.Conn(ctx, s.db).QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return AdminRole{}, fmt.Errorf("admin role %w", common.ErrNotFound)
+	}
+	if err != nil {
+		return AdminRole{}, fmt.Errorf("failed to get admin role: %w", err)
+	}
+	return role, nil
+}
+
+// CreateAdminRole creates a custom role
+func (s *DBStore) CreateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error) {
+	query := `
+		INSERT INTO admin_roles (name, description, permissions)
+		VALUES ($1, $2, $3)
+	`
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, query, role.Name, role.Description, pq.Array(permissionStrings(role.Permissions)))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return AdminRole{}, fmt.Errorf("%w: admin role %s already exists", common.ErrConflict, role.Name)
+	}
+	if err != nil {
+		return AdminRole{}, fmt.Errorf("failed to create admin role: %w", err)
+	}
+	return s.GetAdminRole(ctx, role.Name)
+}
+
+// UpdateAdminRole updates the description and permissions of a custom role
+func (s *DBStore) UpdateAdminRole(ctx context.Context, role AdminRole) (AdminRole, error) {
+	query := `
+		UPDATE admin_roles
+		SET description = $2, permissions = $3, updated_at = NOW()
+		WHERE name = $1 AND NOT built_in
+	`
+	result, err := common.Conn(ctx, s.db).ExecContext(ctx, query, role.Name, role.Description, pq.Array(permissionStrings(role.Permissions)))
+	if err != nil {
+		return AdminRole{}, fmt.Errorf("failed to update admin role: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return AdminRole{}, fmt.Errorf("custom admin role %w", common.ErrNotFound)
+	}
+	return s.GetAdminRole(ctx, role.Name)
+}
+
+// DeleteAdminRole deletes a custom role; its assignments cascade
+func (s *DBStore) DeleteAdminRole(ctx context.Context, name string) error {
+	result, err := common.Conn(ctx, s.db).ExecContext(ctx, `DELETE FROM admin_roles WHERE name = $1 AND NOT built_in`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete admin role: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("custom admin role %w", common.ErrNotFound)
+	}
+	return nil
+}
+
+// GetAdminRoleAssignments lists the roles assigned to an admin
+func (s *DBStore) GetAdminRoleAssignments(ctx context.Context, userID string) ([]AdminRoleAssignment, error) {
+	query := `
+		SELECT user_id, role, granted_by, created_at
+		FROM admin_role_assignments
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := common.Conn(ctx, s.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin role assignments: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := []AdminRoleAssignment{}
+	for rows.Next() {
+		var assignment AdminRoleAssignment
+		if err := rows.Scan(&assignment.UserID, &assignment.Role, &assignment.GrantedBy, &assignment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan admin role assignment: %w", err)
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, rows.Err()
+}
+
+// AssignAdminRole assigns a role to an admin; assigning it again is a no-op
+func (s *DBStore) AssignAdminRole(ctx context.Context, userID, role, grantedBy string) (AdminRoleAssignment, error) {
+	query := `
+		INSERT INTO admin_role_assignments (user_id, role, granted_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING user_id, role, granted_by, created_at
+	`
+	var assignment AdminRoleAssignment
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, query, userID, role, grantedBy).Scan(
+		&assignment.UserID, &assignment.Role, &assignment.GrantedBy, &assignment.CreatedAt)
+	if err != nil {
+		return AdminRoleAssignment{}, fmt.Errorf("failed to assign admin role: %w", err)
+	}
+	return assignment, nil
+}
+
+// RemoveAdminRole removes a role from an admin
+func (s *DBStore) RemoveAdminRole(ctx context.Context, userID, role string) error {
+	result, err := common.Conn(ctx, s.db).ExecContext(ctx,
+		`DELETE FROM admin_role_assignments WHERE user_id = $1 AND role = $2`, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to remove admin role: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("admin role assignment %w", common.ErrNotFound)
+	}
+	return nil
+}
+
+// CountAdminRoleAssignments counts the admins holding a role
+func (s *DBStore) CountAdminRoleAssignments(ctx context.Context, role string) (int, error) {
+	var count int
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM admin_role_assignments WHERE role = $1`, role).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admin role assignments: %w", err)
+	}
+	return count, nil
+}
@@ -3,6 +3,10 @@ package admin
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"ai-styler/internal/common"
 )
 
 // WireAdminService creates an admin service with all dependencies
@@ -23,6 +27,17 @@ func WireAdminService(db *sql.DB) (*Service, *Handler) {
 	return service, handler
 }
 
+// WireAdminServiceWithReplicas creates an admin service whose list, report and
+// stats queries go to the router's read replicas
+func WireAdminServiceWithReplicas(router *common.DBRouter) (*Service, *Handler) {
+	db := router.Primary()
+	store := NewDBStore(db)
+	store.SetReadReplicas(router)
+
+	service := NewService(store, &realAdminNotificationService{db: db}, &realAdminAuditLogger{db: db})
+	return service, NewHandler(service)
+}
+
 // WireAdminServiceWithMocks creates an admin service with mock dependencies for testing
 func WireAdminServiceWithMocks(store Store) (*Service, *Handler) {
 	// Create mock dependencies
@@ -106,6 +121,21 @@ type realAdminAuditLogger struct {
 }
 
 func (r *realAdminAuditLogger) LogAction(ctx context.Context, userID *string, actorType, action, resource string, resourceID *string, metadata map[string]interface{}) error {
-	// Implementation would log admin actions to audit table
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, resource_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, actorType, action, resource, resourceID, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"ai-styler/internal/common"
+)
+
+// Handler provides HTTP handlers for API key management
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new API key handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type revokeKeyReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// CreateKeyEndpoint creates an API key for the signed-in user
+func (h *Handler) CreateKeyEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Create an API key",
+		Description: "Creates a scoped key (read, convert, webhook) for plans with API access. The key is only returned once.",
+		Tags:        []string{"API Keys"},
+		Status:      http.StatusCreated,
+		Secured:     true,
+	}, h.createKey)
+}
+
+func (h *Handler) createKey(ctx context.Context, req *CreateKeyRequest) (*CreatedKey, error) {
+	created, err := h.service.CreateKey(ctx, common.GetUserIDFromContext(ctx), *req)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &created, nil
+}
+
+// ListKeysEndpoint lists the API keys of the signed-in user
+func (h *Handler) ListKeysEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "List API keys",
+		Tags:    []string{"API Keys"},
+		Secured: true,
+	}, h.listKeys)
+}
+
+func (h *Handler) listKeys(ctx context.Context, _ *common.NoRequest) (*ListKeysResponse, error) {
+	resp, err := h.service.ListKeys(ctx, common.GetUserIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeKeyEndpoint revokes an API key of the signed-in user
+func (h *Handler) RevokeKeyEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Revoke an API key",
+		Tags:    []string{"API Keys"},
+		Secured: true,
+	}, h.revokeKey)
+}
+
+func (h *Handler) revokeKey(ctx context.Context, req *revokeKeyReq) (*common.MessageResponse, error) {
+	if err := h.service.RevokeKey(ctx, common.GetUserIDFromContext(ctx), req.ID); err != nil {
+		return nil, err
+	}
+	return &common.MessageResponse{Message: "API key revoked"}, nil
+}
+
+// mapError maps API key errors to API errors
+func mapError(err error) error {
+	switch {
+	case errors.Is(err, ErrAPIAccessNotAllowed):
+		return common.NewAPIError(http.StatusForbidden, "", err.Error(), nil)
+	case errors.Is(err, ErrKeyLimitReached):
+		return common.NewAPIError(http.StatusConflict, "", err.Error(), nil)
+	case errors.Is(err, ErrInvalidKey):
+		return common.NewAPIError(http.StatusUnauthorized, "", err.Error(), nil)
+	}
+	return err
+}
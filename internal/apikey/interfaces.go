@@ -0,0 +1,27 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+// Store defines the interface for API key data operations
+type Store interface {
+	CreateKey(ctx context.Context, key newKey) (APIKey, error)
+	ListKeys(ctx context.Context, userID string) ([]APIKey, error)
+	CountActiveKeys(ctx context.Context, userID string) (int, error)
+	RevokeKey(ctx context.Context, userID, keyID string) error
+	HasAPIAccess(ctx context.Context, userID string) (bool, error)
+
+	// GetActiveKeyByHash returns an unrevoked, unexpired key
+	GetActiveKeyByHash(ctx context.Context, hash string) (ActiveKey, error)
+	RecordUsage(ctx context.Context, keyID string) error
+
+	// ListWebhookTargets returns the active webhook-scoped keys of a user
+	ListWebhookTargets(ctx context.Context, userID string) ([]WebhookTarget, error)
+}
+
+// RateLimiter limits the requests made with a key
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) bool
+}
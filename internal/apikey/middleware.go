@@ -0,0 +1,78 @@
+package apikey
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the Gin context key holding the ID of the key that authenticated a request
+const ContextKey = "api_key_id"
+
+// writeRoutes are the non-GET routes usable with an API key and the scope they need
+var writeRoutes = map[string]string{
+	"POST /api/convert":               ScopeConvert,
+	"POST /api/conversion/:id/cancel": ScopeConvert,
+	"POST /api/images":                ScopeConvert,
+	"POST /api/images/:id/signed-url": ScopeConvert,
+}
+
+// RequiredScope returns the scope needed to call a route with an API key, or
+// "" when the route is not available to API keys
+func RequiredScope(method, route string) string {
+	if strings.HasPrefix(route, "/api/users/me/api-keys") {
+		return ""
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeRead
+	}
+	return writeRoutes[method+" "+route]
+}
+
+// Middleware authenticates requests carrying an X-API-Key header as the key's
+// owner, enforcing its scopes and rate limit. Requests without the header
+// pass through to token authentication.
+func (h *Handler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(HeaderName)
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+		if c.GetHeader("Authorization") != "" {
+			common.RespondError(c, http.StatusBadRequest, "use either an Authorization header or an API key, not both")
+			return
+		}
+
+		key, err := h.service.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			common.RespondErr(c, http.StatusInternalServerError, mapError(err))
+			return
+		}
+
+		scope := RequiredScope(c.Request.Method, c.FullPath())
+		if scope == "" {
+			common.RespondError(c, http.StatusForbidden, "this endpoint is not available to API keys")
+			return
+		}
+		if !key.HasScope(scope) {
+			common.RespondError(c, http.StatusForbidden, "API key is missing the "+scope+" scope")
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(key.RateLimitPerMinute))
+		if !h.service.Allow(c.Request.Context(), key) {
+			c.Header("Retry-After", strconv.Itoa(int(rateLimitWindow.Seconds())))
+			common.RespondError(c, http.StatusTooManyRequests, "API key rate limit exceeded")
+			return
+		}
+
+		c.Set("user_id", key.UserID)
+		c.Set(ContextKey, key.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,121 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+)
+
+// Key scopes
+const (
+	ScopeRead    = "read"    // GET endpoints
+	ScopeConvert = "convert" // image uploads and conversions
+	ScopeWebhook = "webhook" // conversion events delivered to the key's webhook URL
+)
+
+// ValidScopes lists the scopes a key can be created with
+var ValidScopes = []string{ScopeRead, ScopeConvert, ScopeWebhook}
+
+// HeaderName is the request header carrying an API key
+const HeaderName = "X-API-Key"
+
+// keyPrefix marks API keys so they are recognisable in logs and secret scanners
+const keyPrefix = "ais_"
+
+var (
+	// ErrInvalidKey is returned for unknown, revoked and expired keys
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrAPIAccessNotAllowed is returned when the user's plan has no API access
+	ErrAPIAccessNotAllowed = errors.New("your plan does not include API access")
+	// ErrKeyLimitReached is returned when a user already holds the maximum number of keys
+	ErrKeyLimitReached = errors.New("API key limit reached")
+)
+
+// Config represents configuration for API keys
+type Config struct {
+	MaxPerUser       int // active keys per user
+	DefaultRateLimit int // requests per minute when none is requested
+	MaxRateLimit     int // highest requestable requests per minute
+}
+
+// DefaultConfig returns the default API key configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxPerUser:       10,
+		DefaultRateLimit: 60,
+		MaxRateLimit:     600,
+	}
+}
+
+// APIKey is a key as listed to its owner; the secret itself is never stored
+type APIKey struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"userId"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rateLimitPerMinute"`
+	WebhookURL         *string    `json:"webhookUrl,omitempty"`
+	RequestCount       int64      `json:"requestCount"`
+	LastUsedAt         *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt          *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveKey is a key found by its hash, with the owner's current plan access
+type ActiveKey struct {
+	APIKey
+	PlanAllowsAPI bool
+}
+
+// WebhookTarget is a webhook-scoped key that receives conversion events
+type WebhookTarget struct {
+	KeyID  string
+	URL    string
+	Secret string
+}
+
+// CreateKeyRequest represents a request to create an API key
+type CreateKeyRequest struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	Scopes             []string `json:"scopes" binding:"required,min=1"`
+	RateLimitPerMinute int      `json:"rateLimitPerMinute" binding:"omitempty,min=1"`
+	WebhookURL         string   `json:"webhookUrl" binding:"omitempty,url"`
+	ExpiresInDays      int      `json:"expiresInDays" binding:"omitempty,min=1,max=3650"`
+}
+
+// CreatedKey is returned once when a key is created; Key and WebhookSecret
+// cannot be retrieved again
+type CreatedKey struct {
+	APIKey
+	Key           string `json:"key"`
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+// ListKeysResponse represents the keys of a user
+type ListKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// newKey holds the fields persisted for a new key
+type newKey struct {
+	UserID             string
+	Name               string
+	Prefix             string
+	Hash               string
+	Scopes             []string
+	RateLimitPerMinute int
+	WebhookURL         *string
+	WebhookSecret      *string
+	ExpiresAt          *time.Time
+}
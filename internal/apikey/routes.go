@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MountRoutes mounts API key management routes for the signed-in user
+func MountRoutes(r *gin.RouterGroup, handler *Handler) {
+	keys := r.Group("/users/me/api-keys")
+	keys.Use(authenticateMiddleware())
+	{
+		common.Mount(keys, http.MethodPost, "", handler.CreateKeyEndpoint())
+		common.Mount(keys, http.MethodGet, "", handler.ListKeysEndpoint())
+		common.Mount(keys, http.MethodDelete, "/:id", handler.RevokeKeyEndpoint())
+	}
+}
+
+// authenticateMiddleware requires a user set by token authentication
+func authenticateMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := common.GetUserIDFromContext(c.Request.Context())
+		if userID == "" {
+			common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
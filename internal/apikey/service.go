@@ -0,0 +1,208 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// rateLimitWindow is the window of a key's rate limit
+const rateLimitWindow = time.Minute
+
+// Service manages API keys and authenticates requests made with them
+type Service struct {
+	store   Store
+	limiter RateLimiter
+	config  Config
+
+	// lookupHost resolves webhook hosts, replaced in tests
+	lookupHost func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewService creates a new API key service
+func NewService(store Store, limiter RateLimiter, config Config) *Service {
+	defaults := DefaultConfig()
+	if config.MaxPerUser <= 0 {
+		config.MaxPerUser = defaults.MaxPerUser
+	}
+	if config.DefaultRateLimit <= 0 {
+		config.DefaultRateLimit = defaults.DefaultRateLimit
+	}
+	if config.MaxRateLimit < config.DefaultRateLimit {
+		config.MaxRateLimit = config.DefaultRateLimit
+	}
+
+	return &Service{store: store, limiter: limiter, config: config, lookupHost: net.DefaultResolver.LookupIPAddr}
+}
+
+// CreateKey creates a key for a user whose plan includes API access. The
+// returned key is the only time its secret is shown.
+func (s *Service) CreateKey(ctx context.Context, userID string, req CreateKeyRequest) (CreatedKey, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+
+	rateLimit := req.RateLimitPerMinute
+	if rateLimit == 0 {
+		rateLimit = s.config.DefaultRateLimit
+	}
+	if rateLimit > s.config.MaxRateLimit {
+		return CreatedKey{}, fmt.Errorf("%w: rate limit cannot exceed %d requests per minute", common.ErrValidation, s.config.MaxRateLimit)
+	}
+
+	hasWebhookScope := false
+	for _, scope := range scopes {
+		hasWebhookScope = hasWebhookScope || scope == ScopeWebhook
+	}
+	if hasWebhookScope != (req.WebhookURL != "") {
+		return CreatedKey{}, fmt.Errorf("%w: webhookUrl is required with, and only allowed with, the webhook scope", common.ErrValidation)
+	}
+	if req.WebhookURL != "" {
+		if err := validateWebhookURL(ctx, s.lookupHost, req.WebhookURL); err != nil {
+			return CreatedKey{}, err
+		}
+	}
+
+	allowed, err := s.store.HasAPIAccess(ctx, userID)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	if !allowed {
+		return CreatedKey{}, ErrAPIAccessNotAllowed
+	}
+
+	count, err := s.store.CountActiveKeys(ctx, userID)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	if count >= s.config.MaxPerUser {
+		return CreatedKey{}, ErrKeyLimitReached
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	rawKey := keyPrefix + secret
+
+	key := newKey{
+		UserID:             userID,
+		Name:               strings.TrimSpace(req.Name),
+		Prefix:             rawKey[:len(keyPrefix)+8],
+		Hash:               hashKey(rawKey),
+		Scopes:             scopes,
+		RateLimitPerMinute: rateLimit,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	created := CreatedKey{Key: rawKey}
+	if req.WebhookURL != "" {
+		webhookSecret, err := randomToken(24)
+		if err != nil {
+			return CreatedKey{}, err
+		}
+		key.WebhookURL = &req.WebhookURL
+		key.WebhookSecret = &webhookSecret
+		created.WebhookSecret = webhookSecret
+	}
+
+	created.APIKey, err = s.store.CreateKey(ctx, key)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	return created, nil
+}
+
+// ListKeys returns the keys of a user
+func (s *Service) ListKeys(ctx context.Context, userID string) (ListKeysResponse, error) {
+	keys, err := s.store.ListKeys(ctx, userID)
+	if err != nil {
+		return ListKeysResponse{}, err
+	}
+	return ListKeysResponse{Keys: keys}, nil
+}
+
+// RevokeKey revokes a key of the user
+func (s *Service) RevokeKey(ctx context.Context, userID, keyID string) error {
+	return s.store.RevokeKey(ctx, userID, keyID)
+}
+
+// Authenticate returns the active key matching rawKey. Keys stop working when
+// their owner's plan no longer includes API access.
+func (s *Service) Authenticate(ctx context.Context, rawKey string) (APIKey, error) {
+	if !strings.HasPrefix(rawKey, keyPrefix) {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	key, err := s.store.GetActiveKeyByHash(ctx, hashKey(rawKey))
+	if err != nil {
+		return APIKey{}, err
+	}
+	if !key.PlanAllowsAPI {
+		return APIKey{}, ErrAPIAccessNotAllowed
+	}
+
+	if err := s.store.RecordUsage(ctx, key.ID); err != nil {
+		// Log but don't fail the request - usage counts are informational
+		fmt.Printf("Failed to record API key usage: %v\n", err)
+	}
+	return key.APIKey, nil
+}
+
+// Allow applies the key's per-minute rate limit
+func (s *Service) Allow(ctx context.Context, key APIKey) bool {
+	if s.limiter == nil {
+		return true
+	}
+	return s.limiter.Allow(ctx, "api_key:"+key.ID, key.RateLimitPerMinute, rateLimitWindow)
+}
+
+// normalizeScopes validates and deduplicates scopes
+func normalizeScopes(requested []string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range requested {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		valid := false
+		for _, v := range ValidScopes {
+			valid = valid || scope == v
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: unknown scope %q, expected one of %s", common.ErrValidation, scope, strings.Join(ValidScopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", common.ErrValidation)
+	}
+	return scopes, nil
+}
+
+// hashKey returns the stored form of a key
+func hashKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
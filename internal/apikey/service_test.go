@@ -0,0 +1,313 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockStore struct {
+	keys      map[string]APIKey // by hash
+	apiAccess map[string]bool
+	usage     map[string]int
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{keys: map[string]APIKey{}, apiAccess: map[string]bool{}, usage: map[string]int{}}
+}
+
+func (m *mockStore) CreateKey(ctx context.Context, key newKey) (APIKey, error) {
+	created := APIKey{
+		ID:                 "key-" + key.Prefix,
+		UserID:             key.UserID,
+		Name:               key.Name,
+		Prefix:             key.Prefix,
+		Scopes:             key.Scopes,
+		RateLimitPerMinute: key.RateLimitPerMinute,
+		WebhookURL:         key.WebhookURL,
+		ExpiresAt:          key.ExpiresAt,
+		CreatedAt:          time.Now(),
+	}
+	m.keys[key.Hash] = created
+	return created, nil
+}
+
+func (m *mockStore) ListKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockStore) CountActiveKeys(ctx context.Context, userID string) (int, error) {
+	count := 0
+	for _, key := range m.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockStore) RevokeKey(ctx context.Context, userID, keyID string) error {
+	for hash, key := range m.keys {
+		if key.ID == keyID && key.UserID == userID && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			m.keys[hash] = key
+			return nil
+		}
+	}
+	return common.ErrNotFound
+}
+
+func (m *mockStore) HasAPIAccess(ctx context.Context, userID string) (bool, error) {
+	return m.apiAccess[userID], nil
+}
+
+func (m *mockStore) GetActiveKeyByHash(ctx context.Context, hash string) (ActiveKey, error) {
+	key, exists := m.keys[hash]
+	if !exists || key.RevokedAt != nil {
+		return ActiveKey{}, ErrInvalidKey
+	}
+	return ActiveKey{APIKey: key, PlanAllowsAPI: m.apiAccess[key.UserID]}, nil
+}
+
+func (m *mockStore) RecordUsage(ctx context.Context, keyID string) error {
+	m.usage[keyID]++
+	return nil
+}
+
+func (m *mockStore) ListWebhookTargets(ctx context.Context, userID string) ([]WebhookTarget, error) {
+	return nil, nil
+}
+
+type countingLimiter struct {
+	counts map[string]int
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) bool {
+	l.counts[key]++
+	return l.counts[key] <= limit
+}
+
+// fakeLookup resolves the hosts of the webhook tests without DNS
+func fakeLookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs := map[string]string{
+		"example.com":        "93.184.216.34",
+		"internal.example":   "10.0.0.5",
+		"localhost":          "127.0.0.1",
+		"metadata.internal":  "169.254.169.254",
+		"rebind.example.com": "192.168.1.10",
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+	if addr, ok := addrs[host]; ok {
+		return []net.IPAddr{{IP: net.ParseIP(addr)}}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestService_CreateKey_WebhookURL(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.apiAccess["premium-user"] = true
+	service := NewService(store, nil, Config{MaxPerUser: 10})
+	service.lookupHost = fakeLookup
+
+	rejected := []string{
+		"http://example.com/hook",
+		"https://localhost/hook",
+		"https://127.0.0.1:8080/hook",
+		"https://internal.example/hook",
+		"https://metadata.internal/latest/meta-data",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://100.64.0.1/hook",
+		"https://unknown.example/hook",
+	}
+	for _, webhookURL := range rejected {
+		_, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Hooks", Scopes: []string{ScopeWebhook}, WebhookURL: webhookURL})
+		if !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", webhookURL, err)
+		}
+	}
+
+	if _, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Hooks", Scopes: []string{ScopeWebhook}, WebhookURL: "https://example.com/hook"}); err != nil {
+		t.Errorf("Expected a public https webhook to be accepted, got %v", err)
+	}
+}
+
+func TestWebhookClient_RefusesPrivateAddresses(t *testing.T) {
+	// The test server listens on loopback, like an internal service would
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	resp, err := newWebhookClient(time.Second).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Expected the delivery to a loopback address to be refused")
+	}
+	if !errors.Is(err, errPrivateWebhookAddress) {
+		t.Errorf("Expected a private address error, got %v", err)
+	}
+}
+
+func TestService_CreateKey(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.apiAccess["premium-user"] = true
+	service := NewService(store, nil, Config{MaxPerUser: 2, DefaultRateLimit: 60, MaxRateLimit: 120})
+	service.lookupHost = fakeLookup
+
+	created, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Shop", Scopes: []string{"read", "Convert", "read"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(created.Key, keyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("Expected key %q to start with its prefix %q", created.Key, created.Prefix)
+	}
+	if len(created.Scopes) != 2 || created.RateLimitPerMinute != 60 {
+		t.Errorf("Expected scopes [read convert] at 60/min, got %v at %d/min", created.Scopes, created.RateLimitPerMinute)
+	}
+	if _, stored := store.keys[hashKey(created.Key)]; !stored {
+		t.Error("Expected only the key hash to be stored")
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		req    CreateKeyRequest
+		err    error
+	}{
+		{"no plan access", "free-user", CreateKeyRequest{Name: "x", Scopes: []string{ScopeRead}}, ErrAPIAccessNotAllowed},
+		{"unknown scope", "premium-user", CreateKeyRequest{Name: "x", Scopes: []string{"admin"}}, common.ErrValidation},
+		{"rate limit too high", "premium-user", CreateKeyRequest{Name: "x", Scopes: []string{ScopeRead}, RateLimitPerMinute: 500}, common.ErrValidation},
+		{"webhook scope without URL", "premium-user", CreateKeyRequest{Name: "x", Scopes: []string{ScopeWebhook}}, common.ErrValidation},
+		{"URL without webhook scope", "premium-user", CreateKeyRequest{Name: "x", Scopes: []string{ScopeRead}, WebhookURL: "https://example.com/hook"}, common.ErrValidation},
+	}
+	for _, tt := range tests {
+		if _, err := service.CreateKey(ctx, tt.userID, tt.req); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	webhook, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Hooks", Scopes: []string{ScopeWebhook}, WebhookURL: "https://example.com/hook"})
+	if err != nil {
+		t.Fatalf("Expected webhook key to be created, got %v", err)
+	}
+	if webhook.WebhookSecret == "" {
+		t.Error("Expected a webhook secret to be returned")
+	}
+
+	if _, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Third", Scopes: []string{ScopeRead}}); !errors.Is(err, ErrKeyLimitReached) {
+		t.Errorf("Expected key limit error, got %v", err)
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.apiAccess["premium-user"] = true
+	service := NewService(store, nil, DefaultConfig())
+
+	created, err := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Shop", Scopes: []string{ScopeRead}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	key, err := service.Authenticate(ctx, created.Key)
+	if err != nil || key.UserID != "premium-user" {
+		t.Fatalf("Expected key of premium-user, got %+v, %v", key, err)
+	}
+	if store.usage[key.ID] != 1 {
+		t.Errorf("Expected usage to be recorded once, got %d", store.usage[key.ID])
+	}
+
+	if _, err := service.Authenticate(ctx, "ais_unknown"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected invalid key error, got %v", err)
+	}
+
+	// Downgraded plans lose API access without revoking their keys
+	store.apiAccess["premium-user"] = false
+	if _, err := service.Authenticate(ctx, created.Key); !errors.Is(err, ErrAPIAccessNotAllowed) {
+		t.Errorf("Expected API access error after downgrade, got %v", err)
+	}
+
+	store.apiAccess["premium-user"] = true
+	if err := service.RevokeKey(ctx, "premium-user", created.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, created.Key); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	store := newMockStore()
+	store.apiAccess["premium-user"] = true
+	service := NewService(store, &countingLimiter{counts: map[string]int{}}, DefaultConfig())
+
+	readKey, _ := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Read", Scopes: []string{ScopeRead}, RateLimitPerMinute: 1})
+	convertKey, _ := service.CreateKey(ctx, "premium-user", CreateKeyRequest{Name: "Convert", Scopes: []string{ScopeConvert}})
+
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(NewHandler(service).Middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) }
+	api.GET("/conversions", ok)
+	api.POST("/convert", ok)
+	api.PUT("/user/profile", ok)
+
+	send := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(HeaderName, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"no key passes through", http.MethodGet, "/api/conversions", "", http.StatusOK},
+		{"read scope", http.MethodGet, "/api/conversions", readKey.Key, http.StatusOK},
+		{"convert without scope", http.MethodPost, "/api/convert", readKey.Key, http.StatusForbidden},
+		{"convert scope", http.MethodPost, "/api/convert", convertKey.Key, http.StatusOK},
+		{"route not available to keys", http.MethodPut, "/api/user/profile", convertKey.Key, http.StatusForbidden},
+		{"invalid key", http.MethodGet, "/api/conversions", "ais_invalid", http.StatusUnauthorized},
+		{"rate limited", http.MethodGet, "/api/conversions", readKey.Key, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		w := send(tt.method, tt.path, tt.key)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusOK && tt.key != "" && w.Body.String() != "premium-user" {
+			t.Errorf("%s: expected request to run as premium-user, got %q", tt.name, w.Body.String())
+		}
+	}
+}
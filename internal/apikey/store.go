@@ -0,0 +1,175 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// DBStore implements Store on top of the api_keys table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+const keyColumns = `id, user_id, name, key_prefix, scopes, rate_limit_per_minute, webhook_url,
+	request_count, last_used_at, expires_at, revoked_at, created_at`
+
+func scanKey(scanner interface{ Scan(...interface{}) error }, key *APIKey, extra ...interface{}) error {
+	dest := []interface{}{
+		&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RateLimitPerMinute, &key.WebhookURL,
+		&key.RequestCount, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt,
+	}
+	return scanner.Scan(append(dest, extra...)...)
+}
+
+// CreateKey stores a new key
+func (s *DBStore) CreateKey(ctx context.Context, key newKey) (APIKey, error) {
+	var created APIKey
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, rate_limit_per_minute,
+			webhook_url, webhook_secret, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+keyColumns,
+		key.UserID, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.RateLimitPerMinute,
+		key.WebhookURL, key.WebhookSecret, key.ExpiresAt)
+	if err := scanKey(row, &created); err != nil {
+		return APIKey{}, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return created, nil
+}
+
+// ListKeys returns every key of a user, newest first
+func (s *DBStore) ListKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+keyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		var key APIKey
+		if err := scanKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// CountActiveKeys counts the unrevoked, unexpired keys of a user
+func (s *DBStore) CountActiveKeys(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	return count, nil
+}
+
+// RevokeKey revokes a key of the user
+func (s *DBStore) RevokeKey(ctx context.Context, userID, keyID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("API key %w", common.ErrNotFound)
+	}
+	return nil
+}
+
+// planAPIAccess is true when the user's newest active plan includes API access,
+// matching plan definitions by name like the quota store does
+const planAPIAccess = `COALESCE((
+	SELECT pp.api_access
+	FROM user_plans up
+	JOIN payment_plans pp ON pp.name = up.plan_name
+	WHERE up.user_id = %s AND up.status = 'active'
+	  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+	ORDER BY up.created_at DESC
+	LIMIT 1), false)`
+
+// HasAPIAccess reports whether the user's plan includes API access
+func (s *DBStore) HasAPIAccess(ctx context.Context, userID string) (bool, error) {
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, "SELECT "+fmt.Sprintf(planAPIAccess, "$1"), userID).Scan(&allowed); err != nil {
+		return false, fmt.Errorf("failed to check API access: %w", err)
+	}
+	return allowed, nil
+}
+
+// GetActiveKeyByHash returns an unrevoked, unexpired key with its owner's plan access
+func (s *DBStore) GetActiveKeyByHash(ctx context.Context, hash string) (ActiveKey, error) {
+	var key ActiveKey
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+keyColumns+`, `+fmt.Sprintf(planAPIAccess, "k.user_id")+`
+		FROM api_keys k
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`, hash)
+	if err := scanKey(row, &key.APIKey, &key.PlanAllowsAPI); err != nil {
+		if err == sql.ErrNoRows {
+			return ActiveKey{}, ErrInvalidKey
+		}
+		return ActiveKey{}, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// RecordUsage counts a request made with the key
+func (s *DBStore) RecordUsage(ctx context.Context, keyID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET request_count = request_count + 1, last_used_at = NOW()
+		WHERE id = $1`, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to record API key usage: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookTargets returns the active webhook-scoped keys of a user
+func (s *DBStore) ListWebhookTargets(ctx context.Context, userID string) ([]WebhookTarget, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, webhook_url, COALESCE(webhook_secret, '')
+		FROM api_keys
+		WHERE user_id = $1 AND webhook_url IS NOT NULL AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND $2 = ANY(scopes)`, userID, ScopeWebhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []WebhookTarget
+	for rows.Next() {
+		var target WebhookTarget
+		if err := rows.Scan(&target.KeyID, &target.URL, &target.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook target: %w", err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, rows.Err()
+}
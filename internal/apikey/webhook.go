@@ -0,0 +1,154 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/outbox"
+)
+
+// errPrivateWebhookAddress is returned when a webhook resolves to an address
+// inside the network
+var errPrivateWebhookAddress = errors.New("webhook address is not public")
+
+// nonPublicNetworks are ranges net.IP has no predicate for that must not be
+// reached from inside the network either
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// WebhookPublisher delivers conversion events to the webhook URLs of the
+// owner's webhook-scoped keys. It is registered with the outbox dispatcher.
+type WebhookPublisher struct {
+	store  Store
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a new API key webhook publisher. Deliveries only
+// connect to public addresses and do not follow redirects.
+func NewWebhookPublisher(store Store, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{store: store, client: newWebhookClient(timeout)}
+}
+
+// newWebhookClient creates a client that refuses non-public addresses when it
+// dials, after name resolution, so DNS changes made after a key was created
+// cannot point deliveries inside the network
+func newWebhookClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: rejectNonPublicAddress}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// rejectNonPublicAddress is the dialer control refusing non-public addresses
+func rejectNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicIP(net.ParseIP(host)) {
+		return errPrivateWebhookAddress
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is reachable on the internet: not loopback,
+// private, link-local (which covers cloud metadata endpoints) or reserved
+func isPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookURL requires an https URL whose host resolves only to public
+// addresses. The publisher checks the address again when it connects.
+func validateWebhookURL(ctx context.Context, lookup func(ctx context.Context, host string) ([]net.IPAddr, error), rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: webhookUrl must be an https URL", common.ErrValidation)
+	}
+	addrs, err := lookup(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: webhookUrl host cannot be resolved", common.ErrValidation)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: webhookUrl must point to a public address", common.ErrValidation)
+		}
+	}
+	return nil
+}
+
+// Name returns the publisher name
+func (p *WebhookPublisher) Name() string {
+	return "api_key_webhook"
+}
+
+// Handles reports whether the event is a conversion event
+func (p *WebhookPublisher) Handles(eventType string) bool {
+	switch eventType {
+	case outbox.EventConversionStarted, outbox.EventConversionCompleted, outbox.EventConversionFailed:
+		return true
+	}
+	return false
+}
+
+// Publish posts the event to each webhook of the conversion's owner, signed
+// with the key's webhook secret. Deliveries are best effort: a failing
+// customer endpoint is logged rather than retried, so it can't cause the
+// event's other publishers to run again.
+func (p *WebhookPublisher) Publish(ctx context.Context, event outbox.Event) error {
+	var payload outbox.ConversionEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to decode conversion event: %w", err)
+	}
+
+	targets, err := p.store.ListWebhookTargets(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		// Keys created before webhooks had to be https are skipped
+		if u, err := url.Parse(target.URL); err != nil || u.Scheme != "https" {
+			log.Printf("Skipping event %s for API key %s: webhook is not an https URL", event.ID, target.KeyID)
+			continue
+		}
+		publisher := outbox.NewWebhookPublisherWithClient([]string{target.URL}, target.Secret, p.client)
+		if err := publisher.Publish(ctx, event); err != nil {
+			log.Printf("Failed to deliver event %s to API key %s webhook: %v", event.ID, target.KeyID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package apikey
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+)
+
+// WireAPIKeyService creates an API key service with all dependencies
+func WireAPIKeyService(db *sql.DB, cfg *config.Config, limiter RateLimiter) (*Service, *Handler) {
+	store := NewDBStore(db)
+	service := NewService(store, limiter, Config{
+		MaxPerUser:       cfg.APIKey.MaxPerUser,
+		DefaultRateLimit: cfg.APIKey.DefaultRateLimit,
+		MaxRateLimit:     cfg.APIKey.MaxRateLimit,
+	})
+	handler := NewHandler(service)
+	return service, handler
+}
@@ -2,7 +2,6 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -24,25 +23,44 @@ type Handler struct {
 	sms         sms.Provider
 	hasher      security.PasswordHasher
 	accessTTL   time.Duration
+	onboarding  OnboardingGranter
+	screener    SignupScreener
+	sessions    SessionManager
+
+	twoFactor       TwoFactorStore
+	twoFactorTokens TwoFactorTokenIssuer
+	twoFactorConfig TwoFactorConfig
+
+	telegramLinks  TelegramLinkStore
+	telegramConfig TelegramLinkConfig
+
+	loginDevices     LoginDeviceStore
+	loginAlerts      LoginAlertSender
+	loginAlertConfig LoginAlertConfig
+
+	otpGuard *otpGuard
+
+	jwks JWKSProvider
 }
 
+// NewHandler creates a handler hashing passwords with the default settings
 func NewHandler(store Store, tokens TokenService, rl RateLimiter, smsProvider sms.Provider) *Handler {
-	// Load config for password hashing
-	cfg, err := config.Load()
-	if err != nil {
-		// Use default config if loading fails
-		cfg = &config.Config{
-			Security: config.SecurityConfig{
-				BCryptCost:        12,
-				Argon2Memory:      65536,
-				Argon2Iterations:  3,
-				Argon2Parallelism: 2,
-				Argon2SaltLength:  16,
-				Argon2KeyLength:   32,
-			},
-		}
-	}
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			BCryptCost:        12,
+			Argon2Memory:      65536,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 2,
+			Argon2SaltLength:  16,
+			Argon2KeyLength:   32,
+		},
+	}
+	return NewHandlerWithConfig(cfg, store, tokens, rl, smsProvider)
+}
 
+// NewHandlerWithConfig creates a handler using the password hashing settings
+// and access token TTL of cfg
+func NewHandlerWithConfig(cfg *config.Config, store Store, tokens TokenService, rl RateLimiter, smsProvider sms.Provider) *Handler {
 	// Create password hasher based on config
 	var hasher security.PasswordHasher
 	if cfg.Security.Argon2Memory > 0 {
@@ -73,13 +91,24 @@ func NewHandler(store Store, tokens TokenService, rl RateLimiter, smsProvider sm
 	}
 }
 
+// SetOnboardingGranter enables signup credit grants on registration
+func (h *Handler) SetOnboardingGranter(onboarding OnboardingGranter) {
+	h.onboarding = onboarding
+}
+
+// SetSignupScreener enables abuse scoring of new accounts, which may limit
+// or hold back the free quota granted at signup
+func (h *Handler) SetSignupScreener(screener SignupScreener) {
+	h.screener = screener
+}
+
 // GetTokenService returns the token service for use in middleware
 func (h *Handler) GetTokenService() TokenService {
 	return h.tokens
 }
 
 type sendOtpReq struct {
-	Phone   string `json:"phone"`
+	Phone   string `json:"phone" binding:"required,phone"`
 	Purpose string `json:"purpose"`
 	Channel string `json:"channel"`
 }
@@ -89,38 +118,56 @@ type sendOtpResp struct {
 	ExpiresInSec int    `json:"expiresInSec"`
 	Code         string `json:"code,omitempty"`  // Only returned in development/mock mode
 	Debug        bool   `json:"debug,omitempty"` // Indicates if this is a debug response
+
+	// Wait before another code can be requested, when resends are limited
+	ResendInSec int `json:"resendInSec,omitempty"`
 }
 
 func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 	var req sendOtpReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("SendOTP: JSON decode error: %v", err)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
+	if err := common.DecodeJSON(r, &req); err != nil {
+		log.Printf("SendOTP: invalid request: %v", err)
+		common.WriteAPIError(w, common.FromError(err, http.StatusBadRequest))
 		return
 	}
 	phone := normalizePhone(req.Phone)
-	if phone == "" {
-		log.Printf("SendOTP: Invalid phone - original: %q, normalized: %q", req.Phone, phone)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid phone number", nil)
-		return
-	}
 	ip := clientIP(r)
 	if !h.rateLimiter.Allow(r.Context(), "send_otp:phone:"+phone, 3, time.Hour) ||
 		!h.rateLimiter.Allow(r.Context(), "send_otp:ip:"+ip, 100, 24*time.Hour) {
 		common.WriteError(w, http.StatusTooManyRequests, "rate_limited", "too many requests", nil)
 		return
 	}
+	var resendIn time.Duration
+	if h.otpGuard != nil {
+		wait, err := h.otpGuard.allowSend(r.Context(), phone, ip)
+		switch {
+		case errors.Is(err, ErrOTPLocked):
+			writeOTPRetry(w, "otp_locked", "too many failed attempts, try again later", wait)
+			return
+		case errors.Is(err, ErrOTPResendCooldown):
+			writeOTPRetry(w, "otp_cooldown", "please wait before requesting another code", wait)
+			return
+		case err != nil:
+			log.Printf("SendOTP: otp guard failed: %v", err)
+			common.WriteError(w, http.StatusInternalServerError, "server_error", "could not create otp", nil)
+			return
+		}
+		resendIn = wait
+	}
 	code, _, err := h.store.CreateOTP(r.Context(), phone, "phone_verify", 6, 5*time.Minute)
 	if err != nil {
 		common.WriteError(w, http.StatusInternalServerError, "server_error", "could not create otp", nil)
 		return
 	}
-	_ = h.sms.Send(code, phone)
+	if err := h.sms.Send(code, phone); err != nil {
+		log.Printf("SendOTP: delivery failed: %v", err)
+	}
 
 	// If SMS provider is mock, include the code in response for development
 	resp := sendOtpResp{
 		Sent:         true,
 		ExpiresInSec: 300,
+		ResendInSec:  int(resendIn.Seconds()),
 	}
 	if h.sms.IsMock() {
 		resp.Code = code
@@ -131,8 +178,8 @@ func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 }
 
 type verifyReq struct {
-	Phone   string `json:"phone"`
-	Code    string `json:"code"`
+	Phone   string `json:"phone" binding:"required,phone"`
+	Code    string `json:"code" binding:"required,len=6,numeric"`
 	Purpose string `json:"purpose"`
 }
 
@@ -141,7 +188,7 @@ type verifyResp struct {
 }
 
 type checkUserReq struct {
-	Phone string `json:"phone"`
+	Phone string `json:"phone" binding:"required,phone"`
 }
 
 type checkUserResp struct {
@@ -150,25 +197,42 @@ type checkUserResp struct {
 
 func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	var req verifyReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("VerifyOTP: JSON decode error: %v", err)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
+	if err := common.DecodeJSON(r, &req); err != nil {
+		log.Printf("VerifyOTP: invalid request: %v", err)
+		common.WriteAPIError(w, common.FromError(err, http.StatusBadRequest))
 		return
 	}
 	phone := normalizePhone(req.Phone)
-	if phone == "" {
-		log.Printf("VerifyOTP: Invalid phone - original: %q, normalized: %q", req.Phone, phone)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid phone number", nil)
-		return
-	}
-	if len(req.Code) != 6 {
-		log.Printf("VerifyOTP: Invalid code length - provided: %d, expected: 6", len(req.Code))
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "OTP code must be exactly 6 digits", nil)
-		return
+	ip := clientIP(r)
+	if h.otpGuard != nil {
+		if wait, err := h.otpGuard.checkLocked(r.Context(), phone, ip); errors.Is(err, ErrOTPLocked) {
+			writeOTPRetry(w, "otp_locked", "too many failed attempts, try again later", wait)
+			return
+		} else if err != nil {
+			log.Printf("VerifyOTP: otp guard failed: %v", err)
+			common.WriteError(w, http.StatusInternalServerError, "server_error", "verification failed", nil)
+			return
+		}
 	}
 	ok, err := h.store.VerifyOTP(r.Context(), phone, req.Code, "phone_verify")
 	if err != nil {
-		if errors.Is(err, ErrOTPExpired) || errors.Is(err, ErrOTPInvalid) {
+		invalid := errors.Is(err, ErrOTPExpired) || errors.Is(err, ErrOTPInvalid) || errors.Is(err, ErrOTPAttemptsExceeded)
+		if invalid && h.otpGuard != nil {
+			lockedUntil, guardErr := h.otpGuard.failed(r.Context(), phone, ip, err)
+			if guardErr != nil {
+				log.Printf("VerifyOTP: failed to count otp failure: %v", guardErr)
+			}
+			if lockedUntil != nil {
+				h.notifyLockout(r.Context(), phone, ip, *lockedUntil)
+				writeOTPRetry(w, "otp_locked", "too many failed attempts, try again later", time.Until(*lockedUntil))
+				return
+			}
+		}
+		if errors.Is(err, ErrOTPAttemptsExceeded) {
+			common.WriteError(w, http.StatusBadRequest, "otp_attempts_exceeded", "too many attempts, request a new code", nil)
+			return
+		}
+		if invalid {
 			common.WriteError(w, http.StatusBadRequest, "invalid_otp", "invalid or expired otp", nil)
 			return
 		}
@@ -176,6 +240,9 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if ok {
+		if h.otpGuard != nil {
+			h.otpGuard.verified(r.Context(), phone, ip)
+		}
 		_ = h.store.MarkPhoneVerified(r.Context(), phone)
 		common.WriteJSON(w, http.StatusOK, verifyResp{Verified: true})
 		return
@@ -183,37 +250,46 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	common.WriteJSON(w, http.StatusOK, verifyResp{Verified: false})
 }
 
+// CheckUserEndpoint reports whether a phone number is registered
+func (h *Handler) CheckUserEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Check whether a phone number is registered",
+		Tags:    []string{"Authentication"},
+	}, h.checkUser)
+}
+
 func (h *Handler) CheckUser(w http.ResponseWriter, r *http.Request) {
-	var req checkUserReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("CheckUser: JSON decode error: %v", err)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
-		return
-	}
+	h.CheckUserEndpoint().ServeHTTP(w, r)
+}
 
+func (h *Handler) checkUser(ctx context.Context, req *checkUserReq) (*checkUserResp, error) {
 	phone := normalizePhone(req.Phone)
-	if phone == "" {
-		log.Printf("CheckUser: Invalid phone - original: %q, normalized: %q", req.Phone, phone)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid phone number", nil)
-		return
-	}
 
-	exists, err := h.store.UserExists(r.Context(), phone)
+	exists, err := h.store.UserExists(ctx, phone)
 	if err != nil {
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "could not check user", nil)
-		return
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not check user", nil)
 	}
 
-	common.WriteJSON(w, http.StatusOK, checkUserResp{Registered: exists})
+	return &checkUserResp{Registered: exists}, nil
 }
 
 type registerReq struct {
-	Phone       string `json:"phone"`
-	Password    string `json:"password"`
-	Role        string `json:"role"`
+	Phone       string `json:"phone" binding:"required,phone"`
+	Password    string `json:"password" binding:"required"`
+	Role        string `json:"role" binding:"required,oneof=user vendor"`
 	AutoLogin   bool   `json:"autoLogin"`
-	DisplayName string `json:"displayName"`
-	CompanyName string `json:"companyName"`
+	DisplayName string `json:"displayName" binding:"max=100"`
+	CompanyName string `json:"companyName" binding:"max=200"`
+}
+
+// Validate applies the password rules
+func (r *registerReq) Validate() error {
+	if valid, errMsg := validatePassword(r.Password); !valid {
+		var errs common.ValidationErrors
+		errs.Add("password", errMsg, "")
+		return errs
+	}
+	return nil
 }
 
 type registerResp struct {
@@ -228,28 +304,12 @@ type registerResp struct {
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req registerReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Register: JSON decode error: %v", err)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
+	if err := common.DecodeJSON(r, &req); err != nil {
+		log.Printf("Register: invalid request: %v", err)
+		common.WriteAPIError(w, common.FromError(err, http.StatusBadRequest))
 		return
 	}
 	phone := normalizePhone(req.Phone)
-	if phone == "" {
-		log.Printf("Register: Invalid phone - original: %q, normalized: %q", req.Phone, phone)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid phone number", nil)
-		return
-	}
-	valid, errMsg := validatePassword(req.Password)
-	if !valid {
-		log.Printf("Register: Password validation failed - length: %d, error: %s", len(req.Password), errMsg)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", errMsg, nil)
-		return
-	}
-	if req.Role != "user" && req.Role != "vendor" {
-		log.Printf("Register: Invalid role - provided: %q", req.Role)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "role must be 'user' or 'vendor'", nil)
-		return
-	}
 	if exists, _ := h.store.UserExists(r.Context(), phone); exists {
 		common.WriteError(w, http.StatusConflict, "conflict", "account exists", nil)
 		return
@@ -280,6 +340,25 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.onboarding != nil {
+		if err := h.onboarding.GrantSignupCredits(r.Context(), userID, req.Role); err != nil {
+			// Log but don't fail registration
+			log.Printf("Register: failed to grant signup credits to %s: %v", userID, err)
+		}
+	}
+	if h.screener != nil {
+		// Browsers without a device ID share their fingerprint with every
+		// user of the same browser, so only app devices are compared
+		fingerprint := ""
+		if deviceID := r.Header.Get(DeviceIDHeader); strings.TrimSpace(deviceID) != "" {
+			fingerprint = deviceFingerprint(deviceID, r.UserAgent())
+		}
+		if err := h.screener.ScreenSignup(r.Context(), userID, req.Role, phone, fingerprint, ClientIPFromContext(r.Context())); err != nil {
+			// Log but don't fail registration
+			log.Printf("Register: failed to screen signup of %s: %v", userID, err)
+		}
+	}
+
 	// If registration came from Telegram bot, mark phone as verified automatically
 	if skipVerification {
 		_ = h.store.MarkPhoneVerified(r.Context(), phone)
@@ -299,8 +378,10 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 }
 
 type loginReq struct {
-	Phone    string `json:"phone"`
-	Password string `json:"password"`
+	Phone     string `json:"phone" binding:"required,phone"`
+	Password  string `json:"password" binding:"required"`
+	UserAgent string `json:"-" header:"User-Agent"`
+	DeviceID  string `json:"-" header:"X-Device-ID"`
 }
 
 type loginResp struct {
@@ -313,51 +394,59 @@ type loginResp struct {
 		Role            string `json:"role"`
 		IsPhoneVerified bool   `json:"isPhoneVerified"`
 	} `json:"user"`
+
+	// Set instead of tokens when the account has two-factor authentication;
+	// the challenge is exchanged at /auth/2fa/verify
+	TwoFactorRequired  bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken     string `json:"challengeToken,omitempty"`
+	ChallengeExpiresIn int    `json:"challengeExpiresIn,omitempty"`
+}
+
+// LoginEndpoint exchanges a phone number and password for a token pair
+func (h *Handler) LoginEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Log in with phone number and password",
+		Tags:    []string{"Authentication"},
+	}, h.login)
 }
 
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
-	var req loginReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Login: JSON decode error: %v", err)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
-		return
-	}
+	h.LoginEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) login(ctx context.Context, req *loginReq) (*loginResp, error) {
 	phone := normalizePhone(req.Phone)
-	if phone == "" {
-		log.Printf("Login: Invalid phone - original: %q, normalized: %q", req.Phone, phone)
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid phone number", nil)
-		return
-	}
-	if len(req.Password) == 0 {
-		log.Printf("Login: Password is empty")
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "password is required", nil)
-		return
-	}
-	user, err := h.store.GetUserByPhone(r.Context(), phone)
+	user, err := h.store.GetUserByPhone(ctx, phone)
 	if err != nil {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid credentials", nil)
-		return
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid credentials", nil)
 	}
 	if !h.hasher.Verify(req.Password, user.PasswordHash) {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid credentials", nil)
-		return
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid credentials", nil)
 	}
 	if !user.IsPhoneVerified {
-		common.WriteError(w, http.StatusForbidden, "forbidden", "phone not verified", nil)
-		return
+		return nil, common.NewAPIError(http.StatusForbidden, "", "phone not verified", nil)
 	}
 	if !user.IsActive {
-		common.WriteError(w, http.StatusForbidden, "forbidden", "account is inactive", nil)
-		return
+		return nil, common.NewAPIError(http.StatusForbidden, "", "account is inactive", nil)
+	}
+	if h.twoFactor != nil && isTwoFactorRole(user.Role) {
+		enabled, err := h.twoFactorEnabled(ctx, user.ID)
+		if err != nil {
+			log.Printf("Failed to load two-factor enrollment: %v", err)
+			return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not log in", nil)
+		}
+		if enabled {
+			return h.twoFactorChallenge(ctx, user)
+		}
 	}
-	at, rt, expAt, err := h.tokens.IssueTokens(r.Context(), user.ID, user.Phone, user.Role, r.UserAgent())
+	at, rt, expAt, err := h.tokens.IssueTokens(ctx, user.ID, user.Phone, user.Role, req.UserAgent)
 	if err != nil {
 		// Log the actual error for debugging
 		log.Printf("Failed to issue tokens: %v", err)
-		common.WriteError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("could not issue tokens: %v", err), nil)
-		return
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", fmt.Sprintf("could not issue tokens: %v", err), nil)
 	}
-	var resp loginResp
+	h.checkNewLogin(ctx, user.ID, at, req.UserAgent, req.DeviceID)
+	resp := &loginResp{}
 	resp.AccessToken = at
 	resp.AccessTokenExpiresIn = int(h.accessTTL.Seconds())
 	resp.RefreshToken = rt
@@ -365,7 +454,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	resp.User.ID = user.ID
 	resp.User.Role = user.Role
 	resp.User.IsPhoneVerified = user.IsPhoneVerified
-	common.WriteJSON(w, http.StatusOK, resp)
+	return resp, nil
 }
 
 type refreshReq struct {
@@ -388,25 +477,30 @@ type refreshResp struct {
 	RefreshTokenExpiresAt string `json:"refreshTokenExpiresAt"`
 }
 
+// RefreshEndpoint rotates a refresh token into a new token pair
+func (h *Handler) RefreshEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Rotate a refresh token",
+		Tags:    []string{"Authentication"},
+	}, h.refresh)
+}
+
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
-	var req refreshReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
-		return
-	}
+	h.RefreshEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) refresh(ctx context.Context, req *refreshReq) (*refreshResp, error) {
 	refreshToken := req.GetRefreshToken()
 	if refreshToken == "" {
-		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid input: refreshToken is required", nil)
-		return
+		return nil, common.NewAPIError(http.StatusBadRequest, "bad_request", "invalid input: refreshToken is required", nil)
 	}
-	at, rt, expAt, err := h.tokens.Rotate(r.Context(), refreshToken)
+	at, rt, expAt, err := h.tokens.Rotate(ctx, refreshToken)
 	if err != nil {
 		// Log the actual error for debugging
 		log.Printf("Failed to rotate refresh token: %v", err)
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", fmt.Sprintf("invalid refresh: %v", err), nil)
-		return
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", fmt.Sprintf("invalid refresh: %v", err), nil)
 	}
-	common.WriteJSON(w, http.StatusOK, refreshResp{AccessToken: at, AccessTokenExpiresIn: int(h.accessTTL.Seconds()), RefreshToken: rt, RefreshTokenExpiresAt: expAt.Format(time.RFC3339)})
+	return &refreshResp{AccessToken: at, AccessTokenExpiresIn: int(h.accessTTL.Seconds()), RefreshToken: rt, RefreshTokenExpiresAt: expAt.Format(time.RFC3339)}, nil
 }
 
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
@@ -437,6 +531,7 @@ func (h *Handler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 		}
 		ctx := context.WithValue(r.Context(), ctxUserID{}, claims.UserID)
 		ctx = context.WithValue(ctx, ctxSessionID{}, claims.SessionID)
+		ctx = context.WithValue(ctx, ctxClaims{}, claims)
 		next(w, r.WithContext(ctx))
 	}
 }
@@ -444,6 +539,7 @@ func (h *Handler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 // Helpers and models
 type ctxUserID struct{}
 type ctxSessionID struct{}
+type ctxClaims struct{}
 
 type User struct {
 	ID              string
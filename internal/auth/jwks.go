@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/security"
+)
+
+// JWKSProvider returns the public keys tokens can be verified with
+type JWKSProvider interface {
+	JWKS() security.JWKSet
+	JWKSMaxAge() time.Duration
+}
+
+// SetJWKS publishes the token verification keys of jwks at /.well-known/jwks.json
+func (h *Handler) SetJWKS(jwks JWKSProvider) {
+	h.jwks = jwks
+}
+
+// JWKS handles GET /.well-known/jwks.json so other services, such as the bot,
+// can verify access tokens without calling the API
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	if h.jwks == nil {
+		common.WriteJSON(w, http.StatusOK, security.JWKSet{Keys: []security.JWK{}})
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.jwks.JWKSMaxAge().Seconds())))
+	common.WriteJSON(w, http.StatusOK, h.jwks.JWKS())
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/security"
+)
+
+// PostgresJWTKeyStore implements security.JWTKeyStore using PostgreSQL
+type PostgresJWTKeyStore struct {
+	db *sql.DB
+}
+
+// NewPostgresJWTKeyStore creates a new PostgreSQL JWT signing key store
+func NewPostgresJWTKeyStore(db *sql.DB) *PostgresJWTKeyStore {
+	return &PostgresJWTKeyStore{db: db}
+}
+
+// ListJWTKeys returns the signing keys that have not expired
+func (s *PostgresJWTKeyStore) ListJWTKeys(ctx context.Context) ([]security.StoredJWTKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, algorithm, private_key, created_at, expires_at
+		FROM jwt_signing_keys
+		WHERE expires_at > NOW()
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []security.StoredJWTKey
+	for rows.Next() {
+		var key security.StoredJWTKey
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &key.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// CreateJWTKey stores a signing key unless a key of its algorithm was created
+// after since. The table lock keeps instances rotating at the same time from
+// both adding a key.
+func (s *PostgresJWTKeyStore) CreateJWTKey(ctx context.Context, key security.StoredJWTKey, since time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE jwt_signing_keys IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return false, fmt.Errorf("failed to lock signing keys: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO jwt_signing_keys (id, algorithm, private_key, created_at, expires_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM jwt_signing_keys
+			WHERE algorithm = $2 AND created_at > $6 AND expires_at > NOW()
+		)
+	`, key.ID, key.Algorithm, key.PrivateKey, key.CreatedAt, key.ExpiresAt, since)
+	if err != nil {
+		return false, fmt.Errorf("failed to create signing key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create signing key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit signing key: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// DeleteExpiredJWTKeys removes keys no token can be verified with any more
+func (s *PostgresJWTKeyStore) DeleteExpiredJWTKeys(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM jwt_signing_keys WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to delete expired signing keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// ErrRevokeLinkInvalid is returned for forged, malformed or expired revoke links
+var ErrRevokeLinkInvalid = errors.New("invalid or expired revoke link")
+
+const (
+	defaultRevokeLinkTTL = 7 * 24 * time.Hour
+	// DeviceIDHeader carries a stable device identifier sent by the mobile apps
+	DeviceIDHeader = "X-Device-ID"
+)
+
+// LoginDevice is where a new session signed in from
+type LoginDevice struct {
+	UserID      string
+	SessionID   string
+	Fingerprint string
+	Country     string // empty when the country is unknown
+}
+
+// LoginHistory tells which parts of a login were seen before for its user
+type LoginHistory struct {
+	FirstLogin bool // the user never signed in before
+	NewDevice  bool
+	NewCountry bool
+}
+
+// LoginDeviceStore remembers the devices and countries users signed in from
+type LoginDeviceStore interface {
+	// RecordLogin tags the session with its device and country, remembers
+	// both for the user and reports which of them were seen before
+	RecordLogin(ctx context.Context, login LoginDevice) (LoginHistory, error)
+}
+
+// LoginAlertSender notifies a user about a sign-in from a new device or
+// country. revokeURL signs the session out without logging in.
+type LoginAlertSender interface {
+	SendNewLoginAlert(ctx context.Context, userID, sessionID, device, ip, country, revokeURL string) error
+}
+
+// LoginCountryResolver maps a client address to its ISO 3166 country code,
+// or "" when the address is unknown
+type LoginCountryResolver interface {
+	Country(ip net.IP) string
+}
+
+// LoginAlertConfig configures new device and country sign-in alerts
+type LoginAlertConfig struct {
+	RevokeURL     string // public URL of GET /auth/sessions/revoke
+	RevokeSecret  []byte // signs revoke links
+	RevokeLinkTTL time.Duration
+	Countries     LoginCountryResolver // nil only compares devices
+}
+
+// SetLoginAlerts enables alerts for sign-ins from new devices or countries
+func (h *Handler) SetLoginAlerts(store LoginDeviceStore, sender LoginAlertSender, config LoginAlertConfig) {
+	if config.RevokeLinkTTL <= 0 {
+		config.RevokeLinkTTL = defaultRevokeLinkTTL
+	}
+	h.loginDevices = store
+	h.loginAlerts = sender
+	h.loginAlertConfig = config
+}
+
+// deviceFingerprint identifies the device of a login. Apps send DeviceIDHeader;
+// browsers are told apart by their "Browser on OS" label so that version
+// updates don't look like a new device.
+func deviceFingerprint(deviceID, userAgent string) string {
+	source := "ua:" + describeDevice(userAgent)
+	if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+		source = "id:" + deviceID
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:16])
+}
+
+// checkNewLogin records the device and country of a login session and alerts
+// the user when either was not seen before. It never fails the login.
+func (h *Handler) checkNewLogin(ctx context.Context, userID, accessToken, userAgent, deviceID string) {
+	if h.loginDevices == nil {
+		return
+	}
+
+	claims, err := h.tokens.ValidateAccess(ctx, accessToken)
+	if err != nil {
+		log.Printf("Login alerts: failed to read new session: %v", err)
+		return
+	}
+
+	ip := ClientIPFromContext(ctx)
+	country := ""
+	if parsed := net.ParseIP(ip); parsed != nil && h.loginAlertConfig.Countries != nil {
+		country = h.loginAlertConfig.Countries.Country(parsed)
+	}
+
+	history, err := h.loginDevices.RecordLogin(ctx, LoginDevice{
+		UserID:      userID,
+		SessionID:   claims.SessionID,
+		Fingerprint: deviceFingerprint(deviceID, userAgent),
+		Country:     country,
+	})
+	if err != nil {
+		log.Printf("Login alerts: failed to record login for %s: %v", userID, err)
+		return
+	}
+	if history.FirstLogin || (!history.NewDevice && !history.NewCountry) || h.loginAlerts == nil {
+		return
+	}
+
+	revokeURL, err := h.revokeLink(userID, claims.SessionID, time.Now())
+	if err != nil {
+		log.Printf("Login alerts: failed to sign revoke link: %v", err)
+		return
+	}
+	if err := h.loginAlerts.SendNewLoginAlert(ctx, userID, claims.SessionID, describeDevice(userAgent), ip, country, revokeURL); err != nil {
+		log.Printf("Login alerts: failed to notify %s: %v", userID, err)
+	}
+}
+
+// revokeLink returns the one-click link that revokes sessionID
+func (h *Handler) revokeLink(userID, sessionID string, now time.Time) (string, error) {
+	link, err := url.Parse(h.loginAlertConfig.RevokeURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", h.signRevokeToken(userID, sessionID, now.Add(h.loginAlertConfig.RevokeLinkTTL)))
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// signRevokeToken encodes the session and expiry with an HMAC so the link
+// works without a login
+func (h *Handler) signRevokeToken(userID, sessionID string, expiresAt time.Time) string {
+	payload := userID + ":" + sessionID + ":" + strconv.FormatInt(expiresAt.Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + h.revokeSignature(encoded)
+}
+
+// verifyRevokeToken returns the user and session of a valid revoke token
+func (h *Handler) verifyRevokeToken(token string, now time.Time) (string, string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(h.revokeSignature(encoded))) {
+		return "", "", ErrRevokeLinkInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", ErrRevokeLinkInvalid
+	}
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 3 {
+		return "", "", ErrRevokeLinkInvalid
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return "", "", ErrRevokeLinkInvalid
+	}
+	return parts[0], parts[1], nil
+}
+
+func (h *Handler) revokeSignature(encoded string) string {
+	mac := hmac.New(sha256.New, h.loginAlertConfig.RevokeSecret)
+	mac.Write([]byte("session-revoke:" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type revokeSessionLinkReq struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// RevokeSessionLinkEndpoint revokes the session named by a signed link from
+// a new sign-in alert
+func (h *Handler) RevokeSessionLinkEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Revoke a session from a sign-in alert link",
+		Description: "The token comes from the link in a new device or country sign-in alert; no login is needed.",
+		Tags:        []string{"Authentication"},
+	}, h.revokeSessionLink)
+}
+
+// RevokeSessionLink revokes the session named by a signed link from a new
+// sign-in alert
+func (h *Handler) RevokeSessionLink(w http.ResponseWriter, r *http.Request) {
+	h.RevokeSessionLinkEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) revokeSessionLink(ctx context.Context, req *revokeSessionLinkReq) (*common.MessageResponse, error) {
+	if h.sessions == nil || h.loginDevices == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "session management is not available", nil)
+	}
+
+	userID, sessionID, err := h.verifyRevokeToken(req.Token, time.Now())
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusBadRequest, "", "invalid or expired link", nil)
+	}
+	if err := h.sessions.RevokeUserSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, common.NewAPIError(http.StatusNotFound, "", "session already signed out", nil)
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not revoke session", nil)
+	}
+	return &common.MessageResponse{Message: "session revoked"}, nil
+}
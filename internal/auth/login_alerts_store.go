@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PostgresLoginDeviceStore implements LoginDeviceStore using PostgreSQL
+type PostgresLoginDeviceStore struct {
+	db *sql.DB
+}
+
+// NewPostgresLoginDeviceStore creates a new PostgreSQL login device store
+func NewPostgresLoginDeviceStore(db *sql.DB) *PostgresLoginDeviceStore {
+	return &PostgresLoginDeviceStore{db: db}
+}
+
+// RecordLogin tags the session with its device and country and remembers
+// both for the user
+func (s *PostgresLoginDeviceStore) RecordLogin(ctx context.Context, login LoginDevice) (LoginHistory, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return LoginHistory{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sessions
+		SET device_fingerprint = $2, country = NULLIF($3, '')
+		WHERE id = $1
+	`, login.SessionID, login.Fingerprint, login.Country); err != nil {
+		return LoginHistory{}, fmt.Errorf("failed to tag session: %w", err)
+	}
+
+	var seen, knownDevice, knownCountry bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) > 0,
+			COALESCE(BOOL_OR(fingerprint = $2), FALSE),
+			COALESCE(BOOL_OR(country = $3), FALSE)
+		FROM user_login_devices
+		WHERE user_id = $1
+	`, login.UserID, login.Fingerprint, login.Country).Scan(&seen, &knownDevice, &knownCountry); err != nil {
+		return LoginHistory{}, fmt.Errorf("failed to load login history: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_login_devices (user_id, fingerprint, country)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, fingerprint, country)
+		DO UPDATE SET last_seen_at = NOW()
+	`, login.UserID, login.Fingerprint, login.Country); err != nil {
+		return LoginHistory{}, fmt.Errorf("failed to remember login device: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return LoginHistory{}, fmt.Errorf("failed to commit login device: %w", err)
+	}
+
+	return LoginHistory{
+		FirstLogin: !seen,
+		NewDevice:  seen && !knownDevice,
+		// An unknown country can't be compared
+		NewCountry: seen && login.Country != "" && !knownCountry,
+	}, nil
+}
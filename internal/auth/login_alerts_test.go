@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLoginDeviceStore remembers fingerprints and countries per user
+type fakeLoginDeviceStore struct {
+	devices   map[string]map[string]bool
+	countries map[string]map[string]bool
+}
+
+func newFakeLoginDeviceStore() *fakeLoginDeviceStore {
+	return &fakeLoginDeviceStore{
+		devices:   make(map[string]map[string]bool),
+		countries: make(map[string]map[string]bool),
+	}
+}
+
+func (f *fakeLoginDeviceStore) RecordLogin(ctx context.Context, login LoginDevice) (LoginHistory, error) {
+	devices, seen := f.devices[login.UserID]
+	if !seen {
+		devices = make(map[string]bool)
+		f.devices[login.UserID] = devices
+		f.countries[login.UserID] = make(map[string]bool)
+	}
+	countries := f.countries[login.UserID]
+
+	history := LoginHistory{
+		FirstLogin: !seen,
+		NewDevice:  seen && !devices[login.Fingerprint],
+		NewCountry: seen && login.Country != "" && !countries[login.Country],
+	}
+	devices[login.Fingerprint] = true
+	countries[login.Country] = true
+	return history, nil
+}
+
+type sentLoginAlert struct {
+	userID, sessionID, device, ip, country, revokeURL string
+}
+
+type fakeLoginAlertSender struct {
+	alerts []sentLoginAlert
+}
+
+func (f *fakeLoginAlertSender) SendNewLoginAlert(ctx context.Context, userID, sessionID, device, ip, country, revokeURL string) error {
+	f.alerts = append(f.alerts, sentLoginAlert{userID, sessionID, device, ip, country, revokeURL})
+	return nil
+}
+
+// fakeCountries resolves 10.1.x.x to IR and 10.2.x.x to DE
+type fakeCountries struct{}
+
+func (fakeCountries) Country(ip net.IP) string {
+	switch {
+	case strings.HasPrefix(ip.String(), "10.1."):
+		return "IR"
+	case strings.HasPrefix(ip.String(), "10.2."):
+		return "DE"
+	}
+	return ""
+}
+
+const (
+	chromeOnWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	firefoxOnLinux  = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+)
+
+func newLoginAlertsTestHandler() (*Handler, *fakeLoginAlertSender) {
+	sender := &fakeLoginAlertSender{}
+	handler := &Handler{tokens: &mockTokenService{}}
+	handler.SetLoginAlerts(newFakeLoginDeviceStore(), sender, LoginAlertConfig{
+		RevokeURL:    "https://api.example.com/auth/sessions/revoke",
+		RevokeSecret: []byte("test-secret"),
+		Countries:    fakeCountries{},
+	})
+	return handler, sender
+}
+
+func TestDeviceFingerprint(t *testing.T) {
+	newerChrome := strings.Replace(chromeOnWindows, "Chrome/120.0", "Chrome/121.0", 1)
+	if deviceFingerprint("", chromeOnWindows) != deviceFingerprint("", newerChrome) {
+		t.Error("Expected a browser update to keep the fingerprint")
+	}
+	if deviceFingerprint("", chromeOnWindows) == deviceFingerprint("", firefoxOnLinux) {
+		t.Error("Expected different browsers to have different fingerprints")
+	}
+	if deviceFingerprint("device-1", chromeOnWindows) == deviceFingerprint("device-2", chromeOnWindows) {
+		t.Error("Expected the device ID to distinguish devices with the same browser")
+	}
+}
+
+func TestCheckNewLogin(t *testing.T) {
+	handler, sender := newLoginAlertsTestHandler()
+	login := func(ip, userAgent, deviceID string) {
+		handler.checkNewLogin(WithClientIP(context.Background(), ip), "test-user", "access-token", userAgent, deviceID)
+	}
+
+	login("10.1.0.1", chromeOnWindows, "")
+	if len(sender.alerts) != 0 {
+		t.Fatalf("Expected no alert on the first login, got %d", len(sender.alerts))
+	}
+
+	login("10.1.0.2", chromeOnWindows, "")
+	login("192.168.1.5", chromeOnWindows, "")
+	if len(sender.alerts) != 0 {
+		t.Fatalf("Expected no alert for a known device and country, got %d", len(sender.alerts))
+	}
+
+	login("10.1.0.1", firefoxOnLinux, "")
+	if len(sender.alerts) != 1 {
+		t.Fatalf("Expected an alert for a new device, got %d", len(sender.alerts))
+	}
+	alert := sender.alerts[0]
+	if alert.device != "Firefox on Linux" || alert.sessionID != "test-session" || alert.country != "IR" {
+		t.Errorf("Unexpected alert %+v", alert)
+	}
+	if !strings.HasPrefix(alert.revokeURL, "https://api.example.com/auth/sessions/revoke?token=") {
+		t.Errorf("Expected a revoke link, got %s", alert.revokeURL)
+	}
+
+	login("10.2.0.1", chromeOnWindows, "")
+	if len(sender.alerts) != 2 || sender.alerts[1].country != "DE" {
+		t.Errorf("Expected an alert for a new country, got %+v", sender.alerts)
+	}
+}
+
+func TestRevokeSessionLink(t *testing.T) {
+	handler, _ := newLoginAlertsTestHandler()
+	handler.SetSessionManager(&mockSessionManager{sessions: map[string]Session{
+		"test-session": {ID: "test-session", UserID: "test-user"},
+	}})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /auth/sessions/revoke", handler.RevokeSessionLink)
+	revoke := func(token string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/sessions/revoke?token="+url.QueryEscape(token), nil))
+		return w.Code
+	}
+
+	token := handler.signRevokeToken("test-user", "test-session", time.Now().Add(time.Hour))
+	if code := revoke(token + "x"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a tampered link, got %d", code)
+	}
+	if code := revoke(token); code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+	if code := revoke(token); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a revoked session, got %d", code)
+	}
+
+	expired := handler.signRevokeToken("test-user", "test-session", time.Now().Add(-time.Minute))
+	if _, _, err := handler.verifyRevokeToken(expired, time.Now()); err != ErrRevokeLinkInvalid {
+		t.Errorf("Expected ErrRevokeLinkInvalid for an expired link, got %v", err)
+	}
+}
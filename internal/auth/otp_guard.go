@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+var (
+	// ErrOTPLocked is returned while a phone is locked after repeated failed
+	// verifications
+	ErrOTPLocked = errors.New("phone is locked after repeated otp failures")
+	// ErrOTPResendCooldown is returned when a code is requested before the
+	// resend cooldown of the phone ends
+	ErrOTPResendCooldown = errors.New("otp resend cooldown")
+)
+
+// OTP events recorded for the security team
+const (
+	OTPEventSent          = "sent"
+	OTPEventResendBlocked = "resend_blocked" // requested during the resend cooldown
+	OTPEventVerified      = "verified"
+	OTPEventVerifyFailed  = "verify_failed"
+	OTPEventLocked        = "locked"
+	OTPEventLockedAttempt = "locked_attempt" // send or verify refused while locked
+)
+
+// OTPState is the resend and failure history of a phone
+type OTPState struct {
+	Phone          string
+	Sends          int // codes sent since the cooldown last started over
+	LastSentAt     *time.Time
+	NextSendAt     *time.Time
+	Failures       int // failed verifications since FirstFailureAt
+	FirstFailureAt *time.Time
+	LockedUntil    *time.Time
+}
+
+// OTPEvent is a recorded send, verification or lockout
+type OTPEvent struct {
+	ID        string                 `json:"id"`
+	Phone     string                 `json:"phone"`
+	Event     string                 `json:"event"`
+	IP        string                 `json:"ip"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// OTPEventFilter selects recorded OTP events, newest first
+type OTPEventFilter struct {
+	Phone string `form:"phone"`
+	Event string `form:"event" binding:"omitempty,oneof=sent resend_blocked verified verify_failed locked locked_attempt"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// OTPGuardStore keeps the OTP state of phones and records OTP events
+type OTPGuardStore interface {
+	// GetOTPState returns the state of phone, empty when it has none
+	GetOTPState(ctx context.Context, phone string) (OTPState, error)
+	SaveOTPState(ctx context.Context, state OTPState) error
+
+	RecordOTPEvent(ctx context.Context, event OTPEvent) error
+	ListOTPEvents(ctx context.Context, filter OTPEventFilter) ([]OTPEvent, error)
+}
+
+// OTPLockoutNotifier tells a registered user that their phone was locked
+// after repeated failed verifications
+type OTPLockoutNotifier interface {
+	SendOTPLockoutAlert(ctx context.Context, userID, ip string, lockedUntil time.Time) error
+}
+
+// OTPGuardConfig configures resend cooldowns and lockouts
+type OTPGuardConfig struct {
+	// The first resend waits ResendCooldown; each further resend doubles the
+	// wait up to MaxResendCooldown. A phone that sent nothing for
+	// ResendResetAfter starts over.
+	ResendCooldown    time.Duration
+	MaxResendCooldown time.Duration
+	ResendResetAfter  time.Duration
+
+	// MaxFailures failed verifications within FailureWindow lock the phone
+	// for LockoutDuration
+	MaxFailures     int
+	FailureWindow   time.Duration
+	LockoutDuration time.Duration
+}
+
+// DefaultOTPGuardConfig returns the default OTP guard configuration
+func DefaultOTPGuardConfig() OTPGuardConfig {
+	return OTPGuardConfig{
+		ResendCooldown:    30 * time.Second,
+		MaxResendCooldown: 15 * time.Minute,
+		ResendResetAfter:  time.Hour,
+		MaxFailures:       10,
+		FailureWindow:     time.Hour,
+		LockoutDuration:   30 * time.Minute,
+	}
+}
+
+// otpGuard enforces resend cooldowns and lockouts per phone
+type otpGuard struct {
+	store    OTPGuardStore
+	notifier OTPLockoutNotifier
+	config   OTPGuardConfig
+	now      func() time.Time
+}
+
+// SetOTPGuard enables resend cooldowns, lockouts after repeated failed
+// verifications and OTP event recording. notifier may be nil.
+func (h *Handler) SetOTPGuard(store OTPGuardStore, notifier OTPLockoutNotifier, config OTPGuardConfig) {
+	defaults := DefaultOTPGuardConfig()
+	if config.ResendCooldown <= 0 {
+		config.ResendCooldown = defaults.ResendCooldown
+	}
+	if config.MaxResendCooldown < config.ResendCooldown {
+		config.MaxResendCooldown = config.ResendCooldown
+	}
+	if config.ResendResetAfter <= 0 {
+		config.ResendResetAfter = defaults.ResendResetAfter
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = defaults.MaxFailures
+	}
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = defaults.FailureWindow
+	}
+	if config.LockoutDuration <= 0 {
+		config.LockoutDuration = defaults.LockoutDuration
+	}
+	h.otpGuard = &otpGuard{store: store, notifier: notifier, config: config, now: time.Now}
+}
+
+// allowSend checks the lock and resend cooldown of a phone and, when a code
+// may be sent, starts the next cooldown. It returns how long the phone has
+// to wait before the next send; with an error, until it may send again.
+func (g *otpGuard) allowSend(ctx context.Context, phone, ip string) (time.Duration, error) {
+	state, err := g.store.GetOTPState(ctx, phone)
+	if err != nil {
+		return 0, err
+	}
+	now := g.now()
+
+	if state.LockedUntil != nil && now.Before(*state.LockedUntil) {
+		g.record(ctx, phone, OTPEventLockedAttempt, ip, map[string]interface{}{"action": "send"})
+		return state.LockedUntil.Sub(now), ErrOTPLocked
+	}
+	if state.NextSendAt != nil && now.Before(*state.NextSendAt) {
+		g.record(ctx, phone, OTPEventResendBlocked, ip, map[string]interface{}{"sends": state.Sends})
+		return state.NextSendAt.Sub(now), ErrOTPResendCooldown
+	}
+
+	if state.LastSentAt == nil || now.Sub(*state.LastSentAt) >= g.config.ResendResetAfter {
+		state.Sends = 0
+	}
+	state.Sends++
+	cooldown := g.cooldown(state.Sends)
+	next := now.Add(cooldown)
+	state.Phone = phone
+	state.LastSentAt = &now
+	state.NextSendAt = &next
+	if err := g.store.SaveOTPState(ctx, state); err != nil {
+		return 0, err
+	}
+
+	g.record(ctx, phone, OTPEventSent, ip, map[string]interface{}{"sends": state.Sends, "cooldownSec": int(cooldown.Seconds())})
+	return cooldown, nil
+}
+
+// cooldown is the wait after the nth send of a phone
+func (g *otpGuard) cooldown(sends int) time.Duration {
+	cooldown := g.config.ResendCooldown
+	for i := 1; i < sends && cooldown < g.config.MaxResendCooldown; i++ {
+		cooldown *= 2
+	}
+	return min(cooldown, g.config.MaxResendCooldown)
+}
+
+// checkLocked returns ErrOTPLocked and how long is left while the phone is
+// locked
+func (g *otpGuard) checkLocked(ctx context.Context, phone, ip string) (time.Duration, error) {
+	state, err := g.store.GetOTPState(ctx, phone)
+	if err != nil {
+		return 0, err
+	}
+	now := g.now()
+	if state.LockedUntil != nil && now.Before(*state.LockedUntil) {
+		g.record(ctx, phone, OTPEventLockedAttempt, ip, map[string]interface{}{"action": "verify"})
+		return state.LockedUntil.Sub(now), ErrOTPLocked
+	}
+	return 0, nil
+}
+
+// verified clears the failures and resend cooldown of a phone
+func (g *otpGuard) verified(ctx context.Context, phone, ip string) {
+	if err := g.store.SaveOTPState(ctx, OTPState{Phone: phone}); err != nil {
+		log.Printf("Failed to reset OTP state of %s: %v", phone, err)
+	}
+	g.record(ctx, phone, OTPEventVerified, ip, nil)
+}
+
+// failed counts a failed verification and locks the phone once it reaches
+// MaxFailures within FailureWindow. It returns when the lock ends, or nil.
+func (g *otpGuard) failed(ctx context.Context, phone, ip string, reason error) (*time.Time, error) {
+	state, err := g.store.GetOTPState(ctx, phone)
+	if err != nil {
+		return nil, err
+	}
+	now := g.now()
+
+	if state.FirstFailureAt == nil || now.Sub(*state.FirstFailureAt) >= g.config.FailureWindow {
+		state.Failures = 0
+		state.FirstFailureAt = &now
+	}
+	state.Failures++
+	state.Phone = phone
+	g.record(ctx, phone, OTPEventVerifyFailed, ip, map[string]interface{}{"reason": reason.Error(), "failures": state.Failures})
+
+	if state.Failures < g.config.MaxFailures {
+		return nil, g.store.SaveOTPState(ctx, state)
+	}
+
+	lockedUntil := now.Add(g.config.LockoutDuration)
+	state.LockedUntil = &lockedUntil
+	state.Failures = 0
+	state.FirstFailureAt = nil
+	if err := g.store.SaveOTPState(ctx, state); err != nil {
+		return nil, err
+	}
+	g.record(ctx, phone, OTPEventLocked, ip, map[string]interface{}{"lockedUntil": lockedUntil})
+	return &lockedUntil, nil
+}
+
+// record stores an OTP event; failures are logged
+func (g *otpGuard) record(ctx context.Context, phone, event, ip string, metadata map[string]interface{}) {
+	if err := g.store.RecordOTPEvent(ctx, OTPEvent{Phone: phone, Event: event, IP: ip, Metadata: metadata}); err != nil {
+		log.Printf("Failed to record OTP event %s for %s: %v", event, phone, err)
+	}
+}
+
+// notifyLockout tells the user registered with phone about the lock. Phones
+// without an account only get the lock recorded.
+func (h *Handler) notifyLockout(ctx context.Context, phone, ip string, lockedUntil time.Time) {
+	if h.otpGuard.notifier == nil {
+		return
+	}
+	user, err := h.store.GetUserByPhone(ctx, phone)
+	if err != nil || user.ID == "" {
+		return
+	}
+	if err := h.otpGuard.notifier.SendOTPLockoutAlert(ctx, user.ID, ip, lockedUntil); err != nil {
+		log.Printf("Failed to send OTP lockout alert to user %s: %v", user.ID, err)
+	}
+}
+
+// writeOTPRetry rejects an OTP request with 429 until retryAfter has passed
+func writeOTPRetry(w http.ResponseWriter, code, message string, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	common.WriteError(w, http.StatusTooManyRequests, code, message, map[string]interface{}{"retryAfterSec": seconds})
+}
+
+type listOTPEventsResp struct {
+	Events []OTPEvent `json:"events"`
+}
+
+// ListOTPEventsEndpoint lists recorded OTP events for the security team
+func (h *Handler) ListOTPEventsEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "List OTP sends, failures and lockouts",
+		Tags:    []string{"Admin"},
+		Secured: true,
+	}, h.listOTPEvents)
+}
+
+func (h *Handler) listOTPEvents(ctx context.Context, filter *OTPEventFilter) (*listOTPEventsResp, error) {
+	if h.otpGuard == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "otp events are not recorded", nil)
+	}
+	if filter.Phone != "" {
+		filter.Phone = normalizePhone(filter.Phone)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+	events, err := h.otpGuard.store.ListOTPEvents(ctx, *filter)
+	if err != nil {
+		return nil, err
+	}
+	return &listOTPEventsResp{Events: events}, nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PostgresOTPGuardStore implements OTPGuardStore using PostgreSQL
+type PostgresOTPGuardStore struct {
+	db *sql.DB
+}
+
+// NewPostgresOTPGuardStore creates a new PostgreSQL OTP guard store
+func NewPostgresOTPGuardStore(db *sql.DB) *PostgresOTPGuardStore {
+	return &PostgresOTPGuardStore{db: db}
+}
+
+// GetOTPState returns the state of phone, empty when it has none
+func (s *PostgresOTPGuardStore) GetOTPState(ctx context.Context, phone string) (OTPState, error) {
+	state := OTPState{Phone: phone}
+	var lastSentAt, nextSendAt, firstFailureAt, lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT sends, last_sent_at, next_send_at, failures, first_failure_at, locked_until
+		FROM otp_guards
+		WHERE phone = $1
+	`, phone).Scan(&state.Sends, &lastSentAt, &nextSendAt, &state.Failures, &firstFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return state, nil
+	}
+	if err != nil {
+		return OTPState{}, fmt.Errorf("failed to get otp state: %w", err)
+	}
+
+	state.LastSentAt = nullTimePtr(lastSentAt)
+	state.NextSendAt = nullTimePtr(nextSendAt)
+	state.FirstFailureAt = nullTimePtr(firstFailureAt)
+	state.LockedUntil = nullTimePtr(lockedUntil)
+	return state, nil
+}
+
+// SaveOTPState stores the state of a phone
+func (s *PostgresOTPGuardStore) SaveOTPState(ctx context.Context, state OTPState) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO otp_guards (phone, sends, last_sent_at, next_send_at, failures, first_failure_at, locked_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (phone) DO UPDATE SET
+			sends = EXCLUDED.sends,
+			last_sent_at = EXCLUDED.last_sent_at,
+			next_send_at = EXCLUDED.next_send_at,
+			failures = EXCLUDED.failures,
+			first_failure_at = EXCLUDED.first_failure_at,
+			locked_until = EXCLUDED.locked_until,
+			updated_at = NOW()
+	`, state.Phone, state.Sends, state.LastSentAt, state.NextSendAt, state.Failures, state.FirstFailureAt, state.LockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to save otp state: %w", err)
+	}
+	return nil
+}
+
+// RecordOTPEvent stores an OTP event
+func (s *PostgresOTPGuardStore) RecordOTPEvent(ctx context.Context, event OTPEvent) error {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal otp event metadata: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO otp_events (phone, event, ip, metadata)
+		VALUES ($1, $2, $3, $4)
+	`, event.Phone, event.Event, event.IP, metadataJSON); err != nil {
+		return fmt.Errorf("failed to record otp event: %w", err)
+	}
+	return nil
+}
+
+// ListOTPEvents returns the events matching filter, newest first
+func (s *PostgresOTPGuardStore) ListOTPEvents(ctx context.Context, filter OTPEventFilter) ([]OTPEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, phone, event, ip, metadata, created_at
+		FROM otp_events
+		WHERE ($1 = '' OR phone = $1) AND ($2 = '' OR event = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, filter.Phone, filter.Event, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list otp events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []OTPEvent{}
+	for rows.Next() {
+		var event OTPEvent
+		var metadata []byte
+		if err := rows.Scan(&event.ID, &event.Phone, &event.Event, &event.IP, &metadata, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan otp event: %w", err)
+		}
+		if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode otp event metadata: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
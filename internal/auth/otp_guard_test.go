@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-styler/internal/sms"
+)
+
+type memoryOTPGuardStore struct {
+	states map[string]OTPState
+	events []OTPEvent
+}
+
+func newMemoryOTPGuardStore() *memoryOTPGuardStore {
+	return &memoryOTPGuardStore{states: make(map[string]OTPState)}
+}
+
+func (m *memoryOTPGuardStore) GetOTPState(ctx context.Context, phone string) (OTPState, error) {
+	if state, ok := m.states[phone]; ok {
+		return state, nil
+	}
+	return OTPState{Phone: phone}, nil
+}
+
+func (m *memoryOTPGuardStore) SaveOTPState(ctx context.Context, state OTPState) error {
+	m.states[state.Phone] = state
+	return nil
+}
+
+func (m *memoryOTPGuardStore) RecordOTPEvent(ctx context.Context, event OTPEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryOTPGuardStore) ListOTPEvents(ctx context.Context, filter OTPEventFilter) ([]OTPEvent, error) {
+	return m.events, nil
+}
+
+func (m *memoryOTPGuardStore) count(event string) int {
+	n := 0
+	for _, e := range m.events {
+		if e.Event == event {
+			n++
+		}
+	}
+	return n
+}
+
+type recordingLockoutNotifier struct {
+	users []string
+}
+
+func (r *recordingLockoutNotifier) SendOTPLockoutAlert(ctx context.Context, userID, ip string, lockedUntil time.Time) error {
+	r.users = append(r.users, userID)
+	return nil
+}
+
+func postOTP(h http.HandlerFunc, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h(w, req)
+	return w
+}
+
+func TestOTPGuard_ResendCooldown(t *testing.T) {
+	handler := NewHandler(newMockStore(), &mockTokenService{}, &mockRateLimiter{}, &sms.MockSMSProvider{})
+	guardStore := newMemoryOTPGuardStore()
+	handler.SetOTPGuard(guardStore, nil, OTPGuardConfig{ResendCooldown: 30 * time.Second, MaxResendCooldown: 90 * time.Second})
+	now := time.Now()
+	handler.otpGuard.now = func() time.Time { return now }
+
+	send := sendOtpReq{Phone: "+9123456789"}
+	var waits []int
+	for i := 0; i < 4; i++ {
+		w := postOTP(handler.SendOTP, "/auth/send-otp", send)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected send %d to succeed, got %d", i+1, w.Code)
+		}
+		var resp sendOtpResp
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		waits = append(waits, resp.ResendInSec)
+
+		if w := postOTP(handler.SendOTP, "/auth/send-otp", send); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("Expected a resend during the cooldown to be refused, got %d", w.Code)
+		}
+		now = now.Add(time.Duration(resp.ResendInSec) * time.Second)
+	}
+
+	if waits[0] != 30 || waits[1] != 60 || waits[2] != 90 || waits[3] != 90 {
+		t.Errorf("Expected the cooldown to double up to the maximum, got %v", waits)
+	}
+	if guardStore.count(OTPEventSent) != 4 || guardStore.count(OTPEventResendBlocked) != 4 {
+		t.Errorf("Expected sends and refused resends to be recorded, got %+v", guardStore.events)
+	}
+
+	// A phone quiet for the reset period starts over
+	now = now.Add(2 * time.Hour)
+	w := postOTP(handler.SendOTP, "/auth/send-otp", send)
+	var resp sendOtpResp
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.ResendInSec != 30 {
+		t.Errorf("Expected the cooldown to start over, got %d", resp.ResendInSec)
+	}
+}
+
+func TestOTPGuard_Lockout(t *testing.T) {
+	store := newMockStore()
+	store.users["+9123456789"] = User{ID: "user-1", Phone: "+9123456789"}
+	store.CreateOTP(context.Background(), "+9123456789", "phone_verify", 6, 5*time.Minute)
+	handler := NewHandler(store, &mockTokenService{}, &mockRateLimiter{}, &sms.MockSMSProvider{})
+	guardStore := newMemoryOTPGuardStore()
+	notifier := &recordingLockoutNotifier{}
+	handler.SetOTPGuard(guardStore, notifier, OTPGuardConfig{MaxFailures: 3, LockoutDuration: time.Minute})
+
+	wrong := verifyReq{Phone: "+9123456789", Code: "000000"}
+	for i := 0; i < 2; i++ {
+		if w := postOTP(handler.VerifyOTP, "/auth/verify-otp", wrong); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected a wrong code to be rejected, got %d", w.Code)
+		}
+	}
+	if w := postOTP(handler.VerifyOTP, "/auth/verify-otp", wrong); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the third failure to lock the phone, got %d", w.Code)
+	}
+	if len(notifier.users) != 1 || notifier.users[0] != "user-1" {
+		t.Errorf("Expected the registered user to be notified, got %v", notifier.users)
+	}
+
+	right := verifyReq{Phone: "+9123456789", Code: "123456"}
+	if w := postOTP(handler.VerifyOTP, "/auth/verify-otp", right); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the right code to be refused while locked, got %d", w.Code)
+	}
+	if w := postOTP(handler.SendOTP, "/auth/send-otp", sendOtpReq{Phone: "+9123456789"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected sends to be refused while locked, got %d", w.Code)
+	}
+	if guardStore.count(OTPEventVerifyFailed) != 3 || guardStore.count(OTPEventLocked) != 1 || guardStore.count(OTPEventLockedAttempt) != 2 {
+		t.Errorf("Expected failures, the lock and refused attempts to be recorded, got %+v", guardStore.events)
+	}
+
+	// Once the lock ends the phone verifies and its failures are cleared
+	handler.otpGuard.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if w := postOTP(handler.VerifyOTP, "/auth/verify-otp", right); w.Code != http.StatusOK {
+		t.Fatalf("Expected verification after the lock, got %d", w.Code)
+	}
+	if state := guardStore.states["+9123456789"]; state.Failures != 0 || state.LockedUntil != nil {
+		t.Errorf("Expected the state to be cleared after verification, got %+v", state)
+	}
+}
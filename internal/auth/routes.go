@@ -22,4 +22,17 @@ func ProvideStdMux(mux *http.ServeMux) {
 	mux.HandleFunc("/auth/refresh", h.Refresh)
 	mux.HandleFunc("/auth/logout", h.Authenticate(h.Logout))
 	mux.HandleFunc("/auth/logout-all", h.Authenticate(h.LogoutAll))
+	mux.HandleFunc("GET /api/users/me/sessions", h.Authenticate(h.ListSessions))
+	mux.HandleFunc("DELETE /api/users/me/sessions/{id}", h.Authenticate(h.RevokeSessionByID))
+	mux.HandleFunc("GET /auth/sessions/revoke", h.RevokeSessionLink)
+	mux.HandleFunc("GET /auth/2fa", h.Authenticate(h.TwoFactorStatus))
+	mux.HandleFunc("POST /auth/2fa/enroll", h.Authenticate(h.TwoFactorEnroll))
+	mux.HandleFunc("POST /auth/2fa/confirm", h.Authenticate(h.TwoFactorConfirm))
+	mux.HandleFunc("POST /auth/2fa/recovery-codes", h.Authenticate(h.TwoFactorRecoveryCodes))
+	mux.HandleFunc("POST /auth/2fa/verify", h.TwoFactorVerify)
+	mux.HandleFunc("GET /auth/telegram", h.Authenticate(h.TelegramLinkStatus))
+	mux.HandleFunc("DELETE /auth/telegram", h.Authenticate(h.TelegramUnlink))
+	mux.HandleFunc("POST /auth/telegram/link-code", h.Authenticate(h.TelegramLinkCode))
+	mux.HandleFunc("POST /auth/telegram/link", h.TelegramLink)
+	mux.HandleFunc("POST /auth/telegram/login", h.TelegramLogin)
 }
@@ -12,8 +12,14 @@ import (
 var (
 	ErrOTPExpired = errors.New("otp expired")
 	ErrOTPInvalid = errors.New("otp invalid")
+	// ErrOTPAttemptsExceeded is returned when a code was guessed wrong too
+	// often; it is burned and a new one has to be sent
+	ErrOTPAttemptsExceeded = errors.New("too many otp attempts")
 )
 
+// MaxOTPAttempts is how many times a code can be tried
+const MaxOTPAttempts = 5
+
 type Store interface {
 	CreateOTP(ctx context.Context, phone, purpose string, digits int, ttl time.Duration) (code string, expiresAt time.Time, err error)
 	VerifyOTP(ctx context.Context, phone, code, purpose string) (bool, error)
@@ -30,6 +36,10 @@ type TokenClaims struct {
 	Role      string
 	SessionID string
 	ExpiresAt time.Time
+	// ImpersonatorID is the admin acting as this user, empty for regular sessions
+	ImpersonatorID string
+	// TwoFactor is set when the session passed TOTP verification at login
+	TwoFactor bool
 }
 
 type TokenService interface {
@@ -44,6 +54,17 @@ type RateLimiter interface {
 	Allow(ctx context.Context, key string, limit int, window time.Duration) bool
 }
 
+// OnboardingGranter grants signup credits to newly registered users
+type OnboardingGranter interface {
+	GrantSignupCredits(ctx context.Context, userID, role string) error
+}
+
+// SignupScreener scores new accounts for free-quota abuse. deviceFingerprint
+// is empty when the client sent no DeviceIDHeader.
+type SignupScreener interface {
+	ScreenSignup(ctx context.Context, userID, role, phone, deviceFingerprint, ip string) error
+}
+
 // SMSProvider interface moved to internal/sms package
 
 // In-memory implementations for scaffolding
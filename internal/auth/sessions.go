@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound is returned when a session does not exist, is not active
+// or belongs to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// impersonationUserAgentPrefix marks sessions created for admin impersonation
+const impersonationUserAgentPrefix = "admin-impersonation:"
+
+// SessionManager lists and revokes the sessions of a user
+type SessionManager interface {
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeUserSession(ctx context.Context, userID, sessionID string) error
+}
+
+// SetSessionManager enables the session management endpoints
+func (h *Handler) SetSessionManager(sessions SessionManager) {
+	h.sessions = sessions
+}
+
+// SessionInfo is an active session as shown to its user
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	Country    string    `json:"country,omitempty"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+type listSessionsResp struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+type revokeSessionReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// ListSessionsEndpoint lists the active sessions of the signed-in user
+func (h *Handler) ListSessionsEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "List active sessions",
+		Tags:    []string{"Authentication"},
+		Secured: true,
+	}, h.listSessions)
+}
+
+// ListSessions lists the active sessions of the signed-in user
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	h.ListSessionsEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) listSessions(ctx context.Context, _ *common.NoRequest) (*listSessionsResp, error) {
+	if h.sessions == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "session management is not available", nil)
+	}
+
+	userID, _ := ctx.Value(ctxUserID{}).(string)
+	currentID, _ := ctx.Value(ctxSessionID{}).(string)
+
+	sessions, err := h.sessions.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not list sessions", nil)
+	}
+
+	resp := &listSessionsResp{Sessions: make([]SessionInfo, 0, len(sessions))}
+	for _, session := range sessions {
+		info := SessionInfo{
+			ID:         session.ID,
+			Device:     describeDevice(session.UserAgent),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Country:    session.Country,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentID,
+		}
+		// Don't expose which admin opened an impersonation session
+		if strings.HasPrefix(session.UserAgent, impersonationUserAgentPrefix) {
+			info.UserAgent = ""
+		}
+		resp.Sessions = append(resp.Sessions, info)
+	}
+	return resp, nil
+}
+
+// RevokeSessionEndpoint revokes one session of the signed-in user
+func (h *Handler) RevokeSessionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Revoke a session",
+		Tags:    []string{"Authentication"},
+		Secured: true,
+	}, h.revokeSession)
+}
+
+// RevokeSessionByID revokes one session of the signed-in user
+func (h *Handler) RevokeSessionByID(w http.ResponseWriter, r *http.Request) {
+	h.RevokeSessionEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) revokeSession(ctx context.Context, req *revokeSessionReq) (*common.MessageResponse, error) {
+	if h.sessions == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "session management is not available", nil)
+	}
+
+	userID, _ := ctx.Value(ctxUserID{}).(string)
+	if err := h.sessions.RevokeUserSession(ctx, userID, req.ID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, common.NewAPIError(http.StatusNotFound, "", "session not found", nil)
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not revoke session", nil)
+	}
+	return &common.MessageResponse{Message: "session revoked"}, nil
+}
+
+// describeDevice returns a short "Browser on OS" label for a user agent
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+	if strings.HasPrefix(userAgent, impersonationUserAgentPrefix) {
+		return "Support session"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	client := ""
+	for _, c := range []struct{ token, name string }{
+		{"telegrambot", "Telegram bot"},
+		{"okhttp", "Android app"},
+		{"cfnetwork", "iOS app"},
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, c.token) {
+			client = c.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, o := range []struct{ token, name string }{
+		{"android", "Android"},
+		{"iphone", "iOS"},
+		{"ipad", "iPadOS"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			platform = o.name
+			break
+		}
+	}
+
+	switch {
+	case client != "" && platform != "":
+		return client + " on " + platform
+	case client != "":
+		return client
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
+
+type ctxClientIP struct{}
+
+// WithClientIP stores the client IP recorded on sessions created with ctx
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxClientIP{}, ip)
+}
+
+// ClientIPFromContext returns the client IP stored by WithClientIP
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxClientIP{}).(string)
+	return ip
+}
+
+// ClientIPMiddleware records the client IP in the request context so new
+// sessions store where they were created
+func ClientIPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
+
+// ListSessions returns the active sessions of a user, most recently used first
+func (s *ProductionTokenService) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	return s.sessionStore.ListUserSessions(ctx, userID)
+}
+
+// RevokeUserSession revokes a session of a user
+func (s *ProductionTokenService) RevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	return s.sessionStore.RevokeUserSession(ctx, userID, sessionID)
+}
+
+// ListUserSessions returns the active sessions of a user
+func (s *PostgresSessionStore) ListUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, host(ip), country, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var userAgent, ip, country sql.NullString
+		if err := rows.Scan(&session.ID, &session.UserID, &userAgent, &ip, &country, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		session.UserAgent = userAgent.String
+		session.IP = ip.String
+		session.Country = country.String
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSession revokes an active session owned by userID
+func (s *PostgresSessionStore) RevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := s.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
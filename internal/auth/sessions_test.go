@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockSessionManager struct {
+	sessions map[string]Session
+}
+
+func (m *mockSessionManager) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	var sessions []Session
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessionManager) RevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	session, exists := m.sessions[sessionID]
+	if !exists || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func newSessionsTestMux(manager SessionManager) *http.ServeMux {
+	handler := &Handler{tokens: &mockTokenService{}}
+	handler.SetSessionManager(manager)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/users/me/sessions", handler.Authenticate(handler.ListSessions))
+	mux.HandleFunc("DELETE /api/users/me/sessions/{id}", handler.Authenticate(handler.RevokeSessionByID))
+	return mux
+}
+
+func newSessionsRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	return req
+}
+
+func TestHandler_ListSessions(t *testing.T) {
+	manager := &mockSessionManager{sessions: map[string]Session{
+		"test-session": {
+			ID: "test-session", UserID: "test-user", IP: "10.0.0.1",
+			UserAgent:  "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36",
+			LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+		},
+		"support": {
+			ID: "support", UserID: "test-user", UserAgent: "admin-impersonation:admin-1",
+			LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+		},
+		"other-user": {ID: "other-user", UserID: "someone-else"},
+	}}
+
+	w := httptest.NewRecorder()
+	newSessionsTestMux(manager).ServeHTTP(w, newSessionsRequest(http.MethodGet, "/api/users/me/sessions"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp listSessionsResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(resp.Sessions))
+	}
+
+	for _, session := range resp.Sessions {
+		switch session.ID {
+		case "test-session":
+			if !session.Current {
+				t.Error("Expected the requesting session to be marked current")
+			}
+			if session.Device != "Chrome on Android" || session.IP != "10.0.0.1" {
+				t.Errorf("Expected Chrome on Android from 10.0.0.1, got %s from %s", session.Device, session.IP)
+			}
+		case "support":
+			if session.Current || session.Device != "Support session" || session.UserAgent != "" {
+				t.Errorf("Expected an anonymous support session, got %+v", session)
+			}
+		default:
+			t.Errorf("Unexpected session %s", session.ID)
+		}
+	}
+}
+
+func TestHandler_RevokeSessionByID(t *testing.T) {
+	manager := &mockSessionManager{sessions: map[string]Session{
+		"phone":      {ID: "phone", UserID: "test-user"},
+		"other-user": {ID: "other-user", UserID: "someone-else"},
+	}}
+	mux := newSessionsTestMux(manager)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, newSessionsRequest(http.MethodDelete, "/api/users/me/sessions/phone"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, exists := manager.sessions["phone"]; exists {
+		t.Error("Expected session to be revoked")
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, newSessionsRequest(http.MethodDelete, "/api/users/me/sessions/other-user"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for another user's session, got %d", w.Code)
+	}
+	if _, exists := manager.sessions["other-user"]; !exists {
+		t.Error("Expected another user's session to be kept")
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	tests := map[string]string{
+		"": "Unknown device",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Version/17.0 Safari/605.1.15": "Safari on macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36 Edg/120.0": "Edge on Windows",
+		"okhttp/4.12.0": "Android app",
+	}
+
+	for userAgent, expected := range tests {
+		if got := describeDevice(userAgent); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, userAgent, got)
+		}
+	}
+}
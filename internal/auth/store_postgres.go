@@ -51,28 +51,47 @@ func (s *postgresStore) CreateOTP(ctx context.Context, phone, purpose string, di
 	return returnedCode, returnedExpiresAt, nil
 }
 
-// VerifyOTP verifies an OTP code
+// VerifyOTP verifies an OTP code. A wrong code counts against the live code
+// of the phone, which is burned after MaxOTPAttempts wrong guesses.
 func (s *postgresStore) VerifyOTP(ctx context.Context, phone, code, purpose string) (bool, error) {
 	query := `
 		UPDATE otps 
 		SET consumed_at = NOW(),
+		    verified_at = NOW(),
 		    attempt_count = attempt_count + 1
 		WHERE phone = $1 AND code = $2 AND purpose = $3 
 		  AND expires_at > NOW() 
 		  AND consumed_at IS NULL
-		  AND attempt_count < 5
+		  AND attempt_count < $4
 		RETURNING id`
 
 	var otpID string
-	err := s.db.QueryRowContext(ctx, query, phone, code, purpose).Scan(&otpID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return false, ErrOTPInvalid
-		}
+	err := s.db.QueryRowContext(ctx, query, phone, code, purpose, MaxOTPAttempts).Scan(&otpID)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
 		return false, fmt.Errorf("failed to verify OTP: %w", err)
 	}
 
-	return true, nil
+	var attempts int
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE otps
+		SET attempt_count = attempt_count + 1,
+		    consumed_at = CASE WHEN attempt_count + 1 >= $3 THEN NOW() END
+		WHERE phone = $1 AND purpose = $2
+		  AND expires_at > NOW()
+		  AND consumed_at IS NULL
+		RETURNING attempt_count`, phone, purpose, MaxOTPAttempts).Scan(&attempts)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, ErrOTPInvalid
+	case err != nil:
+		return false, fmt.Errorf("failed to count OTP attempt: %w", err)
+	case attempts >= MaxOTPAttempts:
+		return false, ErrOTPAttemptsExceeded
+	}
+	return false, ErrOTPInvalid
 }
 
 // MarkPhoneVerified marks a phone number as verified
@@ -88,7 +107,7 @@ func (s *postgresStore) MarkPhoneVerified(ctx context.Context, phone string) err
 	}
 
 	// Note: It's OK if no rows affected - user may not exist yet
-	// The verification status is tracked via consumed OTPs in IsPhoneVerified()
+	// The verification status is tracked via verified OTPs in IsPhoneVerified()
 	return nil
 }
 
@@ -104,7 +123,7 @@ func (s *postgresStore) UserExists(ctx context.Context, phone string) (bool, err
 }
 
 // IsPhoneVerified checks if a phone number is verified
-// First checks if user exists and is verified, otherwise checks for consumed OTP
+// First checks if user exists and is verified, otherwise checks for a verified OTP
 func (s *postgresStore) IsPhoneVerified(ctx context.Context, phone string) (bool, error) {
 	// First, check if user exists and is verified
 	query := `
@@ -124,7 +143,7 @@ func (s *postgresStore) IsPhoneVerified(ctx context.Context, phone string) (bool
 		return false, fmt.Errorf("failed to check phone verification: %w", err)
 	}
 
-	// User doesn't exist yet, check if there's a consumed OTP for phone_verify
+	// User doesn't exist yet, check if there's a verified OTP for phone_verify
 	// This allows registration after OTP verification
 	otpQuery := `
 		SELECT EXISTS(
@@ -132,8 +151,8 @@ func (s *postgresStore) IsPhoneVerified(ctx context.Context, phone string) (bool
 			FROM otps 
 			WHERE phone = $1 
 			  AND purpose = 'phone_verify' 
-			  AND consumed_at IS NOT NULL
-			  AND consumed_at > NOW() - INTERVAL '24 hours'
+			  AND verified_at IS NOT NULL
+			  AND verified_at > NOW() - INTERVAL '24 hours'
 		)`
 
 	err = s.db.QueryRowContext(ctx, otpQuery, phone).Scan(&verified)
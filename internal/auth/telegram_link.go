@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+var (
+	// ErrTelegramLinkCodeInvalid is returned for unknown, used or expired link codes
+	ErrTelegramLinkCodeInvalid = errors.New("invalid or expired link code")
+	// ErrTelegramNotLinked is returned when no user is linked to a Telegram account
+	ErrTelegramNotLinked = errors.New("telegram account not linked")
+)
+
+const (
+	defaultTelegramLinkCodeTTL = 10 * time.Minute
+	telegramLinkCodeLength     = 8
+	// telegramLinkCodeAlphabet leaves out characters that are easily confused (0/O, 1/I/L)
+	telegramLinkCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	// TelegramLinkPayloadPrefix starts a /start deep link payload that carries a link code
+	TelegramLinkPayloadPrefix = "link_"
+	// TelegramBotKeyHeader carries the shared secret on requests from the bot
+	TelegramBotKeyHeader = "X-Telegram-Bot-Key"
+	// telegramBotUserAgent labels sessions the bot signs in with
+	telegramBotUserAgent = "TelegramBot (account link)"
+)
+
+// TelegramLink is the Telegram account linked to a user
+type TelegramLink struct {
+	TelegramUserID int64
+	LinkedAt       time.Time
+}
+
+// TelegramLinkStore persists link codes and the Telegram account of each user
+type TelegramLinkStore interface {
+	// SaveTelegramLinkCode stores a new code for userID and drops the user's unused codes
+	SaveTelegramLinkCode(ctx context.Context, userID, codeHash string, expiresAt time.Time) error
+	// LinkTelegramAccount uses the code and links telegramUserID to its user,
+	// moving the Telegram account off any user it was linked to before
+	LinkTelegramAccount(ctx context.Context, codeHash string, telegramUserID int64) (User, error)
+	GetUserByTelegramID(ctx context.Context, telegramUserID int64) (User, error)
+	// GetTelegramLink returns nil when the user has no linked account
+	GetTelegramLink(ctx context.Context, userID string) (*TelegramLink, error)
+	UnlinkTelegramAccount(ctx context.Context, userID string) error
+}
+
+// TelegramLinkConfig configures Telegram account linking
+type TelegramLinkConfig struct {
+	BotAPIKey   string // shared secret the bot sends in TelegramBotKeyHeader
+	BotUsername string // builds t.me deep links; empty leaves them out
+	CodeTTL     time.Duration
+}
+
+// SetTelegramLink enables linking Telegram accounts and passwordless bot sign-in
+func (h *Handler) SetTelegramLink(store TelegramLinkStore, config TelegramLinkConfig) {
+	if config.CodeTTL <= 0 {
+		config.CodeTTL = defaultTelegramLinkCodeTTL
+	}
+	config.BotUsername = strings.TrimPrefix(config.BotUsername, "@")
+	h.telegramLinks = store
+	h.telegramConfig = config
+}
+
+// LoginResponse is the token pair and user returned by the sign-in flows
+type LoginResponse = loginResp
+
+type telegramLinkStatusResp struct {
+	Linked         bool       `json:"linked"`
+	TelegramUserID int64      `json:"telegramUserId,omitempty"`
+	LinkedAt       *time.Time `json:"linkedAt,omitempty"`
+}
+
+type telegramLinkCodeResp struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	ExpiresIn int       `json:"expiresIn"`
+	DeepLink  string    `json:"deepLink,omitempty"`
+}
+
+type telegramLinkReq struct {
+	Code           string `json:"code" binding:"required,max=32"`
+	TelegramUserID int64  `json:"telegramUserId" binding:"required,gt=0"`
+	BotKey         string `json:"-" header:"X-Telegram-Bot-Key"`
+}
+
+type telegramLoginReq struct {
+	TelegramUserID int64  `json:"telegramUserId" binding:"required,gt=0"`
+	BotKey         string `json:"-" header:"X-Telegram-Bot-Key"`
+}
+
+// TelegramLinkStatusEndpoint shows whether the signed-in user linked a Telegram account
+func (h *Handler) TelegramLinkStatusEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Get Telegram account link status",
+		Tags:    []string{"Authentication"},
+		Secured: true,
+	}, h.telegramLinkStatus)
+}
+
+// TelegramLinkStatus shows whether the signed-in user linked a Telegram account
+func (h *Handler) TelegramLinkStatus(w http.ResponseWriter, r *http.Request) {
+	h.TelegramLinkStatusEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) telegramLinkStatus(ctx context.Context, _ *common.NoRequest) (*telegramLinkStatusResp, error) {
+	claims, err := h.telegramLinkClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := h.telegramLinks.GetTelegramLink(ctx, claims.UserID)
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not load Telegram link", nil)
+	}
+	if link == nil {
+		return &telegramLinkStatusResp{}, nil
+	}
+	return &telegramLinkStatusResp{Linked: true, TelegramUserID: link.TelegramUserID, LinkedAt: &link.LinkedAt}, nil
+}
+
+// TelegramLinkCodeEndpoint issues a short-lived code that links a Telegram account
+func (h *Handler) TelegramLinkCodeEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Issue a Telegram link code",
+		Description: "The code is entered in the bot or opened as the deep link. A new code replaces the user's unused codes.",
+		Tags:        []string{"Authentication"},
+		Secured:     true,
+	}, h.telegramLinkCode)
+}
+
+// TelegramLinkCode issues a short-lived code that links a Telegram account
+func (h *Handler) TelegramLinkCode(w http.ResponseWriter, r *http.Request) {
+	h.TelegramLinkCodeEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) telegramLinkCode(ctx context.Context, _ *common.NoRequest) (*telegramLinkCodeResp, error) {
+	claims, err := h.telegramLinkClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ImpersonatorID != "" {
+		return nil, common.NewAPIError(http.StatusForbidden, "", "Telegram accounts cannot be linked during a support session", nil)
+	}
+	if !h.rateLimiter.Allow(ctx, "tglink-code:"+claims.UserID, 5, time.Hour) {
+		return nil, common.NewAPIError(http.StatusTooManyRequests, "rate_limited", "too many link codes requested", nil)
+	}
+
+	code, err := generateTelegramLinkCode()
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not issue link code", nil)
+	}
+	expiresAt := time.Now().Add(h.telegramConfig.CodeTTL)
+	if err := h.telegramLinks.SaveTelegramLinkCode(ctx, claims.UserID, hashTelegramLinkCode(code), expiresAt); err != nil {
+		log.Printf("Failed to save Telegram link code: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not issue link code", nil)
+	}
+
+	resp := &telegramLinkCodeResp{
+		Code:      code,
+		ExpiresAt: expiresAt,
+		ExpiresIn: int(h.telegramConfig.CodeTTL.Seconds()),
+	}
+	if h.telegramConfig.BotUsername != "" {
+		resp.DeepLink = "https://t.me/" + h.telegramConfig.BotUsername + "?start=" + TelegramLinkPayloadPrefix + code
+	}
+	return resp, nil
+}
+
+// TelegramUnlinkEndpoint removes the Telegram account of the signed-in user
+func (h *Handler) TelegramUnlinkEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Unlink the Telegram account",
+		Tags:    []string{"Authentication"},
+		Secured: true,
+	}, h.telegramUnlink)
+}
+
+// TelegramUnlink removes the Telegram account of the signed-in user
+func (h *Handler) TelegramUnlink(w http.ResponseWriter, r *http.Request) {
+	h.TelegramUnlinkEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) telegramUnlink(ctx context.Context, _ *common.NoRequest) (*common.MessageResponse, error) {
+	claims, err := h.telegramLinkClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.telegramLinks.UnlinkTelegramAccount(ctx, claims.UserID); err != nil {
+		if errors.Is(err, ErrTelegramNotLinked) {
+			return nil, common.NewAPIError(http.StatusNotFound, "", "no Telegram account is linked", nil)
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not unlink Telegram account", nil)
+	}
+	return &common.MessageResponse{Message: "Telegram account unlinked"}, nil
+}
+
+// TelegramLinkEndpoint links a Telegram account with a code; called by the bot
+func (h *Handler) TelegramLinkEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Link a Telegram account",
+		Description: "Bot only (X-Telegram-Bot-Key). Uses a link code and returns a token pair for the linked user.",
+		Tags:        []string{"Authentication"},
+	}, h.telegramLink)
+}
+
+// TelegramLink links a Telegram account with a code; called by the bot
+func (h *Handler) TelegramLink(w http.ResponseWriter, r *http.Request) {
+	h.TelegramLinkEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) telegramLink(ctx context.Context, req *telegramLinkReq) (*loginResp, error) {
+	if err := h.requireTelegramBot(req.BotKey); err != nil {
+		return nil, err
+	}
+	return h.TelegramLinkSignIn(ctx, req.Code, req.TelegramUserID)
+}
+
+// TelegramLinkSignIn links a Telegram account with a link code and signs in
+// its user. The caller must have authenticated the bot; the REST endpoint
+// checks the bot key and the internal API the client certificate.
+func (h *Handler) TelegramLinkSignIn(ctx context.Context, code string, telegramUserID int64) (*LoginResponse, error) {
+	if h.telegramLinks == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "Telegram account linking is not available", nil)
+	}
+	if !h.rateLimiter.Allow(ctx, "tglink:"+strconv.FormatInt(telegramUserID, 10), 5, 15*time.Minute) {
+		return nil, common.NewAPIError(http.StatusTooManyRequests, "rate_limited", "too many attempts", nil)
+	}
+
+	user, err := h.telegramLinks.LinkTelegramAccount(ctx, hashTelegramLinkCode(code), telegramUserID)
+	if err != nil {
+		if errors.Is(err, ErrTelegramLinkCodeInvalid) {
+			return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid or expired link code", nil)
+		}
+		log.Printf("Failed to link Telegram account: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not link Telegram account", nil)
+	}
+	return h.telegramSession(ctx, user)
+}
+
+// TelegramLoginEndpoint signs in the user linked to a Telegram account; called by the bot
+func (h *Handler) TelegramLoginEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Sign in with a linked Telegram account",
+		Description: "Bot only (X-Telegram-Bot-Key). Returns 404 when the Telegram account is not linked.",
+		Tags:        []string{"Authentication"},
+	}, h.telegramLogin)
+}
+
+// TelegramLogin signs in the user linked to a Telegram account; called by the bot
+func (h *Handler) TelegramLogin(w http.ResponseWriter, r *http.Request) {
+	h.TelegramLoginEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) telegramLogin(ctx context.Context, req *telegramLoginReq) (*loginResp, error) {
+	if err := h.requireTelegramBot(req.BotKey); err != nil {
+		return nil, err
+	}
+	return h.TelegramSignIn(ctx, req.TelegramUserID)
+}
+
+// TelegramSignIn signs in the user linked to a Telegram account. Like
+// TelegramLinkSignIn it trusts the caller to be the bot.
+func (h *Handler) TelegramSignIn(ctx context.Context, telegramUserID int64) (*LoginResponse, error) {
+	if h.telegramLinks == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "Telegram account linking is not available", nil)
+	}
+
+	user, err := h.telegramLinks.GetUserByTelegramID(ctx, telegramUserID)
+	if err != nil {
+		if errors.Is(err, ErrTelegramNotLinked) {
+			return nil, common.NewAPIError(http.StatusNotFound, "not_linked", "telegram account is not linked", nil)
+		}
+		log.Printf("Failed to load linked Telegram user: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not sign in", nil)
+	}
+	return h.telegramSession(ctx, user)
+}
+
+// telegramLinkClaims returns the signed-in user's claims when linking is enabled
+func (h *Handler) telegramLinkClaims(ctx context.Context) (TokenClaims, error) {
+	if h.telegramLinks == nil {
+		return TokenClaims{}, common.NewAPIError(http.StatusNotImplemented, "", "Telegram account linking is not available", nil)
+	}
+	claims, _ := ctx.Value(ctxClaims{}).(TokenClaims)
+	if isTwoFactorRole(claims.Role) {
+		return TokenClaims{}, common.NewAPIError(http.StatusForbidden, "", "admin accounts cannot be linked to Telegram", nil)
+	}
+	return claims, nil
+}
+
+// requireTelegramBot checks the shared secret of the bot
+func (h *Handler) requireTelegramBot(key string) error {
+	if h.telegramLinks == nil || h.telegramConfig.BotAPIKey == "" {
+		return common.NewAPIError(http.StatusNotImplemented, "", "Telegram account linking is not available", nil)
+	}
+	if subtle.ConstantTimeCompare([]byte(key), []byte(h.telegramConfig.BotAPIKey)) != 1 {
+		return common.NewAPIError(http.StatusUnauthorized, "", "invalid bot credentials", nil)
+	}
+	return nil
+}
+
+// telegramSession issues a token pair for a user signing in through the bot.
+// Admins never sign in this way since it would skip their second factor.
+func (h *Handler) telegramSession(ctx context.Context, user User) (*loginResp, error) {
+	if !user.IsActive {
+		return nil, common.NewAPIError(http.StatusForbidden, "", "account is inactive", nil)
+	}
+	if isTwoFactorRole(user.Role) {
+		return nil, common.NewAPIError(http.StatusForbidden, "", "admin accounts cannot sign in through Telegram", nil)
+	}
+
+	at, rt, expAt, err := h.tokens.IssueTokens(ctx, user.ID, user.Phone, user.Role, telegramBotUserAgent)
+	if err != nil {
+		log.Printf("Failed to issue tokens: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not issue tokens", nil)
+	}
+	resp := &loginResp{}
+	resp.AccessToken = at
+	resp.AccessTokenExpiresIn = int(h.accessTTL.Seconds())
+	resp.RefreshToken = rt
+	resp.RefreshTokenExpiresAt = expAt
+	resp.User.ID = user.ID
+	resp.User.Role = user.Role
+	resp.User.IsPhoneVerified = user.IsPhoneVerified
+	return resp, nil
+}
+
+// generateTelegramLinkCode returns a random code from telegramLinkCodeAlphabet
+func generateTelegramLinkCode() (string, error) {
+	code := make([]byte, telegramLinkCodeLength)
+	max := big.NewInt(int64(len(telegramLinkCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = telegramLinkCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// hashTelegramLinkCode hashes a code as typed by the user, ignoring case,
+// spaces and dashes
+func hashTelegramLinkCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresTelegramLinkStore implements TelegramLinkStore using PostgreSQL
+type PostgresTelegramLinkStore struct {
+	db *sql.DB
+}
+
+// NewPostgresTelegramLinkStore creates a new PostgreSQL Telegram link store
+func NewPostgresTelegramLinkStore(db *sql.DB) *PostgresTelegramLinkStore {
+	return &PostgresTelegramLinkStore{db: db}
+}
+
+// SaveTelegramLinkCode stores a new link code and drops the user's unused codes
+func (s *PostgresTelegramLinkStore) SaveTelegramLinkCode(ctx context.Context, userID, codeHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM telegram_link_codes
+		WHERE user_id = $1 AND (used_at IS NULL OR expires_at < NOW())
+	`, userID); err != nil {
+		return fmt.Errorf("failed to drop old link codes: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO telegram_link_codes (code_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`, codeHash, userID, expiresAt); err != nil {
+		return fmt.Errorf("failed to save link code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit link code: %w", err)
+	}
+	return nil
+}
+
+// LinkTelegramAccount uses a link code and stores the Telegram account on its user
+func (s *PostgresTelegramLinkStore) LinkTelegramAccount(ctx context.Context, codeHash string, telegramUserID int64) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE telegram_link_codes
+		SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, codeHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrTelegramLinkCodeInvalid
+		}
+		return User{}, fmt.Errorf("failed to use link code: %w", err)
+	}
+
+	// A Telegram account belongs to one user; linking it again moves it
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET telegram_user_id = NULL, telegram_linked_at = NULL, updated_at = NOW()
+		WHERE telegram_user_id = $1 AND id <> $2
+	`, telegramUserID, userID); err != nil {
+		return User{}, fmt.Errorf("failed to release Telegram account: %w", err)
+	}
+
+	user, err := scanTelegramUser(tx.QueryRowContext(ctx, `
+		UPDATE users
+		SET telegram_user_id = $2, telegram_linked_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, phone, role, is_phone_verified, is_active
+	`, userID, telegramUserID))
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("failed to commit Telegram link: %w", err)
+	}
+	return user, nil
+}
+
+// GetUserByTelegramID returns the user linked to a Telegram account
+func (s *PostgresTelegramLinkStore) GetUserByTelegramID(ctx context.Context, telegramUserID int64) (User, error) {
+	return scanTelegramUser(s.db.QueryRowContext(ctx, `
+		SELECT id, phone, role, is_phone_verified, is_active
+		FROM users
+		WHERE telegram_user_id = $1
+	`, telegramUserID))
+}
+
+// GetTelegramLink returns the Telegram account linked to a user, or nil
+func (s *PostgresTelegramLinkStore) GetTelegramLink(ctx context.Context, userID string) (*TelegramLink, error) {
+	var telegramUserID sql.NullInt64
+	var linkedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT telegram_user_id, telegram_linked_at FROM users WHERE id = $1
+	`, userID).Scan(&telegramUserID, &linkedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Telegram link: %w", err)
+	}
+	if !telegramUserID.Valid {
+		return nil, nil
+	}
+	return &TelegramLink{TelegramUserID: telegramUserID.Int64, LinkedAt: linkedAt.Time}, nil
+}
+
+// UnlinkTelegramAccount removes the Telegram account of a user
+func (s *PostgresTelegramLinkStore) UnlinkTelegramAccount(ctx context.Context, userID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET telegram_user_id = NULL, telegram_linked_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND telegram_user_id IS NOT NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink Telegram account: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to unlink Telegram account: %w", err)
+	}
+	if affected == 0 {
+		return ErrTelegramNotLinked
+	}
+	return nil
+}
+
+func scanTelegramUser(row *sql.Row) (User, error) {
+	var user User
+	var role sql.NullString
+	if err := row.Scan(&user.ID, &user.Phone, &role, &user.IsPhoneVerified, &user.IsActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrTelegramNotLinked
+		}
+		return User{}, fmt.Errorf("failed to get linked user: %w", err)
+	}
+	user.Role = role.String
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	return user, nil
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockTelegramLinkStore struct {
+	users map[string]User
+	codes map[string]string    // code hash -> user ID
+	links map[int64]string     // Telegram user ID -> user ID
+	exp   map[string]time.Time // code hash -> expiry
+}
+
+func newMockTelegramLinkStore() *mockTelegramLinkStore {
+	return &mockTelegramLinkStore{
+		users: map[string]User{
+			"user-+989120000001": {ID: "user-+989120000001", Phone: "+989120000001", Role: "admin", IsActive: true},
+			"user-+989120000002": {ID: "user-+989120000002", Phone: "+989120000002", Role: "user", IsActive: true},
+		},
+		codes: make(map[string]string),
+		links: make(map[int64]string),
+		exp:   make(map[string]time.Time),
+	}
+}
+
+func (m *mockTelegramLinkStore) SaveTelegramLinkCode(ctx context.Context, userID, codeHash string, expiresAt time.Time) error {
+	m.codes[codeHash] = userID
+	m.exp[codeHash] = expiresAt
+	return nil
+}
+
+func (m *mockTelegramLinkStore) LinkTelegramAccount(ctx context.Context, codeHash string, telegramUserID int64) (User, error) {
+	userID, ok := m.codes[codeHash]
+	if !ok || time.Now().After(m.exp[codeHash]) {
+		return User{}, ErrTelegramLinkCodeInvalid
+	}
+	delete(m.codes, codeHash)
+	m.links[telegramUserID] = userID
+	return m.users[userID], nil
+}
+
+func (m *mockTelegramLinkStore) GetUserByTelegramID(ctx context.Context, telegramUserID int64) (User, error) {
+	userID, ok := m.links[telegramUserID]
+	if !ok {
+		return User{}, ErrTelegramNotLinked
+	}
+	return m.users[userID], nil
+}
+
+func (m *mockTelegramLinkStore) GetTelegramLink(ctx context.Context, userID string) (*TelegramLink, error) {
+	for telegramUserID, linked := range m.links {
+		if linked == userID {
+			return &TelegramLink{TelegramUserID: telegramUserID}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockTelegramLinkStore) UnlinkTelegramAccount(ctx context.Context, userID string) error {
+	for telegramUserID, linked := range m.links {
+		if linked == userID {
+			delete(m.links, telegramUserID)
+			return nil
+		}
+	}
+	return ErrTelegramNotLinked
+}
+
+func newTelegramLinkTestHandler(t *testing.T) (*Handler, *mockTelegramLinkStore) {
+	t.Helper()
+	handler, _ := newTwoFactorTestHandler(t)
+	store := newMockTelegramLinkStore()
+	handler.SetTelegramLink(store, TelegramLinkConfig{BotAPIKey: "bot-secret", BotUsername: "@StylerBot"})
+	return handler, store
+}
+
+func postTelegramBotJSON(handler http.HandlerFunc, target, botKey string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if botKey != "" {
+		req.Header.Set(TelegramBotKeyHeader, botKey)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestHandler_TelegramLinkAndLogin(t *testing.T) {
+	handler, store := newTelegramLinkTestHandler(t)
+
+	w := postTwoFactorJSON(handler.Authenticate(handler.TelegramLinkCode), "/auth/telegram/link-code", "user-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var issued telegramLinkCodeResp
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(issued.Code) != telegramLinkCodeLength {
+		t.Errorf("Expected a %d character code, got %q", telegramLinkCodeLength, issued.Code)
+	}
+	if issued.DeepLink != "https://t.me/StylerBot?start=link_"+issued.Code {
+		t.Errorf("Expected deep link with the code, got %s", issued.DeepLink)
+	}
+
+	login := map[string]interface{}{"telegramUserId": 42}
+	w = postTelegramBotJSON(handler.TelegramLogin, "/auth/telegram/login", "bot-secret", login)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 before linking, got %d", w.Code)
+	}
+
+	// Codes are accepted regardless of case and separators
+	link := map[string]interface{}{"code": strings.ToLower(issued.Code[:4] + "-" + issued.Code[4:]), "telegramUserId": 42}
+	w = postTelegramBotJSON(handler.TelegramLink, "/auth/telegram/link", "wrong-secret", link)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for wrong bot key, got %d", w.Code)
+	}
+	w = postTelegramBotJSON(handler.TelegramLink, "/auth/telegram/link", "bot-secret", link)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if store.links[42] != "user-+989120000002" {
+		t.Errorf("Expected Telegram account to be linked, got %v", store.links)
+	}
+
+	// A code works once
+	w = postTelegramBotJSON(handler.TelegramLink, "/auth/telegram/link", "bot-secret", link)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for used code, got %d", w.Code)
+	}
+
+	w = postTelegramBotJSON(handler.TelegramLogin, "/auth/telegram/login", "bot-secret", login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var session loginResp
+	if err := json.Unmarshal(w.Body.Bytes(), &session); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if session.AccessToken == "" || session.User.ID != "user-+989120000002" {
+		t.Errorf("Expected a session for the linked user, got %+v", session)
+	}
+}
+
+func TestHandler_TelegramLinkRejected(t *testing.T) {
+	handler, store := newTelegramLinkTestHandler(t)
+
+	w := postTwoFactorJSON(handler.Authenticate(handler.TelegramLinkCode), "/auth/telegram/link-code", "admin-token", nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for admin, got %d", w.Code)
+	}
+
+	store.SaveTelegramLinkCode(context.Background(), "user-+989120000002", hashTelegramLinkCode("EXPIRED1"), time.Now().Add(-time.Minute))
+	w = postTelegramBotJSON(handler.TelegramLink, "/auth/telegram/link", "bot-secret", map[string]interface{}{"code": "EXPIRED1", "telegramUserId": 7})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for expired code, got %d", w.Code)
+	}
+
+	// Admins linked before their role changed still cannot sign in through the bot
+	store.links[8] = "user-+989120000001"
+	w = postTelegramBotJSON(handler.TelegramLogin, "/auth/telegram/login", "bot-secret", map[string]interface{}{"telegramUserId": 8})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for admin sign-in, got %d", w.Code)
+	}
+
+	disabled := &Handler{rateLimiter: &mockRateLimiter{}}
+	w = postTelegramBotJSON(disabled.TelegramLogin, "/auth/telegram/login", "bot-secret", map[string]interface{}{"telegramUserId": 8})
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 when linking is disabled, got %d", w.Code)
+	}
+}
@@ -29,11 +29,13 @@ func (a *TokenServiceAdapter) ValidateAccess(ctx context.Context, token string)
 
 	// Convert security.JWTClaims to auth.TokenClaims
 	return TokenClaims{
-		UserID:    claims.UserID,
-		Phone:     claims.Phone,
-		Role:      claims.Role,
-		SessionID: claims.SessionID,
-		ExpiresAt: claims.ExpiresAt.Time,
+		UserID:         claims.UserID,
+		Phone:          claims.Phone,
+		Role:           claims.Role,
+		SessionID:      claims.SessionID,
+		ExpiresAt:      claims.ExpiresAt.Time,
+		ImpersonatorID: claims.ImpersonatorID,
+		TwoFactor:      claims.TwoFactor,
 	}, nil
 }
 
@@ -52,3 +54,12 @@ func (a *TokenServiceAdapter) RevokeAll(ctx context.Context, userID string) erro
 	return a.service.RevokeAll(ctx, userID)
 }
 
+// ListSessions implements SessionManager interface
+func (a *TokenServiceAdapter) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	return a.service.ListSessions(ctx, userID)
+}
+
+// RevokeUserSession implements SessionManager interface
+func (a *TokenServiceAdapter) RevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	return a.service.RevokeUserSession(ctx, userID, sessionID)
+}
@@ -21,6 +21,8 @@ type SessionStore interface {
 	RevokeSession(ctx context.Context, sessionID string) error
 	RevokeUserSessions(ctx context.Context, userID string) error
 	CleanupExpiredSessions(ctx context.Context) error
+	ListUserSessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeUserSession(ctx context.Context, userID, sessionID string) error
 }
 
 // PostgresSessionStore implements SessionStore using PostgreSQL
@@ -152,6 +154,7 @@ type Session struct {
 	RefreshTokenHash string
 	UserAgent        string
 	IP               string
+	Country          string
 	LastUsedAt       time.Time
 	ExpiresAt        time.Time
 	RevokedAt        *time.Time
@@ -177,6 +180,12 @@ func NewProductionTokenService(jwtSigner *security.ProductionJWTSigner, sessionS
 
 // IssueTokens creates new access and refresh tokens
 func (s *ProductionTokenService) IssueTokens(ctx context.Context, userID, phone, role, userAgent string) (string, string, time.Time, error) {
+	return s.issueTokens(ctx, userID, phone, role, userAgent, false)
+}
+
+// issueTokens creates a session with its token pair; twoFactor marks the access
+// token as having passed TOTP verification
+func (s *ProductionTokenService) issueTokens(ctx context.Context, userID, phone, role, userAgent string, twoFactor bool) (string, string, time.Time, error) {
 	sessionID := uuid.New().String()
 	now := time.Now()
 
@@ -195,13 +204,18 @@ func (s *ProductionTokenService) IssueTokens(ctx context.Context, userID, phone,
 
 	// Create access token
 	accessExpiresAt := now.Add(s.accessTTL)
-	accessToken, err := s.jwtSigner.Sign(userID, sessionID, role, phone, accessExpiresAt)
+	var accessToken string
+	if twoFactor {
+		accessToken, err = s.jwtSigner.SignTwoFactor(userID, sessionID, role, phone, accessExpiresAt)
+	} else {
+		accessToken, err = s.jwtSigner.Sign(userID, sessionID, role, phone, accessExpiresAt)
+	}
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("failed to create access token: %w", err)
 	}
 
 	// Store session
-	err = s.sessionStore.CreateSession(ctx, sessionID, userID, refreshTokenHash, userAgent, "", refreshExpiresAt)
+	err = s.sessionStore.CreateSession(ctx, sessionID, userID, refreshTokenHash, userAgent, ClientIPFromContext(ctx), refreshExpiresAt)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -209,6 +223,26 @@ func (s *ProductionTokenService) IssueTokens(ctx context.Context, userID, phone,
 	return accessToken, refreshToken, refreshExpiresAt, nil
 }
 
+// IssueImpersonationToken creates a short-lived access token that lets an admin act as userID.
+// The token is backed by its own session without a refresh token, so it cannot be rotated
+// and can be revoked like any other session.
+func (s *ProductionTokenService) IssueImpersonationToken(ctx context.Context, userID, phone, role, impersonatorID string, ttl time.Duration) (string, time.Time, error) {
+	sessionID := uuid.New().String()
+	expiresAt := time.Now().Add(ttl)
+
+	accessToken, err := s.jwtSigner.SignImpersonation(userID, sessionID, role, phone, impersonatorID, expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create impersonation token: %w", err)
+	}
+
+	err = s.sessionStore.CreateSession(ctx, sessionID, userID, "", impersonationUserAgentPrefix+impersonatorID, "", expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return accessToken, expiresAt, nil
+}
+
 // ValidateAccess validates an access token
 func (s *ProductionTokenService) ValidateAccess(ctx context.Context, token string) (*security.JWTClaims, error) {
 	claims, err := s.jwtSigner.Verify(token)
@@ -0,0 +1,520 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrTwoFactorNotEnrolled is returned when a user has not started TOTP enrollment
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrTwoFactorEnabled is returned when enrolling a user whose TOTP is already active
+	ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+)
+
+// ErrCodeTwoFactorRequired is returned by admin routes when the session has not
+// passed two-factor verification
+const ErrCodeTwoFactorRequired = "two_factor_required"
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+	// totpSkew accepts codes from one step either side of the server clock
+	totpSkew = 1
+)
+
+// TwoFactor is a user's TOTP enrollment
+type TwoFactor struct {
+	UserID            string
+	Secret            string
+	EnabledAt         *time.Time
+	LastUsedStep      int64
+	RecoveryCodesLeft int
+}
+
+// Enabled reports whether enrollment was confirmed with a first code
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TwoFactorStore persists TOTP secrets and recovery codes
+type TwoFactorStore interface {
+	GetTwoFactor(ctx context.Context, userID string) (*TwoFactor, error)
+	// SaveTwoFactorSecret starts (or restarts) an enrollment that is not enabled yet
+	SaveTwoFactorSecret(ctx context.Context, userID, secret string) error
+	EnableTwoFactor(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep records step as used, returning false if it or a later step was used already
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+}
+
+// TwoFactorTokenIssuer issues login challenges and tokens for two-factor sessions
+type TwoFactorTokenIssuer interface {
+	IssueTwoFactorTokens(ctx context.Context, userID, phone, role, userAgent string) (access string, refresh string, refreshExp time.Time, err error)
+	IssueTwoFactorChallenge(ctx context.Context, userID, phone string, ttl time.Duration) (string, time.Time, error)
+	VerifyTwoFactorChallenge(ctx context.Context, challenge string) (userID string, phone string, err error)
+}
+
+// TwoFactorConfig configures TOTP two-factor authentication for admins
+type TwoFactorConfig struct {
+	Issuer   string // shown in authenticator apps
+	Required bool   // reject admin routes for sessions without a second factor
+}
+
+// SetTwoFactor enables TOTP two-factor authentication for admin accounts
+func (h *Handler) SetTwoFactor(store TwoFactorStore, tokens TwoFactorTokenIssuer, config TwoFactorConfig) {
+	if config.Issuer == "" {
+		config.Issuer = "AI Styler"
+	}
+	h.twoFactor = store
+	h.twoFactorTokens = tokens
+	h.twoFactorConfig = config
+}
+
+// isTwoFactorRole reports whether role signs in with a second factor
+func isTwoFactorRole(role string) bool {
+	return role == "admin" || role == "super_admin"
+}
+
+// RequireTwoFactor rejects admin sessions that did not pass TOTP verification.
+// Admins without an enrollment get the same error and enroll through /auth/2fa.
+func (h *Handler) RequireTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.twoFactor == nil || !h.twoFactorConfig.Required {
+			c.Next()
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		claims, err := h.tokens.ValidateAccess(c.Request.Context(), token)
+		if err != nil {
+			common.WriteError(c.Writer, http.StatusUnauthorized, "unauthorized", "invalid token", nil)
+			c.Abort()
+			return
+		}
+		if isTwoFactorRole(claims.Role) && !claims.TwoFactor {
+			common.WriteError(c.Writer, http.StatusForbidden, ErrCodeTwoFactorRequired, "two-factor authentication required", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// twoFactorEnabled reports whether userID has a confirmed TOTP enrollment
+func (h *Handler) twoFactorEnabled(ctx context.Context, userID string) (bool, error) {
+	tf, err := h.twoFactor.GetTwoFactor(ctx, userID)
+	if errors.Is(err, ErrTwoFactorNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled(), nil
+}
+
+// twoFactorChallenge answers a password login that still needs a second factor
+func (h *Handler) twoFactorChallenge(ctx context.Context, user User) (*loginResp, error) {
+	challenge, expiresAt, err := h.twoFactorTokens.IssueTwoFactorChallenge(ctx, user.ID, user.Phone, twoFactorChallengeTTL)
+	if err != nil {
+		log.Printf("Failed to issue two-factor challenge: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not start two-factor login", nil)
+	}
+
+	resp := &loginResp{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challenge,
+		ChallengeExpiresIn: int(time.Until(expiresAt).Seconds()),
+	}
+	resp.User.ID = user.ID
+	resp.User.Role = user.Role
+	resp.User.IsPhoneVerified = user.IsPhoneVerified
+	return resp, nil
+}
+
+type twoFactorStatusResp struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type twoFactorEnrollResp struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type twoFactorCodeReq struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type twoFactorConfirmReq struct {
+	Code      string `json:"code" binding:"required,len=6,numeric"`
+	UserAgent string `json:"-" header:"User-Agent"`
+}
+
+type twoFactorConfirmResp struct {
+	loginResp
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type twoFactorVerifyReq struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	// Code is a TOTP code or one of the recovery codes
+	Code      string `json:"code" binding:"required,max=32"`
+	UserAgent string `json:"-" header:"User-Agent"`
+	DeviceID  string `json:"-" header:"X-Device-ID"`
+}
+
+type recoveryCodesResp struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorStatusEndpoint shows whether the signed-in user has two-factor authentication
+func (h *Handler) TwoFactorStatusEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary: "Get two-factor authentication status",
+		Tags:    []string{"Authentication"},
+		Secured: true,
+	}, h.twoFactorStatus)
+}
+
+// TwoFactorStatus shows whether the signed-in user has two-factor authentication
+func (h *Handler) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	h.TwoFactorStatusEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) twoFactorStatus(ctx context.Context, _ *common.NoRequest) (*twoFactorStatusResp, error) {
+	claims, err := h.twoFactorClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &twoFactorStatusResp{Required: h.twoFactorConfig.Required && isTwoFactorRole(claims.Role)}
+	tf, err := h.twoFactor.GetTwoFactor(ctx, claims.UserID)
+	if errors.Is(err, ErrTwoFactorNotEnrolled) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not load two-factor status", nil)
+	}
+	resp.Enabled = tf.Enabled()
+	if resp.Enabled {
+		resp.RecoveryCodesRemaining = tf.RecoveryCodesLeft
+	}
+	return resp, nil
+}
+
+// TwoFactorEnrollEndpoint starts TOTP enrollment and returns the provisioning URI
+func (h *Handler) TwoFactorEnrollEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Start two-factor enrollment",
+		Description: "Returns a new TOTP secret and an otpauth:// URI to show as a QR code. The secret takes effect once a code is confirmed.",
+		Tags:        []string{"Authentication"},
+		Secured:     true,
+	}, h.twoFactorEnroll)
+}
+
+// TwoFactorEnroll starts TOTP enrollment and returns the provisioning URI
+func (h *Handler) TwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	h.TwoFactorEnrollEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) twoFactorEnroll(ctx context.Context, _ *common.NoRequest) (*twoFactorEnrollResp, error) {
+	claims, err := h.twoFactorClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not start enrollment", nil)
+	}
+	if err := h.twoFactor.SaveTwoFactorSecret(ctx, claims.UserID, secret); err != nil {
+		if errors.Is(err, ErrTwoFactorEnabled) {
+			return nil, common.NewAPIError(http.StatusConflict, "", "two-factor authentication is already enabled", nil)
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not start enrollment", nil)
+	}
+
+	account := claims.Phone
+	if account == "" {
+		account = claims.UserID
+	}
+	return &twoFactorEnrollResp{
+		Secret:          secret,
+		ProvisioningURI: security.TOTPProvisioningURI(h.twoFactorConfig.Issuer, account, secret),
+	}, nil
+}
+
+// TwoFactorConfirmEndpoint enables two-factor authentication with a first TOTP code
+func (h *Handler) TwoFactorConfirmEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Confirm two-factor enrollment",
+		Description: "Enables two-factor authentication and returns single-use recovery codes and a token pair for a two-factor session.",
+		Tags:        []string{"Authentication"},
+		Secured:     true,
+	}, h.twoFactorConfirm)
+}
+
+// TwoFactorConfirm enables two-factor authentication with a first TOTP code
+func (h *Handler) TwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	h.TwoFactorConfirmEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) twoFactorConfirm(ctx context.Context, req *twoFactorConfirmReq) (*twoFactorConfirmResp, error) {
+	claims, err := h.twoFactorClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !h.rateLimiter.Allow(ctx, "2fa:"+claims.UserID, 5, 15*time.Minute) {
+		return nil, common.NewAPIError(http.StatusTooManyRequests, "rate_limited", "too many attempts", nil)
+	}
+
+	tf, err := h.twoFactor.GetTwoFactor(ctx, claims.UserID)
+	if errors.Is(err, ErrTwoFactorNotEnrolled) {
+		return nil, common.NewAPIError(http.StatusBadRequest, "", "start two-factor enrollment first", nil)
+	}
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not confirm enrollment", nil)
+	}
+	if tf.Enabled() {
+		return nil, common.NewAPIError(http.StatusConflict, "", "two-factor authentication is already enabled", nil)
+	}
+
+	step, ok := security.ValidateTOTP(tf.Secret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid code", nil)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not confirm enrollment", nil)
+	}
+	if err := h.twoFactor.EnableTwoFactor(ctx, claims.UserID, step, hashes); err != nil {
+		if errors.Is(err, ErrTwoFactorEnabled) {
+			return nil, common.NewAPIError(http.StatusConflict, "", "two-factor authentication is already enabled", nil)
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not confirm enrollment", nil)
+	}
+
+	// The session that enrolled has just proven the second factor
+	login, err := h.issueTwoFactorTokens(ctx, User{ID: claims.UserID, Phone: claims.Phone, Role: claims.Role, IsPhoneVerified: true}, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactorConfirmResp{loginResp: *login, RecoveryCodes: codes}, nil
+}
+
+// TwoFactorVerifyEndpoint completes a two-factor login
+func (h *Handler) TwoFactorVerifyEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Complete two-factor login",
+		Description: "Exchanges the challenge token from /auth/login and a TOTP or recovery code for a token pair.",
+		Tags:        []string{"Authentication"},
+	}, h.twoFactorVerify)
+}
+
+// TwoFactorVerify completes a two-factor login
+func (h *Handler) TwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	h.TwoFactorVerifyEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) twoFactorVerify(ctx context.Context, req *twoFactorVerifyReq) (*loginResp, error) {
+	if h.twoFactor == nil {
+		return nil, common.NewAPIError(http.StatusNotImplemented, "", "two-factor authentication is not available", nil)
+	}
+
+	userID, phone, err := h.twoFactorTokens.VerifyTwoFactorChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid or expired challenge", nil)
+	}
+	if !h.rateLimiter.Allow(ctx, "2fa:"+userID, 5, 15*time.Minute) {
+		return nil, common.NewAPIError(http.StatusTooManyRequests, "rate_limited", "too many attempts", nil)
+	}
+
+	user, err := h.store.GetUserByPhone(ctx, phone)
+	if err != nil || user.ID != userID {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid or expired challenge", nil)
+	}
+	if !user.IsActive {
+		return nil, common.NewAPIError(http.StatusForbidden, "", "account is inactive", nil)
+	}
+
+	tf, err := h.twoFactor.GetTwoFactor(ctx, user.ID)
+	if err != nil || !tf.Enabled() {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid or expired challenge", nil)
+	}
+
+	ok, err := h.verifySecondFactor(ctx, tf, req.Code)
+	if err != nil {
+		log.Printf("Failed to verify second factor: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not verify code", nil)
+	}
+	if !ok {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid code", nil)
+	}
+
+	resp, err := h.issueTwoFactorTokens(ctx, user, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	h.checkNewLogin(ctx, user.ID, resp.AccessToken, req.UserAgent, req.DeviceID)
+	return resp, nil
+}
+
+// TwoFactorRecoveryCodesEndpoint replaces the recovery codes of the signed-in user
+func (h *Handler) TwoFactorRecoveryCodesEndpoint() *common.Endpoint {
+	return common.NewEndpoint(common.OperationDoc{
+		Summary:     "Regenerate two-factor recovery codes",
+		Description: "Requires a current TOTP code. Previous recovery codes stop working.",
+		Tags:        []string{"Authentication"},
+		Secured:     true,
+	}, h.twoFactorRecoveryCodes)
+}
+
+// TwoFactorRecoveryCodes replaces the recovery codes of the signed-in user
+func (h *Handler) TwoFactorRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	h.TwoFactorRecoveryCodesEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) twoFactorRecoveryCodes(ctx context.Context, req *twoFactorCodeReq) (*recoveryCodesResp, error) {
+	claims, err := h.twoFactorClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !h.rateLimiter.Allow(ctx, "2fa:"+claims.UserID, 5, 15*time.Minute) {
+		return nil, common.NewAPIError(http.StatusTooManyRequests, "rate_limited", "too many attempts", nil)
+	}
+
+	tf, err := h.twoFactor.GetTwoFactor(ctx, claims.UserID)
+	if errors.Is(err, ErrTwoFactorNotEnrolled) || (err == nil && !tf.Enabled()) {
+		return nil, common.NewAPIError(http.StatusBadRequest, "", "two-factor authentication is not enabled", nil)
+	}
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not regenerate recovery codes", nil)
+	}
+
+	step, ok := security.ValidateTOTP(tf.Secret, req.Code, time.Now(), totpSkew)
+	if ok {
+		ok, err = h.twoFactor.UseTOTPStep(ctx, tf.UserID, step)
+		if err != nil {
+			return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not verify code", nil)
+		}
+	}
+	if !ok {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "invalid code", nil)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not regenerate recovery codes", nil)
+	}
+	if err := h.twoFactor.ReplaceRecoveryCodes(ctx, tf.UserID, hashes); err != nil {
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not regenerate recovery codes", nil)
+	}
+	return &recoveryCodesResp{RecoveryCodes: codes}, nil
+}
+
+// twoFactorClaims returns the caller's token claims for the enrollment
+// endpoints, which only admin roles may use
+func (h *Handler) twoFactorClaims(ctx context.Context) (TokenClaims, error) {
+	if h.twoFactor == nil {
+		return TokenClaims{}, common.NewAPIError(http.StatusNotImplemented, "", "two-factor authentication is not available", nil)
+	}
+	claims, _ := ctx.Value(ctxClaims{}).(TokenClaims)
+	if !isTwoFactorRole(claims.Role) {
+		return TokenClaims{}, common.NewAPIError(http.StatusForbidden, "", "two-factor authentication is only available for admin accounts", nil)
+	}
+	return claims, nil
+}
+
+// verifySecondFactor accepts an unused TOTP code or an unused recovery code
+func (h *Handler) verifySecondFactor(ctx context.Context, tf *TwoFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := security.ValidateTOTP(tf.Secret, code, time.Now(), totpSkew); ok {
+		return h.twoFactor.UseTOTPStep(ctx, tf.UserID, step)
+	}
+	if len(code) == security.TOTPDigits {
+		return false, nil
+	}
+	return h.twoFactor.UseRecoveryCode(ctx, tf.UserID, hashRecoveryCode(code))
+}
+
+// issueTwoFactorTokens issues a token pair marked as two-factor verified
+func (h *Handler) issueTwoFactorTokens(ctx context.Context, user User, userAgent string) (*loginResp, error) {
+	at, rt, expAt, err := h.twoFactorTokens.IssueTwoFactorTokens(ctx, user.ID, user.Phone, user.Role, userAgent)
+	if err != nil {
+		log.Printf("Failed to issue tokens: %v", err)
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "could not issue tokens", nil)
+	}
+	resp := &loginResp{}
+	resp.AccessToken = at
+	resp.AccessTokenExpiresIn = int(h.accessTTL.Seconds())
+	resp.RefreshToken = rt
+	resp.RefreshTokenExpiresAt = expAt
+	resp.User.ID = user.ID
+	resp.User.Role = user.Role
+	resp.User.IsPhoneVerified = user.IsPhoneVerified
+	return resp, nil
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as xxxx-xxxx and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueTwoFactorTokens creates a session whose access token passed two-factor verification
+func (s *ProductionTokenService) IssueTwoFactorTokens(ctx context.Context, userID, phone, role, userAgent string) (string, string, time.Time, error) {
+	return s.issueTokens(ctx, userID, phone, role, userAgent, true)
+}
+
+// IssueTwoFactorChallenge creates a short-lived challenge for a login awaiting its second factor
+func (s *ProductionTokenService) IssueTwoFactorChallenge(ctx context.Context, userID, phone string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	challenge, err := s.jwtSigner.SignTwoFactorChallenge(userID, phone, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return challenge, expiresAt, nil
+}
+
+// VerifyTwoFactorChallenge returns the user a two-factor login challenge was issued for
+func (s *ProductionTokenService) VerifyTwoFactorChallenge(ctx context.Context, challenge string) (string, string, error) {
+	claims, err := s.jwtSigner.VerifyTwoFactorChallenge(challenge)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.UserID, claims.Phone, nil
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/security"
+	"ai-styler/internal/sms"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockTwoFactorStore struct {
+	enrollments   map[string]*TwoFactor
+	recoveryCodes map[string]map[string]bool
+}
+
+func newMockTwoFactorStore() *mockTwoFactorStore {
+	return &mockTwoFactorStore{
+		enrollments:   make(map[string]*TwoFactor),
+		recoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func (m *mockTwoFactorStore) GetTwoFactor(ctx context.Context, userID string) (*TwoFactor, error) {
+	tf, ok := m.enrollments[userID]
+	if !ok {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	result := *tf
+	result.RecoveryCodesLeft = 0
+	for _, used := range m.recoveryCodes[userID] {
+		if !used {
+			result.RecoveryCodesLeft++
+		}
+	}
+	return &result, nil
+}
+
+func (m *mockTwoFactorStore) SaveTwoFactorSecret(ctx context.Context, userID, secret string) error {
+	if tf, ok := m.enrollments[userID]; ok && tf.Enabled() {
+		return ErrTwoFactorEnabled
+	}
+	m.enrollments[userID] = &TwoFactor{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (m *mockTwoFactorStore) EnableTwoFactor(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	tf, ok := m.enrollments[userID]
+	if !ok || tf.Enabled() {
+		return ErrTwoFactorEnabled
+	}
+	now := time.Now()
+	tf.EnabledAt = &now
+	tf.LastUsedStep = step
+	return m.ReplaceRecoveryCodes(ctx, userID, recoveryCodeHashes)
+}
+
+func (m *mockTwoFactorStore) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	tf := m.enrollments[userID]
+	if step <= tf.LastUsedStep {
+		return false, nil
+	}
+	tf.LastUsedStep = step
+	return true, nil
+}
+
+func (m *mockTwoFactorStore) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	used, ok := m.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (m *mockTwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	m.recoveryCodes[userID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		m.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+
+// mockTwoFactorTokens maps access tokens to claims and issues readable challenges
+type mockTwoFactorTokens struct {
+	mockTokenService
+	claims map[string]TokenClaims
+}
+
+func (m *mockTwoFactorTokens) ValidateAccess(ctx context.Context, token string) (TokenClaims, error) {
+	claims, ok := m.claims[token]
+	if !ok {
+		return TokenClaims{}, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func (m *mockTwoFactorTokens) IssueTwoFactorTokens(ctx context.Context, userID, phone, role, userAgent string) (string, string, time.Time, error) {
+	return "mfa-access-token", "mfa-refresh-token", time.Now().Add(30 * 24 * time.Hour), nil
+}
+
+func (m *mockTwoFactorTokens) IssueTwoFactorChallenge(ctx context.Context, userID, phone string, ttl time.Duration) (string, time.Time, error) {
+	return "challenge|" + userID + "|" + phone, time.Now().Add(ttl), nil
+}
+
+func (m *mockTwoFactorTokens) VerifyTwoFactorChallenge(ctx context.Context, challenge string) (string, string, error) {
+	parts := strings.Split(challenge, "|")
+	if len(parts) != 3 || parts[0] != "challenge" {
+		return "", "", errors.New("invalid challenge")
+	}
+	return parts[1], parts[2], nil
+}
+
+func newTwoFactorTestHandler(t *testing.T) (*Handler, *mockTwoFactorStore) {
+	t.Helper()
+	store := newMockStore()
+	tokens := &mockTwoFactorTokens{claims: map[string]TokenClaims{
+		"admin-token": {UserID: "user-+989120000001", Phone: "+989120000001", Role: "admin", SessionID: "admin-session"},
+		"user-token":  {UserID: "user-+989120000002", Phone: "+989120000002", Role: "user", SessionID: "user-session"},
+		"mfa-token":   {UserID: "user-+989120000001", Phone: "+989120000001", Role: "admin", SessionID: "mfa-session", TwoFactor: true},
+	}}
+
+	hasher := security.NewBCryptHasher(4)
+	hash, err := hasher.Hash("Password123")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	store.CreateUser(context.Background(), "+989120000001", hash, "admin", "", "")
+
+	handler := &Handler{
+		store:       store,
+		tokens:      tokens,
+		rateLimiter: &mockRateLimiter{},
+		sms:         &sms.MockSMSProvider{},
+		hasher:      hasher,
+		accessTTL:   time.Hour,
+	}
+	twoFactor := newMockTwoFactorStore()
+	handler.SetTwoFactor(twoFactor, tokens, TwoFactorConfig{Required: true})
+	return handler, twoFactor
+}
+
+func postTwoFactorJSON(handler http.HandlerFunc, target, token string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := security.TOTPCode(secret, security.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	return code
+}
+
+func TestHandler_TwoFactorEnrollment(t *testing.T) {
+	handler, twoFactor := newTwoFactorTestHandler(t)
+
+	w := postTwoFactorJSON(handler.Authenticate(handler.TwoFactorEnroll), "/auth/2fa/enroll", "user-token", nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for non-admin, got %d", w.Code)
+	}
+
+	w = postTwoFactorJSON(handler.Authenticate(handler.TwoFactorEnroll), "/auth/2fa/enroll", "admin-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var enroll twoFactorEnrollResp
+	if err := json.Unmarshal(w.Body.Bytes(), &enroll); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(enroll.ProvisioningURI, "otpauth://totp/AI%20Styler:+989120000001?") {
+		t.Errorf("Expected provisioning URI, got %s", enroll.ProvisioningURI)
+	}
+	if twoFactor.enrollments["user-+989120000001"].Enabled() {
+		t.Error("Expected enrollment to stay pending until confirmed")
+	}
+
+	w = postTwoFactorJSON(handler.Authenticate(handler.TwoFactorConfirm), "/auth/2fa/confirm", "admin-token", map[string]string{"code": "000000"})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for wrong code, got %d", w.Code)
+	}
+
+	code := currentTOTP(t, enroll.Secret)
+	w = postTwoFactorJSON(handler.Authenticate(handler.TwoFactorConfirm), "/auth/2fa/confirm", "admin-token", map[string]string{"code": code})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var confirm twoFactorConfirmResp
+	if err := json.Unmarshal(w.Body.Bytes(), &confirm); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(confirm.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("Expected %d recovery codes, got %d", recoveryCodeCount, len(confirm.RecoveryCodes))
+	}
+	if confirm.AccessToken != "mfa-access-token" {
+		t.Errorf("Expected two-factor access token, got %s", confirm.AccessToken)
+	}
+
+	w = postTwoFactorJSON(handler.Authenticate(handler.TwoFactorEnroll), "/auth/2fa/enroll", "admin-token", nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when already enabled, got %d", w.Code)
+	}
+}
+
+func TestHandler_TwoFactorLogin(t *testing.T) {
+	handler, twoFactor := newTwoFactorTestHandler(t)
+	userID := "user-+989120000001"
+
+	secret, _ := security.GenerateTOTPSecret()
+	twoFactor.SaveTwoFactorSecret(context.Background(), userID, secret)
+	codes, hashes, _ := generateRecoveryCodes()
+	twoFactor.EnableTwoFactor(context.Background(), userID, 0, hashes)
+
+	w := postTwoFactorJSON(handler.Login, "/auth/login", "", map[string]string{"phone": "+989120000001", "password": "Password123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var login loginResp
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !login.TwoFactorRequired || login.ChallengeToken == "" || login.AccessToken != "" {
+		t.Fatalf("Expected a two-factor challenge without tokens, got %+v", login)
+	}
+
+	code := currentTOTP(t, secret)
+	tests := []struct {
+		name           string
+		challenge      string
+		code           string
+		expectedStatus int
+	}{
+		{"invalid challenge", "forged", code, http.StatusUnauthorized},
+		{"wrong code", login.ChallengeToken, "000000", http.StatusUnauthorized},
+		{"totp code", login.ChallengeToken, code, http.StatusOK},
+		{"replayed totp code", login.ChallengeToken, code, http.StatusUnauthorized},
+		{"recovery code", login.ChallengeToken, strings.ToUpper(codes[0]), http.StatusOK},
+		{"used recovery code", login.ChallengeToken, codes[0], http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postTwoFactorJSON(handler.TwoFactorVerify, "/auth/2fa/verify", "", map[string]string{"challengeToken": tt.challenge, "code": tt.code})
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && !strings.Contains(w.Body.String(), "mfa-access-token") {
+				t.Errorf("Expected two-factor access token, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RequireTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, _ := newTwoFactorTestHandler(t)
+
+	r := gin.New()
+	r.GET("/api/admin/users", handler.RequireTwoFactor(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{"admin without second factor", "admin-token", http.StatusForbidden},
+		{"admin with second factor", "mfa-token", http.StatusOK},
+		{"non-admin", "user-token", http.StatusOK},
+		{"invalid token", "unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusForbidden && !strings.Contains(w.Body.String(), ErrCodeTwoFactorRequired) {
+				t.Errorf("Expected %s error, got %s", ErrCodeTwoFactorRequired, w.Body.String())
+			}
+		})
+	}
+}
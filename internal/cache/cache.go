@@ -0,0 +1,181 @@
+// Package cache caches lookups made on most requests, such as the user's
+// plan, quota status and vendor profile, in two tiers: for the duration of
+// the request and in Redis, shared by every instance.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cached entities. Keys are the entity and an ID joined by a colon.
+const (
+	EntityUserPlan            = "user_plan"
+	EntityQuotaStatus         = "quota_status"
+	EntityVendorProfile       = "vendor_profile"
+	EntityUnreadNotifications = "unread_notifications"
+)
+
+// Cache stores opaque values with a TTL in seconds, like dashboard.Cache
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl int) error
+	Delete(ctx context.Context, key string) error
+	DeletePattern(ctx context.Context, pattern string) error
+}
+
+// Key returns the cache key of an entity
+func Key(entity, id string) string {
+	return entity + ":" + id
+}
+
+// entityOf returns the entity of a key
+func entityOf(key string) string {
+	entity, _, _ := strings.Cut(key, ":")
+	return entity
+}
+
+// TwoTier looks keys up in the request scope first and then in the shared
+// cache, copying shared hits into the request scope. Without a request scope
+// on the context only the shared cache is used.
+type TwoTier struct {
+	shared  Cache // nil caches for the request only
+	ttls    map[string]time.Duration
+	metrics *Metrics
+}
+
+// NewTwoTier creates a cache keeping each entity for its TTL in shared, which
+// may be nil. Entities without a positive TTL are not cached.
+func NewTwoTier(shared Cache, ttls map[string]time.Duration) *TwoTier {
+	return &TwoTier{shared: shared, ttls: ttls, metrics: NewMetrics()}
+}
+
+// Metrics returns the hit and miss counters
+func (t *TwoTier) Metrics() *Metrics {
+	return t.metrics
+}
+
+// Get returns a cached value or ErrMiss. Errors of the shared cache are
+// counted and reported as misses.
+func (t *TwoTier) Get(ctx context.Context, key string) ([]byte, error) {
+	entity := entityOf(key)
+	scope := scopeFrom(ctx)
+	if value, ok := scope.get(key); ok {
+		t.metrics.record(entity, func(c *counters) { c.requestHits.Add(1) })
+		return value, nil
+	}
+
+	if t.shared != nil {
+		value, err := t.shared.Get(ctx, key)
+		if err == nil {
+			scope.set(key, value)
+			t.metrics.record(entity, func(c *counters) { c.sharedHits.Add(1) })
+			return value, nil
+		}
+		if !errors.Is(err, ErrMiss) {
+			t.metrics.record(entity, func(c *counters) { c.errors.Add(1) })
+		}
+	}
+
+	t.metrics.record(entity, func(c *counters) { c.misses.Add(1) })
+	return nil, ErrMiss
+}
+
+// Set caches value for the request and for ttl seconds in the shared cache
+func (t *TwoTier) Set(ctx context.Context, key string, value []byte, ttl int) error {
+	scopeFrom(ctx).set(key, value)
+	if t.shared == nil {
+		return nil
+	}
+	if err := t.shared.Set(ctx, key, value, ttl); err != nil {
+		t.metrics.record(entityOf(key), func(c *counters) { c.errors.Add(1) })
+		return err
+	}
+	return nil
+}
+
+// Delete drops key from both tiers
+func (t *TwoTier) Delete(ctx context.Context, key string) error {
+	scopeFrom(ctx).delete(key)
+	t.metrics.record(entityOf(key), func(c *counters) { c.invalidations.Add(1) })
+	if t.shared == nil {
+		return nil
+	}
+	if err := t.shared.Delete(ctx, key); err != nil {
+		t.metrics.record(entityOf(key), func(c *counters) { c.errors.Add(1) })
+		return err
+	}
+	return nil
+}
+
+// DeletePattern drops the keys matching a glob pattern from both tiers
+func (t *TwoTier) DeletePattern(ctx context.Context, pattern string) error {
+	scopeFrom(ctx).deletePattern(pattern)
+	t.metrics.record(entityOf(pattern), func(c *counters) { c.invalidations.Add(1) })
+	if t.shared == nil {
+		return nil
+	}
+	return t.shared.DeletePattern(ctx, pattern)
+}
+
+// TTL returns how long an entity is cached, 0 when it is not
+func (t *TwoTier) TTL(entity string) time.Duration {
+	return t.ttls[entity]
+}
+
+// Load returns the cached entity with the given ID, or loads and caches it.
+// A nil cache, or an entity without TTL, always loads. Failed loads are not
+// cached.
+func Load[T any](ctx context.Context, c *TwoTier, entity, id string, load func(ctx context.Context) (T, error)) (T, error) {
+	if c == nil || c.TTL(entity) <= 0 {
+		return load(ctx)
+	}
+
+	key := Key(entity, id)
+	if data, err := c.Get(ctx, key); err == nil {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil {
+		// Redis TTLs are whole seconds; round up so a short TTL still caches
+		ttl := int((c.TTL(entity) + time.Second - 1) / time.Second)
+		_ = c.Set(ctx, key, data, ttl)
+	}
+	return value, nil
+}
+
+// Invalidate drops the cached entity with the given ID after it was written.
+// A nil cache does nothing.
+func Invalidate(ctx context.Context, c *TwoTier, entity, id string) {
+	if c == nil {
+		return
+	}
+	_ = c.Delete(ctx, Key(entity, id))
+}
+
+// defaultCache is the cache set with SetDefault
+var defaultCache atomic.Pointer[TwoTier]
+
+// SetDefault makes c the cache of services created afterwards
+func SetDefault(c *TwoTier) {
+	defaultCache.Store(c)
+}
+
+// Default returns the cache set with SetDefault, nil when there is none
+func Default() *TwoTier {
+	return defaultCache.Load()
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryCache is a shared cache that ignores TTLs and can be made to fail
+type memoryCache struct {
+	values map[string][]byte
+	ttls   map[string]int
+	err    error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}, ttls: map[string]int{}}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl int) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key], m.ttls[key] = value, ttl
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	return nil
+}
+
+type plan struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+}
+
+// counter returns a loader of plan that counts its calls
+func counter(calls *int) func(ctx context.Context) (plan, error) {
+	return func(ctx context.Context) (plan, error) {
+		*calls++
+		return plan{Name: "pro", Limit: 100 + *calls}, nil
+	}
+}
+
+func statsOf(t *testing.T, c *TwoTier, entity string) EntityStats {
+	t.Helper()
+	for _, stats := range c.Metrics().Snapshot().Entities {
+		if stats.Entity == entity {
+			return stats
+		}
+	}
+	return EntityStats{Entity: entity}
+}
+
+func TestLoad_TwoTiers(t *testing.T) {
+	shared := newMemoryCache()
+	c := NewTwoTier(shared, map[string]time.Duration{EntityUserPlan: 1500 * time.Millisecond})
+
+	calls := 0
+	first := WithRequestScope(context.Background())
+	for i := 0; i < 3; i++ {
+		got, err := Load(first, c, EntityUserPlan, "u1", counter(&calls))
+		if err != nil || got.Limit != 101 {
+			t.Fatalf("Load() = %+v, %v, want the first load", got, err)
+		}
+	}
+	if ttl := shared.ttls["user_plan:u1"]; ttl != 2 {
+		t.Errorf("shared TTL = %ds, want 2s rounded up", ttl)
+	}
+
+	// Another request finds the plan in the shared cache
+	second := WithRequestScope(context.Background())
+	if got, _ := Load(second, c, EntityUserPlan, "u1", counter(&calls)); got.Limit != 101 || calls != 1 {
+		t.Errorf("Load() in another request = %+v after %d loads, want the shared value", got, calls)
+	}
+
+	stats := statsOf(t, c, EntityUserPlan)
+	if stats.RequestHits != 2 || stats.SharedHits != 1 || stats.Misses != 1 || stats.HitRatio != 0.75 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestLoad_Invalidate(t *testing.T) {
+	shared := newMemoryCache()
+	c := NewTwoTier(shared, map[string]time.Duration{EntityQuotaStatus: time.Minute})
+	ctx := WithRequestScope(context.Background())
+
+	calls := 0
+	Load(ctx, c, EntityQuotaStatus, "u1", counter(&calls))
+	Invalidate(ctx, c, EntityQuotaStatus, "u1")
+
+	if got, _ := Load(ctx, c, EntityQuotaStatus, "u1", counter(&calls)); got.Limit != 102 {
+		t.Errorf("Load() after Invalidate = %+v, want a fresh load", got)
+	}
+	if stats := statsOf(t, c, EntityQuotaStatus); stats.Invalidations != 1 || stats.Misses != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestLoad_PassThrough(t *testing.T) {
+	ctx := WithRequestScope(context.Background())
+	tests := []struct {
+		name  string
+		cache *TwoTier
+	}{
+		{"nil cache", nil},
+		{"entity without TTL", NewTwoTier(newMemoryCache(), map[string]time.Duration{EntityUserPlan: time.Minute})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			Load(ctx, tt.cache, EntityVendorProfile, "v1", counter(&calls))
+			Load(ctx, tt.cache, EntityVendorProfile, "v1", counter(&calls))
+			Invalidate(ctx, tt.cache, EntityVendorProfile, "v1")
+			if calls != 2 {
+				t.Errorf("loaded %d times, want every lookup loaded", calls)
+			}
+		})
+	}
+}
+
+func TestLoad_SharedCacheDown(t *testing.T) {
+	shared := newMemoryCache()
+	shared.err = errors.New("connection refused")
+	c := NewTwoTier(shared, map[string]time.Duration{EntityUserPlan: time.Minute})
+
+	calls := 0
+	got, err := Load(context.Background(), c, EntityUserPlan, "u1", counter(&calls))
+	if err != nil || got.Limit != 101 {
+		t.Fatalf("Load() = %+v, %v, want the loaded value", got, err)
+	}
+
+	failing := func(ctx context.Context) (plan, error) { return plan{}, errors.New("no rows") }
+	if _, err := Load(context.Background(), c, EntityUserPlan, "u2", failing); err == nil {
+		t.Error("Load() error = nil, want the load error")
+	}
+	if stats := statsOf(t, c, EntityUserPlan); stats.Errors != 3 || stats.Misses != 2 {
+		t.Errorf("stats = %+v, want the failed get, set and get counted", stats)
+	}
+
+	c.Metrics().Reset()
+	if entities := c.Metrics().Snapshot().Entities; len(entities) != 0 {
+		t.Errorf("entities after Reset = %+v", entities)
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts cache hits and misses per entity since the instance started
+// or the counters were reset
+type Metrics struct {
+	mu       sync.Mutex
+	since    time.Time
+	entities map[string]*counters
+}
+
+type counters struct {
+	requestHits   atomic.Int64
+	sharedHits    atomic.Int64
+	misses        atomic.Int64
+	errors        atomic.Int64
+	invalidations atomic.Int64
+}
+
+// EntityStats are the counters of one entity
+type EntityStats struct {
+	Entity        string  `json:"entity"`
+	RequestHits   int64   `json:"requestHits"`
+	SharedHits    int64   `json:"sharedHits"`
+	Misses        int64   `json:"misses"`
+	Errors        int64   `json:"errors"` // failed shared cache calls, served from the source
+	Invalidations int64   `json:"invalidations"`
+	HitRatio      float64 `json:"hitRatio"`
+}
+
+// MetricsSnapshot lists the counters of every entity looked up, by entity
+type MetricsSnapshot struct {
+	Since    time.Time     `json:"since"`
+	Entities []EntityStats `json:"entities"`
+}
+
+// NewMetrics creates empty counters
+func NewMetrics() *Metrics {
+	return &Metrics{since: time.Now(), entities: make(map[string]*counters)}
+}
+
+// record applies update to the counters of entity
+func (m *Metrics) record(entity string, update func(c *counters)) {
+	m.mu.Lock()
+	c, ok := m.entities[entity]
+	if !ok {
+		c = &counters{}
+		m.entities[entity] = c
+	}
+	m.mu.Unlock()
+	update(c)
+}
+
+// Snapshot returns the current counters
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{Since: m.since, Entities: make([]EntityStats, 0, len(m.entities))}
+	for entity, c := range m.entities {
+		stats := EntityStats{
+			Entity:        entity,
+			RequestHits:   c.requestHits.Load(),
+			SharedHits:    c.sharedHits.Load(),
+			Misses:        c.misses.Load(),
+			Errors:        c.errors.Load(),
+			Invalidations: c.invalidations.Load(),
+		}
+		if lookups := stats.RequestHits + stats.SharedHits + stats.Misses; lookups > 0 {
+			stats.HitRatio = float64(stats.RequestHits+stats.SharedHits) / float64(lookups)
+		}
+		snapshot.Entities = append(snapshot.Entities, stats)
+	}
+	sort.Slice(snapshot.Entities, func(i, j int) bool { return snapshot.Entities[i].Entity < snapshot.Entities[j].Entity })
+	return snapshot
+}
+
+// Reset clears the counters
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.since = time.Now()
+	m.entities = make(map[string]*counters)
+}
+
+// ListHandler handles GET /admin/system/cache
+func (m *Metrics) ListHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, m.Snapshot())
+}
+
+// ResetHandler handles DELETE /admin/system/cache
+func (m *Metrics) ResetHandler(c *gin.Context) {
+	m.Reset()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisKeyPrefix namespaces cached lookups in Redis
+const redisKeyPrefix = "cache:"
+
+// RedisCache stores values in Redis, shared by every instance
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache creates a new Redis cache
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+// Get returns the value of key or ErrMiss
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set stores value for ttl seconds
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl int) error {
+	if err := c.client.Set(ctx, redisKeyPrefix+key, value, time.Duration(ttl)*time.Second).Err(); err != nil {
+		return fmt.Errorf("failed to cache %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes key
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, redisKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate cached %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeletePattern removes the keys matching a glob pattern, scanning in batches
+func (c *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
+	iter := c.client.Scan(ctx, 0, redisKeyPrefix+pattern, 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := c.client.Del(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to invalidate cached %s: %w", pattern, err)
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan cached %s: %w", pattern, err)
+	}
+	if len(keys) > 0 {
+		if err := c.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cached %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
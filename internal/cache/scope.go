@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"path"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type scopeKey struct{}
+
+// scope holds the values looked up while serving one request. Its entries
+// live as long as the request, so they never expire.
+type scope struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+// WithRequestScope returns a context whose lookups are cached until it is
+// discarded, e.g. for one request or one job
+func WithRequestScope(ctx context.Context) context.Context {
+	if scopeFrom(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeKey{}, &scope{values: make(map[string][]byte)})
+}
+
+// RequestScope caches lookups for the duration of each request
+func RequestScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithRequestScope(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// scopeFrom returns the scope of ctx. A nil scope caches nothing.
+func scopeFrom(ctx context.Context) *scope {
+	s, _ := ctx.Value(scopeKey{}).(*scope)
+	return s
+}
+
+func (s *scope) get(key string) ([]byte, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *scope) set(key string, value []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+func (s *scope) delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+func (s *scope) deletePattern(pattern string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.values {
+		if matched, _ := path.Match(pattern, key); matched {
+			delete(s.values, key)
+		}
+	}
+}
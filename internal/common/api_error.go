@@ -0,0 +1,213 @@
+package common
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ai-styler/internal/locale"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+// Error code catalog shared by all handlers
+const (
+	ErrCodeBadRequest         = "bad_request"
+	ErrCodeValidation         = "validation_error"
+	ErrCodeUnauthorized       = "unauthorized"
+	ErrCodeForbidden          = "forbidden"
+	ErrCodeNotFound           = "not_found"
+	ErrCodeNotAcceptable      = "not_acceptable"
+	ErrCodeConflict           = "conflict"
+	ErrCodePayloadTooLarge    = "payload_too_large"
+	ErrCodeUnprocessable      = "unprocessable_entity"
+	ErrCodeRateLimited        = "rate_limit_exceeded"
+	ErrCodeQuotaExceeded      = "quota_exceeded"
+	ErrCodeInternal           = "internal_error"
+	ErrCodeServiceUnavailable = "service_unavailable"
+	ErrCodeMaintenance        = "maintenance"
+	ErrCodeIPBlocked          = "ip_blocked"
+	ErrCodeRegionBlocked      = "region_not_allowed"
+)
+
+// RequestIDHeader is the response header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// Sentinel errors stores can wrap so handlers map them to HTTP statuses
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// APIError is the structured error returned by all HTTP handlers. It is
+// rendered as {"error": {"code", "message", "localized_message", "details",
+// "request_id"}}; localized_message follows the request's Accept-Language.
+type APIError struct {
+	Status           int         `json:"-"`
+	Code             string      `json:"code"`
+	Message          string      `json:"message"`
+	LocalizedMessage string      `json:"localized_message,omitempty"`
+	Details          interface{} `json:"details,omitempty"`
+	RequestID        string      `json:"request_id,omitempty"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// NewAPIError creates a new API error
+func NewAPIError(status int, code, message string, details interface{}) *APIError {
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+	// Typed nil maps would otherwise render as "details": null
+	if m, ok := details.(map[string]interface{}); ok && m == nil {
+		details = nil
+	}
+	return &APIError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+// CodeForStatus returns the catalog code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusNotAcceptable:
+		return ErrCodeNotAcceptable
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrCodeServiceUnavailable
+	}
+	if status >= 500 {
+		return ErrCodeInternal
+	}
+	return ErrCodeBadRequest
+}
+
+// FromError maps an error to an API error, falling back to the given status
+// when the error cannot be classified.
+func FromError(err error, fallbackStatus int) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		err = validationError(err)
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return NewAPIError(http.StatusBadRequest, ErrCodeValidation, "Validation failed", map[string]interface{}{
+			"validation_errors": validationErrs.Errors,
+		})
+	}
+
+	var businessErr HTTPBusinessError
+	if errors.As(err, &businessErr) {
+		return NewAPIError(http.StatusUnprocessableEntity, businessErr.Code, businessErr.Message, businessErr.Details)
+	}
+
+	return NewAPIError(StatusForError(err, fallbackStatus), "", err.Error(), nil)
+}
+
+// StatusForError classifies store and service errors into HTTP statuses
+func StatusForError(err error, fallbackStatus int) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "23":
+			if pqErr.Code == "23505" {
+				return http.StatusConflict
+			}
+			return http.StatusBadRequest
+		case "22":
+			return http.StatusBadRequest
+		}
+	}
+
+	// Module errors are plain errors.New values, so fall back to their wording
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "already exists"), strings.Contains(msg, "duplicate"):
+		return http.StatusConflict
+	}
+
+	return fallbackStatus
+}
+
+// WriteAPIError writes an API error using the shared envelope
+func WriteAPIError(w http.ResponseWriter, apiErr *APIError) {
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	WriteJSON(w, apiErr.Status, map[string]interface{}{"error": apiErr})
+}
+
+// RespondAPIError writes an API error for a Gin request and aborts the chain
+func RespondAPIError(c *gin.Context, apiErr *APIError) {
+	if apiErr.RequestID == "" {
+		if id, ok := c.Get("request_id"); ok {
+			apiErr.RequestID = fmt.Sprint(id)
+		} else {
+			apiErr.RequestID = c.Writer.Header().Get(RequestIDHeader)
+		}
+	}
+	if apiErr.LocalizedMessage == "" {
+		lang := locale.FromContext(c.Request.Context()).Language()
+		apiErr.LocalizedMessage = LocalizedErrorMessage(lang, apiErr.Code, apiErr.Status)
+	}
+	c.AbortWithStatusJSON(apiErr.Status, gin.H{"error": apiErr})
+}
+
+// RespondError writes an error with the catalog code for the status
+func RespondError(c *gin.Context, status int, message string) {
+	RespondAPIError(c, NewAPIError(status, "", message, nil))
+}
+
+// RespondErrorWithDetails writes an error with extra details
+func RespondErrorWithDetails(c *gin.Context, status int, message string, details interface{}) {
+	RespondAPIError(c, NewAPIError(status, "", message, details))
+}
+
+// RespondErr maps err to a status and writes it, using fallbackStatus when
+// the error is not recognised.
+func RespondErr(c *gin.Context, fallbackStatus int, err error) {
+	RespondAPIError(c, FromError(err, fallbackStatus))
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ai-styler/internal/locale"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback int
+		expected int
+	}{
+		{"no rows", fmt.Errorf("failed to get user: %w", sql.ErrNoRows), http.StatusInternalServerError, http.StatusNotFound},
+		{"sentinel not found", fmt.Errorf("lookup: %w", ErrNotFound), http.StatusInternalServerError, http.StatusNotFound},
+		{"sentinel conflict", ErrConflict, http.StatusInternalServerError, http.StatusConflict},
+		{"sentinel validation", ErrValidation, http.StatusInternalServerError, http.StatusBadRequest},
+		{"unique violation", &pq.Error{Code: "23505"}, http.StatusInternalServerError, http.StatusConflict},
+		{"foreign key violation", &pq.Error{Code: "23503"}, http.StatusInternalServerError, http.StatusBadRequest},
+		{"module not found", errors.New("conversion not found"), http.StatusBadRequest, http.StatusNotFound},
+		{"unclassified", errors.New("boom"), http.StatusBadRequest, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusForError(tt.err, tt.fallback); got != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRespondErr_Envelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("request_id", "req-123")
+
+	RespondErr(c, http.StatusInternalServerError, fmt.Errorf("failed to get plan: %w", sql.ErrNoRows))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	if !c.IsAborted() {
+		t.Error("Expected context to be aborted")
+	}
+
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body.Error == nil {
+		t.Fatal("Expected error envelope")
+	}
+	if body.Error.Code != ErrCodeNotFound {
+		t.Errorf("Expected code %s, got %s", ErrCodeNotFound, body.Error.Code)
+	}
+	if body.Error.RequestID != "req-123" {
+		t.Errorf("Expected request_id req-123, got %s", body.Error.RequestID)
+	}
+}
+
+func TestRespondAPIError_LocalizedMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(locale.Middleware())
+	r.GET("/quota", func(c *gin.Context) {
+		RespondAPIError(c, NewAPIError(http.StatusForbidden, ErrCodeQuotaExceeded, "quota exceeded", nil))
+	})
+	r.GET("/custom", func(c *gin.Context) {
+		RespondAPIError(c, NewAPIError(http.StatusNotFound, "plan_not_found", "plan not found", nil))
+	})
+
+	tests := []struct {
+		path     string
+		language string
+		expected string
+	}{
+		{"/quota", "en-US", "Your quota is used up. Upgrade your plan to continue."},
+		{"/quota", "", "سهمیه شما تمام شده است. برای ادامه، پلن خود را ارتقا دهید."},
+		{"/custom", "en", "The requested item was not found."},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept-Language", tt.language)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode body: %v", err)
+		}
+		if body.Error.LocalizedMessage != tt.expected {
+			t.Errorf("Expected localized message %q for %s (%q), got %q", tt.expected, tt.path, tt.language, body.Error.LocalizedMessage)
+		}
+		if body.Error.Message == body.Error.LocalizedMessage {
+			t.Errorf("Expected message to stay developer-facing for %s", tt.path)
+		}
+	}
+}
+
+func TestWriteError_UsesRequestIDHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-456")
+
+	WriteError(w, http.StatusBadRequest, "bad_request", "invalid json", nil)
+
+	var body map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body["error"]["request_id"] != "req-456" {
+		t.Errorf("Expected request_id req-456, got %v", body["error"]["request_id"])
+	}
+	if _, ok := body["error"]["details"]; ok {
+		t.Error("Expected details to be omitted")
+	}
+}
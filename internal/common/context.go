@@ -10,8 +10,13 @@ type contextKey string
 const (
 	UserIDKey   contextKey = "userID"
 	VendorIDKey contextKey = "vendorID"
+	TraceIDKey  contextKey = "traceID"
 )
 
+// requestTraceIDKey is the key the request context middleware stores the
+// request's trace ID under, read by the logger and the error reporter
+const requestTraceIDKey = "trace_id"
+
 // SetUserIDInContext sets the user ID in the context
 func SetUserIDInContext(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
@@ -42,3 +47,22 @@ func GetVendorIDFromContext(ctx context.Context) string {
 func WithVendorID(ctx context.Context, vendorID string) context.Context {
 	return SetVendorIDInContext(ctx, vendorID)
 }
+
+// SetTraceIDInContext sets the trace ID of a conversion in the context, also
+// under the key the logger and the error reporter read
+func SetTraceIDInContext(ctx context.Context, traceID string) context.Context {
+	ctx = context.WithValue(ctx, TraceIDKey, traceID)
+	return context.WithValue(ctx, requestTraceIDKey, traceID) //nolint:staticcheck // the key of the request context middleware
+}
+
+// GetTraceIDFromContext gets the trace ID from the context, falling back to
+// the trace ID of the request
+func GetTraceIDFromContext(ctx context.Context) string {
+	if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
+		return traceID
+	}
+	if traceID, ok := ctx.Value(requestTraceIDKey).(string); ok {
+		return traceID
+	}
+	return ""
+}
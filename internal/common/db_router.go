@@ -0,0 +1,251 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// replicaLagQuery measures how far a replica is behind the primary. A replica
+// that replayed everything it received is not lagging, however old the last
+// replayed transaction is.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+// replica is a read replica with its last measured health and lag
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // nanoseconds
+}
+
+// DBRouter sends read-only queries to read replicas and everything else to the
+// primary. Replicas that are down or lag more than maxLag are skipped, and a
+// user who wrote recently reads from the primary until replicas caught up
+// with the write. Without healthy replicas all queries go to the primary.
+type DBRouter struct {
+	primary  *sql.DB
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint32
+
+	mu     sync.Mutex
+	writes map[string]time.Time // last write per user, "" for writes without one
+}
+
+// NewDBRouter creates a router over primary and replicas. Replicas serve reads
+// once CheckReplicas measured them.
+func NewDBRouter(primary *sql.DB, replicas []*sql.DB, maxLag time.Duration) *DBRouter {
+	router := &DBRouter{primary: primary, maxLag: maxLag, writes: make(map[string]time.Time)}
+	for _, db := range replicas {
+		router.replicas = append(router.replicas, &replica{db: db})
+	}
+	return router
+}
+
+// Primary returns the primary database
+func (r *DBRouter) Primary() *sql.DB {
+	return r.primary
+}
+
+// Reader returns the connection for a read-only query: the transaction ctx
+// carries, a replica that is fresh enough for the user of ctx, or the
+// primary. Queries through it fall back to the primary when the replica's
+// connection fails.
+func (r *DBRouter) Reader(ctx context.Context) DBTX {
+	if InTx(ctx) || len(r.replicas) == 0 {
+		return Conn(ctx, r.primary)
+	}
+	replica := r.pickReplica(r.sinceWrite(ctx))
+	if replica == nil {
+		return r.primary
+	}
+	return &replicaReader{router: r, replica: replica}
+}
+
+// Writer returns the primary, or the transaction ctx carries, and marks the
+// user of ctx as having written once a statement ran through it
+func (r *DBRouter) Writer(ctx context.Context) DBTX {
+	return &primaryWriter{router: r, conn: Conn(ctx, r.primary)}
+}
+
+// MarkWrite records that the user of ctx changed data, so their next reads
+// go to the primary until replicas replayed the change
+func (r *DBRouter) MarkWrite(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[GetUserIDFromContext(ctx)] = now
+
+	// Forget writes every replica has caught up with
+	if len(r.writes) > 1000 {
+		for key, at := range r.writes {
+			if now.Sub(at) > r.maxLag {
+				delete(r.writes, key)
+			}
+		}
+	}
+}
+
+// sinceWrite returns how long ago the user of ctx last wrote, or -1 when they
+// did not write within maxLag
+func (r *DBRouter) sinceWrite(ctx context.Context) time.Duration {
+	r.mu.Lock()
+	at, ok := r.writes[GetUserIDFromContext(ctx)]
+	r.mu.Unlock()
+	if !ok {
+		return -1
+	}
+	if since := time.Since(at); since <= r.maxLag {
+		return since
+	}
+	return -1
+}
+
+// pickReplica returns the next healthy replica within maxLag that also
+// replayed a write made sinceWrite ago, or nil
+func (r *DBRouter) pickReplica(sinceWrite time.Duration) *replica {
+	start := int(r.next.Add(1))
+	for i := range r.replicas {
+		candidate := r.replicas[(start+i)%len(r.replicas)]
+		if !candidate.healthy.Load() {
+			continue
+		}
+		lag := time.Duration(candidate.lag.Load())
+		if lag > r.maxLag || (sinceWrite >= 0 && lag >= sinceWrite) {
+			continue
+		}
+		return candidate
+	}
+	return nil
+}
+
+// CheckReplicas measures the lag of every replica. Replicas that cannot be
+// reached are skipped until a later check succeeds.
+func (r *DBRouter) CheckReplicas(ctx context.Context) {
+	for i, replica := range r.replicas {
+		var seconds float64
+		if err := replica.db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+			if replica.healthy.Swap(false) {
+				log.Printf("Read replica %d is unavailable, reading from primary: %v", i, err)
+			}
+			continue
+		}
+		lag := time.Duration(seconds * float64(time.Second))
+		replica.lag.Store(int64(lag))
+		if !replica.healthy.Swap(true) {
+			log.Printf("Read replica %d is available (lag %v)", i, lag)
+		}
+		if lag > r.maxLag {
+			log.Printf("Read replica %d lags %v behind primary, skipping it", i, lag)
+		}
+	}
+}
+
+// Run checks the replicas every interval until ctx is done
+func (r *DBRouter) Run(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	r.CheckReplicas(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.CheckReplicas(ctx)
+		}
+	}
+}
+
+// primaryWriter runs statements on the primary and marks the write
+type primaryWriter struct {
+	router *DBRouter
+	conn   DBTX
+}
+
+func (w *primaryWriter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer w.router.MarkWrite(ctx)
+	return w.conn.ExecContext(ctx, query, args...)
+}
+
+func (w *primaryWriter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer w.router.MarkWrite(ctx)
+	return w.conn.QueryContext(ctx, query, args...)
+}
+
+func (w *primaryWriter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer w.router.MarkWrite(ctx)
+	return w.conn.QueryRowContext(ctx, query, args...)
+}
+
+// replicaReader runs queries on a replica, falling back to the primary when
+// the replica's connection fails
+type replicaReader struct {
+	router  *DBRouter
+	replica *replica
+}
+
+func (q *replicaReader) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	// Writes never go to a replica
+	return q.router.primary.ExecContext(ctx, query, args...)
+}
+
+func (q *replicaReader) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := q.replica.db.QueryContext(ctx, query, args...)
+	if err != nil && isReplicaFailure(err) {
+		q.replica.healthy.Store(false)
+		log.Printf("Read replica query failed, retrying on primary: %v", err)
+		return q.router.primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext cannot retry, *sql.Row reports errors only on Scan. The
+// replica is checked for a working connection first instead.
+func (q *replicaReader) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if err := q.replica.db.PingContext(ctx); err != nil && ctx.Err() == nil {
+		q.replica.healthy.Store(false)
+		log.Printf("Read replica is unreachable, reading from primary: %v", err)
+		return q.router.primary.QueryRowContext(ctx, query, args...)
+	}
+	return q.replica.db.QueryRowContext(ctx, query, args...)
+}
+
+// isReplicaFailure reports whether err is a problem of the replica rather than
+// of the query: a lost connection, a shutdown or a query cancelled because of
+// a conflict with replication
+func isReplicaFailure(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03", "40001":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	return false
+}
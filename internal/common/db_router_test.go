@@ -0,0 +1,129 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// setReplica marks the router's i-th replica healthy with the given lag
+func setReplica(router *DBRouter, i int, healthy bool, lag time.Duration) {
+	router.replicas[i].healthy.Store(healthy)
+	router.replicas[i].lag.Store(int64(lag))
+}
+
+// readsFromReplica reports whether the router sends ctx's reads to a replica
+func readsFromReplica(router *DBRouter, ctx context.Context) bool {
+	_, ok := router.Reader(ctx).(*replicaReader)
+	return ok
+}
+
+func TestDBRouter_Reader(t *testing.T) {
+	primary, _ := newCountingDB(t)
+	replicaDB, _ := newCountingDB(t)
+	ctx := context.Background()
+
+	if conn := NewDBRouter(primary, nil, time.Second).Reader(ctx); conn != primary {
+		t.Errorf("Expected primary without replicas, got %T", conn)
+	}
+
+	router := NewDBRouter(primary, []*sql.DB{replicaDB}, 5*time.Second)
+	if readsFromReplica(router, ctx) {
+		t.Error("Expected primary before the replica was checked")
+	}
+
+	setReplica(router, 0, true, time.Second)
+	if !readsFromReplica(router, ctx) {
+		t.Error("Expected a healthy replica within the lag limit to serve reads")
+	}
+
+	setReplica(router, 0, true, 10*time.Second)
+	if readsFromReplica(router, ctx) {
+		t.Error("Expected a lagging replica to be skipped")
+	}
+
+	setReplica(router, 0, false, 0)
+	if readsFromReplica(router, ctx) {
+		t.Error("Expected an unhealthy replica to be skipped")
+	}
+
+	setReplica(router, 0, true, 0)
+	err := WithinTx(ctx, primary, func(ctx context.Context, tx DBTX) error {
+		if _, ok := router.Reader(ctx).(*sql.Tx); !ok {
+			t.Error("Expected reads in a unit of work to join its transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestDBRouter_ReadYourWrites(t *testing.T) {
+	primary, _ := newCountingDB(t)
+	replicaDB, _ := newCountingDB(t)
+	router := NewDBRouter(primary, []*sql.DB{replicaDB}, 5*time.Second)
+	setReplica(router, 0, true, time.Second)
+
+	admin := SetUserIDInContext(context.Background(), "admin-1")
+	other := SetUserIDInContext(context.Background(), "admin-2")
+
+	if _, err := router.Writer(admin).ExecContext(admin, "UPDATE users SET is_active = false"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if readsFromReplica(router, admin) {
+		t.Error("Expected the writer to read from primary until the replica replayed the write")
+	}
+	if !readsFromReplica(router, other) {
+		t.Error("Expected other users to keep reading from the replica")
+	}
+
+	// Once the write is older than the replica's lag the replica has it
+	router.mu.Lock()
+	router.writes["admin-1"] = time.Now().Add(-2 * time.Second)
+	router.mu.Unlock()
+	if !readsFromReplica(router, admin) {
+		t.Error("Expected the replica to serve reads after replaying the write")
+	}
+}
+
+func TestDBRouter_CheckReplicas(t *testing.T) {
+	primary, _ := newCountingDB(t)
+	replicaDB, _ := newCountingDB(t)
+	router := NewDBRouter(primary, []*sql.DB{replicaDB}, 5*time.Second)
+	setReplica(router, 0, true, 0)
+
+	// The counting driver cannot run the lag query, like an unreachable replica
+	router.CheckReplicas(context.Background())
+	if readsFromReplica(router, context.Background()) {
+		t.Error("Expected a replica failing its check to be skipped")
+	}
+}
+
+func TestIsReplicaFailure(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("query failed: %w", sql.ErrConnDone), true},
+		{&pq.Error{Code: "08006"}, true},
+		{&pq.Error{Code: "57P01"}, true},
+		{&pq.Error{Code: "40001"}, true},
+		{&pq.Error{Code: "42P01"}, false},
+		{&pq.Error{Code: "57014"}, false},
+		{sql.ErrNoRows, false},
+		{errors.New("syntax error"), false},
+	}
+	for _, tt := range tests {
+		if got := isReplicaFailure(tt.err); got != tt.expected {
+			t.Errorf("Expected isReplicaFailure(%v) = %v, got %v", tt.err, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// TypedFunc is a handler that receives a bound, validated request and returns
+// a response or an error. Errors are rendered with the APIError envelope.
+type TypedFunc[Req any, Resp any] func(ctx context.Context, req *Req) (*Resp, error)
+
+// NoRequest is used by endpoints that take no input
+type NoRequest struct{}
+
+// MessageResponse is a plain acknowledgement body
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// StatusCoder lets a response choose its own success status
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Validatable requests are checked after binding and tag validation
+type Validatable interface {
+	Validate() error
+}
+
+// OperationDoc describes an endpoint for the generated OpenAPI document
+type OperationDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Status      int  // success status, defaults to 200
+	Secured     bool // requires a bearer token
+}
+
+// Operation is a mounted endpoint as seen by the OpenAPI generator
+type Operation struct {
+	OperationDoc
+	Method   string
+	Path     string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// Endpoint is a typed handler that can be served through Gin or net/http
+type Endpoint struct {
+	doc      OperationDoc
+	reqType  reflect.Type
+	respType reflect.Type
+	serve    func(ctx context.Context, r *http.Request, params map[string]string) (int, interface{}, error)
+}
+
+// NewEndpoint creates an endpoint with automatic binding and validation.
+// Request fields are bound from the JSON body, `uri` path parameters,
+// `form` query parameters and `header` headers, then validated with
+// `binding` tags and the optional Validate method.
+func NewEndpoint[Req any, Resp any](doc OperationDoc, fn TypedFunc[Req, Resp]) *Endpoint {
+	if doc.Status == 0 {
+		doc.Status = http.StatusOK
+	}
+
+	reqType := reflect.TypeOf((*Req)(nil)).Elem()
+	sources := bindingSourcesFor(reqType)
+
+	return &Endpoint{
+		doc:      doc,
+		reqType:  reqType,
+		respType: reflect.TypeOf((*Resp)(nil)).Elem(),
+		serve: func(ctx context.Context, r *http.Request, params map[string]string) (int, interface{}, error) {
+			req := new(Req)
+			if err := bindRequest(r, params, sources, req); err != nil {
+				return 0, nil, err
+			}
+
+			resp, err := fn(ctx, req)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			status := doc.Status
+			if resp == nil {
+				return status, nil, nil
+			}
+			if coder, ok := any(resp).(StatusCoder); ok {
+				status = coder.StatusCode()
+			}
+			return status, resp, nil
+		},
+	}
+}
+
+// Gin returns the endpoint as a Gin handler
+func (e *Endpoint) Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsJSON(c.Request) {
+			RespondAPIError(c, NewAPIError(http.StatusNotAcceptable, "", "only application/json responses are supported", nil))
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+
+		status, resp, err := e.serve(c.Request.Context(), c.Request, params)
+		if err != nil {
+			RespondErr(c, http.StatusInternalServerError, err)
+			return
+		}
+		if resp == nil {
+			c.Status(status)
+			return
+		}
+		c.JSON(status, resp)
+	}
+}
+
+// ServeHTTP serves the endpoint on a standard library mux
+func (e *Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(r) {
+		WriteAPIError(w, NewAPIError(http.StatusNotAcceptable, "", "only application/json responses are supported", nil))
+		return
+	}
+
+	// Path parameters come from the 1.22 mux patterns or from GinWrap
+	params := make(map[string]string)
+	for _, name := range uriParamNames(e.reqType) {
+		if value := r.PathValue(name); value != "" {
+			params[name] = value
+		} else if value, ok := r.Context().Value("path_param_" + name).(string); ok {
+			params[name] = value
+		}
+	}
+
+	status, resp, err := e.serve(r.Context(), r, params)
+	if err != nil {
+		WriteAPIError(w, FromError(err, http.StatusInternalServerError))
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(status)
+		return
+	}
+	WriteJSON(w, status, resp)
+}
+
+// RouteGroup is a Gin router that knows its base path
+type RouteGroup interface {
+	gin.IRoutes
+	BasePath() string
+}
+
+// Mount registers the endpoint on the router and records it for the OpenAPI document
+func Mount(r RouteGroup, method, relativePath string, e *Endpoint) {
+	r.Handle(method, relativePath, e.Gin())
+
+	fullPath := path.Join(r.BasePath(), relativePath)
+	operations.register(Operation{
+		OperationDoc: e.doc,
+		Method:       method,
+		Path:         fullPath,
+		Request:      e.reqType,
+		Response:     e.respType,
+	})
+}
+
+// RegisteredOperations returns the mounted endpoints sorted by path and method
+func RegisteredOperations() []Operation {
+	return operations.list()
+}
+
+type operationRegistry struct {
+	mu  sync.RWMutex
+	ops map[string]Operation
+}
+
+var operations = &operationRegistry{ops: make(map[string]Operation)}
+
+func (r *operationRegistry) register(op Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops[op.Method+" "+op.Path] = op
+}
+
+func (r *operationRegistry) list() []Operation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ops := make([]Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// bindingSources records which request sources a type reads from
+type bindingSources struct {
+	body   bool
+	uri    bool
+	query  bool
+	header bool
+}
+
+func bindingSourcesFor(t reflect.Type) bindingSources {
+	var sources bindingSources
+	if t.Kind() != reflect.Struct {
+		sources.body = true
+		return sources
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			nested := bindingSourcesFor(field.Type)
+			sources.body = sources.body || nested.body
+			sources.uri = sources.uri || nested.uri
+			sources.query = sources.query || nested.query
+			sources.header = sources.header || nested.header
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("uri"); ok {
+			sources.uri = true
+		}
+		if _, ok := field.Tag.Lookup("form"); ok {
+			sources.query = true
+		}
+		if _, ok := field.Tag.Lookup("header"); ok {
+			sources.header = true
+		}
+		if tag := field.Tag.Get("json"); tag != "-" {
+			sources.body = true
+		}
+	}
+	return sources
+}
+
+// uriParamNames lists the path parameters a request type binds
+func uriParamNames(t reflect.Type) []string {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, uriParamNames(field.Type)...)
+			continue
+		}
+		if name := strings.Split(field.Tag.Get("uri"), ",")[0]; name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// bindRequest fills req from the request and validates it
+func bindRequest(r *http.Request, params map[string]string, sources bindingSources, req interface{}) error {
+	if sources.body && r.Body != nil && r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+			return NewAPIError(http.StatusBadRequest, "", "invalid request body", nil)
+		}
+	}
+
+	if sources.uri && len(params) > 0 {
+		values := make(map[string][]string, len(params))
+		for key, value := range params {
+			values[key] = []string{value}
+		}
+		if err := binding.MapFormWithTag(req, values, "uri"); err != nil {
+			return fmt.Errorf("%w: invalid path parameter: %v", ErrValidation, err)
+		}
+	}
+
+	if sources.query && len(r.URL.Query()) > 0 {
+		if err := binding.MapFormWithTag(req, r.URL.Query(), "form"); err != nil {
+			return fmt.Errorf("%w: invalid query parameter: %v", ErrValidation, err)
+		}
+	}
+
+	if sources.header {
+		if err := binding.MapFormWithTag(req, r.Header, "header"); err != nil {
+			return fmt.Errorf("%w: invalid header: %v", ErrValidation, err)
+		}
+	}
+
+	return ValidateRequest(req)
+}
+
+// acceptsJSON reports whether the Accept header allows a JSON response
+func acceptsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+		switch mediaType {
+		case "*/*", "application/*", gin.MIMEJSON:
+			return true
+		}
+	}
+	return false
+}
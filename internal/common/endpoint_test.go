@@ -0,0 +1,145 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type widgetRequest struct {
+	ID    string `uri:"id" json:"-" binding:"required"`
+	Color string `form:"color" json:"-"`
+	Agent string `header:"User-Agent" json:"-"`
+	Name  string `json:"name" binding:"required"`
+	Size  int    `json:"size"`
+}
+
+func (r *widgetRequest) Validate() error {
+	if r.Size < 0 {
+		return errors.New("size must not be negative")
+	}
+	return nil
+}
+
+type widgetResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Agent string `json:"agent"`
+}
+
+func newWidgetEndpoint() *Endpoint {
+	return NewEndpoint(OperationDoc{Summary: "Update widget", Tags: []string{"Widgets"}, Status: http.StatusCreated},
+		func(ctx context.Context, req *widgetRequest) (*widgetResponse, error) {
+			if req.Name == "missing" {
+				return nil, ErrNotFound
+			}
+			return &widgetResponse{ID: req.ID, Name: req.Name, Color: req.Color, Agent: req.Agent}, nil
+		})
+}
+
+func newWidgetRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Mount(r.Group("/api/widgets"), http.MethodPost, "/:id", newWidgetEndpoint())
+	return r
+}
+
+func TestEndpoint_Gin(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		accept         string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"binds body, path, query and header", `{"name":"gear","size":2}`, "", http.StatusCreated, ""},
+		{"missing required field", `{"size":2}`, "", http.StatusBadRequest, ErrCodeValidation},
+		{"invalid json", `{"name":`, "", http.StatusBadRequest, ErrCodeBadRequest},
+		{"custom validation", `{"name":"gear","size":-1}`, "", http.StatusBadRequest, ErrCodeBadRequest},
+		{"service error mapping", `{"name":"missing"}`, "", http.StatusNotFound, ErrCodeNotFound},
+		{"unsupported accept header", `{"name":"gear"}`, "text/html", http.StatusNotAcceptable, ErrCodeNotAcceptable},
+	}
+
+	r := newWidgetRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/widgets/w-1?color=red", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "tests")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var body ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("Failed to unmarshal error: %v", err)
+				}
+				if body.Error.Code != tt.expectedCode {
+					t.Errorf("Expected code %s, got %s", tt.expectedCode, body.Error.Code)
+				}
+				return
+			}
+
+			var resp widgetResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			expected := widgetResponse{ID: "w-1", Name: "gear", Color: "red", Agent: "tests"}
+			if resp != expected {
+				t.Errorf("Expected response %+v, got %+v", expected, resp)
+			}
+		})
+	}
+}
+
+func TestEndpoint_ServeHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("POST /widgets/{id}", newWidgetEndpoint())
+
+	req := httptest.NewRequest(http.MethodPost, "/widgets/w-2", strings.NewReader(`{"name":"bolt"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp widgetResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.ID != "w-2" || resp.Name != "bolt" {
+		t.Errorf("Expected widget w-2/bolt, got %+v", resp)
+	}
+}
+
+func TestMount_RegistersOperation(t *testing.T) {
+	newWidgetRouter()
+
+	for _, op := range RegisteredOperations() {
+		if op.Method == http.MethodPost && op.Path == "/api/widgets/:id" {
+			if op.Summary != "Update widget" || op.Status != http.StatusCreated {
+				t.Errorf("Unexpected operation metadata: %+v", op.OperationDoc)
+			}
+			if op.Request.Name() != "widgetRequest" || op.Response.Name() != "widgetResponse" {
+				t.Errorf("Expected widget request/response types, got %v/%v", op.Request, op.Response)
+			}
+			return
+		}
+	}
+	t.Error("Expected POST /api/widgets/:id to be registered")
+}
@@ -0,0 +1,53 @@
+package common
+
+import "ai-styler/internal/locale"
+
+// errorMessages are the user-facing descriptions of the error codes, shown by
+// clients as localized_message next to the developer-facing message
+var errorMessages = locale.NewCatalog(map[string]map[string]string{
+	locale.LangPersian: {
+		ErrCodeBadRequest:         "درخواست نامعتبر است.",
+		ErrCodeValidation:         "اطلاعات وارد شده معتبر نیست.",
+		ErrCodeUnauthorized:       "لطفاً ابتدا وارد حساب کاربری خود شوید.",
+		ErrCodeForbidden:          "شما اجازه دسترسی به این بخش را ندارید.",
+		ErrCodeNotFound:           "مورد درخواستی پیدا نشد.",
+		ErrCodeNotAcceptable:      "قالب درخواستی پشتیبانی نمی‌شود.",
+		ErrCodeConflict:           "این مورد قبلاً ثبت شده است.",
+		ErrCodePayloadTooLarge:    "حجم فایل ارسالی بیش از حد مجاز است.",
+		ErrCodeUnprocessable:      "امکان انجام این درخواست وجود ندارد.",
+		ErrCodeRateLimited:        "تعداد درخواست‌های شما بیش از حد مجاز است. لطفاً کمی بعد دوباره تلاش کنید.",
+		ErrCodeQuotaExceeded:      "سهمیه شما تمام شده است. برای ادامه، پلن خود را ارتقا دهید.",
+		ErrCodeInternal:           "خطای داخلی رخ داد. لطفاً دوباره تلاش کنید.",
+		ErrCodeServiceUnavailable: "سرویس در حال حاضر در دسترس نیست. لطفاً کمی بعد دوباره تلاش کنید.",
+		ErrCodeMaintenance:        "سرویس در حال به‌روزرسانی است. لطفاً چند دقیقه دیگر دوباره تلاش کنید.",
+		ErrCodeIPBlocked:          "دسترسی از این آدرس IP موقتاً مسدود شده است.",
+		ErrCodeRegionBlocked:      "این سرویس در منطقه شما در دسترس نیست.",
+	},
+	locale.LangEnglish: {
+		ErrCodeBadRequest:         "The request is invalid.",
+		ErrCodeValidation:         "Some of the submitted fields are invalid.",
+		ErrCodeUnauthorized:       "Please sign in first.",
+		ErrCodeForbidden:          "You do not have access to this resource.",
+		ErrCodeNotFound:           "The requested item was not found.",
+		ErrCodeNotAcceptable:      "The requested format is not supported.",
+		ErrCodeConflict:           "This item already exists.",
+		ErrCodePayloadTooLarge:    "The uploaded file is too large.",
+		ErrCodeUnprocessable:      "This request cannot be completed.",
+		ErrCodeRateLimited:        "Too many requests. Please try again shortly.",
+		ErrCodeQuotaExceeded:      "Your quota is used up. Upgrade your plan to continue.",
+		ErrCodeInternal:           "Something went wrong. Please try again.",
+		ErrCodeServiceUnavailable: "The service is temporarily unavailable. Please try again shortly.",
+		ErrCodeMaintenance:        "The service is under maintenance. Please try again in a few minutes.",
+		ErrCodeIPBlocked:          "Access from this IP address has been blocked.",
+		ErrCodeRegionBlocked:      "This service is not available in your region.",
+	},
+})
+
+// LocalizedErrorMessage returns the description of an error code in lang.
+// Module-specific codes without a translation use the code of the status.
+func LocalizedErrorMessage(lang, code string, status int) string {
+	if !errorMessages.Has(locale.DefaultLanguage, code) {
+		code = CodeForStatus(status)
+	}
+	return errorMessages.Text(lang, code)
+}
@@ -2,7 +2,6 @@ package common
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -12,12 +11,7 @@ import (
 
 // ErrorResponse represents a standardized error response
 type ErrorResponse struct {
-	Error     string                 `json:"error"`
-	Code      string                 `json:"code"`
-	Message   string                 `json:"message"`
-	Details   map[string]interface{} `json:"details,omitempty"`
-	Timestamp time.Time              `json:"timestamp"`
-	RequestID string                 `json:"request_id,omitempty"`
+	Error *APIError `json:"error"`
 }
 
 // HTTPErrorHandler handles HTTP errors consistently
@@ -34,20 +28,7 @@ func NewHTTPErrorHandler(logger Logger) *HTTPErrorHandler {
 
 // WriteError writes a standardized error response
 func (h *HTTPErrorHandler) WriteError(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	response := ErrorResponse{
-		Error:     http.StatusText(statusCode),
-		Code:      code,
-		Message:   message,
-		Details:   details,
-		Timestamp: time.Now(),
-	}
-
-	// Note: Request ID extraction would need to be handled differently
-	// as we can't access the gin context directly from http.ResponseWriter
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
+	WriteAPIError(w, NewAPIError(statusCode, code, message, details))
 
 	// Log the error
 	if h.logger != nil {
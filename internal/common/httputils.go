@@ -15,20 +15,9 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// WriteError writes an error response
+// WriteError writes an error response using the shared APIError envelope
 func WriteError(w http.ResponseWriter, statusCode int, code, message string, details interface{}) {
-	errorResponse := map[string]interface{}{
-		"error": map[string]interface{}{
-			"code":    code,
-			"message": message,
-		},
-	}
-
-	if details != nil {
-		errorResponse["error"].(map[string]interface{})["details"] = details
-	}
-
-	WriteJSON(w, statusCode, errorResponse)
+	WriteAPIError(w, NewAPIError(statusCode, code, message, details))
 }
 
 // GinWrap adapts http.HandlerFunc to gin.HandlerFunc
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// List endpoints page in created_at DESC, id DESC order. Page-based requests
+// use LIMIT/OFFSET and count the total; requests with a cursor continue after
+// the row it names, which stays fast on large tables, and skip the count.
+// Both return the cursor of the next page while more rows follow.
+
+// Cursor is a position in created_at DESC, id DESC order: the last row of
+// the previous page
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// EncodeCursor returns the opaque cursor of the row with createdAt and id
+func EncodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor. An empty value
+// returns nil; a malformed one an error wrapping ErrValidation.
+func DecodeCursor(value string) (*Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	cursor := &Cursor{ID: id}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	return cursor, nil
+}
+
+// Condition returns the condition selecting the rows after c, with
+// placeholders from argIndex. A nil cursor returns an empty condition.
+func (c *Cursor) Condition(createdAtColumn, idColumn string, argIndex int) (string, []interface{}) {
+	if c == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("(%s, %s) < ($%d, $%d)", createdAtColumn, idColumn, argIndex, argIndex+1),
+		[]interface{}{c.CreatedAt, c.ID}
+}
+
+// PageClause returns the ORDER BY and LIMIT clause of a page, with
+// placeholders from argIndex. Without a cursor the page starts at offset.
+// One row more than pageSize is fetched so NextPage can tell whether more
+// rows follow.
+func PageClause(createdAtColumn, idColumn string, cursor *Cursor, pageSize, offset, argIndex int) (string, []interface{}) {
+	clause := fmt.Sprintf(" ORDER BY %s DESC, %s DESC LIMIT $%d", createdAtColumn, idColumn, argIndex)
+	if cursor != nil {
+		return clause, []interface{}{pageSize + 1}
+	}
+	return clause + fmt.Sprintf(" OFFSET $%d", argIndex+1), []interface{}{pageSize + 1, offset}
+}
+
+// NextPage drops the extra row fetched for a PageClause and returns the
+// cursor of the next page, empty on the last page
+func NextPage[T any](items []T, pageSize int, key func(T) (time.Time, string)) ([]T, string) {
+	if len(items) <= pageSize {
+		return items, ""
+	}
+	items = items[:pageSize]
+	createdAt, id := key(items[pageSize-1])
+	return items, EncodeCursor(createdAt, id)
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 11, 4, 10, 0, 0, 123456000, time.UTC)
+	id := "5f2b9a64-1c1e-4c51-9a59-0f7f3f0e6c21"
+
+	cursor, err := DecodeCursor(EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("Expected the cursor to decode, got %v", err)
+	}
+	if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Errorf("Expected %s/%s, got %+v", createdAt, id, cursor)
+	}
+
+	if cursor, err := DecodeCursor(""); cursor != nil || err != nil {
+		t.Errorf("Expected no cursor for an empty value, got %+v, %v", cursor, err)
+	}
+
+	for _, value := range []string{"not base64!", EncodeCursor(createdAt, "not-a-uuid"), "bm8tc2VwYXJhdG9y"} {
+		if _, err := DecodeCursor(value); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected a validation error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestPageClause(t *testing.T) {
+	clause, args := PageClause("c.created_at", "c.id", nil, 20, 40, 3)
+	if clause != " ORDER BY c.created_at DESC, c.id DESC LIMIT $3 OFFSET $4" || len(args) != 2 || args[0] != 21 || args[1] != 40 {
+		t.Errorf("Expected an offset page fetching one extra row, got %q %v", clause, args)
+	}
+
+	cursor := &Cursor{CreatedAt: time.Now(), ID: "5f2b9a64-1c1e-4c51-9a59-0f7f3f0e6c21"}
+	condition, conditionArgs := cursor.Condition("c.created_at", "c.id", 3)
+	if condition != "(c.created_at, c.id) < ($3, $4)" || len(conditionArgs) != 2 {
+		t.Errorf("Expected a keyset condition, got %q %v", condition, conditionArgs)
+	}
+	clause, args = PageClause("c.created_at", "c.id", cursor, 20, 0, 5)
+	if clause != " ORDER BY c.created_at DESC, c.id DESC LIMIT $5" || len(args) != 1 {
+		t.Errorf("Expected a cursor page without offset, got %q %v", clause, args)
+	}
+
+	var none *Cursor
+	if condition, _ := none.Condition("created_at", "id", 1); condition != "" {
+		t.Errorf("Expected no condition without a cursor, got %q", condition)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	type row struct {
+		id        string
+		createdAt time.Time
+	}
+	now := time.Now().UTC()
+	rows := []row{{"a", now}, {"b", now.Add(-time.Second)}, {"c", now.Add(-2 * time.Second)}}
+	key := func(r row) (time.Time, string) { return r.createdAt, r.id }
+
+	page, next := NextPage(rows, 2, key)
+	if len(page) != 2 || next != EncodeCursor(rows[1].createdAt, "b") {
+		t.Errorf("Expected two rows and a cursor after the second, got %d rows, %q", len(page), next)
+	}
+
+	page, next = NextPage(rows, 3, key)
+	if len(page) != 3 || next != "" {
+		t.Errorf("Expected the last page without a cursor, got %d rows, %q", len(page), next)
+	}
+}
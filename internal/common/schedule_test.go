@@ -0,0 +1,49 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2025, 11, 4, 10, 30, 15, 0, time.UTC) // a Tuesday
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 2 * * *", time.Date(2025, 11, 5, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 11, 4, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 11, 5, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2025, 11, 4, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 11, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 3 *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// A restricted day of month and day of week match either
+		{"0 0 13 * 5", time.Date(2025, 11, 7, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("Expected %q to parse, got %v", tt.expr, err)
+		}
+		if next := schedule.Next(from); !next.Equal(tt.expected) {
+			t.Errorf("Expected %q to run next at %s, got %s", tt.expr, tt.expected, next)
+		}
+	}
+
+	never, _ := ParseSchedule("0 0 30 2 *")
+	if next := never.Next(from); !next.IsZero() {
+		t.Errorf("Expected February 30th never to match, got %s", next)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, expr := range []string{"", "0 2 * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(expr); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected a validation error for %q, got %v", expr, err)
+		}
+	}
+}
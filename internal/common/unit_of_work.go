@@ -0,0 +1,92 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key of the transaction of a unit of work
+type txKey struct{}
+
+// TxRunner runs a function atomically. Stores taking part read their
+// connection with Conn or WithinTx, so their statements join the transaction
+// carried by the function's context.
+type TxRunner interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UnitOfWork is a TxRunner on top of a database. A nil UnitOfWork runs
+// functions without a transaction, for services wired without a database.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates a unit of work on db
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// RunInTx runs fn in a transaction, committed when fn returns nil and rolled
+// back otherwise. Inside another unit of work fn joins the outer transaction.
+func (u *UnitOfWork) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if u == nil || u.db == nil {
+		return fn(ctx)
+	}
+	return WithinTx(ctx, u.db, func(ctx context.Context, tx DBTX) error {
+		return fn(ctx)
+	})
+}
+
+// RunInTx runs fn in runner's transaction, or directly when runner is nil
+func RunInTx(ctx context.Context, runner TxRunner, fn func(ctx context.Context) error) error {
+	if runner == nil {
+		return fn(ctx)
+	}
+	return runner.RunInTx(ctx, fn)
+}
+
+// WithinTx runs fn in the transaction ctx carries or, outside a unit of work,
+// in a new transaction on db. Stores that need several statements to apply
+// together use it instead of db.BeginTx so they can join a larger unit.
+func WithinTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx DBTX) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx, tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx), tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Conn returns the transaction ctx carries, or db outside a unit of work
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// InTx reports whether ctx carries the transaction of a unit of work
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
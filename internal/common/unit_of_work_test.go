@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// txCounts counts the transactions of the countingDriver
+type txCounts struct {
+	mu                         sync.Mutex
+	begins, commits, rollbacks int
+	execs                      int
+}
+
+// countingDriver is a database/sql driver whose connections only count
+// transactions and statements
+type countingDriver struct {
+	counts *txCounts
+}
+
+func (d countingDriver) Open(name string) (driver.Conn, error) {
+	return countingConn{d.counts}, nil
+}
+
+type countingConn struct {
+	counts *txCounts
+}
+
+func (c countingConn) Prepare(query string) (driver.Stmt, error) {
+	return countingStmt{c.counts}, nil
+}
+
+func (c countingConn) Close() error {
+	return nil
+}
+
+func (c countingConn) Begin() (driver.Tx, error) {
+	c.counts.mu.Lock()
+	defer c.counts.mu.Unlock()
+	c.counts.begins++
+	return countingTx{c.counts}, nil
+}
+
+type countingTx struct {
+	counts *txCounts
+}
+
+func (t countingTx) Commit() error {
+	t.counts.mu.Lock()
+	defer t.counts.mu.Unlock()
+	t.counts.commits++
+	return nil
+}
+
+func (t countingTx) Rollback() error {
+	t.counts.mu.Lock()
+	defer t.counts.mu.Unlock()
+	t.counts.rollbacks++
+	return nil
+}
+
+type countingStmt struct {
+	counts *txCounts
+}
+
+func (s countingStmt) Close() error {
+	return nil
+}
+
+func (s countingStmt) NumInput() int {
+	return -1
+}
+
+func (s countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.counts.mu.Lock()
+	defer s.counts.mu.Unlock()
+	s.counts.execs++
+	return driver.RowsAffected(1), nil
+}
+
+func (s countingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func newCountingDB(t *testing.T) (*sql.DB, *txCounts) {
+	counts := &txCounts{}
+	db := sql.OpenDB(connector{counts})
+	t.Cleanup(func() { db.Close() })
+	return db, counts
+}
+
+// connector opens countingConns sharing the counts of one test
+type connector struct {
+	counts *txCounts
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return countingConn{c.counts}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return countingDriver{c.counts}
+}
+
+func TestUnitOfWork_CommitsAndRollsBack(t *testing.T) {
+	db, counts := newCountingDB(t)
+	uow := NewUnitOfWork(db)
+
+	err := uow.RunInTx(context.Background(), func(ctx context.Context) error {
+		if !InTx(ctx) {
+			t.Error("Expected context to carry the transaction")
+		}
+		_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE payments SET status = 'completed'")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts.begins != 1 || counts.commits != 1 || counts.rollbacks != 0 || counts.execs != 1 {
+		t.Errorf("Expected one committed transaction, got %+v", counts)
+	}
+
+	failure := errors.New("quota update failed")
+	err = uow.RunInTx(context.Background(), func(ctx context.Context) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the function's error, got %v", err)
+	}
+	if counts.begins != 2 || counts.commits != 1 || counts.rollbacks != 1 {
+		t.Errorf("Expected the second transaction rolled back, got %+v", counts)
+	}
+}
+
+func TestUnitOfWork_NestedCallsJoin(t *testing.T) {
+	db, counts := newCountingDB(t)
+	uow := NewUnitOfWork(db)
+
+	err := uow.RunInTx(context.Background(), func(ctx context.Context) error {
+		return WithinTx(ctx, db, func(ctx context.Context, tx DBTX) error {
+			if _, ok := tx.(*sql.Tx); !ok {
+				t.Errorf("Expected the outer transaction, got %T", tx)
+			}
+			return uow.RunInTx(ctx, func(ctx context.Context) error {
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts.begins != 1 || counts.commits != 1 {
+		t.Errorf("Expected nested units to share one transaction, got %+v", counts)
+	}
+}
+
+func TestRunInTx_WithoutRunner(t *testing.T) {
+	db, counts := newCountingDB(t)
+	ran := false
+	err := RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		ran = true
+		if InTx(ctx) {
+			t.Error("Expected no transaction without a runner")
+		}
+		if _, ok := Conn(ctx, db).(*sql.DB); !ok {
+			t.Error("Expected the database outside a unit of work")
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("Expected function to run, got %v", err)
+	}
+
+	var uow *UnitOfWork
+	if err := uow.RunInTx(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected nil unit of work to run the function, got %v", err)
+	}
+	if counts.begins != 0 {
+		t.Errorf("Expected no transaction, got %d", counts.begins)
+	}
+}
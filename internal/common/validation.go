@@ -0,0 +1,165 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Requests declare their rules with `binding` struct tags, checked by the
+// go-playground validator Gin uses, plus an optional Validate method for rules
+// tags cannot express. Failures are returned as ValidationErrors keyed by the
+// field's JSON (or query, path, header) name.
+
+// phonePattern matches international phone numbers such as +989123456789
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(requestFieldName)
+	if err := v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(strings.TrimSpace(fl.Field().String()))
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// BindJSON binds the JSON body of a Gin request into req and validates it
+func BindJSON(c *gin.Context, req interface{}) error {
+	if err := c.ShouldBindWith(req, binding.JSON); err != nil {
+		return bindingError(err, "invalid request body")
+	}
+	return validateCustom(req)
+}
+
+// BindQuery binds the query string of a Gin request into req and validates it
+func BindQuery(c *gin.Context, req interface{}) error {
+	if err := c.ShouldBindWith(req, binding.Query); err != nil {
+		return bindingError(err, "invalid query parameter")
+	}
+	return validateCustom(req)
+}
+
+// DecodeJSON decodes the JSON body of a net/http request into req and validates it
+func DecodeJSON(r *http.Request, req interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		return NewAPIError(http.StatusBadRequest, "", "invalid request body", nil)
+	}
+	return ValidateRequest(req)
+}
+
+// ValidateRequest checks req against its binding tags and Validate method
+func ValidateRequest(req interface{}) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return validationError(err)
+	}
+	return validateCustom(req)
+}
+
+// validateCustom runs the request's Validate method, if it has one
+func validateCustom(req interface{}) error {
+	v, ok := req.(Validatable)
+	if !ok {
+		return nil
+	}
+	err := v.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *APIError
+	var validationErrs ValidationErrors
+	if errors.As(err, &apiErr) || errors.As(err, &validationErrs) || errors.Is(err, ErrValidation) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrValidation, err)
+}
+
+// bindingError converts a Gin binding failure into a validation or bad request error
+func bindingError(err error, message string) error {
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return validationError(err)
+	}
+	return NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s: %v", message, err), nil)
+}
+
+// validationError converts binding tag failures into ValidationErrors
+func validationError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	var result ValidationErrors
+	for _, fieldErr := range fieldErrs {
+		result.Add(fieldErr.Field(), validationMessage(fieldErr), fmt.Sprint(fieldErr.Value()))
+	}
+	return result
+}
+
+// validationMessage describes a failed rule in words
+func validationMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", param, unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", param, unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", param, unit)
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "numeric":
+		return "must contain only digits"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "datetime":
+		return "must be a date in the format " + param
+	case "phone":
+		return "must be a phone number in international format, e.g. +989123456789"
+	}
+	return fmt.Sprintf("failed on the '%s' rule", fieldErr.Tag())
+}
+
+// requestFieldName names a field after the JSON, query, path or header key
+// clients send it as
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
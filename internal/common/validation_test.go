@@ -0,0 +1,146 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type signupRequest struct {
+	Phone string `json:"phone" binding:"required,phone"`
+	Role  string `json:"role" binding:"required,oneof=user vendor"`
+	Code  string `json:"code" binding:"omitempty,len=6,numeric"`
+}
+
+type listRequest struct {
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Status string `form:"status" binding:"omitempty,oneof=pending completed"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedFields []string
+	}{
+		{
+			name:           "valid request",
+			body:           `{"phone":"+989123456789","role":"user","code":"123456"}`,
+			expectedStatus: 0,
+		},
+		{
+			name:           "field errors",
+			body:           `{"phone":"09123456789","role":"admin","code":"12ab"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   ErrCodeValidation,
+			expectedFields: []string{"phone", "role", "code"},
+		},
+		{
+			name:           "empty body",
+			body:           ``,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   ErrCodeValidation,
+			expectedFields: []string{"phone", "role"},
+		},
+		{
+			name:           "invalid json",
+			body:           `{"phone":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   ErrCodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tt.body))
+
+			var req signupRequest
+			err := DecodeJSON(r, &req)
+			if tt.expectedStatus == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			apiErr := FromError(err, http.StatusInternalServerError)
+			if apiErr.Status != tt.expectedStatus || apiErr.Code != tt.expectedCode {
+				t.Fatalf("Expected %d %s, got %d %s", tt.expectedStatus, tt.expectedCode, apiErr.Status, apiErr.Code)
+			}
+			assertValidationFields(t, apiErr, tt.expectedFields)
+		})
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/items", func(c *gin.Context) {
+		var req listRequest
+		if err := BindQuery(c, &req); err != nil {
+			RespondErr(c, http.StatusBadRequest, err)
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?page=2&status=pending", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?page=-1&status=unknown", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				ValidationErrors []ValidationError `json:"validation_errors"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Error.Code != ErrCodeValidation {
+		t.Errorf("Expected code %s, got %s", ErrCodeValidation, body.Error.Code)
+	}
+	if len(body.Error.Details.ValidationErrors) != 2 {
+		t.Fatalf("Expected 2 field errors, got %+v", body.Error.Details.ValidationErrors)
+	}
+	if got := body.Error.Details.ValidationErrors[1]; got.Field != "status" || got.Message != "must be one of: pending, completed" {
+		t.Errorf("Expected status oneof error, got %+v", got)
+	}
+}
+
+func assertValidationFields(t *testing.T, apiErr *APIError, fields []string) {
+	t.Helper()
+	if len(fields) == 0 {
+		return
+	}
+
+	details, ok := apiErr.Details.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected validation details, got %#v", apiErr.Details)
+	}
+	errs, _ := details["validation_errors"].([]ValidationError)
+	if len(errs) != len(fields) {
+		t.Fatalf("Expected %d field errors, got %+v", len(fields), errs)
+	}
+	for i, field := range fields {
+		if errs[i].Field != field {
+			t.Errorf("Expected error %d on %s, got %s", i, field, errs[i].Field)
+		}
+	}
+}
@@ -1,25 +1,66 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database   DatabaseConfig
-	Server     ServerConfig
-	JWT        JWTConfig
-	Redis      RedisConfig
-	SMS        SMSConfig
-	Security   SecurityConfig
-	RateLimit  RateLimitConfig
-	Storage    StorageConfig
-	Monitoring MonitoringConfig
-	Gemini     GeminiConfig
-	BazaarPay  BazaarPayConfig
+	Database        DatabaseConfig
+	Server          ServerConfig
+	JWT             JWTConfig
+	Redis           RedisConfig
+	SMS             SMSConfig
+	Security        SecurityConfig
+	RateLimit       RateLimitConfig
+	Storage         StorageConfig
+	Monitoring      MonitoringConfig
+	Gemini          GeminiConfig
+	Moderation      ModerationConfig
+	Onboarding      OnboardingConfig
+	Outbox          OutboxConfig
+	Quota           QuotaConfig
+	ConversionLog   ConversionLogConfig
+	ConversionRetry ConversionRetryConfig
+	ConversionOutfit ConversionOutfitConfig
+	ConversionSchedule ConversionScheduleConfig
+	ConversionExport ConversionExportConfig
+	UserDataExport  UserDataExportConfig
+	PaymentExport   PaymentExportConfig
+	ImageTrash      ImageTrashConfig
+	ImageDedup      ImageDedupConfig
+	ImageUpload     ImageUploadConfig
+	ImageImport     ImageImportConfig
+	BazaarPay       BazaarPayConfig
+	PlanTrial       PlanTrialConfig
+	CouponExpiry    CouponExpiryConfig
+	Email           EmailConfig
+	Digest          NotificationDigestConfig
+	NotifyQueue     NotificationQueueConfig
+	NotifyTemplates NotificationTemplatesConfig
+	Push            PushConfig
+	WorkerQueue     WorkerQueueConfig
+	APIKey          APIKeyConfig
+	InternalAPI     InternalAPIConfig
+
+	SystemSettings  SystemSettingsConfig
+	FeatureFlags    FeatureFlagsConfig
+	InputCheck      InputCheckConfig
+	AdminActivity   AdminActivityConfig
+	PromptTemplates PromptTemplatesConfig
+	Support         SupportConfig
+	Reputation      ReputationConfig
+	Verification    VerificationConfig
+	LookupCache     LookupCacheConfig
+
+	// envErrors are the malformed values Load replaced with their defaults
+	envErrors []error
 }
 
 type DatabaseConfig struct {
@@ -31,17 +72,36 @@ type DatabaseConfig struct {
 	SSLMode       string
 	AutoMigrate   bool   // Automatically run migrations on startup
 	MigrationsDir string // Path to migrations directory
+
+	MigrationPhase            string        // pre-deploy, post-deploy or all
+	MigrationLockTimeout      time.Duration // lock_timeout applied to each migration
+	MigrationStatementTimeout time.Duration // statement_timeout applied to each migration
+
+	ReadReplicaDSNs      []string      // optional replicas serving heavy admin reads
+	ReplicaMaxLag        time.Duration // replicas further behind are skipped
+	ReplicaCheckInterval time.Duration // how often replica health and lag are measured
+
+	QueryMetricsEnabled       bool          // record per-statement latency and log slow queries
+	SlowQueryThreshold        time.Duration // queries taking longer are logged
+	QueryMetricsMaxStatements int           // distinct statements tracked per module
 }
 
 type ServerConfig struct {
-	HTTPAddr string
-	GinMode  string
+	HTTPAddr      string
+	GinMode       string
+	PublicBaseURL string // scheme and host clients reach the API at, used in absolute links
 }
 
 type JWTConfig struct {
-	Secret     string
-	AccessTTL  time.Duration
-	RefreshTTL time.Duration
+	Secret           string
+	AccessTTL        time.Duration
+	RefreshTTL       time.Duration
+	ImpersonationTTL time.Duration
+
+	Algorithm           string        // HS256, RS256 or EdDSA; used with key rotation
+	KeyRotationEnabled  bool          // sign with rotating keys from the database instead of the secret
+	KeyRotationInterval time.Duration // how long a signing key is used before the next one takes over
+	KeyRefreshInterval  time.Duration // how often instances reload keys and check rotation
 }
 
 type RedisConfig struct {
@@ -56,6 +116,29 @@ type SMSConfig struct {
 	APIKey         string
 	TemplateID     int
 	ParameterName  string // Parameter name used in the SMS template (e.g., "Code", "VERIFY")
+
+	// OTP delivery fallback chain, tried in order when the provider above fails
+	FallbackProviders []string      // sms_ir, kavenegar, kavenegar_voice, ghasedak, ghasedak_voice, twilio or telegram
+	ProviderTimeout   time.Duration // how long to wait for one provider before trying the next
+	KavenegarAPIKey   string
+	KavenegarTemplate string
+	GhasedakAPIKey    string
+	GhasedakTemplate  string
+
+	// Twilio, for international numbers
+	TwilioAccountSID        string
+	TwilioAuthToken         string // also verifies Twilio's delivery reports
+	TwilioFrom              string // sending number or Messaging Service SID
+	TwilioStatusCallbackURL string // public URL of /api/webhooks/sms/twilio, empty for no reports
+
+	// Providers by country calling code, e.g. 1=twilio, or *=twilio for every
+	// foreign country; other numbers go through the provider and fallbacks above
+	CountryProviders []string
+
+	// Delivery report webhooks, a provider's reports are rejected while its secret is unset
+	SMSIrWebhookSecret     string
+	KavenegarWebhookSecret string
+	GhasedakWebhookSecret  string
 }
 
 type SecurityConfig struct {
@@ -65,6 +148,60 @@ type SecurityConfig struct {
 	Argon2Parallelism uint8
 	Argon2SaltLength  uint32
 	Argon2KeyLength   uint32
+
+	// Admin two-factor authentication (TOTP)
+	AdminTwoFactorRequired bool
+	TOTPIssuer             string
+
+	// Admin roles: admins without an assigned role get AdminDefaultRole, and
+	// an admin's permissions are cached for AdminPermissionCacheTTL
+	AdminDefaultRole        string
+	AdminPermissionCacheTTL time.Duration
+
+	// Telegram account linking
+	TelegramBotAPIKey   string // shared with the bot (API_KEY_FOR_BOT); empty disables linking
+	TelegramBotUsername string // builds t.me/<bot>?start=link_<code> deep links
+	TelegramLinkCodeTTL time.Duration
+
+	// Alerts for sign-ins from a new device or country, with a one-click
+	// link that revokes the new session
+	LoginAlertsEnabled   bool
+	SessionRevokeURL     string // public URL of GET /auth/sessions/revoke
+	SessionRevokeLinkTTL time.Duration
+
+	// CORS and response security headers
+	CORSAllowedOrigins    []string // "*" allows any origin without credentials
+	CORSAllowedMethods    []string
+	CORSAllowedHeaders    []string
+	CORSAllowCredentials  bool
+	CORSMaxAge            time.Duration
+	HSTSMaxAge            time.Duration // 0 disables Strict-Transport-Security
+	HSTSIncludeSubdomains bool
+	ContentSecurityPolicy string
+
+	// IP block-list and country restrictions. GeoIPPath is an extracted
+	// GeoLite2 Country CSV directory or a network,country CSV file; without it
+	// the country lists are not enforced.
+	GeoIPPath                  string
+	GeoIPAllowedCountries      []string
+	GeoIPDeniedCountries       []string
+	IPBlockExempt              []string
+	IPAutoBlockThreshold       int // 0 disables automatic blocking
+	IPAutoBlockWindow          time.Duration
+	IPAutoBlockDuration        time.Duration
+	IPBlockListRefreshInterval time.Duration
+
+	// OTP hardening. The wait before another code can be sent starts at
+	// OTPResendCooldown and doubles with each resend up to OTPMaxResendCooldown;
+	// a phone that sent nothing for OTPResendResetAfter starts over. A phone
+	// with OTPMaxFailures failed verifications within OTPFailureWindow is
+	// locked for OTPLockoutDuration. OTPMaxFailures 0 disables the guard.
+	OTPResendCooldown    time.Duration
+	OTPMaxResendCooldown time.Duration
+	OTPResendResetAfter  time.Duration
+	OTPMaxFailures       int
+	OTPFailureWindow     time.Duration
+	OTPLockoutDuration   time.Duration
 }
 
 type RateLimitConfig struct {
@@ -79,6 +216,54 @@ type StorageConfig struct {
 	UploadMaxSize string
 	StoragePath   string
 	SignedURLTTL  time.Duration
+
+	// URL signing keys as comma separated id:secret pairs. New URLs are signed
+	// with SigningKeyID (the first key by default); every listed key verifies.
+	SigningKeys          string
+	SigningKeyID         string
+	SignedURLDownloadTTL time.Duration
+
+	// Backend the worker reads input images from: local or s3
+	Backend     string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// Background WebP/AVIF and responsive width variants of result images
+	ImageVariantsEnabled bool
+	ImageVariantWidths   []string
+	ImageVariantFormats  []string
+	ImageVariantQuality  int
+	ImageVariantWorkers  int
+
+	// CDN serving public images; CDNProvider (cloudflare or bunny) enables
+	// purging deleted and replaced files from the CDN cache
+	CDNBaseURL            string
+	CDNProvider           string
+	CDNCloudflareZoneID   string
+	CDNCloudflareAPIToken string
+	CDNBunnyAPIKey        string
+	CDNPurgeTimeout       time.Duration
+
+	// Logo overlaid on results of plans without watermark_removal; when
+	// empty the watermark_text system setting is drawn instead
+	WatermarkLogoPath string
+
+	// Scheduled tar.gz snapshots of StoragePath; BackupFrequency is daily,
+	// weekly or monthly
+	BackupEnabled       bool
+	BackupFrequency     string
+	BackupRetentionDays int
+
+	// Cleanup of unreferenced image files, direct upload sessions, expired
+	// conversion and user data exports and share links, on the CleanupSchedule cron
+	// expression. Files younger than CleanupOrphanGrace are never removed.
+	CleanupEnabled      bool
+	CleanupSchedule     string
+	CleanupUploadMaxAge time.Duration
+	CleanupOrphanGrace  time.Duration
 }
 
 type MonitoringConfig struct {
@@ -89,6 +274,39 @@ type MonitoringConfig struct {
 	Environment      string
 	Version          string
 	HealthEnabled    bool
+
+	// Readiness probes; the storage and AI provider results are cached so
+	// frequent load balancer probes do not write to storage or call the provider
+	HealthCheckTimeout    time.Duration // bounds every dependency probe
+	HealthStorageCacheTTL time.Duration
+	HealthAICacheTTL      time.Duration
+
+	// Log redaction and request log sampling
+	LogRedactFields []string           // field names removed from logs and Sentry events, on top of the defaults
+	LogSampleRates  map[string]float64 // path prefix -> share of successful requests logged
+
+	// Audit log forwarding to a SIEM
+	AuditForwardEnabled       bool
+	AuditForwardTarget        string // syslog or http
+	AuditForwardSyslogNetwork string
+	AuditForwardSyslogAddress string
+	AuditForwardHTTPURL       string
+	AuditForwardHTTPToken     string
+	AuditForwardBatchSize     int
+	AuditForwardInterval      time.Duration
+
+	// Audit log retention; expired entries are archived to object storage
+	AuditRetentionDays     int // 0 keeps audit logs forever
+	AuditRetentionInterval time.Duration
+	AuditArchiveBatchSize  int
+	AuditArchivePrefix     string
+
+	// OpenTelemetry tracing exported over OTLP/HTTP
+	TracingEnabled     bool
+	OTLPEndpoint       string // host:port or URL of the collector
+	OTLPInsecure       bool
+	TracingSampleRatio float64
+	TracingServiceName string
 }
 
 type GeminiConfig struct {
@@ -99,6 +317,164 @@ type GeminiConfig struct {
 	MaxRetries           int
 	PreprocessNoiseLevel float64
 	PreprocessJpegQuality int
+
+	// Retry/backoff and circuit breaker
+	RetryBaseDelayMs        int
+	RetryMaxDelayMs         int
+	AttemptTimeout          int
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      int
+
+	// Monthly budget guardrails (MonthlyBudget 0 disables them)
+	MonthlyBudget             float64
+	CostPerConversion         float64
+	BudgetAlertThreshold      float64
+	BudgetDegradeMode         string
+	BudgetLowResMaxSide       int
+	FallbackModel             string
+	FallbackCostPerConversion float64
+
+	// Per-conversion cost accounting. With no token or image price set, every
+	// conversion costs CostPerConversion.
+	InputTokenPrice  float64 // per million prompt tokens
+	OutputTokenPrice float64 // per million output tokens
+	ImagePrice       float64 // per generated image
+	DailyBudget      float64 // daily spend that triggers a Telegram alert; 0 disables it
+
+	// Model served to the users the new_provider feature flag selects
+	CandidateModel string
+}
+
+type ModerationConfig struct {
+	Enabled       bool
+	Provider      string // local or api
+	APIURL        string
+	APIKey        string
+	Timeout       int
+	NSFWThreshold float64
+	RequireFace   bool
+	MaxFaces      int
+	FailOpen      bool
+}
+
+// InputCheckConfig configures the worker pre-check of conversion inputs
+type InputCheckConfig struct {
+	Enabled         bool
+	Provider        string // local or api
+	APIURL          string
+	APIKey          string
+	Timeout         int
+	MinUserSide     int     // pixels
+	MinGarmentSide  int     // pixels
+	MinPersonRatio  float64 // share of the photo height the person must span
+	MinGarmentRatio float64 // share of the photo area the garment must cover
+	MaxPeople       int     // 0 = no limit
+	FailOpen        bool
+}
+
+type OnboardingConfig struct {
+	Enabled                 bool
+	SignupCredits           int  // free conversions granted at signup
+	FirstConversionFree     bool // first conversion bypasses quota regardless of plan
+	FirstConversionPriority int  // worker job priority of the first conversion
+}
+
+type OutboxConfig struct {
+	Enabled        bool
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	RetryDelay     time.Duration
+	MaxRetryDelay  time.Duration
+	WebhookURLs    string // comma separated
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+type QuotaConfig struct {
+	Enabled bool // charge conversions to the user's plan in the quota middleware
+}
+
+type ConversionLogConfig struct {
+	Enabled          bool
+	MaxPerConversion int           // older entries of a conversion are dropped beyond this cap
+	Retention        time.Duration // entries older than this are pruned by the worker cleanup loop
+}
+
+type ConversionRetryConfig struct {
+	MaxRetries int // retries a user may request per failed conversion
+}
+
+type ConversionOutfitConfig struct {
+	MaxGarments int // garments a single conversion may try on, 1 disables outfits
+}
+
+type ConversionScheduleConfig struct {
+	Enabled      bool
+	MaxAhead     time.Duration // how far in the future a conversion may be scheduled
+	PollInterval time.Duration // how often due scheduled conversions are enqueued
+	BatchSize    int           // due conversions enqueued per claim
+}
+
+type ConversionExportConfig struct {
+	Enabled        bool
+	DownloadURL    string        // public URL of the signed export download route
+	Retention      time.Duration // how long a ready export can be downloaded
+	MaxConversions int           // newest conversions included in an export
+	MaxSizeMB      int           // result images stop being added past this size
+	PollInterval   time.Duration // how often the worker looks for pending exports
+}
+
+type UserDataExportConfig struct {
+	Enabled      bool
+	DownloadURL  string        // public URL of the signed data export download route
+	Retention    time.Duration // how long a ready export can be downloaded
+	PollInterval time.Duration // how often the worker looks for pending exports
+}
+
+// PaymentExportConfig configures the admin export of payments for accounting.
+// Ranges up to StreamMaxDays are streamed, longer ones are built in the
+// background.
+type PaymentExportConfig struct {
+	Timezone      string        // days of the export and its daily totals
+	StreamMaxDays int           // longest range streamed as a direct download
+	MaxDays       int           // longest range that can be exported
+	Retention     time.Duration // how long a background export can be downloaded
+	PollInterval  time.Duration // how often the worker looks for pending exports
+}
+
+// LookupCacheConfig configures the cache of lookups made on most requests,
+// kept per request and in Redis. A TTL of 0 stops caching that entity.
+type LookupCacheConfig struct {
+	Enabled                bool
+	UserPlanTTL            time.Duration // the user's active plan
+	QuotaStatusTTL         time.Duration // the user's remaining conversions
+	VendorProfileTTL       time.Duration // vendor profiles
+	UnreadNotificationsTTL time.Duration // the user's unread notification count
+}
+
+type ImageTrashConfig struct {
+	Enabled       bool
+	RestoreWindow time.Duration // deleted vendor images can be restored for this long
+	PurgeInterval time.Duration // how often images past the window are hard-deleted
+}
+
+type ImageDedupConfig struct {
+	Enabled bool // re-uploads of an owner's image return the existing image
+}
+
+type ImageUploadConfig struct {
+	DirectEnabled bool          // clients may upload to pre-signed storage URLs
+	URLTTL        time.Duration // how long a pre-signed upload URL stays valid
+	PurgeInterval time.Duration // how often unconfirmed expired uploads are deleted
+}
+
+type ImageImportConfig struct {
+	Enabled          bool
+	MaxFiles         int           // files accepted per import
+	MaxArchiveSizeMB int           // largest accepted ZIP archive
+	FetchTimeout     time.Duration // timeout of each URL download
+	PollInterval     time.Duration // how often the worker looks for queued imports
 }
 
 type BazaarPayConfig struct {
@@ -107,7 +483,147 @@ type BazaarPayConfig struct {
 	RedirectURL string
 }
 
+type PlanTrialConfig struct {
+	Enabled        bool
+	DefaultPlan    string        // plan an expired trial falls back to, empty for none
+	ExpiryInterval time.Duration // how often expired trials are collected
+	BatchSize      int           // trials expired per query
+}
+
+type CouponExpiryConfig struct {
+	Interval  time.Duration // how often coupons held by expired payments are released
+	BatchSize int           // payments expired per query
+}
+
+type NotificationDigestConfig struct {
+	Enabled   bool          // users may receive low-priority notifications as hourly or daily digests
+	Interval  time.Duration // how often due digests are sent
+	BatchSize int           // digests sent per dispatch
+}
+
+type NotificationQueueConfig struct {
+	Enabled      bool               // channel deliveries go through bounded queues instead of a goroutine each
+	Size         int                // deliveries waiting per channel
+	Workers      int                // concurrent sends per channel
+	EnqueueWait  time.Duration      // a full queue blocks this long before the delivery fails
+	Rates        map[string]float64 // provider calls per second by channel (email, sms, telegram, push); missing is unthrottled
+	SMSBatchSize int                // SMS sent per provider call where the provider supports batches
+}
+
+type NotificationTemplatesConfig struct {
+	CacheTTL time.Duration // how long each instance caches the templates admins manage
+}
+
+type PushConfig struct {
+	FCMProjectID       string        // defaults to the project of the service account
+	FCMCredentialsFile string        // service account key JSON; empty disables push notifications
+	FCMAPIURL          string        // FCM API base URL
+	Timeout            time.Duration // per FCM request
+	DeviceStaleAfter   time.Duration // devices not seen this long are pruned
+	PruneInterval      time.Duration // how often stale devices are pruned
+}
+
+type WorkerQueueConfig struct {
+	AgingInterval time.Duration // a pending job gains one priority level per interval; 0 disables aging
+	AgingMaxBoost int           // cap on the priority levels gained by aging
+	DrainTimeout  time.Duration // on shutdown, in-flight jobs get this long to finish before they are requeued
+
+	HeartbeatInterval time.Duration // workers record their status for the admin worker status this often
+}
+
+// InternalAPIConfig configures the gRPC API used by internal services such
+// as the Telegram bot. Clients authenticate with a certificate signed by
+// ClientCAFile (mutual TLS).
+type InternalAPIConfig struct {
+	Enabled        bool
+	Addr           string
+	CertFile       string   // server certificate
+	KeyFile        string   // server private key
+	ClientCAFile   string   // CA that signs client certificates
+	AllowedClients []string // client certificate common names; empty allows any client the CA signed
+	MaxMessageSize int      // bytes; bounds image uploads
+}
+
+type SystemSettingsConfig struct {
+	RefreshInterval       time.Duration // settings are reloaded this often even without a change announced over Redis
+	MaintenanceAllowedIPs []string      // IPs or CIDR ranges that bypass maintenance mode
+}
+
+type FeatureFlagsConfig struct {
+	CacheTTL time.Duration // how long flags and user plans are cached for evaluation
+}
+
+type PromptTemplatesConfig struct {
+	CacheTTL time.Duration // how long each instance caches the prompt templates conversions are made with
+}
+
+type SupportConfig struct {
+	MaxOpenTickets   int // tickets a user may have that are not closed
+	MaxMessageLength int // characters of a ticket message
+}
+
+type ReputationConfig struct {
+	Enabled           bool          // score signups and limit the free quota of risky ones
+	ASNPath           string        // extracted GeoLite2 ASN CSV directory; empty skips network signals
+	PhonePrefixDigits int           // leading phone number digits grouped as a prefix
+	Window            time.Duration // how far back phone prefix and ASN signups are counted
+	PrefixThreshold   int           // other signups of a phone prefix within Window that are suspicious
+	DeviceThreshold   int           // other accounts of a device that are suspicious
+	ASNThreshold      int           // other signups of an ASN within Window that are suspicious
+	RiskyASNs         []string      // hosting and VPN networks scored as risky
+	LimitScore        int           // score from which the free quota is limited
+	HoldScore         int           // score from which the free quota is held for review
+	LimitedCredits    int           // free conversions of limited accounts
+	ReleaseDelay      time.Duration // limited accounts get their full quota after this unless reviewed
+	ReleaseInterval   time.Duration // how often due accounts are released
+}
+
+type VerificationConfig struct {
+	Enabled           bool // let vendors apply for verification with business documents
+	MaxDocumentSizeMB int  // largest document a vendor may upload
+	MaxDocuments      int  // documents of one verification
+}
+
+type AdminActivityConfig struct {
+	Enabled        bool          // stream key events to admins over WebSocket
+	SpikeThreshold int           // failed conversions within SpikeWindow that raise a failure spike
+	SpikeWindow    time.Duration // window failed conversions are counted over
+}
+
+type APIKeyConfig struct {
+	MaxPerUser       int // active keys a user may hold
+	DefaultRateLimit int // requests per minute of a key created without a limit
+	MaxRateLimit     int // highest per-key limit a user may request
+}
+
+type EmailConfig struct {
+	Provider    string // smtp or sendgrid; empty disables email notifications
+	FromAddress string
+	FromName    string
+	Timeout     time.Duration
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPPoolSize int // idle SMTP connections kept open for reuse
+
+	SendGridAPIKey string
+	SendGridAPIURL string
+}
+
+// loadMu serializes Load so the malformed values recorded while it runs
+// belong to the config it returns
+var loadMu sync.Mutex
+
+// Load reads the configuration from the environment and the optional .env
+// file. Malformed values fall back to their defaults and are reported by
+// Validate, which callers run once at startup.
 func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	takeEnvErrors()
+
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		// .env file is optional, continue without it
@@ -123,15 +639,34 @@ func Load() (*Config, error) {
 			SSLMode:       getEnv("DB_SSLMODE", "disable"),
 			AutoMigrate:   getEnvAsBool("DB_AUTO_MIGRATE", true),
 			MigrationsDir: getEnv("DB_MIGRATIONS_DIR", "db/migrations"),
+
+			MigrationPhase:            getEnv("DB_MIGRATION_PHASE", "all"),
+			MigrationLockTimeout:      getEnvAsDuration("DB_MIGRATION_LOCK_TIMEOUT", 5*time.Second),
+			MigrationStatementTimeout: getEnvAsDuration("DB_MIGRATION_STATEMENT_TIMEOUT", 2*time.Minute),
+
+			ReadReplicaDSNs:      getEnvAsList("DB_READ_REPLICA_DSNS", nil),
+			ReplicaMaxLag:        getEnvAsDuration("DB_REPLICA_MAX_LAG", 10*time.Second),
+			ReplicaCheckInterval: getEnvAsDuration("DB_REPLICA_CHECK_INTERVAL", 15*time.Second),
+
+			QueryMetricsEnabled:       getEnvAsBool("DB_QUERY_METRICS_ENABLED", true),
+			SlowQueryThreshold:        getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+			QueryMetricsMaxStatements: getEnvAsInt("DB_QUERY_METRICS_MAX_STATEMENTS", 500),
 		},
 		Server: ServerConfig{
-			HTTPAddr: getEnv("HTTP_ADDR", ":8080"),
-			GinMode:  getEnv("GIN_MODE", "debug"),
+			HTTPAddr:      getEnv("HTTP_ADDR", ":8080"),
+			GinMode:       getEnv("GIN_MODE", "debug"),
+			PublicBaseURL: strings.TrimRight(getEnv("PUBLIC_BASE_URL", ""), "/"),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			AccessTTL:  getEnvAsDuration("JWT_ACCESS_TTL", 30*24*time.Hour),   // 30 days
-			RefreshTTL: getEnvAsDuration("JWT_REFRESH_TTL", 90*24*time.Hour), // 90 days
+			Secret:           getEnv("JWT_SECRET", defaultJWTSecret),
+			AccessTTL:        getEnvAsDuration("JWT_ACCESS_TTL", 30*24*time.Hour),       // 30 days
+			RefreshTTL:       getEnvAsDuration("JWT_REFRESH_TTL", 90*24*time.Hour),      // 90 days
+			ImpersonationTTL: getEnvAsDuration("JWT_IMPERSONATION_TTL", 15*time.Minute), // 15 minutes
+
+			Algorithm:           getEnv("JWT_ALGORITHM", "HS256"),
+			KeyRotationEnabled:  getEnvAsBool("JWT_KEY_ROTATION_ENABLED", true),
+			KeyRotationInterval: getEnvAsDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+			KeyRefreshInterval:  getEnvAsDuration("JWT_KEY_REFRESH_INTERVAL", 5*time.Minute),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -144,6 +679,24 @@ func Load() (*Config, error) {
 			APIKey:        getEnv("SMS_API_KEY", ""),
 			TemplateID:    getEnvAsInt("SMS_TEMPLATE_ID", 100000),
 			ParameterName: getEnv("SMS_PARAMETER_NAME", "Code"),
+
+			FallbackProviders: getEnvAsList("SMS_FALLBACK_PROVIDERS", nil),
+			ProviderTimeout:   getEnvAsDuration("SMS_PROVIDER_TIMEOUT", 10*time.Second),
+			KavenegarAPIKey:   getEnv("KAVENEGAR_API_KEY", ""),
+			KavenegarTemplate: getEnv("KAVENEGAR_TEMPLATE", "verify"),
+			GhasedakAPIKey:    getEnv("GHASEDAK_API_KEY", ""),
+			GhasedakTemplate:  getEnv("GHASEDAK_TEMPLATE", "verify"),
+
+			TwilioAccountSID:        getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:         getEnv("TWILIO_AUTH_TOKEN", ""),
+			TwilioFrom:              getEnv("TWILIO_FROM", ""),
+			TwilioStatusCallbackURL: getEnv("TWILIO_STATUS_CALLBACK_URL", ""),
+
+			CountryProviders: getEnvAsList("SMS_COUNTRY_PROVIDERS", nil),
+
+			SMSIrWebhookSecret:     getEnv("SMS_IR_WEBHOOK_SECRET", ""),
+			KavenegarWebhookSecret: getEnv("KAVENEGAR_WEBHOOK_SECRET", ""),
+			GhasedakWebhookSecret:  getEnv("GHASEDAK_WEBHOOK_SECRET", ""),
 		},
 		Security: SecurityConfig{
 			BCryptCost:        getEnvAsInt("BCRYPT_COST", 12),
@@ -152,6 +705,47 @@ func Load() (*Config, error) {
 			Argon2Parallelism: uint8(getEnvAsInt("ARGON2_PARALLELISM", 2)),
 			Argon2SaltLength:  uint32(getEnvAsInt("ARGON2_SALT_LENGTH", 16)),
 			Argon2KeyLength:   uint32(getEnvAsInt("ARGON2_KEY_LENGTH", 32)),
+
+			AdminTwoFactorRequired: getEnvAsBool("ADMIN_2FA_REQUIRED", true),
+			TOTPIssuer:             getEnv("TOTP_ISSUER", "AI Styler"),
+
+			AdminDefaultRole:        getEnv("ADMIN_DEFAULT_ROLE", "super_admin"),
+			AdminPermissionCacheTTL: getEnvAsDuration("ADMIN_PERMISSION_CACHE_TTL", 30*time.Second),
+
+			TelegramBotAPIKey:   getEnv("TELEGRAM_BOT_API_KEY", ""),
+			TelegramBotUsername: getEnv("TELEGRAM_BOT_USERNAME", ""),
+			TelegramLinkCodeTTL: getEnvAsDuration("TELEGRAM_LINK_CODE_TTL", 10*time.Minute),
+
+			LoginAlertsEnabled:   getEnvAsBool("LOGIN_ALERTS_ENABLED", true),
+			SessionRevokeURL:     getEnv("SESSION_REVOKE_URL", "https://yourdomain.com/auth/sessions/revoke"),
+			SessionRevokeLinkTTL: getEnvAsDuration("SESSION_REVOKE_LINK_TTL", 7*24*time.Hour),
+
+			CORSAllowedOrigins:    getEnvAsList("CORS_ALLOWED_ORIGINS", nil),
+			CORSAllowedMethods:    getEnvAsList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			CORSAllowedHeaders:    getEnvAsList("CORS_ALLOWED_HEADERS", []string{
+				"Authorization", "Content-Type", "Accept", "Accept-Language", "X-API-Key", "X-Request-ID", "If-None-Match",
+			}),
+			CORSAllowCredentials:  getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
+			CORSMaxAge:            getEnvAsDuration("CORS_MAX_AGE", 24*time.Hour),
+			HSTSMaxAge:            getEnvAsDuration("HSTS_MAX_AGE", 365*24*time.Hour),
+			HSTSIncludeSubdomains: getEnvAsBool("HSTS_INCLUDE_SUBDOMAINS", true),
+			ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", "default-src 'self'; frame-ancestors 'none'"),
+
+			GeoIPPath:                  getEnv("GEOIP_PATH", ""),
+			GeoIPAllowedCountries:      getEnvAsList("GEOIP_ALLOWED_COUNTRIES", nil),
+			GeoIPDeniedCountries:       getEnvAsList("GEOIP_DENIED_COUNTRIES", nil),
+			IPBlockExempt:              getEnvAsList("IP_BLOCK_EXEMPT", []string{"127.0.0.1", "::1"}),
+			IPAutoBlockThreshold:       getEnvAsInt("IP_AUTO_BLOCK_THRESHOLD", 30),
+			IPAutoBlockWindow:          getEnvAsDuration("IP_AUTO_BLOCK_WINDOW", 5*time.Minute),
+			IPAutoBlockDuration:        getEnvAsDuration("IP_AUTO_BLOCK_DURATION", time.Hour),
+			IPBlockListRefreshInterval: getEnvAsDuration("IP_BLOCKLIST_REFRESH_INTERVAL", time.Minute),
+
+			OTPResendCooldown:    getEnvAsDuration("OTP_RESEND_COOLDOWN", 30*time.Second),
+			OTPMaxResendCooldown: getEnvAsDuration("OTP_MAX_RESEND_COOLDOWN", 15*time.Minute),
+			OTPResendResetAfter:  getEnvAsDuration("OTP_RESEND_RESET_AFTER", time.Hour),
+			OTPMaxFailures:       getEnvAsInt("OTP_MAX_FAILURES", 10),
+			OTPFailureWindow:     getEnvAsDuration("OTP_FAILURE_WINDOW", time.Hour),
+			OTPLockoutDuration:   getEnvAsDuration("OTP_LOCKOUT_DURATION", 30*time.Minute),
 		},
 		RateLimit: RateLimitConfig{
 			OTPPerPhone:   getEnvAsInt("RATE_LIMIT_OTP_PER_PHONE", 3),
@@ -161,9 +755,40 @@ func Load() (*Config, error) {
 			Window:        getEnvAsDuration("RATE_LIMIT_WINDOW", time.Hour),
 		},
 		Storage: StorageConfig{
-			UploadMaxSize: getEnv("UPLOAD_MAX_SIZE", "10MB"),
-			StoragePath:   getEnv("STORAGE_PATH", "./uploads"),
-			SignedURLTTL:  getEnvAsDuration("SIGNED_URL_TTL", time.Hour),
+			UploadMaxSize:        getEnv("UPLOAD_MAX_SIZE", "10MB"),
+			StoragePath:          getEnv("STORAGE_PATH", "./uploads"),
+			SignedURLTTL:         getEnvAsDuration("SIGNED_URL_TTL", time.Hour),
+			SigningKeys:          getEnv("STORAGE_SIGNING_KEYS", ""),
+			SigningKeyID:         getEnv("STORAGE_SIGNING_KEY_ID", ""),
+			SignedURLDownloadTTL: getEnvAsDuration("SIGNED_URL_DOWNLOAD_TTL", 15*time.Minute),
+			Backend:              getEnv("STORAGE_BACKEND", "local"),
+			S3Endpoint:           getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Bucket:             getEnv("STORAGE_S3_BUCKET", ""),
+			S3Region:             getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3AccessKey:          getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey:          getEnv("STORAGE_S3_SECRET_KEY", ""),
+			ImageVariantsEnabled: getEnvAsBool("IMAGE_VARIANTS_ENABLED", true),
+			ImageVariantWidths:   getEnvAsList("IMAGE_VARIANT_WIDTHS", []string{"320", "640", "1024"}),
+			ImageVariantFormats:  getEnvAsList("IMAGE_VARIANT_FORMATS", []string{"webp", "avif"}),
+			ImageVariantQuality:  getEnvAsInt("IMAGE_VARIANT_QUALITY", 80),
+			ImageVariantWorkers:  getEnvAsInt("IMAGE_VARIANT_WORKERS", 2),
+
+			CDNBaseURL:            getEnv("STORAGE_CDN_BASE_URL", ""),
+			CDNProvider:           getEnv("STORAGE_CDN_PROVIDER", ""),
+			CDNCloudflareZoneID:   getEnv("STORAGE_CDN_CLOUDFLARE_ZONE_ID", ""),
+			CDNCloudflareAPIToken: getEnv("STORAGE_CDN_CLOUDFLARE_API_TOKEN", ""),
+			CDNBunnyAPIKey:        getEnv("STORAGE_CDN_BUNNY_API_KEY", ""),
+			CDNPurgeTimeout:       getEnvAsDuration("STORAGE_CDN_PURGE_TIMEOUT", 10*time.Second),
+
+			WatermarkLogoPath:    getEnv("WATERMARK_LOGO_PATH", ""),
+			BackupEnabled:        getEnvAsBool("STORAGE_BACKUP_ENABLED", true),
+			BackupFrequency:      getEnv("STORAGE_BACKUP_FREQUENCY", "daily"),
+			BackupRetentionDays:  getEnvAsInt("STORAGE_BACKUP_RETENTION_DAYS", 365),
+
+			CleanupEnabled:      getEnvAsBool("STORAGE_CLEANUP_ENABLED", true),
+			CleanupSchedule:     getEnv("STORAGE_CLEANUP_SCHEDULE", "0 2 * * *"),
+			CleanupUploadMaxAge: getEnvAsDuration("STORAGE_CLEANUP_UPLOAD_MAX_AGE", 24*time.Hour),
+			CleanupOrphanGrace:  getEnvAsDuration("STORAGE_CLEANUP_ORPHAN_GRACE", 24*time.Hour),
 		},
 		Monitoring: MonitoringConfig{
 			TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
@@ -173,6 +798,33 @@ func Load() (*Config, error) {
 			Environment:      getEnv("ENVIRONMENT", "development"),
 			Version:          getEnv("VERSION", "1.0.0"),
 			HealthEnabled:    getEnvAsBool("HEALTH_ENABLED", true),
+
+			HealthCheckTimeout:    getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 5*time.Second),
+			HealthStorageCacheTTL: getEnvAsDuration("HEALTH_STORAGE_CACHE_TTL", 30*time.Second),
+			HealthAICacheTTL:      getEnvAsDuration("HEALTH_AI_CACHE_TTL", 5*time.Minute),
+
+			LogRedactFields: getEnvAsList("LOG_REDACT_FIELDS", nil),
+			LogSampleRates:  getEnvAsRates("LOG_SAMPLE_RATES", map[string]float64{"/api/health": 0.01}),
+
+			AuditForwardEnabled:       getEnvAsBool("AUDIT_FORWARD_ENABLED", false),
+			AuditForwardTarget:        getEnv("AUDIT_FORWARD_TARGET", "syslog"),
+			AuditForwardSyslogNetwork: getEnv("AUDIT_FORWARD_SYSLOG_NETWORK", "tcp"),
+			AuditForwardSyslogAddress: getEnv("AUDIT_FORWARD_SYSLOG_ADDRESS", ""),
+			AuditForwardHTTPURL:       getEnv("AUDIT_FORWARD_HTTP_URL", ""),
+			AuditForwardHTTPToken:     getEnv("AUDIT_FORWARD_HTTP_TOKEN", ""),
+			AuditForwardBatchSize:     getEnvAsInt("AUDIT_FORWARD_BATCH_SIZE", 200),
+			AuditForwardInterval:      getEnvAsDuration("AUDIT_FORWARD_INTERVAL", 10*time.Second),
+
+			AuditRetentionDays:     getEnvAsInt("AUDIT_RETENTION_DAYS", 0),
+			AuditRetentionInterval: getEnvAsDuration("AUDIT_RETENTION_INTERVAL", 24*time.Hour),
+			AuditArchiveBatchSize:  getEnvAsInt("AUDIT_ARCHIVE_BATCH_SIZE", 5000),
+			AuditArchivePrefix:     getEnv("AUDIT_ARCHIVE_PREFIX", "audit-archive"),
+
+			TracingEnabled:     getEnvAsBool("OTEL_TRACING_ENABLED", false),
+			OTLPEndpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure:       getEnvAsBool("OTEL_EXPORTER_OTLP_INSECURE", true),
+			TracingSampleRatio: getEnvAsFloat("OTEL_TRACES_SAMPLE_RATIO", 1.0),
+			TracingServiceName: getEnv("OTEL_SERVICE_NAME", "ai-styler"),
 		},
 		Gemini: GeminiConfig{
 			APIKey:               getEnv("GEMINI_API_KEY", ""),
@@ -182,17 +834,273 @@ func Load() (*Config, error) {
 			MaxRetries:           getEnvAsInt("GEMINI_MAX_RETRIES", 1),
 			PreprocessNoiseLevel: getEnvAsFloat("GEMINI_PREPROCESS_NOISE_LEVEL", 0.02),
 			PreprocessJpegQuality: getEnvAsInt("GEMINI_PREPROCESS_JPEG_QUALITY", 95),
+			RetryBaseDelayMs:          getEnvAsInt("GEMINI_RETRY_BASE_DELAY_MS", 1000),
+			RetryMaxDelayMs:           getEnvAsInt("GEMINI_RETRY_MAX_DELAY_MS", 30000),
+			AttemptTimeout:            getEnvAsInt("GEMINI_ATTEMPT_TIMEOUT", 0),
+			BreakerFailureThreshold:   getEnvAsInt("GEMINI_BREAKER_FAILURE_THRESHOLD", 5),
+			BreakerOpenTimeout:        getEnvAsInt("GEMINI_BREAKER_OPEN_TIMEOUT", 30),
+			MonthlyBudget:             getEnvAsFloat("GEMINI_MONTHLY_BUDGET", 0),
+			CostPerConversion:         getEnvAsFloat("GEMINI_COST_PER_CONVERSION", 0.04),
+			BudgetAlertThreshold:      getEnvAsFloat("GEMINI_BUDGET_ALERT_THRESHOLD", 0.8),
+			BudgetDegradeMode:         getEnv("GEMINI_BUDGET_DEGRADE_MODE", "fallback,lower_resolution,pause_free_tier"),
+			BudgetLowResMaxSide:       getEnvAsInt("GEMINI_BUDGET_LOW_RES_MAX_SIDE", 768),
+			FallbackModel:             getEnv("GEMINI_FALLBACK_MODEL", ""),
+			FallbackCostPerConversion: getEnvAsFloat("GEMINI_FALLBACK_COST_PER_CONVERSION", 0.01),
+			InputTokenPrice:           getEnvAsFloat("GEMINI_INPUT_TOKEN_PRICE", 0),
+			OutputTokenPrice:          getEnvAsFloat("GEMINI_OUTPUT_TOKEN_PRICE", 0),
+			ImagePrice:                getEnvAsFloat("GEMINI_IMAGE_PRICE", 0),
+			DailyBudget:               getEnvAsFloat("GEMINI_DAILY_BUDGET", 0),
+
+			CandidateModel: getEnv("GEMINI_CANDIDATE_MODEL", ""),
+		},
+		Moderation: ModerationConfig{
+			Enabled:       getEnvAsBool("MODERATION_ENABLED", false),
+			Provider:      getEnv("MODERATION_PROVIDER", "local"),
+			APIURL:        getEnv("MODERATION_API_URL", ""),
+			APIKey:        getEnv("MODERATION_API_KEY", ""),
+			Timeout:       getEnvAsInt("MODERATION_TIMEOUT", 15),
+			NSFWThreshold: getEnvAsFloat("MODERATION_NSFW_THRESHOLD", 0.8),
+			RequireFace:   getEnvAsBool("MODERATION_REQUIRE_FACE", false),
+			MaxFaces:      getEnvAsInt("MODERATION_MAX_FACES", 0),
+			FailOpen:      getEnvAsBool("MODERATION_FAIL_OPEN", true),
+		},
+		InputCheck: InputCheckConfig{
+			Enabled:         getEnvAsBool("INPUT_CHECK_ENABLED", false),
+			Provider:        getEnv("INPUT_CHECK_PROVIDER", "local"),
+			APIURL:          getEnv("INPUT_CHECK_API_URL", ""),
+			APIKey:          getEnv("INPUT_CHECK_API_KEY", ""),
+			Timeout:         getEnvAsInt("INPUT_CHECK_TIMEOUT", 10),
+			MinUserSide:     getEnvAsInt("INPUT_CHECK_MIN_USER_SIDE", 256),
+			MinGarmentSide:  getEnvAsInt("INPUT_CHECK_MIN_GARMENT_SIDE", 200),
+			MinPersonRatio:  getEnvAsFloat("INPUT_CHECK_MIN_PERSON_RATIO", 0.3),
+			MinGarmentRatio: getEnvAsFloat("INPUT_CHECK_MIN_GARMENT_RATIO", 0.1),
+			MaxPeople:       getEnvAsInt("INPUT_CHECK_MAX_PEOPLE", 1),
+			FailOpen:        getEnvAsBool("INPUT_CHECK_FAIL_OPEN", true),
+		},
+		Onboarding: OnboardingConfig{
+			Enabled:                 getEnvAsBool("ONBOARDING_ENABLED", true),
+			SignupCredits:           getEnvAsInt("ONBOARDING_SIGNUP_CREDITS", 2),
+			FirstConversionFree:     getEnvAsBool("ONBOARDING_FIRST_CONVERSION_FREE", true),
+			FirstConversionPriority: getEnvAsInt("ONBOARDING_FIRST_CONVERSION_PRIORITY", 20),
+		},
+		Outbox: OutboxConfig{
+			Enabled:        getEnvAsBool("OUTBOX_ENABLED", false),
+			PollInterval:   getEnvAsDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			BatchSize:      getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:    getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryDelay:     getEnvAsDuration("OUTBOX_RETRY_DELAY", 5*time.Second),
+			MaxRetryDelay:  getEnvAsDuration("OUTBOX_MAX_RETRY_DELAY", time.Hour),
+			WebhookURLs:    getEnv("OUTBOX_WEBHOOK_URLS", ""),
+			WebhookSecret:  getEnv("OUTBOX_WEBHOOK_SECRET", ""),
+			WebhookTimeout: getEnvAsDuration("OUTBOX_WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Quota: QuotaConfig{
+			Enabled: getEnvAsBool("QUOTA_ENFORCEMENT_ENABLED", true),
+		},
+		ConversionLog: ConversionLogConfig{
+			Enabled:          getEnvAsBool("CONVERSION_LOG_ENABLED", true),
+			MaxPerConversion: getEnvAsInt("CONVERSION_LOG_MAX_PER_CONVERSION", 200),
+			Retention:        getEnvAsDuration("CONVERSION_LOG_RETENTION", 30*24*time.Hour),
+		},
+		ConversionRetry: ConversionRetryConfig{
+			MaxRetries: getEnvAsInt("CONVERSION_MAX_RETRIES", 3),
+		},
+		ConversionOutfit: ConversionOutfitConfig{
+			MaxGarments: getEnvAsInt("CONVERSION_MAX_GARMENTS", 3),
+		},
+		ConversionSchedule: ConversionScheduleConfig{
+			Enabled:      getEnvAsBool("CONVERSION_SCHEDULE_ENABLED", true),
+			MaxAhead:     getEnvAsDuration("CONVERSION_SCHEDULE_MAX_AHEAD", 7*24*time.Hour),
+			PollInterval: getEnvAsDuration("CONVERSION_SCHEDULE_POLL_INTERVAL", 30*time.Second),
+			BatchSize:    getEnvAsInt("CONVERSION_SCHEDULE_BATCH_SIZE", 100),
+		},
+		ConversionExport: ConversionExportConfig{
+			Enabled:        getEnvAsBool("CONVERSION_EXPORT_ENABLED", true),
+			DownloadURL:    getEnv("CONVERSION_EXPORT_DOWNLOAD_URL", "https://yourdomain.com/api/conversions/exports/download"),
+			Retention:      getEnvAsDuration("CONVERSION_EXPORT_RETENTION", 24*time.Hour),
+			MaxConversions: getEnvAsInt("CONVERSION_EXPORT_MAX_CONVERSIONS", 500),
+			MaxSizeMB:      getEnvAsInt("CONVERSION_EXPORT_MAX_SIZE_MB", 256),
+			PollInterval:   getEnvAsDuration("CONVERSION_EXPORT_POLL_INTERVAL", 10*time.Second),
+		},
+		UserDataExport: UserDataExportConfig{
+			Enabled:      getEnvAsBool("USER_DATA_EXPORT_ENABLED", true),
+			DownloadURL:  getEnv("USER_DATA_EXPORT_DOWNLOAD_URL", "https://yourdomain.com/api/users/exports/download"),
+			Retention:    getEnvAsDuration("USER_DATA_EXPORT_RETENTION", 24*time.Hour),
+			PollInterval: getEnvAsDuration("USER_DATA_EXPORT_POLL_INTERVAL", 10*time.Second),
+		},
+		PaymentExport: PaymentExportConfig{
+			Timezone:      getEnv("PAYMENT_EXPORT_TIMEZONE", "Asia/Tehran"),
+			StreamMaxDays: getEnvAsInt("PAYMENT_EXPORT_STREAM_MAX_DAYS", 31),
+			MaxDays:       getEnvAsInt("PAYMENT_EXPORT_MAX_DAYS", 366),
+			Retention:     getEnvAsDuration("PAYMENT_EXPORT_RETENTION", 24*time.Hour),
+			PollInterval:  getEnvAsDuration("PAYMENT_EXPORT_POLL_INTERVAL", 10*time.Second),
+		},
+		LookupCache: LookupCacheConfig{
+			Enabled:                getEnvAsBool("LOOKUP_CACHE_ENABLED", true),
+			UserPlanTTL:            getEnvAsDuration("LOOKUP_CACHE_USER_PLAN_TTL", time.Minute),
+			QuotaStatusTTL:         getEnvAsDuration("LOOKUP_CACHE_QUOTA_STATUS_TTL", 15*time.Second),
+			VendorProfileTTL:       getEnvAsDuration("LOOKUP_CACHE_VENDOR_PROFILE_TTL", 5*time.Minute),
+			UnreadNotificationsTTL: getEnvAsDuration("LOOKUP_CACHE_UNREAD_NOTIFICATIONS_TTL", 5*time.Minute),
+		},
+		ImageTrash: ImageTrashConfig{
+			Enabled:       getEnvAsBool("IMAGE_TRASH_ENABLED", true),
+			RestoreWindow: getEnvAsDuration("IMAGE_TRASH_RESTORE_WINDOW", 30*24*time.Hour),
+			PurgeInterval: getEnvAsDuration("IMAGE_TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		ImageDedup: ImageDedupConfig{
+			Enabled: getEnvAsBool("IMAGE_DEDUP_ENABLED", true),
+		},
+		ImageUpload: ImageUploadConfig{
+			DirectEnabled: getEnvAsBool("IMAGE_DIRECT_UPLOAD_ENABLED", true),
+			URLTTL:        getEnvAsDuration("IMAGE_DIRECT_UPLOAD_URL_TTL", 15*time.Minute),
+			PurgeInterval: getEnvAsDuration("IMAGE_DIRECT_UPLOAD_PURGE_INTERVAL", time.Hour),
+		},
+		ImageImport: ImageImportConfig{
+			Enabled:          getEnvAsBool("IMAGE_IMPORT_ENABLED", true),
+			MaxFiles:         getEnvAsInt("IMAGE_IMPORT_MAX_FILES", 200),
+			MaxArchiveSizeMB: getEnvAsInt("IMAGE_IMPORT_MAX_ARCHIVE_SIZE_MB", 100),
+			FetchTimeout:     getEnvAsDuration("IMAGE_IMPORT_FETCH_TIMEOUT", 30*time.Second),
+			PollInterval:     getEnvAsDuration("IMAGE_IMPORT_POLL_INTERVAL", 10*time.Second),
 		},
 		BazaarPay: BazaarPayConfig{
 			APIKey:      getEnv("BAZAARPAY_API_KEY", ""),
 			Destination: getEnv("BAZAARPAY_DESTINATION", "mynaa_bazaar"),
 			RedirectURL: getEnv("BAZAARPAY_REDIRECT_URL", "https://yourdomain.com/api/payments/bazaarpay/status"),
 		},
+		PlanTrial: PlanTrialConfig{
+			Enabled:        getEnvAsBool("PLAN_TRIAL_ENABLED", true),
+			DefaultPlan:    getEnv("PLAN_TRIAL_DEFAULT_PLAN", "free"),
+			ExpiryInterval: getEnvAsDuration("PLAN_TRIAL_EXPIRY_INTERVAL", 5*time.Minute),
+			BatchSize:      getEnvAsInt("PLAN_TRIAL_BATCH_SIZE", 100),
+		},
+		CouponExpiry: CouponExpiryConfig{
+			Interval:  getEnvAsDuration("COUPON_EXPIRY_INTERVAL", 5*time.Minute),
+			BatchSize: getEnvAsInt("COUPON_EXPIRY_BATCH_SIZE", 100),
+		},
+		Email: EmailConfig{
+			Provider:       getEnv("EMAIL_PROVIDER", ""),
+			FromAddress:    getEnv("EMAIL_FROM_ADDRESS", "noreply@aistyler.com"),
+			FromName:       getEnv("EMAIL_FROM_NAME", "AI Styler"),
+			Timeout:        getEnvAsDuration("EMAIL_TIMEOUT", 10*time.Second),
+			SMTPHost:       getEnv("SMTP_HOST", ""),
+			SMTPPort:       getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+			SMTPPoolSize:   getEnvAsInt("SMTP_POOL_SIZE", 4),
+			SendGridAPIKey: getEnv("SENDGRID_API_KEY", ""),
+			SendGridAPIURL: getEnv("SENDGRID_API_URL", "https://api.sendgrid.com"),
+		},
+		Digest: NotificationDigestConfig{
+			Enabled:   getEnvAsBool("NOTIFICATION_DIGEST_ENABLED", true),
+			Interval:  getEnvAsDuration("NOTIFICATION_DIGEST_INTERVAL", 5*time.Minute),
+			BatchSize: getEnvAsInt("NOTIFICATION_DIGEST_BATCH_SIZE", 100),
+		},
+		NotifyQueue: NotificationQueueConfig{
+			Enabled:      getEnvAsBool("NOTIFICATION_QUEUE_ENABLED", true),
+			Size:         getEnvAsInt("NOTIFICATION_QUEUE_SIZE", 1000),
+			Workers:      getEnvAsInt("NOTIFICATION_QUEUE_WORKERS", 4),
+			EnqueueWait:  getEnvAsDuration("NOTIFICATION_QUEUE_ENQUEUE_WAIT", 2*time.Second),
+			Rates:        getEnvAsRates("NOTIFICATION_SEND_RATES", map[string]float64{"email": 10, "sms": 10, "telegram": 25}),
+			SMSBatchSize: getEnvAsInt("NOTIFICATION_SMS_BATCH_SIZE", 50),
+		},
+		NotifyTemplates: NotificationTemplatesConfig{
+			CacheTTL: getEnvAsDuration("NOTIFICATION_TEMPLATES_CACHE_TTL", 30*time.Second),
+		},
+		Push: PushConfig{
+			FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			FCMAPIURL:          getEnv("FCM_API_URL", "https://fcm.googleapis.com"),
+			Timeout:            getEnvAsDuration("FCM_TIMEOUT", 10*time.Second),
+			DeviceStaleAfter:   getEnvAsDuration("PUSH_DEVICE_STALE_AFTER", 270*24*time.Hour),
+			PruneInterval:      getEnvAsDuration("PUSH_DEVICE_PRUNE_INTERVAL", 24*time.Hour),
+		},
+		WorkerQueue: WorkerQueueConfig{
+			AgingInterval: getEnvAsDuration("WORKER_QUEUE_AGING_INTERVAL", time.Minute),
+			AgingMaxBoost: getEnvAsInt("WORKER_QUEUE_AGING_MAX_BOOST", 10),
+			DrainTimeout:  getEnvAsDuration("WORKER_DRAIN_TIMEOUT", 30*time.Second),
+
+			HeartbeatInterval: getEnvAsDuration("WORKER_HEARTBEAT_INTERVAL", 15*time.Second),
+		},
+		APIKey: APIKeyConfig{
+			MaxPerUser:       getEnvAsInt("API_KEY_MAX_PER_USER", 10),
+			DefaultRateLimit: getEnvAsInt("API_KEY_DEFAULT_RATE_LIMIT", 60),
+			MaxRateLimit:     getEnvAsInt("API_KEY_MAX_RATE_LIMIT", 600),
+		},
+		InternalAPI: InternalAPIConfig{
+			Enabled:        getEnvAsBool("INTERNAL_API_ENABLED", false),
+			Addr:           getEnv("INTERNAL_API_ADDR", ":9090"),
+			CertFile:       getEnv("INTERNAL_API_TLS_CERT", ""),
+			KeyFile:        getEnv("INTERNAL_API_TLS_KEY", ""),
+			ClientCAFile:   getEnv("INTERNAL_API_CLIENT_CA", ""),
+			AllowedClients: getEnvAsList("INTERNAL_API_ALLOWED_CLIENTS", nil),
+			MaxMessageSize: getEnvAsInt("INTERNAL_API_MAX_MESSAGE_SIZE", 64<<20),
+		},
+		SystemSettings: SystemSettingsConfig{
+			RefreshInterval:       getEnvAsDuration("SYSTEM_SETTINGS_REFRESH_INTERVAL", time.Minute),
+			MaintenanceAllowedIPs: getEnvAsList("MAINTENANCE_ALLOWED_IPS", nil),
+		},
+		FeatureFlags: FeatureFlagsConfig{
+			CacheTTL: getEnvAsDuration("FEATURE_FLAGS_CACHE_TTL", 30*time.Second),
+		},
+		PromptTemplates: PromptTemplatesConfig{
+			CacheTTL: getEnvAsDuration("PROMPT_TEMPLATES_CACHE_TTL", 30*time.Second),
+		},
+		Support: SupportConfig{
+			MaxOpenTickets:   getEnvAsInt("SUPPORT_MAX_OPEN_TICKETS", 5),
+			MaxMessageLength: getEnvAsInt("SUPPORT_MAX_MESSAGE_LENGTH", 4000),
+		},
+		Reputation: ReputationConfig{
+			Enabled:           getEnvAsBool("REPUTATION_ENABLED", false),
+			ASNPath:           getEnv("REPUTATION_ASN_PATH", ""),
+			PhonePrefixDigits: getEnvAsInt("REPUTATION_PHONE_PREFIX_DIGITS", 8),
+			Window:            getEnvAsDuration("REPUTATION_WINDOW", 24*time.Hour),
+			PrefixThreshold:   getEnvAsInt("REPUTATION_PREFIX_THRESHOLD", 5),
+			DeviceThreshold:   getEnvAsInt("REPUTATION_DEVICE_THRESHOLD", 2),
+			ASNThreshold:      getEnvAsInt("REPUTATION_ASN_THRESHOLD", 50),
+			RiskyASNs:         getEnvAsList("REPUTATION_RISKY_ASNS", nil),
+			LimitScore:        getEnvAsInt("REPUTATION_LIMIT_SCORE", 40),
+			HoldScore:         getEnvAsInt("REPUTATION_HOLD_SCORE", 70),
+			LimitedCredits:    getEnvAsInt("REPUTATION_LIMITED_CREDITS", 1),
+			ReleaseDelay:      getEnvAsDuration("REPUTATION_RELEASE_DELAY", 24*time.Hour),
+			ReleaseInterval:   getEnvAsDuration("REPUTATION_RELEASE_INTERVAL", 5*time.Minute),
+		},
+		Verification: VerificationConfig{
+			Enabled:           getEnvAsBool("VENDOR_VERIFICATION_ENABLED", true),
+			MaxDocumentSizeMB: getEnvAsInt("VENDOR_VERIFICATION_MAX_DOCUMENT_SIZE_MB", 10),
+			MaxDocuments:      getEnvAsInt("VENDOR_VERIFICATION_MAX_DOCUMENTS", 10),
+		},
+		AdminActivity: AdminActivityConfig{
+			Enabled:        getEnvAsBool("ADMIN_ACTIVITY_ENABLED", true),
+			SpikeThreshold: getEnvAsInt("ADMIN_ACTIVITY_FAILURE_SPIKE_THRESHOLD", 10),
+			SpikeWindow:    getEnvAsDuration("ADMIN_ACTIVITY_FAILURE_SPIKE_WINDOW", 5*time.Minute),
+		},
 	}
+	config.envErrors = takeEnvErrors()
 
 	return config, nil
 }
 
+var (
+	envErrorsMu sync.Mutex
+	envErrors   []error
+)
+
+// recordEnvError notes a variable whose value could not be parsed
+func recordEnvError(key, value, kind string) {
+	envErrorsMu.Lock()
+	defer envErrorsMu.Unlock()
+	envErrors = append(envErrors, fmt.Errorf("%s=%q is not a valid %s", key, value, kind))
+}
+
+// takeEnvErrors returns and clears the recorded malformed variables
+func takeEnvErrors() []error {
+	envErrorsMu.Lock()
+	defer envErrorsMu.Unlock()
+	errs := envErrors
+	envErrors = nil
+	return errs
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -205,6 +1113,7 @@ func getEnvAsInt(key string, defaultValue int) int {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		recordEnvError(key, value, "integer")
 	}
 	return defaultValue
 }
@@ -214,6 +1123,7 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		recordEnvError(key, value, "duration (e.g. 30s, 15m, 24h)")
 	}
 	return defaultValue
 }
@@ -223,6 +1133,20 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
+		recordEnvError(key, value, "boolean")
+	}
+	return defaultValue
+}
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values
 	}
 	return defaultValue
 }
@@ -232,6 +1156,30 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
 			return floatValue
 		}
+		recordEnvError(key, value, "number")
 	}
 	return defaultValue
 }
+
+// getEnvAsRates parses comma separated key=rate pairs such as
+// "/api/health=0.01,/api/images=0.1"
+func getEnvAsRates(key string, defaultValue map[string]float64) map[string]float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	rates := make(map[string]float64)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, rateValue, ok := strings.Cut(item, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateValue), 64)
+		if !ok || err != nil {
+			recordEnvError(key, value, "list of key=rate pairs")
+			return defaultValue
+		}
+		rates[strings.TrimSpace(name)] = rate
+	}
+	return rates
+}
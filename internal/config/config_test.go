@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -173,3 +174,114 @@ func TestGetEnvAsDuration(t *testing.T) {
 		t.Errorf("Expected %v, got %v", time.Second, value)
 	}
 }
+
+func TestValidate_Defaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got: %v", err)
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	os.Setenv("DB_PORT", "not-a-port")
+	os.Setenv("REDIS_PORT", "70000")
+	os.Setenv("JWT_ACCESS_TTL", "30")
+	os.Setenv("GEMINI_BASE_URL", "generativelanguage.googleapis.com")
+	os.Setenv("EMAIL_PROVIDER", "smtp")
+	defer func() {
+		os.Unsetenv("DB_PORT")
+		os.Unsetenv("REDIS_PORT")
+		os.Unsetenv("JWT_ACCESS_TTL")
+		os.Unsetenv("GEMINI_BASE_URL")
+		os.Unsetenv("EMAIL_PROVIDER")
+	}()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cfg.Database.Port != 5432 {
+		t.Errorf("Expected the malformed port to fall back to 5432, got %d", cfg.Database.Port)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{
+		`DB_PORT="not-a-port" is not a valid integer`,
+		`JWT_ACCESS_TTL="30" is not a valid duration`,
+		"REDIS_PORT=70000 is not a valid port",
+		`GEMINI_BASE_URL="generativelanguage.googleapis.com" is not a valid http(s) URL`,
+		"SMTP_HOST is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err.Error())
+		}
+	}
+}
+
+func TestValidate_ProductionJWTSecret(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	cfg.Monitoring.Environment = "production"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET must be set in production") {
+		t.Errorf("Expected the default JWT secret to be rejected, got: %v", err)
+	}
+
+	cfg.JWT.Secret = "short"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least 32 characters") {
+		t.Errorf("Expected a short JWT secret to be rejected, got: %v", err)
+	}
+
+	cfg.JWT.Secret = strings.Repeat("s", 32)
+	cfg.Storage.SigningKeys = "k1:" + strings.Repeat("s", 32)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_SigningKeysOutsideDevelopment(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	cfg.JWT.Secret = strings.Repeat("s", 32)
+
+	for _, environment := range []string{"production", "staging"} {
+		cfg.Monitoring.Environment = environment
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "STORAGE_SIGNING_KEYS is required") {
+			t.Errorf("%s: expected missing signing keys to be rejected, got: %v", environment, err)
+		}
+	}
+
+	cfg.Storage.SigningKeys = "k1:" + strings.Repeat("s", 32)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestSummary_RedactsSecrets(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	cfg.Database.Password = "db-password"
+	cfg.JWT.Secret = "jwt-secret"
+	cfg.Gemini.APIKey = "gemini-key"
+
+	summary := cfg.Summary()
+	for _, secret := range []string{"db-password", "jwt-secret", "gemini-key"} {
+		if strings.Contains(summary, secret) {
+			t.Errorf("Expected %q to be redacted from the summary", secret)
+		}
+	}
+	if !strings.Contains(summary, "host=localhost port=5432") {
+		t.Errorf("Expected the database address in the summary, got %q", summary)
+	}
+}
@@ -0,0 +1,489 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// defaultJWTSecret is the placeholder JWT_SECRET used when none is set
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// minProductionJWTSecretLength is the shortest JWT_SECRET accepted in production
+const minProductionJWTSecretLength = 32
+
+// IsProduction reports whether ENVIRONMENT is production
+func (c *Config) IsProduction() bool {
+	return c.Monitoring.Environment == "production"
+}
+
+// IsDevelopment reports whether ENVIRONMENT is development
+func (c *Config) IsDevelopment() bool {
+	return c.Monitoring.Environment == "development"
+}
+
+// Validate checks the loaded configuration and returns every problem found,
+// one per line, each naming the environment variable to fix. It also reports
+// malformed values Load replaced with their defaults.
+func (c *Config) Validate() error {
+	v := &validator{}
+	v.errs = append(v.errs, c.envErrors...)
+
+	// Database
+	v.required("DB_HOST", c.Database.Host)
+	v.required("DB_NAME", c.Database.Name)
+	v.required("DB_USER", c.Database.User)
+	v.port("DB_PORT", c.Database.Port)
+	v.oneOf("DB_SSLMODE", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	v.oneOf("DB_MIGRATION_PHASE", strings.ToLower(c.Database.MigrationPhase), "", "all", "pre", "pre-deploy", "post", "post-deploy")
+	if len(c.Database.ReadReplicaDSNs) > 0 {
+		v.positive("DB_REPLICA_CHECK_INTERVAL", c.Database.ReplicaCheckInterval)
+	}
+	if c.Database.QueryMetricsEnabled {
+		v.positive("DB_SLOW_QUERY_THRESHOLD", c.Database.SlowQueryThreshold)
+		v.between("DB_QUERY_METRICS_MAX_STATEMENTS", c.Database.QueryMetricsMaxStatements, 1, 10000)
+	}
+
+	// Server
+	v.listenAddr("HTTP_ADDR", c.Server.HTTPAddr)
+	v.oneOf("GIN_MODE", c.Server.GinMode, "debug", "release", "test")
+	if c.Server.PublicBaseURL != "" {
+		v.url("PUBLIC_BASE_URL", c.Server.PublicBaseURL)
+	}
+
+	// JWT
+	if c.IsProduction() {
+		if c.JWT.Secret == defaultJWTSecret {
+			v.add("JWT_SECRET must be set in production")
+		} else if len(c.JWT.Secret) < minProductionJWTSecretLength {
+			v.add("JWT_SECRET must be at least %d characters in production", minProductionJWTSecretLength)
+		}
+	}
+	v.positive("JWT_ACCESS_TTL", c.JWT.AccessTTL)
+	v.positive("JWT_REFRESH_TTL", c.JWT.RefreshTTL)
+	v.positive("JWT_IMPERSONATION_TTL", c.JWT.ImpersonationTTL)
+	if c.JWT.RefreshTTL > 0 && c.JWT.RefreshTTL < c.JWT.AccessTTL {
+		v.add("JWT_REFRESH_TTL (%s) must not be shorter than JWT_ACCESS_TTL (%s)", c.JWT.RefreshTTL, c.JWT.AccessTTL)
+	}
+	if c.JWT.KeyRotationEnabled {
+		v.oneOf("JWT_ALGORITHM", c.JWT.Algorithm, "HS256", "RS256", "EdDSA")
+		v.positive("JWT_KEY_REFRESH_INTERVAL", c.JWT.KeyRefreshInterval)
+		if c.JWT.KeyRotationInterval < time.Hour {
+			v.add("JWT_KEY_ROTATION_INTERVAL must be at least 1h")
+		}
+	}
+
+	// Redis
+	v.required("REDIS_HOST", c.Redis.Host)
+	v.port("REDIS_PORT", c.Redis.Port)
+	if c.Redis.DB < 0 {
+		v.add("REDIS_DB=%d must not be negative", c.Redis.DB)
+	}
+
+	// SMS
+	v.oneOf("SMS_PROVIDER", c.SMS.Provider, "mock", "sms_ir")
+	if c.SMS.Provider == "sms_ir" {
+		v.required("SMS_API_KEY", c.SMS.APIKey)
+	}
+	for _, provider := range c.SMS.FallbackProviders {
+		v.oneOf("SMS_FALLBACK_PROVIDERS", provider, "sms_ir", "kavenegar", "kavenegar_voice", "telegram")
+	}
+	if len(c.SMS.FallbackProviders) > 0 {
+		v.positive("SMS_PROVIDER_TIMEOUT", c.SMS.ProviderTimeout)
+	}
+
+	// Security
+	v.between("BCRYPT_COST", c.Security.BCryptCost, 4, 31)
+	v.positive("ADMIN_PERMISSION_CACHE_TTL", c.Security.AdminPermissionCacheTTL)
+	v.positive("TELEGRAM_LINK_CODE_TTL", c.Security.TelegramLinkCodeTTL)
+	if c.Security.LoginAlertsEnabled {
+		v.url("SESSION_REVOKE_URL", c.Security.SessionRevokeURL)
+		v.positive("SESSION_REVOKE_LINK_TTL", c.Security.SessionRevokeLinkTTL)
+	}
+	v.positive("IP_BLOCKLIST_REFRESH_INTERVAL", c.Security.IPBlockListRefreshInterval)
+	if c.Security.IPAutoBlockThreshold > 0 {
+		v.positive("IP_AUTO_BLOCK_WINDOW", c.Security.IPAutoBlockWindow)
+		v.positive("IP_AUTO_BLOCK_DURATION", c.Security.IPAutoBlockDuration)
+	}
+	if c.Security.OTPMaxFailures > 0 {
+		v.positive("OTP_RESEND_COOLDOWN", c.Security.OTPResendCooldown)
+		v.positive("OTP_RESEND_RESET_AFTER", c.Security.OTPResendResetAfter)
+		v.positive("OTP_FAILURE_WINDOW", c.Security.OTPFailureWindow)
+		v.positive("OTP_LOCKOUT_DURATION", c.Security.OTPLockoutDuration)
+		if c.Security.OTPMaxResendCooldown < c.Security.OTPResendCooldown {
+			v.add("OTP_MAX_RESEND_COOLDOWN (%s) must not be lower than OTP_RESEND_COOLDOWN (%s)", c.Security.OTPMaxResendCooldown, c.Security.OTPResendCooldown)
+		}
+	}
+
+	// Rate limits
+	v.positive("RATE_LIMIT_WINDOW", c.RateLimit.Window)
+
+	// Storage
+	if !c.IsDevelopment() {
+		// A random per-process key would break signed URLs across restarts and replicas
+		v.required("STORAGE_SIGNING_KEYS", c.Storage.SigningKeys)
+	}
+	v.positive("SIGNED_URL_TTL", c.Storage.SignedURLTTL)
+	v.positive("SIGNED_URL_DOWNLOAD_TTL", c.Storage.SignedURLDownloadTTL)
+	v.oneOf("STORAGE_BACKEND", c.Storage.Backend, "local", "s3")
+	if c.Storage.Backend == "s3" {
+		v.required("STORAGE_S3_BUCKET", c.Storage.S3Bucket)
+		v.url("STORAGE_S3_ENDPOINT", c.Storage.S3Endpoint)
+	}
+	if c.Storage.ImageVariantsEnabled {
+		v.between("IMAGE_VARIANT_QUALITY", c.Storage.ImageVariantQuality, 1, 100)
+		for _, width := range c.Storage.ImageVariantWidths {
+			if n, err := strconv.Atoi(width); err != nil || n <= 0 {
+				v.add("IMAGE_VARIANT_WIDTHS contains %q, widths must be positive integers", width)
+			}
+		}
+	}
+	if c.Storage.BackupEnabled {
+		v.oneOf("STORAGE_BACKUP_FREQUENCY", c.Storage.BackupFrequency, "daily", "weekly", "monthly")
+	}
+	if c.Storage.CleanupEnabled {
+		if _, err := common.ParseSchedule(c.Storage.CleanupSchedule); err != nil {
+			v.add("STORAGE_CLEANUP_SCHEDULE is invalid: %v", err)
+		}
+		v.positive("STORAGE_CLEANUP_UPLOAD_MAX_AGE", c.Storage.CleanupUploadMaxAge)
+		v.positive("STORAGE_CLEANUP_ORPHAN_GRACE", c.Storage.CleanupOrphanGrace)
+	}
+	if c.Storage.CDNBaseURL != "" {
+		v.url("STORAGE_CDN_BASE_URL", c.Storage.CDNBaseURL)
+		v.oneOf("STORAGE_CDN_PROVIDER", c.Storage.CDNProvider, "", "cloudflare", "bunny")
+		v.positive("STORAGE_CDN_PURGE_TIMEOUT", c.Storage.CDNPurgeTimeout)
+		switch c.Storage.CDNProvider {
+		case "cloudflare":
+			v.required("STORAGE_CDN_CLOUDFLARE_ZONE_ID", c.Storage.CDNCloudflareZoneID)
+			v.required("STORAGE_CDN_CLOUDFLARE_API_TOKEN", c.Storage.CDNCloudflareAPIToken)
+		case "bunny":
+			v.required("STORAGE_CDN_BUNNY_API_KEY", c.Storage.CDNBunnyAPIKey)
+		}
+	}
+
+	// Monitoring
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.Monitoring.LogLevel), "debug", "info", "warn", "warning", "error", "fatal")
+	v.positive("HEALTH_CHECK_TIMEOUT", c.Monitoring.HealthCheckTimeout)
+	for prefix, rate := range c.Monitoring.LogSampleRates {
+		v.ratio("LOG_SAMPLE_RATES["+prefix+"]", rate)
+	}
+	if c.Monitoring.AuditForwardEnabled {
+		v.oneOf("AUDIT_FORWARD_TARGET", c.Monitoring.AuditForwardTarget, "syslog", "http")
+		switch c.Monitoring.AuditForwardTarget {
+		case "syslog":
+			v.required("AUDIT_FORWARD_SYSLOG_ADDRESS", c.Monitoring.AuditForwardSyslogAddress)
+		case "http":
+			v.url("AUDIT_FORWARD_HTTP_URL", c.Monitoring.AuditForwardHTTPURL)
+		}
+		v.positive("AUDIT_FORWARD_INTERVAL", c.Monitoring.AuditForwardInterval)
+	}
+	if c.Monitoring.AuditRetentionDays > 0 {
+		v.positive("AUDIT_RETENTION_INTERVAL", c.Monitoring.AuditRetentionInterval)
+	}
+	if c.Monitoring.TracingEnabled {
+		v.ratio("OTEL_TRACES_SAMPLE_RATIO", c.Monitoring.TracingSampleRatio)
+	}
+
+	// Gemini
+	v.url("GEMINI_BASE_URL", c.Gemini.BaseURL)
+	v.required("GEMINI_MODEL", c.Gemini.Model)
+	v.between("GEMINI_PREPROCESS_JPEG_QUALITY", c.Gemini.PreprocessJpegQuality, 1, 100)
+	v.ratio("GEMINI_BUDGET_ALERT_THRESHOLD", c.Gemini.BudgetAlertThreshold)
+	if c.Gemini.RetryMaxDelayMs < c.Gemini.RetryBaseDelayMs {
+		v.add("GEMINI_RETRY_MAX_DELAY_MS (%d) must not be lower than GEMINI_RETRY_BASE_DELAY_MS (%d)", c.Gemini.RetryMaxDelayMs, c.Gemini.RetryBaseDelayMs)
+	}
+
+	// Moderation
+	if c.Moderation.Enabled {
+		v.oneOf("MODERATION_PROVIDER", c.Moderation.Provider, "local", "api")
+		if c.Moderation.Provider == "api" {
+			v.url("MODERATION_API_URL", c.Moderation.APIURL)
+		}
+		v.ratio("MODERATION_NSFW_THRESHOLD", c.Moderation.NSFWThreshold)
+	}
+
+	// Input check
+	if c.InputCheck.Enabled {
+		v.oneOf("INPUT_CHECK_PROVIDER", c.InputCheck.Provider, "local", "api")
+		if c.InputCheck.Provider == "api" {
+			v.url("INPUT_CHECK_API_URL", c.InputCheck.APIURL)
+		}
+		v.ratio("INPUT_CHECK_MIN_PERSON_RATIO", c.InputCheck.MinPersonRatio)
+		v.ratio("INPUT_CHECK_MIN_GARMENT_RATIO", c.InputCheck.MinGarmentRatio)
+	}
+
+	// Outbox
+	if c.Outbox.Enabled {
+		v.positive("OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+		for _, webhookURL := range strings.Split(c.Outbox.WebhookURLs, ",") {
+			if webhookURL = strings.TrimSpace(webhookURL); webhookURL != "" {
+				v.url("OUTBOX_WEBHOOK_URLS", webhookURL)
+			}
+		}
+	}
+
+	// Conversions and images
+	v.between("CONVERSION_MAX_GARMENTS", c.ConversionOutfit.MaxGarments, 1, 6)
+	if c.ConversionLog.Enabled {
+		v.positive("CONVERSION_LOG_RETENTION", c.ConversionLog.Retention)
+	}
+	if c.ConversionSchedule.Enabled {
+		v.positive("CONVERSION_SCHEDULE_MAX_AHEAD", c.ConversionSchedule.MaxAhead)
+		v.positive("CONVERSION_SCHEDULE_POLL_INTERVAL", c.ConversionSchedule.PollInterval)
+		v.between("CONVERSION_SCHEDULE_BATCH_SIZE", c.ConversionSchedule.BatchSize, 1, 1000)
+	}
+	if c.ConversionExport.Enabled {
+		v.url("CONVERSION_EXPORT_DOWNLOAD_URL", c.ConversionExport.DownloadURL)
+		v.positive("CONVERSION_EXPORT_RETENTION", c.ConversionExport.Retention)
+		v.positive("CONVERSION_EXPORT_POLL_INTERVAL", c.ConversionExport.PollInterval)
+		v.between("CONVERSION_EXPORT_MAX_CONVERSIONS", c.ConversionExport.MaxConversions, 1, 10000)
+		v.between("CONVERSION_EXPORT_MAX_SIZE_MB", c.ConversionExport.MaxSizeMB, 1, 4096)
+	}
+	if c.UserDataExport.Enabled {
+		v.url("USER_DATA_EXPORT_DOWNLOAD_URL", c.UserDataExport.DownloadURL)
+		v.positive("USER_DATA_EXPORT_RETENTION", c.UserDataExport.Retention)
+		v.positive("USER_DATA_EXPORT_POLL_INTERVAL", c.UserDataExport.PollInterval)
+	}
+	v.between("PAYMENT_EXPORT_MAX_DAYS", c.PaymentExport.MaxDays, 1, 3660)
+	v.between("PAYMENT_EXPORT_STREAM_MAX_DAYS", c.PaymentExport.StreamMaxDays, 1, c.PaymentExport.MaxDays)
+	v.positive("PAYMENT_EXPORT_RETENTION", c.PaymentExport.Retention)
+	v.positive("PAYMENT_EXPORT_POLL_INTERVAL", c.PaymentExport.PollInterval)
+	if c.ImageTrash.Enabled {
+		v.positive("IMAGE_TRASH_PURGE_INTERVAL", c.ImageTrash.PurgeInterval)
+	}
+	if c.ImageUpload.DirectEnabled {
+		v.positive("IMAGE_DIRECT_UPLOAD_URL_TTL", c.ImageUpload.URLTTL)
+		v.positive("IMAGE_DIRECT_UPLOAD_PURGE_INTERVAL", c.ImageUpload.PurgeInterval)
+	}
+	if c.ImageImport.Enabled {
+		v.between("IMAGE_IMPORT_MAX_FILES", c.ImageImport.MaxFiles, 1, 1000)
+		v.between("IMAGE_IMPORT_MAX_ARCHIVE_SIZE_MB", c.ImageImport.MaxArchiveSizeMB, 1, 1024)
+		v.positive("IMAGE_IMPORT_FETCH_TIMEOUT", c.ImageImport.FetchTimeout)
+		v.positive("IMAGE_IMPORT_POLL_INTERVAL", c.ImageImport.PollInterval)
+	}
+
+	// Payments
+	if c.BazaarPay.APIKey != "" {
+		v.url("BAZAARPAY_REDIRECT_URL", c.BazaarPay.RedirectURL)
+	}
+	if c.PlanTrial.Enabled {
+		v.positive("PLAN_TRIAL_EXPIRY_INTERVAL", c.PlanTrial.ExpiryInterval)
+		v.between("PLAN_TRIAL_BATCH_SIZE", c.PlanTrial.BatchSize, 1, 1000)
+	}
+
+	// Notifications
+	v.oneOf("EMAIL_PROVIDER", c.Email.Provider, "", "smtp", "sendgrid")
+	switch c.Email.Provider {
+	case "smtp":
+		v.required("SMTP_HOST", c.Email.SMTPHost)
+		v.port("SMTP_PORT", c.Email.SMTPPort)
+	case "sendgrid":
+		v.required("SENDGRID_API_KEY", c.Email.SendGridAPIKey)
+		v.url("SENDGRID_API_URL", c.Email.SendGridAPIURL)
+	}
+	if c.Digest.Enabled {
+		v.positive("NOTIFICATION_DIGEST_INTERVAL", c.Digest.Interval)
+	}
+	if c.NotifyQueue.Enabled {
+		v.between("NOTIFICATION_QUEUE_SIZE", c.NotifyQueue.Size, 1, 1000000)
+		v.between("NOTIFICATION_QUEUE_WORKERS", c.NotifyQueue.Workers, 1, 100)
+		v.positive("NOTIFICATION_QUEUE_ENQUEUE_WAIT", c.NotifyQueue.EnqueueWait)
+		// Kavenegar accepts up to 200 messages per sendarray call
+		v.between("NOTIFICATION_SMS_BATCH_SIZE", c.NotifyQueue.SMSBatchSize, 1, 200)
+		for channel, rate := range c.NotifyQueue.Rates {
+			v.oneOf("NOTIFICATION_SEND_RATES", channel, "email", "sms", "telegram", "push", "websocket")
+			if rate < 0 {
+				v.add("NOTIFICATION_SEND_RATES[%s]=%g must not be negative", channel, rate)
+			}
+		}
+	}
+	if c.Push.FCMCredentialsFile != "" {
+		v.url("FCM_API_URL", c.Push.FCMAPIURL)
+		v.positive("PUSH_DEVICE_PRUNE_INTERVAL", c.Push.PruneInterval)
+	}
+
+	// Worker and settings
+	v.positive("WORKER_HEARTBEAT_INTERVAL", c.WorkerQueue.HeartbeatInterval)
+	v.positive("SYSTEM_SETTINGS_REFRESH_INTERVAL", c.SystemSettings.RefreshInterval)
+	v.positive("FEATURE_FLAGS_CACHE_TTL", c.FeatureFlags.CacheTTL)
+	v.positive("PROMPT_TEMPLATES_CACHE_TTL", c.PromptTemplates.CacheTTL)
+	v.positive("NOTIFICATION_TEMPLATES_CACHE_TTL", c.NotifyTemplates.CacheTTL)
+	v.between("SUPPORT_MAX_OPEN_TICKETS", c.Support.MaxOpenTickets, 1, 100)
+	v.between("SUPPORT_MAX_MESSAGE_LENGTH", c.Support.MaxMessageLength, 1, 20000)
+	if c.Reputation.Enabled {
+		v.between("REPUTATION_PHONE_PREFIX_DIGITS", c.Reputation.PhonePrefixDigits, 3, 15)
+		v.positive("REPUTATION_WINDOW", c.Reputation.Window)
+		v.between("REPUTATION_PREFIX_THRESHOLD", c.Reputation.PrefixThreshold, 1, 100000)
+		v.between("REPUTATION_DEVICE_THRESHOLD", c.Reputation.DeviceThreshold, 1, 1000)
+		v.between("REPUTATION_ASN_THRESHOLD", c.Reputation.ASNThreshold, 1, 1000000)
+		v.between("REPUTATION_LIMIT_SCORE", c.Reputation.LimitScore, 1, 100)
+		v.between("REPUTATION_HOLD_SCORE", c.Reputation.HoldScore, 1, 100)
+		v.between("REPUTATION_LIMITED_CREDITS", c.Reputation.LimitedCredits, 0, 1000)
+		v.positive("REPUTATION_RELEASE_DELAY", c.Reputation.ReleaseDelay)
+		v.positive("REPUTATION_RELEASE_INTERVAL", c.Reputation.ReleaseInterval)
+		if c.Reputation.HoldScore < c.Reputation.LimitScore {
+			v.add("REPUTATION_HOLD_SCORE (%d) must not be below REPUTATION_LIMIT_SCORE (%d)", c.Reputation.HoldScore, c.Reputation.LimitScore)
+		}
+	}
+	if c.Verification.Enabled {
+		v.between("VENDOR_VERIFICATION_MAX_DOCUMENT_SIZE_MB", c.Verification.MaxDocumentSizeMB, 1, 100)
+		v.between("VENDOR_VERIFICATION_MAX_DOCUMENTS", c.Verification.MaxDocuments, 1, 50)
+	}
+	if c.AdminActivity.Enabled {
+		v.between("ADMIN_ACTIVITY_FAILURE_SPIKE_THRESHOLD", c.AdminActivity.SpikeThreshold, 1, 10000)
+		v.positive("ADMIN_ACTIVITY_FAILURE_SPIKE_WINDOW", c.AdminActivity.SpikeWindow)
+	}
+	if c.APIKey.DefaultRateLimit > c.APIKey.MaxRateLimit {
+		v.add("API_KEY_DEFAULT_RATE_LIMIT (%d) must not exceed API_KEY_MAX_RATE_LIMIT (%d)", c.APIKey.DefaultRateLimit, c.APIKey.MaxRateLimit)
+	}
+
+	// Internal gRPC API, always over mutual TLS
+	if c.InternalAPI.Enabled {
+		v.listenAddr("INTERNAL_API_ADDR", c.InternalAPI.Addr)
+		v.required("INTERNAL_API_TLS_CERT", c.InternalAPI.CertFile)
+		v.required("INTERNAL_API_TLS_KEY", c.InternalAPI.KeyFile)
+		v.required("INTERNAL_API_CLIENT_CA", c.InternalAPI.ClientCAFile)
+		if c.InternalAPI.MaxMessageSize <= 0 {
+			v.add("INTERNAL_API_MAX_MESSAGE_SIZE=%d must be positive", c.InternalAPI.MaxMessageSize)
+		}
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// validator collects configuration problems
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add("%s is required", key)
+	}
+}
+
+func (v *validator) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		v.add("%s=%d is not a valid port (1-65535)", key, port)
+	}
+}
+
+func (v *validator) between(key string, value, min, max int) {
+	if value < min || value > max {
+		v.add("%s=%d must be between %d and %d", key, value, min, max)
+	}
+}
+
+func (v *validator) ratio(key string, value float64) {
+	if value < 0 || value > 1 {
+		v.add("%s=%g must be between 0 and 1", key, value)
+	}
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	if d <= 0 {
+		v.add("%s=%s must be a positive duration", key, d)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	var names []string
+	for _, candidate := range allowed {
+		if candidate != "" {
+			names = append(names, candidate)
+		}
+	}
+	v.add("%s=%q is not supported (use %s)", key, value, strings.Join(names, ", "))
+}
+
+func (v *validator) url(key, value string) {
+	if value == "" {
+		v.add("%s is required", key)
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("%s=%q is not a valid http(s) URL", key, value)
+	}
+}
+
+func (v *validator) listenAddr(key, addr string) {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.add("%s=%q is not a valid listen address (e.g. :8080)", key, addr)
+		return
+	}
+	if port, err := strconv.Atoi(portStr); err != nil || port < 0 || port > 65535 {
+		v.add("%s=%q has an invalid port", key, addr)
+	}
+}
+
+// Summary describes the effective configuration for the startup log with
+// secrets redacted
+func (c *Config) Summary() string {
+	lines := []string{
+		fmt.Sprintf("environment=%s version=%s gin_mode=%s http_addr=%s log_level=%s",
+			c.Monitoring.Environment, c.Monitoring.Version, c.Server.GinMode, c.Server.HTTPAddr, c.Monitoring.LogLevel),
+		fmt.Sprintf("database: host=%s port=%d name=%s user=%s password=%s sslmode=%s auto_migrate=%t read_replicas=%d",
+			c.Database.Host, c.Database.Port, c.Database.Name, c.Database.User, redact(c.Database.Password),
+			c.Database.SSLMode, c.Database.AutoMigrate, len(c.Database.ReadReplicaDSNs)),
+		fmt.Sprintf("redis: addr=%s:%d db=%d password=%s", c.Redis.Host, c.Redis.Port, c.Redis.DB, redact(c.Redis.Password)),
+		fmt.Sprintf("jwt: secret=%s access_ttl=%s refresh_ttl=%s key_rotation=%t algorithm=%s",
+			redact(c.JWT.Secret), c.JWT.AccessTTL, c.JWT.RefreshTTL, c.JWT.KeyRotationEnabled, c.JWT.Algorithm),
+		fmt.Sprintf("sms: provider=%s api_key=%s fallbacks=%s", c.SMS.Provider, redact(c.SMS.APIKey), listOrNone(c.SMS.FallbackProviders)),
+		fmt.Sprintf("storage: backend=%s path=%s s3_bucket=%s s3_secret_key=%s signing_keys=%s",
+			c.Storage.Backend, c.Storage.StoragePath, orNone(c.Storage.S3Bucket), redact(c.Storage.S3SecretKey), redact(c.Storage.SigningKeys)),
+		fmt.Sprintf("cdn: base_url=%s provider=%s", orNone(c.Storage.CDNBaseURL), orNone(c.Storage.CDNProvider)),
+		fmt.Sprintf("storage_cleanup: enabled=%t schedule=%q", c.Storage.CleanupEnabled, c.Storage.CleanupSchedule),
+		fmt.Sprintf("gemini: base_url=%s model=%s api_key=%s monthly_budget=%g",
+			c.Gemini.BaseURL, c.Gemini.Model, redact(c.Gemini.APIKey), c.Gemini.MonthlyBudget),
+		fmt.Sprintf("moderation: enabled=%t provider=%s", c.Moderation.Enabled, c.Moderation.Provider),
+		fmt.Sprintf("input_check: enabled=%t provider=%s", c.InputCheck.Enabled, c.InputCheck.Provider),
+		fmt.Sprintf("email: provider=%s from=%s", orNone(c.Email.Provider), c.Email.FromAddress),
+		fmt.Sprintf("push: fcm=%t", c.Push.FCMCredentialsFile != ""),
+		fmt.Sprintf("notification_queue: enabled=%t size=%d workers=%d sms_batch=%d", c.NotifyQueue.Enabled, c.NotifyQueue.Size, c.NotifyQueue.Workers, c.NotifyQueue.SMSBatchSize),
+		fmt.Sprintf("internal_api: enabled=%t addr=%s allowed_clients=%s", c.InternalAPI.Enabled, c.InternalAPI.Addr, listOrNone(c.InternalAPI.AllowedClients)),
+		fmt.Sprintf("admin_activity: enabled=%t failure_spike=%d/%s", c.AdminActivity.Enabled, c.AdminActivity.SpikeThreshold, c.AdminActivity.SpikeWindow),
+		fmt.Sprintf("outbox: enabled=%t webhook_secret=%s", c.Outbox.Enabled, redact(c.Outbox.WebhookSecret)),
+		fmt.Sprintf("tracing: enabled=%t endpoint=%s sample_ratio=%g", c.Monitoring.TracingEnabled, c.Monitoring.OTLPEndpoint, c.Monitoring.TracingSampleRatio),
+		fmt.Sprintf("quota_enforcement=%t telegram_alerts=%t sentry=%t", c.Quota.Enabled, c.Monitoring.TelegramBotToken != "", c.Monitoring.SentryDSN != ""),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redact hides a secret, only telling whether it is set
+func redact(secret string) string {
+	if secret == "" {
+		return "(unset)"
+	}
+	return "****"
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,620 @@
+package conversion
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Conversion export statuses
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+// Conversion export defaults
+const (
+	DefaultExportRetention      = 24 * time.Hour
+	DefaultExportMaxConversions = 500
+	DefaultExportMaxSize        = 256 << 20
+	DefaultExportPollInterval   = 10 * time.Second
+
+	// ExportObjectPrefix is the object storage prefix of export archives
+	ExportObjectPrefix = "exports"
+
+	// exportStaleAfter is how long an export may stay processing before
+	// another instance takes it over
+	exportStaleAfter      = 30 * time.Minute
+	exportManifestName    = "manifest.json"
+	exportArchiveType     = "application/zip"
+	exportDefaultImageExt = ".jpg"
+)
+
+var (
+	// ErrExportNotFound is returned for exports that do not exist or have expired
+	ErrExportNotFound = fmt.Errorf("export %w", common.ErrNotFound)
+	// ErrExportsUnavailable is returned when exports are not configured
+	ErrExportsUnavailable = errors.New("conversion exports are not available")
+)
+
+// ConversionExport is a ZIP archive of a user's conversion results
+type ConversionExport struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"-"`
+	Status          string     `json:"status"`
+	ObjectKey       string     `json:"-"`
+	ConversionCount int        `json:"conversionCount"`
+	FileSize        int64      `json:"fileSize"`
+	Truncated       bool       `json:"truncated"` // the size limit left some results out
+	Error           *string    `json:"error,omitempty"`
+	DownloadURL     string     `json:"downloadUrl,omitempty"`
+	LinkExpiresAt   *time.Time `json:"linkExpiresAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+}
+
+// StatusCode answers 202 while the export is being built
+func (e ConversionExport) StatusCode() int {
+	if e.Status == ExportStatusPending || e.Status == ExportStatusProcessing {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// ExportItem is a completed conversion included in an export
+type ExportItem struct {
+	ConversionID   string     `json:"conversionId"`
+	StyleName      string     `json:"styleName,omitempty"`
+	UserImageID    string     `json:"userImageId"`
+	ClothImageID   string     `json:"clothImageId"`
+	ResultImageID  string     `json:"resultImageId"`
+	ResultImageURL string     `json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+}
+
+// exportManifestEntry is a conversion listed in the manifest, with the
+// archive path of its result image
+type exportManifestEntry struct {
+	ExportItem
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// exportManifest is manifest.json at the root of the archive
+type exportManifest struct {
+	ExportID    string                `json:"exportId"`
+	UserID      string                `json:"userId"`
+	GeneratedAt time.Time             `json:"generatedAt"`
+	Truncated   bool                  `json:"truncated"`
+	Conversions []exportManifestEntry `json:"conversions"`
+}
+
+// ExportStore stores conversion export requests
+type ExportStore interface {
+	// GetLatestExport returns the user's most recent export, or nil
+	GetLatestExport(ctx context.Context, userID string) (*ConversionExport, error)
+	GetExport(ctx context.Context, exportID string) (ConversionExport, error)
+	CreateExport(ctx context.Context, userID string) (ConversionExport, error)
+	// ClaimExport marks the oldest pending export, or one processing for
+	// longer than staleAfter, as processing and returns it; nil when none
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (*ConversionExport, error)
+	CompleteExport(ctx context.Context, export ConversionExport) error
+	FailExport(ctx context.Context, exportID, message string) error
+	// ListExportItems returns up to limit of the user's completed
+	// conversions with a result image, newest first
+	ListExportItems(ctx context.Context, userID string, limit int) ([]ExportItem, error)
+}
+
+// ExportStorage reads result images and stores and signs export archives
+type ExportStorage interface {
+	// ReadImage reads a stored image by its URL
+	ReadImage(ctx context.Context, imageURL string) ([]byte, error)
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+	// SignDownload signs a download of key at baseURL under the storage
+	// signed-URL policy
+	SignDownload(baseURL, key string) (string, time.Time)
+	VerifyDownload(key string, query url.Values) error
+}
+
+// ExportNotifier tells users their export finished
+type ExportNotifier interface {
+	SendConversionExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error
+	SendConversionExportFailed(ctx context.Context, userID, exportID string) error
+}
+
+// ExportConfig configures conversion exports
+type ExportConfig struct {
+	DownloadURL    string        // public URL of GET /conversions/exports/download
+	Retention      time.Duration // how long a ready export can be downloaded
+	MaxConversions int           // newest conversions included
+	MaxSize        int64         // result images stop being added past this many bytes
+	PollInterval   time.Duration // how often the worker looks for queued exports
+}
+
+// exporter builds conversion exports in the background
+type exporter struct {
+	store    ExportStore
+	storage  ExportStorage
+	notifier ExportNotifier
+	config   ExportConfig
+	wake     chan struct{}
+}
+
+// SetExports enables ZIP exports of conversion results. Exports are built by
+// StartExportWorker.
+func (s *Service) SetExports(store ExportStore, storage ExportStorage, notifier ExportNotifier, config ExportConfig) {
+	if config.Retention <= 0 {
+		config.Retention = DefaultExportRetention
+	}
+	if config.MaxConversions <= 0 {
+		config.MaxConversions = DefaultExportMaxConversions
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultExportMaxSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultExportPollInterval
+	}
+	s.exports = &exporter{
+		store:    store,
+		storage:  storage,
+		notifier: notifier,
+		config:   config,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// RequestExport returns the user's export in progress or still downloadable,
+// queueing a new one when there is none or refresh is set
+func (s *Service) RequestExport(ctx context.Context, userID string, refresh bool) (ConversionExport, error) {
+	if s.exports == nil {
+		return ConversionExport{}, ErrExportsUnavailable
+	}
+	e := s.exports
+
+	latest, err := e.store.GetLatestExport(ctx, userID)
+	if err != nil {
+		return ConversionExport{}, err
+	}
+	if latest != nil {
+		switch {
+		case latest.Status == ExportStatusPending || latest.Status == ExportStatusProcessing:
+			return *latest, nil
+		case latest.Status == ExportStatusReady && !refresh && latest.ExpiresAt != nil && time.Now().Before(*latest.ExpiresAt):
+			return e.withDownloadURL(*latest), nil
+		}
+	}
+
+	export, err := e.store.CreateExport(ctx, userID)
+	if err != nil {
+		return ConversionExport{}, err
+	}
+
+	// Wake the worker rather than waiting for the next poll
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return export, nil
+}
+
+// OpenExportDownload verifies a signed download of key and returns the archive
+func (s *Service) OpenExportDownload(ctx context.Context, key string, query url.Values) ([]byte, error) {
+	if s.exports == nil {
+		return nil, ErrExportsUnavailable
+	}
+	e := s.exports
+
+	exportID, ok := exportIDFromKey(key)
+	if !ok {
+		return nil, ErrExportNotFound
+	}
+	if err := e.storage.VerifyDownload(key, query); err != nil {
+		return nil, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, err.Error(), nil)
+	}
+
+	export, err := e.store.GetExport(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != ExportStatusReady || export.ObjectKey != key || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return nil, ErrExportNotFound
+	}
+	return e.storage.ReadObject(ctx, key)
+}
+
+// StartExportWorker builds queued exports until ctx is cancelled
+func (s *Service) StartExportWorker(ctx context.Context) {
+	if s.exports == nil {
+		return
+	}
+	e := s.exports
+
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.processQueued(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// processQueued builds exports until none is left to claim
+func (e *exporter) processQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := e.store.ClaimExport(ctx, exportStaleAfter)
+		if err != nil {
+			log.Printf("Conversion exports: failed to claim an export: %v", err)
+			return
+		}
+		if export == nil {
+			return
+		}
+		e.process(ctx, *export)
+	}
+}
+
+// process builds one export and notifies its user
+func (e *exporter) process(ctx context.Context, export ConversionExport) {
+	built, err := e.build(ctx, export)
+	if err != nil {
+		log.Printf("Conversion exports: export %s failed: %v", export.ID, err)
+		if err := e.store.FailExport(ctx, export.ID, "failed to build the export"); err != nil {
+			log.Printf("Conversion exports: failed to record failure of %s: %v", export.ID, err)
+		}
+		if err := e.notifier.SendConversionExportFailed(ctx, export.UserID, export.ID); err != nil {
+			log.Printf("Conversion exports: failed to notify %s: %v", export.UserID, err)
+		}
+		return
+	}
+
+	if err := e.store.CompleteExport(ctx, built); err != nil {
+		log.Printf("Conversion exports: failed to complete export %s: %v", export.ID, err)
+		return
+	}
+
+	built = e.withDownloadURL(built)
+	if err := e.notifier.SendConversionExportReady(ctx, built.UserID, built.ID, built.DownloadURL, *built.LinkExpiresAt); err != nil {
+		log.Printf("Conversion exports: failed to notify %s: %v", export.UserID, err)
+	}
+}
+
+// build writes the archive of an export to object storage
+func (e *exporter) build(ctx context.Context, export ConversionExport) (ConversionExport, error) {
+	items, err := e.store.ListExportItems(ctx, export.UserID, e.config.MaxConversions)
+	if err != nil {
+		return ConversionExport{}, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	manifest := exportManifest{
+		ExportID:    export.ID,
+		UserID:      export.UserID,
+		GeneratedAt: time.Now().UTC(),
+		Conversions: make([]exportManifestEntry, 0, len(items)),
+	}
+
+	var size int64
+	for _, item := range items {
+		entry := exportManifestEntry{ExportItem: item}
+		if manifest.Truncated {
+			manifest.Conversions = append(manifest.Conversions, entry)
+			continue
+		}
+
+		data, err := e.storage.ReadImage(ctx, item.ResultImageURL)
+		if err != nil {
+			log.Printf("Conversion exports: skipping result of %s: %v", item.ConversionID, err)
+			entry.Error = "result image unavailable"
+			manifest.Conversions = append(manifest.Conversions, entry)
+			continue
+		}
+		if size+int64(len(data)) > e.config.MaxSize {
+			manifest.Truncated = true
+			manifest.Conversions = append(manifest.Conversions, entry)
+			continue
+		}
+
+		// Images are already compressed, so they are stored as is
+		entry.File = "results/" + item.ConversionID + imageExt(item.ResultImageURL)
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: entry.File, Method: zip.Store, Modified: item.CreatedAt})
+		if err != nil {
+			return ConversionExport{}, fmt.Errorf("failed to add %s: %w", entry.File, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return ConversionExport{}, fmt.Errorf("failed to add %s: %w", entry.File, err)
+		}
+		size += int64(len(data))
+		manifest.Conversions = append(manifest.Conversions, entry)
+	}
+
+	w, err := archive.Create(exportManifestName)
+	if err != nil {
+		return ConversionExport{}, fmt.Errorf("failed to add manifest: %w", err)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return ConversionExport{}, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := archive.Close(); err != nil {
+		return ConversionExport{}, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	key := exportObjectKey(export.UserID, export.ID)
+	if err := e.storage.WriteObject(ctx, key, buf.Bytes(), exportArchiveType); err != nil {
+		return ConversionExport{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(e.config.Retention)
+	export.Status = ExportStatusReady
+	export.ObjectKey = key
+	export.ConversionCount = len(items)
+	export.FileSize = int64(buf.Len())
+	export.Truncated = manifest.Truncated
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return export, nil
+}
+
+// withDownloadURL attaches a freshly signed download link to a ready export
+func (e *exporter) withDownloadURL(export ConversionExport) ConversionExport {
+	if export.Status != ExportStatusReady || export.ObjectKey == "" {
+		return export
+	}
+	baseURL := strings.TrimSuffix(e.config.DownloadURL, "/") + "/" + export.ObjectKey
+	downloadURL, expiresAt := e.storage.SignDownload(baseURL, export.ObjectKey)
+	// The link never outlives the export
+	if export.ExpiresAt != nil && expiresAt.After(*export.ExpiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+	export.DownloadURL = downloadURL
+	export.LinkExpiresAt = &expiresAt
+	return export
+}
+
+// exportObjectKey is the storage key of an export archive
+func exportObjectKey(userID, exportID string) string {
+	return fmt.Sprintf("%s/%s/%s.zip", ExportObjectPrefix, userID, exportID)
+}
+
+// exportIDFromKey extracts the export ID from an archive key
+func exportIDFromKey(key string) (string, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != ExportObjectPrefix || !strings.HasSuffix(parts[2], ".zip") {
+		return "", false
+	}
+	return strings.TrimSuffix(parts[2], ".zip"), true
+}
+
+// imageExt returns the file extension of an image URL
+func imageExt(imageURL string) string {
+	if parsed, err := url.Parse(imageURL); err == nil {
+		imageURL = parsed.Path
+	}
+	if ext := strings.ToLower(path.Ext(imageURL)); ext != "" && len(ext) <= 5 {
+		return ext
+	}
+	return exportDefaultImageExt
+}
+
+func exportDoc(summary string) common.OperationDoc {
+	return common.OperationDoc{Summary: summary, Tags: []string{"Conversion Exports"}, Secured: true}
+}
+
+// exportError maps export errors to API errors
+func exportError(err error, message string) error {
+	var apiErr *common.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, ErrExportNotFound), errors.Is(err, common.ErrNotFound):
+		return common.NewAPIError(http.StatusNotFound, common.ErrCodeNotFound, "export not found", nil)
+	case errors.Is(err, ErrExportsUnavailable):
+		return common.NewAPIError(http.StatusServiceUnavailable, common.ErrCodeServiceUnavailable, err.Error(), nil)
+	}
+	return common.NewAPIError(http.StatusInternalServerError, common.ErrCodeInternal, message, nil)
+}
+
+// exportRequest is the query of GET /users/me/conversions/export
+type exportRequest struct {
+	Refresh bool `form:"refresh"`
+}
+
+// ExportConversionsEndpoint handles GET /users/me/conversions/export
+func (h *Handler) ExportConversionsEndpoint() *common.Endpoint {
+	doc := exportDoc("Export conversion results as a ZIP archive")
+	doc.Description = "Queues a ZIP of the user's result images and a JSON manifest and answers 202 " +
+		"until it is ready; the user is notified with a signed download link. A ready export " +
+		"is returned with a fresh link until it expires, refresh=true queues a new one."
+	return common.NewEndpoint(doc, h.exportConversions)
+}
+
+func (h *Handler) exportConversions(ctx context.Context, req *exportRequest) (*ConversionExport, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := h.service.RequestExport(ctx, userID, req.Refresh)
+	if err != nil {
+		return nil, exportError(err, "failed to export conversions")
+	}
+	return &export, nil
+}
+
+// DownloadExport handles GET /conversions/exports/download/*key, authorized
+// by the signature of the link rather than a bearer token
+func (h *Handler) DownloadExport(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	data, err := h.service.OpenExportDownload(c.Request.Context(), key, c.Request.URL.Query())
+	if err != nil {
+		common.RespondAPIError(c, common.FromError(exportError(err, "failed to download export"), http.StatusInternalServerError))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "conversions-"+path.Base(key)))
+	c.Data(http.StatusOK, exportArchiveType, data)
+}
+
+// dbExportStore implements ExportStore on PostgreSQL
+type dbExportStore struct {
+	db *sql.DB
+}
+
+// NewDBExportStore creates a new database-backed export store
+func NewDBExportStore(db *sql.DB) ExportStore {
+	return &dbExportStore{db: db}
+}
+
+const exportColumns = `id, user_id, status, COALESCE(object_key, ''), conversion_count, file_size,
+	truncated, error_message, created_at, completed_at, expires_at`
+
+// scanExport scans a row of exportColumns
+func scanExport(row interface{ Scan(...interface{}) error }) (ConversionExport, error) {
+	var export ConversionExport
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.ObjectKey, &export.ConversionCount,
+		&export.FileSize, &export.Truncated, &export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	return export, err
+}
+
+func (s *dbExportStore) GetLatestExport(ctx context.Context, userID string) (*ConversionExport, error) {
+	export, err := scanExport(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM conversion_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`, userID))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get latest export: %w", err)
+	}
+	return &export, nil
+}
+
+func (s *dbExportStore) GetExport(ctx context.Context, exportID string) (ConversionExport, error) {
+	export, err := scanExport(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM conversion_exports
+		WHERE id = $1`, exportID))
+	switch {
+	case err == sql.ErrNoRows:
+		return ConversionExport{}, ErrExportNotFound
+	case err != nil:
+		return ConversionExport{}, fmt.Errorf("failed to get export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *dbExportStore) CreateExport(ctx context.Context, userID string) (ConversionExport, error) {
+	export, err := scanExport(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		INSERT INTO conversion_exports (user_id)
+		VALUES ($1)
+		RETURNING `+exportColumns, userID))
+	if err != nil {
+		return ConversionExport{}, fmt.Errorf("failed to create export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *dbExportStore) ClaimExport(ctx context.Context, staleAfter time.Duration) (*ConversionExport, error) {
+	export, err := scanExport(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		UPDATE conversion_exports
+		SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM conversion_exports
+			WHERE status = 'pending'
+			   OR (status = 'processing' AND started_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportColumns, staleAfter.Seconds()))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim export: %w", err)
+	}
+	return &export, nil
+}
+
+func (s *dbExportStore) CompleteExport(ctx context.Context, export ConversionExport) error {
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE conversion_exports
+		SET status = 'ready', object_key = $2, conversion_count = $3, file_size = $4,
+		    truncated = $5, completed_at = $6, expires_at = $7
+		WHERE id = $1`,
+		export.ID, export.ObjectKey, export.ConversionCount, export.FileSize,
+		export.Truncated, export.CompletedAt, export.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+	return nil
+}
+
+func (s *dbExportStore) FailExport(ctx context.Context, exportID, message string) error {
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE conversion_exports
+		SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1`, exportID, message)
+	if err != nil {
+		return fmt.Errorf("failed to fail export: %w", err)
+	}
+	return nil
+}
+
+func (s *dbExportStore) ListExportItems(ctx context.Context, userID string, limit int) ([]ExportItem, error) {
+	rows, err := common.Conn(ctx, s.db).QueryContext(ctx, `
+		SELECT c.id, COALESCE(c.style_name, ''), c.user_image_id, c.cloth_image_id,
+		       c.result_image_id, ri.original_url, c.created_at, c.completed_at
+		FROM conversions c
+		JOIN images ri ON ri.id = c.result_image_id
+		WHERE c.user_id = $1 AND c.status = 'completed'
+		ORDER BY c.created_at DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []ExportItem{}
+	for rows.Next() {
+		var item ExportItem
+		if err := rows.Scan(&item.ConversionID, &item.StyleName, &item.UserImageID, &item.ClothImageID,
+			&item.ResultImageID, &item.ResultImageURL, &item.CreatedAt, &item.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan export item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
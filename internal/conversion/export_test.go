@@ -0,0 +1,285 @@
+package conversion
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type fakeExportStore struct {
+	exports map[string]*ConversionExport
+	items   []ExportItem
+	order   []string
+}
+
+func (f *fakeExportStore) GetLatestExport(ctx context.Context, userID string) (*ConversionExport, error) {
+	for i := len(f.order) - 1; i >= 0; i-- {
+		if export := f.exports[f.order[i]]; export.UserID == userID {
+			latest := *export
+			return &latest, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeExportStore) GetExport(ctx context.Context, exportID string) (ConversionExport, error) {
+	export, ok := f.exports[exportID]
+	if !ok {
+		return ConversionExport{}, ErrExportNotFound
+	}
+	return *export, nil
+}
+
+func (f *fakeExportStore) CreateExport(ctx context.Context, userID string) (ConversionExport, error) {
+	export := &ConversionExport{
+		ID:        fmt.Sprintf("export-%d", len(f.order)+1),
+		UserID:    userID,
+		Status:    ExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+	f.exports[export.ID] = export
+	f.order = append(f.order, export.ID)
+	return *export, nil
+}
+
+func (f *fakeExportStore) ClaimExport(ctx context.Context, staleAfter time.Duration) (*ConversionExport, error) {
+	for _, id := range f.order {
+		if export := f.exports[id]; export.Status == ExportStatusPending {
+			export.Status = ExportStatusProcessing
+			claimed := *export
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeExportStore) CompleteExport(ctx context.Context, export ConversionExport) error {
+	f.exports[export.ID] = &export
+	return nil
+}
+
+func (f *fakeExportStore) FailExport(ctx context.Context, exportID, message string) error {
+	f.exports[exportID].Status = ExportStatusFailed
+	f.exports[exportID].Error = &message
+	return nil
+}
+
+func (f *fakeExportStore) ListExportItems(ctx context.Context, userID string, limit int) ([]ExportItem, error) {
+	if len(f.items) > limit {
+		return f.items[:limit], nil
+	}
+	return f.items, nil
+}
+
+// fakeExportStorage signs links with a fixed signature
+type fakeExportStorage struct {
+	images  map[string][]byte
+	objects map[string][]byte
+}
+
+func (f *fakeExportStorage) ReadImage(ctx context.Context, imageURL string) ([]byte, error) {
+	data, ok := f.images[imageURL]
+	if !ok {
+		return nil, errors.New("missing image")
+	}
+	return data, nil
+}
+
+func (f *fakeExportStorage) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	return f.objects[key], nil
+}
+
+func (f *fakeExportStorage) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	f.objects[key] = data
+	return nil
+}
+
+func (f *fakeExportStorage) SignDownload(baseURL, key string) (string, time.Time) {
+	return baseURL + "?signature=valid", time.Now().Add(15 * time.Minute)
+}
+
+func (f *fakeExportStorage) VerifyDownload(key string, query url.Values) error {
+	if query.Get("signature") != "valid" {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+type fakeExportNotifier struct {
+	ready  []string // download URLs
+	failed []string // export IDs
+}
+
+func (f *fakeExportNotifier) SendConversionExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error {
+	f.ready = append(f.ready, downloadURL)
+	return nil
+}
+
+func (f *fakeExportNotifier) SendConversionExportFailed(ctx context.Context, userID, exportID string) error {
+	f.failed = append(f.failed, exportID)
+	return nil
+}
+
+func newExportTestService(maxSize int64) (*Service, *fakeExportStore, *fakeExportStorage, *fakeExportNotifier) {
+	service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	store := &fakeExportStore{
+		exports: make(map[string]*ConversionExport),
+		items: []ExportItem{
+			{ConversionID: "c2", ResultImageID: "r2", ResultImageURL: "https://cdn.example.com/results/r2.png"},
+			{ConversionID: "c1", ResultImageID: "r1", ResultImageURL: "https://cdn.example.com/results/r1.jpg"},
+			{ConversionID: "c0", ResultImageID: "r0", ResultImageURL: "https://cdn.example.com/results/gone.jpg"},
+		},
+	}
+	storage := &fakeExportStorage{
+		images: map[string][]byte{
+			"https://cdn.example.com/results/r2.png": bytes.Repeat([]byte("2"), 100),
+			"https://cdn.example.com/results/r1.jpg": bytes.Repeat([]byte("1"), 100),
+		},
+		objects: make(map[string][]byte),
+	}
+	notifier := &fakeExportNotifier{}
+	service.SetExports(store, storage, notifier, ExportConfig{
+		DownloadURL: "https://api.example.com/api/conversions/exports/download",
+		MaxSize:     maxSize,
+	})
+	return service, store, storage, notifier
+}
+
+// readExportArchive returns the files of an archive and its manifest
+func readExportArchive(t *testing.T, data []byte) (map[string][]byte, exportManifest) {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a valid ZIP, got %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		files[file.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(files[exportManifestName], &manifest); err != nil {
+		t.Fatalf("Expected a JSON manifest, got %v", err)
+	}
+	return files, manifest
+}
+
+func TestRequestExport_QueuesOnce(t *testing.T) {
+	service, store, _, _ := newExportTestService(0)
+	ctx := context.Background()
+
+	export, err := service.RequestExport(ctx, "u1", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if export.Status != ExportStatusPending || export.StatusCode() != http.StatusAccepted {
+		t.Errorf("Expected a pending export answered with 202, got %s (%d)", export.Status, export.StatusCode())
+	}
+
+	again, _ := service.RequestExport(ctx, "u1", true)
+	if again.ID != export.ID || len(store.order) != 1 {
+		t.Errorf("Expected the pending export to be reused, got %s and %d exports", again.ID, len(store.order))
+	}
+}
+
+func TestExportWorker_BuildsArchive(t *testing.T) {
+	service, store, storage, notifier := newExportTestService(0)
+	ctx := context.Background()
+
+	export, _ := service.RequestExport(ctx, "u1", false)
+	service.exports.processQueued(ctx)
+
+	built := store.exports[export.ID]
+	if built.Status != ExportStatusReady || built.ConversionCount != 3 || built.Truncated {
+		t.Fatalf("Expected a ready export of 3 conversions, got %+v", built)
+	}
+	if len(notifier.ready) != 1 || !strings.Contains(notifier.ready[0], "/exports/u1/"+export.ID+".zip?signature=valid") {
+		t.Errorf("Expected a signed download link to be sent, got %v", notifier.ready)
+	}
+
+	files, manifest := readExportArchive(t, storage.objects[built.ObjectKey])
+	if len(files["results/c2.png"]) != 100 || len(files["results/c1.jpg"]) != 100 {
+		t.Errorf("Expected both result images in the archive, got %d files", len(files))
+	}
+	if len(manifest.Conversions) != 3 || manifest.Conversions[2].File != "" || manifest.Conversions[2].Error == "" {
+		t.Errorf("Expected the unreadable result listed with an error, got %+v", manifest.Conversions)
+	}
+
+	ready, _ := service.RequestExport(ctx, "u1", false)
+	if ready.ID != export.ID || ready.DownloadURL == "" || ready.StatusCode() != http.StatusOK {
+		t.Errorf("Expected the ready export with a fresh link, got %+v", ready)
+	}
+	if refreshed, _ := service.RequestExport(ctx, "u1", true); refreshed.ID == export.ID {
+		t.Error("Expected refresh to queue a new export")
+	}
+}
+
+func TestExportWorker_SizeLimit(t *testing.T) {
+	service, store, storage, _ := newExportTestService(150)
+	ctx := context.Background()
+
+	export, _ := service.RequestExport(ctx, "u1", false)
+	service.exports.processQueued(ctx)
+
+	built := store.exports[export.ID]
+	if !built.Truncated {
+		t.Fatalf("Expected the export to be truncated, got %+v", built)
+	}
+	files, manifest := readExportArchive(t, storage.objects[built.ObjectKey])
+	if _, ok := files["results/c1.jpg"]; ok || len(files["results/c2.png"]) != 100 {
+		t.Errorf("Expected only the newest result in the archive, got %d files", len(files))
+	}
+	if !manifest.Truncated || len(manifest.Conversions) != 3 {
+		t.Errorf("Expected a truncated manifest listing every conversion, got %+v", manifest)
+	}
+}
+
+func TestOpenExportDownload(t *testing.T) {
+	service, store, _, _ := newExportTestService(0)
+	ctx := context.Background()
+
+	export, _ := service.RequestExport(ctx, "u1", false)
+	service.exports.processQueued(ctx)
+	key := store.exports[export.ID].ObjectKey
+
+	if _, err := service.OpenExportDownload(ctx, key, url.Values{"signature": {"forged"}}); common.FromError(err, http.StatusInternalServerError).Status != http.StatusForbidden {
+		t.Errorf("Expected a forged link to be forbidden, got %v", err)
+	}
+	data, err := service.OpenExportDownload(ctx, key, url.Values{"signature": {"valid"}})
+	if err != nil || len(data) == 0 {
+		t.Fatalf("Expected the archive, got %d bytes: %v", len(data), err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	store.exports[export.ID].ExpiresAt = &expired
+	if _, err := service.OpenExportDownload(ctx, key, url.Values{"signature": {"valid"}}); !errors.Is(err, ErrExportNotFound) {
+		t.Errorf("Expected an expired export to be gone, got %v", err)
+	}
+	if _, err := service.OpenExportDownload(ctx, "exports/u1/../secret", url.Values{"signature": {"valid"}}); !errors.Is(err, ErrExportNotFound) {
+		t.Errorf("Expected a malformed key to be rejected, got %v", err)
+	}
+}
+
+func TestExports_Unavailable(t *testing.T) {
+	service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	if _, err := service.RequestExport(context.Background(), "u1", false); !errors.Is(err, ErrExportsUnavailable) {
+		t.Errorf("Expected ErrExportsUnavailable, got %v", err)
+	}
+}
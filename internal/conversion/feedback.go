@@ -0,0 +1,180 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// Issues users can flag on a conversion result
+const (
+	FeedbackIssueGarmentMisfit     = "garment_misfit"
+	FeedbackIssueArtifacts         = "artifacts"
+	FeedbackIssueWrongColor        = "wrong_color"
+	FeedbackIssueFaceChanged       = "face_changed"
+	FeedbackIssueBackgroundChanged = "background_changed"
+	FeedbackIssueOther             = "other"
+)
+
+// feedbackIssues is the set of accepted issue flags
+var feedbackIssues = map[string]bool{
+	FeedbackIssueGarmentMisfit:     true,
+	FeedbackIssueArtifacts:         true,
+	FeedbackIssueWrongColor:        true,
+	FeedbackIssueFaceChanged:       true,
+	FeedbackIssueBackgroundChanged: true,
+	FeedbackIssueOther:             true,
+}
+
+// MaxFeedbackCommentLength bounds the free-text comment of a rating
+const MaxFeedbackCommentLength = 1000
+
+var (
+	// ErrFeedbackNotAllowed is returned when the conversion has no result to rate
+	ErrFeedbackNotAllowed = fmt.Errorf("%w: only completed conversions can be rated", common.ErrConflict)
+	// ErrFeedbackUnavailable is returned when feedback is not configured
+	ErrFeedbackUnavailable = errors.New("conversion feedback is not available")
+)
+
+// FeedbackRequest is the body of POST /conversions/{id}/feedback
+type FeedbackRequest struct {
+	Rating  int      `json:"rating" binding:"required,min=1,max=5"`
+	Issues  []string `json:"issues,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+// Feedback is a user's rating of a conversion result. The style and the
+// provider that produced the result are captured for the admin analytics.
+type Feedback struct {
+	ID           string    `json:"id"`
+	ConversionID string    `json:"conversionId"`
+	Rating       int       `json:"rating"`
+	Issues       []string  `json:"issues"`
+	Comment      string    `json:"comment,omitempty"`
+	StyleName    string    `json:"styleName,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// FeedbackStore stores conversion ratings
+type FeedbackStore interface {
+	// SaveFeedback creates the user's rating of a conversion, or replaces it
+	// when they rate the same conversion again
+	SaveFeedback(ctx context.Context, conversionID, userID string, req FeedbackRequest) (Feedback, error)
+}
+
+// SetFeedback enables rating conversion results
+func (s *Service) SetFeedback(store FeedbackStore) {
+	s.feedbackStore = store
+}
+
+// SubmitFeedback records the user's rating of one of their completed conversions
+func (s *Service) SubmitFeedback(ctx context.Context, conversionID, userID string, req FeedbackRequest) (Feedback, error) {
+	if s.feedbackStore == nil {
+		return Feedback{}, ErrFeedbackUnavailable
+	}
+
+	req, err := normalizeFeedback(req)
+	if err != nil {
+		return Feedback{}, err
+	}
+
+	conversion, err := s.store.GetConversion(ctx, conversionID)
+	if err != nil {
+		return Feedback{}, fmt.Errorf("failed to get conversion: %w", err)
+	}
+	if conversion.UserID != userID {
+		return Feedback{}, fmt.Errorf("conversion not found")
+	}
+	if conversion.Status != ConversionStatusCompleted {
+		return Feedback{}, ErrFeedbackNotAllowed
+	}
+
+	return s.feedbackStore.SaveFeedback(ctx, conversionID, userID, req)
+}
+
+// normalizeFeedback validates a rating and drops duplicate issue flags
+func normalizeFeedback(req FeedbackRequest) (FeedbackRequest, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return req, fmt.Errorf("%w: rating must be between 1 and 5", common.ErrValidation)
+	}
+
+	req.Comment = strings.TrimSpace(req.Comment)
+	if len([]rune(req.Comment)) > MaxFeedbackCommentLength {
+		return req, fmt.Errorf("%w: comment must be at most %d characters", common.ErrValidation, MaxFeedbackCommentLength)
+	}
+
+	seen := make(map[string]bool, len(req.Issues))
+	issues := make([]string, 0, len(req.Issues))
+	for _, issue := range req.Issues {
+		issue = strings.ToLower(strings.TrimSpace(issue))
+		if !feedbackIssues[issue] {
+			return req, fmt.Errorf("%w: unknown issue %q", common.ErrValidation, issue)
+		}
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	req.Issues = issues
+	return req, nil
+}
+
+// dbFeedbackStore implements FeedbackStore on top of the conversion_feedback table
+type dbFeedbackStore struct {
+	db *sql.DB
+}
+
+// NewDBFeedbackStore creates a new database-backed feedback store
+func NewDBFeedbackStore(db *sql.DB) FeedbackStore {
+	return &dbFeedbackStore{db: db}
+}
+
+// SaveFeedback upserts the rating. The provider is the one whose attempt
+// succeeded according to the conversion log.
+func (s *dbFeedbackStore) SaveFeedback(ctx context.Context, conversionID, userID string, req FeedbackRequest) (Feedback, error) {
+	feedback := Feedback{
+		ConversionID: conversionID,
+		Rating:       req.Rating,
+		Issues:       req.Issues,
+		Comment:      req.Comment,
+	}
+
+	var styleName, provider sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO conversion_feedback (conversion_id, user_id, rating, issues, comment, style_name, provider)
+		SELECT c.id, $2, $3, $4, $5, c.style_name,
+		       (SELECT l.metadata->>'provider'
+		        FROM conversion_logs l
+		        WHERE l.conversion_id = c.id AND l.stage = 'provider' AND l.level = 'info'
+		        ORDER BY l.id DESC
+		        LIMIT 1)
+		FROM conversions c
+		WHERE c.id = $1
+		ON CONFLICT (conversion_id) DO UPDATE
+		SET rating = EXCLUDED.rating,
+		    issues = EXCLUDED.issues,
+		    comment = EXCLUDED.comment,
+		    updated_at = NOW()
+		RETURNING id, style_name, provider, created_at, updated_at`,
+		conversionID, userID, req.Rating, pq.Array(req.Issues), req.Comment,
+	).Scan(&feedback.ID, &styleName, &provider, &feedback.CreatedAt, &feedback.UpdatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		return Feedback{}, fmt.Errorf("conversion not found")
+	case err != nil:
+		return Feedback{}, fmt.Errorf("failed to save feedback: %w", err)
+	}
+
+	feedback.StyleName = styleName.String
+	feedback.Provider = provider.String
+	return feedback, nil
+}
@@ -0,0 +1,84 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ai-styler/internal/common"
+)
+
+type fakeFeedbackStore struct {
+	saved map[string]FeedbackRequest
+}
+
+func (f *fakeFeedbackStore) SaveFeedback(ctx context.Context, conversionID, userID string, req FeedbackRequest) (Feedback, error) {
+	f.saved[conversionID] = req
+	return Feedback{ConversionID: conversionID, Rating: req.Rating, Issues: req.Issues, Comment: req.Comment}, nil
+}
+
+func newFeedbackTestService() (*Service, *fakeFeedbackStore) {
+	store := newMockStore()
+	store.conversions["done"] = Conversion{ID: "done", UserID: "u1", Status: ConversionStatusCompleted}
+	store.conversions["pending"] = Conversion{ID: "pending", UserID: "u1", Status: ConversionStatusPending}
+	service := NewService(store, &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	feedbackStore := &fakeFeedbackStore{saved: make(map[string]FeedbackRequest)}
+	service.SetFeedback(feedbackStore)
+	return service, feedbackStore
+}
+
+func TestSubmitFeedback(t *testing.T) {
+	service, feedbackStore := newFeedbackTestService()
+	ctx := context.Background()
+
+	feedback, err := service.SubmitFeedback(ctx, "done", "u1", FeedbackRequest{
+		Rating:  2,
+		Issues:  []string{"Garment_Misfit", "artifacts", "garment_misfit"},
+		Comment: "  sleeves are too long  ",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(feedback.Issues) != 2 || feedback.Issues[0] != FeedbackIssueGarmentMisfit || feedback.Issues[1] != FeedbackIssueArtifacts {
+		t.Errorf("Expected normalized issues [garment_misfit artifacts], got %v", feedback.Issues)
+	}
+	if feedbackStore.saved["done"].Comment != "sleeves are too long" {
+		t.Errorf("Expected trimmed comment, got %q", feedbackStore.saved["done"].Comment)
+	}
+}
+
+func TestSubmitFeedback_Rejected(t *testing.T) {
+	service, feedbackStore := newFeedbackTestService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		conversionID string
+		userID       string
+		req          FeedbackRequest
+		check        func(error) bool
+	}{
+		{"rating out of range", "done", "u1", FeedbackRequest{Rating: 6}, func(err error) bool { return errors.Is(err, common.ErrValidation) }},
+		{"unknown issue", "done", "u1", FeedbackRequest{Rating: 3, Issues: []string{"too_dark"}}, func(err error) bool { return errors.Is(err, common.ErrValidation) }},
+		{"not completed", "pending", "u1", FeedbackRequest{Rating: 3}, func(err error) bool { return errors.Is(err, ErrFeedbackNotAllowed) }},
+		{"other user", "done", "u2", FeedbackRequest{Rating: 3}, func(err error) bool { return err != nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.SubmitFeedback(ctx, tt.conversionID, tt.userID, tt.req); !tt.check(err) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+	if len(feedbackStore.saved) != 0 {
+		t.Errorf("Expected no feedback to be saved, got %d", len(feedbackStore.saved))
+	}
+
+	unconfigured := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	if _, err := unconfigured.SubmitFeedback(ctx, "done", "u1", FeedbackRequest{Rating: 5}); !errors.Is(err, ErrFeedbackUnavailable) {
+		t.Errorf("Expected ErrFeedbackUnavailable, got %v", err)
+	}
+}
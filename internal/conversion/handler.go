@@ -2,7 +2,7 @@ package conversion
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"ai-styler/internal/common"
+	"ai-styler/internal/quota"
 )
 
 // Handler provides HTTP handlers for conversion operations
@@ -47,35 +48,24 @@ func (h *Handler) CreateConversionImmediate(w http.ResponseWriter, r *http.Reque
 	}
 
 	var req ConversionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid request body", nil)
+	if err := common.DecodeJSON(r, &req); err != nil {
+		common.WriteAPIError(w, common.FromError(err, http.StatusBadRequest))
 		return
 	}
-
-	// Validate required fields using helper methods that support both formats
 	userImageID := req.GetUserImageID()
 	clothImageID := req.GetClothImageID()
 
-	if userImageID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "userImageId or user_image_id is required", nil)
-		return
-	}
-	if clothImageID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "clothImageId or cloth_image_id is required", nil)
-		return
-	}
-
-	// Validate that user image and cloth image are different
-	if userImageID == clothImageID {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "user image and cloth image must be different", nil)
-		return
-	}
-
 	// Create a normalized request with the extracted values
 	normalizedReq := ConversionRequest{
 		UserImageID:  userImageID,
 		ClothImageID: clothImageID,
 		StyleName:    req.GetStyleName(),
+		PresetID:     req.GetPresetID(),
+		Garments:     req.Garments,
+		ScheduledAt:  req.ScheduledAt,
+		OutputWidth:  req.OutputWidth,
+		OutputHeight: req.OutputHeight,
+		AspectRatio:  req.AspectRatio,
 	}
 
 	conversion, err := h.service.CreateConversion(r.Context(), userID, normalizedReq)
@@ -95,6 +85,10 @@ func (h *Handler) CreateConversionImmediate(w http.ResponseWriter, r *http.Reque
 			common.WriteError(w, http.StatusTooManyRequests, "rate_limit_exceeded", err.Error(), nil)
 			return
 		}
+		if errors.Is(err, ErrSchedulingUnavailable) {
+			common.WriteError(w, http.StatusServiceUnavailable, common.ErrCodeServiceUnavailable, err.Error(), nil)
+			return
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			common.WriteError(w, http.StatusForbidden, "access_denied", "You do not have permission to access one or more of the specified images", nil)
 			return
@@ -126,30 +120,13 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 	}
 
 	var req ConversionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid request body", nil)
+	if err := common.DecodeJSON(r, &req); err != nil {
+		common.WriteAPIError(w, common.FromError(err, http.StatusBadRequest))
 		return
 	}
-
-	// Validate required fields
 	userImageID := req.GetUserImageID()
 	clothImageID := req.GetClothImageID()
 
-	if userImageID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "userImageId or user_image_id is required", nil)
-		return
-	}
-	if clothImageID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "clothImageId or cloth_image_id is required", nil)
-		return
-	}
-
-	// Validate that user image and cloth image are different
-	if userImageID == clothImageID {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "user image and cloth image must be different", nil)
-		return
-	}
-
 	// Check if mock mode is enabled
 	if r.URL.Query().Get("mock") == "true" {
 		h.returnMockConversion(w, r, userID, userImageID, clothImageID)
@@ -161,6 +138,12 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 		UserImageID:  userImageID,
 		ClothImageID: clothImageID,
 		StyleName:    req.GetStyleName(),
+		PresetID:     req.GetPresetID(),
+		Garments:     req.Garments,
+		ScheduledAt:  req.ScheduledAt,
+		OutputWidth:  req.OutputWidth,
+		OutputHeight: req.OutputHeight,
+		AspectRatio:  req.AspectRatio,
 	}
 
 	// Create conversion
@@ -180,6 +163,10 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 			common.WriteError(w, http.StatusTooManyRequests, "rate_limit_exceeded", err.Error(), nil)
 			return
 		}
+		if errors.Is(err, ErrSchedulingUnavailable) {
+			common.WriteError(w, http.StatusServiceUnavailable, common.ErrCodeServiceUnavailable, err.Error(), nil)
+			return
+		}
 		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not accessible") || strings.Contains(err.Error(), "must be different") {
 			common.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
 			return
@@ -188,6 +175,12 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// A scheduled conversion runs later, so there is nothing to wait for
+	if conversion.ScheduledAt != nil {
+		common.WriteJSON(w, http.StatusAccepted, conversion)
+		return
+	}
+
 	// Parse timeout and poll interval from query parameters
 	// This must be done before setting headers to ensure proper error handling
 	timeout := 5 * time.Minute // Default: 5 minutes
@@ -223,7 +216,7 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering for long polling
 
 	// If conversion is already completed or failed, return immediately
-	if conversion.Status == ConversionStatusCompleted || conversion.Status == ConversionStatusFailed {
+	if conversion.IsFinished() {
 		common.WriteJSON(w, http.StatusOK, conversion)
 		// Flush response to ensure it's sent immediately
 		if flusher, ok := w.(http.Flusher); ok {
@@ -242,7 +235,7 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 
 	// Quick check before starting watch loop - worker might have already finished
 	quickCheck, err := h.service.GetConversion(ctx, conversion.ID, userID)
-	if err == nil && (quickCheck.Status == ConversionStatusCompleted || quickCheck.Status == ConversionStatusFailed) {
+	if err == nil && quickCheck.IsFinished() {
 		common.WriteJSON(w, http.StatusOK, quickCheck)
 		if flusher, ok := w.(http.Flusher); ok {
 			flusher.Flush()
@@ -282,231 +275,443 @@ func (h *Handler) CreateConversionWithWait(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// GetConversion handles GET /conversion/{id}
-func (h *Handler) GetConversion(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
+// conversionIDRequest identifies a conversion by its path parameter
+type conversionIDRequest struct {
+	ID string `uri:"id" json:"-" binding:"required"`
+}
+
+// updateConversionRequest is the body of PUT /conversion/{id}
+type updateConversionRequest struct {
+	ID string `uri:"id" json:"-" binding:"required"`
+	UpdateConversionRequest
+}
+
+// listConversionsRequest holds the query parameters of GET /conversions
+type listConversionsRequest struct {
+	Page     int    `form:"page" json:"-"`
+	PageSize int    `form:"pageSize" json:"-"`
+	Status   string `form:"status" json:"-"`
+	Cursor   string `form:"cursor" json:"-"`
+}
+
+// conversionMetricsRequest holds the query parameters of GET /convert/metrics
+type conversionMetricsRequest struct {
+	TimeRange string `form:"timeRange" json:"-"`
+}
+
+// processingStatusResponse is the body of GET /conversion/{id}/status
+type processingStatusResponse struct {
+	Status string `json:"status"`
+}
+
+// conversionDoc builds the OpenAPI metadata shared by conversion endpoints
+func conversionDoc(summary string) common.OperationDoc {
+	return common.OperationDoc{Summary: summary, Tags: []string{"Conversions"}, Secured: true}
+}
+
+// requireUser returns the authenticated user ID from the context
+func requireUser(ctx context.Context) (string, error) {
+	userID := common.GetUserIDFromContext(ctx)
 	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
+		return "", common.NewAPIError(http.StatusUnauthorized, "", "user not authenticated", nil)
 	}
+	return userID, nil
+}
 
-	conversionID := getPathParam(r, "id")
-	if conversionID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "conversion ID is required", nil)
-		return
+// conversionError maps service errors to API errors, hiding internal details
+func conversionError(err error, message string) error {
+	if strings.Contains(err.Error(), "not found") {
+		return common.NewAPIError(http.StatusNotFound, "", "conversion not found", nil)
+	}
+	return common.NewAPIError(http.StatusInternalServerError, "", message, nil)
+}
+
+// GetConversionEndpoint handles GET /conversion/{id}
+func (h *Handler) GetConversionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Get a conversion"), h.getConversion)
+}
+
+// GetConversion handles GET /conversion/{id}
+func (h *Handler) GetConversion(w http.ResponseWriter, r *http.Request) {
+	h.GetConversionEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) getConversion(ctx context.Context, req *conversionIDRequest) (*ConversionResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	conversion, err := h.service.GetConversion(r.Context(), conversionID, userID)
+	conversion, err := h.service.GetConversion(ctx, req.ID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			common.WriteError(w, http.StatusNotFound, "not_found", "conversion not found", nil)
-			return
-		}
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get conversion", nil)
-		return
+		return nil, conversionError(err, "failed to get conversion")
 	}
 
-	common.WriteJSON(w, http.StatusOK, conversion)
+	return &conversion, nil
+}
+
+// ListConversionsEndpoint handles GET /conversions
+func (h *Handler) ListConversionsEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("List the user's conversions"), h.listConversions)
 }
 
 // ListConversions handles GET /conversions
 func (h *Handler) ListConversions(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
+	h.ListConversionsEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) listConversions(ctx context.Context, req *listConversionsRequest) (*ConversionListResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse query parameters
-	req := ConversionListRequest{
+	listReq := ConversionListRequest{
 		Page:     1,
 		PageSize: DefaultPageSize,
+		Status:   req.Status,
+		Cursor:   req.Cursor,
 	}
-
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			req.Page = page
-		}
+	if req.Page > 0 {
+		listReq.Page = req.Page
 	}
-
-	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= MaxPageSize {
-			req.PageSize = pageSize
-		}
+	if req.PageSize > 0 && req.PageSize <= MaxPageSize {
+		listReq.PageSize = req.PageSize
 	}
 
-	if status := r.URL.Query().Get("status"); status != "" {
-		req.Status = status
+	conversions, err := h.service.ListConversions(ctx, userID, listReq)
+	if errors.Is(err, common.ErrValidation) {
+		return nil, err
 	}
-
-	conversions, err := h.service.ListConversions(r.Context(), userID, req)
 	if err != nil {
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to list conversions", nil)
-		return
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "failed to list conversions", nil)
 	}
 
-	common.WriteJSON(w, http.StatusOK, conversions)
+	return &conversions, nil
+}
+
+// UpdateConversionEndpoint handles PUT /conversion/{id}
+func (h *Handler) UpdateConversionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Update a conversion"), h.updateConversion)
 }
 
 // UpdateConversion handles PUT /conversion/{id}
 func (h *Handler) UpdateConversion(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
-	}
+	h.UpdateConversionEndpoint().ServeHTTP(w, r)
+}
 
-	conversionID := getPathParam(r, "id")
-	if conversionID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "conversion ID is required", nil)
-		return
+func (h *Handler) updateConversion(ctx context.Context, req *updateConversionRequest) (*common.MessageResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var req UpdateConversionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid request body", nil)
-		return
+	if err := h.service.UpdateConversion(ctx, req.ID, userID, req.UpdateConversionRequest); err != nil {
+		return nil, conversionError(err, "failed to update conversion")
 	}
 
-	err := h.service.UpdateConversion(r.Context(), conversionID, userID, req)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			common.WriteError(w, http.StatusNotFound, "not_found", "conversion not found", nil)
-			return
-		}
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to update conversion", nil)
-		return
-	}
+	return &common.MessageResponse{Message: "conversion updated successfully"}, nil
+}
 
-	common.WriteJSON(w, http.StatusOK, map[string]string{"message": "conversion updated successfully"})
+// DeleteConversionEndpoint handles DELETE /conversion/{id}
+func (h *Handler) DeleteConversionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Delete a conversion"), h.deleteConversion)
 }
 
 // DeleteConversion handles DELETE /conversion/{id}
 func (h *Handler) DeleteConversion(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
-	}
+	h.DeleteConversionEndpoint().ServeHTTP(w, r)
+}
 
-	conversionID := getPathParam(r, "id")
-	if conversionID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "conversion ID is required", nil)
-		return
+func (h *Handler) deleteConversion(ctx context.Context, req *conversionIDRequest) (*common.MessageResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	err := h.service.DeleteConversion(r.Context(), conversionID, userID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			common.WriteError(w, http.StatusNotFound, "not_found", "conversion not found", nil)
-			return
-		}
+	if err := h.service.DeleteConversion(ctx, req.ID, userID); err != nil {
 		if strings.Contains(err.Error(), "cannot delete") {
-			common.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
-			return
+			return nil, common.NewAPIError(http.StatusBadRequest, "", err.Error(), nil)
 		}
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to delete conversion", nil)
-		return
+		return nil, conversionError(err, "failed to delete conversion")
 	}
 
-	common.WriteJSON(w, http.StatusOK, map[string]string{"message": "conversion deleted successfully"})
+	return &common.MessageResponse{Message: "conversion deleted successfully"}, nil
+}
+
+// GetQuotaStatusEndpoint handles GET /quota
+func (h *Handler) GetQuotaStatusEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Get the user's conversion quota"), h.getQuotaStatus)
 }
 
 // GetQuotaStatus handles GET /quota
 func (h *Handler) GetQuotaStatus(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
+	h.GetQuotaStatusEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) getQuotaStatus(ctx context.Context, _ *common.NoRequest) (*QuotaCheck, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	quota, err := h.service.GetQuotaStatus(r.Context(), userID)
+	quota, err := h.service.GetQuotaStatus(ctx, userID)
 	if err != nil {
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get quota status", nil)
-		return
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "failed to get quota status", nil)
 	}
 
-	common.WriteJSON(w, http.StatusOK, quota)
+	return &quota, nil
+}
+
+// CancelConversionEndpoint handles POST /conversion/{id}/cancel
+func (h *Handler) CancelConversionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Cancel a pending conversion"), h.cancelConversion)
 }
 
 // CancelConversion handles POST /conversion/{id}/cancel
 func (h *Handler) CancelConversion(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
+	h.CancelConversionEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) cancelConversion(ctx context.Context, req *conversionIDRequest) (*common.MessageResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	conversionID := getPathParam(r, "id")
-	if conversionID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "conversion ID is required", nil)
-		return
+	if err := h.service.CancelConversion(ctx, req.ID, userID); err != nil {
+		if strings.Contains(err.Error(), "cannot cancel") {
+			return nil, common.NewAPIError(http.StatusBadRequest, "", err.Error(), nil)
+		}
+		return nil, conversionError(err, "failed to cancel conversion")
 	}
 
-	err := h.service.CancelConversion(r.Context(), conversionID, userID)
+	return &common.MessageResponse{Message: "conversion cancelled successfully"}, nil
+}
+
+// RetryConversionEndpoint handles POST /conversions/{id}/retry
+func (h *Handler) RetryConversionEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Retry a failed conversion"), h.retryConversion)
+}
+
+// RetryConversion handles POST /conversions/{id}/retry
+func (h *Handler) RetryConversion(w http.ResponseWriter, r *http.Request) {
+	h.RetryConversionEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) retryConversion(ctx context.Context, req *conversionIDRequest) (*RetryResponse, error) {
+	userID, err := requireUser(ctx)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			common.WriteError(w, http.StatusNotFound, "not_found", "conversion not found", nil)
-			return
+		return nil, err
+	}
+
+	retry, err := h.service.RetryConversion(ctx, req.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRetryNotAllowed), errors.Is(err, ErrRetryLimitReached):
+			return nil, common.NewAPIError(http.StatusConflict, "", err.Error(), nil)
+		case errors.Is(err, quota.ErrQuotaExceeded), strings.Contains(err.Error(), "quota exceeded"):
+			return nil, common.NewAPIError(http.StatusForbidden, "quota_exceeded", "You have exceeded your conversion limit. Please upgrade your plan to retry this conversion.", nil)
+		case errors.Is(err, ErrRetryUnavailable):
+			return nil, common.NewAPIError(http.StatusServiceUnavailable, "", err.Error(), nil)
 		}
-		if strings.Contains(err.Error(), "cannot cancel") {
-			common.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
-			return
+		return nil, conversionError(err, "failed to retry conversion")
+	}
+
+	return &retry, nil
+}
+
+// feedbackRequest is the body of POST /conversions/{id}/feedback
+type feedbackRequest struct {
+	ID string `uri:"id" json:"-" binding:"required"`
+	FeedbackRequest
+}
+
+// SubmitFeedbackEndpoint handles POST /conversions/{id}/feedback
+func (h *Handler) SubmitFeedbackEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Rate a conversion result"), h.submitFeedback)
+}
+
+// SubmitFeedback handles POST /conversions/{id}/feedback
+func (h *Handler) SubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	h.SubmitFeedbackEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) submitFeedback(ctx context.Context, req *feedbackRequest) (*Feedback, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback, err := h.service.SubmitFeedback(ctx, req.ID, userID, req.FeedbackRequest)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrFeedbackNotAllowed):
+			return nil, common.NewAPIError(http.StatusConflict, "", err.Error(), nil)
+		case errors.Is(err, common.ErrValidation):
+			return nil, common.NewAPIError(http.StatusBadRequest, common.ErrCodeValidation, err.Error(), nil)
+		case errors.Is(err, ErrFeedbackUnavailable):
+			return nil, common.NewAPIError(http.StatusServiceUnavailable, "", err.Error(), nil)
 		}
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to cancel conversion", nil)
-		return
+		return nil, conversionError(err, "failed to save feedback")
+	}
+
+	return &feedback, nil
+}
+
+// presetDoc documents a preset endpoint
+func presetDoc(summary string, status int) common.OperationDoc {
+	return common.OperationDoc{Summary: summary, Tags: []string{"Conversion Presets"}, Status: status, Secured: true}
+}
+
+// presetError maps preset errors to API errors
+func presetError(err error, message string) error {
+	switch {
+	case errors.Is(err, common.ErrValidation):
+		return common.NewAPIError(http.StatusBadRequest, common.ErrCodeValidation, err.Error(), nil)
+	case errors.Is(err, ErrPresetNotFound):
+		return common.NewAPIError(http.StatusNotFound, common.ErrCodeNotFound, err.Error(), nil)
+	case errors.Is(err, common.ErrConflict):
+		return common.NewAPIError(http.StatusConflict, common.ErrCodeConflict, err.Error(), nil)
+	case errors.Is(err, ErrPresetsUnavailable):
+		return common.NewAPIError(http.StatusServiceUnavailable, "", err.Error(), nil)
+	}
+	return common.NewAPIError(http.StatusInternalServerError, "", message, nil)
+}
+
+// presetIDRequest identifies a preset in the path
+type presetIDRequest struct {
+	ID string `uri:"id" json:"-" binding:"required"`
+}
+
+// updatePresetRequest is the body of PUT /users/me/presets/{id}
+type updatePresetRequest struct {
+	ID string `uri:"id" json:"-" binding:"required"`
+	PresetRequest
+}
+
+// ListPresetsEndpoint handles GET /users/me/presets
+func (h *Handler) ListPresetsEndpoint() *common.Endpoint {
+	return common.NewEndpoint(presetDoc("List saved conversion presets", 0), h.listPresets)
+}
+
+func (h *Handler) listPresets(ctx context.Context, _ *common.NoRequest) (*PresetList, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	common.WriteJSON(w, http.StatusOK, map[string]string{"message": "conversion cancelled successfully"})
+	presets, err := h.service.ListPresets(ctx, userID)
+	if err != nil {
+		return nil, presetError(err, "failed to list presets")
+	}
+	return &presets, nil
+}
+
+// CreatePresetEndpoint handles POST /users/me/presets
+func (h *Handler) CreatePresetEndpoint() *common.Endpoint {
+	return common.NewEndpoint(presetDoc("Save a conversion preset", http.StatusCreated), h.createPreset)
+}
+
+func (h *Handler) createPreset(ctx context.Context, req *PresetRequest) (*Preset, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preset, err := h.service.CreatePreset(ctx, userID, *req)
+	if err != nil {
+		return nil, presetError(err, "failed to create preset")
+	}
+	return &preset, nil
+}
+
+// UpdatePresetEndpoint handles PUT /users/me/presets/{id}
+func (h *Handler) UpdatePresetEndpoint() *common.Endpoint {
+	return common.NewEndpoint(presetDoc("Update a conversion preset", 0), h.updatePreset)
+}
+
+func (h *Handler) updatePreset(ctx context.Context, req *updatePresetRequest) (*Preset, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preset, err := h.service.UpdatePreset(ctx, req.ID, userID, req.PresetRequest)
+	if err != nil {
+		return nil, presetError(err, "failed to update preset")
+	}
+	return &preset, nil
+}
+
+// DeletePresetEndpoint handles DELETE /users/me/presets/{id}
+func (h *Handler) DeletePresetEndpoint() *common.Endpoint {
+	return common.NewEndpoint(presetDoc("Delete a conversion preset", 0), h.deletePreset)
+}
+
+func (h *Handler) deletePreset(ctx context.Context, req *presetIDRequest) (*common.MessageResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeletePreset(ctx, req.ID, userID); err != nil {
+		return nil, presetError(err, "failed to delete preset")
+	}
+	return &common.MessageResponse{Message: "preset deleted"}, nil
+}
+
+// GetProcessingStatusEndpoint handles GET /conversion/{id}/status
+func (h *Handler) GetProcessingStatusEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Get the processing status of a conversion"), h.getProcessingStatus)
 }
 
 // GetProcessingStatus handles GET /conversion/{id}/status
 func (h *Handler) GetProcessingStatus(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
-	}
+	h.GetProcessingStatusEndpoint().ServeHTTP(w, r)
+}
 
-	conversionID := getPathParam(r, "id")
-	if conversionID == "" {
-		common.WriteError(w, http.StatusBadRequest, "invalid_request", "conversion ID is required", nil)
-		return
+func (h *Handler) getProcessingStatus(ctx context.Context, req *conversionIDRequest) (*processingStatusResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	status, err := h.service.GetProcessingStatus(r.Context(), conversionID, userID)
+	status, err := h.service.GetProcessingStatus(ctx, req.ID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			common.WriteError(w, http.StatusNotFound, "not_found", "conversion not found", nil)
-			return
-		}
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get processing status", nil)
-		return
+		return nil, conversionError(err, "failed to get processing status")
 	}
 
-	common.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+	return &processingStatusResponse{Status: status}, nil
+}
+
+// GetConversionMetricsEndpoint handles GET /conversions/metrics
+func (h *Handler) GetConversionMetricsEndpoint() *common.Endpoint {
+	return common.NewEndpoint(conversionDoc("Get the user's conversion metrics"), h.getConversionMetrics)
 }
 
 // GetConversionMetrics handles GET /conversions/metrics
 func (h *Handler) GetConversionMetrics(w http.ResponseWriter, r *http.Request) {
-	userID := common.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		common.WriteError(w, http.StatusUnauthorized, "unauthorized", "user not authenticated", nil)
-		return
+	h.GetConversionMetricsEndpoint().ServeHTTP(w, r)
+}
+
+func (h *Handler) getConversionMetrics(ctx context.Context, req *conversionMetricsRequest) (*map[string]interface{}, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	timeRange := r.URL.Query().Get("timeRange")
+	timeRange := req.TimeRange
 	if timeRange == "" {
 		timeRange = "30d" // Default to last 30 days
 	}
 
-	metrics, err := h.service.GetConversionMetrics(r.Context(), userID, timeRange)
+	metrics, err := h.service.GetConversionMetrics(ctx, userID, timeRange)
 	if err != nil {
-		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get conversion metrics", nil)
-		return
+		return nil, common.NewAPIError(http.StatusInternalServerError, "", "failed to get conversion metrics", nil)
 	}
 
-	common.WriteJSON(w, http.StatusOK, metrics)
+	return &metrics, nil
 }
 
 // Helper functions - now using common package
@@ -556,7 +761,7 @@ func (h *Handler) returnMockConversion(w http.ResponseWriter, r *http.Request, u
 	// Use picsum.photos for mock result image
 	resultImageURL := "https://fastly.picsum.photos/id/565/200/300.jpg?hmac=Ho0T-TCTMRX_uDDGzaLhGzTmukSZdDjpGZJTbL0NY3k"
 
-	mockResponse := ConversionResponse{
+	mockResponse := Conversion{
 		ID:               conversionID,
 		UserID:           userID,
 		UserImageID:      userImageID,
@@ -568,46 +773,8 @@ func (h *Handler) returnMockConversion(w http.ResponseWriter, r *http.Request, u
 		CreatedAt:        now,
 		UpdatedAt:        completedAt,
 		CompletedAt:      &completedAt,
-		UserImageURL:     userImageURL,
-		ClothImageURL:    clothImageURL,
-		ResultImageURL:   resultImageURL,
-	}
+	}.WithImageURLs(userImageURL, clothImageURL, resultImageURL)
 
 	w.Header().Set("Content-Type", "application/json")
 	common.WriteJSON(w, http.StatusOK, mockResponse)
 }
-
-// getPathParam extracts a path parameter from the request
-// First tries to get it from context (set by GinWrap), then falls back to parsing the URL
-func getPathParam(r *http.Request, param string) string {
-	// First, try to get from context (set by GinWrap)
-	if val := r.Context().Value("path_param_" + param); val != nil {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-
-	// Fallback: parse from URL path
-	path := r.URL.Path
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-
-	// For routes like /api/conversion/:id or /api/conversion/:id/status
-	// We look for "conversion" and return the segment immediately after it
-	if param == "id" {
-		for i, part := range parts {
-			if part == "conversion" && i+1 < len(parts) {
-				// Return the next segment (which is the ID)
-				return parts[i+1]
-			}
-		}
-	}
-
-	// Fallback: look for the parameter name directly (for other routes)
-	for i, part := range parts {
-		if part == param && i+1 < len(parts) {
-			return parts[i+1]
-		}
-	}
-
-	return ""
-}
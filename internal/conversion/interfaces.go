@@ -2,6 +2,8 @@ package conversion
 
 import (
 	"context"
+
+	"ai-styler/internal/outbox"
 )
 
 // Store defines the interface for conversion data operations
@@ -27,6 +29,18 @@ type Store interface {
 	FailJob(ctx context.Context, jobID string, errorMessage string) error
 }
 
+// EventStore writes conversion changes together with their outbox events, so
+// notifications are published only for committed changes and survive crashes
+type EventStore interface {
+	CreateConversionWithEvents(ctx context.Context, userID, userImageID, clothImageID, styleName string, events func(conversionID string) []outbox.Event) (string, error)
+	UpdateConversionWithEvents(ctx context.Context, conversionID string, req UpdateConversionRequest, events []outbox.Event) error
+}
+
+// ProgressStore records how far the worker got with a conversion
+type ProgressStore interface {
+	UpdateConversionProgress(ctx context.Context, conversionID string, progress int) error
+}
+
 // ConversionJob represents a background conversion job
 type ConversionJob struct {
 	ID           string `json:"id"`
@@ -91,7 +105,7 @@ type AuditLogger interface {
 
 // WorkerService defines the interface for background job processing
 type WorkerService interface {
-	EnqueueConversion(ctx context.Context, conversionID string) error
+	EnqueueConversion(ctx context.Context, conversionID string, priority int) error
 	ProcessConversion(ctx context.Context, jobID string) error
 	GetJobStatus(ctx context.Context, jobID string) (string, error)
 	CancelJob(ctx context.Context, jobID string) error
@@ -3,22 +3,13 @@ package conversion
 import (
 	"encoding/json"
 	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/domain"
 )
 
 // Conversion represents a conversion request
-type Conversion struct {
-	ID               string     `json:"id"`
-	UserID           string     `json:"userId"`
-	UserImageID      string     `json:"userImageId"`
-	ClothImageID     string     `json:"clothImageId"`
-	Status           string     `json:"status"` // "pending", "processing", "completed", "failed"
-	ResultImageID    *string    `json:"resultImageId,omitempty"`
-	ErrorMessage     *string    `json:"errorMessage,omitempty"`
-	ProcessingTimeMs *int       `json:"processingTimeMs,omitempty"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
-	CompletedAt      *time.Time `json:"completedAt,omitempty"`
-}
+type Conversion = domain.Conversion
 
 // ConversionRequest represents the request to create a new conversion
 type ConversionRequest struct {
@@ -28,6 +19,13 @@ type ConversionRequest struct {
 	ClothImageIDSnake string `json:"cloth_image_id"`  // snake_case (backward compatibility)
 	StyleName        string `json:"styleName,omitempty"`
 	StyleNameSnake   string `json:"style_name,omitempty"`
+	PresetID         string `json:"presetId,omitempty"`   // saved preset whose options apply
+	PresetIDSnake    string `json:"preset_id,omitempty"`
+	Garments         []Garment `json:"garments,omitempty"` // outfit of several garments instead of clothImageId
+	ScheduledAt      *time.Time `json:"scheduledAt,omitempty"` // run later, e.g. off-peak, instead of now
+	OutputWidth      int        `json:"outputWidth,omitempty"` // result dimensions, see OutputOptions
+	OutputHeight     int        `json:"outputHeight,omitempty"`
+	AspectRatio      string     `json:"aspectRatio,omitempty"`
 }
 
 // UnmarshalJSON custom unmarshaling to support both camelCase and snake_case
@@ -40,6 +38,17 @@ func (r *ConversionRequest) UnmarshalJSON(data []byte) error {
 		ClothImageIDSnake string `json:"cloth_image_id"`
 		StyleName        string `json:"styleName"`
 		StyleNameSnake   string `json:"style_name"`
+		PresetID         string `json:"presetId"`
+		PresetIDSnake    string `json:"preset_id"`
+		Garments         []Garment `json:"garments"`
+		ScheduledAt      *time.Time `json:"scheduledAt"`
+		ScheduledAtSnake *time.Time `json:"scheduled_at"`
+		OutputWidth      int        `json:"outputWidth"`
+		OutputWidthSnake int        `json:"output_width"`
+		OutputHeight     int        `json:"outputHeight"`
+		OutputHeightSnake int       `json:"output_height"`
+		AspectRatio      string     `json:"aspectRatio"`
+		AspectRatioSnake string     `json:"aspect_ratio"`
 	}
 	
 	var temp Alias
@@ -65,6 +74,38 @@ func (r *ConversionRequest) UnmarshalJSON(data []byte) error {
 	} else {
 		r.StyleName = temp.StyleNameSnake
 	}
+
+	if temp.PresetID != "" {
+		r.PresetID = temp.PresetID
+	} else {
+		r.PresetID = temp.PresetIDSnake
+	}
+
+	r.Garments = temp.Garments
+
+	if temp.ScheduledAt != nil {
+		r.ScheduledAt = temp.ScheduledAt
+	} else {
+		r.ScheduledAt = temp.ScheduledAtSnake
+	}
+
+	if temp.OutputWidth != 0 {
+		r.OutputWidth = temp.OutputWidth
+	} else {
+		r.OutputWidth = temp.OutputWidthSnake
+	}
+
+	if temp.OutputHeight != 0 {
+		r.OutputHeight = temp.OutputHeight
+	} else {
+		r.OutputHeight = temp.OutputHeightSnake
+	}
+
+	if temp.AspectRatio != "" {
+		r.AspectRatio = temp.AspectRatio
+	} else {
+		r.AspectRatio = temp.AspectRatioSnake
+	}
 	
 	return nil
 }
@@ -85,6 +126,18 @@ func (r *ConversionRequest) GetClothImageID() string {
 	return r.ClothImageIDSnake
 }
 
+// GetGarments returns the garments of the request in layering order, a
+// single garment when only a cloth image was given
+func (r *ConversionRequest) GetGarments() []Garment {
+	if len(r.Garments) > 0 {
+		return r.Garments
+	}
+	if clothImageID := r.GetClothImageID(); clothImageID != "" {
+		return []Garment{{ImageID: clothImageID}}
+	}
+	return nil
+}
+
 // GetStyleName returns the style name from whichever field was provided
 func (r *ConversionRequest) GetStyleName() string {
 	if r.StyleName != "" {
@@ -93,24 +146,47 @@ func (r *ConversionRequest) GetStyleName() string {
 	return r.StyleNameSnake
 }
 
-// ConversionResponse represents the response for conversion operations
-type ConversionResponse struct {
-	ID               string     `json:"id"`
-	UserID           string     `json:"userId"`
-	UserImageID      string     `json:"userImageId"`
-	ClothImageID     string     `json:"clothImageId"`
-	Status           string     `json:"status"`
-	ResultImageID    *string    `json:"resultImageId,omitempty"`
-	ErrorMessage     *string    `json:"errorMessage,omitempty"`
-	ProcessingTimeMs *int       `json:"processingTimeMs,omitempty"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
-	CompletedAt      *time.Time `json:"completedAt,omitempty"`
-	UserImageURL     string     `json:"userImageUrl,omitempty"`
-	ClothImageURL    string     `json:"clothImageUrl,omitempty"`
-	ResultImageURL   string     `json:"resultImageUrl,omitempty"`
+// GetPresetID returns the preset ID from whichever field was provided
+func (r *ConversionRequest) GetPresetID() string {
+	if r.PresetID != "" {
+		return r.PresetID
+	}
+	return r.PresetIDSnake
+}
+
+// Output returns the requested result dimensions
+func (r *ConversionRequest) Output() OutputOptions {
+	return OutputOptions{Width: r.OutputWidth, Height: r.OutputHeight, AspectRatio: r.AspectRatio}
+}
+
+// Validate checks the image IDs given in either naming style and the output options
+func (r *ConversionRequest) Validate() error {
+	var errs common.ValidationErrors
+	userImageID, clothImageID := r.GetUserImageID(), r.GetClothImageID()
+	if userImageID == "" {
+		errs.Add("userImageId", "userImageId or user_image_id is required", "")
+	}
+	if len(r.Garments) > 0 {
+		if clothImageID != "" {
+			errs.Add("garments", "send either clothImageId or garments, not both", "")
+		}
+		validateGarments(&errs, userImageID, r.Garments)
+	} else if clothImageID == "" {
+		errs.Add("clothImageId", "clothImageId, cloth_image_id or garments is required", "")
+	}
+	if userImageID != "" && userImageID == clothImageID {
+		errs.Add("clothImageId", "user image and cloth image must be different", clothImageID)
+	}
+	r.Output().validate(&errs)
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
 }
 
+// ConversionResponse represents the response for conversion operations
+type ConversionResponse = domain.ConversionDetails
+
 // ConversionListRequest represents the request to list conversions
 type ConversionListRequest struct {
 	Page      int       `json:"page" form:"page"`
@@ -119,6 +195,7 @@ type ConversionListRequest struct {
 	UserID    string    `json:"userId" form:"userId"`
 	StartDate time.Time `json:"startDate" form:"startDate"`
 	EndDate   time.Time `json:"endDate" form:"endDate"`
+	Cursor    string    `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
 }
 
 // ConversionListResponse represents the response for conversion listing
@@ -128,14 +205,28 @@ type ConversionListResponse struct {
 	Page        int                  `json:"page"`
 	PageSize    int                  `json:"pageSize"`
 	TotalPages  int                  `json:"totalPages"`
+	NextCursor  string               `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // UpdateConversionRequest represents the request to update a conversion
 type UpdateConversionRequest struct {
-	Status           *string `json:"status,omitempty"`
-	ResultImageID    *string `json:"resultImageId,omitempty"`
-	ErrorMessage     *string `json:"errorMessage,omitempty"`
-	ProcessingTimeMs *int    `json:"processingTimeMs,omitempty"`
+	Status           *string     `json:"status,omitempty"`
+	ResultImageID    *string     `json:"resultImageId,omitempty"`
+	ErrorMessage     *string     `json:"errorMessage,omitempty"`
+	ProcessingTimeMs *int        `json:"processingTimeMs,omitempty"`
+	FailureKind      *string     `json:"failureKind,omitempty"` // provider or request, recorded with a failed status
+	DeadLetter       *DeadLetter `json:"deadLetter,omitempty"`  // set when the worker gave up on the conversion
+	Progress         *int        `json:"progress,omitempty"`    // 0-100
+
+	// ErrorCode tells the client what to change when the inputs caused the
+	// failure, e.g. "no_person_detected"
+	ErrorCode *string `json:"errorCode,omitempty"`
+}
+
+// DeadLetter is the provider response captured when a conversion is dead-lettered
+type DeadLetter struct {
+	ProviderStatusCode int    `json:"providerStatusCode,omitempty"` // 0 when the provider did not respond
+	ProviderResponse   string `json:"providerResponse"`
 }
 
 // QuotaCheck represents the result of a quota check
@@ -146,20 +237,25 @@ type QuotaCheck struct {
 	TotalRemaining int    `json:"totalRemaining"`
 	PlanName       string `json:"planName"`
 	MonthlyLimit   int    `json:"monthlyLimit"`
+
+	// Reported when conversions are charged through quota charges; refunded
+	// conversions are not counted
+	Used    int        `json:"used,omitempty"`
+	ResetAt *time.Time `json:"resetAt,omitempty"`
 }
 
 // Conversion status constants
 const (
-	ConversionStatusPending    = "pending"
-	ConversionStatusProcessing = "processing"
-	ConversionStatusCompleted  = "completed"
-	ConversionStatusFailed     = "failed"
+	ConversionStatusPending    = domain.ConversionStatusPending
+	ConversionStatusProcessing = domain.ConversionStatusProcessing
+	ConversionStatusCompleted  = domain.ConversionStatusCompleted
+	ConversionStatusFailed     = domain.ConversionStatusFailed
 )
 
 // Conversion type constants
 const (
-	ConversionTypeFree = "free"
-	ConversionTypePaid = "paid"
+	ConversionTypeFree = domain.ConversionTypeFree
+	ConversionTypePaid = domain.ConversionTypePaid
 )
 
 // Default values
@@ -0,0 +1,154 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Worker job priorities used when enqueuing conversions
+const (
+	DefaultJobPriority  = 5  // worker.JobPriorityNormal
+	FastLaneJobPriority = 20 // worker.JobPriorityUrgent
+)
+
+// onboardingRoleUser is the only role that receives signup credits
+const onboardingRoleUser = "user"
+
+// OnboardingConfig represents configuration for new-user onboarding
+type OnboardingConfig struct {
+	Enabled                 bool
+	SignupCredits           int  // free conversions granted at signup
+	FirstConversionFree     bool // first conversion bypasses quota regardless of plan
+	FirstConversionPriority int  // worker job priority of the first conversion
+}
+
+// OnboardingStore persists onboarding grants and fast-lane usage
+type OnboardingStore interface {
+	GrantSignupCredits(ctx context.Context, userID string, credits int) (bool, error)
+	HasConversions(ctx context.Context, userID string) (bool, error)
+	RecordFastLane(ctx context.Context, userID, conversionID string) error
+}
+
+// Onboarding applies the signup credit grant and the first-conversion fast lane
+type Onboarding struct {
+	config OnboardingConfig
+	store  OnboardingStore
+}
+
+// NewOnboarding creates a new onboarding policy
+func NewOnboarding(config OnboardingConfig, store OnboardingStore) *Onboarding {
+	if config.FirstConversionPriority <= 0 {
+		config.FirstConversionPriority = FastLaneJobPriority
+	}
+	if config.SignupCredits < 0 {
+		config.SignupCredits = 0
+	}
+
+	return &Onboarding{
+		config: config,
+		store:  store,
+	}
+}
+
+// GrantSignupCredits grants the configured free conversions to a new user.
+// Vendors and disabled onboarding are skipped.
+func (o *Onboarding) GrantSignupCredits(ctx context.Context, userID, role string) error {
+	if !o.config.Enabled || role != onboardingRoleUser {
+		return nil
+	}
+
+	if _, err := o.store.GrantSignupCredits(ctx, userID, o.config.SignupCredits); err != nil {
+		return fmt.Errorf("failed to grant signup credits: %w", err)
+	}
+	return nil
+}
+
+// IsFastLane reports whether the user's next conversion is their first one
+func (o *Onboarding) IsFastLane(ctx context.Context, userID string) (bool, error) {
+	if !o.config.Enabled {
+		return false, nil
+	}
+
+	hasConversions, err := o.store.HasConversions(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check previous conversions: %w", err)
+	}
+	return !hasConversions, nil
+}
+
+// BypassQuota reports whether fast-lane conversions skip the quota check
+func (o *Onboarding) BypassQuota() bool {
+	return o.config.Enabled && o.config.FirstConversionFree
+}
+
+// QuotaExempt reports whether the user's next conversion skips the quota
+// because it goes through the fast lane. Lookup errors fall back to the quota.
+func (o *Onboarding) QuotaExempt(ctx context.Context, userID string) bool {
+	if !o.BypassQuota() {
+		return false
+	}
+
+	fastLane, err := o.IsFastLane(ctx, userID)
+	if err != nil {
+		fmt.Printf("Failed to check onboarding fast lane: %v\n", err)
+		return false
+	}
+	return fastLane
+}
+
+// Priority returns the worker job priority for fast-lane conversions
+func (o *Onboarding) Priority() int {
+	return o.config.FirstConversionPriority
+}
+
+// RecordFastLane records that a conversion went through the fast lane
+func (o *Onboarding) RecordFastLane(ctx context.Context, userID, conversionID string) error {
+	return o.store.RecordFastLane(ctx, userID, conversionID)
+}
+
+// dbOnboardingStore implements OnboardingStore on top of PostgreSQL
+type dbOnboardingStore struct {
+	db *sql.DB
+}
+
+// NewDBOnboardingStore creates a new database-backed onboarding store
+func NewDBOnboardingStore(db *sql.DB) OnboardingStore {
+	return &dbOnboardingStore{db: db}
+}
+
+// GrantSignupCredits grants credits once per user
+func (s *dbOnboardingStore) GrantSignupCredits(ctx context.Context, userID string, credits int) (bool, error) {
+	var granted bool
+	err := s.db.QueryRowContext(ctx, `SELECT grant_onboarding_credits($1, $2)`, userID, credits).Scan(&granted)
+	if err != nil {
+		return false, fmt.Errorf("failed to grant onboarding credits: %w", err)
+	}
+	return granted, nil
+}
+
+// HasConversions reports whether the user has created any conversion
+func (s *dbOnboardingStore) HasConversions(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM conversions WHERE user_id = $1)`, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check conversions: %w", err)
+	}
+	return exists, nil
+}
+
+// RecordFastLane stores the fast-lane conversion for cohort reporting
+func (s *dbOnboardingStore) RecordFastLane(ctx context.Context, userID, conversionID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO onboarding_grants (user_id, fast_lane_conversion_id, fast_lane_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+			SET fast_lane_conversion_id = EXCLUDED.fast_lane_conversion_id,
+				fast_lane_at = EXCLUDED.fast_lane_at
+			WHERE onboarding_grants.fast_lane_conversion_id IS NULL`,
+		userID, conversionID)
+	if err != nil {
+		return fmt.Errorf("failed to record fast lane conversion: %w", err)
+	}
+	return nil
+}
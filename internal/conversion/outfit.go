@@ -0,0 +1,315 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/domain"
+	"ai-styler/internal/outbox"
+	"ai-styler/internal/quota"
+)
+
+// Garment is one garment of an outfit conversion
+type Garment = domain.Garment
+
+// DefaultMaxGarments is the number of garments an outfit may have when none is configured
+const DefaultMaxGarments = 3
+
+// Garment slots, telling the provider where each garment of an outfit is worn
+const (
+	GarmentSlotTop       = "top"
+	GarmentSlotBottom    = "bottom"
+	GarmentSlotDress     = "dress"
+	GarmentSlotOuterwear = "outerwear"
+	GarmentSlotShoes     = "shoes"
+	GarmentSlotAccessory = "accessory"
+)
+
+// GarmentSlots are the valid garment slots
+var GarmentSlots = []string{
+	GarmentSlotTop, GarmentSlotBottom, GarmentSlotDress, GarmentSlotOuterwear, GarmentSlotShoes, GarmentSlotAccessory,
+}
+
+// ErrTooManyGarments is returned for outfits with more garments than allowed
+var ErrTooManyGarments = fmt.Errorf("%w: too many garments", common.ErrValidation)
+
+// OutfitStore creates conversions of several garments and lists their garments
+type OutfitStore interface {
+	// CreateOutfitConversion creates a conversion of the garments in the given
+	// order, with the first garment as its cloth image. events, when not nil,
+	// are written in the same transaction.
+	CreateOutfitConversion(ctx context.Context, userID, userImageID, styleName string, garments []Garment, events func(conversionID string) []outbox.Event) (string, error)
+	// ListConversionGarments returns the garments of an outfit conversion in
+	// layering order, none for single-garment conversions
+	ListConversionGarments(ctx context.Context, conversionID string) ([]Garment, error)
+}
+
+// OutfitQuota charges the garments of an outfit beyond the first
+type OutfitQuota interface {
+	Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error)
+	Release(ctx context.Context, reservation quota.Reservation) error
+}
+
+// SetOutfits enables conversions of up to maxGarments garments. Every garment
+// is charged as one conversion; quota may be nil, in which case the extra
+// garments are charged to the legacy conversion quota.
+func (s *Service) SetOutfits(store OutfitStore, quota OutfitQuota, maxGarments int) {
+	if maxGarments <= 0 {
+		maxGarments = DefaultMaxGarments
+	}
+	s.outfitStore = store
+	s.outfitQuota = quota
+	s.maxGarments = maxGarments
+}
+
+// checkGarmentCount rejects outfits with more garments than configured.
+// Without an outfit store only single-garment conversions are possible.
+func (s *Service) checkGarmentCount(garments []Garment) error {
+	maxGarments := 1
+	if s.outfitStore != nil {
+		maxGarments = s.maxGarments
+	}
+	if len(garments) > maxGarments {
+		return fmt.Errorf("invalid outfit: %w, at most %d per conversion", ErrTooManyGarments, maxGarments)
+	}
+	return nil
+}
+
+// AlbumAccess checks album membership like the image read endpoints do
+type AlbumAccess interface {
+	// PrivateAlbumImages returns which of the images are in non-public albums
+	PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error)
+	// CanViewImage reports whether viewerID may see the image
+	CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error)
+}
+
+// SetAlbumAccess keeps images of private albums from being tried on by users
+// the album is not shared with
+func (s *Service) SetAlbumAccess(albums AlbumAccess) {
+	s.albumAccess = albums
+}
+
+// validateClothImage checks that a garment image exists and that the user may
+// try it on: a public image, a vendor image or the user's own image, outside
+// private albums not shared with the user
+func (s *Service) validateClothImage(ctx context.Context, userID, imageID string) error {
+	clothImage, err := s.imageService.GetImage(ctx, imageID)
+	if err != nil {
+		return fmt.Errorf("invalid cloth image: %w", err)
+	}
+
+	isOwnImage := (clothImage.UserID != "" && clothImage.UserID == userID) ||
+		(clothImage.VendorID != "" && clothImage.VendorID == userID)
+	if isOwnImage {
+		return nil
+	}
+
+	// Note: SQL function will also validate this, but we check early for better error messages
+	if !clothImage.IsPublic && clothImage.Type != "vendor" {
+		return fmt.Errorf("cloth image is not accessible: must be public, vendor image, or your own image")
+	}
+	return s.checkAlbumAccess(ctx, userID, imageID)
+}
+
+// checkAlbumAccess rejects images of private albums the user may not see
+func (s *Service) checkAlbumAccess(ctx context.Context, userID, imageID string) error {
+	if s.albumAccess == nil {
+		return nil
+	}
+
+	private, err := s.albumAccess.PrivateAlbumImages(ctx, []string{imageID})
+	if err != nil {
+		return fmt.Errorf("failed to check cloth image album: %w", err)
+	}
+	if !private[imageID] {
+		return nil
+	}
+
+	allowed, err := s.albumAccess.CanViewImage(ctx, imageID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check cloth image album: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("cloth image is not accessible: it is in a private album")
+	}
+	return nil
+}
+
+// garmentCharge records the extra garments charged for an outfit
+type garmentCharge struct {
+	reservations []quota.Reservation
+	legacy       int // garments reserved on the legacy conversion quota
+}
+
+// chargeGarments charges extra garments of an outfit, on top of the one
+// conversion charged for it like for any other. Nothing stays charged when
+// the quota runs out part way.
+func (s *Service) chargeGarments(ctx context.Context, userID string, extra int) (*garmentCharge, error) {
+	charge := &garmentCharge{}
+	if extra <= 0 {
+		return charge, nil
+	}
+
+	if s.outfitQuota != nil {
+		for i := 0; i < extra; i++ {
+			reservation, _, err := s.outfitQuota.Consume(ctx, userID)
+			if err != nil {
+				s.releaseGarments(ctx, userID, charge)
+				return nil, err
+			}
+			charge.reservations = append(charge.reservations, reservation)
+		}
+		return charge, nil
+	}
+
+	quotaCheck, err := s.store.CheckUserQuota(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if quotaCheck.TotalRemaining < extra+1 {
+		return nil, fmt.Errorf("quota exceeded: an outfit of %d garments needs %d conversions, free=%d, paid=%d",
+			extra+1, extra+1, quotaCheck.RemainingFree, quotaCheck.RemainingPaid)
+	}
+	for i := 0; i < extra; i++ {
+		if err := s.store.ReserveQuota(ctx, userID); err != nil {
+			s.releaseGarments(ctx, userID, charge)
+			return nil, fmt.Errorf("failed to reserve quota: %w", err)
+		}
+		charge.legacy++
+	}
+	return charge, nil
+}
+
+// releaseGarments gives back the garments charged for an outfit that was not created
+func (s *Service) releaseGarments(ctx context.Context, userID string, charge *garmentCharge) {
+	if charge == nil {
+		return
+	}
+	for _, reservation := range charge.reservations {
+		if err := s.outfitQuota.Release(ctx, reservation); err != nil {
+			fmt.Printf("Failed to release outfit quota: %v\n", err)
+		}
+	}
+	for i := 0; i < charge.legacy; i++ {
+		if err := s.store.ReleaseQuota(ctx, userID); err != nil {
+			fmt.Printf("Failed to release outfit quota: %v\n", err)
+		}
+	}
+}
+
+// conversionGarmentCount returns how many garments a conversion was created with
+func (s *Service) conversionGarmentCount(ctx context.Context, conversionID string) (int, error) {
+	if s.outfitStore == nil {
+		return 1, nil
+	}
+	garments, err := s.outfitStore.ListConversionGarments(ctx, conversionID)
+	if err != nil {
+		return 0, err
+	}
+	return max(len(garments), 1), nil
+}
+
+// validateGarments checks the garments of an outfit request
+func validateGarments(errs *common.ValidationErrors, userImageID string, garments []Garment) {
+	seen := make(map[string]bool, len(garments))
+	for i, garment := range garments {
+		field := fmt.Sprintf("garments[%d]", i)
+		switch {
+		case garment.ImageID == "":
+			errs.Add(field+".imageId", "imageId is required", "")
+		case garment.ImageID == userImageID:
+			errs.Add(field+".imageId", "user image and cloth image must be different", garment.ImageID)
+		case seen[garment.ImageID]:
+			errs.Add(field+".imageId", "garments must be different images", garment.ImageID)
+		}
+		seen[garment.ImageID] = true
+
+		if garment.Slot != "" && !validGarmentSlot(garment.Slot) {
+			errs.Add(field+".slot", "slot must be one of "+strings.Join(GarmentSlots, ", "), garment.Slot)
+		}
+	}
+}
+
+func validGarmentSlot(slot string) bool {
+	for _, valid := range GarmentSlots {
+		if slot == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// NewOutfitStore creates a store for conversions of several garments
+func NewOutfitStore(db *sql.DB) OutfitStore {
+	return &store{db: db}
+}
+
+// CreateOutfitConversion creates the conversion and its garments in one transaction
+func (s *store) CreateOutfitConversion(ctx context.Context, userID, userImageID, styleName string, garments []Garment, events func(conversionID string) []outbox.Event) (string, error) {
+	if len(garments) == 0 {
+		return "", fmt.Errorf("%w: an outfit needs at least one garment", common.ErrValidation)
+	}
+
+	var conversionID string
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		var err error
+		conversionID, err = createConversion(ctx, tx, userID, userImageID, garments[0].ImageID, styleName)
+		if err != nil {
+			return err
+		}
+
+		for i, garment := range garments {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO conversion_garments (conversion_id, position, image_id, slot)
+				VALUES ($1, $2, $3, NULLIF($4, ''))
+			`, conversionID, i+1, garment.ImageID, garment.Slot); err != nil {
+				return fmt.Errorf("failed to save conversion garment: %w", err)
+			}
+		}
+
+		if events == nil {
+			return nil
+		}
+		return outbox.Write(ctx, tx, events(conversionID)...)
+	})
+	if err != nil {
+		return "", err
+	}
+	return conversionID, nil
+}
+
+// ListConversionGarments returns the garments of an outfit conversion in layering order
+func (s *store) ListConversionGarments(ctx context.Context, conversionID string) ([]Garment, error) {
+	return listConversionGarments(ctx, common.Conn(ctx, s.db), conversionID)
+}
+
+func listConversionGarments(ctx context.Context, q common.DBTX, conversionID string) ([]Garment, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT g.image_id, COALESCE(g.slot, ''), COALESCE(i.original_url, '')
+		FROM conversion_garments g
+		LEFT JOIN images i ON i.id = g.image_id
+		WHERE g.conversion_id = $1
+		ORDER BY g.position
+	`, conversionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversion garments: %w", err)
+	}
+	defer rows.Close()
+
+	var garments []Garment
+	for rows.Next() {
+		var garment Garment
+		if err := rows.Scan(&garment.ImageID, &garment.Slot, &garment.ImageURL); err != nil {
+			return nil, fmt.Errorf("failed to scan conversion garment: %w", err)
+		}
+		garments = append(garments, garment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversion garments: %w", err)
+	}
+	return garments, nil
+}
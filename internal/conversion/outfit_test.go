@@ -0,0 +1,195 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/outbox"
+)
+
+// fakeOutfitStore creates outfits in the mock store, records their garments
+// and can be made to fail
+type fakeOutfitStore struct {
+	store    *mockStore
+	garments map[string][]Garment
+	fail     bool
+}
+
+func (f *fakeOutfitStore) CreateOutfitConversion(ctx context.Context, userID, userImageID, styleName string, garments []Garment, events func(conversionID string) []outbox.Event) (string, error) {
+	if f.fail {
+		return "", fmt.Errorf("database unavailable")
+	}
+	conversionID, err := f.store.CreateConversion(ctx, userID, userImageID, garments[0].ImageID, styleName)
+	if err != nil {
+		return "", err
+	}
+	f.garments[conversionID] = garments
+	return conversionID, nil
+}
+
+func (f *fakeOutfitStore) ListConversionGarments(ctx context.Context, conversionID string) ([]Garment, error) {
+	return f.garments[conversionID], nil
+}
+
+func newOutfitTestService(outfitQuota OutfitQuota) (*Service, *fakeOutfitStore) {
+	store := newMockStore()
+	service := NewService(store, &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	outfits := &fakeOutfitStore{store: store, garments: make(map[string][]Garment)}
+	service.SetOutfits(outfits, outfitQuota, 3)
+	return service, outfits
+}
+
+func outfitRequest(imageIDs ...string) ConversionRequest {
+	req := ConversionRequest{UserImageID: "user-image-id"}
+	for _, imageID := range imageIDs {
+		req.Garments = append(req.Garments, Garment{ImageID: imageID})
+	}
+	return req
+}
+
+func TestConversionRequestValidate_Garments(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   ConversionRequest
+		valid bool
+	}{
+		{"outfit", ConversionRequest{UserImageID: "u", Garments: []Garment{{ImageID: "a", Slot: GarmentSlotTop}, {ImageID: "b", Slot: GarmentSlotBottom}}}, true},
+		{"garments and cloth image", ConversionRequest{UserImageID: "u", ClothImageID: "a", Garments: []Garment{{ImageID: "b"}}}, false},
+		{"duplicate garment", ConversionRequest{UserImageID: "u", Garments: []Garment{{ImageID: "a"}, {ImageID: "a"}}}, false},
+		{"user image as garment", ConversionRequest{UserImageID: "u", Garments: []Garment{{ImageID: "u"}}}, false},
+		{"unknown slot", ConversionRequest{UserImageID: "u", Garments: []Garment{{ImageID: "a", Slot: "hat"}}}, false},
+		{"no garment", ConversionRequest{UserImageID: "u"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestCreateConversion_Outfit(t *testing.T) {
+	outfitQuota := &fakeRetryQuota{remaining: 5}
+	service, outfits := newOutfitTestService(outfitQuota)
+
+	response, err := service.CreateConversion(context.Background(), "user-1", outfitRequest("top", "bottom", "bag"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.ClothImageID != "top" {
+		t.Errorf("Expected the first garment as cloth image, got %s", response.ClothImageID)
+	}
+	if len(outfits.garments[response.ID]) != 3 {
+		t.Errorf("Expected 3 garments saved, got %d", len(outfits.garments[response.ID]))
+	}
+	if outfitQuota.consumed != 2 {
+		t.Errorf("Expected 2 extra garments charged, got %d", outfitQuota.consumed)
+	}
+}
+
+func TestCreateConversion_OutfitErrors(t *testing.T) {
+	t.Run("too many garments", func(t *testing.T) {
+		service, _ := newOutfitTestService(&fakeRetryQuota{remaining: 5})
+		_, err := service.CreateConversion(context.Background(), "user-1", outfitRequest("a", "b", "c", "d"))
+		if !errors.Is(err, ErrTooManyGarments) {
+			t.Errorf("Expected ErrTooManyGarments, got %v", err)
+		}
+	})
+
+	t.Run("outfits disabled", func(t *testing.T) {
+		service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+			&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+		_, err := service.CreateConversion(context.Background(), "user-1", outfitRequest("a", "b"))
+		if !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("quota runs out part way", func(t *testing.T) {
+		outfitQuota := &fakeRetryQuota{remaining: 1}
+		service, outfits := newOutfitTestService(outfitQuota)
+		if _, err := service.CreateConversion(context.Background(), "user-1", outfitRequest("a", "b", "c")); err == nil {
+			t.Fatal("Expected quota error, got nil")
+		}
+		if outfitQuota.consumed != 0 || outfitQuota.remaining != 1 {
+			t.Errorf("Expected the partial charge released, got consumed=%d remaining=%d", outfitQuota.consumed, outfitQuota.remaining)
+		}
+		if len(outfits.garments) != 0 {
+			t.Error("Expected no outfit created")
+		}
+	})
+
+	t.Run("create fails", func(t *testing.T) {
+		outfitQuota := &fakeRetryQuota{remaining: 5}
+		service, outfits := newOutfitTestService(outfitQuota)
+		outfits.fail = true
+		if _, err := service.CreateConversion(context.Background(), "user-1", outfitRequest("a", "b")); err == nil {
+			t.Fatal("Expected create error, got nil")
+		}
+		if outfitQuota.consumed != 0 {
+			t.Errorf("Expected the garment charge released, got %d consumed", outfitQuota.consumed)
+		}
+	})
+}
+
+// vendorImageService serves every cloth image as a non-public vendor image
+type vendorImageService struct {
+	mockImageService
+}
+
+func (m *vendorImageService) GetImage(ctx context.Context, imageID string) (ImageInfo, error) {
+	return ImageInfo{ID: imageID, VendorID: "vendor-1", Type: "vendor"}, nil
+}
+
+// fakeAlbumAccess keeps images in a private album shared with members only
+type fakeAlbumAccess struct {
+	private map[string]bool
+	members map[string]bool
+}
+
+func (f *fakeAlbumAccess) PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error) {
+	private := make(map[string]bool)
+	for _, imageID := range imageIDs {
+		private[imageID] = f.private[imageID]
+	}
+	return private, nil
+}
+
+func (f *fakeAlbumAccess) CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error) {
+	return !f.private[imageID] || f.members[viewerID], nil
+}
+
+func TestCreateConversion_PrivateAlbumVendorImage(t *testing.T) {
+	service := NewService(newMockStore(), &vendorImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	service.SetAlbumAccess(&fakeAlbumAccess{
+		private: map[string]bool{"private-image": true},
+		members: map[string]bool{"member": true},
+	})
+
+	req := ConversionRequest{UserImageID: "user-image-id", ClothImageID: "private-image"}
+	_, err := service.CreateConversion(context.Background(), "outsider", req)
+	if err == nil || !strings.Contains(err.Error(), "not accessible") {
+		t.Errorf("Expected the private album image to be rejected, got %v", err)
+	}
+
+	if _, err := service.CreateConversion(context.Background(), "member", req); err != nil {
+		t.Errorf("Expected an album member to try the image on, got %v", err)
+	}
+
+	req.ClothImageID = "catalog-image"
+	if _, err := service.CreateConversion(context.Background(), "outsider", req); err != nil {
+		t.Errorf("Expected a vendor image outside private albums to be allowed, got %v", err)
+	}
+}
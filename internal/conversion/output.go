@@ -0,0 +1,200 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ai-styler/internal/common"
+)
+
+// Output dimension limits. A plan allows results up to its max_output_edge;
+// users without an active plan get DefaultMaxOutputEdge.
+const (
+	MinOutputDimension   = 256
+	MaxOutputDimension   = 4096
+	DefaultMaxOutputEdge = 1024
+)
+
+// AspectRatios are the aspect ratios the provider can generate, width:height
+var AspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// ErrOutputTooLarge is returned for output dimensions above the plan's limit
+var ErrOutputTooLarge = fmt.Errorf("%w: output size exceeds your plan's limit", common.ErrValidation)
+
+// OutputOptions are the result dimensions requested for a conversion. Zero
+// values leave the provider's size; the result is never upscaled.
+type OutputOptions struct {
+	Width       int    `json:"outputWidth,omitempty"`
+	Height      int    `json:"outputHeight,omitempty"`
+	AspectRatio string `json:"aspectRatio,omitempty"` // one of AspectRatios
+}
+
+// IsZero reports whether no output option was requested
+func (o OutputOptions) IsZero() bool {
+	return o.Width == 0 && o.Height == 0 && o.AspectRatio == ""
+}
+
+// Validate checks the dimensions and aspect ratio, and that an explicit width
+// and height agree with the aspect ratio
+func (o OutputOptions) Validate() error {
+	var errs common.ValidationErrors
+	o.validate(&errs)
+	if errs.HasErrors() {
+		return fmt.Errorf("%w: %s %s", common.ErrValidation, errs.Errors[0].Field, errs.Errors[0].Message)
+	}
+	return nil
+}
+
+// validate adds the problems of the options to errs
+func (o OutputOptions) validate(errs *common.ValidationErrors) {
+	for _, dimension := range []struct {
+		field string
+		value int
+	}{{"outputWidth", o.Width}, {"outputHeight", o.Height}} {
+		if dimension.value != 0 && (dimension.value < MinOutputDimension || dimension.value > MaxOutputDimension) {
+			errs.Add(dimension.field, fmt.Sprintf("must be between %d and %d pixels", MinOutputDimension, MaxOutputDimension), strconv.Itoa(dimension.value))
+		}
+	}
+	if o.AspectRatio == "" {
+		return
+	}
+	ratio, ok := parseAspectRatio(o.AspectRatio)
+	switch {
+	case !ok || !slices.Contains(AspectRatios, o.AspectRatio):
+		errs.Add("aspectRatio", "must be one of "+strings.Join(AspectRatios, ", "), o.AspectRatio)
+	case o.Width > 0 && o.Height > 0 && math.Abs(float64(o.Width)/float64(o.Height)-ratio) > 0.01*ratio:
+		errs.Add("aspectRatio", "does not match outputWidth and outputHeight", o.AspectRatio)
+	}
+}
+
+// LongestEdge returns the longest requested edge, 0 when none was requested
+func (o OutputOptions) LongestEdge() int {
+	return max(o.Width, o.Height)
+}
+
+// ProviderAspectRatio returns the aspect ratio the provider is asked for: the
+// requested one, or the supported ratio nearest to an explicit width and
+// height. The result is cropped to the exact width and height afterwards.
+func (o OutputOptions) ProviderAspectRatio() string {
+	if o.AspectRatio != "" || o.Width == 0 || o.Height == 0 {
+		return o.AspectRatio
+	}
+	want := float64(o.Width) / float64(o.Height)
+	nearest, distance := "", math.Inf(1)
+	for _, candidate := range AspectRatios {
+		ratio, _ := parseAspectRatio(candidate)
+		if d := math.Abs(math.Log(ratio / want)); d < distance {
+			nearest, distance = candidate, d
+		}
+	}
+	return nearest
+}
+
+// apply adds the output options to the worker job options
+func (o OutputOptions) apply(options map[string]interface{}) map[string]interface{} {
+	if options == nil {
+		options = make(map[string]interface{})
+	}
+	if o.Width > 0 {
+		options["outputWidth"] = o.Width
+	}
+	if o.Height > 0 {
+		options["outputHeight"] = o.Height
+	}
+	if aspectRatio := o.ProviderAspectRatio(); aspectRatio != "" {
+		options["aspectRatio"] = aspectRatio
+	}
+	return options
+}
+
+// parseAspectRatio returns width divided by height of a "W:H" ratio
+func parseAspectRatio(value string) (float64, bool) {
+	w, h, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, false
+	}
+	return float64(width) / float64(height), true
+}
+
+// PlanOutputStore looks up the largest result a user's active plan allows
+type PlanOutputStore interface {
+	// ActivePlanMaxOutputEdge returns the plan's longest allowed edge in
+	// pixels and false when the user has no active plan
+	ActivePlanMaxOutputEdge(ctx context.Context, userID string) (int, bool, error)
+}
+
+// SetPlanOutputLimits checks requested output dimensions against the user's plan
+func (s *Service) SetPlanOutputLimits(store PlanOutputStore) {
+	s.planOutput = store
+}
+
+// checkOutputOptions validates the requested output dimensions and checks
+// them against the user's plan. Options are saved with the conversion like
+// preset options, so they need the preset store.
+func (s *Service) checkOutputOptions(ctx context.Context, userID string, output OutputOptions) error {
+	if output.IsZero() {
+		return nil
+	}
+	if err := output.Validate(); err != nil {
+		return fmt.Errorf("invalid output options: %w", err)
+	}
+	if s.presetStore == nil {
+		return fmt.Errorf("invalid output options: %w: output options are not available", common.ErrValidation)
+	}
+
+	maxEdge := DefaultMaxOutputEdge
+	if s.planOutput != nil {
+		planEdge, ok, err := s.planOutput.ActivePlanMaxOutputEdge(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check output limit: %w", err)
+		}
+		if ok {
+			maxEdge = planEdge
+		}
+	}
+	if edge := output.LongestEdge(); edge > maxEdge {
+		return fmt.Errorf("invalid output options: %w of %d pixels, requested %d", ErrOutputTooLarge, maxEdge, edge)
+	}
+	return nil
+}
+
+// dbPlanOutputStore implements PlanOutputStore on top of user_plans and payment_plans
+type dbPlanOutputStore struct {
+	db *sql.DB
+}
+
+// NewDBPlanOutputStore creates a new database-backed plan output store
+func NewDBPlanOutputStore(db *sql.DB) PlanOutputStore {
+	return &dbPlanOutputStore{db: db}
+}
+
+// ActivePlanMaxOutputEdge reads the output limit of the newest active plan,
+// matching plan definitions by name like the quota store does
+func (s *dbPlanOutputStore) ActivePlanMaxOutputEdge(ctx context.Context, userID string) (int, bool, error) {
+	var maxEdge int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(pp.max_output_edge, $2)
+		FROM user_plans up
+		LEFT JOIN payment_plans pp ON pp.name = up.plan_name
+		WHERE up.user_id = $1 AND up.status = 'active'
+		  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+		ORDER BY up.created_at DESC
+		LIMIT 1`, userID, DefaultMaxOutputEdge).Scan(&maxEdge)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to get plan output limit: %w", err)
+	}
+	return maxEdge, true, nil
+}
@@ -0,0 +1,134 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"ai-styler/internal/common"
+)
+
+type fakePlanOutputStore struct {
+	maxEdge int
+	hasPlan bool
+}
+
+func (f *fakePlanOutputStore) ActivePlanMaxOutputEdge(ctx context.Context, userID string) (int, bool, error) {
+	return f.maxEdge, f.hasPlan, nil
+}
+
+func outputRequest(width, height int, aspectRatio string) ConversionRequest {
+	return ConversionRequest{
+		UserImageID: "user-image-id", ClothImageID: "cloth-image-id",
+		OutputWidth: width, OutputHeight: height, AspectRatio: aspectRatio,
+	}
+}
+
+func TestOutputOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		output OutputOptions
+		valid  bool
+	}{
+		{"none", OutputOptions{}, true},
+		{"width and height", OutputOptions{Width: 768, Height: 1024}, true},
+		{"matching aspect ratio", OutputOptions{Width: 768, Height: 1024, AspectRatio: "3:4"}, true},
+		{"aspect ratio only", OutputOptions{AspectRatio: "16:9"}, true},
+		{"width too small", OutputOptions{Width: 100}, false},
+		{"height too large", OutputOptions{Height: 8000}, false},
+		{"unsupported aspect ratio", OutputOptions{AspectRatio: "7:3"}, false},
+		{"malformed aspect ratio", OutputOptions{AspectRatio: "square"}, false},
+		{"mismatched aspect ratio", OutputOptions{Width: 1024, Height: 1024, AspectRatio: "3:4"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.output.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOutputOptions_ProviderAspectRatio(t *testing.T) {
+	tests := []struct {
+		output OutputOptions
+		want   string
+	}{
+		{OutputOptions{AspectRatio: "4:5"}, "4:5"},
+		{OutputOptions{Width: 1000, Height: 1000}, "1:1"},
+		{OutputOptions{Width: 1080, Height: 1920}, "9:16"},
+		{OutputOptions{Width: 700, Height: 1000}, "2:3"},
+		{OutputOptions{Width: 1024}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.output.ProviderAspectRatio(); got != tt.want {
+			t.Errorf("ProviderAspectRatio(%+v) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCreateConversion_OutputOptions(t *testing.T) {
+	service, presetStore := newPresetTestService()
+	planOutput := &fakePlanOutputStore{maxEdge: 2048, hasPlan: true}
+	service.SetPlanOutputLimits(planOutput)
+	ctx := context.Background()
+
+	if _, err := service.CreateConversion(ctx, "u1", outputRequest(1536, 2048, "")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	options := presetStore.saved["test-conversion-id"]
+	if options["outputWidth"] != 1536 || options["outputHeight"] != 2048 || options["aspectRatio"] != "3:4" {
+		t.Errorf("Expected the output options saved, got %v", options)
+	}
+
+	// Output options add to the preset's
+	req := outputRequest(0, 0, "1:1")
+	req.PresetID = testPresetID
+	if _, err := service.CreateConversion(ctx, "u1", req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	options = presetStore.saved["test-conversion-id"]
+	if options["aspectRatio"] != "1:1" || options["outputSize"] != 1024 || options["style"] != "studio" {
+		t.Errorf("Expected the preset's and output options saved, got %v", options)
+	}
+
+	if _, err := service.CreateConversion(ctx, "u1", outputRequest(4096, 0, "")); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("Expected ErrOutputTooLarge above the plan's limit, got %v", err)
+	}
+
+	// Users without an active plan get the default limit
+	planOutput.hasPlan = false
+	if _, err := service.CreateConversion(ctx, "u1", outputRequest(0, DefaultMaxOutputEdge+1, "")); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("Expected ErrOutputTooLarge above the default limit, got %v", err)
+	}
+
+	// Without a preset store the options cannot be saved
+	plain := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	if _, err := plain.CreateConversion(ctx, "u1", outputRequest(512, 512, "")); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected validation error without a preset store, got %v", err)
+	}
+}
+
+func TestConversionRequest_OutputOptions(t *testing.T) {
+	for _, body := range []string{
+		`{"userImageId":"u","clothImageId":"c","outputWidth":768,"outputHeight":1024,"aspectRatio":"3:4"}`,
+		`{"user_image_id":"u","cloth_image_id":"c","output_width":768,"output_height":1024,"aspect_ratio":"3:4"}`,
+	} {
+		var req ConversionRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if output := req.Output(); output != (OutputOptions{Width: 768, Height: 1024, AspectRatio: "3:4"}) {
+			t.Errorf("Expected 768x1024 at 3:4 from %s, got %+v", body, output)
+		}
+		if err := req.Validate(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+}
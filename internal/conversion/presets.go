@@ -0,0 +1,398 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Result qualities a preset can ask the provider for
+const (
+	PresetQualityStandard = "standard"
+	PresetQualityHigh     = "high"
+	PresetQualityUltra    = "ultra"
+)
+
+// presetQualities is the set of accepted qualities
+var presetQualities = map[string]bool{
+	PresetQualityStandard: true,
+	PresetQualityHigh:     true,
+	PresetQualityUltra:    true,
+}
+
+// Preset limits
+const (
+	MaxPresetsPerUser          = 20
+	MaxPresetNameLength        = 100
+	MaxPresetStyleLength       = 100
+	MaxPresetWatermarkLength   = 100
+	MinPresetOutputSize        = 256
+	MaxPresetOutputSize        = 4096
+	presetNameUniqueConstraint = "uq_conversion_presets_user_name"
+)
+
+var (
+	// ErrPresetNotFound is returned for presets that do not exist or belong to another user
+	ErrPresetNotFound = fmt.Errorf("preset %w", common.ErrNotFound)
+	// ErrPresetNameTaken is returned when the user already has a preset with the name
+	ErrPresetNameTaken = fmt.Errorf("%w: a preset with this name already exists", common.ErrConflict)
+	// ErrPresetLimitReached is returned when the user has MaxPresetsPerUser presets
+	ErrPresetLimitReached = fmt.Errorf("%w: at most %d presets can be saved", common.ErrConflict, MaxPresetsPerUser)
+	// ErrPresetsUnavailable is returned when presets are not configured
+	ErrPresetsUnavailable = errors.New("conversion presets are not available")
+)
+
+// PresetRequest is the body of POST and PUT /users/me/presets. Options left
+// empty fall back to the defaults of the conversion pipeline.
+type PresetRequest struct {
+	Name          string `json:"name" binding:"required"`
+	StyleName     string `json:"styleName,omitempty"`
+	Quality       string `json:"quality,omitempty"`
+	OutputSize    int    `json:"outputSize,omitempty"`
+	WatermarkText string `json:"watermarkText,omitempty"`
+	IsDefault     bool   `json:"isDefault"`
+}
+
+// Preset is a named set of conversion options saved by a user. Clients such as
+// the Telegram bot use the default preset when the user does not pick one.
+type Preset struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	StyleName     string    `json:"styleName,omitempty"`
+	Quality       string    `json:"quality,omitempty"`
+	OutputSize    int       `json:"outputSize,omitempty"`
+	WatermarkText string    `json:"watermarkText,omitempty"`
+	IsDefault     bool      `json:"isDefault"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// PresetList is the response of GET /users/me/presets
+type PresetList struct {
+	Presets []Preset `json:"presets"`
+}
+
+// Options returns the worker job options of a conversion created from the
+// preset. styleName is the conversion's style, which may override the preset's.
+func (p Preset) Options(styleName string) map[string]interface{} {
+	options := make(map[string]interface{})
+	if styleName != "" {
+		options["style"] = styleName
+	}
+	if p.Quality != "" {
+		options["quality"] = p.Quality
+	}
+	if p.OutputSize > 0 {
+		options["outputSize"] = p.OutputSize
+	}
+	if p.WatermarkText != "" {
+		options["watermarkText"] = p.WatermarkText
+	}
+	return options
+}
+
+// PresetStore stores conversion presets
+type PresetStore interface {
+	ListPresets(ctx context.Context, userID string) ([]Preset, error)
+	// GetPreset returns ErrPresetNotFound unless the preset belongs to userID
+	GetPreset(ctx context.Context, presetID, userID string) (Preset, error)
+	// CreatePreset and UpdatePreset clear the user's previous default when
+	// the preset is marked default
+	CreatePreset(ctx context.Context, userID string, req PresetRequest) (Preset, error)
+	UpdatePreset(ctx context.Context, presetID, userID string, req PresetRequest) (Preset, error)
+	DeletePreset(ctx context.Context, presetID, userID string) error
+	// SaveConversionOptions records the preset a conversion was created from,
+	// empty for none, and the options the worker applies to it
+	SaveConversionOptions(ctx context.Context, conversionID, presetID string, options map[string]interface{}) error
+}
+
+// SetPresets enables saved conversion presets
+func (s *Service) SetPresets(store PresetStore) {
+	s.presetStore = store
+}
+
+// ListPresets returns the user's presets, the default first
+func (s *Service) ListPresets(ctx context.Context, userID string) (PresetList, error) {
+	if s.presetStore == nil {
+		return PresetList{}, ErrPresetsUnavailable
+	}
+	presets, err := s.presetStore.ListPresets(ctx, userID)
+	if err != nil {
+		return PresetList{}, err
+	}
+	return PresetList{Presets: presets}, nil
+}
+
+// CreatePreset saves a new preset for the user
+func (s *Service) CreatePreset(ctx context.Context, userID string, req PresetRequest) (Preset, error) {
+	if s.presetStore == nil {
+		return Preset{}, ErrPresetsUnavailable
+	}
+
+	req, err := normalizePreset(req)
+	if err != nil {
+		return Preset{}, err
+	}
+
+	presets, err := s.presetStore.ListPresets(ctx, userID)
+	if err != nil {
+		return Preset{}, err
+	}
+	if len(presets) >= MaxPresetsPerUser {
+		return Preset{}, ErrPresetLimitReached
+	}
+
+	return s.presetStore.CreatePreset(ctx, userID, req)
+}
+
+// UpdatePreset replaces the options of one of the user's presets
+func (s *Service) UpdatePreset(ctx context.Context, presetID, userID string, req PresetRequest) (Preset, error) {
+	if s.presetStore == nil {
+		return Preset{}, ErrPresetsUnavailable
+	}
+	if _, err := uuid.Parse(presetID); err != nil {
+		return Preset{}, ErrPresetNotFound
+	}
+
+	req, err := normalizePreset(req)
+	if err != nil {
+		return Preset{}, err
+	}
+	return s.presetStore.UpdatePreset(ctx, presetID, userID, req)
+}
+
+// DeletePreset deletes one of the user's presets. Conversions created from it
+// keep their options.
+func (s *Service) DeletePreset(ctx context.Context, presetID, userID string) error {
+	if s.presetStore == nil {
+		return ErrPresetsUnavailable
+	}
+	if _, err := uuid.Parse(presetID); err != nil {
+		return ErrPresetNotFound
+	}
+	return s.presetStore.DeletePreset(ctx, presetID, userID)
+}
+
+// getPreset resolves the preset a conversion request references
+func (s *Service) getPreset(ctx context.Context, presetID, userID string) (Preset, error) {
+	if s.presetStore == nil {
+		return Preset{}, ErrPresetsUnavailable
+	}
+	if _, err := uuid.Parse(presetID); err != nil {
+		return Preset{}, ErrPresetNotFound
+	}
+	return s.presetStore.GetPreset(ctx, presetID, userID)
+}
+
+// normalizePreset validates a preset and trims its text fields
+func normalizePreset(req PresetRequest) (PresetRequest, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return req, fmt.Errorf("%w: name is required", common.ErrValidation)
+	}
+	if len([]rune(req.Name)) > MaxPresetNameLength {
+		return req, fmt.Errorf("%w: name must be at most %d characters", common.ErrValidation, MaxPresetNameLength)
+	}
+
+	req.StyleName = strings.TrimSpace(req.StyleName)
+	if len([]rune(req.StyleName)) > MaxPresetStyleLength {
+		return req, fmt.Errorf("%w: style name must be at most %d characters", common.ErrValidation, MaxPresetStyleLength)
+	}
+
+	req.Quality = strings.ToLower(strings.TrimSpace(req.Quality))
+	if req.Quality != "" && !presetQualities[req.Quality] {
+		return req, fmt.Errorf("%w: quality must be one of standard, high or ultra", common.ErrValidation)
+	}
+
+	if req.OutputSize != 0 && (req.OutputSize < MinPresetOutputSize || req.OutputSize > MaxPresetOutputSize) {
+		return req, fmt.Errorf("%w: output size must be between %d and %d pixels", common.ErrValidation, MinPresetOutputSize, MaxPresetOutputSize)
+	}
+
+	req.WatermarkText = strings.TrimSpace(req.WatermarkText)
+	if len([]rune(req.WatermarkText)) > MaxPresetWatermarkLength {
+		return req, fmt.Errorf("%w: watermark text must be at most %d characters", common.ErrValidation, MaxPresetWatermarkLength)
+	}
+	return req, nil
+}
+
+// dbPresetStore implements PresetStore on top of the conversion_presets table
+type dbPresetStore struct {
+	db *sql.DB
+}
+
+// NewDBPresetStore creates a new database-backed preset store
+func NewDBPresetStore(db *sql.DB) PresetStore {
+	return &dbPresetStore{db: db}
+}
+
+const presetColumns = `id, name, style_name, quality, output_size, watermark_text, is_default, created_at, updated_at`
+
+// scanPreset scans a row of presetColumns
+func scanPreset(row interface{ Scan(...interface{}) error }) (Preset, error) {
+	var preset Preset
+	var styleName, quality, watermarkText sql.NullString
+	var outputSize sql.NullInt64
+	err := row.Scan(&preset.ID, &preset.Name, &styleName, &quality, &outputSize, &watermarkText,
+		&preset.IsDefault, &preset.CreatedAt, &preset.UpdatedAt)
+	if err != nil {
+		return Preset{}, err
+	}
+	preset.StyleName = styleName.String
+	preset.Quality = quality.String
+	preset.OutputSize = int(outputSize.Int64)
+	preset.WatermarkText = watermarkText.String
+	return preset, nil
+}
+
+func (s *dbPresetStore) ListPresets(ctx context.Context, userID string) ([]Preset, error) {
+	rows, err := common.Conn(ctx, s.db).QueryContext(ctx, `
+		SELECT `+presetColumns+`
+		FROM conversion_presets
+		WHERE user_id = $1
+		ORDER BY is_default DESC, name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list presets: %w", err)
+	}
+	defer rows.Close()
+
+	presets := []Preset{}
+	for rows.Next() {
+		preset, err := scanPreset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan preset: %w", err)
+		}
+		presets = append(presets, preset)
+	}
+	return presets, rows.Err()
+}
+
+func (s *dbPresetStore) GetPreset(ctx context.Context, presetID, userID string) (Preset, error) {
+	preset, err := scanPreset(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT `+presetColumns+`
+		FROM conversion_presets
+		WHERE id = $1 AND user_id = $2`, presetID, userID))
+	switch {
+	case err == sql.ErrNoRows:
+		return Preset{}, ErrPresetNotFound
+	case err != nil:
+		return Preset{}, fmt.Errorf("failed to get preset: %w", err)
+	}
+	return preset, nil
+}
+
+func (s *dbPresetStore) CreatePreset(ctx context.Context, userID string, req PresetRequest) (Preset, error) {
+	var preset Preset
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		if err := clearDefaultPreset(ctx, tx, userID, req.IsDefault); err != nil {
+			return err
+		}
+		var err error
+		preset, err = scanPreset(tx.QueryRowContext(ctx, `
+			INSERT INTO conversion_presets (user_id, name, style_name, quality, output_size, watermark_text, is_default)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING `+presetColumns,
+			userID, req.Name, nullPresetString(req.StyleName), nullPresetString(req.Quality),
+			sql.NullInt64{Int64: int64(req.OutputSize), Valid: req.OutputSize > 0},
+			nullPresetString(req.WatermarkText), req.IsDefault))
+		if err != nil {
+			return presetWriteError(err, "failed to create preset")
+		}
+		return nil
+	})
+	return preset, err
+}
+
+func (s *dbPresetStore) UpdatePreset(ctx context.Context, presetID, userID string, req PresetRequest) (Preset, error) {
+	var preset Preset
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		if err := clearDefaultPreset(ctx, tx, userID, req.IsDefault); err != nil {
+			return err
+		}
+		var err error
+		preset, err = scanPreset(tx.QueryRowContext(ctx, `
+			UPDATE conversion_presets
+			SET name = $3,
+			    style_name = $4,
+			    quality = $5,
+			    output_size = $6,
+			    watermark_text = $7,
+			    is_default = $8,
+			    updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING `+presetColumns,
+			presetID, userID, req.Name, nullPresetString(req.StyleName), nullPresetString(req.Quality),
+			sql.NullInt64{Int64: int64(req.OutputSize), Valid: req.OutputSize > 0},
+			nullPresetString(req.WatermarkText), req.IsDefault))
+		if err == sql.ErrNoRows {
+			return ErrPresetNotFound
+		}
+		if err != nil {
+			return presetWriteError(err, "failed to update preset")
+		}
+		return nil
+	})
+	return preset, err
+}
+
+func (s *dbPresetStore) DeletePreset(ctx context.Context, presetID, userID string) error {
+	result, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		DELETE FROM conversion_presets WHERE id = $1 AND user_id = $2`, presetID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete preset: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrPresetNotFound
+	}
+	return nil
+}
+
+func (s *dbPresetStore) SaveConversionOptions(ctx context.Context, conversionID, presetID string, options map[string]interface{}) error {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversion options: %w", err)
+	}
+	_, err = common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE conversions SET preset_id = NULLIF($2, '')::uuid, options = $3 WHERE id = $1`,
+		conversionID, presetID, string(optionsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save conversion options: %w", err)
+	}
+	return nil
+}
+
+// clearDefaultPreset unsets the user's default preset before another one
+// becomes the default
+func clearDefaultPreset(ctx context.Context, tx common.DBTX, userID string, isDefault bool) error {
+	if !isDefault {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE conversion_presets SET is_default = FALSE, updated_at = NOW()
+		WHERE user_id = $1 AND is_default`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear default preset: %w", err)
+	}
+	return nil
+}
+
+// presetWriteError maps a duplicate preset name to ErrPresetNameTaken
+func presetWriteError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == presetNameUniqueConstraint {
+		return ErrPresetNameTaken
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// nullPresetString stores empty preset options as NULL
+func nullPresetString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,167 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"ai-styler/internal/common"
+)
+
+const testPresetID = "5f0c6a9e-2f43-4d8b-9a51-8e1c2b3d4f60"
+
+type fakePresetStore struct {
+	presets map[string]Preset
+	created []PresetRequest
+	saved   map[string]map[string]interface{}
+}
+
+func (f *fakePresetStore) ListPresets(ctx context.Context, userID string) ([]Preset, error) {
+	presets := []Preset{}
+	for _, preset := range f.presets {
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+func (f *fakePresetStore) GetPreset(ctx context.Context, presetID, userID string) (Preset, error) {
+	preset, ok := f.presets[userID+"/"+presetID]
+	if !ok {
+		return Preset{}, ErrPresetNotFound
+	}
+	return preset, nil
+}
+
+func (f *fakePresetStore) CreatePreset(ctx context.Context, userID string, req PresetRequest) (Preset, error) {
+	f.created = append(f.created, req)
+	return Preset{ID: testPresetID, Name: req.Name, Quality: req.Quality}, nil
+}
+
+func (f *fakePresetStore) UpdatePreset(ctx context.Context, presetID, userID string, req PresetRequest) (Preset, error) {
+	return Preset{ID: presetID, Name: req.Name}, nil
+}
+
+func (f *fakePresetStore) DeletePreset(ctx context.Context, presetID, userID string) error {
+	return nil
+}
+
+func (f *fakePresetStore) SaveConversionOptions(ctx context.Context, conversionID, presetID string, options map[string]interface{}) error {
+	f.saved[conversionID] = options
+	return nil
+}
+
+func newPresetTestService() (*Service, *fakePresetStore) {
+	service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	presetStore := &fakePresetStore{
+		presets: map[string]Preset{
+			"u1/" + testPresetID: {
+				ID: testPresetID, Name: "Studio", StyleName: "studio",
+				Quality: PresetQualityHigh, OutputSize: 1024, WatermarkText: "@shop",
+			},
+		},
+		saved: make(map[string]map[string]interface{}),
+	}
+	service.SetPresets(presetStore)
+	return service, presetStore
+}
+
+func TestCreatePreset_Validation(t *testing.T) {
+	service, presetStore := newPresetTestService()
+	ctx := context.Background()
+
+	if _, err := service.CreatePreset(ctx, "u1", PresetRequest{Name: "  Night out ", Quality: "High", WatermarkText: " @me "}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	created := presetStore.created[0]
+	if created.Name != "Night out" || created.Quality != PresetQualityHigh || created.WatermarkText != "@me" {
+		t.Errorf("Expected normalized preset, got %+v", created)
+	}
+
+	tests := []struct {
+		name string
+		req  PresetRequest
+	}{
+		{"blank name", PresetRequest{Name: "  "}},
+		{"unknown quality", PresetRequest{Name: "a", Quality: "best"}},
+		{"output size too small", PresetRequest{Name: "a", OutputSize: 100}},
+		{"output size too large", PresetRequest{Name: "a", OutputSize: 8000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreatePreset(ctx, "u1", tt.req); !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreatePreset_Limit(t *testing.T) {
+	service, presetStore := newPresetTestService()
+	for i := 0; i < MaxPresetsPerUser; i++ {
+		presetStore.presets[string(rune('a'+i))] = Preset{}
+	}
+	if _, err := service.CreatePreset(context.Background(), "u1", PresetRequest{Name: "one more"}); !errors.Is(err, ErrPresetLimitReached) {
+		t.Errorf("Expected ErrPresetLimitReached, got %v", err)
+	}
+}
+
+func TestCreateConversion_WithPreset(t *testing.T) {
+	service, presetStore := newPresetTestService()
+	ctx := context.Background()
+
+	_, err := service.CreateConversion(ctx, "u1", ConversionRequest{
+		UserImageID: "user-image-id", ClothImageID: "cloth-image-id", PresetID: testPresetID,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	options := presetStore.saved["test-conversion-id"]
+	if options["style"] != "studio" || options["quality"] != PresetQualityHigh || options["outputSize"] != 1024 || options["watermarkText"] != "@shop" {
+		t.Errorf("Expected the preset's options saved, got %v", options)
+	}
+
+	// A style picked for the conversion overrides the preset's
+	_, err = service.CreateConversion(ctx, "u1", ConversionRequest{
+		UserImageID: "user-image-id", ClothImageID: "cloth-image-id", PresetID: testPresetID, StyleName: "casual",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if style := presetStore.saved["test-conversion-id"]["style"]; style != "casual" {
+		t.Errorf("Expected style casual, got %v", style)
+	}
+
+	for _, presetID := range []string{"not-a-uuid", "0b8f3c52-6a1e-4f7d-8c2b-9d4e5f6a7b8c"} {
+		_, err := service.CreateConversion(ctx, "u2", ConversionRequest{
+			UserImageID: "user-image-id", ClothImageID: "cloth-image-id", PresetID: presetID,
+		})
+		if !errors.Is(err, ErrPresetNotFound) {
+			t.Errorf("Expected ErrPresetNotFound for %q, got %v", presetID, err)
+		}
+	}
+}
+
+func TestConversionRequest_PresetID(t *testing.T) {
+	for _, body := range []string{
+		`{"userImageId":"u","clothImageId":"c","presetId":"p1"}`,
+		`{"user_image_id":"u","cloth_image_id":"c","preset_id":"p1"}`,
+	} {
+		var req ConversionRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if req.GetPresetID() != "p1" {
+			t.Errorf("Expected preset p1 from %s, got %q", body, req.GetPresetID())
+		}
+	}
+}
+
+func TestPresets_Unavailable(t *testing.T) {
+	service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	if _, err := service.ListPresets(context.Background(), "u1"); !errors.Is(err, ErrPresetsUnavailable) {
+		t.Errorf("Expected ErrPresetsUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PlanPriorityStore looks up the queue priority of a user's active plan
+type PlanPriorityStore interface {
+	// ActivePlanPriority returns the plan's priority and false when the user
+	// has no active plan
+	ActivePlanPriority(ctx context.Context, userID string) (int, bool, error)
+}
+
+// SetPlanPriority queues conversions with the priority of the user's plan
+func (s *Service) SetPlanPriority(store PlanPriorityStore) {
+	s.planPriority = store
+}
+
+// jobPriority returns the worker job priority of a new conversion: the plan
+// priority, raised to the onboarding fast lane for a first conversion
+func (s *Service) jobPriority(ctx context.Context, userID string, fastLane bool) int {
+	priority := DefaultJobPriority
+
+	if s.planPriority != nil {
+		planPriority, ok, err := s.planPriority.ActivePlanPriority(ctx, userID)
+		if err != nil {
+			// Log but don't fail the request - fall back to the default priority
+			fmt.Printf("Failed to get plan priority: %v\n", err)
+		} else if ok {
+			priority = planPriority
+		}
+	}
+
+	if fastLane && s.onboarding.Priority() > priority {
+		priority = s.onboarding.Priority()
+	}
+
+	return priority
+}
+
+// dbPlanPriorityStore implements PlanPriorityStore on top of user_plans and payment_plans
+type dbPlanPriorityStore struct {
+	db *sql.DB
+}
+
+// NewDBPlanPriorityStore creates a new database-backed plan priority store
+func NewDBPlanPriorityStore(db *sql.DB) PlanPriorityStore {
+	return &dbPlanPriorityStore{db: db}
+}
+
+// ActivePlanPriority reads the queue priority of the newest active plan,
+// matching plan definitions by name like the quota store does
+func (s *dbPlanPriorityStore) ActivePlanPriority(ctx context.Context, userID string) (int, bool, error) {
+	var priority int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(pp.queue_priority, $2)
+		FROM user_plans up
+		LEFT JOIN payment_plans pp ON pp.name = up.plan_name
+		WHERE up.user_id = $1 AND up.status = 'active'
+		  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+		ORDER BY up.created_at DESC
+		LIMIT 1`, userID, DefaultJobPriority).Scan(&priority)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to get plan priority: %w", err)
+	}
+	return priority, true, nil
+}
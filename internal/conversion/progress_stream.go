@@ -0,0 +1,103 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// progressPollInterval is how often a progress stream checks its conversion
+	progressPollInterval = time.Second
+	// progressHeartbeatInterval keeps idle streams from being closed by proxies
+	progressHeartbeatInterval = 15 * time.Second
+	// progressStreamTimeout ends streams of conversions that never finish
+	progressStreamTimeout = 10 * time.Minute
+)
+
+// ProgressEvent is the data of a progress event on a conversion's event stream
+type ProgressEvent struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+}
+
+// StreamConversionEvents handles GET /conversion/{id}/events. It streams
+// Server-Sent Events: a "progress" event whenever the status or progress of
+// the conversion changes, then a "done" event with the full conversion once
+// it completed or failed, after which the stream ends.
+func (h *Handler) StreamConversionEvents(c *gin.Context) {
+	userID, err := requireUser(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusUnauthorized, err)
+		return
+	}
+	conversionID := c.Param("id")
+
+	current, err := h.service.GetConversion(c.Request.Context(), conversionID, userID)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, conversionError(err, "failed to get conversion"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering so events arrive as they happen
+	c.Status(http.StatusOK)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), progressStreamTimeout)
+	defer cancel()
+
+	poll := time.NewTicker(progressPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(progressHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var last ProgressEvent
+	for {
+		event := ProgressEvent{ID: current.ID, Status: current.Status, Progress: current.Progress}
+		if event != last {
+			writeEvent(c.Writer, "progress", event)
+			last = event
+		}
+		if current.IsFinished() {
+			writeEvent(c.Writer, "done", current)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case <-poll.C:
+			next, err := h.service.GetConversion(ctx, conversionID, userID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// Keep the last known state and try again on the next tick
+				continue
+			}
+			current = next
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with data encoded as JSON and flushes it
+func writeEvent(w gin.ResponseWriter, name string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	w.Flush()
+}
@@ -0,0 +1,89 @@
+package conversion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scriptedStore returns the next of its states on every conversion lookup
+type scriptedStore struct {
+	*mockStore
+	states []Conversion
+}
+
+func (s *scriptedStore) GetConversionWithDetails(ctx context.Context, conversionID string) (ConversionResponse, error) {
+	state := s.states[0]
+	if len(s.states) > 1 {
+		s.states = s.states[1:]
+	}
+	return ConversionResponse{Conversion: state}, nil
+}
+
+func streamEvents(t *testing.T, store Store, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(NewService(store, &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{}))
+
+	router := gin.New()
+	router.GET("/conversion/:id/events", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(common.SetUserIDInContext(c.Request.Context(), userID))
+		c.Next()
+	}, handler.StreamConversionEvents)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/conversion/c1/events", nil))
+	return recorder
+}
+
+func TestStreamConversionEvents(t *testing.T) {
+	defer func(interval time.Duration) { progressPollInterval = interval }(progressPollInterval)
+	progressPollInterval = time.Millisecond
+
+	conversion := Conversion{ID: "c1", UserID: "u1", Status: ConversionStatusPending}
+	processing, stored, completed := conversion, conversion, conversion
+	processing.Status, processing.Progress = ConversionStatusProcessing, 40
+	stored.Status, stored.Progress = ConversionStatusProcessing, 95
+	completed.Status, completed.Progress = ConversionStatusCompleted, 100
+	store := &scriptedStore{mockStore: newMockStore(), states: []Conversion{conversion, processing, processing, stored, completed}}
+
+	recorder := streamEvents(t, store, "u1")
+	if ct := recorder.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+
+	body := recorder.Body.String()
+	expected := []string{
+		`event: progress` + "\n" + `data: {"id":"c1","status":"pending","progress":0}`,
+		`event: progress` + "\n" + `data: {"id":"c1","status":"processing","progress":40}`,
+		`event: progress` + "\n" + `data: {"id":"c1","status":"processing","progress":95}`,
+		`event: progress` + "\n" + `data: {"id":"c1","status":"completed","progress":100}`,
+		`event: done`,
+	}
+	position := 0
+	for _, event := range expected {
+		index := strings.Index(body[position:], event)
+		if index < 0 {
+			t.Fatalf("Expected %q after position %d in stream:\n%s", event, position, body)
+		}
+		position += index + len(event)
+	}
+	if strings.Count(body, "event: progress") != 4 {
+		t.Errorf("Expected unchanged states to be skipped, got:\n%s", body)
+	}
+}
+
+func TestStreamConversionEvents_OtherUser(t *testing.T) {
+	store := &scriptedStore{mockStore: newMockStore(), states: []Conversion{{ID: "c1", UserID: "u1", Status: ConversionStatusPending}}}
+	if recorder := streamEvents(t, store, "u2"); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", recorder.Code)
+	}
+}
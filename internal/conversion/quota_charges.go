@@ -0,0 +1,57 @@
+package conversion
+
+import (
+	"context"
+	"fmt"
+
+	"ai-styler/internal/quota"
+)
+
+// QuotaCharges records what every conversion was charged, so the worker can
+// refund conversions that fail through no fault of the user, and reports the
+// quota those charges leave
+type QuotaCharges interface {
+	RecordCharges(ctx context.Context, conversionID string, reservations ...quota.Reservation) error
+	GetStatus(ctx context.Context, userID string) (quota.Status, error)
+}
+
+// SetQuotaCharges ties the quota charged for conversions and their retries
+// to them. The quota status is then reported from charges instead of the
+// legacy quota.
+func (s *Service) SetQuotaCharges(charges QuotaCharges) {
+	s.quotaCharges = charges
+}
+
+// recordCharges ties the reservations a conversion was charged with to it.
+// A conversion whose charges could not be recorded stays charged if it fails.
+func (s *Service) recordCharges(ctx context.Context, conversionID string, reservation *quota.Reservation, outfitCharge *garmentCharge) {
+	if s.quotaCharges == nil {
+		return
+	}
+
+	var reservations []quota.Reservation
+	if reservation != nil {
+		reservations = append(reservations, *reservation)
+	}
+	if outfitCharge != nil {
+		reservations = append(reservations, outfitCharge.reservations...)
+	}
+	if err := s.quotaCharges.RecordCharges(ctx, conversionID, reservations...); err != nil {
+		// Log but don't fail the request - the conversion is created
+		fmt.Printf("Failed to record quota charges of conversion %s: %v\n", conversionID, err)
+	}
+}
+
+// quotaCheckOf reports a quota status in the shape of the legacy quota check
+func quotaCheckOf(status quota.Status) QuotaCheck {
+	return QuotaCheck{
+		CanConvert:     status.Remaining > 0,
+		RemainingFree:  status.FreeRemaining,
+		RemainingPaid:  status.PlanRemaining,
+		TotalRemaining: status.Remaining,
+		PlanName:       status.PlanName,
+		MonthlyLimit:   status.PlanLimit,
+		Used:           status.Used,
+		ResetAt:        status.ResetAt,
+	}
+}
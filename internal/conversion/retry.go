@@ -0,0 +1,292 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/quota"
+)
+
+// DefaultMaxConversionRetries is the per-conversion retry cap used when none is configured
+const DefaultMaxConversionRetries = 3
+
+// Failure kinds recorded by the worker when a conversion fails
+const (
+	// FailureKindProvider is a provider or server error; retries are not charged
+	FailureKindProvider = "provider"
+	// FailureKindRequest is caused by the request itself, e.g. a rejected
+	// image; retries are charged like a new conversion
+	FailureKindRequest = "request"
+)
+
+var (
+	// ErrRetryNotAllowed is returned when the conversion is not in the failed state
+	ErrRetryNotAllowed = fmt.Errorf("%w: only failed conversions can be retried", common.ErrConflict)
+	// ErrRetryLimitReached is returned when the conversion used up its retries
+	ErrRetryLimitReached = fmt.Errorf("%w: conversion retry limit reached", common.ErrConflict)
+	// ErrRetryUnavailable is returned when retries are not configured
+	ErrRetryUnavailable = errors.New("conversion retry is not available")
+)
+
+// RetryState is the part of a conversion the retry policy looks at
+type RetryState struct {
+	UserID      string
+	Status      string
+	RetryCount  int
+	FailureKind string // empty when the failure was not classified, e.g. a cancellation
+
+	// QuotaRefunded is set once the quota the conversion was charged was
+	// given back, which happens to provider failures
+	QuotaRefunded bool
+}
+
+// RetryStore reads and resets failed conversions for a retry
+type RetryStore interface {
+	GetRetryState(ctx context.Context, conversionID string) (RetryState, error)
+	// RetryConversion moves a failed conversion back to pending if its retry
+	// count still equals expectedCount, and records the retry in the audit
+	// trail. It returns the new retry count.
+	RetryConversion(ctx context.Context, conversionID, userID string, expectedCount int, quotaCharged bool) (int, error)
+}
+
+// RetryQuota charges retries of conversions that failed because of the request
+type RetryQuota interface {
+	Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error)
+	Release(ctx context.Context, reservation quota.Reservation) error
+}
+
+// RetryResponse is a re-enqueued conversion together with its retry budget
+type RetryResponse struct {
+	ConversionResponse
+	RetryCount       int  `json:"retryCount"`
+	RetriesRemaining int  `json:"retriesRemaining"`
+	QuotaCharged     bool `json:"quotaCharged"`
+}
+
+// SetRetries enables retrying failed conversions. quota may be nil, in which
+// case charged retries are checked against the legacy conversion quota and
+// are not refunded when they fail again.
+func (s *Service) SetRetries(store RetryStore, quota RetryQuota, maxRetries int) {
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxConversionRetries
+	}
+	if quota == nil {
+		log.Printf("WARNING: conversion retries are not charged to the row-locked quota, only the legacy conversion quota is checked")
+	}
+	s.retryStore = store
+	s.retryQuota = quota
+	s.maxRetries = maxRetries
+}
+
+// RetryConversion re-enqueues a failed conversion with its original input
+// images. Provider failures are retried without consuming quota, unless the
+// quota was already refunded for the failure.
+func (s *Service) RetryConversion(ctx context.Context, conversionID, userID string) (RetryResponse, error) {
+	if s.retryStore == nil {
+		return RetryResponse{}, ErrRetryUnavailable
+	}
+
+	state, err := s.retryStore.GetRetryState(ctx, conversionID)
+	if err != nil {
+		return RetryResponse{}, fmt.Errorf("failed to get conversion: %w", err)
+	}
+	if state.UserID != userID {
+		return RetryResponse{}, fmt.Errorf("conversion not found")
+	}
+	if state.Status != ConversionStatusFailed {
+		return RetryResponse{}, ErrRetryNotAllowed
+	}
+	if state.RetryCount >= s.maxRetries {
+		return RetryResponse{}, ErrRetryLimitReached
+	}
+
+	// Only provider failures are free; request failures and cancellations
+	// are charged like a new conversion, and so are refunded conversions
+	charge := state.FailureKind != FailureKindProvider || state.QuotaRefunded
+	var reservation *quota.Reservation
+	var outfitCharge *garmentCharge
+	if charge {
+		// Outfits are charged once per garment, like when they were created
+		garments, err := s.conversionGarmentCount(ctx, conversionID)
+		if err != nil {
+			return RetryResponse{}, fmt.Errorf("failed to get conversion garments: %w", err)
+		}
+		if outfitCharge, err = s.chargeGarments(ctx, userID, garments-1); err != nil {
+			return RetryResponse{}, err
+		}
+		reservation, err = s.chargeRetry(ctx, userID)
+		if err != nil {
+			s.releaseGarments(ctx, userID, outfitCharge)
+			return RetryResponse{}, err
+		}
+	}
+
+	retryCount, err := s.retryStore.RetryConversion(ctx, conversionID, userID, state.RetryCount, charge)
+	if err != nil {
+		s.releaseRetry(ctx, reservation)
+		s.releaseGarments(ctx, userID, outfitCharge)
+		return RetryResponse{}, err
+	}
+	s.recordCharges(ctx, conversionID, reservation, outfitCharge)
+
+	// Record metrics
+	if err := s.metrics.RecordConversionStart(ctx, conversionID, userID); err != nil {
+		// Log but don't fail the request
+		fmt.Printf("Failed to record metrics: %v\n", err)
+	}
+
+	// Enqueue job for processing
+	if err := s.worker.EnqueueConversion(ctx, conversionID, s.jobPriority(ctx, userID, false)); err != nil {
+		// Log but don't fail the request - conversion is pending again
+		fmt.Printf("Failed to enqueue conversion retry: %v\n", err)
+	}
+
+	conversion, err := s.store.GetConversionWithDetails(ctx, conversionID)
+	if err != nil {
+		return RetryResponse{}, fmt.Errorf("failed to get retried conversion: %w", err)
+	}
+
+	return RetryResponse{
+		ConversionResponse: conversion,
+		RetryCount:         retryCount,
+		RetriesRemaining:   s.maxRetries - retryCount,
+		QuotaCharged:       charge,
+	}, nil
+}
+
+// chargeRetry consumes one conversion from the user's plan. The returned
+// reservation is nil when the legacy quota was checked instead.
+func (s *Service) chargeRetry(ctx context.Context, userID string) (*quota.Reservation, error) {
+	if s.retryQuota != nil {
+		reservation, _, err := s.retryQuota.Consume(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &reservation, nil
+	}
+
+	quotaCheck, err := s.store.CheckUserQuota(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if !quotaCheck.CanConvert {
+		return nil, fmt.Errorf("quota exceeded: free=%d, paid=%d", quotaCheck.RemainingFree, quotaCheck.RemainingPaid)
+	}
+	if err := s.store.ReserveQuota(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to reserve quota: %w", err)
+	}
+	return nil, nil
+}
+
+// releaseRetry gives back the conversion charged for a retry that did not happen
+func (s *Service) releaseRetry(ctx context.Context, reservation *quota.Reservation) {
+	if reservation == nil {
+		return
+	}
+	if err := s.retryQuota.Release(ctx, *reservation); err != nil {
+		fmt.Printf("Failed to release retry quota: %v\n", err)
+	}
+}
+
+// dbRetryStore implements RetryStore on top of the conversions table
+type dbRetryStore struct {
+	db *sql.DB
+}
+
+// NewDBRetryStore creates a new database-backed retry store
+func NewDBRetryStore(db *sql.DB) RetryStore {
+	return &dbRetryStore{db: db}
+}
+
+// GetRetryState reads the owner, status and retry bookkeeping of a conversion,
+// and whether its quota was refunded
+func (s *dbRetryStore) GetRetryState(ctx context.Context, conversionID string) (RetryState, error) {
+	var state RetryState
+	var userID, failureKind sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT c.user_id, c.status, c.retry_count, c.failure_kind,
+		       EXISTS (SELECT 1 FROM conversion_quota_charges q WHERE q.conversion_id = c.id AND q.refunded_at IS NOT NULL)
+		FROM conversions c
+		WHERE c.id = $1`, conversionID).Scan(&userID, &state.Status, &state.RetryCount, &failureKind, &state.QuotaRefunded)
+	switch {
+	case err == sql.ErrNoRows:
+		return RetryState{}, fmt.Errorf("conversion not found")
+	case err != nil:
+		return RetryState{}, fmt.Errorf("failed to get retry state: %w", err)
+	}
+	state.UserID = userID.String
+	state.FailureKind = failureKind.String
+	return state, nil
+}
+
+// RetryConversion resets the conversion to pending and writes the audit log
+// and conversion log entries in the same transaction. A concurrent retry
+// changes retry_count first, so the second one fails with ErrRetryNotAllowed.
+func (s *dbRetryStore) RetryConversion(ctx context.Context, conversionID, userID string, expectedCount int, quotaCharged bool) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var retryCount int
+	var previousError, failureKind sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		UPDATE conversions c
+		SET status = 'pending',
+		    result_image_id = NULL,
+		    error_message = NULL,
+		    processing_time_ms = NULL,
+		    progress = 0,
+		    failure_kind = NULL,
+		    error_code = NULL,
+		    dead_lettered_at = NULL,
+		    retry_count = c.retry_count + 1,
+		    updated_at = NOW()
+		FROM conversions previous
+		WHERE c.id = $1 AND previous.id = c.id
+		  AND c.status = 'failed' AND c.retry_count = $2
+		RETURNING c.retry_count, previous.error_message, previous.failure_kind`,
+		conversionID, expectedCount).Scan(&retryCount, &previousError, &failureKind)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, ErrRetryNotAllowed
+	case err != nil:
+		return 0, fmt.Errorf("failed to retry conversion: %w", err)
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"retry_count":    retryCount,
+		"previous_error": previousError.String,
+		"failure_kind":   failureKind.String,
+		"quota_charged":  quotaCharged,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode retry metadata: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, resource_id, metadata)
+		VALUES ($1, 'user', 'conversion_retry', 'conversion', $2, $3)`,
+		userID, conversionID, metadata); err != nil {
+		return 0, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO conversion_logs (conversion_id, stage, level, message, attempt, metadata)
+		VALUES ($1, 'retried', 'info', 'Conversion retried by user', $2, $3)`,
+		conversionID, retryCount, metadata); err != nil {
+		return 0, fmt.Errorf("failed to write conversion log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit conversion retry: %w", err)
+	}
+
+	return retryCount, nil
+}
@@ -0,0 +1,192 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ai-styler/internal/quota"
+)
+
+type fakeRetryStore struct {
+	states  map[string]RetryState
+	charged []bool
+}
+
+func (f *fakeRetryStore) GetRetryState(ctx context.Context, conversionID string) (RetryState, error) {
+	state, ok := f.states[conversionID]
+	if !ok {
+		return RetryState{}, fmt.Errorf("conversion not found")
+	}
+	return state, nil
+}
+
+func (f *fakeRetryStore) RetryConversion(ctx context.Context, conversionID, userID string, expectedCount int, quotaCharged bool) (int, error) {
+	state := f.states[conversionID]
+	if state.Status != ConversionStatusFailed || state.RetryCount != expectedCount {
+		return 0, ErrRetryNotAllowed
+	}
+	state.Status = ConversionStatusPending
+	state.FailureKind = ""
+	state.RetryCount++
+	f.states[conversionID] = state
+	f.charged = append(f.charged, quotaCharged)
+	return state.RetryCount, nil
+}
+
+type fakeRetryQuota struct {
+	remaining int
+	consumed  int
+}
+
+func (f *fakeRetryQuota) Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error) {
+	if f.remaining == 0 {
+		return quota.Reservation{}, quota.Status{}, &quota.ExceededError{}
+	}
+	f.remaining--
+	f.consumed++
+	return quota.Reservation{UserID: userID, Source: "plan"}, quota.Status{}, nil
+}
+
+func (f *fakeRetryQuota) Release(ctx context.Context, reservation quota.Reservation) error {
+	f.remaining++
+	f.consumed--
+	return nil
+}
+
+type recordingWorker struct {
+	mockWorker
+	enqueued []string
+}
+
+func (w *recordingWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
+	w.enqueued = append(w.enqueued, conversionID)
+	return nil
+}
+
+func newRetryTestService(states map[string]RetryState, retryQuota RetryQuota) (*Service, *fakeRetryStore, *recordingWorker) {
+	store := newMockStore()
+	for id, state := range states {
+		store.conversions[id] = Conversion{ID: id, UserID: state.UserID, Status: state.Status}
+	}
+	worker := &recordingWorker{}
+	service := NewService(store, &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, worker, &mockMetrics{})
+	retryStore := &fakeRetryStore{states: states}
+	service.SetRetries(retryStore, retryQuota, 2)
+	return service, retryStore, worker
+}
+
+func TestRetryConversion_ProviderFailureIsFree(t *testing.T) {
+	ctx := context.Background()
+	retryQuota := &fakeRetryQuota{}
+	service, retryStore, worker := newRetryTestService(map[string]RetryState{
+		"c1": {UserID: "u1", Status: ConversionStatusFailed, FailureKind: FailureKindProvider},
+	}, retryQuota)
+
+	response, err := service.RetryConversion(ctx, "c1", "u1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.QuotaCharged || retryQuota.consumed != 0 {
+		t.Errorf("Expected a provider failure to be retried for free, consumed %d", retryQuota.consumed)
+	}
+	if response.RetryCount != 1 || response.RetriesRemaining != 1 {
+		t.Errorf("Expected retry 1 with 1 remaining, got %d and %d", response.RetryCount, response.RetriesRemaining)
+	}
+	if len(worker.enqueued) != 1 || worker.enqueued[0] != "c1" {
+		t.Errorf("Expected c1 to be enqueued, got %v", worker.enqueued)
+	}
+	if len(retryStore.charged) != 1 || retryStore.charged[0] {
+		t.Errorf("Expected the audit trail to record an uncharged retry, got %v", retryStore.charged)
+	}
+}
+
+func TestRetryConversion_RequestFailureIsCharged(t *testing.T) {
+	ctx := context.Background()
+	retryQuota := &fakeRetryQuota{remaining: 1}
+	service, _, _ := newRetryTestService(map[string]RetryState{
+		"rejected":  {UserID: "u1", Status: ConversionStatusFailed, FailureKind: FailureKindRequest},
+		"cancelled": {UserID: "u1", Status: ConversionStatusFailed},
+	}, retryQuota)
+
+	response, err := service.RetryConversion(ctx, "rejected", "u1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !response.QuotaCharged || retryQuota.consumed != 1 {
+		t.Errorf("Expected a request failure to consume quota, consumed %d", retryQuota.consumed)
+	}
+
+	// Unclassified failures such as cancellations are charged too
+	if _, err := service.RetryConversion(ctx, "cancelled", "u1"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Errorf("Expected quota exceeded, got %v", err)
+	}
+}
+
+type fakeQuotaCharges struct {
+	recorded map[string][]quota.Reservation
+}
+
+func (f *fakeQuotaCharges) RecordCharges(ctx context.Context, conversionID string, reservations ...quota.Reservation) error {
+	f.recorded[conversionID] = append(f.recorded[conversionID], reservations...)
+	return nil
+}
+
+func (f *fakeQuotaCharges) GetStatus(ctx context.Context, userID string) (quota.Status, error) {
+	return quota.Status{}, nil
+}
+
+func TestRetryConversion_RefundedProviderFailureIsCharged(t *testing.T) {
+	ctx := context.Background()
+	retryQuota := &fakeRetryQuota{remaining: 1}
+	service, _, _ := newRetryTestService(map[string]RetryState{
+		"c1": {UserID: "u1", Status: ConversionStatusFailed, FailureKind: FailureKindProvider, QuotaRefunded: true},
+	}, retryQuota)
+	charges := &fakeQuotaCharges{recorded: map[string][]quota.Reservation{}}
+	service.SetQuotaCharges(charges)
+
+	response, err := service.RetryConversion(ctx, "c1", "u1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !response.QuotaCharged || retryQuota.consumed != 1 {
+		t.Errorf("Expected a refunded failure to be charged again, consumed %d", retryQuota.consumed)
+	}
+	if len(charges.recorded["c1"]) != 1 {
+		t.Errorf("Expected the retry charge to be recorded for a refund, got %v", charges.recorded)
+	}
+}
+
+func TestRetryConversion_Rejected(t *testing.T) {
+	ctx := context.Background()
+	service, _, worker := newRetryTestService(map[string]RetryState{
+		"done":    {UserID: "u1", Status: ConversionStatusCompleted},
+		"limit":   {UserID: "u1", Status: ConversionStatusFailed, RetryCount: 2, FailureKind: FailureKindProvider},
+		"foreign": {UserID: "u2", Status: ConversionStatusFailed, FailureKind: FailureKindProvider},
+	}, &fakeRetryQuota{})
+
+	tests := []struct {
+		conversionID string
+		check        func(error) bool
+	}{
+		{"done", func(err error) bool { return errors.Is(err, ErrRetryNotAllowed) }},
+		{"limit", func(err error) bool { return errors.Is(err, ErrRetryLimitReached) }},
+		{"foreign", func(err error) bool { return err != nil && err.Error() == "conversion not found" }},
+		{"missing", func(err error) bool { return err != nil }},
+	}
+	for _, tt := range tests {
+		if _, err := service.RetryConversion(ctx, tt.conversionID, "u1"); !tt.check(err) {
+			t.Errorf("Unexpected error for %s: %v", tt.conversionID, err)
+		}
+	}
+	if len(worker.enqueued) != 0 {
+		t.Errorf("Expected nothing to be enqueued, got %v", worker.enqueued)
+	}
+
+	unconfigured := &Service{}
+	if _, err := unconfigured.RetryConversion(ctx, "done", "u1"); !errors.Is(err, ErrRetryUnavailable) {
+		t.Errorf("Expected retries to be unavailable, got %v", err)
+	}
+}
@@ -8,20 +8,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// MountRoutes mounts conversion service routes to the gin engine
-func MountRoutes(r *gin.RouterGroup, handler *Handler) {
+// MountRoutes mounts conversion service routes to the gin engine.
+// createMiddleware runs before conversions are created, e.g. quota enforcement.
+func MountRoutes(r *gin.RouterGroup, handler *Handler, createMiddleware ...gin.HandlerFunc) {
 	// Conversion routes (protected)
 	conversionGroup := r.Group("/convert")
 	conversionGroup.Use(authenticateMiddleware())
 	{
 		// Create conversion
-		conversionGroup.POST("", common.GinWrap(handler.CreateConversion))
+		createHandlers := append(append([]gin.HandlerFunc{}, createMiddleware...), common.GinWrap(handler.CreateConversion))
+		conversionGroup.POST("", createHandlers...)
 
 		// Get quota status
-		conversionGroup.GET("/quota", common.GinWrap(handler.GetQuotaStatus))
+		common.Mount(conversionGroup, http.MethodGet, "/quota", handler.GetQuotaStatusEndpoint())
 
 		// Get conversion metrics
-		conversionGroup.GET("/metrics", common.GinWrap(handler.GetConversionMetrics))
+		common.Mount(conversionGroup, http.MethodGet, "/metrics", handler.GetConversionMetricsEndpoint())
 	}
 
 	// Individual conversion routes (protected)
@@ -29,28 +31,60 @@ func MountRoutes(r *gin.RouterGroup, handler *Handler) {
 	conversionIDGroup.Use(authenticateMiddleware())
 	{
 		// Get conversion by ID
-		conversionIDGroup.GET("/:id", common.GinWrap(handler.GetConversion))
+		common.Mount(conversionIDGroup, http.MethodGet, "/:id", handler.GetConversionEndpoint())
 
 		// Update conversion
-		conversionIDGroup.PUT("/:id", common.GinWrap(handler.UpdateConversion))
+		common.Mount(conversionIDGroup, http.MethodPut, "/:id", handler.UpdateConversionEndpoint())
 
 		// Delete conversion
-		conversionIDGroup.DELETE("/:id", common.GinWrap(handler.DeleteConversion))
+		common.Mount(conversionIDGroup, http.MethodDelete, "/:id", handler.DeleteConversionEndpoint())
 
 		// Cancel conversion
-		conversionIDGroup.POST("/:id/cancel", common.GinWrap(handler.CancelConversion))
+		common.Mount(conversionIDGroup, http.MethodPost, "/:id/cancel", handler.CancelConversionEndpoint())
 
 		// Get processing status
-		conversionIDGroup.GET("/:id/status", common.GinWrap(handler.GetProcessingStatus))
+		common.Mount(conversionIDGroup, http.MethodGet, "/:id/status", handler.GetProcessingStatusEndpoint())
+
+		// Stream progress as Server-Sent Events
+		conversionIDGroup.GET("/:id/events", handler.StreamConversionEvents)
 	}
 
-	// List conversions (protected)
+	// List and retry conversions (protected)
 	conversionsGroup := r.Group("/conversions")
 	conversionsGroup.Use(authenticateMiddleware())
 	{
 		// List user's conversions
-		conversionsGroup.GET("", common.GinWrap(handler.ListConversions))
+		common.Mount(conversionsGroup, http.MethodGet, "", handler.ListConversionsEndpoint())
+
+		// Retry a failed conversion
+		common.Mount(conversionsGroup, http.MethodPost, "/:id/retry", handler.RetryConversionEndpoint())
+
+		// Rate a conversion result
+		common.Mount(conversionsGroup, http.MethodPost, "/:id/feedback", handler.SubmitFeedbackEndpoint())
+	}
+
+	// Saved conversion presets (protected)
+	presetsGroup := r.Group("/users/me/presets")
+	presetsGroup.Use(authenticateMiddleware())
+	{
+		common.Mount(presetsGroup, http.MethodGet, "", handler.ListPresetsEndpoint())
+		common.Mount(presetsGroup, http.MethodPost, "", handler.CreatePresetEndpoint())
+		common.Mount(presetsGroup, http.MethodPut, "/:id", handler.UpdatePresetEndpoint())
+		common.Mount(presetsGroup, http.MethodDelete, "/:id", handler.DeletePresetEndpoint())
 	}
+
+	// ZIP export of conversion results (protected)
+	exportGroup := r.Group("/users/me/conversions")
+	exportGroup.Use(authenticateMiddleware())
+	{
+		common.Mount(exportGroup, http.MethodGet, "/export", handler.ExportConversionsEndpoint())
+	}
+}
+
+// SetupExportDownloadRoutes mounts the download of export archives, which
+// is authorized by the signed link sent to the user instead of a token
+func SetupExportDownloadRoutes(r *gin.RouterGroup, handler *Handler) {
+	r.GET("/conversions/exports/download/*key", handler.DownloadExport)
 }
 
 // authenticateMiddleware provides authentication middleware for conversion routes
@@ -75,12 +109,7 @@ func authenticateMiddleware() gin.HandlerFunc {
 		}
 		
 		if userIDStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "unauthorized",
-					"message": "user not authenticated",
-				},
-			})
+			common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 			c.Abort()
 			return
 		}
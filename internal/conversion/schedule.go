@@ -0,0 +1,202 @@
+package conversion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Conversion scheduling defaults
+const (
+	DefaultScheduleMaxAhead     = 7 * 24 * time.Hour
+	DefaultSchedulePollInterval = 30 * time.Second
+	DefaultScheduleBatchSize    = 100
+)
+
+var (
+	// ErrInvalidSchedule is returned for a scheduledAt in the past or too far ahead
+	ErrInvalidSchedule = fmt.Errorf("%w: invalid scheduledAt", common.ErrValidation)
+	// ErrSchedulingUnavailable is returned when scheduling is not configured
+	ErrSchedulingUnavailable = errors.New("conversion scheduling is not available")
+)
+
+// ScheduledConversion is a scheduled conversion that became due
+type ScheduledConversion struct {
+	ID          string
+	UserID      string
+	ScheduledAt time.Time
+}
+
+// ScheduleStore records when conversions run and hands out the due ones
+type ScheduleStore interface {
+	// ScheduleConversion holds a pending conversion back until at
+	ScheduleConversion(ctx context.Context, conversionID string, at time.Time) error
+	// ClaimDueConversions marks up to limit pending conversions scheduled at
+	// or before now as released and returns them, earliest first
+	ClaimDueConversions(ctx context.Context, now time.Time, limit int) ([]ScheduledConversion, error)
+	// UnclaimConversion makes a released conversion due again, e.g. when it
+	// could not be enqueued
+	UnclaimConversion(ctx context.Context, conversionID string) error
+}
+
+// ScheduleConfig configures conversion scheduling
+type ScheduleConfig struct {
+	MaxAhead     time.Duration // how far in the future a conversion may be scheduled
+	PollInterval time.Duration // how often due conversions are enqueued
+	BatchSize    int           // conversions enqueued per claim
+}
+
+// scheduler enqueues scheduled conversions once they are due
+type scheduler struct {
+	store  ScheduleStore
+	config ScheduleConfig
+}
+
+// SetScheduling enables creating conversions with a scheduledAt, e.g. to
+// run batches off-peak. Due conversions are enqueued by StartScheduler.
+func (s *Service) SetScheduling(store ScheduleStore, config ScheduleConfig) {
+	if config.MaxAhead <= 0 {
+		config.MaxAhead = DefaultScheduleMaxAhead
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultSchedulePollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultScheduleBatchSize
+	}
+	s.schedules = &scheduler{store: store, config: config}
+}
+
+// checkSchedule validates the requested run time; nil runs the conversion now
+func (s *Service) checkSchedule(scheduledAt *time.Time) (*time.Time, error) {
+	if scheduledAt == nil {
+		return nil, nil
+	}
+	if s.schedules == nil {
+		return nil, ErrSchedulingUnavailable
+	}
+
+	now := time.Now()
+	if !scheduledAt.After(now) {
+		return nil, fmt.Errorf("%w: must be in the future", ErrInvalidSchedule)
+	}
+	if scheduledAt.After(now.Add(s.schedules.config.MaxAhead)) {
+		return nil, fmt.Errorf("%w: must be within %s", ErrInvalidSchedule, s.schedules.config.MaxAhead)
+	}
+	at := scheduledAt.UTC()
+	return &at, nil
+}
+
+// StartScheduler enqueues scheduled conversions as they become due until
+// ctx is cancelled
+func (s *Service) StartScheduler(ctx context.Context) {
+	if s.schedules == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.schedules.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.releaseDueConversions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// releaseDueConversions enqueues due conversions until none is left or an
+// enqueue fails
+func (s *Service) releaseDueConversions(ctx context.Context) {
+	sch := s.schedules
+	for ctx.Err() == nil {
+		due, err := sch.store.ClaimDueConversions(ctx, time.Now(), sch.config.BatchSize)
+		if err != nil {
+			log.Printf("Conversion scheduler: failed to claim due conversions: %v", err)
+			return
+		}
+
+		failed := false
+		for _, conversion := range due {
+			// Priority is looked up now, so a plan bought meanwhile applies
+			priority := s.jobPriority(ctx, conversion.UserID, false)
+			if err := s.worker.EnqueueConversion(ctx, conversion.ID, priority); err != nil {
+				log.Printf("Conversion scheduler: failed to enqueue %s: %v", conversion.ID, err)
+				if err := sch.store.UnclaimConversion(ctx, conversion.ID); err != nil {
+					log.Printf("Conversion scheduler: failed to release %s again: %v", conversion.ID, err)
+				}
+				failed = true
+			}
+		}
+
+		// Failed conversions are due again, so wait for the next poll
+		if failed || len(due) < sch.config.BatchSize {
+			return
+		}
+	}
+}
+
+// dbScheduleStore implements ScheduleStore on the conversions table
+type dbScheduleStore struct {
+	db *sql.DB
+}
+
+// NewDBScheduleStore creates a new database-backed schedule store
+func NewDBScheduleStore(db *sql.DB) ScheduleStore {
+	return &dbScheduleStore{db: db}
+}
+
+func (s *dbScheduleStore) ScheduleConversion(ctx context.Context, conversionID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE conversions
+		SET scheduled_at = $2, schedule_released_at = NULL, updated_at = NOW()
+		WHERE id = $1`, conversionID, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule conversion: %w", err)
+	}
+	return nil
+}
+
+func (s *dbScheduleStore) ClaimDueConversions(ctx context.Context, now time.Time, limit int) ([]ScheduledConversion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE conversions
+		SET schedule_released_at = NOW()
+		WHERE id IN (
+			SELECT id FROM conversions
+			WHERE status = 'pending' AND scheduled_at <= $1 AND schedule_released_at IS NULL
+			ORDER BY scheduled_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, scheduled_at`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due conversions: %w", err)
+	}
+	defer rows.Close()
+
+	var due []ScheduledConversion
+	for rows.Next() {
+		var conversion ScheduledConversion
+		if err := rows.Scan(&conversion.ID, &conversion.UserID, &conversion.ScheduledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan due conversion: %w", err)
+		}
+		due = append(due, conversion)
+	}
+	return due, rows.Err()
+}
+
+func (s *dbScheduleStore) UnclaimConversion(ctx context.Context, conversionID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE conversions SET schedule_released_at = NULL WHERE id = $1`, conversionID)
+	if err != nil {
+		return fmt.Errorf("failed to unclaim conversion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// fakeScheduleStore schedules conversions of the mock store
+type fakeScheduleStore struct {
+	store    *mockStore
+	released map[string]bool
+}
+
+func (f *fakeScheduleStore) ScheduleConversion(ctx context.Context, conversionID string, at time.Time) error {
+	conversion := f.store.conversions[conversionID]
+	conversion.ScheduledAt = &at
+	f.store.conversions[conversionID] = conversion
+	return nil
+}
+
+func (f *fakeScheduleStore) ClaimDueConversions(ctx context.Context, now time.Time, limit int) ([]ScheduledConversion, error) {
+	var due []ScheduledConversion
+	for id, conversion := range f.store.conversions {
+		if conversion.Status == ConversionStatusPending && conversion.ScheduledAt != nil &&
+			!conversion.ScheduledAt.After(now) && !f.released[id] {
+			due = append(due, ScheduledConversion{ID: id, UserID: conversion.UserID, ScheduledAt: *conversion.ScheduledAt})
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ScheduledAt.Before(due[j].ScheduledAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, conversion := range due {
+		f.released[conversion.ID] = true
+	}
+	return due, nil
+}
+
+func (f *fakeScheduleStore) UnclaimConversion(ctx context.Context, conversionID string) error {
+	delete(f.released, conversionID)
+	return nil
+}
+
+// failingWorker fails to enqueue every conversion
+type failingWorker struct {
+	mockWorker
+}
+
+func (w *failingWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
+	return errors.New("queue unavailable")
+}
+
+func newScheduleTestService(worker WorkerService) (*Service, *mockStore, *fakeScheduleStore) {
+	store := newMockStore()
+	service := NewService(store, &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+		&mockRateLimiter{}, &mockAuditLogger{}, worker, &mockMetrics{})
+	schedules := &fakeScheduleStore{store: store, released: make(map[string]bool)}
+	service.SetScheduling(schedules, ScheduleConfig{MaxAhead: 24 * time.Hour, BatchSize: 2})
+	return service, store, schedules
+}
+
+func scheduledRequest(at time.Time) ConversionRequest {
+	return ConversionRequest{UserImageID: "user-image-id", ClothImageID: "cloth-image-id", ScheduledAt: &at}
+}
+
+func TestCreateConversion_Scheduled(t *testing.T) {
+	worker := &recordingWorker{}
+	service, _, _ := newScheduleTestService(worker)
+
+	at := time.Now().Add(2 * time.Hour)
+	response, err := service.CreateConversion(context.Background(), "user-1", scheduledRequest(at))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.ScheduledAt == nil || !response.ScheduledAt.Equal(at) {
+		t.Errorf("Expected the conversion scheduled at %v, got %v", at, response.ScheduledAt)
+	}
+	if response.Status != ConversionStatusPending {
+		t.Errorf("Expected a pending conversion, got %s", response.Status)
+	}
+	if len(worker.enqueued) != 0 {
+		t.Errorf("Expected a scheduled conversion not to be enqueued yet, got %v", worker.enqueued)
+	}
+}
+
+func TestCreateConversion_ScheduleErrors(t *testing.T) {
+	service, _, _ := newScheduleTestService(&recordingWorker{})
+
+	for name, at := range map[string]time.Time{
+		"in the past":   time.Now().Add(-time.Minute),
+		"too far ahead": time.Now().Add(48 * time.Hour),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.CreateConversion(context.Background(), "user-1", scheduledRequest(at))
+			if !errors.Is(err, ErrInvalidSchedule) || !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+			}
+		})
+	}
+
+	t.Run("scheduling disabled", func(t *testing.T) {
+		service := NewService(newMockStore(), &mockImageService{}, &mockProcessor{}, &mockNotifier{},
+			&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+		_, err := service.CreateConversion(context.Background(), "user-1", scheduledRequest(time.Now().Add(time.Hour)))
+		if !errors.Is(err, ErrSchedulingUnavailable) {
+			t.Errorf("Expected ErrSchedulingUnavailable, got %v", err)
+		}
+	})
+}
+
+func TestReleaseDueConversions(t *testing.T) {
+	worker := &recordingWorker{}
+	service, store, _ := newScheduleTestService(worker)
+	ctx := context.Background()
+
+	now := time.Now()
+	for id, offset := range map[string]time.Duration{"due-1": -2 * time.Minute, "due-2": -time.Minute, "due-3": -time.Second, "later": time.Hour, "cancelled": -time.Hour} {
+		at := now.Add(offset)
+		store.conversions[id] = Conversion{ID: id, UserID: "user-1", Status: ConversionStatusPending, ScheduledAt: &at}
+	}
+	if err := service.CancelConversion(ctx, "cancelled", "user-1"); err != nil {
+		t.Fatalf("Expected a scheduled conversion to be cancellable, got %v", err)
+	}
+
+	// Due conversions are enqueued over several batches, earliest first
+	service.releaseDueConversions(ctx)
+	if len(worker.enqueued) != 3 || worker.enqueued[0] != "due-1" || worker.enqueued[2] != "due-3" {
+		t.Fatalf("Expected the 3 due conversions to be enqueued in order, got %v", worker.enqueued)
+	}
+
+	// Released conversions are not enqueued twice
+	service.releaseDueConversions(ctx)
+	if len(worker.enqueued) != 3 {
+		t.Errorf("Expected no more conversions to be enqueued, got %v", worker.enqueued)
+	}
+}
+
+func TestReleaseDueConversions_EnqueueFails(t *testing.T) {
+	service, store, schedules := newScheduleTestService(&failingWorker{})
+
+	at := time.Now().Add(-time.Minute)
+	store.conversions["due"] = Conversion{ID: "due", UserID: "user-1", Status: ConversionStatusPending, ScheduledAt: &at}
+
+	service.releaseDueConversions(context.Background())
+	if schedules.released["due"] {
+		t.Error("Expected a conversion that failed to enqueue to be due again")
+	}
+}
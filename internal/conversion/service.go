@@ -4,6 +4,12 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/outbox"
+	"ai-styler/internal/quota"
+
+	"github.com/google/uuid"
 )
 
 // Service provides conversion management functionality
@@ -16,6 +22,28 @@ type Service struct {
 	auditLogger  AuditLogger
 	worker       WorkerService
 	metrics      MetricsCollector
+	onboarding   *Onboarding
+	eventStore   EventStore
+	planPriority PlanPriorityStore
+	planOutput   PlanOutputStore
+	retryStore   RetryStore
+	retryQuota   RetryQuota
+	maxRetries   int
+
+	feedbackStore FeedbackStore
+	presetStore   PresetStore
+
+	outfitStore OutfitStore
+	outfitQuota OutfitQuota
+	maxGarments int
+
+	quotaCharges QuotaCharges // nil leaves failed conversions charged
+
+	albumAccess AlbumAccess // nil skips album membership checks on cloth images
+
+	exports *exporter // nil disables conversion exports
+
+	schedules *scheduler // nil disables scheduled conversions
 }
 
 // NewService creates a new conversion service
@@ -41,8 +69,24 @@ func NewService(
 	}
 }
 
+// SetOnboarding enables the first-conversion fast lane
+func (s *Service) SetOnboarding(onboarding *Onboarding) {
+	s.onboarding = onboarding
+}
+
+// SetEventStore routes conversion notifications through the transactional outbox
+func (s *Service) SetEventStore(eventStore EventStore) {
+	s.eventStore = eventStore
+}
+
 // CreateConversion creates a new conversion request
 func (s *Service) CreateConversion(ctx context.Context, userID string, req ConversionRequest) (ConversionResponse, error) {
+	// The conversion keeps the trace ID of the request creating it; requests
+	// without one, e.g. over gRPC, get a new ID
+	if common.GetTraceIDFromContext(ctx) == "" {
+		ctx = common.SetTraceIDInContext(ctx, uuid.New().String())
+	}
+
 	// Check rate limit
 	allowed, err := s.rateLimiter.CheckRateLimit(ctx, userID)
 	if err != nil {
@@ -54,10 +98,22 @@ func (s *Service) CreateConversion(ctx context.Context, userID string, req Conve
 
 	// Validate that user_image_id and cloth_image_id are different
 	userImageID := req.GetUserImageID()
-	clothImageID := req.GetClothImageID()
-	
-	if userImageID == clothImageID {
-		return ConversionResponse{}, fmt.Errorf("user image and cloth image must be different")
+	garments := req.GetGarments()
+	if len(garments) == 0 {
+		return ConversionResponse{}, fmt.Errorf("invalid request: cloth image is required")
+	}
+	clothImageID := garments[0].ImageID
+	for _, garment := range garments {
+		if userImageID == garment.ImageID {
+			return ConversionResponse{}, fmt.Errorf("user image and cloth image must be different")
+		}
+	}
+	if err := s.checkGarmentCount(garments); err != nil {
+		return ConversionResponse{}, err
+	}
+	scheduledAt, err := s.checkSchedule(req.ScheduledAt)
+	if err != nil {
+		return ConversionResponse{}, err
 	}
 
 	// Validate image access
@@ -65,42 +121,110 @@ func (s *Service) CreateConversion(ctx context.Context, userID string, req Conve
 		return ConversionResponse{}, fmt.Errorf("invalid user image access: %w", err)
 	}
 
-	// Validate cloth image exists and is accessible
-	// Cloth image can be:
-	// 1. Public image (is_public = true)
-	// 2. Vendor image (type = 'vendor')
-	// 3. User's own image (belongs to the same user)
-	clothImage, err := s.imageService.GetImage(ctx, clothImageID)
-	if err != nil {
-		return ConversionResponse{}, fmt.Errorf("invalid cloth image: %w", err)
+	// Validate every garment exists and is accessible
+	for _, garment := range garments {
+		if err := s.validateClothImage(ctx, userID, garment.ImageID); err != nil {
+			return ConversionResponse{}, err
+		}
 	}
-	
-	// Check if cloth image belongs to the user (allow using own images)
-	isOwnImage := (clothImage.UserID != "" && clothImage.UserID == userID) ||
-	              (clothImage.VendorID != "" && clothImage.VendorID == userID)
-	
-	// Allow if: own image, public, or vendor type
-	// Note: SQL function will also validate this, but we check early for better error messages
-	if !isOwnImage && !clothImage.IsPublic && clothImage.Type != "vendor" {
-		return ConversionResponse{}, fmt.Errorf("cloth image is not accessible: must be public, vendor image, or your own image")
+
+	// Check the requested output dimensions against the user's plan
+	output := req.Output()
+	if err := s.checkOutputOptions(ctx, userID, output); err != nil {
+		return ConversionResponse{}, err
 	}
 
-	// Check user quota and create conversion (handled by database function)
-	quota, err := s.store.CheckUserQuota(ctx, userID)
-	if err != nil {
-		return ConversionResponse{}, fmt.Errorf("failed to check quota: %w", err)
+	// Resolve the preset whose options the worker applies
+	var preset *Preset
+	if presetID := req.GetPresetID(); presetID != "" {
+		p, err := s.getPreset(ctx, presetID, userID)
+		if err != nil {
+			return ConversionResponse{}, fmt.Errorf("invalid preset: %w", err)
+		}
+		preset = &p
+	}
+
+	// A user's first conversion goes through the onboarding fast lane
+	fastLane := false
+	if s.onboarding != nil {
+		fastLane, err = s.onboarding.IsFastLane(ctx, userID)
+		if err != nil {
+			// Log but don't fail the request - fall back to the normal lane
+			fmt.Printf("Failed to check onboarding fast lane: %v\n", err)
+		}
 	}
-	if !quota.CanConvert {
-		return ConversionResponse{}, fmt.Errorf("quota exceeded: free=%d, paid=%d", quota.RemainingFree, quota.RemainingPaid)
+
+	// Check user quota unless the quota middleware already charged this request
+	if _, reserved := quota.ReservationFromContext(ctx); !reserved {
+		quotaCheck, err := s.store.CheckUserQuota(ctx, userID)
+		if err != nil {
+			return ConversionResponse{}, fmt.Errorf("failed to check quota: %w", err)
+		}
+		if !quotaCheck.CanConvert && !(fastLane && s.onboarding.BypassQuota()) {
+			return ConversionResponse{}, fmt.Errorf("quota exceeded: free=%d, paid=%d", quotaCheck.RemainingFree, quotaCheck.RemainingPaid)
+		}
+	}
+
+	// Every garment of an outfit beyond the first is charged as another conversion
+	var outfitCharge *garmentCharge
+	if len(garments) > 1 && !(fastLane && s.onboarding.BypassQuota()) {
+		outfitCharge, err = s.chargeGarments(ctx, userID, len(garments)-1)
+		if err != nil {
+			return ConversionResponse{}, err
+		}
 	}
 
 	// Create conversion (this will also update quota counters)
 	styleName := req.GetStyleName()
-	conversionID, err := s.store.CreateConversion(ctx, userID, userImageID, clothImageID, styleName)
+	if styleName == "" && preset != nil {
+		styleName = preset.StyleName
+	}
+	var conversionID string
+	started := func(conversionID string) []outbox.Event {
+		return []outbox.Event{outbox.NewConversionEvent(outbox.EventConversionStarted, outbox.ConversionEventPayload{
+			UserID:       userID,
+			ConversionID: conversionID,
+		})}
+	}
+	if len(garments) > 1 {
+		var events func(conversionID string) []outbox.Event
+		if s.eventStore != nil {
+			events = started
+		}
+		conversionID, err = s.outfitStore.CreateOutfitConversion(ctx, userID, userImageID, styleName, garments, events)
+	} else if s.eventStore != nil {
+		conversionID, err = s.eventStore.CreateConversionWithEvents(ctx, userID, userImageID, clothImageID, styleName, started)
+	} else {
+		conversionID, err = s.store.CreateConversion(ctx, userID, userImageID, clothImageID, styleName)
+	}
 	if err != nil {
+		s.releaseGarments(ctx, userID, outfitCharge)
 		return ConversionResponse{}, fmt.Errorf("failed to create conversion: %w", err)
 	}
 
+	// Tie the quota charged for the conversion to it, for refunds on failure
+	var reservation *quota.Reservation
+	if r, reserved := quota.ReservationFromContext(ctx); reserved {
+		reservation = &r
+	}
+	s.recordCharges(ctx, conversionID, reservation, outfitCharge)
+
+	// Snapshot the preset's and output options before the job is enqueued,
+	// so later edits to the preset do not change this conversion
+	if preset != nil || !output.IsZero() {
+		var presetID string
+		var options map[string]interface{}
+		if preset != nil {
+			presetID, options = preset.ID, preset.Options(styleName)
+		} else if styleName != "" {
+			options = map[string]interface{}{"style": styleName}
+		}
+		if err := s.presetStore.SaveConversionOptions(ctx, conversionID, presetID, output.apply(options)); err != nil {
+			// Log but don't fail the request - the worker falls back to the style
+			fmt.Printf("Failed to save conversion options: %v\n", err)
+		}
+	}
+
 	// Record request
 	if err := s.rateLimiter.RecordRequest(ctx, userID); err != nil {
 		// Log but don't fail the request
@@ -119,16 +243,38 @@ func (s *Service) CreateConversion(ctx context.Context, userID string, req Conve
 		fmt.Printf("Failed to record metrics: %v\n", err)
 	}
 
+	// Paid plans jump the queue; a first conversion goes through the fast lane
+	priority := s.jobPriority(ctx, userID, fastLane)
+	if fastLane {
+		if err := s.onboarding.RecordFastLane(ctx, userID, conversionID); err != nil {
+			// Log but don't fail the request
+			fmt.Printf("Failed to record fast lane conversion: %v\n", err)
+		}
+	}
+
+	// Scheduled conversions are enqueued by the scheduler once due
+	if scheduledAt != nil {
+		if err := s.schedules.store.ScheduleConversion(ctx, conversionID, *scheduledAt); err != nil {
+			// Log but don't fail the request - run it now rather than never
+			fmt.Printf("Failed to schedule conversion: %v\n", err)
+			scheduledAt = nil
+		}
+	}
+
 	// Enqueue job for processing
-	if err := s.worker.EnqueueConversion(ctx, conversionID); err != nil {
-		// Log but don't fail the request - conversion is created
-		fmt.Printf("Failed to enqueue conversion: %v\n", err)
+	if scheduledAt == nil {
+		if err := s.worker.EnqueueConversion(ctx, conversionID, priority); err != nil {
+			// Log but don't fail the request - conversion is created
+			fmt.Printf("Failed to enqueue conversion: %v\n", err)
+		}
 	}
 
-	// Send notification
-	if err := s.notifier.SendConversionStarted(ctx, userID, conversionID); err != nil {
-		// Log but don't fail the request
-		fmt.Printf("Failed to send notification: %v\n", err)
+	// Send notification (the outbox dispatcher publishes it when enabled)
+	if s.eventStore == nil {
+		if err := s.notifier.SendConversionStarted(ctx, userID, conversionID); err != nil {
+			// Log but don't fail the request
+			fmt.Printf("Failed to send notification: %v\n", err)
+		}
 	}
 
 	// Get the created conversion
@@ -220,6 +366,14 @@ func (s *Service) DeleteConversion(ctx context.Context, conversionID, userID str
 
 // GetQuotaStatus gets user's quota status
 func (s *Service) GetQuotaStatus(ctx context.Context, userID string) (QuotaCheck, error) {
+	if s.quotaCharges != nil {
+		status, err := s.quotaCharges.GetStatus(ctx, userID)
+		if err != nil {
+			return QuotaCheck{}, fmt.Errorf("failed to get quota status: %w", err)
+		}
+		return quotaCheckOf(status), nil
+	}
+
 	quota, err := s.store.CheckUserQuota(ctx, userID)
 	if err != nil {
 		return QuotaCheck{}, fmt.Errorf("failed to get quota status: %w", err)
@@ -253,13 +407,20 @@ func (s *Service) ProcessConversion(ctx context.Context, conversionID string) er
 			Status:       stringPtr(ConversionStatusFailed),
 			ErrorMessage: stringPtr(err.Error()),
 		}
-		if updateErr := s.store.UpdateConversion(ctx, conversionID, updateReq); updateErr != nil {
+		failedEvent := outbox.NewConversionEvent(outbox.EventConversionFailed, outbox.ConversionEventPayload{
+			UserID:       conversion.UserID,
+			ConversionID: conversionID,
+			ErrorMessage: err.Error(),
+		})
+		if updateErr := s.updateConversion(ctx, conversionID, updateReq, failedEvent); updateErr != nil {
 			fmt.Printf("Failed to update conversion status to failed: %v\n", updateErr)
 		}
 
 		// Send failure notification
-		if notifyErr := s.notifier.SendConversionFailed(ctx, conversion.UserID, conversionID, err.Error()); notifyErr != nil {
-			fmt.Printf("Failed to send failure notification: %v\n", notifyErr)
+		if s.eventStore == nil {
+			if notifyErr := s.notifier.SendConversionFailed(ctx, conversion.UserID, conversionID, err.Error()); notifyErr != nil {
+				fmt.Printf("Failed to send failure notification: %v\n", notifyErr)
+			}
 		}
 
 		// Record error metrics
@@ -283,13 +444,20 @@ func (s *Service) ProcessConversion(ctx context.Context, conversionID string) er
 		ResultImageID:    stringPtr(resultImageID),
 		ProcessingTimeMs: intPtr(processingTime),
 	}
-	if err := s.store.UpdateConversion(ctx, conversionID, updateReq); err != nil {
+	completedEvent := outbox.NewConversionEvent(outbox.EventConversionCompleted, outbox.ConversionEventPayload{
+		UserID:        conversion.UserID,
+		ConversionID:  conversionID,
+		ResultImageID: resultImageID,
+	})
+	if err := s.updateConversion(ctx, conversionID, updateReq, completedEvent); err != nil {
 		return fmt.Errorf("failed to update conversion status: %w", err)
 	}
 
 	// Send success notification
-	if err := s.notifier.SendConversionCompleted(ctx, conversion.UserID, conversionID, resultImageID); err != nil {
-		fmt.Printf("Failed to send success notification: %v\n", err)
+	if s.eventStore == nil {
+		if err := s.notifier.SendConversionCompleted(ctx, conversion.UserID, conversionID, resultImageID); err != nil {
+			fmt.Printf("Failed to send success notification: %v\n", err)
+		}
 	}
 
 	// Record success metrics
@@ -300,6 +468,15 @@ func (s *Service) ProcessConversion(ctx context.Context, conversionID string) er
 	return nil
 }
 
+// updateConversion applies req, writing events to the outbox in the same
+// transaction when the event store is set
+func (s *Service) updateConversion(ctx context.Context, conversionID string, req UpdateConversionRequest, events ...outbox.Event) error {
+	if s.eventStore != nil {
+		return s.eventStore.UpdateConversionWithEvents(ctx, conversionID, req, events)
+	}
+	return s.store.UpdateConversion(ctx, conversionID, req)
+}
+
 // GetProcessingStatus gets the processing status of a conversion
 func (s *Service) GetProcessingStatus(ctx context.Context, conversionID, userID string) (string, error) {
 	conversion, err := s.store.GetConversion(ctx, conversionID)
@@ -314,7 +491,8 @@ func (s *Service) GetProcessingStatus(ctx context.Context, conversionID, userID
 	return conversion.Status, nil
 }
 
-// CancelConversion cancels a pending conversion
+// CancelConversion cancels a pending conversion, including a scheduled one
+// that has not run yet
 func (s *Service) CancelConversion(ctx context.Context, conversionID, userID string) error {
 	conversion, err := s.store.GetConversion(ctx, conversionID)
 	if err != nil {
@@ -380,7 +558,7 @@ func (s *Service) WatchConversion(ctx context.Context, conversionID, userID stri
 	}
 
 	// If already completed or failed, return immediately
-	if conversion.Status == ConversionStatusCompleted || conversion.Status == ConversionStatusFailed {
+	if conversion.IsFinished() {
 		return conversion, nil
 	}
 
@@ -397,7 +575,7 @@ func (s *Service) WatchConversion(ctx context.Context, conversionID, userID stri
 		if err == nil {
 			lastKnownConversion = current
 			// If status changed to completed or failed, return immediately
-			if current.Status == ConversionStatusCompleted || current.Status == ConversionStatusFailed {
+			if current.IsFinished() {
 				return current, nil
 			}
 		} else if ctx.Err() != nil {
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/outbox"
 )
 
 // Mock implementations for testing
@@ -28,6 +31,7 @@ func (m *mockStore) CreateConversion(ctx context.Context, userID, userImageID, c
 		UserImageID:  userImageID,
 		ClothImageID: clothImageID,
 		Status:       ConversionStatusPending,
+		TraceID:      common.GetTraceIDFromContext(ctx),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -49,30 +53,7 @@ func (m *mockStore) GetConversionWithDetails(ctx context.Context, conversionID s
 		return ConversionResponse{}, fmt.Errorf("conversion not found")
 	}
 
-	response := ConversionResponse{
-		ID:           conv.ID,
-		UserID:       conv.UserID,
-		UserImageID:  conv.UserImageID,
-		ClothImageID: conv.ClothImageID,
-		Status:       conv.Status,
-		CreatedAt:    conv.CreatedAt,
-		UpdatedAt:    conv.UpdatedAt,
-	}
-
-	if conv.ResultImageID != nil {
-		response.ResultImageID = conv.ResultImageID
-	}
-	if conv.ErrorMessage != nil {
-		response.ErrorMessage = conv.ErrorMessage
-	}
-	if conv.ProcessingTimeMs != nil {
-		response.ProcessingTimeMs = conv.ProcessingTimeMs
-	}
-	if conv.CompletedAt != nil {
-		response.CompletedAt = conv.CompletedAt
-	}
-
-	return response, nil
+	return ConversionResponse{Conversion: conv}, nil
 }
 
 func (m *mockStore) UpdateConversion(ctx context.Context, conversionID string, req UpdateConversionRequest) error {
@@ -104,16 +85,7 @@ func (m *mockStore) ListConversions(ctx context.Context, req ConversionListReque
 	conversions := make([]ConversionResponse, 0)
 	for _, conv := range m.conversions {
 		if conv.UserID == req.UserID {
-			response := ConversionResponse{
-				ID:           conv.ID,
-				UserID:       conv.UserID,
-				UserImageID:  conv.UserImageID,
-				ClothImageID: conv.ClothImageID,
-				Status:       conv.Status,
-				CreatedAt:    conv.CreatedAt,
-				UpdatedAt:    conv.UpdatedAt,
-			}
-			conversions = append(conversions, response)
+			conversions = append(conversions, ConversionResponse{Conversion: conv})
 		}
 	}
 
@@ -219,6 +191,41 @@ func TestCreateConversion(t *testing.T) {
 	}
 }
 
+func TestCreateConversion_TraceID(t *testing.T) {
+	newService := func() *Service {
+		return &Service{
+			store:        newMockStore(),
+			imageService: &mockImageService{},
+			processor:    &mockProcessor{},
+			notifier:     &mockNotifier{},
+			rateLimiter:  &mockRateLimiter{},
+			auditLogger:  &mockAuditLogger{},
+			worker:       &mockWorker{},
+			metrics:      &mockMetrics{},
+		}
+	}
+	req := ConversionRequest{UserImageID: "user-image-id", ClothImageID: "cloth-image-id"}
+
+	// The conversion keeps the trace ID of the request
+	ctx := common.SetTraceIDInContext(context.Background(), "request-trace")
+	response, err := newService().CreateConversion(ctx, "test-user-id", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.TraceID != "request-trace" {
+		t.Errorf("Expected trace ID request-trace, got %q", response.TraceID)
+	}
+
+	// Requests without a trace ID get a new one
+	response, err = newService().CreateConversion(context.Background(), "test-user-id", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.TraceID == "" {
+		t.Error("Expected a trace ID to be generated")
+	}
+}
+
 func TestGetConversion(t *testing.T) {
 	store := newMockStore()
 	service := &Service{
@@ -279,3 +286,221 @@ func TestGetQuotaStatus(t *testing.T) {
 		t.Errorf("Expected plan name 'free', got %s", quota.PlanName)
 	}
 }
+
+type fakeOnboardingStore struct {
+	hasConversions bool
+	fastLane       map[string]string
+	grants         map[string]int
+}
+
+func (f *fakeOnboardingStore) GrantSignupCredits(ctx context.Context, userID string, credits int) (bool, error) {
+	f.grants[userID] = credits
+	return true, nil
+}
+
+func (f *fakeOnboardingStore) HasConversions(ctx context.Context, userID string) (bool, error) {
+	return f.hasConversions, nil
+}
+
+func (f *fakeOnboardingStore) RecordFastLane(ctx context.Context, userID, conversionID string) error {
+	f.fastLane[userID] = conversionID
+	return nil
+}
+
+type priorityRecordingWorker struct {
+	mockWorker
+	priority int
+}
+
+func (w *priorityRecordingWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
+	w.priority = priority
+	return nil
+}
+
+func TestCreateConversion_OnboardingFastLane(t *testing.T) {
+	ctx := context.Background()
+	userID := "new-user-id"
+	req := ConversionRequest{UserImageID: "user-image-id", ClothImageID: "cloth-image-id"}
+	config := OnboardingConfig{Enabled: true, SignupCredits: 3, FirstConversionFree: true, FirstConversionPriority: 20}
+
+	newService := func(onboardingStore *fakeOnboardingStore, worker WorkerService) (*Service, *mockStore) {
+		store := newMockStore()
+		// Out of quota, as on a paid plan that has been used up
+		store.quota[userID] = QuotaCheck{CanConvert: false, PlanName: "basic"}
+		service := &Service{
+			store:        store,
+			imageService: &mockImageService{},
+			processor:    &mockProcessor{},
+			notifier:     &mockNotifier{},
+			rateLimiter:  &mockRateLimiter{},
+			auditLogger:  &mockAuditLogger{},
+			worker:       worker,
+			metrics:      &mockMetrics{},
+		}
+		service.SetOnboarding(NewOnboarding(config, onboardingStore))
+		return service, store
+	}
+
+	t.Run("first conversion is free and prioritized", func(t *testing.T) {
+		onboardingStore := &fakeOnboardingStore{fastLane: map[string]string{}, grants: map[string]int{}}
+		worker := &priorityRecordingWorker{}
+		service, _ := newService(onboardingStore, worker)
+
+		response, err := service.CreateConversion(ctx, userID, req)
+		if err != nil {
+			t.Fatalf("Expected first conversion to bypass quota, got %v", err)
+		}
+		if worker.priority != 20 {
+			t.Errorf("Expected fast lane priority 20, got %d", worker.priority)
+		}
+		if onboardingStore.fastLane[userID] != response.ID {
+			t.Errorf("Expected fast lane conversion %s to be recorded, got %s", response.ID, onboardingStore.fastLane[userID])
+		}
+	})
+
+	t.Run("later conversions use quota and normal priority", func(t *testing.T) {
+		onboardingStore := &fakeOnboardingStore{hasConversions: true, fastLane: map[string]string{}, grants: map[string]int{}}
+		service, store := newService(onboardingStore, &priorityRecordingWorker{})
+
+		if _, err := service.CreateConversion(ctx, userID, req); err == nil {
+			t.Error("Expected quota exceeded error")
+		}
+
+		store.quota[userID] = QuotaCheck{CanConvert: true, TotalRemaining: 1}
+		worker := &priorityRecordingWorker{}
+		service.worker = worker
+		if _, err := service.CreateConversion(ctx, userID, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if worker.priority != DefaultJobPriority {
+			t.Errorf("Expected default priority %d, got %d", DefaultJobPriority, worker.priority)
+		}
+	})
+
+	t.Run("signup credits only for users", func(t *testing.T) {
+		onboardingStore := &fakeOnboardingStore{fastLane: map[string]string{}, grants: map[string]int{}}
+		onboarding := NewOnboarding(config, onboardingStore)
+
+		if err := onboarding.GrantSignupCredits(ctx, "u1", "user"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := onboarding.GrantSignupCredits(ctx, "v1", "vendor"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if onboardingStore.grants["u1"] != 3 {
+			t.Errorf("Expected 3 credits for user, got %d", onboardingStore.grants["u1"])
+		}
+		if _, ok := onboardingStore.grants["v1"]; ok {
+			t.Error("Expected vendors not to receive signup credits")
+		}
+	})
+}
+
+type fakePlanPriorityStore struct {
+	priorities map[string]int
+}
+
+func (f *fakePlanPriorityStore) ActivePlanPriority(ctx context.Context, userID string) (int, bool, error) {
+	priority, ok := f.priorities[userID]
+	return priority, ok, nil
+}
+
+func TestJobPriority_PlanTier(t *testing.T) {
+	ctx := context.Background()
+	onboardingStore := &fakeOnboardingStore{fastLane: map[string]string{}, grants: map[string]int{}}
+	service := &Service{}
+	service.SetOnboarding(NewOnboarding(OnboardingConfig{Enabled: true, FirstConversionPriority: 20}, onboardingStore))
+
+	if got := service.jobPriority(ctx, "free-user", false); got != DefaultJobPriority {
+		t.Errorf("Expected default priority %d without a plan store, got %d", DefaultJobPriority, got)
+	}
+
+	service.SetPlanPriority(&fakePlanPriorityStore{priorities: map[string]int{"premium-user": 15, "enterprise-user": 25}})
+
+	tests := []struct {
+		userID   string
+		fastLane bool
+		expected int
+	}{
+		{"free-user", false, DefaultJobPriority},
+		{"premium-user", false, 15},
+		{"premium-user", true, 20},    // fast lane outranks the plan
+		{"enterprise-user", true, 25}, // but never lowers it
+	}
+	for _, tt := range tests {
+		if got := service.jobPriority(ctx, tt.userID, tt.fastLane); got != tt.expected {
+			t.Errorf("Expected priority %d for %s (fast lane %v), got %d", tt.expected, tt.userID, tt.fastLane, got)
+		}
+	}
+}
+
+type fakeEventStore struct {
+	*mockStore
+	events []outbox.Event
+}
+
+func (f *fakeEventStore) CreateConversionWithEvents(ctx context.Context, userID, userImageID, clothImageID, styleName string, events func(conversionID string) []outbox.Event) (string, error) {
+	conversionID, err := f.CreateConversion(ctx, userID, userImageID, clothImageID, styleName)
+	if err != nil {
+		return "", err
+	}
+	f.events = append(f.events, events(conversionID)...)
+	return conversionID, nil
+}
+
+func (f *fakeEventStore) UpdateConversionWithEvents(ctx context.Context, conversionID string, req UpdateConversionRequest, events []outbox.Event) error {
+	if err := f.UpdateConversion(ctx, conversionID, req); err != nil {
+		return err
+	}
+	f.events = append(f.events, events...)
+	return nil
+}
+
+type countingNotifier struct {
+	mockNotifier
+	calls int
+}
+
+func (n *countingNotifier) SendConversionStarted(ctx context.Context, userID, conversionID string) error {
+	n.calls++
+	return nil
+}
+
+func (n *countingNotifier) SendConversionCompleted(ctx context.Context, userID, conversionID, resultImageID string) error {
+	n.calls++
+	return nil
+}
+
+func TestConversionEventsUseOutbox(t *testing.T) {
+	ctx := context.Background()
+	userID := "test-user-id"
+
+	store := newMockStore()
+	store.quota[userID] = QuotaCheck{CanConvert: true, TotalRemaining: 1}
+	eventStore := &fakeEventStore{mockStore: store}
+	notifier := &countingNotifier{}
+	service := NewService(store, &mockImageService{}, &mockProcessor{}, notifier,
+		&mockRateLimiter{}, &mockAuditLogger{}, &mockWorker{}, &mockMetrics{})
+	service.SetEventStore(eventStore)
+
+	response, err := service.CreateConversion(ctx, userID, ConversionRequest{UserImageID: "user-image-id", ClothImageID: "cloth-image-id"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.ProcessConversion(ctx, response.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if notifier.calls != 0 {
+		t.Errorf("Expected notifications to go through the outbox, got %d direct calls", notifier.calls)
+	}
+	if len(eventStore.events) != 2 {
+		t.Fatalf("Expected 2 outbox events, got %d", len(eventStore.events))
+	}
+	if eventStore.events[0].EventType != outbox.EventConversionStarted || eventStore.events[0].AggregateID != response.ID {
+		t.Errorf("Expected started event for %s, got %+v", response.ID, eventStore.events[0])
+	}
+	if eventStore.events[1].EventType != outbox.EventConversionCompleted {
+		t.Errorf("Expected completed event, got %s", eventStore.events[1].EventType)
+	}
+}
@@ -4,30 +4,99 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/outbox"
 )
 
-// store implements the Store interface
+// store implements the Store and EventStore interfaces
 type store struct {
 	db *sql.DB
 }
 
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // NewStore creates a new conversion store
 func NewStore(db *sql.DB) Store {
 	return &store{db: db}
 }
 
+// NewEventStore creates a conversion store that writes outbox events
+// in the same transaction as the conversion change
+func NewEventStore(db *sql.DB) EventStore {
+	return &store{db: db}
+}
+
+// NewProgressStore creates a store recording the progress the worker reports
+func NewProgressStore(db *sql.DB) ProgressStore {
+	return &store{db: db}
+}
+
+// UpdateConversionProgress records how far an unfinished conversion got.
+// Progress never goes backwards, so reports arriving out of order are ignored.
+func (s *store) UpdateConversionProgress(ctx context.Context, conversionID string, progress int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE conversions
+		SET progress = $2, updated_at = NOW()
+		WHERE id = $1 AND progress < $2 AND status IN ('pending', 'processing')
+	`, conversionID, progress)
+	if err != nil {
+		return fmt.Errorf("failed to update conversion progress: %w", err)
+	}
+	return nil
+}
+
 // CreateConversion creates a new conversion request
 func (s *store) CreateConversion(ctx context.Context, userID, userImageID, clothImageID, styleName string) (string, error) {
+	return createConversion(ctx, s.db, userID, userImageID, clothImageID, styleName)
+}
+
+// CreateConversionWithEvents creates a conversion and writes its outbox events in one transaction
+func (s *store) CreateConversionWithEvents(ctx context.Context, userID, userImageID, clothImageID, styleName string, events func(conversionID string) []outbox.Event) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	conversionID, err := createConversion(ctx, tx, userID, userImageID, clothImageID, styleName)
+	if err != nil {
+		return "", err
+	}
+
+	if err := outbox.Write(ctx, tx, events(conversionID)...); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit conversion: %w", err)
+	}
+
+	return conversionID, nil
+}
+
+func createConversion(ctx context.Context, q common.DBTX, userID, userImageID, clothImageID, styleName string) (string, error) {
 	query := `
 		SELECT create_conversion($1, NULL, $2, $3, 'free', $4)
 	`
 
 	var conversionID string
-	err := s.db.QueryRowContext(ctx, query, userID, userImageID, clothImageID, styleName).Scan(&conversionID)
+	err := q.QueryRowContext(ctx, query, userID, userImageID, clothImageID, styleName).Scan(&conversionID)
 	if err != nil {
 		return "", fmt.Errorf("failed to create conversion: %w", err)
 	}
 
+	// Tie the conversion to the trace of the request creating it
+	if traceID := common.GetTraceIDFromContext(ctx); traceID != "" {
+		if _, err := q.ExecContext(ctx, `UPDATE conversions SET trace_id = $2 WHERE id = $1`, conversionID, traceID); err != nil {
+			return "", fmt.Errorf("failed to save conversion trace ID: %w", err)
+		}
+	}
+
 	return conversionID, nil
 }
 
@@ -35,7 +104,8 @@ func (s *store) CreateConversion(ctx context.Context, userID, userImageID, cloth
 func (s *store) GetConversion(ctx context.Context, conversionID string) (Conversion, error) {
 	query := `
 		SELECT id, user_id, user_image_id, cloth_image_id, status, result_image_id, 
-		       error_message, processing_time_ms, created_at, updated_at, completed_at
+		       error_message, processing_time_ms, created_at, updated_at, completed_at, progress, error_code, scheduled_at,
+		       COALESCE(trace_id, '')
 		FROM conversions 
 		WHERE id = $1
 	`
@@ -48,7 +118,7 @@ func (s *store) GetConversion(ctx context.Context, conversionID string) (Convers
 
 	err := s.db.QueryRowContext(ctx, query, conversionID).Scan(
 		&conv.ID, &conv.UserID, &conv.UserImageID, &conv.ClothImageID, &conv.Status,
-		&resultImageID, &errorMessage, &processingTimeMs, &conv.CreatedAt, &conv.UpdatedAt, &completedAt,
+		&resultImageID, &errorMessage, &processingTimeMs, &conv.CreatedAt, &conv.UpdatedAt, &completedAt, &conv.Progress, &conv.ErrorCode, &conv.ScheduledAt, &conv.TraceID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -71,6 +141,10 @@ func (s *store) GetConversion(ctx context.Context, conversionID string) (Convers
 		conv.CompletedAt = &completedAt.Time
 	}
 
+	if conv.Garments, err = listConversionGarments(ctx, s.db, conversionID); err != nil {
+		return conv, err
+	}
+
 	return conv, nil
 }
 
@@ -92,7 +166,7 @@ func (s *store) GetConversionWithDetails(ctx context.Context, conversionID strin
 	err := s.db.QueryRowContext(ctx, query, conversionID).Scan(
 		&conv.ID, &conv.UserID, &conv.UserImageID, &conv.ClothImageID, &conv.Status,
 		&resultImageID, &errorMessage, &processingTimeMs, &conv.CreatedAt, &conv.UpdatedAt, &completedAt,
-		&userImageURL, &clothImageURL, &resultImageURL,
+		&userImageURL, &clothImageURL, &resultImageURL, &conv.Progress, &conv.ErrorCode, &conv.ScheduledAt, &conv.TraceID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -115,11 +189,30 @@ func (s *store) GetConversionWithDetails(ctx context.Context, conversionID strin
 		conv.CompletedAt = &completedAt.Time
 	}
 
+	if conv.Garments, err = listConversionGarments(ctx, s.db, conversionID); err != nil {
+		return conv, err
+	}
+
 	return conv, nil
 }
 
-// UpdateConversion updates a conversion
+// UpdateConversion updates a conversion, in the caller's unit of work when there is one
 func (s *store) UpdateConversion(ctx context.Context, conversionID string, req UpdateConversionRequest) error {
+	return updateConversion(ctx, common.Conn(ctx, s.db), conversionID, req)
+}
+
+// UpdateConversionWithEvents updates a conversion and writes its outbox events
+// in one transaction, the caller's unit of work when there is one
+func (s *store) UpdateConversionWithEvents(ctx context.Context, conversionID string, req UpdateConversionRequest, events []outbox.Event) error {
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		if err := updateConversion(ctx, tx, conversionID, req); err != nil {
+			return err
+		}
+		return outbox.Write(ctx, tx, events...)
+	})
+}
+
+func updateConversion(ctx context.Context, q queryRower, conversionID string, req UpdateConversionRequest) error {
 	query := `
 		SELECT update_conversion_status($1, $2, $3, $4, $5)
 	`
@@ -147,7 +240,7 @@ func (s *store) UpdateConversion(ctx context.Context, conversionID string, req U
 	}
 
 	var success bool
-	err := s.db.QueryRowContext(ctx, query, conversionID, status, resultImageID, errorMessage, processingTimeMs).Scan(&success)
+	err := q.QueryRowContext(ctx, query, conversionID, status, resultImageID, errorMessage, processingTimeMs).Scan(&success)
 	if err != nil {
 		return fmt.Errorf("failed to update conversion: %w", err)
 	}
@@ -156,6 +249,48 @@ func (s *store) UpdateConversion(ctx context.Context, conversionID string, req U
 		return fmt.Errorf("conversion not found")
 	}
 
+	if req.FailureKind != nil {
+		var id string
+		err := q.QueryRowContext(ctx, `
+			UPDATE conversions SET failure_kind = $2 WHERE id = $1 RETURNING id
+		`, conversionID, *req.FailureKind).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to record failure kind: %w", err)
+		}
+	}
+
+	if req.ErrorCode != nil {
+		var id string
+		err := q.QueryRowContext(ctx, `
+			UPDATE conversions SET error_code = $2 WHERE id = $1 RETURNING id
+		`, conversionID, *req.ErrorCode).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to record error code: %w", err)
+		}
+	}
+
+	if req.Progress != nil {
+		var id string
+		err := q.QueryRowContext(ctx, `
+			UPDATE conversions SET progress = $2 WHERE id = $1 RETURNING id
+		`, conversionID, *req.Progress).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to record progress: %w", err)
+		}
+	}
+
+	if req.DeadLetter != nil {
+		var id string
+		err := q.QueryRowContext(ctx, `
+			UPDATE conversions
+			SET dead_lettered_at = NOW(), provider_status_code = NULLIF($2, 0), provider_response = $3
+			WHERE id = $1 RETURNING id
+		`, conversionID, req.DeadLetter.ProviderStatusCode, req.DeadLetter.ProviderResponse).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to dead-letter conversion: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -174,6 +309,11 @@ func (s *store) ListConversions(ctx context.Context, req ConversionListRequest)
 
 	offset := (req.Page - 1) * req.PageSize
 
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return ConversionListResponse{}, err
+	}
+
 	// Build query
 	whereClause := "WHERE user_id = $1"
 	args := []interface{}{req.UserID}
@@ -185,25 +325,30 @@ func (s *store) ListConversions(ctx context.Context, req ConversionListRequest)
 		argIndex++
 	}
 
-	// Count total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM conversions %s", whereClause)
+	// Count total; cursor pages skip the count
 	var total int
-	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
+	if cursor != nil {
+		req.Page = 0
+	} else if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM conversions "+whereClause, args...).Scan(&total); err != nil {
 		return ConversionListResponse{}, fmt.Errorf("failed to count conversions: %w", err)
 	}
 
+	if condition, cursorArgs := cursor.Condition("created_at", "id", argIndex); condition != "" {
+		whereClause += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("created_at", "id", cursor, req.PageSize, offset, argIndex)
+	args = append(args, pageArgs...)
+
 	// Get conversions
 	query := fmt.Sprintf(`
 		SELECT id, user_id, user_image_id, cloth_image_id, status, result_image_id, 
-		       error_message, processing_time_ms, created_at, updated_at, completed_at
+		       error_message, processing_time_ms, created_at, updated_at, completed_at, progress, error_code, scheduled_at,
+		       COALESCE(trace_id, '')
 		FROM conversions 
-		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
-
-	args = append(args, req.PageSize, offset)
+		%s%s
+	`, whereClause, clause)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -221,7 +366,7 @@ func (s *store) ListConversions(ctx context.Context, req ConversionListRequest)
 
 		err := rows.Scan(
 			&conv.ID, &conv.UserID, &conv.UserImageID, &conv.ClothImageID, &conv.Status,
-			&resultImageID, &errorMessage, &processingTimeMs, &conv.CreatedAt, &conv.UpdatedAt, &completedAt,
+			&resultImageID, &errorMessage, &processingTimeMs, &conv.CreatedAt, &conv.UpdatedAt, &completedAt, &conv.Progress, &conv.ErrorCode, &conv.ScheduledAt, &conv.TraceID,
 		)
 		if err != nil {
 			return ConversionListResponse{}, fmt.Errorf("failed to scan conversion: %w", err)
@@ -248,6 +393,9 @@ func (s *store) ListConversions(ctx context.Context, req ConversionListRequest)
 		return ConversionListResponse{}, fmt.Errorf("failed to iterate conversions: %w", err)
 	}
 
+	conversions, nextCursor := common.NextPage(conversions, req.PageSize, func(conv ConversionResponse) (time.Time, string) {
+		return conv.CreatedAt, conv.ID
+	})
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
 	return ConversionListResponse{
@@ -256,6 +404,7 @@ func (s *store) ListConversions(ctx context.Context, req ConversionListRequest)
 		Page:        req.Page,
 		PageSize:    req.PageSize,
 		TotalPages:  totalPages,
+		NextCursor:  nextCursor,
 	}, nil
 }
 
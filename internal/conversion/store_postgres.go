@@ -129,6 +129,21 @@ func (s *postgresStore) UpdateConversion(ctx context.Context, conversionID strin
 		args = append(args, *req.ProcessingTimeMs)
 		argIndex++
 	}
+	if req.FailureKind != nil {
+		setParts = append(setParts, fmt.Sprintf("failure_kind = $%d", argIndex))
+		args = append(args, *req.FailureKind)
+		argIndex++
+	}
+	if req.ErrorCode != nil {
+		setParts = append(setParts, fmt.Sprintf("error_code = $%d", argIndex))
+		args = append(args, *req.ErrorCode)
+		argIndex++
+	}
+	if req.DeadLetter != nil {
+		setParts = append(setParts, fmt.Sprintf("dead_lettered_at = NOW(), provider_status_code = NULLIF($%d, 0), provider_response = $%d", argIndex, argIndex+1))
+		args = append(args, req.DeadLetter.ProviderStatusCode, req.DeadLetter.ProviderResponse)
+		argIndex += 2
+	}
 
 	if len(setParts) == 0 {
 		return nil
@@ -5,8 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
+	"ai-styler/internal/config"
+	"ai-styler/internal/image"
+	"ai-styler/internal/storage"
+
 	"github.com/google/uuid"
 	"github.com/google/wire"
 )
@@ -42,6 +47,8 @@ func WireConversionService(db *sql.DB) (*Service, *Handler) {
 		worker:       worker,
 		metrics:      metrics,
 	}
+	// Private album images are only tried on by the album's members
+	service.SetAlbumAccess(image.NewDBStore(db))
 
 	handler := NewHandler(service)
 
@@ -108,7 +115,7 @@ func (m *mockAuditLogger) LogConversionError(ctx context.Context, userID, conver
 
 type mockWorker struct{}
 
-func (m *mockWorker) EnqueueConversion(ctx context.Context, conversionID string) error {
+func (m *mockWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
 	return nil
 }
 func (m *mockWorker) ProcessConversion(ctx context.Context, jobID string) error {
@@ -318,17 +325,18 @@ type realWorker struct {
 	db *sql.DB
 }
 
-func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string) error {
+func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
 	// Get conversion details to build job payload
 	conversion, err := r.getConversion(ctx, conversionID)
 	if err != nil {
 		return fmt.Errorf("failed to get conversion: %w", err)
 	}
 
-	// Get style_name from database
+	// Get style_name and the options saved from a preset from database
 	var styleName sql.NullString
-	styleQuery := `SELECT style_name FROM conversions WHERE id = $1`
-	err = r.db.QueryRowContext(ctx, styleQuery, conversionID).Scan(&styleName)
+	var savedOptions []byte
+	styleQuery := `SELECT style_name, options FROM conversions WHERE id = $1`
+	err = r.db.QueryRowContext(ctx, styleQuery, conversionID).Scan(&styleName, &savedOptions)
 	if err != nil && err != sql.ErrNoRows {
 		return fmt.Errorf("failed to get style_name: %w", err)
 	}
@@ -338,6 +346,13 @@ func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string)
 
 	// Build job payload with options
 	options := make(map[string]interface{})
+	if len(savedOptions) > 0 {
+		if err := json.Unmarshal(savedOptions, &options); err != nil {
+			// Log but don't fail - convert with the style only
+			fmt.Printf("Failed to decode options of conversion %s: %v\n", conversionID, err)
+			options = make(map[string]interface{})
+		}
+	}
 	if styleName.Valid && styleName.String != "" {
 		options["style"] = styleName.String
 	}
@@ -349,6 +364,9 @@ func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string)
 	if len(options) > 0 {
 		payload["options"] = options
 	}
+	if conversion.TraceID != "" {
+		payload["traceId"] = conversion.TraceID
+	}
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
@@ -366,7 +384,7 @@ func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string)
 		"image_conversion",
 		conversionID,
 		conversion.UserID,
-		5, // JobPriorityNormal
+		priority,
 		"pending",
 		0,
 		1, // MaxRetries
@@ -391,7 +409,7 @@ func (r *realWorker) EnqueueConversion(ctx context.Context, conversionID string)
 // getConversion retrieves conversion details from database
 func (r *realWorker) getConversion(ctx context.Context, conversionID string) (*Conversion, error) {
 	query := `
-		SELECT id, user_id, user_image_id, cloth_image_id, status
+		SELECT id, user_id, user_image_id, cloth_image_id, status, COALESCE(trace_id, '')
 		FROM conversions 
 		WHERE id = $1`
 
@@ -402,6 +420,7 @@ func (r *realWorker) getConversion(ctx context.Context, conversionID string) (*C
 		&conv.UserImageID,
 		&conv.ClothImageID,
 		&conv.Status,
+		&conv.TraceID,
 	)
 	if err != nil {
 		return nil, err
@@ -451,3 +470,76 @@ func (r *realMetrics) GetConversionMetrics(ctx context.Context, userID string, t
 		"average_processing_time": 0,
 	}, nil
 }
+
+// WireExportStorage creates the export storage of the configured backend.
+// Download links are signed with the storage URL signing keys.
+func WireExportStorage(cfg *config.Config) (ExportStorage, error) {
+	signing, err := storage.SigningSettingsFromConfig(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := storage.NewURLSigner(storage.URLSignerConfig{
+		Keys:        signing.Keys,
+		ActiveKeyID: signing.ActiveKeyID,
+		TTLs:        signing.TTLs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objects := storage.ObjectStoreConfig{
+		Backend:     cfg.Storage.Backend,
+		BasePath:    cfg.Storage.StoragePath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3Region:    cfg.Storage.S3Region,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+	}
+	reader, err := storage.NewObjectReader(objects)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := storage.NewObjectWriter(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return &objectExportStorage{
+		ObjectReader: reader,
+		ObjectWriter: writer,
+		signer:       signer,
+		basePath:     cfg.Storage.StoragePath,
+	}, nil
+}
+
+// objectExportStorage implements ExportStorage on the object store
+type objectExportStorage struct {
+	storage.ObjectReader
+	storage.ObjectWriter
+	signer   *storage.URLSigner
+	basePath string
+}
+
+func (s *objectExportStorage) ReadImage(ctx context.Context, imageURL string) ([]byte, error) {
+	key, ok := storage.ObjectKey(imageURL, s.basePath)
+	if !ok {
+		return nil, fmt.Errorf("image %s is not in storage", imageURL)
+	}
+	return s.ReadObject(ctx, key)
+}
+
+func (s *objectExportStorage) SignDownload(baseURL, key string) (string, time.Time) {
+	return s.signer.SignURL(baseURL, key, storage.AccessTypeDownload, 0)
+}
+
+func (s *objectExportStorage) VerifyDownload(key string, query url.Values) error {
+	accessType, err := s.signer.Verify(key, query)
+	if err != nil {
+		return err
+	}
+	if accessType != storage.AccessTypeDownload {
+		return fmt.Errorf("signed URL does not grant downloads")
+	}
+	return nil
+}
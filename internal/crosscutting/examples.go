@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -144,7 +146,7 @@ func ExampleUsage() {
 
 		signedURL, err := ccl.signedURLGen.GenerateSignedURL(context.Background(), signedURLReq)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to generate signed URL"})
+			common.RespondError(c, http.StatusInternalServerError, "Failed to generate signed URL")
 			return
 		}
 
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,7 +39,7 @@ func NewDashboardHandler(service *Service) *Handler {
 func (h *Handler) GetDashboard(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
@@ -90,7 +92,7 @@ func (h *Handler) GetDashboard(c *gin.Context) {
 
 	dashboardData, err := h.service.GetDashboardData(c.Request.Context(), userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get dashboard data: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get dashboard data: "+err.Error())
 		return
 	}
 
@@ -112,13 +114,13 @@ func (h *Handler) GetDashboard(c *gin.Context) {
 func (h *Handler) GetQuotaStatus(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
 	quotaResponse, err := h.service.CheckQuota(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check quota: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to check quota: "+err.Error())
 		return
 	}
 
@@ -141,7 +143,7 @@ func (h *Handler) GetQuotaStatus(c *gin.Context) {
 func (h *Handler) GetConversionHistory(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
@@ -158,7 +160,7 @@ func (h *Handler) GetConversionHistory(c *gin.Context) {
 
 	history, err := h.service.GetConversionHistory(c.Request.Context(), userID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get conversion history: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get conversion history: "+err.Error())
 		return
 	}
 
@@ -190,7 +192,7 @@ func (h *Handler) GetVendorGallery(c *gin.Context) {
 
 	gallery, err := h.service.GetVendorGallery(c.Request.Context(), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get vendor gallery: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get vendor gallery: "+err.Error())
 		return
 	}
 
@@ -212,13 +214,13 @@ func (h *Handler) GetVendorGallery(c *gin.Context) {
 func (h *Handler) GetPlanStatus(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
 	planStatus, err := h.service.GetPlanStatus(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get plan status: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get plan status: "+err.Error())
 		return
 	}
 
@@ -240,13 +242,13 @@ func (h *Handler) GetPlanStatus(c *gin.Context) {
 func (h *Handler) GetStatistics(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
 	statistics, err := h.service.GetDashboardStatistics(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get statistics: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get statistics: "+err.Error())
 		return
 	}
 
@@ -269,7 +271,7 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 func (h *Handler) GetRecentActivity(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
@@ -286,7 +288,7 @@ func (h *Handler) GetRecentActivity(c *gin.Context) {
 
 	activity, err := h.service.GetRecentActivity(c.Request.Context(), userID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get recent activity: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get recent activity: "+err.Error())
 		return
 	}
 
@@ -308,13 +310,13 @@ func (h *Handler) GetRecentActivity(c *gin.Context) {
 func (h *Handler) InvalidateCache(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
 	err := h.service.InvalidateCache(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invalidate cache: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to invalidate cache: "+err.Error())
 		return
 	}
 
@@ -336,21 +338,19 @@ func (h *Handler) InvalidateCache(c *gin.Context) {
 func (h *Handler) CheckQuotaExceeded(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		common.RespondError(c, http.StatusUnauthorized, "user_id not found in context")
 		return
 	}
 
 	quotaResponse, err := h.service.CheckQuota(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check quota: " + err.Error()})
+		common.RespondError(c, http.StatusInternalServerError, "failed to check quota: "+err.Error())
 		return
 	}
 
 	// If quota exceeded, return 403 with upgrade information
 	if !quotaResponse.CanConvert {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":              "quota_exceeded",
-			"message":            "You have exceeded your conversion quota. Please upgrade your plan to continue.",
+		common.RespondErrorWithDetails(c, http.StatusForbidden, "You have exceeded your conversion quota. Please upgrade your plan to continue.", gin.H{
 			"quota_status":       quotaResponse.QuotaStatus,
 			"upgrade_prompt":     quotaResponse.UpgradePrompt,
 			"recommended_action": quotaResponse.RecommendedAction,
@@ -2,6 +2,8 @@ package dashboard
 
 import (
 	"time"
+
+	"ai-styler/internal/domain"
 )
 
 // DashboardData represents the complete dashboard information for a user
@@ -17,16 +19,7 @@ type DashboardData struct {
 }
 
 // UserInfo represents basic user information for dashboard
-type UserInfo struct {
-	ID              string     `json:"id"`
-	Name            *string    `json:"name,omitempty"`
-	AvatarURL       *string    `json:"avatarUrl,omitempty"`
-	Phone           string     `json:"phone"`
-	IsPhoneVerified bool       `json:"isPhoneVerified"`
-	Role            string     `json:"role"`
-	CreatedAt       time.Time  `json:"createdAt"`
-	LastLoginAt     *time.Time `json:"lastLoginAt,omitempty"`
-}
+type UserInfo = domain.UserSummary
 
 // QuotaStatus represents current quota information
 type QuotaStatus struct {
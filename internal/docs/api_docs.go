@@ -125,7 +125,7 @@ type APITag struct {
 
 // GenerateAPIDocumentation generates comprehensive API documentation
 func GenerateAPIDocumentation() *APIDocumentation {
-	return &APIDocumentation{
+	doc := &APIDocumentation{
 		OpenAPI: "3.0.3",
 		Info: APIInfo{
 			Title:       "AI Styler API",
@@ -168,6 +168,8 @@ func GenerateAPIDocumentation() *APIDocumentation {
 		},
 		Tags: generateAPITags(),
 	}
+	addRegisteredOperations(doc)
+	return doc
 }
 
 // generateAPIPaths generates all API paths
@@ -0,0 +1,209 @@
+package docs
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// addRegisteredOperations adds endpoints mounted with common.Mount to the
+// documentation, replacing any hand-written entry for the same route.
+func addRegisteredOperations(doc *APIDocumentation) {
+	for _, op := range common.RegisteredOperations() {
+		docPath := openAPIPath(op.Path)
+		item := doc.Paths[docPath]
+		operation := buildOperation(op)
+
+		switch op.Method {
+		case http.MethodGet:
+			item.Get = operation
+		case http.MethodPost:
+			item.Post = operation
+		case http.MethodPut:
+			item.Put = operation
+		case http.MethodDelete:
+			item.Delete = operation
+		case http.MethodPatch:
+			item.Patch = operation
+		default:
+			continue
+		}
+		doc.Paths[docPath] = item
+	}
+}
+
+// openAPIPath converts Gin path parameters (:id) to OpenAPI ones ({id})
+func openAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func buildOperation(op common.Operation) *APIOperation {
+	operation := &APIOperation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: strings.ToLower(op.Method) + operationIDSuffix(op.Path),
+		Parameters:  requestParameters(op.Request),
+		Responses: map[string]APIResponse{
+			strconv.Itoa(op.Status): {
+				Description: http.StatusText(op.Status),
+				Content: map[string]APIContent{
+					"application/json": {Schema: schemaFor(op.Response)},
+				},
+			},
+			"default": {
+				Description: "Error",
+				Content: map[string]APIContent{
+					"application/json": {Schema: &APISchema{Ref: "#/components/schemas/ErrorResponse"}},
+				},
+			},
+		},
+	}
+
+	if op.Method != http.MethodGet && op.Method != http.MethodDelete {
+		if body := bodySchema(op.Request); body != nil {
+			operation.RequestBody = &APIRequestBody{
+				Content:  map[string]APIContent{"application/json": {Schema: body}},
+				Required: true,
+			}
+		}
+	}
+
+	if op.Secured {
+		operation.Security = []map[string][]string{{"BearerAuth": {}}}
+	}
+
+	return operation
+}
+
+func operationIDSuffix(path string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		for _, part := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' }) {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// requestParameters documents path, query and header fields of a request type
+func requestParameters(t reflect.Type) []APIParameter {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []APIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, requestParameters(field.Type)...)
+			continue
+		}
+
+		for _, source := range []struct{ tag, in string }{{"uri", "path"}, {"form", "query"}, {"header", "header"}} {
+			name := strings.Split(field.Tag.Get(source.tag), ",")[0]
+			if name == "" {
+				continue
+			}
+			params = append(params, APIParameter{
+				Name:     name,
+				In:       source.in,
+				Required: source.in == "path" || strings.Contains(field.Tag.Get("binding"), "required"),
+				Schema:   schemaFor(field.Type),
+			})
+		}
+	}
+	return params
+}
+
+// bodySchema returns the JSON body schema of a request type, nil when it has no body fields
+func bodySchema(t reflect.Type) *APISchema {
+	if t == nil {
+		return nil
+	}
+	schema := schemaFor(t)
+	if schema.Type == "object" && len(schema.Properties) == 0 {
+		return nil
+	}
+	return schema
+}
+
+// maxSchemaDepth stops recursion on self-referencing types
+const maxSchemaDepth = 8
+
+// schemaFor derives an OpenAPI schema from a Go type using its json tags
+func schemaFor(t reflect.Type) *APISchema {
+	return schemaForDepth(t, 0)
+}
+
+func schemaForDepth(t reflect.Type, depth int) *APISchema {
+	if t == nil || depth > maxSchemaDepth {
+		return &APISchema{Type: "object"}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return &APISchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &APISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &APISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &APISchema{Type: "number"}
+	case reflect.String:
+		return &APISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &APISchema{Type: "array", Items: schemaForDepth(t.Elem(), depth+1)}
+	case reflect.Map, reflect.Interface:
+		return &APISchema{Type: "object"}
+	case reflect.Struct:
+		schema := &APISchema{Type: "object", Properties: make(map[string]*APISchema)}
+		addStructProperties(schema, t, depth)
+		return schema
+	}
+
+	return &APISchema{}
+}
+
+func addStructProperties(schema *APISchema, t reflect.Type, depth int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		if field.Anonymous && jsonTag == "" && field.Type.Kind() == reflect.Struct {
+			addStructProperties(schema, field.Type, depth)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(jsonTag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaForDepth(field.Type, depth+1)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+)
+
+// Conversion statuses
+const (
+	ConversionStatusPending    = "pending"
+	ConversionStatusProcessing = "processing"
+	ConversionStatusCompleted  = "completed"
+	ConversionStatusFailed     = "failed"
+)
+
+// Conversion types
+const (
+	ConversionTypeFree = "free"
+	ConversionTypePaid = "paid"
+)
+
+// Conversion is a try-on conversion of a user image with a cloth image
+type Conversion struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"userId"`
+	UserImageID      string     `json:"userImageId"`
+	ClothImageID     string     `json:"clothImageId"`
+	Status           string     `json:"status"` // "pending", "processing", "completed", "failed"
+	ResultImageID    *string    `json:"resultImageId,omitempty"`
+	ErrorMessage     *string    `json:"errorMessage,omitempty"`
+	ErrorCode        *string    `json:"errorCode,omitempty"` // set when the inputs caused the failure, e.g. "no_person_detected"
+	ProcessingTimeMs *int       `json:"processingTimeMs,omitempty"`
+	Progress         int        `json:"progress"` // 0-100, reported by the worker as it passes its stages
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+	ScheduledAt      *time.Time `json:"scheduledAt,omitempty"` // pending conversions wait for it before they are enqueued
+	TraceID          string     `json:"traceId,omitempty"`     // trace ID of the creating request, quoted to support
+	// Garments of an outfit conversion in layering order, ClothImageID is the
+	// first of them. Empty for single-garment conversions.
+	Garments []Garment `json:"garments,omitempty"`
+}
+
+// Garment is one garment of an outfit (multi-garment) conversion
+type Garment struct {
+	ImageID  string `json:"imageId"`
+	Slot     string `json:"slot,omitempty"` // where it is worn, e.g. "top", "bottom" or "accessory"
+	ImageURL string `json:"imageUrl,omitempty"`
+}
+
+// IsFinished reports whether the conversion reached a terminal status
+func (c Conversion) IsFinished() bool {
+	return c.Status == ConversionStatusCompleted || c.Status == ConversionStatusFailed
+}
+
+// WithImageURLs returns the conversion with the URLs of its images
+func (c Conversion) WithImageURLs(userImageURL, clothImageURL, resultImageURL string) ConversionDetails {
+	return ConversionDetails{
+		Conversion:     c,
+		UserImageURL:   userImageURL,
+		ClothImageURL:  clothImageURL,
+		ResultImageURL: resultImageURL,
+	}
+}
+
+// ConversionDetails is a conversion together with the URLs of its images, as
+// returned by the conversion API and consumed by the share module and the bot
+type ConversionDetails struct {
+	Conversion
+	UserImageURL   string `json:"userImageUrl,omitempty"`
+	ClothImageURL  string `json:"clothImageUrl,omitempty"`
+	ResultImageURL string `json:"resultImageUrl,omitempty"`
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestConversionDetailsJSONIsFlat(t *testing.T) {
+	resultImageID := "result-1"
+	details := Conversion{
+		ID:            "conv-1",
+		UserID:        "user-1",
+		Status:        ConversionStatusCompleted,
+		ResultImageID: &resultImageID,
+		CreatedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}.WithImageURLs("", "", "https://cdn.example.com/result.jpg")
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("Failed to marshal conversion details: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal conversion details: %v", err)
+	}
+	if fields["id"] != "conv-1" || fields["resultImageId"] != "result-1" {
+		t.Errorf("Expected conversion fields at the top level, got %s", data)
+	}
+	if fields["resultImageUrl"] != "https://cdn.example.com/result.jpg" {
+		t.Errorf("Expected resultImageUrl, got %s", data)
+	}
+	if _, exists := fields["userImageUrl"]; exists {
+		t.Errorf("Expected empty userImageUrl to be omitted, got %s", data)
+	}
+}
+
+func TestConversionIsFinished(t *testing.T) {
+	tests := map[string]bool{
+		ConversionStatusPending:    false,
+		ConversionStatusProcessing: false,
+		ConversionStatusCompleted:  true,
+		ConversionStatusFailed:     true,
+	}
+
+	for status, expected := range tests {
+		if got := (Conversion{Status: status}).IsFinished(); got != expected {
+			t.Errorf("Expected IsFinished %v for %s, got %v", expected, status, got)
+		}
+	}
+}
+
+func TestUserSummaryAndQuota(t *testing.T) {
+	name := "Sara"
+	user := User{
+		ID:                   "user-1",
+		Phone:                "+989121234567",
+		Name:                 &name,
+		Role:                 RoleVendor,
+		FreeConversionsUsed:  3,
+		FreeConversionsLimit: 2,
+	}
+
+	summary := user.Summary()
+	if summary.ID != user.ID || summary.Phone != user.Phone || summary.Name != user.Name || summary.Role != RoleVendor {
+		t.Errorf("Expected summary to copy the user fields, got %+v", summary)
+	}
+	if got := user.FreeConversionsRemaining(); got != 0 {
+		t.Errorf("Expected no free conversions remaining, got %d", got)
+	}
+
+	if !IsValidRole(RoleAdmin) || IsValidRole("superuser") {
+		t.Error("Expected only known roles to be valid")
+	}
+}
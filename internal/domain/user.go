@@ -0,0 +1,79 @@
+// Package domain holds the canonical entity types shared across modules.
+// Module APIs alias these types instead of re-declaring them, so the same
+// entity has one shape in admin, user, dashboard, share and bot payloads.
+package domain
+
+import (
+	"time"
+)
+
+// User roles
+const (
+	RoleUser   = "user"
+	RoleVendor = "vendor"
+	RoleAdmin  = "admin"
+)
+
+// Roles lists the valid user roles
+var Roles = []string{RoleUser, RoleVendor, RoleAdmin}
+
+// IsValidRole reports whether role is a known user role
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// User is a user account as exposed by the API
+type User struct {
+	ID                   string     `json:"id"`
+	Phone                string     `json:"phone"`
+	Name                 *string    `json:"name,omitempty"`
+	AvatarURL            *string    `json:"avatarUrl,omitempty"`
+	Bio                  *string    `json:"bio,omitempty"`
+	Role                 string     `json:"role"`
+	IsPhoneVerified      bool       `json:"isPhoneVerified"`
+	IsActive             bool       `json:"isActive"`
+	LastLoginAt          *time.Time `json:"lastLoginAt,omitempty"`
+	FreeConversionsUsed  int        `json:"freeConversionsUsed"`
+	FreeConversionsLimit int        `json:"freeConversionsLimit"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+// UserSummary is the public subset of a user shown on dashboards and lists
+type UserSummary struct {
+	ID              string     `json:"id"`
+	Name            *string    `json:"name,omitempty"`
+	AvatarURL       *string    `json:"avatarUrl,omitempty"`
+	Phone           string     `json:"phone"`
+	IsPhoneVerified bool       `json:"isPhoneVerified"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastLoginAt     *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+// Summary returns the public subset of the user
+func (u User) Summary() UserSummary {
+	return UserSummary{
+		ID:              u.ID,
+		Name:            u.Name,
+		AvatarURL:       u.AvatarURL,
+		Phone:           u.Phone,
+		IsPhoneVerified: u.IsPhoneVerified,
+		Role:            u.Role,
+		CreatedAt:       u.CreatedAt,
+		LastLoginAt:     u.LastLoginAt,
+	}
+}
+
+// FreeConversionsRemaining returns the free conversions left to the user
+func (u User) FreeConversionsRemaining() int {
+	if remaining := u.FreeConversionsLimit - u.FreeConversionsUsed; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis keys of the cached flags and user plans
+const (
+	flagsCacheKey      = "feature_flags:all"
+	planCacheKeyPrefix = "feature_flags:plan:"
+)
+
+// MemoryCache caches evaluation data on a single instance. Other instances
+// see admin changes once their cached flags expire.
+type MemoryCache struct {
+	mu           sync.Mutex
+	flags        []Flag
+	flagsExpires time.Time
+	plans        map[string]cachedPlan
+	now          func() time.Time
+}
+
+type cachedPlan struct {
+	plan    string
+	expires time.Time
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{plans: make(map[string]cachedPlan), now: time.Now}
+}
+
+// GetFlags returns the cached flags until they expire
+func (c *MemoryCache) GetFlags(ctx context.Context) ([]Flag, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flags == nil || !c.now().Before(c.flagsExpires) {
+		return nil, false, nil
+	}
+	return c.flags, true, nil
+}
+
+// SetFlags caches flags for ttl
+func (c *MemoryCache) SetFlags(ctx context.Context, flags []Flag, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if flags == nil {
+		flags = []Flag{}
+	}
+	c.flags = flags
+	c.flagsExpires = c.now().Add(ttl)
+	return nil
+}
+
+// InvalidateFlags drops the cached flags
+func (c *MemoryCache) InvalidateFlags(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flags = nil
+	return nil
+}
+
+// GetPlan returns the cached plan of a user until it expires
+func (c *MemoryCache) GetPlan(ctx context.Context, userID string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.plans[userID]
+	if !ok || !c.now().Before(cached.expires) {
+		return "", false, nil
+	}
+	return cached.plan, true, nil
+}
+
+// SetPlan caches the plan of a user for ttl. Expired plans are pruned as
+// new ones are cached.
+func (c *MemoryCache) SetPlan(ctx context.Context, userID, plan string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for id, cached := range c.plans {
+		if !now.Before(cached.expires) {
+			delete(c.plans, id)
+		}
+	}
+	c.plans[userID] = cachedPlan{plan: plan, expires: now.Add(ttl)}
+	return nil
+}
+
+// RedisCache caches evaluation data in Redis, so an admin change is seen by
+// every instance as soon as it is made
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache creates a new Redis cache
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+// GetFlags returns the cached flags
+func (c *RedisCache) GetFlags(ctx context.Context) ([]Flag, bool, error) {
+	data, err := c.client.Get(ctx, flagsCacheKey).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached feature flags: %w", err)
+	}
+
+	var flags []Flag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached feature flags: %w", err)
+	}
+	return flags, true, nil
+}
+
+// SetFlags caches flags for ttl
+func (c *RedisCache) SetFlags(ctx context.Context, flags []Flag, ttl time.Duration) error {
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return fmt.Errorf("failed to encode feature flags: %w", err)
+	}
+	return c.client.Set(ctx, flagsCacheKey, data, ttl).Err()
+}
+
+// InvalidateFlags drops the cached flags
+func (c *RedisCache) InvalidateFlags(ctx context.Context) error {
+	return c.client.Del(ctx, flagsCacheKey).Err()
+}
+
+// GetPlan returns the cached plan of a user
+func (c *RedisCache) GetPlan(ctx context.Context, userID string) (string, bool, error) {
+	plan, err := c.client.Get(ctx, planCacheKeyPrefix+userID).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read cached plan: %w", err)
+	}
+	return plan, true, nil
+}
+
+// SetPlan caches the plan of a user for ttl
+func (c *RedisCache) SetPlan(ctx context.Context, userID, plan string, ttl time.Duration) error {
+	return c.client.Set(ctx, planCacheKeyPrefix+userID, plan, ttl).Err()
+}
@@ -0,0 +1,110 @@
+package flags
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for feature flags
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new feature flags handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetUserFlags handles GET /api/flags
+func (h *Handler) GetUserFlags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	flags, err := h.service.Evaluate(c.Request.Context(), fmt.Sprint(userID))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// ListFlags handles GET /admin/feature-flags
+func (h *Handler) ListFlags(c *gin.Context) {
+	flags, err := h.service.ListFlags(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// CreateFlag handles POST /admin/feature-flags
+func (h *Handler) CreateFlag(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	flag, err := h.service.CreateFlag(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, flag)
+}
+
+// UpdateFlag handles PUT /admin/feature-flags/:key
+func (h *Handler) UpdateFlag(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	flag, err := h.service.UpdateFlag(c.Request.Context(), fmt.Sprint(adminID), c.Param("key"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag handles DELETE /admin/feature-flags/:key
+func (h *Handler) DeleteFlag(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteFlag(c.Request.Context(), fmt.Sprint(adminID), c.Param("key")); err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
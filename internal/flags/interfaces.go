@@ -0,0 +1,40 @@
+package flags
+
+import (
+	"context"
+	"time"
+)
+
+// Store defines the interface for feature flag data operations
+type Store interface {
+	// ListFlags returns every flag ordered by key
+	ListFlags(ctx context.Context) ([]Flag, error)
+
+	// CreateFlag stores a new flag and audits it
+	CreateFlag(ctx context.Context, flag Flag) (*Flag, error)
+
+	// UpdateFlag replaces a flag's settings and audits the change
+	UpdateFlag(ctx context.Context, flag Flag) (*Flag, error)
+
+	// DeleteFlag removes a flag and audits the removal
+	DeleteFlag(ctx context.Context, key, deletedBy string) error
+
+	// ActivePlan returns the name of the user's newest active plan, or
+	// "free" when the user has none
+	ActivePlan(ctx context.Context, userID string) (string, error)
+}
+
+// Cache holds what flag evaluation reads, shared by every instance when it
+// is backed by Redis
+type Cache interface {
+	// GetFlags returns the cached flags; ok is false on a miss
+	GetFlags(ctx context.Context) (flags []Flag, ok bool, err error)
+	SetFlags(ctx context.Context, flags []Flag, ttl time.Duration) error
+
+	// InvalidateFlags drops the cached flags after an admin change
+	InvalidateFlags(ctx context.Context) error
+
+	// GetPlan returns the cached plan of a user; ok is false on a miss
+	GetPlan(ctx context.Context, userID string) (plan string, ok bool, err error)
+	SetPlan(ctx context.Context, userID, plan string, ttl time.Duration) error
+}
@@ -0,0 +1,66 @@
+package flags
+
+import (
+	"time"
+)
+
+// Flag gates a feature per user. An enabled flag applies to the users and
+// plans it targets and to RolloutPercent of everyone else.
+type Flag struct {
+	Key            string    `json:"key"`
+	Description    string    `json:"description"`
+	Enabled        bool      `json:"enabled"`
+	RolloutPercent int       `json:"rolloutPercent"`
+	UserIDs        []string  `json:"userIds"`
+	Plans          []string  `json:"plans"`
+	CreatedBy      *string   `json:"createdBy,omitempty"`
+	UpdatedBy      *string   `json:"updatedBy,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// CreateFlagRequest is the body of POST /admin/feature-flags
+type CreateFlagRequest struct {
+	// Key is a lowercase identifier such as new_provider
+	Key            string   `json:"key" binding:"required"`
+	Description    string   `json:"description"`
+	Enabled        bool     `json:"enabled"`
+	RolloutPercent int      `json:"rolloutPercent"`
+	UserIDs        []string `json:"userIds"`
+	Plans          []string `json:"plans"`
+}
+
+// UpdateFlagRequest is the body of PUT /admin/feature-flags/:key. Fields left
+// out keep their value; an empty list clears the targeting.
+type UpdateFlagRequest struct {
+	Description    *string   `json:"description"`
+	Enabled        *bool     `json:"enabled"`
+	RolloutPercent *int      `json:"rolloutPercent"`
+	UserIDs        *[]string `json:"userIds"`
+	Plans          *[]string `json:"plans"`
+}
+
+// ListFlagsResponse is the response of GET /admin/feature-flags
+type ListFlagsResponse struct {
+	Flags []Flag `json:"flags"`
+	Total int    `json:"total"`
+}
+
+// UserFlagsResponse is the response of GET /api/flags
+type UserFlagsResponse struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// Config configures flag evaluation
+type Config struct {
+	// How long the flags and user plans read for evaluation are cached.
+	// Admin changes invalidate the cached flags right away.
+	CacheTTL time.Duration
+}
+
+// DefaultConfig returns the default flags configuration
+func DefaultConfig() Config {
+	return Config{
+		CacheTTL: 30 * time.Second,
+	}
+}
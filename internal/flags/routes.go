@@ -0,0 +1,21 @@
+package flags
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes mounts the user flag routes on an authenticated router group
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	router.GET("/flags", handler.GetUserFlags) // GET /api/flags
+}
+
+// SetupAdminRoutes mounts the flag management routes on an admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	flags := router.Group("/feature-flags")
+	{
+		flags.GET("", handler.ListFlags)          // GET /admin/feature-flags
+		flags.POST("", handler.CreateFlag)        // POST /admin/feature-flags
+		flags.PUT("/:key", handler.UpdateFlag)    // PUT /admin/feature-flags/:key
+		flags.DELETE("/:key", handler.DeleteFlag) // DELETE /admin/feature-flags/:key
+	}
+}
@@ -0,0 +1,287 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// Flag keys are lowercase identifiers
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Service evaluates feature flags for users and manages them for admins.
+// Evaluation reads the flags and user plans through the cache and fails
+// closed: a flag that cannot be read is off.
+type Service struct {
+	store  Store
+	cache  Cache
+	config Config
+}
+
+// NewService creates a new flags service with an in-memory cache
+func NewService(store Store, config Config) *Service {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultConfig().CacheTTL
+	}
+	return &Service{
+		store:  store,
+		cache:  NewMemoryCache(),
+		config: config,
+	}
+}
+
+// SetCache replaces the in-memory cache, e.g. with a RedisCache shared by
+// every instance
+func (s *Service) SetCache(cache Cache) {
+	s.cache = cache
+}
+
+// defaultService backs the package-level IsEnabled
+var defaultService atomic.Pointer[Service]
+
+// SetDefault makes service the one the package-level IsEnabled evaluates with
+func SetDefault(service *Service) {
+	defaultService.Store(service)
+}
+
+// IsEnabled reports whether the flag key is on for userID using the service
+// set with SetDefault. Without one every flag is off.
+func IsEnabled(ctx context.Context, key, userID string) bool {
+	service := defaultService.Load()
+	if service == nil {
+		return false
+	}
+	return service.IsEnabled(ctx, key, userID)
+}
+
+// IsEnabled reports whether the flag key is on for userID. Unknown flags and
+// flags that cannot be read are off.
+func (s *Service) IsEnabled(ctx context.Context, key, userID string) bool {
+	flags, err := s.flags(ctx)
+	if err != nil {
+		log.Printf("Failed to load feature flags, treating %s as off: %v", key, err)
+		return false
+	}
+	for _, flag := range flags {
+		if flag.Key == key {
+			return s.evaluate(ctx, flag, userID)
+		}
+	}
+	return false
+}
+
+// Evaluate returns every flag evaluated for userID
+func (s *Service) Evaluate(ctx context.Context, userID string) (UserFlagsResponse, error) {
+	flags, err := s.flags(ctx)
+	if err != nil {
+		return UserFlagsResponse{}, err
+	}
+	evaluated := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		evaluated[flag.Key] = s.evaluate(ctx, flag, userID)
+	}
+	return UserFlagsResponse{Flags: evaluated}, nil
+}
+
+// ListFlags returns every flag
+func (s *Service) ListFlags(ctx context.Context) (ListFlagsResponse, error) {
+	flags, err := s.store.ListFlags(ctx)
+	if err != nil {
+		return ListFlagsResponse{}, err
+	}
+	return ListFlagsResponse{Flags: flags, Total: len(flags)}, nil
+}
+
+// CreateFlag validates and stores a new flag
+func (s *Service) CreateFlag(ctx context.Context, adminID string, req CreateFlagRequest) (*Flag, error) {
+	flag := Flag{
+		Key:            strings.TrimSpace(req.Key),
+		Description:    strings.TrimSpace(req.Description),
+		Enabled:        req.Enabled,
+		RolloutPercent: req.RolloutPercent,
+		UserIDs:        req.UserIDs,
+		Plans:          req.Plans,
+		CreatedBy:      &adminID,
+	}
+	if !keyPattern.MatchString(flag.Key) {
+		return nil, fmt.Errorf("%w: key must be a lowercase identifier such as new_provider", common.ErrValidation)
+	}
+	if err := normalizeTargeting(&flag); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.CreateFlag(ctx, flag)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return created, nil
+}
+
+// UpdateFlag applies the set fields of req to the flag key
+func (s *Service) UpdateFlag(ctx context.Context, adminID, key string, req UpdateFlagRequest) (*Flag, error) {
+	flags, err := s.store.ListFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(flags, func(flag Flag) bool { return flag.Key == key })
+	if index < 0 {
+		return nil, fmt.Errorf("feature flag %s: %w", key, common.ErrNotFound)
+	}
+
+	flag := flags[index]
+	if req.Description != nil {
+		flag.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Enabled != nil {
+		flag.Enabled = *req.Enabled
+	}
+	if req.RolloutPercent != nil {
+		flag.RolloutPercent = *req.RolloutPercent
+	}
+	if req.UserIDs != nil {
+		flag.UserIDs = *req.UserIDs
+	}
+	if req.Plans != nil {
+		flag.Plans = *req.Plans
+	}
+	flag.UpdatedBy = &adminID
+	if err := normalizeTargeting(&flag); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.UpdateFlag(ctx, flag)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return updated, nil
+}
+
+// DeleteFlag removes the flag key; code checking it sees it as off
+func (s *Service) DeleteFlag(ctx context.Context, adminID, key string) error {
+	if err := s.store.DeleteFlag(ctx, key, adminID); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// evaluate reports whether an enabled flag applies to userID: targeted users
+// and plans always get it, everyone else falls in or out of the rollout by a
+// stable hash, so raising the percentage only adds users
+func (s *Service) evaluate(ctx context.Context, flag Flag, userID string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if userID == "" {
+		return flag.RolloutPercent >= 100
+	}
+	if slices.Contains(flag.UserIDs, userID) {
+		return true
+	}
+	if len(flag.Plans) > 0 {
+		plan, err := s.userPlan(ctx, userID)
+		if err != nil {
+			log.Printf("Failed to get plan of user %s for feature flag %s: %v", userID, flag.Key, err)
+		} else if slices.Contains(flag.Plans, plan) {
+			return true
+		}
+	}
+	return rolloutBucket(flag.Key, userID) < flag.RolloutPercent
+}
+
+// flags returns the cached flags, loading them from the store on a miss
+func (s *Service) flags(ctx context.Context) ([]Flag, error) {
+	flags, ok, err := s.cache.GetFlags(ctx)
+	if err != nil {
+		log.Printf("Failed to read cached feature flags: %v", err)
+	} else if ok {
+		return flags, nil
+	}
+
+	flags, err = s.store.ListFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.SetFlags(ctx, flags, s.config.CacheTTL); err != nil {
+		log.Printf("Failed to cache feature flags: %v", err)
+	}
+	return flags, nil
+}
+
+// userPlan returns the cached plan of a user, loading it on a miss
+func (s *Service) userPlan(ctx context.Context, userID string) (string, error) {
+	plan, ok, err := s.cache.GetPlan(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to read cached plan of user %s: %v", userID, err)
+	} else if ok {
+		return plan, nil
+	}
+
+	plan, err = s.store.ActivePlan(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.SetPlan(ctx, userID, plan, s.config.CacheTTL); err != nil {
+		log.Printf("Failed to cache plan of user %s: %v", userID, err)
+	}
+	return plan, nil
+}
+
+// invalidate drops the cached flags after an admin change. When that fails
+// other instances pick up the change once the cached flags expire.
+func (s *Service) invalidate(ctx context.Context) {
+	if err := s.cache.InvalidateFlags(ctx); err != nil {
+		log.Printf("Failed to invalidate cached feature flags: %v", err)
+	}
+}
+
+// rolloutBucket places a user in one of 100 buckets, independently per flag
+func rolloutBucket(key, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userID))
+	return int(h.Sum32() % 100)
+}
+
+// normalizeTargeting validates the rollout and targeting of a flag and
+// trims and de-duplicates its user IDs and plans
+func normalizeTargeting(flag *Flag) error {
+	if flag.RolloutPercent < 0 || flag.RolloutPercent > 100 {
+		return fmt.Errorf("%w: rolloutPercent must be between 0 and 100", common.ErrValidation)
+	}
+
+	userIDs := []string{}
+	for _, id := range flag.UserIDs {
+		parsed, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return fmt.Errorf("%w: invalid user ID %q", common.ErrValidation, id)
+		}
+		if !slices.Contains(userIDs, parsed.String()) {
+			userIDs = append(userIDs, parsed.String())
+		}
+	}
+	flag.UserIDs = userIDs
+
+	plans := []string{}
+	for _, plan := range flag.Plans {
+		plan = strings.TrimSpace(plan)
+		if plan == "" {
+			return fmt.Errorf("%w: plan names must not be empty", common.ErrValidation)
+		}
+		if !slices.Contains(plans, plan) {
+			plans = append(plans, plan)
+		}
+	}
+	flag.Plans = plans
+	return nil
+}
@@ -0,0 +1,177 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ai-styler/internal/common"
+)
+
+type fakeStore struct {
+	flags     map[string]Flag
+	plans     map[string]string
+	listCalls int
+	planCalls int
+}
+
+func newFakeStore(flags ...Flag) *fakeStore {
+	store := &fakeStore{flags: make(map[string]Flag), plans: make(map[string]string)}
+	for _, flag := range flags {
+		store.flags[flag.Key] = flag
+	}
+	return store
+}
+
+func (f *fakeStore) ListFlags(ctx context.Context) ([]Flag, error) {
+	f.listCalls++
+	flags := []Flag{}
+	for _, flag := range f.flags {
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+func (f *fakeStore) CreateFlag(ctx context.Context, flag Flag) (*Flag, error) {
+	if _, ok := f.flags[flag.Key]; ok {
+		return nil, common.ErrConflict
+	}
+	f.flags[flag.Key] = flag
+	return &flag, nil
+}
+
+func (f *fakeStore) UpdateFlag(ctx context.Context, flag Flag) (*Flag, error) {
+	f.flags[flag.Key] = flag
+	return &flag, nil
+}
+
+func (f *fakeStore) DeleteFlag(ctx context.Context, key, deletedBy string) error {
+	if _, ok := f.flags[key]; !ok {
+		return common.ErrNotFound
+	}
+	delete(f.flags, key)
+	return nil
+}
+
+func (f *fakeStore) ActivePlan(ctx context.Context, userID string) (string, error) {
+	f.planCalls++
+	if plan, ok := f.plans[userID]; ok {
+		return plan, nil
+	}
+	return "free", nil
+}
+
+func TestIsEnabled_Targeting(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore(Flag{
+		Key:     "new_provider",
+		Enabled: true,
+		UserIDs: []string{"user-1"},
+		Plans:   []string{"premium"},
+	})
+	store.plans["user-2"] = "premium"
+	service := NewService(store, DefaultConfig())
+
+	if !service.IsEnabled(ctx, "new_provider", "user-1") {
+		t.Error("Expected a targeted user to get the flag")
+	}
+	if !service.IsEnabled(ctx, "new_provider", "user-2") {
+		t.Error("Expected a user on a targeted plan to get the flag")
+	}
+	if service.IsEnabled(ctx, "new_provider", "user-3") {
+		t.Error("Expected a free user outside the rollout not to get the flag")
+	}
+	if service.IsEnabled(ctx, "unknown", "user-1") {
+		t.Error("Expected unknown flags to be off")
+	}
+
+	service.IsEnabled(ctx, "new_provider", "user-2")
+	if store.listCalls != 1 || store.planCalls != 2 {
+		t.Errorf("Expected flags and plans to be cached, got %d list and %d plan reads", store.listCalls, store.planCalls)
+	}
+}
+
+func TestIsEnabled_Rollout(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore(Flag{Key: "new_provider", Enabled: true, RolloutPercent: 30})
+	service := NewService(store, DefaultConfig())
+
+	enabled := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if service.IsEnabled(ctx, "new_provider", userID) {
+			enabled[userID] = true
+		}
+	}
+	if len(enabled) < 250 || len(enabled) > 350 {
+		t.Errorf("Expected about 30%% of users in the rollout, got %d of 1000", len(enabled))
+	}
+
+	// Raising the percentage keeps everyone already in the rollout
+	fifty := 50
+	if _, err := service.UpdateFlag(ctx, "admin-1", "new_provider", UpdateFlagRequest{RolloutPercent: &fifty}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for userID := range enabled {
+		if !service.IsEnabled(ctx, "new_provider", userID) {
+			t.Fatalf("Expected %s to stay in the rollout", userID)
+		}
+	}
+
+	disabled := false
+	if _, err := service.UpdateFlag(ctx, "admin-1", "new_provider", UpdateFlagRequest{Enabled: &disabled}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for userID := range enabled {
+		if service.IsEnabled(ctx, "new_provider", userID) {
+			t.Fatal("Expected a disabled flag to be off for everyone")
+		}
+	}
+}
+
+func TestCreateFlag_Validation(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(newFakeStore(), DefaultConfig())
+
+	invalid := []CreateFlagRequest{
+		{Key: "New-Provider"},
+		{Key: "new_provider", RolloutPercent: 101},
+		{Key: "new_provider", UserIDs: []string{"not-a-uuid"}},
+		{Key: "new_provider", Plans: []string{" "}},
+	}
+	for _, req := range invalid {
+		if _, err := service.CreateFlag(ctx, "admin-1", req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected %+v to be rejected, got %v", req, err)
+		}
+	}
+
+	flag, err := service.CreateFlag(ctx, "admin-1", CreateFlagRequest{
+		Key:     "new_provider",
+		Enabled: true,
+		UserIDs: []string{"6F9619FF-8B86-D011-B42D-00C04FC964FF", "6f9619ff-8b86-d011-b42d-00c04fc964ff"},
+		Plans:   []string{" premium "},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(flag.UserIDs) != 1 || flag.Plans[0] != "premium" {
+		t.Errorf("Expected targeting to be normalized, got %v and %v", flag.UserIDs, flag.Plans)
+	}
+	if !service.IsEnabled(ctx, "new_provider", "6f9619ff-8b86-d011-b42d-00c04fc964ff") {
+		t.Error("Expected a new flag to be evaluated right away")
+	}
+}
+
+func TestPackageIsEnabled(t *testing.T) {
+	ctx := context.Background()
+	if IsEnabled(ctx, "new_provider", "user-1") {
+		t.Error("Expected flags to be off without a default service")
+	}
+
+	SetDefault(NewService(newFakeStore(Flag{Key: "new_provider", Enabled: true, RolloutPercent: 100}), DefaultConfig()))
+	defer SetDefault(nil)
+	if !IsEnabled(ctx, "new_provider", "user-1") {
+		t.Error("Expected the default service to evaluate the flag")
+	}
+}
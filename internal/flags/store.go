@@ -0,0 +1,196 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// flagColumns are the feature_flags columns scanned by scanFlag
+const flagColumns = `key, description, enabled, rollout_percent, user_ids::text[], plans,
+	created_by, updated_by, created_at, updated_at`
+
+// DBStore implements Store on top of the feature_flags table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// ListFlags returns every flag ordered by key
+func (s *DBStore) ListFlags(ctx context.Context) ([]Flag, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+flagColumns+` FROM feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []Flag{}
+	for rows.Next() {
+		flag, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	return flags, rows.Err()
+}
+
+// CreateFlag stores a new flag and records it in the audit log in the same
+// transaction
+func (s *DBStore) CreateFlag(ctx context.Context, flag Flag) (*Flag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := scanFlag(tx.QueryRowContext(ctx, `
+		INSERT INTO feature_flags (key, description, enabled, rollout_percent, user_ids, plans, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5::uuid[], $6, $7, $7)
+		RETURNING `+flagColumns,
+		flag.Key, flag.Description, flag.Enabled, flag.RolloutPercent, pq.Array(flag.UserIDs), pq.Array(flag.Plans), flag.CreatedBy,
+	))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%w: feature flag %s already exists", common.ErrConflict, flag.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if flag.CreatedBy != nil {
+		if err := audit(ctx, tx, *flag.CreatedBy, "create", created); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit feature flag: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateFlag replaces a flag's settings and records the change in the audit
+// log in the same transaction
+func (s *DBStore) UpdateFlag(ctx context.Context, flag Flag) (*Flag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := scanFlag(tx.QueryRowContext(ctx, `
+		UPDATE feature_flags
+		SET description = $2, enabled = $3, rollout_percent = $4, user_ids = $5::uuid[], plans = $6,
+		    updated_by = $7, updated_at = NOW()
+		WHERE key = $1
+		RETURNING `+flagColumns,
+		flag.Key, flag.Description, flag.Enabled, flag.RolloutPercent, pq.Array(flag.UserIDs), pq.Array(flag.Plans), flag.UpdatedBy,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("feature flag %s: %w", flag.Key, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if flag.UpdatedBy != nil {
+		if err := audit(ctx, tx, *flag.UpdatedBy, "update", updated); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit feature flag: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteFlag removes a flag and records the removal in the audit log
+func (s *DBStore) DeleteFlag(ctx context.Context, key, deletedBy string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("feature flag %s: %w", key, common.ErrNotFound)
+	}
+
+	if err := audit(ctx, tx, deletedBy, "delete", map[string]interface{}{"key": key}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit feature flag removal: %w", err)
+	}
+	return nil
+}
+
+// ActivePlan reads the newest active plan of a user, like the cost tracker
+func (s *DBStore) ActivePlan(ctx context.Context, userID string) (string, error) {
+	var plan string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT plan_name FROM user_plans
+		WHERE user_id = $1 AND status = 'active'
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC
+		LIMIT 1`, userID).Scan(&plan)
+	switch {
+	case err == sql.ErrNoRows:
+		return "free", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to get active plan: %w", err)
+	}
+	return plan, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFlag(row rowScanner) (*Flag, error) {
+	var flag Flag
+	var createdBy, updatedBy sql.NullString
+	err := row.Scan(&flag.Key, &flag.Description, &flag.Enabled, &flag.RolloutPercent,
+		pq.Array(&flag.UserIDs), pq.Array(&flag.Plans), &createdBy, &updatedBy, &flag.CreatedAt, &flag.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+	}
+	if createdBy.Valid {
+		flag.CreatedBy = &createdBy.String
+	}
+	if updatedBy.Valid {
+		flag.UpdatedBy = &updatedBy.String
+	}
+	return &flag, nil
+}
+
+// audit records an admin change of the feature flags
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'feature_flags', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package flags
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// WireFlagsService creates a flags service with all dependencies. Without
+// Redis the flags are cached per instance.
+func WireFlagsService(db *sql.DB, redisClient *redis.Client, cfg *config.Config) *Service {
+	service := NewService(NewDBStore(db), Config{
+		CacheTTL: cfg.FeatureFlags.CacheTTL,
+	})
+	if redisClient != nil {
+		service.SetCache(NewRedisCache(redisClient))
+	}
+	return service
+}
@@ -0,0 +1,20 @@
+package grpcapi
+
+import "encoding/json"
+
+// jsonCodec encodes messages as JSON. The internal API has no protobuf
+// stubs: its messages are the JSON bodies of the matching REST endpoints, so
+// clients can reuse the types they already decode REST responses into.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
@@ -0,0 +1,341 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"ai-styler/internal/auth"
+	"ai-styler/internal/common"
+	"ai-styler/internal/conversion"
+	"ai-styler/internal/image"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMaxMessageSize bounds requests when Config leaves it unset
+const defaultMaxMessageSize = 64 << 20
+
+// AuthBackend signs in Telegram users for the bot
+type AuthBackend interface {
+	TelegramSignIn(ctx context.Context, telegramUserID int64) (*auth.LoginResponse, error)
+	TelegramLinkSignIn(ctx context.Context, code string, telegramUserID int64) (*auth.LoginResponse, error)
+}
+
+// ConversionBackend creates and reads conversions of a user
+type ConversionBackend interface {
+	CreateConversion(ctx context.Context, userID string, req conversion.ConversionRequest) (conversion.ConversionResponse, error)
+	GetConversion(ctx context.Context, conversionID, userID string) (conversion.ConversionResponse, error)
+	ListConversions(ctx context.Context, userID string, req conversion.ConversionListRequest) (conversion.ConversionListResponse, error)
+}
+
+// ImageBackend stores and reads images
+type ImageBackend interface {
+	UploadImage(ctx context.Context, userID *string, vendorID *string, req image.UploadImageRequest) (image.Image, error)
+	GetImage(ctx context.Context, imageID string) (image.Image, error)
+}
+
+// Config configures the internal API server
+type Config struct {
+	// AllowedClients are the client certificate common names that may call
+	// the API; empty allows any client whose certificate the CA signed
+	AllowedClients []string
+	MaxMessageSize int
+}
+
+// Server implements the internal API. Callers are services authenticated by
+// mutual TLS, so the public middleware (API keys, rate limits) doesn't apply;
+// calls on behalf of a user still carry that user's access token in the
+// "authorization" metadata.
+type Server struct {
+	auth        AuthBackend
+	tokens      auth.TokenService
+	conversions ConversionBackend
+	images      ImageBackend
+
+	allowedClients map[string]bool
+	maxMessageSize int
+}
+
+// NewServer creates the internal API server
+func NewServer(authBackend AuthBackend, tokens auth.TokenService, conversions ConversionBackend, images ImageBackend, config Config) *Server {
+	allowed := make(map[string]bool, len(config.AllowedClients))
+	for _, name := range config.AllowedClients {
+		allowed[name] = true
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultMaxMessageSize
+	}
+	return &Server{
+		auth:           authBackend,
+		tokens:         tokens,
+		conversions:    conversions,
+		images:         images,
+		allowedClients: allowed,
+		maxMessageSize: config.MaxMessageSize,
+	}
+}
+
+// NewGRPCServer returns a gRPC server serving s. Pass the mutual TLS
+// credentials from ServerCredentials in opts.
+func NewGRPCServer(s *Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ForceServerCodec(jsonCodec{}),
+		grpc.UnaryInterceptor(s.intercept),
+		grpc.MaxRecvMsgSize(s.maxMessageSize),
+		grpc.MaxSendMsgSize(s.maxMessageSize),
+	}, opts...)
+
+	server := grpc.NewServer(opts...)
+	for _, desc := range serviceDescs {
+		server.RegisterService(desc, s)
+	}
+	return server
+}
+
+// intercept checks the client certificate, recovers panics and turns
+// errors into gRPC statuses
+func (s *Server) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Internal API: panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			resp, err = nil, status.Error(codes.Internal, "internal error")
+		}
+	}()
+
+	if err := s.authorizeClient(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err = handler(ctx, req)
+	if err != nil {
+		st := toStatus(err)
+		if status.Code(st) == codes.Internal {
+			log.Printf("Internal API: %s failed: %v", info.FullMethod, err)
+		}
+		return nil, st
+	}
+	return resp, nil
+}
+
+// authorizeClient accepts the peer when its verified certificate names an
+// allowed client
+func (s *Server) authorizeClient(ctx context.Context) error {
+	if len(s.allowedClients) == 0 {
+		return nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "client certificate required")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "client certificate required")
+	}
+	if name := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName; !s.allowedClients[name] {
+		return status.Errorf(codes.PermissionDenied, "client %q is not allowed", name)
+	}
+	return nil
+}
+
+// user returns the user of the access token in the "authorization" metadata
+// and a context that carries it like the REST middleware does
+func (s *Server) user(ctx context.Context) (context.Context, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, "", status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	claims, err := s.tokens.ValidateAccess(ctx, strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return nil, "", status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return common.SetUserIDInContext(ctx, claims.UserID), claims.UserID, nil
+}
+
+func (s *Server) telegramLogin(ctx context.Context, req *TelegramLoginRequest) (*auth.LoginResponse, error) {
+	if req.TelegramUserID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "telegramUserId is required")
+	}
+	return s.auth.TelegramSignIn(ctx, req.TelegramUserID)
+}
+
+func (s *Server) telegramLink(ctx context.Context, req *TelegramLinkRequest) (*auth.LoginResponse, error) {
+	if req.TelegramUserID == 0 || strings.TrimSpace(req.Code) == "" {
+		return nil, status.Error(codes.InvalidArgument, "code and telegramUserId are required")
+	}
+	return s.auth.TelegramLinkSignIn(ctx, req.Code, req.TelegramUserID)
+}
+
+// createConversion queues a conversion and returns it without waiting for
+// the result; poll GetConversion for the outcome
+func (s *Server) createConversion(ctx context.Context, req *conversion.ConversionRequest) (*conversion.ConversionResponse, error) {
+	ctx, userID, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := conversion.ConversionRequest{
+		UserImageID:  req.GetUserImageID(),
+		ClothImageID: req.GetClothImageID(),
+		StyleName:    req.GetStyleName(),
+		PresetID:     req.GetPresetID(),
+		Garments:     req.Garments,
+		OutputWidth:  req.OutputWidth,
+		OutputHeight: req.OutputHeight,
+		AspectRatio:  req.AspectRatio,
+	}
+	created, err := s.conversions.CreateConversion(ctx, userID, normalized)
+	if err != nil {
+		return nil, classify(err,
+			errorText{"quota exceeded", codes.ResourceExhausted},
+			errorText{"rate limit", codes.ResourceExhausted},
+			errorText{"access denied", codes.PermissionDenied},
+			errorText{"invalid", codes.InvalidArgument},
+			errorText{"not found", codes.InvalidArgument},
+			errorText{"not accessible", codes.InvalidArgument},
+			errorText{"must be different", codes.InvalidArgument},
+		)
+	}
+	return &created, nil
+}
+
+func (s *Server) getConversion(ctx context.Context, req *ConversionIDRequest) (*conversion.ConversionResponse, error) {
+	ctx, userID, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.conversions.GetConversion(ctx, req.ConversionID, userID)
+	if err != nil {
+		return nil, classify(err, errorText{"not found", codes.NotFound})
+	}
+	return &found, nil
+}
+
+func (s *Server) listConversions(ctx context.Context, req *conversion.ConversionListRequest) (*conversion.ConversionListResponse, error) {
+	ctx, userID, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.UserID = userID
+	list, err := s.conversions.ListConversions(ctx, userID, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (s *Server) uploadImage(ctx context.Context, req *UploadImageRequest) (*image.Image, error) {
+	ctx, userID, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Data) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "data is required")
+	}
+
+	uploaded, err := s.images.UploadImage(ctx, &userID, nil, image.UploadImageRequest{
+		Type:     image.ImageType(req.Type),
+		FileName: req.FileName,
+		FileSize: int64(len(req.Data)),
+		MimeType: req.MimeType,
+		File:     bytes.NewReader(req.Data),
+	})
+	if err != nil {
+		return nil, classify(err,
+			errorText{"rate limit", codes.ResourceExhausted},
+			errorText{"quota exceeded", codes.ResourceExhausted},
+			errorText{"required", codes.InvalidArgument},
+			errorText{"too long", codes.InvalidArgument},
+			errorText{"unsupported", codes.InvalidArgument},
+			errorText{"invalid", codes.InvalidArgument},
+		)
+	}
+	return &uploaded, nil
+}
+
+// getImage returns an image the user owns, a vendor image or a public image
+func (s *Server) getImage(ctx context.Context, req *ImageIDRequest) (*image.Image, error) {
+	ctx, userID, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.images.GetImage(ctx, req.ImageID)
+	if err != nil {
+		return nil, classify(err, errorText{"not found", codes.NotFound})
+	}
+	if found.UserID != nil && *found.UserID != userID && !found.IsPublic {
+		return nil, status.Error(codes.NotFound, "image not found")
+	}
+	return &found, nil
+}
+
+// errorText maps service errors whose message contains text to code
+type errorText struct {
+	text string
+	code codes.Code
+}
+
+// classify returns the status of the first matching errorText, reading
+// service errors the way the REST handlers of the same services do
+func classify(err error, texts ...errorText) error {
+	for _, t := range texts {
+		if strings.Contains(err.Error(), t.text) {
+			return status.Error(t.code, err.Error())
+		}
+	}
+	return err
+}
+
+// toStatus converts an error to a gRPC status, mapping the HTTP status of
+// API errors and the common sentinel errors
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	apiErr := common.FromError(err, http.StatusInternalServerError)
+	code := codes.Internal
+	switch apiErr.Status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	if code == codes.Internal {
+		return status.Error(code, "internal error")
+	}
+	return status.Error(code, apiErr.Message)
+}
@@ -0,0 +1,234 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"ai-styler/internal/auth"
+	"ai-styler/internal/common"
+	"ai-styler/internal/conversion"
+	"ai-styler/internal/domain"
+	"ai-styler/internal/image"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeTokens accepts "token-<userID>"
+type fakeTokens struct {
+	auth.TokenService
+}
+
+func (fakeTokens) ValidateAccess(ctx context.Context, token string) (auth.TokenClaims, error) {
+	if !strings.HasPrefix(token, "token-") {
+		return auth.TokenClaims{}, errors.New("invalid token")
+	}
+	return auth.TokenClaims{UserID: strings.TrimPrefix(token, "token-")}, nil
+}
+
+// fakeAuth signs in Telegram user 42 as user-1
+type fakeAuth struct{}
+
+func (fakeAuth) TelegramSignIn(ctx context.Context, telegramUserID int64) (*auth.LoginResponse, error) {
+	if telegramUserID != 42 {
+		return nil, common.NewAPIError(http.StatusNotFound, "not_linked", "telegram account is not linked", nil)
+	}
+	return &auth.LoginResponse{AccessToken: "token-user-1"}, nil
+}
+
+func (fakeAuth) TelegramLinkSignIn(ctx context.Context, code string, telegramUserID int64) (*auth.LoginResponse, error) {
+	return nil, common.NewAPIError(http.StatusUnauthorized, "invalid_code", "invalid or expired link code", nil)
+}
+
+type fakeConversions struct {
+	createErr error
+}
+
+func (f fakeConversions) CreateConversion(ctx context.Context, userID string, req conversion.ConversionRequest) (conversion.ConversionResponse, error) {
+	if f.createErr != nil {
+		return conversion.ConversionResponse{}, f.createErr
+	}
+	return conversion.ConversionResponse{Conversion: domain.Conversion{ID: "conv-1", UserID: userID}}, nil
+}
+
+func (fakeConversions) GetConversion(ctx context.Context, conversionID, userID string) (conversion.ConversionResponse, error) {
+	return conversion.ConversionResponse{}, errors.New("conversion not found")
+}
+
+func (fakeConversions) ListConversions(ctx context.Context, userID string, req conversion.ConversionListRequest) (conversion.ConversionListResponse, error) {
+	return conversion.ConversionListResponse{}, nil
+}
+
+// fakeImages holds a private image of user-1 and a public image of user-2
+type fakeImages struct{}
+
+func (fakeImages) UploadImage(ctx context.Context, userID *string, vendorID *string, req image.UploadImageRequest) (image.Image, error) {
+	return image.Image{ID: "img-new", UserID: userID, FileName: req.FileName, FileSize: req.FileSize}, nil
+}
+
+func (fakeImages) GetImage(ctx context.Context, imageID string) (image.Image, error) {
+	user1, user2 := "user-1", "user-2"
+	switch imageID {
+	case "private":
+		return image.Image{ID: imageID, UserID: &user1}, nil
+	case "public":
+		return image.Image{ID: imageID, UserID: &user2, IsPublic: true}, nil
+	}
+	return image.Image{}, errors.New("image not found")
+}
+
+func newTestConn(t *testing.T, conversions ConversionBackend) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(NewServer(fakeAuth{}, fakeTokens{}, conversions, fakeImages{}, Config{}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func asUser(userID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token-"+userID)
+}
+
+func TestTelegramLogin(t *testing.T) {
+	conn := newTestConn(t, fakeConversions{})
+	method := "/" + AuthService + "/" + MethodTelegramLogin
+
+	var resp auth.LoginResponse
+	if err := conn.Invoke(context.Background(), method, &TelegramLoginRequest{TelegramUserID: 42}, &resp); err != nil {
+		t.Fatalf("Expected sign-in to succeed, got %v", err)
+	}
+	if resp.AccessToken != "token-user-1" {
+		t.Errorf("Expected the access token of user-1, got %q", resp.AccessToken)
+	}
+
+	err := conn.Invoke(context.Background(), method, &TelegramLoginRequest{TelegramUserID: 7}, &resp)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unlinked account, got %v", err)
+	}
+
+	err = conn.Invoke(context.Background(), "/"+AuthService+"/"+MethodTelegramLink, &TelegramLinkRequest{Code: "bad", TelegramUserID: 7}, &resp)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for an invalid link code, got %v", err)
+	}
+}
+
+func TestUserCallsRequireToken(t *testing.T) {
+	conn := newTestConn(t, fakeConversions{})
+	method := "/" + ConversionsService + "/" + MethodCreateConversion
+	req := &conversion.ConversionRequest{UserImageID: "a", ClothImageID: "b"}
+
+	var resp conversion.ConversionResponse
+	if err := conn.Invoke(context.Background(), method, req, &resp); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer forged")
+	if err := conn.Invoke(ctx, method, req, &resp); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for an invalid token, got %v", err)
+	}
+
+	if err := conn.Invoke(asUser("user-1"), method, req, &resp); err != nil {
+		t.Fatalf("Expected the conversion to be created, got %v", err)
+	}
+	if resp.UserID != "user-1" {
+		t.Errorf("Expected the conversion of user-1, got %q", resp.UserID)
+	}
+}
+
+func TestGetImageOwnership(t *testing.T) {
+	conn := newTestConn(t, fakeConversions{})
+	method := "/" + ImagesService + "/" + MethodGetImage
+
+	tests := []struct {
+		user, imageID string
+		want          codes.Code
+	}{
+		{"user-1", "private", codes.OK},
+		{"user-2", "private", codes.NotFound},
+		{"user-1", "public", codes.OK},
+		{"user-1", "missing", codes.NotFound},
+	}
+	for _, tt := range tests {
+		var resp image.Image
+		err := conn.Invoke(asUser(tt.user), method, &ImageIDRequest{ImageID: tt.imageID}, &resp)
+		if status.Code(err) != tt.want {
+			t.Errorf("%s getting %s: expected %v, got %v", tt.user, tt.imageID, tt.want, err)
+		}
+	}
+}
+
+func TestUploadImage(t *testing.T) {
+	conn := newTestConn(t, fakeConversions{})
+	method := "/" + ImagesService + "/" + MethodUploadImage
+
+	var resp image.Image
+	req := &UploadImageRequest{Type: "user", FileName: "me.jpg", MimeType: "image/jpeg", Data: []byte("jpeg")}
+	if err := conn.Invoke(asUser("user-1"), method, req, &resp); err != nil {
+		t.Fatalf("Expected the upload to succeed, got %v", err)
+	}
+	if resp.UserID == nil || *resp.UserID != "user-1" || resp.FileSize != 4 {
+		t.Errorf("Unexpected image %+v", resp)
+	}
+
+	if err := conn.Invoke(asUser("user-1"), method, &UploadImageRequest{FileName: "empty.jpg"}, &resp); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an empty upload, got %v", err)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{errors.New("monthly quota exceeded"), codes.ResourceExhausted},
+		{errors.New("user image not found"), codes.InvalidArgument},
+		{common.NewAPIError(http.StatusConflict, "", "conflict", nil), codes.AlreadyExists},
+		{errors.New("pq: connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		conn := newTestConn(t, fakeConversions{createErr: tt.err})
+
+		var resp conversion.ConversionResponse
+		err := conn.Invoke(asUser("user-1"), "/"+ConversionsService+"/"+MethodCreateConversion, &conversion.ConversionRequest{}, &resp)
+		if status.Code(err) != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.err, tt.want, err)
+		}
+		if tt.want == codes.Internal && strings.Contains(err.Error(), "pq:") {
+			t.Errorf("Expected internal errors to be hidden, got %v", err)
+		}
+	}
+}
+
+func TestAuthorizeClient(t *testing.T) {
+	server := NewServer(fakeAuth{}, fakeTokens{}, fakeConversions{}, fakeImages{}, Config{AllowedClients: []string{"telegram-bot"}})
+	if err := server.authorizeClient(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a client certificate, got %v", err)
+	}
+
+	open := NewServer(fakeAuth{}, fakeTokens{}, fakeConversions{}, fakeImages{}, Config{})
+	if err := open.authorizeClient(context.Background()); err != nil {
+		t.Errorf("Expected any client to be accepted without an allow list, got %v", err)
+	}
+}
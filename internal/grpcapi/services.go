@@ -0,0 +1,105 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Service names of the internal API
+const (
+	AuthService        = "aistyler.internal.v1.Auth"
+	ConversionsService = "aistyler.internal.v1.Conversions"
+	ImagesService      = "aistyler.internal.v1.Images"
+)
+
+// Method names; a call goes to "/<service>/<method>"
+const (
+	MethodTelegramLogin    = "TelegramLogin"
+	MethodTelegramLink     = "TelegramLink"
+	MethodCreateConversion = "CreateConversion"
+	MethodGetConversion    = "GetConversion"
+	MethodListConversions  = "ListConversions"
+	MethodUploadImage      = "UploadImage"
+	MethodGetImage         = "GetImage"
+)
+
+// TelegramLoginRequest signs in the user linked to a Telegram account
+type TelegramLoginRequest struct {
+	TelegramUserID int64 `json:"telegramUserId"`
+}
+
+// TelegramLinkRequest links a Telegram account with a link code
+type TelegramLinkRequest struct {
+	Code           string `json:"code"`
+	TelegramUserID int64  `json:"telegramUserId"`
+}
+
+// ConversionIDRequest names a conversion of the calling user
+type ConversionIDRequest struct {
+	ConversionID string `json:"conversionId"`
+}
+
+// UploadImageRequest carries an image file; Data is base64 in JSON
+type UploadImageRequest struct {
+	Type     string `json:"type"`
+	FileName string `json:"fileName"`
+	MimeType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+// ImageIDRequest names an image
+type ImageIDRequest struct {
+	ImageID string `json:"imageId"`
+}
+
+// serviceDescs describe the services for grpc.Server.RegisterService. They
+// take the place of generated stubs.
+var serviceDescs = []*grpc.ServiceDesc{
+	{
+		ServiceName: AuthService,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			unaryMethod(AuthService, MethodTelegramLogin, (*Server).telegramLogin),
+			unaryMethod(AuthService, MethodTelegramLink, (*Server).telegramLink),
+		},
+	},
+	{
+		ServiceName: ConversionsService,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			unaryMethod(ConversionsService, MethodCreateConversion, (*Server).createConversion),
+			unaryMethod(ConversionsService, MethodGetConversion, (*Server).getConversion),
+			unaryMethod(ConversionsService, MethodListConversions, (*Server).listConversions),
+		},
+	},
+	{
+		ServiceName: ImagesService,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			unaryMethod(ImagesService, MethodUploadImage, (*Server).uploadImage),
+			unaryMethod(ImagesService, MethodGetImage, (*Server).getImage),
+		},
+	},
+}
+
+// unaryMethod adapts a typed Server method to a gRPC method handler
+func unaryMethod[Req any, Resp any](service, method string, fn func(s *Server, ctx context.Context, req *Req) (*Resp, error)) grpc.MethodDesc {
+	fullMethod := "/" + service + "/" + method
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return fn(srv.(*Server), ctx, req.(*Req))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+		},
+	}
+}
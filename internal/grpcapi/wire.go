@@ -0,0 +1,25 @@
+package grpcapi
+
+import (
+	"fmt"
+
+	"ai-styler/internal/auth"
+	"ai-styler/internal/config"
+
+	"google.golang.org/grpc"
+)
+
+// WireInternalAPI creates the internal gRPC server with mutual TLS from
+// cfg.InternalAPI. Callers serve it only when cfg.InternalAPI.Enabled.
+func WireInternalAPI(cfg *config.Config, authBackend AuthBackend, tokens auth.TokenService, conversions ConversionBackend, images ImageBackend) (*grpc.Server, error) {
+	creds, err := ServerCredentials(cfg.InternalAPI.CertFile, cfg.InternalAPI.KeyFile, cfg.InternalAPI.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load internal API credentials: %w", err)
+	}
+
+	server := NewServer(authBackend, tokens, conversions, images, Config{
+		AllowedClients: cfg.InternalAPI.AllowedClients,
+		MaxMessageSize: cfg.InternalAPI.MaxMessageSize,
+	})
+	return NewGRPCServer(server, grpc.Creds(creds)), nil
+}
@@ -0,0 +1,103 @@
+package image
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrImageAccessDenied is returned when the viewer may not see the image
+var ErrImageAccessDenied = errors.New("image access denied")
+
+// viewerCondition restricts images to those the user in parameter argIndex
+// may see: their own and their vendor's images, public images outside private
+// albums, and every image of the albums shared with them
+func viewerCondition(argIndex int) string {
+	return fmt.Sprintf(`(images.user_id = $%[1]d::uuid
+		OR images.vendor_id IN (SELECT id FROM vendors WHERE user_id = $%[1]d::uuid)
+		OR (images.is_public AND NOT EXISTS (
+			SELECT 1 FROM albums WHERE albums.id = images.album_id AND NOT albums.is_public))
+		OR images.album_id IN (SELECT album_id FROM album_shares WHERE user_id = $%[1]d::uuid))`, argIndex)
+}
+
+// checkViewer fails with ErrImageAccessDenied unless viewerID may see the image
+func (s *Service) checkViewer(ctx context.Context, viewerID, imageID string) error {
+	allowed, err := s.store.CanViewImage(ctx, imageID, viewerID)
+	if err != nil {
+		return fmt.Errorf("failed to check image access: %w", err)
+	}
+	if !allowed {
+		return ErrImageAccessDenied
+	}
+	return nil
+}
+
+// CanViewImage reports whether viewerID may see a live image
+func (s *DBStore) CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error) {
+	return canViewImage(ctx, s.db, imageID, viewerID)
+}
+
+// CanViewImage reports whether viewerID may see an image
+func (s *postgresStore) CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error) {
+	return canViewImage(ctx, s.db, imageID, viewerID)
+}
+
+// canViewImage checks viewerCondition for one image. An empty viewer only
+// sees public images.
+func canViewImage(ctx context.Context, db *sql.DB, imageID, viewerID string) (bool, error) {
+	var allowed bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM images
+			WHERE id = $1 AND deleted_at IS NULL AND `+viewerCondition(2)+`
+		)`, imageID, nullableViewer(viewerID)).Scan(&allowed)
+	if err != nil {
+		return false, err
+	}
+	return allowed, nil
+}
+
+// PrivateAlbumImages returns which of the images are in non-public albums
+func (s *DBStore) PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error) {
+	return privateAlbumImages(ctx, s.db, imageIDs)
+}
+
+// PrivateAlbumImages returns which of the images are in non-public albums
+func (s *postgresStore) PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error) {
+	return privateAlbumImages(ctx, s.db, imageIDs)
+}
+
+// privateAlbumImages is the album half of viewerCondition for a set of images
+func privateAlbumImages(ctx context.Context, db *sql.DB, imageIDs []string) (map[string]bool, error) {
+	private := make(map[string]bool)
+	if len(imageIDs) == 0 {
+		return private, nil
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT images.id::text FROM images
+		JOIN albums ON albums.id = images.album_id
+		WHERE images.id::text = ANY($1) AND NOT albums.is_public`, pq.Array(imageIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		private[id] = true
+	}
+	return private, rows.Err()
+}
+
+// nullableViewer passes an anonymous viewer as NULL, which matches no owner or share
+func nullableViewer(viewerID string) interface{} {
+	if viewerID == "" {
+		return nil
+	}
+	return viewerID
+}
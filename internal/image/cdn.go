@@ -0,0 +1,71 @@
+package image
+
+import (
+	"context"
+	"log"
+)
+
+// SetCDN serves public images from cdn and purges them from its cache when
+// they are deleted or made private
+func (s *Service) SetCDN(cdn CDN) {
+	s.cdn = cdn
+}
+
+// WithCDNURLs returns image with its file URLs on the CDN host. Private images,
+// and public ones in albums that are not public, keep their storage URLs,
+// which are only reachable through signed URLs. The service itself works with
+// storage URLs, so this is applied to responses.
+func (s *Service) WithCDNURLs(ctx context.Context, image Image) Image {
+	return s.WithCDNURLsAll(ctx, []Image{image})[0]
+}
+
+// WithCDNURLsAll applies WithCDNURLs to a page of images with one album lookup
+func (s *Service) WithCDNURLsAll(ctx context.Context, images []Image) []Image {
+	if s.cdn == nil {
+		return images
+	}
+
+	var publicIDs []string
+	for _, image := range images {
+		if image.IsPublic {
+			publicIDs = append(publicIDs, image.ID)
+		}
+	}
+	if len(publicIDs) == 0 {
+		return images
+	}
+	// Without the album visibility every image stays on signed URLs
+	privateAlbum, err := s.store.PrivateAlbumImages(ctx, publicIDs)
+	if err != nil {
+		log.Printf("Failed to check album visibility, serving storage URLs: %v", err)
+		return images
+	}
+
+	result := make([]Image, len(images))
+	for i, image := range images {
+		if image.IsPublic && !privateAlbum[image.ID] {
+			image.OriginalURL = s.cdn.URL(image.OriginalURL)
+			if image.ThumbnailURL != nil {
+				thumbnailURL := s.cdn.URL(*image.ThumbnailURL)
+				image.ThumbnailURL = &thumbnailURL
+			}
+		}
+		result[i] = image
+	}
+	return result
+}
+
+// purgeCDN removes the image files from the CDN cache. A failed purge is
+// logged; the cached copies expire with the CDN cache TTL.
+func (s *Service) purgeCDN(ctx context.Context, image Image) {
+	if s.cdn == nil {
+		return
+	}
+	refs := []string{image.OriginalURL}
+	if image.ThumbnailURL != nil {
+		refs = append(refs, *image.ThumbnailURL)
+	}
+	if err := s.cdn.Purge(ctx, refs...); err != nil {
+		log.Printf("Failed to purge image %s from the CDN: %v", image.ID, err)
+	}
+}
@@ -0,0 +1,134 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// mockCDN serves every reference from cdn.example.com and records purges
+type mockCDN struct {
+	purged []string
+}
+
+func (m *mockCDN) URL(ref string) string {
+	return "https://cdn.example.com/" + strings.TrimPrefix(ref, "uploads/")
+}
+
+func (m *mockCDN) Purge(ctx context.Context, refs ...string) error {
+	m.purged = append(m.purged, refs...)
+	return nil
+}
+
+func TestWithCDNURLs(t *testing.T) {
+	store := newMockStore()
+	service := newTrashTestService(store, newMockTrashStore(store), time.Hour)
+	thumbnail := "uploads/images/vendor/v1/thumbnails/a.jpg"
+	public := Image{ID: "img-1", OriginalURL: "uploads/images/vendor/v1/a.jpg", ThumbnailURL: &thumbnail, IsPublic: true}
+
+	if got := service.WithCDNURLs(context.Background(), public); got.OriginalURL != public.OriginalURL {
+		t.Errorf("Expected storage URLs without a CDN, got %s", got.OriginalURL)
+	}
+
+	service.SetCDN(&mockCDN{})
+	got := service.WithCDNURLs(context.Background(), public)
+	if got.OriginalURL != "https://cdn.example.com/images/vendor/v1/a.jpg" || *got.ThumbnailURL != "https://cdn.example.com/images/vendor/v1/thumbnails/a.jpg" {
+		t.Errorf("Expected CDN URLs, got %s and %s", got.OriginalURL, *got.ThumbnailURL)
+	}
+	if thumbnail != "uploads/images/vendor/v1/thumbnails/a.jpg" {
+		t.Error("Expected the original image to be left unchanged")
+	}
+
+	private := public
+	private.IsPublic = false
+	if got := service.WithCDNURLs(context.Background(), private); got.OriginalURL != private.OriginalURL {
+		t.Errorf("Expected private images to keep storage URLs, got %s", got.OriginalURL)
+	}
+
+	// A public image in a private album is only reachable through signed URLs
+	store.privateAlbum["img-1"] = true
+	other := Image{ID: "img-2", OriginalURL: "uploads/images/vendor/v1/b.jpg", IsPublic: true}
+	page := service.WithCDNURLsAll(context.Background(), []Image{public, other})
+	if page[0].OriginalURL != public.OriginalURL || *page[0].ThumbnailURL != thumbnail {
+		t.Errorf("Expected an image in a private album to keep storage URLs, got %s", page[0].OriginalURL)
+	}
+	if page[1].OriginalURL != "https://cdn.example.com/images/vendor/v1/b.jpg" {
+		t.Errorf("Expected other public images on the CDN, got %s", page[1].OriginalURL)
+	}
+}
+
+func TestGetImageHandler_ChecksViewer(t *testing.T) {
+	store := newMockStore()
+	service := newTrashTestService(store, newMockTrashStore(store), time.Hour)
+	service.SetCDN(&mockCDN{})
+	handler := NewHandler(service)
+
+	ownerID := "user-1"
+	store.images["img-1"] = Image{ID: "img-1", UserID: &ownerID, Type: ImageTypeUser, OriginalURL: "uploads/images/user/a.jpg", IsPublic: true}
+	store.privateAlbum["img-1"] = true
+
+	get := func(viewerID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/images/img-1", nil)
+		req = req.WithContext(common.SetUserIDInContext(req.Context(), viewerID))
+		rec := httptest.NewRecorder()
+		handler.GetImage(rec, req)
+		return rec
+	}
+
+	if rec := get("user-2"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected other users to be denied an image in a private album, got %d", rec.Code)
+	}
+
+	rec := get(ownerID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the owner to get the image, got %d", rec.Code)
+	}
+	var image Image
+	if err := json.Unmarshal(rec.Body.Bytes(), &image); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if image.OriginalURL != "uploads/images/user/a.jpg" {
+		t.Errorf("Expected a storage URL for an image in a private album, got %s", image.OriginalURL)
+	}
+}
+
+func TestCDNPurgedOnDeleteAndUnpublish(t *testing.T) {
+	store := newMockStore()
+	trash := newMockTrashStore(store)
+	service := newTrashTestService(store, trash, time.Hour)
+	cdn := &mockCDN{}
+	service.SetCDN(cdn)
+
+	userID, vendorID := "user-1", "vendor-1"
+	thumbnail := "uploads/images/user/thumb.jpg"
+	store.images["img-1"] = Image{ID: "img-1", UserID: &userID, Type: ImageTypeUser, OriginalURL: "uploads/images/user/a.jpg", ThumbnailURL: &thumbnail}
+	store.images["img-2"] = Image{ID: "img-2", VendorID: &vendorID, Type: ImageTypeVendor, OriginalURL: "uploads/images/vendor/b.jpg", IsPublic: true}
+
+	if err := service.DeleteImage(context.Background(), "img-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cdn.purged) != 2 {
+		t.Fatalf("Expected the image and thumbnail to be purged, got %v", cdn.purged)
+	}
+
+	private := false
+	if _, err := service.UpdateImage(context.Background(), "img-2", UpdateImageRequest{IsPublic: &private}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cdn.purged) != 3 || cdn.purged[2] != "uploads/images/vendor/b.jpg" {
+		t.Errorf("Expected an image made private to be purged, got %v", cdn.purged)
+	}
+
+	if err := service.DeleteImage(context.Background(), "img-2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cdn.purged) != 4 {
+		t.Errorf("Expected a trashed image to be purged, got %v", cdn.purged)
+	}
+}
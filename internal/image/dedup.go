@@ -0,0 +1,247 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// ErrDedupDisabled is returned by dedup settings operations when no dedup store is configured
+var ErrDedupDisabled = errors.New("image deduplication is not enabled")
+
+// dHash grid: each row compares dhashWidth+1 neighbouring cells, giving 64 bits
+const (
+	dhashWidth  = 8
+	dhashHeight = 8
+	// dhashSamples bounds the pixels sampled per cell side, so large images hash quickly
+	dhashSamples = 8
+)
+
+// DedupSettings represents a vendor's upload deduplication setting
+type DedupSettings struct {
+	Enabled bool `json:"enabled"`
+}
+
+// imageHashes holds the hashes computed for an uploaded file
+type imageHashes struct {
+	content    string // hex SHA-256 of the file bytes
+	perceptual *int64 // dHash of the decoded image, nil if it can't be decoded
+}
+
+// SetDedup makes UploadImage return the owner's existing image when the same
+// file, or a re-encoded copy of it, is uploaded again. Vendors can turn this
+// off for their uploads.
+func (s *Service) SetDedup(dedup DedupStore) {
+	s.dedup = dedup
+}
+
+// findDuplicate looks up an existing image matching the upload's hashes
+func (s *Service) findDuplicate(ctx context.Context, userID *string, vendorID *string, imageType ImageType, hashes imageHashes) (Image, bool) {
+	if s.dedup == nil {
+		return Image{}, false
+	}
+
+	if vendorID != nil {
+		enabled, err := s.dedup.IsVendorDedupEnabled(ctx, *vendorID)
+		if err != nil {
+			_ = s.auditLogger.LogImageAction(ctx, "", userID, vendorID, "dedup_lookup_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return Image{}, false
+		}
+		if !enabled {
+			return Image{}, false
+		}
+	}
+
+	existing, found, err := s.dedup.FindDuplicateImage(ctx, userID, vendorID, imageType, hashes.content, hashes.perceptual)
+	if err != nil {
+		// Log error but continue with a regular upload
+		_ = s.auditLogger.LogImageAction(ctx, "", userID, vendorID, "dedup_lookup_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return Image{}, false
+	}
+	if !found {
+		return Image{}, false
+	}
+
+	// Record usage
+	_ = s.usageTracker.RecordUsage(ctx, existing.ID, userID, ActionDedup, map[string]interface{}{
+		"content_hash": hashes.content,
+	})
+
+	// Log the action
+	_ = s.auditLogger.LogImageAction(ctx, existing.ID, userID, vendorID, "image_deduplicated", map[string]interface{}{
+		"file_name": existing.FileName,
+		"file_size": existing.FileSize,
+	})
+
+	existing.Deduplicated = true
+	return existing, true
+}
+
+// GetVendorDedupSettings returns the vendor's deduplication setting
+func (s *Service) GetVendorDedupSettings(ctx context.Context, vendorID string) (DedupSettings, error) {
+	if s.dedup == nil {
+		return DedupSettings{}, ErrDedupDisabled
+	}
+
+	enabled, err := s.dedup.IsVendorDedupEnabled(ctx, vendorID)
+	if err != nil {
+		return DedupSettings{}, fmt.Errorf("failed to get dedup setting: %w", err)
+	}
+
+	return DedupSettings{Enabled: enabled}, nil
+}
+
+// UpdateVendorDedupSettings turns deduplication of the vendor's uploads on or off
+func (s *Service) UpdateVendorDedupSettings(ctx context.Context, vendorID string, settings DedupSettings) (DedupSettings, error) {
+	if s.dedup == nil {
+		return DedupSettings{}, ErrDedupDisabled
+	}
+
+	if err := s.dedup.SetVendorDedupEnabled(ctx, vendorID, settings.Enabled); err != nil {
+		return DedupSettings{}, fmt.Errorf("failed to update dedup setting: %w", err)
+	}
+
+	// Log the action
+	_ = s.auditLogger.LogImageAction(ctx, "", nil, &vendorID, "dedup_setting_updated", map[string]interface{}{
+		"enabled": settings.Enabled,
+	})
+
+	return settings, nil
+}
+
+// computeImageHashes hashes an uploaded file for deduplication
+func computeImageHashes(data []byte) imageHashes {
+	sum := sha256.Sum256(data)
+	hashes := imageHashes{content: hex.EncodeToString(sum[:])}
+	if hash, ok := perceptualHash(data); ok {
+		hashes.perceptual = &hash
+	}
+	return hashes
+}
+
+// perceptualHash computes a difference hash (dHash) of an image: the image is
+// reduced to a 9x8 grayscale grid and each bit records whether a cell is
+// brighter than its right neighbour. Re-encoded or resized copies of an image
+// get the same hash. Flat images, whose hash is all zeros, are not hashed.
+func perceptualHash(data []byte) (int64, bool) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, false
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() < dhashWidth+1 || bounds.Dy() < dhashHeight {
+		return 0, false
+	}
+
+	var grid [dhashHeight][dhashWidth + 1]float64
+	for row := 0; row < dhashHeight; row++ {
+		y0 := bounds.Min.Y + row*bounds.Dy()/dhashHeight
+		y1 := bounds.Min.Y + (row+1)*bounds.Dy()/dhashHeight
+		for col := 0; col <= dhashWidth; col++ {
+			x0 := bounds.Min.X + col*bounds.Dx()/(dhashWidth+1)
+			x1 := bounds.Min.X + (col+1)*bounds.Dx()/(dhashWidth+1)
+			grid[row][col] = averageLuminance(img, x0, y0, x1, y1)
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < dhashHeight; row++ {
+		for col := 0; col < dhashWidth; col++ {
+			hash <<= 1
+			if grid[row][col] > grid[row][col+1] {
+				hash |= 1
+			}
+		}
+	}
+	if hash == 0 {
+		return 0, false
+	}
+
+	return int64(hash), true
+}
+
+// averageLuminance samples the mean gray level of the cell [x0,x1) x [y0,y1)
+func averageLuminance(img image.Image, x0, y0, x1, y1 int) float64 {
+	stepX := max(1, (x1-x0)/dhashSamples)
+	stepY := max(1, (y1-y0)/dhashSamples)
+
+	var total float64
+	var count int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			total += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// FindDuplicateImage finds the owner's live image with the same content hash,
+// falling back to the same perceptual hash. Exact copies win over re-encodes.
+func (s *DBStore) FindDuplicateImage(ctx context.Context, userID *string, vendorID *string, imageType ImageType, contentHash string, perceptualHash *int64) (Image, bool, error) {
+	query := `
+		SELECT id
+		FROM images
+		WHERE deleted_at IS NULL
+		  AND type = $1
+		  AND user_id IS NOT DISTINCT FROM $2
+		  AND vendor_id IS NOT DISTINCT FROM $3
+		  AND (content_hash = $4 OR perceptual_hash = $5)
+		ORDER BY COALESCE(content_hash = $4, false) DESC, created_at
+		LIMIT 1`
+
+	var imageID string
+	err := s.db.QueryRowContext(ctx, query, imageType, userID, vendorID, contentHash, perceptualHash).Scan(&imageID)
+	if err == sql.ErrNoRows {
+		return Image{}, false, nil
+	}
+	if err != nil {
+		return Image{}, false, fmt.Errorf("failed to find duplicate image: %w", err)
+	}
+
+	image, err := s.GetImage(ctx, imageID)
+	if err != nil {
+		return Image{}, false, err
+	}
+	return image, true, nil
+}
+
+// IsVendorDedupEnabled reports whether the vendor's uploads are deduplicated
+func (s *DBStore) IsVendorDedupEnabled(ctx context.Context, vendorID string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRowContext(ctx, `SELECT image_dedupe_enabled FROM vendors WHERE id = $1`, vendorID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("vendor not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get vendor dedup setting: %w", err)
+	}
+	return enabled, nil
+}
+
+// SetVendorDedupEnabled updates the vendor's deduplication setting
+func (s *DBStore) SetVendorDedupEnabled(ctx context.Context, vendorID string, enabled bool) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE vendors SET image_dedupe_enabled = $2 WHERE id = $1`, vendorID, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to update vendor dedup setting: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("vendor not found")
+	}
+	return nil
+}
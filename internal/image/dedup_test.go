@@ -0,0 +1,156 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// mockDedupStore finds duplicates among images seeded by content hash
+type mockDedupStore struct {
+	byContentHash map[string]Image
+	vendorEnabled map[string]bool
+}
+
+func newMockDedupStore() *mockDedupStore {
+	return &mockDedupStore{byContentHash: make(map[string]Image), vendorEnabled: make(map[string]bool)}
+}
+
+func (m *mockDedupStore) FindDuplicateImage(ctx context.Context, userID *string, vendorID *string, imageType ImageType, contentHash string, perceptualHash *int64) (Image, bool, error) {
+	image, exists := m.byContentHash[contentHash]
+	if !exists || image.Type != imageType || !sameOwner(image.UserID, userID) || !sameOwner(image.VendorID, vendorID) {
+		return Image{}, false, nil
+	}
+	return image, true, nil
+}
+
+func (m *mockDedupStore) IsVendorDedupEnabled(ctx context.Context, vendorID string) (bool, error) {
+	enabled, exists := m.vendorEnabled[vendorID]
+	return !exists || enabled, nil
+}
+
+func (m *mockDedupStore) SetVendorDedupEnabled(ctx context.Context, vendorID string, enabled bool) error {
+	m.vendorEnabled[vendorID] = enabled
+	return nil
+}
+
+func sameOwner(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func newDedupTestService(store *mockStore, dedup DedupStore) *Service {
+	service := NewService(
+		store,
+		&mockFileStorage{},
+		&mockImageProcessor{},
+		&mockUsageTracker{},
+		&mockCache{},
+		&mockNotificationService{},
+		&mockAuditLogger{},
+		&mockRateLimiter{},
+		StorageConfig{
+			MaxFileSize:  10 * 1024 * 1024,
+			AllowedTypes: []string{"image/jpeg", "image/png"},
+		},
+	)
+	service.SetDedup(dedup)
+	return service
+}
+
+func TestUploadImageReturnsDuplicate(t *testing.T) {
+	store := newMockStore()
+	dedup := newMockDedupStore()
+	service := newDedupTestService(store, dedup)
+
+	data := []byte("same file contents")
+	userID := "user-1"
+	existing := Image{ID: "existing", UserID: &userID, Type: ImageTypeUser}
+	dedup.byContentHash[computeImageHashes(data).content] = existing
+
+	upload := func(userID, vendorID *string, imageType ImageType) Image {
+		t.Helper()
+		image, err := service.UploadImage(context.Background(), userID, vendorID, UploadImageRequest{
+			Type:     imageType,
+			FileName: "photo.jpg",
+			FileSize: int64(len(data)),
+			MimeType: "image/jpeg",
+			File:     bytes.NewReader(data),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return image
+	}
+
+	image := upload(&userID, nil, ImageTypeUser)
+	if image.ID != "existing" || !image.Deduplicated {
+		t.Errorf("Expected existing image to be returned, got %s (deduplicated=%v)", image.ID, image.Deduplicated)
+	}
+	if len(store.images) != 0 {
+		t.Errorf("Expected no new image to be stored, got %d", len(store.images))
+	}
+
+	otherUser := "user-2"
+	image = upload(&otherUser, nil, ImageTypeUser)
+	if image.Deduplicated {
+		t.Error("Expected another user's upload not to be deduplicated")
+	}
+
+	vendorID := "vendor-1"
+	dedup.byContentHash[computeImageHashes(data).content] = Image{ID: "vendor-existing", VendorID: &vendorID, Type: ImageTypeVendor}
+	if image := upload(nil, &vendorID, ImageTypeVendor); image.ID != "vendor-existing" {
+		t.Errorf("Expected vendor duplicate to be returned, got %s", image.ID)
+	}
+
+	if _, err := service.UpdateVendorDedupSettings(context.Background(), vendorID, DedupSettings{Enabled: false}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if image := upload(nil, &vendorID, ImageTypeVendor); image.Deduplicated {
+		t.Error("Expected upload not to be deduplicated after the vendor opted out")
+	}
+}
+
+func TestPerceptualHashMatchesReencodedImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 90, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 90; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 2), G: uint8((x * y) % 256), B: uint8(255 - y*3), A: 255})
+		}
+	}
+
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if err := jpeg.Encode(&jpegData, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+
+	pngHashes := computeImageHashes(pngData.Bytes())
+	jpegHashes := computeImageHashes(jpegData.Bytes())
+	if pngHashes.content == jpegHashes.content {
+		t.Fatal("Expected different content hashes for different encodings")
+	}
+	if pngHashes.perceptual == nil || jpegHashes.perceptual == nil {
+		t.Fatal("Expected perceptual hashes for decodable images")
+	}
+	if *pngHashes.perceptual != *jpegHashes.perceptual {
+		t.Errorf("Expected re-encoded image to keep its perceptual hash, got %x and %x", *pngHashes.perceptual, *jpegHashes.perceptual)
+	}
+
+	flat := image.NewGray(image.Rect(0, 0, 20, 20))
+	var flatData bytes.Buffer
+	if err := png.Encode(&flatData, flat); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if hashes := computeImageHashes(flatData.Bytes()); hashes.perceptual != nil {
+		t.Error("Expected flat image not to get a perceptual hash")
+	}
+}
@@ -2,7 +2,9 @@ package image
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -80,7 +82,91 @@ func (h *Handler) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.WriteJSON(w, http.StatusCreated, image)
+	// Re-uploads of an existing image return it without creating a new one
+	if image.Deduplicated {
+		common.WriteJSON(w, http.StatusOK, h.service.WithCDNURLs(r.Context(), image))
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, h.service.WithCDNURLs(r.Context(), image))
+}
+
+// PresignUpload handles POST /images/presign
+func (h *Handler) PresignUpload(w http.ResponseWriter, r *http.Request) {
+	userID := common.GetUserIDFromContext(r.Context())
+	vendorID := common.GetVendorIDFromContext(r.Context())
+
+	var req PresignUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid JSON", nil)
+		return
+	}
+
+	response, err := h.service.PresignUpload(r.Context(), &userID, &vendorID, req)
+	if err != nil {
+		if errors.Is(err, ErrDirectUploadsDisabled) {
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+			return
+		}
+		h.writeUploadError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, response)
+}
+
+// ConfirmUpload handles POST /images/confirm
+func (h *Handler) ConfirmUpload(w http.ResponseWriter, r *http.Request) {
+	userID := common.GetUserIDFromContext(r.Context())
+	vendorID := common.GetVendorIDFromContext(r.Context())
+
+	var req ConfirmUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UploadID == "" {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "uploadId is required", nil)
+		return
+	}
+
+	image, err := h.service.ConfirmUpload(r.Context(), &userID, &vendorID, req.UploadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDirectUploadsDisabled), errors.Is(err, ErrUploadNotFound):
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+		case errors.Is(err, ErrUploadNotPending), errors.Is(err, ErrUploadMissing):
+			common.WriteError(w, http.StatusConflict, "conflict", err.Error(), nil)
+		default:
+			h.writeUploadError(w, err)
+		}
+		return
+	}
+
+	// Re-uploads of an existing image return it without creating a new one
+	if image.Deduplicated {
+		common.WriteJSON(w, http.StatusOK, h.service.WithCDNURLs(r.Context(), image))
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, h.service.WithCDNURLs(r.Context(), image))
+}
+
+// writeUploadError writes the response of a failed upload
+func (h *Handler) writeUploadError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "rate limit") {
+		common.WriteError(w, http.StatusTooManyRequests, "rate_limit", err.Error(), nil)
+		return
+	}
+	if strings.Contains(err.Error(), "quota exceeded") {
+		common.WriteError(w, http.StatusForbidden, "quota_exceeded", "You have exceeded your free gallery upload limit. Please upgrade your plan to continue.", map[string]interface{}{
+			"remaining_free":   0,
+			"upgrade_required": true,
+			"upgrade_url":      "/plans",
+		})
+		return
+	}
+	if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "too long") || strings.Contains(err.Error(), "too large") || strings.Contains(err.Error(), "unsupported") || strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "must be positive") || strings.Contains(err.Error(), "too many") {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", err.Error(), nil)
+		return
+	}
+	common.WriteError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("failed to upload image: %v", err), nil)
 }
 
 // GetImage handles GET /images/:id
@@ -100,8 +186,16 @@ func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) {
 		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get image", nil)
 		return
 	}
+	if err := h.service.checkViewer(r.Context(), common.GetUserIDFromContext(r.Context()), imageID); err != nil {
+		if errors.Is(err, ErrImageAccessDenied) {
+			common.WriteError(w, http.StatusForbidden, "forbidden", err.Error(), nil)
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get image", nil)
+		return
+	}
 
-	common.WriteJSON(w, http.StatusOK, image)
+	common.WriteJSON(w, http.StatusOK, h.service.WithCDNURLs(r.Context(), image))
 }
 
 // UpdateImage handles PUT /images/:id
@@ -132,7 +226,7 @@ func (h *Handler) UpdateImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.WriteJSON(w, http.StatusOK, image)
+	common.WriteJSON(w, http.StatusOK, h.service.WithCDNURLs(r.Context(), image))
 }
 
 // DeleteImage handles DELETE /images/:id
@@ -165,19 +259,28 @@ func (h *Handler) ListImages(w http.ResponseWriter, r *http.Request) {
 	req := parseImageListRequest(r)
 
 	// If user/vendor is authenticated, filter by their images by default
-	// (unless explicitly requested otherwise via query params)
-	if userID != "" && req.UserID == nil {
-		req.UserID = &userID
-	}
-	if vendorID != "" && req.VendorID == nil {
-		req.VendorID = &vendorID
+	// (unless explicitly requested otherwise via query params). Listing an
+	// album shows the images of albums shared with the user as well.
+	if req.AlbumID == nil {
+		if userID != "" && req.UserID == nil {
+			req.UserID = &userID
+		}
+		if vendorID != "" && req.VendorID == nil {
+			req.VendorID = &vendorID
+		}
 	}
+	req.ViewerID = userID
 
 	response, err := h.service.ListImages(r.Context(), req)
+	if errors.Is(err, common.ErrValidation) {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid cursor", nil)
+		return
+	}
 	if err != nil {
 		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to list images", nil)
 		return
 	}
+	response.Images = h.service.WithCDNURLsAll(r.Context(), response.Images)
 
 	common.WriteJSON(w, http.StatusOK, response)
 }
@@ -206,8 +309,12 @@ func (h *Handler) GenerateSignedURL(w http.ResponseWriter, r *http.Request) {
 		req.AccessType = AccessTypeView
 	}
 
-	response, err := h.service.GenerateSignedURL(r.Context(), imageID, req.AccessType)
+	response, err := h.service.GenerateSignedURL(r.Context(), common.GetUserIDFromContext(r.Context()), imageID, req.AccessType)
 	if err != nil {
+		if errors.Is(err, ErrImageAccessDenied) {
+			common.WriteError(w, http.StatusForbidden, "forbidden", err.Error(), nil)
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			common.WriteError(w, http.StatusNotFound, "not_found", "image not found", nil)
 			return
@@ -237,6 +344,180 @@ func (h *Handler) GetImageUsageHistory(w http.ResponseWriter, r *http.Request) {
 	common.WriteJSON(w, http.StatusOK, response)
 }
 
+// ListTrash handles GET /vendor/images/trash
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	req := parseTrashListRequest(r)
+	response, err := h.service.ListTrashedImages(r.Context(), vendorID, req)
+	if err != nil {
+		if errors.Is(err, ErrTrashDisabled) {
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to list trashed images", nil)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, response)
+}
+
+// RestoreImage handles POST /vendor/images/:id/restore
+func (h *Handler) RestoreImage(w http.ResponseWriter, r *http.Request) {
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	imageID := getImageIDFromPath(r.URL.Path)
+	if imageID == "" {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "image ID required", nil)
+		return
+	}
+
+	image, err := h.service.RestoreImage(r.Context(), vendorID, imageID)
+	if err != nil {
+		if errors.Is(err, ErrImageNotInTrash) || errors.Is(err, ErrTrashDisabled) {
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to restore image", nil)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, h.service.WithCDNURLs(r.Context(), image))
+}
+
+// GetDedupSettings handles GET /vendor/images/dedup
+func (h *Handler) GetDedupSettings(w http.ResponseWriter, r *http.Request) {
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	settings, err := h.service.GetVendorDedupSettings(r.Context(), vendorID)
+	if err != nil {
+		if errors.Is(err, ErrDedupDisabled) || strings.Contains(err.Error(), "not found") {
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to get dedup settings", nil)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, settings)
+}
+
+// UpdateDedupSettings handles PUT /vendor/images/dedup
+func (h *Handler) UpdateDedupSettings(w http.ResponseWriter, r *http.Request) {
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	var req DedupSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid JSON", nil)
+		return
+	}
+
+	settings, err := h.service.UpdateVendorDedupSettings(r.Context(), vendorID, req)
+	if err != nil {
+		if errors.Is(err, ErrDedupDisabled) || strings.Contains(err.Error(), "not found") {
+			common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, "server_error", "failed to update dedup settings", nil)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, settings)
+}
+
+// ImportImages handles POST /vendors/me/images/import. It takes a ZIP archive
+// as the multipart "file" field or a JSON body with the URLs to import.
+func (h *Handler) ImportImages(w http.ResponseWriter, r *http.Request) {
+	userID := common.GetUserIDFromContext(r.Context())
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	var req ImportRequest
+	var archive []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, h.service.importArchiveLimit()+1<<20)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			common.WriteError(w, http.StatusBadRequest, "bad_request", "failed to parse multipart form", nil)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			common.WriteError(w, http.StatusBadRequest, "bad_request", "file is required", nil)
+			return
+		}
+		defer file.Close()
+
+		if archive, err = io.ReadAll(file); err != nil {
+			common.WriteError(w, http.StatusBadRequest, "bad_request", "failed to read file", nil)
+			return
+		}
+		req.IsPublic, _ = strconv.ParseBool(r.FormValue("isPublic"))
+		req.AutoTag, _ = strconv.ParseBool(r.FormValue("autoTag"))
+		if tagsStr := r.FormValue("tags"); tagsStr != "" {
+			req.Tags = strings.Split(tagsStr, ",")
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, http.StatusBadRequest, "bad_request", "invalid JSON", nil)
+		return
+	}
+
+	imp, err := h.service.RequestImport(r.Context(), userID, vendorID, req, archive)
+	if err != nil {
+		h.writeImportError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusAccepted, imp)
+}
+
+// GetImport handles GET /vendors/me/images/imports/{id}
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	vendorID := common.GetVendorIDFromContext(r.Context())
+	if vendorID == "" {
+		common.WriteError(w, http.StatusForbidden, "forbidden", "vendor account required", nil)
+		return
+	}
+
+	imp, err := h.service.GetImport(r.Context(), vendorID, r.PathValue("id"))
+	if err != nil {
+		h.writeImportError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, imp)
+}
+
+// writeImportError writes the response of a failed import request
+func (h *Handler) writeImportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrImportsUnavailable):
+		common.WriteError(w, http.StatusNotFound, "not_found", err.Error(), nil)
+	case strings.Contains(err.Error(), "rate limit"):
+		common.WriteError(w, http.StatusTooManyRequests, "rate_limit", err.Error(), nil)
+	default:
+		common.WriteAPIError(w, common.FromError(err, http.StatusInternalServerError))
+	}
+}
+
 // GetQuotaStatus handles GET /quota
 func (h *Handler) GetQuotaStatus(w http.ResponseWriter, r *http.Request) {
 	userID := common.GetUserIDFromContext(r.Context())
@@ -349,6 +630,33 @@ func parseImageListRequest(r *http.Request) ImageListRequest {
 		req.VendorID = &vendorID
 	}
 
+	if albumID := r.URL.Query().Get("albumId"); albumID != "" {
+		req.AlbumID = &albumID
+	}
+
+	req.Cursor = r.URL.Query().Get("cursor")
+
+	return req
+}
+
+func parseTrashListRequest(r *http.Request) TrashListRequest {
+	req := TrashListRequest{
+		Page:     1,
+		PageSize: 20,
+	}
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			req.Page = page
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
+			req.PageSize = pageSize
+		}
+	}
+
 	return req
 }
 
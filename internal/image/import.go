@@ -0,0 +1,824 @@
+package image
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Image import statuses
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+// Image import sources
+const (
+	ImportSourceArchive = "zip"
+	ImportSourceURLs    = "urls"
+)
+
+// Outcomes of the files of an import
+const (
+	ImportResultImported  = "imported"
+	ImportResultDuplicate = "duplicate"
+	ImportResultFailed    = "failed"
+)
+
+// Image import defaults
+const (
+	DefaultImportMaxFiles       = 200
+	DefaultImportMaxArchiveSize = 100 << 20
+	DefaultImportFetchTimeout   = 30 * time.Second
+	DefaultImportPollInterval   = 10 * time.Second
+
+	// importStaleAfter is how long an import may stay processing before
+	// another instance takes it over
+	importStaleAfter = 30 * time.Minute
+	// importArchivePath is the storage directory archives wait in until
+	// they are imported; it is outside the directories swept for orphans
+	importArchivePath = "imports"
+	// importRateLimit bounds the imports a vendor may start per hour
+	importRateLimit = 10
+	// importMaxRedirects bounds the redirects followed when fetching a URL
+	importMaxRedirects = 3
+	// Limits of upload tags, see validateUploadRequest
+	importMaxTags      = 20
+	importMaxTagLength = 50
+)
+
+var (
+	// ErrImportsUnavailable is returned when image imports are not configured
+	ErrImportsUnavailable = errors.New("image imports are not available")
+	// ErrImportNotFound is returned for unknown imports and imports of other vendors
+	ErrImportNotFound = fmt.Errorf("import %w", common.ErrNotFound)
+
+	// errPrivateAddress is returned when an import URL resolves to a
+	// loopback, private or link-local address
+	errPrivateAddress = errors.New("address is not public")
+)
+
+// ImageImport is a batch of vendor images imported in the background from a
+// ZIP archive or a list of public URLs
+type ImageImport struct {
+	ID          string         `json:"id"`
+	VendorID    string         `json:"vendorId"`
+	UserID      string         `json:"-"` // the vendor's user, notified when done
+	Source      string         `json:"source"`
+	Status      string         `json:"status"`
+	URLs        []string       `json:"urls,omitempty"`
+	ArchivePath string         `json:"-"`
+	IsPublic    bool           `json:"isPublic"`
+	Tags        []string       `json:"tags"`
+	AutoTag     bool           `json:"autoTag"`
+	Total       int            `json:"total"`
+	Imported    int            `json:"imported"`
+	Duplicates  int            `json:"duplicates"`
+	Failed      int            `json:"failed"`
+	Results     []ImportResult `json:"results"`
+	Error       *string        `json:"error,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	CompletedAt *time.Time     `json:"completedAt,omitempty"`
+}
+
+// ImportResult is the outcome of one file of an import
+type ImportResult struct {
+	Source  string   `json:"source"` // path in the archive or URL
+	Status  string   `json:"status"`
+	ImageID string   `json:"imageId,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ImportRequest starts an import. URLs is used when no archive is uploaded.
+type ImportRequest struct {
+	URLs     []string `json:"urls"`
+	IsPublic bool     `json:"isPublic"`
+	Tags     []string `json:"tags"`
+	AutoTag  bool     `json:"autoTag"` // add the tags suggested by the import tagger
+}
+
+// ImportStore stores image imports
+type ImportStore interface {
+	CreateImport(ctx context.Context, imp ImageImport) (ImageImport, error)
+	GetImport(ctx context.Context, importID string) (ImageImport, error)
+	// ClaimImport marks the oldest pending import, or one processing for
+	// longer than staleAfter, as processing and returns it; nil when none
+	ClaimImport(ctx context.Context, staleAfter time.Duration) (*ImageImport, error)
+	CompleteImport(ctx context.Context, imp ImageImport) error
+	FailImport(ctx context.Context, importID, message string) error
+}
+
+// ImportNotifier tells vendors their import finished
+type ImportNotifier interface {
+	SendImageImportCompleted(ctx context.Context, userID, importID string, imported, failed int) error
+	SendImageImportFailed(ctx context.Context, userID, importID string) error
+}
+
+// ImportFetcher downloads the image behind an import URL
+type ImportFetcher interface {
+	// Fetch returns the body of rawURL, failing when it is larger than maxSize
+	Fetch(ctx context.Context, rawURL string, maxSize int64) ([]byte, error)
+}
+
+// ImportTagger suggests tags for an imported image
+type ImportTagger interface {
+	SuggestTags(ctx context.Context, fileName string, data []byte) ([]string, error)
+}
+
+// ImportConfig configures image imports
+type ImportConfig struct {
+	MaxFiles       int           // files accepted per import
+	MaxArchiveSize int64         // largest accepted ZIP archive in bytes
+	FetchTimeout   time.Duration // timeout of each URL download
+	PollInterval   time.Duration // how often the worker looks for queued imports
+}
+
+// importer runs image imports in the background
+type importer struct {
+	store    ImportStore
+	notifier ImportNotifier
+	fetcher  ImportFetcher
+	tagger   ImportTagger
+	config   ImportConfig
+	wake     chan struct{}
+}
+
+// SetImports enables bulk imports of vendor images. Imports are run by
+// StartImportWorker; auto-tagging suggests the words of file names and the
+// image orientation until SetImportTagger replaces the tagger.
+func (s *Service) SetImports(store ImportStore, notifier ImportNotifier, config ImportConfig) {
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = DefaultImportMaxFiles
+	}
+	if config.MaxArchiveSize <= 0 {
+		config.MaxArchiveSize = DefaultImportMaxArchiveSize
+	}
+	if config.FetchTimeout <= 0 {
+		config.FetchTimeout = DefaultImportFetchTimeout
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultImportPollInterval
+	}
+	s.imports = &importer{
+		store:    store,
+		notifier: notifier,
+		fetcher:  newHTTPImportFetcher(config.FetchTimeout),
+		tagger:   fileNameTagger{},
+		config:   config,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// SetImportTagger replaces the tagger used by auto-tagging imports
+func (s *Service) SetImportTagger(tagger ImportTagger) {
+	if s.imports != nil {
+		s.imports.tagger = tagger
+	}
+}
+
+// importArchiveLimit returns the size of the largest archive accepted by imports
+func (s *Service) importArchiveLimit() int64 {
+	if s.imports == nil {
+		return DefaultImportMaxArchiveSize
+	}
+	return s.imports.config.MaxArchiveSize
+}
+
+// RequestImport queues an import of vendor images from archive, a ZIP file,
+// or from req.URLs when archive is nil
+func (s *Service) RequestImport(ctx context.Context, userID, vendorID string, req ImportRequest, archive []byte) (ImageImport, error) {
+	if s.imports == nil {
+		return ImageImport{}, ErrImportsUnavailable
+	}
+	im := s.imports
+
+	if vendorID == "" {
+		return ImageImport{}, fmt.Errorf("%w: vendor account required", common.ErrValidation)
+	}
+	tags, err := normalizeImportTags(req.Tags)
+	if err != nil {
+		return ImageImport{}, err
+	}
+
+	imp := ImageImport{
+		VendorID: vendorID,
+		UserID:   userID,
+		Status:   ImportStatusPending,
+		IsPublic: req.IsPublic,
+		Tags:     tags,
+		AutoTag:  req.AutoTag,
+	}
+	switch {
+	case archive != nil && len(req.URLs) > 0:
+		return ImageImport{}, fmt.Errorf("%w: send either an archive or urls", common.ErrValidation)
+	case archive != nil:
+		imp.Source = ImportSourceArchive
+		imp.Total, err = im.countArchiveFiles(archive)
+	default:
+		imp.Source = ImportSourceURLs
+		imp.URLs, err = im.validateURLs(req.URLs)
+		imp.Total = len(imp.URLs)
+	}
+	if err != nil {
+		return ImageImport{}, err
+	}
+
+	rateLimitKey := fmt.Sprintf("image_import:vendor:%s", vendorID)
+	if !s.rateLimiter.Allow(ctx, rateLimitKey, importRateLimit, int64(time.Hour.Seconds())) {
+		return ImageImport{}, errors.New("rate limit exceeded for image import")
+	}
+
+	// The archive waits in storage rather than the database until it is imported
+	if archive != nil {
+		imp.ArchivePath, err = s.fileStorage.UploadFile(ctx, archive, "import.zip", importArchivePath+"/"+vendorID)
+		if err != nil {
+			return ImageImport{}, fmt.Errorf("failed to store import archive: %w", err)
+		}
+	}
+
+	created, err := im.store.CreateImport(ctx, imp)
+	if err != nil {
+		if imp.ArchivePath != "" {
+			_ = s.fileStorage.DeleteFile(ctx, imp.ArchivePath)
+		}
+		return ImageImport{}, err
+	}
+
+	// Wake the worker rather than waiting for the next poll
+	select {
+	case im.wake <- struct{}{}:
+	default:
+	}
+	return created, nil
+}
+
+// GetImport returns an import of the vendor with its per-file results
+func (s *Service) GetImport(ctx context.Context, vendorID, importID string) (ImageImport, error) {
+	if s.imports == nil {
+		return ImageImport{}, ErrImportsUnavailable
+	}
+	if _, err := uuid.Parse(importID); err != nil {
+		return ImageImport{}, ErrImportNotFound
+	}
+
+	imp, err := s.imports.store.GetImport(ctx, importID)
+	if err != nil {
+		return ImageImport{}, err
+	}
+	if imp.VendorID != vendorID {
+		return ImageImport{}, ErrImportNotFound
+	}
+	return imp, nil
+}
+
+// StartImportWorker runs queued imports until ctx is cancelled
+func (s *Service) StartImportWorker(ctx context.Context) {
+	if s.imports == nil {
+		return
+	}
+	im := s.imports
+
+	ticker := time.NewTicker(im.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processQueuedImports(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-im.wake:
+		}
+	}
+}
+
+// processQueuedImports runs imports until none is left to claim
+func (s *Service) processQueuedImports(ctx context.Context) {
+	for ctx.Err() == nil {
+		imp, err := s.imports.store.ClaimImport(ctx, importStaleAfter)
+		if err != nil {
+			log.Printf("Image imports: failed to claim an import: %v", err)
+			return
+		}
+		if imp == nil {
+			return
+		}
+		s.processImport(ctx, *imp)
+	}
+}
+
+// processImport imports the files of one import and notifies its vendor
+func (s *Service) processImport(ctx context.Context, imp ImageImport) {
+	im := s.imports
+
+	done, err := s.runImport(ctx, imp)
+	if imp.ArchivePath != "" && ctx.Err() == nil {
+		if err := s.fileStorage.DeleteFile(ctx, imp.ArchivePath); err != nil {
+			log.Printf("Image imports: failed to delete archive of %s: %v", imp.ID, err)
+		}
+	}
+	if err != nil {
+		log.Printf("Image imports: import %s failed: %v", imp.ID, err)
+		if err := im.store.FailImport(ctx, imp.ID, "failed to read the import"); err != nil {
+			log.Printf("Image imports: failed to record failure of %s: %v", imp.ID, err)
+		}
+		if err := im.notifier.SendImageImportFailed(ctx, imp.UserID, imp.ID); err != nil {
+			log.Printf("Image imports: failed to notify %s: %v", imp.UserID, err)
+		}
+		return
+	}
+
+	if err := im.store.CompleteImport(ctx, done); err != nil {
+		log.Printf("Image imports: failed to complete import %s: %v", imp.ID, err)
+		return
+	}
+	if err := im.notifier.SendImageImportCompleted(ctx, done.UserID, done.ID, done.Imported+done.Duplicates, done.Failed); err != nil {
+		log.Printf("Image imports: failed to notify %s: %v", imp.UserID, err)
+	}
+}
+
+// runImport imports every file of an import and records its outcome
+func (s *Service) runImport(ctx context.Context, imp ImageImport) (ImageImport, error) {
+	imp.Results = []ImportResult{}
+	add := func(result ImportResult) {
+		switch result.Status {
+		case ImportResultImported:
+			imp.Imported++
+		case ImportResultDuplicate:
+			imp.Duplicates++
+		default:
+			imp.Failed++
+		}
+		imp.Results = append(imp.Results, result)
+	}
+
+	switch imp.Source {
+	case ImportSourceArchive:
+		archive, err := s.fileStorage.GetFile(ctx, imp.ArchivePath)
+		if err != nil {
+			return ImageImport{}, err
+		}
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return ImageImport{}, fmt.Errorf("failed to open archive: %w", err)
+		}
+		for _, file := range importableFiles(reader, s.imports.config.MaxFiles) {
+			data, err := readArchiveFile(file, s.maxUploadSize())
+			if err != nil {
+				add(ImportResult{Source: file.Name, Status: ImportResultFailed, Error: err.Error()})
+				continue
+			}
+			add(s.importFile(ctx, imp, file.Name, path.Base(file.Name), data))
+		}
+	case ImportSourceURLs:
+		for _, rawURL := range imp.URLs {
+			data, err := s.imports.fetcher.Fetch(ctx, rawURL, s.maxUploadSize())
+			if err != nil {
+				add(ImportResult{Source: rawURL, Status: ImportResultFailed, Error: err.Error()})
+				continue
+			}
+			add(s.importFile(ctx, imp, rawURL, urlFileName(rawURL), data))
+		}
+	default:
+		return ImageImport{}, fmt.Errorf("unknown import source %q", imp.Source)
+	}
+
+	now := time.Now()
+	imp.Status = ImportStatusCompleted
+	imp.CompletedAt = &now
+	return imp, nil
+}
+
+// importFile validates, tags and stores one file like a vendor upload
+func (s *Service) importFile(ctx context.Context, imp ImageImport, source, fileName string, data []byte) ImportResult {
+	result := ImportResult{Source: source, Status: ImportResultFailed}
+
+	// The extension only decides when the content does not tell the type
+	mimeType := http.DetectContentType(data)
+	if mimeType == "application/octet-stream" {
+		mimeType = getMimeTypeFromExtension(fileName)
+	}
+	if path.Ext(fileName) == "" {
+		fileName += extensionForMimeType(mimeType)
+	}
+
+	tags := imp.Tags
+	if imp.AutoTag && s.imports.tagger != nil {
+		suggested, err := s.imports.tagger.SuggestTags(ctx, fileName, data)
+		if err != nil {
+			log.Printf("Image imports: failed to tag %s of %s: %v", source, imp.ID, err)
+		}
+		// Suggestions only fill the room left by the vendor's own tags
+		if tags = mergeTags(tags, suggested); len(tags) > importMaxTags {
+			tags = tags[:importMaxTags]
+		}
+	}
+
+	req := UploadImageRequest{
+		Type:     ImageTypeVendor,
+		FileName: fileName,
+		FileSize: int64(len(data)),
+		MimeType: mimeType,
+		IsPublic: imp.IsPublic,
+		Tags:     tags,
+		Metadata: map[string]interface{}{"importId": imp.ID, "importSource": source},
+	}
+	if err := s.validateUploadRequest(req); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	vendorID := imp.VendorID
+	stored, err := s.storeImage(ctx, req, ImageTypeVendor, nil, &vendorID, data)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = ImportResultImported
+	if stored.Deduplicated {
+		result.Status = ImportResultDuplicate
+	}
+	result.ImageID = stored.ID
+	result.Tags = tags
+	return result
+}
+
+// countArchiveFiles checks an uploaded archive and counts the files it imports
+func (im *importer) countArchiveFiles(archive []byte) (int, error) {
+	if int64(len(archive)) > im.config.MaxArchiveSize {
+		return 0, fmt.Errorf("%w: archive is larger than %d MB", common.ErrValidation, im.config.MaxArchiveSize>>20)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return 0, fmt.Errorf("%w: archive is not a valid ZIP file", common.ErrValidation)
+	}
+
+	files := importableFiles(reader, im.config.MaxFiles+1)
+	switch {
+	case len(files) == 0:
+		return 0, fmt.Errorf("%w: archive contains no files", common.ErrValidation)
+	case len(files) > im.config.MaxFiles:
+		return 0, fmt.Errorf("%w: at most %d files can be imported at once", common.ErrValidation, im.config.MaxFiles)
+	}
+	return len(files), nil
+}
+
+// validateURLs checks the URLs of an import and drops repeated ones
+func (im *importer) validateURLs(rawURLs []string) ([]string, error) {
+	urls := make([]string, 0, len(rawURLs))
+	seen := make(map[string]bool, len(rawURLs))
+	for _, rawURL := range rawURLs {
+		rawURL = strings.TrimSpace(rawURL)
+		if seen[rawURL] {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return nil, fmt.Errorf("%w: invalid URL %q", common.ErrValidation, rawURL)
+		}
+		seen[rawURL] = true
+		urls = append(urls, rawURL)
+	}
+
+	switch {
+	case len(urls) == 0:
+		return nil, fmt.Errorf("%w: an archive or urls are required", common.ErrValidation)
+	case len(urls) > im.config.MaxFiles:
+		return nil, fmt.Errorf("%w: at most %d files can be imported at once", common.ErrValidation, im.config.MaxFiles)
+	}
+	return urls, nil
+}
+
+// importableFiles returns up to limit regular files of an archive, leaving
+// out directories and the hidden files added by archivers
+func importableFiles(reader *zip.Reader, limit int) []*zip.File {
+	var files []*zip.File
+	for _, file := range reader.File {
+		if len(files) == limit {
+			break
+		}
+		base := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// readArchiveFile reads a file of an archive, refusing to inflate more than
+// maxSize bytes whatever size its header claims
+func readArchiveFile(file *zip.File, maxSize int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(maxSize) {
+		return nil, errors.New("file size too large")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("file size too large")
+	}
+	return data, nil
+}
+
+// normalizeImportTags trims the tags of an import and checks their limits
+func normalizeImportTags(tags []string) ([]string, error) {
+	normalized := mergeTags(nil, tags)
+	if len(normalized) > importMaxTags {
+		return nil, fmt.Errorf("%w: too many tags", common.ErrValidation)
+	}
+	for _, tag := range normalized {
+		if len(tag) > importMaxTagLength {
+			return nil, fmt.Errorf("%w: tag too long", common.ErrValidation)
+		}
+	}
+	return normalized, nil
+}
+
+// mergeTags appends the tags of more missing from tags, ignoring case
+func mergeTags(tags, more []string) []string {
+	merged := make([]string, 0, len(tags)+len(more))
+	seen := make(map[string]bool, len(tags)+len(more))
+	for _, tag := range append(append([]string{}, tags...), more...) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+// urlFileName derives a file name from the path of an import URL
+func urlFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "image"
+	}
+	if base := path.Base(u.Path); base != "." && base != "/" {
+		return base
+	}
+	return "image"
+}
+
+// extensionForMimeType returns the file extension of a supported image type
+func extensionForMimeType(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
+// fileNameTagger suggests the words of a file name, such as "red" and
+// "dress" for red-summer_dress-01.jpg, and the orientation of the image
+type fileNameTagger struct{}
+
+// fileNameStopWords are file name words that say nothing about the image
+var fileNameStopWords = map[string]bool{
+	"img": true, "image": true, "photo": true, "pic": true, "dsc": true, "copy": true,
+	"final": true, "edit": true, "edited": true, "scan": true, "untitled": true,
+	"jpg": true, "jpeg": true, "png": true, "webp": true, "gif": true,
+}
+
+// SuggestTags implements ImportTagger
+func (fileNameTagger) SuggestTags(ctx context.Context, fileName string, data []byte) ([]string, error) {
+	name := strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	var tags []string
+	for _, word := range words {
+		if len([]rune(word)) >= 3 && !fileNameStopWords[word] {
+			tags = append(tags, word)
+		}
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return tags, nil
+	}
+	switch {
+	case config.Width*10 > config.Height*11:
+		tags = append(tags, "landscape")
+	case config.Height*10 > config.Width*11:
+		tags = append(tags, "portrait")
+	default:
+		tags = append(tags, "square")
+	}
+	return tags, nil
+}
+
+// httpImportFetcher downloads import URLs, refusing addresses that are not
+// public so imports cannot reach internal services
+type httpImportFetcher struct {
+	client *http.Client
+}
+
+// newHTTPImportFetcher creates a fetcher whose downloads time out after timeout
+func newHTTPImportFetcher(timeout time.Duration) *httpImportFetcher {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: rejectPrivateAddress}
+	return &httpImportFetcher{client: &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= importMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}}
+}
+
+// Fetch implements ImportFetcher
+func (f *httpImportFetcher) Fetch(ctx context.Context, rawURL string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, errors.New("file size too large")
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("file size too large")
+	}
+	return data, nil
+}
+
+// rejectPrivateAddress refuses connections to addresses that are not
+// public. It runs after name resolution, so DNS cannot be used to get around it.
+func rejectPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// importColumns are the image_imports columns scanned by scanImport
+const importColumns = `id, vendor_id, user_id, source, status, urls, COALESCE(archive_path, ''), is_public,
+	tags, auto_tag, total, imported, duplicates, failed, results, error_message, created_at, completed_at`
+
+// scanImport scans a row of importColumns
+func scanImport(row interface{ Scan(...interface{}) error }) (ImageImport, error) {
+	var imp ImageImport
+	var results []byte
+	err := row.Scan(&imp.ID, &imp.VendorID, &imp.UserID, &imp.Source, &imp.Status, pq.Array(&imp.URLs),
+		&imp.ArchivePath, &imp.IsPublic, pq.Array(&imp.Tags), &imp.AutoTag, &imp.Total, &imp.Imported,
+		&imp.Duplicates, &imp.Failed, &results, &imp.Error, &imp.CreatedAt, &imp.CompletedAt)
+	if err != nil {
+		return ImageImport{}, err
+	}
+	imp.Results = []ImportResult{}
+	if len(results) > 0 {
+		if err := json.Unmarshal(results, &imp.Results); err != nil {
+			return ImageImport{}, fmt.Errorf("failed to decode import results: %w", err)
+		}
+	}
+	if imp.Tags == nil {
+		imp.Tags = []string{}
+	}
+	return imp, nil
+}
+
+// CreateImport implements ImportStore
+func (s *DBStore) CreateImport(ctx context.Context, imp ImageImport) (ImageImport, error) {
+	var archivePath *string
+	if imp.ArchivePath != "" {
+		archivePath = &imp.ArchivePath
+	}
+	created, err := scanImport(s.db.QueryRowContext(ctx, `
+		INSERT INTO image_imports (vendor_id, user_id, source, urls, archive_path, is_public, tags, auto_tag, total)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+importColumns,
+		imp.VendorID, imp.UserID, imp.Source, pq.Array(imp.URLs), archivePath, imp.IsPublic,
+		pq.Array(imp.Tags), imp.AutoTag, imp.Total))
+	if err != nil {
+		return ImageImport{}, fmt.Errorf("failed to create import: %w", err)
+	}
+	return created, nil
+}
+
+// GetImport implements ImportStore
+func (s *DBStore) GetImport(ctx context.Context, importID string) (ImageImport, error) {
+	imp, err := scanImport(s.db.QueryRowContext(ctx, `
+		SELECT `+importColumns+`
+		FROM image_imports
+		WHERE id = $1`, importID))
+	switch {
+	case err == sql.ErrNoRows:
+		return ImageImport{}, ErrImportNotFound
+	case err != nil:
+		return ImageImport{}, fmt.Errorf("failed to get import: %w", err)
+	}
+	return imp, nil
+}
+
+// ClaimImport implements ImportStore
+func (s *DBStore) ClaimImport(ctx context.Context, staleAfter time.Duration) (*ImageImport, error) {
+	imp, err := scanImport(s.db.QueryRowContext(ctx, `
+		UPDATE image_imports
+		SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM image_imports
+			WHERE status = 'pending'
+			   OR (status = 'processing' AND started_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+importColumns, staleAfter.Seconds()))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim import: %w", err)
+	}
+	return &imp, nil
+}
+
+// CompleteImport implements ImportStore
+func (s *DBStore) CompleteImport(ctx context.Context, imp ImageImport) error {
+	results, err := json.Marshal(imp.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode import results: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE image_imports
+		SET status = 'completed', imported = $2, duplicates = $3, failed = $4, results = $5,
+		    archive_path = NULL, completed_at = $6
+		WHERE id = $1`,
+		imp.ID, imp.Imported, imp.Duplicates, imp.Failed, results, imp.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete import: %w", err)
+	}
+	return nil
+}
+
+// FailImport implements ImportStore
+func (s *DBStore) FailImport(ctx context.Context, importID, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE image_imports
+		SET status = 'failed', error_message = $2, archive_path = NULL, completed_at = NOW()
+		WHERE id = $1`, importID, message)
+	if err != nil {
+		return fmt.Errorf("failed to fail import: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package image
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// mockImportStore keeps imports in memory and claims them in creation order
+type mockImportStore struct {
+	mu      sync.Mutex
+	imports []ImageImport
+}
+
+func (m *mockImportStore) CreateImport(ctx context.Context, imp ImageImport) (ImageImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	imp.ID = "00000000-0000-0000-0000-00000000000" + string(rune('1'+len(m.imports)))
+	imp.CreatedAt = time.Now()
+	m.imports = append(m.imports, imp)
+	return imp, nil
+}
+
+func (m *mockImportStore) GetImport(ctx context.Context, importID string) (ImageImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, imp := range m.imports {
+		if imp.ID == importID {
+			return imp, nil
+		}
+	}
+	return ImageImport{}, ErrImportNotFound
+}
+
+func (m *mockImportStore) ClaimImport(ctx context.Context, staleAfter time.Duration) (*ImageImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.imports {
+		if m.imports[i].Status == ImportStatusPending {
+			m.imports[i].Status = ImportStatusProcessing
+			imp := m.imports[i]
+			return &imp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockImportStore) CompleteImport(ctx context.Context, imp ImageImport) error {
+	return m.update(imp.ID, func(stored *ImageImport) { *stored = imp })
+}
+
+func (m *mockImportStore) FailImport(ctx context.Context, importID, message string) error {
+	return m.update(importID, func(stored *ImageImport) {
+		stored.Status = ImportStatusFailed
+		stored.Error = &message
+	})
+}
+
+func (m *mockImportStore) update(importID string, apply func(*ImageImport)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.imports {
+		if m.imports[i].ID == importID {
+			apply(&m.imports[i])
+			return nil
+		}
+	}
+	return ErrImportNotFound
+}
+
+// mockImportNotifier records the imports vendors were told about
+type mockImportNotifier struct {
+	completed []string
+	failed    []string
+}
+
+func (m *mockImportNotifier) SendImageImportCompleted(ctx context.Context, userID, importID string, imported, failed int) error {
+	m.completed = append(m.completed, importID)
+	return nil
+}
+
+func (m *mockImportNotifier) SendImageImportFailed(ctx context.Context, userID, importID string) error {
+	m.failed = append(m.failed, importID)
+	return nil
+}
+
+// mockImportFetcher serves URLs from a map and fails for the others
+type mockImportFetcher map[string][]byte
+
+func (m mockImportFetcher) Fetch(ctx context.Context, rawURL string, maxSize int64) ([]byte, error) {
+	data, exists := m[rawURL]
+	if !exists {
+		return nil, errors.New("failed to download: status 404")
+	}
+	return data, nil
+}
+
+// archiveFileStorage keeps uploaded files so stored archives can be read back
+type archiveFileStorage struct {
+	mockFileStorage
+	files map[string][]byte
+}
+
+func (s *archiveFileStorage) UploadFile(ctx context.Context, data []byte, fileName string, path string) (string, error) {
+	filePath := path + "/" + fileName
+	s.files[filePath] = data
+	return filePath, nil
+}
+
+func (s *archiveFileStorage) GetFile(ctx context.Context, filePath string) ([]byte, error) {
+	data, exists := s.files[filePath]
+	if !exists {
+		return nil, errors.New("file not found")
+	}
+	return data, nil
+}
+
+func newImportTestService(storage FileStorage) (*Service, *mockImportStore, *mockImportNotifier) {
+	service := NewService(
+		newMockStore(),
+		storage,
+		&mockImageProcessor{},
+		&mockUsageTracker{},
+		&mockCache{},
+		&mockNotificationService{},
+		&mockAuditLogger{},
+		&mockRateLimiter{},
+		StorageConfig{
+			MaxFileSize:  10 * 1024 * 1024,
+			AllowedTypes: []string{"image/jpeg", "image/png"},
+		},
+	)
+	store := &mockImportStore{}
+	notifier := &mockImportNotifier{}
+	service.SetImports(store, notifier, ImportConfig{MaxFiles: 3})
+	return service, store, notifier
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testArchive(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRequestImportValidation(t *testing.T) {
+	service, _, _ := newImportTestService(&mockFileStorage{})
+	ctx := context.Background()
+	png := testPNG(t, 4, 4)
+
+	tests := []struct {
+		name    string
+		req     ImportRequest
+		archive []byte
+	}{
+		{"nothing to import", ImportRequest{}, nil},
+		{"archive and urls", ImportRequest{URLs: []string{"https://example.com/a.png"}}, testArchive(t, map[string][]byte{"a.png": png})},
+		{"invalid archive", ImportRequest{}, []byte("not a zip")},
+		{"empty archive", ImportRequest{}, testArchive(t, map[string][]byte{"__MACOSX/._a.png": png, ".DS_Store": nil})},
+		{"too many files", ImportRequest{}, testArchive(t, map[string][]byte{"a.png": png, "b.png": png, "c.png": png, "d.png": png})},
+		{"unsupported scheme", ImportRequest{URLs: []string{"ftp://example.com/a.png"}}, nil},
+		{"too many urls", ImportRequest{URLs: []string{"https://a.com/1", "https://a.com/2", "https://a.com/3", "https://a.com/4"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.RequestImport(ctx, "user-1", "vendor-1", tt.req, tt.archive)
+			if !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+
+	// Repeated URLs are imported once
+	imp, err := service.RequestImport(ctx, "user-1", "vendor-1", ImportRequest{
+		URLs: []string{"https://a.com/1", " https://a.com/1 ", "https://a.com/2", "https://a.com/2"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RequestImport failed: %v", err)
+	}
+	if imp.Total != 2 || imp.Status != ImportStatusPending {
+		t.Errorf("Expected 2 pending URLs, got %d (%s)", imp.Total, imp.Status)
+	}
+}
+
+func TestImportArchive(t *testing.T) {
+	storage := &archiveFileStorage{files: make(map[string][]byte)}
+	service, store, notifier := newImportTestService(storage)
+	ctx := context.Background()
+
+	archive := testArchive(t, map[string][]byte{
+		"summer/red-summer_dress-01.png": testPNG(t, 20, 10),
+		"summer/":                        nil,
+		"notes.txt":                      []byte("not an image"),
+		"__MACOSX/summer/._dress.png":    []byte("resource fork"),
+	})
+	imp, err := service.RequestImport(ctx, "user-1", "vendor-1", ImportRequest{Tags: []string{"Summer"}, AutoTag: true}, archive)
+	if err != nil {
+		t.Fatalf("RequestImport failed: %v", err)
+	}
+	if imp.Source != ImportSourceArchive || imp.Total != 2 {
+		t.Fatalf("Expected an archive of 2 files, got %s with %d", imp.Source, imp.Total)
+	}
+
+	service.processQueuedImports(ctx)
+
+	done, err := service.GetImport(ctx, "vendor-1", imp.ID)
+	if err != nil {
+		t.Fatalf("GetImport failed: %v", err)
+	}
+	if done.Status != ImportStatusCompleted || done.Imported != 1 || done.Failed != 1 {
+		t.Fatalf("Expected 1 imported and 1 failed file, got %+v", done)
+	}
+	for _, result := range done.Results {
+		switch result.Source {
+		case "summer/red-summer_dress-01.png":
+			want := []string{"Summer", "red", "dress", "landscape"}
+			if result.Status != ImportResultImported || len(result.Tags) != len(want) {
+				t.Fatalf("Expected imported image tagged %v, got %+v", want, result)
+			}
+			for i := range want {
+				if result.Tags[i] != want[i] {
+					t.Errorf("Expected tags %v, got %v", want, result.Tags)
+				}
+			}
+		case "notes.txt":
+			if result.Status != ImportResultFailed || result.Error == "" {
+				t.Errorf("Expected notes.txt to fail, got %+v", result)
+			}
+		default:
+			t.Errorf("Unexpected result for %s", result.Source)
+		}
+	}
+	if len(notifier.completed) != 1 || notifier.completed[0] != imp.ID {
+		t.Errorf("Expected the vendor to be notified, got %v", notifier.completed)
+	}
+
+	// Other vendors cannot see the import
+	if _, err := service.GetImport(ctx, "vendor-2", imp.ID); !errors.Is(err, ErrImportNotFound) {
+		t.Errorf("Expected ErrImportNotFound for another vendor, got %v", err)
+	}
+	if claimed, _ := store.ClaimImport(ctx, importStaleAfter); claimed != nil {
+		t.Errorf("Expected no import left to claim, got %s", claimed.ID)
+	}
+}
+
+func TestImportURLs(t *testing.T) {
+	service, _, notifier := newImportTestService(&mockFileStorage{})
+	service.imports.fetcher = mockImportFetcher{"https://cdn.example.com/shoes": testPNG(t, 10, 20)}
+	ctx := context.Background()
+
+	imp, err := service.RequestImport(ctx, "user-1", "vendor-1", ImportRequest{
+		URLs: []string{"https://cdn.example.com/shoes", "https://cdn.example.com/missing.png"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RequestImport failed: %v", err)
+	}
+	service.processQueuedImports(ctx)
+
+	done, err := service.GetImport(ctx, "vendor-1", imp.ID)
+	if err != nil {
+		t.Fatalf("GetImport failed: %v", err)
+	}
+	if done.Imported != 1 || done.Failed != 1 || len(done.Results) != 2 {
+		t.Fatalf("Expected 1 imported and 1 failed URL, got %+v", done)
+	}
+	if result := done.Results[0]; result.Status != ImportResultImported || result.ImageID == "" || len(result.Tags) != 0 {
+		t.Errorf("Expected an untagged imported image, got %+v", result)
+	}
+	if result := done.Results[1]; result.Status != ImportResultFailed || result.Error == "" {
+		t.Errorf("Expected the missing URL to fail, got %+v", result)
+	}
+	if len(notifier.completed) != 1 {
+		t.Errorf("Expected the vendor to be notified once, got %d", len(notifier.completed))
+	}
+}
+
+func TestImportFailsWithoutArchive(t *testing.T) {
+	storage := &archiveFileStorage{files: make(map[string][]byte)}
+	service, store, notifier := newImportTestService(storage)
+	ctx := context.Background()
+
+	archive := testArchive(t, map[string][]byte{"a.png": testPNG(t, 4, 4)})
+	imp, err := service.RequestImport(ctx, "user-1", "vendor-1", ImportRequest{}, archive)
+	if err != nil {
+		t.Fatalf("RequestImport failed: %v", err)
+	}
+	storage.files = make(map[string][]byte)
+	service.processQueuedImports(ctx)
+
+	failed, _ := store.GetImport(ctx, imp.ID)
+	if failed.Status != ImportStatusFailed || failed.Error == nil {
+		t.Errorf("Expected the import to fail, got %+v", failed)
+	}
+	if len(notifier.failed) != 1 {
+		t.Errorf("Expected the vendor to be told of the failure, got %v", notifier.failed)
+	}
+}
+
+func TestRejectPrivateAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"10.0.0.5:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"[::1]:80", false},
+		{"0.0.0.0:80", false},
+	}
+	for _, tt := range tests {
+		err := rejectPrivateAddress("tcp", tt.address, nil)
+		if (err == nil) != tt.allowed {
+			t.Errorf("rejectPrivateAddress(%s) = %v, want allowed %v", tt.address, err, tt.allowed)
+		}
+	}
+}
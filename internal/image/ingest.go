@@ -0,0 +1,296 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// ingestJPEGQuality is used when an upload has to be re-encoded to fix its orientation
+const ingestJPEGQuality = 92
+
+// OrientationNormal is the EXIF orientation (tag 0x0112) of upright images
+const OrientationNormal = 1
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// IngestResult is an upload after orientation correction and metadata stripping
+type IngestResult struct {
+	Data []byte
+
+	// Dimensions of Data, i.e. after auto-rotation
+	Width  int
+	Height int
+
+	// Dimensions of the pixels as uploaded, before auto-rotation
+	OriginalWidth  int
+	OriginalHeight int
+
+	// EXIF orientation of the upload, OrientationNormal when it had none
+	Orientation int
+	// Whether any metadata segments or chunks were removed
+	MetadataStripped bool
+}
+
+// ingestImage prepares an upload for storage. JPEG and PNG uploads lose their
+// EXIF, GPS, XMP, IPTC and text metadata, and are rotated upright when their
+// EXIF orientation asks for it. Upright images are stripped without
+// re-encoding; rotated ones are re-encoded in their own format. Other formats
+// are stored as uploaded.
+func ingestImage(data []byte) (IngestResult, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return IngestResult{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+	result := IngestResult{
+		Data:           data,
+		Width:          config.Width,
+		Height:         config.Height,
+		OriginalWidth:  config.Width,
+		OriginalHeight: config.Height,
+		Orientation:    OrientationNormal,
+	}
+
+	var stripped []byte
+	var format string
+	switch {
+	case isJPEG(data):
+		format = "jpeg"
+		stripped, result.Orientation, err = stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		format = "png"
+		stripped, result.Orientation, err = stripPNGMetadata(data)
+	default:
+		return result, nil
+	}
+	if err != nil {
+		return IngestResult{}, err
+	}
+	result.MetadataStripped = len(stripped) != len(data)
+	result.Data = stripped
+
+	if result.Orientation == OrientationNormal {
+		return result, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return IngestResult{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+	upright := applyOrientation(img, result.Orientation)
+
+	var buf bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&buf, upright)
+	} else {
+		err = jpeg.Encode(&buf, upright, &jpeg.Options{Quality: ingestJPEGQuality})
+	}
+	if err != nil {
+		return IngestResult{}, fmt.Errorf("failed to encode rotated image: %w", err)
+	}
+	result.Data = buf.Bytes()
+	result.Width = upright.Bounds().Dx()
+	result.Height = upright.Bounds().Dy()
+	return result, nil
+}
+
+// DecodeOriented decodes an image and rotates it upright according to its
+// EXIF orientation, for images stored before uploads were ingested
+func DecodeOriented(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	orientation := OrientationNormal
+	switch {
+	case isJPEG(data):
+		_, orientation, err = stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		_, orientation, err = stripPNGMetadata(data)
+	}
+	if err != nil || orientation == OrientationNormal {
+		return img, format, nil
+	}
+	return applyOrientation(img, orientation), format, nil
+}
+
+func isJPEG(data []byte) bool {
+	return len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8
+}
+
+// stripJPEGMetadata drops APP1 (EXIF, XMP), APP3-APP13 (IPTC and vendor
+// data), APP15 and comment segments. JFIF (APP0), ICC profiles (APP2) and
+// Adobe color info (APP14) are kept since decoders need them. Everything from
+// the start of scan on is copied unchanged.
+func stripJPEGMetadata(data []byte) ([]byte, int, error) {
+	orientation := OrientationNormal
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 0, fmt.Errorf("invalid JPEG marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xDA {
+			// Start of scan: the rest is entropy-coded data
+			return append(out, data[pos:]...), orientation, nil
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, fmt.Errorf("truncated JPEG segment at offset %d", pos)
+		}
+		payload := data[pos+4 : end]
+
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+				orientation = exifOrientation(payload[6:])
+			}
+		case marker == 0xFE, marker == 0xEF, marker >= 0xE3 && marker <= 0xED:
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return nil, 0, fmt.Errorf("JPEG has no image data")
+}
+
+// strippedPNGChunks are the ancillary chunks that carry metadata
+var strippedPNGChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNGMetadata drops the EXIF, text and timestamp chunks of a PNG
+func stripPNGMetadata(data []byte) ([]byte, int, error) {
+	orientation := OrientationNormal
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, 0, fmt.Errorf("truncated PNG chunk at offset %d", pos)
+		}
+		chunkType := string(data[pos+4 : pos+8])
+
+		if chunkType == "eXIf" {
+			orientation = exifOrientation(data[pos+8 : pos+8+length])
+		}
+		if !strippedPNGChunks[chunkType] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+		if chunkType == "IEND" {
+			return out, orientation, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("PNG has no IEND chunk")
+}
+
+// exifOrientation reads the orientation tag from the first IFD of TIFF-encoded
+// EXIF data. Missing or invalid values count as OrientationNormal.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return OrientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return OrientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return OrientationNormal
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		// SHORT value stored in the first two bytes of the value field
+		value := int(order.Uint16(tiff[entry+8:]))
+		if value < OrientationNormal || value > 8 {
+			return OrientationNormal
+		}
+		return value
+	}
+	return OrientationNormal
+}
+
+// applyOrientation returns img transformed so that it displays upright
+func applyOrientation(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Orientations 5-8 swap width and height
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90° counter-clockwise, so turn clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90° clockwise, so turn counter-clockwise
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
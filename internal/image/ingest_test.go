@@ -0,0 +1,184 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// twoToneImage is 40x20 with a red left half and a blue right half
+func twoToneImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if x < 20 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// exifWithOrientation returns little-endian TIFF data with only the orientation tag
+func exifWithOrientation(orientation uint16) []byte {
+	tiff := []byte("II")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 42)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+	return binary.LittleEndian.AppendUint32(tiff, 0)
+}
+
+// jpegWithExif encodes img and inserts an EXIF APP1 segment after SOI
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	payload := append([]byte("Exif\x00\x00"), exifWithOrientation(orientation)...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// pngWithChunks encodes img and inserts the given chunks after IHDR
+func pngWithChunks(t *testing.T, img image.Image, chunks map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+
+	out := append([]byte{}, data[:ihdrEnd]...)
+	for chunkType, payload := range chunks {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(payload)))
+		chunk := append([]byte(chunkType), payload...)
+		out = append(out, chunk...)
+		out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(chunk))
+	}
+	return append(out, data[ihdrEnd:]...)
+}
+
+func TestIngestImage_RotatesJPEG(t *testing.T) {
+	data := jpegWithExif(t, twoToneImage(), 6)
+
+	result, err := ingestImage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Orientation != 6 || !result.MetadataStripped {
+		t.Errorf("Expected orientation 6 with metadata stripped, got %d (stripped=%v)", result.Orientation, result.MetadataStripped)
+	}
+	if result.Width != 20 || result.Height != 40 || result.OriginalWidth != 40 || result.OriginalHeight != 20 {
+		t.Errorf("Expected 20x40 from 40x20, got %dx%d from %dx%d", result.Width, result.Height, result.OriginalWidth, result.OriginalHeight)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) {
+		t.Error("Expected EXIF segment to be removed")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("Expected rotated JPEG to decode, got %v", err)
+	}
+	// Turning clockwise puts the red left half on top
+	if r, _, b, _ := img.At(10, 5).RGBA(); r < b {
+		t.Errorf("Expected top to be red, got r=%d b=%d", r>>8, b>>8)
+	}
+	if r, _, b, _ := img.At(10, 35).RGBA(); b < r {
+		t.Errorf("Expected bottom to be blue, got r=%d b=%d", r>>8, b>>8)
+	}
+}
+
+func TestIngestImage_StripsUprightJPEGWithoutReencoding(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, twoToneImage(), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := jpegWithExif(t, twoToneImage(), OrientationNormal)
+
+	result, err := ingestImage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(result.Data, buf.Bytes()) {
+		t.Error("Expected upright JPEG to equal the original without its EXIF segment")
+	}
+	if result.Width != 40 || result.Height != 20 || !result.MetadataStripped {
+		t.Errorf("Expected 40x20 with metadata stripped, got %dx%d (stripped=%v)", result.Width, result.Height, result.MetadataStripped)
+	}
+}
+
+func TestIngestImage_PNG(t *testing.T) {
+	data := pngWithChunks(t, twoToneImage(), map[string][]byte{
+		"eXIf": exifWithOrientation(3),
+		"tEXt": []byte("Comment\x00taken at home"),
+	})
+
+	result, err := ingestImage(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Orientation != 3 || bytes.Contains(result.Data, []byte("tEXt")) || bytes.Contains(result.Data, []byte("eXIf")) {
+		t.Errorf("Expected orientation 3 with chunks stripped, got %d", result.Orientation)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(result.Data))
+	if err != nil || format != "png" {
+		t.Fatalf("Expected PNG output, got %q (%v)", format, err)
+	}
+	// Rotating 180° swaps the halves
+	if r, _, _, _ := img.At(5, 5).RGBA(); r != 0 {
+		t.Errorf("Expected left half to be blue after rotation, got red %d", r>>8)
+	}
+
+	plain := pngWithChunks(t, twoToneImage(), nil)
+	result, err = ingestImage(plain)
+	if err != nil || result.MetadataStripped || !bytes.Equal(result.Data, plain) {
+		t.Errorf("Expected PNG without metadata to be unchanged, got stripped=%v err=%v", result.MetadataStripped, err)
+	}
+}
+
+func TestDecodeOriented(t *testing.T) {
+	img, format, err := DecodeOriented(jpegWithExif(t, twoToneImage(), 8))
+	if err != nil || format != "jpeg" {
+		t.Fatalf("Expected JPEG to decode, got %q (%v)", format, err)
+	}
+	if size := img.Bounds().Size(); size.X != 20 || size.Y != 40 {
+		t.Errorf("Expected 20x40 upright image, got %dx%d", size.X, size.Y)
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"rotated", exifWithOrientation(6), 6},
+		{"out of range", exifWithOrientation(9), OrientationNormal},
+		{"truncated", exifWithOrientation(6)[:12], OrientationNormal},
+		{"not TIFF", []byte("garbage data"), OrientationNormal},
+	}
+	for _, tt := range tests {
+		if got := exifOrientation(tt.tiff); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package image
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // Store defines the interface for image data persistence
@@ -14,6 +15,13 @@ type Store interface {
 	DeleteImage(ctx context.Context, imageID string) error
 	ListImages(ctx context.Context, req ImageListRequest) (ImageListResponse, error)
 
+	// CanViewImage reports whether the user may see the image: their own,
+	// public or in an album shared with them. An empty viewerID is anonymous.
+	CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error)
+	// PrivateAlbumImages returns which of the images are in albums that are
+	// not public, whatever their own flag says
+	PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error)
+
 	// Quota operations
 	CanUploadImage(ctx context.Context, userID *string, vendorID *string, imageType ImageType, fileSize int64) (bool, error)
 	GetQuotaStatus(ctx context.Context, userID *string, vendorID *string) (QuotaStatus, error)
@@ -22,6 +30,35 @@ type Store interface {
 	GetImageStats(ctx context.Context, userID *string, vendorID *string) (ImageStats, error)
 }
 
+// TrashStore defines the interface for soft-deleted images. Trashed images are
+// hidden from Store reads but keep their row and files until purged.
+type TrashStore interface {
+	TrashImage(ctx context.Context, imageID string) (time.Time, error)
+	RestoreImage(ctx context.Context, imageID string, vendorID string, deletedAfter time.Time) (Image, error)
+	ListTrashedImages(ctx context.Context, vendorID string, deletedAfter time.Time, req TrashListRequest) (TrashListResponse, error)
+	ListExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) ([]Image, error)
+}
+
+// DedupStore defines the interface for finding images an upload duplicates.
+// Duplicates are only looked up among the owner's live images of the same type.
+type DedupStore interface {
+	FindDuplicateImage(ctx context.Context, userID *string, vendorID *string, imageType ImageType, contentHash string, perceptualHash *int64) (Image, bool, error)
+	IsVendorDedupEnabled(ctx context.Context, vendorID string) (bool, error)
+	SetVendorDedupEnabled(ctx context.Context, vendorID string, enabled bool) error
+}
+
+// UploadSessionStore defines the interface for direct uploads awaiting
+// confirmation. Each session is confirmed at most once.
+type UploadSessionStore interface {
+	CreateUploadSession(ctx context.Context, session UploadSession) (UploadSession, error)
+	GetUploadSession(ctx context.Context, uploadID string) (UploadSession, error)
+	// ClaimUploadSession moves a pending, unexpired session to processing and
+	// returns ErrUploadNotPending when another confirmation got there first
+	ClaimUploadSession(ctx context.Context, uploadID string) error
+	FinishUploadSession(ctx context.Context, uploadID string, status string, imageID *string) error
+	ExpireUploadSessions(ctx context.Context, expiredBefore time.Time, limit int) ([]UploadSession, error)
+}
+
 // FileStorage defines the interface for file storage operations
 type FileStorage interface {
 	// File operations
@@ -44,6 +81,9 @@ type ImageProcessor interface {
 	// Image validation
 	ValidateImage(ctx context.Context, data []byte, fileName string, mimeType string) error
 	GetImageDimensions(ctx context.Context, data []byte) (int, int, error)
+
+	// Upload ingestion: auto-rotation and metadata stripping, see ingest.go
+	IngestImage(ctx context.Context, data []byte) (IngestResult, error)
 }
 
 // UsageTracker defines the interface for tracking image usage
@@ -77,6 +117,12 @@ type NotificationService interface {
 	SendQuotaWarning(ctx context.Context, userID *string, vendorID *string, quotaType string, remaining int) error
 }
 
+// CDN defines the interface for serving images from a CDN
+type CDN interface {
+	URL(ref string) string
+	Purge(ctx context.Context, refs ...string) error
+}
+
 // AuditLogger defines the interface for audit logging
 type AuditLogger interface {
 	// Image audit logging
@@ -108,6 +154,14 @@ type CreateImageRequest struct {
 	IsPublic     bool                   `json:"isPublic"`
 	Tags         []string               `json:"tags,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+
+	// Dimensions as uploaded, before auto-rotation, see ingest.go
+	OriginalWidth  *int `json:"originalWidth,omitempty"`
+	OriginalHeight *int `json:"originalHeight,omitempty"`
+
+	// Hashes of the uploaded file, see dedup.go
+	ContentHash    *string `json:"contentHash,omitempty"`
+	PerceptualHash *int64  `json:"perceptualHash,omitempty"`
 }
 
 // Response types
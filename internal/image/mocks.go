@@ -61,6 +61,10 @@ func (m *MockImageProcessor) GetImageDimensions(ctx context.Context, data []byte
 	return 800, 600, nil
 }
 
+func (m *MockImageProcessor) IngestImage(ctx context.Context, data []byte) (IngestResult, error) {
+	return IngestResult{Data: data, Width: 800, Height: 600, OriginalWidth: 800, OriginalHeight: 600, Orientation: OrientationNormal}, nil
+}
+
 // MockUsageTracker implements UsageTracker interface for testing
 type MockUsageTracker struct{}
 
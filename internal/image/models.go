@@ -32,6 +32,13 @@ type Image struct {
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt    time.Time              `json:"createdAt"`
 	UpdatedAt    time.Time              `json:"updatedAt"`
+
+	// Dimensions as uploaded, before EXIF auto-rotation
+	OriginalWidth  *int `json:"originalWidth,omitempty"`
+	OriginalHeight *int `json:"originalHeight,omitempty"`
+
+	// Set on upload responses that returned an existing copy of the file
+	Deduplicated bool `json:"deduplicated,omitempty"`
 }
 
 // ImageUsageHistory represents the usage history of an image
@@ -60,6 +67,58 @@ type UploadImageRequest struct {
 	File     io.Reader              `json:"-"` // File data reader
 }
 
+// PresignUploadRequest describes a file the client will upload directly to storage
+type PresignUploadRequest struct {
+	Type     ImageType `json:"type"`
+	FileName string    `json:"fileName"`
+	FileSize int64     `json:"fileSize"`
+	MimeType string    `json:"mimeType"`
+	IsPublic bool      `json:"isPublic"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+// PresignUploadResponse is a one-time URL to PUT the file to. The upload is
+// finalized with POST /images/confirm before ExpiresAt.
+type PresignUploadResponse struct {
+	UploadID  string            `json:"uploadId"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// ConfirmUploadRequest finalizes a direct upload
+type ConfirmUploadRequest struct {
+	UploadID string `json:"uploadId"`
+}
+
+// UploadSession is a pending direct upload to storage
+type UploadSession struct {
+	ID        string    `json:"id"`
+	UserID    *string   `json:"userId,omitempty"`
+	VendorID  *string   `json:"vendorId,omitempty"`
+	Type      ImageType `json:"type"`
+	FileName  string    `json:"fileName"`
+	FileSize  int64     `json:"fileSize"`
+	MimeType  string    `json:"mimeType"`
+	IsPublic  bool      `json:"isPublic"`
+	Tags      []string  `json:"tags"`
+	ObjectKey string    `json:"objectKey"`
+	Status    string    `json:"status"`
+	ImageID   *string   `json:"imageId,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Upload session statuses
+const (
+	UploadStatusPending    = "pending"
+	UploadStatusProcessing = "processing"
+	UploadStatusCompleted  = "completed"
+	UploadStatusFailed     = "failed"
+	UploadStatusExpired    = "expired"
+)
+
 // UpdateImageRequest represents the request to update an image
 type UpdateImageRequest struct {
 	IsPublic *bool                  `json:"isPublic,omitempty"`
@@ -76,6 +135,11 @@ type ImageListRequest struct {
 	Tags     []string   `json:"tags" form:"tags"`
 	UserID   *string    `json:"userId" form:"userId"`
 	VendorID *string    `json:"vendorId" form:"vendorId"`
+	AlbumID  *string    `json:"albumId" form:"albumId"`
+	Cursor   string     `json:"cursor" form:"cursor"` // nextCursor of the previous page, instead of page
+
+	// ViewerID limits the listing to images the user may see, see viewerCondition
+	ViewerID string `json:"-" form:"-"`
 }
 
 // ImageListResponse represents the response for image listing
@@ -85,6 +149,7 @@ type ImageListResponse struct {
 	Page       int     `json:"page"`
 	PageSize   int     `json:"pageSize"`
 	TotalPages int     `json:"totalPages"`
+	NextCursor string  `json:"nextCursor,omitempty"` // empty on the last page
 }
 
 // ImageUsageHistoryRequest represents the request to get image usage history
@@ -103,6 +168,29 @@ type ImageUsageHistoryResponse struct {
 	TotalPages int                 `json:"totalPages"`
 }
 
+// TrashedImage is a deleted image that can still be restored
+type TrashedImage struct {
+	Image
+	DeletedAt    time.Time `json:"deletedAt"`
+	RestoreUntil time.Time `json:"restoreUntil"`
+}
+
+// TrashListRequest represents the request to list trashed images
+type TrashListRequest struct {
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"pageSize" form:"pageSize"`
+}
+
+// TrashListResponse represents the response for trash listing
+type TrashListResponse struct {
+	Images        []TrashedImage `json:"images"`
+	Total         int            `json:"total"`
+	TotalFileSize int64          `json:"totalFileSize"` // still counted towards storage usage
+	Page          int            `json:"page"`
+	PageSize      int            `json:"pageSize"`
+	TotalPages    int            `json:"totalPages"`
+}
+
 // SignedURLResponse represents the response for signed URL generation
 type SignedURLResponse struct {
 	URL        string    `json:"url"`
@@ -123,6 +211,8 @@ type ImageStats struct {
 	TotalSizeBytes   int64   `json:"totalSizeBytes"`
 	AverageFileSize  float64 `json:"averageFileSize"` // Changed to float64 for PostgreSQL AVG()
 	ImagesLast30Days int     `json:"imagesLast30Days"`
+	TrashedImages    int     `json:"trashedImages"`   // included in the totals until purged
+	TrashedFileSize  int64   `json:"trashedFileSize"` // included in TotalFileSize until purged
 }
 
 // Constants
@@ -138,6 +228,9 @@ const (
 	ActionDownload = "download"
 	ActionDelete   = "delete"
 	ActionUpdate   = "update"
+	ActionTrash    = "trash"
+	ActionRestore  = "restore"
+	ActionDedup    = "dedup"
 
 	// Access types
 	AccessTypeView     = "view"
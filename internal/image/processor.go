@@ -40,6 +40,11 @@ func (p *ImageProcessorImpl) ProcessImage(ctx context.Context, data []byte, file
 	return data, width, height, nil
 }
 
+// IngestImage rotates an upload upright and strips its metadata
+func (p *ImageProcessorImpl) IngestImage(ctx context.Context, data []byte) (IngestResult, error) {
+	return ingestImage(data)
+}
+
 // GenerateThumbnail generates a thumbnail of the specified dimensions
 func (p *ImageProcessorImpl) GenerateThumbnail(ctx context.Context, data []byte, fileName string, width, height int) ([]byte, error) {
 	// Decode image
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"ai-styler/internal/common"
+	"ai-styler/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,20 +15,44 @@ func SetupGinRoutes(router *gin.RouterGroup, handler *Handler) {
 	// Image management routes
 	images := router.Group("/images")
 	{
-		images.POST("", handler.UploadImageGin)                      // POST /images
-		images.GET("", common.GinWrap(handler.ListImages))           // GET /images
-		images.GET("/:id", handler.GetImageGin)                      // GET /images/:id
-		images.PUT("/:id", handler.UpdateImageGin)                   // PUT /images/:id
-		images.DELETE("/:id", handler.DeleteImageGin)                // DELETE /images/:id
-		images.POST("/:id/signed-url", handler.GenerateSignedURLGin) // POST /images/:id/signed-url
-		images.GET("/:id/usage", handler.GetImageUsageHistoryGin)    // GET /images/:id/usage
+		images.POST("", handler.UploadImageGin)                        // POST /images
+		images.POST("/presign", common.GinWrap(handler.PresignUpload)) // POST /images/presign
+		images.POST("/confirm", common.GinWrap(handler.ConfirmUpload)) // POST /images/confirm
+		images.GET("", common.GinWrap(handler.ListImages))             // GET /images
+		images.GET("/:id", handler.GetImageGin)                        // GET /images/:id
+		images.PUT("/:id", handler.UpdateImageGin)                     // PUT /images/:id
+		images.DELETE("/:id", handler.DeleteImageGin)                  // DELETE /images/:id
+		images.POST("/:id/signed-url", handler.GenerateSignedURLGin)   // POST /images/:id/signed-url
+		images.GET("/:id/usage", handler.GetImageUsageHistoryGin)      // GET /images/:id/usage
 	}
 
+	// Vendor image trash
+	vendorImages := router.Group("/vendor/images")
+	{
+		vendorImages.GET("/trash", common.GinWrap(handler.ListTrash))           // GET /vendor/images/trash
+		vendorImages.POST("/:id/restore", handler.RestoreImageGin)              // POST /vendor/images/:id/restore
+		vendorImages.GET("/dedup", common.GinWrap(handler.GetDedupSettings))    // GET /vendor/images/dedup
+		vendorImages.PUT("/dedup", common.GinWrap(handler.UpdateDedupSettings)) // PUT /vendor/images/dedup
+	}
+
+	// Bulk imports of vendor images
+	router.POST("/vendors/me/images/import", common.GinWrap(handler.ImportImages)) // POST /vendors/me/images/import
+	router.GET("/vendors/me/images/imports/:id", handler.GetImportGin)             // GET /vendors/me/images/imports/:id
+
 	// Quota and statistics
 	router.GET("/quota", common.GinWrap(handler.GetQuotaStatus)) // GET /quota
 	router.GET("/stats", common.GinWrap(handler.GetImageStats))  // GET /stats
 }
 
+// SetupUploadRoutes mounts the endpoint receiving direct uploads to local
+// storage. It must not require authentication: the signed URL authorizes the
+// upload. Nothing is mounted when uploads go to S3 or are disabled.
+func SetupUploadRoutes(router *gin.RouterGroup, handler *Handler) {
+	if uploads, ok := handler.service.directUploads.(*storage.LocalDirectUploads); ok {
+		router.PUT("/storage/uploads/*key", uploads.ReceiveUpload) // PUT /storage/uploads/*key
+	}
+}
+
 // Gin handler wrappers for handlers that need path parameters
 
 // UploadImageGin handles POST /images with multipart form
@@ -67,6 +92,16 @@ func (h *Handler) DeleteImageGin(c *gin.Context) {
 	h.DeleteImage(c.Writer, c.Request)
 }
 
+// RestoreImageGin handles POST /vendor/images/:id/restore
+func (h *Handler) RestoreImageGin(c *gin.Context) {
+	// Extract path parameter and set it in request URL
+	imageID := c.Param("id")
+	if imageID != "" {
+		c.Request.URL.Path = "/api/vendor/images/" + imageID + "/restore"
+	}
+	h.RestoreImage(c.Writer, c.Request)
+}
+
 // GenerateSignedURLGin handles POST /images/:id/signed-url
 func (h *Handler) GenerateSignedURLGin(c *gin.Context) {
 	// Extract path parameter and set it in request URL
@@ -87,12 +122,20 @@ func (h *Handler) GetImageUsageHistoryGin(c *gin.Context) {
 	h.GetImageUsageHistory(c.Writer, c.Request)
 }
 
+// GetImportGin handles GET /vendors/me/images/imports/:id
+func (h *Handler) GetImportGin(c *gin.Context) {
+	c.Request.SetPathValue("id", c.Param("id"))
+	h.GetImport(c.Writer, c.Request)
+}
+
 // SetupRoutes configures the image service routes
 func SetupRoutes(handler *Handler) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Image management routes
 	mux.HandleFunc("POST /images", handler.UploadImage)
+	mux.HandleFunc("POST /images/presign", handler.PresignUpload)
+	mux.HandleFunc("POST /images/confirm", handler.ConfirmUpload)
 	mux.HandleFunc("GET /images", handler.ListImages)
 	mux.HandleFunc("GET /images/{id}", handler.GetImage)
 	mux.HandleFunc("PUT /images/{id}", handler.UpdateImage)
@@ -104,6 +147,14 @@ func SetupRoutes(handler *Handler) *http.ServeMux {
 	// Usage tracking
 	mux.HandleFunc("GET /images/{id}/usage", handler.GetImageUsageHistory)
 
+	// Vendor image trash
+	mux.HandleFunc("GET /vendor/images/trash", handler.ListTrash)
+	mux.HandleFunc("POST /vendor/images/{id}/restore", handler.RestoreImage)
+	mux.HandleFunc("GET /vendor/images/dedup", handler.GetDedupSettings)
+	mux.HandleFunc("PUT /vendor/images/dedup", handler.UpdateDedupSettings)
+	mux.HandleFunc("POST /vendors/me/images/import", handler.ImportImages)
+	mux.HandleFunc("GET /vendors/me/images/imports/{id}", handler.GetImport)
+
 	// Quota and statistics
 	mux.HandleFunc("GET /quota", handler.GetQuotaStatus)
 	mux.HandleFunc("GET /stats", handler.GetImageStats)
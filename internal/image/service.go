@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"ai-styler/internal/storage"
 )
 
 // Service provides image management functionality
@@ -20,6 +23,27 @@ type Service struct {
 	auditLogger    AuditLogger
 	rateLimiter    RateLimiter
 	config         StorageConfig
+
+	// Optional trash for vendor images, see SetTrash
+	trash       TrashStore
+	trashWindow time.Duration
+
+	// Optional upload deduplication, see SetDedup
+	dedup DedupStore
+
+	// Optional direct uploads to storage, see SetDirectUploads
+	uploadSessions UploadSessionStore
+	directUploads  storage.DirectUploads
+	uploadTTL      time.Duration
+
+	// Optional CDN for public images, see SetCDN
+	cdn CDN
+
+	// Optional bulk imports of vendor images, see SetImports
+	imports *importer
+
+	// Upload size limit set at runtime, see SetMaxFileSize
+	maxFileSize atomic.Int64
 }
 
 // NewService creates a new image service
@@ -47,6 +71,20 @@ func NewService(
 	}
 }
 
+// SetMaxFileSize changes the largest accepted upload while the service is
+// running. A size of zero restores the configured limit.
+func (s *Service) SetMaxFileSize(size int64) {
+	s.maxFileSize.Store(size)
+}
+
+// maxUploadSize returns the largest accepted upload in bytes
+func (s *Service) maxUploadSize() int64 {
+	if size := s.maxFileSize.Load(); size > 0 {
+		return size
+	}
+	return s.config.MaxFileSize
+}
+
 // UploadImage uploads a new image
 func (s *Service) UploadImage(ctx context.Context, userID *string, vendorID *string, req UploadImageRequest) (Image, error) {
 	// Validate input
@@ -66,26 +104,47 @@ func (s *Service) UploadImage(ctx context.Context, userID *string, vendorID *str
 		return Image{}, errors.New("rate limit exceeded for image upload")
 	}
 
-	// Check quota
-	canUpload, err := s.store.CanUploadImage(ctx, ownerUserID, ownerVendorID, imageType, req.FileSize)
-	if err != nil {
-		return Image{}, fmt.Errorf("failed to check upload permission: %w", err)
-	}
-	if !canUpload {
-		return Image{}, errors.New("image quota exceeded")
-	}
-
 	// Read file data from request
 	fileData, err := io.ReadAll(req.File)
 	if err != nil {
 		return Image{}, fmt.Errorf("failed to read file data: %w", err)
 	}
 
+	return s.storeImage(ctx, req, imageType, ownerUserID, ownerVendorID, fileData)
+}
+
+// storeImage validates, ingests and stores the file of an upload and creates
+// its image record
+func (s *Service) storeImage(ctx context.Context, req UploadImageRequest, imageType ImageType, ownerUserID *string, ownerVendorID *string, fileData []byte) (Image, error) {
 	// Validate image
 	if err := s.imageProcessor.ValidateImage(ctx, fileData, req.FileName, req.MimeType); err != nil {
 		return Image{}, fmt.Errorf("image validation failed: %w", err)
 	}
 
+	// Rotate the upload upright and strip EXIF/GPS metadata before it is
+	// hashed or stored
+	ingested, err := s.imageProcessor.IngestImage(ctx, fileData)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to ingest image: %w", err)
+	}
+	fileData = ingested.Data
+
+	// Return the owner's existing copy instead of storing the file again.
+	// This runs before the quota check since a duplicate uses no quota.
+	hashes := computeImageHashes(fileData)
+	if duplicate, found := s.findDuplicate(ctx, ownerUserID, ownerVendorID, imageType, hashes); found {
+		return duplicate, nil
+	}
+
+	// Check quota
+	canUpload, err := s.store.CanUploadImage(ctx, ownerUserID, ownerVendorID, imageType, req.FileSize)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to check upload permission: %w", err)
+	}
+	if !canUpload {
+		return Image{}, errors.New("image quota exceeded")
+	}
+
 	// Process image
 	processedData, width, height, err := s.imageProcessor.ProcessImage(ctx, fileData, req.FileName)
 	if err != nil {
@@ -123,19 +182,23 @@ func (s *Service) UploadImage(ctx context.Context, userID *string, vendorID *str
 
 	// Create image record
 	createReq := CreateImageRequest{
-		UserID:       ownerUserID,
-		VendorID:     ownerVendorID,
-		Type:         imageType,
-		FileName:     req.FileName,
-		OriginalURL:  originalURL,
-		ThumbnailURL: thumbnailURL,
-		FileSize:     int64(len(processedData)),
-		MimeType:     req.MimeType,
-		Width:        &width,
-		Height:       &height,
-		IsPublic:     req.IsPublic,
-		Tags:         req.Tags,
-		Metadata:     req.Metadata,
+		UserID:         ownerUserID,
+		VendorID:       ownerVendorID,
+		Type:           imageType,
+		FileName:       req.FileName,
+		OriginalURL:    originalURL,
+		ThumbnailURL:   thumbnailURL,
+		FileSize:       int64(len(processedData)),
+		MimeType:       req.MimeType,
+		Width:          &width,
+		Height:         &height,
+		OriginalWidth:  &ingested.OriginalWidth,
+		OriginalHeight: &ingested.OriginalHeight,
+		IsPublic:       req.IsPublic,
+		Tags:           req.Tags,
+		Metadata:       req.Metadata,
+		ContentHash:    &hashes.content,
+		PerceptualHash: hashes.perceptual,
 	}
 
 	image, err := s.store.CreateImage(ctx, createReq)
@@ -157,10 +220,12 @@ func (s *Service) UploadImage(ctx context.Context, userID *string, vendorID *str
 
 	// Log the action
 	_ = s.auditLogger.LogImageAction(ctx, image.ID, ownerUserID, ownerVendorID, "image_uploaded", map[string]interface{}{
-		"file_name": image.FileName,
-		"file_size": image.FileSize,
-		"mime_type": image.MimeType,
-		"is_public": image.IsPublic,
+		"file_name":         image.FileName,
+		"file_size":         image.FileSize,
+		"mime_type":         image.MimeType,
+		"is_public":         image.IsPublic,
+		"orientation":       ingested.Orientation,
+		"metadata_stripped": ingested.MetadataStripped,
 	})
 
 	// Send notification
@@ -215,10 +280,15 @@ func (s *Service) UpdateImage(ctx context.Context, imageID string, req UpdateIma
 	// Update cache
 	_ = s.cache.CacheImage(ctx, image.ID, image)
 
+	// Files made private must not stay reachable on the CDN
+	if req.IsPublic != nil && !*req.IsPublic {
+		s.purgeCDN(ctx, image)
+	}
+
 	return image, nil
 }
 
-// DeleteImage deletes an image
+// DeleteImage deletes an image. Vendor images go to the trash when it is enabled.
 func (s *Service) DeleteImage(ctx context.Context, imageID string) error {
 	// Get image info before deletion for logging and cleanup
 	image, err := s.store.GetImage(ctx, imageID)
@@ -226,6 +296,10 @@ func (s *Service) DeleteImage(ctx context.Context, imageID string) error {
 		return fmt.Errorf("failed to get image: %w", err)
 	}
 
+	if s.trash != nil && image.Type == ImageTypeVendor {
+		return s.trashImage(ctx, image)
+	}
+
 	err = s.store.DeleteImage(ctx, imageID)
 	if err != nil {
 		return fmt.Errorf("failed to delete image: %w", err)
@@ -236,6 +310,7 @@ func (s *Service) DeleteImage(ctx context.Context, imageID string) error {
 	if image.ThumbnailURL != nil {
 		_ = s.fileStorage.DeleteFile(ctx, *image.ThumbnailURL)
 	}
+	s.purgeCDN(ctx, image)
 
 	// Record usage
 	_ = s.usageTracker.RecordUsage(ctx, imageID, image.UserID, ActionDelete, map[string]interface{}{
@@ -276,13 +351,17 @@ func (s *Service) ListImages(ctx context.Context, req ImageListRequest) (ImageLi
 	return response, nil
 }
 
-// GenerateSignedURL generates a signed URL for image access
-func (s *Service) GenerateSignedURL(ctx context.Context, imageID string, accessType string) (SignedURLResponse, error) {
+// GenerateSignedURL generates a signed URL for image access. It fails with
+// ErrImageAccessDenied unless viewerID may see the image.
+func (s *Service) GenerateSignedURL(ctx context.Context, viewerID, imageID string, accessType string) (SignedURLResponse, error) {
 	// Get image
 	image, err := s.GetImage(ctx, imageID)
 	if err != nil {
 		return SignedURLResponse{}, fmt.Errorf("failed to get image: %w", err)
 	}
+	if err := s.checkViewer(ctx, viewerID, imageID); err != nil {
+		return SignedURLResponse{}, err
+	}
 
 	// Try cache first
 	if cachedURL, err := s.cache.GetCachedSignedURL(ctx, imageID); err == nil {
@@ -375,7 +454,7 @@ func (s *Service) validateUploadRequest(req UploadImageRequest) error {
 	if req.FileSize <= 0 {
 		return errors.New("file size must be positive")
 	}
-	if req.FileSize > s.config.MaxFileSize {
+	if req.FileSize > s.maxUploadSize() {
 		return errors.New("file size too large")
 	}
 	if !s.isValidMimeType(req.MimeType) {
@@ -14,6 +14,9 @@ type mockStore struct {
 	images map[string]Image
 	quotas map[string]QuotaStatus
 	stats  map[string]ImageStats
+	shares map[string]bool // imageID + "/" + viewerID of images in shared albums
+
+	privateAlbum map[string]bool // images in albums that are not public
 }
 
 func newMockStore() *mockStore {
@@ -21,6 +24,9 @@ func newMockStore() *mockStore {
 		images: make(map[string]Image),
 		quotas: make(map[string]QuotaStatus),
 		stats:  make(map[string]ImageStats),
+		shares: make(map[string]bool),
+
+		privateAlbum: make(map[string]bool),
 	}
 }
 
@@ -100,6 +106,23 @@ func (m *mockStore) ListImages(ctx context.Context, req ImageListRequest) (Image
 	}, nil
 }
 
+func (m *mockStore) CanViewImage(ctx context.Context, imageID, viewerID string) (bool, error) {
+	image, exists := m.images[imageID]
+	if !exists {
+		return false, nil
+	}
+	owner := viewerID != "" && image.UserID != nil && *image.UserID == viewerID
+	return owner || (image.IsPublic && !m.privateAlbum[imageID]) || m.shares[imageID+"/"+viewerID], nil
+}
+
+func (m *mockStore) PrivateAlbumImages(ctx context.Context, imageIDs []string) (map[string]bool, error) {
+	private := make(map[string]bool)
+	for _, id := range imageIDs {
+		private[id] = m.privateAlbum[id]
+	}
+	return private, nil
+}
+
 func (m *mockStore) CanUploadImage(ctx context.Context, userID *string, vendorID *string, imageType ImageType, fileSize int64) (bool, error) {
 	key := "default"
 	if userID != nil {
@@ -236,6 +259,10 @@ func (m *mockImageProcessor) GetImageDimensions(ctx context.Context, data []byte
 	return 800, 600, nil
 }
 
+func (m *mockImageProcessor) IngestImage(ctx context.Context, data []byte) (IngestResult, error) {
+	return IngestResult{Data: data, Width: 800, Height: 600, OriginalWidth: 800, OriginalHeight: 600, Orientation: OrientationNormal}, nil
+}
+
 type mockUsageTracker struct{}
 
 func (m *mockUsageTracker) RecordUsage(ctx context.Context, imageID string, userID *string, action string, metadata map[string]interface{}) error {
@@ -543,6 +570,49 @@ func TestDeleteImage(t *testing.T) {
 	}
 }
 
+func TestGenerateSignedURLChecksViewer(t *testing.T) {
+	store := newMockStore()
+	service := NewService(
+		store,
+		&mockFileStorage{},
+		&mockImageProcessor{},
+		&mockUsageTracker{},
+		&mockCache{},
+		&mockNotificationService{},
+		&mockAuditLogger{},
+		&mockRateLimiter{},
+		StorageConfig{
+			MaxFileSize:  10 * 1024 * 1024,
+			AllowedTypes: []string{"image/jpeg", "image/png"},
+			SignedURLTTL: 3600,
+		},
+	)
+
+	ownerID := "owner-id"
+	image, err := service.UploadImage(context.Background(), &ownerID, nil, UploadImageRequest{
+		Type:     ImageTypeUser,
+		FileName: "test.jpg",
+		FileSize: 1024,
+		MimeType: "image/jpeg",
+		File:     &mockReader{data: make([]byte, 1024)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := service.GenerateSignedURL(context.Background(), ownerID, image.ID, AccessTypeView); err != nil {
+		t.Errorf("Expected the owner to get a signed URL, got %v", err)
+	}
+	if _, err := service.GenerateSignedURL(context.Background(), "other-id", image.ID, AccessTypeView); !errors.Is(err, ErrImageAccessDenied) {
+		t.Errorf("Expected ErrImageAccessDenied for another user, got %v", err)
+	}
+
+	store.shares[image.ID+"/other-id"] = true
+	if _, err := service.GenerateSignedURL(context.Background(), "other-id", image.ID, AccessTypeView); err != nil {
+		t.Errorf("Expected a user the album is shared with to get a signed URL, got %v", err)
+	}
+}
+
 // Helper types for testing
 
 type mockReader struct {
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -27,9 +29,10 @@ func (s *DBStore) CreateImage(ctx context.Context, req CreateImageRequest) (Imag
 	query := `
 		INSERT INTO images (
 			id, user_id, vendor_id, type, file_name, original_url, thumbnail_url,
-			file_size, mime_type, width, height, is_public, tags, metadata
+			file_size, mime_type, width, height, is_public, tags, metadata,
+			content_hash, perceptual_hash, original_width, original_height
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
 		) RETURNING id, created_at, updated_at`
 
 	var image Image
@@ -70,6 +73,10 @@ func (s *DBStore) CreateImage(ctx context.Context, req CreateImageRequest) (Imag
 		req.IsPublic,
 		tagsArg,
 		metadataJSONStr,
+		req.ContentHash,
+		req.PerceptualHash,
+		req.OriginalWidth,
+		req.OriginalHeight,
 	).Scan(&image.ID, &image.CreatedAt, &image.UpdatedAt)
 
 	if err != nil {
@@ -87,6 +94,8 @@ func (s *DBStore) CreateImage(ctx context.Context, req CreateImageRequest) (Imag
 	image.MimeType = req.MimeType
 	image.Width = req.Width
 	image.Height = req.Height
+	image.OriginalWidth = req.OriginalWidth
+	image.OriginalHeight = req.OriginalHeight
 	image.IsPublic = req.IsPublic
 	image.Tags = req.Tags
 	image.Metadata = req.Metadata
@@ -99,9 +108,9 @@ func (s *DBStore) GetImage(ctx context.Context, imageID string) (Image, error) {
 	query := `
 		SELECT id, user_id, vendor_id, type, file_name, original_url, thumbnail_url,
 			   file_size, mime_type, width, height, is_public, tags, metadata,
-			   created_at, updated_at
+			   created_at, updated_at, original_width, original_height
 		FROM images
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var image Image
 	var metadataJSON string
@@ -122,6 +131,8 @@ func (s *DBStore) GetImage(ctx context.Context, imageID string) (Image, error) {
 		&metadataJSON,
 		&image.CreatedAt,
 		&image.UpdatedAt,
+		&image.OriginalWidth,
+		&image.OriginalHeight,
 	)
 	if err != nil {
 		return Image{}, fmt.Errorf("failed to get image: %w", err)
@@ -177,7 +188,7 @@ func (s *DBStore) UpdateImage(ctx context.Context, imageID string, req UpdateIma
 	query := fmt.Sprintf(`
 		UPDATE images 
 		SET %s
-		WHERE id = $%d
+		WHERE id = $%d AND deleted_at IS NULL
 		RETURNING id, user_id, vendor_id, type, file_name, original_url, thumbnail_url,
 				  file_size, mime_type, width, height, is_public, tags, metadata,
 				  created_at, updated_at`,
@@ -245,8 +256,13 @@ func (s *DBStore) DeleteImage(ctx context.Context, imageID string) error {
 
 // ListImages retrieves images with filtering and pagination
 func (s *DBStore) ListImages(ctx context.Context, req ImageListRequest) (ImageListResponse, error) {
-	// Build WHERE clause
-	whereParts := []string{}
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return ImageListResponse{}, err
+	}
+
+	// Build WHERE clause, trashed images are listed separately
+	whereParts := []string{"deleted_at IS NULL"}
 	args := []interface{}{}
 	argIndex := 1
 
@@ -280,16 +296,25 @@ func (s *DBStore) ListImages(ctx context.Context, req ImageListRequest) (ImageLi
 		argIndex++
 	}
 
-	whereClause := ""
-	if len(whereParts) > 0 {
-		whereClause = "WHERE " + strings.Join(whereParts, " AND ")
+	if req.AlbumID != nil {
+		whereParts = append(whereParts, fmt.Sprintf("album_id::text = $%d", argIndex))
+		args = append(args, *req.AlbumID)
+		argIndex++
+	}
+
+	if req.ViewerID != "" {
+		whereParts = append(whereParts, viewerCondition(argIndex))
+		args = append(args, req.ViewerID)
+		argIndex++
 	}
 
-	// Count total records
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM images %s", whereClause)
+	whereClause := "WHERE " + strings.Join(whereParts, " AND ")
+
+	// Count total records; cursor pages skip the count
 	var total int
-	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
+	if cursor != nil {
+		req.Page = 0
+	} else if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM images "+whereClause, args...).Scan(&total); err != nil {
 		return ImageListResponse{}, fmt.Errorf("failed to count images: %w", err)
 	}
 
@@ -297,8 +322,13 @@ func (s *DBStore) ListImages(ctx context.Context, req ImageListRequest) (ImageLi
 	offset := (req.Page - 1) * req.PageSize
 	totalPages := (total + req.PageSize - 1) / req.PageSize
 
-	// Add pagination to args
-	args = append(args, req.PageSize, offset)
+	if condition, cursorArgs := cursor.Condition("created_at", "id", argIndex); condition != "" {
+		whereClause += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("created_at", "id", cursor, req.PageSize, offset, argIndex)
+	args = append(args, pageArgs...)
 
 	// Get images
 	query := fmt.Sprintf(`
@@ -306,12 +336,9 @@ func (s *DBStore) ListImages(ctx context.Context, req ImageListRequest) (ImageLi
 			   file_size, mime_type, width, height, is_public, tags, metadata,
 			   created_at, updated_at
 		FROM images
-		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`,
+		%s%s`,
 		whereClause,
-		argIndex,
-		argIndex+1,
+		clause,
 	)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -360,12 +387,17 @@ func (s *DBStore) ListImages(ctx context.Context, req ImageListRequest) (ImageLi
 		return ImageListResponse{}, fmt.Errorf("failed to iterate images: %w", err)
 	}
 
+	images, nextCursor := common.NextPage(images, req.PageSize, func(image Image) (time.Time, string) {
+		return image.CreatedAt, image.ID
+	})
+
 	return ImageListResponse{
 		Images:     images,
 		Total:      total,
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -449,7 +481,9 @@ func (s *DBStore) GetImageStats(ctx context.Context, userID *string, vendorID *s
 				COUNT(*) FILTER (WHERE is_public = false) as private_images,
 				SUM(file_size) as total_file_size,
 				AVG(file_size) as average_file_size,
-				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days
+				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days,
+				COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) as trashed_images,
+				COALESCE(SUM(file_size) FILTER (WHERE deleted_at IS NOT NULL), 0) as trashed_file_size
 			FROM images
 			WHERE user_id = $1`
 		args = []interface{}{*userID}
@@ -464,7 +498,9 @@ func (s *DBStore) GetImageStats(ctx context.Context, userID *string, vendorID *s
 				COUNT(*) FILTER (WHERE is_public = false) as private_images,
 				SUM(file_size) as total_file_size,
 				AVG(file_size) as average_file_size,
-				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days
+				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days,
+				COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) as trashed_images,
+				COALESCE(SUM(file_size) FILTER (WHERE deleted_at IS NOT NULL), 0) as trashed_file_size
 			FROM images
 			WHERE vendor_id = $1`
 		args = []interface{}{*vendorID}
@@ -479,7 +515,9 @@ func (s *DBStore) GetImageStats(ctx context.Context, userID *string, vendorID *s
 				COUNT(*) FILTER (WHERE is_public = false) as private_images,
 				SUM(file_size) as total_file_size,
 				AVG(file_size) as average_file_size,
-				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days
+				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') as images_last_30_days,
+				COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) as trashed_images,
+				COALESCE(SUM(file_size) FILTER (WHERE deleted_at IS NOT NULL), 0) as trashed_file_size
 			FROM images`
 		args = []interface{}{}
 	}
@@ -495,6 +533,8 @@ func (s *DBStore) GetImageStats(ctx context.Context, userID *string, vendorID *s
 		&stats.TotalFileSize,
 		&stats.AverageFileSize,
 		&stats.ImagesLast30Days,
+		&stats.TrashedImages,
+		&stats.TrashedFileSize,
 	)
 
 	if err != nil {
@@ -245,6 +245,16 @@ func (s *postgresStore) ListImages(ctx context.Context, req ImageListRequest) (I
 		args = append(args, pq.StringArray(req.Tags))
 		argIndex++
 	}
+	if req.AlbumID != nil {
+		query += fmt.Sprintf(" AND album_id::text = $%d", argIndex)
+		args = append(args, *req.AlbumID)
+		argIndex++
+	}
+	if req.ViewerID != "" {
+		query += " AND " + viewerCondition(argIndex)
+		args = append(args, req.ViewerID)
+		argIndex++
+	}
 
 	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
 	offset := (req.Page - 1) * req.PageSize
@@ -310,6 +320,16 @@ func (s *postgresStore) ListImages(ctx context.Context, req ImageListRequest) (I
 		countArgs = append(countArgs, pq.StringArray(req.Tags))
 		countArgIndex++
 	}
+	if req.AlbumID != nil {
+		countQuery += fmt.Sprintf(" AND album_id::text = $%d", countArgIndex)
+		countArgs = append(countArgs, *req.AlbumID)
+		countArgIndex++
+	}
+	if req.ViewerID != "" {
+		countQuery += " AND " + viewerCondition(countArgIndex)
+		countArgs = append(countArgs, req.ViewerID)
+		countArgIndex++
+	}
 
 	var total int
 	err = s.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total)
@@ -0,0 +1,372 @@
+package image
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrImageNotInTrash is returned when an image is not in the vendor's trash or
+// its restore window has passed
+var ErrImageNotInTrash = errors.New("image not found in trash")
+
+// ErrTrashDisabled is returned by trash operations when no trash is configured
+var ErrTrashDisabled = errors.New("image trash is not enabled")
+
+// trashPurgeBatchSize bounds the images hard-deleted per store round trip
+const trashPurgeBatchSize = 100
+
+// SetTrash makes DeleteImage move vendor images to the trash, where they stay
+// restorable for restoreWindow before PurgeExpiredTrash removes them
+func (s *Service) SetTrash(trash TrashStore, restoreWindow time.Duration) {
+	s.trash = trash
+	s.trashWindow = restoreWindow
+}
+
+// trashImage soft-deletes a vendor image, keeping its files for a restore
+func (s *Service) trashImage(ctx context.Context, image Image) error {
+	deletedAt, err := s.trash.TrashImage(ctx, image.ID)
+	if err != nil {
+		return fmt.Errorf("failed to trash image: %w", err)
+	}
+	restoreUntil := deletedAt.Add(s.trashWindow)
+
+	// Record usage
+	_ = s.usageTracker.RecordUsage(ctx, image.ID, image.UserID, ActionTrash, map[string]interface{}{
+		"file_name":     image.FileName,
+		"restore_until": restoreUntil,
+	})
+
+	// Log the action
+	_ = s.auditLogger.LogImageAction(ctx, image.ID, image.UserID, image.VendorID, "image_trashed", map[string]interface{}{
+		"file_name":     image.FileName,
+		"file_size":     image.FileSize,
+		"restore_until": restoreUntil,
+	})
+
+	// Send notification
+	_ = s.notifier.SendImageDeleted(ctx, image.UserID, image.VendorID, image.ID, image.Type)
+
+	// Clear cache
+	_ = s.cache.Delete(ctx, fmt.Sprintf("image:%s", image.ID))
+	_ = s.cache.Delete(ctx, fmt.Sprintf("signed_url:%s", image.ID))
+
+	// The files stay in storage for a restore but leave the gallery now
+	s.purgeCDN(ctx, image)
+
+	return nil
+}
+
+// ListTrashedImages lists the vendor's images that can still be restored
+func (s *Service) ListTrashedImages(ctx context.Context, vendorID string, req TrashListRequest) (TrashListResponse, error) {
+	if s.trash == nil {
+		return TrashListResponse{}, ErrTrashDisabled
+	}
+
+	// Set defaults
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	response, err := s.trash.ListTrashedImages(ctx, vendorID, time.Now().Add(-s.trashWindow), req)
+	if err != nil {
+		return TrashListResponse{}, fmt.Errorf("failed to list trashed images: %w", err)
+	}
+
+	for i := range response.Images {
+		response.Images[i].RestoreUntil = response.Images[i].DeletedAt.Add(s.trashWindow)
+	}
+	if response.Images == nil {
+		response.Images = []TrashedImage{}
+	}
+
+	return response, nil
+}
+
+// RestoreImage moves a trashed vendor image back into the vendor's gallery
+func (s *Service) RestoreImage(ctx context.Context, vendorID string, imageID string) (Image, error) {
+	if s.trash == nil {
+		return Image{}, ErrTrashDisabled
+	}
+
+	image, err := s.trash.RestoreImage(ctx, imageID, vendorID, time.Now().Add(-s.trashWindow))
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to restore image: %w", err)
+	}
+
+	// Record usage
+	_ = s.usageTracker.RecordUsage(ctx, image.ID, image.UserID, ActionRestore, map[string]interface{}{
+		"file_name": image.FileName,
+	})
+
+	// Log the action
+	_ = s.auditLogger.LogImageAction(ctx, image.ID, image.UserID, image.VendorID, "image_restored", map[string]interface{}{
+		"file_name": image.FileName,
+		"file_size": image.FileSize,
+	})
+
+	// Cache the image
+	_ = s.cache.CacheImage(ctx, image.ID, image)
+
+	return image, nil
+}
+
+// PurgeExpiredTrash hard-deletes trashed images past the restore window
+// together with their files and returns the number of purged images
+func (s *Service) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	if s.trash == nil {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-s.trashWindow)
+	purged := 0
+	for {
+		images, err := s.trash.ListExpiredTrash(ctx, cutoff, trashPurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired trash: %w", err)
+		}
+
+		for _, image := range images {
+			if err := s.store.DeleteImage(ctx, image.ID); err != nil {
+				return purged, fmt.Errorf("failed to purge image %s: %w", image.ID, err)
+			}
+
+			// Clean up files
+			_ = s.fileStorage.DeleteFile(ctx, image.OriginalURL)
+			if image.ThumbnailURL != nil {
+				_ = s.fileStorage.DeleteFile(ctx, *image.ThumbnailURL)
+			}
+			s.purgeCDN(ctx, image)
+
+			// Log the action
+			_ = s.auditLogger.LogImageAction(ctx, image.ID, image.UserID, image.VendorID, "image_purged", map[string]interface{}{
+				"file_name": image.FileName,
+				"file_size": image.FileSize,
+			})
+			purged++
+		}
+
+		if len(images) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// StartTrashPurger purges expired trash every interval until ctx is cancelled
+func (s *Service) StartTrashPurger(ctx context.Context, interval time.Duration) {
+	if s.trash == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Image trash purger started with interval: %v", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Image trash purger stopped")
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpiredTrash(ctx)
+			if err != nil {
+				log.Printf("Error purging image trash: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Purged %d trashed images", purged)
+			}
+		}
+	}
+}
+
+// TrashImage marks an image as deleted
+func (s *DBStore) TrashImage(ctx context.Context, imageID string) (time.Time, error) {
+	query := `
+		UPDATE images
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING deleted_at`
+
+	var deletedAt time.Time
+	err := s.db.QueryRowContext(ctx, query, imageID).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, fmt.Errorf("image not found")
+		}
+		return time.Time{}, fmt.Errorf("failed to trash image: %w", err)
+	}
+
+	return deletedAt, nil
+}
+
+// RestoreImage clears the deletion mark of a vendor image trashed after deletedAfter
+func (s *DBStore) RestoreImage(ctx context.Context, imageID string, vendorID string, deletedAfter time.Time) (Image, error) {
+	query := `
+		UPDATE images
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND vendor_id = $2 AND deleted_at IS NOT NULL AND deleted_at > $3
+		RETURNING id, user_id, vendor_id, type, file_name, original_url, thumbnail_url,
+				  file_size, mime_type, width, height, is_public, tags, metadata,
+				  created_at, updated_at`
+
+	var image Image
+	var metadataJSON string
+	err := s.db.QueryRowContext(ctx, query, imageID, vendorID, deletedAfter).Scan(
+		&image.ID,
+		&image.UserID,
+		&image.VendorID,
+		&image.Type,
+		&image.FileName,
+		&image.OriginalURL,
+		&image.ThumbnailURL,
+		&image.FileSize,
+		&image.MimeType,
+		&image.Width,
+		&image.Height,
+		&image.IsPublic,
+		pq.Array(&image.Tags),
+		&metadataJSON,
+		&image.CreatedAt,
+		&image.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Image{}, ErrImageNotInTrash
+		}
+		return Image{}, fmt.Errorf("failed to restore image: %w", err)
+	}
+
+	// Parse metadata JSON
+	if metadataJSON != "" {
+		if err := json.Unmarshal([]byte(metadataJSON), &image.Metadata); err != nil {
+			return Image{}, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+
+	return image, nil
+}
+
+// ListTrashedImages lists a vendor's images trashed after deletedAfter, most recently deleted first
+func (s *DBStore) ListTrashedImages(ctx context.Context, vendorID string, deletedAfter time.Time, req TrashListRequest) (TrashListResponse, error) {
+	var response TrashListResponse
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(file_size), 0)
+		FROM images
+		WHERE vendor_id = $1 AND deleted_at > $2`,
+		vendorID, deletedAfter,
+	).Scan(&response.Total, &response.TotalFileSize)
+	if err != nil {
+		return TrashListResponse{}, fmt.Errorf("failed to count trashed images: %w", err)
+	}
+
+	query := `
+		SELECT id, user_id, vendor_id, type, file_name, original_url, thumbnail_url,
+			   file_size, mime_type, width, height, is_public, tags, metadata,
+			   created_at, updated_at, deleted_at
+		FROM images
+		WHERE vendor_id = $1 AND deleted_at > $2
+		ORDER BY deleted_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := s.db.QueryContext(ctx, query, vendorID, deletedAfter, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		return TrashListResponse{}, fmt.Errorf("failed to list trashed images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var image TrashedImage
+		var metadataJSON string
+		err := rows.Scan(
+			&image.ID,
+			&image.UserID,
+			&image.VendorID,
+			&image.Type,
+			&image.FileName,
+			&image.OriginalURL,
+			&image.ThumbnailURL,
+			&image.FileSize,
+			&image.MimeType,
+			&image.Width,
+			&image.Height,
+			&image.IsPublic,
+			pq.Array(&image.Tags),
+			&metadataJSON,
+			&image.CreatedAt,
+			&image.UpdatedAt,
+			&image.DeletedAt,
+		)
+		if err != nil {
+			return TrashListResponse{}, fmt.Errorf("failed to scan trashed image: %w", err)
+		}
+
+		// Parse metadata JSON
+		if metadataJSON != "" {
+			if err := json.Unmarshal([]byte(metadataJSON), &image.Metadata); err != nil {
+				return TrashListResponse{}, fmt.Errorf("failed to parse metadata: %w", err)
+			}
+		}
+
+		response.Images = append(response.Images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return TrashListResponse{}, fmt.Errorf("failed to iterate trashed images: %w", err)
+	}
+
+	response.Page = req.Page
+	response.PageSize = req.PageSize
+	response.TotalPages = (response.Total + req.PageSize - 1) / req.PageSize
+	return response, nil
+}
+
+// ListExpiredTrash returns images trashed before deletedBefore, oldest first
+func (s *DBStore) ListExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) ([]Image, error) {
+	query := `
+		SELECT id, user_id, vendor_id, type, file_name, original_url, thumbnail_url, file_size
+		FROM images
+		WHERE deleted_at IS NOT NULL AND deleted_at <= $1
+		ORDER BY deleted_at
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired trash: %w", err)
+	}
+	defer rows.Close()
+
+	var images []Image
+	for rows.Next() {
+		var image Image
+		err := rows.Scan(
+			&image.ID,
+			&image.UserID,
+			&image.VendorID,
+			&image.Type,
+			&image.FileName,
+			&image.OriginalURL,
+			&image.ThumbnailURL,
+			&image.FileSize,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expired image: %w", err)
+		}
+		images = append(images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate expired trash: %w", err)
+	}
+
+	return images, nil
+}
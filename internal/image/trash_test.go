@@ -0,0 +1,174 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockTrashStore moves images between the mock store and a trash map
+type mockTrashStore struct {
+	store   *mockStore
+	trashed map[string]TrashedImage
+}
+
+func newMockTrashStore(store *mockStore) *mockTrashStore {
+	return &mockTrashStore{store: store, trashed: make(map[string]TrashedImage)}
+}
+
+func (m *mockTrashStore) TrashImage(ctx context.Context, imageID string) (time.Time, error) {
+	image, exists := m.store.images[imageID]
+	if !exists {
+		return time.Time{}, errors.New("image not found")
+	}
+	delete(m.store.images, imageID)
+	deletedAt := time.Now()
+	m.trashed[imageID] = TrashedImage{Image: image, DeletedAt: deletedAt}
+	return deletedAt, nil
+}
+
+func (m *mockTrashStore) RestoreImage(ctx context.Context, imageID string, vendorID string, deletedAfter time.Time) (Image, error) {
+	trashed, exists := m.trashed[imageID]
+	if !exists || trashed.VendorID == nil || *trashed.VendorID != vendorID || !trashed.DeletedAt.After(deletedAfter) {
+		return Image{}, ErrImageNotInTrash
+	}
+	delete(m.trashed, imageID)
+	m.store.images[imageID] = trashed.Image
+	return trashed.Image, nil
+}
+
+func (m *mockTrashStore) ListTrashedImages(ctx context.Context, vendorID string, deletedAfter time.Time, req TrashListRequest) (TrashListResponse, error) {
+	var response TrashListResponse
+	for _, trashed := range m.trashed {
+		if trashed.VendorID != nil && *trashed.VendorID == vendorID && trashed.DeletedAt.After(deletedAfter) {
+			response.Images = append(response.Images, trashed)
+			response.TotalFileSize += trashed.FileSize
+		}
+	}
+	response.Total = len(response.Images)
+	return response, nil
+}
+
+func (m *mockTrashStore) ListExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) ([]Image, error) {
+	var images []Image
+	for id, trashed := range m.trashed {
+		if !trashed.DeletedAt.After(deletedBefore) && len(images) < limit {
+			images = append(images, trashed.Image)
+			// The mock store is the source of truth for hard deletes
+			m.store.images[id] = trashed.Image
+			delete(m.trashed, id)
+		}
+	}
+	return images, nil
+}
+
+func newTrashTestService(store *mockStore, trash TrashStore, window time.Duration) *Service {
+	service := NewService(
+		store,
+		&mockFileStorage{},
+		&mockImageProcessor{},
+		&mockUsageTracker{},
+		&mockCache{},
+		&mockNotificationService{},
+		&mockAuditLogger{},
+		&mockRateLimiter{},
+		StorageConfig{
+			MaxFileSize:  10 * 1024 * 1024,
+			AllowedTypes: []string{"image/jpeg", "image/png"},
+		},
+	)
+	service.SetTrash(trash, window)
+	return service
+}
+
+func TestDeleteVendorImageMovesToTrash(t *testing.T) {
+	store := newMockStore()
+	trash := newMockTrashStore(store)
+	service := newTrashTestService(store, trash, time.Hour)
+
+	vendorID := "vendor-1"
+	store.images["img-1"] = Image{ID: "img-1", VendorID: &vendorID, Type: ImageTypeVendor, FileSize: 2048}
+
+	if err := service.DeleteImage(context.Background(), "img-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.GetImage(context.Background(), "img-1"); err == nil {
+		t.Error("Expected trashed image to be hidden")
+	}
+
+	list, err := service.ListTrashedImages(context.Background(), vendorID, TrashListRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Total != 1 || list.TotalFileSize != 2048 {
+		t.Fatalf("Expected 1 trashed image of 2048 bytes, got %d of %d bytes", list.Total, list.TotalFileSize)
+	}
+	if got := list.Images[0].RestoreUntil.Sub(list.Images[0].DeletedAt); got != time.Hour {
+		t.Errorf("Expected restore window of 1h, got %v", got)
+	}
+
+	if _, err := service.RestoreImage(context.Background(), "vendor-2", "img-1"); !errors.Is(err, ErrImageNotInTrash) {
+		t.Errorf("Expected ErrImageNotInTrash for another vendor, got %v", err)
+	}
+
+	restored, err := service.RestoreImage(context.Background(), vendorID, "img-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.ID != "img-1" {
+		t.Errorf("Expected restored image img-1, got %s", restored.ID)
+	}
+	if _, err := service.GetImage(context.Background(), "img-1"); err != nil {
+		t.Errorf("Expected restored image to be visible, got %v", err)
+	}
+}
+
+func TestDeleteUserImageBypassesTrash(t *testing.T) {
+	store := newMockStore()
+	trash := newMockTrashStore(store)
+	service := newTrashTestService(store, trash, time.Hour)
+
+	userID := "user-1"
+	store.images["img-1"] = Image{ID: "img-1", UserID: &userID, Type: ImageTypeUser}
+
+	if err := service.DeleteImage(context.Background(), "img-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(trash.trashed) != 0 {
+		t.Errorf("Expected user image to be deleted permanently, got %d trashed", len(trash.trashed))
+	}
+	if _, exists := store.images["img-1"]; exists {
+		t.Error("Expected user image to be removed from the store")
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	store := newMockStore()
+	trash := newMockTrashStore(store)
+	service := newTrashTestService(store, trash, time.Hour)
+
+	vendorID := "vendor-1"
+	old := Image{ID: "old", VendorID: &vendorID, Type: ImageTypeVendor}
+	recent := Image{ID: "recent", VendorID: &vendorID, Type: ImageTypeVendor}
+	trash.trashed["old"] = TrashedImage{Image: old, DeletedAt: time.Now().Add(-2 * time.Hour)}
+	trash.trashed["recent"] = TrashedImage{Image: recent, DeletedAt: time.Now()}
+
+	purged, err := service.PurgeExpiredTrash(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged image, got %d", purged)
+	}
+	if _, exists := trash.trashed["recent"]; !exists {
+		t.Error("Expected image within the restore window to stay in the trash")
+	}
+	if _, exists := store.images["old"]; exists {
+		t.Error("Expected expired image to be hard-deleted")
+	}
+
+	if _, err := service.RestoreImage(context.Background(), vendorID, "old"); !errors.Is(err, ErrImageNotInTrash) {
+		t.Errorf("Expected ErrImageNotInTrash after purge, got %v", err)
+	}
+}
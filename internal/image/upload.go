@@ -0,0 +1,383 @@
+package image
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-styler/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrDirectUploadsDisabled is returned when no direct upload storage is configured
+	ErrDirectUploadsDisabled = errors.New("direct uploads are not enabled")
+	// ErrUploadNotFound is returned for unknown uploads and uploads of other owners
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrUploadNotPending is returned when an upload was already confirmed or expired
+	ErrUploadNotPending = errors.New("upload was already confirmed or has expired")
+	// ErrUploadMissing is returned when confirming before the file was uploaded
+	ErrUploadMissing = errors.New("file has not been uploaded yet")
+)
+
+// uploadPurgeBatchSize bounds the expired uploads removed per store round trip
+const uploadPurgeBatchSize = 100
+
+// SetDirectUploads lets clients upload files straight to storage with URLs
+// that stay valid for ttl
+func (s *Service) SetDirectUploads(sessions UploadSessionStore, uploads storage.DirectUploads, ttl time.Duration) {
+	s.uploadSessions = sessions
+	s.directUploads = uploads
+	s.uploadTTL = ttl
+}
+
+// PresignUpload validates the metadata of a file and returns a one-time URL
+// the client uploads it to
+func (s *Service) PresignUpload(ctx context.Context, userID *string, vendorID *string, req PresignUploadRequest) (PresignUploadResponse, error) {
+	if s.uploadSessions == nil || s.directUploads == nil {
+		return PresignUploadResponse{}, ErrDirectUploadsDisabled
+	}
+
+	uploadReq := UploadImageRequest{
+		Type:     req.Type,
+		FileName: req.FileName,
+		FileSize: req.FileSize,
+		MimeType: req.MimeType,
+		IsPublic: req.IsPublic,
+		Tags:     req.Tags,
+	}
+	if err := s.validateUploadRequest(uploadReq); err != nil {
+		return PresignUploadResponse{}, err
+	}
+
+	imageType, ownerUserID, ownerVendorID, err := s.determineImageOwnership(userID, vendorID, req.Type)
+	if err != nil {
+		return PresignUploadResponse{}, err
+	}
+
+	rateLimitKey := s.getRateLimitKey(ownerUserID, ownerVendorID, imageType)
+	if !s.rateLimiter.Allow(ctx, rateLimitKey, 50, int64(time.Hour.Seconds())) {
+		return PresignUploadResponse{}, errors.New("rate limit exceeded for image upload")
+	}
+
+	// Refuse before the client spends bandwidth on an upload that can't be kept
+	canUpload, err := s.store.CanUploadImage(ctx, ownerUserID, ownerVendorID, imageType, req.FileSize)
+	if err != nil {
+		return PresignUploadResponse{}, fmt.Errorf("failed to check upload permission: %w", err)
+	}
+	if !canUpload {
+		return PresignUploadResponse{}, errors.New("image quota exceeded")
+	}
+
+	uploadID := uuid.New().String()
+	session, err := s.uploadSessions.CreateUploadSession(ctx, UploadSession{
+		ID:        uploadID,
+		UserID:    ownerUserID,
+		VendorID:  ownerVendorID,
+		Type:      imageType,
+		FileName:  req.FileName,
+		FileSize:  req.FileSize,
+		MimeType:  req.MimeType,
+		IsPublic:  req.IsPublic,
+		Tags:      req.Tags,
+		ObjectKey: s.uploadObjectKey(imageType, ownerUserID, ownerVendorID, uploadID, req.FileName),
+		Status:    UploadStatusPending,
+		ExpiresAt: time.Now().Add(s.uploadTTL),
+	})
+	if err != nil {
+		return PresignUploadResponse{}, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	presigned, err := s.directUploads.PresignUpload(session.ObjectKey, session.MimeType, s.uploadTTL)
+	if err != nil {
+		return PresignUploadResponse{}, fmt.Errorf("failed to sign upload URL: %w", err)
+	}
+
+	return PresignUploadResponse{
+		UploadID:  session.ID,
+		URL:       presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+	}, nil
+}
+
+// ConfirmUpload finalizes a direct upload: the uploaded file goes through the
+// same validation and processing as multipart uploads and becomes an image
+func (s *Service) ConfirmUpload(ctx context.Context, userID *string, vendorID *string, uploadID string) (Image, error) {
+	if s.uploadSessions == nil || s.directUploads == nil {
+		return Image{}, ErrDirectUploadsDisabled
+	}
+
+	session, err := s.uploadSessions.GetUploadSession(ctx, uploadID)
+	if err != nil {
+		return Image{}, err
+	}
+	if !ownsUpload(session, userID, vendorID) {
+		return Image{}, ErrUploadNotFound
+	}
+	if session.Status != UploadStatusPending || !time.Now().Before(session.ExpiresAt) {
+		return Image{}, ErrUploadNotPending
+	}
+
+	// Read before claiming so a confirmation sent too early can be retried
+	fileData, err := s.directUploads.ReadUpload(ctx, session.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return Image{}, ErrUploadMissing
+		}
+		return Image{}, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	if err := s.uploadSessions.ClaimUploadSession(ctx, session.ID); err != nil {
+		return Image{}, err
+	}
+	defer func() {
+		if err := s.directUploads.DeleteUpload(context.WithoutCancel(ctx), session.ObjectKey); err != nil {
+			log.Printf("Failed to delete direct upload %s: %v", session.ObjectKey, err)
+		}
+	}()
+
+	image, err := s.confirmUpload(ctx, session, fileData)
+	if err != nil {
+		if finishErr := s.uploadSessions.FinishUploadSession(ctx, session.ID, UploadStatusFailed, nil); finishErr != nil {
+			log.Printf("Failed to mark upload %s as failed: %v", session.ID, finishErr)
+		}
+		return Image{}, err
+	}
+
+	if err := s.uploadSessions.FinishUploadSession(ctx, session.ID, UploadStatusCompleted, &image.ID); err != nil {
+		log.Printf("Failed to mark upload %s as completed: %v", session.ID, err)
+	}
+	return image, nil
+}
+
+// confirmUpload stores the uploaded file of a claimed session
+func (s *Service) confirmUpload(ctx context.Context, session UploadSession, fileData []byte) (Image, error) {
+	if int64(len(fileData)) != session.FileSize {
+		return Image{}, fmt.Errorf("invalid upload: received %d bytes, expected %d", len(fileData), session.FileSize)
+	}
+
+	req := UploadImageRequest{
+		Type:     session.Type,
+		FileName: session.FileName,
+		FileSize: session.FileSize,
+		MimeType: session.MimeType,
+		IsPublic: session.IsPublic,
+		Tags:     session.Tags,
+	}
+	return s.storeImage(ctx, req, session.Type, session.UserID, session.VendorID, fileData)
+}
+
+// PurgeExpiredUploads expires unconfirmed uploads past their deadline and
+// deletes their files, returning the number of expired uploads
+func (s *Service) PurgeExpiredUploads(ctx context.Context) (int, error) {
+	if s.uploadSessions == nil || s.directUploads == nil {
+		return 0, nil
+	}
+
+	purged := 0
+	for {
+		sessions, err := s.uploadSessions.ExpireUploadSessions(ctx, time.Now(), uploadPurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to expire uploads: %w", err)
+		}
+
+		for _, session := range sessions {
+			if err := s.directUploads.DeleteUpload(ctx, session.ObjectKey); err != nil {
+				log.Printf("Failed to delete expired upload %s: %v", session.ObjectKey, err)
+			}
+			purged++
+		}
+
+		if len(sessions) < uploadPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// StartUploadPurger purges expired uploads every interval until ctx is cancelled
+func (s *Service) StartUploadPurger(ctx context.Context, interval time.Duration) {
+	if s.uploadSessions == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpiredUploads(ctx)
+			if err != nil {
+				log.Printf("Error purging expired uploads: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Purged %d expired uploads", purged)
+			}
+		}
+	}
+}
+
+// uploadObjectKey returns the staging key of a direct upload. Only the
+// extension of the client's file name is kept.
+func (s *Service) uploadObjectKey(imageType ImageType, userID *string, vendorID *string, uploadID string, fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, c := range strings.TrimPrefix(ext, ".") {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			ext = ""
+			break
+		}
+	}
+	return fmt.Sprintf("%s/%s/%s%s", storage.DirectUploadPrefix, s.generateStoragePath(imageType, userID, vendorID), uploadID, ext)
+}
+
+// ownsUpload reports whether the caller created the upload
+func ownsUpload(session UploadSession, userID *string, vendorID *string) bool {
+	if session.VendorID != nil {
+		return vendorID != nil && *vendorID == *session.VendorID
+	}
+	return session.UserID != nil && userID != nil && *userID == *session.UserID
+}
+
+// CreateUploadSession stores a pending direct upload
+func (s *DBStore) CreateUploadSession(ctx context.Context, session UploadSession) (UploadSession, error) {
+	query := `
+		INSERT INTO image_uploads (id, user_id, vendor_id, type, file_name, file_size, mime_type,
+								   is_public, tags, object_key, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at`
+
+	if session.Tags == nil {
+		session.Tags = []string{}
+	}
+	err := s.db.QueryRowContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.VendorID,
+		session.Type,
+		session.FileName,
+		session.FileSize,
+		session.MimeType,
+		session.IsPublic,
+		pq.Array(session.Tags),
+		session.ObjectKey,
+		session.Status,
+		session.ExpiresAt,
+	).Scan(&session.CreatedAt)
+	if err != nil {
+		return UploadSession{}, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetUploadSession retrieves a direct upload
+func (s *DBStore) GetUploadSession(ctx context.Context, uploadID string) (UploadSession, error) {
+	query := `
+		SELECT id, user_id, vendor_id, type, file_name, file_size, mime_type, is_public, tags,
+			   object_key, status, image_id, expires_at, created_at
+		FROM image_uploads
+		WHERE id = $1`
+
+	var session UploadSession
+	err := s.db.QueryRowContext(ctx, query, uploadID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.VendorID,
+		&session.Type,
+		&session.FileName,
+		&session.FileSize,
+		&session.MimeType,
+		&session.IsPublic,
+		pq.Array(&session.Tags),
+		&session.ObjectKey,
+		&session.Status,
+		&session.ImageID,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return UploadSession{}, ErrUploadNotFound
+		}
+		return UploadSession{}, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	return session, nil
+}
+
+// ClaimUploadSession moves a pending, unexpired upload to processing
+func (s *DBStore) ClaimUploadSession(ctx context.Context, uploadID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE image_uploads
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status = $3 AND expires_at > NOW()`,
+		uploadID, UploadStatusProcessing, UploadStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to claim upload: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to claim upload: %w", err)
+	}
+	if rows == 0 {
+		return ErrUploadNotPending
+	}
+	return nil
+}
+
+// FinishUploadSession records the outcome of a claimed upload
+func (s *DBStore) FinishUploadSession(ctx context.Context, uploadID string, status string, imageID *string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE image_uploads
+		SET status = $2, image_id = $3, updated_at = NOW()
+		WHERE id = $1`,
+		uploadID, status, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to finish upload: %w", err)
+	}
+	return nil
+}
+
+// ExpireUploadSessions marks pending uploads that expired before
+// expiredBefore as expired and returns them
+func (s *DBStore) ExpireUploadSessions(ctx context.Context, expiredBefore time.Time, limit int) ([]UploadSession, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE image_uploads
+		SET status = $1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM image_uploads
+			WHERE status = $2 AND expires_at < $3
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, object_key`,
+		UploadStatusExpired, UploadStatusPending, expiredBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []UploadSession
+	for rows.Next() {
+		session := UploadSession{Status: UploadStatusExpired}
+		if err := rows.Scan(&session.ID, &session.ObjectKey); err != nil {
+			return nil, fmt.Errorf("failed to scan upload: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
@@ -0,0 +1,207 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/storage"
+)
+
+// mockUploadSessionStore keeps upload sessions in memory
+type mockUploadSessionStore struct {
+	sessions map[string]UploadSession
+}
+
+func (m *mockUploadSessionStore) CreateUploadSession(ctx context.Context, session UploadSession) (UploadSession, error) {
+	session.CreatedAt = time.Now()
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *mockUploadSessionStore) GetUploadSession(ctx context.Context, uploadID string) (UploadSession, error) {
+	session, exists := m.sessions[uploadID]
+	if !exists {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+func (m *mockUploadSessionStore) ClaimUploadSession(ctx context.Context, uploadID string) error {
+	session := m.sessions[uploadID]
+	if session.Status != UploadStatusPending || !time.Now().Before(session.ExpiresAt) {
+		return ErrUploadNotPending
+	}
+	session.Status = UploadStatusProcessing
+	m.sessions[uploadID] = session
+	return nil
+}
+
+func (m *mockUploadSessionStore) FinishUploadSession(ctx context.Context, uploadID string, status string, imageID *string) error {
+	session := m.sessions[uploadID]
+	session.Status = status
+	session.ImageID = imageID
+	m.sessions[uploadID] = session
+	return nil
+}
+
+func (m *mockUploadSessionStore) ExpireUploadSessions(ctx context.Context, expiredBefore time.Time, limit int) ([]UploadSession, error) {
+	var expired []UploadSession
+	for id, session := range m.sessions {
+		if session.Status == UploadStatusPending && session.ExpiresAt.Before(expiredBefore) && len(expired) < limit {
+			session.Status = UploadStatusExpired
+			m.sessions[id] = session
+			expired = append(expired, session)
+		}
+	}
+	return expired, nil
+}
+
+// mockDirectUploads stores uploaded objects in memory
+type mockDirectUploads struct {
+	objects map[string][]byte
+}
+
+func (m *mockDirectUploads) PresignUpload(key, contentType string, ttl time.Duration) (storage.PresignedUpload, error) {
+	return storage.PresignedUpload{URL: "/api/storage/uploads/" + key, Method: "PUT", ExpiresAt: time.Now().Add(ttl)}, nil
+}
+
+func (m *mockDirectUploads) ReadUpload(ctx context.Context, key string) ([]byte, error) {
+	data, exists := m.objects[key]
+	if !exists {
+		return nil, storage.ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (m *mockDirectUploads) DeleteUpload(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func newUploadTestService() (*Service, *mockUploadSessionStore, *mockDirectUploads) {
+	service := newTrashTestService(newMockStore(), nil, 0)
+	sessions := &mockUploadSessionStore{sessions: make(map[string]UploadSession)}
+	uploads := &mockDirectUploads{objects: make(map[string][]byte)}
+	service.SetDirectUploads(sessions, uploads, 15*time.Minute)
+	return service, sessions, uploads
+}
+
+func TestDirectUpload(t *testing.T) {
+	service, sessions, uploads := newUploadTestService()
+	ctx := context.Background()
+	userID, vendorID := "user-1", ""
+
+	presigned, err := service.PresignUpload(ctx, &userID, &vendorID, PresignUploadRequest{
+		Type:     ImageTypeUser,
+		FileName: "My Photo.JPG",
+		FileSize: 5,
+		MimeType: "image/jpeg",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	session := sessions.sessions[presigned.UploadID]
+	if !strings.HasPrefix(session.ObjectKey, storage.DirectUploadPrefix+"/users/user-1/") || !strings.HasSuffix(session.ObjectKey, ".jpg") {
+		t.Errorf("Unexpected object key %s", session.ObjectKey)
+	}
+
+	if _, err := service.ConfirmUpload(ctx, &userID, &vendorID, presigned.UploadID); !errors.Is(err, ErrUploadMissing) {
+		t.Errorf("Expected ErrUploadMissing before the upload, got %v", err)
+	}
+
+	uploads.objects[session.ObjectKey] = []byte("image")
+
+	otherUser := "user-2"
+	if _, err := service.ConfirmUpload(ctx, &otherUser, &vendorID, presigned.UploadID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Expected ErrUploadNotFound for another user, got %v", err)
+	}
+
+	image, err := service.ConfirmUpload(ctx, &userID, &vendorID, presigned.UploadID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if image.FileName != "My Photo.JPG" || image.FileSize != 5 {
+		t.Errorf("Expected the declared file, got %s of %d bytes", image.FileName, image.FileSize)
+	}
+	if status := sessions.sessions[presigned.UploadID].Status; status != UploadStatusCompleted {
+		t.Errorf("Expected upload to be completed, got %s", status)
+	}
+	if _, exists := uploads.objects[session.ObjectKey]; exists {
+		t.Error("Expected the staged file to be deleted")
+	}
+
+	if _, err := service.ConfirmUpload(ctx, &userID, &vendorID, presigned.UploadID); !errors.Is(err, ErrUploadNotPending) {
+		t.Errorf("Expected ErrUploadNotPending on a second confirmation, got %v", err)
+	}
+}
+
+func TestDirectUpload_SizeMismatch(t *testing.T) {
+	service, sessions, uploads := newUploadTestService()
+	ctx := context.Background()
+	userID, vendorID := "user-1", ""
+
+	presigned, err := service.PresignUpload(ctx, &userID, &vendorID, PresignUploadRequest{
+		Type:     ImageTypeUser,
+		FileName: "photo.png",
+		FileSize: 3,
+		MimeType: "image/png",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	uploads.objects[sessions.sessions[presigned.UploadID].ObjectKey] = []byte("larger than declared")
+
+	if _, err := service.ConfirmUpload(ctx, &userID, &vendorID, presigned.UploadID); err == nil || !strings.Contains(err.Error(), "invalid upload") {
+		t.Errorf("Expected an invalid upload error, got %v", err)
+	}
+	if status := sessions.sessions[presigned.UploadID].Status; status != UploadStatusFailed {
+		t.Errorf("Expected upload to be failed, got %s", status)
+	}
+}
+
+func TestDirectUpload_Validation(t *testing.T) {
+	service, _, _ := newUploadTestService()
+	ctx := context.Background()
+	userID, vendorID := "user-1", ""
+
+	if _, err := service.PresignUpload(ctx, &userID, &vendorID, PresignUploadRequest{
+		Type:     ImageTypeUser,
+		FileName: "notes.txt",
+		FileSize: 10,
+		MimeType: "text/plain",
+	}); err == nil {
+		t.Error("Expected unsupported file types to be refused")
+	}
+
+	unconfigured := newTrashTestService(newMockStore(), nil, 0)
+	if _, err := unconfigured.PresignUpload(ctx, &userID, &vendorID, PresignUploadRequest{}); !errors.Is(err, ErrDirectUploadsDisabled) {
+		t.Errorf("Expected ErrDirectUploadsDisabled, got %v", err)
+	}
+}
+
+func TestPurgeExpiredUploads(t *testing.T) {
+	service, sessions, uploads := newUploadTestService()
+	userID := "user-1"
+
+	sessions.sessions["expired"] = UploadSession{ID: "expired", UserID: &userID, ObjectKey: "uploads/staging/a.jpg", Status: UploadStatusPending, ExpiresAt: time.Now().Add(-time.Minute)}
+	sessions.sessions["active"] = UploadSession{ID: "active", UserID: &userID, ObjectKey: "uploads/staging/b.jpg", Status: UploadStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+	uploads.objects["uploads/staging/a.jpg"] = []byte("a")
+	uploads.objects["uploads/staging/b.jpg"] = []byte("b")
+
+	purged, err := service.PurgeExpiredUploads(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged upload, got %d", purged)
+	}
+	if _, exists := uploads.objects["uploads/staging/a.jpg"]; exists {
+		t.Error("Expected the expired upload to be deleted")
+	}
+	if _, exists := uploads.objects["uploads/staging/b.jpg"]; !exists {
+		t.Error("Expected the active upload to be kept")
+	}
+}
@@ -2,21 +2,27 @@ package image
 
 import (
 	"database/sql"
+	"log"
 	"time"
 
+	"ai-styler/internal/config"
 	"ai-styler/internal/storage"
 )
 
 // WireImageService creates an image service with all dependencies
-func WireImageService(db *sql.DB) (*Service, *Handler) {
+func WireImageService(db *sql.DB, cfg *config.Config) (*Service, *Handler) {
 	// Create store
 	store := NewDBStore(db)
 
 	// Create file storage
+	signing, err := storage.SigningSettingsFromConfig(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
 	fileStorage, err := storage.NewStorageService(storage.StorageConfig{
-		BasePath:     "./uploads",
-		BackupPath:   "./backups",
-		SignedURLKey: "default-key-change-in-production",
+		BasePath:   "./uploads",
+		BackupPath: "./backups",
+		Signing:    signing,
 	})
 	if err != nil {
 		panic(err)
@@ -46,7 +52,7 @@ func WireImageService(db *sql.DB) (*Service, *Handler) {
 		MaxFileSize:   MaxImageFileSize,
 		AllowedTypes:  SupportedImageTypes,
 		ThumbnailPath: "./uploads/thumbnails",
-		SignedURLTTL:  int64(time.Hour.Seconds()),
+		SignedURLTTL:  int64(cfg.Storage.SignedURLTTL.Seconds()),
 	}
 
 	// Create service
@@ -62,12 +68,46 @@ func WireImageService(db *sql.DB) (*Service, *Handler) {
 		config,
 	)
 
+	// Serve public images from the CDN when one is configured
+	if cdn, err := storage.CDNFromConfig(cfg.Storage); err != nil {
+		log.Printf("Image CDN disabled: %v", err)
+	} else if cdn != nil {
+		service.SetCDN(cdn)
+	}
+
 	// Create handler
 	handler := NewHandler(service)
 
 	return service, handler
 }
 
+// WireDirectUploads creates the direct upload storage of the configured
+// backend. Local uploads are signed with the storage URL signing keys.
+func WireDirectUploads(cfg *config.Config) (storage.DirectUploads, error) {
+	signing, err := storage.SigningSettingsFromConfig(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := storage.NewURLSigner(storage.URLSignerConfig{
+		Keys:        signing.Keys,
+		ActiveKeyID: signing.ActiveKeyID,
+		TTLs:        signing.TTLs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewDirectUploads(storage.ObjectStoreConfig{
+		Backend:     cfg.Storage.Backend,
+		BasePath:    cfg.Storage.StoragePath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3Region:    cfg.Storage.S3Region,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+	}, signer)
+}
+
 // WireImageServiceWithMocks creates an image service with mock dependencies for testing
 func WireImageServiceWithMocks(store Store) (*Service, *Handler) {
 	// Create mock dependencies
@@ -0,0 +1,253 @@
+package ipfilter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RangeResolver resolves countries from a sorted list of address ranges
+type RangeResolver struct {
+	ranges []countryRange
+}
+
+type countryRange struct {
+	start   net.IP // 16-byte form
+	end     net.IP
+	country string
+}
+
+// NewRangeResolver creates a resolver from CIDR ranges mapped to country
+// codes. Ranges must not overlap.
+func NewRangeResolver(networks map[string]string) (*RangeResolver, error) {
+	r := &RangeResolver{}
+	for cidr, country := range networks {
+		if err := r.add(cidr, country); err != nil {
+			return nil, err
+		}
+	}
+	r.sort()
+	return r, nil
+}
+
+// GeoLite2 Country CSV file names
+const (
+	geoLiteLocationsFile = "GeoLite2-Country-Locations-en.csv"
+	geoLiteIPv4File      = "GeoLite2-Country-Blocks-IPv4.csv"
+	geoLiteIPv6File      = "GeoLite2-Country-Blocks-IPv6.csv"
+)
+
+// LoadResolver loads a country resolver from path: an extracted GeoLite2
+// Country CSV directory, or a "network,country" CSV file
+func LoadResolver(path string) (*RangeResolver, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	if !info.IsDir() {
+		return LoadRangesCSV(path)
+	}
+
+	blocks := []string{filepath.Join(path, geoLiteIPv4File)}
+	if _, err := os.Stat(filepath.Join(path, geoLiteIPv6File)); err == nil {
+		blocks = append(blocks, filepath.Join(path, geoLiteIPv6File))
+	}
+	return LoadGeoLiteCSV(filepath.Join(path, geoLiteLocationsFile), blocks...)
+}
+
+// LoadGeoLiteCSV loads the MaxMind GeoLite2 Country CSV database. blocksPaths
+// are the GeoLite2-Country-Blocks-IPv4.csv and -IPv6.csv files; locationsPath
+// is a GeoLite2-Country-Locations-*.csv file mapping geoname IDs to countries.
+func LoadGeoLiteCSV(locationsPath string, blocksPaths ...string) (*RangeResolver, error) {
+	countries := make(map[string]string)
+	err := readCSV(locationsPath, func(header map[string]int, record []string) error {
+		countries[record[header["geoname_id"]]] = record[header["country_iso_code"]]
+		return nil
+	}, "geoname_id", "country_iso_code")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RangeResolver{}
+	for _, path := range blocksPaths {
+		err := readCSV(path, func(header map[string]int, record []string) error {
+			// Fall back to the registered country for anonymous proxies and
+			// satellite providers, which have no geoname_id
+			geonameID := record[header["geoname_id"]]
+			if geonameID == "" {
+				geonameID = record[header["registered_country_geoname_id"]]
+			}
+			country := countries[geonameID]
+			if country == "" {
+				return nil
+			}
+			return r.add(record[header["network"]], country)
+		}, "network", "geoname_id", "registered_country_geoname_id")
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.sort()
+	return r, nil
+}
+
+// GeoLite2 ASN CSV file names
+const (
+	geoLiteASNIPv4File = "GeoLite2-ASN-Blocks-IPv4.csv"
+	geoLiteASNIPv6File = "GeoLite2-ASN-Blocks-IPv6.csv"
+)
+
+// LoadASNResolver loads an autonomous system resolver from path: an
+// extracted GeoLite2 ASN CSV directory or one of its blocks files. Look
+// addresses up with ASN.
+func LoadASNResolver(path string) (*RangeResolver, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open asn database: %w", err)
+	}
+	blocks := []string{path}
+	if info.IsDir() {
+		blocks = []string{filepath.Join(path, geoLiteASNIPv4File)}
+		if _, err := os.Stat(filepath.Join(path, geoLiteASNIPv6File)); err == nil {
+			blocks = append(blocks, filepath.Join(path, geoLiteASNIPv6File))
+		}
+	}
+
+	r := &RangeResolver{}
+	for _, path := range blocks {
+		err := readCSV(path, func(header map[string]int, record []string) error {
+			number := strings.TrimSpace(record[header["autonomous_system_number"]])
+			if number == "" {
+				return nil
+			}
+			return r.add(record[header["network"]], "AS"+number)
+		}, "network", "autonomous_system_number")
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.sort()
+	return r, nil
+}
+
+// LoadRangesCSV loads a CSV file of "network,country" lines, for instance
+// exported from a regional registry
+func LoadRangesCSV(path string) (*RangeResolver, error) {
+	r := &RangeResolver{}
+	err := readCSV(path, func(header map[string]int, record []string) error {
+		return r.add(record[header["network"]], record[header["country"]])
+	}, "network", "country")
+	if err != nil {
+		return nil, err
+	}
+	r.sort()
+	return r, nil
+}
+
+// Country returns the country code of ip, or "" when no range contains it
+func (r *RangeResolver) Country(ip net.IP) string {
+	ip = ip.To16()
+	if ip == nil {
+		return ""
+	}
+	// Find the last range starting at or before ip
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return bytes.Compare(r.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, r.ranges[i].end) > 0 {
+		return ""
+	}
+	return r.ranges[i].country
+}
+
+// ASN returns the autonomous system, such as "AS44244", of ip for resolvers
+// loaded with LoadASNResolver, or "" when no range contains it
+func (r *RangeResolver) ASN(ip net.IP) string {
+	return r.Country(ip)
+}
+
+// Len returns the number of ranges
+func (r *RangeResolver) Len() int {
+	return len(r.ranges)
+}
+
+func (r *RangeResolver) add(cidr, country string) error {
+	network, err := parseNetwork(cidr)
+	if err != nil {
+		return err
+	}
+
+	start := network.IP.To16()
+	end := make(net.IP, net.IPv6len)
+	copy(end, start)
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		// IPv4 addresses sit in the last four bytes of the 16-byte form
+		mask = append(net.CIDRMask(96, 128)[:12:12], mask...)
+	}
+	for i := range end {
+		end[i] |= ^mask[i]
+	}
+
+	r.ranges = append(r.ranges, countryRange{
+		start:   start,
+		end:     end,
+		country: strings.ToUpper(strings.TrimSpace(country)),
+	})
+	return nil
+}
+
+func (r *RangeResolver) sort() {
+	sort.Slice(r.ranges, func(i, j int) bool {
+		return bytes.Compare(r.ranges[i].start, r.ranges[j].start) < 0
+	})
+}
+
+// readCSV calls fn for every record of a CSV file with a header row, after
+// checking the header has the required columns
+func readCSV(path string, fn func(header map[string]int, record []string) error, required ...string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	columns, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+	header := make(map[string]int, len(columns))
+	for i, column := range columns {
+		header[strings.TrimSpace(column)] = i
+	}
+	for _, column := range required {
+		if _, ok := header[column]; !ok {
+			return fmt.Errorf("%s has no %s column", path, column)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if len(record) < len(columns) {
+			continue
+		}
+		if err := fn(header, record); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for the IP block-list
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new IP filter handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListBlocks handles GET /admin/ip-blocks
+func (h *Handler) ListBlocks(c *gin.Context) {
+	blocks, err := h.service.ListBlocks(c.Request.Context(), c.Query("all") == "true")
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, blocks)
+}
+
+// CreateBlock handles POST /admin/ip-blocks
+func (h *Handler) CreateBlock(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	block, err := h.service.CreateBlock(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, block)
+}
+
+// DeleteBlock handles DELETE /admin/ip-blocks/:id
+func (h *Handler) DeleteBlock(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteBlock(c.Request.Context(), fmt.Sprint(adminID), c.Param("id")); err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
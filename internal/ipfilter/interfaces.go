@@ -0,0 +1,25 @@
+package ipfilter
+
+import (
+	"context"
+	"net"
+)
+
+// Store defines the interface for block-list data operations
+type Store interface {
+	// ListBlocks returns the blocks, newest first. With activeOnly expired
+	// blocks are left out.
+	ListBlocks(ctx context.Context, activeOnly bool) ([]Block, error)
+
+	// CreateBlock stores a block and audits it when an admin created it
+	CreateBlock(ctx context.Context, block Block) (*Block, error)
+
+	// DeleteBlock removes a block and audits the removal
+	DeleteBlock(ctx context.Context, id, deletedBy string) error
+}
+
+// CountryResolver maps an address to its ISO 3166 country code, or "" when
+// the address is unknown
+type CountryResolver interface {
+	Country(ip net.IP) string
+}
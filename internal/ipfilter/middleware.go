@@ -0,0 +1,44 @@
+package ipfilter
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Violation kinds counted towards automatic blocking
+const (
+	ViolationRateLimited  = "rate_limited"
+	ViolationUnauthorized = "unauthorized"
+)
+
+// Middleware refuses requests from blocked addresses and refused countries
+// with 403. It must run before the rate limiter and authentication, so it
+// sees their 429 and 401 responses and counts them towards automatic blocking.
+func (s *Service) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+
+		if err := s.Check(ip); err != nil {
+			code := common.ErrCodeIPBlocked
+			if errors.Is(err, ErrCountryNotAllowed) {
+				code = common.ErrCodeRegionBlocked
+			}
+			common.RespondAPIError(c, common.NewAPIError(http.StatusForbidden, code, err.Error(), nil))
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusTooManyRequests:
+			s.RecordViolation(c.Request.Context(), ip, ViolationRateLimited)
+		case http.StatusUnauthorized:
+			s.RecordViolation(c.Request.Context(), ip, ViolationUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ipfilter
+
+import (
+	"time"
+)
+
+// Block sources
+const (
+	SourceManual = "manual" // added by an admin
+	SourceAuto   = "auto"   // added after repeated rate limit or auth failures
+)
+
+// Block denies every request from an address or CIDR range until it expires
+type Block struct {
+	ID        string     `json:"id"`
+	Network   string     `json:"network"`
+	Reason    string     `json:"reason"`
+	Source    string     `json:"source"`
+	CreatedBy *string    `json:"createdBy,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// CreateBlockRequest is the body of POST /admin/ip-blocks
+type CreateBlockRequest struct {
+	// IP is a single address or a CIDR range
+	IP     string `json:"ip" binding:"required"`
+	Reason string `json:"reason"`
+	// ExpiresIn is a duration such as "24h"; empty blocks permanently
+	ExpiresIn string `json:"expiresIn"`
+}
+
+// ListBlocksResponse is the response of GET /admin/ip-blocks
+type ListBlocksResponse struct {
+	Blocks []Block `json:"blocks"`
+	Total  int     `json:"total"`
+}
+
+// Config configures the IP filter
+type Config struct {
+	// ISO 3166 country codes. With AllowedCountries set only those countries
+	// are served; DeniedCountries are always refused. Addresses the resolver
+	// cannot place (private networks) are allowed.
+	AllowedCountries []string
+	DeniedCountries  []string
+
+	// Addresses or CIDR ranges that are never blocked, such as health checks
+	ExemptIPs []string
+
+	// An IP answered with ViolationThreshold 401 or 429 responses within
+	// ViolationWindow is blocked for AutoBlockDuration. A zero threshold
+	// disables automatic blocking.
+	ViolationThreshold int
+	ViolationWindow    time.Duration
+	AutoBlockDuration  time.Duration
+
+	// How often the block-list is reloaded to pick up other instances' blocks
+	RefreshInterval time.Duration
+}
+
+// DefaultConfig returns the default IP filter configuration
+func DefaultConfig() Config {
+	return Config{
+		ExemptIPs:          []string{"127.0.0.1", "::1"},
+		ViolationThreshold: 30,
+		ViolationWindow:    5 * time.Minute,
+		AutoBlockDuration:  time.Hour,
+		RefreshInterval:    time.Minute,
+	}
+}
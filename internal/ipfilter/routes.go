@@ -0,0 +1,15 @@
+package ipfilter
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes mounts the block-list routes on an admin-only router group
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	blocks := router.Group("/ip-blocks")
+	{
+		blocks.GET("", handler.ListBlocks)         // GET /admin/ip-blocks
+		blocks.POST("", handler.CreateBlock)       // POST /admin/ip-blocks
+		blocks.DELETE("/:id", handler.DeleteBlock) // DELETE /admin/ip-blocks/:id
+	}
+}
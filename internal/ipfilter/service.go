@@ -0,0 +1,330 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+var (
+	// ErrBlocked is returned by Check for addresses on the block-list
+	ErrBlocked = errors.New("ip address is blocked")
+	// ErrCountryNotAllowed is returned by Check for addresses in a refused country
+	ErrCountryNotAllowed = errors.New("country is not allowed")
+)
+
+// Service enforces the block-list and country restrictions. Active blocks are
+// cached and reloaded every refresh interval; rate limit and authentication
+// failures are counted per IP on each instance.
+type Service struct {
+	store    Store
+	config   Config
+	resolver CountryResolver
+	allowed  map[string]bool
+	denied   map[string]bool
+	exempt   []*net.IPNet
+
+	mu     sync.RWMutex
+	blocks []activeBlock
+
+	violationsMu sync.Mutex
+	violations   map[string]*violationWindow
+
+	now func() time.Time
+}
+
+type activeBlock struct {
+	network   *net.IPNet
+	expiresAt *time.Time
+}
+
+type violationWindow struct {
+	count int
+	start time.Time
+}
+
+// NewService creates a new IP filter service. The block-list is empty until
+// Reload is called.
+func NewService(store Store, config Config) *Service {
+	defaults := DefaultConfig()
+	if config.ViolationWindow <= 0 {
+		config.ViolationWindow = defaults.ViolationWindow
+	}
+	if config.AutoBlockDuration <= 0 {
+		config.AutoBlockDuration = defaults.AutoBlockDuration
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaults.RefreshInterval
+	}
+
+	s := &Service{
+		store:      store,
+		config:     config,
+		allowed:    countrySet(config.AllowedCountries),
+		denied:     countrySet(config.DeniedCountries),
+		violations: make(map[string]*violationWindow),
+		now:        time.Now,
+	}
+	for _, entry := range config.ExemptIPs {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		network, err := parseNetwork(entry)
+		if err != nil {
+			log.Printf("Ignoring invalid IP filter exemption %q: %v", entry, err)
+			continue
+		}
+		s.exempt = append(s.exempt, network)
+	}
+	return s
+}
+
+// SetCountryResolver enables the country allow and deny lists
+func (s *Service) SetCountryResolver(resolver CountryResolver) {
+	s.resolver = resolver
+}
+
+// Country returns the country of ip, or "" without a resolver or when the
+// address is unknown
+func (s *Service) Country(ip net.IP) string {
+	if s.resolver == nil || ip == nil {
+		return ""
+	}
+	return s.resolver.Country(ip)
+}
+
+// Check returns ErrBlocked or ErrCountryNotAllowed when requests from ip must
+// be refused
+func (s *Service) Check(ip net.IP) error {
+	if ip == nil || s.isExempt(ip) {
+		return nil
+	}
+
+	now := s.now()
+	s.mu.RLock()
+	for _, block := range s.blocks {
+		if block.network.Contains(ip) && (block.expiresAt == nil || block.expiresAt.After(now)) {
+			s.mu.RUnlock()
+			return ErrBlocked
+		}
+	}
+	s.mu.RUnlock()
+
+	if s.resolver == nil || (len(s.allowed) == 0 && len(s.denied) == 0) {
+		return nil
+	}
+	country := s.resolver.Country(ip)
+	if country == "" {
+		return nil
+	}
+	if s.denied[country] || (len(s.allowed) > 0 && !s.allowed[country]) {
+		return ErrCountryNotAllowed
+	}
+	return nil
+}
+
+// RecordViolation counts a rate limit or authentication failure of ip and
+// blocks it for the auto-block duration once the threshold is reached
+func (s *Service) RecordViolation(ctx context.Context, ip net.IP, kind string) {
+	if s.config.ViolationThreshold <= 0 || ip == nil || s.isExempt(ip) {
+		return
+	}
+
+	now := s.now()
+	key := ip.String()
+
+	s.violationsMu.Lock()
+	window, ok := s.violations[key]
+	if !ok || now.Sub(window.start) > s.config.ViolationWindow {
+		window = &violationWindow{start: now}
+		s.violations[key] = window
+	}
+	window.count++
+	reached := window.count >= s.config.ViolationThreshold
+	if reached {
+		delete(s.violations, key)
+	}
+	s.violationsMu.Unlock()
+
+	if !reached {
+		return
+	}
+
+	expiresAt := now.Add(s.config.AutoBlockDuration)
+	block, err := s.store.CreateBlock(ctx, Block{
+		Network:   hostNetwork(ip).String(),
+		Reason:    fmt.Sprintf("%d %s responses within %v", s.config.ViolationThreshold, kind, s.config.ViolationWindow),
+		Source:    SourceAuto,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		log.Printf("Failed to block %s after repeated %s responses: %v", key, kind, err)
+		return
+	}
+	log.Printf("Blocked %s until %s: %s", key, expiresAt.Format(time.RFC3339), block.Reason)
+	s.cache(*block)
+}
+
+// Reload reads the active blocks from the store
+func (s *Service) Reload(ctx context.Context) error {
+	blocks, err := s.store.ListBlocks(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	active := make([]activeBlock, 0, len(blocks))
+	for _, block := range blocks {
+		network, err := parseNetwork(block.Network)
+		if err != nil {
+			log.Printf("Ignoring invalid ip block %s: %v", block.ID, err)
+			continue
+		}
+		active = append(active, activeBlock{network: network, expiresAt: block.ExpiresAt})
+	}
+
+	s.mu.Lock()
+	s.blocks = active
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the block-list and forgets stale violation counts every
+// refresh interval. It blocks until ctx is cancelled.
+func (s *Service) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Reload(ctx); err != nil {
+			log.Printf("Failed to reload ip block-list: %v", err)
+		}
+		s.pruneViolations()
+	}
+}
+
+// ListBlocks returns the blocks, including expired ones when all is set
+func (s *Service) ListBlocks(ctx context.Context, all bool) (ListBlocksResponse, error) {
+	blocks, err := s.store.ListBlocks(ctx, !all)
+	if err != nil {
+		return ListBlocksResponse{}, err
+	}
+	return ListBlocksResponse{Blocks: blocks, Total: len(blocks)}, nil
+}
+
+// CreateBlock blocks an address or range on behalf of an admin
+func (s *Service) CreateBlock(ctx context.Context, adminID string, req CreateBlockRequest) (*Block, error) {
+	network, err := parseNetwork(req.IP)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", common.ErrValidation, err)
+	}
+
+	block := Block{
+		Network:   network.String(),
+		Reason:    strings.TrimSpace(req.Reason),
+		Source:    SourceManual,
+		CreatedBy: &adminID,
+	}
+	if req.ExpiresIn != "" {
+		duration, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%w: expiresIn must be a positive duration such as 24h", common.ErrValidation)
+		}
+		expiresAt := s.now().Add(duration)
+		block.ExpiresAt = &expiresAt
+	}
+
+	created, err := s.store.CreateBlock(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(*created)
+	return created, nil
+}
+
+// DeleteBlock lifts a block on behalf of an admin
+func (s *Service) DeleteBlock(ctx context.Context, adminID, id string) error {
+	if err := s.store.DeleteBlock(ctx, id, adminID); err != nil {
+		return err
+	}
+	return s.Reload(ctx)
+}
+
+// cache adds a new block to the cached block-list so it applies right away
+func (s *Service) cache(block Block) {
+	network, err := parseNetwork(block.Network)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.blocks = append(s.blocks, activeBlock{network: network, expiresAt: block.ExpiresAt})
+	s.mu.Unlock()
+}
+
+func (s *Service) pruneViolations() {
+	now := s.now()
+	s.violationsMu.Lock()
+	defer s.violationsMu.Unlock()
+
+	for key, window := range s.violations {
+		if now.Sub(window.start) > s.config.ViolationWindow {
+			delete(s.violations, key)
+		}
+	}
+}
+
+func (s *Service) isExempt(ip net.IP) bool {
+	for _, network := range s.exempt {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetwork parses a CIDR range or a single address as its host network
+func parseNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", value)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", value)
+	}
+	return hostNetwork(ip), nil
+}
+
+// hostNetwork returns the /32 or /128 network of a single address
+func hostNetwork(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+func countrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
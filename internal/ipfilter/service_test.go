@@ -0,0 +1,238 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockStore struct {
+	blocks []Block
+}
+
+func (m *mockStore) ListBlocks(ctx context.Context, activeOnly bool) ([]Block, error) {
+	var blocks []Block
+	for _, block := range m.blocks {
+		if activeOnly && block.ExpiresAt != nil && !block.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (m *mockStore) CreateBlock(ctx context.Context, block Block) (*Block, error) {
+	block.ID = fmt.Sprintf("block-%d", len(m.blocks)+1)
+	block.CreatedAt = time.Now()
+	m.blocks = append(m.blocks, block)
+	return &block, nil
+}
+
+func (m *mockStore) DeleteBlock(ctx context.Context, id, deletedBy string) error {
+	for i, block := range m.blocks {
+		if block.ID == id {
+			m.blocks = append(m.blocks[:i], m.blocks[i+1:]...)
+			return nil
+		}
+	}
+	return common.ErrNotFound
+}
+
+func TestService_Blocks(t *testing.T) {
+	ctx := context.Background()
+	store := &mockStore{}
+	service := NewService(store, DefaultConfig())
+
+	block, err := service.CreateBlock(ctx, "admin-1", CreateBlockRequest{IP: "203.0.113.0/24", Reason: "abuse"})
+	if err != nil {
+		t.Fatalf("Failed to create block: %v", err)
+	}
+	if block.Source != SourceManual || block.ExpiresAt != nil {
+		t.Errorf("Expected a permanent manual block, got %+v", block)
+	}
+
+	if err := service.Check(net.ParseIP("203.0.113.7")); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Expected ErrBlocked inside the range, got %v", err)
+	}
+	if err := service.Check(net.ParseIP("203.0.114.7")); err != nil {
+		t.Errorf("Expected addresses outside the range to pass, got %v", err)
+	}
+
+	if _, err := service.CreateBlock(ctx, "admin-1", CreateBlockRequest{IP: "not-an-ip"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an invalid address, got %v", err)
+	}
+	if _, err := service.CreateBlock(ctx, "admin-1", CreateBlockRequest{IP: "198.51.100.1", ExpiresIn: "-1h"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected ErrValidation for a negative duration, got %v", err)
+	}
+
+	if err := service.DeleteBlock(ctx, "admin-1", block.ID); err != nil {
+		t.Fatalf("Failed to delete block: %v", err)
+	}
+	if err := service.Check(net.ParseIP("203.0.113.7")); err != nil {
+		t.Errorf("Expected the lifted block to stop applying, got %v", err)
+	}
+}
+
+func TestService_ExpiredBlock(t *testing.T) {
+	service := NewService(&mockStore{}, DefaultConfig())
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	if _, err := service.CreateBlock(context.Background(), "admin-1", CreateBlockRequest{IP: "198.51.100.1", ExpiresIn: "1h"}); err != nil {
+		t.Fatalf("Failed to create block: %v", err)
+	}
+	if err := service.Check(net.ParseIP("198.51.100.1")); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Expected ErrBlocked before expiry, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := service.Check(net.ParseIP("198.51.100.1")); err != nil {
+		t.Errorf("Expected the block to expire, got %v", err)
+	}
+}
+
+func TestService_Countries(t *testing.T) {
+	resolver, err := NewRangeResolver(map[string]string{
+		"5.160.0.0/14":  "IR",
+		"8.8.8.0/24":    "US",
+		"2001:db8::/32": "DE",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		ip       string
+		expected error
+	}{
+		{"allowed country", Config{AllowedCountries: []string{"ir"}}, "5.161.2.3", nil},
+		{"not in allow list", Config{AllowedCountries: []string{"IR"}}, "8.8.8.8", ErrCountryNotAllowed},
+		{"unknown country", Config{AllowedCountries: []string{"IR"}}, "10.0.0.1", nil},
+		{"denied country", Config{DeniedCountries: []string{"DE"}}, "2001:db8::1", ErrCountryNotAllowed},
+		{"exempt address", Config{AllowedCountries: []string{"IR"}, ExemptIPs: []string{"8.8.8.0/24"}}, "8.8.8.8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(&mockStore{}, tt.config)
+			service.SetCountryResolver(resolver)
+			if err := service.Check(net.ParseIP(tt.ip)); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestMiddleware_AutoBlock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &mockStore{}
+	config := DefaultConfig()
+	config.ViolationThreshold = 3
+	service := NewService(store, config)
+
+	router := gin.New()
+	router.Use(service.Middleware())
+	router.GET("/login", func(c *gin.Context) {
+		common.RespondError(c, http.StatusUnauthorized, "invalid credentials")
+	})
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.RemoteAddr = "192.0.2.10:5000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < config.ViolationThreshold; i++ {
+		if code := request(); code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 before the threshold, got %d", code)
+		}
+	}
+	if code := request(); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 once blocked, got %d", code)
+	}
+
+	if len(store.blocks) != 1 {
+		t.Fatalf("Expected 1 stored block, got %d", len(store.blocks))
+	}
+	block := store.blocks[0]
+	if block.Network != "192.0.2.10/32" || block.Source != SourceAuto || block.ExpiresAt == nil {
+		t.Errorf("Expected a temporary automatic block of 192.0.2.10/32, got %+v", block)
+	}
+}
+
+func TestLoadGeoLiteCSV(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		geoLiteLocationsFile: "geoname_id,locale_code,continent_code,continent_name,country_iso_code,country_name,is_in_european_union\n" +
+			"130758,en,AS,Asia,IR,Iran,0\n" +
+			"2921044,en,EU,Europe,DE,Germany,1\n",
+		geoLiteIPv4File: "network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,is_anonymous_proxy,is_satellite_provider\n" +
+			"5.160.0.0/14,130758,130758,,0,0\n" +
+			"46.4.0.0/16,,2921044,,0,0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	resolver, err := LoadResolver(dir)
+	if err != nil {
+		t.Fatalf("Failed to load resolver: %v", err)
+	}
+	if resolver.Len() != 2 {
+		t.Errorf("Expected 2 ranges, got %d", resolver.Len())
+	}
+
+	for ip, expected := range map[string]string{
+		"5.160.0.1":     "IR",
+		"5.163.255.255": "IR",
+		"5.164.0.0":     "",
+		"46.4.10.10":    "DE",
+		"1.1.1.1":       "",
+	} {
+		if got := resolver.Country(net.ParseIP(ip)); got != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, ip, got)
+		}
+	}
+}
+
+func TestLoadASNResolver(t *testing.T) {
+	dir := t.TempDir()
+	content := "network,autonomous_system_number,autonomous_system_organization\n" +
+		"5.160.0.0/14,44244,Iran Cell Service and Communication Company\n" +
+		"46.4.0.0/16,24940,Hetzner Online GmbH\n"
+	if err := os.WriteFile(filepath.Join(dir, geoLiteASNIPv4File), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write blocks: %v", err)
+	}
+
+	resolver, err := LoadASNResolver(dir)
+	if err != nil {
+		t.Fatalf("Failed to load resolver: %v", err)
+	}
+	for ip, expected := range map[string]string{
+		"5.161.2.3":  "AS44244",
+		"46.4.10.10": "AS24940",
+		"1.1.1.1":    "",
+	} {
+		if got := resolver.ASN(net.ParseIP(ip)); got != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, ip, got)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ipfilter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"ai-styler/internal/common"
+)
+
+// DBStore implements Store on top of the ip_blocks table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// ListBlocks returns the blocks, newest first
+func (s *DBStore) ListBlocks(ctx context.Context, activeOnly bool) ([]Block, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, network::text, reason, source, created_by, expires_at, created_at
+		FROM ip_blocks
+		WHERE NOT $1 OR expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at DESC`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ip blocks: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := []Block{}
+	for rows.Next() {
+		var block Block
+		var createdBy sql.NullString
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&block.ID, &block.Network, &block.Reason, &block.Source, &createdBy, &expiresAt, &block.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ip block: %w", err)
+		}
+		if createdBy.Valid {
+			block.CreatedBy = &createdBy.String
+		}
+		if expiresAt.Valid {
+			block.ExpiresAt = &expiresAt.Time
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+// CreateBlock stores a block. Blocks added by an admin are recorded in the
+// audit log in the same transaction.
+func (s *DBStore) CreateBlock(ctx context.Context, block Block) (*Block, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ip_blocks (network, reason, source, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		block.Network, block.Reason, block.Source, block.CreatedBy, block.ExpiresAt,
+	).Scan(&block.ID, &block.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ip block: %w", err)
+	}
+
+	if block.CreatedBy != nil {
+		if err := audit(ctx, tx, *block.CreatedBy, "create", map[string]interface{}{
+			"id":        block.ID,
+			"network":   block.Network,
+			"reason":    block.Reason,
+			"expiresAt": block.ExpiresAt,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit ip block: %w", err)
+	}
+	return &block, nil
+}
+
+// DeleteBlock removes a block and records the removal in the audit log
+func (s *DBStore) DeleteBlock(ctx context.Context, id, deletedBy string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var network string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM ip_blocks WHERE id = $1
+		RETURNING network::text`, id).Scan(&network)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("ip block %s: %w", id, common.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete ip block: %w", err)
+	}
+
+	if err := audit(ctx, tx, deletedBy, "delete", map[string]interface{}{
+		"id":      id,
+		"network": network,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ip block removal: %w", err)
+	}
+	return nil
+}
+
+// audit records an admin change of the block-list
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata map[string]interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'ip_blocks', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package ipfilter
+
+import (
+	"database/sql"
+	"fmt"
+
+	"ai-styler/internal/config"
+)
+
+// WireIPFilterService creates an IP filter service with all dependencies.
+// Country restrictions need cfg.Security.GeoIPPath; an unreadable database
+// is an error rather than silently serving every country.
+func WireIPFilterService(db *sql.DB, cfg *config.Config) (*Service, error) {
+	service := NewService(NewDBStore(db), Config{
+		AllowedCountries:   cfg.Security.GeoIPAllowedCountries,
+		DeniedCountries:    cfg.Security.GeoIPDeniedCountries,
+		ExemptIPs:          cfg.Security.IPBlockExempt,
+		ViolationThreshold: cfg.Security.IPAutoBlockThreshold,
+		ViolationWindow:    cfg.Security.IPAutoBlockWindow,
+		AutoBlockDuration:  cfg.Security.IPAutoBlockDuration,
+		RefreshInterval:    cfg.Security.IPBlockListRefreshInterval,
+	})
+
+	if cfg.Security.GeoIPPath != "" {
+		resolver, err := LoadResolver(cfg.Security.GeoIPPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load geoip database: %w", err)
+		}
+		service.SetCountryResolver(resolver)
+	}
+	return service, nil
+}
@@ -0,0 +1,52 @@
+package locale
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog holds translated message templates keyed by language and message key
+type Catalog struct {
+	messages map[string]map[string]string
+}
+
+// NewCatalog creates a catalog from message templates per language code
+func NewCatalog(messages map[string]map[string]string) *Catalog {
+	return &Catalog{messages: messages}
+}
+
+// Text returns the template of key in lang, formatted with args when given.
+// Keys missing from lang fall back to DefaultLanguage, then to the key itself.
+func (c *Catalog) Text(lang, key string, args ...interface{}) string {
+	template, ok := c.lookup(strings.ToLower(lang), key)
+	if !ok {
+		template, ok = c.lookup(DefaultLanguage, key)
+	}
+	if !ok {
+		template = key
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// Has reports whether lang defines key, without falling back
+func (c *Catalog) Has(lang, key string) bool {
+	_, ok := c.lookup(strings.ToLower(lang), key)
+	return ok
+}
+
+// Keys returns the message keys defined for lang
+func (c *Catalog) Keys(lang string) []string {
+	keys := make([]string, 0, len(c.messages[lang]))
+	for key := range c.messages[lang] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (c *Catalog) lookup(lang, key string) (string, bool) {
+	template, ok := c.messages[lang][key]
+	return template, ok
+}
@@ -0,0 +1,38 @@
+package locale
+
+import "testing"
+
+func TestCatalogText(t *testing.T) {
+	catalog := NewCatalog(map[string]map[string]string{
+		LangPersian: {"greeting": "سلام %s", "only_fa": "فقط فارسی"},
+		LangEnglish: {"greeting": "Hello %s", "percent": "100%"},
+	})
+
+	tests := []struct {
+		lang     string
+		key      string
+		args     []interface{}
+		expected string
+	}{
+		{LangEnglish, "greeting", []interface{}{"Sara"}, "Hello Sara"},
+		{"EN", "greeting", []interface{}{"Sara"}, "Hello Sara"},
+		{LangPersian, "greeting", []interface{}{"سارا"}, "سلام سارا"},
+		{LangEnglish, "only_fa", nil, "فقط فارسی"},
+		{"de", "greeting", []interface{}{"Sara"}, "سلام Sara"},
+		{LangEnglish, "percent", nil, "100%"},
+		{LangEnglish, "missing_key", nil, "missing_key"},
+	}
+
+	for _, tt := range tests {
+		if got := catalog.Text(tt.lang, tt.key, tt.args...); got != tt.expected {
+			t.Errorf("Expected %q for %s/%s, got %q", tt.expected, tt.lang, tt.key, got)
+		}
+	}
+
+	if catalog.Has(LangEnglish, "only_fa") {
+		t.Error("Expected Has to ignore the default language fallback")
+	}
+	if len(catalog.Keys(LangPersian)) != 2 {
+		t.Errorf("Expected 2 Persian keys, got %d", len(catalog.Keys(LangPersian)))
+	}
+}
@@ -0,0 +1,207 @@
+package locale
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Supported languages
+const (
+	LangPersian = "fa"
+	LangEnglish = "en"
+)
+
+// DefaultLanguage is used when the client does not ask for a supported language
+const DefaultLanguage = LangPersian
+
+// Iran has observed a fixed UTC+03:30 since daylight saving was abolished in 2022
+var tehran = loadTehran()
+
+func loadTehran() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Tehran"); err == nil {
+		return loc
+	}
+	return time.FixedZone("IRST", 3*60*60+30*60)
+}
+
+// Formatter renders dates and numbers for one language. Persian uses the
+// Jalali calendar, Tehran time and Persian digits; English uses Gregorian
+// dates in UTC and ASCII digits.
+type Formatter struct {
+	lang string
+	loc  *time.Location
+}
+
+// Predefined formatters
+var (
+	Persian = Formatter{lang: LangPersian, loc: tehran}
+	English = Formatter{lang: LangEnglish, loc: time.UTC}
+)
+
+// ForLanguage returns the formatter of a language code, falling back to DefaultLanguage
+func ForLanguage(lang string) Formatter {
+	switch strings.ToLower(lang) {
+	case LangEnglish:
+		return English
+	case LangPersian:
+		return Persian
+	}
+	return ForLanguage(DefaultLanguage)
+}
+
+// Language returns the language code of the formatter
+func (f Formatter) Language() string {
+	return f.lang
+}
+
+// DateTime formats t as "۱۴۰۳/۰۱/۰۱ ۱۴:۳۰" (fa) or "2024-03-20 14:30" (en)
+func (f Formatter) DateTime(t time.Time) string {
+	return f.Date(t) + " " + f.Time(t)
+}
+
+// Date formats the calendar date of t
+func (f Formatter) Date(t time.Time) string {
+	t = t.In(f.loc)
+	if f.lang != LangPersian {
+		return t.Format("2006-01-02")
+	}
+	d := ToJalali(t)
+	return f.Digits(fmt.Sprintf("%04d/%02d/%02d", d.Year, d.Month, d.Day))
+}
+
+// LongDate formats t as "۱ فروردین ۱۴۰۳" (fa) or "20 March 2024" (en)
+func (f Formatter) LongDate(t time.Time) string {
+	t = t.In(f.loc)
+	if f.lang != LangPersian {
+		return t.Format("2 January 2006")
+	}
+	d := ToJalali(t)
+	return f.Digits(strconv.Itoa(d.Day)) + " " + d.MonthName() + " " + f.Digits(strconv.Itoa(d.Year))
+}
+
+// Time formats the wall clock time of t as HH:MM
+func (f Formatter) Time(t time.Time) string {
+	return f.Digits(t.In(f.loc).Format("15:04"))
+}
+
+// Number formats an integer with thousands separators
+func (f Formatter) Number(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	separator := ","
+	if f.lang == LangPersian {
+		separator = "٬"
+	}
+
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(c)
+	}
+	return f.Digits(sign + b.String())
+}
+
+// Decimal formats a float with the given number of decimals
+func (f Formatter) Decimal(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if f.lang == LangPersian {
+		s = strings.Replace(s, ".", "٫", 1)
+	}
+	return f.Digits(s)
+}
+
+// Percent formats an integer percentage
+func (f Formatter) Percent(p int) string {
+	if f.lang == LangPersian {
+		return f.Digits(strconv.Itoa(p)) + "٪"
+	}
+	return strconv.Itoa(p) + "%"
+}
+
+// Digits replaces ASCII digits with Persian digits for the Persian formatter
+func (f Formatter) Digits(s string) string {
+	if f.lang != LangPersian {
+		return s
+	}
+	return PersianDigits(s)
+}
+
+// PersianDigits replaces ASCII digits in s with Persian digits
+func PersianDigits(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			c = '۰' + (c - '0')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ParseAcceptLanguage returns the supported language with the highest q-value
+// in an Accept-Language header, or DefaultLanguage
+func ParseAcceptLanguage(header string) string {
+	best, bestQ := DefaultLanguage, -1.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != LangPersian && tag != LangEnglish {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+type formatterKey struct{}
+
+// WithFormatter stores the request formatter in ctx
+func WithFormatter(ctx context.Context, f Formatter) context.Context {
+	return context.WithValue(ctx, formatterKey{}, f)
+}
+
+// FromContext returns the request formatter, or the default language formatter
+func FromContext(ctx context.Context) Formatter {
+	if f, ok := ctx.Value(formatterKey{}).(Formatter); ok {
+		return f
+	}
+	return ForLanguage(DefaultLanguage)
+}
+
+// Middleware selects the formatter from the Accept-Language header so handlers
+// can render display strings with FromContext, and sets Content-Language
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := ForLanguage(ParseAcceptLanguage(c.GetHeader("Accept-Language")))
+		c.Request = c.Request.WithContext(WithFormatter(c.Request.Context(), f))
+		c.Header("Content-Language", f.Language())
+		c.Next()
+	}
+}
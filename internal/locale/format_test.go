@@ -0,0 +1,115 @@
+package locale
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestToJalali(t *testing.T) {
+	tests := []struct {
+		date     time.Time
+		expected JalaliDate
+	}{
+		{time.Date(2023, 3, 21, 0, 0, 0, 0, time.UTC), JalaliDate{1402, 1, 1}},
+		{time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), JalaliDate{1403, 1, 1}},
+		{time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), JalaliDate{1403, 12, 30}},
+		{time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), JalaliDate{1404, 1, 1}},
+		{time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), JalaliDate{1403, 10, 1}},
+		{time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), JalaliDate{1378, 12, 10}},
+	}
+
+	for _, tt := range tests {
+		got := ToJalali(tt.date)
+		if got != tt.expected {
+			t.Errorf("Expected %v for %s, got %v", tt.expected, tt.date.Format("2006-01-02"), got)
+		}
+	}
+}
+
+func TestFromJalaliRoundTrip(t *testing.T) {
+	day := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 365*50; i++ {
+		got := FromJalali(ToJalali(day), time.UTC)
+		if !got.Equal(day) {
+			t.Fatalf("Expected %s after round trip, got %s", day.Format("2006-01-02"), got.Format("2006-01-02"))
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+func TestFormatter_Persian(t *testing.T) {
+	// 2024-03-20 11:00 UTC is 14:30 in Tehran on 1 Farvardin 1403
+	ts := time.Date(2024, 3, 20, 11, 0, 0, 0, time.UTC)
+
+	if got := Persian.DateTime(ts); got != "۱۴۰۳/۰۱/۰۱ ۱۴:۳۰" {
+		t.Errorf("Expected Persian date time, got %s", got)
+	}
+	if got := Persian.LongDate(ts); got != "۱ فروردین ۱۴۰۳" {
+		t.Errorf("Expected Persian long date, got %s", got)
+	}
+	if got := Persian.Number(1234567); got != "۱٬۲۳۴٬۵۶۷" {
+		t.Errorf("Expected grouped Persian number, got %s", got)
+	}
+	if got := Persian.Decimal(87.5, 1); got != "۸۷٫۵" {
+		t.Errorf("Expected Persian decimal, got %s", got)
+	}
+	if got := Persian.Percent(40); got != "۴۰٪" {
+		t.Errorf("Expected Persian percent, got %s", got)
+	}
+}
+
+func TestFormatter_English(t *testing.T) {
+	ts := time.Date(2024, 3, 20, 11, 0, 0, 0, time.UTC)
+
+	if got := English.DateTime(ts); got != "2024-03-20 11:00" {
+		t.Errorf("Expected Gregorian date time, got %s", got)
+	}
+	if got := English.Number(-1234567); got != "-1,234,567" {
+		t.Errorf("Expected grouped number, got %s", got)
+	}
+	if got := English.Percent(40); got != "40%" {
+		t.Errorf("Expected percent, got %s", got)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                        LangPersian,
+		"en-US,en;q=0.9":          LangEnglish,
+		"fa-IR":                   LangPersian,
+		"de-DE,en;q=0.5,fa;q=0.8": LangPersian,
+		"de-DE,en;q=0.5":          LangEnglish,
+		"de-DE":                   LangPersian,
+	}
+
+	for header, expected := range tests {
+		if got := ParseAcceptLanguage(header); got != expected {
+			t.Errorf("Expected %s for %q, got %s", expected, header, got)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, FromContext(c.Request.Context()).Language())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "en-GB")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != LangEnglish {
+		t.Errorf("Expected formatter %s, got %s", LangEnglish, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Language"); got != LangEnglish {
+		t.Errorf("Expected Content-Language %s, got %s", LangEnglish, got)
+	}
+}
@@ -0,0 +1,129 @@
+package locale
+
+import "time"
+
+// JalaliMonthNames are the Persian names of the Jalali (Solar Hijri) months
+var JalaliMonthNames = [12]string{
+	"فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور",
+	"مهر", "آبان", "آذر", "دی", "بهمن", "اسفند",
+}
+
+var (
+	gregorianMonthDays = [12]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+	jalaliMonthDays    = [12]int{31, 31, 31, 31, 31, 31, 30, 30, 30, 30, 30, 29}
+)
+
+// JalaliDate is a date in the Jalali calendar
+type JalaliDate struct {
+	Year  int
+	Month int // 1-12
+	Day   int
+}
+
+// MonthName returns the Persian name of the month
+func (d JalaliDate) MonthName() string {
+	if d.Month < 1 || d.Month > 12 {
+		return ""
+	}
+	return JalaliMonthNames[d.Month-1]
+}
+
+// ToJalali converts the calendar date of t (in t's location) to the Jalali calendar.
+// It is exact for Gregorian years 1600 to 2400.
+func ToJalali(t time.Time) JalaliDate {
+	gy, gm, gd := t.Date()
+	return gregorianToJalali(gy, int(gm), gd)
+}
+
+// FromJalali returns midnight of a Jalali date in loc
+func FromJalali(date JalaliDate, loc *time.Location) time.Time {
+	gy, gm, gd := jalaliToGregorian(date.Year, date.Month, date.Day)
+	return time.Date(gy, time.Month(gm), gd, 0, 0, 0, 0, loc)
+}
+
+// gregorianToJalali counts days since 1600-01-01 (1 Dey 978) and walks the
+// 33-year Jalali leap cycle
+func gregorianToJalali(gy, gm, gd int) JalaliDate {
+	gy2, gm2, gd2 := gy-1600, gm-1, gd-1
+
+	gDayNo := 365*gy2 + (gy2+3)/4 - (gy2+99)/100 + (gy2+399)/400
+	for i := 0; i < gm2; i++ {
+		gDayNo += gregorianMonthDays[i]
+	}
+	if gm2 > 1 && isGregorianLeap(gy) {
+		gDayNo++
+	}
+	gDayNo += gd2
+
+	jDayNo := gDayNo - 79
+	cycles := jDayNo / 12053
+	jDayNo %= 12053
+
+	jy := 979 + 33*cycles + 4*(jDayNo/1461)
+	jDayNo %= 1461
+	if jDayNo >= 366 {
+		jy += (jDayNo - 1) / 365
+		jDayNo = (jDayNo - 1) % 365
+	}
+
+	month := 0
+	for ; month < 11 && jDayNo >= jalaliMonthDays[month]; month++ {
+		jDayNo -= jalaliMonthDays[month]
+	}
+
+	return JalaliDate{Year: jy, Month: month + 1, Day: jDayNo + 1}
+}
+
+// jalaliToGregorian is the inverse of gregorianToJalali
+func jalaliToGregorian(jy, jm, jd int) (int, int, int) {
+	jy2, jm2, jd2 := jy-979, jm-1, jd-1
+
+	jDayNo := 365*jy2 + (jy2/33)*8 + (jy2%33+3)/4
+	for i := 0; i < jm2; i++ {
+		jDayNo += jalaliMonthDays[i]
+	}
+	jDayNo += jd2
+
+	gDayNo := jDayNo + 79
+	gy := 1600 + 400*(gDayNo/146097)
+	gDayNo %= 146097
+
+	leap := true
+	if gDayNo >= 36525 {
+		gDayNo--
+		gy += 100 * (gDayNo / 36524)
+		gDayNo %= 36524
+		if gDayNo >= 365 {
+			gDayNo++
+		} else {
+			leap = false
+		}
+	}
+
+	gy += 4 * (gDayNo / 1461)
+	gDayNo %= 1461
+	if gDayNo >= 366 {
+		leap = false
+		gDayNo--
+		gy += gDayNo / 365
+		gDayNo %= 365
+	}
+
+	month := 0
+	for ; month < 11; month++ {
+		days := gregorianMonthDays[month]
+		if month == 1 && leap {
+			days++
+		}
+		if gDayNo < days {
+			break
+		}
+		gDayNo -= days
+	}
+
+	return gy, month + 1, gDayNo + 1
+}
+
+func isGregorianLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
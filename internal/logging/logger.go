@@ -31,12 +31,17 @@ type LoggerConfig struct {
 	Service     string
 	Version     string
 	Environment string
+
+	// RedactFields extends DefaultRedactFields with more field names whose
+	// values are removed from every entry
+	RedactFields []string
 }
 
 // StructuredLogger provides structured logging capabilities
 type StructuredLogger struct {
-	logger *logrus.Logger
-	config LoggerConfig
+	logger   *logrus.Logger
+	config   LoggerConfig
+	redactor *Redactor
 }
 
 // LogEntry represents a log entry with context
@@ -97,15 +102,23 @@ func NewStructuredLogger(config LoggerConfig) *StructuredLogger {
 	}
 
 	// Add hooks for additional functionality
+	redactor := NewRedactor(config.RedactFields)
 	logger.AddHook(&ContextHook{})
 	logger.AddHook(&CallerHook{})
+	logger.AddHook(NewRedactionHook(redactor))
 
 	return &StructuredLogger{
-		logger: logger,
-		config: config,
+		logger:   logger,
+		config:   config,
+		redactor: redactor,
 	}
 }
 
+// Redactor returns the redactor applied to the logger's entries
+func (l *StructuredLogger) Redactor() *Redactor {
+	return l.redactor
+}
+
 // Debug logs a debug message
 func (l *StructuredLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
 	l.logWithContext(ctx, logrus.DebugLevel, msg, fields)
@@ -135,8 +148,9 @@ func (l *StructuredLogger) Fatal(ctx context.Context, msg string, fields map[str
 // WithFields creates a new logger with additional fields
 func (l *StructuredLogger) WithFields(fields map[string]interface{}) *StructuredLogger {
 	return &StructuredLogger{
-		logger: l.logger.WithFields(fields).Logger,
-		config: l.config,
+		logger:   l.logger.WithFields(fields).Logger,
+		config:   l.config,
+		redactor: l.redactor,
 	}
 }
 
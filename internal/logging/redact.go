@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces secrets removed from log entries
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are the field names whose values never reach the logs.
+// Names are matched case-insensitively with dashes read as underscores.
+var DefaultRedactFields = []string{
+	"password", "secret", "token", "access_token", "refresh_token", "id_token",
+	"otp", "otp_code", "verification_code", "recovery_code",
+	"api_key", "apikey", "x_api_key", "authorization", "cookie", "set_cookie",
+	"signature", "sig", "private_key", "credentials",
+}
+
+// redactSuffixes extend the deny-list to fields such as webhook_secret or bot_token
+var redactSuffixes = []string{"_password", "_secret", "_token"}
+
+// phoneFields hold phone numbers, which are masked rather than removed so
+// support can still tell numbers apart
+var phoneFields = map[string]bool{
+	"phone": true, "mobile": true, "phone_number": true, "msisdn": true,
+}
+
+var (
+	// Iranian mobile numbers in any of their usual forms, and other E.164 numbers
+	phonePattern  = regexp.MustCompile(`(?:\+98|\b0098|\b98|\b0|\b)9\d{9}\b|\+\d{10,14}\b`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// "OTP 123456" or "code is 123456" in free text
+	otpPattern = regexp.MustCompile(`(?i)\b(otp|code)(\s+(?:is\s+)?)(\d{4,8})\b`)
+)
+
+// Redactor removes personal data and secrets from log messages and fields
+type Redactor struct {
+	fields   map[string]bool
+	keyValue *regexp.Regexp // key=value, key: value and "key":"value" pairs of deny-listed keys
+}
+
+// NewRedactor creates a redactor for the default deny-list plus extraFields
+func NewRedactor(extraFields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool)}
+	var keys []string
+	for _, field := range append(append([]string{}, DefaultRedactFields...), extraFields...) {
+		field = normalizeField(field)
+		if field == "" || r.fields[field] {
+			continue
+		}
+		r.fields[field] = true
+		keys = append(keys, regexp.QuoteMeta(field))
+	}
+	r.keyValue = regexp.MustCompile(`(?i)(\b(?:` + strings.Join(keys, "|") + `)"?\s*[:=]\s*"?)([^"&\s,;}]+)`)
+	return r
+}
+
+// String redacts phone numbers, bearer tokens, JWTs, OTP codes and the values
+// of deny-listed keys from s
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	s = bearerPattern.ReplaceAllString(s, Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = r.keyValue.ReplaceAllString(s, "${1}"+Redacted)
+	s = otpPattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+	return phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+}
+
+// Fields returns a copy of fields with deny-listed fields removed, phone
+// fields masked and every other string value redacted
+func (r *Redactor) Fields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		redacted[key] = r.Value(key, value)
+	}
+	return redacted
+}
+
+// Value redacts the value of the field key
+func (r *Redactor) Value(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	field := normalizeField(key)
+	if r.Denied(field) {
+		return Redacted
+	}
+	if phoneFields[field] {
+		if s, ok := value.(string); ok {
+			return MaskPhone(s)
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case error:
+		return r.String(v.Error())
+	case map[string]interface{}:
+		return r.Fields(v)
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, s := range v {
+			redacted[k] = fmt.Sprint(r.Value(k, s))
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = r.String(s)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.Value("", item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// Denied reports whether values of the field key are removed entirely
+func (r *Redactor) Denied(key string) bool {
+	field := normalizeField(key)
+	if r.fields[field] {
+		return true
+	}
+	for _, suffix := range redactSuffixes {
+		if strings.HasSuffix(field, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskPhone hides all but the last four digits of a phone number
+func MaskPhone(phone string) string {
+	digits := 0
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	var b strings.Builder
+	seen := 0
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			seen++
+			if seen <= digits-4 {
+				b.WriteByte('*')
+				continue
+			}
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func normalizeField(field string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(field)), "-", "_")
+}
+
+// RedactionHook redacts the message and fields of every log entry
+type RedactionHook struct {
+	redactor *Redactor
+}
+
+// NewRedactionHook creates a hook redacting entries with redactor
+func NewRedactionHook(redactor *Redactor) *RedactionHook {
+	return &RedactionHook{redactor: redactor}
+}
+
+func (hook *RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = hook.redactor.String(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = hook.redactor.Value(key, value)
+	}
+	return nil
+}
+
+func (hook *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// redactingWriter redacts each write, which the standard log package makes
+// one line at a time
+type redactingWriter struct {
+	out      io.Writer
+	redactor *Redactor
+}
+
+// NewRedactingWriter wraps out so that everything written to it is redacted,
+// for use with log.SetOutput
+func NewRedactingWriter(out io.Writer, redactor *Redactor) io.Writer {
+	return &redactingWriter{out: out, redactor: redactor}
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, w.redactor.String(string(p))); err != nil {
+		return 0, err
+	}
+	// Report the caller's bytes as written, the redacted line may differ in length
+	return len(p), nil
+}
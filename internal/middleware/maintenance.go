@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/locale"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceRetryAfter is the Retry-After hint sent while in maintenance mode
+const MaintenanceRetryAfter = 5 * time.Minute
+
+// maintenanceExemptPaths stay reachable during maintenance so load balancers
+// keep the instance in rotation and admins can turn maintenance off again
+var maintenanceExemptPaths = []string{"/api/health", "/api/admin"}
+
+// maintenanceMessages are the localized 503 messages by language
+var maintenanceMessages = map[string]string{
+	locale.LangPersian: "سرویس در حال به‌روزرسانی است. لطفاً چند دقیقه دیگر دوباره تلاش کنید.",
+	locale.LangEnglish: "The service is under maintenance. Please try again in a few minutes.",
+}
+
+// MaintenanceMiddleware rejects traffic with 503 while maintenance mode is on
+type MaintenanceMiddleware struct {
+	enabled func() bool
+	allowed []*net.IPNet
+}
+
+// NewMaintenanceMiddleware creates a maintenance middleware. enabled reports
+// whether maintenance mode is on; requests from allowedIPs (addresses or CIDR
+// ranges) are always let through.
+func NewMaintenanceMiddleware(enabled func() bool, allowedIPs []string) *MaintenanceMiddleware {
+	m := &MaintenanceMiddleware{enabled: enabled}
+	for _, entry := range allowedIPs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("Ignoring invalid maintenance allowlist entry %q: %v", entry, err)
+			continue
+		}
+		m.allowed = append(m.allowed, network)
+	}
+	return m
+}
+
+// Handler returns the gin middleware. It must run after locale.Middleware so
+// the message follows the client's language.
+func (m *MaintenanceMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.enabled() || m.exempt(c) {
+			c.Next()
+			return
+		}
+
+		message := maintenanceMessages[locale.FromContext(c.Request.Context()).Language()]
+		if message == "" {
+			message = maintenanceMessages[locale.DefaultLanguage]
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(MaintenanceRetryAfter.Seconds())))
+		common.RespondAPIError(c, common.NewAPIError(http.StatusServiceUnavailable, common.ErrCodeMaintenance, message, nil))
+	}
+}
+
+// exempt reports whether a request is let through during maintenance
+func (m *MaintenanceMiddleware) exempt(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	for _, prefix := range maintenanceExemptPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return false
+	}
+	for _, network := range m.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
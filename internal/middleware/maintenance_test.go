@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/locale"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newMaintenanceTestRouter(enabled *bool, allowedIPs []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(locale.Middleware())
+	r.Use(NewMaintenanceMiddleware(func() bool { return *enabled }, allowedIPs).Handler())
+	for _, path := range []string{"/api/images", "/api/health/live", "/api/admin/settings", "/api/healthcheck"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	return r
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	enabled := false
+	r := newMaintenanceTestRouter(&enabled, []string{"10.0.0.0/8", "192.168.1.7", "not-an-ip"})
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		path       string
+		remoteAddr string
+		want       int
+	}{
+		{"disabled", false, "/api/images", "203.0.113.5:1234", http.StatusOK},
+		{"user traffic", true, "/api/images", "203.0.113.5:1234", http.StatusServiceUnavailable},
+		{"health check", true, "/api/health/live", "203.0.113.5:1234", http.StatusOK},
+		{"admin endpoint", true, "/api/admin/settings", "203.0.113.5:1234", http.StatusOK},
+		{"path sharing a prefix", true, "/api/healthcheck", "203.0.113.5:1234", http.StatusServiceUnavailable},
+		{"allowlisted range", true, "/api/images", "10.1.2.3:1234", http.StatusOK},
+		{"allowlisted address", true, "/api/images", "192.168.1.7:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		enabled = tt.enabled
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestMaintenanceMiddleware_LocalizedMessage(t *testing.T) {
+	enabled := true
+	r := newMaintenanceTestRouter(&enabled, nil)
+
+	for lang, want := range maintenanceMessages {
+		req := httptest.NewRequest(http.MethodGet, "/api/images", nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body struct {
+			Error common.APIError `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Expected JSON error body, got %q", w.Body.String())
+		}
+		if body.Error.Code != common.ErrCodeMaintenance || body.Error.Message != want {
+			t.Errorf("%s: expected maintenance error %q, got %+v", lang, want, body.Error)
+		}
+		if w.Header().Get("Retry-After") != "300" {
+			t.Errorf("%s: expected Retry-After 300, got %q", lang, w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -3,7 +3,10 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"ai-styler/internal/common"
@@ -22,19 +25,59 @@ func generateRequestID() string {
 
 // RequestLoggerMiddleware provides request logging
 type RequestLoggerMiddleware struct {
-	logger *logging.StructuredLogger
+	logger   *logging.StructuredLogger
+	sampling []samplingRule
+	sample   func() float64
+}
+
+// samplingRule logs the given share of successful requests under a path prefix
+type samplingRule struct {
+	prefix string
+	rate   float64
 }
 
 // NewRequestLoggerMiddleware creates a new request logger middleware
 func NewRequestLoggerMiddleware(logger *logging.StructuredLogger) *RequestLoggerMiddleware {
 	return &RequestLoggerMiddleware{
 		logger: logger,
+		sample: rand.Float64,
+	}
+}
+
+// SetSampling logs only a share of the successful requests of high-volume
+// endpoints, keyed by path prefix with rates between 0 and 1. The longest
+// matching prefix wins; failed requests are always logged.
+func (m *RequestLoggerMiddleware) SetSampling(rates map[string]float64) {
+	m.sampling = nil
+	for prefix, rate := range rates {
+		m.sampling = append(m.sampling, samplingRule{prefix: prefix, rate: rate})
+	}
+	sort.Slice(m.sampling, func(i, j int) bool {
+		return len(m.sampling[i].prefix) > len(m.sampling[j].prefix)
+	})
+}
+
+// sampled reports whether a request is logged
+func (m *RequestLoggerMiddleware) sampled(path string, status int) bool {
+	if status >= http.StatusBadRequest {
+		return true
 	}
+	for _, rule := range m.sampling {
+		if strings.HasPrefix(path, rule.prefix) {
+			return rule.rate >= 1 || m.sample() < rule.rate
+		}
+	}
+	return true
 }
 
-// RequestLogging returns a Gin middleware for request logging
+// RequestLogging returns a Gin middleware for request logging. Paths, query
+// strings and user agents are redacted by the logger before they are written.
 func (m *RequestLoggerMiddleware) RequestLogging() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		if !m.sampled(param.Request.URL.Path, param.StatusCode) {
+			return ""
+		}
+
 		// Log structured request information
 		m.logger.Info(context.Background(), "HTTP Request", map[string]interface{}{
 			"method":     param.Method,
@@ -275,10 +318,7 @@ func (m *RecoveryMiddleware) Recovery() gin.HandlerFunc {
 				})
 
 				// Return error response
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Internal Server Error",
-					"message": "An unexpected error occurred",
-				})
+				common.RespondError(c, http.StatusInternalServerError, "An unexpected error occurred")
 			}
 		}()
 
@@ -298,37 +338,14 @@ func NewSecurityMiddleware(config *security.SecurityConfig) *SecurityMiddleware
 	}
 }
 
-// CORSMiddleware returns a Gin middleware for CORS
+// CORSMiddleware returns a Gin middleware for CORS using the configured origins
 func (m *SecurityMiddleware) CORSMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if m.config.CORSEnabled {
-			c.Header("Access-Control-Allow-Origin", "*")
-			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-			if c.Request.Method == "OPTIONS" {
-				c.AbortWithStatus(http.StatusNoContent)
-				return
-			}
-		}
-
-		c.Next()
-	}
+	return security.NewSecurityMiddleware(m.config).CORSMiddleware()
 }
 
-// SecurityHeadersMiddleware returns a Gin middleware for security headers
+// SecurityHeadersMiddleware returns a Gin middleware for the configured security headers
 func (m *SecurityMiddleware) SecurityHeadersMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if m.config.SecurityHeadersEnabled {
-			c.Header("X-Content-Type-Options", "nosniff")
-			c.Header("X-Frame-Options", "DENY")
-			c.Header("X-XSS-Protection", "1; mode=block")
-			c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-			c.Header("Content-Security-Policy", "default-src 'self'")
-		}
-
-		c.Next()
-	}
+	return security.NewSecurityMiddleware(m.config).SecurityHeadersMiddleware()
 }
 
 // JWTAuthMiddleware returns a Gin middleware for JWT authentication
@@ -336,10 +353,7 @@ func (m *SecurityMiddleware) JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "Missing authorization header",
-			})
+			common.RespondError(c, http.StatusUnauthorized, "Missing authorization header")
 			c.Abort()
 			return
 		}
@@ -347,10 +361,7 @@ func (m *SecurityMiddleware) JWTAuthMiddleware() gin.HandlerFunc {
 		// This would be implemented with proper JWT validation
 		// For now, we'll just check if the header exists
 		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "Invalid authorization header format",
-			})
+			common.RespondError(c, http.StatusUnauthorized, "Invalid authorization header format")
 			c.Abort()
 			return
 		}
@@ -296,3 +296,36 @@ func TestMiddlewareIntegration(t *testing.T) {
 		t.Errorf("Expected status 500, got %d", w.Code)
 	}
 }
+
+func TestRequestLoggerMiddleware_Sampling(t *testing.T) {
+	logger := logging.NewStructuredLogger(logging.LoggerConfig{Level: logging.LogLevelInfo, Format: "json"})
+	middleware := NewRequestLoggerMiddleware(logger)
+	middleware.SetSampling(map[string]float64{
+		"/api/health":       0,
+		"/api/health/ready": 1,
+		"/api/images":       0.5,
+	})
+	middleware.sample = func() float64 { return 0.7 }
+
+	tests := []struct {
+		path   string
+		status int
+		logged bool
+	}{
+		{"/api/health", http.StatusOK, false},
+		{"/api/health/ready", http.StatusOK, true},
+		{"/api/health", http.StatusServiceUnavailable, true},
+		{"/api/images/1", http.StatusOK, false},
+		{"/api/conversions", http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		if got := middleware.sampled(tt.path, tt.status); got != tt.logged {
+			t.Errorf("Expected %s (%d) logged=%v, got %v", tt.path, tt.status, tt.logged, got)
+		}
+	}
+
+	middleware.sample = func() float64 { return 0.2 }
+	if !middleware.sampled("/api/images/1", http.StatusOK) {
+		t.Error("Expected a request within the sampling rate to be logged")
+	}
+}
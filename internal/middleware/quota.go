@@ -2,62 +2,114 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"ai-styler/internal/common"
+	"ai-styler/internal/quota"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 )
 
+// ConversionQuota charges conversions to a user's plan or free allowance
+type ConversionQuota interface {
+	Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error)
+	Release(ctx context.Context, reservation quota.Reservation) error
+}
+
+// Quota response headers
+const (
+	QuotaLimitHeader     = "X-Quota-Limit"
+	QuotaRemainingHeader = "X-Quota-Remaining"
+	QuotaResetHeader     = "X-Quota-Reset"
+)
+
 // QuotaMiddleware enforces quota limits for users and vendors
 type QuotaMiddleware struct {
 	redisClient *redis.Client
+	conversions ConversionQuota
+	exempt      func(ctx context.Context, userID string) bool
 }
 
 // NewQuotaMiddleware creates a new quota middleware
-func NewQuotaMiddleware(redisClient *redis.Client) *QuotaMiddleware {
+func NewQuotaMiddleware(redisClient *redis.Client, conversions ConversionQuota) *QuotaMiddleware {
 	return &QuotaMiddleware{
 		redisClient: redisClient,
+		conversions: conversions,
 	}
 }
 
-// EnforceConversionQuota checks if user has remaining conversions
+// SetConversionExemption sets a check for requests that skip the conversion
+// quota, e.g. the onboarding fast lane
+func (q *QuotaMiddleware) SetConversionExemption(exempt func(ctx context.Context, userID string) bool) {
+	q.exempt = exempt
+}
+
+// EnforceConversionQuota charges one conversion before the handler runs and
+// releases it again when the handler does not succeed. Exhausted users get
+// 429 with the quota headers and Retry-After set to the billing cycle reset.
 func (q *QuotaMiddleware) EnforceConversionQuota() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.GetString("user_id")
+		if userID == "" {
+			userID = common.GetUserIDFromContext(c.Request.Context())
+		}
 		if userID == "" {
 			c.Next()
 			return
 		}
 
-		// Check monthly conversion quota
-		monthlyKey := "quota:conversions:" + userID + ":" + time.Now().Format("2006-01")
-		count, err := q.redisClient.Get(context.Background(), monthlyKey).Int()
-		if err != nil && err != redis.Nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check quota"})
-			c.Abort()
+		ctx := c.Request.Context()
+		if q.exempt != nil && q.exempt(ctx, userID) {
+			c.Next()
 			return
 		}
 
-		// Get user's plan limit (mock for now)
-		limit := 2 // Free plan limit
-		if count >= limit {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Monthly conversion quota exceeded",
-				"quota": map[string]interface{}{
-					"used":      count,
-					"limit":     limit,
-					"remaining": 0,
-				},
-			})
-			c.Abort()
+		reservation, status, err := q.conversions.Consume(ctx, userID)
+		if err != nil {
+			var exceeded *quota.ExceededError
+			if !errors.As(err, &exceeded) {
+				common.RespondError(c, http.StatusInternalServerError, "Failed to check quota")
+				c.Abort()
+				return
+			}
+
+			setQuotaHeaders(c, status)
+			if status.ResetAt != nil {
+				retryAfter := int(time.Until(*status.ResetAt).Seconds())
+				c.Header("Retry-After", strconv.Itoa(max(retryAfter, 0)))
+			}
+			common.RespondAPIError(c, common.NewAPIError(http.StatusTooManyRequests, common.ErrCodeQuotaExceeded, "Monthly conversion quota exceeded", gin.H{
+				"quota": status,
+			}))
 			return
 		}
 
-		c.Set("quota_remaining", limit-count)
+		setQuotaHeaders(c, status)
+		c.Set("quota_remaining", status.Remaining)
+		c.Request = c.Request.WithContext(quota.WithReservation(ctx, reservation))
 		c.Next()
+
+		// Give the conversion back when the request did not create one
+		if c.Writer.Status() < 200 || c.Writer.Status() >= 300 {
+			if err := q.conversions.Release(context.Background(), reservation); err != nil {
+				fmt.Printf("Failed to release conversion quota for user %s: %v\n", userID, err)
+			}
+		}
+	}
+}
+
+// setQuotaHeaders writes the remaining quota headers
+func setQuotaHeaders(c *gin.Context, status quota.Status) {
+	c.Header(QuotaLimitHeader, strconv.Itoa(status.Limit))
+	c.Header(QuotaRemainingHeader, strconv.Itoa(status.Remaining))
+	if status.ResetAt != nil {
+		c.Header(QuotaResetHeader, strconv.FormatInt(status.ResetAt.Unix(), 10))
 	}
 }
 
@@ -74,7 +126,7 @@ func (q *QuotaMiddleware) EnforceImageQuota() gin.HandlerFunc {
 		monthlyKey := "quota:images:" + vendorID + ":" + time.Now().Format("2006-01")
 		count, err := q.redisClient.Get(context.Background(), monthlyKey).Int()
 		if err != nil && err != redis.Nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check quota"})
+			common.RespondError(c, http.StatusInternalServerError, "Failed to check quota")
 			c.Abort()
 			return
 		}
@@ -82,14 +134,13 @@ func (q *QuotaMiddleware) EnforceImageQuota() gin.HandlerFunc {
 		// Get vendor's plan limit (mock for now)
 		limit := 50 // Basic vendor plan limit
 		if count >= limit {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Monthly image upload quota exceeded",
+			common.RespondAPIError(c, common.NewAPIError(http.StatusForbidden, common.ErrCodeQuotaExceeded, "Monthly image upload quota exceeded", gin.H{
 				"quota": map[string]interface{}{
 					"used":      count,
 					"limit":     limit,
 					"remaining": 0,
 				},
-			})
+			}))
 			c.Abort()
 			return
 		}
@@ -99,23 +150,6 @@ func (q *QuotaMiddleware) EnforceImageQuota() gin.HandlerFunc {
 	}
 }
 
-// IncrementConversionQuota increments conversion count after successful conversion
-func (q *QuotaMiddleware) IncrementConversionQuota() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Next()
-
-		// Only increment if request was successful
-		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
-			userID := c.GetString("user_id")
-			if userID != "" {
-				monthlyKey := "quota:conversions:" + userID + ":" + time.Now().Format("2006-01")
-				q.redisClient.Incr(context.Background(), monthlyKey)
-				q.redisClient.Expire(context.Background(), monthlyKey, 31*24*time.Hour)
-			}
-		}
-	}
-}
-
 // IncrementImageQuota increments image upload count after successful upload
 func (q *QuotaMiddleware) IncrementImageQuota() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -154,15 +188,14 @@ func (f *FileValidationMiddleware) ValidateFileUpload() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		file, err := c.FormFile("file")
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+			common.RespondError(c, http.StatusBadRequest, "No file provided")
 			c.Abort()
 			return
 		}
 
 		// Check file size
 		if file.Size > f.maxFileSize {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":     "File too large",
+			common.RespondErrorWithDetails(c, http.StatusBadRequest, "File too large", gin.H{
 				"max_size":  f.maxFileSize,
 				"file_size": file.Size,
 			})
@@ -180,8 +213,7 @@ func (f *FileValidationMiddleware) ValidateFileUpload() gin.HandlerFunc {
 			}
 		}
 		if !allowed {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":         "File type not allowed",
+			common.RespondErrorWithDetails(c, http.StatusBadRequest, "File type not allowed", gin.H{
 				"allowed_types": f.allowedExts,
 				"file_type":     ext,
 			})
@@ -192,7 +224,7 @@ func (f *FileValidationMiddleware) ValidateFileUpload() gin.HandlerFunc {
 		// Check MIME type
 		fileHeader, err := file.Open()
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			common.RespondError(c, http.StatusBadRequest, "Failed to read file")
 			c.Abort()
 			return
 		}
@@ -201,7 +233,7 @@ func (f *FileValidationMiddleware) ValidateFileUpload() gin.HandlerFunc {
 		buffer := make([]byte, 512)
 		_, err = fileHeader.Read(buffer)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file header"})
+			common.RespondError(c, http.StatusBadRequest, "Failed to read file header")
 			c.Abort()
 			return
 		}
@@ -215,8 +247,7 @@ func (f *FileValidationMiddleware) ValidateFileUpload() gin.HandlerFunc {
 			}
 		}
 		if !allowedMime {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":         "File MIME type not allowed",
+			common.RespondErrorWithDetails(c, http.StatusBadRequest, "File MIME type not allowed", gin.H{
 				"allowed_types": f.allowedTypes,
 				"file_type":     mimeType,
 			})
@@ -282,15 +313,14 @@ func (r *RateLimitMiddleware) RateLimitByIP(limit int, window time.Duration) gin
 
 		count, err := r.redisClient.Get(context.Background(), key).Int()
 		if err != nil && err != redis.Nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Rate limit check failed"})
+			common.RespondError(c, http.StatusInternalServerError, "Rate limit check failed")
 			c.Abort()
 			return
 		}
 
 		if count >= limit {
 			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":  "Rate limit exceeded",
+			common.RespondErrorWithDetails(c, http.StatusTooManyRequests, "Rate limit exceeded", gin.H{
 				"limit":  limit,
 				"window": window.String(),
 			})
@@ -318,15 +348,14 @@ func (r *RateLimitMiddleware) RateLimitByUser(limit int, window time.Duration) g
 
 		count, err := r.redisClient.Get(context.Background(), key).Int()
 		if err != nil && err != redis.Nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Rate limit check failed"})
+			common.RespondError(c, http.StatusInternalServerError, "Rate limit check failed")
 			c.Abort()
 			return
 		}
 
 		if count >= limit {
 			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":  "Rate limit exceeded",
+			common.RespondErrorWithDetails(c, http.StatusTooManyRequests, "Rate limit exceeded", gin.H{
 				"limit":  limit,
 				"window": window.String(),
 			})
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-styler/internal/quota"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeConversionQuota grants a fixed number of conversions
+type fakeConversionQuota struct {
+	remaining int
+	released  int
+	resetAt   time.Time
+}
+
+func (f *fakeConversionQuota) Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error) {
+	if f.remaining == 0 {
+		status := quota.Status{Limit: 2, Used: 2, ResetAt: &f.resetAt}
+		return quota.Reservation{}, status, &quota.ExceededError{Status: status}
+	}
+	f.remaining--
+	return quota.Reservation{UserID: userID, Source: quota.SourcePlan},
+		quota.Status{Limit: 2, Used: 2 - f.remaining, Remaining: f.remaining, ResetAt: &f.resetAt}, nil
+}
+
+func (f *fakeConversionQuota) Release(ctx context.Context, reservation quota.Reservation) error {
+	f.released++
+	f.remaining++
+	return nil
+}
+
+func newQuotaTestRouter(conversions ConversionQuota, handlerStatus int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Next()
+	})
+	r.POST("/convert", NewQuotaMiddleware(nil, conversions).EnforceConversionQuota(), func(c *gin.Context) {
+		if _, ok := quota.ReservationFromContext(c.Request.Context()); !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(handlerStatus)
+	})
+	return r
+}
+
+func TestEnforceConversionQuota(t *testing.T) {
+	conversions := &fakeConversionQuota{remaining: 1, resetAt: time.Now().Add(48 * time.Hour)}
+	r := newQuotaTestRouter(conversions, http.StatusCreated)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if w.Header().Get(QuotaRemainingHeader) != "0" || w.Header().Get(QuotaLimitHeader) != "2" {
+		t.Errorf("Expected quota headers limit 2 remaining 0, got %q/%q",
+			w.Header().Get(QuotaLimitHeader), w.Header().Get(QuotaRemainingHeader))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get(QuotaResetHeader) == "" {
+		t.Error("Expected Retry-After and reset headers on exhausted quota")
+	}
+}
+
+func TestEnforceConversionQuota_ReleasesOnFailure(t *testing.T) {
+	conversions := &fakeConversionQuota{remaining: 1, resetAt: time.Now().Add(time.Hour)}
+	r := newQuotaTestRouter(conversions, http.StatusBadRequest)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if conversions.released != 1 || conversions.remaining != 1 {
+		t.Errorf("Expected the reservation to be released, got %d releases and %d remaining", conversions.released, conversions.remaining)
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/monitoring"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader exposes the trace ID of a request so clients can quote it in
+// support requests
+const TraceIDHeader = "X-Trace-ID"
+
+// TracingMiddleware starts an OpenTelemetry server span for every request
+type TracingMiddleware struct {
+	tracer trace.Tracer
+}
+
+// NewTracingMiddleware creates a new tracing middleware
+func NewTracingMiddleware() *TracingMiddleware {
+	return &TracingMiddleware{
+		tracer: monitoring.Tracer(),
+	}
+}
+
+// Tracing returns a Gin middleware that continues the caller's W3C trace
+// context and stores the request span in the request context
+func (m *TracingMiddleware) Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx, span := m.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		if route != "" {
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		if sc := span.SpanContext(); sc.IsSampled() {
+			c.Header(TraceIDHeader, sc.TraceID().String())
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if userID, ok := c.Get("user_id"); ok {
+			span.SetAttributes(attribute.String("enduser.id", fmt.Sprint(userID)))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer provider.Shutdown(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewTracingMiddleware().Tracing())
+	r.GET("/conversions/:id", func(c *gin.Context) {
+		c.Set("user_id", "u1")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/conversions/c1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get(TraceIDHeader) != traceID {
+		t.Errorf("Expected trace ID %s in response, got %q", traceID, w.Header().Get(TraceIDHeader))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /conversions/:id" {
+		t.Errorf("Expected span name GET /conversions/:id, got %s", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID {
+		t.Errorf("Expected the incoming trace to be continued, got %s", span.SpanContext().TraceID())
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["http.route"].AsString() != "/conversions/:id" {
+		t.Errorf("Expected route attribute, got %v", attrs["http.route"])
+	}
+	if attrs["http.response.status_code"].AsInt64() != http.StatusOK {
+		t.Errorf("Expected status code 200, got %v", attrs["http.response.status_code"])
+	}
+	if attrs["enduser.id"].AsString() != "u1" {
+		t.Errorf("Expected user u1, got %v", attrs["enduser.id"])
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	spans = recorder.Ended()
+	if len(spans) != 2 || spans[1].Status().Code != codes.Error {
+		t.Errorf("Expected a 5xx response to mark the span as failed")
+	}
+}
@@ -1,113 +1,192 @@
 package migration
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// Default session timeouts applied while a migration runs. A short lock_timeout makes a
+// migration fail fast instead of queueing behind long transactions and blocking traffic.
+const (
+	DefaultLockTimeout      = 5 * time.Second
+	DefaultStatementTimeout = 2 * time.Minute
+)
+
+// Options controls which migrations run and the timeouts applied to them
+type Options struct {
+	Phase            Phase
+	LockTimeout      time.Duration // 0 disables the timeout
+	StatementTimeout time.Duration // 0 disables the timeout
+	DryRun           bool          // print the SQL instead of executing it
+}
+
+// DefaultOptions runs every pending migration with the default timeouts
+func DefaultOptions() Options {
+	return Options{
+		Phase:            PhaseAll,
+		LockTimeout:      DefaultLockTimeout,
+		StatementTimeout: DefaultStatementTimeout,
+	}
+}
+
 // RunMigrations runs pending database migrations
 func RunMigrations(db *sql.DB, migrationsDir string) error {
+	return RunMigrationsWithOptions(db, migrationsDir, DefaultOptions())
+}
+
+// RunMigrationsWithOptions runs pending migrations for a deploy phase
+func RunMigrationsWithOptions(db *sql.DB, migrationsDir string, opts Options) error {
 	// Create migrations table if it doesn't exist
 	if err := createMigrationsTable(db); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
-	// Get all migration files
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	migrations, err := LoadMigrations(migrationsDir)
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+		return err
 	}
 
-	// Sort files by name
-	sort.Strings(files)
-
-	var appliedCount int
-	var skippedCount int
+	pending, err := pendingMigrations(db, migrations)
+	if err != nil {
+		return err
+	}
 
-	for _, file := range files {
-		filename := filepath.Base(file)
-		version := strings.TrimSuffix(filename, ".sql")
+	selected := selectForPhase(pending, opts.Phase)
 
-		// Check if migration is already applied
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status for %s: %w", filename, err)
+	for _, m := range selected {
+		if err := applyMigration(db, m, opts); err != nil {
+			return err
 		}
+	}
 
-		if count > 0 {
-			skippedCount++
-			continue
-		}
+	if opts.DryRun {
+		return nil
+	}
+	if len(selected) > 0 {
+		fmt.Printf("Applied %d migration(s), skipped %d already applied\n", len(selected), len(migrations)-len(pending))
+	}
+	if held := len(pending) - len(selected); held > 0 {
+		fmt.Printf("Held back %d post-deploy migration(s) for phase %s\n", held, opts.Phase)
+	}
 
-		// Read migration file
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", filename, err)
-		}
+	return nil
+}
 
-		// Execute migration (file already contains BEGIN/COMMIT)
-		_, err = db.Exec(string(content))
-		if err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", filename, err)
-		}
+// applyMigration runs the up file of a migration and records it
+func applyMigration(db *sql.DB, m Migration, opts Options) error {
+	return runMigrationFile(db, m, m.Path, opts,
+		"INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING")
+}
 
-		// Update migrations table
-		tx, err := db.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction for %s: %w", filename, err)
-		}
+// revertMigration runs the down file of a migration and removes its record
+func revertMigration(db *sql.DB, m Migration, opts Options) error {
+	if m.DownPath == "" {
+		return fmt.Errorf("migration %s has no down file", m.Version)
+	}
+	return runMigrationFile(db, m, m.DownPath, opts, "DELETE FROM schema_migrations WHERE version = $1")
+}
 
-		_, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING", version)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to update migrations table for %s: %w", filename, err)
-		}
+// runMigrationFile executes a migration file with the migration's timeouts and
+// then updates schema_migrations with record, or prints both in dry-run mode
+func runMigrationFile(db *sql.DB, m Migration, path string, opts Options, record string) error {
+	filename := filepath.Base(path)
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration record for %s: %w", filename, err)
-		}
+	// Read migration file
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read migration file %s: %w", filename, err)
+	}
+
+	lockTimeout := opts.LockTimeout
+	if m.LockTimeout != nil {
+		lockTimeout = *m.LockTimeout
+	}
+	statementTimeout := opts.StatementTimeout
+	if m.StatementTimeout != nil {
+		statementTimeout = *m.StatementTimeout
+	}
+
+	if opts.DryRun {
+		fmt.Printf("-- %s (lock_timeout=%s statement_timeout=%s)\n", filename, lockTimeout, statementTimeout)
+		fmt.Println(strings.TrimSpace(string(content)))
+		fmt.Printf("%s;\n\n", strings.Replace(record, "$1", "'"+strings.ReplaceAll(m.Version, "'", "''")+"'", 1))
+		return nil
+	}
+
+	// Execute migration (file already contains BEGIN/COMMIT)
+	if err := execWithTimeouts(db, string(content), lockTimeout, statementTimeout); err != nil {
+		return fmt.Errorf("failed to execute migration %s: %w", filename, err)
+	}
+
+	// Update migrations table
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", filename, err)
+	}
 
-		appliedCount++
+	if _, err := tx.Exec(record, m.Version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update migrations table for %s: %w", filename, err)
 	}
 
-	if appliedCount > 0 {
-		fmt.Printf("Applied %d migration(s), skipped %d already applied\n", appliedCount, skippedCount)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration record for %s: %w", filename, err)
 	}
 
 	return nil
 }
 
-// GetMigrationStatus returns the status of all migrations
-func GetMigrationStatus(db *sql.DB, migrationsDir string) (map[string]bool, error) {
-	status := make(map[string]bool)
-
-	// Get all migration files
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+// execWithTimeouts runs a migration on a dedicated connection with session-level
+// lock_timeout and statement_timeout, resetting them before the connection returns to the pool
+func execWithTimeouts(db *sql.DB, content string, lockTimeout, statementTimeout time.Duration) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+		return err
 	}
+	defer conn.Close()
 
-	// Sort files by name
-	sort.Strings(files)
+	settings := fmt.Sprintf("SET lock_timeout = %d; SET statement_timeout = %d;",
+		lockTimeout.Milliseconds(), statementTimeout.Milliseconds())
+	if _, err := conn.ExecContext(ctx, settings); err != nil {
+		return fmt.Errorf("failed to apply migration timeouts: %w", err)
+	}
 
-	for _, file := range files {
-		filename := filepath.Base(file)
-		version := strings.TrimSuffix(filename, ".sql")
+	_, execErr := conn.ExecContext(ctx, content)
+	if execErr != nil {
+		// A failed statement inside the file's BEGIN leaves the session in an aborted transaction
+		conn.ExecContext(ctx, "ROLLBACK")
+	}
 
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check migration status for %s: %w", filename, err)
-		}
+	if _, err := conn.ExecContext(ctx, "RESET lock_timeout; RESET statement_timeout;"); err != nil && execErr == nil {
+		return fmt.Errorf("failed to reset migration timeouts: %w", err)
+	}
+
+	return execErr
+}
+
+// GetMigrationStatus returns the status of all migrations
+func GetMigrationStatus(db *sql.DB, migrationsDir string) (map[string]bool, error) {
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
 
-		status[filename] = count > 0
+	status := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		status[m.Filename] = applied[m.Version]
 	}
 
 	return status, nil
@@ -0,0 +1,445 @@
+package migration
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Phase describes when a migration may run relative to a code deploy
+type Phase string
+
+const (
+	// PhasePreDeploy migrations are backwards compatible and safe to run before new code ships
+	PhasePreDeploy Phase = "pre-deploy"
+	// PhasePostDeploy migrations require code N+1 to be live (e.g. dropping columns old code still reads)
+	PhasePostDeploy Phase = "post-deploy"
+	// PhaseAll runs every pending migration regardless of annotation
+	PhaseAll Phase = "all"
+)
+
+// Annotation prefix recognised in the leading comment block of a migration file:
+//
+//	-- +migrate phase: post-deploy
+//	-- +migrate lock_timeout: 2s
+//	-- +migrate statement_timeout: 10m
+const annotationPrefix = "-- +migrate "
+
+// Lock modes reported by the planner, ordered from weakest to strongest
+const (
+	LockRowExclusive         = "ROW EXCLUSIVE"
+	LockShareUpdateExclusive = "SHARE UPDATE EXCLUSIVE"
+	LockShare                = "SHARE"
+	LockShareRowExclusive    = "SHARE ROW EXCLUSIVE"
+	LockAccessExclusive      = "ACCESS EXCLUSIVE"
+)
+
+// Migration file suffixes. A migration is either a single NNN_name.sql file,
+// which cannot be rolled back, or a NNN_name.up.sql / NNN_name.down.sql pair.
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// Migration is a migration file together with its parsed annotations
+type Migration struct {
+	Version          string
+	Filename         string
+	Path             string
+	DownPath         string // empty when the migration has no down file
+	Phase            Phase
+	LockTimeout      *time.Duration // nil means use the runner default
+	StatementTimeout *time.Duration // nil means use the runner default
+}
+
+// TableLock describes a lock a migration statement is expected to take
+type TableLock struct {
+	Table     string `json:"table"`
+	Mode      string `json:"mode"`
+	Statement string `json:"statement"`
+}
+
+// PlannedMigration is a pending migration with the locks it will acquire
+type PlannedMigration struct {
+	Migration
+	WillRun bool        // false when the migration is held back for a later phase
+	Locks   []TableLock // locks detected from the SQL
+}
+
+// ParsePhase parses a phase name, accepting "pre" and "post" as shorthands
+func ParsePhase(s string) (Phase, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "all":
+		return PhaseAll, nil
+	case "pre", "pre-deploy":
+		return PhasePreDeploy, nil
+	case "post", "post-deploy":
+		return PhasePostDeploy, nil
+	default:
+		return "", fmt.Errorf("unknown migration phase %q (use pre-deploy, post-deploy or all)", s)
+	}
+}
+
+// LoadMigrations reads all migration files in a directory, sorted by version,
+// and pairs up files with their down files
+func LoadMigrations(migrationsDir string) ([]Migration, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	// Sort files by name
+	sort.Strings(files)
+
+	downs := make(map[string]string)
+	seen := make(map[string]string)
+	migrations := make([]Migration, 0, len(files))
+	for _, file := range files {
+		if name := filepath.Base(file); strings.HasSuffix(name, downSuffix) {
+			downs[strings.TrimSuffix(name, downSuffix)] = file
+			continue
+		}
+
+		m, err := parseMigrationFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := seen[m.Version]; exists {
+			return nil, fmt.Errorf("migration %s is defined by both %s and %s", m.Version, other, m.Filename)
+		}
+		seen[m.Version] = m.Filename
+		migrations = append(migrations, m)
+	}
+
+	for i := range migrations {
+		m := &migrations[i]
+		if down, exists := downs[m.Version]; exists {
+			if !strings.HasSuffix(m.Filename, upSuffix) {
+				return nil, fmt.Errorf("down file for %s requires the up file to be named %s%s", m.Filename, m.Version, upSuffix)
+			}
+			m.DownPath = down
+			delete(downs, m.Version)
+		}
+	}
+	for version := range downs {
+		return nil, fmt.Errorf("down migration %s%s has no matching up file", version, downSuffix)
+	}
+
+	return migrations, nil
+}
+
+// parseMigrationFile reads the leading comment block of a migration for annotations
+func parseMigrationFile(path string) (Migration, error) {
+	filename := filepath.Base(path)
+	version := strings.TrimSuffix(filename, upSuffix)
+	if version == filename {
+		version = strings.TrimSuffix(filename, ".sql")
+	}
+	m := Migration{
+		Version:  version,
+		Filename: filename,
+		Path:     path,
+		Phase:    PhasePreDeploy,
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return m, fmt.Errorf("failed to read migration file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Annotations must appear before the first SQL statement
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		if !strings.HasPrefix(line, annotationPrefix) {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, annotationPrefix), ":")
+		if !ok {
+			return m, fmt.Errorf("invalid annotation in %s: %q", filename, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "phase":
+			phase, err := ParsePhase(value)
+			if err != nil || phase == PhaseAll {
+				return m, fmt.Errorf("invalid phase in %s: %q", filename, value)
+			}
+			m.Phase = phase
+		case "lock_timeout", "statement_timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil && value == "0" {
+				d, err = 0, nil
+			}
+			if err != nil {
+				return m, fmt.Errorf("invalid %s in %s: %q", key, filename, value)
+			}
+			if key == "lock_timeout" {
+				m.LockTimeout = &d
+			} else {
+				m.StatementTimeout = &d
+			}
+		default:
+			return m, fmt.Errorf("unknown annotation %q in %s", key, filename)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return m, fmt.Errorf("failed to read migration file %s: %w", filename, err)
+	}
+
+	return m, nil
+}
+
+// selectForPhase returns the pending migrations that should run in the given phase.
+// Pre-deploy runs stop at the first pending post-deploy migration, since later files may depend on it.
+func selectForPhase(pending []Migration, phase Phase) []Migration {
+	if phase != PhasePreDeploy {
+		return pending
+	}
+
+	selected := make([]Migration, 0, len(pending))
+	for _, m := range pending {
+		if m.Phase == PhasePostDeploy {
+			break
+		}
+		selected = append(selected, m)
+	}
+	return selected
+}
+
+// pendingMigrations filters out migrations already recorded in schema_migrations
+func pendingMigrations(db *sql.DB, migrations []Migration) ([]Migration, error) {
+	pending := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", m.Version).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check migration status for %s: %w", m.Filename, err)
+		}
+		if count == 0 {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Plan lists pending migrations, whether they run in the given phase, and the locks they take
+func Plan(db *sql.DB, migrationsDir string, phase Phase) ([]PlannedMigration, error) {
+	if err := createMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := pendingMigrations(db, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	willRun := make(map[string]bool)
+	for _, m := range selectForPhase(pending, phase) {
+		willRun[m.Version] = true
+	}
+
+	plan := make([]PlannedMigration, 0, len(pending))
+	for _, m := range pending {
+		content, err := os.ReadFile(m.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", m.Filename, err)
+		}
+		plan = append(plan, PlannedMigration{
+			Migration: m,
+			WillRun:   willRun[m.Version],
+			Locks:     DetectLocks(string(content)),
+		})
+	}
+
+	return plan, nil
+}
+
+var (
+	reAlterTable   = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)`)
+	reDropTable    = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	reTruncate     = regexp.MustCompile(`(?is)^TRUNCATE\s+(?:TABLE\s+)?(?:ONLY\s+)?([\w."]+)`)
+	reCreateIndex  = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?([\w."]+)`)
+	reDropIndex    = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?([\w."]+)`)
+	reTrigger      = regexp.MustCompile(`(?is)^(?:CREATE\s+(?:OR\s+REPLACE\s+)?|DROP\s+)TRIGGER\s+.*?\bON\s+([\w."]+)`)
+	reDML          = regexp.MustCompile(`(?is)^(?:UPDATE\s+(?:ONLY\s+)?|DELETE\s+FROM\s+(?:ONLY\s+)?|INSERT\s+INTO\s+)([\w."]+)`)
+	reReferences   = regexp.MustCompile(`(?is)\bREFERENCES\s+([\w."]+)`)
+	reValidateOnly = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+.*?\bVALIDATE\s+CONSTRAINT\b`)
+)
+
+// DetectLocks statically inspects migration SQL and reports the table locks it will take.
+// The analysis is conservative: ALTER TABLE is always reported as ACCESS EXCLUSIVE unless
+// it only validates a constraint.
+func DetectLocks(content string) []TableLock {
+	var locks []TableLock
+	for _, stmt := range splitStatements(content) {
+		summary := summarizeStatement(stmt)
+		add := func(table, mode string) {
+			locks = append(locks, TableLock{Table: strings.Trim(table, `"`), Mode: mode, Statement: summary})
+		}
+
+		switch {
+		case reValidateOnly.MatchString(stmt):
+			add(reAlterTable.FindStringSubmatch(stmt)[1], LockShareUpdateExclusive)
+		case reAlterTable.MatchString(stmt):
+			add(reAlterTable.FindStringSubmatch(stmt)[1], LockAccessExclusive)
+		case reDropTable.MatchString(stmt):
+			for _, table := range strings.Split(reDropTable.FindStringSubmatch(stmt)[1], ",") {
+				add(strings.TrimSpace(table), LockAccessExclusive)
+			}
+		case reTruncate.MatchString(stmt):
+			add(reTruncate.FindStringSubmatch(stmt)[1], LockAccessExclusive)
+		case reCreateIndex.MatchString(stmt):
+			match := reCreateIndex.FindStringSubmatch(stmt)
+			if match[1] != "" {
+				add(match[2], LockShareUpdateExclusive)
+			} else {
+				add(match[2], LockShare)
+			}
+		case reDropIndex.MatchString(stmt):
+			match := reDropIndex.FindStringSubmatch(stmt)
+			if match[1] != "" {
+				add(match[2], LockShareUpdateExclusive)
+			} else {
+				add(match[2], LockAccessExclusive)
+			}
+		case reTrigger.MatchString(stmt):
+			add(reTrigger.FindStringSubmatch(stmt)[1], LockShareRowExclusive)
+		case reDML.MatchString(stmt):
+			add(reDML.FindStringSubmatch(stmt)[1], LockRowExclusive)
+		}
+
+		// Foreign keys lock the referenced table as well
+		for _, match := range reReferences.FindAllStringSubmatch(stmt, -1) {
+			add(match[1], LockShareRowExclusive)
+		}
+	}
+	return locks
+}
+
+// IsBlocking reports whether a lock mode blocks normal reads or writes on the table
+func IsBlocking(mode string) bool {
+	switch mode {
+	case LockAccessExclusive, LockShare, LockShareRowExclusive:
+		return true
+	default:
+		return false
+	}
+}
+
+// summarizeStatement collapses whitespace and truncates a statement for display
+func summarizeStatement(stmt string) string {
+	summary := strings.Join(strings.Fields(stmt), " ")
+	if len(summary) > 80 {
+		summary = summary[:77] + "..."
+	}
+	return summary
+}
+
+// splitStatements splits SQL into statements, dropping comments and the bodies
+// of dollar-quoted strings (function bodies don't run at migration time).
+func splitStatements(content string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		dollarTag  string
+		inQuote    bool
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		current.Reset()
+		upper := strings.ToUpper(stmt)
+		if stmt == "" || upper == "BEGIN" || upper == "COMMIT" {
+			return
+		}
+		statements = append(statements, stmt)
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch {
+		case dollarTag != "":
+			if strings.HasPrefix(content[i:], dollarTag) {
+				current.WriteString(dollarTag)
+				i += len(dollarTag) - 1
+				dollarTag = ""
+			}
+		case inQuote:
+			current.WriteByte(c)
+			if c == '\'' {
+				inQuote = false
+			}
+		case c == '\'':
+			inQuote = true
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(content[i:], "--"):
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == '$':
+			end := strings.IndexByte(content[i+1:], '$')
+			tag := ""
+			if end >= 0 {
+				tag = content[i : i+end+2]
+			}
+			if tag != "" && isDollarTag(tag) {
+				dollarTag = tag
+				current.WriteString(tag)
+				i += len(tag) - 1
+			} else {
+				current.WriteByte(c)
+			}
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// isDollarTag reports whether s is a dollar-quote delimiter such as $$ or $body$
+func isDollarTag(s string) bool {
+	inner := s[1 : len(s)-1]
+	for _, r := range inner {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return inner == "" || !(inner[0] >= '0' && inner[0] <= '9')
+}
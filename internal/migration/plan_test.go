@@ -0,0 +1,232 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMigrations_Annotations(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"0001_init.sql": "-- Initial schema\nBEGIN;\nCREATE TABLE a (id INT);\nCOMMIT;\n",
+		"0002_drop.sql": "-- Drop legacy column\n-- +migrate phase: post-deploy\n-- +migrate lock_timeout: 2s\n-- +migrate statement_timeout: 0\nALTER TABLE a DROP COLUMN legacy;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+
+	if migrations[0].Phase != PhasePreDeploy {
+		t.Errorf("Expected default phase %s, got %s", PhasePreDeploy, migrations[0].Phase)
+	}
+	if migrations[0].LockTimeout != nil {
+		t.Errorf("Expected no lock timeout override for %s", migrations[0].Filename)
+	}
+
+	m := migrations[1]
+	if m.Phase != PhasePostDeploy {
+		t.Errorf("Expected phase %s, got %s", PhasePostDeploy, m.Phase)
+	}
+	if m.LockTimeout == nil || *m.LockTimeout != 2*time.Second {
+		t.Errorf("Expected lock timeout 2s, got %v", m.LockTimeout)
+	}
+	if m.StatementTimeout == nil || *m.StatementTimeout != 0 {
+		t.Errorf("Expected statement timeout 0, got %v", m.StatementTimeout)
+	}
+}
+
+func TestLoadMigrations_InvalidAnnotation(t *testing.T) {
+	dir := t.TempDir()
+	content := "-- +migrate phase: sometime\nSELECT 1;\n"
+	if err := os.WriteFile(filepath.Join(dir, "0001_bad.sql"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write migration: %v", err)
+	}
+
+	if _, err := LoadMigrations(dir); err == nil {
+		t.Error("Expected error for invalid phase annotation")
+	}
+}
+
+func TestSelectForPhase(t *testing.T) {
+	pending := []Migration{
+		{Version: "0001", Phase: PhasePreDeploy},
+		{Version: "0002", Phase: PhasePostDeploy},
+		{Version: "0003", Phase: PhasePreDeploy},
+	}
+
+	pre := selectForPhase(pending, PhasePreDeploy)
+	if len(pre) != 1 || pre[0].Version != "0001" {
+		t.Errorf("Expected pre-deploy to stop before the first post-deploy migration, got %v", pre)
+	}
+
+	post := selectForPhase(pending, PhasePostDeploy)
+	if len(post) != 3 {
+		t.Errorf("Expected post-deploy to run all pending migrations, got %d", len(post))
+	}
+}
+
+func TestDetectLocks(t *testing.T) {
+	content := `
+BEGIN;
+-- comment mentioning ALTER TABLE ignored
+CREATE TABLE shared_links (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id)
+);
+CREATE INDEX idx_shared_links_user ON shared_links(user_id);
+CREATE INDEX CONCURRENTLY idx_users_phone ON users(phone);
+ALTER TABLE conversions ADD COLUMN priority INT;
+DROP TABLE IF EXISTS rate_limits, worker_stats CASCADE;
+CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    UPDATE users SET updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+UPDATE images SET is_public = false;
+COMMIT;
+`
+
+	locks := DetectLocks(content)
+
+	expected := []TableLock{
+		{Table: "users", Mode: LockShareRowExclusive},
+		{Table: "shared_links", Mode: LockShare},
+		{Table: "users", Mode: LockShareUpdateExclusive},
+		{Table: "conversions", Mode: LockAccessExclusive},
+		{Table: "rate_limits", Mode: LockAccessExclusive},
+		{Table: "worker_stats", Mode: LockAccessExclusive},
+		{Table: "images", Mode: LockRowExclusive},
+	}
+
+	if len(locks) != len(expected) {
+		t.Fatalf("Expected %d locks, got %d: %+v", len(expected), len(locks), locks)
+	}
+	for i, want := range expected {
+		if locks[i].Table != want.Table || locks[i].Mode != want.Mode {
+			t.Errorf("Lock %d: expected %s on %s, got %s on %s", i, want.Mode, want.Table, locks[i].Mode, locks[i].Table)
+		}
+	}
+}
+
+func TestParsePhase(t *testing.T) {
+	tests := map[string]Phase{
+		"":            PhaseAll,
+		"all":         PhaseAll,
+		"pre":         PhasePreDeploy,
+		"post-deploy": PhasePostDeploy,
+	}
+	for input, want := range tests {
+		got, err := ParsePhase(input)
+		if err != nil {
+			t.Errorf("ParsePhase(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("ParsePhase(%q) = %s, want %s", input, got, want)
+		}
+	}
+
+	if _, err := ParsePhase("later"); err == nil {
+		t.Error("Expected error for unknown phase")
+	}
+}
+
+func TestLoadMigrations_UpDownPairs(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"0001_init.sql":         "CREATE TABLE a (id INT);\n",
+		"0002_email.up.sql":     "-- +migrate lock_timeout: 2s\nALTER TABLE a ADD COLUMN email TEXT;\n",
+		"0002_email.down.sql":   "ALTER TABLE a DROP COLUMN email;\n",
+		"0003_phone.up.sql":     "ALTER TABLE a ADD COLUMN phone TEXT;\n",
+		"0004_cleanup.down.sql": "SELECT 1;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	if _, err := LoadMigrations(dir); err == nil {
+		t.Error("Expected error for a down file without an up file")
+	}
+	os.Remove(filepath.Join(dir, "0004_cleanup.down.sql"))
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+
+	if migrations[0].Version != "0001_init" || migrations[0].DownPath != "" {
+		t.Errorf("Expected irreversible 0001_init, got %+v", migrations[0])
+	}
+	if m := migrations[1]; m.Version != "0002_email" || filepath.Base(m.DownPath) != "0002_email.down.sql" {
+		t.Errorf("Expected 0002_email with a down file, got %+v", m)
+	}
+	if m := migrations[1]; m.LockTimeout == nil || *m.LockTimeout != 2*time.Second {
+		t.Errorf("Expected annotations to be read from the up file, got %v", m.LockTimeout)
+	}
+	if migrations[2].Version != "0003_phone" || migrations[2].DownPath != "" {
+		t.Errorf("Expected 0003_phone without a down file, got %+v", migrations[2])
+	}
+}
+
+func TestLoadMigrations_DuplicateVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_init.sql", "0001_init.up.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	if _, err := LoadMigrations(dir); err == nil {
+		t.Error("Expected error for a version defined twice")
+	}
+}
+
+func TestFindVersion(t *testing.T) {
+	migrations := []Migration{
+		{Version: "0001_init"},
+		{Version: "0002_email"},
+		{Version: "0010_phone"},
+	}
+
+	tests := map[string]int{
+		"0":          -1,
+		"2":          1,
+		"0002_email": 1,
+		"10":         2,
+		"0010":       2,
+	}
+	for target, expected := range tests {
+		index, err := findVersion(migrations, target)
+		if err != nil {
+			t.Errorf("Expected %q to resolve, got %v", target, err)
+			continue
+		}
+		if index != expected {
+			t.Errorf("Expected index %d for %q, got %d", expected, target, index)
+		}
+	}
+
+	for _, target := range []string{"3", "email", "0002_phone"} {
+		if _, err := findVersion(migrations, target); err == nil {
+			t.Errorf("Expected error for unknown version %q", target)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rollback reverts the last steps applied migrations, newest first. Nothing
+// runs unless every migration to revert has a down file.
+func Rollback(db *sql.DB, migrationsDir string, steps int, opts Options) error {
+	if steps <= 0 {
+		return fmt.Errorf("rollback steps must be positive, got %d", steps)
+	}
+
+	if err := createMigrationsTable(db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	var toRevert []Migration
+	for i := len(migrations) - 1; i >= 0 && len(toRevert) < steps; i-- {
+		if applied[migrations[i].Version] {
+			toRevert = append(toRevert, migrations[i])
+		}
+	}
+
+	if len(toRevert) < steps {
+		fmt.Printf("Only %d migration(s) are applied, rolling back all of them\n", len(toRevert))
+	}
+
+	if err := revertAll(db, toRevert, opts); err != nil {
+		return err
+	}
+
+	if !opts.DryRun && len(toRevert) > 0 {
+		fmt.Printf("Rolled back %d migration(s)\n", len(toRevert))
+	}
+
+	return nil
+}
+
+// MigrateTo applies or reverts migrations so that target is the newest applied
+// migration. target is a full version (0025_vendor_storefront) or its number
+// (25); "0" reverts every migration.
+func MigrateTo(db *sql.DB, migrationsDir, target string, opts Options) error {
+	if err := createMigrationsTable(db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	index, err := findVersion(migrations, target)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	// Revert newer migrations first so an up never runs on top of them
+	var toRevert []Migration
+	for i := len(migrations) - 1; i > index; i-- {
+		if applied[migrations[i].Version] {
+			toRevert = append(toRevert, migrations[i])
+		}
+	}
+	if err := revertAll(db, toRevert, opts); err != nil {
+		return err
+	}
+
+	var toApply []Migration
+	for i := 0; i <= index; i++ {
+		if !applied[migrations[i].Version] {
+			toApply = append(toApply, migrations[i])
+		}
+	}
+	for _, m := range toApply {
+		if err := applyMigration(db, m, opts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.DryRun && len(toRevert)+len(toApply) > 0 {
+		fmt.Printf("Applied %d and rolled back %d migration(s)\n", len(toApply), len(toRevert))
+	}
+
+	return nil
+}
+
+// revertAll reverts migrations in the given order after checking they all
+// have down files
+func revertAll(db *sql.DB, migrations []Migration, opts Options) error {
+	var irreversible []string
+	for _, m := range migrations {
+		if m.DownPath == "" {
+			irreversible = append(irreversible, m.Filename)
+		}
+	}
+	if len(irreversible) > 0 {
+		return fmt.Errorf("cannot roll back, no down file for: %s", strings.Join(irreversible, ", "))
+	}
+
+	for _, m := range migrations {
+		if err := revertMigration(db, m, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findVersion returns the index of the migration matching target, or -1 for "0"
+func findVersion(migrations []Migration, target string) (int, error) {
+	target = strings.TrimSpace(target)
+	// Only a bare number matches by prefix, a full version must match exactly
+	number, err := strconv.Atoi(target)
+	numeric := err == nil
+	if numeric && number == 0 {
+		return -1, nil
+	}
+
+	match := -1
+	for i, m := range migrations {
+		if m.Version == target {
+			return i, nil
+		}
+		if n, ok := parseVersionNumber(m.Version); ok && numeric && n == number {
+			if match >= 0 {
+				return 0, fmt.Errorf("migration version %q is ambiguous, use the full version", target)
+			}
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return 0, fmt.Errorf("unknown migration version %q", target)
+	}
+	return match, nil
+}
+
+// parseVersionNumber parses the numeric prefix of a version such as 0025_vendor_storefront
+func parseVersionNumber(version string) (int, bool) {
+	digits := version
+	if i := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		digits = version[:i]
+	}
+	if digits == "" || (digits != version && version[len(digits)] != '_') {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
+
+// appliedVersions returns the versions recorded in schema_migrations
+func appliedVersions(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
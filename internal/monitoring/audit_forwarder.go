@@ -0,0 +1,406 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"ai-styler/internal/logging"
+)
+
+// Audit forward targets
+const (
+	AuditForwardTargetSyslog = "syslog"
+	AuditForwardTargetHTTP   = "http"
+)
+
+// AuditForwardConfig represents the configuration for pushing audit logs to a SIEM
+type AuditForwardConfig struct {
+	Enabled       bool
+	Name          string // cursor name, allows several forwarders to share the table
+	Target        string // syslog or http
+	SyslogNetwork string // tcp or udp
+	SyslogAddress string
+	HTTPURL       string
+	HTTPToken     string
+	BatchSize     int
+	Interval      time.Duration
+	Timeout       time.Duration
+}
+
+// AuditForwardEntry is a hash-chained audit log entry as sent to the SIEM
+type AuditForwardEntry struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	UserID     *string         `json:"user_id,omitempty"`
+	HashedUser string          `json:"hashed_user_id,omitempty"` // user ID included in entry_hash
+	ActorType  string          `json:"actor_type"`
+	Action     string          `json:"action"`
+	Resource   string          `json:"resource"`
+	ResourceID *string         `json:"resource_id,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	EntryHash  string          `json:"entry_hash"`
+}
+
+// AuditSink delivers audit log entries to a SIEM
+type AuditSink interface {
+	Send(ctx context.Context, entries []AuditForwardEntry) error
+	Close() error
+}
+
+// auditForwardStore reads audit entries and persists the forward cursor
+type auditForwardStore interface {
+	LoadCursor(ctx context.Context, name string) (int64, error)
+	SaveCursor(ctx context.Context, name string, seq int64) error
+	FetchAfter(ctx context.Context, afterSeq int64, limit int) ([]AuditForwardEntry, error)
+}
+
+// AuditForwarder pushes new audit log entries to a SIEM in sequence order
+type AuditForwarder struct {
+	config AuditForwardConfig
+	store  auditForwardStore
+	sink   AuditSink
+	logger *logging.StructuredLogger
+	cursor int64
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewAuditForwarder creates a forwarder for the configured target
+func NewAuditForwarder(config AuditForwardConfig, db *sql.DB, logger *logging.StructuredLogger) (*AuditForwarder, error) {
+	sink, err := NewAuditSink(config)
+	if err != nil {
+		return nil, err
+	}
+	return newAuditForwarder(config, &dbAuditForwardStore{db: db}, sink, logger), nil
+}
+
+func newAuditForwarder(config AuditForwardConfig, store auditForwardStore, sink AuditSink, logger *logging.StructuredLogger) *AuditForwarder {
+	if config.Name == "" {
+		config.Name = "siem"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 200
+	}
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+
+	return &AuditForwarder{
+		config: config,
+		store:  store,
+		sink:   sink,
+		logger: logger,
+		cursor: -1,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// NewAuditSink creates the sink for the configured target
+func NewAuditSink(config AuditForwardConfig) (AuditSink, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	switch config.Target {
+	case AuditForwardTargetSyslog:
+		if config.SyslogAddress == "" {
+			return nil, errors.New("syslog address is required for audit forwarding")
+		}
+		network := config.SyslogNetwork
+		if network == "" {
+			network = "tcp"
+		}
+		return NewSyslogAuditSink(network, config.SyslogAddress, timeout), nil
+	case AuditForwardTargetHTTP:
+		if config.HTTPURL == "" {
+			return nil, errors.New("HTTP URL is required for audit forwarding")
+		}
+		return NewHTTPAuditSink(config.HTTPURL, config.HTTPToken, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown audit forward target: %s", config.Target)
+	}
+}
+
+// Start runs the forwarding loop in the background
+func (f *AuditForwarder) Start() {
+	go f.run()
+}
+
+// Stop stops the forwarding loop and closes the sink
+func (f *AuditForwarder) Stop() {
+	f.once.Do(func() {
+		close(f.stop)
+		<-f.done
+		f.sink.Close()
+	})
+}
+
+func (f *AuditForwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), f.config.Interval)
+		// Drain backlogs batch by batch before waiting for the next tick
+		for {
+			sent, err := f.ForwardBatch(ctx)
+			if err != nil {
+				f.logger.Error(ctx, "Failed to forward audit logs", map[string]interface{}{
+					"error":  err.Error(),
+					"target": f.config.Target,
+				})
+				break
+			}
+			if sent < f.config.BatchSize {
+				break
+			}
+		}
+		cancel()
+
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ForwardBatch sends the next batch of entries and advances the cursor.
+// It returns the number of entries sent.
+func (f *AuditForwarder) ForwardBatch(ctx context.Context) (int, error) {
+	if f.cursor < 0 {
+		cursor, err := f.store.LoadCursor(ctx, f.config.Name)
+		if err != nil {
+			return 0, err
+		}
+		f.cursor = cursor
+	}
+
+	entries, err := f.store.FetchAfter(ctx, f.cursor, f.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	if err := f.sink.Send(ctx, entries); err != nil {
+		return 0, fmt.Errorf("failed to send audit logs: %w", err)
+	}
+
+	last := entries[len(entries)-1].Seq
+	if err := f.store.SaveCursor(ctx, f.config.Name, last); err != nil {
+		return 0, err
+	}
+	f.cursor = last
+
+	return len(entries), nil
+}
+
+// syslogAuditSink sends entries as RFC 5424 messages with a JSON body
+type syslogAuditSink struct {
+	network  string
+	address  string
+	timeout  time.Duration
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogAuditSink creates a sink that writes to a remote syslog collector
+func NewSyslogAuditSink(network, address string, timeout time.Duration) AuditSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogAuditSink{
+		network:  network,
+		address:  address,
+		timeout:  timeout,
+		hostname: hostname,
+	}
+}
+
+// Send writes one syslog message per entry, reconnecting on failure
+func (s *syslogAuditSink) Send(ctx context.Context, entries []AuditForwardEntry) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+
+	for _, entry := range entries {
+		line, err := formatSyslogAuditMessage(s.hostname, entry)
+		if err != nil {
+			return err
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if _, err := s.conn.Write(line); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the syslog connection
+func (s *syslogAuditSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// formatSyslogAuditMessage renders an entry as an RFC 5424 line.
+// Priority 110 is facility log audit (13) with severity informational (6).
+func formatSyslogAuditMessage(hostname string, entry AuditForwardEntry) ([]byte, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit log entry: %w", err)
+	}
+	header := fmt.Sprintf("<110>1 %s %s ai-styler - audit - ",
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano), hostname)
+	return append(append([]byte(header), body...), '\n'), nil
+}
+
+// httpAuditSink posts batches of entries as JSON
+type httpAuditSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPAuditSink creates a sink that posts {"entries": [...]} to a SIEM collector
+func NewHTTPAuditSink(url, token string, timeout time.Duration) AuditSink {
+	return &httpAuditSink{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts a batch of entries
+func (s *httpAuditSink) Send(ctx context.Context, entries []AuditForwardEntry) error {
+	body, err := json.Marshal(map[string]interface{}{"entries": entries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit logs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("SIEM collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close is a no-op for the HTTP sink
+func (s *httpAuditSink) Close() error {
+	return nil
+}
+
+// dbAuditForwardStore reads audit_logs and audit_forward_cursors
+type dbAuditForwardStore struct {
+	db *sql.DB
+}
+
+// LoadCursor returns the last forwarded sequence number
+func (s *dbAuditForwardStore) LoadCursor(ctx context.Context, name string) (int64, error) {
+	var seq int64
+	err := s.db.QueryRowContext(ctx, `SELECT last_seq FROM audit_forward_cursors WHERE name = $1`, name).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load audit forward cursor: %w", err)
+	}
+	return seq, nil
+}
+
+// SaveCursor persists the last forwarded sequence number
+func (s *dbAuditForwardStore) SaveCursor(ctx context.Context, name string, seq int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_forward_cursors (name, last_seq, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET last_seq = EXCLUDED.last_seq, updated_at = NOW()`,
+		name, seq)
+	if err != nil {
+		return fmt.Errorf("failed to save audit forward cursor: %w", err)
+	}
+	return nil
+}
+
+// FetchAfter returns entries with a sequence number above afterSeq
+func (s *dbAuditForwardStore) FetchAfter(ctx context.Context, afterSeq int64, limit int) ([]AuditForwardEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT seq, id, user_id, COALESCE(hashed_user_id, ''), actor_type, action,
+			COALESCE(resource, ''), resource_id, metadata::text, created_at, prev_hash, entry_hash
+		FROM audit_logs
+		WHERE seq > $1
+		ORDER BY seq ASC
+		LIMIT $2`, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditForwardEntry
+	for rows.Next() {
+		var entry AuditForwardEntry
+		var userID, resourceID sql.NullString
+		var metadata string
+
+		if err := rows.Scan(&entry.Seq, &entry.ID, &userID, &entry.HashedUser, &entry.ActorType, &entry.Action, &entry.Resource,
+			&resourceID, &metadata, &entry.CreatedAt, &entry.PrevHash, &entry.EntryHash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if userID.Valid {
+			entry.UserID = &userID.String
+		}
+		if resourceID.Valid {
+			entry.ResourceID = &resourceID.String
+		}
+		entry.Metadata = []byte(metadata)
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+	return entries, nil
+}
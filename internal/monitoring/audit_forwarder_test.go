@@ -0,0 +1,157 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/logging"
+)
+
+type memoryAuditForwardStore struct {
+	entries []AuditForwardEntry
+	cursors map[string]int64
+}
+
+func (s *memoryAuditForwardStore) LoadCursor(ctx context.Context, name string) (int64, error) {
+	return s.cursors[name], nil
+}
+
+func (s *memoryAuditForwardStore) SaveCursor(ctx context.Context, name string, seq int64) error {
+	s.cursors[name] = seq
+	return nil
+}
+
+func (s *memoryAuditForwardStore) FetchAfter(ctx context.Context, afterSeq int64, limit int) ([]AuditForwardEntry, error) {
+	var entries []AuditForwardEntry
+	for _, entry := range s.entries {
+		if entry.Seq > afterSeq && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func newMemoryAuditForwardStore(count int) *memoryAuditForwardStore {
+	store := &memoryAuditForwardStore{cursors: make(map[string]int64)}
+	for i := 1; i <= count; i++ {
+		store.entries = append(store.entries, AuditForwardEntry{
+			Seq:       int64(i),
+			ID:        "log",
+			ActorType: "admin",
+			Action:    "update",
+			Metadata:  json.RawMessage(`{}`),
+			CreatedAt: time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC),
+			PrevHash:  strings.Repeat("0", 64),
+			EntryHash: strings.Repeat("a", 64),
+		})
+	}
+	return store
+}
+
+func TestAuditForwarder_HTTP(t *testing.T) {
+	var batches [][]AuditForwardEntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %s", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Entries []AuditForwardEntry `json:"entries"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		batches = append(batches, body.Entries)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	store := newMemoryAuditForwardStore(3)
+	forwarder := newAuditForwarder(AuditForwardConfig{BatchSize: 2},
+		store, NewHTTPAuditSink(server.URL, "secret", time.Second),
+		logging.NewStructuredLogger(logging.GetDefaultLoggerConfig()))
+
+	ctx := context.Background()
+	for _, expected := range []int{2, 1, 0} {
+		sent, err := forwarder.ForwardBatch(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if sent != expected {
+			t.Errorf("Expected %d entries sent, got %d", expected, sent)
+		}
+	}
+
+	if len(batches) != 2 {
+		t.Errorf("Expected 2 batches, got %d", len(batches))
+	}
+	if store.cursors["siem"] != 3 {
+		t.Errorf("Expected cursor 3, got %d", store.cursors["siem"])
+	}
+}
+
+func TestAuditForwarder_HTTPFailureKeepsCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newMemoryAuditForwardStore(1)
+	forwarder := newAuditForwarder(AuditForwardConfig{}, store, NewHTTPAuditSink(server.URL, "", time.Second), nil)
+
+	if _, err := forwarder.ForwardBatch(context.Background()); err == nil {
+		t.Error("Expected error when the collector is unavailable")
+	}
+	if store.cursors["siem"] != 0 {
+		t.Errorf("Expected cursor to stay at 0, got %d", store.cursors["siem"])
+	}
+}
+
+func TestSyslogAuditSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	sink := NewSyslogAuditSink("tcp", listener.Addr().String(), time.Second)
+	defer sink.Close()
+
+	store := newMemoryAuditForwardStore(2)
+	if err := sink.Send(context.Background(), store.entries); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case line := <-lines:
+			if !strings.HasPrefix(line, "<110>1 2025-06-01T12:00:00Z ") {
+				t.Errorf("Expected RFC 5424 header, got %s", line)
+			}
+			if !strings.Contains(line, ` ai-styler - audit - {"seq":`) {
+				t.Errorf("Expected JSON payload, got %s", line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for syslog message")
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +29,7 @@ type HealthCheck struct {
 	Status      HealthStatus           `json:"status"`
 	Message     string                 `json:"message,omitempty"`
 	Duration    time.Duration          `json:"duration"`
+	LatencyMs   float64                `json:"latency_ms"`
 	LastChecked time.Time              `json:"last_checked"`
 	Details     map[string]interface{} `json:"details,omitempty"`
 }
@@ -41,6 +44,15 @@ type HealthResponse struct {
 	Summary   HealthSummary `json:"summary"`
 }
 
+// ReadinessResponse is the readiness of the instance to serve traffic, with
+// the result of every dependency probe
+type ReadinessResponse struct {
+	Status    string        `json:"status"` // ready or not ready
+	Timestamp time.Time     `json:"timestamp"`
+	Checks    []HealthCheck `json:"checks"`
+	Summary   HealthSummary `json:"summary"`
+}
+
 // HealthSummary provides a summary of health checks
 type HealthSummary struct {
 	Total     int `json:"total"`
@@ -67,12 +79,19 @@ type MemoryInfo struct {
 	NumGC      uint32 `json:"num_gc"`
 }
 
+// DefaultHealthCheckTimeout bounds a single health check
+const DefaultHealthCheckTimeout = 5 * time.Second
+
 // HealthMonitor provides health monitoring capabilities
 type HealthMonitor struct {
 	startTime   time.Time
 	version     string
 	environment string
-	checks      map[string]HealthChecker
+	timeout     time.Duration
+
+	mu        sync.RWMutex
+	checks    map[string]HealthChecker
+	readiness map[string]bool // checks the instance cannot serve traffic without
 }
 
 // HealthChecker interface for health checks
@@ -93,41 +112,78 @@ type RedisHealthChecker struct {
 // SystemHealthChecker checks system health
 type SystemHealthChecker struct{}
 
+// StorageProbeWriter writes objects to the configured storage backend
+type StorageProbeWriter interface {
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// StorageHealthChecker checks that storage accepts writes by overwriting a
+// small probe object
+type StorageHealthChecker struct {
+	writer StorageProbeWriter
+	key    string
+}
+
+// AIProviderProbe checks the configured AI provider answers requests
+type AIProviderProbe interface {
+	ProviderHealthCheck(ctx context.Context) error
+}
+
+// AIProviderHealthChecker checks the AI provider. A failing provider only
+// degrades the service: every instance shares it, so taking instances out of
+// rotation would not help.
+type AIProviderHealthChecker struct {
+	provider string
+	probe    AIProviderProbe
+}
+
+// CachedHealthChecker reuses the result of a costly check for a while, so
+// frequent load balancer probes do not hit the dependency every time
+type CachedHealthChecker struct {
+	checker HealthChecker
+	ttl     time.Duration
+
+	mu   sync.Mutex
+	last *HealthCheck
+}
+
 // NewHealthMonitor creates a new health monitor
 func NewHealthMonitor(version string, environment string) *HealthMonitor {
 	return &HealthMonitor{
 		startTime:   time.Now(),
 		version:     version,
 		environment: environment,
+		timeout:     DefaultHealthCheckTimeout,
 		checks:      make(map[string]HealthChecker),
+		readiness:   make(map[string]bool),
+	}
+}
+
+// SetCheckTimeout bounds every health check to timeout
+func (h *HealthMonitor) SetCheckTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.timeout = timeout
 	}
 }
 
 // AddChecker adds a health checker
 func (h *HealthMonitor) AddChecker(name string, checker HealthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = checker
+}
+
+// AddReadinessChecker adds a health checker that readiness also depends on
+func (h *HealthMonitor) AddReadinessChecker(name string, checker HealthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.checks[name] = checker
+	h.readiness[name] = true
 }
 
 // GetHealth returns the overall health status
 func (h *HealthMonitor) GetHealth(ctx context.Context) HealthResponse {
-	checks := make([]HealthCheck, 0, len(h.checks))
-	summary := HealthSummary{}
-
-	for name, checker := range h.checks {
-		check := checker.Check(ctx)
-		check.Name = name
-		checks = append(checks, check)
-
-		summary.Total++
-		switch check.Status {
-		case HealthStatusHealthy:
-			summary.Healthy++
-		case HealthStatusDegraded:
-			summary.Degraded++
-		case HealthStatusUnhealthy:
-			summary.Unhealthy++
-		}
-	}
+	checks, summary := h.runChecks(ctx, false)
 
 	// Determine overall status
 	var status HealthStatus
@@ -149,6 +205,73 @@ func (h *HealthMonitor) GetHealth(ctx context.Context) HealthResponse {
 	}
 }
 
+// GetReadiness probes the dependencies the instance needs to serve traffic.
+// Degraded dependencies do not make it unready.
+func (h *HealthMonitor) GetReadiness(ctx context.Context) ReadinessResponse {
+	checks, summary := h.runChecks(ctx, true)
+
+	status := "ready"
+	if summary.Unhealthy > 0 {
+		status = "not ready"
+	}
+	return ReadinessResponse{
+		Status:    status,
+		Timestamp: time.Now(),
+		Checks:    checks,
+		Summary:   summary,
+	}
+}
+
+// runChecks runs the checks concurrently, each bounded by the check timeout,
+// and returns them sorted by name
+func (h *HealthMonitor) runChecks(ctx context.Context, readinessOnly bool) ([]HealthCheck, HealthSummary) {
+	h.mu.RLock()
+	checkers := make(map[string]HealthChecker, len(h.checks))
+	for name, checker := range h.checks {
+		if !readinessOnly || h.readiness[name] {
+			checkers[name] = checker
+		}
+	}
+	h.mu.RUnlock()
+
+	checks := make([]HealthCheck, 0, len(checkers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			check := checker.Check(checkCtx)
+			check.Name = name
+			check.LatencyMs = float64(check.Duration.Microseconds()) / 1000
+
+			mu.Lock()
+			checks = append(checks, check)
+			mu.Unlock()
+		}(name, checker)
+	}
+	wg.Wait()
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	summary := HealthSummary{}
+	for _, check := range checks {
+		summary.Total++
+		switch check.Status {
+		case HealthStatusHealthy:
+			summary.Healthy++
+		case HealthStatusDegraded:
+			summary.Degraded++
+		case HealthStatusUnhealthy:
+			summary.Unhealthy++
+		}
+	}
+	return checks, summary
+}
+
 // GetSystemInfo returns system information
 func (h *HealthMonitor) GetSystemInfo() SystemInfo {
 	var memStats runtime.MemStats
@@ -313,6 +436,95 @@ func (s *SystemHealthChecker) Check(ctx context.Context) HealthCheck {
 	}
 }
 
+// NewStorageHealthChecker creates a storage checker writing its probe under key
+func NewStorageHealthChecker(writer StorageProbeWriter, key string) *StorageHealthChecker {
+	return &StorageHealthChecker{writer: writer, key: key}
+}
+
+// Check performs a health check for storage
+func (s *StorageHealthChecker) Check(ctx context.Context) HealthCheck {
+	start := time.Now()
+
+	probe := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	err := s.writer.WriteObject(ctx, s.key, probe, "text/plain")
+	duration := time.Since(start)
+
+	if err != nil {
+		return HealthCheck{
+			Status:      HealthStatusUnhealthy,
+			Message:     fmt.Sprintf("Storage write failed: %v", err),
+			Duration:    duration,
+			LastChecked: time.Now(),
+		}
+	}
+
+	return HealthCheck{
+		Status:      HealthStatusHealthy,
+		Message:     "Storage accepts writes",
+		Duration:    duration,
+		LastChecked: time.Now(),
+		Details:     map[string]interface{}{"probe_key": s.key},
+	}
+}
+
+// NewAIProviderHealthChecker creates a checker for the named AI provider
+func NewAIProviderHealthChecker(provider string, probe AIProviderProbe) *AIProviderHealthChecker {
+	return &AIProviderHealthChecker{provider: provider, probe: probe}
+}
+
+// Check performs a health check for the AI provider
+func (a *AIProviderHealthChecker) Check(ctx context.Context) HealthCheck {
+	start := time.Now()
+
+	err := a.probe.ProviderHealthCheck(ctx)
+	duration := time.Since(start)
+	details := map[string]interface{}{"provider": a.provider}
+
+	if err != nil {
+		return HealthCheck{
+			Status:      HealthStatusDegraded,
+			Message:     fmt.Sprintf("AI provider check failed: %v", err),
+			Duration:    duration,
+			LastChecked: time.Now(),
+			Details:     details,
+		}
+	}
+
+	return HealthCheck{
+		Status:      HealthStatusHealthy,
+		Message:     "AI provider reachable",
+		Duration:    duration,
+		LastChecked: time.Now(),
+		Details:     details,
+	}
+}
+
+// NewCachedHealthChecker caches the results of checker for ttl
+func NewCachedHealthChecker(checker HealthChecker, ttl time.Duration) *CachedHealthChecker {
+	return &CachedHealthChecker{checker: checker, ttl: ttl}
+}
+
+// Check returns the cached result while it is fresh, checking again
+// afterwards. Concurrent callers wait for a single check.
+func (c *CachedHealthChecker) Check(ctx context.Context) HealthCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.LastChecked) < c.ttl {
+		cached := *c.last
+		cached.Details = make(map[string]interface{}, len(c.last.Details)+1)
+		for key, value := range c.last.Details {
+			cached.Details[key] = value
+		}
+		cached.Details["cached"] = true
+		return cached
+	}
+
+	check := c.checker.Check(ctx)
+	c.last = &check
+	return check
+}
+
 // HealthHandler handles health check endpoints
 type HealthHandler struct {
 	monitor *HealthMonitor
@@ -343,30 +555,21 @@ func (h *HealthHandler) Health(c *gin.Context) {
 	c.JSON(statusCode, health)
 }
 
-// Readiness returns the readiness status
+// Readiness returns the readiness status with the status and latency of
+// every dependency probe
 func (h *HealthHandler) Readiness(c *gin.Context) {
-	ctx := c.Request.Context()
-	health := h.monitor.GetHealth(ctx)
-
-	// For readiness, we only care about critical components
-	ready := true
-	for _, check := range health.Checks {
-		if check.Status == HealthStatusUnhealthy {
-			ready = false
-			break
-		}
-	}
+	readiness := h.monitor.GetReadiness(c.Request.Context())
 
-	if ready {
-		c.JSON(http.StatusOK, gin.H{"status": "ready"})
-	} else {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+	statusCode := http.StatusOK
+	if readiness.Summary.Unhealthy > 0 {
+		statusCode = http.StatusServiceUnavailable
 	}
+	c.JSON(statusCode, readiness)
 }
 
-// Liveness returns the liveness status
+// Liveness returns the liveness status. It never probes dependencies, so an
+// outage of one does not get healthy instances restarted.
 func (h *HealthHandler) Liveness(c *gin.Context) {
-	// Liveness check is simple - if the service is running, it's alive
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "alive",
 		"timestamp": time.Now(),
@@ -2,11 +2,17 @@ package monitoring
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"ai-styler/internal/common"
 	"ai-styler/internal/logging"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Define custom types for context keys to avoid SA1029 warnings
@@ -564,3 +570,105 @@ func TestMonitoringIntegration(t *testing.T) {
 		t.Error("Expected system info to be available")
 	}
 }
+
+type fakeProbeWriter struct {
+	err    error
+	writes int
+}
+
+func (f *fakeProbeWriter) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	f.writes++
+	return f.err
+}
+
+type fakeProviderProbe struct {
+	err   error
+	calls int
+}
+
+func (f *fakeProviderProbe) ProviderHealthCheck(ctx context.Context) error {
+	f.calls++
+	return f.err
+}
+
+func TestHealthMonitorReadiness(t *testing.T) {
+	monitor := NewHealthMonitor("1.0.0", "test")
+	storage := &fakeProbeWriter{}
+	provider := &fakeProviderProbe{err: errors.New("quota exceeded")}
+	monitor.AddChecker("system", &SystemHealthChecker{})
+	monitor.AddReadinessChecker("storage", NewStorageHealthChecker(storage, "health/probe.txt"))
+	monitor.AddReadinessChecker("ai_provider", NewAIProviderHealthChecker("gemini:test", provider))
+
+	readiness := monitor.GetReadiness(context.Background())
+	if readiness.Status != "ready" || len(readiness.Checks) != 2 {
+		t.Fatalf("Expected ready with 2 checks, got %s with %d", readiness.Status, len(readiness.Checks))
+	}
+	if readiness.Checks[0].Name != "ai_provider" || readiness.Checks[0].Status != HealthStatusDegraded {
+		t.Errorf("Expected a failing AI provider to only degrade, got %+v", readiness.Checks[0])
+	}
+
+	storage.err = errors.New("permission denied")
+	readiness = monitor.GetReadiness(context.Background())
+	if readiness.Status != "not ready" || readiness.Summary.Unhealthy != 1 {
+		t.Errorf("Expected a failing storage write to make the instance unready, got %+v", readiness)
+	}
+	if len(monitor.GetHealth(context.Background()).Checks) != 3 {
+		t.Error("Expected the full health report to include non-readiness checks")
+	}
+}
+
+func TestCachedHealthChecker(t *testing.T) {
+	provider := &fakeProviderProbe{}
+	checker := NewCachedHealthChecker(NewAIProviderHealthChecker("gemini:test", provider), time.Minute)
+
+	first := checker.Check(context.Background())
+	second := checker.Check(context.Background())
+	if provider.calls != 1 {
+		t.Errorf("Expected one provider call within the TTL, got %d", provider.calls)
+	}
+	if first.Details["cached"] != nil || second.Details["cached"] != true {
+		t.Errorf("Expected only the second result to be cached, got %v and %v", first.Details, second.Details)
+	}
+
+	checker.ttl = 0
+	checker.Check(context.Background())
+	if provider.calls != 2 {
+		t.Errorf("Expected an expired result to be checked again, got %d calls", provider.calls)
+	}
+}
+
+func setupHealthTestRouter(handler *HealthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestReadinessHandler(t *testing.T) {
+	monitor := NewHealthMonitor("1.0.0", "test")
+	monitor.AddReadinessChecker("storage", NewStorageHealthChecker(&fakeProbeWriter{err: errors.New("disk full")}, "health/probe.txt"))
+	handler := NewHealthHandler(monitor)
+
+	router := setupHealthTestRouter(handler)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/health/ready", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", w.Code)
+	}
+	var readiness ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &readiness); err != nil {
+		t.Fatalf("Expected a JSON body, got %v", err)
+	}
+	if len(readiness.Checks) != 1 || readiness.Checks[0].Message == "" {
+		t.Errorf("Expected the failing storage probe in the body, got %+v", readiness.Checks)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/health/live", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected liveness to ignore dependencies, got %d", w.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of all spans created by this service
+const TracerName = "ai-styler"
+
+// OTelConfig configures OpenTelemetry tracing
+type OTelConfig struct {
+	Enabled     bool
+	Endpoint    string // OTLP/HTTP collector as host:port or a full URL
+	Insecure    bool   // plain HTTP for host:port endpoints
+	SampleRatio float64
+	ServiceName string
+	Environment string
+	Version     string
+}
+
+// Tracer returns the service tracer from the global tracer provider. Spans
+// are no-ops until InitTracing installs an exporting provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// InitTracing installs a global tracer provider exporting spans to the OTLP
+// endpoint and the W3C trace context propagator. The returned function
+// flushes pending spans and must be called on shutdown.
+func InitTracing(ctx context.Context, config OTelConfig) (func(context.Context) error, error) {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("tracing is enabled but no OTLP endpoint is configured")
+	}
+
+	var opts []otlptracehttp.Option
+	if strings.Contains(config.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(config.Endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = TracerName
+	}
+	attrs := []attribute.KeyValue{attribute.String("service.name", serviceName)}
+	if config.Version != "" {
+		attrs = append(attrs, attribute.String("service.version", config.Version))
+	}
+	if config.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment.name", config.Environment))
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// EndSpan records err on span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
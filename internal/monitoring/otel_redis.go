@@ -0,0 +1,95 @@
+package monitoring
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisTracingHook records Redis commands and pipelines as child spans of the
+// span in the command context
+type RedisTracingHook struct{}
+
+// NewRedisTracingHook creates a Redis hook for client.AddHook
+func NewRedisTracingHook() *RedisTracingHook {
+	return &RedisTracingHook{}
+}
+
+type redisSpanKey struct{}
+
+func (h *RedisTracingHook) start(ctx context.Context, name, statement string) context.Context {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx
+	}
+	ctx, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", name),
+		),
+	)
+	if statement != "" {
+		span.SetAttributes(attribute.String("db.statement", statement))
+	}
+	return context.WithValue(ctx, redisSpanKey{}, span)
+}
+
+func (h *RedisTracingHook) end(ctx context.Context, err error) {
+	span, ok := ctx.Value(redisSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if err == redis.Nil {
+		err = nil
+	}
+	EndSpan(span, err)
+}
+
+// BeforeProcess starts a span for a single command. Only the command name and
+// key are recorded since values may hold tokens or OTP codes.
+func (h *RedisTracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.start(ctx, "redis "+strings.ToUpper(cmd.Name()), redisStatement(cmd)), nil
+}
+
+// AfterProcess ends the command span
+func (h *RedisTracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.end(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline starts a span for a pipeline
+func (h *RedisTracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = strings.ToUpper(cmd.Name())
+	}
+	return h.start(ctx, "redis pipeline", strings.Join(names, " ")), nil
+}
+
+// AfterProcessPipeline ends the pipeline span with the first command error
+func (h *RedisTracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && cmdErr != redis.Nil {
+			err = cmdErr
+			break
+		}
+	}
+	h.end(ctx, err)
+	return nil
+}
+
+// redisStatement returns the command name and its first argument, the key
+func redisStatement(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return strings.ToUpper(cmd.Name())
+	}
+	if key, ok := args[1].(string); ok {
+		return strings.ToUpper(cmd.Name()) + " " + key
+	}
+	return strings.ToUpper(cmd.Name())
+}
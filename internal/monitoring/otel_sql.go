@@ -0,0 +1,277 @@
+package monitoring
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementLength bounds the db.statement attribute
+const maxStatementLength = 2048
+
+// OpenTracedDB opens a database whose queries are recorded as child spans of
+// the span in the query context. Queries without a parent span, such as
+// pool health checks, are not traced.
+func OpenTracedDB(driverName, dsn string) (*sql.DB, error) {
+	return OpenInstrumentedDB(driverName, dsn, nil)
+}
+
+// OpenInstrumentedDB opens a traced database like OpenTracedDB that also
+// records every query in metrics, when it is not nil
+func OpenInstrumentedDB(driverName, dsn string, metrics *QueryMetrics) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	var connector driver.Connector
+	if dc, ok := drv.(driver.DriverContext); ok {
+		connector, err = dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connector: %w", err)
+		}
+	} else {
+		connector = dsnConnector{driver: drv, dsn: dsn}
+	}
+
+	return sql.OpenDB(&tracedConnector{connector: connector, system: dbSystem(driverName), metrics: metrics}), nil
+}
+
+func dbSystem(driverName string) string {
+	switch driverName {
+	case "postgres", "pgx":
+		return "postgresql"
+	default:
+		return driverName
+	}
+}
+
+// dsnConnector adapts drivers that do not implement driver.DriverContext
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type tracedConnector struct {
+	connector driver.Connector
+	system    string
+	metrics   *QueryMetrics
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, system: c.system, metrics: c.metrics}, nil
+}
+
+func (c *tracedConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// startQuerySpan starts a client span for a statement when ctx carries a span
+func startQuerySpan(ctx context.Context, system, operation, query string) (context.Context, trace.Span, bool) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, nil, false
+	}
+	if len(query) > maxStatementLength {
+		query = query[:maxStatementLength]
+	}
+	name := "db." + operation
+	if verb := statementVerb(query); verb != "" {
+		name = verb
+	}
+	ctx, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+	return ctx, span, true
+}
+
+// statementVerb returns the leading SQL keyword of query, e.g. SELECT
+func statementVerb(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// endQuerySpan ends span, ignoring driver.ErrSkip which only makes
+// database/sql fall back to another code path
+func endQuerySpan(span trace.Span, err error) {
+	if err == driver.ErrSkip {
+		err = nil
+	}
+	EndSpan(span, err)
+}
+
+// observeQuery records a finished statement when metrics are enabled
+func observeQuery(ctx context.Context, metrics *QueryMetrics, operation, query string, start time.Time, err error) {
+	if metrics != nil {
+		metrics.observe(ctx, operation, query, time.Since(start), err)
+	}
+}
+
+type tracedConn struct {
+	driver.Conn
+	system  string
+	metrics *QueryMetrics
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span, traced := startQuerySpan(ctx, c.system, "exec", query)
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	observeQuery(ctx, c.metrics, "exec", query, start, err)
+	if traced {
+		endQuerySpan(span, err)
+	}
+	return result, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span, traced := startQuerySpan(ctx, c.system, "query", query)
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	observeQuery(ctx, c.metrics, "query", query, start, err)
+	if traced {
+		endQuerySpan(span, err)
+	}
+	return rows, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query, system: c.system, metrics: c.metrics}, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	query   string
+	system  string
+	metrics *QueryMetrics
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span, traced := startQuerySpan(ctx, s.system, "exec", s.query)
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args)) //nolint:staticcheck // fallback for drivers without ExecContext
+	}
+	observeQuery(ctx, s.metrics, "exec", s.query, start, err)
+	if traced {
+		endQuerySpan(span, err)
+	}
+	return result, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span, traced := startQuerySpan(ctx, s.system, "query", s.query)
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args)) //nolint:staticcheck // fallback for drivers without QueryContext
+	}
+	observeQuery(ctx, s.metrics, "query", s.query, start, err)
+	if traced {
+		endQuerySpan(span, err)
+	}
+	return rows, err
+}
+
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts positional arguments for the pre-context driver API
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
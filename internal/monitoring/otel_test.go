@@ -0,0 +1,93 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInitTracingDisabled(t *testing.T) {
+	shutdown, err := InitTracing(context.Background(), OTelConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no-op shutdown, got %v", err)
+	}
+
+	if _, err := InitTracing(context.Background(), OTelConfig{Enabled: true}); err == nil {
+		t.Error("Expected error without an endpoint")
+	}
+}
+
+func TestRedisTracingHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	hook := NewRedisTracingHook()
+
+	// Commands outside a trace are not recorded
+	cmd := redis.NewStringCmd(context.Background(), "get", "otp:123")
+	ctx, _ := hook.BeforeProcess(context.Background(), cmd)
+	hook.AfterProcess(ctx, cmd)
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("Expected no span without a parent, got %d", len(recorder.Ended()))
+	}
+
+	parent, span := Tracer().Start(context.Background(), "request")
+	cmd = redis.NewStringCmd(parent, "set", "otp:123", "secret-code")
+	ctx, _ = hook.BeforeProcess(parent, cmd)
+	hook.AfterProcess(ctx, cmd)
+
+	miss := redis.NewStringCmd(parent, "get", "otp:456")
+	miss.SetErr(redis.Nil)
+	ctx, _ = hook.BeforeProcess(parent, miss)
+	hook.AfterProcess(ctx, miss)
+
+	failed := redis.NewStringCmd(parent, "get", "otp:789")
+	failed.SetErr(errors.New("connection refused"))
+	cmds := []redis.Cmder{redis.NewStringCmd(parent, "incr", "rate"), failed}
+	ctx, _ = hook.BeforeProcessPipeline(parent, cmds)
+	hook.AfterProcessPipeline(ctx, cmds)
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "redis SET" || spans[0].Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected a redis SET child span, got %s", spans[0].Name())
+	}
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "db.statement" && kv.Value.AsString() != "SET otp:123" {
+			t.Errorf("Expected values to be left out of the statement, got %s", kv.Value.AsString())
+		}
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Error("Expected a cache miss not to be an error")
+	}
+	if spans[2].Name() != "redis pipeline" || spans[2].Status().Code != codes.Error {
+		t.Errorf("Expected a failed pipeline span, got %s (%v)", spans[2].Name(), spans[2].Status())
+	}
+}
+
+func TestStatementVerb(t *testing.T) {
+	tests := map[string]string{
+		"\n\t\tSELECT id FROM users": "SELECT",
+		"update users set x = 1":     "UPDATE",
+		"":                           "",
+	}
+	for query, expected := range tests {
+		if verb := statementVerb(query); verb != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, query, verb)
+		}
+	}
+}
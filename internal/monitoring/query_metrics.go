@@ -0,0 +1,401 @@
+package monitoring
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Query metrics defaults
+const (
+	DefaultSlowQueryThreshold = 500 * time.Millisecond
+	DefaultMaxQueryStatements = 500
+)
+
+// otherStatement collects statements past QueryMetricsConfig.MaxStatements
+const otherStatement = "(other)"
+
+// maxNormalizedCache bounds the raw query to statement cache
+const maxNormalizedCache = 4096
+
+// queryLatencyBuckets are the upper bounds of the latency histogram buckets;
+// the last bucket has no upper bound
+var queryLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// queryCallerPackages are skipped when looking for the module that ran a query
+var queryCallerPackages = []string{
+	"database/sql.",
+	"runtime.",
+	"github.com/lib/pq.",
+	"ai-styler/internal/monitoring.",
+	"ai-styler/internal/common.", // DBRouter and transaction helpers
+}
+
+// moduleRoot is trimmed from caller functions, so ai-styler/internal/admin
+// is reported as admin
+const moduleRoot = "ai-styler/internal/"
+
+var (
+	queryLiteralPattern = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+	queryListPattern    = regexp.MustCompile(`(\$?\?)(?:\s*,\s*\$?\?)+`)
+)
+
+// QueryMetricsConfig configures database query metrics
+type QueryMetricsConfig struct {
+	SlowThreshold time.Duration // queries taking longer are logged; 0 uses the default
+	MaxStatements int           // distinct statements tracked per module before they are grouped as (other)
+}
+
+// QueryStats are the recorded executions of one statement from one module
+type QueryStats struct {
+	Module    string           `json:"module"`
+	Caller    string           `json:"caller"` // function that first ran the statement
+	Statement string           `json:"statement"`
+	Operation string           `json:"operation"` // query or exec
+	Count     int64            `json:"count"`
+	Errors    int64            `json:"errors"`
+	Slow      int64            `json:"slow"`
+	TotalMs   float64          `json:"totalMs"`
+	MeanMs    float64          `json:"meanMs"`
+	MaxMs     float64          `json:"maxMs"`
+	P50Ms     float64          `json:"p50Ms"`
+	P95Ms     float64          `json:"p95Ms"`
+	P99Ms     float64          `json:"p99Ms"`
+	Buckets   map[string]int64 `json:"buckets"` // upper bound ("le") -> executions, not cumulative
+}
+
+// QueryMetricsSnapshot lists the busiest statements, by total time
+type QueryMetricsSnapshot struct {
+	Since         time.Time    `json:"since"`
+	SlowThreshold string       `json:"slowThreshold"`
+	Queries       int64        `json:"queries"`
+	SlowQueries   int64        `json:"slowQueries"`
+	Statements    []QueryStats `json:"statements"`
+}
+
+// queryKey identifies a statement run from a module
+type queryKey struct {
+	module    string
+	statement string
+}
+
+type queryStats struct {
+	caller    string
+	operation string
+	count     int64
+	errors    int64
+	slow      int64
+	total     time.Duration
+	max       time.Duration
+	buckets   []int64 // len(queryLatencyBuckets)+1
+}
+
+// QueryMetrics records latency histograms of database statements per
+// calling module and logs slow ones. Statements are normalized, so the
+// same query with other literal values or list lengths is counted once.
+// Query latency covers the driver call, not reading the returned rows.
+type QueryMetrics struct {
+	config QueryMetricsConfig
+	logger *logging.StructuredLogger
+
+	mu         sync.Mutex
+	since      time.Time
+	stats      map[queryKey]*queryStats
+	perModule  map[string]int
+	normalized map[string]string
+	queries    int64
+	slow       int64
+}
+
+// NewQueryMetrics creates a query metrics registry. Open databases with
+// OpenInstrumentedDB to fill it.
+func NewQueryMetrics(config QueryMetricsConfig) *QueryMetrics {
+	if config.SlowThreshold <= 0 {
+		config.SlowThreshold = DefaultSlowQueryThreshold
+	}
+	if config.MaxStatements <= 0 {
+		config.MaxStatements = DefaultMaxQueryStatements
+	}
+	q := &QueryMetrics{config: config}
+	q.Reset()
+	return q
+}
+
+// SetLogger logs slow queries through logger instead of the standard logger
+func (q *QueryMetrics) SetLogger(logger *logging.StructuredLogger) {
+	q.mu.Lock()
+	q.logger = logger
+	q.mu.Unlock()
+}
+
+// Reset drops the recorded statements, for example after a deploy
+func (q *QueryMetrics) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.since = time.Now()
+	q.stats = make(map[queryKey]*queryStats)
+	q.perModule = make(map[string]int)
+	q.normalized = make(map[string]string)
+	q.queries = 0
+	q.slow = 0
+}
+
+// observe records one execution of query
+func (q *QueryMetrics) observe(ctx context.Context, operation, query string, duration time.Duration, err error) {
+	if err == driver.ErrSkip {
+		// database/sql retries through another code path, which is recorded
+		return
+	}
+	module, caller := queryCaller()
+	slow := duration >= q.config.SlowThreshold
+
+	q.mu.Lock()
+	statement := q.normalize(query)
+	key := queryKey{module: module, statement: statement}
+	stats, ok := q.stats[key]
+	if !ok {
+		if q.perModule[module] >= q.config.MaxStatements {
+			key.statement = otherStatement
+			stats, ok = q.stats[key]
+		}
+		if !ok {
+			stats = &queryStats{caller: caller, operation: operation, buckets: make([]int64, len(queryLatencyBuckets)+1)}
+			q.stats[key] = stats
+			q.perModule[module]++
+		}
+	}
+
+	stats.count++
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	stats.buckets[bucketIndex(duration)]++
+	if err != nil {
+		stats.errors++
+	}
+	q.queries++
+	if slow {
+		stats.slow++
+		q.slow++
+	}
+	logger := q.logger
+	q.mu.Unlock()
+
+	if !slow {
+		return
+	}
+	fields := map[string]interface{}{
+		"duration_ms": durationMs(duration),
+		"module":      module,
+		"caller":      caller,
+		"operation":   operation,
+		"statement":   statement,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	if logger != nil {
+		logger.Warn(ctx, "Slow database query", fields)
+		return
+	}
+	log.Printf("Slow database query (%.1fms) from %s: %s", durationMs(duration), caller, statement)
+}
+
+// normalize replaces literals with ? and collapses parameter lists and
+// whitespace. Must be called with q.mu held.
+func (q *QueryMetrics) normalize(query string) string {
+	if statement, ok := q.normalized[query]; ok {
+		return statement
+	}
+	statement := queryLiteralPattern.ReplaceAllStringFunc(query, func(literal string) string {
+		if strings.HasPrefix(literal, "'") {
+			return "'?'"
+		}
+		return "?"
+	})
+	statement = queryListPattern.ReplaceAllString(statement, "$1, ...")
+	statement = strings.Join(strings.Fields(statement), " ")
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength]
+	}
+
+	if len(q.normalized) >= maxNormalizedCache {
+		q.normalized = make(map[string]string)
+	}
+	q.normalized[query] = statement
+	return statement
+}
+
+// Snapshot returns the statements with the most total time first, limited
+// to limit statements (0 returns all) and to module when it is not empty
+func (q *QueryMetrics) Snapshot(module string, limit int) QueryMetricsSnapshot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	snapshot := QueryMetricsSnapshot{
+		Since:         q.since,
+		SlowThreshold: q.config.SlowThreshold.String(),
+		Queries:       q.queries,
+		SlowQueries:   q.slow,
+		Statements:    []QueryStats{},
+	}
+	for key, stats := range q.stats {
+		if module != "" && key.module != module {
+			continue
+		}
+		snapshot.Statements = append(snapshot.Statements, stats.export(key))
+	}
+	sort.Slice(snapshot.Statements, func(i, j int) bool {
+		return snapshot.Statements[i].TotalMs > snapshot.Statements[j].TotalMs
+	})
+	if limit > 0 && len(snapshot.Statements) > limit {
+		snapshot.Statements = snapshot.Statements[:limit]
+	}
+	return snapshot
+}
+
+func (s *queryStats) export(key queryKey) QueryStats {
+	stats := QueryStats{
+		Module:    key.module,
+		Caller:    s.caller,
+		Statement: key.statement,
+		Operation: s.operation,
+		Count:     s.count,
+		Errors:    s.errors,
+		Slow:      s.slow,
+		TotalMs:   durationMs(s.total),
+		MaxMs:     durationMs(s.max),
+		P50Ms:     s.percentile(0.50),
+		P95Ms:     s.percentile(0.95),
+		P99Ms:     s.percentile(0.99),
+		Buckets:   make(map[string]int64, len(s.buckets)),
+	}
+	if s.count > 0 {
+		stats.MeanMs = stats.TotalMs / float64(s.count)
+	}
+	for i, count := range s.buckets {
+		le := "+Inf"
+		if i < len(queryLatencyBuckets) {
+			le = strconv.FormatFloat(durationMs(queryLatencyBuckets[i]), 'f', -1, 64)
+		}
+		stats.Buckets[le] = count
+	}
+	return stats
+}
+
+// percentile estimates a latency percentile as the upper bound of the
+// bucket it falls in, capped at the slowest execution
+func (s *queryStats) percentile(p float64) float64 {
+	rank := int64(p*float64(s.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, count := range s.buckets {
+		seen += count
+		if seen >= rank {
+			if i < len(queryLatencyBuckets) && queryLatencyBuckets[i] < s.max {
+				return durationMs(queryLatencyBuckets[i])
+			}
+			return durationMs(s.max)
+		}
+	}
+	return durationMs(s.max)
+}
+
+func bucketIndex(duration time.Duration) int {
+	for i, bound := range queryLatencyBuckets {
+		if duration <= bound {
+			return i
+		}
+	}
+	return len(queryLatencyBuckets)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// queryCaller returns the module and function that ran the current query:
+// the first frame outside database/sql, the driver and this package. Test
+// functions of this package count as callers.
+func queryCaller() (string, string) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && (strings.HasSuffix(frame.File, "_test.go") || !hasAnyPrefix(frame.Function, queryCallerPackages)) {
+			caller := strings.TrimPrefix(frame.Function, moduleRoot)
+			module := caller
+			if slash := strings.LastIndex(module, "/"); slash >= 0 {
+				// Nested packages such as cmd/bot keep their full path
+				if dot := strings.Index(module[slash:], "."); dot >= 0 {
+					module = module[:slash+dot]
+				}
+			} else if dot := strings.Index(module, "."); dot >= 0 {
+				module = module[:dot]
+			}
+			return module, caller
+		}
+		if !more {
+			return "unknown", "unknown"
+		}
+	}
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListHandler handles GET /admin/system/queries. The module query parameter
+// filters by calling module and limit bounds the statements (default 50).
+func (q *QueryMetrics) ListHandler(c *gin.Context) {
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			common.RespondError(c, http.StatusBadRequest, "limit must be a non-negative number")
+			return
+		}
+		limit = parsed
+	}
+	c.JSON(http.StatusOK, q.Snapshot(c.Query("module"), limit))
+}
+
+// ResetHandler handles DELETE /admin/system/queries
+func (q *QueryMetrics) ResetHandler(c *gin.Context) {
+	q.Reset()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package monitoring
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeDriver accepts every statement; queries return no rows
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("monitoring-fake", fakeDriver{})
+}
+
+func TestQueryMetricsNormalize(t *testing.T) {
+	metrics := NewQueryMetrics(QueryMetricsConfig{})
+	for query, expected := range map[string]string{
+		"SELECT id FROM users\n\t WHERE id IN ($1, $2, $3) AND name = 'o''brien' LIMIT 20": "SELECT id FROM users WHERE id IN ($?, ...) AND name = '?' LIMIT ?",
+		"UPDATE users SET credits = credits - 1 WHERE id = $1":                             "UPDATE users SET credits = credits - ? WHERE id = $?",
+		"SELECT COUNT(*) FROM conversions WHERE REPLACE(status, 'x', 'y') = $1":            "SELECT COUNT(*) FROM conversions WHERE REPLACE(status, '?', '?') = $?",
+	} {
+		if got := metrics.normalize(query); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestQueryMetricsObserve(t *testing.T) {
+	metrics := NewQueryMetrics(QueryMetricsConfig{SlowThreshold: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	for _, duration := range []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, 40 * time.Millisecond, 300 * time.Millisecond} {
+		metrics.observe(ctx, "query", "SELECT * FROM users WHERE id = $1", duration, nil)
+	}
+	metrics.observe(ctx, "exec", "DELETE FROM sessions WHERE id = 'abc'", time.Millisecond, errors.New("boom"))
+	metrics.observe(ctx, "exec", "DELETE FROM sessions WHERE id = 'def'", time.Millisecond, driver.ErrSkip)
+
+	snapshot := metrics.Snapshot("", 0)
+	if snapshot.Queries != 5 || snapshot.SlowQueries != 1 || len(snapshot.Statements) != 2 {
+		t.Fatalf("Expected 5 queries, 1 slow, in 2 statements, got %+v", snapshot)
+	}
+	stats := snapshot.Statements[0]
+	if stats.Statement != "SELECT * FROM users WHERE id = $?" || stats.Count != 4 || stats.Slow != 1 {
+		t.Errorf("Expected the select first with 4 executions, got %+v", stats)
+	}
+	if stats.Module != "monitoring" {
+		t.Errorf("Expected the calling module monitoring, got %q (%s)", stats.Module, stats.Caller)
+	}
+	if stats.P50Ms != 5 || stats.P95Ms != 300 || stats.MaxMs != 300 {
+		t.Errorf("Expected p50 5ms and p95 300ms, got %v and %v", stats.P50Ms, stats.P95Ms)
+	}
+	if stats.Buckets["5"] != 2 || stats.Buckets["50"] != 1 || stats.Buckets["500"] != 1 {
+		t.Errorf("Unexpected buckets %v", stats.Buckets)
+	}
+	if deletes := snapshot.Statements[1]; deletes.Count != 1 || deletes.Errors != 1 {
+		t.Errorf("Expected one failed delete, got %+v", deletes)
+	}
+
+	if filtered := metrics.Snapshot("admin", 0); len(filtered.Statements) != 0 {
+		t.Errorf("Expected no admin statements, got %+v", filtered.Statements)
+	}
+	if limited := metrics.Snapshot("", 1); len(limited.Statements) != 1 {
+		t.Errorf("Expected 1 statement, got %d", len(limited.Statements))
+	}
+
+	metrics.Reset()
+	if snapshot := metrics.Snapshot("", 0); snapshot.Queries != 0 || len(snapshot.Statements) != 0 {
+		t.Errorf("Expected no statements after a reset, got %+v", snapshot)
+	}
+}
+
+func TestQueryMetricsMaxStatements(t *testing.T) {
+	metrics := NewQueryMetrics(QueryMetricsConfig{MaxStatements: 1})
+	ctx := context.Background()
+
+	metrics.observe(ctx, "query", "SELECT id FROM users", time.Millisecond, nil)
+	metrics.observe(ctx, "query", "SELECT id FROM vendors", time.Millisecond, nil)
+	metrics.observe(ctx, "query", "SELECT id FROM albums", time.Millisecond, nil)
+
+	snapshot := metrics.Snapshot("", 0)
+	if len(snapshot.Statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %+v", snapshot.Statements)
+	}
+	for _, stats := range snapshot.Statements {
+		if stats.Statement == otherStatement && stats.Count != 2 {
+			t.Errorf("Expected 2 statements grouped as %s, got %d", otherStatement, stats.Count)
+		}
+	}
+}
+
+func TestOpenInstrumentedDB(t *testing.T) {
+	metrics := NewQueryMetrics(QueryMetricsConfig{})
+	db, err := OpenInstrumentedDB("monitoring-fake", "", metrics)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = $1 WHERE id = $2", "a", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rows.Close()
+
+	snapshot := metrics.Snapshot("monitoring", 0)
+	if snapshot.Queries != 2 || len(snapshot.Statements) != 2 {
+		t.Fatalf("Expected 2 recorded statements, got %+v", snapshot)
+	}
+	for _, stats := range snapshot.Statements {
+		if stats.Caller != "monitoring.TestOpenInstrumentedDB" {
+			t.Errorf("Expected the test as caller, got %q", stats.Caller)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"ai-styler/internal/common"
+	"ai-styler/internal/logging"
 
 	"github.com/getsentry/sentry-go"
 )
@@ -21,6 +22,7 @@ type SentryConfig struct {
 	TracesSampleRate float64
 	AttachStacktrace bool
 	MaxBreadcrumbs   int
+	RedactFields     []string // extends logging.DefaultRedactFields
 }
 
 // SentryMonitor provides Sentry integration for error tracking
@@ -35,6 +37,7 @@ func NewSentryMonitor(config SentryConfig) (*SentryMonitor, error) {
 		return &SentryMonitor{}, nil // Return empty monitor if no DSN
 	}
 
+	redactor := logging.NewRedactor(config.RedactFields)
 	err := sentry.Init(sentry.ClientOptions{
 		Dsn:              config.DSN,
 		Environment:      config.Environment,
@@ -48,6 +51,7 @@ func NewSentryMonitor(config SentryConfig) (*SentryMonitor, error) {
 			// Add custom tags and context
 			event.Tags["service"] = "ai-stayler"
 			event.Tags["component"] = "backend"
+			redactEvent(event, redactor)
 			return event
 		},
 	})
@@ -364,3 +368,34 @@ func (s *SentryMonitor) CaptureCustomEvent(ctx context.Context, eventType string
 
 	s.hub.CaptureMessage(fmt.Sprintf("Custom event: %s", eventType))
 }
+
+// redactEvent removes personal data and secrets from everything an event
+// carries before it leaves the process
+func redactEvent(event *sentry.Event, redactor *logging.Redactor) {
+	event.Message = redactor.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = redactor.String(event.Exception[i].Value)
+	}
+	for name, context := range event.Contexts {
+		event.Contexts[name] = redactor.Fields(context)
+	}
+	event.Extra = redactor.Fields(event.Extra)
+	for key, value := range event.Tags {
+		event.Tags[key] = fmt.Sprint(redactor.Value(key, value))
+	}
+	for _, breadcrumb := range event.Breadcrumbs {
+		breadcrumb.Message = redactor.String(breadcrumb.Message)
+		breadcrumb.Data = redactor.Fields(breadcrumb.Data)
+	}
+	if event.Request != nil {
+		event.Request.URL = redactor.String(event.Request.URL)
+		event.Request.QueryString = redactor.String(event.Request.QueryString)
+		event.Request.Data = redactor.String(event.Request.Data)
+		if event.Request.Cookies != "" {
+			event.Request.Cookies = logging.Redacted
+		}
+		for key, value := range event.Request.Headers {
+			event.Request.Headers[key] = fmt.Sprint(redactor.Value(key, value))
+		}
+	}
+}
@@ -15,10 +15,11 @@ import (
 
 // MonitoringConfig represents the monitoring configuration
 type MonitoringConfig struct {
-	Sentry   SentryConfig
-	Telegram TelegramConfig
-	Logging  logging.LoggerConfig
-	Health   HealthConfig
+	Sentry       SentryConfig
+	Telegram     TelegramConfig
+	Logging      logging.LoggerConfig
+	Health       HealthConfig
+	AuditForward AuditForwardConfig
 }
 
 // HealthConfig represents health monitoring configuration
@@ -36,6 +37,8 @@ type MonitoringService struct {
 	health       *HealthMonitor
 	config       MonitoringConfig
 	errorHandler *common.ErrorHandler
+	auditForward *AuditForwarder
+	queryMetrics *QueryMetrics
 }
 
 // NewMonitoringService creates a new monitoring service
@@ -57,13 +60,14 @@ func NewMonitoringService(config MonitoringConfig, db *sql.DB, redisClient *redi
 
 	// Initialize health monitor
 	health := NewHealthMonitor(config.Logging.Version, config.Logging.Environment)
+	health.SetCheckTimeout(config.Health.Timeout)
 
-	// Add health checkers
+	// Add health checkers; storage and the AI provider are added by their owners
 	if db != nil {
-		health.AddChecker("database", &DatabaseHealthChecker{db: db})
+		health.AddReadinessChecker("database", &DatabaseHealthChecker{db: db})
 	}
 	if redisClient != nil {
-		health.AddChecker("redis", &RedisHealthChecker{client: redisClient})
+		health.AddReadinessChecker("redis", &RedisHealthChecker{client: redisClient})
 	}
 	health.AddChecker("system", &SystemHealthChecker{})
 
@@ -92,6 +96,19 @@ func NewMonitoringService(config MonitoringConfig, db *sql.DB, redisClient *redi
 		go service.startHealthMonitoring()
 	}
 
+	// Start pushing audit logs to the SIEM if configured
+	if config.AuditForward.Enabled && db != nil {
+		forwarder, err := NewAuditForwarder(config.AuditForward, db, logger)
+		if err != nil {
+			logger.Error(context.Background(), "Failed to initialize audit log forwarder", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			forwarder.Start()
+			service.auditForward = forwarder
+		}
+	}
+
 	return service, nil
 }
 
@@ -110,6 +127,18 @@ func (m *MonitoringService) Telegram() *TelegramMonitor {
 	return m.telegram
 }
 
+// SetQueryMetrics exports the database query metrics of metrics and logs
+// its slow queries through the structured logger
+func (m *MonitoringService) SetQueryMetrics(metrics *QueryMetrics) {
+	metrics.SetLogger(m.logger)
+	m.queryMetrics = metrics
+}
+
+// QueryMetrics returns the database query metrics, or nil when disabled
+func (m *MonitoringService) QueryMetrics() *QueryMetrics {
+	return m.queryMetrics
+}
+
 // Health returns the health monitor
 func (m *MonitoringService) Health() *HealthMonitor {
 	return m.health
@@ -337,6 +366,9 @@ func (m *MonitoringService) startHealthMonitoring() {
 
 // Close closes the monitoring service
 func (m *MonitoringService) Close() {
+	if m.auditForward != nil {
+		m.auditForward.Stop()
+	}
 	if m.sentry != nil {
 		m.sentry.Close()
 	}
@@ -0,0 +1,321 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-styler/internal/locale"
+
+	"github.com/lib/pq"
+)
+
+// DigestFrequency is how often a user's low-priority notifications are sent
+// as a single summary
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestHourly DigestFrequency = "hourly"
+	DigestDaily  DigestFrequency = "daily"
+)
+
+// Valid reports whether f is a known digest frequency
+func (f DigestFrequency) Valid() bool {
+	switch f {
+	case DigestOff, DigestHourly, DigestDaily:
+		return true
+	default:
+		return false
+	}
+}
+
+// digestChannels are the channels whose low-priority notifications can be
+// batched; other channels are always sent instantly
+var digestChannels = map[NotificationChannel]bool{
+	ChannelEmail:     true,
+	ChannelWebSocket: true,
+}
+
+// DefaultDigestBatchSize is the number of digests sent per dispatch when no
+// batch size is configured
+const DefaultDigestBatchSize = 100
+
+// DigestItem is a notification waiting to be sent in a user's digest
+type DigestItem struct {
+	ID             string
+	NotificationID string
+	UserID         string
+	Channel        NotificationChannel
+	Type           NotificationType
+	Title          string
+	Message        string
+	Language       string
+	CreatedAt      time.Time
+}
+
+// DigestStore keeps low-priority notifications until they are sent in a digest
+type DigestStore interface {
+	// QueueDigestItem adds a notification to the user's pending digest on channel
+	QueueDigestItem(ctx context.Context, notificationID, userID string, channel NotificationChannel) error
+	// ListDueDigestItems returns the pending items of at most limit user and
+	// channel pairs whose oldest pending item has waited a full period of the
+	// user's digest frequency, ordered by user, channel and creation time
+	ListDueDigestItems(ctx context.Context, now time.Time, limit int) ([]DigestItem, error)
+	// MarkDigestItemsSent links the items to the digest notification that covered them
+	MarkDigestItemsSent(ctx context.Context, itemIDs []string, digestID string) error
+}
+
+// SetDigests enables batching low-priority notifications of users who chose a
+// digest frequency. batchSize caps the digests sent per dispatch.
+func (s *Service) SetDigests(store DigestStore, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = DefaultDigestBatchSize
+	}
+	s.digests = store
+	s.digestBatchSize = batchSize
+}
+
+// queueForDigest holds back a low-priority user notification on a digest
+// channel when the user receives digests. It reports whether the notification
+// was queued; if queuing fails it is sent instantly instead.
+func (s *Service) queueForDigest(ctx context.Context, notification Notification, channel NotificationChannel, prefs NotificationPreference) bool {
+	if s.digests == nil || notification.UserID == nil || notification.Priority != PriorityLow {
+		return false
+	}
+	if !digestChannels[channel] || prefs.DigestFrequency == "" || prefs.DigestFrequency == DigestOff {
+		return false
+	}
+
+	if err := s.digests.QueueDigestItem(ctx, notification.ID, *notification.UserID, channel); err != nil {
+		log.Printf("Failed to queue notification for digest: %v", err)
+		return false
+	}
+	return true
+}
+
+// DispatchDigests sends every due digest and returns how many were sent.
+// Digests of users in their quiet hours stay pending until the next dispatch.
+func (s *Service) DispatchDigests(ctx context.Context) (int, error) {
+	if s.digests == nil {
+		return 0, nil
+	}
+
+	items, err := s.digests.ListDueDigestItems(ctx, time.Now(), s.digestBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due digests: %w", err)
+	}
+
+	sent := 0
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && items[end].UserID == items[start].UserID && items[end].Channel == items[start].Channel {
+			end++
+		}
+		group := items[start:end]
+		start = end
+
+		prefs, err := s.GetNotificationPreferences(ctx, group[0].UserID)
+		if err != nil {
+			prefs = s.getDefaultPreferences()
+		}
+		if s.isInQuietHours(prefs) {
+			continue
+		}
+
+		if err := s.sendDigest(ctx, group); err != nil {
+			log.Printf("Failed to send digest to user %s: %v", group[0].UserID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// sendDigest creates the digest notification of a user's pending items on one
+// channel and delivers it
+func (s *Service) sendDigest(ctx context.Context, items []DigestItem) error {
+	userID := items[0].UserID
+	channel := items[0].Channel
+
+	// Digests are written in the language of the latest notification they cover
+	ctx = locale.WithFormatter(ctx, locale.ForLanguage(items[len(items)-1].Language))
+	lang, title, message := localizedText(ctx, NotificationTypeDigest, locale.FromContext(ctx).Number(int64(len(items))))
+
+	itemIDs := make([]string, len(items))
+	notificationIDs := make([]string, len(items))
+	entries := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+		notificationIDs[i] = item.NotificationID
+		entries[i] = map[string]interface{}{
+			"id":        item.NotificationID,
+			"type":      item.Type,
+			"title":     item.Title,
+			"message":   item.Message,
+			"createdAt": item.CreatedAt,
+		}
+	}
+
+	digest := Notification{
+		ID:      generateID(),
+		UserID:  &userID,
+		Type:    NotificationTypeDigest,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"count":           len(items),
+			"notificationIds": notificationIDs,
+			"items":           entries,
+			"language":        lang,
+		},
+		Channels:  []NotificationChannel{channel},
+		Priority:  PriorityLow,
+		Status:    StatusSending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.store.CreateNotification(ctx, digest); err != nil {
+		return fmt.Errorf("failed to create digest notification: %w", err)
+	}
+
+	// Items are marked before delivery so a failed digest isn't resent on
+	// every dispatch; its delivery record keeps the failure
+	if err := s.digests.MarkDigestItemsSent(ctx, itemIDs, digest.ID); err != nil {
+		return fmt.Errorf("failed to mark digest items: %w", err)
+	}
+
+	s.deliver(ctx, digest, channel)
+	return nil
+}
+
+// StartDigestDispatcher sends due digests every interval until ctx is done
+func (s *Service) StartDigestDispatcher(ctx context.Context, interval time.Duration) {
+	if s.digests == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Notification digest dispatcher started with interval: %v", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification digest dispatcher stopped")
+			return
+		case <-ticker.C:
+			sent, err := s.DispatchDigests(ctx)
+			if err != nil {
+				log.Printf("Error dispatching notification digests: %v", err)
+			}
+			if sent > 0 {
+				log.Printf("Sent %d notification digests", sent)
+			}
+		}
+	}
+}
+
+// dbDigestStore implements DigestStore on top of the notification_digest_items table
+type dbDigestStore struct {
+	db *sql.DB
+}
+
+// NewDigestStore creates a new database-backed digest store
+func NewDigestStore(db *sql.DB) DigestStore {
+	return &dbDigestStore{db: db}
+}
+
+// QueueDigestItem adds a notification to the user's pending digest
+func (s *dbDigestStore) QueueDigestItem(ctx context.Context, notificationID, userID string, channel NotificationChannel) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_digest_items (notification_id, user_id, channel)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (notification_id, channel) DO NOTHING`,
+		notificationID, userID, string(channel),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to queue digest item: %w", err)
+	}
+	return nil
+}
+
+// ListDueDigestItems returns the items of due digests. Items of users who
+// turned digests off since they were queued are due right away.
+func (s *dbDigestStore) ListDueDigestItems(ctx context.Context, now time.Time, limit int) ([]DigestItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT i.user_id, i.channel
+			FROM notification_digest_items i
+			LEFT JOIN notification_preferences p ON p.user_id = i.user_id
+			WHERE i.digest_id IS NULL
+			GROUP BY i.user_id, i.channel, p.digest_frequency
+			HAVING MIN(i.created_at) <= $1::timestamptz - CASE COALESCE(p.digest_frequency, 'off')
+				WHEN 'daily' THEN INTERVAL '1 day'
+				WHEN 'hourly' THEN INTERVAL '1 hour'
+				ELSE INTERVAL '0'
+			END
+			ORDER BY MIN(i.created_at)
+			LIMIT $2
+		)
+		SELECT i.id, i.notification_id, i.user_id, i.channel, n.type, n.title, n.message,
+		       COALESCE(n.data->>'language', ''), i.created_at
+		FROM notification_digest_items i
+		JOIN due d ON d.user_id = i.user_id AND d.channel = i.channel
+		JOIN notifications n ON n.id = i.notification_id
+		WHERE i.digest_id IS NULL
+		ORDER BY i.user_id, i.channel, i.created_at`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due digest items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []DigestItem
+	for rows.Next() {
+		var item DigestItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.NotificationID,
+			&item.UserID,
+			&item.Channel,
+			&item.Type,
+			&item.Title,
+			&item.Message,
+			&item.Language,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan digest item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// MarkDigestItemsSent links the items to their digest notification
+func (s *dbDigestStore) MarkDigestItemsSent(ctx context.Context, itemIDs []string, digestID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notification_digest_items
+		SET digest_id = $2, sent_at = NOW()
+		WHERE id = ANY($1)`,
+		pq.Array(itemIDs), digestID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest items: %w", err)
+	}
+	return nil
+}
+
+// digestFrequencyOrDefault stores preferences created before digests existed as DigestOff
+func digestFrequencyOrDefault(f DigestFrequency) string {
+	if f == "" {
+		return string(DigestOff)
+	}
+	return string(f)
+}
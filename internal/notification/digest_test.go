@@ -0,0 +1,231 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// memoryNotificationStore keeps the notifications, deliveries and preferences
+// the digest tests touch; other methods panic through the nil interface
+type memoryNotificationStore struct {
+	NotificationStore
+	mu            sync.Mutex
+	notifications []Notification
+	deliveries    []NotificationDelivery
+	prefs         map[string]NotificationPreference
+}
+
+func (m *memoryNotificationStore) CreateNotification(ctx context.Context, notification Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifications = append(m.notifications, notification)
+	return nil
+}
+
+func (m *memoryNotificationStore) CreateDelivery(ctx context.Context, delivery NotificationDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *memoryNotificationStore) UpdateDelivery(ctx context.Context, deliveryID string, updates map[string]interface{}) error {
+	return nil
+}
+
+func (m *memoryNotificationStore) GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error) {
+	prefs, ok := m.prefs[userID]
+	if !ok {
+		return NotificationPreference{}, errors.New("not found")
+	}
+	return prefs, nil
+}
+
+func (m *memoryNotificationStore) UpdateNotificationPreferences(ctx context.Context, userID string, prefs NotificationPreference) error {
+	m.prefs[userID] = prefs
+	return nil
+}
+
+type memoryDigestStore struct {
+	queued  []DigestItem
+	due     []DigestItem
+	limit   int
+	marked  map[string][]string
+	failErr error
+}
+
+func (m *memoryDigestStore) QueueDigestItem(ctx context.Context, notificationID, userID string, channel NotificationChannel) error {
+	if m.failErr != nil {
+		return m.failErr
+	}
+	m.queued = append(m.queued, DigestItem{NotificationID: notificationID, UserID: userID, Channel: channel})
+	return nil
+}
+
+func (m *memoryDigestStore) ListDueDigestItems(ctx context.Context, now time.Time, limit int) ([]DigestItem, error) {
+	m.limit = limit
+	return m.due, nil
+}
+
+func (m *memoryDigestStore) MarkDigestItemsSent(ctx context.Context, itemIDs []string, digestID string) error {
+	m.marked[digestID] = itemIDs
+	return nil
+}
+
+type recordingEmailProvider struct {
+	MockEmailProvider
+	mu     sync.Mutex
+	emails []string
+}
+
+func (r *recordingEmailProvider) SendEmail(ctx context.Context, to, subject, body string, isHTML bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails = append(r.emails, subject+"\n"+body)
+	return nil
+}
+
+func newDigestTestService(store *memoryNotificationStore, email EmailProvider) *Service {
+	return NewService(
+		store,
+		email,
+		NewMockSMSProvider(),
+		NewMockTelegramProvider(),
+		NewMockWebSocketProvider(),
+		NewTemplateEngine(),
+		NewMockQuotaService(),
+		NewMockUserService(),
+		NewMockConversionService(),
+		NewMockPaymentService(),
+		NewMockAuditLogger(),
+		NewMockMetricsCollector(),
+		NewMockRetryHandler(),
+		NotificationConfig{
+			Email:     EmailConfig{Enabled: true},
+			WebSocket: WebSocketConfig{Enabled: true},
+		},
+	)
+}
+
+func TestQueueForDigest(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	email := &recordingEmailProvider{}
+	service := newDigestTestService(store, email)
+	digests := &memoryDigestStore{marked: map[string][]string{}}
+	service.SetDigests(digests, 0)
+
+	userID := "user-1"
+	prefs := service.getDefaultPreferences()
+	prefs.DigestFrequency = DigestDaily
+
+	low := Notification{ID: "n1", UserID: &userID, Type: NotificationTypeQuotaReset, Priority: PriorityLow}
+	service.processChannel(context.Background(), low, ChannelEmail, prefs)
+	if len(digests.queued) != 1 || len(email.emails) != 0 {
+		t.Errorf("Expected low priority email to be queued, got %d queued and %d sent", len(digests.queued), len(email.emails))
+	}
+
+	normal := low
+	normal.ID = "n2"
+	normal.Priority = PriorityNormal
+	service.processChannel(context.Background(), normal, ChannelEmail, prefs)
+	if len(digests.queued) != 1 || len(email.emails) != 1 {
+		t.Errorf("Expected normal priority email to be sent instantly, got %d queued and %d sent", len(digests.queued), len(email.emails))
+	}
+
+	prefs.DigestFrequency = DigestOff
+	low.ID = "n3"
+	service.processChannel(context.Background(), low, ChannelEmail, prefs)
+	if len(digests.queued) != 1 || len(email.emails) != 2 {
+		t.Errorf("Expected low priority email to be sent without a digest, got %d queued and %d sent", len(digests.queued), len(email.emails))
+	}
+
+	prefs.DigestFrequency = DigestHourly
+	digests.failErr = errors.New("db down")
+	low.ID = "n4"
+	service.processChannel(context.Background(), low, ChannelEmail, prefs)
+	if len(email.emails) != 3 {
+		t.Errorf("Expected email to be sent when queuing fails, got %d sent", len(email.emails))
+	}
+
+	if service.digestBatchSize != DefaultDigestBatchSize {
+		t.Errorf("Expected default batch size %d, got %d", DefaultDigestBatchSize, service.digestBatchSize)
+	}
+}
+
+func TestDispatchDigests(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	email := &recordingEmailProvider{}
+	service := newDigestTestService(store, email)
+
+	created := time.Now().Add(-2 * time.Hour)
+	digests := &memoryDigestStore{
+		marked: map[string][]string{},
+		due: []DigestItem{
+			{ID: "i1", NotificationID: "n1", UserID: "user-1", Channel: ChannelEmail, Title: "Quota Reset", Message: "Your quota was reset", Language: "en", CreatedAt: created},
+			{ID: "i2", NotificationID: "n2", UserID: "user-1", Channel: ChannelEmail, Title: "Plan <Pro>", Message: "Plan activated", Language: "en", CreatedAt: created},
+			{ID: "i3", NotificationID: "n3", UserID: "user-2", Channel: ChannelEmail, Title: "Quota Warning", Message: "Low quota", CreatedAt: created},
+		},
+	}
+	service.SetDigests(digests, 10)
+
+	sent, err := service.DispatchDigests(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sent != 2 {
+		t.Errorf("Expected 2 digests, got %d", sent)
+	}
+	if digests.limit != 10 {
+		t.Errorf("Expected batch size 10, got %d", digests.limit)
+	}
+
+	if len(store.notifications) != 2 {
+		t.Fatalf("Expected 2 digest notifications, got %d", len(store.notifications))
+	}
+	first := store.notifications[0]
+	if first.Type != NotificationTypeDigest || *first.UserID != "user-1" || first.Data["count"] != 2 {
+		t.Errorf("Expected digest of 2 notifications for user-1, got %+v", first)
+	}
+	if first.Title != "Notification Digest" || first.Message != "You have 2 new notifications." {
+		t.Errorf("Expected English digest text, got %q %q", first.Title, first.Message)
+	}
+	if ids := digests.marked[first.ID]; len(ids) != 2 || ids[0] != "i1" || ids[1] != "i2" {
+		t.Errorf("Expected items i1 and i2 marked, got %v", ids)
+	}
+	if _, ok := digests.marked[store.notifications[1].ID]; !ok {
+		t.Error("Expected user-2 items to be marked")
+	}
+
+	if len(email.emails) != 2 {
+		t.Fatalf("Expected 2 digest emails, got %d", len(email.emails))
+	}
+	body := email.emails[0]
+	if !strings.HasPrefix(body, "Notification Digest\n") || !strings.Contains(body, "Your quota was reset") || !strings.Contains(body, "Plan &lt;Pro&gt;") {
+		t.Errorf("Expected rendered digest listing both notifications, got %q", body)
+	}
+}
+
+func TestUpdateNotificationPreferences_DigestFrequency(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	service := newDigestTestService(store, NewMockEmailProvider())
+
+	daily := DigestDaily
+	if err := service.UpdateNotificationPreferences(context.Background(), "user-1", UpdateNotificationPreferenceRequest{DigestFrequency: &daily}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.prefs["user-1"].DigestFrequency != DigestDaily {
+		t.Errorf("Expected daily digest, got %q", store.prefs["user-1"].DigestFrequency)
+	}
+
+	weekly := DigestFrequency("weekly")
+	err := service.UpdateNotificationPreferences(context.Background(), "user-1", UpdateNotificationPreferenceRequest{DigestFrequency: &weekly})
+	if !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned when a channel's delivery queue stays full for
+// the whole enqueue wait
+var ErrQueueFull = errors.New("notification delivery queue is full")
+
+// errDispatcherStopped fails deliveries still queued at shutdown
+var errDispatcherStopped = errors.New("notification dispatcher stopped")
+
+// Dispatcher defaults used when DispatcherConfig leaves them unset
+const (
+	DefaultDeliveryQueueSize = 1000
+	DefaultDeliveryWorkers   = 4
+	DefaultEnqueueWait       = 2 * time.Second
+)
+
+// DispatcherConfig configures queued channel delivery
+type DispatcherConfig struct {
+	QueueSize   int           // deliveries waiting per channel
+	Workers     int           // concurrent sends per channel
+	EnqueueWait time.Duration // a full queue blocks this long before the delivery fails
+	// Rates are the provider calls per second of each channel; channels
+	// missing or at 0 are unthrottled. A batch is one call.
+	Rates map[NotificationChannel]float64
+	// SMSBatchSize caps the SMS sent per call when the provider supports batches
+	SMSBatchSize int
+}
+
+// ChannelQueueStats describes the delivery queue of one channel
+type ChannelQueueStats struct {
+	Channel     NotificationChannel `json:"channel"`
+	Queued      int                 `json:"queued"`
+	Capacity    int                 `json:"capacity"`
+	InFlight    int64               `json:"inFlight"`
+	Sent        int64               `json:"sent"`
+	Failed      int64               `json:"failed"`
+	Rejected    int64               `json:"rejected"` // failed because the queue stayed full
+	Batches     int64               `json:"batches"`
+	RatePerSec  float64             `json:"ratePerSecond,omitempty"`
+	ThrottledMs int64               `json:"throttledMs"` // total time sends waited for the rate limit
+	LastWaitMs  int64               `json:"lastWaitMs"`  // time the last delivery taken spent queued
+}
+
+// deliveryJob is a queued delivery of a notification on a channel
+type deliveryJob struct {
+	notification Notification
+	channel      NotificationChannel
+	queuedAt     time.Time
+}
+
+// deliveryLane queues, throttles and counts the deliveries of one channel
+type deliveryLane struct {
+	channel  NotificationChannel
+	jobs     chan deliveryJob
+	throttle *throttle
+	rate     float64
+	batch    int // most jobs sent per provider call
+
+	inFlight, sent, failed, rejected, batches atomic.Int64
+	throttledNs, lastWaitNs                   atomic.Int64
+}
+
+// dispatcher holds the delivery lanes of a Service
+type dispatcher struct {
+	lanes       map[NotificationChannel]*deliveryLane
+	workers     int
+	enqueueWait time.Duration
+}
+
+// SetDispatcher routes channel deliveries through bounded per-channel queues
+// drained by StartDispatcher, instead of a goroutine per delivery. Each
+// channel's provider is throttled to its configured rate, and SMS are sent
+// in batches when the provider supports them.
+func (s *Service) SetDispatcher(config DispatcherConfig) {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultDeliveryQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultDeliveryWorkers
+	}
+	if config.EnqueueWait <= 0 {
+		config.EnqueueWait = DefaultEnqueueWait
+	}
+
+	d := &dispatcher{
+		lanes:       make(map[NotificationChannel]*deliveryLane),
+		workers:     config.Workers,
+		enqueueWait: config.EnqueueWait,
+	}
+	for _, channel := range []NotificationChannel{ChannelEmail, ChannelSMS, ChannelTelegram, ChannelWebSocket, ChannelPush} {
+		rate := config.Rates[channel]
+		d.lanes[channel] = &deliveryLane{
+			channel:  channel,
+			jobs:     make(chan deliveryJob, config.QueueSize),
+			throttle: newThrottle(rate),
+			rate:     rate,
+			batch:    1,
+		}
+	}
+	if sender, ok := s.smsProvider.(SMSBatchSender); ok && sender.SMSBatchSize() > 1 && config.SMSBatchSize > 1 {
+		d.lanes[ChannelSMS].batch = min(config.SMSBatchSize, sender.SMSBatchSize())
+	}
+	s.dispatcher = d
+}
+
+// StartDispatcher sends queued deliveries until ctx is done. Sends in
+// flight then finish; deliveries still queued are marked failed.
+func (s *Service) StartDispatcher(ctx context.Context) {
+	if s.dispatcher == nil {
+		return
+	}
+
+	log.Printf("Notification dispatcher started with %d workers per channel", s.dispatcher.workers)
+
+	var wg sync.WaitGroup
+	for _, lane := range s.dispatcher.lanes {
+		for i := 0; i < s.dispatcher.workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.runLane(ctx, lane)
+			}()
+		}
+	}
+	wg.Wait()
+
+	dropped := 0
+	for _, lane := range s.dispatcher.lanes {
+		for {
+			select {
+			case job := <-lane.jobs:
+				s.reject(context.Background(), lane, job, errDispatcherStopped)
+				dropped++
+				continue
+			default:
+			}
+			break
+		}
+	}
+	log.Printf("Notification dispatcher stopped, %d queued deliveries failed", dropped)
+}
+
+// QueueStats describes the delivery queues, or returns nil without a dispatcher
+func (s *Service) QueueStats() []ChannelQueueStats {
+	if s.dispatcher == nil {
+		return nil
+	}
+
+	stats := make([]ChannelQueueStats, 0, len(s.dispatcher.lanes))
+	for _, channel := range []NotificationChannel{ChannelEmail, ChannelSMS, ChannelTelegram, ChannelWebSocket, ChannelPush} {
+		lane := s.dispatcher.lanes[channel]
+		stats = append(stats, ChannelQueueStats{
+			Channel:     channel,
+			Queued:      len(lane.jobs),
+			Capacity:    cap(lane.jobs),
+			InFlight:    lane.inFlight.Load(),
+			Sent:        lane.sent.Load(),
+			Failed:      lane.failed.Load(),
+			Rejected:    lane.rejected.Load(),
+			Batches:     lane.batches.Load(),
+			RatePerSec:  lane.rate,
+			ThrottledMs: time.Duration(lane.throttledNs.Load()).Milliseconds(),
+			LastWaitMs:  time.Duration(lane.lastWaitNs.Load()).Milliseconds(),
+		})
+	}
+	return stats
+}
+
+// enqueue queues a delivery, waiting up to the enqueue wait for room. A
+// delivery that doesn't fit is recorded as failed.
+func (s *Service) enqueue(ctx context.Context, notification Notification, channel NotificationChannel) {
+	lane, ok := s.dispatcher.lanes[channel]
+	if !ok {
+		s.deliver(ctx, notification, channel)
+		return
+	}
+
+	job := deliveryJob{notification: notification, channel: channel, queuedAt: time.Now()}
+	select {
+	case lane.jobs <- job:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(s.dispatcher.enqueueWait)
+	defer timer.Stop()
+	select {
+	case lane.jobs <- job:
+	case <-timer.C:
+		log.Printf("Notification %s queue is full, failing delivery of %s", channel, notification.ID)
+		s.reject(ctx, lane, job, ErrQueueFull)
+	case <-ctx.Done():
+		s.reject(context.WithoutCancel(ctx), lane, job, ctx.Err())
+	}
+}
+
+// reject records a delivery that was never sent as failed
+func (s *Service) reject(ctx context.Context, lane *deliveryLane, job deliveryJob, err error) {
+	lane.rejected.Add(1)
+	delivery := s.newDelivery(job.notification, job.channel)
+	if createErr := s.store.CreateDelivery(ctx, delivery); createErr != nil {
+		log.Printf("Failed to create delivery record: %v", createErr)
+		return
+	}
+	s.handleDeliveryFailure(ctx, delivery, err)
+}
+
+// runLane sends the deliveries of a lane until ctx is done
+func (s *Service) runLane(ctx context.Context, lane *deliveryLane) {
+	for {
+		var job deliveryJob
+		select {
+		case <-ctx.Done():
+			return
+		case job = <-lane.jobs:
+		}
+
+		jobs := lane.take(job)
+		lane.lastWaitNs.Store(int64(time.Since(job.queuedAt)))
+
+		waited, err := lane.throttle.wait(ctx)
+		lane.throttledNs.Add(int64(waited))
+		if err != nil {
+			// Stopped while throttled; the shutdown fails the rest of the queue
+			for _, job := range jobs {
+				s.reject(context.WithoutCancel(ctx), lane, job, errDispatcherStopped)
+			}
+			return
+		}
+
+		// Sends in flight finish even when the dispatcher stops
+		sendCtx := context.WithoutCancel(ctx)
+		lane.inFlight.Add(int64(len(jobs)))
+		sent := s.sendJobs(sendCtx, lane, jobs)
+		lane.inFlight.Add(-int64(len(jobs)))
+		lane.sent.Add(int64(sent))
+		lane.failed.Add(int64(len(jobs) - sent))
+	}
+}
+
+// take returns job and, on lanes that batch, the jobs queued behind it
+func (l *deliveryLane) take(job deliveryJob) []deliveryJob {
+	jobs := []deliveryJob{job}
+	for len(jobs) < l.batch {
+		select {
+		case next := <-l.jobs:
+			jobs = append(jobs, next)
+		default:
+			return jobs
+		}
+	}
+	return jobs
+}
+
+// sendJobs sends jobs in one provider call and returns how many were sent
+func (s *Service) sendJobs(ctx context.Context, lane *deliveryLane, jobs []deliveryJob) int {
+	if len(jobs) > 1 {
+		lane.batches.Add(1)
+		return s.deliverSMSBatch(ctx, s.smsProvider.(SMSBatchSender), jobs)
+	}
+	if err := s.deliver(ctx, jobs[0].notification, jobs[0].channel); err != nil {
+		return 0
+	}
+	return 1
+}
+
+// deliverSMSBatch records the SMS deliveries of jobs and sends them in one
+// provider call, returning how many were sent
+func (s *Service) deliverSMSBatch(ctx context.Context, sender SMSBatchSender, jobs []deliveryJob) int {
+	var deliveries []NotificationDelivery
+	var notifications []Notification
+	var messages []SMSMessage
+	for _, job := range jobs {
+		delivery := s.newDelivery(job.notification, ChannelSMS)
+		if err := s.store.CreateDelivery(ctx, delivery); err != nil {
+			log.Printf("Failed to create delivery record: %v", err)
+			continue
+		}
+		if !s.config.SMS.Enabled {
+			s.handleDeliveryFailure(ctx, delivery, fmt.Errorf("SMS notifications are disabled"))
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+		notifications = append(notifications, job.notification)
+		messages = append(messages, SMSMessage{Phone: delivery.Recipient, Text: s.smsText(job.notification)})
+	}
+	if len(messages) == 0 {
+		return 0
+	}
+
+	receipts, err := sender.SendSMSBatch(ctx, messages)
+	if err != nil {
+		log.Printf("Failed to send batch of %d SMS: %v", len(messages), err)
+		for _, delivery := range deliveries {
+			s.handleDeliveryFailure(ctx, delivery, fmt.Errorf("failed to send SMS: %w", err))
+		}
+		return 0
+	}
+
+	for i, delivery := range deliveries {
+		if i < len(receipts) {
+			s.recordReceipt(ctx, delivery.ID, receipts[i].Provider, receipts[i].MessageID)
+		}
+		if err := s.metrics.RecordNotificationSent(ctx, notifications[i].Type, ChannelSMS); err != nil {
+			log.Printf("Failed to record SMS metrics: %v", err)
+		}
+		s.updateDeliveryStatus(ctx, delivery.ID, StatusSent, nil)
+	}
+	return len(deliveries)
+}
+
+// throttle spaces provider calls to a rate per second; a nil throttle is
+// unlimited
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newThrottle(perSecond float64) *throttle {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &throttle{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next call may start and returns how long it waited
+func (t *throttle) wait(ctx context.Context) (time.Duration, error) {
+	if t == nil {
+		return 0, nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	at := t.next
+	t.next = t.next.Add(t.interval)
+	t.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		return time.Since(now), ctx.Err()
+	}
+}
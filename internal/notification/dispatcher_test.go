@@ -0,0 +1,141 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchSMSProvider records the size of every batch it sends
+type batchSMSProvider struct {
+	MockSMSProvider
+	mu      sync.Mutex
+	batches []int
+}
+
+func (b *batchSMSProvider) SMSBatchSize() int {
+	return 10
+}
+
+func (b *batchSMSProvider) SendSMSBatch(ctx context.Context, messages []SMSMessage) ([]SMSReceipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, len(messages))
+	return make([]SMSReceipt, len(messages)), nil
+}
+
+func (b *batchSMSProvider) sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]int(nil), b.batches...)
+}
+
+func laneStats(service *Service, channel NotificationChannel) ChannelQueueStats {
+	for _, stats := range service.QueueStats() {
+		if stats.Channel == channel {
+			return stats
+		}
+	}
+	return ChannelQueueStats{}
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := newThrottle(20)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := throttle.wait(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// The first call goes right away, the other four 50ms apart
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Expected 5 calls at 20/s to take at least 200ms, took %v", elapsed)
+	}
+
+	if waited, err := newThrottle(0).wait(context.Background()); waited != 0 || err != nil {
+		t.Errorf("Expected a zero rate to be unthrottled, waited %v: %v", waited, err)
+	}
+}
+
+func TestDispatcherBatchesSMS(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	service := newDigestTestService(store, NewMockEmailProvider())
+	provider := &batchSMSProvider{}
+	service.smsProvider = provider
+	service.config.SMS.Enabled = true
+	service.SetDispatcher(DispatcherConfig{Workers: 1, SMSBatchSize: 3})
+
+	// Queue before the workers start so they find full batches
+	for i := 0; i < 5; i++ {
+		service.enqueue(context.Background(), Notification{ID: generateID(), Message: "hi"}, ChannelSMS)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.StartDispatcher(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for laneStats(service, ChannelSMS).Sent < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	stats := laneStats(service, ChannelSMS)
+	if stats.Sent != 5 || stats.Batches != 2 {
+		t.Errorf("Expected 5 SMS sent in 2 batches, got %+v", stats)
+	}
+	if sizes := provider.sizes(); len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 2 {
+		t.Errorf("Expected batches of 3 and 2, got %v", sizes)
+	}
+	if len(store.deliveries) != 5 {
+		t.Errorf("Expected 5 delivery records, got %d", len(store.deliveries))
+	}
+}
+
+func TestEnqueueRejectsWhenFull(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	service := newDigestTestService(store, NewMockEmailProvider())
+	service.SetDispatcher(DispatcherConfig{QueueSize: 1, EnqueueWait: 10 * time.Millisecond})
+
+	service.enqueue(context.Background(), Notification{ID: "n1"}, ChannelEmail)
+	start := time.Now()
+	service.enqueue(context.Background(), Notification{ID: "n2"}, ChannelEmail)
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected a full queue to wait before rejecting, waited %v", elapsed)
+	}
+
+	stats := laneStats(service, ChannelEmail)
+	if stats.Queued != 1 || stats.Capacity != 1 || stats.Rejected != 1 {
+		t.Errorf("Expected one queued and one rejected delivery, got %+v", stats)
+	}
+	if len(store.deliveries) != 1 || store.deliveries[0].NotificationID != "n2" {
+		t.Errorf("Expected a delivery record for the rejected notification, got %+v", store.deliveries)
+	}
+}
+
+func TestStartDispatcherDrainsOnStop(t *testing.T) {
+	store := &memoryNotificationStore{prefs: map[string]NotificationPreference{}}
+	service := newDigestTestService(store, NewMockEmailProvider())
+	service.SetDispatcher(DispatcherConfig{Workers: 1, Rates: map[NotificationChannel]float64{ChannelWebSocket: 1}})
+
+	for i := 0; i < 3; i++ {
+		service.enqueue(context.Background(), Notification{ID: generateID()}, ChannelWebSocket)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.StartDispatcher(ctx)
+
+	stats := laneStats(service, ChannelWebSocket)
+	if stats.Queued != 0 {
+		t.Errorf("Expected the queue to be drained, got %+v", stats)
+	}
+	if stats.Sent+stats.Failed+stats.Rejected != 3 {
+		t.Errorf("Expected every queued delivery to be settled, got %+v", stats)
+	}
+}
@@ -1,77 +1,536 @@
 package notification
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// EmailProviderImpl implements EmailProvider interface
-type EmailProviderImpl struct {
-	config EmailConfig
+const (
+	defaultEmailTimeout   = 10 * time.Second
+	defaultSMTPPoolSize   = 4
+	defaultSendGridAPIURL = "https://api.sendgrid.com"
+)
+
+// NewEmailProvider creates the email provider selected by config.Provider,
+// defaulting to SMTP
+func NewEmailProvider(config EmailConfig, templates TemplateEngine) EmailProvider {
+	if config.Provider == EmailProviderSendGrid {
+		return NewSendGridEmailProvider(config, templates)
+	}
+	return NewSMTPEmailProvider(config, templates)
 }
 
-// NewEmailProvider creates a new email provider
-func NewEmailProvider(config EmailConfig) EmailProvider {
-	return &EmailProviderImpl{
-		config: config,
+// ComposeEmail renders an email for to. HTML bodies are wrapped in the
+// branded layout unless they are already a full document, and get a plain
+// text alternative.
+func ComposeEmail(brand, to, subject, body string, isHTML bool) EmailMessage {
+	msg := EmailMessage{To: to, Subject: subject}
+	if !isHTML {
+		msg.TextBody = body
+		return msg
 	}
+
+	msg.HTMLBody = renderEmailLayout(brand, subject, body)
+	msg.TextBody = htmlToText(body)
+	return msg
 }
 
-// SendEmail sends an email
-func (e *EmailProviderImpl) SendEmail(ctx context.Context, to, subject, body string, isHTML bool) error {
-	if !e.config.Enabled {
-		return fmt.Errorf("email notifications are disabled")
+var emailLayout = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Tahoma,Arial,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:24px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;">
+<tr><td style="padding:24px;border-bottom:1px solid #eaeaec;font-size:20px;font-weight:bold;color:#333333;">{{.Brand}}</td></tr>
+<tr><td style="padding:24px;font-size:15px;line-height:1.6;color:#51545e;">{{.Body}}</td></tr>
+<tr><td style="padding:16px 24px;font-size:12px;color:#a8aaaf;">You received this email because you have an account with {{.Brand}}.</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>`))
+
+// renderEmailLayout wraps an HTML fragment in the branded email layout. The
+// body comes from notification templates and is trusted.
+func renderEmailLayout(brand, subject, body string) string {
+	if strings.Contains(strings.ToLower(body), "<html") {
+		return body
 	}
 
-	// Validate email
-	if !e.ValidateEmail(to) {
-		return fmt.Errorf("invalid email address: %s", to)
+	var buf bytes.Buffer
+	err := emailLayout.Execute(&buf, struct {
+		Brand   string
+		Subject string
+		Body    template.HTML
+	}{brand, subject, template.HTML(body)})
+	if err != nil {
+		return body
 	}
+	return buf.String()
+}
 
-	// Prepare message
-	message := e.prepareMessage(to, subject, body, isHTML)
+var (
+	htmlBreakTags  = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</h[1-6]>|</tr>|</li>`)
+	htmlHiddenTags = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+)
 
-	// Send email
-	auth := smtp.PlainAuth("", e.config.SMTPUsername, e.config.SMTPPassword, e.config.SMTPHost)
-	addr := fmt.Sprintf("%s:%d", e.config.SMTPHost, e.config.SMTPPort)
+// htmlToText derives the plain text alternative of an HTML body
+func htmlToText(body string) string {
+	text := htmlHiddenTags.ReplaceAllString(body, "")
+	text = htmlBreakTags.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(htmlTags.ReplaceAllString(text, ""))
 
-	if err := smtp.SendMail(addr, auth, e.config.FromEmail, []string{to}, []byte(message)); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateEmailAddress reports whether email is a single bare address
+func validateEmailAddress(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email[strings.LastIndex(email, "@"):], ".")
+}
+
+// sendComposedEmail validates and composes an email and hands it to sender
+func sendComposedEmail(ctx context.Context, sender EmailSender, config EmailConfig, to, subject, body string, isHTML bool) error {
+	if !config.Enabled {
+		return fmt.Errorf("email notifications are disabled")
+	}
+	if !validateEmailAddress(to) {
+		return fmt.Errorf("invalid email address: %s", to)
 	}
 
+	if _, err := sender.Send(ctx, ComposeEmail(config.FromName, to, subject, body, isHTML)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
 	return nil
 }
 
+// sendTemplatedEmail renders templateID and sends it as an HTML email
+func sendTemplatedEmail(ctx context.Context, sender EmailSender, templates TemplateEngine, config EmailConfig, to, templateID string, data map[string]interface{}) error {
+	subject, body, err := templates.ProcessEmailTemplate(templateID, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template %s: %w", templateID, err)
+	}
+	return sendComposedEmail(ctx, sender, config, to, subject, body, true)
+}
+
+// SMTPEmailProvider sends emails over SMTP, reusing authenticated connections
+type SMTPEmailProvider struct {
+	config    EmailConfig
+	templates TemplateEngine
+	slots     chan struct{}  // bounds the open connections
+	idle      chan *smtpConn // connections ready for reuse
+}
+
+type smtpConn struct {
+	conn   net.Conn
+	client *smtp.Client
+}
+
+// NewSMTPEmailProvider creates an SMTP email provider
+func NewSMTPEmailProvider(config EmailConfig, templates TemplateEngine) *SMTPEmailProvider {
+	if config.SMTPUsername == "" {
+		config.SMTPUsername = config.Username
+	}
+	if config.SMTPPassword == "" {
+		config.SMTPPassword = config.Password
+	}
+	if config.SMTPPoolSize <= 0 {
+		config.SMTPPoolSize = defaultSMTPPoolSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultEmailTimeout
+	}
+
+	return &SMTPEmailProvider{
+		config:    config,
+		templates: templates,
+		slots:     make(chan struct{}, config.SMTPPoolSize),
+		idle:      make(chan *smtpConn, config.SMTPPoolSize),
+	}
+}
+
+// SendEmail sends an email
+func (e *SMTPEmailProvider) SendEmail(ctx context.Context, to, subject, body string, isHTML bool) error {
+	return sendComposedEmail(ctx, e, e.config, to, subject, body, isHTML)
+}
+
 // SendTemplateEmail sends an email using a template
-func (e *EmailProviderImpl) SendTemplateEmail(ctx context.Context, to, templateID string, data map[string]interface{}) error {
-	// This would integrate with a template engine
-	// For now, we'll use a simple approach
-	subject := "Notification"
-	body := "You have a new notification"
+func (e *SMTPEmailProvider) SendTemplateEmail(ctx context.Context, to, templateID string, data map[string]interface{}) error {
+	return sendTemplatedEmail(ctx, e, e.templates, e.config, to, templateID, data)
+}
+
+// ValidateEmail validates an email address
+func (e *SMTPEmailProvider) ValidateEmail(email string) bool {
+	return validateEmailAddress(email)
+}
+
+// Send delivers a composed email and returns the Message-ID it was sent with
+func (e *SMTPEmailProvider) Send(ctx context.Context, msg EmailMessage) (EmailReceipt, error) {
+	messageID := newEmailMessageID(e.config.FromEmail)
+	raw, err := buildMIMEMessage(e.config.FromName, e.config.FromEmail, messageID, msg, time.Now())
+	if err != nil {
+		return EmailReceipt{}, err
+	}
+
+	c, err := e.acquire(ctx)
+	if err != nil {
+		return EmailReceipt{}, err
+	}
+
+	err = e.deliver(ctx, c, msg.To, raw)
+	e.release(c, err)
+	if err != nil {
+		return EmailReceipt{}, err
+	}
+
+	return EmailReceipt{Provider: EmailProviderSMTP, MessageID: messageID}, nil
+}
+
+func (e *SMTPEmailProvider) deliver(ctx context.Context, c *smtpConn, to string, raw []byte) error {
+	deadline := time.Now().Add(e.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+
+	if err := c.client.Mail(e.config.FromEmail); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	if err := c.client.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP RCPT TO failed: %w", err)
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+	return nil
+}
+
+// acquire returns an idle connection that still answers NOOP, or dials a
+// new one once a slot is free
+func (e *SMTPEmailProvider) acquire(ctx context.Context) (*smtpConn, error) {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for {
+		select {
+		case c := <-e.idle:
+			c.conn.SetDeadline(time.Now().Add(e.config.Timeout))
+			if c.client.Noop() == nil {
+				return c, nil
+			}
+			c.client.Close()
+		default:
+			c, err := e.dial(ctx)
+			if err != nil {
+				<-e.slots
+				return nil, err
+			}
+			return c, nil
+		}
+	}
+}
 
-	return e.SendEmail(ctx, to, subject, body, true)
+// release returns a healthy connection to the pool and closes a broken one
+func (e *SMTPEmailProvider) release(c *smtpConn, sendErr error) {
+	defer func() { <-e.slots }()
+
+	var protoErr *textproto.Error
+	if sendErr != nil && !errors.As(sendErr, &protoErr) {
+		c.client.Close()
+		return
+	}
+	if c.client.Reset() != nil {
+		c.client.Close()
+		return
+	}
+
+	select {
+	case e.idle <- c:
+	default:
+		c.client.Quit()
+	}
+}
+
+func (e *SMTPEmailProvider) dial(ctx context.Context) (*smtpConn, error) {
+	host := e.config.SMTPHost
+	addr := net.JoinHostPort(host, strconv.Itoa(e.config.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: host}
+	dialer := &net.Dialer{Timeout: e.config.Timeout}
+
+	var conn net.Conn
+	var err error
+	if e.config.SMTPPort == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(e.config.Timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if e.config.SMTPPort != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("SMTP STARTTLS failed: %w", err)
+			}
+		}
+	}
+
+	if e.config.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", e.config.SMTPUsername, e.config.SMTPPassword, host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+// Close closes the idle SMTP connections
+func (e *SMTPEmailProvider) Close() error {
+	for {
+		select {
+		case c := <-e.idle:
+			c.client.Quit()
+		default:
+			return nil
+		}
+	}
+}
+
+// SendGridEmailProvider sends emails through the SendGrid v3 mail API
+type SendGridEmailProvider struct {
+	config    EmailConfig
+	templates TemplateEngine
+	client    *http.Client
+}
+
+// NewSendGridEmailProvider creates a SendGrid email provider
+func NewSendGridEmailProvider(config EmailConfig, templates TemplateEngine) *SendGridEmailProvider {
+	if config.SendGridAPIURL == "" {
+		config.SendGridAPIURL = defaultSendGridAPIURL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultEmailTimeout
+	}
+
+	return &SendGridEmailProvider{
+		config:    config,
+		templates: templates,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: config.Timeout,
+			},
+		},
+	}
+}
+
+// SendEmail sends an email
+func (e *SendGridEmailProvider) SendEmail(ctx context.Context, to, subject, body string, isHTML bool) error {
+	return sendComposedEmail(ctx, e, e.config, to, subject, body, isHTML)
+}
+
+// SendTemplateEmail sends an email using a template
+func (e *SendGridEmailProvider) SendTemplateEmail(ctx context.Context, to, templateID string, data map[string]interface{}) error {
+	return sendTemplatedEmail(ctx, e, e.templates, e.config, to, templateID, data)
 }
 
 // ValidateEmail validates an email address
-func (e *EmailProviderImpl) ValidateEmail(email string) bool {
-	return strings.Contains(email, "@") && strings.Contains(email, ".")
+func (e *SendGridEmailProvider) ValidateEmail(email string) bool {
+	return validateEmailAddress(email)
 }
 
-// prepareMessage prepares the email message
-func (e *EmailProviderImpl) prepareMessage(to, subject, body string, isHTML bool) string {
-	contentType := "text/plain"
-	if isHTML {
-		contentType = "text/html"
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Send delivers a composed email and returns the X-Message-Id SendGrid
+// assigned to it
+func (e *SendGridEmailProvider) Send(ctx context.Context, msg EmailMessage) (EmailReceipt, error) {
+	payload := sendGridMail{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: msg.To}}}},
+		From:             sendGridAddress{Email: e.config.FromEmail, Name: e.config.FromName},
+		Subject:          msg.Subject,
+		// SendGrid requires text/plain to come before text/html
+		Content: []sendGridContent{{Type: "text/plain", Value: msg.TextBody}},
+	}
+	if msg.HTMLBody != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/html", Value: msg.HTMLBody})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return EmailReceipt{}, fmt.Errorf("failed to encode SendGrid request: %w", err)
+	}
+
+	url := strings.TrimRight(e.config.SendGridAPIURL, "/") + "/v3/mail/send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return EmailReceipt{}, fmt.Errorf("failed to create SendGrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.config.SendGridAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return EmailReceipt{}, fmt.Errorf("SendGrid request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return EmailReceipt{}, fmt.Errorf("SendGrid returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
 	}
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
 
-	message := fmt.Sprintf("From: %s <%s>\r\n", e.config.FromName, e.config.FromEmail)
-	message += fmt.Sprintf("To: %s\r\n", to)
-	message += fmt.Sprintf("Subject: %s\r\n", subject)
-	message += fmt.Sprintf("Content-Type: %s; charset=UTF-8\r\n", contentType)
-	message += "\r\n"
-	message += body
+	return EmailReceipt{Provider: EmailProviderSendGrid, MessageID: resp.Header.Get("X-Message-Id")}, nil
+}
+
+// newEmailMessageID generates an RFC 5322 Message-ID in the sender's domain
+func newEmailMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("<%s.%s@%s>", strconv.FormatInt(time.Now().UnixNano(), 36), hex.EncodeToString(b), domain)
+}
+
+// buildMIMEMessage encodes msg as a MIME message, using multipart/alternative
+// when it has an HTML body
+func buildMIMEMessage(fromName, fromEmail, messageID string, msg EmailMessage, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	from := mail.Address{Name: fromName, Address: fromEmail}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTMLBody == "" {
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, msg.TextBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
 
-	return message
+	var parts bytes.Buffer
+	mw := multipart.NewWriter(&parts)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create MIME part: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close MIME message: %w", err)
+	}
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	buf.Write(parts.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return nil
 }
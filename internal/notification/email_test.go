@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendGridEmailProvider_Send(t *testing.T) {
+	var payload sendGridMail
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" {
+			t.Errorf("Expected path /v3/mail/send, got %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Expected bearer API key, got %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode SendGrid payload: %v", err)
+		}
+		w.Header().Set("X-Message-Id", "sg-123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := NewSendGridEmailProvider(EmailConfig{
+		Enabled:        true,
+		SendGridAPIKey: "test-key",
+		SendGridAPIURL: server.URL,
+		FromEmail:      "noreply@aistyler.com",
+		FromName:       "AI Styler",
+	}, NewTemplateEngine())
+
+	msg := ComposeEmail("AI Styler", "user@example.com", "Conversion ready", "<p>Your result is ready</p>", true)
+	receipt, err := provider.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Expected email to be sent, got %v", err)
+	}
+	if receipt.Provider != EmailProviderSendGrid || receipt.MessageID != "sg-123" {
+		t.Errorf("Expected sendgrid receipt sg-123, got %+v", receipt)
+	}
+
+	if len(payload.Personalizations) != 1 || payload.Personalizations[0].To[0].Email != "user@example.com" {
+		t.Errorf("Expected one recipient user@example.com, got %+v", payload.Personalizations)
+	}
+	if len(payload.Content) != 2 || payload.Content[0].Type != "text/plain" || payload.Content[1].Type != "text/html" {
+		t.Fatalf("Expected text/plain then text/html content, got %+v", payload.Content)
+	}
+	if payload.Content[0].Value != "Your result is ready" {
+		t.Errorf("Expected plain text alternative, got %q", payload.Content[0].Value)
+	}
+}
+
+func TestSendGridEmailProvider_SendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"message":"invalid api key"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewSendGridEmailProvider(EmailConfig{Enabled: true, SendGridAPIURL: server.URL}, NewTemplateEngine())
+	err := provider.SendEmail(context.Background(), "user@example.com", "Hi", "Hello", false)
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("Expected SendGrid error to be reported, got %v", err)
+	}
+}
+
+func TestComposeEmail(t *testing.T) {
+	msg := ComposeEmail("AI Styler", "user@example.com", "Welcome", "<h2>Hi</h2><p>Thanks &amp; welcome</p>", true)
+	if !strings.Contains(msg.HTMLBody, "<!DOCTYPE html>") || !strings.Contains(msg.HTMLBody, "<h2>Hi</h2>") {
+		t.Errorf("Expected body wrapped in the email layout, got %s", msg.HTMLBody)
+	}
+	if msg.TextBody != "Hi\nThanks & welcome" {
+		t.Errorf("Expected plain text alternative, got %q", msg.TextBody)
+	}
+
+	document := "<html><body><p>Done</p></body></html>"
+	if msg := ComposeEmail("AI Styler", "user@example.com", "Done", document, true); msg.HTMLBody != document {
+		t.Errorf("Expected full documents to be sent as is, got %s", msg.HTMLBody)
+	}
+
+	if msg := ComposeEmail("AI Styler", "user@example.com", "Code", "1234", false); msg.HTMLBody != "" || msg.TextBody != "1234" {
+		t.Errorf("Expected a plain text email, got %+v", msg)
+	}
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	msg := EmailMessage{To: "user@example.com", Subject: "تبدیل آماده است", HTMLBody: "<p>Ready</p>", TextBody: "Ready"}
+	raw, err := buildMIMEMessage("AI Styler", "noreply@aistyler.com", "<id@aistyler.com>", msg, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to build message: %v", err)
+	}
+
+	message := string(raw)
+	for _, expected := range []string{
+		"From: \"AI Styler\" <noreply@aistyler.com>\r\n",
+		"Subject: =?UTF-8?q?",
+		"Message-ID: <id@aistyler.com>\r\n",
+		"Content-Type: multipart/alternative; boundary=",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Type: text/html; charset=UTF-8",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected message to contain %q, got:\n%s", expected, message)
+		}
+	}
+}
+
+func TestValidateEmailAddress(t *testing.T) {
+	tests := map[string]bool{
+		"user@example.com":           true,
+		"user@localhost":             false,
+		"User <user@example.com>":    false,
+		"user@example.com\r\nBcc: x": false,
+		"not-an-email":               false,
+	}
+
+	for email, expected := range tests {
+		if got := validateEmailAddress(email); got != expected {
+			t.Errorf("Expected %v for %q, got %v", expected, email, got)
+		}
+	}
+}
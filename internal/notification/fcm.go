@@ -0,0 +1,291 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultFCMAPIURL   = "https://fcm.googleapis.com"
+	defaultFCMTokenURL = "https://oauth2.googleapis.com/token"
+	defaultFCMTimeout  = 10 * time.Second
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMConfig configures the Firebase Cloud Messaging provider
+type FCMConfig struct {
+	ProjectID       string // defaults to the project of the service account
+	CredentialsFile string // service account key JSON downloaded from the Firebase console
+	APIURL          string
+	Timeout         time.Duration
+}
+
+// fcmServiceAccount is the part of a service account key the provider uses
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends push notifications through the FCM HTTP v1 API. iOS
+// devices are reached through FCM's APNs integration, so one provider serves
+// Android, iOS and web tokens.
+type FCMProvider struct {
+	config   FCMConfig
+	email    string
+	key      *rsa.PrivateKey
+	tokenURL string
+	client   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMProvider creates an FCM provider from the service account key in
+// config.CredentialsFile
+func NewFCMProvider(config FCMConfig) (*FCMProvider, error) {
+	raw, err := os.ReadFile(config.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+	return newFCMProvider(config, raw)
+}
+
+func newFCMProvider(config FCMConfig, credentials []byte) (*FCMProvider, error) {
+	var account fcmServiceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("FCM credentials are not a service account key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM private key: %w", err)
+	}
+
+	if config.ProjectID == "" {
+		config.ProjectID = account.ProjectID
+	}
+	if config.ProjectID == "" {
+		return nil, fmt.Errorf("FCM project ID is required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = defaultFCMAPIURL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultFCMTimeout
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultFCMTokenURL
+	}
+
+	return &FCMProvider{
+		config:   config,
+		email:    account.ClientEmail,
+		key:      key,
+		tokenURL: tokenURL,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: config.Timeout,
+			},
+		},
+	}, nil
+}
+
+// Name identifies the provider in delivery receipts
+func (p *FCMProvider) Name() string {
+	return "fcm"
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type fcmAndroidConfig struct {
+	Priority string `json:"priority"`
+}
+
+type fcmAPNSConfig struct {
+	Headers map[string]string      `json:"headers"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroidConfig  `json:"android"`
+	APNS         fcmAPNSConfig     `json:"apns"`
+}
+
+// fcmErrorResponse is the error body of the FCM v1 API
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// SendPush sends msg to token and returns the message name FCM assigned
+func (p *FCMProvider) SendPush(ctx context.Context, token string, msg PushMessage) (string, error) {
+	accessToken, err := p.getAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	androidPriority, apnsPriority := "NORMAL", "5"
+	if msg.HighPriority {
+		androidPriority, apnsPriority = "HIGH", "10"
+	}
+	payload := map[string]fcmMessage{"message": {
+		Token:        token,
+		Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+		Data:         msg.Data,
+		Android:      fcmAndroidConfig{Priority: androidPriority},
+		APNS: fcmAPNSConfig{
+			Headers: map[string]string{"apns-priority": apnsPriority},
+			Payload: map[string]interface{}{"aps": map[string]interface{}{"sound": "default"}},
+		},
+	}}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode FCM request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", strings.TrimRight(p.config.APIURL, "/"), url.PathEscape(p.config.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create FCM request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("FCM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			// Fetch a new access token for the next message
+			p.mu.Lock()
+			p.accessToken = ""
+			p.mu.Unlock()
+		}
+		var fcmErr fcmErrorResponse
+		json.Unmarshal(detail, &fcmErr)
+		if isInvalidFCMToken(fcmErr) {
+			return "", fmt.Errorf("%w: %s", ErrPushTokenInvalid, fcmErr.Error.Message)
+		}
+		return "", fmt.Errorf("FCM returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(detail, &result); err != nil {
+		return "", fmt.Errorf("failed to decode FCM response: %w", err)
+	}
+	return result.Name, nil
+}
+
+// isInvalidFCMToken reports whether FCM rejected the message because of its
+// token: the app was uninstalled, the token belongs to another project, or
+// it is not a registration token at all
+func isInvalidFCMToken(resp fcmErrorResponse) bool {
+	for _, detail := range resp.Error.Details {
+		switch detail.ErrorCode {
+		case "UNREGISTERED", "SENDER_ID_MISMATCH":
+			return true
+		case "INVALID_ARGUMENT":
+			// Also returned for malformed payloads, which are not the token's fault
+			if strings.Contains(strings.ToLower(resp.Error.Message), "registration token") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getAccessToken returns a cached OAuth2 access token, exchanging a signed
+// service account assertion for a new one shortly before it expires
+func (p *FCMProvider) getAccessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.email,
+		"scope": fcmScope,
+		"aud":   p.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create FCM token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("FCM token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("FCM token request returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(detail, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("failed to decode FCM access token: %v", err)
+	}
+
+	p.accessToken = token.AccessToken
+	// Renew a minute early so in-flight messages do not carry an expired token
+	p.expiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return p.accessToken, nil
+}
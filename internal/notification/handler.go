@@ -1,9 +1,13 @@
 package notification
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,13 +27,13 @@ func NewHandler(service NotificationService) *Handler {
 func (h *Handler) CreateNotification(c *gin.Context) {
 	var req CreateNotificationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	notification, err := h.service.CreateNotification(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -40,13 +44,13 @@ func (h *Handler) CreateNotification(c *gin.Context) {
 func (h *Handler) GetNotification(c *gin.Context) {
 	notificationID := c.Param("id")
 	if notificationID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "notification ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "notification ID is required")
 		return
 	}
 
 	notification, err := h.service.GetNotification(c.Request.Context(), notificationID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		common.RespondError(c, http.StatusNotFound, "notification not found")
 		return
 	}
 
@@ -98,7 +102,7 @@ func (h *Handler) ListNotifications(c *gin.Context) {
 
 	response, err := h.service.ListNotifications(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -109,54 +113,129 @@ func (h *Handler) ListNotifications(c *gin.Context) {
 func (h *Handler) MarkAsRead(c *gin.Context) {
 	notificationID := c.Param("id")
 	if notificationID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "notification ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "notification ID is required")
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	if err := h.service.MarkAsRead(c.Request.Context(), notificationID, userIDStr); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
 }
 
+// ListInbox handles GET /notifications/inbox
+func (h *Handler) ListInbox(c *gin.Context) {
+	userID, ok := inboxUser(c)
+	if !ok {
+		return
+	}
+
+	var req InboxRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	inbox, err := h.service.ListInbox(c.Request.Context(), userID, req)
+	if err != nil {
+		respondInboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inbox)
+}
+
+// GetUnreadCount handles GET /notifications/inbox/unread-count
+func (h *Handler) GetUnreadCount(c *gin.Context) {
+	userID, ok := inboxUser(c)
+	if !ok {
+		return
+	}
+
+	unread, err := h.service.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		respondInboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, UnreadCountResponse{UnreadCount: unread})
+}
+
+// MarkAllRead handles POST /notifications/inbox/read-all
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	userID, ok := inboxUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		respondInboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// inboxUser returns the signed-in user, or responds 401
+func inboxUser(c *gin.Context) (string, bool) {
+	userID, _ := c.Get("userID")
+	userIDStr, _ := userID.(string)
+	if userIDStr == "" {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return "", false
+	}
+	return userIDStr, true
+}
+
+// respondInboxError writes inbox errors, reporting a missing inbox store as
+// unavailable
+func respondInboxError(c *gin.Context, err error) {
+	if errors.Is(err, ErrInboxUnavailable) {
+		common.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	common.RespondErr(c, http.StatusInternalServerError, err)
+}
+
 // DeleteNotification deletes a notification
 func (h *Handler) DeleteNotification(c *gin.Context) {
 	notificationID := c.Param("id")
 	if notificationID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "notification ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "notification ID is required")
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	if err := h.service.DeleteNotification(c.Request.Context(), notificationID, userIDStr); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -168,19 +247,19 @@ func (h *Handler) GetNotificationPreferences(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	preferences, err := h.service.GetNotificationPreferences(c.Request.Context(), userIDStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -192,48 +271,145 @@ func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	var req UpdateNotificationPreferenceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	if err := h.service.UpdateNotificationPreferences(c.Request.Context(), userIDStr, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "preferences updated"})
 }
 
+// RegisterDevice registers the device's push token for the signed-in user
+func (h *Handler) RegisterDevice(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	device, err := h.service.RegisterDevice(c.Request.Context(), userIDStr, req)
+	if err != nil {
+		respondDeviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, device)
+}
+
+// ListDevices lists the push devices of the signed-in user
+func (h *Handler) ListDevices(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	devices, err := h.service.ListDevices(c.Request.Context(), userIDStr)
+	if err != nil {
+		respondDeviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// UnregisterDevice removes a push device of the signed-in user
+func (h *Handler) UnregisterDevice(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.UnregisterDevice(c.Request.Context(), userIDStr, c.Param("id")); err != nil {
+		respondDeviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "device unregistered"})
+}
+
+// respondDeviceError writes push device errors, reporting a missing push
+// provider as unavailable
+func respondDeviceError(c *gin.Context, err error) {
+	if errors.Is(err, ErrPushUnavailable) {
+		common.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	common.RespondErr(c, http.StatusInternalServerError, err)
+}
+
 // GetNotificationStats gets notification statistics
 func (h *Handler) GetNotificationStats(c *gin.Context) {
 	timeRange := c.DefaultQuery("timeRange", "24h")
 
 	stats, err := h.service.GetNotificationStats(c.Request.Context(), timeRange)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetQueueStats reports the delivery queues: depth, throughput, rejected
+// deliveries and time spent throttled
+func (h *Handler) GetQueueStats(c *gin.Context) {
+	stats := h.service.QueueStats()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  stats != nil,
+		"channels": stats,
+	})
+}
+
 // WebSocketHandler handles WebSocket connections
 func (h *Handler) WebSocketHandler(c *gin.Context) {
 	// This would be implemented by the WebSocket provider
 	// The actual WebSocket handling is done in the WebSocket provider
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "WebSocket handler not implemented"})
+	common.RespondError(c, http.StatusNotImplemented, "WebSocket handler not implemented")
 }
 
 // SendTestNotification sends a test notification (for testing purposes)
@@ -241,13 +417,13 @@ func (h *Handler) SendTestNotification(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		common.RespondError(c, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
@@ -265,7 +441,7 @@ func (h *Handler) SendTestNotification(c *gin.Context) {
 
 	notification, err := h.service.CreateNotification(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -274,3 +450,110 @@ func (h *Handler) SendTestNotification(c *gin.Context) {
 		"notification": notification,
 	})
 }
+
+// ListTemplates handles GET /admin/notification-templates
+func (h *Handler) ListTemplates(c *gin.Context) {
+	var req ListTemplatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	templates, err := h.service.ListTemplates(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate handles GET /admin/notification-templates/:id
+func (h *Handler) GetTemplate(c *gin.Context) {
+	template, err := h.service.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// CreateTemplate handles POST /admin/notification-templates
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate handles PUT /admin/notification-templates/:id
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate handles DELETE /admin/notification-templates/:id
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), fmt.Sprint(adminID), c.Param("id")); err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewTemplate handles POST /admin/notification-templates/preview
+func (h *Handler) PreviewTemplate(c *gin.Context) {
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	preview, err := h.service.PreviewTemplate(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,258 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
+)
+
+// Inbox page sizes
+const (
+	DefaultInboxPageSize = 20
+	MaxInboxPageSize     = 100
+)
+
+// WebSocketTypeUnreadCount is the WebSocket message carrying a user's unread
+// count after it changed
+const WebSocketTypeUnreadCount = "notification.unread_count"
+
+// ErrInboxUnavailable is returned when no inbox store is configured
+var ErrInboxUnavailable = errors.New("notification inbox is not available")
+
+// InboxRequest pages through the signed-in user's inbox, newest first
+type InboxRequest struct {
+	Cursor     string `form:"cursor"` // nextCursor of the previous page
+	PageSize   int    `form:"pageSize"`
+	UnreadOnly bool   `form:"unread"`
+}
+
+// InboxItem is a notification of the user's inbox with its read flag
+type InboxItem struct {
+	ID        string                 `json:"id"`
+	Type      NotificationType       `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Priority  NotificationPriority   `json:"priority"`
+	Read      bool                   `json:"read"`
+	ReadAt    *time.Time             `json:"readAt,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// InboxResponse is a page of the inbox with the user's unread count
+type InboxResponse struct {
+	Notifications []InboxItem `json:"notifications"`
+	UnreadCount   int         `json:"unreadCount"`
+	PageSize      int         `json:"pageSize"`
+	NextCursor    string      `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// UnreadCountResponse is the number of unread notifications of a user
+type UnreadCountResponse struct {
+	UnreadCount int `json:"unreadCount"`
+}
+
+// MarkAllReadResponse reports how many notifications were marked read
+type MarkAllReadResponse struct {
+	Marked      int `json:"marked"`
+	UnreadCount int `json:"unreadCount"`
+}
+
+// InboxStore lists a user's notifications and keeps their unread counter.
+// The counter is maintained by the database as notifications are created,
+// read and deleted. Digests repeat notifications already in the inbox, so
+// they are neither listed nor counted.
+type InboxStore interface {
+	ListInbox(ctx context.Context, userID string, req InboxRequest, cursor *common.Cursor) ([]InboxItem, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	// MarkAllRead marks every unread notification of the user read and
+	// returns how many were
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+}
+
+// SetInbox enables the per-user inbox with its unread counter
+func (s *Service) SetInbox(store InboxStore) {
+	s.inbox = store
+}
+
+// ListInbox returns a page of the user's notifications with their read flags
+func (s *Service) ListInbox(ctx context.Context, userID string, req InboxRequest) (InboxResponse, error) {
+	if s.inbox == nil {
+		return InboxResponse{}, ErrInboxUnavailable
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = DefaultInboxPageSize
+	}
+	if req.PageSize > MaxInboxPageSize {
+		req.PageSize = MaxInboxPageSize
+	}
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return InboxResponse{}, err
+	}
+
+	items, err := s.inbox.ListInbox(ctx, userID, req, cursor)
+	if err != nil {
+		return InboxResponse{}, err
+	}
+	items, nextCursor := common.NextPage(items, req.PageSize, func(item InboxItem) (time.Time, string) {
+		return item.CreatedAt, item.ID
+	})
+	unread, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		return InboxResponse{}, err
+	}
+	return InboxResponse{Notifications: items, UnreadCount: unread, PageSize: req.PageSize, NextCursor: nextCursor}, nil
+}
+
+// UnreadCount returns the number of unread notifications of the user, cached
+// until it changes
+func (s *Service) UnreadCount(ctx context.Context, userID string) (int, error) {
+	if s.inbox == nil {
+		return 0, ErrInboxUnavailable
+	}
+	return cache.Load(ctx, s.cache, cache.EntityUnreadNotifications, userID, func(ctx context.Context) (int, error) {
+		return s.inbox.UnreadCount(ctx, userID)
+	})
+}
+
+// MarkAllRead marks every unread notification of the user read
+func (s *Service) MarkAllRead(ctx context.Context, userID string) (MarkAllReadResponse, error) {
+	if s.inbox == nil {
+		return MarkAllReadResponse{}, ErrInboxUnavailable
+	}
+	marked, err := s.inbox.MarkAllRead(ctx, userID)
+	if err != nil {
+		return MarkAllReadResponse{}, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	unread := s.unreadCountChanged(ctx, userID)
+	return MarkAllReadResponse{Marked: marked, UnreadCount: unread}, nil
+}
+
+// unreadCountChanged drops the cached unread count of the user and pushes
+// the new count to the user's WebSocket connections. It returns the new
+// count, 0 when it could not be read.
+func (s *Service) unreadCountChanged(ctx context.Context, userID string) int {
+	if s.inbox == nil {
+		return 0
+	}
+	cache.Invalidate(ctx, s.cache, cache.EntityUnreadNotifications, userID)
+
+	unread, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get unread notification count of user %s: %v", userID, err)
+		return 0
+	}
+	if s.config.WebSocket.Enabled {
+		message := WebSocketMessage{
+			Type:      WebSocketTypeUnreadCount,
+			Data:      map[string]interface{}{"unreadCount": unread},
+			Timestamp: time.Now(),
+		}
+		if err := s.websocketProvider.BroadcastToUser(ctx, userID, message); err != nil {
+			log.Printf("Failed to push unread notification count to user %s: %v", userID, err)
+		}
+	}
+	return unread
+}
+
+// dbInboxStore implements InboxStore on top of notifications and
+// notification_unread_counts
+type dbInboxStore struct {
+	db *sql.DB
+}
+
+// NewInboxStore creates a new database-backed inbox store
+func NewInboxStore(db *sql.DB) InboxStore {
+	return &dbInboxStore{db: db}
+}
+
+// ListInbox returns the user's notifications, newest first, fetching one more
+// than the page size
+func (s *dbInboxStore) ListInbox(ctx context.Context, userID string, req InboxRequest, cursor *common.Cursor) ([]InboxItem, error) {
+	query := `
+		SELECT id, type, title, message, data, priority, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND type <> 'digest'`
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if req.UnreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	if condition, cursorArgs := cursor.Condition("created_at", "id", argIndex); condition != "" {
+		query += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("created_at", "id", cursor, req.PageSize, 0, argIndex)
+	query += clause
+	args = append(args, pageArgs...)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbox: %w", err)
+	}
+	defer rows.Close()
+
+	items := []InboxItem{}
+	for rows.Next() {
+		var item InboxItem
+		var dataJSON []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&item.ID, &item.Type, &item.Title, &item.Message, &dataJSON,
+			&item.Priority, &readAt, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inbox notification: %w", err)
+		}
+		if readAt.Valid {
+			item.Read, item.ReadAt = true, &readAt.Time
+		}
+		if len(dataJSON) > 0 {
+			if err := json.Unmarshal(dataJSON, &item.Data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal notification data: %w", err)
+			}
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list inbox: %w", err)
+	}
+	return items, nil
+}
+
+// UnreadCount reads the user's counter, 0 for users who never had a notification
+func (s *dbInboxStore) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var unread int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT unread_count FROM notification_unread_counts WHERE user_id = $1`, userID).Scan(&unread)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to get unread notification count: %w", err)
+	}
+	return unread, nil
+}
+
+// MarkAllRead marks the user's unread notifications read; the counter
+// trigger brings the count to zero
+func (s *dbInboxStore) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET status = 'read', read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL AND type <> 'digest'`, userID)
+	if err != nil {
+		return 0, err
+	}
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(marked), nil
+}
@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
+)
+
+// memoryInboxStore keeps a user's inbox newest first and counts its unread
+// notifications on every call, like the database trigger keeps the counter
+type memoryInboxStore struct {
+	items  map[string][]InboxItem
+	counts int
+}
+
+func (m *memoryInboxStore) ListInbox(ctx context.Context, userID string, req InboxRequest, cursor *common.Cursor) ([]InboxItem, error) {
+	items := []InboxItem{}
+	for _, item := range m.items[userID] {
+		if req.UnreadOnly && item.Read {
+			continue
+		}
+		if cursor != nil && !item.CreatedAt.Before(cursor.CreatedAt) {
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) > req.PageSize+1 {
+		items = items[:req.PageSize+1]
+	}
+	return items, nil
+}
+
+func (m *memoryInboxStore) UnreadCount(ctx context.Context, userID string) (int, error) {
+	m.counts++
+	unread := 0
+	for _, item := range m.items[userID] {
+		if !item.Read {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (m *memoryInboxStore) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	marked := 0
+	for i := range m.items[userID] {
+		if !m.items[userID][i].Read {
+			m.items[userID][i].Read = true
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (m *memoryInboxStore) markRead(userID, notificationID string) {
+	for i := range m.items[userID] {
+		if m.items[userID][i].ID == notificationID {
+			m.items[userID][i].Read = true
+		}
+	}
+}
+
+// inboxNotificationStore marks notifications read in the inbox store
+type inboxNotificationStore struct {
+	*memoryNotificationStore
+	inbox *memoryInboxStore
+}
+
+func (s inboxNotificationStore) MarkAsRead(ctx context.Context, notificationID, userID string) error {
+	s.inbox.markRead(userID, notificationID)
+	return nil
+}
+
+func (s inboxNotificationStore) UpdateNotification(ctx context.Context, notificationID string, updates map[string]interface{}) error {
+	return nil
+}
+
+// recordingWebSocketProvider records the messages sent to each user
+type recordingWebSocketProvider struct {
+	MockWebSocketProvider
+	sent map[string][]WebSocketMessage
+}
+
+func (r *recordingWebSocketProvider) BroadcastToUser(ctx context.Context, userID string, message WebSocketMessage) error {
+	r.sent[userID] = append(r.sent[userID], message)
+	return nil
+}
+
+func newInboxTestService() (*Service, *memoryInboxStore, *recordingWebSocketProvider) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	inbox := &memoryInboxStore{items: map[string][]InboxItem{
+		"user-1": {
+			{ID: "00000000-0000-0000-0000-000000000003", Title: "third", CreatedAt: base.Add(2 * time.Minute)},
+			{ID: "00000000-0000-0000-0000-000000000002", Title: "second", Read: true, CreatedAt: base.Add(time.Minute)},
+			{ID: "00000000-0000-0000-0000-000000000001", Title: "first", CreatedAt: base},
+		},
+	}}
+	store := inboxNotificationStore{&memoryNotificationStore{prefs: map[string]NotificationPreference{}}, inbox}
+	service := newDigestTestService(store.memoryNotificationStore, &recordingEmailProvider{})
+	service.store = store
+	websocket := &recordingWebSocketProvider{sent: map[string][]WebSocketMessage{}}
+	service.websocketProvider = websocket
+	service.SetInbox(inbox)
+	return service, inbox, websocket
+}
+
+func TestListInbox(t *testing.T) {
+	service, _, _ := newInboxTestService()
+	ctx := context.Background()
+
+	page, err := service.ListInbox(ctx, "user-1", InboxRequest{PageSize: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Notifications) != 2 || page.Notifications[0].Title != "third" || !page.Notifications[1].Read {
+		t.Errorf("Expected the two newest with read flags, got %+v", page.Notifications)
+	}
+	if page.UnreadCount != 2 || page.NextCursor == "" {
+		t.Errorf("Expected 2 unread and a next page, got %d and %q", page.UnreadCount, page.NextCursor)
+	}
+
+	next, err := service.ListInbox(ctx, "user-1", InboxRequest{PageSize: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(next.Notifications) != 1 || next.Notifications[0].Title != "first" || next.NextCursor != "" {
+		t.Errorf("Expected the last notification, got %+v", next)
+	}
+
+	unread, _ := service.ListInbox(ctx, "user-1", InboxRequest{UnreadOnly: true})
+	if len(unread.Notifications) != 2 || unread.PageSize != DefaultInboxPageSize {
+		t.Errorf("Expected the unread notifications on a default page, got %+v", unread)
+	}
+
+	if _, err := service.ListInbox(ctx, "user-1", InboxRequest{Cursor: "not a cursor"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected validation error for a malformed cursor, got %v", err)
+	}
+}
+
+func TestUnreadCount_CachedUntilChanged(t *testing.T) {
+	service, inbox, websocket := newInboxTestService()
+	service.cache = cache.NewTwoTier(nil, map[string]time.Duration{cache.EntityUnreadNotifications: time.Minute})
+	ctx := cache.WithRequestScope(context.Background())
+
+	for i := 0; i < 2; i++ {
+		if unread, err := service.UnreadCount(ctx, "user-1"); err != nil || unread != 2 {
+			t.Fatalf("UnreadCount() = %d, %v, want 2", unread, err)
+		}
+	}
+	if inbox.counts != 1 {
+		t.Errorf("Expected the count read once, got %d reads", inbox.counts)
+	}
+
+	if err := service.MarkAsRead(ctx, "00000000-0000-0000-0000-000000000003", "user-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if unread, _ := service.UnreadCount(ctx, "user-1"); unread != 1 {
+		t.Errorf("Expected 1 unread after marking one read, got %d", unread)
+	}
+
+	marked, err := service.MarkAllRead(ctx, "user-1")
+	if err != nil || marked.Marked != 1 || marked.UnreadCount != 0 {
+		t.Errorf("MarkAllRead() = %+v, %v, want 1 marked and none unread", marked, err)
+	}
+
+	sent := websocket.sent["user-1"]
+	if len(sent) != 2 || sent[0].Type != WebSocketTypeUnreadCount || sent[0].Data["unreadCount"] != 1 || sent[1].Data["unreadCount"] != 0 {
+		t.Errorf("Expected the counts 1 and 0 pushed, got %+v", sent)
+	}
+}
+
+func TestCreateNotification_PushesUnreadCount(t *testing.T) {
+	service, inbox, websocket := newInboxTestService()
+	userID := "user-1"
+
+	inbox.items[userID] = append([]InboxItem{{ID: "00000000-0000-0000-0000-000000000004"}}, inbox.items[userID]...)
+	if _, err := service.CreateNotification(context.Background(), CreateNotificationRequest{
+		UserID: &userID, Type: NotificationTypeQuotaWarning, Title: "t", Message: "m",
+		Channels: []NotificationChannel{ChannelEmail},
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sent := websocket.sent[userID]; len(sent) != 1 || sent[0].Data["unreadCount"] != 3 {
+		t.Errorf("Expected the new count pushed, got %+v", sent)
+	}
+}
+
+func TestInbox_Unavailable(t *testing.T) {
+	service := newDigestTestService(&memoryNotificationStore{prefs: map[string]NotificationPreference{}}, &recordingEmailProvider{})
+	if _, err := service.ListInbox(context.Background(), "user-1", InboxRequest{}); !errors.Is(err, ErrInboxUnavailable) {
+		t.Errorf("Expected ErrInboxUnavailable, got %v", err)
+	}
+	if _, err := service.MarkAllRead(context.Background(), "user-1"); !errors.Is(err, ErrInboxUnavailable) {
+		t.Errorf("Expected ErrInboxUnavailable, got %v", err)
+	}
+}
@@ -13,6 +13,11 @@ type NotificationService interface {
 	MarkAsRead(ctx context.Context, notificationID, userID string) error
 	DeleteNotification(ctx context.Context, notificationID, userID string) error
 
+	// Inbox of the signed-in user
+	ListInbox(ctx context.Context, userID string, req InboxRequest) (InboxResponse, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	MarkAllRead(ctx context.Context, userID string) (MarkAllReadResponse, error)
+
 	// Specific notification types
 	SendConversionStarted(ctx context.Context, userID, conversionID string) error
 	SendConversionCompleted(ctx context.Context, userID, conversionID, resultImageID string) error
@@ -31,8 +36,22 @@ type NotificationService interface {
 	GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error)
 	UpdateNotificationPreferences(ctx context.Context, userID string, req UpdateNotificationPreferenceRequest) error
 
+	// Push devices
+	RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error)
+	ListDevices(ctx context.Context, userID string) (PushDeviceList, error)
+	UnregisterDevice(ctx context.Context, userID, deviceID string) error
+
 	// Statistics
 	GetNotificationStats(ctx context.Context, timeRange string) (NotificationStats, error)
+	QueueStats() []ChannelQueueStats
+
+	// Templates managed by admins
+	ListTemplates(ctx context.Context, req ListTemplatesRequest) (*ListTemplatesResponse, error)
+	GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error)
+	CreateTemplate(ctx context.Context, adminID string, req CreateTemplateRequest) (*NotificationTemplate, error)
+	UpdateTemplate(ctx context.Context, adminID, id string, req UpdateTemplateRequest) (*NotificationTemplate, error)
+	DeleteTemplate(ctx context.Context, adminID, id string) error
+	PreviewTemplate(ctx context.Context, req PreviewTemplateRequest) (*PreviewTemplateResponse, error)
 
 	// WebSocket operations
 	BroadcastToUser(ctx context.Context, userID string, message WebSocketMessage) error
@@ -54,6 +73,7 @@ type NotificationStore interface {
 	UpdateDelivery(ctx context.Context, deliveryID string, updates map[string]interface{}) error
 	GetFailedDeliveries(ctx context.Context, limit int) ([]NotificationDelivery, error)
 	GetDeliveriesByNotification(ctx context.Context, notificationID string) ([]NotificationDelivery, error)
+	GetDeliveryByProviderMessageID(ctx context.Context, provider, messageID string) (NotificationDelivery, error)
 
 	// Preference operations
 	GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error)
@@ -77,6 +97,12 @@ type EmailProvider interface {
 	ValidateEmail(email string) bool
 }
 
+// EmailSender is implemented by email providers that report the message ID
+// assigned to a sent email, so deliveries can be traced at the provider
+type EmailSender interface {
+	Send(ctx context.Context, msg EmailMessage) (EmailReceipt, error)
+}
+
 // SMSProvider defines the interface for SMS sending
 type SMSProvider interface {
 	SendSMS(ctx context.Context, phone, message string) error
@@ -84,6 +110,22 @@ type SMSProvider interface {
 	ValidatePhone(phone string) bool
 }
 
+// SMSSender is implemented by SMS providers that report the message ID
+// assigned to a sent SMS, so delivery reports can be matched to the delivery
+type SMSSender interface {
+	SendSMSWithReceipt(ctx context.Context, phone, message string) (SMSReceipt, error)
+}
+
+// SMSBatchSender is implemented by SMS providers that send several messages
+// in one call
+type SMSBatchSender interface {
+	// SMSBatchSize is the most messages one call takes; below 2 when the
+	// configured provider doesn't support batches
+	SMSBatchSize() int
+	// SendSMSBatch sends messages and returns their receipts in order
+	SendSMSBatch(ctx context.Context, messages []SMSMessage) ([]SMSReceipt, error)
+}
+
 // TelegramProvider defines the interface for Telegram messaging
 type TelegramProvider interface {
 	SendMessage(ctx context.Context, chatID, message string) error
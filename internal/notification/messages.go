@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+
+	"ai-styler/internal/locale"
+)
+
+// Suffixes of the catalog keys of a notification type
+const (
+	titleKeySuffix   = ".title"
+	messageKeySuffix = ".message"
+)
+
+// messages holds the user-facing notification texts per language. Keys are
+// the notification type followed by titleKeySuffix or messageKeySuffix.
+var messages = locale.NewCatalog(map[string]map[string]string{
+	locale.LangPersian: {
+		"conversion_started.title":         "تبدیل شروع شد",
+		"conversion_started.message":       "تبدیل تصویر شما شروع شد. شناسه تبدیل: %s",
+		"conversion_completed.title":       "تبدیل انجام شد",
+		"conversion_completed.message":     "تبدیل تصویر شما با موفقیت انجام شد! شناسه تصویر نتیجه: %s",
+		"conversion_failed.title":          "تبدیل ناموفق بود",
+		"conversion_failed.message":        "تبدیل تصویر شما با خطا مواجه شد: %s",
+		"quota_exhausted.title":            "سهمیه تمام شد",
+		"quota_exhausted.message":          "سهمیه %s شما تمام شده است. برای ادامه، پلن خود را ارتقا دهید.",
+		"quota_warning.title":              "هشدار سهمیه",
+		"quota_warning.message":            "%s تبدیل %s در این ماه برای شما باقی مانده است.",
+		"quota_reset.title":                "سهمیه تمدید شد",
+		"quota_reset.message":              "سهمیه ماهانه شما تمدید شد و می‌توانید دوباره از تبدیل‌ها استفاده کنید.",
+		"payment_success.title":            "پرداخت موفق",
+		"payment_success.message":          "پرداخت شما برای پلن %s با موفقیت انجام شد.",
+		"payment_failed.title":             "پرداخت ناموفق",
+		"payment_failed.message":           "پرداخت شما انجام نشد: %s",
+		"plan_activated.title":             "پلن فعال شد",
+		"plan_activated.message":           "پلن %s شما با موفقیت فعال شد!",
+		"plan_expired.title":               "پلن منقضی شد",
+		"plan_expired.message":             "پلن %s شما منقضی شده است. برای استفاده از امکانات ویژه، آن را تمدید کنید.",
+		"system_maintenance.title":         "به‌روزرسانی سیستم",
+		"digest.title":                     "خلاصه اعلان‌ها",
+		"digest.message":                   "%s اعلان جدید دارید.",
+		"new_login.title":                  "ورود از دستگاه جدید",
+		"new_login.message":                "حساب شما از %s (%s) وارد شد. اگر این شما نبودید، این نشست را لغو کنید: %s",
+		"conversion_export_ready.title":    "خروجی تبدیل‌ها آماده است",
+		"conversion_export_ready.message":  "فایل ZIP تبدیل‌های شما آماده است. دانلود تا %s: %s",
+		"conversion_export_failed.title":   "خروجی تبدیل‌ها ناموفق بود",
+		"conversion_export_failed.message": "ساخت فایل ZIP تبدیل‌های شما ناموفق بود. لطفاً دوباره تلاش کنید.",
+		"otp_lockout.title":                "ورود موقتاً مسدود شد",
+		"otp_lockout.message":              "به دلیل چند تلاش ناموفق برای وارد کردن کد تأیید از %s، ورود با کد تا %s مسدود شد. اگر این شما نبودید، رمز عبور خود را تغییر دهید.",
+		"support_reply.title":              "پاسخ پشتیبانی",
+		"support_reply.message":            "پشتیبانی به تیکت «%s» شما پاسخ داد.",
+		"spending_limit_reached.title":     "سقف هزینه ماهانه",
+		"spending_limit_reached.message":   "پرداخت شما رد شد، چون از سقف هزینه ماهانه %s ریالی حساب شما بیشتر می‌شد. این ماه %s ریال پرداخت کرده‌اید.",
+		"image_import_completed.title":     "وارد کردن تصاویر انجام شد",
+		"image_import_completed.message":   "%s تصویر وارد شد و %s فایل ناموفق بود.",
+		"image_import_failed.title":        "وارد کردن تصاویر ناموفق بود",
+		"image_import_failed.message":      "وارد کردن تصاویر شما ناموفق بود. لطفاً دوباره تلاش کنید.",
+		"user_data_export_ready.title":     "نسخه اطلاعات حساب آماده است",
+		"user_data_export_ready.message":   "فایل اطلاعات حساب شما آماده است. دانلود تا %s: %s",
+		"user_data_export_failed.title":    "نسخه اطلاعات حساب ناموفق بود",
+		"user_data_export_failed.message":  "ساخت فایل اطلاعات حساب شما ناموفق بود. لطفاً دوباره تلاش کنید.",
+		"verification_approved.title":      "فروشگاه شما تأیید شد",
+		"verification_approved.message":    "مدارک کسب‌وکار شما بررسی و فروشگاه شما تأیید شد.",
+		"verification_rejected.title":      "تأیید فروشگاه رد شد",
+		"verification_rejected.message":    "درخواست تأیید فروشگاه شما رد شد: %s. می‌توانید مدارک جدید بارگذاری کنید.",
+	},
+	locale.LangEnglish: {
+		"conversion_started.title":         "Conversion Started",
+		"conversion_started.message":       "Your image conversion has started. Conversion ID: %s",
+		"conversion_completed.title":       "Conversion Completed",
+		"conversion_completed.message":     "Your image conversion has completed successfully! Result image ID: %s",
+		"conversion_failed.title":          "Conversion Failed",
+		"conversion_failed.message":        "Your image conversion failed: %s",
+		"quota_exhausted.title":            "Quota Exhausted",
+		"quota_exhausted.message":          "Your %s quota has been exhausted. Please upgrade your plan to continue.",
+		"quota_warning.title":              "Quota Warning",
+		"quota_warning.message":            "You have %s %s conversions remaining this month.",
+		"quota_reset.title":                "Quota Reset",
+		"quota_reset.message":              "Your monthly quota has been reset. You can now use your conversions again.",
+		"payment_success.title":            "Payment Successful",
+		"payment_success.message":          "Your payment for %s plan has been processed successfully.",
+		"payment_failed.title":             "Payment Failed",
+		"payment_failed.message":           "Your payment failed: %s",
+		"plan_activated.title":             "Plan Activated",
+		"plan_activated.message":           "Your %s plan has been activated successfully!",
+		"plan_expired.title":               "Plan Expired",
+		"plan_expired.message":             "Your %s plan has expired. Please renew to continue using premium features.",
+		"system_maintenance.title":         "System Maintenance",
+		"digest.title":                     "Notification Digest",
+		"digest.message":                   "You have %s new notifications.",
+		"new_login.title":                  "New Sign-in",
+		"new_login.message":                "Your account was signed in from %s (%s). If this wasn't you, revoke the session: %s",
+		"conversion_export_ready.title":    "Conversion Export Ready",
+		"conversion_export_ready.message":  "The ZIP of your conversions is ready. Download it until %s: %s",
+		"conversion_export_failed.title":   "Conversion Export Failed",
+		"conversion_export_failed.message": "The ZIP of your conversions could not be created. Please try again.",
+		"otp_lockout.title":                "Sign-in Temporarily Locked",
+		"otp_lockout.message":              "After repeated wrong verification codes from %s, code sign-in is locked until %s. If this wasn't you, change your password.",
+		"support_reply.title":              "Support Reply",
+		"support_reply.message":            "Support replied to your ticket \"%s\".",
+		"spending_limit_reached.title":     "Monthly Spending Limit",
+		"spending_limit_reached.message":   "Your payment was declined because it would exceed your monthly spending limit of %s IRR. You have spent %s IRR this month.",
+		"image_import_completed.title":     "Image Import Finished",
+		"image_import_completed.message":   "%s images were imported and %s files failed.",
+		"image_import_failed.title":        "Image Import Failed",
+		"image_import_failed.message":      "Your image import failed. Please try again.",
+		"user_data_export_ready.title":     "Account Data Export Ready",
+		"user_data_export_ready.message":   "A copy of your account data is ready. Download it until %s: %s",
+		"user_data_export_failed.title":    "Account Data Export Failed",
+		"user_data_export_failed.message":  "A copy of your account data could not be created. Please try again.",
+		"verification_approved.title":      "Vendor Verified",
+		"verification_approved.message":    "Your business documents were reviewed and your vendor account is now verified.",
+		"verification_rejected.title":      "Vendor Verification Rejected",
+		"verification_rejected.message":    "Your vendor verification was rejected: %s. You can upload new documents.",
+	},
+})
+
+// localizedText returns the language of ctx, as chosen from Accept-Language
+// for API requests and DefaultLanguage otherwise, with the title and message
+// of a notification type in that language
+func localizedText(ctx context.Context, notificationType NotificationType, args ...interface{}) (lang, title, message string) {
+	lang = locale.FromContext(ctx).Language()
+	title = messages.Text(lang, string(notificationType)+titleKeySuffix)
+	message = messages.Text(lang, string(notificationType)+messageKeySuffix, args...)
+	return lang, title, message
+}
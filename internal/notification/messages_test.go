@@ -0,0 +1,27 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"ai-styler/internal/locale"
+)
+
+func TestLocalizedText(t *testing.T) {
+	ctx := locale.WithFormatter(context.Background(), locale.English)
+	lang, title, message := localizedText(ctx, NotificationTypePlanActivated, "Pro")
+	if lang != locale.LangEnglish || title != "Plan Activated" || message != "Your Pro plan has been activated successfully!" {
+		t.Errorf("Expected English plan activated text, got %s %q %q", lang, title, message)
+	}
+
+	lang, title, _ = localizedText(context.Background(), NotificationTypePlanActivated, "Pro")
+	if lang != locale.DefaultLanguage || title != "پلن فعال شد" {
+		t.Errorf("Expected default language title without a request language, got %s %q", lang, title)
+	}
+
+	for _, key := range messages.Keys(locale.LangPersian) {
+		if !messages.Has(locale.LangEnglish, key) {
+			t.Errorf("Expected English translation for %s", key)
+		}
+	}
+}
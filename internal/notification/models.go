@@ -9,9 +9,19 @@ type NotificationType string
 
 const (
 	// Conversion notifications
-	NotificationTypeConversionStarted   NotificationType = "conversion_started"
-	NotificationTypeConversionCompleted NotificationType = "conversion_completed"
-	NotificationTypeConversionFailed    NotificationType = "conversion_failed"
+	NotificationTypeConversionStarted      NotificationType = "conversion_started"
+	NotificationTypeConversionCompleted    NotificationType = "conversion_completed"
+	NotificationTypeConversionFailed       NotificationType = "conversion_failed"
+	NotificationTypeConversionExportReady  NotificationType = "conversion_export_ready"
+	NotificationTypeConversionExportFailed NotificationType = "conversion_export_failed"
+
+	// Image notifications
+	NotificationTypeImageImportCompleted NotificationType = "image_import_completed"
+	NotificationTypeImageImportFailed    NotificationType = "image_import_failed"
+
+	// Vendor notifications
+	NotificationTypeVerificationApproved NotificationType = "verification_approved"
+	NotificationTypeVerificationRejected NotificationType = "verification_rejected"
 
 	// Quota notifications
 	NotificationTypeQuotaExhausted NotificationType = "quota_exhausted"
@@ -30,9 +40,18 @@ const (
 	NotificationTypeCriticalError     NotificationType = "critical_error"
 
 	// User notifications
-	NotificationTypeWelcome         NotificationType = "welcome"
-	NotificationTypeProfileUpdated  NotificationType = "profile_updated"
-	NotificationTypePasswordChanged NotificationType = "password_changed"
+	NotificationTypeWelcome          NotificationType = "welcome"
+	NotificationTypeProfileUpdated   NotificationType = "profile_updated"
+	NotificationTypePasswordChanged  NotificationType = "password_changed"
+	NotificationTypeNewLogin         NotificationType = "new_login"
+	NotificationTypeOTPLockout       NotificationType = "otp_lockout"
+	NotificationTypeSupportReply     NotificationType = "support_reply"
+	NotificationTypeSpendingLimit    NotificationType = "spending_limit_reached"
+	NotificationTypeDataExportReady  NotificationType = "user_data_export_ready"
+	NotificationTypeDataExportFailed NotificationType = "user_data_export_failed"
+
+	// Summary of batched low-priority notifications
+	NotificationTypeDigest NotificationType = "digest"
 )
 
 // NotificationChannel represents the delivery channel
@@ -87,15 +106,20 @@ const (
 	StatusExpired   NotificationStatus = "expired"
 )
 
-// NotificationTemplate represents a notification template
+// NotificationTemplate is the text admins set for a notification type on one
+// channel in one language. Subject and Body are Go templates rendered with
+// the notification as .notification (e.g. {{.notification.Title}} or
+// {{.notification.Data.conversionId}}); email templates also get .user.
 type NotificationTemplate struct {
 	ID        string              `json:"id"`
 	Type      NotificationType    `json:"type"`
 	Channel   NotificationChannel `json:"channel"`
-	Subject   string              `json:"subject"`
+	Locale    string              `json:"locale"`
+	Subject   string              `json:"subject"` // email only
 	Body      string              `json:"body"`
 	Variables []string            `json:"variables"` // List of template variables
 	IsActive  bool                `json:"isActive"`
+	UpdatedBy *string             `json:"updatedBy,omitempty"`
 	CreatedAt time.Time           `json:"createdAt"`
 	UpdatedAt time.Time           `json:"updatedAt"`
 }
@@ -112,25 +136,28 @@ type NotificationPreference struct {
 	QuietHoursStart  *string                   `json:"quietHoursStart,omitempty"` // Format: "HH:MM"
 	QuietHoursEnd    *string                   `json:"quietHoursEnd,omitempty"`   // Format: "HH:MM"
 	Timezone         string                    `json:"timezone"`
+	DigestFrequency  DigestFrequency           `json:"digestFrequency"` // low-priority email/WebSocket notifications are batched at this frequency
 	CreatedAt        time.Time                 `json:"createdAt"`
 	UpdatedAt        time.Time                 `json:"updatedAt"`
 }
 
 // NotificationDelivery represents a specific delivery attempt
 type NotificationDelivery struct {
-	ID             string              `json:"id"`
-	NotificationID string              `json:"notificationId"`
-	Channel        NotificationChannel `json:"channel"`
-	Recipient      string              `json:"recipient"` // email, phone, telegram_id, etc.
-	Status         NotificationStatus  `json:"status"`
-	ErrorMessage   *string             `json:"errorMessage,omitempty"`
-	SentAt         *time.Time          `json:"sentAt,omitempty"`
-	DeliveredAt    *time.Time          `json:"deliveredAt,omitempty"`
-	ReadAt         *time.Time          `json:"readAt,omitempty"`
-	RetryCount     int                 `json:"retryCount"`
-	NextRetryAt    *time.Time          `json:"nextRetryAt,omitempty"`
-	CreatedAt      time.Time           `json:"createdAt"`
-	UpdatedAt      time.Time           `json:"updatedAt"`
+	ID                string              `json:"id"`
+	NotificationID    string              `json:"notificationId"`
+	Channel           NotificationChannel `json:"channel"`
+	Recipient         string              `json:"recipient"` // email, phone, telegram_id, etc.
+	Status            NotificationStatus  `json:"status"`
+	Provider          *string             `json:"provider,omitempty"`          // e.g. smtp, sendgrid
+	ProviderMessageID *string             `json:"providerMessageId,omitempty"` // message ID assigned by the provider
+	ErrorMessage      *string             `json:"errorMessage,omitempty"`
+	SentAt            *time.Time          `json:"sentAt,omitempty"`
+	DeliveredAt       *time.Time          `json:"deliveredAt,omitempty"`
+	ReadAt            *time.Time          `json:"readAt,omitempty"`
+	RetryCount        int                 `json:"retryCount"`
+	NextRetryAt       *time.Time          `json:"nextRetryAt,omitempty"`
+	CreatedAt         time.Time           `json:"createdAt"`
+	UpdatedAt         time.Time           `json:"updatedAt"`
 }
 
 // WebSocketMessage represents a real-time message
@@ -149,17 +176,54 @@ type TelegramConfig struct {
 	RetryDelayMs int    `json:"retryDelayMs"`
 }
 
+// Email providers
+const (
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSendGrid = "sendgrid"
+)
+
 // EmailConfig represents email configuration
 type EmailConfig struct {
-	SMTPHost     string `json:"smtpHost"`
-	SMTPPort     int    `json:"smtpPort"`
-	SMTPUsername string `json:"smtpUsername"`
-	SMTPPassword string `json:"smtpPassword"`
-	FromEmail    string `json:"fromEmail"`
-	FromName     string `json:"fromName"`
-	Enabled      bool   `json:"enabled"`
-	Username     string `json:"username"` // Alias for SMTPUsername for backward compatibility
-	Password     string `json:"password"` // Alias for SMTPPassword for backward compatibility
+	Provider       string        `json:"provider"` // smtp or sendgrid
+	SMTPHost       string        `json:"smtpHost"`
+	SMTPPort       int           `json:"smtpPort"`
+	SMTPUsername   string        `json:"smtpUsername"`
+	SMTPPassword   string        `json:"smtpPassword"`
+	SMTPPoolSize   int           `json:"smtpPoolSize"` // idle connections kept open for reuse
+	SendGridAPIKey string        `json:"-"`
+	SendGridAPIURL string        `json:"sendGridApiUrl"`
+	FromEmail      string        `json:"fromEmail"`
+	FromName       string        `json:"fromName"`
+	Enabled        bool          `json:"enabled"`
+	Timeout        time.Duration `json:"timeout"`
+	Username       string        `json:"username"` // Alias for SMTPUsername for backward compatibility
+	Password       string        `json:"password"` // Alias for SMTPPassword for backward compatibility
+}
+
+// EmailMessage is a rendered email ready to be handed to a provider
+type EmailMessage struct {
+	To       string
+	Subject  string
+	HTMLBody string // empty for plain text emails
+	TextBody string
+}
+
+// EmailReceipt identifies an accepted email at the provider
+type EmailReceipt struct {
+	Provider  string
+	MessageID string
+}
+
+// SMSReceipt identifies an accepted SMS at the provider
+type SMSReceipt struct {
+	Provider  string
+	MessageID string
+}
+
+// SMSMessage is one message of a batch send
+type SMSMessage struct {
+	Phone string
+	Text  string
 }
 
 // NotificationConfig represents the overall notification configuration
@@ -175,6 +239,7 @@ type NotificationConfig struct {
 type SMSConfig struct {
 	Provider   string `json:"provider"`
 	APIKey     string `json:"apiKey"`
+	Sender     string `json:"sender"` // sending line; batch sends need it
 	TemplateID int    `json:"templateId"`
 	Enabled    bool   `json:"enabled"`
 	RetryCount int    `json:"retryCount"`
@@ -241,6 +306,7 @@ type UpdateNotificationPreferenceRequest struct {
 	QuietHoursStart  *string                   `json:"quietHoursStart,omitempty"`
 	QuietHoursEnd    *string                   `json:"quietHoursEnd,omitempty"`
 	Timezone         *string                   `json:"timezone,omitempty"`
+	DigestFrequency  *DigestFrequency          `json:"digestFrequency,omitempty"`
 }
 
 // NotificationStats represents notification statistics
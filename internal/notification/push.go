@@ -0,0 +1,388 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Push device platforms
+const (
+	PushPlatformAndroid = "android"
+	PushPlatformIOS     = "ios"
+	PushPlatformWeb     = "web"
+)
+
+const (
+	// MaxPushDevicesPerUser caps the active devices of a user; registering
+	// another one disables the least recently seen
+	MaxPushDevicesPerUser = 10
+	// MaxPushTokenLength is the longest registration token accepted
+	MaxPushTokenLength = 4096
+	// DefaultPushDeviceStaleAfter is how long a device may go without
+	// registering its token again before it is pruned. FCM treats tokens
+	// inactive for 270 days as expired.
+	DefaultPushDeviceStaleAfter = 270 * 24 * time.Hour
+)
+
+var (
+	// ErrPushUnavailable is returned when no push provider is configured
+	ErrPushUnavailable = errors.New("push notifications are not available")
+	// ErrPushDeviceNotFound is returned for devices the user did not register
+	ErrPushDeviceNotFound = fmt.Errorf("push device %w", common.ErrNotFound)
+	// ErrPushTokenInvalid is returned by providers for tokens that will never
+	// be delivered to again, such as tokens of uninstalled apps
+	ErrPushTokenInvalid = errors.New("push token is no longer valid")
+	// errNoPushDevices fails push deliveries of users without active devices
+	errNoPushDevices = errors.New("user has no registered push devices")
+)
+
+// RegisterDeviceRequest registers a device's push token for the signed-in user
+type RegisterDeviceRequest struct {
+	Token      string `json:"token" binding:"required"`
+	Platform   string `json:"platform" binding:"required"`
+	AppVersion string `json:"appVersion,omitempty"`
+}
+
+// PushDevice is a device registered to receive push notifications
+type PushDevice struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Token      string    `json:"-"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"appVersion,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// PushDeviceList is the list of a user's active devices
+type PushDeviceList struct {
+	Devices []PushDevice `json:"devices"`
+}
+
+// PushMessage is a notification sent to a single device
+type PushMessage struct {
+	Title        string
+	Body         string
+	Data         map[string]string
+	HighPriority bool
+}
+
+// PushProvider delivers push notifications to device tokens
+type PushProvider interface {
+	// SendPush sends msg to the device holding token and returns the
+	// provider's message ID. It returns ErrPushTokenInvalid when the token
+	// should not be used again.
+	SendPush(ctx context.Context, token string, msg PushMessage) (string, error)
+	// Name identifies the provider in delivery receipts
+	Name() string
+}
+
+// DeviceStore keeps the push devices of users
+type DeviceStore interface {
+	// RegisterDevice adds or refreshes a token for userID, moving it from
+	// another user and re-enabling it when needed
+	RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error)
+	// ListDevices returns the active devices of userID, most recently seen first
+	ListDevices(ctx context.Context, userID string) ([]PushDevice, error)
+	// DeleteDevice removes a device of userID
+	DeleteDevice(ctx context.Context, userID, deviceID string) error
+	// DisableDevice stops deliveries to a device whose token became invalid
+	DisableDevice(ctx context.Context, deviceID, reason string) error
+	// DeleteStaleDevices removes devices last seen before the given time
+	DeleteStaleDevices(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SetPush enables the push channel, delivering through provider to the
+// devices in store
+func (s *Service) SetPush(provider PushProvider, devices DeviceStore) {
+	s.pushProvider = provider
+	s.devices = devices
+}
+
+// RegisterDevice registers a device's push token for userID
+func (s *Service) RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error) {
+	if s.devices == nil {
+		return PushDevice{}, ErrPushUnavailable
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	req.Platform = strings.ToLower(strings.TrimSpace(req.Platform))
+	req.AppVersion = strings.TrimSpace(req.AppVersion)
+	if req.Token == "" || len(req.Token) > MaxPushTokenLength || strings.ContainsAny(req.Token, " \t\r\n") {
+		return PushDevice{}, fmt.Errorf("%w: invalid push token", common.ErrValidation)
+	}
+	switch req.Platform {
+	case PushPlatformAndroid, PushPlatformIOS, PushPlatformWeb:
+	default:
+		return PushDevice{}, fmt.Errorf("%w: platform must be android, ios or web", common.ErrValidation)
+	}
+	if len(req.AppVersion) > 50 {
+		return PushDevice{}, fmt.Errorf("%w: app version must be at most 50 characters", common.ErrValidation)
+	}
+
+	return s.devices.RegisterDevice(ctx, userID, req)
+}
+
+// ListDevices returns the active push devices of userID
+func (s *Service) ListDevices(ctx context.Context, userID string) (PushDeviceList, error) {
+	if s.devices == nil {
+		return PushDeviceList{}, ErrPushUnavailable
+	}
+	devices, err := s.devices.ListDevices(ctx, userID)
+	if err != nil {
+		return PushDeviceList{}, err
+	}
+	return PushDeviceList{Devices: devices}, nil
+}
+
+// UnregisterDevice removes a push device of userID, e.g. on sign out
+func (s *Service) UnregisterDevice(ctx context.Context, userID, deviceID string) error {
+	if s.devices == nil {
+		return ErrPushUnavailable
+	}
+	return s.devices.DeleteDevice(ctx, userID, deviceID)
+}
+
+// PruneStaleDevices removes devices that did not register their token again
+// within staleAfter
+func (s *Service) PruneStaleDevices(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	if s.devices == nil {
+		return 0, nil
+	}
+	if staleAfter <= 0 {
+		staleAfter = DefaultPushDeviceStaleAfter
+	}
+	return s.devices.DeleteStaleDevices(ctx, time.Now().Add(-staleAfter))
+}
+
+// StartDevicePruner prunes stale push devices every interval until ctx is done
+func (s *Service) StartDevicePruner(ctx context.Context, interval, staleAfter time.Duration) {
+	if s.devices == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := s.PruneStaleDevices(ctx, staleAfter)
+			if err != nil {
+				log.Printf("Error pruning stale push devices: %v", err)
+			}
+			if pruned > 0 {
+				log.Printf("Pruned %d stale push devices", pruned)
+			}
+		}
+	}
+}
+
+// sendPush sends a push notification to every active device of the user.
+// It succeeds when at least one device accepted it; devices whose token is
+// no longer valid are disabled.
+func (s *Service) sendPush(ctx context.Context, notification Notification, delivery NotificationDelivery) error {
+	if s.pushProvider == nil || s.devices == nil {
+		return ErrPushUnavailable
+	}
+	if notification.UserID == nil {
+		return fmt.Errorf("push notifications require a user")
+	}
+
+	devices, err := s.devices.ListDevices(ctx, *notification.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list push devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return errNoPushDevices
+	}
+
+	msg := PushMessage{
+		Title:        notification.Title,
+		Body:         notification.Message,
+		Data:         pushData(notification),
+		HighPriority: notification.Priority == PriorityHigh || notification.Priority == PriorityCritical,
+	}
+
+	var messageID string
+	var lastErr error
+	for _, device := range devices {
+		id, err := s.pushProvider.SendPush(ctx, device.Token, msg)
+		if errors.Is(err, ErrPushTokenInvalid) {
+			if err := s.devices.DisableDevice(ctx, device.ID, "invalid token"); err != nil {
+				log.Printf("Failed to disable push device %s: %v", device.ID, err)
+			}
+			lastErr = err
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if messageID == "" {
+			messageID = id
+		}
+	}
+	if messageID == "" {
+		return fmt.Errorf("failed to send push notification: %w", lastErr)
+	}
+
+	s.recordReceipt(ctx, delivery.ID, s.pushProvider.Name(), messageID)
+
+	// Record metrics
+	if err := s.metrics.RecordNotificationSent(ctx, notification.Type, ChannelPush); err != nil {
+		log.Printf("Failed to record push metrics: %v", err)
+	}
+
+	return nil
+}
+
+// pushData flattens the notification data into the string values push
+// payloads carry
+func pushData(notification Notification) map[string]string {
+	data := map[string]string{
+		"notificationId": notification.ID,
+		"type":           string(notification.Type),
+	}
+	for key, value := range notification.Data {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			data[key] = v
+		default:
+			if encoded, err := json.Marshal(v); err == nil {
+				data[key] = string(encoded)
+			}
+		}
+	}
+	return data
+}
+
+// dbDeviceStore keeps push devices in PostgreSQL
+type dbDeviceStore struct {
+	db *sql.DB
+}
+
+// NewDeviceStore creates a PostgreSQL-backed push device store
+func NewDeviceStore(db *sql.DB) DeviceStore {
+	return &dbDeviceStore{db: db}
+}
+
+// RegisterDevice upserts the token and disables the user's least recently
+// seen devices beyond MaxPushDevicesPerUser
+func (s *dbDeviceStore) RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error) {
+	device := PushDevice{UserID: userID, Token: req.Token}
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		var appVersion sql.NullString
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO push_devices (user_id, token, platform, app_version)
+			VALUES ($1, $2, $3, NULLIF($4, ''))
+			ON CONFLICT (token) DO UPDATE SET
+				user_id = EXCLUDED.user_id,
+				platform = EXCLUDED.platform,
+				app_version = EXCLUDED.app_version,
+				last_seen_at = NOW(),
+				disabled_at = NULL,
+				disabled_reason = NULL
+			RETURNING id, platform, app_version, created_at, last_seen_at`,
+			userID, req.Token, req.Platform, req.AppVersion,
+		).Scan(&device.ID, &device.Platform, &appVersion, &device.CreatedAt, &device.LastSeenAt)
+		if err != nil {
+			return fmt.Errorf("failed to register push device: %w", err)
+		}
+		device.AppVersion = appVersion.String
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE push_devices SET disabled_at = NOW(), disabled_reason = 'device limit'
+			WHERE id IN (
+				SELECT id FROM push_devices
+				WHERE user_id = $1 AND disabled_at IS NULL
+				ORDER BY last_seen_at DESC
+				OFFSET $2
+			)`,
+			userID, MaxPushDevicesPerUser,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to enforce push device limit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return PushDevice{}, err
+	}
+	return device, nil
+}
+
+// ListDevices returns the active devices of userID
+func (s *dbDeviceStore) ListDevices(ctx context.Context, userID string) ([]PushDevice, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, token, platform, app_version, created_at, last_seen_at
+		FROM push_devices
+		WHERE user_id = $1 AND disabled_at IS NULL
+		ORDER BY last_seen_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := []PushDevice{}
+	for rows.Next() {
+		var device PushDevice
+		var appVersion sql.NullString
+		if err := rows.Scan(&device.ID, &device.UserID, &device.Token, &device.Platform, &appVersion, &device.CreatedAt, &device.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan push device: %w", err)
+		}
+		device.AppVersion = appVersion.String
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+// DeleteDevice removes a device of userID
+func (s *dbDeviceStore) DeleteDevice(ctx context.Context, userID, deviceID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM push_devices WHERE id::text = $1 AND user_id = $2`,
+		deviceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete push device: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrPushDeviceNotFound
+	}
+	return nil
+}
+
+// DisableDevice stops deliveries to a device
+func (s *dbDeviceStore) DisableDevice(ctx context.Context, deviceID, reason string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE push_devices SET disabled_at = NOW(), disabled_reason = $2
+		WHERE id = $1 AND disabled_at IS NULL`,
+		deviceID, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to disable push device: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleDevices removes devices last seen before the given time
+func (s *dbDeviceStore) DeleteStaleDevices(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM push_devices WHERE last_seen_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale push devices: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,222 @@
+package notification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type memoryDeviceStore struct {
+	devices    []PushDevice
+	registered []RegisterDeviceRequest
+	disabled   []string
+}
+
+func (m *memoryDeviceStore) RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error) {
+	m.registered = append(m.registered, req)
+	return PushDevice{ID: "d-new", UserID: userID, Token: req.Token, Platform: req.Platform}, nil
+}
+
+func (m *memoryDeviceStore) ListDevices(ctx context.Context, userID string) ([]PushDevice, error) {
+	devices := []PushDevice{}
+	for _, device := range m.devices {
+		if device.UserID == userID {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+func (m *memoryDeviceStore) DeleteDevice(ctx context.Context, userID, deviceID string) error {
+	return ErrPushDeviceNotFound
+}
+
+func (m *memoryDeviceStore) DisableDevice(ctx context.Context, deviceID, reason string) error {
+	m.disabled = append(m.disabled, deviceID)
+	return nil
+}
+
+func (m *memoryDeviceStore) DeleteStaleDevices(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type recordingPushProvider struct {
+	invalid map[string]bool
+	sent    []string
+	last    PushMessage
+}
+
+func (r *recordingPushProvider) SendPush(ctx context.Context, token string, msg PushMessage) (string, error) {
+	if r.invalid[token] {
+		return "", ErrPushTokenInvalid
+	}
+	r.sent = append(r.sent, token)
+	r.last = msg
+	return "msg-" + token, nil
+}
+
+func (r *recordingPushProvider) Name() string {
+	return "test"
+}
+
+func TestSendPush(t *testing.T) {
+	service := newDigestTestService(&memoryNotificationStore{prefs: map[string]NotificationPreference{}}, &recordingEmailProvider{})
+	devices := &memoryDeviceStore{devices: []PushDevice{
+		{ID: "d1", UserID: "user-1", Token: "token-1"},
+		{ID: "d2", UserID: "user-1", Token: "token-2"},
+		{ID: "d3", UserID: "user-2", Token: "token-3"},
+	}}
+	provider := &recordingPushProvider{invalid: map[string]bool{"token-2": true}}
+	service.SetPush(provider, devices)
+
+	userID := "user-1"
+	notification := Notification{
+		ID: "n1", UserID: &userID, Type: NotificationTypeConversionCompleted,
+		Title: "Done", Message: "Your conversion is ready", Priority: PriorityHigh,
+		Data: map[string]interface{}{"conversionId": "c1", "count": 2},
+	}
+	if err := service.sendNotification(context.Background(), notification, ChannelPush, NotificationDelivery{ID: "del-1"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "token-1" {
+		t.Errorf("Expected push sent to token-1 only, got %v", provider.sent)
+	}
+	if len(devices.disabled) != 1 || devices.disabled[0] != "d2" {
+		t.Errorf("Expected the invalid token's device disabled, got %v", devices.disabled)
+	}
+	msg := provider.last
+	if !msg.HighPriority || msg.Data["conversionId"] != "c1" || msg.Data["count"] != "2" || msg.Data["notificationId"] != "n1" {
+		t.Errorf("Expected high priority message with string data, got %+v", msg)
+	}
+
+	// Every device failing fails the delivery
+	provider.invalid["token-1"] = true
+	if err := service.sendNotification(context.Background(), notification, ChannelPush, NotificationDelivery{ID: "del-2"}); !errors.Is(err, ErrPushTokenInvalid) {
+		t.Errorf("Expected ErrPushTokenInvalid, got %v", err)
+	}
+
+	other := "user-3"
+	notification.UserID = &other
+	if err := service.sendNotification(context.Background(), notification, ChannelPush, NotificationDelivery{ID: "del-3"}); !errors.Is(err, errNoPushDevices) {
+		t.Errorf("Expected errNoPushDevices, got %v", err)
+	}
+}
+
+func TestRegisterDevice_Validation(t *testing.T) {
+	service := newDigestTestService(&memoryNotificationStore{prefs: map[string]NotificationPreference{}}, &recordingEmailProvider{})
+	ctx := context.Background()
+
+	if _, err := service.RegisterDevice(ctx, "user-1", RegisterDeviceRequest{Token: "t", Platform: "android"}); !errors.Is(err, ErrPushUnavailable) {
+		t.Errorf("Expected ErrPushUnavailable, got %v", err)
+	}
+
+	devices := &memoryDeviceStore{}
+	service.SetPush(&recordingPushProvider{}, devices)
+	if _, err := service.RegisterDevice(ctx, "user-1", RegisterDeviceRequest{Token: " abc:123 ", Platform: "iOS", AppVersion: "2.1.0"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if req := devices.registered[0]; req.Token != "abc:123" || req.Platform != PushPlatformIOS {
+		t.Errorf("Expected normalized request, got %+v", req)
+	}
+
+	tests := []struct {
+		name string
+		req  RegisterDeviceRequest
+	}{
+		{"blank token", RegisterDeviceRequest{Token: "  ", Platform: "android"}},
+		{"token with spaces", RegisterDeviceRequest{Token: "a b", Platform: "android"}},
+		{"token too long", RegisterDeviceRequest{Token: strings.Repeat("a", MaxPushTokenLength+1), Platform: "android"}},
+		{"unknown platform", RegisterDeviceRequest{Token: "abc", Platform: "symbian"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.RegisterDevice(ctx, "user-1", tt.req); !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func newTestFCMCredentials(t *testing.T, tokenURL string) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "ai-styler-test",
+		"client_email": "push@ai-styler-test.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    tokenURL,
+	})
+	return credentials
+}
+
+func TestFCMProvider_SendPush(t *testing.T) {
+	var tokenRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.FormValue("assertion") == "" {
+			t.Errorf("Expected a JWT bearer grant, got %v", r.Form)
+		}
+		w.Write([]byte(`{"access_token":"access-1","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/projects/ai-styler-test/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			t.Errorf("Expected the access token, got %q", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Message fcmMessage `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Message.Token == "stale" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		if body.Message.Android.Priority != "HIGH" || body.Message.APNS.Headers["apns-priority"] != "10" {
+			t.Errorf("Expected high priority on Android and APNs, got %+v", body.Message)
+		}
+		w.Write([]byte(`{"name":"projects/ai-styler-test/messages/123"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider, err := newFCMProvider(FCMConfig{APIURL: server.URL}, newTestFCMCredentials(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msg := PushMessage{Title: "Done", Body: "Ready", HighPriority: true}
+	for i := 0; i < 2; i++ {
+		id, err := provider.SendPush(context.Background(), "device-token", msg)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if id != "projects/ai-styler-test/messages/123" {
+			t.Errorf("Expected the FCM message name, got %q", id)
+		}
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Errorf("Expected the access token to be cached, got %d token requests", n)
+	}
+
+	if _, err := provider.SendPush(context.Background(), "stale", msg); !errors.Is(err, ErrPushTokenInvalid) {
+		t.Errorf("Expected ErrPushTokenInvalid, got %v", err)
+	}
+}
@@ -17,6 +17,14 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 		notifications.POST("/test", handler.SendTestNotification) // POST /notifications/test
 	}
 
+	// Inbox routes
+	inbox := router.Group("/notifications/inbox")
+	{
+		inbox.GET("", handler.ListInbox)                   // GET /notifications/inbox
+		inbox.GET("/unread-count", handler.GetUnreadCount) // GET /notifications/inbox/unread-count
+		inbox.POST("/read-all", handler.MarkAllRead)       // POST /notifications/inbox/read-all
+	}
+
 	// Notification preferences routes
 	preferences := router.Group("/notifications/preferences")
 	{
@@ -24,10 +32,19 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 		preferences.PUT("", handler.UpdateNotificationPreferences) // PUT /notifications/preferences
 	}
 
+	// Push device routes
+	devices := router.Group("/users/me/devices")
+	{
+		devices.POST("", handler.RegisterDevice)         // POST /users/me/devices
+		devices.GET("", handler.ListDevices)             // GET /users/me/devices
+		devices.DELETE("/:id", handler.UnregisterDevice) // DELETE /users/me/devices/:id
+	}
+
 	// Statistics routes
 	stats := router.Group("/notifications/stats")
 	{
 		stats.GET("", handler.GetNotificationStats) // GET /notifications/stats
+		stats.GET("/queue", handler.GetQueueStats)  // GET /notifications/stats/queue
 	}
 
 	// WebSocket routes
@@ -36,3 +53,16 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 		ws.GET("", handler.WebSocketHandler) // GET /notifications/ws
 	}
 }
+
+// SetupAdminRoutes sets up the notification template routes of admins
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	templates := router.Group("/notification-templates")
+	{
+		templates.GET("", handler.ListTemplates)            // GET /admin/notification-templates
+		templates.POST("", handler.CreateTemplate)          // POST /admin/notification-templates
+		templates.POST("/preview", handler.PreviewTemplate) // POST /admin/notification-templates/preview
+		templates.GET("/:id", handler.GetTemplate)          // GET /admin/notification-templates/:id
+		templates.PUT("/:id", handler.UpdateTemplate)       // PUT /admin/notification-templates/:id
+		templates.DELETE("/:id", handler.DeleteTemplate)    // DELETE /admin/notification-templates/:id
+	}
+}
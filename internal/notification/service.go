@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
+	"ai-styler/internal/locale"
 )
 
 // Service provides notification functionality
@@ -25,6 +29,24 @@ type Service struct {
 	metrics           MetricsCollector
 	retryHandler      RetryHandler
 	config            NotificationConfig
+
+	// Optional batching of low-priority notifications into digests
+	digests         DigestStore
+	digestBatchSize int
+
+	// Optional push channel
+	pushProvider PushProvider
+	devices      DeviceStore
+
+	// Optional templates managed by admins
+	templates TemplateStore
+
+	// Optional queued, throttled delivery; nil sends each channel in its own goroutine
+	dispatcher *dispatcher
+
+	// Optional per-user inbox with unread counts, cached in cache
+	inbox InboxStore
+	cache *cache.TwoTier
 }
 
 // NewService creates a new notification service
@@ -59,6 +81,7 @@ func NewService(
 		metrics:           metrics,
 		retryHandler:      retryHandler,
 		config:            config,
+		cache:             cache.Default(),
 	}
 }
 
@@ -103,6 +126,9 @@ func (s *Service) CreateNotification(ctx context.Context, req CreateNotification
 	if err := s.store.CreateNotification(ctx, notification); err != nil {
 		return Notification{}, fmt.Errorf("failed to create notification: %w", err)
 	}
+	if notification.UserID != nil {
+		s.unreadCountChanged(ctx, *notification.UserID)
+	}
 
 	// Process notification immediately if not scheduled
 	if req.ScheduledFor == nil || req.ScheduledFor.Before(time.Now()) {
@@ -127,6 +153,7 @@ func (s *Service) MarkAsRead(ctx context.Context, notificationID, userID string)
 	if err := s.store.MarkAsRead(ctx, notificationID, userID); err != nil {
 		return fmt.Errorf("failed to mark notification as read: %w", err)
 	}
+	s.unreadCountChanged(ctx, userID)
 
 	// Log audit
 	if err := s.auditLogger.LogNotificationRead(ctx, userID, notificationID); err != nil {
@@ -146,6 +173,7 @@ func (s *Service) DeleteNotification(ctx context.Context, notificationID, userID
 	if err := s.store.DeleteNotification(ctx, notificationID); err != nil {
 		return fmt.Errorf("failed to delete notification: %w", err)
 	}
+	s.unreadCountChanged(ctx, userID)
 
 	// Log audit
 	if err := s.auditLogger.LogNotificationDeleted(ctx, userID, notificationID); err != nil {
@@ -164,14 +192,16 @@ func (s *Service) SendConversionStarted(ctx context.Context, userID, conversionI
 	}
 
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeConversionStarted, conversionID)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeConversionStarted,
-		Title:   "Conversion Started",
-		Message: fmt.Sprintf("Your image conversion has started. Conversion ID: %s", conversionID),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"conversionId": conversionID,
 			"status":       "started",
+			"language":     lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -189,15 +219,17 @@ func (s *Service) SendConversionCompleted(ctx context.Context, userID, conversio
 	}
 
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeConversionCompleted, resultImageID)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeConversionCompleted,
-		Title:   "Conversion Completed",
-		Message: fmt.Sprintf("Your image conversion has completed successfully! Result image ID: %s", resultImageID),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"conversionId":  conversionID,
 			"resultImageId": resultImageID,
 			"status":        "completed",
+			"language":      lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -209,15 +241,17 @@ func (s *Service) SendConversionCompleted(ctx context.Context, userID, conversio
 // SendConversionFailed sends a conversion failed notification
 func (s *Service) SendConversionFailed(ctx context.Context, userID, conversionID, errorMessage string) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeConversionFailed, errorMessage)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeConversionFailed,
-		Title:   "Conversion Failed",
-		Message: fmt.Sprintf("Your image conversion failed: %s", errorMessage),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"conversionId": conversionID,
 			"errorMessage": errorMessage,
 			"status":       "failed",
+			"language":     lang,
 		},
 		Priority: PriorityHigh,
 	}
@@ -235,14 +269,16 @@ func (s *Service) SendQuotaExhausted(ctx context.Context, userID string, quotaTy
 	}
 
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeQuotaExhausted, quotaType)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeQuotaExhausted,
-		Title:   "Quota Exhausted",
-		Message: fmt.Sprintf("Your %s quota has been exhausted. Please upgrade your plan to continue.", quotaType),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"quotaType": quotaType,
 			"user":      user,
+			"language":  lang,
 		},
 		Priority: PriorityHigh,
 	}
@@ -254,14 +290,16 @@ func (s *Service) SendQuotaExhausted(ctx context.Context, userID string, quotaTy
 // SendQuotaWarning sends a quota warning notification
 func (s *Service) SendQuotaWarning(ctx context.Context, userID string, quotaType string, remaining int) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeQuotaWarning, locale.FromContext(ctx).Number(int64(remaining)), quotaType)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeQuotaWarning,
-		Title:   "Quota Warning",
-		Message: fmt.Sprintf("You have %d %s conversions remaining this month.", remaining, quotaType),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"quotaType": quotaType,
 			"remaining": remaining,
+			"language":  lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -273,13 +311,15 @@ func (s *Service) SendQuotaWarning(ctx context.Context, userID string, quotaType
 // SendQuotaReset sends a quota reset notification
 func (s *Service) SendQuotaReset(ctx context.Context, userID string) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypeQuotaReset)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypeQuotaReset,
-		Title:   "Quota Reset",
-		Message: "Your monthly quota has been reset. You can now use your conversions again.",
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"resetDate": time.Now().Format("2006-01-02"),
+			"language":  lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -297,15 +337,17 @@ func (s *Service) SendPaymentSuccess(ctx context.Context, userID, paymentID, pla
 	}
 
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypePaymentSuccess, planName)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypePaymentSuccess,
-		Title:   "Payment Successful",
-		Message: fmt.Sprintf("Your payment for %s plan has been processed successfully.", planName),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"paymentId": paymentID,
 			"planName":  planName,
 			"payment":   payment,
+			"language":  lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -317,14 +359,16 @@ func (s *Service) SendPaymentSuccess(ctx context.Context, userID, paymentID, pla
 // SendPaymentFailed sends a payment failed notification
 func (s *Service) SendPaymentFailed(ctx context.Context, userID, paymentID, reason string) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypePaymentFailed, reason)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypePaymentFailed,
-		Title:   "Payment Failed",
-		Message: fmt.Sprintf("Your payment failed: %s", reason),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"paymentId": paymentID,
 			"reason":    reason,
+			"language":  lang,
 		},
 		Priority: PriorityHigh,
 	}
@@ -336,13 +380,15 @@ func (s *Service) SendPaymentFailed(ctx context.Context, userID, paymentID, reas
 // SendPlanActivated sends a plan activated notification
 func (s *Service) SendPlanActivated(ctx context.Context, userID, planName string) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypePlanActivated, planName)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypePlanActivated,
-		Title:   "Plan Activated",
-		Message: fmt.Sprintf("Your %s plan has been activated successfully!", planName),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"planName": planName,
+			"language": lang,
 		},
 		Priority: PriorityNormal,
 	}
@@ -354,13 +400,126 @@ func (s *Service) SendPlanActivated(ctx context.Context, userID, planName string
 // SendPlanExpired sends a plan expired notification
 func (s *Service) SendPlanExpired(ctx context.Context, userID, planName string) error {
 	// Create notification
+	lang, title, message := localizedText(ctx, NotificationTypePlanExpired, planName)
 	req := CreateNotificationRequest{
 		UserID:  &userID,
 		Type:    NotificationTypePlanExpired,
-		Title:   "Plan Expired",
-		Message: fmt.Sprintf("Your %s plan has expired. Please renew to continue using premium features.", planName),
+		Title:   title,
+		Message: message,
 		Data: map[string]interface{}{
 			"planName": planName,
+			"language": lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendNewLoginAlert warns a user about a sign-in from a new device or
+// country. It is high priority so it is never held for a digest, and the
+// message carries revokeURL to sign the session out in one click.
+func (s *Service) SendNewLoginAlert(ctx context.Context, userID, sessionID, device, ip, country, revokeURL string) error {
+	location := ip
+	if country != "" {
+		location = ip + ", " + country
+	}
+
+	lang, title, message := localizedText(ctx, NotificationTypeNewLogin, device, location, revokeURL)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeNewLogin,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"sessionId": sessionID,
+			"device":    device,
+			"ip":        ip,
+			"country":   country,
+			"revokeUrl": revokeURL,
+			"language":  lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendConversionExportReady tells a user their conversion export can be
+// downloaded from downloadURL until expiresAt
+func (s *Service) SendConversionExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error {
+	lang, title, message := localizedText(ctx, NotificationTypeConversionExportReady, expiresAt.UTC().Format(time.RFC1123), downloadURL)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeConversionExportReady,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"exportId":    exportID,
+			"downloadUrl": downloadURL,
+			"expiresAt":   expiresAt,
+			"language":    lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendConversionExportFailed tells a user their conversion export failed
+func (s *Service) SendConversionExportFailed(ctx context.Context, userID, exportID string) error {
+	lang, title, message := localizedText(ctx, NotificationTypeConversionExportFailed)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeConversionExportFailed,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"exportId": exportID,
+			"language": lang,
+		},
+		Priority: PriorityNormal,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendOTPLockoutAlert tells a user that verification codes for their phone
+// are refused until lockedUntil after repeated wrong codes from ip
+func (s *Service) SendOTPLockoutAlert(ctx context.Context, userID, ip string, lockedUntil time.Time) error {
+	lang, title, message := localizedText(ctx, NotificationTypeOTPLockout, ip, lockedUntil.UTC().Format(time.RFC1123))
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeOTPLockout,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"ip":          ip,
+			"lockedUntil": lockedUntil,
+			"language":    lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendSupportReply tells a user support replied to their ticket
+func (s *Service) SendSupportReply(ctx context.Context, userID, ticketID, subject string) error {
+	lang, title, message := localizedText(ctx, NotificationTypeSupportReply, subject)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeSupportReply,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"ticketId": ticketID,
+			"language": lang,
 		},
 		Priority: PriorityHigh,
 	}
@@ -369,6 +528,138 @@ func (s *Service) SendPlanExpired(ctx context.Context, userID, planName string)
 	return err
 }
 
+// SendVendorVerificationDecision tells a vendor their verification was
+// approved or rejected, with the reason of a rejection
+func (s *Service) SendVendorVerificationDecision(ctx context.Context, userID, verificationID, status, reason string) error {
+	notificationType := NotificationTypeVerificationApproved
+	var args []interface{}
+	if status == "rejected" {
+		notificationType = NotificationTypeVerificationRejected
+		args = append(args, reason)
+	}
+	lang, title, message := localizedText(ctx, notificationType, args...)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"verificationId": verificationID,
+			"status":         status,
+			"language":       lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendSpendingLimitReached tells a user a payment was declined by their
+// monthly spending limit
+func (s *Service) SendSpendingLimitReached(ctx context.Context, userID string, limit, spent int64) error {
+	formatter := locale.FromContext(ctx)
+	lang, title, message := localizedText(ctx, NotificationTypeSpendingLimit, formatter.Number(limit), formatter.Number(spent))
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeSpendingLimit,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"limit":    limit,
+			"spent":    spent,
+			"language": lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendImageImportCompleted tells a vendor their image import finished with
+// imported images and failed files
+func (s *Service) SendImageImportCompleted(ctx context.Context, userID, importID string, imported, failed int) error {
+	formatter := locale.FromContext(ctx)
+	lang, title, message := localizedText(ctx, NotificationTypeImageImportCompleted, formatter.Number(int64(imported)), formatter.Number(int64(failed)))
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeImageImportCompleted,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"importId": importID,
+			"imported": imported,
+			"failed":   failed,
+			"language": lang,
+		},
+		Priority: PriorityNormal,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendImageImportFailed tells a vendor their image import could not be run
+func (s *Service) SendImageImportFailed(ctx context.Context, userID, importID string) error {
+	lang, title, message := localizedText(ctx, NotificationTypeImageImportFailed)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeImageImportFailed,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"importId": importID,
+			"language": lang,
+		},
+		Priority: PriorityNormal,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendDataExportReady tells a user the copy of their account data can be
+// downloaded from downloadURL until expiresAt
+func (s *Service) SendDataExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error {
+	lang, title, message := localizedText(ctx, NotificationTypeDataExportReady, expiresAt.UTC().Format(time.RFC1123), downloadURL)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeDataExportReady,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"exportId":    exportID,
+			"downloadUrl": downloadURL,
+			"expiresAt":   expiresAt,
+			"language":    lang,
+		},
+		Priority: PriorityHigh,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
+// SendDataExportFailed tells a user the copy of their account data failed
+func (s *Service) SendDataExportFailed(ctx context.Context, userID, exportID string) error {
+	lang, title, message := localizedText(ctx, NotificationTypeDataExportFailed)
+	req := CreateNotificationRequest{
+		UserID:  &userID,
+		Type:    NotificationTypeDataExportFailed,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"exportId": exportID,
+			"language": lang,
+		},
+		Priority: PriorityNormal,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	return err
+}
+
 // SendCriticalError sends a critical error alert to Telegram
 func (s *Service) SendCriticalError(ctx context.Context, errorType, message string, metadata map[string]interface{}) error {
 	// Create notification for admin
@@ -392,9 +683,10 @@ func (s *Service) SendCriticalError(ctx context.Context, errorType, message stri
 // SendSystemMaintenance sends a system maintenance notification
 func (s *Service) SendSystemMaintenance(ctx context.Context, message string, scheduledFor *string) error {
 	// Create system-wide notification
+	_, title, _ := localizedText(ctx, NotificationTypeSystemMaintenance)
 	req := CreateNotificationRequest{
 		Type:    NotificationTypeSystemMaintenance,
-		Title:   "System Maintenance",
+		Title:   title,
 		Message: message,
 		Data: map[string]interface{}{
 			"scheduledFor": scheduledFor,
@@ -427,6 +719,7 @@ func (s *Service) UpdateNotificationPreferences(ctx context.Context, userID stri
 			PushEnabled:      false,
 			Preferences:      make(map[NotificationType]bool),
 			Timezone:         "UTC",
+			DigestFrequency:  DigestOff,
 			CreatedAt:        time.Now(),
 			UpdatedAt:        time.Now(),
 		}
@@ -462,6 +755,12 @@ func (s *Service) UpdateNotificationPreferences(ctx context.Context, userID stri
 	if req.Timezone != nil {
 		prefs.Timezone = *req.Timezone
 	}
+	if req.DigestFrequency != nil {
+		if !req.DigestFrequency.Valid() {
+			return fmt.Errorf("%w: digest frequency must be off, hourly or daily", common.ErrValidation)
+		}
+		prefs.DigestFrequency = *req.DigestFrequency
+	}
 
 	prefs.UpdatedAt = time.Now()
 
@@ -510,9 +809,13 @@ func (s *Service) processNotification(ctx context.Context, notification Notifica
 		}
 	}
 
-	// Process each channel
+	// Process each channel; with a dispatcher this only queues the deliveries
 	for _, channel := range notification.Channels {
-		go s.processChannel(ctx, notification, channel, prefs)
+		if s.dispatcher != nil {
+			s.processChannel(ctx, notification, channel, prefs)
+		} else {
+			go s.processChannel(ctx, notification, channel, prefs)
+		}
 	}
 }
 
@@ -535,8 +838,21 @@ func (s *Service) processChannel(ctx context.Context, notification Notification,
 		return
 	}
 
-	// Create delivery record
-	delivery := NotificationDelivery{
+	// Low-priority notifications wait for the user's next digest
+	if s.queueForDigest(ctx, notification, channel, prefs) {
+		return
+	}
+
+	if s.dispatcher != nil {
+		s.enqueue(ctx, notification, channel)
+		return
+	}
+	s.deliver(ctx, notification, channel)
+}
+
+// newDelivery returns a pending delivery of the notification on channel
+func (s *Service) newDelivery(notification Notification, channel NotificationChannel) NotificationDelivery {
+	return NotificationDelivery{
 		ID:             generateID(),
 		NotificationID: notification.ID,
 		Channel:        channel,
@@ -546,21 +862,27 @@ func (s *Service) processChannel(ctx context.Context, notification Notification,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
+}
 
+// deliver records a delivery of the notification on channel and sends it
+func (s *Service) deliver(ctx context.Context, notification Notification, channel NotificationChannel) error {
+	// Create delivery record
+	delivery := s.newDelivery(notification, channel)
 	if err := s.store.CreateDelivery(ctx, delivery); err != nil {
 		log.Printf("Failed to create delivery record: %v", err)
-		return
+		return err
 	}
 
 	// Send notification
 	if err := s.sendNotification(ctx, notification, channel, delivery); err != nil {
 		log.Printf("Failed to send notification via %s: %v", channel, err)
 		s.handleDeliveryFailure(ctx, delivery, err)
-		return
+		return err
 	}
 
 	// Update delivery status
 	s.updateDeliveryStatus(ctx, delivery.ID, StatusSent, nil)
+	return nil
 }
 
 // sendNotification sends a notification via the specified channel
@@ -574,6 +896,8 @@ func (s *Service) sendNotification(ctx context.Context, notification Notificatio
 		return s.sendTelegram(ctx, notification, delivery)
 	case ChannelWebSocket:
 		return s.sendWebSocket(ctx, notification, delivery)
+	case ChannelPush:
+		return s.sendPush(ctx, notification, delivery)
 	default:
 		return fmt.Errorf("unsupported channel: %s", channel)
 	}
@@ -602,8 +926,17 @@ func (s *Service) sendEmail(ctx context.Context, notification Notification, deli
 		body = notification.Message
 	}
 
-	// Send email
-	if err := s.emailProvider.SendEmail(ctx, delivery.Recipient, subject, body, true); err != nil {
+	// Send email, recording the provider message ID when the provider reports it
+	if sender, ok := s.emailProvider.(EmailSender); ok {
+		if !s.emailProvider.ValidateEmail(delivery.Recipient) {
+			return fmt.Errorf("invalid email address: %s", delivery.Recipient)
+		}
+		receipt, err := sender.Send(ctx, ComposeEmail(s.config.Email.FromName, delivery.Recipient, subject, body, true))
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		s.recordReceipt(ctx, delivery.ID, receipt.Provider, receipt.MessageID)
+	} else if err := s.emailProvider.SendEmail(ctx, delivery.Recipient, subject, body, true); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -621,17 +954,16 @@ func (s *Service) sendSMS(ctx context.Context, notification Notification, delive
 		return fmt.Errorf("SMS notifications are disabled")
 	}
 
-	// Process template
-	message, err := s.templateEngine.ProcessSMSTemplate(string(notification.Type), map[string]interface{}{
-		"notification": notification,
-	})
-	if err != nil {
-		// Fallback to simple message
-		message = notification.Message
-	}
+	message := s.smsText(notification)
 
-	// Send SMS
-	if err := s.smsProvider.SendSMS(ctx, delivery.Recipient, message); err != nil {
+	// Send SMS, recording the provider message ID for delivery reports
+	if sender, ok := s.smsProvider.(SMSSender); ok {
+		receipt, err := sender.SendSMSWithReceipt(ctx, delivery.Recipient, message)
+		if err != nil {
+			return fmt.Errorf("failed to send SMS: %w", err)
+		}
+		s.recordReceipt(ctx, delivery.ID, receipt.Provider, receipt.MessageID)
+	} else if err := s.smsProvider.SendSMS(ctx, delivery.Recipient, message); err != nil {
 		return fmt.Errorf("failed to send SMS: %w", err)
 	}
 
@@ -643,6 +975,17 @@ func (s *Service) sendSMS(ctx context.Context, notification Notification, delive
 	return nil
 }
 
+// smsText renders the SMS template of the notification, falling back to its message
+func (s *Service) smsText(notification Notification) string {
+	message, err := s.templateEngine.ProcessSMSTemplate(string(notification.Type), map[string]interface{}{
+		"notification": notification,
+	})
+	if err != nil {
+		return notification.Message
+	}
+	return message
+}
+
 // sendTelegram sends a Telegram notification
 func (s *Service) sendTelegram(ctx context.Context, notification Notification, delivery NotificationDelivery) error {
 	if !s.config.Telegram.Enabled {
@@ -739,6 +1082,7 @@ func (s *Service) getDefaultPreferences() NotificationPreference {
 		PushEnabled:      false,
 		Preferences:      make(map[NotificationType]bool),
 		Timezone:         "UTC",
+		DigestFrequency:  DigestOff,
 	}
 }
 
@@ -816,6 +1160,21 @@ func (s *Service) handleDeliveryFailure(ctx context.Context, delivery Notificati
 	s.updateDeliveryStatus(ctx, delivery.ID, StatusFailed, &errorMsg)
 }
 
+// recordReceipt stores which provider accepted a message and its message ID
+func (s *Service) recordReceipt(ctx context.Context, deliveryID, provider, messageID string) {
+	updates := map[string]interface{}{
+		"provider":  provider,
+		"updatedAt": time.Now(),
+	}
+	if messageID != "" {
+		updates["providerMessageId"] = messageID
+	}
+
+	if err := s.store.UpdateDelivery(ctx, deliveryID, updates); err != nil {
+		log.Printf("Failed to record delivery receipt: %v", err)
+	}
+}
+
 func (s *Service) updateDeliveryStatus(ctx context.Context, deliveryID string, status NotificationStatus, errorMessage *string) {
 	updates := map[string]interface{}{
 		"status": status,
@@ -360,10 +360,62 @@ func (m *MockNotificationService) UpdateNotificationPreferences(ctx context.Cont
 	return nil
 }
 
+func (m *MockNotificationService) ListInbox(ctx context.Context, userID string, req InboxRequest) (InboxResponse, error) {
+	return InboxResponse{}, nil
+}
+
+func (m *MockNotificationService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockNotificationService) MarkAllRead(ctx context.Context, userID string) (MarkAllReadResponse, error) {
+	return MarkAllReadResponse{}, nil
+}
+
+func (m *MockNotificationService) RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (PushDevice, error) {
+	return PushDevice{}, nil
+}
+
+func (m *MockNotificationService) ListDevices(ctx context.Context, userID string) (PushDeviceList, error) {
+	return PushDeviceList{}, nil
+}
+
+func (m *MockNotificationService) UnregisterDevice(ctx context.Context, userID, deviceID string) error {
+	return nil
+}
+
 func (m *MockNotificationService) GetNotificationStats(ctx context.Context, timeRange string) (NotificationStats, error) {
 	return NotificationStats{}, nil
 }
 
+func (m *MockNotificationService) QueueStats() []ChannelQueueStats {
+	return nil
+}
+
+func (m *MockNotificationService) ListTemplates(ctx context.Context, req ListTemplatesRequest) (*ListTemplatesResponse, error) {
+	return &ListTemplatesResponse{}, nil
+}
+
+func (m *MockNotificationService) GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error) {
+	return &NotificationTemplate{}, nil
+}
+
+func (m *MockNotificationService) CreateTemplate(ctx context.Context, adminID string, req CreateTemplateRequest) (*NotificationTemplate, error) {
+	return &NotificationTemplate{}, nil
+}
+
+func (m *MockNotificationService) UpdateTemplate(ctx context.Context, adminID, id string, req UpdateTemplateRequest) (*NotificationTemplate, error) {
+	return &NotificationTemplate{}, nil
+}
+
+func (m *MockNotificationService) DeleteTemplate(ctx context.Context, adminID, id string) error {
+	return nil
+}
+
+func (m *MockNotificationService) PreviewTemplate(ctx context.Context, req PreviewTemplateRequest) (*PreviewTemplateResponse, error) {
+	return &PreviewTemplateResponse{}, nil
+}
+
 func (m *MockNotificationService) BroadcastToUser(ctx context.Context, userID string, message WebSocketMessage) error {
 	return nil
 }
@@ -2,9 +2,11 @@ package notification
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -27,24 +29,37 @@ func NewSMSProvider(config SMSConfig) SMSProvider {
 
 // SendSMS sends an SMS message
 func (s *SMSProviderImpl) SendSMS(ctx context.Context, phone, message string) error {
+	_, err := s.SendSMSWithReceipt(ctx, phone, message)
+	return err
+}
+
+// SendSMSWithReceipt sends an SMS message and returns the message ID assigned
+// by the provider, empty when the provider's response doesn't carry one
+func (s *SMSProviderImpl) SendSMSWithReceipt(ctx context.Context, phone, message string) (SMSReceipt, error) {
 	if !s.config.Enabled {
-		return fmt.Errorf("SMS notifications are disabled")
+		return SMSReceipt{}, fmt.Errorf("SMS notifications are disabled")
 	}
 
 	// Validate phone number
 	if !s.ValidatePhone(phone) {
-		return fmt.Errorf("invalid phone number: %s", phone)
+		return SMSReceipt{}, fmt.Errorf("invalid phone number: %s", phone)
 	}
 
 	// Send SMS based on provider
+	var messageID string
+	var err error
 	switch s.config.Provider {
 	case "sms_ir":
-		return s.sendViaSMSIR(ctx, phone, message)
+		messageID, err = s.sendViaSMSIR(ctx, phone, message)
 	case "kavenegar":
-		return s.sendViaKavenegar(ctx, phone, message)
+		messageID, err = s.sendViaKavenegar(ctx, phone, message)
 	default:
-		return fmt.Errorf("unsupported SMS provider: %s", s.config.Provider)
+		return SMSReceipt{}, fmt.Errorf("unsupported SMS provider: %s", s.config.Provider)
+	}
+	if err != nil {
+		return SMSReceipt{}, err
 	}
+	return SMSReceipt{Provider: s.config.Provider, MessageID: messageID}, nil
 }
 
 // SendTemplateSMS sends an SMS using a template
@@ -68,7 +83,7 @@ func (s *SMSProviderImpl) ValidatePhone(phone string) bool {
 }
 
 // sendViaSMSIR sends SMS via SMS.ir
-func (s *SMSProviderImpl) sendViaSMSIR(ctx context.Context, phone, message string) error {
+func (s *SMSProviderImpl) sendViaSMSIR(ctx context.Context, phone, message string) (string, error) {
 	// SMS.ir API implementation
 	apiURL := "https://api.sms.ir/v1/send/verify"
 
@@ -79,7 +94,7 @@ func (s *SMSProviderImpl) sendViaSMSIR(ctx context.Context, phone, message strin
 
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -87,19 +102,27 @@ func (s *SMSProviderImpl) sendViaSMSIR(ctx context.Context, phone, message strin
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS: %w", err)
+		return "", fmt.Errorf("failed to send SMS: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("SMS API returned status %d", resp.StatusCode)
+		return "", fmt.Errorf("SMS API returned status %d", resp.StatusCode)
 	}
 
-	return nil
+	var result struct {
+		Data struct {
+			MessageID int64 `json:"messageId"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Data.MessageID <= 0 {
+		return "", nil
+	}
+	return strconv.FormatInt(result.Data.MessageID, 10), nil
 }
 
 // sendViaKavenegar sends SMS via Kavenegar
-func (s *SMSProviderImpl) sendViaKavenegar(ctx context.Context, phone, message string) error {
+func (s *SMSProviderImpl) sendViaKavenegar(ctx context.Context, phone, message string) (string, error) {
 	// Kavenegar API implementation
 	apiURL := fmt.Sprintf("https://api.kavenegar.com/v1/%s/sms/send.json", s.config.APIKey)
 
@@ -109,20 +132,125 @@ func (s *SMSProviderImpl) sendViaKavenegar(ctx context.Context, phone, message s
 
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("SMS API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Entries []struct {
+			MessageID int64 `json:"messageid"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || len(result.Entries) == 0 || result.Entries[0].MessageID <= 0 {
+		return "", nil
+	}
+	return strconv.FormatInt(result.Entries[0].MessageID, 10), nil
+}
+
+// kavenegarBatchSize is the most messages Kavenegar's sendarray takes
+const kavenegarBatchSize = 200
+
+// SMSBatchSize returns how many messages one SendSMSBatch call takes. Only
+// Kavenegar supports batches, and its sendarray needs a sending line.
+func (s *SMSProviderImpl) SMSBatchSize() int {
+	if s.config.Provider == "kavenegar" && s.config.Sender != "" {
+		return kavenegarBatchSize
+	}
+	return 0
+}
+
+// SendSMSBatch sends messages in one provider call and returns their
+// receipts in order
+func (s *SMSProviderImpl) SendSMSBatch(ctx context.Context, messages []SMSMessage) ([]SMSReceipt, error) {
+	if !s.config.Enabled {
+		return nil, fmt.Errorf("SMS notifications are disabled")
+	}
+	if len(messages) > s.SMSBatchSize() {
+		return nil, fmt.Errorf("batch of %d messages exceeds the %s batch size %d", len(messages), s.config.Provider, s.SMSBatchSize())
+	}
+	for _, msg := range messages {
+		if !s.ValidatePhone(msg.Phone) {
+			return nil, fmt.Errorf("invalid phone number: %s", msg.Phone)
+		}
+	}
+
+	messageIDs, err := s.sendArrayViaKavenegar(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]SMSReceipt, len(messages))
+	for i := range receipts {
+		receipts[i] = SMSReceipt{Provider: s.config.Provider}
+		if i < len(messageIDs) {
+			receipts[i].MessageID = messageIDs[i]
+		}
+	}
+	return receipts, nil
+}
+
+// sendArrayViaKavenegar sends messages through Kavenegar's sendarray, which
+// takes parallel JSON arrays of receptors, senders and messages
+func (s *SMSProviderImpl) sendArrayViaKavenegar(ctx context.Context, messages []SMSMessage) ([]string, error) {
+	apiURL := fmt.Sprintf("https://api.kavenegar.com/v1/%s/sms/sendarray.json", s.config.APIKey)
+
+	receptors := make([]string, len(messages))
+	senders := make([]string, len(messages))
+	texts := make([]string, len(messages))
+	for i, msg := range messages {
+		receptors[i] = msg.Phone
+		senders[i] = s.config.Sender
+		texts[i] = msg.Text
+	}
+	data := url.Values{}
+	for key, values := range map[string][]string{"receptor": receptors, "sender": senders, "message": texts} {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		data.Set(key, string(encoded))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS: %w", err)
+		return nil, fmt.Errorf("failed to send SMS batch: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("SMS API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("SMS API returned status %d", resp.StatusCode)
 	}
 
-	return nil
+	var result struct {
+		Entries []struct {
+			MessageID int64 `json:"messageid"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil
+	}
+	messageIDs := make([]string, len(result.Entries))
+	for i, entry := range result.Entries {
+		if entry.MessageID > 0 {
+			messageIDs[i] = strconv.FormatInt(entry.MessageID, 10)
+		}
+	}
+	return messageIDs, nil
 }
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-styler/internal/sms"
+)
+
+// ApplyDeliveryReport updates the SMS delivery a provider's delivery report is
+// about and records the outcome in the notification metrics. It reports
+// whether the message was sent as a notification.
+func (s *Service) ApplyDeliveryReport(ctx context.Context, provider string, report sms.DeliveryReport) (bool, error) {
+	delivery, err := s.store.GetDeliveryByProviderMessageID(ctx, provider, report.MessageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find delivery: %w", err)
+	}
+	if delivery.Channel != ChannelSMS {
+		return false, nil
+	}
+
+	// Reports are retried and may arrive out of order, a final status sticks
+	if delivery.Status == StatusDelivered || delivery.Status == StatusFailed {
+		return true, nil
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"updatedAt": now}
+	switch report.Status {
+	case sms.DeliveryStatusDelivered:
+		updates["status"] = StatusDelivered
+		updates["deliveredAt"] = now
+	case sms.DeliveryStatusFailed:
+		updates["status"] = StatusFailed
+		updates["errorMessage"] = report.Detail
+	default:
+		return true, nil
+	}
+
+	if err := s.store.UpdateDelivery(ctx, delivery.ID, updates); err != nil {
+		return true, fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	notificationType := NotificationType("")
+	if notification, err := s.store.GetNotification(ctx, delivery.NotificationID); err == nil {
+		notificationType = notification.Type
+	} else {
+		log.Printf("Failed to get notification %s for delivery metrics: %v", delivery.NotificationID, err)
+	}
+
+	if report.Status == sms.DeliveryStatusDelivered {
+		var deliveryTimeMs int64
+		if delivery.SentAt != nil {
+			deliveryTimeMs = now.Sub(*delivery.SentAt).Milliseconds()
+		}
+		s.metrics.RecordNotificationDelivered(ctx, notificationType, ChannelSMS, deliveryTimeMs)
+	} else {
+		s.metrics.RecordNotificationFailed(ctx, notificationType, ChannelSMS, report.Detail)
+	}
+
+	return true, nil
+}
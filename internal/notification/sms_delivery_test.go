@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"ai-styler/internal/sms"
+)
+
+// reportStore serves one SMS delivery and records the updates applied to it
+type reportStore struct {
+	memoryNotificationStore
+	delivery NotificationDelivery
+	updates  map[string]interface{}
+}
+
+func (r *reportStore) GetDeliveryByProviderMessageID(ctx context.Context, provider, messageID string) (NotificationDelivery, error) {
+	if r.delivery.Provider == nil || *r.delivery.Provider != provider || *r.delivery.ProviderMessageID != messageID {
+		return NotificationDelivery{}, sql.ErrNoRows
+	}
+	return r.delivery, nil
+}
+
+func (r *reportStore) UpdateDelivery(ctx context.Context, deliveryID string, updates map[string]interface{}) error {
+	r.updates = updates
+	return nil
+}
+
+func (r *reportStore) GetNotification(ctx context.Context, notificationID string) (Notification, error) {
+	return Notification{ID: notificationID, Type: NotificationTypeQuotaWarning}, nil
+}
+
+type recordingMetrics struct {
+	MockMetricsCollector
+	delivered []int64
+	failed    []string
+}
+
+func (r *recordingMetrics) RecordNotificationDelivered(ctx context.Context, notificationType NotificationType, channel NotificationChannel, deliveryTimeMs int64) error {
+	r.delivered = append(r.delivered, deliveryTimeMs)
+	return nil
+}
+
+func (r *recordingMetrics) RecordNotificationFailed(ctx context.Context, notificationType NotificationType, channel NotificationChannel, errorType string) error {
+	r.failed = append(r.failed, errorType)
+	return nil
+}
+
+func TestApplyDeliveryReport(t *testing.T) {
+	provider, messageID := "kavenegar", "42"
+	sentAt := time.Now().Add(-time.Minute)
+	newService := func(status NotificationStatus) (*Service, *reportStore, *recordingMetrics) {
+		store := &reportStore{delivery: NotificationDelivery{
+			ID: "d1", NotificationID: "n1", Channel: ChannelSMS, Status: status,
+			Provider: &provider, ProviderMessageID: &messageID, SentAt: &sentAt,
+		}}
+		metrics := &recordingMetrics{}
+		service := newDigestTestService(&store.memoryNotificationStore, NewMockEmailProvider())
+		service.store = store
+		service.metrics = metrics
+		return service, store, metrics
+	}
+
+	service, store, metrics := newService(StatusSent)
+	matched, err := service.ApplyDeliveryReport(context.Background(), provider, sms.DeliveryReport{MessageID: messageID, Status: sms.DeliveryStatusDelivered})
+	if err != nil || !matched {
+		t.Fatalf("Expected report to match, got %v, %v", matched, err)
+	}
+	if store.updates["status"] != StatusDelivered || store.updates["deliveredAt"] == nil {
+		t.Errorf("Expected delivery marked delivered, got %v", store.updates)
+	}
+	if len(metrics.delivered) != 1 || metrics.delivered[0] < time.Minute.Milliseconds() {
+		t.Errorf("Expected delivery time of at least a minute recorded, got %v", metrics.delivered)
+	}
+
+	service, store, metrics = newService(StatusSent)
+	service.ApplyDeliveryReport(context.Background(), provider, sms.DeliveryReport{MessageID: messageID, Status: sms.DeliveryStatusFailed, Detail: "kavenegar status 11"})
+	if store.updates["status"] != StatusFailed || store.updates["errorMessage"] != "kavenegar status 11" {
+		t.Errorf("Expected delivery marked failed with the provider status, got %v", store.updates)
+	}
+	if len(metrics.failed) != 1 {
+		t.Errorf("Expected failure recorded, got %v", metrics.failed)
+	}
+
+	service, store, _ = newService(StatusDelivered)
+	matched, _ = service.ApplyDeliveryReport(context.Background(), provider, sms.DeliveryReport{MessageID: messageID, Status: sms.DeliveryStatusFailed})
+	if !matched || store.updates != nil {
+		t.Errorf("Expected a final status to stick, got %v", store.updates)
+	}
+
+	matched, err = service.ApplyDeliveryReport(context.Background(), provider, sms.DeliveryReport{MessageID: "other", Status: sms.DeliveryStatusDelivered})
+	if err != nil || matched {
+		t.Errorf("Expected unknown message not to match, got %v, %v", matched, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/lib/pq"
 )
@@ -326,9 +327,9 @@ func (s Store) MarkAsRead(ctx context.Context, notificationID, userID string) er
 func (s Store) CreateDelivery(ctx context.Context, delivery NotificationDelivery) error {
 	query := `
 		INSERT INTO notification_deliveries (
-			id, notification_id, channel, recipient, status, error_message,
+			id, notification_id, channel, recipient, status, provider, provider_message_id, error_message,
 			sent_at, delivered_at, read_at, retry_count, next_retry_at, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
 	_, err := s.db.ExecContext(ctx, query,
 		delivery.ID,
@@ -336,6 +337,8 @@ func (s Store) CreateDelivery(ctx context.Context, delivery NotificationDelivery
 		string(delivery.Channel),
 		delivery.Recipient,
 		string(delivery.Status),
+		delivery.Provider,
+		delivery.ProviderMessageID,
 		delivery.ErrorMessage,
 		delivery.SentAt,
 		delivery.DeliveredAt,
@@ -349,6 +352,20 @@ func (s Store) CreateDelivery(ctx context.Context, delivery NotificationDelivery
 	return err
 }
 
+// deliveryColumns maps the keys accepted by UpdateDelivery to their columns
+var deliveryColumns = map[string]string{
+	"status":            "status",
+	"provider":          "provider",
+	"providerMessageId": "provider_message_id",
+	"errorMessage":      "error_message",
+	"sentAt":            "sent_at",
+	"deliveredAt":       "delivered_at",
+	"readAt":            "read_at",
+	"retryCount":        "retry_count",
+	"nextRetryAt":       "next_retry_at",
+	"updatedAt":         "updated_at",
+}
+
 // UpdateDelivery updates a delivery record
 func (s Store) UpdateDelivery(ctx context.Context, deliveryID string, updates map[string]interface{}) error {
 	if len(updates) == 0 {
@@ -360,20 +377,20 @@ func (s Store) UpdateDelivery(ctx context.Context, deliveryID string, updates ma
 	argIndex := 1
 
 	for key, value := range updates {
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", key, argIndex))
+		column, ok := deliveryColumns[key]
+		if !ok {
+			return fmt.Errorf("unknown delivery field: %s", key)
+		}
+		if status, ok := value.(NotificationStatus); ok {
+			value = string(status)
+		}
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, argIndex))
 		args = append(args, value)
 		argIndex++
 	}
 
 	query := fmt.Sprintf("UPDATE notification_deliveries SET %s WHERE id = $%d",
-		fmt.Sprintf("%s", setParts[0]), argIndex)
-
-	for i := 1; i < len(setParts); i++ {
-		query = fmt.Sprintf("UPDATE notification_deliveries SET %s, %s WHERE id = $%d",
-			query[30:len(query)-12], setParts[i], argIndex+1)
-		argIndex++
-	}
-
+		strings.Join(setParts, ", "), argIndex)
 	args = append(args, deliveryID)
 
 	_, err := s.db.ExecContext(ctx, query, args...)
@@ -383,7 +400,7 @@ func (s Store) UpdateDelivery(ctx context.Context, deliveryID string, updates ma
 // GetFailedDeliveries gets failed delivery records
 func (s Store) GetFailedDeliveries(ctx context.Context, limit int) ([]NotificationDelivery, error) {
 	query := `
-		SELECT id, notification_id, channel, recipient, status, error_message,
+		SELECT id, notification_id, channel, recipient, status, provider, provider_message_id, error_message,
 		       sent_at, delivered_at, read_at, retry_count, next_retry_at, created_at, updated_at
 		FROM notification_deliveries 
 		WHERE status = 'failed' 
@@ -400,7 +417,7 @@ func (s Store) GetFailedDeliveries(ctx context.Context, limit int) ([]Notificati
 	for rows.Next() {
 		var delivery NotificationDelivery
 		var sentAt, deliveredAt, readAt, nextRetryAt sql.NullTime
-		var errorMessage sql.NullString
+		var provider, providerMessageID, errorMessage sql.NullString
 
 		err := rows.Scan(
 			&delivery.ID,
@@ -408,6 +425,8 @@ func (s Store) GetFailedDeliveries(ctx context.Context, limit int) ([]Notificati
 			&delivery.Channel,
 			&delivery.Recipient,
 			&delivery.Status,
+			&provider,
+			&providerMessageID,
 			&errorMessage,
 			&sentAt,
 			&deliveredAt,
@@ -422,6 +441,12 @@ func (s Store) GetFailedDeliveries(ctx context.Context, limit int) ([]Notificati
 			return nil, err
 		}
 
+		if provider.Valid {
+			delivery.Provider = &provider.String
+		}
+		if providerMessageID.Valid {
+			delivery.ProviderMessageID = &providerMessageID.String
+		}
 		if errorMessage.Valid {
 			delivery.ErrorMessage = &errorMessage.String
 		}
@@ -447,7 +472,7 @@ func (s Store) GetFailedDeliveries(ctx context.Context, limit int) ([]Notificati
 // GetDeliveriesByNotification gets delivery records for a notification
 func (s Store) GetDeliveriesByNotification(ctx context.Context, notificationID string) ([]NotificationDelivery, error) {
 	query := `
-		SELECT id, notification_id, channel, recipient, status, error_message,
+		SELECT id, notification_id, channel, recipient, status, provider, provider_message_id, error_message,
 		       sent_at, delivered_at, read_at, retry_count, next_retry_at, created_at, updated_at
 		FROM notification_deliveries 
 		WHERE notification_id = $1 
@@ -463,7 +488,7 @@ func (s Store) GetDeliveriesByNotification(ctx context.Context, notificationID s
 	for rows.Next() {
 		var delivery NotificationDelivery
 		var sentAt, deliveredAt, readAt, nextRetryAt sql.NullTime
-		var errorMessage sql.NullString
+		var provider, providerMessageID, errorMessage sql.NullString
 
 		err := rows.Scan(
 			&delivery.ID,
@@ -471,6 +496,8 @@ func (s Store) GetDeliveriesByNotification(ctx context.Context, notificationID s
 			&delivery.Channel,
 			&delivery.Recipient,
 			&delivery.Status,
+			&provider,
+			&providerMessageID,
 			&errorMessage,
 			&sentAt,
 			&deliveredAt,
@@ -485,6 +512,12 @@ func (s Store) GetDeliveriesByNotification(ctx context.Context, notificationID s
 			return nil, err
 		}
 
+		if provider.Valid {
+			delivery.Provider = &provider.String
+		}
+		if providerMessageID.Valid {
+			delivery.ProviderMessageID = &providerMessageID.String
+		}
 		if errorMessage.Valid {
 			delivery.ErrorMessage = &errorMessage.String
 		}
@@ -507,11 +540,73 @@ func (s Store) GetDeliveriesByNotification(ctx context.Context, notificationID s
 	return deliveries, nil
 }
 
+// GetDeliveryByProviderMessageID gets the latest delivery a provider accepted
+// under the given message ID, or sql.ErrNoRows when none matches
+func (s Store) GetDeliveryByProviderMessageID(ctx context.Context, provider, messageID string) (NotificationDelivery, error) {
+	query := `
+		SELECT id, notification_id, channel, recipient, status, provider, provider_message_id, error_message,
+		       sent_at, delivered_at, read_at, retry_count, next_retry_at, created_at, updated_at
+		FROM notification_deliveries 
+		WHERE provider = $1 AND provider_message_id = $2 
+		ORDER BY created_at DESC 
+		LIMIT 1`
+
+	var delivery NotificationDelivery
+	var sentAt, deliveredAt, readAt, nextRetryAt sql.NullTime
+	var providerName, providerMessageID, errorMessage sql.NullString
+
+	err := s.db.QueryRowContext(ctx, query, provider, messageID).Scan(
+		&delivery.ID,
+		&delivery.NotificationID,
+		&delivery.Channel,
+		&delivery.Recipient,
+		&delivery.Status,
+		&providerName,
+		&providerMessageID,
+		&errorMessage,
+		&sentAt,
+		&deliveredAt,
+		&readAt,
+		&delivery.RetryCount,
+		&nextRetryAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return NotificationDelivery{}, err
+	}
+
+	if providerName.Valid {
+		delivery.Provider = &providerName.String
+	}
+	if providerMessageID.Valid {
+		delivery.ProviderMessageID = &providerMessageID.String
+	}
+	if errorMessage.Valid {
+		delivery.ErrorMessage = &errorMessage.String
+	}
+	if sentAt.Valid {
+		delivery.SentAt = &sentAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	if readAt.Valid {
+		delivery.ReadAt = &readAt.Time
+	}
+	if nextRetryAt.Valid {
+		delivery.NextRetryAt = &nextRetryAt.Time
+	}
+
+	return delivery, nil
+}
+
 // GetNotificationPreferences gets user notification preferences
 func (s Store) GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error) {
 	query := `
 		SELECT user_id, email_enabled, sms_enabled, telegram_enabled, websocket_enabled, 
-		       push_enabled, preferences, quiet_hours_start, quiet_hours_end, timezone, created_at, updated_at
+		       push_enabled, preferences, quiet_hours_start, quiet_hours_end, timezone, digest_frequency,
+		       created_at, updated_at
 		FROM notification_preferences 
 		WHERE user_id = $1`
 
@@ -529,6 +624,7 @@ func (s Store) GetNotificationPreferences(ctx context.Context, userID string) (N
 		&prefs.QuietHoursStart,
 		&prefs.QuietHoursEnd,
 		&prefs.Timezone,
+		&prefs.DigestFrequency,
 		&prefs.CreatedAt,
 		&prefs.UpdatedAt,
 	)
@@ -552,7 +648,7 @@ func (s Store) UpdateNotificationPreferences(ctx context.Context, userID string,
 		UPDATE notification_preferences 
 		SET email_enabled = $2, sms_enabled = $3, telegram_enabled = $4, websocket_enabled = $5,
 		    push_enabled = $6, preferences = $7, quiet_hours_start = $8, quiet_hours_end = $9,
-		    timezone = $10, updated_at = $11
+		    timezone = $10, updated_at = $11, digest_frequency = $12
 		WHERE user_id = $1`
 
 	// Convert preferences map
@@ -573,6 +669,7 @@ func (s Store) UpdateNotificationPreferences(ctx context.Context, userID string,
 		prefs.QuietHoursEnd,
 		prefs.Timezone,
 		prefs.UpdatedAt,
+		digestFrequencyOrDefault(prefs.DigestFrequency),
 	)
 
 	return err
@@ -583,8 +680,9 @@ func (s Store) CreateNotificationPreferences(ctx context.Context, prefs Notifica
 	query := `
 		INSERT INTO notification_preferences (
 			user_id, email_enabled, sms_enabled, telegram_enabled, websocket_enabled,
-			push_enabled, preferences, quiet_hours_start, quiet_hours_end, timezone, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+			push_enabled, preferences, quiet_hours_start, quiet_hours_end, timezone, created_at, updated_at,
+			digest_frequency
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 
 	// Convert preferences map
 	preferences := make(map[string]bool)
@@ -605,6 +703,7 @@ func (s Store) CreateNotificationPreferences(ctx context.Context, prefs Notifica
 		prefs.Timezone,
 		prefs.CreatedAt,
 		prefs.UpdatedAt,
+		digestFrequencyOrDefault(prefs.DigestFrequency),
 	)
 
 	return err
@@ -1,14 +1,30 @@
 package notification
 
 import (
+	"context"
 	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
+
+	"ai-styler/internal/locale"
 )
 
 // TemplateEngineImpl implements TemplateEngine interface
 type TemplateEngineImpl struct {
 	templates map[string]string
+
+	// Templates admins manage take precedence over the built-in ones
+	store    TemplateStore
+	cacheTTL time.Duration
+	mutex    sync.Mutex
+	managed  map[templateKey]NotificationTemplate
+	expires  time.Time
+	now      func() time.Time
 }
 
 // NewTemplateEngine creates a new template engine
@@ -18,6 +34,21 @@ func NewTemplateEngine() TemplateEngine {
 	}
 }
 
+// NewStoredTemplateEngine creates a template engine that renders the active
+// templates of store, read again once they are cacheTTL old, and the
+// built-in templates for the rest
+func NewStoredTemplateEngine(store TemplateStore, cacheTTL time.Duration) *TemplateEngineImpl {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultTemplateCacheTTL
+	}
+	return &TemplateEngineImpl{
+		templates: make(map[string]string),
+		store:     store,
+		cacheTTL:  cacheTTL,
+		now:       time.Now,
+	}
+}
+
 // ProcessTemplate processes a template with data
 func (t *TemplateEngineImpl) ProcessTemplate(templateStr string, data map[string]interface{}) (string, error) {
 	tmpl, err := template.New("notification").Parse(templateStr)
@@ -35,6 +66,14 @@ func (t *TemplateEngineImpl) ProcessTemplate(templateStr string, data map[string
 
 // ProcessEmailTemplate processes an email template
 func (t *TemplateEngineImpl) ProcessEmailTemplate(templateID string, data map[string]interface{}) (subject, body string, err error) {
+	if managed := t.managedTemplate(templateID, ChannelEmail, data); managed != nil {
+		subject, body, err := renderTemplate(ChannelEmail, managed.Subject, managed.Body, data)
+		if err == nil {
+			return subject, body, nil
+		}
+		log.Printf("Failed to render notification template %s, using the built-in one: %v", managed.ID, err)
+	}
+
 	// Get template by ID
 	templateStr, exists := t.templates[templateID]
 	if !exists {
@@ -63,6 +102,14 @@ func (t *TemplateEngineImpl) ProcessEmailTemplate(templateID string, data map[st
 
 // ProcessSMSTemplate processes an SMS template
 func (t *TemplateEngineImpl) ProcessSMSTemplate(templateID string, data map[string]interface{}) (string, error) {
+	if managed := t.managedTemplate(templateID, ChannelSMS, data); managed != nil {
+		_, body, err := renderTemplate(ChannelSMS, "", managed.Body, data)
+		if err == nil {
+			return body, nil
+		}
+		log.Printf("Failed to render notification template %s, using the built-in one: %v", managed.ID, err)
+	}
+
 	// Get template by ID
 	templateStr, exists := t.templates[templateID]
 	if !exists {
@@ -75,6 +122,14 @@ func (t *TemplateEngineImpl) ProcessSMSTemplate(templateID string, data map[stri
 
 // ProcessTelegramTemplate processes a Telegram template
 func (t *TemplateEngineImpl) ProcessTelegramTemplate(templateID string, data map[string]interface{}) (string, error) {
+	if managed := t.managedTemplate(templateID, ChannelTelegram, data); managed != nil {
+		_, body, err := renderTemplate(ChannelTelegram, "", managed.Body, data)
+		if err == nil {
+			return body, nil
+		}
+		log.Printf("Failed to render notification template %s, using the built-in one: %v", managed.ID, err)
+	}
+
 	// Get template by ID
 	templateStr, exists := t.templates[templateID]
 	if !exists {
@@ -85,6 +140,92 @@ func (t *TemplateEngineImpl) ProcessTelegramTemplate(templateID string, data map
 	return t.ProcessTemplate(templateStr, data)
 }
 
+// managedTemplate returns the active template admins set for templateID on
+// channel in the language of the notification in data, or nil
+func (t *TemplateEngineImpl) managedTemplate(templateID string, channel NotificationChannel, data map[string]interface{}) *NotificationTemplate {
+	if t.store == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.managed == nil || !t.now().Before(t.expires) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		templates, err := t.store.ListActiveTemplates(ctx)
+		cancel()
+		if err != nil {
+			// Keep serving the templates read last time until the store is back
+			log.Printf("Failed to read notification templates: %v", err)
+		} else {
+			t.managed = make(map[templateKey]NotificationTemplate, len(templates))
+			for _, managed := range templates {
+				t.managed[managed.key()] = managed
+			}
+		}
+		t.expires = t.now().Add(t.cacheTTL)
+	}
+
+	managed, ok := t.managed[templateKey{NotificationType(templateID), channel, templateLanguage(data)}]
+	if !ok {
+		return nil
+	}
+	return &managed
+}
+
+// invalidate drops the cached templates after an admin change. Other
+// instances pick up the change once their copy expires.
+func (t *TemplateEngineImpl) invalidate() {
+	t.mutex.Lock()
+	t.managed = nil
+	t.mutex.Unlock()
+}
+
+// templateLanguage returns the language the notification in data was
+// created in
+func templateLanguage(data map[string]interface{}) string {
+	if notification, ok := data["notification"].(Notification); ok {
+		if lang, ok := notification.Data["language"].(string); ok && lang != "" {
+			return lang
+		}
+	}
+	return locale.DefaultLanguage
+}
+
+// renderTemplate renders the subject and body of a template of channel with
+// data. Email bodies are HTML and escape the values they show; other texts,
+// and email subjects, are plain. Only email templates have a subject.
+func renderTemplate(channel NotificationChannel, subject, body string, data map[string]interface{}) (string, string, error) {
+	var bodyTemplate interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+	var err error
+	if channel == ChannelEmail {
+		bodyTemplate, err = htmltemplate.New("body").Parse(body)
+	} else {
+		bodyTemplate, err = template.New("body").Parse(body)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse body: %w", err)
+	}
+	var renderedBody strings.Builder
+	if err := bodyTemplate.Execute(&renderedBody, data); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+	if channel != ChannelEmail {
+		return "", renderedBody.String(), nil
+	}
+
+	subjectTemplate, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse subject: %w", err)
+	}
+	var renderedSubject strings.Builder
+	if err := subjectTemplate.Execute(&renderedSubject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	return strings.TrimSpace(renderedSubject.String()), renderedBody.String(), nil
+}
+
 // getDefaultEmailTemplate returns a default email template for the given type
 func (t *TemplateEngineImpl) getDefaultEmailTemplate(templateID string) string {
 	switch templateID {
@@ -184,6 +325,19 @@ func (t *TemplateEngineImpl) getDefaultEmailTemplate(templateID string) string {
 <p>Error Type: {{.notification.data.errorType}}</p>
 <p>Timestamp: {{.notification.data.timestamp}}</p>
 </body>
+</html>`
+
+	case string(NotificationTypeDigest):
+		return `{{.notification.Title}}
+---
+<html>
+<body>
+<h2>{{.notification.Title}}</h2>
+<p>{{.notification.Message}}</p>
+<ul>
+{{range .notification.Data.items}}<li><strong>{{html .title}}</strong><br>{{html .message}}</li>
+{{end}}</ul>
+</body>
 </html>`
 
 	default:
@@ -0,0 +1,577 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/locale"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DefaultTemplateCacheTTL is how long the templates admins manage are cached
+// when no cache TTL is configured
+const DefaultTemplateCacheTTL = 30 * time.Second
+
+// Bounds of a template in bytes
+const (
+	maxTemplateSubjectLength = 200
+	maxTemplateBodyLength    = 20000
+)
+
+// templateChannels are the channels whose texts are rendered from templates
+var templateChannels = map[NotificationChannel]bool{
+	ChannelEmail:    true,
+	ChannelSMS:      true,
+	ChannelTelegram: true,
+}
+
+// Notification types are lowercase identifiers
+var templateTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// templateKey identifies the template of a type on a channel in a language
+type templateKey struct {
+	Type    NotificationType
+	Channel NotificationChannel
+	Locale  string
+}
+
+func (t NotificationTemplate) key() templateKey {
+	return templateKey{t.Type, t.Channel, t.Locale}
+}
+
+// CreateTemplateRequest is the body of POST /admin/notification-templates
+type CreateTemplateRequest struct {
+	Type     NotificationType    `json:"type" binding:"required"`
+	Channel  NotificationChannel `json:"channel" binding:"required"`
+	Locale   string              `json:"locale" binding:"required"`
+	Subject  string              `json:"subject"` // required for email
+	Body     string              `json:"body" binding:"required"`
+	IsActive *bool               `json:"isActive"` // active by default
+}
+
+// UpdateTemplateRequest is the body of PUT /admin/notification-templates/:id.
+// Fields left out keep their value.
+type UpdateTemplateRequest struct {
+	Subject  *string `json:"subject"`
+	Body     *string `json:"body"`
+	IsActive *bool   `json:"isActive"`
+}
+
+// ListTemplatesRequest filters GET /admin/notification-templates
+type ListTemplatesRequest struct {
+	Type    string `json:"type" form:"type"`
+	Channel string `json:"channel" form:"channel"`
+	Locale  string `json:"locale" form:"locale"`
+}
+
+// ListTemplatesResponse is the response of GET /admin/notification-templates
+type ListTemplatesResponse struct {
+	Templates []NotificationTemplate `json:"templates"`
+	Total     int                    `json:"total"`
+}
+
+// PreviewTemplateRequest is the body of POST /admin/notification-templates/preview.
+// Without a body the template notifications of the type are sent with is
+// rendered: the active stored one, or the built-in one.
+type PreviewTemplateRequest struct {
+	Type    NotificationType    `json:"type" binding:"required"`
+	Channel NotificationChannel `json:"channel" binding:"required"`
+	Locale  string              `json:"locale"` // DefaultLanguage by default
+	Subject string              `json:"subject"`
+	Body    string              `json:"body"`
+	// Data is the sample notification data, e.g. {"conversionId": "..."}
+	Data map[string]interface{} `json:"data"`
+}
+
+// Sources of a preview
+const (
+	PreviewSourceRequest = "request" // the template of the request
+	PreviewSourceStored  = "stored"  // the active stored template
+	PreviewSourceBuiltin = "builtin" // the built-in template
+)
+
+// PreviewTemplateResponse is a template rendered with sample data
+type PreviewTemplateResponse struct {
+	Source  string `json:"source"`
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+// TemplateStore keeps the notification templates admins manage
+type TemplateStore interface {
+	// ListTemplates returns the templates matching req by type, channel and locale
+	ListTemplates(ctx context.Context, req ListTemplatesRequest) ([]NotificationTemplate, error)
+	// ListActiveTemplates returns every active template, for rendering
+	ListActiveTemplates(ctx context.Context) ([]NotificationTemplate, error)
+	GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error)
+	// FindTemplate returns the template of a type on a channel in a language
+	FindTemplate(ctx context.Context, key templateKey) (*NotificationTemplate, error)
+	// CreateTemplate stores a new template and audits it
+	CreateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error)
+	// UpdateTemplate stores the subject, body and state of a template and
+	// audits the change
+	UpdateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error)
+	// DeleteTemplate removes a template and audits the removal
+	DeleteTemplate(ctx context.Context, id, adminID string) error
+}
+
+// SetTemplates enables managing the notification templates in store
+func (s *Service) SetTemplates(store TemplateStore) {
+	s.templates = store
+}
+
+// ListTemplates returns the templates admins manage, optionally of one type,
+// channel or locale
+func (s *Service) ListTemplates(ctx context.Context, req ListTemplatesRequest) (*ListTemplatesResponse, error) {
+	if err := s.templatesEnabled(); err != nil {
+		return nil, err
+	}
+	if req.Channel != "" && !templateChannels[NotificationChannel(req.Channel)] {
+		return nil, fmt.Errorf("%w: unknown channel %q", common.ErrValidation, req.Channel)
+	}
+	templates, err := s.templates.ListTemplates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &ListTemplatesResponse{Templates: templates, Total: len(templates)}, nil
+}
+
+// GetTemplate returns a notification template
+func (s *Service) GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error) {
+	if err := s.templatesEnabled(); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("notification template %s: %w", id, common.ErrNotFound)
+	}
+	return s.templates.GetTemplate(ctx, id)
+}
+
+// CreateTemplate stores the template of a type on a channel in a language.
+// Each may only have one.
+func (s *Service) CreateTemplate(ctx context.Context, adminID string, req CreateTemplateRequest) (*NotificationTemplate, error) {
+	if err := s.templatesEnabled(); err != nil {
+		return nil, err
+	}
+	if !templateTypePattern.MatchString(string(req.Type)) {
+		return nil, fmt.Errorf("%w: type must be a lowercase identifier such as conversion_completed", common.ErrValidation)
+	}
+	if err := validateTemplateKey(req.Channel, req.Locale); err != nil {
+		return nil, err
+	}
+	tmpl := NotificationTemplate{
+		Type:      req.Type,
+		Channel:   req.Channel,
+		Locale:    req.Locale,
+		Subject:   strings.TrimSpace(req.Subject),
+		Body:      req.Body,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+		UpdatedBy: &adminID,
+	}
+	if err := validateTemplate(tmpl); err != nil {
+		return nil, err
+	}
+
+	created, err := s.templates.CreateTemplate(ctx, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTemplates()
+	return created, nil
+}
+
+// UpdateTemplate changes the subject, body or state of a template
+func (s *Service) UpdateTemplate(ctx context.Context, adminID, id string, req UpdateTemplateRequest) (*NotificationTemplate, error) {
+	tmpl, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Subject != nil {
+		tmpl.Subject = strings.TrimSpace(*req.Subject)
+	}
+	if req.Body != nil {
+		tmpl.Body = *req.Body
+	}
+	if req.IsActive != nil {
+		tmpl.IsActive = *req.IsActive
+	}
+	tmpl.UpdatedBy = &adminID
+	if err := validateTemplate(*tmpl); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.templates.UpdateTemplate(ctx, *tmpl)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTemplates()
+	return updated, nil
+}
+
+// DeleteTemplate removes a template; its notifications go back to the
+// built-in template
+func (s *Service) DeleteTemplate(ctx context.Context, adminID, id string) error {
+	if _, err := s.GetTemplate(ctx, id); err != nil {
+		return err
+	}
+	if err := s.templates.DeleteTemplate(ctx, id, adminID); err != nil {
+		return err
+	}
+	s.invalidateTemplates()
+	return nil
+}
+
+// PreviewTemplate renders a template with a sample notification of the type.
+// The template of the request is rendered as is, with its errors reported;
+// the stored and built-in templates are rendered the way notifications are
+// sent, falling back to the plain title and message like sending does.
+func (s *Service) PreviewTemplate(ctx context.Context, req PreviewTemplateRequest) (*PreviewTemplateResponse, error) {
+	if err := s.templatesEnabled(); err != nil {
+		return nil, err
+	}
+	if req.Locale == "" {
+		req.Locale = locale.DefaultLanguage
+	}
+	if err := validateTemplateKey(req.Channel, req.Locale); err != nil {
+		return nil, err
+	}
+	notification := sampleNotification(req.Type, req.Locale, req.Data)
+	data := map[string]interface{}{"notification": notification}
+
+	if req.Body != "" {
+		tmpl := NotificationTemplate{Type: req.Type, Channel: req.Channel, Locale: req.Locale, Subject: req.Subject, Body: req.Body}
+		if err := validateTemplate(tmpl); err != nil {
+			return nil, err
+		}
+		subject, body, err := renderTemplate(req.Channel, tmpl.Subject, tmpl.Body, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", common.ErrValidation, err)
+		}
+		return &PreviewTemplateResponse{Source: PreviewSourceRequest, Subject: subject, Body: body}, nil
+	}
+
+	stored, err := s.templates.FindTemplate(ctx, templateKey{req.Type, req.Channel, req.Locale})
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return nil, err
+	}
+	if stored != nil && stored.IsActive {
+		subject, body, err := renderTemplate(req.Channel, stored.Subject, stored.Body, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", common.ErrValidation, err)
+		}
+		return &PreviewTemplateResponse{Source: PreviewSourceStored, Subject: subject, Body: body}, nil
+	}
+
+	preview := &PreviewTemplateResponse{Source: PreviewSourceBuiltin}
+	builtin := NewTemplateEngine()
+	switch req.Channel {
+	case ChannelEmail:
+		if preview.Subject, preview.Body, err = builtin.ProcessEmailTemplate(string(req.Type), data); err != nil {
+			preview.Subject, preview.Body = notification.Title, notification.Message
+		}
+	case ChannelSMS:
+		if preview.Body, err = builtin.ProcessSMSTemplate(string(req.Type), data); err != nil {
+			preview.Body = notification.Message
+		}
+	case ChannelTelegram:
+		if preview.Body, err = builtin.ProcessTelegramTemplate(string(req.Type), data); err != nil {
+			preview.Body = fmt.Sprintf("*%s*\n\n%s", notification.Title, notification.Message)
+		}
+	}
+	return preview, nil
+}
+
+func (s *Service) templatesEnabled() error {
+	if s.templates == nil {
+		return fmt.Errorf("notification templates: %w", common.ErrNotFound)
+	}
+	return nil
+}
+
+// invalidateTemplates makes this instance render the changed templates at once
+func (s *Service) invalidateTemplates() {
+	if engine, ok := s.templateEngine.(*TemplateEngineImpl); ok {
+		engine.invalidate()
+	}
+}
+
+func validateTemplateKey(channel NotificationChannel, lang string) error {
+	if !templateChannels[channel] {
+		return fmt.Errorf("%w: channel must be email, sms or telegram", common.ErrValidation)
+	}
+	if lang != locale.LangPersian && lang != locale.LangEnglish {
+		return fmt.Errorf("%w: locale must be %s or %s", common.ErrValidation, locale.LangPersian, locale.LangEnglish)
+	}
+	return nil
+}
+
+// validateTemplate checks the bounds of a template and that it parses
+func validateTemplate(tmpl NotificationTemplate) error {
+	if strings.TrimSpace(tmpl.Body) == "" {
+		return fmt.Errorf("%w: body is required", common.ErrValidation)
+	}
+	if len(tmpl.Body) > maxTemplateBodyLength {
+		return fmt.Errorf("%w: body must be at most %d bytes", common.ErrValidation, maxTemplateBodyLength)
+	}
+
+	var err error
+	if tmpl.Channel == ChannelEmail {
+		if tmpl.Subject == "" {
+			return fmt.Errorf("%w: subject is required for email", common.ErrValidation)
+		}
+		if len(tmpl.Subject) > maxTemplateSubjectLength {
+			return fmt.Errorf("%w: subject must be at most %d bytes", common.ErrValidation, maxTemplateSubjectLength)
+		}
+		if _, err := template.New("subject").Parse(tmpl.Subject); err != nil {
+			return fmt.Errorf("%w: invalid subject: %v", common.ErrValidation, err)
+		}
+		_, err = htmltemplate.New("body").Parse(tmpl.Body)
+	} else {
+		if tmpl.Subject != "" {
+			return fmt.Errorf("%w: only email templates have a subject", common.ErrValidation)
+		}
+		_, err = template.New("body").Parse(tmpl.Body)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: invalid body: %v", common.ErrValidation, err)
+	}
+	return nil
+}
+
+// sampleNotification returns a notification of notificationType in lang
+// with data, titled and worded like real ones with "…" for the values
+func sampleNotification(notificationType NotificationType, lang string, data map[string]interface{}) Notification {
+	sampleData := map[string]interface{}{}
+	for key, value := range data {
+		sampleData[key] = value
+	}
+	sampleData["language"] = lang
+
+	message := messages.Text(lang, string(notificationType)+messageKeySuffix)
+	if verbs := strings.Count(message, "%s"); verbs > 0 {
+		args := make([]interface{}, verbs)
+		for i := range args {
+			args[i] = "…"
+		}
+		message = fmt.Sprintf(message, args...)
+	}
+
+	userID := uuid.Nil.String()
+	return Notification{
+		ID:        uuid.Nil.String(),
+		UserID:    &userID,
+		Type:      notificationType,
+		Title:     messages.Text(lang, string(notificationType)+titleKeySuffix),
+		Message:   message,
+		Data:      sampleData,
+		Priority:  PriorityNormal,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// dbTemplateStore keeps notification templates in PostgreSQL
+type dbTemplateStore struct {
+	db *sql.DB
+}
+
+// NewTemplateStore creates a PostgreSQL-backed notification template store
+func NewTemplateStore(db *sql.DB) TemplateStore {
+	return &dbTemplateStore{db: db}
+}
+
+const templateColumns = `id, type, channel, locale, subject, body, variables, is_active, updated_by, created_at, updated_at`
+
+func (s *dbTemplateStore) ListTemplates(ctx context.Context, req ListTemplatesRequest) ([]NotificationTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM notification_templates WHERE TRUE`
+	args := []interface{}{}
+	if req.Type != "" {
+		args = append(args, req.Type)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	if req.Channel != "" {
+		args = append(args, req.Channel)
+		query += fmt.Sprintf(" AND channel = $%d", len(args))
+	}
+	if req.Locale != "" {
+		args = append(args, req.Locale)
+		query += fmt.Sprintf(" AND locale = $%d", len(args))
+	}
+	return s.query(ctx, query+` ORDER BY type, channel, locale`, args...)
+}
+
+func (s *dbTemplateStore) ListActiveTemplates(ctx context.Context) ([]NotificationTemplate, error) {
+	return s.query(ctx, `SELECT `+templateColumns+` FROM notification_templates WHERE is_active`)
+}
+
+func (s *dbTemplateStore) GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error) {
+	tmpl, err := scanTemplate(s.db.QueryRowContext(ctx, `
+		SELECT `+templateColumns+` FROM notification_templates WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("notification template %s: %w", id, common.ErrNotFound)
+	}
+	return tmpl, err
+}
+
+func (s *dbTemplateStore) FindTemplate(ctx context.Context, key templateKey) (*NotificationTemplate, error) {
+	tmpl, err := scanTemplate(s.db.QueryRowContext(ctx, `
+		SELECT `+templateColumns+` FROM notification_templates
+		WHERE type = $1 AND channel = $2 AND locale = $3`, key.Type, key.Channel, key.Locale))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("notification template %s/%s/%s: %w", key.Type, key.Channel, key.Locale, common.ErrNotFound)
+	}
+	return tmpl, err
+}
+
+func (s *dbTemplateStore) CreateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := scanTemplate(tx.QueryRowContext(ctx, `
+		INSERT INTO notification_templates (type, channel, locale, subject, body, is_active, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+templateColumns,
+		tmpl.Type, tmpl.Channel, tmpl.Locale, tmpl.Subject, tmpl.Body, tmpl.IsActive, tmpl.UpdatedBy))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%w: %s already has a %s template in %s", common.ErrConflict, tmpl.Type, tmpl.Channel, tmpl.Locale)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auditTemplate(ctx, tx, *tmpl.UpdatedBy, "notification_template_create", *created); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+func (s *dbTemplateStore) UpdateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := scanTemplate(tx.QueryRowContext(ctx, `
+		UPDATE notification_templates
+		SET subject = $2, body = $3, is_active = $4, updated_by = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+templateColumns,
+		tmpl.ID, tmpl.Subject, tmpl.Body, tmpl.IsActive, tmpl.UpdatedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("notification template %s: %w", tmpl.ID, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auditTemplate(ctx, tx, *tmpl.UpdatedBy, "notification_template_update", *updated); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *dbTemplateStore) DeleteTemplate(ctx context.Context, id, adminID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted, err := scanTemplate(tx.QueryRowContext(ctx, `
+		DELETE FROM notification_templates WHERE id = $1 RETURNING `+templateColumns, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("notification template %s: %w", id, common.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := auditTemplate(ctx, tx, adminID, "notification_template_delete", *deleted); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTemplateStore) query(ctx context.Context, query string, args ...interface{}) ([]NotificationTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []NotificationTemplate{}
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	return templates, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTemplate(row rowScanner) (*NotificationTemplate, error) {
+	var tmpl NotificationTemplate
+	var updatedBy sql.NullString
+	err := row.Scan(&tmpl.ID, &tmpl.Type, &tmpl.Channel, &tmpl.Locale, &tmpl.Subject, &tmpl.Body,
+		pq.Array(&tmpl.Variables), &tmpl.IsActive, &updatedBy, &tmpl.CreatedAt, &tmpl.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notification template: %w", err)
+	}
+	if updatedBy.Valid {
+		tmpl.UpdatedBy = &updatedBy.String
+	}
+	return &tmpl, nil
+}
+
+// auditTemplate records an admin's change of a template in the audit log
+func auditTemplate(ctx context.Context, tx *sql.Tx, adminID, action string, tmpl NotificationTemplate) error {
+	metadata, err := json.Marshal(map[string]interface{}{
+		"template_id": tmpl.ID,
+		"type":        tmpl.Type,
+		"channel":     tmpl.Channel,
+		"locale":      tmpl.Locale,
+		"is_active":   tmpl.IsActive,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'notification_template', $3)`, adminID, action, metadata); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
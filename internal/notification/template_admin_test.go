@@ -0,0 +1,262 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// fakeTemplateStore keeps templates in memory and counts reads for rendering
+type fakeTemplateStore struct {
+	templates []NotificationTemplate
+	reads     int
+}
+
+func (f *fakeTemplateStore) ListTemplates(ctx context.Context, req ListTemplatesRequest) ([]NotificationTemplate, error) {
+	return f.templates, nil
+}
+
+func (f *fakeTemplateStore) ListActiveTemplates(ctx context.Context) ([]NotificationTemplate, error) {
+	f.reads++
+	var active []NotificationTemplate
+	for _, tmpl := range f.templates {
+		if tmpl.IsActive {
+			active = append(active, tmpl)
+		}
+	}
+	return active, nil
+}
+
+func (f *fakeTemplateStore) GetTemplate(ctx context.Context, id string) (*NotificationTemplate, error) {
+	for _, tmpl := range f.templates {
+		if tmpl.ID == id {
+			return &tmpl, nil
+		}
+	}
+	return nil, fmt.Errorf("notification template %s: %w", id, common.ErrNotFound)
+}
+
+func (f *fakeTemplateStore) FindTemplate(ctx context.Context, key templateKey) (*NotificationTemplate, error) {
+	for _, tmpl := range f.templates {
+		if tmpl.key() == key {
+			return &tmpl, nil
+		}
+	}
+	return nil, fmt.Errorf("notification template: %w", common.ErrNotFound)
+}
+
+func (f *fakeTemplateStore) CreateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error) {
+	tmpl.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(f.templates)+1)
+	f.templates = append(f.templates, tmpl)
+	return &tmpl, nil
+}
+
+func (f *fakeTemplateStore) UpdateTemplate(ctx context.Context, tmpl NotificationTemplate) (*NotificationTemplate, error) {
+	for i := range f.templates {
+		if f.templates[i].ID == tmpl.ID {
+			f.templates[i] = tmpl
+			return &tmpl, nil
+		}
+	}
+	return nil, common.ErrNotFound
+}
+
+func (f *fakeTemplateStore) DeleteTemplate(ctx context.Context, id, adminID string) error {
+	for i := range f.templates {
+		if f.templates[i].ID == id {
+			f.templates = append(f.templates[:i], f.templates[i+1:]...)
+			return nil
+		}
+	}
+	return common.ErrNotFound
+}
+
+func templateData(lang string) map[string]interface{} {
+	return map[string]interface{}{
+		"notification": Notification{
+			Type:    NotificationTypeConversionCompleted,
+			Title:   "Done",
+			Message: "Your conversion is ready",
+			Data:    map[string]interface{}{"language": lang, "conversionId": "conv-1"},
+		},
+	}
+}
+
+func TestTemplateEngine_ManagedTemplatePerLocale(t *testing.T) {
+	store := &fakeTemplateStore{templates: []NotificationTemplate{{
+		ID:       "tmpl-1",
+		Type:     NotificationTypeConversionCompleted,
+		Channel:  ChannelSMS,
+		Locale:   "en",
+		Body:     "{{.notification.Title}}: {{.notification.Data.conversionId}}",
+		IsActive: true,
+	}}}
+	engine := NewStoredTemplateEngine(store, time.Minute)
+
+	message, err := engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	if err != nil {
+		t.Fatalf("ProcessSMSTemplate() error = %v", err)
+	}
+	if message != "Done: conv-1" {
+		t.Errorf("managed message = %q, want %q", message, "Done: conv-1")
+	}
+
+	builtin, _ := NewTemplateEngine().ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("fa"))
+	message, _ = engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("fa"))
+	if message != builtin {
+		t.Errorf("message without a template in the locale = %q, want the built-in %q", message, builtin)
+	}
+}
+
+func TestTemplateEngine_BrokenTemplateFallsBack(t *testing.T) {
+	store := &fakeTemplateStore{templates: []NotificationTemplate{{
+		ID:       "tmpl-1",
+		Type:     NotificationTypeConversionCompleted,
+		Channel:  ChannelSMS,
+		Locale:   "en",
+		Body:     "{{.notification.Missing}}",
+		IsActive: true,
+	}}}
+	engine := NewStoredTemplateEngine(store, time.Minute)
+
+	builtin, _ := NewTemplateEngine().ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	message, _ := engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	if message != builtin {
+		t.Errorf("message = %q, want the built-in %q", message, builtin)
+	}
+}
+
+func TestTemplateEngine_CachesUntilExpired(t *testing.T) {
+	store := &fakeTemplateStore{}
+	engine := NewStoredTemplateEngine(store, time.Minute)
+	now := time.Now()
+	engine.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	}
+	if store.reads != 1 {
+		t.Errorf("store read %d times, want 1", store.reads)
+	}
+
+	now = now.Add(2 * time.Minute)
+	engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	if store.reads != 2 {
+		t.Errorf("store read %d times after expiry, want 2", store.reads)
+	}
+}
+
+func TestService_CreateTemplateRendersAtOnce(t *testing.T) {
+	store := &fakeTemplateStore{}
+	engine := NewStoredTemplateEngine(store, time.Hour)
+	service := &Service{templateEngine: engine}
+	service.SetTemplates(store)
+
+	engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+
+	_, err := service.CreateTemplate(context.Background(), "admin-1", CreateTemplateRequest{
+		Type:    NotificationTypeConversionCompleted,
+		Channel: ChannelSMS,
+		Locale:  "en",
+		Body:    "Ready: {{.notification.Data.conversionId}}",
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	message, _ := engine.ProcessSMSTemplate(string(NotificationTypeConversionCompleted), templateData("en"))
+	if message != "Ready: conv-1" {
+		t.Errorf("message after create = %q, want %q", message, "Ready: conv-1")
+	}
+}
+
+func TestService_CreateTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CreateTemplateRequest
+	}{
+		{"unknown channel", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelPush, Locale: "en", Body: "x"}},
+		{"unknown locale", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelSMS, Locale: "de", Body: "x"}},
+		{"invalid type", CreateTemplateRequest{Type: "Quota Warning", Channel: ChannelSMS, Locale: "en", Body: "x"}},
+		{"email without subject", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelEmail, Locale: "en", Body: "x"}},
+		{"sms with subject", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelSMS, Locale: "en", Subject: "s", Body: "x"}},
+		{"unparsable body", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelSMS, Locale: "en", Body: "{{.notification"}},
+		{"body too long", CreateTemplateRequest{Type: "quota_warning", Channel: ChannelSMS, Locale: "en", Body: strings.Repeat("x", maxTemplateBodyLength+1)}},
+	}
+
+	service := &Service{}
+	service.SetTemplates(&fakeTemplateStore{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTemplate(context.Background(), "admin-1", tt.req)
+			if !errors.Is(err, common.ErrValidation) {
+				t.Errorf("CreateTemplate() error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestService_TemplatesDisabled(t *testing.T) {
+	_, err := (&Service{}).ListTemplates(context.Background(), ListTemplatesRequest{})
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("ListTemplates() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_PreviewTemplate(t *testing.T) {
+	store := &fakeTemplateStore{templates: []NotificationTemplate{{
+		ID:       "tmpl-1",
+		Type:     NotificationTypeConversionCompleted,
+		Channel:  ChannelSMS,
+		Locale:   "en",
+		Body:     "Stored {{.notification.Data.conversionId}}",
+		IsActive: true,
+	}}}
+	service := &Service{}
+	service.SetTemplates(store)
+	ctx := context.Background()
+	data := map[string]interface{}{"conversionId": "conv-9"}
+
+	preview, err := service.PreviewTemplate(ctx, PreviewTemplateRequest{
+		Type: NotificationTypeConversionCompleted, Channel: ChannelEmail, Locale: "en",
+		Subject: "{{.notification.Title}}", Body: "<p>{{.notification.Data.conversionId}}</p>", Data: data,
+	})
+	if err != nil {
+		t.Fatalf("PreviewTemplate() error = %v", err)
+	}
+	if preview.Source != PreviewSourceRequest || preview.Body != "<p>conv-9</p>" || preview.Subject == "" {
+		t.Errorf("request preview = %+v", preview)
+	}
+
+	preview, err = service.PreviewTemplate(ctx, PreviewTemplateRequest{
+		Type: NotificationTypeConversionCompleted, Channel: ChannelSMS, Locale: "en", Data: data,
+	})
+	if err != nil {
+		t.Fatalf("PreviewTemplate() error = %v", err)
+	}
+	if preview.Source != PreviewSourceStored || preview.Body != "Stored conv-9" {
+		t.Errorf("stored preview = %+v", preview)
+	}
+
+	preview, err = service.PreviewTemplate(ctx, PreviewTemplateRequest{
+		Type: NotificationTypeConversionCompleted, Channel: ChannelSMS, Locale: "fa", Data: data,
+	})
+	if err != nil {
+		t.Fatalf("PreviewTemplate() error = %v", err)
+	}
+	if preview.Source != PreviewSourceBuiltin || preview.Body == "" {
+		t.Errorf("built-in preview = %+v", preview)
+	}
+
+	_, err = service.PreviewTemplate(ctx, PreviewTemplateRequest{
+		Type: NotificationTypeConversionCompleted, Channel: ChannelSMS, Locale: "en", Body: "{{.notification.Missing}}",
+	})
+	if !errors.Is(err, common.ErrValidation) {
+		t.Errorf("PreviewTemplate() of a failing template error = %v, want ErrValidation", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"sync"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -125,13 +127,13 @@ func (w *WebSocketProviderImpl) HandleWebSocket(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	_, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	// This is a simplified implementation
 	// In production, you'd upgrade the HTTP connection to WebSocket
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "WebSocket not implemented"})
+	common.RespondError(c, http.StatusNotImplemented, "WebSocket not implemented")
 }
 
 // sendMessage sends a message through a WebSocket connection
@@ -2,24 +2,45 @@ package notification
 
 import (
 	"database/sql"
+	"log"
+
+	"ai-styler/internal/config"
 )
 
+// NewDispatcherConfig converts the queue settings of the configuration
+func NewDispatcherConfig(cfg config.NotificationQueueConfig) DispatcherConfig {
+	rates := make(map[NotificationChannel]float64, len(cfg.Rates))
+	for channel, rate := range cfg.Rates {
+		rates[NotificationChannel(channel)] = rate
+	}
+	return DispatcherConfig{
+		QueueSize:    cfg.Size,
+		Workers:      cfg.Workers,
+		EnqueueWait:  cfg.EnqueueWait,
+		Rates:        rates,
+		SMSBatchSize: cfg.SMSBatchSize,
+	}
+}
+
 // WireNotificationService creates a notification service with all dependencies
-func WireNotificationService(db *sql.DB) (*Service, *Handler) {
+func WireNotificationService(db *sql.DB, cfg *config.Config) (*Service, *Handler) {
 	// Create store
 	store := NewStore(db)
 
 	// Create config first
 	emailConfig := EmailConfig{
-		Enabled:      true,
-		SMTPHost:     "smtp.gmail.com",
-		SMTPPort:     587,
-		SMTPUsername: "noreply@aistyler.com",
-		SMTPPassword: "your-email-password",
-		FromEmail:    "noreply@aistyler.com",
-		FromName:     "AI Styler",
-		Username:     "noreply@aistyler.com",
-		Password:     "your-email-password",
+		Enabled:        cfg.Email.Provider != "",
+		Provider:       cfg.Email.Provider,
+		SMTPHost:       cfg.Email.SMTPHost,
+		SMTPPort:       cfg.Email.SMTPPort,
+		SMTPUsername:   cfg.Email.SMTPUsername,
+		SMTPPassword:   cfg.Email.SMTPPassword,
+		SMTPPoolSize:   cfg.Email.SMTPPoolSize,
+		SendGridAPIKey: cfg.Email.SendGridAPIKey,
+		SendGridAPIURL: cfg.Email.SendGridAPIURL,
+		FromEmail:      cfg.Email.FromAddress,
+		FromName:       cfg.Email.FromName,
+		Timeout:        cfg.Email.Timeout,
 	}
 
 	smsConfig := SMSConfig{
@@ -51,15 +72,17 @@ func WireNotificationService(db *sql.DB) (*Service, *Handler) {
 		MaxDelay:   30000, // 30 seconds
 	}
 
+	// Create template engine; templates admins manage take precedence over
+	// the built-in ones
+	templates := NewTemplateStore(db)
+	templateEngine := NewStoredTemplateEngine(templates, cfg.NotifyTemplates.CacheTTL)
+
 	// Create providers with config
-	emailProvider := NewEmailProvider(emailConfig)
+	emailProvider := NewEmailProvider(emailConfig, templateEngine)
 	smsProvider := NewSMSProvider(smsConfig)
 	telegramProvider := NewTelegramProvider(telegramConfig)
 	websocketProvider := NewWebSocketProvider(websocketConfig)
 
-	// Create template engine
-	templateEngine := NewTemplateEngine()
-
 	// Create services
 	quotaService := NewQuotaService()
 	userService := NewUserService()
@@ -101,6 +124,23 @@ func WireNotificationService(db *sql.DB) (*Service, *Handler) {
 		retryHandler,
 		config,
 	)
+	service.SetTemplates(templates)
+	service.SetInbox(NewInboxStore(db))
+
+	// Push notifications are delivered through FCM when a service account is configured
+	if cfg.Push.FCMCredentialsFile != "" {
+		pushProvider, err := NewFCMProvider(FCMConfig{
+			ProjectID:       cfg.Push.FCMProjectID,
+			CredentialsFile: cfg.Push.FCMCredentialsFile,
+			APIURL:          cfg.Push.FCMAPIURL,
+			Timeout:         cfg.Push.Timeout,
+		})
+		if err != nil {
+			log.Printf("Push notifications disabled: %v", err)
+		} else {
+			service.SetPush(pushProvider, NewDeviceStore(db))
+		}
+	}
 
 	// Create handler
 	handler := NewHandler(service)
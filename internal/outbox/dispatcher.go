@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Publisher delivers outbox events to a downstream consumer.
+// Delivery is at-least-once, so publishers must tolerate duplicates.
+type Publisher interface {
+	Name() string
+	Handles(eventType string) bool
+	Publish(ctx context.Context, event Event) error
+}
+
+// Dispatcher polls the outbox and publishes pending events
+type Dispatcher struct {
+	config     Config
+	store      Store
+	publishers []Publisher
+	now        func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewDispatcher creates a new outbox dispatcher
+func NewDispatcher(config Config, store Store, publishers ...Publisher) *Dispatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 5 * time.Second
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = time.Hour
+	}
+	if config.LeaseTimeout <= 0 {
+		config.LeaseTimeout = time.Minute
+	}
+
+	return &Dispatcher{
+		config:     config,
+		store:      store,
+		publishers: publishers,
+		now:        time.Now,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts the dispatch loop in the background
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+// Stop stops the dispatch loop and waits for the current batch to finish
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		close(d.stop)
+		<-d.done
+	})
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), d.config.LeaseTimeout)
+		// Drain backlogs batch by batch before waiting for the next tick
+		for {
+			claimed, err := d.DispatchBatch(ctx)
+			if err != nil {
+				log.Printf("Failed to dispatch outbox events: %v", err)
+				break
+			}
+			if claimed < d.config.BatchSize {
+				break
+			}
+		}
+		cancel()
+
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch claims one batch of due events and publishes them.
+// It returns the number of claimed events.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	events, err := d.store.Claim(ctx, d.config.BatchSize, d.config.LeaseTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := d.publish(ctx, event); err != nil {
+			dead := event.Attempts >= d.config.MaxAttempts
+			if dead {
+				log.Printf("Outbox event %s (%s) is dead after %d attempts: %v", event.ID, event.EventType, event.Attempts, err)
+			}
+			if markErr := d.store.MarkFailed(ctx, event.ID, err.Error(), d.now().Add(d.retryDelay(event.Attempts)), dead); markErr != nil {
+				return len(events), markErr
+			}
+			continue
+		}
+
+		if err := d.store.MarkPublished(ctx, event.ID); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+// publish sends the event to every publisher that handles its type
+func (d *Dispatcher) publish(ctx context.Context, event Event) error {
+	for _, publisher := range d.publishers {
+		if !publisher.Handles(event.EventType) {
+			continue
+		}
+		if err := publisher.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", publisher.Name(), err)
+		}
+	}
+	return nil
+}
+
+// retryDelay returns the exponential backoff after the given attempt
+func (d *Dispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.config.RetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.config.MaxRetryDelay {
+			return d.config.MaxRetryDelay
+		}
+	}
+	return delay
+}
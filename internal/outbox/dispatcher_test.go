@@ -0,0 +1,210 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	events    []Event
+	published map[string]bool
+	failed    map[string]string
+	dead      map[string]bool
+	next      map[string]time.Time
+}
+
+func newMemoryStore(events ...Event) *memoryStore {
+	return &memoryStore{
+		events:    events,
+		published: make(map[string]bool),
+		failed:    make(map[string]string),
+		dead:      make(map[string]bool),
+		next:      make(map[string]time.Time),
+	}
+}
+
+func (s *memoryStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	var claimed []Event
+	for i := range s.events {
+		event := &s.events[i]
+		if s.published[event.ID] || s.dead[event.ID] || len(claimed) >= limit {
+			continue
+		}
+		event.Attempts++
+		claimed = append(claimed, *event)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkPublished(ctx context.Context, eventID string) error {
+	s.published[eventID] = true
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(ctx context.Context, eventID string, errMsg string, nextAttemptAt time.Time, dead bool) error {
+	s.failed[eventID] = errMsg
+	s.dead[eventID] = dead
+	s.next[eventID] = nextAttemptAt
+	return nil
+}
+
+type recordingNotifier struct {
+	calls []string
+	err   error
+}
+
+func (n *recordingNotifier) SendConversionStarted(ctx context.Context, userID, conversionID string) error {
+	n.calls = append(n.calls, "started:"+userID+":"+conversionID)
+	return n.err
+}
+
+func (n *recordingNotifier) SendConversionCompleted(ctx context.Context, userID, conversionID, resultImageID string) error {
+	n.calls = append(n.calls, "completed:"+conversionID+":"+resultImageID)
+	return n.err
+}
+
+func (n *recordingNotifier) SendConversionFailed(ctx context.Context, userID, conversionID, errorMessage string) error {
+	n.calls = append(n.calls, "failed:"+conversionID+":"+errorMessage)
+	return n.err
+}
+
+func conversionEvent(id, eventType string, payload ConversionEventPayload) Event {
+	event := NewConversionEvent(eventType, payload)
+	event.ID = id
+	return event
+}
+
+func TestDispatcher_PublishesToNotifier(t *testing.T) {
+	store := newMemoryStore(
+		conversionEvent("e1", EventConversionStarted, ConversionEventPayload{UserID: "u1", ConversionID: "c1"}),
+		conversionEvent("e2", EventConversionCompleted, ConversionEventPayload{UserID: "u1", ConversionID: "c1", ResultImageID: "img"}),
+		conversionEvent("e3", EventConversionFailed, ConversionEventPayload{UserID: "u1", ConversionID: "c2", ErrorMessage: "boom"}),
+	)
+	notifier := &recordingNotifier{}
+	dispatcher := NewDispatcher(Config{}, store, NewNotificationPublisher(notifier))
+
+	claimed, err := dispatcher.DispatchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if claimed != 3 {
+		t.Errorf("Expected 3 claimed events, got %d", claimed)
+	}
+
+	expected := []string{"started:u1:c1", "completed:c1:img", "failed:c2:boom"}
+	if len(notifier.calls) != len(expected) {
+		t.Fatalf("Expected %d notifications, got %v", len(expected), notifier.calls)
+	}
+	for i, call := range expected {
+		if notifier.calls[i] != call {
+			t.Errorf("Expected notification %q, got %q", call, notifier.calls[i])
+		}
+	}
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if !store.published[id] {
+			t.Errorf("Expected event %s to be published", id)
+		}
+	}
+}
+
+func TestDispatcher_RetriesWithBackoffThenDies(t *testing.T) {
+	store := newMemoryStore(conversionEvent("e1", EventConversionStarted, ConversionEventPayload{UserID: "u1", ConversionID: "c1"}))
+	notifier := &recordingNotifier{err: errors.New("telegram down")}
+	dispatcher := NewDispatcher(Config{MaxAttempts: 3, RetryDelay: time.Second, MaxRetryDelay: 3 * time.Second},
+		store, NewNotificationPublisher(notifier))
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+
+	expectedDelays := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for attempt, delay := range expectedDelays {
+		if _, err := dispatcher.DispatchBatch(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if store.published["e1"] {
+			t.Fatal("Expected event not to be published")
+		}
+		if got := store.next["e1"].Sub(now); got != delay {
+			t.Errorf("Attempt %d: expected retry delay %v, got %v", attempt+1, delay, got)
+		}
+		if dead := attempt+1 >= 3; store.dead["e1"] != dead {
+			t.Errorf("Attempt %d: expected dead=%v, got %v", attempt+1, dead, store.dead["e1"])
+		}
+	}
+	if store.failed["e1"] != "notification: telegram down" {
+		t.Errorf("Expected last error to be recorded, got %q", store.failed["e1"])
+	}
+
+	// Dead events are no longer claimed
+	claimed, _ := dispatcher.DispatchBatch(context.Background())
+	if claimed != 0 {
+		t.Errorf("Expected dead event not to be claimed, got %d", claimed)
+	}
+}
+
+func TestDispatcher_SkipsPublishersThatDoNotHandleEvent(t *testing.T) {
+	store := newMemoryStore(Event{ID: "e1", EventType: "payment.completed", Payload: []byte(`{}`)})
+	notifier := &recordingNotifier{err: errors.New("should not be called")}
+	dispatcher := NewDispatcher(Config{}, store, NewNotificationPublisher(notifier))
+
+	if _, err := dispatcher.DispatchBatch(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(notifier.calls) != 0 {
+		t.Errorf("Expected no notifications, got %v", notifier.calls)
+	}
+	if !store.published["e1"] {
+		t.Error("Expected unhandled event to be marked published")
+	}
+}
+
+func TestWebhookPublisher_SignsBody(t *testing.T) {
+	var signature, eventType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(WebhookSignatureHeader)
+		eventType = r.Header.Get(WebhookEventTypeHeader)
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher([]string{server.URL}, "secret", time.Second)
+	event := conversionEvent("e1", EventConversionCompleted, ConversionEventPayload{UserID: "u1", ConversionID: "c1"})
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if eventType != EventConversionCompleted {
+		t.Errorf("Expected event type header %s, got %s", EventConversionCompleted, eventType)
+	}
+	if expected := "sha256=" + SignWebhook("secret", body); signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, signature)
+	}
+}
+
+func TestWebhookPublisher_FailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher([]string{server.URL}, "", time.Second)
+	if err := publisher.Publish(context.Background(), Event{ID: "e1", EventType: EventConversionStarted}); err == nil {
+		t.Error("Expected error for 502 response")
+	}
+}
+
+func TestParseWebhookURLs(t *testing.T) {
+	urls := ParseWebhookURLs(" https://a.example/hook, ,https://b.example/hook ")
+	if len(urls) != 2 || urls[0] != "https://a.example/hook" || urls[1] != "https://b.example/hook" {
+		t.Errorf("Expected two trimmed URLs, got %v", urls)
+	}
+	if urls := ParseWebhookURLs(""); len(urls) != 0 {
+		t.Errorf("Expected no URLs, got %v", urls)
+	}
+}
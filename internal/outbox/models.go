@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event statuses
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published"
+	StatusDead      = "dead"
+)
+
+// Aggregate types
+const (
+	AggregateConversion = "conversion"
+)
+
+// Event types published through the outbox
+const (
+	EventConversionStarted   = "conversion.started"
+	EventConversionCompleted = "conversion.completed"
+	EventConversionFailed    = "conversion.failed"
+)
+
+// Event is a cross-module event stored in the outbox
+type Event struct {
+	ID            string          `json:"id"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// ConversionEventPayload is the payload of conversion.* events
+type ConversionEventPayload struct {
+	UserID        string `json:"userId"`
+	ConversionID  string `json:"conversionId"`
+	ResultImageID string `json:"resultImageId,omitempty"`
+	ErrorMessage  string `json:"errorMessage,omitempty"`
+}
+
+// NewEvent creates an event with a JSON encoded payload
+func NewEvent(aggregateType, aggregateID, eventType string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	return Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	}, nil
+}
+
+// NewConversionEvent creates a conversion.* event
+func NewConversionEvent(eventType string, payload ConversionEventPayload) Event {
+	// ConversionEventPayload only holds strings, so marshalling cannot fail
+	event, _ := NewEvent(AggregateConversion, payload.ConversionID, eventType, payload)
+	return event
+}
+
+// Config represents configuration for the outbox dispatcher
+type Config struct {
+	Enabled       bool
+	PollInterval  time.Duration
+	BatchSize     int
+	MaxAttempts   int           // attempts before an event is marked dead
+	RetryDelay    time.Duration // first retry delay, doubled per attempt
+	MaxRetryDelay time.Duration
+	LeaseTimeout  time.Duration // how long a claimed event is hidden from other dispatchers
+}
@@ -0,0 +1,173 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook request headers
+const (
+	WebhookEventIDHeader   = "X-Outbox-Event-ID"
+	WebhookEventTypeHeader = "X-Outbox-Event-Type"
+	WebhookSignatureHeader = "X-Outbox-Signature"
+)
+
+// ConversionNotifier is the part of the notification service used for conversion events
+type ConversionNotifier interface {
+	SendConversionStarted(ctx context.Context, userID, conversionID string) error
+	SendConversionCompleted(ctx context.Context, userID, conversionID, resultImageID string) error
+	SendConversionFailed(ctx context.Context, userID, conversionID, errorMessage string) error
+}
+
+// NotificationPublisher forwards conversion events to the notification service
+type NotificationPublisher struct {
+	notifier ConversionNotifier
+}
+
+// NewNotificationPublisher creates a new notification publisher
+func NewNotificationPublisher(notifier ConversionNotifier) *NotificationPublisher {
+	return &NotificationPublisher{notifier: notifier}
+}
+
+// Name returns the publisher name
+func (p *NotificationPublisher) Name() string {
+	return "notification"
+}
+
+// Handles reports whether the event is a conversion event
+func (p *NotificationPublisher) Handles(eventType string) bool {
+	switch eventType {
+	case EventConversionStarted, EventConversionCompleted, EventConversionFailed:
+		return true
+	}
+	return false
+}
+
+// Publish sends the notification matching the event
+func (p *NotificationPublisher) Publish(ctx context.Context, event Event) error {
+	var payload ConversionEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to decode conversion event: %w", err)
+	}
+
+	switch event.EventType {
+	case EventConversionStarted:
+		return p.notifier.SendConversionStarted(ctx, payload.UserID, payload.ConversionID)
+	case EventConversionCompleted:
+		return p.notifier.SendConversionCompleted(ctx, payload.UserID, payload.ConversionID, payload.ResultImageID)
+	case EventConversionFailed:
+		return p.notifier.SendConversionFailed(ctx, payload.UserID, payload.ConversionID, payload.ErrorMessage)
+	}
+	return nil
+}
+
+// WebhookPublisher posts every event to the configured URLs, signed with
+// HMAC-SHA256 when a secret is set
+type WebhookPublisher struct {
+	urls       []string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewWebhookPublisher creates a new webhook publisher
+func NewWebhookPublisher(urls []string, secret string, timeout time.Duration) *WebhookPublisher {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &WebhookPublisher{
+		urls:       urls,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// NewWebhookPublisherWithClient creates a webhook publisher that posts with
+// client, for callers that restrict where deliveries may connect
+func NewWebhookPublisherWithClient(urls []string, secret string, client *http.Client) *WebhookPublisher {
+	return &WebhookPublisher{urls: urls, secret: secret, httpClient: client}
+}
+
+// Name returns the publisher name
+func (p *WebhookPublisher) Name() string {
+	return "webhook"
+}
+
+// Handles reports true for every event type when webhooks are configured
+func (p *WebhookPublisher) Handles(eventType string) bool {
+	return len(p.urls) > 0
+}
+
+// Publish posts the event to every webhook URL
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":            event.ID,
+		"type":          event.EventType,
+		"aggregateType": event.AggregateType,
+		"aggregateId":   event.AggregateID,
+		"payload":       event.Payload,
+		"createdAt":     event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	for _, url := range p.urls {
+		if err := p.post(ctx, url, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *WebhookPublisher) post(ctx context.Context, url string, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventIDHeader, event.ID)
+	req.Header.Set(WebhookEventTypeHeader, event.EventType)
+	if p.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(p.secret, body))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of body, used by receivers to verify deliveries
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseWebhookURLs splits a comma separated URL list
+func ParseWebhookURLs(value string) []string {
+	var urls []string
+	for _, url := range strings.Split(value, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Execer is satisfied by both *sql.DB and *sql.Tx so events can be written
+// inside the caller's transaction
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Store defines the persistence used by the dispatcher
+type Store interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error)
+	MarkPublished(ctx context.Context, eventID string) error
+	MarkFailed(ctx context.Context, eventID string, errMsg string, nextAttemptAt time.Time, dead bool) error
+}
+
+// Write stores events using exec, normally the transaction of the business change
+func Write(ctx context.Context, exec Execer, events ...Event) error {
+	for _, event := range events {
+		payload := event.Payload
+		if len(payload) == 0 {
+			payload = []byte("{}")
+		}
+
+		_, err := exec.ExecContext(ctx, `
+			INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+			VALUES ($1, $2, $3, $4)`,
+			event.AggregateType, event.AggregateID, event.EventType, string(payload))
+		if err != nil {
+			return fmt.Errorf("failed to write outbox event %s: %w", event.EventType, err)
+		}
+	}
+	return nil
+}
+
+// dbStore implements Store on top of PostgreSQL
+type dbStore struct {
+	db *sql.DB
+}
+
+// NewStore creates a new database-backed outbox store
+func NewStore(db *sql.DB) Store {
+	return &dbStore{db: db}
+}
+
+// Claim leases due pending events. SKIP LOCKED lets several dispatchers run
+// side by side without publishing the same event twice.
+func (s *dbStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE outbox_events
+		SET locked_until = NOW() + make_interval(secs => $2), attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = 'pending'
+			  AND next_attempt_at <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_type, aggregate_id, event_type, payload::text, attempts, created_at`,
+		limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.EventType,
+			&payload, &event.Attempts, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	// RETURNING does not keep the subquery order
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+// MarkPublished marks an event as delivered
+func (s *dbStore) MarkPublished(ctx context.Context, eventID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET status = 'published', published_at = NOW(), locked_until = NULL, last_error = NULL
+		WHERE id = $1`, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed schedules a retry or marks the event dead
+func (s *dbStore) MarkFailed(ctx context.Context, eventID string, errMsg string, nextAttemptAt time.Time, dead bool) error {
+	status := StatusPending
+	if dead {
+		status = StatusDead
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET status = $2, last_error = $3, next_attempt_at = $4, locked_until = NULL
+		WHERE id = $1`, eventID, status, errMsg, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
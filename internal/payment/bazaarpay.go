@@ -11,6 +11,9 @@ import (
 	"net/url"
 	"os"
 	"time"
+
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
 )
 
 const (
@@ -21,9 +24,11 @@ const (
 type BazaarPayService struct {
 	db          *sql.DB
 	store       PaymentStore
+	tx          common.TxRunner
 	apiKey      string
 	destination string
 	redirectURL string
+	cache       *cache.TwoTier
 }
 
 // NewBazaarPayService creates a new BazaarPay service
@@ -41,9 +46,11 @@ func NewBazaarPayService(db *sql.DB) *BazaarPayService {
 	return &BazaarPayService{
 		db:          db,
 		store:       NewPaymentStore(db),
+		tx:          common.NewUnitOfWork(db),
 		apiKey:      apiKey,
 		destination: destination,
 		redirectURL: redirectURL,
+		cache:       cache.Default(),
 	}
 }
 
@@ -304,7 +311,7 @@ func (s *BazaarPayService) CreatePlanPayment(ctx context.Context, payment *PlanP
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
 		ON CONFLICT (order_id) DO NOTHING`
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, query,
 		payment.UserID,
 		payment.OrderID,
 		payment.RefNumber,
@@ -421,12 +428,18 @@ func (s *BazaarPayService) ProcessBazaarPayment(ctx context.Context, checkoutTok
 		PaidAt:     stringPtrHelper(time.Now().Format("2006-01-02 15:04:05")),
 	}
 
-	if err := s.CreatePlanPayment(ctx, planPayment); err != nil {
-		return fmt.Errorf("error saving payment: %w", err)
-	}
+	// The plan payment marks the checkout as processed, so it is saved together
+	// with the plan it activates; on failure the next commit retries both
+	err = common.RunInTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.CreatePlanPayment(ctx, planPayment); err != nil {
+			return fmt.Errorf("error saving payment: %w", err)
+		}
+
+		// 5. فعال کردن plan اگر planID موجود باشد
+		if prePayment.PlanID == nil || *prePayment.PlanID == "" {
+			return nil
+		}
 
-	// 5. فعال کردن plan اگر planID موجود باشد
-	if prePayment.PlanID != nil && *prePayment.PlanID != "" {
 		// ایجاد payment record در جدول payments برای سازگاری با سیستم موجود
 		paymentID := generatePaymentID()
 		payment := Payment{
@@ -449,19 +462,20 @@ func (s *BazaarPayService) ProcessBazaarPayment(ctx context.Context, checkoutTok
 			PaidAt:            timePtr(time.Now()),
 		}
 
-		_, err = s.store.CreatePayment(ctx, payment)
-		if err != nil {
-			// لاگ کردن خطا ولی ادامه بده
-			fmt.Printf("Error creating payment record: %v\n", err)
-		} else {
-			// فعال کردن plan
-			err = s.store.ActivateUserPlan(ctx, prePayment.UserID, *prePayment.PlanID, paymentID)
-			if err != nil {
-				// لاگ کردن خطا ولی ادامه بده
-				fmt.Printf("Error activating user plan: %v\n", err)
-			}
+		if _, err := s.store.CreatePayment(ctx, payment); err != nil {
+			return fmt.Errorf("error creating payment record: %w", err)
+		}
+
+		// فعال کردن plan
+		if err := s.store.ActivateUserPlan(ctx, prePayment.UserID, *prePayment.PlanID, paymentID); err != nil {
+			return fmt.Errorf("error activating user plan: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	invalidatePlan(ctx, s.cache, prePayment.UserID)
 
 	// 6. Commit کردن تراکنش
 	if err := s.CommitCheckout(checkoutToken); err != nil {
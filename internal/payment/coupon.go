@@ -0,0 +1,916 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// Discount types of a coupon
+const (
+	CouponTypePercent = "percent"
+	CouponTypeFixed   = "fixed"
+)
+
+// Statuses of a coupon redemption. A redemption is pending while its payment
+// is, and released when the payment fails or is cancelled.
+const (
+	RedemptionPending  = "pending"
+	RedemptionRedeemed = "redeemed"
+	RedemptionReleased = "released"
+)
+
+const (
+	// MinPaymentAmount is the smallest amount the gateways accept, in Rials.
+	// Discounts never bring a payment below it.
+	MinPaymentAmount int64 = 10000
+
+	// MaxCouponCodeLength limits the length of coupon codes
+	MaxCouponCodeLength = 32
+
+	defaultCouponPageSize = 20
+	maxCouponPageSize     = 100
+
+	couponCodeUniqueConstraint = "coupons_code_key"
+)
+
+var (
+	// ErrCouponNotFound is returned for unknown coupon codes and IDs
+	ErrCouponNotFound = fmt.Errorf("coupon %w", common.ErrNotFound)
+	// ErrCouponInactive is returned for deactivated coupons and coupons not valid yet
+	ErrCouponInactive = fmt.Errorf("%w: coupon is not active", common.ErrValidation)
+	// ErrCouponExpired is returned for coupons past their expiry
+	ErrCouponExpired = fmt.Errorf("%w: coupon has expired", common.ErrValidation)
+	// ErrCouponNotApplicable is returned for plans the coupon does not discount
+	ErrCouponNotApplicable = fmt.Errorf("%w: coupon does not apply to this plan", common.ErrValidation)
+	// ErrCouponExhausted is returned when the coupon reached its redemption limit
+	ErrCouponExhausted = fmt.Errorf("%w: coupon usage limit reached", common.ErrConflict)
+	// ErrCouponAlreadyUsed is returned when the user reached the per-user limit
+	ErrCouponAlreadyUsed = fmt.Errorf("%w: coupon already used", common.ErrConflict)
+	// ErrCouponCodeTaken is returned when another coupon has the same code
+	ErrCouponCodeTaken = fmt.Errorf("%w: coupon code already exists", common.ErrConflict)
+	// ErrCouponsUnavailable is returned when coupons are not configured
+	ErrCouponsUnavailable = errors.New("coupons are not available")
+)
+
+// Coupon is a discount code for plan payments
+type Coupon struct {
+	ID                    string     `json:"id"`
+	Code                  string     `json:"code"`
+	Description           string     `json:"description"`
+	DiscountType          string     `json:"discountType"`
+	DiscountValue         int64      `json:"discountValue"`            // percent, or Rials for fixed coupons
+	MaxRedemptions        *int       `json:"maxRedemptions,omitempty"` // nil is unlimited
+	MaxRedemptionsPerUser int        `json:"maxRedemptionsPerUser"`
+	Redemptions           int        `json:"redemptions"` // pending and redeemed
+	PlanIDs               []string   `json:"planIds"`     // empty applies to every plan
+	StartsAt              *time.Time `json:"startsAt,omitempty"`
+	ExpiresAt             *time.Time `json:"expiresAt,omitempty"`
+	IsActive              bool       `json:"isActive"`
+	CreatedBy             *string    `json:"createdBy,omitempty"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
+
+// AppliesTo reports whether the coupon may be used to pay for the plan
+func (c Coupon) AppliesTo(planID string) bool {
+	if len(c.PlanIDs) == 0 {
+		return true
+	}
+	for _, id := range c.PlanIDs {
+		if id == planID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns the discount of the coupon on amount, leaving at least
+// MinPaymentAmount to pay
+func (c Coupon) Discount(amount int64) int64 {
+	var discount int64
+	switch c.DiscountType {
+	case CouponTypePercent:
+		discount = amount * c.DiscountValue / 100
+	case CouponTypeFixed:
+		discount = c.DiscountValue
+	}
+	if limit := amount - MinPaymentAmount; discount > limit {
+		discount = limit
+	}
+	if discount < 0 {
+		return 0
+	}
+	return discount
+}
+
+// CouponRedemption is the use of a coupon for a payment
+type CouponRedemption struct {
+	CouponID       string
+	Code           string
+	UserID         string
+	PaymentID      string
+	DiscountAmount int64
+	Status         string
+}
+
+// CouponStats reports how a coupon was redeemed
+type CouponStats struct {
+	Redeemed       int   `json:"redeemed"`
+	Pending        int   `json:"pending"`
+	Released       int   `json:"released"`
+	DiscountAmount int64 `json:"discountAmount"` // given on redeemed payments, in Rials
+	Revenue        int64 `json:"revenue"`        // paid on redeemed payments, in Rials
+}
+
+// CouponDetails is a coupon with its redemption stats
+type CouponDetails struct {
+	Coupon
+	Stats CouponStats `json:"stats"`
+}
+
+// ValidateCouponRequest asks for the discount of a coupon on a plan
+type ValidateCouponRequest struct {
+	Code   string `json:"code" binding:"required"`
+	PlanID string `json:"planId" binding:"required"`
+}
+
+// CouponQuote is the price of a plan with a coupon applied
+type CouponQuote struct {
+	Code           string `json:"code"`
+	PlanID         string `json:"planId"`
+	DiscountType   string `json:"discountType"`
+	DiscountValue  int64  `json:"discountValue"`
+	OriginalAmount int64  `json:"originalAmount"`
+	DiscountAmount int64  `json:"discountAmount"`
+	FinalAmount    int64  `json:"finalAmount"`
+
+	couponID string
+}
+
+// CouponRequest creates or replaces a coupon
+type CouponRequest struct {
+	Code                  string     `json:"code" binding:"required"`
+	Description           string     `json:"description"`
+	DiscountType          string     `json:"discountType" binding:"required"`
+	DiscountValue         int64      `json:"discountValue" binding:"required"`
+	MaxRedemptions        *int       `json:"maxRedemptions"`
+	MaxRedemptionsPerUser int        `json:"maxRedemptionsPerUser"` // 0 defaults to 1
+	PlanIDs               []string   `json:"planIds"`
+	StartsAt              *time.Time `json:"startsAt"`
+	ExpiresAt             *time.Time `json:"expiresAt"`
+	IsActive              *bool      `json:"isActive"` // defaults to true
+}
+
+// CouponListRequest pages through coupons, optionally matching a code prefix
+type CouponListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+	Code     string `form:"code"`
+}
+
+// CouponListResponse is a page of coupons
+type CouponListResponse struct {
+	Coupons    []Coupon `json:"coupons"`
+	Total      int      `json:"total"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"pageSize"`
+	TotalPages int      `json:"totalPages"`
+}
+
+// CouponStore persists coupons and their redemptions
+type CouponStore interface {
+	GetCouponByCode(ctx context.Context, code string) (Coupon, error)
+	// CountUserRedemptions counts the pending and redeemed uses of the coupon by the user
+	CountUserRedemptions(ctx context.Context, couponID, userID string) (int, error)
+	// ReserveRedemption records a pending redemption, failing with
+	// ErrCouponExhausted or ErrCouponAlreadyUsed when a limit was reached
+	// since the coupon was checked
+	ReserveRedemption(ctx context.Context, redemption CouponRedemption) error
+	// FinishRedemption moves the pending redemption of a payment to status,
+	// reporting false when the payment has none
+	FinishRedemption(ctx context.Context, paymentID, status string) (CouponRedemption, bool, error)
+	// ListExpiredReservations lists pending redemptions of payments that will
+	// not complete: pending past their expiry, or already failed, cancelled
+	// or expired
+	ListExpiredReservations(ctx context.Context, limit int) ([]CouponRedemption, error)
+	// ExpireReservation marks the payment expired if it is still pending past
+	// its expiry and releases its redemption, reporting false when the payment
+	// completed or is still open
+	ExpireReservation(ctx context.Context, paymentID string) (CouponRedemption, bool, error)
+
+	CreateCoupon(ctx context.Context, coupon Coupon) (Coupon, error)
+	GetCoupon(ctx context.Context, couponID string) (Coupon, error)
+	ListCoupons(ctx context.Context, req CouponListRequest) ([]Coupon, int, error)
+	UpdateCoupon(ctx context.Context, coupon Coupon) (Coupon, error)
+	DeactivateCoupon(ctx context.Context, couponID string) error
+	GetCouponStats(ctx context.Context, couponID string) (CouponStats, error)
+}
+
+// SetCoupons enables coupon codes on plan payments
+func (s *Service) SetCoupons(store CouponStore) {
+	s.coupons = store
+}
+
+// ValidateCoupon returns the discount the coupon gives the user on a plan,
+// without redeeming it
+func (s *Service) ValidateCoupon(ctx context.Context, userID string, req ValidateCouponRequest) (CouponQuote, error) {
+	if s.coupons == nil {
+		return CouponQuote{}, ErrCouponsUnavailable
+	}
+
+	// Guessing codes is cheap, so attempts are limited like plan changes
+	rateLimitKey := fmt.Sprintf("coupon:user:%s", userID)
+	if !s.rateLimiter.Allow(ctx, rateLimitKey, 20, time.Hour) {
+		return CouponQuote{}, errors.New("rate limit exceeded")
+	}
+
+	plan, err := s.store.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return CouponQuote{}, fmt.Errorf("failed to get plan: %w", err)
+	}
+	if !plan.IsActive {
+		return CouponQuote{}, errors.New("plan is not active")
+	}
+
+	return s.quoteCoupon(ctx, userID, req.Code, plan)
+}
+
+// quoteCoupon checks that the user may use the coupon for the plan and prices it
+func (s *Service) quoteCoupon(ctx context.Context, userID, code string, plan PaymentPlan) (CouponQuote, error) {
+	code = normalizeCouponCode(code)
+	if code == "" {
+		return CouponQuote{}, ErrCouponNotFound
+	}
+	coupon, err := s.coupons.GetCouponByCode(ctx, code)
+	if err != nil {
+		return CouponQuote{}, err
+	}
+
+	now := time.Now()
+	switch {
+	case !coupon.IsActive || (coupon.StartsAt != nil && now.Before(*coupon.StartsAt)):
+		return CouponQuote{}, ErrCouponInactive
+	case coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt):
+		return CouponQuote{}, ErrCouponExpired
+	case !coupon.AppliesTo(plan.ID):
+		return CouponQuote{}, ErrCouponNotApplicable
+	case coupon.MaxRedemptions != nil && coupon.Redemptions >= *coupon.MaxRedemptions:
+		return CouponQuote{}, ErrCouponExhausted
+	}
+
+	used, err := s.coupons.CountUserRedemptions(ctx, coupon.ID, userID)
+	if err != nil {
+		return CouponQuote{}, err
+	}
+	if used >= coupon.MaxRedemptionsPerUser {
+		return CouponQuote{}, ErrCouponAlreadyUsed
+	}
+
+	discount := coupon.Discount(plan.PricePerMonthCents)
+	if discount == 0 {
+		// Free and minimum-price plans cannot be discounted
+		return CouponQuote{}, ErrCouponNotApplicable
+	}
+
+	return CouponQuote{
+		Code:           coupon.Code,
+		PlanID:         plan.ID,
+		DiscountType:   coupon.DiscountType,
+		DiscountValue:  coupon.DiscountValue,
+		OriginalAmount: plan.PricePerMonthCents,
+		DiscountAmount: discount,
+		FinalAmount:    plan.PricePerMonthCents - discount,
+		couponID:       coupon.ID,
+	}, nil
+}
+
+// StartCouponExpiry releases the coupons of abandoned payments every interval
+// until ctx is cancelled
+func (s *Service) StartCouponExpiry(ctx context.Context, interval time.Duration, batchSize int) {
+	if s.coupons == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireCouponReservations(ctx, batchSize); err != nil && ctx.Err() == nil {
+			log.Printf("Coupon expiry failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireCouponReservations expires pending payments past their expiry that
+// hold a coupon and gives the coupon back, so a user who leaves the gateway
+// page gets their per-user allowance back and the coupon its redemption. It
+// returns the number of coupons released.
+func (s *Service) ExpireCouponReservations(ctx context.Context, batchSize int) (int, error) {
+	if s.coupons == nil {
+		return 0, ErrCouponsUnavailable
+	}
+
+	released := 0
+	for {
+		batch, err := s.coupons.ListExpiredReservations(ctx, batchSize)
+		if err != nil {
+			return released, err
+		}
+
+		progressed := false
+		for _, reservation := range batch {
+			redemption, ok, err := s.coupons.ExpireReservation(ctx, reservation.PaymentID)
+			if err != nil {
+				log.Printf("Failed to release coupon of payment %s: %v", reservation.PaymentID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			progressed = true
+			released++
+			_ = s.auditLogger.LogPaymentAction(ctx, redemption.UserID, "coupon_released", map[string]interface{}{
+				"payment_id":  redemption.PaymentID,
+				"coupon_id":   redemption.CouponID,
+				"coupon_code": redemption.Code,
+				"reason":      "payment_expired",
+			})
+		}
+
+		// A batch that failed entirely would be listed again, so stop there
+		if len(batch) < batchSize || !progressed {
+			return released, nil
+		}
+	}
+}
+
+// releaseCoupon gives back the coupon redemption of a payment that will not complete
+func (s *Service) releaseCoupon(ctx context.Context, payment Payment) {
+	if s.coupons == nil {
+		return
+	}
+	redemption, found, err := s.coupons.FinishRedemption(ctx, payment.ID, RedemptionReleased)
+	if err != nil {
+		_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "coupon_release_failed", map[string]interface{}{
+			"payment_id": payment.ID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	if found {
+		_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "coupon_released", map[string]interface{}{
+			"payment_id":  payment.ID,
+			"coupon_id":   redemption.CouponID,
+			"coupon_code": redemption.Code,
+		})
+	}
+}
+
+// CreateCoupon creates a coupon on behalf of an admin
+func (s *Service) CreateCoupon(ctx context.Context, adminID string, req CouponRequest) (Coupon, error) {
+	if s.coupons == nil {
+		return Coupon{}, ErrCouponsUnavailable
+	}
+	coupon, err := s.normalizeCoupon(ctx, req)
+	if err != nil {
+		return Coupon{}, err
+	}
+	if adminID != "" {
+		coupon.CreatedBy = &adminID
+	}
+
+	created, err := s.coupons.CreateCoupon(ctx, coupon)
+	if err != nil {
+		return Coupon{}, err
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, adminID, "coupon_created", couponAuditMetadata(created))
+	return created, nil
+}
+
+// GetCoupon returns a coupon with its redemption stats
+func (s *Service) GetCoupon(ctx context.Context, couponID string) (CouponDetails, error) {
+	if s.coupons == nil {
+		return CouponDetails{}, ErrCouponsUnavailable
+	}
+	coupon, err := s.coupons.GetCoupon(ctx, couponID)
+	if err != nil {
+		return CouponDetails{}, err
+	}
+	stats, err := s.coupons.GetCouponStats(ctx, couponID)
+	if err != nil {
+		return CouponDetails{}, err
+	}
+	return CouponDetails{Coupon: coupon, Stats: stats}, nil
+}
+
+// ListCoupons returns a page of coupons, newest first
+func (s *Service) ListCoupons(ctx context.Context, req CouponListRequest) (CouponListResponse, error) {
+	if s.coupons == nil {
+		return CouponListResponse{}, ErrCouponsUnavailable
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > maxCouponPageSize {
+		req.PageSize = defaultCouponPageSize
+	}
+	req.Code = normalizeCouponCode(req.Code)
+
+	coupons, total, err := s.coupons.ListCoupons(ctx, req)
+	if err != nil {
+		return CouponListResponse{}, err
+	}
+	return CouponListResponse{
+		Coupons:    coupons,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: (total + req.PageSize - 1) / req.PageSize,
+	}, nil
+}
+
+// UpdateCoupon replaces a coupon's settings. Redemptions made so far are kept
+// and still count towards the new limits.
+func (s *Service) UpdateCoupon(ctx context.Context, adminID, couponID string, req CouponRequest) (Coupon, error) {
+	if s.coupons == nil {
+		return Coupon{}, ErrCouponsUnavailable
+	}
+	coupon, err := s.normalizeCoupon(ctx, req)
+	if err != nil {
+		return Coupon{}, err
+	}
+	coupon.ID = couponID
+
+	updated, err := s.coupons.UpdateCoupon(ctx, coupon)
+	if err != nil {
+		return Coupon{}, err
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, adminID, "coupon_updated", couponAuditMetadata(updated))
+	return updated, nil
+}
+
+// DeleteCoupon deactivates a coupon. It is kept so past payments still show
+// the coupon they were discounted with.
+func (s *Service) DeleteCoupon(ctx context.Context, adminID, couponID string) error {
+	if s.coupons == nil {
+		return ErrCouponsUnavailable
+	}
+	if err := s.coupons.DeactivateCoupon(ctx, couponID); err != nil {
+		return err
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, adminID, "coupon_deactivated", map[string]interface{}{
+		"coupon_id": couponID,
+	})
+	return nil
+}
+
+// normalizeCoupon validates a coupon request and fills in its defaults
+func (s *Service) normalizeCoupon(ctx context.Context, req CouponRequest) (Coupon, error) {
+	coupon := Coupon{
+		Code:                  normalizeCouponCode(req.Code),
+		Description:           strings.TrimSpace(req.Description),
+		DiscountType:          strings.ToLower(strings.TrimSpace(req.DiscountType)),
+		DiscountValue:         req.DiscountValue,
+		MaxRedemptions:        req.MaxRedemptions,
+		MaxRedemptionsPerUser: req.MaxRedemptionsPerUser,
+		PlanIDs:               []string{},
+		StartsAt:              req.StartsAt,
+		ExpiresAt:             req.ExpiresAt,
+		IsActive:              req.IsActive == nil || *req.IsActive,
+	}
+
+	if coupon.Code == "" {
+		return Coupon{}, fmt.Errorf("%w: code is required", common.ErrValidation)
+	}
+	if len(coupon.Code) > MaxCouponCodeLength || !validCouponCode(coupon.Code) {
+		return Coupon{}, fmt.Errorf("%w: code must be up to %d letters, digits, dashes or underscores", common.ErrValidation, MaxCouponCodeLength)
+	}
+
+	switch coupon.DiscountType {
+	case CouponTypePercent:
+		if coupon.DiscountValue < 1 || coupon.DiscountValue > 100 {
+			return Coupon{}, fmt.Errorf("%w: percent discount must be between 1 and 100", common.ErrValidation)
+		}
+	case CouponTypeFixed:
+		if coupon.DiscountValue < 1 {
+			return Coupon{}, fmt.Errorf("%w: fixed discount must be positive", common.ErrValidation)
+		}
+	default:
+		return Coupon{}, fmt.Errorf("%w: discount type must be percent or fixed", common.ErrValidation)
+	}
+
+	if coupon.MaxRedemptions != nil && *coupon.MaxRedemptions < 1 {
+		return Coupon{}, fmt.Errorf("%w: max redemptions must be positive", common.ErrValidation)
+	}
+	if coupon.MaxRedemptionsPerUser == 0 {
+		coupon.MaxRedemptionsPerUser = 1
+	}
+	if coupon.MaxRedemptionsPerUser < 0 {
+		return Coupon{}, fmt.Errorf("%w: max redemptions per user must be positive", common.ErrValidation)
+	}
+	if coupon.StartsAt != nil && coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(*coupon.StartsAt) {
+		return Coupon{}, fmt.Errorf("%w: expiry must be after the start", common.ErrValidation)
+	}
+
+	seen := make(map[string]bool, len(req.PlanIDs))
+	for _, planID := range req.PlanIDs {
+		if seen[planID] {
+			continue
+		}
+		seen[planID] = true
+		if _, err := s.store.GetPlan(ctx, planID); err != nil {
+			return Coupon{}, fmt.Errorf("%w: unknown plan %s", common.ErrValidation, planID)
+		}
+		coupon.PlanIDs = append(coupon.PlanIDs, planID)
+	}
+	return coupon, nil
+}
+
+// normalizeCouponCode makes coupon codes case-insensitive
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validCouponCode(code string) bool {
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func couponAuditMetadata(coupon Coupon) map[string]interface{} {
+	return map[string]interface{}{
+		"coupon_id":      coupon.ID,
+		"coupon_code":    coupon.Code,
+		"discount_type":  coupon.DiscountType,
+		"discount_value": coupon.DiscountValue,
+		"is_active":      coupon.IsActive,
+	}
+}
+
+// dbCouponStore implements CouponStore on top of the coupons and
+// coupon_redemptions tables
+type dbCouponStore struct {
+	db *sql.DB
+}
+
+// NewDBCouponStore creates a new database-backed coupon store
+func NewDBCouponStore(db *sql.DB) CouponStore {
+	return &dbCouponStore{db: db}
+}
+
+const couponColumns = `id, code, description, discount_type, discount_value, max_redemptions,
+	max_redemptions_per_user, redemptions_count, plan_ids, starts_at, expires_at, is_active,
+	created_by, created_at, updated_at`
+
+// scanCoupon scans a row of couponColumns
+func scanCoupon(row interface{ Scan(...interface{}) error }) (Coupon, error) {
+	var coupon Coupon
+	var maxRedemptions sql.NullInt64
+	var startsAt, expiresAt sql.NullTime
+	var createdBy sql.NullString
+	err := row.Scan(&coupon.ID, &coupon.Code, &coupon.Description, &coupon.DiscountType,
+		&coupon.DiscountValue, &maxRedemptions, &coupon.MaxRedemptionsPerUser, &coupon.Redemptions,
+		pq.Array(&coupon.PlanIDs), &startsAt, &expiresAt, &coupon.IsActive, &createdBy,
+		&coupon.CreatedAt, &coupon.UpdatedAt)
+	if err != nil {
+		return Coupon{}, err
+	}
+	if maxRedemptions.Valid {
+		limit := int(maxRedemptions.Int64)
+		coupon.MaxRedemptions = &limit
+	}
+	if startsAt.Valid {
+		coupon.StartsAt = &startsAt.Time
+	}
+	if expiresAt.Valid {
+		coupon.ExpiresAt = &expiresAt.Time
+	}
+	if createdBy.Valid {
+		coupon.CreatedBy = &createdBy.String
+	}
+	if coupon.PlanIDs == nil {
+		coupon.PlanIDs = []string{}
+	}
+	return coupon, nil
+}
+
+func (s *dbCouponStore) GetCouponByCode(ctx context.Context, code string) (Coupon, error) {
+	coupon, err := scanCoupon(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT `+couponColumns+`
+		FROM coupons
+		WHERE code = $1`, code))
+	switch {
+	case err == sql.ErrNoRows:
+		return Coupon{}, ErrCouponNotFound
+	case err != nil:
+		return Coupon{}, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+func (s *dbCouponStore) CountUserRedemptions(ctx context.Context, couponID, userID string) (int, error) {
+	var count int
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM coupon_redemptions
+		WHERE coupon_id = $1 AND user_id = $2 AND status <> 'released'`,
+		couponID, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count coupon redemptions: %w", err)
+	}
+	return count, nil
+}
+
+// ReserveRedemption locks the coupon row so concurrent payments cannot
+// overshoot its limits
+func (s *dbCouponStore) ReserveRedemption(ctx context.Context, redemption CouponRedemption) error {
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		var maxRedemptions sql.NullInt64
+		var perUser, redemptions int
+		err := tx.QueryRowContext(ctx, `
+			SELECT max_redemptions, max_redemptions_per_user, redemptions_count
+			FROM coupons
+			WHERE id = $1
+			FOR UPDATE`, redemption.CouponID).Scan(&maxRedemptions, &perUser, &redemptions)
+		switch {
+		case err == sql.ErrNoRows:
+			return ErrCouponNotFound
+		case err != nil:
+			return fmt.Errorf("failed to lock coupon: %w", err)
+		}
+		if maxRedemptions.Valid && int64(redemptions) >= maxRedemptions.Int64 {
+			return ErrCouponExhausted
+		}
+
+		var used int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM coupon_redemptions
+			WHERE coupon_id = $1 AND user_id = $2 AND status <> 'released'`,
+			redemption.CouponID, redemption.UserID).Scan(&used); err != nil {
+			return fmt.Errorf("failed to count coupon redemptions: %w", err)
+		}
+		if used >= perUser {
+			return ErrCouponAlreadyUsed
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO coupon_redemptions (coupon_id, user_id, payment_id, discount_amount, status)
+			VALUES ($1, $2, $3, $4, 'pending')`,
+			redemption.CouponID, redemption.UserID, redemption.PaymentID, redemption.DiscountAmount); err != nil {
+			return fmt.Errorf("failed to record coupon redemption: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE coupons SET redemptions_count = redemptions_count + 1, updated_at = NOW()
+			WHERE id = $1`, redemption.CouponID); err != nil {
+			return fmt.Errorf("failed to count coupon redemption: %w", err)
+		}
+		return nil
+	})
+}
+
+// FinishRedemption gives the redemption back to the coupon when it is released
+func (s *dbCouponStore) FinishRedemption(ctx context.Context, paymentID, status string) (CouponRedemption, bool, error) {
+	var redemption CouponRedemption
+	found := false
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE coupon_redemptions r
+			SET status = $2, updated_at = NOW()
+			FROM coupons c
+			WHERE r.payment_id = $1 AND r.status = 'pending' AND c.id = r.coupon_id
+			RETURNING r.coupon_id, c.code, r.user_id, r.payment_id, r.discount_amount, r.status`,
+			paymentID, status).Scan(&redemption.CouponID, &redemption.Code, &redemption.UserID,
+			&redemption.PaymentID, &redemption.DiscountAmount, &redemption.Status)
+		switch {
+		case err == sql.ErrNoRows:
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to update coupon redemption: %w", err)
+		}
+		found = true
+
+		if status != RedemptionReleased {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE coupons SET redemptions_count = GREATEST(redemptions_count - 1, 0), updated_at = NOW()
+			WHERE id = $1`, redemption.CouponID); err != nil {
+			return fmt.Errorf("failed to release coupon redemption: %w", err)
+		}
+		return nil
+	})
+	return redemption, found, err
+}
+
+// ListExpiredReservations returns the oldest expired reservations first
+func (s *dbCouponStore) ListExpiredReservations(ctx context.Context, limit int) ([]CouponRedemption, error) {
+	rows, err := common.Conn(ctx, s.db).QueryContext(ctx, `
+		SELECT r.coupon_id, c.code, r.user_id, r.payment_id, r.discount_amount, r.status
+		FROM coupon_redemptions r
+		JOIN coupons c ON c.id = r.coupon_id
+		JOIN payments p ON p.id = r.payment_id
+		WHERE r.status = 'pending'
+		  AND (p.status IN ('failed', 'cancelled', 'expired')
+		       OR (p.status = 'pending' AND p.expires_at <= NOW()))
+		ORDER BY r.created_at
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired coupon reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []CouponRedemption
+	for rows.Next() {
+		var r CouponRedemption
+		if err := rows.Scan(&r.CouponID, &r.Code, &r.UserID, &r.PaymentID, &r.DiscountAmount, &r.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan coupon reservation: %w", err)
+		}
+		redemptions = append(redemptions, r)
+	}
+	return redemptions, rows.Err()
+}
+
+// ExpireReservation expires the payment and releases its redemption in one
+// transaction. Locking the payment keeps a verification running at the same
+// time from completing it after its coupon was released.
+func (s *dbCouponStore) ExpireReservation(ctx context.Context, paymentID string) (CouponRedemption, bool, error) {
+	var redemption CouponRedemption
+	released := false
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		var status string
+		var expired bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT status, COALESCE(expires_at <= NOW(), false)
+			FROM payments
+			WHERE id = $1
+			FOR UPDATE`, paymentID).Scan(&status, &expired)
+		switch {
+		case err == sql.ErrNoRows:
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+
+		switch {
+		case status == PaymentStatusCompleted:
+			return nil
+		case status == PaymentStatusPending && !expired:
+			return nil
+		case status == PaymentStatusPending:
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE payments SET status = 'expired', updated_at = NOW()
+				WHERE id = $1`, paymentID); err != nil {
+				return fmt.Errorf("failed to expire payment: %w", err)
+			}
+		}
+
+		redemption, released, err = s.FinishRedemption(ctx, paymentID, RedemptionReleased)
+		return err
+	})
+	return redemption, released, err
+}
+
+func (s *dbCouponStore) CreateCoupon(ctx context.Context, coupon Coupon) (Coupon, error) {
+	created, err := scanCoupon(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		INSERT INTO coupons (code, description, discount_type, discount_value, max_redemptions,
+		                     max_redemptions_per_user, plan_ids, starts_at, expires_at, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+couponColumns,
+		coupon.Code, coupon.Description, coupon.DiscountType, coupon.DiscountValue, coupon.MaxRedemptions,
+		coupon.MaxRedemptionsPerUser, pq.Array(coupon.PlanIDs), coupon.StartsAt, coupon.ExpiresAt,
+		coupon.IsActive, coupon.CreatedBy))
+	if err != nil {
+		return Coupon{}, couponWriteError(err, "failed to create coupon")
+	}
+	return created, nil
+}
+
+func (s *dbCouponStore) GetCoupon(ctx context.Context, couponID string) (Coupon, error) {
+	coupon, err := scanCoupon(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT `+couponColumns+`
+		FROM coupons
+		WHERE id = $1`, couponID))
+	switch {
+	case err == sql.ErrNoRows:
+		return Coupon{}, ErrCouponNotFound
+	case err != nil:
+		return Coupon{}, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+func (s *dbCouponStore) ListCoupons(ctx context.Context, req CouponListRequest) ([]Coupon, int, error) {
+	conn := common.Conn(ctx, s.db)
+	prefix := req.Code + "%"
+
+	var total int
+	if err := conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM coupons WHERE code LIKE $1`, prefix).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count coupons: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT `+couponColumns+`
+		FROM coupons
+		WHERE code LIKE $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3`, prefix, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list coupons: %w", err)
+	}
+	defer rows.Close()
+
+	coupons := []Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan coupon: %w", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, total, rows.Err()
+}
+
+func (s *dbCouponStore) UpdateCoupon(ctx context.Context, coupon Coupon) (Coupon, error) {
+	updated, err := scanCoupon(common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		UPDATE coupons
+		SET code = $2,
+		    description = $3,
+		    discount_type = $4,
+		    discount_value = $5,
+		    max_redemptions = $6,
+		    max_redemptions_per_user = $7,
+		    plan_ids = $8,
+		    starts_at = $9,
+		    expires_at = $10,
+		    is_active = $11,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+couponColumns,
+		coupon.ID, coupon.Code, coupon.Description, coupon.DiscountType, coupon.DiscountValue,
+		coupon.MaxRedemptions, coupon.MaxRedemptionsPerUser, pq.Array(coupon.PlanIDs),
+		coupon.StartsAt, coupon.ExpiresAt, coupon.IsActive))
+	if err == sql.ErrNoRows {
+		return Coupon{}, ErrCouponNotFound
+	}
+	if err != nil {
+		return Coupon{}, couponWriteError(err, "failed to update coupon")
+	}
+	return updated, nil
+}
+
+func (s *dbCouponStore) DeactivateCoupon(ctx context.Context, couponID string) error {
+	result, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE coupons SET is_active = FALSE, updated_at = NOW() WHERE id = $1`, couponID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate coupon: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+func (s *dbCouponStore) GetCouponStats(ctx context.Context, couponID string) (CouponStats, error) {
+	var stats CouponStats
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE r.status = 'redeemed'),
+		       COUNT(*) FILTER (WHERE r.status = 'pending'),
+		       COUNT(*) FILTER (WHERE r.status = 'released'),
+		       COALESCE(SUM(r.discount_amount) FILTER (WHERE r.status = 'redeemed'), 0),
+		       COALESCE(SUM(p.amount) FILTER (WHERE r.status = 'redeemed'), 0)
+		FROM coupon_redemptions r
+		JOIN payments p ON p.id = r.payment_id
+		WHERE r.coupon_id = $1`, couponID).Scan(&stats.Redeemed, &stats.Pending, &stats.Released,
+		&stats.DiscountAmount, &stats.Revenue)
+	if err != nil {
+		return CouponStats{}, fmt.Errorf("failed to get coupon stats: %w", err)
+	}
+	return stats, nil
+}
+
+// couponWriteError maps a duplicate coupon code to ErrCouponCodeTaken
+func couponWriteError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == couponCodeUniqueConstraint {
+		return ErrCouponCodeTaken
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
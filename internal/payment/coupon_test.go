@@ -0,0 +1,351 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// fakeCouponStore keeps coupons by code and redemptions by payment
+type fakeCouponStore struct {
+	coupons     map[string]Coupon
+	redemptions map[string]CouponRedemption
+	payments    *mockStore // payments the redemptions belong to
+}
+
+func newFakeCouponStore(coupons ...Coupon) *fakeCouponStore {
+	store := &fakeCouponStore{
+		coupons:     make(map[string]Coupon),
+		redemptions: make(map[string]CouponRedemption),
+	}
+	for _, coupon := range coupons {
+		store.coupons[coupon.Code] = coupon
+	}
+	return store
+}
+
+func (f *fakeCouponStore) GetCouponByCode(ctx context.Context, code string) (Coupon, error) {
+	coupon, ok := f.coupons[code]
+	if !ok {
+		return Coupon{}, ErrCouponNotFound
+	}
+	return coupon, nil
+}
+
+func (f *fakeCouponStore) CountUserRedemptions(ctx context.Context, couponID, userID string) (int, error) {
+	count := 0
+	for _, redemption := range f.redemptions {
+		if redemption.CouponID == couponID && redemption.UserID == userID && redemption.Status != RedemptionReleased {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeCouponStore) ReserveRedemption(ctx context.Context, redemption CouponRedemption) error {
+	coupon := f.coupons[redemption.Code]
+	coupon.Redemptions++
+	f.coupons[redemption.Code] = coupon
+	redemption.Status = RedemptionPending
+	f.redemptions[redemption.PaymentID] = redemption
+	return nil
+}
+
+func (f *fakeCouponStore) FinishRedemption(ctx context.Context, paymentID, status string) (CouponRedemption, bool, error) {
+	redemption, ok := f.redemptions[paymentID]
+	if !ok || redemption.Status != RedemptionPending {
+		return CouponRedemption{}, false, nil
+	}
+	redemption.Status = status
+	f.redemptions[paymentID] = redemption
+	if status == RedemptionReleased {
+		coupon := f.coupons[redemption.Code]
+		coupon.Redemptions--
+		f.coupons[redemption.Code] = coupon
+	}
+	return redemption, true, nil
+}
+
+// reservationExpired reports whether the payment of a pending redemption will not complete
+func (f *fakeCouponStore) reservationExpired(paymentID string) bool {
+	payment, ok := f.payments.payments[paymentID]
+	if !ok {
+		return false
+	}
+	switch payment.Status {
+	case PaymentStatusFailed, PaymentStatusCancelled, PaymentStatusExpired:
+		return true
+	case PaymentStatusPending:
+		return payment.ExpiresAt != nil && !payment.ExpiresAt.After(time.Now())
+	}
+	return false
+}
+
+func (f *fakeCouponStore) ListExpiredReservations(ctx context.Context, limit int) ([]CouponRedemption, error) {
+	var redemptions []CouponRedemption
+	for paymentID, redemption := range f.redemptions {
+		if redemption.Status == RedemptionPending && f.reservationExpired(paymentID) && len(redemptions) < limit {
+			redemptions = append(redemptions, redemption)
+		}
+	}
+	return redemptions, nil
+}
+
+func (f *fakeCouponStore) ExpireReservation(ctx context.Context, paymentID string) (CouponRedemption, bool, error) {
+	if !f.reservationExpired(paymentID) {
+		return CouponRedemption{}, false, nil
+	}
+	payment := f.payments.payments[paymentID]
+	if payment.Status == PaymentStatusPending {
+		payment.Status = PaymentStatusExpired
+		f.payments.payments[paymentID] = payment
+	}
+	return f.FinishRedemption(ctx, paymentID, RedemptionReleased)
+}
+
+func (f *fakeCouponStore) CreateCoupon(ctx context.Context, coupon Coupon) (Coupon, error) {
+	if _, ok := f.coupons[coupon.Code]; ok {
+		return Coupon{}, ErrCouponCodeTaken
+	}
+	coupon.ID = "coupon-" + coupon.Code
+	f.coupons[coupon.Code] = coupon
+	return coupon, nil
+}
+
+func (f *fakeCouponStore) GetCoupon(ctx context.Context, couponID string) (Coupon, error) {
+	for _, coupon := range f.coupons {
+		if coupon.ID == couponID {
+			return coupon, nil
+		}
+	}
+	return Coupon{}, ErrCouponNotFound
+}
+
+func (f *fakeCouponStore) ListCoupons(ctx context.Context, req CouponListRequest) ([]Coupon, int, error) {
+	coupons := []Coupon{}
+	for _, coupon := range f.coupons {
+		coupons = append(coupons, coupon)
+	}
+	return coupons, len(coupons), nil
+}
+
+func (f *fakeCouponStore) UpdateCoupon(ctx context.Context, coupon Coupon) (Coupon, error) {
+	return coupon, nil
+}
+
+func (f *fakeCouponStore) DeactivateCoupon(ctx context.Context, couponID string) error {
+	return nil
+}
+
+func (f *fakeCouponStore) GetCouponStats(ctx context.Context, couponID string) (CouponStats, error) {
+	return CouponStats{}, nil
+}
+
+func newCouponTestService(coupons ...Coupon) (*Service, *mockStore, *mockGateway, *fakeCouponStore) {
+	store := newMockStore()
+	gateway := newMockGateway()
+	service := NewService(store, gateway, &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	couponStore := newFakeCouponStore(coupons...)
+	couponStore.payments = store
+	service.SetCoupons(couponStore)
+	return service, store, gateway, couponStore
+}
+
+func TestCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name   string
+		coupon Coupon
+		amount int64
+		want   int64
+	}{
+		{"percent", Coupon{DiscountType: CouponTypePercent, DiscountValue: 20}, 50000, 10000},
+		{"fixed", Coupon{DiscountType: CouponTypeFixed, DiscountValue: 15000}, 50000, 15000},
+		{"capped at minimum payment", Coupon{DiscountType: CouponTypePercent, DiscountValue: 100}, 50000, 50000 - MinPaymentAmount},
+		{"free plan", Coupon{DiscountType: CouponTypeFixed, DiscountValue: 5000}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.coupon.Discount(tt.amount); got != tt.want {
+			t.Errorf("%s: expected discount %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestValidateCoupon(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	limit := 3
+
+	tests := []struct {
+		name   string
+		coupon Coupon
+		err    error
+	}{
+		{"valid", Coupon{IsActive: true}, nil},
+		{"inactive", Coupon{IsActive: false}, ErrCouponInactive},
+		{"not started", Coupon{IsActive: true, StartsAt: &future}, ErrCouponInactive},
+		{"expired", Coupon{IsActive: true, ExpiresAt: &past}, ErrCouponExpired},
+		{"other plan", Coupon{IsActive: true, PlanIDs: []string{"plan-2"}}, ErrCouponNotApplicable},
+		{"exhausted", Coupon{IsActive: true, MaxRedemptions: &limit, Redemptions: 3}, ErrCouponExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := tt.coupon
+			coupon.ID, coupon.Code = "c1", "SPRING20"
+			coupon.DiscountType, coupon.DiscountValue = CouponTypePercent, 20
+			coupon.MaxRedemptionsPerUser = 1
+			service, _, _, _ := newCouponTestService(coupon)
+
+			quote, err := service.ValidateCoupon(context.Background(), "user-1", ValidateCouponRequest{Code: " spring20 ", PlanID: "plan-1"})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if quote.OriginalAmount != 50000 || quote.DiscountAmount != 10000 || quote.FinalAmount != 40000 {
+				t.Errorf("Unexpected quote %+v", quote)
+			}
+		})
+	}
+}
+
+func TestCreatePaymentWithCoupon(t *testing.T) {
+	coupon := Coupon{ID: "c1", Code: "WELCOME", DiscountType: CouponTypeFixed, DiscountValue: 20000,
+		MaxRedemptionsPerUser: 1, IsActive: true}
+	service, store, _, coupons := newCouponTestService(coupon)
+	ctx := context.Background()
+
+	req := CreatePaymentRequest{PlanID: "plan-1", ReturnURL: "https://test.com/return", CouponCode: "welcome"}
+	resp, err := service.CreatePayment(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Amount != 30000 || resp.DiscountAmount != 20000 || resp.CouponCode != "WELCOME" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if store.payments[resp.PaymentID].Amount != 30000 {
+		t.Errorf("Expected the payment to be discounted, got %d", store.payments[resp.PaymentID].Amount)
+	}
+	if coupons.redemptions[resp.PaymentID].Status != RedemptionPending {
+		t.Errorf("Expected a pending redemption, got %+v", coupons.redemptions[resp.PaymentID])
+	}
+
+	// The redemption counts against the per-user limit until it is released
+	if _, err := service.CreatePayment(ctx, "user-1", req); !errors.Is(err, ErrCouponAlreadyUsed) {
+		t.Errorf("Expected ErrCouponAlreadyUsed, got %v", err)
+	}
+
+	if err := service.VerifyPayment(ctx, PaymentWebhook{TrackID: "test-track-id"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if coupons.redemptions[resp.PaymentID].Status != RedemptionRedeemed {
+		t.Errorf("Expected the coupon redeemed, got %s", coupons.redemptions[resp.PaymentID].Status)
+	}
+}
+
+func TestCreatePaymentWithCoupon_ReleasedOnFailure(t *testing.T) {
+	coupon := Coupon{ID: "c1", Code: "WELCOME", DiscountType: CouponTypePercent, DiscountValue: 10,
+		MaxRedemptionsPerUser: 1, IsActive: true}
+	service, _, gateway, coupons := newCouponTestService(coupon)
+	gateway.createPaymentError = errors.New("gateway down")
+
+	req := CreatePaymentRequest{PlanID: "plan-1", ReturnURL: "https://test.com/return", CouponCode: "WELCOME"}
+	if _, err := service.CreatePayment(context.Background(), "user-1", req); err == nil {
+		t.Fatal("Expected gateway error, got nil")
+	}
+	if coupons.coupons["WELCOME"].Redemptions != 0 {
+		t.Errorf("Expected the redemption released, got %d redemptions", coupons.coupons["WELCOME"].Redemptions)
+	}
+	for _, redemption := range coupons.redemptions {
+		if redemption.Status != RedemptionReleased {
+			t.Errorf("Expected released redemption, got %s", redemption.Status)
+		}
+	}
+}
+
+func TestExpireCouponReservations(t *testing.T) {
+	coupon := Coupon{ID: "c1", Code: "WELCOME", DiscountType: CouponTypeFixed, DiscountValue: 20000,
+		MaxRedemptionsPerUser: 1, IsActive: true}
+	service, store, _, coupons := newCouponTestService(coupon)
+	ctx := context.Background()
+
+	req := CreatePaymentRequest{PlanID: "plan-1", ReturnURL: "https://test.com/return", CouponCode: "WELCOME"}
+	abandoned, err := service.CreatePayment(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	open, err := service.CreatePayment(ctx, "user-2", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// user-1 left the gateway page and their payment ran past its expiry
+	past := time.Now().Add(-time.Minute)
+	payment := store.payments[abandoned.PaymentID]
+	payment.ExpiresAt = &past
+	store.payments[abandoned.PaymentID] = payment
+
+	released, err := service.ExpireCouponReservations(ctx, 100)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if released != 1 {
+		t.Errorf("Expected 1 coupon released, got %d", released)
+	}
+	if store.payments[abandoned.PaymentID].Status != PaymentStatusExpired {
+		t.Errorf("Expected the abandoned payment expired, got %s", store.payments[abandoned.PaymentID].Status)
+	}
+	if coupons.redemptions[abandoned.PaymentID].Status != RedemptionReleased {
+		t.Errorf("Expected the abandoned redemption released, got %s", coupons.redemptions[abandoned.PaymentID].Status)
+	}
+	if coupons.redemptions[open.PaymentID].Status != RedemptionPending {
+		t.Errorf("Expected the open redemption kept, got %s", coupons.redemptions[open.PaymentID].Status)
+	}
+	if coupons.coupons["WELCOME"].Redemptions != 1 {
+		t.Errorf("Expected 1 redemption left on the coupon, got %d", coupons.coupons["WELCOME"].Redemptions)
+	}
+
+	// The allowance is back, so user-1 can use the coupon again
+	if _, err := service.CreatePayment(ctx, "user-1", req); err != nil {
+		t.Errorf("Expected the coupon usable again, got %v", err)
+	}
+}
+
+func TestCreateCoupon_Validation(t *testing.T) {
+	service, _, _, _ := newCouponTestService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  CouponRequest
+	}{
+		{"invalid code", CouponRequest{Code: "10% OFF", DiscountType: CouponTypePercent, DiscountValue: 10}},
+		{"percent over 100", CouponRequest{Code: "BIG", DiscountType: CouponTypePercent, DiscountValue: 150}},
+		{"unknown type", CouponRequest{Code: "FREE", DiscountType: "gift", DiscountValue: 10}},
+		{"unknown plan", CouponRequest{Code: "PLAN", DiscountType: CouponTypeFixed, DiscountValue: 10, PlanIDs: []string{"missing"}}},
+	}
+	for _, tt := range tests {
+		if _, err := service.CreateCoupon(ctx, "admin-1", tt.req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", tt.name, err)
+		}
+	}
+
+	coupon, err := service.CreateCoupon(ctx, "admin-1", CouponRequest{Code: " summer-24 ", DiscountType: "Percent", DiscountValue: 15})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if coupon.Code != "SUMMER-24" || coupon.DiscountType != CouponTypePercent || !coupon.IsActive || coupon.MaxRedemptionsPerUser != 1 {
+		t.Errorf("Unexpected coupon %+v", coupon)
+	}
+	if _, err := service.CreateCoupon(ctx, "admin-1", CouponRequest{Code: "summer-24", DiscountType: CouponTypeFixed, DiscountValue: 5}); !errors.Is(err, ErrCouponCodeTaken) {
+		t.Errorf("Expected ErrCouponCodeTaken, got %v", err)
+	}
+}
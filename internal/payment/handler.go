@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,20 +34,20 @@ func (h *Handler) CreatePayment(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	var req CreatePaymentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	// Create payment
 	resp, err := h.service.CreatePayment(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -57,20 +59,20 @@ func (h *Handler) GetPaymentStatus(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	paymentID := c.Param("id")
 	if paymentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "payment ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "payment ID is required")
 		return
 	}
 
 	// Get payment status
 	resp, err := h.service.GetPaymentStatus(c.Request.Context(), userID.(string), paymentID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -82,21 +84,21 @@ func (h *Handler) GetPaymentHistory(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	// Parse query parameters
 	var req PaymentHistoryRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	// Get payment history
 	resp, err := h.service.GetPaymentHistory(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -108,7 +110,7 @@ func (h *Handler) GetPlans(c *gin.Context) {
 	// Get plans
 	plans, err := h.service.GetPlans(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -120,39 +122,274 @@ func (h *Handler) GetUserActivePlan(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	// Get user active plan
 	plan, err := h.service.GetUserActivePlan(c.Request.Context(), userID.(string))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusNotFound, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"plan": plan})
 }
 
+// ChangePlan handles upgrading or downgrading the active plan
+func (h *Handler) ChangePlan(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req ChangePlanRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.service.ChangePlan(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		if errors.Is(err, ErrPlanChangesUnavailable) {
+			common.RespondErr(c, http.StatusServiceUnavailable, err)
+			return
+		}
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// StartTrial handles starting the free trial of a plan
+func (h *Handler) StartTrial(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	resp, err := h.service.StartTrial(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrTrialsUnavailable) {
+			common.RespondErr(c, http.StatusServiceUnavailable, err)
+			return
+		}
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ValidateCoupon handles checking a coupon code against a plan
+func (h *Handler) ValidateCoupon(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req ValidateCouponRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	quote, err := h.service.ValidateCoupon(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		respondCouponErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// ListCoupons handles listing coupons for admins
+func (h *Handler) ListCoupons(c *gin.Context) {
+	var req CouponListRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.service.ListCoupons(c.Request.Context(), req)
+	if err != nil {
+		respondCouponErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateCoupon handles creating a coupon
+func (h *Handler) CreateCoupon(c *gin.Context) {
+	var req CouponRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		respondCouponErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"coupon": coupon})
+}
+
+// GetCoupon handles retrieving a coupon with its redemption stats
+func (h *Handler) GetCoupon(c *gin.Context) {
+	coupon, err := h.service.GetCoupon(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondCouponErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coupon": coupon})
+}
+
+// UpdateCoupon handles replacing a coupon's settings
+func (h *Handler) UpdateCoupon(c *gin.Context) {
+	var req CouponRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	coupon, err := h.service.UpdateCoupon(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		respondCouponErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coupon": coupon})
+}
+
+// DeleteCoupon handles deactivating a coupon
+func (h *Handler) DeleteCoupon(c *gin.Context) {
+	if err := h.service.DeleteCoupon(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		respondCouponErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondCouponErr writes a coupon error, answering unconfigured coupons with 503
+func respondCouponErr(c *gin.Context, fallbackStatus int, err error) {
+	if errors.Is(err, ErrCouponsUnavailable) {
+		common.RespondErr(c, http.StatusServiceUnavailable, err)
+		return
+	}
+	common.RespondErr(c, fallbackStatus, err)
+}
+
+// GetSpendingLimit handles retrieving the user's monthly spending limit
+func (h *Handler) GetSpendingLimit(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	limit, err := h.service.GetSpendingLimit(c.Request.Context(), userID.(string))
+	if err != nil {
+		respondSpendingLimitErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"spendingLimit": limit})
+}
+
+// UpdateSpendingLimit handles setting the user's monthly spending limit
+func (h *Handler) UpdateSpendingLimit(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req UpdateSpendingLimitRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := h.service.UpdateSpendingLimit(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		respondSpendingLimitErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"spendingLimit": limit})
+}
+
+// GetUserSpendingLimit handles retrieving a user's spending limit for an admin
+func (h *Handler) GetUserSpendingLimit(c *gin.Context) {
+	limit, err := h.service.GetUserSpendingLimit(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		respondSpendingLimitErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"spendingLimit": limit})
+}
+
+// EnforceSpendingLimit handles setting the admin-enforced spending limit of a user
+func (h *Handler) EnforceSpendingLimit(c *gin.Context) {
+	var req EnforceSpendingLimitRequest
+	if err := common.BindJSON(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := h.service.EnforceSpendingLimit(c.Request.Context(), c.GetString("user_id"), c.Param("userId"), req)
+	if err != nil {
+		respondSpendingLimitErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"spendingLimit": limit})
+}
+
+// respondSpendingLimitErr writes a spending limit error, answering
+// unconfigured spending limits with 503
+func respondSpendingLimitErr(c *gin.Context, fallbackStatus int, err error) {
+	if errors.Is(err, ErrSpendingLimitsUnavailable) {
+		common.RespondErr(c, http.StatusServiceUnavailable, err)
+		return
+	}
+	common.RespondErr(c, fallbackStatus, err)
+}
+
 // CancelPayment handles payment cancellation
 func (h *Handler) CancelPayment(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	paymentID := c.Param("id")
 	if paymentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "payment ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "payment ID is required")
 		return
 	}
 
 	// Cancel payment
 	err := h.service.CancelPayment(c.Request.Context(), userID.(string), paymentID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -164,7 +401,7 @@ func (h *Handler) WebhookHandler(c *gin.Context) {
 	// Parse webhook data based on gateway
 	gateway := c.Query("gateway")
 	if gateway == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "gateway parameter is required"})
+		common.RespondError(c, http.StatusBadRequest, "gateway parameter is required")
 		return
 	}
 
@@ -175,19 +412,19 @@ func (h *Handler) WebhookHandler(c *gin.Context) {
 	case GatewayZarinpal:
 		webhook, err = h.parseZarinpalWebhook(c)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported gateway"})
+		common.RespondError(c, http.StatusBadRequest, "unsupported gateway")
 		return
 	}
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	// Process webhook
 	err = h.service.VerifyPayment(c.Request.Context(), webhook)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -235,7 +472,7 @@ func (h *Handler) ZarinpalCallback(c *gin.Context) {
 	// Parse callback data from query parameters
 	trackID := c.Query("trackId")
 	if trackID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "trackId parameter is required"})
+		common.RespondError(c, http.StatusBadRequest, "trackId parameter is required")
 		return
 	}
 
@@ -251,7 +488,7 @@ func (h *Handler) ZarinpalCallback(c *gin.Context) {
 	// Verify and process payment
 	err := h.service.VerifyPayment(c.Request.Context(), webhook)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -287,14 +524,14 @@ func (h *Handler) PayPlanZarinpal(c *gin.Context) {
 	if !exists {
 		userID = c.GetString("user_id")
 		if userID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 			return
 		}
 	}
 
 	planID := c.Param("id")
 	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan id is required"})
+		common.RespondError(c, http.StatusBadRequest, "plan id is required")
 		return
 	}
 
@@ -302,6 +539,7 @@ func (h *Handler) PayPlanZarinpal(c *gin.Context) {
 	var req struct {
 		ReturnURL   string `json:"returnUrl,omitempty"`
 		Description string `json:"description,omitempty"`
+		CouponCode  string `json:"couponCode,omitempty"`
 	}
 	_ = c.ShouldBindJSON(&req) // Ignore error, use defaults if not provided
 
@@ -315,12 +553,13 @@ func (h *Handler) PayPlanZarinpal(c *gin.Context) {
 		PlanID:      planID,
 		ReturnURL:   req.ReturnURL,
 		Description: req.Description,
+		CouponCode:  req.CouponCode,
 	}
 
 	// Create payment using Zarinpal gateway
 	resp, err := h.service.CreatePayment(c.Request.Context(), userID.(string), paymentReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -330,6 +569,9 @@ func (h *Handler) PayPlanZarinpal(c *gin.Context) {
 			"payment_id":  resp.PaymentID,
 			"gateway_url": resp.GatewayURL,
 			"track_id":    resp.TrackID,
+			"amount":      resp.Amount,
+			"coupon_code": resp.CouponCode,
+			"discount":    resp.DiscountAmount,
 			"expires_at":  resp.ExpiresAt,
 		},
 	})
@@ -342,7 +584,7 @@ func (h *Handler) PayPlanZarinpal(c *gin.Context) {
 // PayPlanBazaarPay - ایجاد لینک پرداخت برای خرید پلن
 func (h *Handler) PayPlanBazaarPay(c *gin.Context) {
 	if h.bazaarPayService == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "BazaarPay service not initialized"})
+		common.RespondError(c, http.StatusInternalServerError, "BazaarPay service not initialized")
 		return
 	}
 
@@ -351,21 +593,21 @@ func (h *Handler) PayPlanBazaarPay(c *gin.Context) {
 	if !exists {
 		userID = c.GetString("user_id")
 		if userID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 			return
 		}
 	}
 
 	planID := c.Param("id")
 	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan id is required"})
+		common.RespondError(c, http.StatusBadRequest, "plan id is required")
 		return
 	}
 
 	// Get plan details using service
 	plans, err := h.service.GetPlans(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "failed to get plans"})
+		common.RespondError(c, http.StatusNotFound, "failed to get plans")
 		return
 	}
 
@@ -377,7 +619,12 @@ func (h *Handler) PayPlanBazaarPay(c *gin.Context) {
 		}
 	}
 	if plan == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
+		common.RespondError(c, http.StatusNotFound, "plan not found")
+		return
+	}
+
+	if err := h.service.CheckSpendingLimit(c.Request.Context(), userID.(string), plan.PricePerMonthCents); err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -395,7 +642,7 @@ func (h *Handler) PayPlanBazaarPay(c *gin.Context) {
 		userPhone,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -416,7 +663,7 @@ func (h *Handler) BazaarPayStatusPage(c *gin.Context) {
 // BazaarPayCheckStatus - API برای بررسی وضعیت (AJAX)
 func (h *Handler) BazaarPayCheckStatus(c *gin.Context) {
 	if h.bazaarPayService == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "BazaarPay service not initialized"})
+		common.RespondError(c, http.StatusInternalServerError, "BazaarPay service not initialized")
 		return
 	}
 
@@ -38,6 +38,7 @@ type PaymentPlan struct {
 	MonthlyImagesLimit      int       `json:"monthlyImagesLimit"`
 	Features                []string  `json:"features"`
 	IsActive                bool      `json:"isActive"`
+	TrialDays               int       `json:"trialDays"` // 0 offers no trial
 	CreatedAt               time.Time `json:"createdAt"`
 	UpdatedAt               time.Time `json:"updatedAt"`
 }
@@ -55,16 +56,21 @@ type PaymentWebhook struct {
 // CreatePaymentRequest represents the request to create a payment
 type CreatePaymentRequest struct {
 	PlanID      string `json:"planId" binding:"required"`
-	ReturnURL   string `json:"returnUrl" binding:"required"`
+	ReturnURL   string `json:"returnUrl" binding:"required,url"`
 	Description string `json:"description,omitempty"`
+	CouponCode  string `json:"couponCode,omitempty"`
 }
 
 // CreatePaymentResponse represents the response for creating a payment
 type CreatePaymentResponse struct {
-	PaymentID  string    `json:"paymentId"`
-	GatewayURL string    `json:"gatewayUrl"`
-	TrackID    string    `json:"trackId"`
-	ExpiresAt  time.Time `json:"expiresAt"`
+	PaymentID       string    `json:"paymentId"`
+	GatewayURL      string    `json:"gatewayUrl"`
+	TrackID         string    `json:"trackId"`
+	Amount          int64     `json:"amount"`
+	CouponCode      string    `json:"couponCode,omitempty"`
+	DiscountAmount  int64     `json:"discountAmount,omitempty"`
+	PlanCreditCents int64     `json:"planCreditCents,omitempty"` // downgrade credit taken off the amount
+	ExpiresAt       time.Time `json:"expiresAt"`
 }
 
 // PaymentStatusResponse represents the response for payment status
@@ -124,9 +130,9 @@ type ZarinpalVerifyResponse struct {
 
 // PaymentHistoryRequest represents the request to get payment history
 type PaymentHistoryRequest struct {
-	Page     int    `json:"page" form:"page"`
-	PageSize int    `json:"pageSize" form:"pageSize"`
-	Status   string `json:"status" form:"status"`
+	Page     int    `json:"page" form:"page" binding:"omitempty,min=1"`
+	PageSize int    `json:"pageSize" form:"pageSize" binding:"omitempty,min=1"`
+	Status   string `json:"status" form:"status" binding:"omitempty,oneof=pending completed failed cancelled expired"`
 }
 
 // PaymentHistoryItem represents a single item in payment history
@@ -0,0 +1,563 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Directions of a plan change
+const (
+	PlanChangeUpgrade   = "upgrade"
+	PlanChangeDowngrade = "downgrade"
+)
+
+var (
+	// ErrNoActivePlan is returned when the user has no active plan to change
+	ErrNoActivePlan = fmt.Errorf("active plan %w", common.ErrNotFound)
+	// ErrSamePlan is returned when the user is already on the requested plan
+	ErrSamePlan = fmt.Errorf("%w: already subscribed to this plan", common.ErrConflict)
+	// ErrPlanChangeConflict is returned when the active plan changed during the request
+	ErrPlanChangeConflict = fmt.Errorf("%w: active plan changed, try again", common.ErrConflict)
+	// ErrPlanChangesUnavailable is returned when plan changes are not configured
+	ErrPlanChangesUnavailable = errors.New("plan changes are not available")
+)
+
+// ChangePlanRequest represents a request to switch the active plan
+type ChangePlanRequest struct {
+	PlanID    string `json:"planId" binding:"required"`
+	ReturnURL string `json:"returnUrl"` // required when the change leaves an amount to pay
+}
+
+// ActivePlanCycle is the active plan of a user within its billing cycle
+type ActivePlanCycle struct {
+	UserPlanID         string
+	PlanName           string
+	PricePerMonthCents int64
+	ConversionsUsed    int
+	CycleStart         *time.Time
+	CycleEnd           *time.Time // exclusive
+	BalanceCents       int64      // credit (negative) carried over from earlier changes
+}
+
+// Proration is the prorated charge and credit of a change within a billing cycle
+type Proration struct {
+	ChargeCents int64 `json:"chargeCents"` // new plan for the rest of the cycle
+	CreditCents int64 `json:"creditCents"` // unused part of the current plan
+	NetCents    int64 `json:"netCents"`    // charge minus credit; negative is a credit
+}
+
+// PlanChange is a plan switch as recorded in user_plans
+type PlanChange struct {
+	PaymentID          string // payment the change waits for, empty when nothing was due
+	UserID             string
+	FromUserPlanID     string
+	FromPlanName       string
+	ToPlanName         string
+	MonthlyLimit       int
+	PricePerMonthCents int64
+	Direction          string
+	Proration          Proration
+	BalanceCents       int64
+	ConversionsUsed    int
+	CycleStart         time.Time
+	CycleEnd           time.Time
+}
+
+// ChangePlanResponse represents the outcome of a plan change
+type ChangePlanResponse struct {
+	UserPlanID      string      `json:"userPlanId,omitempty"` // empty until the payment is verified
+	PreviousPlan    string      `json:"previousPlan"`
+	Plan            PaymentPlan `json:"plan"`
+	Direction       string      `json:"direction"`
+	Proration       Proration   `json:"proration"`
+	BalanceCents    int64       `json:"balanceCents"` // credit taken off the next upgrade or plan purchase
+	ConversionsUsed int         `json:"conversionsUsed"`
+	CycleEnd        time.Time   `json:"cycleEnd"`
+	// Payment is set when the change applies only once this payment is verified
+	Payment *CreatePaymentResponse `json:"payment,omitempty"`
+}
+
+// PlanChangeStore reads and switches the active plan of a user
+type PlanChangeStore interface {
+	GetActivePlanCycle(ctx context.Context, userID string) (ActivePlanCycle, error)
+	// ChangeUserPlan closes the active plan if it is still change.FromUserPlanID,
+	// opens the new plan for the same cycle and writes an audit entry. It
+	// returns the ID of the new user plan.
+	ChangeUserPlan(ctx context.Context, change PlanChange) (string, error)
+	// SavePendingChange holds a change until its payment is verified
+	SavePendingChange(ctx context.Context, change PlanChange) error
+	// GetPendingChange returns the change a payment pays for that has not been
+	// applied yet; found is false when the payment is not for a plan change
+	GetPendingChange(ctx context.Context, paymentID string) (change PlanChange, found bool, err error)
+	// GetPlanCredit returns the credit left on the user's latest plan
+	GetPlanCredit(ctx context.Context, userID string) (int64, error)
+	// SetPaymentPlanCredit records the credit a plan purchase used
+	SetPaymentPlanCredit(ctx context.Context, paymentID string, credit int64) error
+	// SettlePlanCredit carries the credit a verified purchase did not use over
+	// to the plan it activated
+	SettlePlanCredit(ctx context.Context, userID, paymentID string) error
+}
+
+// SetPlanChanges enables upgrading and downgrading the active plan mid-cycle
+func (s *Service) SetPlanChanges(store PlanChangeStore) {
+	s.planChanges = store
+}
+
+// ChangePlan switches the user's active plan to req.PlanID. The new plan keeps
+// the conversions already used in the cycle. When the difference in price for
+// the rest of the cycle, less earlier credit, leaves an amount due, a gateway
+// payment is created and the change applies once it is verified; otherwise the
+// change applies immediately and the credit stays on the balance.
+func (s *Service) ChangePlan(ctx context.Context, userID string, req ChangePlanRequest) (ChangePlanResponse, error) {
+	if s.planChanges == nil {
+		return ChangePlanResponse{}, ErrPlanChangesUnavailable
+	}
+
+	rateLimitKey := fmt.Sprintf("plan_change:user:%s", userID)
+	if !s.rateLimiter.Allow(ctx, rateLimitKey, 5, time.Hour) {
+		return ChangePlanResponse{}, errors.New("rate limit exceeded")
+	}
+
+	plan, err := s.store.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return ChangePlanResponse{}, fmt.Errorf("failed to get plan: %w", err)
+	}
+	if !plan.IsActive {
+		return ChangePlanResponse{}, errors.New("plan is not active")
+	}
+
+	current, err := s.planChanges.GetActivePlanCycle(ctx, userID)
+	if err != nil {
+		return ChangePlanResponse{}, err
+	}
+	if current.PlanName == plan.Name {
+		return ChangePlanResponse{}, ErrSamePlan
+	}
+
+	now := time.Now()
+	cycleStart, cycleEnd := now, now.AddDate(0, 1, 0)
+	used := 0
+	if current.CycleStart != nil && current.CycleEnd != nil && now.Before(*current.CycleEnd) {
+		cycleStart, cycleEnd = *current.CycleStart, *current.CycleEnd
+		used = current.ConversionsUsed
+	}
+
+	proration := ProratePlanChange(current.PricePerMonthCents, plan.PricePerMonthCents, cycleStart, cycleEnd, now)
+	direction := PlanChangeDowngrade
+	if plan.PricePerMonthCents > current.PricePerMonthCents {
+		direction = PlanChangeUpgrade
+	}
+
+	change := PlanChange{
+		UserID:             userID,
+		FromUserPlanID:     current.UserPlanID,
+		FromPlanName:       current.PlanName,
+		ToPlanName:         plan.Name,
+		MonthlyLimit:       plan.MonthlyConversionsLimit,
+		PricePerMonthCents: plan.PricePerMonthCents,
+		Direction:          direction,
+		Proration:          proration,
+		BalanceCents:       current.BalanceCents + proration.NetCents,
+		ConversionsUsed:    used,
+		CycleStart:         cycleStart,
+		CycleEnd:           cycleEnd,
+	}
+	if change.BalanceCents > 0 {
+		return s.startPlanChangePayment(ctx, change, plan, req.ReturnURL)
+	}
+
+	userPlanID, err := s.planChanges.ChangeUserPlan(ctx, change)
+	if err != nil {
+		return ChangePlanResponse{}, err
+	}
+	invalidatePlan(ctx, s.cache, userID)
+
+	_ = s.notifier.SendPlanActivated(ctx, userID, plan.Name)
+
+	return ChangePlanResponse{
+		UserPlanID:      userPlanID,
+		PreviousPlan:    current.PlanName,
+		Plan:            plan,
+		Direction:       direction,
+		Proration:       proration,
+		BalanceCents:    change.BalanceCents,
+		ConversionsUsed: used,
+		CycleEnd:        cycleEnd,
+	}, nil
+}
+
+// startPlanChangePayment creates the gateway payment for the amount a change
+// leaves due and holds the change until the payment is verified
+func (s *Service) startPlanChangePayment(ctx context.Context, change PlanChange, plan PaymentPlan, returnURL string) (ChangePlanResponse, error) {
+	if returnURL == "" {
+		return ChangePlanResponse{}, fmt.Errorf("%w: return URL is required to pay for the plan change", common.ErrValidation)
+	}
+
+	// The gateways take no less than MinPaymentAmount; the rest becomes credit
+	amount := max(change.BalanceCents, MinPaymentAmount)
+	change.BalanceCents -= amount
+	if err := s.CheckSpendingLimit(ctx, change.UserID, amount); err != nil {
+		return ChangePlanResponse{}, err
+	}
+
+	now := time.Now()
+	change.PaymentID = generatePaymentID()
+	payment := Payment{
+		ID:            change.PaymentID,
+		UserID:        change.UserID,
+		PlanID:        plan.ID,
+		Amount:        amount,
+		Currency:      CurrencyIRR,
+		Status:        PaymentStatusPending,
+		PaymentMethod: s.gateway.GetGatewayName(),
+		Gateway:       s.gateway.GetGatewayName(),
+		Description:   fmt.Sprintf("Plan change from %s to %s", change.FromPlanName, change.ToPlanName),
+		CallbackURL:   s.configService.GetPaymentCallbackURL(),
+		ReturnURL:     returnURL,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		ExpiresAt:     timePtr(now.Add(time.Duration(s.configService.GetPaymentExpiryMinutes()) * time.Minute)),
+	}
+	if _, err := s.store.CreatePayment(ctx, payment); err != nil {
+		return ChangePlanResponse{}, fmt.Errorf("failed to create payment record: %w", err)
+	}
+
+	if err := s.planChanges.SavePendingChange(ctx, change); err != nil {
+		s.store.UpdatePayment(ctx, payment.ID, map[string]interface{}{
+			"status": PaymentStatusFailed,
+		})
+		return ChangePlanResponse{}, err
+	}
+
+	gatewayResp, err := s.gateway.CreatePayment(ctx, ZarinpalRequest{
+		Amount:      amount,
+		CallbackURL: payment.CallbackURL,
+		Description: payment.Description,
+		OrderID:     payment.ID,
+	})
+	if err != nil {
+		s.store.UpdatePayment(ctx, payment.ID, map[string]interface{}{
+			"status": PaymentStatusFailed,
+		})
+		return ChangePlanResponse{}, fmt.Errorf("failed to create gateway payment: %w", err)
+	}
+
+	updatedPayment, err := s.store.UpdatePayment(ctx, payment.ID, map[string]interface{}{
+		"gateway_track_id": gatewayResp.TrackID,
+	})
+	if err != nil {
+		return ChangePlanResponse{}, fmt.Errorf("failed to update payment with track ID: %w", err)
+	}
+
+	_ = s.auditLogger.LogPaymentAction(ctx, change.UserID, "plan_change_payment_created", map[string]interface{}{
+		"payment_id": payment.ID,
+		"from_plan":  change.FromPlanName,
+		"to_plan":    change.ToPlanName,
+		"amount":     amount,
+		"track_id":   gatewayResp.TrackID,
+	})
+
+	return ChangePlanResponse{
+		PreviousPlan:    change.FromPlanName,
+		Plan:            plan,
+		Direction:       change.Direction,
+		Proration:       change.Proration,
+		BalanceCents:    change.BalanceCents,
+		ConversionsUsed: change.ConversionsUsed,
+		CycleEnd:        change.CycleEnd,
+		Payment: &CreatePaymentResponse{
+			PaymentID:  payment.ID,
+			GatewayURL: s.gateway.GetPaymentURL(gatewayResp.TrackID),
+			TrackID:    gatewayResp.TrackID,
+			Amount:     amount,
+			ExpiresAt:  *updatedPayment.ExpiresAt,
+		},
+	}, nil
+}
+
+// applyPaidPlanChange applies the plan change a verified payment paid for. It
+// reports false when the payment is not for a plan change.
+func (s *Service) applyPaidPlanChange(ctx context.Context, payment Payment) (bool, error) {
+	if s.planChanges == nil {
+		return false, nil
+	}
+	change, found, err := s.planChanges.GetPendingChange(ctx, payment.ID)
+	if err != nil || !found {
+		return found, err
+	}
+
+	// Conversions used while the payment was open stay used
+	current, err := s.planChanges.GetActivePlanCycle(ctx, payment.UserID)
+	if err != nil {
+		return true, err
+	}
+	if current.UserPlanID == change.FromUserPlanID && current.CycleStart != nil && current.CycleStart.Equal(change.CycleStart) {
+		change.ConversionsUsed = current.ConversionsUsed
+	}
+
+	if _, err := s.planChanges.ChangeUserPlan(ctx, change); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// planCredit returns the part of the user's downgrade credit a purchase of
+// amount can use, keeping at least MinPaymentAmount to pay
+func (s *Service) planCredit(ctx context.Context, userID string, amount int64) (int64, error) {
+	if s.planChanges == nil {
+		return 0, nil
+	}
+	credit, err := s.planChanges.GetPlanCredit(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return max(0, min(credit, amount-MinPaymentAmount)), nil
+}
+
+// ProratePlanChange prices a switch from oldPrice to newPrice at now for the
+// rest of the cycle [cycleStart, cycleEnd). A cycle that has not started is
+// prorated in full and one that has ended is not prorated.
+func ProratePlanChange(oldPrice, newPrice int64, cycleStart, cycleEnd, now time.Time) Proration {
+	total := cycleEnd.Sub(cycleStart)
+	if total <= 0 {
+		return Proration{}
+	}
+	remaining := cycleEnd.Sub(now)
+	switch {
+	case remaining <= 0:
+		return Proration{}
+	case remaining > total:
+		remaining = total
+	}
+
+	// Whole seconds keep the products well inside int64 for any plan price
+	totalSeconds, remainingSeconds := int64(total/time.Second), int64(remaining/time.Second)
+	if totalSeconds == 0 {
+		return Proration{}
+	}
+	charge := newPrice * remainingSeconds / totalSeconds
+	credit := oldPrice * remainingSeconds / totalSeconds
+	return Proration{ChargeCents: charge, CreditCents: credit, NetCents: charge - credit}
+}
+
+// dbPlanChangeStore implements PlanChangeStore on top of user_plans and payment_plans
+type dbPlanChangeStore struct {
+	db *sql.DB
+}
+
+// NewDBPlanChangeStore creates a new database-backed plan change store
+func NewDBPlanChangeStore(db *sql.DB) PlanChangeStore {
+	return &dbPlanChangeStore{db: db}
+}
+
+// GetActivePlanCycle reads the newest active plan, matching plan definitions
+// by name like the quota store does
+func (s *dbPlanChangeStore) GetActivePlanCycle(ctx context.Context, userID string) (ActivePlanCycle, error) {
+	var cycle ActivePlanCycle
+	var cycleStart, cycleEnd sql.NullTime
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT up.id, up.plan_name,
+		       COALESCE(NULLIF(up.price_per_month_cents, 0), pp.price_per_month_cents, 0),
+		       up.conversions_used_this_month, up.billing_cycle_start_date, up.billing_cycle_end_date,
+		       up.proration_balance_cents
+		FROM user_plans up
+		LEFT JOIN payment_plans pp ON pp.name = up.plan_name
+		WHERE up.user_id = $1 AND up.status = 'active'
+		  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+		ORDER BY up.created_at DESC
+		LIMIT 1`, userID).Scan(&cycle.UserPlanID, &cycle.PlanName, &cycle.PricePerMonthCents,
+		&cycle.ConversionsUsed, &cycleStart, &cycleEnd, &cycle.BalanceCents)
+	switch {
+	case err == sql.ErrNoRows:
+		return ActivePlanCycle{}, ErrNoActivePlan
+	case err != nil:
+		return ActivePlanCycle{}, fmt.Errorf("failed to get active plan: %w", err)
+	}
+	if cycleStart.Valid {
+		cycle.CycleStart = &cycleStart.Time
+	}
+	if cycleEnd.Valid {
+		cycle.CycleEnd = &cycleEnd.Time
+	}
+	return cycle, nil
+}
+
+// ChangeUserPlan switches plans in one transaction, in the caller's unit of
+// work when there is one. A concurrent change closes the previous plan first,
+// so the second one fails with ErrPlanChangeConflict.
+func (s *dbPlanChangeStore) ChangeUserPlan(ctx context.Context, change PlanChange) (string, error) {
+	var userPlanID string
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE user_plans
+			SET status = 'cancelled', updated_at = NOW()
+			WHERE id = $1 AND status = 'active'`, change.FromUserPlanID)
+		if err != nil {
+			return fmt.Errorf("failed to close current plan: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to close current plan: %w", err)
+		}
+		if affected == 0 {
+			return ErrPlanChangeConflict
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO user_plans (
+				user_id, plan_name, status, monthly_conversions_limit,
+				conversions_used_this_month, price_per_month_cents,
+				billing_cycle_start_date, billing_cycle_end_date, auto_renew,
+				previous_user_plan_id, change_direction,
+				proration_charge_cents, proration_credit_cents, proration_balance_cents
+			)
+			SELECT user_id, $2, 'active', $3, $4, $5, $6, $7, auto_renew, id, $8, $9, $10, $11
+			FROM user_plans
+			WHERE id = $1
+			RETURNING id`,
+			change.FromUserPlanID, change.ToPlanName, change.MonthlyLimit, change.ConversionsUsed,
+			change.PricePerMonthCents, change.CycleStart, change.CycleEnd, change.Direction,
+			change.Proration.ChargeCents, change.Proration.CreditCents, change.BalanceCents).Scan(&userPlanID)
+		if err != nil {
+			return fmt.Errorf("failed to open new plan: %w", err)
+		}
+
+		if change.PaymentID != "" {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE plan_change_payments SET applied_at = NOW()
+				WHERE payment_id = $1`, change.PaymentID); err != nil {
+				return fmt.Errorf("failed to mark plan change applied: %w", err)
+			}
+		}
+
+		metadata, err := json.Marshal(map[string]interface{}{
+			"from_plan":        change.FromPlanName,
+			"to_plan":          change.ToPlanName,
+			"direction":        change.Direction,
+			"charge_cents":     change.Proration.ChargeCents,
+			"credit_cents":     change.Proration.CreditCents,
+			"balance_cents":    change.BalanceCents,
+			"previous_plan":    change.FromUserPlanID,
+			"conversions_used": change.ConversionsUsed,
+			"payment_id":       change.PaymentID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode plan change metadata: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO audit_logs (user_id, actor_type, action, resource, resource_id, metadata)
+			VALUES ($1, 'user', 'plan_changed', 'user_plan', $2, $3)`,
+			change.UserID, userPlanID, metadata); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return userPlanID, nil
+}
+
+// SavePendingChange records the change change.PaymentID pays for
+func (s *dbPlanChangeStore) SavePendingChange(ctx context.Context, change PlanChange) error {
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		INSERT INTO plan_change_payments (
+			payment_id, user_id, from_user_plan_id, to_plan_name, monthly_conversions_limit,
+			price_per_month_cents, change_direction, proration_charge_cents,
+			proration_credit_cents, proration_balance_cents, conversions_used,
+			billing_cycle_start_date, billing_cycle_end_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		change.PaymentID, change.UserID, change.FromUserPlanID, change.ToPlanName, change.MonthlyLimit,
+		change.PricePerMonthCents, change.Direction, change.Proration.ChargeCents,
+		change.Proration.CreditCents, change.BalanceCents, change.ConversionsUsed,
+		change.CycleStart, change.CycleEnd)
+	if err != nil {
+		return fmt.Errorf("failed to save pending plan change: %w", err)
+	}
+	return nil
+}
+
+// GetPendingChange reads the unapplied change of a payment
+func (s *dbPlanChangeStore) GetPendingChange(ctx context.Context, paymentID string) (PlanChange, bool, error) {
+	change := PlanChange{PaymentID: paymentID}
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT pcp.user_id, pcp.from_user_plan_id, COALESCE(up.plan_name, ''), pcp.to_plan_name,
+		       pcp.monthly_conversions_limit, pcp.price_per_month_cents, pcp.change_direction,
+		       pcp.proration_charge_cents, pcp.proration_credit_cents, pcp.proration_balance_cents,
+		       pcp.conversions_used, pcp.billing_cycle_start_date, pcp.billing_cycle_end_date
+		FROM plan_change_payments pcp
+		LEFT JOIN user_plans up ON up.id = pcp.from_user_plan_id
+		WHERE pcp.payment_id = $1 AND pcp.applied_at IS NULL`, paymentID).Scan(
+		&change.UserID, &change.FromUserPlanID, &change.FromPlanName, &change.ToPlanName,
+		&change.MonthlyLimit, &change.PricePerMonthCents, &change.Direction,
+		&change.Proration.ChargeCents, &change.Proration.CreditCents, &change.BalanceCents,
+		&change.ConversionsUsed, &change.CycleStart, &change.CycleEnd)
+	switch {
+	case err == sql.ErrNoRows:
+		return PlanChange{}, false, nil
+	case err != nil:
+		return PlanChange{}, false, fmt.Errorf("failed to get pending plan change: %w", err)
+	}
+	change.Proration.NetCents = change.Proration.ChargeCents - change.Proration.CreditCents
+	return change, true, nil
+}
+
+// GetPlanCredit reads the credit on the user's newest plan, active or not
+func (s *dbPlanChangeStore) GetPlanCredit(ctx context.Context, userID string) (int64, error) {
+	var credit int64
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT GREATEST(-proration_balance_cents, 0)
+		FROM user_plans
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`, userID).Scan(&credit)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to get plan credit: %w", err)
+	}
+	return credit, nil
+}
+
+// SetPaymentPlanCredit stores the credit a purchase used on its payment
+func (s *dbPlanChangeStore) SetPaymentPlanCredit(ctx context.Context, paymentID string, credit int64) error {
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE payments SET plan_credit_cents = $2 WHERE id = $1`, paymentID, credit)
+	if err != nil {
+		return fmt.Errorf("failed to record plan credit: %w", err)
+	}
+	return nil
+}
+
+// SettlePlanCredit moves the credit the previous plan had left, less what the
+// payment used, onto the newly activated plan
+func (s *dbPlanChangeStore) SettlePlanCredit(ctx context.Context, userID, paymentID string) error {
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
+		UPDATE user_plans up
+		SET proration_balance_cents = LEAST(0, COALESCE((
+		        SELECT prev.proration_balance_cents
+		        FROM user_plans prev
+		        WHERE prev.user_id = up.user_id AND prev.id <> up.id
+		        ORDER BY prev.created_at DESC
+		        LIMIT 1), 0) + p.plan_credit_cents),
+		    updated_at = NOW()
+		FROM payments p
+		WHERE p.id = $2
+		  AND up.id = (
+		        SELECT id FROM user_plans
+		        WHERE user_id = $1 AND status = 'active'
+		        ORDER BY created_at DESC
+		        LIMIT 1)`, userID, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to settle plan credit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type fakePlanChangeStore struct {
+	active         ActivePlanCycle
+	changes        []PlanChange
+	pending        map[string]PlanChange
+	credit         int64
+	paymentCredits map[string]int64
+	settled        []string // payments whose leftover credit was carried over
+}
+
+func (f *fakePlanChangeStore) GetActivePlanCycle(ctx context.Context, userID string) (ActivePlanCycle, error) {
+	if f.active.UserPlanID == "" {
+		return ActivePlanCycle{}, ErrNoActivePlan
+	}
+	return f.active, nil
+}
+
+func (f *fakePlanChangeStore) ChangeUserPlan(ctx context.Context, change PlanChange) (string, error) {
+	if change.FromUserPlanID != f.active.UserPlanID {
+		return "", ErrPlanChangeConflict
+	}
+	f.changes = append(f.changes, change)
+	f.active = ActivePlanCycle{
+		UserPlanID:         "up-2",
+		PlanName:           change.ToPlanName,
+		PricePerMonthCents: change.PricePerMonthCents,
+		ConversionsUsed:    change.ConversionsUsed,
+		CycleStart:         &change.CycleStart,
+		CycleEnd:           &change.CycleEnd,
+		BalanceCents:       change.BalanceCents,
+	}
+	delete(f.pending, change.PaymentID)
+	return "up-2", nil
+}
+
+func (f *fakePlanChangeStore) SavePendingChange(ctx context.Context, change PlanChange) error {
+	f.pending[change.PaymentID] = change
+	return nil
+}
+
+func (f *fakePlanChangeStore) GetPendingChange(ctx context.Context, paymentID string) (PlanChange, bool, error) {
+	change, found := f.pending[paymentID]
+	return change, found, nil
+}
+
+func (f *fakePlanChangeStore) GetPlanCredit(ctx context.Context, userID string) (int64, error) {
+	return f.credit, nil
+}
+
+func (f *fakePlanChangeStore) SetPaymentPlanCredit(ctx context.Context, paymentID string, credit int64) error {
+	f.paymentCredits[paymentID] = credit
+	return nil
+}
+
+func (f *fakePlanChangeStore) SettlePlanCredit(ctx context.Context, userID, paymentID string) error {
+	f.settled = append(f.settled, paymentID)
+	return nil
+}
+
+func newPlanChangeTestService(active ActivePlanCycle) (*Service, *fakePlanChangeStore) {
+	store := newMockStore()
+	store.plans["plan-2"] = PaymentPlan{
+		ID:                      "plan-2",
+		Name:                    "advanced",
+		PricePerMonthCents:      150000,
+		MonthlyConversionsLimit: 100,
+		IsActive:                true,
+	}
+	service := NewService(store, newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	changes := &fakePlanChangeStore{active: active, pending: make(map[string]PlanChange), paymentCredits: make(map[string]int64)}
+	service.SetPlanChanges(changes)
+	return service, changes
+}
+
+func TestProratePlanChange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want Proration
+	}{
+		{"halfway upgrade", start.AddDate(0, 0, 15), Proration{ChargeCents: 75000, CreditCents: 25000, NetCents: 50000}},
+		{"before cycle start", start.AddDate(0, 0, -1), Proration{ChargeCents: 150000, CreditCents: 50000, NetCents: 100000}},
+		{"cycle over", end.Add(time.Hour), Proration{}},
+	}
+	for _, tt := range tests {
+		if got := ProratePlanChange(50000, 150000, start, end, tt.now); got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+
+	// Downgrades leave a credit
+	got := ProratePlanChange(150000, 50000, start, end, start.AddDate(0, 0, 15))
+	if got.NetCents != -50000 {
+		t.Errorf("Expected a credit of 50000, got %+v", got)
+	}
+}
+
+func TestChangePlan_UpgradeAndDowngrade(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cycleStart, cycleEnd := now.AddDate(0, 0, -10), now.AddDate(0, 0, 20)
+	service, changes := newPlanChangeTestService(ActivePlanCycle{
+		UserPlanID:         "up-1",
+		PlanName:           "basic",
+		PricePerMonthCents: 50000,
+		ConversionsUsed:    12,
+		CycleStart:         &cycleStart,
+		CycleEnd:           &cycleEnd,
+	})
+	req := ChangePlanRequest{PlanID: "plan-2", ReturnURL: "https://test.com/return"}
+
+	if _, err := service.ChangePlan(ctx, "user-1", ChangePlanRequest{PlanID: "plan-2"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an upgrade without a return URL to be rejected, got %v", err)
+	}
+
+	// An upgrade waits for the payment of the prorated charge
+	upgrade, err := service.ChangePlan(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if upgrade.Direction != PlanChangeUpgrade || upgrade.PreviousPlan != "basic" {
+		t.Errorf("Expected an upgrade from basic, got %s from %s", upgrade.Direction, upgrade.PreviousPlan)
+	}
+	if upgrade.Payment == nil || upgrade.Payment.Amount != upgrade.Proration.NetCents || upgrade.BalanceCents != 0 {
+		t.Fatalf("Expected a payment for the prorated charge, got %+v", upgrade)
+	}
+	if len(changes.changes) != 0 || changes.active.PlanName != "basic" {
+		t.Fatalf("Expected the plan to stay basic until the payment is verified, got %+v", changes.active)
+	}
+
+	// Conversions used while paying stay used
+	changes.active.ConversionsUsed = 13
+	if err := service.VerifyPayment(ctx, PaymentWebhook{TrackID: upgrade.Payment.TrackID}); err != nil {
+		t.Fatalf("Expected the payment to verify, got %v", err)
+	}
+	if len(changes.changes) != 1 {
+		t.Fatalf("Expected the upgrade to apply once paid, got %d changes", len(changes.changes))
+	}
+	// Quota switches to the new plan but keeps the conversions already used
+	change := changes.changes[0]
+	if change.MonthlyLimit != 100 || change.ConversionsUsed != 13 || !change.CycleEnd.Equal(cycleEnd) {
+		t.Errorf("Expected limit 100 with 13 used in the same cycle, got %+v", change)
+	}
+	if change.PaymentID != upgrade.Payment.PaymentID || len(changes.pending) != 0 {
+		t.Errorf("Expected the paid change to be applied, got %+v", change)
+	}
+
+	if _, err := service.ChangePlan(ctx, "user-1", req); !errors.Is(err, ErrSamePlan) {
+		t.Errorf("Expected same plan error, got %v", err)
+	}
+
+	// A downgrade applies immediately and leaves a credit
+	downgrade, err := service.ChangePlan(ctx, "user-1", ChangePlanRequest{PlanID: "plan-1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if downgrade.Direction != PlanChangeDowngrade || downgrade.Proration.NetCents >= 0 || downgrade.Payment != nil {
+		t.Errorf("Expected a downgrade with a credit, got %s and %+v", downgrade.Direction, downgrade.Proration)
+	}
+	if downgrade.BalanceCents != downgrade.Proration.NetCents || changes.active.PlanName != "basic" {
+		t.Errorf("Expected the credit on the balance, got %d", downgrade.BalanceCents)
+	}
+
+	// The credit pays for the next upgrade
+	again, err := service.ChangePlan(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if again.Payment != nil || again.BalanceCents > 0 || again.BalanceCents != downgrade.BalanceCents+again.Proration.NetCents {
+		t.Errorf("Expected the credit to cover the upgrade, got %+v", again)
+	}
+}
+
+func TestChangePlan_SmallChargeAndSpendingLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	// A day left of the cycle prorates the upgrade below what the gateways take
+	cycleStart, cycleEnd := now.AddDate(0, 0, -29), now.AddDate(0, 0, 1)
+	service, changes := newPlanChangeTestService(ActivePlanCycle{
+		UserPlanID:         "up-1",
+		PlanName:           "basic",
+		PricePerMonthCents: 50000,
+		CycleStart:         &cycleStart,
+		CycleEnd:           &cycleEnd,
+	})
+	req := ChangePlanRequest{PlanID: "plan-2", ReturnURL: "https://test.com/return"}
+
+	resp, err := service.ChangePlan(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Payment == nil || resp.Payment.Amount != MinPaymentAmount {
+		t.Fatalf("Expected a payment of the minimum amount, got %+v", resp.Payment)
+	}
+	if resp.BalanceCents != resp.Proration.NetCents-MinPaymentAmount || resp.BalanceCents >= 0 {
+		t.Errorf("Expected the overpayment as credit, got %d", resp.BalanceCents)
+	}
+	if pending := changes.pending[resp.Payment.PaymentID]; pending.BalanceCents != resp.BalanceCents {
+		t.Errorf("Expected the credit to be applied with the change, got %+v", pending)
+	}
+
+	limits := &fakeSpendingLimitStore{
+		limits: map[string]SpendingLimit{"user-1": {MonthlyLimit: int64Ptr(MinPaymentAmount)}},
+		spent:  map[string]int64{"user-1": 5000},
+	}
+	service.SetSpendingLimits(limits, nil)
+	if _, err := service.ChangePlan(ctx, "user-1", req); !errors.Is(err, ErrSpendingLimitExceeded) {
+		t.Errorf("Expected the upgrade payment to pass the spending limit, got %v", err)
+	}
+}
+
+func TestCreatePayment_UsesPlanCredit(t *testing.T) {
+	ctx := context.Background()
+	service, changes := newPlanChangeTestService(ActivePlanCycle{})
+	changes.credit = 30000
+	req := CreatePaymentRequest{PlanID: "plan-1", ReturnURL: "https://test.com/return"}
+
+	resp, err := service.CreatePayment(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Amount != 20000 || resp.PlanCreditCents != 30000 || changes.paymentCredits[resp.PaymentID] != 30000 {
+		t.Errorf("Expected the credit off the price, got %+v", resp)
+	}
+
+	if err := service.VerifyPayment(ctx, PaymentWebhook{TrackID: resp.TrackID}); err != nil {
+		t.Fatalf("Expected the payment to verify, got %v", err)
+	}
+	if len(changes.settled) != 1 || changes.settled[0] != resp.PaymentID || len(changes.changes) != 0 {
+		t.Errorf("Expected the purchase to settle the credit, got %v", changes.settled)
+	}
+
+	// The credit never brings a payment below the gateway minimum
+	changes.credit = 100000
+	resp, err = service.CreatePayment(ctx, "user-2", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Amount != MinPaymentAmount || resp.PlanCreditCents != 50000-MinPaymentAmount {
+		t.Errorf("Expected the minimum amount to be paid, got %+v", resp)
+	}
+}
+
+func TestChangePlan_Rejected(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newPlanChangeTestService(ActivePlanCycle{})
+
+	if _, err := service.ChangePlan(ctx, "user-1", ChangePlanRequest{PlanID: "plan-2"}); !errors.Is(err, ErrNoActivePlan) {
+		t.Errorf("Expected no active plan error, got %v", err)
+	}
+	if _, err := service.ChangePlan(ctx, "user-1", ChangePlanRequest{PlanID: "missing"}); err == nil {
+		t.Error("Expected error for unknown plan")
+	}
+
+	unconfigured := NewService(newMockStore(), newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	if _, err := unconfigured.ChangePlan(ctx, "user-1", ChangePlanRequest{PlanID: "plan-2"}); !errors.Is(err, ErrPlanChangesUnavailable) {
+		t.Errorf("Expected plan changes to be unavailable, got %v", err)
+	}
+}
@@ -14,6 +14,11 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 		payments.GET("/:id/status", handler.GetPaymentStatus)
 		payments.GET("/history", handler.GetPaymentHistory)
 		payments.DELETE("/:id/cancel", handler.CancelPayment)
+		payments.POST("/plans/change", handler.ChangePlan)
+		payments.POST("/plans/:id/trial", handler.StartTrial)
+		payments.POST("/validate-coupon", handler.ValidateCoupon)
+		payments.GET("/spending-limit", handler.GetSpendingLimit)
+		payments.PUT("/spending-limit", handler.UpdateSpendingLimit)
 
 		// Zarinpal routes
 		zarinpal := payments.Group("/zarinpal")
@@ -55,3 +60,22 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
 	// Health check
 	router.GET("/health", handler.HealthCheck)
 }
+
+// SetupAdminRoutes mounts coupon and spending limit management on an
+// admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	coupons := router.Group("/coupons")
+	{
+		coupons.GET("", handler.ListCoupons)         // GET /admin/coupons
+		coupons.POST("", handler.CreateCoupon)       // POST /admin/coupons
+		coupons.GET("/:id", handler.GetCoupon)       // GET /admin/coupons/:id
+		coupons.PUT("/:id", handler.UpdateCoupon)    // PUT /admin/coupons/:id
+		coupons.DELETE("/:id", handler.DeleteCoupon) // DELETE /admin/coupons/:id
+	}
+
+	limits := router.Group("/spending-limits")
+	{
+		limits.GET("/:userId", handler.GetUserSpendingLimit) // GET /admin/spending-limits/:userId
+		limits.PUT("/:userId", handler.EnforceSpendingLimit) // PUT /admin/spending-limits/:userId
+	}
+}
@@ -5,18 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
 )
 
 // Service provides payment management functionality
 type Service struct {
-	store         PaymentStore
-	gateway       PaymentGateway
-	userService   UserService
-	notifier      NotificationService
-	quotaService  QuotaService
-	auditLogger   AuditLogger
-	rateLimiter   RateLimiter
-	configService PaymentConfigService
+	store          PaymentStore
+	gateway        PaymentGateway
+	userService    UserService
+	notifier       NotificationService
+	quotaService   QuotaService
+	auditLogger    AuditLogger
+	rateLimiter    RateLimiter
+	configService  PaymentConfigService
+	planChanges    PlanChangeStore
+	coupons        CouponStore
+	trials         *trials
+	spendingLimits *spendingLimits
+	tx             common.TxRunner
+	cache          *cache.TwoTier // active plans, dropped on plan changes (optional)
 }
 
 // NewService creates a new payment service
@@ -39,9 +48,16 @@ func NewService(
 		auditLogger:   auditLogger,
 		rateLimiter:   rateLimiter,
 		configService: configService,
+		cache:         cache.Default(),
 	}
 }
 
+// SetUnitOfWork makes completing a payment, activating its plan and updating
+// the user's quota apply together. Without one they are applied one by one.
+func (s *Service) SetUnitOfWork(tx common.TxRunner) {
+	s.tx = tx
+}
+
 // CreatePaymentWithGateway creates a new payment for a plan with a specific gateway
 func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, req CreatePaymentRequest, gateway PaymentGateway) (CreatePaymentResponse, error) {
 	// Validate input
@@ -74,6 +90,32 @@ func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, r
 		return CreatePaymentResponse{}, errors.New("user already has an active plan")
 	}
 
+	// Apply the coupon before anything is written, so an invalid code fails
+	// the request without a payment record
+	amount := plan.PricePerMonthCents
+	var quote *CouponQuote
+	if req.CouponCode != "" {
+		if s.coupons == nil {
+			return CreatePaymentResponse{}, ErrCouponsUnavailable
+		}
+		q, err := s.quoteCoupon(ctx, userID, req.CouponCode, plan)
+		if err != nil {
+			return CreatePaymentResponse{}, err
+		}
+		quote = &q
+		amount = q.FinalAmount
+	}
+
+	// Credit left by plan downgrades is taken off the amount to pay
+	planCredit, err := s.planCredit(ctx, userID, amount)
+	if err != nil {
+		return CreatePaymentResponse{}, err
+	}
+	amount -= planCredit
+	if err := s.CheckSpendingLimit(ctx, userID, amount); err != nil {
+		return CreatePaymentResponse{}, err
+	}
+
 	// Generate payment ID
 	paymentID := generatePaymentID()
 
@@ -82,7 +124,7 @@ func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, r
 		ID:            paymentID,
 		UserID:        userID,
 		PlanID:        req.PlanID,
-		Amount:        plan.PricePerMonthCents,
+		Amount:        amount,
 		Currency:      CurrencyIRR,
 		Status:        PaymentStatusPending,
 		PaymentMethod: gateway.GetGatewayName(),
@@ -100,10 +142,35 @@ func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, r
 	if err != nil {
 		return CreatePaymentResponse{}, fmt.Errorf("failed to create payment record: %w", err)
 	}
+	if planCredit > 0 {
+		if err := s.planChanges.SetPaymentPlanCredit(ctx, paymentID, planCredit); err != nil {
+			s.store.UpdatePayment(ctx, paymentID, map[string]interface{}{
+				"status": PaymentStatusFailed,
+			})
+			return CreatePaymentResponse{}, err
+		}
+	}
+
+	// Hold the coupon for this payment until it completes or fails
+	if quote != nil {
+		err = s.coupons.ReserveRedemption(ctx, CouponRedemption{
+			CouponID:       quote.couponID,
+			Code:           quote.Code,
+			UserID:         userID,
+			PaymentID:      paymentID,
+			DiscountAmount: quote.DiscountAmount,
+		})
+		if err != nil {
+			s.store.UpdatePayment(ctx, paymentID, map[string]interface{}{
+				"status": PaymentStatusFailed,
+			})
+			return CreatePaymentResponse{}, err
+		}
+	}
 
 	// Create gateway payment request
 	gatewayReq := ZarinpalRequest{
-		Amount:      plan.PricePerMonthCents,
+		Amount:      amount,
 		CallbackURL: s.configService.GetPaymentCallbackURL(),
 		Description: req.Description,
 		OrderID:     paymentID,
@@ -116,6 +183,7 @@ func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, r
 		s.store.UpdatePayment(ctx, paymentID, map[string]interface{}{
 			"status": PaymentStatusFailed,
 		})
+		s.releaseCoupon(ctx, payment)
 		return CreatePaymentResponse{}, fmt.Errorf("failed to create gateway payment: %w", err)
 	}
 
@@ -131,17 +199,29 @@ func (s *Service) CreatePaymentWithGateway(ctx context.Context, userID string, r
 	metadata := map[string]interface{}{
 		"payment_id": paymentID,
 		"plan_id":    req.PlanID,
-		"amount":     plan.PricePerMonthCents,
+		"amount":     amount,
 		"track_id":   gatewayResp.TrackID,
 	}
-	_ = s.auditLogger.LogPaymentAction(ctx, userID, "payment_created", metadata)
-
-	return CreatePaymentResponse{
+	response := CreatePaymentResponse{
 		PaymentID:  paymentID,
 		GatewayURL: gateway.GetPaymentURL(gatewayResp.TrackID),
 		TrackID:    gatewayResp.TrackID,
+		Amount:     amount,
 		ExpiresAt:  *updatedPayment.ExpiresAt,
-	}, nil
+	}
+	if quote != nil {
+		metadata["coupon_code"] = quote.Code
+		metadata["discount_amount"] = quote.DiscountAmount
+		response.CouponCode = quote.Code
+		response.DiscountAmount = quote.DiscountAmount
+	}
+	if planCredit > 0 {
+		metadata["plan_credit_cents"] = planCredit
+		response.PlanCreditCents = planCredit
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, userID, "payment_created", metadata)
+
+	return response, nil
 }
 
 // CreatePayment creates a new payment for a plan using the default gateway
@@ -173,6 +253,7 @@ func (s *Service) VerifyPayment(ctx context.Context, webhook PaymentWebhook) err
 		s.store.UpdatePayment(ctx, payment.ID, map[string]interface{}{
 			"status": PaymentStatusFailed,
 		})
+		s.releaseCoupon(ctx, payment)
 		return fmt.Errorf("failed to verify payment: %w", err)
 	}
 
@@ -182,6 +263,7 @@ func (s *Service) VerifyPayment(ctx context.Context, webhook PaymentWebhook) err
 		s.store.UpdatePayment(ctx, payment.ID, map[string]interface{}{
 			"status": PaymentStatusFailed,
 		})
+		s.releaseCoupon(ctx, payment)
 		return fmt.Errorf("payment verification failed: %s", verifyResp.Message)
 	}
 
@@ -194,26 +276,55 @@ func (s *Service) VerifyPayment(ctx context.Context, webhook PaymentWebhook) err
 		"paid_at":             now,
 	}
 
-	updatedPayment, err := s.store.UpdatePayment(ctx, payment.ID, updates)
-	if err != nil {
-		return fmt.Errorf("failed to update payment: %w", err)
-	}
+	// Complete the payment, activate its plan, update the quota and redeem its
+	// coupon together, so a failure leaves the payment pending for the next
+	// verification
+	var redemption CouponRedemption
+	var redeemed bool
+	err = common.RunInTx(ctx, s.tx, func(ctx context.Context) error {
+		updatedPayment, err := s.store.UpdatePayment(ctx, payment.ID, updates)
+		if err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
 
-	// Activate user plan
-	err = s.store.ActivateUserPlan(ctx, payment.UserID, payment.PlanID, payment.ID)
-	if err != nil {
-		return fmt.Errorf("failed to activate user plan: %w", err)
-	}
+		// A plan change payment switches the active plan mid-cycle; other
+		// payments start a new plan
+		changed, err := s.applyPaidPlanChange(ctx, payment)
+		if err != nil {
+			return fmt.Errorf("failed to apply plan change: %w", err)
+		}
+		if !changed {
+			if err := s.store.ActivateUserPlan(ctx, payment.UserID, payment.PlanID, payment.ID); err != nil {
+				return fmt.Errorf("failed to activate user plan: %w", err)
+			}
+
+			if err := s.quotaService.UpdateUserQuota(ctx, payment.UserID, updatedPayment.PlanID); err != nil {
+				return fmt.Errorf("failed to update user quota: %w", err)
+			}
+
+			if s.planChanges != nil {
+				if err := s.planChanges.SettlePlanCredit(ctx, payment.UserID, payment.ID); err != nil {
+					return err
+				}
+			}
+		}
 
-	// Update user quota
-	err = s.quotaService.UpdateUserQuota(ctx, payment.UserID, updatedPayment.PlanID)
+		if s.coupons != nil {
+			redemption, redeemed, err = s.coupons.FinishRedemption(ctx, payment.ID, RedemptionRedeemed)
+			if err != nil {
+				return fmt.Errorf("failed to redeem coupon: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail the payment
-		_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "quota_update_failed", map[string]interface{}{
+		_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "payment_completion_failed", map[string]interface{}{
 			"payment_id": payment.ID,
 			"error":      err.Error(),
 		})
+		return err
 	}
+	invalidatePlan(ctx, s.cache, payment.UserID)
 
 	// Get plan details for notification
 	plan, err := s.store.GetPlan(ctx, payment.PlanID)
@@ -239,6 +350,14 @@ func (s *Service) VerifyPayment(ctx context.Context, webhook PaymentWebhook) err
 		"card_number": verifyResp.CardNumber,
 	}
 	_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "payment_completed", metadata)
+	if redeemed {
+		_ = s.auditLogger.LogPaymentAction(ctx, payment.UserID, "coupon_redeemed", map[string]interface{}{
+			"payment_id":      payment.ID,
+			"coupon_id":       redemption.CouponID,
+			"coupon_code":     redemption.Code,
+			"discount_amount": redemption.DiscountAmount,
+		})
+	}
 
 	return nil
 }
@@ -313,7 +432,9 @@ func (s *Service) GetPlans(ctx context.Context) ([]PaymentPlan, error) {
 
 // GetUserActivePlan retrieves the user's active plan
 func (s *Service) GetUserActivePlan(ctx context.Context, userID string) (PaymentPlan, error) {
-	plan, err := s.store.GetUserActivePlan(ctx, userID)
+	plan, err := cache.Load(ctx, s.cache, cache.EntityUserPlan, userID, func(ctx context.Context) (PaymentPlan, error) {
+		return s.store.GetUserActivePlan(ctx, userID)
+	})
 	if err != nil {
 		return PaymentPlan{}, fmt.Errorf("failed to get user active plan: %w", err)
 	}
@@ -321,6 +442,13 @@ func (s *Service) GetUserActivePlan(ctx context.Context, userID string) (Payment
 	return plan, nil
 }
 
+// invalidatePlan drops the cached plan and quota status of a user whose plan
+// changed
+func invalidatePlan(ctx context.Context, c *cache.TwoTier, userID string) {
+	cache.Invalidate(ctx, c, cache.EntityUserPlan, userID)
+	cache.Invalidate(ctx, c, cache.EntityQuotaStatus, userID)
+}
+
 // CancelPayment cancels a pending payment
 func (s *Service) CancelPayment(ctx context.Context, userID, paymentID string) error {
 	// Get payment
@@ -346,6 +474,7 @@ func (s *Service) CancelPayment(ctx context.Context, userID, paymentID string) e
 	if err != nil {
 		return fmt.Errorf("failed to cancel payment: %w", err)
 	}
+	s.releaseCoupon(ctx, payment)
 
 	// Log the action
 	metadata := map[string]interface{}{
@@ -485,3 +485,59 @@ func TestGetPlans(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+// snapshotTxRunner restores the mock store's payments when a unit of work fails
+type snapshotTxRunner struct {
+	store *mockStore
+	runs  int
+}
+
+func (r *snapshotTxRunner) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.runs++
+	saved := make(map[string]Payment, len(r.store.payments))
+	for id, payment := range r.store.payments {
+		saved[id] = payment
+	}
+	if err := fn(ctx); err != nil {
+		r.store.payments = saved
+		return err
+	}
+	return nil
+}
+
+type failingQuotaService struct {
+	mockQuotaService
+}
+
+func (f *failingQuotaService) UpdateUserQuota(ctx context.Context, userID string, planName string) error {
+	return errors.New("quota store unavailable")
+}
+
+func TestVerifyPayment_RollsBackWhenQuotaUpdateFails(t *testing.T) {
+	store := newMockStore()
+	service := NewService(store, newMockGateway(), &mockUserService{}, &mockNotificationService{}, &failingQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	tx := &snapshotTxRunner{store: store}
+	service.SetUnitOfWork(tx)
+
+	store.payments["payment-1"] = Payment{
+		ID:             "payment-1",
+		UserID:         "user-1",
+		PlanID:         "plan-1",
+		Amount:         50000,
+		Status:         PaymentStatusPending,
+		GatewayTrackID: stringPtr("test-track-id"),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	err := service.VerifyPayment(context.Background(), PaymentWebhook{TrackID: "test-track-id", Success: true, Status: ZarinpalStatusPaid})
+	if err == nil {
+		t.Fatal("Expected error when the quota update fails")
+	}
+	if tx.runs != 1 {
+		t.Errorf("Expected completion to run in one unit of work, got %d", tx.runs)
+	}
+	if status := store.payments["payment-1"].Status; status != PaymentStatusPending {
+		t.Errorf("Expected payment to stay pending for the next verification, got %s", status)
+	}
+}
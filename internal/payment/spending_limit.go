@@ -0,0 +1,281 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// spendingLimitNoticeWindow is how often a user is told about reaching their
+// spending limit, however many payments are rejected meanwhile
+const spendingLimitNoticeWindow = 24 * time.Hour
+
+var (
+	// ErrSpendingLimitExceeded is returned when a payment would take the user
+	// past their monthly spending limit
+	ErrSpendingLimitExceeded = fmt.Errorf("%w: monthly spending limit exceeded", common.ErrConflict)
+	// ErrSpendingLimitsUnavailable is returned when spending limits are not configured
+	ErrSpendingLimitsUnavailable = errors.New("spending limits are not available")
+)
+
+// SpendingLimit is the monthly spending cap of a user. The user sets
+// MonthlyLimit themselves; EnforcedLimit is set by an admin and cannot be
+// lifted by the user. The lower of the two applies.
+type SpendingLimit struct {
+	UserID         string     `json:"userId"`
+	MonthlyLimit   *int64     `json:"monthlyLimit"`
+	EnforcedLimit  *int64     `json:"enforcedLimit"`
+	EnforcedReason string     `json:"enforcedReason,omitempty"`
+	EffectiveLimit *int64     `json:"effectiveLimit"`
+	SpentThisMonth int64      `json:"spentThisMonth"`
+	Remaining      *int64     `json:"remaining"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+// UpdateSpendingLimitRequest sets a user's own monthly limit; a null limit removes it
+type UpdateSpendingLimitRequest struct {
+	MonthlyLimit *int64 `json:"monthlyLimit"`
+}
+
+// EnforceSpendingLimitRequest sets the admin-enforced limit of a user; a null
+// limit removes it
+type EnforceSpendingLimitRequest struct {
+	EnforcedLimit *int64 `json:"enforcedLimit"`
+	Reason        string `json:"reason"`
+}
+
+// SpendingLimitStore persists spending limits and sums a user's spending
+type SpendingLimitStore interface {
+	// GetSpendingLimit returns the limits of a user, without limits when none were set
+	GetSpendingLimit(ctx context.Context, userID string) (SpendingLimit, error)
+	SetMonthlyLimit(ctx context.Context, userID string, limit *int64) error
+	SetEnforcedLimit(ctx context.Context, userID string, limit *int64, reason, adminID string) error
+	// SpentSince sums the completed and still payable pending payments of a
+	// user created at or after since
+	SpentSince(ctx context.Context, userID string, since time.Time) (int64, error)
+}
+
+// SpendingLimitNotifier tells users a payment was rejected by their limit
+type SpendingLimitNotifier interface {
+	SendSpendingLimitReached(ctx context.Context, userID string, limit, spent int64) error
+}
+
+// spendingLimits holds the optional spending limit dependencies
+type spendingLimits struct {
+	store    SpendingLimitStore
+	notifier SpendingLimitNotifier
+}
+
+// SetSpendingLimits enables monthly spending limits on plan payments.
+// Rejected payments are reported through notifier when it is not nil.
+func (s *Service) SetSpendingLimits(store SpendingLimitStore, notifier SpendingLimitNotifier) {
+	s.spendingLimits = &spendingLimits{store: store, notifier: notifier}
+}
+
+// GetSpendingLimit returns a user's limits with their spending this month
+func (s *Service) GetSpendingLimit(ctx context.Context, userID string) (SpendingLimit, error) {
+	if s.spendingLimits == nil {
+		return SpendingLimit{}, ErrSpendingLimitsUnavailable
+	}
+
+	limit, err := s.spendingLimits.store.GetSpendingLimit(ctx, userID)
+	if err != nil {
+		return SpendingLimit{}, err
+	}
+	limit.UserID = userID
+	limit.SpentThisMonth, err = s.spendingLimits.store.SpentSince(ctx, userID, monthStart(time.Now()))
+	if err != nil {
+		return SpendingLimit{}, err
+	}
+
+	limit.EffectiveLimit = limit.MonthlyLimit
+	if limit.EnforcedLimit != nil && (limit.EffectiveLimit == nil || *limit.EnforcedLimit < *limit.EffectiveLimit) {
+		limit.EffectiveLimit = limit.EnforcedLimit
+	}
+	if limit.EffectiveLimit != nil {
+		remaining := *limit.EffectiveLimit - limit.SpentThisMonth
+		if remaining < 0 {
+			remaining = 0
+		}
+		limit.Remaining = &remaining
+	}
+	return limit, nil
+}
+
+// UpdateSpendingLimit sets the user's own monthly limit
+func (s *Service) UpdateSpendingLimit(ctx context.Context, userID string, req UpdateSpendingLimitRequest) (SpendingLimit, error) {
+	if s.spendingLimits == nil {
+		return SpendingLimit{}, ErrSpendingLimitsUnavailable
+	}
+	if req.MonthlyLimit != nil && *req.MonthlyLimit <= 0 {
+		return SpendingLimit{}, fmt.Errorf("%w: monthlyLimit must be positive", common.ErrValidation)
+	}
+
+	if err := s.spendingLimits.store.SetMonthlyLimit(ctx, userID, req.MonthlyLimit); err != nil {
+		return SpendingLimit{}, err
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, userID, "spending_limit_updated", map[string]interface{}{
+		"monthly_limit": req.MonthlyLimit,
+	})
+	return s.GetSpendingLimit(ctx, userID)
+}
+
+// GetUserSpendingLimit returns the limits of any user for an admin
+func (s *Service) GetUserSpendingLimit(ctx context.Context, userID string) (SpendingLimit, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return SpendingLimit{}, fmt.Errorf("user %s: %w", userID, common.ErrNotFound)
+	}
+	return s.GetSpendingLimit(ctx, userID)
+}
+
+// EnforceSpendingLimit sets the limit an admin enforces on a user, for
+// example to contain a suspected fraudulent account
+func (s *Service) EnforceSpendingLimit(ctx context.Context, adminID, userID string, req EnforceSpendingLimitRequest) (SpendingLimit, error) {
+	if s.spendingLimits == nil {
+		return SpendingLimit{}, ErrSpendingLimitsUnavailable
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return SpendingLimit{}, fmt.Errorf("user %s: %w", userID, common.ErrNotFound)
+	}
+	if req.EnforcedLimit != nil && *req.EnforcedLimit < 0 {
+		return SpendingLimit{}, fmt.Errorf("%w: enforcedLimit must not be negative", common.ErrValidation)
+	}
+
+	reason := req.Reason
+	if req.EnforcedLimit == nil {
+		reason = ""
+	}
+	if err := s.spendingLimits.store.SetEnforcedLimit(ctx, userID, req.EnforcedLimit, reason, adminID); err != nil {
+		return SpendingLimit{}, err
+	}
+	_ = s.auditLogger.LogPaymentAction(ctx, adminID, "spending_limit_enforced", map[string]interface{}{
+		"user_id":        userID,
+		"enforced_limit": req.EnforcedLimit,
+		"reason":         reason,
+	})
+	return s.GetSpendingLimit(ctx, userID)
+}
+
+// CheckSpendingLimit rejects a payment of amount that would take the user
+// past their monthly limit and tells them why. It allows every payment when
+// spending limits are not configured.
+func (s *Service) CheckSpendingLimit(ctx context.Context, userID string, amount int64) error {
+	if s.spendingLimits == nil || amount <= 0 {
+		return nil
+	}
+
+	limit, err := s.GetSpendingLimit(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if limit.EffectiveLimit == nil || limit.SpentThisMonth+amount <= *limit.EffectiveLimit {
+		return nil
+	}
+
+	_ = s.auditLogger.LogPaymentAction(ctx, userID, "spending_limit_exceeded", map[string]interface{}{
+		"amount":          amount,
+		"spent":           limit.SpentThisMonth,
+		"effective_limit": *limit.EffectiveLimit,
+	})
+	// Repeated attempts would otherwise notify the user each time
+	noticeKey := fmt.Sprintf("spending_limit_notice:user:%s", userID)
+	if s.spendingLimits.notifier != nil && s.rateLimiter.Allow(ctx, noticeKey, 1, spendingLimitNoticeWindow) {
+		_ = s.spendingLimits.notifier.SendSpendingLimitReached(ctx, userID, *limit.EffectiveLimit, limit.SpentThisMonth)
+	}
+	return fmt.Errorf("%w: %d of the %d IRR limit is left this month", ErrSpendingLimitExceeded, *limit.Remaining, *limit.EffectiveLimit)
+}
+
+// monthStart returns the start of the calendar month of t
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// dbSpendingLimitStore implements SpendingLimitStore on top of
+// user_spending_limits and payments
+type dbSpendingLimitStore struct {
+	db *sql.DB
+}
+
+// NewDBSpendingLimitStore creates a new database-backed spending limit store
+func NewDBSpendingLimitStore(db *sql.DB) SpendingLimitStore {
+	return &dbSpendingLimitStore{db: db}
+}
+
+// GetSpendingLimit returns the stored limits of a user
+func (s *dbSpendingLimitStore) GetSpendingLimit(ctx context.Context, userID string) (SpendingLimit, error) {
+	var limit SpendingLimit
+	var monthly, enforced sql.NullInt64
+	var updatedAt time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT monthly_limit, enforced_limit, COALESCE(enforced_reason, ''), updated_at
+		FROM user_spending_limits
+		WHERE user_id = $1`, userID).Scan(&monthly, &enforced, &limit.EnforcedReason, &updatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		return SpendingLimit{UserID: userID}, nil
+	case err != nil:
+		return SpendingLimit{}, fmt.Errorf("failed to get spending limit: %w", err)
+	}
+
+	limit.UserID = userID
+	if monthly.Valid {
+		limit.MonthlyLimit = &monthly.Int64
+	}
+	if enforced.Valid {
+		limit.EnforcedLimit = &enforced.Int64
+	}
+	limit.UpdatedAt = &updatedAt
+	return limit, nil
+}
+
+// SetMonthlyLimit stores the user's own limit, keeping any enforced limit
+func (s *dbSpendingLimitStore) SetMonthlyLimit(ctx context.Context, userID string, limit *int64) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_spending_limits (user_id, monthly_limit)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET monthly_limit = EXCLUDED.monthly_limit, updated_at = NOW()`,
+		userID, limit); err != nil {
+		return fmt.Errorf("failed to set spending limit: %w", err)
+	}
+	return nil
+}
+
+// SetEnforcedLimit stores the admin-enforced limit, keeping the user's own limit
+func (s *dbSpendingLimitStore) SetEnforcedLimit(ctx context.Context, userID string, limit *int64, reason, adminID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_spending_limits (user_id, enforced_limit, enforced_reason, enforced_by, enforced_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET enforced_limit = EXCLUDED.enforced_limit,
+		    enforced_reason = EXCLUDED.enforced_reason,
+		    enforced_by = EXCLUDED.enforced_by,
+		    enforced_at = EXCLUDED.enforced_at,
+		    updated_at = NOW()`,
+		userID, limit, reason, adminID); err != nil {
+		return fmt.Errorf("failed to enforce spending limit: %w", err)
+	}
+	return nil
+}
+
+// SpentSince sums the user's completed payments and the pending ones that
+// can still be paid, so open checkouts count against the limit too
+func (s *dbSpendingLimitStore) SpentSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var spent int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM payments
+		WHERE user_id = $1 AND created_at >= $2
+		  AND (status = 'completed' OR (status = 'pending' AND (expires_at IS NULL OR expires_at > NOW())))`,
+		userID, since).Scan(&spent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum payments: %w", err)
+	}
+	return spent, nil
+}
@@ -0,0 +1,144 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+const limitedUserID = "11111111-1111-1111-1111-111111111111"
+
+type fakeSpendingLimitStore struct {
+	limits map[string]SpendingLimit
+	spent  map[string]int64
+}
+
+func (f *fakeSpendingLimitStore) GetSpendingLimit(ctx context.Context, userID string) (SpendingLimit, error) {
+	return f.limits[userID], nil
+}
+
+func (f *fakeSpendingLimitStore) SetMonthlyLimit(ctx context.Context, userID string, limit *int64) error {
+	current := f.limits[userID]
+	current.MonthlyLimit = limit
+	f.limits[userID] = current
+	return nil
+}
+
+func (f *fakeSpendingLimitStore) SetEnforcedLimit(ctx context.Context, userID string, limit *int64, reason, adminID string) error {
+	current := f.limits[userID]
+	current.EnforcedLimit = limit
+	current.EnforcedReason = reason
+	f.limits[userID] = current
+	return nil
+}
+
+func (f *fakeSpendingLimitStore) SpentSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	return f.spent[userID], nil
+}
+
+type recordingLimitNotifier struct {
+	reached []int64 // limits users were told about
+}
+
+func (r *recordingLimitNotifier) SendSpendingLimitReached(ctx context.Context, userID string, limit, spent int64) error {
+	r.reached = append(r.reached, limit)
+	return nil
+}
+
+func newSpendingLimitTestService() (*Service, *fakeSpendingLimitStore, *recordingLimitNotifier) {
+	service := NewService(newMockStore(), newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	store := &fakeSpendingLimitStore{limits: make(map[string]SpendingLimit), spent: make(map[string]int64)}
+	notifier := &recordingLimitNotifier{}
+	service.SetSpendingLimits(store, notifier)
+	return service, store, notifier
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestSpendingLimitDeclinesPayment(t *testing.T) {
+	ctx := context.Background()
+	service, store, notifier := newSpendingLimitTestService()
+	req := CreatePaymentRequest{PlanID: "plan-1", ReturnURL: "https://test.com/return"}
+
+	store.spent[limitedUserID] = 30000
+	if _, err := service.UpdateSpendingLimit(ctx, limitedUserID, UpdateSpendingLimitRequest{MonthlyLimit: int64Ptr(100000)}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.CreatePayment(ctx, limitedUserID, req); err != nil {
+		t.Fatalf("Expected a payment within the limit, got %v", err)
+	}
+
+	store.spent[limitedUserID] = 80000
+	_, err := service.CreatePayment(ctx, limitedUserID, req)
+	if !errors.Is(err, ErrSpendingLimitExceeded) || !errors.Is(err, common.ErrConflict) {
+		t.Fatalf("Expected the payment to pass the limit, got %v", err)
+	}
+	if len(notifier.reached) != 1 || notifier.reached[0] != 100000 {
+		t.Errorf("Expected the user to be told about their limit, got %v", notifier.reached)
+	}
+
+	limit, err := service.GetSpendingLimit(ctx, limitedUserID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if limit.Remaining == nil || *limit.Remaining != 20000 || limit.SpentThisMonth != 80000 {
+		t.Errorf("Expected 20000 left of 80000 spent, got %+v", limit)
+	}
+}
+
+func TestEnforcedSpendingLimit(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newSpendingLimitTestService()
+
+	if _, err := service.UpdateSpendingLimit(ctx, limitedUserID, UpdateSpendingLimitRequest{MonthlyLimit: int64Ptr(500000)}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	limit, err := service.EnforceSpendingLimit(ctx, "admin-1", limitedUserID, EnforceSpendingLimitRequest{EnforcedLimit: int64Ptr(0), Reason: "chargebacks"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if limit.EffectiveLimit == nil || *limit.EffectiveLimit != 0 || limit.EnforcedReason != "chargebacks" {
+		t.Errorf("Expected the enforced limit to apply, got %+v", limit)
+	}
+	if err := service.CheckSpendingLimit(ctx, limitedUserID, 50000); !errors.Is(err, ErrSpendingLimitExceeded) {
+		t.Errorf("Expected an enforced limit of 0 to block payments, got %v", err)
+	}
+
+	// The user cannot lift the enforced limit by raising their own
+	if _, err := service.UpdateSpendingLimit(ctx, limitedUserID, UpdateSpendingLimitRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.CheckSpendingLimit(ctx, limitedUserID, 50000); !errors.Is(err, ErrSpendingLimitExceeded) {
+		t.Errorf("Expected the enforced limit to remain, got %v", err)
+	}
+
+	limit, err = service.EnforceSpendingLimit(ctx, "admin-1", limitedUserID, EnforceSpendingLimitRequest{Reason: "cleared"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if limit.EffectiveLimit != nil || limit.EnforcedReason != "" {
+		t.Errorf("Expected no limit left, got %+v", limit)
+	}
+
+	if _, err := service.UpdateSpendingLimit(ctx, limitedUserID, UpdateSpendingLimitRequest{MonthlyLimit: int64Ptr(0)}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected a zero user limit to be rejected, got %v", err)
+	}
+	if _, err := service.EnforceSpendingLimit(ctx, "admin-1", "user-1", EnforceSpendingLimitRequest{}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected a malformed user ID to be reported missing, got %v", err)
+	}
+
+	unconfigured := NewService(newMockStore(), newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	if _, err := unconfigured.GetSpendingLimit(ctx, limitedUserID); !errors.Is(err, ErrSpendingLimitsUnavailable) {
+		t.Errorf("Expected spending limits to be unavailable, got %v", err)
+	}
+	if err := unconfigured.CheckSpendingLimit(ctx, limitedUserID, 50000); err != nil {
+		t.Errorf("Expected payments to be allowed without spending limits, got %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/lib/pq"
 )
 
@@ -35,7 +37,7 @@ func (s *PaymentStoreImpl) CreatePayment(ctx context.Context, payment Payment) (
 			description, callback_url, return_url, created_at, updated_at, paid_at, expires_at`
 
 	var result Payment
-	err := s.db.QueryRowContext(ctx, query,
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, query,
 		payment.ID, payment.UserID, payment.PlanID, payment.Amount, payment.Currency,
 		payment.Status, payment.PaymentMethod, payment.Gateway, payment.GatewayTrackID,
 		payment.Description, payment.CallbackURL, payment.ReturnURL,
@@ -166,7 +168,7 @@ func (s *PaymentStoreImpl) UpdatePayment(ctx context.Context, paymentID string,
 		setClause, argIndex)
 
 	var payment Payment
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+	err := common.Conn(ctx, s.db).QueryRowContext(ctx, query, args...).Scan(
 		&payment.ID, &payment.UserID, &payment.PlanID, &payment.Amount, &payment.Currency,
 		&payment.Status, &payment.PaymentMethod, &payment.Gateway, &payment.GatewayTrackID,
 		&payment.GatewayRefNumber, &payment.GatewayCardNumber, &payment.Description,
@@ -270,14 +272,14 @@ func (s *PaymentStoreImpl) GetPaymentHistory(ctx context.Context, userID string,
 func (s *PaymentStoreImpl) GetPlan(ctx context.Context, planID string) (PaymentPlan, error) {
 	query := `
 		SELECT id, name, display_name, description, price_per_month_cents, 
-			monthly_conversions_limit, features, is_active, created_at, updated_at
+			monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at
 		FROM payment_plans 
 		WHERE id = $1`
 
 	var plan PaymentPlan
 	err := s.db.QueryRowContext(ctx, query, planID).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 
@@ -295,7 +297,7 @@ func (s *PaymentStoreImpl) GetPlan(ctx context.Context, planID string) (PaymentP
 func (s *PaymentStoreImpl) GetAllPlans(ctx context.Context) ([]PaymentPlan, error) {
 	query := `
 		SELECT id, name, display_name, description, price_per_month_cents, 
-			monthly_conversions_limit, features, is_active, created_at, updated_at
+			monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at
 		FROM payment_plans 
 		WHERE is_active = true
 		ORDER BY price_per_month_cents ASC`
@@ -311,7 +313,7 @@ func (s *PaymentStoreImpl) GetAllPlans(ctx context.Context) ([]PaymentPlan, erro
 		var plan PaymentPlan
 		err := rows.Scan(
 			&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-			&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive,
+			&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 			&plan.CreatedAt, &plan.UpdatedAt,
 		)
 		if err != nil {
@@ -332,20 +334,20 @@ func (s *PaymentStoreImpl) CreatePlan(ctx context.Context, plan PaymentPlan) (Pa
 	query := `
 		INSERT INTO payment_plans (
 			id, name, display_name, description, price_per_month_cents, 
-			monthly_conversions_limit, features, is_active, created_at, updated_at
+			monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 		) RETURNING id, name, display_name, description, price_per_month_cents, 
-			monthly_conversions_limit, features, is_active, created_at, updated_at`
+			monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at`
 
 	var result PaymentPlan
 	err := s.db.QueryRowContext(ctx, query,
 		plan.ID, plan.Name, plan.DisplayName, plan.Description, plan.PricePerMonthCents,
-		plan.MonthlyConversionsLimit, pq.Array(plan.Features), plan.IsActive,
+		plan.MonthlyConversionsLimit, pq.Array(plan.Features), plan.IsActive, plan.TrialDays,
 		plan.CreatedAt, plan.UpdatedAt,
 	).Scan(
 		&result.ID, &result.Name, &result.DisplayName, &result.Description, &result.PricePerMonthCents,
-		&result.MonthlyConversionsLimit, pq.Array(&result.Features), &result.IsActive,
+		&result.MonthlyConversionsLimit, pq.Array(&result.Features), &result.IsActive, &result.TrialDays,
 		&result.CreatedAt, &result.UpdatedAt,
 	)
 
@@ -371,6 +373,7 @@ func (s *PaymentStoreImpl) UpdatePlan(ctx context.Context, planID string, update
 		"monthly_conversions_limit": true,
 		"features":                  true,
 		"is_active":                 true,
+		"trial_days":                true,
 	}
 
 	// Build dynamic query
@@ -404,13 +407,13 @@ func (s *PaymentStoreImpl) UpdatePlan(ctx context.Context, planID string, update
 		SET %s
 		WHERE id = $%d
 		RETURNING id, name, display_name, description, price_per_month_cents, 
-			monthly_conversions_limit, features, is_active, created_at, updated_at`,
+			monthly_conversions_limit, features, is_active, trial_days, created_at, updated_at`,
 		setClause, argIndex)
 
 	var plan PaymentPlan
 	err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 
@@ -428,7 +431,7 @@ func (s *PaymentStoreImpl) UpdatePlan(ctx context.Context, planID string, update
 func (s *PaymentStoreImpl) GetUserActivePlan(ctx context.Context, userID string) (PaymentPlan, error) {
 	query := `
 		SELECT p.id, p.name, p.display_name, p.description, p.price_per_month_cents, 
-			p.monthly_conversions_limit, p.features, p.is_active, p.created_at, p.updated_at
+			p.monthly_conversions_limit, p.features, p.is_active, p.trial_days, p.created_at, p.updated_at
 		FROM payment_plans p
 		JOIN user_plans up ON p.id = up.plan_id
 		WHERE up.user_id = $1 AND up.status = 'active'`
@@ -436,7 +439,7 @@ func (s *PaymentStoreImpl) GetUserActivePlan(ctx context.Context, userID string)
 	var plan PaymentPlan
 	err := s.db.QueryRowContext(ctx, query, userID).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description, &plan.PricePerMonthCents,
-		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyConversionsLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 
@@ -450,58 +453,55 @@ func (s *PaymentStoreImpl) GetUserActivePlan(ctx context.Context, userID string)
 	return plan, nil
 }
 
-// ActivateUserPlan activates a plan for a user
+// ActivateUserPlan activates a plan for a user, in the caller's unit of work
+// when there is one
 func (s *PaymentStoreImpl) ActivateUserPlan(ctx context.Context, userID string, planID string, paymentID string) error {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// Deactivate current plan
-	_, err = tx.ExecContext(ctx, `
-		UPDATE user_plans 
-		SET status = 'cancelled', updated_at = NOW() 
-		WHERE user_id = $1 AND status = 'active'`, userID)
-	if err != nil {
-		return fmt.Errorf("failed to deactivate current plan: %w", err)
-	}
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		// Deactivate current plan
+		_, err := tx.ExecContext(ctx, `
+			UPDATE user_plans 
+			SET status = 'cancelled', updated_at = NOW() 
+			WHERE user_id = $1 AND status = 'active'`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to deactivate current plan: %w", err)
+		}
 
-	// Create new active plan
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO user_plans (
-			user_id, plan_id, status, monthly_conversions_limit, 
-			conversions_used_this_month, price_per_month_cents, 
-			billing_cycle_start_date, billing_cycle_end_date, 
-			auto_renew, created_at, updated_at
-		) VALUES (
-			$1, $2, 'active', $3, 0, $4, 
-			CURRENT_DATE, CURRENT_DATE + INTERVAL '1 month', 
-			true, NOW(), NOW()
-		)`, userID, planID, 0, 0) // Will be updated with actual plan details
-	if err != nil {
-		return fmt.Errorf("failed to activate new plan: %w", err)
-	}
+		// Create new active plan
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO user_plans (
+				user_id, plan_id, status, monthly_conversions_limit, 
+				conversions_used_this_month, price_per_month_cents, 
+				billing_cycle_start_date, billing_cycle_end_date, 
+				auto_renew, created_at, updated_at
+			) VALUES (
+				$1, $2, 'active', $3, 0, $4, 
+				CURRENT_DATE, CURRENT_DATE + INTERVAL '1 month', 
+				true, NOW(), NOW()
+			)`, userID, planID, 0, 0) // Will be updated with actual plan details
+		if err != nil {
+			return fmt.Errorf("failed to activate new plan: %w", err)
+		}
 
-	// Update with actual plan details
-	_, err = tx.ExecContext(ctx, `
-		UPDATE user_plans 
-		SET monthly_conversions_limit = p.monthly_conversions_limit,
-			price_per_month_cents = p.price_per_month_cents
-		FROM payment_plans p
-		WHERE user_plans.plan_id = p.id 
-			AND user_plans.user_id = $1 
-			AND user_plans.status = 'active'`, userID)
-	if err != nil {
-		return fmt.Errorf("failed to update plan details: %w", err)
-	}
+		// Update with actual plan details
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_plans 
+			SET monthly_conversions_limit = p.monthly_conversions_limit,
+				price_per_month_cents = p.price_per_month_cents
+			FROM payment_plans p
+			WHERE user_plans.plan_id = p.id 
+				AND user_plans.user_id = $1 
+				AND user_plans.status = 'active'`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update plan details: %w", err)
+		}
 
-	return tx.Commit()
+		return nil
+	})
 }
 
 // DeactivateUserPlan deactivates the user's current plan
 func (s *PaymentStoreImpl) DeactivateUserPlan(ctx context.Context, userID string) error {
-	_, err := s.db.ExecContext(ctx, `
+	_, err := common.Conn(ctx, s.db).ExecContext(ctx, `
 		UPDATE user_plans 
 		SET status = 'cancelled', updated_at = NOW() 
 		WHERE user_id = $1 AND status = 'active'`, userID)
@@ -238,7 +238,7 @@ func (s *postgresStore) GetPlan(ctx context.Context, planID string) (PaymentPlan
 	query := `
 		SELECT id, name, display_name, description, price_per_month_cents,
 		       monthly_conversions_limit, monthly_images_limit, features, is_active,
-		       created_at, updated_at
+		       trial_days, created_at, updated_at
 		FROM payment_plans 
 		WHERE id = $1`
 
@@ -246,7 +246,7 @@ func (s *postgresStore) GetPlan(ctx context.Context, planID string) (PaymentPlan
 	err := s.db.QueryRowContext(ctx, query, planID).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description,
 		&plan.PricePerMonthCents, &plan.MonthlyConversionsLimit,
-		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 	if err != nil {
@@ -264,7 +264,7 @@ func (s *postgresStore) GetAllPlans(ctx context.Context) ([]PaymentPlan, error)
 	query := `
 		SELECT id, name, display_name, description, price_per_month_cents,
 		       monthly_conversions_limit, monthly_images_limit, features, is_active,
-		       created_at, updated_at
+		       trial_days, created_at, updated_at
 		FROM payment_plans 
 		WHERE is_active = true
 		ORDER BY price_per_month_cents ASC`
@@ -281,7 +281,7 @@ func (s *postgresStore) GetAllPlans(ctx context.Context) ([]PaymentPlan, error)
 		err := rows.Scan(
 			&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description,
 			&plan.PricePerMonthCents, &plan.MonthlyConversionsLimit,
-			&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive,
+			&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 			&plan.CreatedAt, &plan.UpdatedAt,
 		)
 		if err != nil {
@@ -297,20 +297,20 @@ func (s *postgresStore) GetAllPlans(ctx context.Context) ([]PaymentPlan, error)
 func (s *postgresStore) CreatePlan(ctx context.Context, plan PaymentPlan) (PaymentPlan, error) {
 	query := `
 		INSERT INTO payment_plans (id, name, display_name, description, price_per_month_cents,
-		                         monthly_conversions_limit, monthly_images_limit, features, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		                         monthly_conversions_limit, monthly_images_limit, features, is_active, trial_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, name, display_name, description, price_per_month_cents,
 		          monthly_conversions_limit, monthly_images_limit, features, is_active,
-		          created_at, updated_at`
+		          trial_days, created_at, updated_at`
 
 	var createdPlan PaymentPlan
 	err := s.db.QueryRowContext(ctx, query,
 		plan.ID, plan.Name, plan.DisplayName, plan.Description, plan.PricePerMonthCents,
-		plan.MonthlyConversionsLimit, plan.MonthlyImagesLimit, pq.Array(plan.Features), plan.IsActive,
+		plan.MonthlyConversionsLimit, plan.MonthlyImagesLimit, pq.Array(plan.Features), plan.IsActive, plan.TrialDays,
 	).Scan(
 		&createdPlan.ID, &createdPlan.Name, &createdPlan.DisplayName, &createdPlan.Description,
 		&createdPlan.PricePerMonthCents, &createdPlan.MonthlyConversionsLimit,
-		&createdPlan.MonthlyImagesLimit, pq.Array(&createdPlan.Features), &createdPlan.IsActive,
+		&createdPlan.MonthlyImagesLimit, pq.Array(&createdPlan.Features), &createdPlan.IsActive, &createdPlan.TrialDays,
 		&createdPlan.CreatedAt, &createdPlan.UpdatedAt,
 	)
 	if err != nil {
@@ -332,6 +332,7 @@ func (s *postgresStore) UpdatePlan(ctx context.Context, planID string, updates m
 		"monthly_images_limit":      true,
 		"features":                  true,
 		"is_active":                 true,
+		"trial_days":                true,
 	}
 
 	// Build dynamic query
@@ -362,7 +363,7 @@ func (s *postgresStore) UpdatePlan(ctx context.Context, planID string, updates m
 		WHERE id = $%d
 		RETURNING id, name, display_name, description, price_per_month_cents,
 		          monthly_conversions_limit, monthly_images_limit, features, is_active,
-		          created_at, updated_at`,
+		          trial_days, created_at, updated_at`,
 		setClause, argIndex)
 
 	args = append(args, planID)
@@ -371,7 +372,7 @@ func (s *postgresStore) UpdatePlan(ctx context.Context, planID string, updates m
 	err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description,
 		&plan.PricePerMonthCents, &plan.MonthlyConversionsLimit,
-		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 	if err != nil {
@@ -413,14 +414,14 @@ func (s *postgresStore) GetUserActivePlan(ctx context.Context, userID string) (P
 	query := `
 		SELECT pp.id, pp.name, pp.display_name, pp.description, pp.price_per_month_cents,
 		       pp.monthly_conversions_limit, pp.monthly_images_limit, pp.features,
-		       pp.is_active, pp.created_at, pp.updated_at
+		       pp.is_active, pp.trial_days, pp.created_at, pp.updated_at
 		FROM payment_plans pp
 		JOIN user_plans up ON pp.id = up.plan_id
 		WHERE up.user_id = $1 AND up.status = 'active'
 		UNION ALL
 		SELECT pp.id, pp.name, pp.display_name, pp.description, pp.price_per_month_cents,
 		       pp.monthly_conversions_limit, pp.monthly_images_limit, pp.features,
-		       pp.is_active, pp.created_at, pp.updated_at
+		       pp.is_active, pp.trial_days, pp.created_at, pp.updated_at
 		FROM payment_plans pp
 		JOIN user_plans up ON pp.id = up.plan_id
 		WHERE up.vendor_id = $1 AND up.status = 'active'
@@ -430,7 +431,7 @@ func (s *postgresStore) GetUserActivePlan(ctx context.Context, userID string) (P
 	err := s.db.QueryRowContext(ctx, query, userID).Scan(
 		&plan.ID, &plan.Name, &plan.DisplayName, &plan.Description,
 		&plan.PricePerMonthCents, &plan.MonthlyConversionsLimit,
-		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive,
+		&plan.MonthlyImagesLimit, pq.Array(&plan.Features), &plan.IsActive, &plan.TrialDays,
 		&plan.CreatedAt, &plan.UpdatedAt,
 	)
 	if err != nil {
@@ -0,0 +1,395 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Trial expiry defaults
+const (
+	DefaultTrialExpiryInterval  = 5 * time.Minute
+	DefaultTrialExpiryBatchSize = 100
+)
+
+var (
+	// ErrTrialNotOffered is returned when the plan has no trial period
+	ErrTrialNotOffered = fmt.Errorf("%w: plan does not offer a trial", common.ErrValidation)
+	// ErrTrialAlreadyUsed is returned when the user's phone number already had a trial
+	ErrTrialAlreadyUsed = fmt.Errorf("%w: a trial was already used for this phone number", common.ErrConflict)
+	// ErrTrialPaidPlanActive is returned when the user already pays for a plan
+	ErrTrialPaidPlanActive = fmt.Errorf("%w: a paid plan is already active", common.ErrConflict)
+	// ErrTrialsUnavailable is returned when plan trials are not configured
+	ErrTrialsUnavailable = errors.New("plan trials are not available")
+)
+
+// TrialStart is a trial to be opened for a user
+type TrialStart struct {
+	UserID       string
+	PlanName     string
+	MonthlyLimit int
+	StartedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// ExpiredTrial is an active trial whose period has ended
+type ExpiredTrial struct {
+	UserPlanID string
+	UserID     string
+	PlanName   string
+	ExpiresAt  time.Time
+}
+
+// StartTrialResponse represents a started trial
+type StartTrialResponse struct {
+	UserPlanID string      `json:"userPlanId"`
+	Plan       PaymentPlan `json:"plan"`
+	ExpiresAt  time.Time   `json:"expiresAt"`
+}
+
+// TrialStore opens and expires plan trials
+type TrialStore interface {
+	// StartTrial records the trial against the user's phone number, closes the
+	// active plan and opens the trial plan. It returns ErrTrialAlreadyUsed when
+	// the phone number already had a trial and ErrTrialPaidPlanActive when a
+	// paid plan is active. It returns the ID of the trial user plan.
+	StartTrial(ctx context.Context, start TrialStart) (string, error)
+	// ListExpiredTrials returns up to limit active trials past their expiry
+	ListExpiredTrials(ctx context.Context, limit int) ([]ExpiredTrial, error)
+	// ExpireTrial closes the trial and opens defaultPlan in its place when that
+	// plan exists. It reports false when the trial was already closed.
+	ExpireTrial(ctx context.Context, trial ExpiredTrial, defaultPlan string) (bool, error)
+}
+
+// TrialConfig configures plan trials
+type TrialConfig struct {
+	DefaultPlan string        // plan an expired trial falls back to; empty leaves the user without a plan
+	Interval    time.Duration // how often expired trials are collected
+	BatchSize   int
+}
+
+// trials holds the optional trial dependencies
+type trials struct {
+	store    TrialStore
+	notifier NotificationService
+	config   TrialConfig
+}
+
+// SetTrials enables plan trials. Expiry notifications go through notifier.
+func (s *Service) SetTrials(store TrialStore, notifier NotificationService, config TrialConfig) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultTrialExpiryInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultTrialExpiryBatchSize
+	}
+	if notifier == nil {
+		notifier = s.notifier
+	}
+	s.trials = &trials{store: store, notifier: notifier, config: config}
+}
+
+// StartTrial starts the trial of planID for the user. Each phone number gets
+// a single trial, which ends after the plan's trial days or when a plan is
+// purchased.
+func (s *Service) StartTrial(ctx context.Context, userID, planID string) (StartTrialResponse, error) {
+	if s.trials == nil {
+		return StartTrialResponse{}, ErrTrialsUnavailable
+	}
+
+	rateLimitKey := fmt.Sprintf("plan_trial:user:%s", userID)
+	if !s.rateLimiter.Allow(ctx, rateLimitKey, 5, time.Hour) {
+		return StartTrialResponse{}, errors.New("rate limit exceeded")
+	}
+
+	plan, err := s.store.GetPlan(ctx, planID)
+	if err != nil {
+		return StartTrialResponse{}, fmt.Errorf("failed to get plan: %w", err)
+	}
+	if !plan.IsActive {
+		return StartTrialResponse{}, errors.New("plan is not active")
+	}
+	if plan.TrialDays <= 0 {
+		return StartTrialResponse{}, ErrTrialNotOffered
+	}
+
+	now := time.Now()
+	start := TrialStart{
+		UserID:       userID,
+		PlanName:     plan.Name,
+		MonthlyLimit: plan.MonthlyConversionsLimit,
+		StartedAt:    now,
+		ExpiresAt:    now.AddDate(0, 0, plan.TrialDays),
+	}
+	userPlanID, err := s.trials.store.StartTrial(ctx, start)
+	if err != nil {
+		return StartTrialResponse{}, err
+	}
+	invalidatePlan(ctx, s.cache, userID)
+
+	_ = s.trials.notifier.SendPlanActivated(ctx, userID, plan.Name)
+
+	return StartTrialResponse{
+		UserPlanID: userPlanID,
+		Plan:       plan,
+		ExpiresAt:  start.ExpiresAt,
+	}, nil
+}
+
+// StartTrialExpiry runs the trial expiry loop until ctx is cancelled
+func (s *Service) StartTrialExpiry(ctx context.Context) {
+	if s.trials == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.trials.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireTrials(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Trial expiry failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireTrials moves every expired trial to the default plan and notifies its
+// user. It returns the number of trials expired.
+func (s *Service) ExpireTrials(ctx context.Context) (int, error) {
+	if s.trials == nil {
+		return 0, ErrTrialsUnavailable
+	}
+
+	expired := 0
+	for {
+		batch, err := s.trials.store.ListExpiredTrials(ctx, s.trials.config.BatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		progressed := false
+		for _, trial := range batch {
+			ok, err := s.trials.store.ExpireTrial(ctx, trial, s.trials.config.DefaultPlan)
+			if err != nil {
+				log.Printf("Failed to expire trial %s: %v", trial.UserPlanID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			progressed = true
+			expired++
+			invalidatePlan(ctx, s.cache, trial.UserID)
+			_ = s.trials.notifier.SendPlanExpired(ctx, trial.UserID, trial.PlanName)
+		}
+
+		// A batch that failed entirely would be listed again, so stop there
+		if len(batch) < s.trials.config.BatchSize || !progressed {
+			return expired, nil
+		}
+	}
+}
+
+// dbTrialStore implements TrialStore on top of user_plans and plan_trials
+type dbTrialStore struct {
+	db *sql.DB
+}
+
+// NewDBTrialStore creates a new database-backed trial store
+func NewDBTrialStore(db *sql.DB) TrialStore {
+	return &dbTrialStore{db: db}
+}
+
+// StartTrial opens the trial in one transaction. The unique phone on
+// plan_trials keeps concurrent requests from starting two trials.
+func (s *dbTrialStore) StartTrial(ctx context.Context, start TrialStart) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var phone string
+	err = tx.QueryRowContext(ctx, `SELECT phone FROM users WHERE id = $1`, start.UserID).Scan(&phone)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", fmt.Errorf("user %w", common.ErrNotFound)
+	case err != nil:
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var paid bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM user_plans up
+			LEFT JOIN payment_plans pp ON pp.name = up.plan_name
+			WHERE up.user_id = $1 AND up.status = 'active' AND NOT up.is_trial
+			  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+			  AND COALESCE(NULLIF(up.price_per_month_cents, 0), pp.price_per_month_cents, 0) > 0
+		)`, start.UserID).Scan(&paid)
+	if err != nil {
+		return "", fmt.Errorf("failed to check active plan: %w", err)
+	}
+	if paid {
+		return "", ErrTrialPaidPlanActive
+	}
+
+	var trialID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO plan_trials (phone, user_id, plan_name, started_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (phone) DO NOTHING
+		RETURNING id`,
+		phone, start.UserID, start.PlanName, start.StartedAt, start.ExpiresAt).Scan(&trialID)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", ErrTrialAlreadyUsed
+	case err != nil:
+		return "", fmt.Errorf("failed to record trial: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_plans
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE user_id = $1 AND status = 'active'`, start.UserID); err != nil {
+		return "", fmt.Errorf("failed to close current plan: %w", err)
+	}
+
+	var userPlanID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_plans (
+			user_id, plan_name, status, monthly_conversions_limit, price_per_month_cents,
+			billing_cycle_start_date, billing_cycle_end_date, auto_renew, is_trial, expires_at
+		)
+		VALUES ($1, $2, 'active', $3, 0, $4, $5, false, true, $5)
+		RETURNING id`,
+		start.UserID, start.PlanName, start.MonthlyLimit, start.StartedAt, start.ExpiresAt).Scan(&userPlanID)
+	if err != nil {
+		return "", fmt.Errorf("failed to open trial plan: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE plan_trials SET user_plan_id = $2 WHERE id = $1`,
+		trialID, userPlanID); err != nil {
+		return "", fmt.Errorf("failed to link trial plan: %w", err)
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"plan":       start.PlanName,
+		"expires_at": start.ExpiresAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode trial metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, resource_id, metadata)
+		VALUES ($1, 'user', 'trial_started', 'user_plan', $2, $3)`,
+		start.UserID, userPlanID, metadata); err != nil {
+		return "", fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit trial: %w", err)
+	}
+	return userPlanID, nil
+}
+
+// ListExpiredTrials returns the oldest expired trials first
+func (s *dbTrialStore) ListExpiredTrials(ctx context.Context, limit int) ([]ExpiredTrial, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, plan_name, expires_at
+		FROM user_plans
+		WHERE is_trial AND status = 'active' AND expires_at <= NOW()
+		ORDER BY expires_at
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired trials: %w", err)
+	}
+	defer rows.Close()
+
+	var trials []ExpiredTrial
+	for rows.Next() {
+		var trial ExpiredTrial
+		if err := rows.Scan(&trial.UserPlanID, &trial.UserID, &trial.PlanName, &trial.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan expired trial: %w", err)
+		}
+		trials = append(trials, trial)
+	}
+	return trials, rows.Err()
+}
+
+// ExpireTrial closes the trial in one transaction. The default plan is only
+// opened when the user has no other active plan.
+func (s *dbTrialStore) ExpireTrial(ctx context.Context, trial ExpiredTrial, defaultPlan string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_plans
+		SET status = 'expired', updated_at = NOW()
+		WHERE id = $1 AND status = 'active'`, trial.UserPlanID)
+	if err != nil {
+		return false, fmt.Errorf("failed to close trial: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to close trial: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if defaultPlan != "" {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_plans (
+				user_id, plan_name, status, monthly_conversions_limit, price_per_month_cents,
+				billing_cycle_start_date, billing_cycle_end_date, auto_renew, previous_user_plan_id
+			)
+			SELECT $1, pp.name, 'active', pp.monthly_conversions_limit, pp.price_per_month_cents,
+			       NOW(), NOW() + INTERVAL '1 month', false, $3
+			FROM payment_plans pp
+			WHERE pp.name = $2 AND pp.is_active
+			  AND NOT EXISTS (SELECT 1 FROM user_plans WHERE user_id = $1 AND status = 'active')`,
+			trial.UserID, defaultPlan, trial.UserPlanID); err != nil {
+			return false, fmt.Errorf("failed to open default plan: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE plan_trials SET expired_at = NOW() WHERE user_plan_id = $1`, trial.UserPlanID); err != nil {
+		return false, fmt.Errorf("failed to mark trial expired: %w", err)
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"plan":         trial.PlanName,
+		"default_plan": defaultPlan,
+		"expires_at":   trial.ExpiresAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode trial metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, resource_id, metadata)
+		VALUES ($1, 'system', 'trial_expired', 'user_plan', $2, $3)`,
+		trial.UserID, trial.UserPlanID, metadata); err != nil {
+		return false, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit trial expiry: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type fakeTrialStore struct {
+	phones   map[string]string // user ID to phone
+	used     map[string]bool   // phones that already had a trial
+	trials   map[string]*ExpiredTrial
+	closed   map[string]bool
+	defaults map[string]string // user ID to the plan opened on expiry
+}
+
+func newFakeTrialStore() *fakeTrialStore {
+	return &fakeTrialStore{
+		phones:   map[string]string{"user-1": "+989120000001", "user-2": "+989120000001", "user-3": "+989120000003"},
+		used:     make(map[string]bool),
+		trials:   make(map[string]*ExpiredTrial),
+		closed:   make(map[string]bool),
+		defaults: make(map[string]string),
+	}
+}
+
+func (f *fakeTrialStore) StartTrial(ctx context.Context, start TrialStart) (string, error) {
+	phone := f.phones[start.UserID]
+	if f.used[phone] {
+		return "", ErrTrialAlreadyUsed
+	}
+	f.used[phone] = true
+	id := fmt.Sprintf("up-trial-%d", len(f.trials)+1)
+	f.trials[id] = &ExpiredTrial{UserPlanID: id, UserID: start.UserID, PlanName: start.PlanName, ExpiresAt: start.ExpiresAt}
+	return id, nil
+}
+
+func (f *fakeTrialStore) ListExpiredTrials(ctx context.Context, limit int) ([]ExpiredTrial, error) {
+	var expired []ExpiredTrial
+	for id, trial := range f.trials {
+		if !f.closed[id] && !trial.ExpiresAt.After(time.Now()) && len(expired) < limit {
+			expired = append(expired, *trial)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeTrialStore) ExpireTrial(ctx context.Context, trial ExpiredTrial, defaultPlan string) (bool, error) {
+	if f.closed[trial.UserPlanID] {
+		return false, nil
+	}
+	f.closed[trial.UserPlanID] = true
+	f.defaults[trial.UserID] = defaultPlan
+	return true, nil
+}
+
+// recordingTrialNotifier records plan notifications by user
+type recordingTrialNotifier struct {
+	mockNotificationService
+	activated []string
+	expired   []string
+}
+
+func (r *recordingTrialNotifier) SendPlanActivated(ctx context.Context, userID string, planName string) error {
+	r.activated = append(r.activated, userID+":"+planName)
+	return nil
+}
+
+func (r *recordingTrialNotifier) SendPlanExpired(ctx context.Context, userID string, planName string) error {
+	r.expired = append(r.expired, userID+":"+planName)
+	return nil
+}
+
+func newTrialTestService() (*Service, *fakeTrialStore, *recordingTrialNotifier) {
+	store := newMockStore()
+	store.plans["plan-trial"] = PaymentPlan{
+		ID:                      "plan-trial",
+		Name:                    "premium",
+		PricePerMonthCents:      250000,
+		MonthlyConversionsLimit: 200,
+		TrialDays:               7,
+		IsActive:                true,
+	}
+	service := NewService(store, newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	trials := newFakeTrialStore()
+	notifier := &recordingTrialNotifier{}
+	service.SetTrials(trials, notifier, TrialConfig{DefaultPlan: "free", BatchSize: 1})
+	return service, trials, notifier
+}
+
+func TestStartTrial(t *testing.T) {
+	ctx := context.Background()
+	service, trials, notifier := newTrialTestService()
+
+	resp, err := service.StartTrial(ctx, "user-1", "plan-trial")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if days := resp.ExpiresAt.Sub(time.Now()).Hours() / 24; days < 6.9 || days > 7.1 {
+		t.Errorf("Expected the trial to expire in 7 days, got %.2f", days)
+	}
+	if trials.trials[resp.UserPlanID].PlanName != "premium" || len(notifier.activated) != 1 {
+		t.Errorf("Expected the premium trial to be opened and announced, got %+v", trials.trials[resp.UserPlanID])
+	}
+
+	// user-2 shares the phone number of user-1
+	if _, err := service.StartTrial(ctx, "user-2", "plan-trial"); !errors.Is(err, ErrTrialAlreadyUsed) {
+		t.Errorf("Expected a repeat trial to be rejected, got %v", err)
+	}
+	if _, err := service.StartTrial(ctx, "user-3", "plan-1"); !errors.Is(err, ErrTrialNotOffered) {
+		t.Errorf("Expected a plan without trial days to be rejected, got %v", err)
+	}
+
+	unconfigured := NewService(newMockStore(), newMockGateway(), &mockUserService{}, &mockNotificationService{},
+		&mockQuotaService{}, &mockAuditLogger{}, &mockRateLimiter{}, &mockPaymentConfigService{})
+	if _, err := unconfigured.StartTrial(ctx, "user-1", "plan-trial"); !errors.Is(err, ErrTrialsUnavailable) {
+		t.Errorf("Expected trials to be unavailable, got %v", err)
+	}
+}
+
+func TestExpireTrials(t *testing.T) {
+	ctx := context.Background()
+	service, trials, notifier := newTrialTestService()
+
+	trials.trials["up-old-1"] = &ExpiredTrial{UserPlanID: "up-old-1", UserID: "user-1", PlanName: "premium", ExpiresAt: time.Now().Add(-time.Hour)}
+	trials.trials["up-old-2"] = &ExpiredTrial{UserPlanID: "up-old-2", UserID: "user-3", PlanName: "premium", ExpiresAt: time.Now().Add(-time.Minute)}
+	trials.trials["up-live"] = &ExpiredTrial{UserPlanID: "up-live", UserID: "user-2", PlanName: "premium", ExpiresAt: time.Now().Add(time.Hour)}
+
+	expired, err := service.ExpireTrials(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expired != 2 || trials.closed["up-live"] {
+		t.Errorf("Expected both expired trials across batches and not the live one, got %d", expired)
+	}
+	if trials.defaults["user-1"] != "free" || trials.defaults["user-3"] != "free" {
+		t.Errorf("Expected expired trials to fall back to the free plan, got %v", trials.defaults)
+	}
+	if len(notifier.expired) != 2 {
+		t.Errorf("Expected two expiry notifications, got %v", notifier.expired)
+	}
+
+	if again, _ := service.ExpireTrials(ctx); again != 0 {
+		t.Errorf("Expected nothing left to expire, got %d", again)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/google/wire"
 )
 
@@ -39,7 +41,7 @@ func NewPaymentService(
 	store := NewPaymentStore(db)
 	gateway := NewZarinpalGatewayFromConfig(configService)
 
-	return NewService(
+	service := NewService(
 		store,
 		gateway,
 		userService,
@@ -49,6 +51,9 @@ func NewPaymentService(
 		rateLimiter,
 		configService,
 	)
+	service.SetUnitOfWork(common.NewUnitOfWork(db))
+
+	return service
 }
 
 // WirePaymentService creates a payment service with all dependencies
@@ -78,6 +83,7 @@ func WirePaymentService(db *sql.DB) (*Service, *Handler) {
 		rateLimiter,
 		configService,
 	)
+	service.SetUnitOfWork(common.NewUnitOfWork(db))
 
 	// Create handler
 	handler := NewHandler(service)
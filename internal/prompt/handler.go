@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for prompt template management
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new prompt template handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListTemplates handles GET /admin/prompt-templates
+func (h *Handler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate handles GET /admin/prompt-templates/:key
+func (h *Handler) GetTemplate(c *gin.Context) {
+	template, err := h.service.GetTemplate(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// CreateTemplate handles POST /admin/prompt-templates
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate handles PUT /admin/prompt-templates/:key
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(c.Request.Context(), fmt.Sprint(adminID), c.Param("key"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate handles DELETE /admin/prompt-templates/:key
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), fmt.Sprint(adminID), c.Param("key")); err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateVersion handles POST /admin/prompt-templates/:key/versions
+func (h *Handler) CreateVersion(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.service.AddVersion(c.Request.Context(), fmt.Sprint(adminID), c.Param("key"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetStats handles GET /admin/prompt-templates/:key/stats
+func (h *Handler) GetStats(c *gin.Context) {
+	var req StatsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.service.GetStats(c.Request.Context(), c.Param("key"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,38 @@
+package prompt
+
+import (
+	"context"
+	"time"
+)
+
+// Store defines the interface for prompt template data operations
+type Store interface {
+	// ListTemplates returns every template with its versions, ordered by key
+	ListTemplates(ctx context.Context) ([]Template, error)
+
+	// GetTemplate returns a template with its versions
+	GetTemplate(ctx context.Context, key string) (*Template, error)
+
+	// CreateTemplate stores a new template with its first version and audits it
+	CreateTemplate(ctx context.Context, template Template, first Version) (*Template, error)
+
+	// AddVersion stores the next version of a template, activating it when
+	// activate is set, and audits it
+	AddVersion(ctx context.Context, version Version, activate bool) (*Template, error)
+
+	// UpdateTemplate replaces a template's settings and version weights and
+	// audits the change
+	UpdateTemplate(ctx context.Context, template Template) (*Template, error)
+
+	// DeleteTemplate removes a template with its versions and recorded runs
+	// and audits the removal
+	DeleteTemplate(ctx context.Context, key, deletedBy string) error
+
+	// RecordRun notes the version a conversion is made with, replacing the
+	// version of an earlier attempt
+	RecordRun(ctx context.Context, key string, version int, conversionID string) error
+
+	// VersionStats counts the outcomes of the conversions made with each
+	// version of a template between from and to
+	VersionStats(ctx context.Context, key string, from, to time.Time) ([]VersionStats, error)
+}
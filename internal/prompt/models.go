@@ -0,0 +1,118 @@
+package prompt
+
+import (
+	"time"
+)
+
+// Template is a versioned provider prompt. It serves its active version, or
+// splits conversions between the versions with a weight while an experiment
+// is running.
+type Template struct {
+	Key               string    `json:"key"`
+	Description       string    `json:"description"`
+	ActiveVersion     int       `json:"activeVersion"`
+	ExperimentEnabled bool      `json:"experimentEnabled"`
+	Versions          []Version `json:"versions"`
+	CreatedBy         *string   `json:"createdBy,omitempty"`
+	UpdatedBy         *string   `json:"updatedBy,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// Version is an immutable revision of a template's body
+type Version struct {
+	TemplateKey string `json:"templateKey"`
+	Version     int    `json:"version"`
+	Body        string `json:"body"`
+	Notes       string `json:"notes"`
+	// Weight is the percentage of conversions the version gets while the
+	// template's experiment is running
+	Weight    int       `json:"weight"`
+	CreatedBy *string   `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// version returns the version number n of t, or nil
+func (t *Template) version(n int) *Version {
+	for i := range t.Versions {
+		if t.Versions[i].Version == n {
+			return &t.Versions[i]
+		}
+	}
+	return nil
+}
+
+// CreateTemplateRequest is the body of POST /admin/prompt-templates
+type CreateTemplateRequest struct {
+	// Key is a lowercase identifier such as conversion
+	Key         string `json:"key" binding:"required"`
+	Description string `json:"description"`
+	// Body becomes version 1 and is served right away
+	Body  string `json:"body" binding:"required"`
+	Notes string `json:"notes"`
+}
+
+// CreateVersionRequest is the body of POST /admin/prompt-templates/:key/versions
+type CreateVersionRequest struct {
+	Body  string `json:"body" binding:"required"`
+	Notes string `json:"notes"`
+	// Activate serves the new version right away
+	Activate bool `json:"activate"`
+}
+
+// UpdateTemplateRequest is the body of PUT /admin/prompt-templates/:key.
+// Fields left out keep their value.
+type UpdateTemplateRequest struct {
+	Description       *string `json:"description"`
+	ActiveVersion     *int    `json:"activeVersion"`
+	ExperimentEnabled *bool   `json:"experimentEnabled"`
+	// Weights maps version numbers to their share of conversions during the
+	// experiment; versions left out get none. They must add up to 100.
+	Weights map[int]int `json:"weights"`
+}
+
+// ListTemplatesResponse is the response of GET /admin/prompt-templates
+type ListTemplatesResponse struct {
+	Templates []Template `json:"templates"`
+	Total     int        `json:"total"`
+}
+
+// StatsRequest filters GET /admin/prompt-templates/:key/stats
+type StatsRequest struct {
+	DateFrom string `json:"dateFrom" form:"dateFrom" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"dateTo" form:"dateTo" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// VersionStats are the outcomes of the conversions made with a version.
+// Rates are shares of the finished conversions.
+type VersionStats struct {
+	Version         int     `json:"version"`
+	Conversions     int     `json:"conversions"`
+	Completed       int     `json:"completed"`
+	Failed          int     `json:"failed"`
+	SafetyBlocked   int     `json:"safetyBlocked"`
+	SuccessRate     float64 `json:"successRate"`
+	SafetyBlockRate float64 `json:"safetyBlockRate"`
+}
+
+// StatsResponse is the response of GET /admin/prompt-templates/:key/stats
+type StatsResponse struct {
+	Key      string         `json:"key"`
+	DateFrom time.Time      `json:"dateFrom"`
+	DateTo   time.Time      `json:"dateTo"`
+	Versions []VersionStats `json:"versions"`
+}
+
+// Config configures template selection
+type Config struct {
+	// How long templates read for selection are cached per instance. Admin
+	// changes clear the cache of the instance that made them right away.
+	CacheTTL time.Duration
+}
+
+// DefaultConfig returns the default prompt template configuration
+func DefaultConfig() Config {
+	return Config{
+		CacheTTL: 30 * time.Second,
+	}
+}
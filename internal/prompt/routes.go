@@ -0,0 +1,20 @@
+package prompt
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes mounts the prompt template management routes on an
+// admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	templates := router.Group("/prompt-templates")
+	{
+		templates.GET("", handler.ListTemplates)                // GET /admin/prompt-templates
+		templates.POST("", handler.CreateTemplate)              // POST /admin/prompt-templates
+		templates.GET("/:key", handler.GetTemplate)             // GET /admin/prompt-templates/:key
+		templates.PUT("/:key", handler.UpdateTemplate)          // PUT /admin/prompt-templates/:key
+		templates.DELETE("/:key", handler.DeleteTemplate)       // DELETE /admin/prompt-templates/:key
+		templates.POST("/:key/versions", handler.CreateVersion) // POST /admin/prompt-templates/:key/versions
+		templates.GET("/:key/stats", handler.GetStats)          // GET /admin/prompt-templates/:key/stats
+	}
+}
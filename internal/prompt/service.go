@@ -0,0 +1,352 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Template keys are lowercase identifiers
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// maxBodyLength bounds a template body in bytes
+const maxBodyLength = 20000
+
+// defaultStatsDays is the period the stats cover without dateFrom
+const defaultStatsDays = 30
+
+// Service selects the prompt version each conversion is made with and
+// manages the templates for admins
+type Service struct {
+	store  Store
+	config Config
+
+	mutex sync.Mutex
+	cache map[string]cachedTemplate
+	now   func() time.Time
+}
+
+// cachedTemplate is a template read for selection; template is nil when the
+// key does not exist
+type cachedTemplate struct {
+	template *Template
+	expires  time.Time
+}
+
+// NewService creates a new prompt template service
+func NewService(store Store, config Config) *Service {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultConfig().CacheTTL
+	}
+	return &Service{
+		store:  store,
+		config: config,
+		cache:  make(map[string]cachedTemplate),
+		now:    time.Now,
+	}
+}
+
+// Select picks the version of template key conversionID is made with and
+// records it for the stats. During an experiment users fall in a version by
+// a stable hash, so they keep it for as long as the weights stay the same.
+func (s *Service) Select(ctx context.Context, key, conversionID, userID string) (*Version, error) {
+	template, err := s.cached(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("prompt template %s: %w", key, common.ErrNotFound)
+	}
+
+	subject := userID
+	if subject == "" {
+		subject = conversionID
+	}
+	version := pickVersion(template, subject)
+	if version == nil {
+		return nil, fmt.Errorf("prompt template %s version %d: %w", key, template.ActiveVersion, common.ErrNotFound)
+	}
+
+	if conversionID != "" {
+		if err := s.store.RecordRun(ctx, key, version.Version, conversionID); err != nil {
+			log.Printf("Failed to record prompt version of conversion %s: %v", conversionID, err)
+		}
+	}
+	selected := *version
+	return &selected, nil
+}
+
+// ListTemplates returns every template
+func (s *Service) ListTemplates(ctx context.Context) (ListTemplatesResponse, error) {
+	templates, err := s.store.ListTemplates(ctx)
+	if err != nil {
+		return ListTemplatesResponse{}, err
+	}
+	return ListTemplatesResponse{Templates: templates, Total: len(templates)}, nil
+}
+
+// GetTemplate returns the template key with its versions
+func (s *Service) GetTemplate(ctx context.Context, key string) (*Template, error) {
+	return s.store.GetTemplate(ctx, key)
+}
+
+// CreateTemplate validates and stores a new template, serving its body as
+// version 1
+func (s *Service) CreateTemplate(ctx context.Context, adminID string, req CreateTemplateRequest) (*Template, error) {
+	template := Template{
+		Key:           strings.TrimSpace(req.Key),
+		Description:   strings.TrimSpace(req.Description),
+		ActiveVersion: 1,
+		CreatedBy:     &adminID,
+	}
+	if !keyPattern.MatchString(template.Key) {
+		return nil, fmt.Errorf("%w: key must be a lowercase identifier such as conversion", common.ErrValidation)
+	}
+	first := Version{
+		TemplateKey: template.Key,
+		Version:     1,
+		Body:        req.Body,
+		Notes:       strings.TrimSpace(req.Notes),
+		CreatedBy:   &adminID,
+	}
+	if err := validateBody(first.Body); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.CreateTemplate(ctx, template, first)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(template.Key)
+	return created, nil
+}
+
+// AddVersion stores req as the next version of the template key. Earlier
+// versions are kept unchanged, so their stats stay comparable.
+func (s *Service) AddVersion(ctx context.Context, adminID, key string, req CreateVersionRequest) (*Template, error) {
+	version := Version{
+		TemplateKey: key,
+		Body:        req.Body,
+		Notes:       strings.TrimSpace(req.Notes),
+		CreatedBy:   &adminID,
+	}
+	if err := validateBody(version.Body); err != nil {
+		return nil, err
+	}
+
+	template, err := s.store.AddVersion(ctx, version, req.Activate)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(key)
+	return template, nil
+}
+
+// UpdateTemplate applies the set fields of req to the template key
+func (s *Service) UpdateTemplate(ctx context.Context, adminID, key string, req UpdateTemplateRequest) (*Template, error) {
+	template, err := s.store.GetTemplate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		template.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.ActiveVersion != nil {
+		if template.version(*req.ActiveVersion) == nil {
+			return nil, fmt.Errorf("%w: template %s has no version %d", common.ErrValidation, key, *req.ActiveVersion)
+		}
+		template.ActiveVersion = *req.ActiveVersion
+	}
+	if req.Weights != nil {
+		for version, weight := range req.Weights {
+			if template.version(version) == nil {
+				return nil, fmt.Errorf("%w: template %s has no version %d", common.ErrValidation, key, version)
+			}
+			if weight < 0 || weight > 100 {
+				return nil, fmt.Errorf("%w: weights must be between 0 and 100", common.ErrValidation)
+			}
+		}
+		for i := range template.Versions {
+			template.Versions[i].Weight = req.Weights[template.Versions[i].Version]
+		}
+	}
+	if req.ExperimentEnabled != nil {
+		template.ExperimentEnabled = *req.ExperimentEnabled
+	}
+	if template.ExperimentEnabled {
+		if err := validateExperiment(template); err != nil {
+			return nil, err
+		}
+	}
+	template.UpdatedBy = &adminID
+
+	updated, err := s.store.UpdateTemplate(ctx, *template)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(key)
+	return updated, nil
+}
+
+// DeleteTemplate removes the template key. Conversions fall back to
+// DefaultConversionTemplate when the conversion template is removed.
+func (s *Service) DeleteTemplate(ctx context.Context, adminID, key string) error {
+	if err := s.store.DeleteTemplate(ctx, key, adminID); err != nil {
+		return err
+	}
+	s.invalidate(key)
+	return nil
+}
+
+// GetStats returns the success and safety-block rates of every version of
+// the template key
+func (s *Service) GetStats(ctx context.Context, key string, req StatsRequest) (*StatsResponse, error) {
+	from, to, err := parseDateRange(req.DateFrom, req.DateTo, defaultStatsDays)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetTemplate(ctx, key); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.store.VersionStats(ctx, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		finished := versions[i].Completed + versions[i].Failed
+		versions[i].SuccessRate = rate(versions[i].Completed, finished)
+		versions[i].SafetyBlockRate = rate(versions[i].SafetyBlocked, finished)
+	}
+	return &StatsResponse{Key: key, DateFrom: from, DateTo: to, Versions: versions}, nil
+}
+
+// cached returns the template key, reading it from the store once the
+// cached copy has expired
+func (s *Service) cached(ctx context.Context, key string) (*Template, error) {
+	s.mutex.Lock()
+	entry, ok := s.cache[key]
+	s.mutex.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		return entry.template, nil
+	}
+
+	template, err := s.store.GetTemplate(ctx, key)
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[key] = cachedTemplate{template: template, expires: s.now().Add(s.config.CacheTTL)}
+	s.mutex.Unlock()
+	return template, nil
+}
+
+// invalidate drops the cached copy of the template key after an admin
+// change. Other instances pick up the change once their copy expires.
+func (s *Service) invalidate(key string) {
+	s.mutex.Lock()
+	delete(s.cache, key)
+	s.mutex.Unlock()
+}
+
+// pickVersion returns the version subject is served: its experiment bucket's
+// version while an experiment runs, the active version otherwise
+func pickVersion(template *Template, subject string) *Version {
+	if template.ExperimentEnabled {
+		bucket := experimentBucket(template.Key, subject)
+		for i := range template.Versions {
+			bucket -= template.Versions[i].Weight
+			if bucket < 0 {
+				return &template.Versions[i]
+			}
+		}
+	}
+	return template.version(template.ActiveVersion)
+}
+
+// experimentBucket places a subject in one of 100 buckets, independently
+// per template
+func experimentBucket(key, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+// validateBody checks a template body's size and variables
+func validateBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is required", common.ErrValidation)
+	}
+	if len(body) > maxBodyLength {
+		return fmt.Errorf("%w: body must be at most %d bytes", common.ErrValidation, maxBodyLength)
+	}
+	if unknown := UnknownVariables(body); len(unknown) > 0 {
+		return fmt.Errorf("%w: unknown variables %s; templates may use %s", common.ErrValidation,
+			strings.Join(unknown, ", "), strings.Join(Variables, ", "))
+	}
+	return nil
+}
+
+// validateExperiment checks that the weights of a running experiment split
+// every conversion between at least two versions
+func validateExperiment(template *Template) error {
+	total, weighted := 0, 0
+	for _, version := range template.Versions {
+		total += version.Weight
+		if version.Weight > 0 {
+			weighted++
+		}
+	}
+	if weighted < 2 {
+		return fmt.Errorf("%w: an experiment needs weights on at least two versions", common.ErrValidation)
+	}
+	if total != 100 {
+		return fmt.Errorf("%w: experiment weights must add up to 100, got %d", common.ErrValidation, total)
+	}
+	return nil
+}
+
+// parseDateRange parses inclusive YYYY-MM-DD dates into a half-open range.
+// dateTo defaults to today and dateFrom to defaultDays before it.
+func parseDateRange(dateFrom, dateTo string, defaultDays int) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if dateTo != "" {
+		parsed, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: dateTo must be YYYY-MM-DD", common.ErrValidation)
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultDays)
+	if dateFrom != "" {
+		parsed, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: dateFrom must be YYYY-MM-DD", common.ErrValidation)
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: dateFrom must be before dateTo", common.ErrValidation)
+	}
+	return from, to, nil
+}
+
+// rate returns part/total rounded to four decimals
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 10000
+}
@@ -0,0 +1,257 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type fakeStore struct {
+	templates map[string]Template
+	runs      map[string]int // conversion ID -> version
+	stats     []VersionStats
+	gets      int
+}
+
+func newFakeStore(templates ...Template) *fakeStore {
+	store := &fakeStore{templates: make(map[string]Template), runs: make(map[string]int)}
+	for _, template := range templates {
+		store.templates[template.Key] = template
+	}
+	return store
+}
+
+func (f *fakeStore) ListTemplates(ctx context.Context) ([]Template, error) {
+	templates := []Template{}
+	for _, template := range f.templates {
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+func (f *fakeStore) GetTemplate(ctx context.Context, key string) (*Template, error) {
+	f.gets++
+	template, ok := f.templates[key]
+	if !ok {
+		return nil, fmt.Errorf("prompt template %s: %w", key, common.ErrNotFound)
+	}
+	template.Versions = append([]Version(nil), template.Versions...)
+	return &template, nil
+}
+
+func (f *fakeStore) CreateTemplate(ctx context.Context, template Template, first Version) (*Template, error) {
+	if _, ok := f.templates[template.Key]; ok {
+		return nil, common.ErrConflict
+	}
+	template.Versions = []Version{first}
+	f.templates[template.Key] = template
+	return &template, nil
+}
+
+func (f *fakeStore) AddVersion(ctx context.Context, version Version, activate bool) (*Template, error) {
+	template, ok := f.templates[version.TemplateKey]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+	version.Version = len(template.Versions) + 1
+	template.Versions = append(template.Versions, version)
+	if activate {
+		template.ActiveVersion = version.Version
+	}
+	f.templates[template.Key] = template
+	return &template, nil
+}
+
+func (f *fakeStore) UpdateTemplate(ctx context.Context, template Template) (*Template, error) {
+	f.templates[template.Key] = template
+	return &template, nil
+}
+
+func (f *fakeStore) DeleteTemplate(ctx context.Context, key, deletedBy string) error {
+	if _, ok := f.templates[key]; !ok {
+		return common.ErrNotFound
+	}
+	delete(f.templates, key)
+	return nil
+}
+
+func (f *fakeStore) RecordRun(ctx context.Context, key string, version int, conversionID string) error {
+	f.runs[conversionID] = version
+	return nil
+}
+
+func (f *fakeStore) VersionStats(ctx context.Context, key string, from, to time.Time) ([]VersionStats, error) {
+	return f.stats, nil
+}
+
+// conversionTemplate has versions 1 to n, serving version 1
+func conversionTemplate(n int) Template {
+	template := Template{Key: KeyConversion, ActiveVersion: 1}
+	for i := 1; i <= n; i++ {
+		template.Versions = append(template.Versions, Version{TemplateKey: KeyConversion, Version: i, Body: fmt.Sprintf("prompt %d", i)})
+	}
+	return template
+}
+
+func TestRender(t *testing.T) {
+	body := "Apply {{ style }} at {{quality}}.{{styleInstruction}}"
+	if got := Render(body, map[string]string{VarStyle: "casual", VarQuality: "high"}); got != "Apply casual at high." {
+		t.Errorf("Expected variables replaced and unset ones removed, got %q", got)
+	}
+
+	unknown := UnknownVariables("{{style}} {{colour}} {{colour}} {{season}}")
+	if len(unknown) != 2 || unknown[0] != "colour" || unknown[1] != "season" {
+		t.Errorf("Expected colour and season reported once each, got %v", unknown)
+	}
+	if unknown := UnknownVariables(DefaultConversionTemplate); len(unknown) != 0 {
+		t.Errorf("Expected the default template to use known variables only, got %v", unknown)
+	}
+}
+
+func TestSelect_ActiveVersionAndCache(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore(conversionTemplate(2))
+	service := NewService(store, DefaultConfig())
+
+	version, err := service.Select(ctx, KeyConversion, "conv-1", "user-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if version.Version != 1 || store.runs["conv-1"] != 1 {
+		t.Errorf("Expected the active version selected and recorded, got %+v, runs %v", version, store.runs)
+	}
+
+	service.Select(ctx, KeyConversion, "conv-2", "user-1")
+	if store.gets != 1 {
+		t.Errorf("Expected the template to be cached, read %d times", store.gets)
+	}
+
+	two := 2
+	if _, err := service.UpdateTemplate(ctx, "admin-1", KeyConversion, UpdateTemplateRequest{ActiveVersion: &two}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if version, _ := service.Select(ctx, KeyConversion, "conv-3", "user-1"); version.Version != 2 {
+		t.Errorf("Expected the new active version right after the update, got %d", version.Version)
+	}
+
+	if _, err := service.Select(ctx, "missing", "conv-4", "user-1"); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found for a missing template, got %v", err)
+	}
+}
+
+func TestSelect_ExperimentSplitsUsers(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore(conversionTemplate(3))
+	service := NewService(store, DefaultConfig())
+
+	enabled := true
+	_, err := service.UpdateTemplate(ctx, "admin-1", KeyConversion, UpdateTemplateRequest{
+		ExperimentEnabled: &enabled,
+		Weights:           map[int]int{1: 50, 3: 50},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		version, err := service.Select(ctx, KeyConversion, "conv-"+userID, userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		counts[version.Version]++
+
+		again, _ := service.Select(ctx, KeyConversion, "conv-again-"+userID, userID)
+		if again.Version != version.Version {
+			t.Fatalf("Expected %s to keep version %d, got %d", userID, version.Version, again.Version)
+		}
+	}
+	if counts[2] != 0 {
+		t.Errorf("Expected no conversions for the unweighted version, got %d", counts[2])
+	}
+	if counts[1] < 400 || counts[3] < 400 {
+		t.Errorf("Expected roughly even split between versions 1 and 3, got %v", counts)
+	}
+}
+
+func TestUpdateTemplate_Validation(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(newFakeStore(conversionTemplate(2)), DefaultConfig())
+	enabled, missing := true, 5
+
+	tests := []struct {
+		name string
+		req  UpdateTemplateRequest
+	}{
+		{"missing active version", UpdateTemplateRequest{ActiveVersion: &missing}},
+		{"weight of missing version", UpdateTemplateRequest{Weights: map[int]int{missing: 10}}},
+		{"weight out of range", UpdateTemplateRequest{Weights: map[int]int{1: 120}}},
+		{"weights below 100", UpdateTemplateRequest{ExperimentEnabled: &enabled, Weights: map[int]int{1: 40, 2: 40}}},
+		{"single weighted version", UpdateTemplateRequest{ExperimentEnabled: &enabled, Weights: map[int]int{1: 100}}},
+	}
+	for _, tt := range tests {
+		if _, err := service.UpdateTemplate(ctx, "admin-1", KeyConversion, tt.req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestCreateTemplateAndVersion(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(newFakeStore(), DefaultConfig())
+
+	if _, err := service.CreateTemplate(ctx, "admin-1", CreateTemplateRequest{Key: "Conversion", Body: "x"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an invalid key to be rejected, got %v", err)
+	}
+	if _, err := service.CreateTemplate(ctx, "admin-1", CreateTemplateRequest{Key: KeyConversion, Body: "Wear {{colour}}"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an unknown variable to be rejected, got %v", err)
+	}
+
+	template, err := service.CreateTemplate(ctx, "admin-1", CreateTemplateRequest{Key: KeyConversion, Body: "Wear {{style}}"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if template.ActiveVersion != 1 || len(template.Versions) != 1 {
+		t.Errorf("Expected the body served as version 1, got %+v", template)
+	}
+
+	template, err = service.AddVersion(ctx, "admin-1", KeyConversion, CreateVersionRequest{Body: "Put on {{style}}", Activate: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if template.ActiveVersion != 2 || template.Versions[0].Body != "Wear {{style}}" {
+		t.Errorf("Expected version 2 active with version 1 unchanged, got %+v", template)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	store := newFakeStore(conversionTemplate(2))
+	store.stats = []VersionStats{
+		{Version: 1, Conversions: 12, Completed: 6, Failed: 2, SafetyBlocked: 1},
+		{Version: 2},
+	}
+	service := NewService(store, DefaultConfig())
+
+	stats, err := service.GetStats(context.Background(), KeyConversion, StatsRequest{DateFrom: "2026-01-01", DateTo: "2026-01-31"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats.Versions[0].SuccessRate != 0.75 || stats.Versions[0].SafetyBlockRate != 0.125 {
+		t.Errorf("Expected rates over finished conversions, got %+v", stats.Versions[0])
+	}
+	if stats.Versions[1].SuccessRate != 0 {
+		t.Errorf("Expected no rate for an unused version, got %+v", stats.Versions[1])
+	}
+	if !stats.DateTo.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected dateTo to include the last day, got %s", stats.DateTo)
+	}
+
+	if _, err := service.GetStats(context.Background(), "missing", StatsRequest{}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found for a missing template, got %v", err)
+	}
+}
@@ -0,0 +1,403 @@
+package prompt
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// templateColumns are the prompt_templates columns scanned by scanTemplate
+const templateColumns = `key, description, active_version, experiment_enabled,
+	created_by, updated_by, created_at, updated_at`
+
+// versionColumns are the prompt_template_versions columns scanned by scanVersion
+const versionColumns = `template_key, version, body, notes, weight, created_by, created_at`
+
+// safetyBlockedCode is the error code the worker stores on conversions the
+// provider's safety filter blocked (worker.ErrorCodeSafetyBlocked)
+const safetyBlockedCode = "safety_blocked"
+
+// DBStore implements Store on top of the prompt_templates tables
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// queryer is a *sql.DB or *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ListTemplates returns every template with its versions, ordered by key
+func (s *DBStore) ListTemplates(ctx context.Context) ([]Template, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM prompt_templates ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []Template{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	versions, err := listVersions(ctx, s.db, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		if list, ok := versions[templates[i].Key]; ok {
+			templates[i].Versions = list
+		}
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a template with its versions
+func (s *DBStore) GetTemplate(ctx context.Context, key string) (*Template, error) {
+	return loadTemplate(ctx, s.db, key)
+}
+
+// CreateTemplate stores a new template with its first version and records it
+// in the audit log in the same transaction
+func (s *DBStore) CreateTemplate(ctx context.Context, template Template, first Version) (*Template, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO prompt_templates (key, description, active_version, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $4)`,
+		template.Key, template.Description, first.Version, template.CreatedBy)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%w: prompt template %s already exists", common.ErrConflict, template.Key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prompt template: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO prompt_template_versions (template_key, version, body, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5)`,
+		template.Key, first.Version, first.Body, first.Notes, first.CreatedBy); err != nil {
+		return nil, fmt.Errorf("failed to create prompt template version: %w", err)
+	}
+
+	created, err := loadTemplate(ctx, tx, template.Key)
+	if err != nil {
+		return nil, err
+	}
+	if template.CreatedBy != nil {
+		if err := audit(ctx, tx, *template.CreatedBy, "create", map[string]interface{}{"key": template.Key, "version": first.Version}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt template: %w", err)
+	}
+	return created, nil
+}
+
+// AddVersion stores the next version of a template and records it in the
+// audit log in the same transaction
+func (s *DBStore) AddVersion(ctx context.Context, version Version, activate bool) (*Template, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the template serializes version numbers
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT TRUE FROM prompt_templates WHERE key = $1 FOR UPDATE`, version.TemplateKey).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("prompt template %s: %w", version.TemplateKey, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock prompt template: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO prompt_template_versions (template_key, version, body, notes, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+		FROM prompt_template_versions WHERE template_key = $1
+		RETURNING version`,
+		version.TemplateKey, version.Body, version.Notes, version.CreatedBy,
+	).Scan(&version.Version); err != nil {
+		return nil, fmt.Errorf("failed to create prompt template version: %w", err)
+	}
+	if activate {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE prompt_templates SET active_version = $2, updated_by = $3, updated_at = NOW()
+			WHERE key = $1`, version.TemplateKey, version.Version, version.CreatedBy); err != nil {
+			return nil, fmt.Errorf("failed to activate prompt template version: %w", err)
+		}
+	}
+
+	template, err := loadTemplate(ctx, tx, version.TemplateKey)
+	if err != nil {
+		return nil, err
+	}
+	if version.CreatedBy != nil {
+		if err := audit(ctx, tx, *version.CreatedBy, "create_version", map[string]interface{}{
+			"key": version.TemplateKey, "version": version.Version, "activated": activate,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt template version: %w", err)
+	}
+	return template, nil
+}
+
+// UpdateTemplate replaces a template's settings and version weights and
+// records the change in the audit log in the same transaction
+func (s *DBStore) UpdateTemplate(ctx context.Context, template Template) (*Template, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE prompt_templates
+		SET description = $2, active_version = $3, experiment_enabled = $4, updated_by = $5, updated_at = NOW()
+		WHERE key = $1`,
+		template.Key, template.Description, template.ActiveVersion, template.ExperimentEnabled, template.UpdatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update prompt template: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("prompt template %s: %w", template.Key, common.ErrNotFound)
+	}
+
+	weights := make(map[int]int, len(template.Versions))
+	for _, version := range template.Versions {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE prompt_template_versions SET weight = $3
+			WHERE template_key = $1 AND version = $2`,
+			template.Key, version.Version, version.Weight); err != nil {
+			return nil, fmt.Errorf("failed to update prompt template weights: %w", err)
+		}
+		if version.Weight > 0 {
+			weights[version.Version] = version.Weight
+		}
+	}
+
+	updated, err := loadTemplate(ctx, tx, template.Key)
+	if err != nil {
+		return nil, err
+	}
+	if template.UpdatedBy != nil {
+		if err := audit(ctx, tx, *template.UpdatedBy, "update", map[string]interface{}{
+			"key":               template.Key,
+			"activeVersion":     template.ActiveVersion,
+			"experimentEnabled": template.ExperimentEnabled,
+			"weights":           weights,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt template: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteTemplate removes a template with its versions and records the
+// removal in the audit log
+func (s *DBStore) DeleteTemplate(ctx context.Context, key, deletedBy string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM prompt_templates WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("prompt template %s: %w", key, common.ErrNotFound)
+	}
+	// A template created later under the same key starts with fresh stats
+	if _, err := tx.ExecContext(ctx, `DELETE FROM prompt_template_runs WHERE template_key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete prompt template runs: %w", err)
+	}
+
+	if err := audit(ctx, tx, deletedBy, "delete", map[string]interface{}{"key": key}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt template removal: %w", err)
+	}
+	return nil
+}
+
+// RecordRun notes the version a conversion is made with; a retried
+// conversion counts towards the version of its last attempt
+func (s *DBStore) RecordRun(ctx context.Context, key string, version int, conversionID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO prompt_template_runs (conversion_id, template_key, version)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (conversion_id) DO UPDATE
+		SET template_key = EXCLUDED.template_key, version = EXCLUDED.version, created_at = NOW()`,
+		conversionID, key, version); err != nil {
+		return fmt.Errorf("failed to record prompt template run: %w", err)
+	}
+	return nil
+}
+
+// VersionStats counts the outcomes of the conversions made with each
+// version of a template between from and to, including unused versions
+func (s *DBStore) VersionStats(ctx context.Context, key string, from, to time.Time) ([]VersionStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT v.version,
+		       COUNT(c.id),
+		       COUNT(c.id) FILTER (WHERE c.status = 'completed'),
+		       COUNT(c.id) FILTER (WHERE c.status = 'failed'),
+		       COUNT(c.id) FILTER (WHERE c.status = 'failed' AND c.error_code = $4)
+		FROM prompt_template_versions v
+		LEFT JOIN prompt_template_runs r
+		       ON r.template_key = v.template_key AND r.version = v.version
+		      AND r.created_at >= $2 AND r.created_at < $3
+		LEFT JOIN conversions c ON c.id = r.conversion_id
+		WHERE v.template_key = $1
+		GROUP BY v.version
+		ORDER BY v.version`, key, from, to, safetyBlockedCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []VersionStats{}
+	for rows.Next() {
+		var version VersionStats
+		if err := rows.Scan(&version.Version, &version.Conversions, &version.Completed, &version.Failed, &version.SafetyBlocked); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template stats: %w", err)
+		}
+		stats = append(stats, version)
+	}
+	return stats, rows.Err()
+}
+
+// loadTemplate reads a template with its versions
+func loadTemplate(ctx context.Context, q queryer, key string) (*Template, error) {
+	template, err := scanTemplate(q.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM prompt_templates WHERE key = $1`, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("prompt template %s: %w", key, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := listVersions(ctx, q, key)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := versions[key]; ok {
+		template.Versions = list
+	}
+	return template, nil
+}
+
+// listVersions returns the versions of the template key, or of every
+// template when key is empty, grouped by template and ordered by version
+func listVersions(ctx context.Context, q queryer, key string) (map[string][]Version, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+versionColumns+` FROM prompt_template_versions
+		WHERE $1 = '' OR template_key = $1
+		ORDER BY template_key, version`, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt template versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string][]Version)
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions[version.TemplateKey] = append(versions[version.TemplateKey], *version)
+	}
+	return versions, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTemplate(row rowScanner) (*Template, error) {
+	var template Template
+	var createdBy, updatedBy sql.NullString
+	err := row.Scan(&template.Key, &template.Description, &template.ActiveVersion, &template.ExperimentEnabled,
+		&createdBy, &updatedBy, &template.CreatedAt, &template.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+	}
+	if createdBy.Valid {
+		template.CreatedBy = &createdBy.String
+	}
+	if updatedBy.Valid {
+		template.UpdatedBy = &updatedBy.String
+	}
+	template.Versions = []Version{}
+	return &template, nil
+}
+
+func scanVersion(row rowScanner) (*Version, error) {
+	var version Version
+	var createdBy sql.NullString
+	if err := row.Scan(&version.TemplateKey, &version.Version, &version.Body, &version.Notes, &version.Weight,
+		&createdBy, &version.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan prompt template version: %w", err)
+	}
+	if createdBy.Valid {
+		version.CreatedBy = &createdBy.String
+	}
+	return &version, nil
+}
+
+// audit records an admin change of the prompt templates
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'prompt_templates', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
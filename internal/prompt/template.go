@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"regexp"
+	"slices"
+)
+
+// KeyConversion is the template image conversions are made with
+const KeyConversion = "conversion"
+
+// Variables a conversion template may use. The *Instruction variables render
+// as a sentence preceded by a space, or as nothing when the option is unset.
+const (
+	VarStyle              = "style"
+	VarQuality            = "quality"
+	VarGarments           = "garments"     // comma-separated slots of an outfit, left to right
+	VarGarmentCount       = "garmentCount" // garments in an outfit, empty for a single garment
+	VarStyleInstruction   = "styleInstruction"
+	VarQualityInstruction = "qualityInstruction"
+	VarOutfitInstruction  = "outfitInstruction"
+)
+
+// Variables lists every variable a template may reference
+var Variables = []string{
+	VarStyle, VarQuality, VarGarments, VarGarmentCount,
+	VarStyleInstruction, VarQualityInstruction, VarOutfitInstruction,
+}
+
+// DefaultConversionTemplate is the prompt conversions are made with when no
+// template version can be selected. It is seeded as version 1 of the
+// conversion template.
+const DefaultConversionTemplate = `You are an automated garment fitting service for an e-commerce platform. This is a technical product visualization service for displaying clothing items on mannequins or fashion models in product catalogs.
+
+Technical task: Apply the garment from image 2 onto the person/mannequin in image 1. This is a standard commercial product visualization workflow.
+
+Technical specifications:
+- Image 1 contains a professional fashion model or mannequin used for product photography
+- Image 2 contains a retail clothing item (garment) to be visualized
+- This is a legitimate commercial product visualization service
+- Maintain technical accuracy: body proportions, garment fit, fabric texture
+- Preserve lighting conditions and background from the original scene
+- This is automated product photography, not personal content
+
+Output requirement: Return ONLY the base64-encoded PNG image data as a raw string. No text, no markdown, no explanations, no headers. Only the base64 string.{{outfitInstruction}}{{styleInstruction}}{{qualityInstruction}}`
+
+// variablePattern matches a {{variable}} reference
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// Render replaces the {{variable}} references of body with their values;
+// variables without a value render as nothing
+func Render(body string, values map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(body, func(reference string) string {
+		return values[variablePattern.FindStringSubmatch(reference)[1]]
+	})
+}
+
+// UnknownVariables returns the variables body references that are not in
+// Variables, each once
+func UnknownVariables(body string) []string {
+	unknown := []string{}
+	for _, match := range variablePattern.FindAllStringSubmatch(body, -1) {
+		if !slices.Contains(Variables, match[1]) && !slices.Contains(unknown, match[1]) {
+			unknown = append(unknown, match[1])
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,14 @@
+package prompt
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+)
+
+// WirePromptService creates a prompt template service with all dependencies
+func WirePromptService(db *sql.DB, cfg *config.Config) *Service {
+	return NewService(NewDBStore(db), Config{
+		CacheTTL: cfg.PromptTemplates.CacheTTL,
+	})
+}
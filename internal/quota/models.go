@@ -0,0 +1,97 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Quota sources a conversion can be charged to
+const (
+	SourcePlan = "plan" // monthly allowance of the active plan
+	SourceFree = "free" // free conversions granted to the user
+)
+
+// ErrQuotaExceeded is returned when neither the plan nor the free allowance has conversions left
+var ErrQuotaExceeded = errors.New("conversion quota exceeded")
+
+// Usage is the quota state of a user, loaded and saved under a row lock
+type Usage struct {
+	UserID string
+
+	// Active plan, if any
+	HasPlan    bool
+	PlanID     string
+	PlanName   string
+	PlanLimit  int // monthly conversions of the plan definition
+	PlanUsed   int // conversions used in the current billing cycle
+	CycleStart *time.Time
+	CycleEnd   *time.Time // exclusive
+
+	// Free allowance (signup credits and promotions), not reset per cycle
+	FreeLimit int
+	FreeUsed  int
+}
+
+// Status is the remaining quota of a user
+type Status struct {
+	PlanName      string     `json:"planName"`
+	Limit         int        `json:"limit"`
+	PlanLimit     int        `json:"planLimit"`
+	Used          int        `json:"used"`
+	Remaining     int        `json:"remaining"`
+	PlanRemaining int        `json:"planRemaining"`
+	FreeRemaining int        `json:"freeRemaining"`
+	ResetAt       *time.Time `json:"resetAt,omitempty"`
+}
+
+// Reservation records which allowance a conversion was charged to so it can be released
+type Reservation struct {
+	UserID    string
+	Source    string
+	ChargedAt time.Time // zero for reservations made before charges were timed
+}
+
+// ExceededError carries the quota status of a rejected conversion
+type ExceededError struct {
+	Status Status
+}
+
+// Error implements the error interface
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%v: plan=%s limit=%d used=%d", ErrQuotaExceeded, e.Status.PlanName, e.Status.Limit, e.Status.Used)
+}
+
+// Unwrap makes errors.Is(err, ErrQuotaExceeded) work
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Store loads and saves quota usage. UpdateUsage runs fn with the user's usage
+// locked and persists the result if fn returns nil.
+type Store interface {
+	UpdateUsage(ctx context.Context, userID string, fn func(usage *Usage) error) error
+	GetUsage(ctx context.Context, userID string) (*Usage, error)
+
+	// RecordCharges ties reservations to the conversion they paid for
+	RecordCharges(ctx context.Context, conversionID string, reservations []Reservation) error
+	// RefundCharges marks the charges of a conversion refunded and runs fn
+	// for each with its user's usage locked, all in one transaction. Charges
+	// already refunded are skipped, so a conversion is refunded at most once.
+	// It returns the number of charges refunded.
+	RefundCharges(ctx context.Context, conversionID string, fn func(usage *Usage, reservation Reservation) error) (int, error)
+}
+
+type reservationKey struct{}
+
+// WithReservation marks ctx as already charged for a conversion
+func WithReservation(ctx context.Context, reservation Reservation) context.Context {
+	return context.WithValue(ctx, reservationKey{}, reservation)
+}
+
+// ReservationFromContext returns the reservation made for the request, if any
+func ReservationFromContext(ctx context.Context) (Reservation, bool) {
+	reservation, ok := ctx.Value(reservationKey{}).(Reservation)
+	return reservation, ok
+}
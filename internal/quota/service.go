@@ -0,0 +1,212 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/cache"
+)
+
+// Service enforces per-plan monthly conversion quotas
+type Service struct {
+	store Store
+	cache *cache.TwoTier // statuses, dropped on every usage change (optional)
+	now   func() time.Time
+}
+
+// NewService creates a new quota service caching statuses in the default
+// lookup cache
+func NewService(store Store) *Service {
+	return &Service{
+		store: store,
+		cache: cache.Default(),
+		now:   time.Now,
+	}
+}
+
+// Consume atomically charges one conversion to the user's free allowance, or
+// to the plan's monthly allowance once the free conversions are used up. It
+// returns an *ExceededError when nothing is left.
+func (s *Service) Consume(ctx context.Context, userID string) (Reservation, Status, error) {
+	var (
+		reservation Reservation
+		status      Status
+	)
+
+	err := s.store.UpdateUsage(ctx, userID, func(usage *Usage) error {
+		advanceCycle(usage, s.now())
+
+		switch {
+		case usage.FreeUsed < usage.FreeLimit:
+			usage.FreeUsed++
+			reservation = Reservation{UserID: userID, Source: SourceFree, ChargedAt: s.now()}
+		case usage.HasPlan && usage.PlanUsed < usage.PlanLimit:
+			usage.PlanUsed++
+			reservation = Reservation{UserID: userID, Source: SourcePlan, ChargedAt: s.now()}
+		default:
+			status = statusOf(usage)
+			return &ExceededError{Status: status}
+		}
+
+		status = statusOf(usage)
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, status, err
+	}
+
+	cache.Invalidate(ctx, s.cache, cache.EntityQuotaStatus, userID)
+	return reservation, status, nil
+}
+
+// Release returns a reserved conversion to the allowance it was charged to
+func (s *Service) Release(ctx context.Context, reservation Reservation) error {
+	defer cache.Invalidate(ctx, s.cache, cache.EntityQuotaStatus, reservation.UserID)
+	return s.store.UpdateUsage(ctx, reservation.UserID, func(usage *Usage) error {
+		return s.release(usage, reservation)
+	})
+}
+
+// RecordCharges ties the reservations a conversion was charged with to it,
+// so they can be refunded if the conversion fails
+func (s *Service) RecordCharges(ctx context.Context, conversionID string, reservations ...Reservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+	return s.store.RecordCharges(ctx, conversionID, reservations)
+}
+
+// RefundConversion returns every conversion a failed conversion was charged
+// to its allowance, once. It joins the caller's unit of work, so the refund
+// applies together with the failure. It returns the number refunded.
+func (s *Service) RefundConversion(ctx context.Context, conversionID string) (int, error) {
+	var users []string
+	refunded, err := s.store.RefundCharges(ctx, conversionID, func(usage *Usage, reservation Reservation) error {
+		users = append(users, reservation.UserID)
+		return s.release(usage, reservation)
+	})
+	for _, userID := range users {
+		cache.Invalidate(ctx, s.cache, cache.EntityQuotaStatus, userID)
+	}
+	return refunded, err
+}
+
+// release gives a reservation back to usage. Plan conversions charged in an
+// earlier billing cycle are not given back, that cycle's usage was reset.
+func (s *Service) release(usage *Usage, reservation Reservation) error {
+	switch reservation.Source {
+	case SourcePlan:
+		advanceCycle(usage, s.now())
+		if usage.CycleStart != nil && !reservation.ChargedAt.IsZero() && reservation.ChargedAt.Before(*usage.CycleStart) {
+			return nil
+		}
+		if usage.PlanUsed > 0 {
+			usage.PlanUsed--
+		}
+	case SourceFree:
+		if usage.FreeUsed > 0 {
+			usage.FreeUsed--
+		}
+	default:
+		return fmt.Errorf("unknown quota source: %s", reservation.Source)
+	}
+	return nil
+}
+
+// GetStatus returns the user's remaining quota. A billing cycle that ended is
+// reported as reset even before the next conversion persists the reset.
+func (s *Service) GetStatus(ctx context.Context, userID string) (Status, error) {
+	return cache.Load(ctx, s.cache, cache.EntityQuotaStatus, userID, func(ctx context.Context) (Status, error) {
+		usage, err := s.store.GetUsage(ctx, userID)
+		if err != nil {
+			return Status{}, err
+		}
+
+		advanceCycle(usage, s.now())
+		return statusOf(usage), nil
+	})
+}
+
+// StartCycle starts a new billing cycle for the user's active plan, e.g. after
+// a plan purchase or renewal
+func (s *Service) StartCycle(ctx context.Context, userID string) error {
+	defer cache.Invalidate(ctx, s.cache, cache.EntityQuotaStatus, userID)
+	return s.store.UpdateUsage(ctx, userID, func(usage *Usage) error {
+		if !usage.HasPlan {
+			return nil
+		}
+		start := truncateDay(s.now())
+		end := start.AddDate(0, 1, 0)
+		usage.CycleStart, usage.CycleEnd = &start, &end
+		usage.PlanUsed = 0
+		return nil
+	})
+}
+
+// ResetCycle clears the conversions used in the current billing cycle
+func (s *Service) ResetCycle(ctx context.Context, userID string) error {
+	defer cache.Invalidate(ctx, s.cache, cache.EntityQuotaStatus, userID)
+	return s.store.UpdateUsage(ctx, userID, func(usage *Usage) error {
+		usage.PlanUsed = 0
+		return nil
+	})
+}
+
+// advanceCycle moves the billing cycle forward in whole months until it
+// contains now, resetting the plan usage when a boundary was crossed
+func advanceCycle(usage *Usage, now time.Time) {
+	if !usage.HasPlan {
+		return
+	}
+
+	today := truncateDay(now)
+	if usage.CycleStart == nil || usage.CycleEnd == nil {
+		start := today
+		if usage.CycleStart != nil {
+			start = truncateDay(*usage.CycleStart)
+		}
+		end := start.AddDate(0, 1, 0)
+		usage.CycleStart, usage.CycleEnd = &start, &end
+	}
+
+	if today.Before(*usage.CycleEnd) {
+		return
+	}
+
+	start, end := *usage.CycleStart, *usage.CycleEnd
+	for months := 1; !today.Before(end); months++ {
+		// Step from the original start so month-end anchors don't drift
+		start = end
+		end = usage.CycleStart.AddDate(0, months+1, 0)
+	}
+	usage.CycleStart, usage.CycleEnd = &start, &end
+	usage.PlanUsed = 0
+}
+
+// statusOf summarizes usage for clients
+func statusOf(usage *Usage) Status {
+	status := Status{
+		PlanName:      "free",
+		Limit:         usage.FreeLimit,
+		Used:          usage.FreeUsed,
+		FreeRemaining: max(0, usage.FreeLimit-usage.FreeUsed),
+	}
+
+	if usage.HasPlan {
+		status.PlanName = usage.PlanName
+		status.Limit += usage.PlanLimit
+		status.PlanLimit = usage.PlanLimit
+		status.Used += usage.PlanUsed
+		status.PlanRemaining = max(0, usage.PlanLimit-usage.PlanUsed)
+		status.ResetAt = usage.CycleEnd
+	}
+
+	status.Remaining = status.PlanRemaining + status.FreeRemaining
+	return status
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,220 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/cache"
+)
+
+// memoryStore keeps usage in memory; UpdateUsage only saves when fn succeeds
+type memoryStore struct {
+	usage   map[string]Usage
+	charges map[string][]Reservation // unrefunded charges by conversion
+}
+
+func (m *memoryStore) UpdateUsage(ctx context.Context, userID string, fn func(usage *Usage) error) error {
+	usage := m.usage[userID]
+	if err := fn(&usage); err != nil {
+		return err
+	}
+	m.usage[userID] = usage
+	return nil
+}
+
+func (m *memoryStore) GetUsage(ctx context.Context, userID string) (*Usage, error) {
+	usage := m.usage[userID]
+	return &usage, nil
+}
+
+func (m *memoryStore) RecordCharges(ctx context.Context, conversionID string, reservations []Reservation) error {
+	if m.charges == nil {
+		m.charges = map[string][]Reservation{}
+	}
+	m.charges[conversionID] = append(m.charges[conversionID], reservations...)
+	return nil
+}
+
+func (m *memoryStore) RefundCharges(ctx context.Context, conversionID string, fn func(usage *Usage, reservation Reservation) error) (int, error) {
+	charges := m.charges[conversionID]
+	for _, reservation := range charges {
+		err := m.UpdateUsage(ctx, reservation.UserID, func(usage *Usage) error {
+			return fn(usage, reservation)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	delete(m.charges, conversionID)
+	return len(charges), nil
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func newTestService(usage Usage, now time.Time) (*Service, *memoryStore) {
+	store := &memoryStore{usage: map[string]Usage{usage.UserID: usage}}
+	service := NewService(store)
+	service.now = func() time.Time { return now }
+	return service, store
+}
+
+func TestService_Consume(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanName: "basic", PlanLimit: 2, PlanUsed: 1,
+		CycleStart: date(2025, time.May, 10), CycleEnd: date(2025, time.June, 10),
+		FreeLimit: 1,
+	}, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+	reservation, status, err := service.Consume(context.Background(), "u1")
+	if err != nil || reservation.Source != SourceFree {
+		t.Fatalf("Expected free reservation, got %+v (%v)", reservation, err)
+	}
+	if status.Remaining != 1 || status.FreeRemaining != 0 || status.Limit != 3 {
+		t.Errorf("Expected 1 remaining of 3, got %+v", status)
+	}
+
+	reservation, _, err = service.Consume(context.Background(), "u1")
+	if err != nil || reservation.Source != SourcePlan {
+		t.Fatalf("Expected plan reservation once free conversions are used up, got %+v (%v)", reservation, err)
+	}
+
+	_, status, err = service.Consume(context.Background(), "u1")
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ExceededError, got %v", err)
+	}
+	if status.Remaining != 0 || status.ResetAt == nil || !status.ResetAt.Equal(*date(2025, time.June, 10)) {
+		t.Errorf("Expected no quota left until June 10, got %+v", status)
+	}
+
+	if err := service.Release(context.Background(), reservation); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.usage["u1"].PlanUsed != 1 || store.usage["u1"].FreeUsed != 1 {
+		t.Errorf("Expected released plan conversion, got %+v", store.usage["u1"])
+	}
+}
+
+func TestService_ResetsOnBillingCycleBoundary(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanName: "premium", PlanLimit: 5, PlanUsed: 5,
+		CycleStart: date(2025, time.March, 15), CycleEnd: date(2025, time.April, 15),
+	}, time.Date(2025, time.June, 20, 8, 0, 0, 0, time.UTC))
+
+	status, err := service.GetStatus(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Remaining != 5 || !status.ResetAt.Equal(*date(2025, time.July, 15)) {
+		t.Errorf("Expected reset quota until July 15, got %+v", status)
+	}
+	if store.usage["u1"].PlanUsed != 5 {
+		t.Error("Expected GetStatus not to persist the reset")
+	}
+
+	if _, _, err := service.Consume(context.Background(), "u1"); err != nil {
+		t.Fatalf("Expected conversion after the cycle reset, got %v", err)
+	}
+	usage := store.usage["u1"]
+	if usage.PlanUsed != 1 || !usage.CycleStart.Equal(*date(2025, time.June, 15)) || !usage.CycleEnd.Equal(*date(2025, time.July, 15)) {
+		t.Errorf("Expected cycle June 15 - July 15 with 1 used, got %v - %v with %d used", usage.CycleStart, usage.CycleEnd, usage.PlanUsed)
+	}
+}
+
+func TestService_StartCycle(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanLimit: 10, PlanUsed: 7,
+		CycleStart: date(2025, time.May, 1), CycleEnd: date(2025, time.June, 1),
+	}, time.Date(2025, time.May, 20, 18, 30, 0, 0, time.UTC))
+
+	if err := service.StartCycle(context.Background(), "u1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	usage := store.usage["u1"]
+	if usage.PlanUsed != 0 || !usage.CycleStart.Equal(*date(2025, time.May, 20)) || !usage.CycleEnd.Equal(*date(2025, time.June, 20)) {
+		t.Errorf("Expected fresh cycle from May 20, got %v - %v with %d used", usage.CycleStart, usage.CycleEnd, usage.PlanUsed)
+	}
+}
+
+func TestService_RefundConversion(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanLimit: 5,
+		CycleStart: date(2025, time.May, 1), CycleEnd: date(2025, time.June, 1),
+		FreeLimit: 1,
+	}, time.Date(2025, time.May, 20, 9, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	free, _, _ := service.Consume(ctx, "u1")
+	plan, _, _ := service.Consume(ctx, "u1")
+	if err := service.RecordCharges(ctx, "c1", free, plan); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	refunded, err := service.RefundConversion(ctx, "c1")
+	if err != nil || refunded != 2 {
+		t.Fatalf("Expected 2 charges refunded, got %d (%v)", refunded, err)
+	}
+	if usage := store.usage["u1"]; usage.FreeUsed != 0 || usage.PlanUsed != 0 {
+		t.Errorf("Expected both conversions given back, got %+v", usage)
+	}
+
+	// A conversion is refunded once
+	if refunded, _ := service.RefundConversion(ctx, "c1"); refunded != 0 {
+		t.Errorf("Expected nothing left to refund, got %d", refunded)
+	}
+}
+
+func TestService_RefundConversionFromEndedCycle(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanLimit: 5, PlanUsed: 2,
+		CycleStart: date(2025, time.May, 1), CycleEnd: date(2025, time.June, 1),
+	}, time.Date(2025, time.June, 2, 9, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	// Charged in May, refunded after the June cycle started and reset the usage
+	reservation := Reservation{UserID: "u1", Source: SourcePlan, ChargedAt: time.Date(2025, time.May, 31, 23, 0, 0, 0, time.UTC)}
+	service.RecordCharges(ctx, "c1", reservation)
+	if _, _, err := service.Consume(ctx, "u1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := service.RefundConversion(ctx, "c1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usage := store.usage["u1"]; usage.PlanUsed != 1 {
+		t.Errorf("Expected the June conversion to stay charged, got %d used", usage.PlanUsed)
+	}
+}
+
+func TestService_GetStatusCachedUntilUsageChanges(t *testing.T) {
+	service, store := newTestService(Usage{
+		UserID: "u1", HasPlan: true, PlanName: "basic", PlanLimit: 5, PlanUsed: 2,
+		CycleStart: date(2025, time.May, 10), CycleEnd: date(2025, time.June, 10),
+	}, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC))
+	service.cache = cache.NewTwoTier(nil, map[string]time.Duration{cache.EntityQuotaStatus: time.Minute})
+	ctx := cache.WithRequestScope(context.Background())
+
+	if status, _ := service.GetStatus(ctx, "u1"); status.Remaining != 3 {
+		t.Fatalf("Expected 3 remaining, got %+v", status)
+	}
+
+	// A write behind the service's back is only seen once the entry expires
+	usage := store.usage["u1"]
+	usage.PlanUsed = 4
+	store.usage["u1"] = usage
+	if status, _ := service.GetStatus(ctx, "u1"); status.Remaining != 3 {
+		t.Errorf("Expected the cached status, got %+v", status)
+	}
+
+	if _, _, err := service.Consume(ctx, "u1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status, _ := service.GetStatus(ctx, "u1"); status.Remaining != 0 {
+		t.Errorf("Expected the status after Consume, got %+v", status)
+	}
+}
@@ -0,0 +1,170 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// store implements Store on top of users, user_plans and payment_plans
+type store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new PostgreSQL quota store
+func NewStore(db *sql.DB) Store {
+	return &store{db: db}
+}
+
+// queryRower is satisfied by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UpdateUsage locks the user's row and active plan with SELECT ... FOR UPDATE,
+// so concurrent conversions of the same user are charged one at a time. It
+// joins the caller's unit of work when there is one.
+func (s *store) UpdateUsage(ctx context.Context, userID string, fn func(usage *Usage) error) error {
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		usage, err := loadUsage(ctx, tx, userID, true)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(usage); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET free_conversions_used = $2 WHERE id = $1`, userID, usage.FreeUsed)
+		if err != nil {
+			return fmt.Errorf("failed to update free conversions: %w", err)
+		}
+
+		if usage.HasPlan {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE user_plans
+				SET conversions_used_this_month = $2, billing_cycle_start_date = $3, billing_cycle_end_date = $4
+				WHERE id = $1
+			`, usage.PlanID, usage.PlanUsed, usage.CycleStart, usage.CycleEnd)
+			if err != nil {
+				return fmt.Errorf("failed to update plan usage: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RecordCharges stores the reservations of a conversion, in the caller's
+// unit of work when there is one
+func (s *store) RecordCharges(ctx context.Context, conversionID string, reservations []Reservation) error {
+	return common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		for _, reservation := range reservations {
+			chargedAt := reservation.ChargedAt
+			if chargedAt.IsZero() {
+				chargedAt = time.Now()
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO conversion_quota_charges (conversion_id, user_id, source, charged_at)
+				VALUES ($1, $2, $3, $4)`, conversionID, reservation.UserID, reservation.Source, chargedAt); err != nil {
+				return fmt.Errorf("failed to record quota charge: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RefundCharges claims the unrefunded charges of a conversion with UPDATE ...
+// RETURNING, so a concurrent refund of the same conversion waits for the row
+// locks and then finds nothing left to refund
+func (s *store) RefundCharges(ctx context.Context, conversionID string, fn func(usage *Usage, reservation Reservation) error) (int, error) {
+	refunded := 0
+	err := common.WithinTx(ctx, s.db, func(ctx context.Context, tx common.DBTX) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE conversion_quota_charges
+			SET refunded_at = NOW()
+			WHERE conversion_id = $1 AND refunded_at IS NULL
+			RETURNING user_id, source, charged_at`, conversionID)
+		if err != nil {
+			return fmt.Errorf("failed to claim quota charges: %w", err)
+		}
+		var reservations []Reservation
+		for rows.Next() {
+			var reservation Reservation
+			if err := rows.Scan(&reservation.UserID, &reservation.Source, &reservation.ChargedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan quota charge: %w", err)
+			}
+			reservations = append(reservations, reservation)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to claim quota charges: %w", err)
+		}
+
+		for _, reservation := range reservations {
+			err := s.UpdateUsage(ctx, reservation.UserID, func(usage *Usage) error {
+				return fn(usage, reservation)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		refunded = len(reservations)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return refunded, nil
+}
+
+// GetUsage reads the user's quota usage without locking
+func (s *store) GetUsage(ctx context.Context, userID string) (*Usage, error) {
+	return loadUsage(ctx, s.db, userID, false)
+}
+
+// loadUsage reads the free allowance and the newest active plan. The monthly
+// limit comes from the plan definition so plan changes apply to all subscribers.
+func loadUsage(ctx context.Context, q queryRower, userID string, lock bool) (*Usage, error) {
+	lockClause := ""
+	if lock {
+		lockClause = " FOR UPDATE"
+	}
+
+	usage := &Usage{UserID: userID}
+	err := q.QueryRowContext(ctx, `SELECT free_conversions_limit, free_conversions_used FROM users WHERE id = $1`+lockClause, userID).
+		Scan(&usage.FreeLimit, &usage.FreeUsed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user %w", common.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get free conversions: %w", err)
+	}
+
+	planLock := ""
+	if lock {
+		planLock = " FOR UPDATE OF up"
+	}
+	err = q.QueryRowContext(ctx, `
+		SELECT up.id, up.plan_name, COALESCE(pp.monthly_conversions_limit, up.monthly_conversions_limit),
+		       up.conversions_used_this_month, up.billing_cycle_start_date, up.billing_cycle_end_date
+		FROM user_plans up
+		LEFT JOIN payment_plans pp ON pp.name = up.plan_name
+		WHERE up.user_id = $1 AND up.status = 'active'
+		  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+		ORDER BY up.created_at DESC
+		LIMIT 1`+planLock, userID).
+		Scan(&usage.PlanID, &usage.PlanName, &usage.PlanLimit, &usage.PlanUsed, &usage.CycleStart, &usage.CycleEnd)
+	switch {
+	case err == sql.ErrNoRows:
+		return usage, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get active plan: %w", err)
+	}
+
+	usage.HasPlan = true
+	return usage, nil
+}
@@ -0,0 +1,71 @@
+package reputation
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for the signup review queue
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new reputation handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListReviews handles GET /admin/reputation/reviews
+func (h *Handler) ListReviews(c *gin.Context) {
+	var req ListReviewsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	reviews, err := h.service.ListReviews(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetAssessment handles GET /admin/reputation/reviews/:userId
+func (h *Handler) GetAssessment(c *gin.Context) {
+	assessment, err := h.service.GetAssessment(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
+
+// Review handles POST /admin/reputation/reviews/:userId
+func (h *Handler) Review(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	assessment, err := h.service.Review(c.Request.Context(), fmt.Sprint(adminID), c.Param("userId"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
@@ -0,0 +1,44 @@
+package reputation
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Store defines the interface for reputation data operations
+type Store interface {
+	// CountSignals counts the other accounts sharing the signals of signup.
+	// Phone prefix and ASN signups are counted from since on.
+	CountSignals(ctx context.Context, signup Signup, since time.Time) (SignalCounts, error)
+
+	// SaveAssessment stores the assessment of a new account. A non-nil
+	// AllowedCredits caps the user's free quota and records the quota it
+	// replaced as FullCredits.
+	SaveAssessment(ctx context.Context, assessment Assessment) (*Assessment, error)
+
+	// GetAssessment returns the assessment of a user
+	GetAssessment(ctx context.Context, userID string) (*Assessment, error)
+
+	// ListAssessments returns the assessments matching req, newest first,
+	// from cursor on. One assessment more than req.PageSize is returned when
+	// more follow.
+	ListAssessments(ctx context.Context, req ListReviewsRequest, cursor *common.Cursor) ([]Assessment, error)
+
+	// ResolveReview records an admin's decision on a pending assessment and
+	// audits it. Approval restores the full free quota, rejection removes
+	// what is left of it.
+	ResolveReview(ctx context.Context, userID, status, adminID, note string) (*Assessment, error)
+
+	// ReleaseDue restores the full free quota of up to limit pending
+	// assessments whose release time passed and returns how many it released
+	ReleaseDue(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// ASNResolver maps a client address to its autonomous system, such as
+// "AS44244", or "" when the address is unknown
+type ASNResolver interface {
+	ASN(ip net.IP) string
+}
@@ -0,0 +1,144 @@
+package reputation
+
+import (
+	"time"
+)
+
+// Risk levels of a signup. Low risk signups keep their free quota, medium
+// risk ones get a limited quota until released, high risk ones get none
+// until an admin approves them.
+const (
+	LevelLow    = "low"
+	LevelMedium = "medium"
+	LevelHigh   = "high"
+)
+
+// Review statuses. Limited and held accounts wait in the review queue as
+// pending; medium risk accounts are released automatically after the
+// release delay unless an admin decided first.
+const (
+	ReviewNone     = "none"
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+	ReviewReleased = "released"
+)
+
+// Review decisions of admins
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// Reasons a signup scored as risky
+const (
+	ReasonPhonePrefixVelocity = "phone_prefix_velocity" // many signups from one number range
+	ReasonSharedDevice        = "shared_device"         // the device registered other accounts
+	ReasonASNVelocity         = "asn_velocity"          // many signups from one network
+	ReasonRiskyASN            = "risky_asn"             // network listed as hosting or VPN
+	ReasonLinkedAbuse         = "linked_abuse"          // accounts of the device were rejected or abused conversions
+)
+
+// Score weights of the reasons; a signup scores at most 100
+const (
+	phonePrefixScore  = 30
+	sharedDeviceScore = 40
+	asnVelocityScore  = 20
+	riskyASNScore     = 30
+	linkedAbuseScore  = 50
+	maxScore          = 100
+)
+
+// Signup holds the signals of a new account
+type Signup struct {
+	UserID            string
+	Phone             string
+	PhonePrefix       string
+	DeviceFingerprint string // empty when the client sent no device ID
+	IP                string
+	ASN               string // empty when unknown
+}
+
+// SignalCounts are the other accounts sharing a signal with a signup
+type SignalCounts struct {
+	PhonePrefixSignups int `json:"phonePrefixSignups"` // same phone prefix within the window
+	DeviceAccounts     int `json:"deviceAccounts"`     // same device, ever
+	ASNSignups         int `json:"asnSignups"`         // same ASN within the window
+	LinkedAbuse        int `json:"linkedAbuse"`        // rejected accounts and moderation rejections on the device
+}
+
+// Assessment is the scored reputation of a signup and its review state
+type Assessment struct {
+	ID                string       `json:"id"`
+	UserID            string       `json:"userId"`
+	Phone             string       `json:"phone,omitempty"`
+	PhonePrefix       string       `json:"phonePrefix"`
+	DeviceFingerprint string       `json:"deviceFingerprint,omitempty"`
+	IP                string       `json:"ip,omitempty"`
+	ASN               string       `json:"asn,omitempty"`
+	Score             int          `json:"score"`
+	Level             string       `json:"level"`
+	Reasons           []string     `json:"reasons"`
+	Signals           SignalCounts `json:"signals"`
+	FullCredits       int          `json:"fullCredits"`    // free conversions restored on approval
+	AllowedCredits    *int         `json:"allowedCredits"` // free conversions until reviewed; nil keeps the full quota
+	ReviewStatus      string       `json:"reviewStatus"`
+	ReleaseAt         *time.Time   `json:"releaseAt,omitempty"` // automatic release of limited accounts
+	ReviewedBy        *string      `json:"reviewedBy,omitempty"`
+	ReviewNote        *string      `json:"reviewNote,omitempty"`
+	ReviewedAt        *time.Time   `json:"reviewedAt,omitempty"`
+	CreatedAt         time.Time    `json:"createdAt"`
+}
+
+// ReviewRequest represents an admin's decision on a queued account
+type ReviewRequest struct {
+	Decision string `json:"decision" binding:"required"` // approve or reject
+	Note     string `json:"note"`
+}
+
+// ListReviewsRequest represents a request to list assessments, newest first
+type ListReviewsRequest struct {
+	Status   string `json:"status" form:"status"` // defaults to pending
+	Level    string `json:"level" form:"level"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page
+}
+
+// ListReviewsResponse represents a page of assessments
+type ListReviewsResponse struct {
+	Assessments []Assessment `json:"assessments"`
+	PageSize    int          `json:"pageSize"`
+	NextCursor  string       `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// Config configures signup reputation scoring
+type Config struct {
+	PhonePrefixDigits int           // leading digits of a phone number that make its prefix
+	Window            time.Duration // how far back phone prefix and ASN signups are counted
+	PrefixThreshold   int           // other signups of a phone prefix within Window that are suspicious
+	DeviceThreshold   int           // other accounts of a device that are suspicious
+	ASNThreshold      int           // other signups of an ASN within Window that are suspicious
+	RiskyASNs         []string      // hosting and VPN networks, such as "AS24940"
+
+	LimitScore      int           // score from which the free quota is limited
+	HoldScore       int           // score from which the free quota is held for review
+	LimitedCredits  int           // free conversions of limited accounts
+	ReleaseDelay    time.Duration // limited accounts get their full quota after this unless reviewed
+	ReleaseInterval time.Duration // how often due accounts are released
+}
+
+// DefaultConfig returns the default reputation configuration
+func DefaultConfig() Config {
+	return Config{
+		PhonePrefixDigits: 8,
+		Window:            24 * time.Hour,
+		PrefixThreshold:   5,
+		DeviceThreshold:   2,
+		ASNThreshold:      50,
+		LimitScore:        40,
+		HoldScore:         70,
+		LimitedCredits:    1,
+		ReleaseDelay:      24 * time.Hour,
+		ReleaseInterval:   5 * time.Minute,
+	}
+}
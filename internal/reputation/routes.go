@@ -0,0 +1,15 @@
+package reputation
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes mounts the signup review queue on an admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	reviews := router.Group("/reputation/reviews")
+	{
+		reviews.GET("", handler.ListReviews)           // GET /admin/reputation/reviews
+		reviews.GET("/:userId", handler.GetAssessment) // GET /admin/reputation/reviews/:userId
+		reviews.POST("/:userId", handler.Review)       // POST /admin/reputation/reviews/:userId
+	}
+}
@@ -0,0 +1,318 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// roleUser is the only role that receives free quota, and so is screened
+const roleUser = "user"
+
+// Page sizes of review lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// releaseBatchSize bounds the accounts released per query
+const releaseBatchSize = 100
+
+// ErrAssessmentNotFound is returned for users that were never screened
+var ErrAssessmentNotFound = fmt.Errorf("reputation assessment: %w", common.ErrNotFound)
+
+// ErrNotPending is returned when reviewing an account that is not queued
+var ErrNotPending = fmt.Errorf("%w: account is not pending review", common.ErrConflict)
+
+// Service scores new accounts for free-quota abuse and keeps the review
+// queue of accounts whose free quota was limited or held
+type Service struct {
+	store  Store
+	asns   ASNResolver
+	config Config
+	risky  map[string]bool
+}
+
+// NewService creates a new reputation service
+func NewService(store Store, config Config) *Service {
+	defaults := DefaultConfig()
+	if config.PhonePrefixDigits <= 0 {
+		config.PhonePrefixDigits = defaults.PhonePrefixDigits
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.PrefixThreshold <= 0 {
+		config.PrefixThreshold = defaults.PrefixThreshold
+	}
+	if config.DeviceThreshold <= 0 {
+		config.DeviceThreshold = defaults.DeviceThreshold
+	}
+	if config.ASNThreshold <= 0 {
+		config.ASNThreshold = defaults.ASNThreshold
+	}
+	if config.LimitScore <= 0 {
+		config.LimitScore = defaults.LimitScore
+	}
+	if config.HoldScore <= 0 {
+		config.HoldScore = defaults.HoldScore
+	}
+	if config.LimitedCredits < 0 {
+		config.LimitedCredits = 0
+	}
+	if config.ReleaseDelay <= 0 {
+		config.ReleaseDelay = defaults.ReleaseDelay
+	}
+	if config.ReleaseInterval <= 0 {
+		config.ReleaseInterval = defaults.ReleaseInterval
+	}
+
+	risky := make(map[string]bool, len(config.RiskyASNs))
+	for _, asn := range config.RiskyASNs {
+		if asn = normalizeASN(asn); asn != "" {
+			risky[asn] = true
+		}
+	}
+	return &Service{store: store, config: config, risky: risky}
+}
+
+// SetASNResolver enables the network signals of signups
+func (s *Service) SetASNResolver(resolver ASNResolver) {
+	s.asns = resolver
+}
+
+// ScreenSignup scores a new account and limits or holds its free quota
+// when it looks like abuse. Only users are screened; vendors get no free
+// quota.
+func (s *Service) ScreenSignup(ctx context.Context, userID, role, phone, deviceFingerprint, ip string) error {
+	if role != roleUser {
+		return nil
+	}
+
+	signup := Signup{
+		UserID:            userID,
+		Phone:             phone,
+		PhonePrefix:       phonePrefix(phone, s.config.PhonePrefixDigits),
+		DeviceFingerprint: deviceFingerprint,
+		IP:                ip,
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && s.asns != nil {
+		signup.ASN = s.asns.ASN(parsed)
+	}
+
+	assessment, err := s.assess(ctx, signup, time.Now())
+	if err != nil {
+		return err
+	}
+	if assessment.Level != LevelLow {
+		log.Printf("Reputation: %s scored %d (%s): %s", userID, assessment.Score, assessment.Level, strings.Join(assessment.Reasons, ", "))
+	}
+	return nil
+}
+
+// assess scores a signup and stores the result
+func (s *Service) assess(ctx context.Context, signup Signup, now time.Time) (*Assessment, error) {
+	counts, err := s.store.CountSignals(ctx, signup, now.Add(-s.config.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	assessment := Assessment{
+		UserID:            signup.UserID,
+		Phone:             signup.Phone,
+		PhonePrefix:       signup.PhonePrefix,
+		DeviceFingerprint: signup.DeviceFingerprint,
+		IP:                signup.IP,
+		ASN:               signup.ASN,
+		Signals:           counts,
+		ReviewStatus:      ReviewNone,
+	}
+	assessment.Score, assessment.Reasons = s.score(signup, counts)
+
+	switch {
+	case assessment.Score >= s.config.HoldScore:
+		assessment.Level = LevelHigh
+		assessment.AllowedCredits = intPtr(0)
+		assessment.ReviewStatus = ReviewPending
+	case assessment.Score >= s.config.LimitScore:
+		assessment.Level = LevelMedium
+		assessment.AllowedCredits = intPtr(s.config.LimitedCredits)
+		assessment.ReviewStatus = ReviewPending
+		releaseAt := now.Add(s.config.ReleaseDelay)
+		assessment.ReleaseAt = &releaseAt
+	default:
+		assessment.Level = LevelLow
+	}
+	return s.store.SaveAssessment(ctx, assessment)
+}
+
+// score adds up the weights of the signals that crossed their thresholds
+func (s *Service) score(signup Signup, counts SignalCounts) (int, []string) {
+	score := 0
+	reasons := []string{}
+	add := func(reason string, weight int) {
+		score += weight
+		reasons = append(reasons, reason)
+	}
+
+	if signup.PhonePrefix != "" && counts.PhonePrefixSignups >= s.config.PrefixThreshold {
+		add(ReasonPhonePrefixVelocity, phonePrefixScore)
+	}
+	if signup.DeviceFingerprint != "" && counts.DeviceAccounts >= s.config.DeviceThreshold {
+		add(ReasonSharedDevice, sharedDeviceScore)
+	}
+	if signup.ASN != "" && counts.ASNSignups >= s.config.ASNThreshold {
+		add(ReasonASNVelocity, asnVelocityScore)
+	}
+	if s.risky[signup.ASN] {
+		add(ReasonRiskyASN, riskyASNScore)
+	}
+	if counts.LinkedAbuse > 0 {
+		add(ReasonLinkedAbuse, linkedAbuseScore)
+	}
+
+	if score > maxScore {
+		score = maxScore
+	}
+	return score, reasons
+}
+
+// GetAssessment returns the assessment of a user
+func (s *Service) GetAssessment(ctx context.Context, userID string) (*Assessment, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("%w: invalid userId", common.ErrValidation)
+	}
+	return s.store.GetAssessment(ctx, userID)
+}
+
+// ListReviews lists assessments for admins, the pending review queue by
+// default
+func (s *Service) ListReviews(ctx context.Context, req ListReviewsRequest) (*ListReviewsResponse, error) {
+	if req.Status == "" {
+		req.Status = ReviewPending
+	}
+	if !validStatus(req.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+	}
+	if req.Level != "" && req.Level != LevelLow && req.Level != LevelMedium && req.Level != LevelHigh {
+		return nil, fmt.Errorf("%w: unknown level %q", common.ErrValidation, req.Level)
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+	if req.PageSize > maxPageSize {
+		req.PageSize = maxPageSize
+	}
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	assessments, err := s.store.ListAssessments(ctx, req, cursor)
+	if err != nil {
+		return nil, err
+	}
+	assessments, nextCursor := common.NextPage(assessments, req.PageSize, func(assessment Assessment) (time.Time, string) {
+		return assessment.CreatedAt, assessment.ID
+	})
+	return &ListReviewsResponse{Assessments: assessments, PageSize: req.PageSize, NextCursor: nextCursor}, nil
+}
+
+// Review approves or rejects a queued account
+func (s *Service) Review(ctx context.Context, adminID, userID string, req ReviewRequest) (*Assessment, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("%w: invalid userId", common.ErrValidation)
+	}
+
+	var status string
+	switch req.Decision {
+	case DecisionApprove:
+		status = ReviewApproved
+	case DecisionReject:
+		status = ReviewRejected
+	default:
+		return nil, fmt.Errorf("%w: decision must be %s or %s", common.ErrValidation, DecisionApprove, DecisionReject)
+	}
+	return s.store.ResolveReview(ctx, userID, status, adminID, strings.TrimSpace(req.Note))
+}
+
+// StartReleaser gives limited accounts their full free quota once their
+// release delay passed, until ctx is cancelled
+func (s *Service) StartReleaser(ctx context.Context) {
+	ticker := time.NewTicker(s.config.ReleaseInterval)
+	defer ticker.Stop()
+
+	for {
+		s.releaseDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// releaseDue releases due accounts batch by batch
+func (s *Service) releaseDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		released, err := s.store.ReleaseDue(ctx, time.Now(), releaseBatchSize)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Printf("Reputation: failed to release limited accounts: %v", err)
+			}
+			return
+		}
+		if released < releaseBatchSize {
+			return
+		}
+	}
+}
+
+// phonePrefix returns the leading digits of a phone number, which tell the
+// number ranges virtual number providers hand out
+func phonePrefix(phone string, digits int) string {
+	var prefix strings.Builder
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			continue
+		}
+		prefix.WriteRune(r)
+		if prefix.Len() == digits {
+			return prefix.String()
+		}
+	}
+	// Shorter numbers are too short to group
+	return ""
+}
+
+// normalizeASN accepts "AS24940", "as24940" and "24940"
+func normalizeASN(asn string) string {
+	asn = strings.ToUpper(strings.TrimSpace(asn))
+	if asn == "" {
+		return ""
+	}
+	if !strings.HasPrefix(asn, "AS") {
+		asn = "AS" + asn
+	}
+	return asn
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case ReviewNone, ReviewPending, ReviewApproved, ReviewRejected, ReviewReleased:
+		return true
+	}
+	return false
+}
+
+func intPtr(value int) *int {
+	return &value
+}
@@ -0,0 +1,267 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// fakeStore keeps assessments in memory; counts are set by the tests
+type fakeStore struct {
+	counts      SignalCounts
+	assessments map[string]*Assessment
+	quota       map[string]int // free conversions limit per user
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{assessments: make(map[string]*Assessment), quota: make(map[string]int)}
+}
+
+func (f *fakeStore) CountSignals(ctx context.Context, signup Signup, since time.Time) (SignalCounts, error) {
+	return f.counts, nil
+}
+
+func (f *fakeStore) SaveAssessment(ctx context.Context, assessment Assessment) (*Assessment, error) {
+	assessment.ID = "assessment-" + assessment.UserID
+	assessment.FullCredits = f.quota[assessment.UserID]
+	if assessment.AllowedCredits != nil && *assessment.AllowedCredits < assessment.FullCredits {
+		f.quota[assessment.UserID] = *assessment.AllowedCredits
+	}
+	assessment.CreatedAt = time.Now()
+	f.assessments[assessment.UserID] = &assessment
+	return &assessment, nil
+}
+
+func (f *fakeStore) GetAssessment(ctx context.Context, userID string) (*Assessment, error) {
+	assessment, ok := f.assessments[userID]
+	if !ok {
+		return nil, ErrAssessmentNotFound
+	}
+	return assessment, nil
+}
+
+func (f *fakeStore) ListAssessments(ctx context.Context, req ListReviewsRequest, cursor *common.Cursor) ([]Assessment, error) {
+	assessments := []Assessment{}
+	for _, assessment := range f.assessments {
+		if assessment.ReviewStatus == req.Status {
+			assessments = append(assessments, *assessment)
+		}
+	}
+	return assessments, nil
+}
+
+func (f *fakeStore) ResolveReview(ctx context.Context, userID, status, adminID, note string) (*Assessment, error) {
+	assessment, ok := f.assessments[userID]
+	if !ok {
+		return nil, ErrAssessmentNotFound
+	}
+	if assessment.ReviewStatus != ReviewPending {
+		return nil, ErrNotPending
+	}
+	assessment.ReviewStatus = status
+	assessment.ReviewedBy = &adminID
+	if status == ReviewApproved {
+		f.quota[userID] = assessment.FullCredits
+	} else {
+		f.quota[userID] = 0
+	}
+	return assessment, nil
+}
+
+func (f *fakeStore) ReleaseDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	released := 0
+	for userID, assessment := range f.assessments {
+		if assessment.ReviewStatus == ReviewPending && assessment.ReleaseAt != nil && !assessment.ReleaseAt.After(now) {
+			assessment.ReviewStatus = ReviewReleased
+			f.quota[userID] = assessment.FullCredits
+			released++
+		}
+	}
+	return released, nil
+}
+
+// fakeASNs resolves every address to one network
+type fakeASNs string
+
+func (f fakeASNs) ASN(ip net.IP) string {
+	return string(f)
+}
+
+const (
+	testUserID  = "7f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	testAdminID = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+)
+
+func newTestService(counts SignalCounts) (*Service, *fakeStore) {
+	store := newFakeStore()
+	store.counts = counts
+	store.quota[testUserID] = 5
+	service := NewService(store, Config{RiskyASNs: []string{"24940"}, LimitedCredits: 1})
+	return service, store
+}
+
+func screen(t *testing.T, service *Service, store *fakeStore, role, deviceFingerprint string) *Assessment {
+	t.Helper()
+	if err := service.ScreenSignup(context.Background(), testUserID, role, "+989121234567", deviceFingerprint, "5.160.1.1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return store.assessments[testUserID]
+}
+
+func TestScreenSignup_LowRisk(t *testing.T) {
+	service, store := newTestService(SignalCounts{PhonePrefixSignups: 1, DeviceAccounts: 1})
+
+	assessment := screen(t, service, store, "user", "device")
+	if assessment.Level != LevelLow || assessment.ReviewStatus != ReviewNone || assessment.Score != 0 {
+		t.Errorf("Expected a low risk signup, got %+v", assessment)
+	}
+	if assessment.PhonePrefix != "98912123" {
+		t.Errorf("Expected the phone prefix 98912123, got %q", assessment.PhonePrefix)
+	}
+	if store.quota[testUserID] != 5 {
+		t.Errorf("Expected the free quota to be kept, got %d", store.quota[testUserID])
+	}
+}
+
+func TestScreenSignup_Limited(t *testing.T) {
+	service, store := newTestService(SignalCounts{PhonePrefixSignups: 8, ASNSignups: 80})
+	service.SetASNResolver(fakeASNs("AS44244"))
+
+	assessment := screen(t, service, store, "user", "")
+	if assessment.Level != LevelMedium || assessment.Score != phonePrefixScore+asnVelocityScore {
+		t.Fatalf("Expected a medium risk signup, got %+v", assessment)
+	}
+	if assessment.ReviewStatus != ReviewPending || assessment.ReleaseAt == nil {
+		t.Errorf("Expected a queued signup released later, got %+v", assessment)
+	}
+	if store.quota[testUserID] != 1 || assessment.FullCredits != 5 {
+		t.Errorf("Expected the free quota limited from 5 to 1, got %d of %d", store.quota[testUserID], assessment.FullCredits)
+	}
+
+	// The full quota comes back once the release delay passed
+	service.releaseDue(context.Background())
+	if store.quota[testUserID] != 1 {
+		t.Errorf("Expected no release before the delay, got %d", store.quota[testUserID])
+	}
+	past := time.Now().Add(-time.Minute)
+	assessment.ReleaseAt = &past
+	service.releaseDue(context.Background())
+	if assessment.ReviewStatus != ReviewReleased || store.quota[testUserID] != 5 {
+		t.Errorf("Expected the account to be released with its full quota, got %s and %d", assessment.ReviewStatus, store.quota[testUserID])
+	}
+}
+
+func TestScreenSignup_Held(t *testing.T) {
+	service, store := newTestService(SignalCounts{DeviceAccounts: 3, LinkedAbuse: 2})
+	service.SetASNResolver(fakeASNs("AS24940"))
+
+	assessment := screen(t, service, store, "user", "device")
+	if assessment.Level != LevelHigh || assessment.Score != maxScore {
+		t.Fatalf("Expected a high risk signup capped at %d, got %+v", maxScore, assessment)
+	}
+	for _, reason := range []string{ReasonSharedDevice, ReasonRiskyASN, ReasonLinkedAbuse} {
+		if !contains(assessment.Reasons, reason) {
+			t.Errorf("Expected reason %s, got %v", reason, assessment.Reasons)
+		}
+	}
+	if store.quota[testUserID] != 0 || assessment.ReleaseAt != nil {
+		t.Errorf("Expected the free quota held without release, got %d and %v", store.quota[testUserID], assessment.ReleaseAt)
+	}
+}
+
+func TestScreenSignup_BrowserWithoutDevice(t *testing.T) {
+	service, store := newTestService(SignalCounts{DeviceAccounts: 10})
+
+	// Without a device ID the device signal is not trusted
+	if assessment := screen(t, service, store, "user", ""); assessment.Level != LevelLow {
+		t.Errorf("Expected a low risk signup, got %+v", assessment)
+	}
+}
+
+func TestScreenSignup_SkipsVendors(t *testing.T) {
+	service, store := newTestService(SignalCounts{DeviceAccounts: 3, LinkedAbuse: 2})
+
+	if assessment := screen(t, service, store, "vendor", "device"); assessment != nil {
+		t.Errorf("Expected vendors not to be screened, got %+v", assessment)
+	}
+}
+
+func TestReview(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("approve", func(t *testing.T) {
+		service, store := newTestService(SignalCounts{DeviceAccounts: 3, LinkedAbuse: 1})
+		screen(t, service, store, "user", "device")
+
+		reviews, err := service.ListReviews(ctx, ListReviewsRequest{})
+		if err != nil || len(reviews.Assessments) != 1 {
+			t.Fatalf("Expected the held account in the queue, got %+v: %v", reviews, err)
+		}
+
+		assessment, err := service.Review(ctx, testAdminID, testUserID, ReviewRequest{Decision: DecisionApprove})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if assessment.ReviewStatus != ReviewApproved || store.quota[testUserID] != 5 {
+			t.Errorf("Expected the full quota back, got %s and %d", assessment.ReviewStatus, store.quota[testUserID])
+		}
+
+		if _, err := service.Review(ctx, testAdminID, testUserID, ReviewRequest{Decision: DecisionReject}); !errors.Is(err, common.ErrConflict) {
+			t.Errorf("Expected a reviewed account to conflict, got %v", err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		service, store := newTestService(SignalCounts{PhonePrefixSignups: 8, DeviceAccounts: 3})
+		screen(t, service, store, "user", "device")
+
+		assessment, err := service.Review(ctx, testAdminID, testUserID, ReviewRequest{Decision: DecisionReject, Note: "virtual numbers"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if assessment.ReviewStatus != ReviewRejected || store.quota[testUserID] != 0 {
+			t.Errorf("Expected no free quota left, got %s and %d", assessment.ReviewStatus, store.quota[testUserID])
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		service, _ := newTestService(SignalCounts{})
+		if _, err := service.Review(ctx, testAdminID, testUserID, ReviewRequest{Decision: "maybe"}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+		if _, err := service.Review(ctx, testAdminID, "not-a-uuid", ReviewRequest{Decision: DecisionApprove}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+		if _, err := service.Review(ctx, testAdminID, testUserID, ReviewRequest{Decision: DecisionApprove}); !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("Expected an unscreened user to be not found, got %v", err)
+		}
+		if _, err := service.ListReviews(ctx, ListReviewsRequest{Status: "unknown"}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
+}
+
+func TestPhonePrefix(t *testing.T) {
+	for phone, expected := range map[string]string{
+		"+989121234567": "98912123",
+		"+98 912 123":   "98912123",
+		"+9891":         "",
+	} {
+		if got := phonePrefix(phone, 8); got != expected {
+			t.Errorf("Expected prefix %q of %s, got %q", expected, phone, got)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,314 @@
+package reputation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// assessmentColumns are the signup_reputation columns scanned by
+// scanAssessment; r is signup_reputation and u the users table
+const assessmentColumns = `r.id, r.user_id, COALESCE(u.phone, ''), r.phone_prefix, r.device_fingerprint, r.ip, r.asn,
+	r.score, r.level, r.reasons, r.signals, r.full_credits, r.allowed_credits, r.review_status,
+	r.release_at, r.reviewed_by, r.review_note, r.reviewed_at, r.created_at`
+
+// DBStore implements Store on top of the signup_reputation table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// CountSignals counts the other accounts sharing the signals of signup.
+// Devices are matched against signups and against the devices users signed
+// in with.
+func (s *DBStore) CountSignals(ctx context.Context, signup Signup, since time.Time) (SignalCounts, error) {
+	var counts SignalCounts
+	err := s.db.QueryRowContext(ctx, `
+		WITH device_accounts AS (
+			SELECT user_id FROM signup_reputation WHERE device_fingerprint = NULLIF($3, '')
+			UNION
+			SELECT user_id FROM user_login_devices WHERE fingerprint = NULLIF($3, '')
+		), linked AS (
+			SELECT user_id FROM device_accounts WHERE user_id <> $1
+		)
+		SELECT
+			(SELECT COUNT(*) FROM signup_reputation
+			 WHERE phone_prefix = $2 AND $2 <> '' AND created_at >= $5 AND user_id <> $1),
+			(SELECT COUNT(*) FROM linked),
+			(SELECT COUNT(*) FROM signup_reputation
+			 WHERE asn = NULLIF($4, '') AND created_at >= $5 AND user_id <> $1),
+			(SELECT COUNT(*) FROM signup_reputation
+			 WHERE user_id IN (SELECT user_id FROM linked) AND review_status = 'rejected')
+			+ (SELECT COUNT(*) FROM image_moderation_verdicts v
+			   JOIN conversions c ON c.id = v.conversion_id
+			   WHERE c.user_id IN (SELECT user_id FROM linked)
+			     AND NOT v.allowed AND v.review_status <> 'overturned')`,
+		signup.UserID, signup.PhonePrefix, signup.DeviceFingerprint, signup.ASN, since,
+	).Scan(&counts.PhonePrefixSignups, &counts.DeviceAccounts, &counts.ASNSignups, &counts.LinkedAbuse)
+	if err != nil {
+		return SignalCounts{}, fmt.Errorf("failed to count reputation signals: %w", err)
+	}
+	return counts, nil
+}
+
+// SaveAssessment stores an assessment and caps the user's free quota in one
+// transaction
+func (s *DBStore) SaveAssessment(ctx context.Context, assessment Assessment) (*Assessment, error) {
+	signals, err := json.Marshal(assessment.Signals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reputation signals: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `
+		SELECT free_conversions_limit FROM users WHERE id = $1 FOR UPDATE`, assessment.UserID,
+	).Scan(&assessment.FullCredits); err != nil {
+		return nil, fmt.Errorf("failed to read free quota: %w", err)
+	}
+	if assessment.AllowedCredits != nil && *assessment.AllowedCredits < assessment.FullCredits {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET free_conversions_limit = $2,
+				free_quota_remaining = GREATEST(0, $2 - free_conversions_used),
+				updated_at = NOW()
+			WHERE id = $1`, assessment.UserID, *assessment.AllowedCredits); err != nil {
+			return nil, fmt.Errorf("failed to limit free quota: %w", err)
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO signup_reputation (user_id, phone_prefix, device_fingerprint, ip, asn, score, level,
+			reasons, signals, full_credits, allowed_credits, review_status, release_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at`,
+		assessment.UserID, assessment.PhonePrefix, assessment.DeviceFingerprint, assessment.IP, assessment.ASN,
+		assessment.Score, assessment.Level, pq.Array(assessment.Reasons), signals, assessment.FullCredits,
+		assessment.AllowedCredits, assessment.ReviewStatus, assessment.ReleaseAt,
+	).Scan(&assessment.ID, &assessment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reputation assessment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &assessment, nil
+}
+
+// GetAssessment returns the assessment of a user
+func (s *DBStore) GetAssessment(ctx context.Context, userID string) (*Assessment, error) {
+	assessment, err := scanAssessment(s.db.QueryRowContext(ctx, `
+		SELECT `+assessmentColumns+`
+		FROM signup_reputation r LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = $1`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssessmentNotFound
+	}
+	return assessment, err
+}
+
+// ListAssessments returns the assessments matching req, newest first
+func (s *DBStore) ListAssessments(ctx context.Context, req ListReviewsRequest, cursor *common.Cursor) ([]Assessment, error) {
+	query := `SELECT ` + assessmentColumns + `
+		FROM signup_reputation r LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.review_status = $1`
+	args := []interface{}{req.Status}
+	argIndex := 2
+
+	if req.Level != "" {
+		query += fmt.Sprintf(" AND r.level = $%d", argIndex)
+		args = append(args, req.Level)
+		argIndex++
+	}
+	if condition, cursorArgs := cursor.Condition("r.created_at", "r.id", argIndex); condition != "" {
+		query += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("r.created_at", "r.id", cursor, req.PageSize, 0, argIndex)
+	query += clause
+	args = append(args, pageArgs...)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reputation assessments: %w", err)
+	}
+	defer rows.Close()
+
+	assessments := []Assessment{}
+	for rows.Next() {
+		assessment, err := scanAssessment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assessments = append(assessments, *assessment)
+	}
+	return assessments, rows.Err()
+}
+
+// ResolveReview records an admin's decision and adjusts the free quota in
+// one transaction
+func (s *DBStore) ResolveReview(ctx context.Context, userID, status, adminID, note string) (*Assessment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var fullCredits int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE signup_reputation
+		SET review_status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = NOW()
+		WHERE user_id = $1 AND review_status = 'pending'
+		RETURNING full_credits`, userID, status, adminID, note).Scan(&fullCredits)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM signup_reputation WHERE user_id = $1)`, userID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to check reputation assessment: %w", err)
+		}
+		if !exists {
+			return nil, ErrAssessmentNotFound
+		}
+		return nil, ErrNotPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to review account: %w", err)
+	}
+
+	if status == ReviewApproved {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET free_conversions_limit = GREATEST(free_conversions_limit, $2),
+				free_quota_remaining = GREATEST(0, GREATEST(free_conversions_limit, $2) - free_conversions_used),
+				updated_at = NOW()
+			WHERE id = $1`, userID, fullCredits)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET free_conversions_limit = LEAST(free_conversions_limit, free_conversions_used),
+				free_quota_remaining = 0,
+				updated_at = NOW()
+			WHERE id = $1`, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update free quota: %w", err)
+	}
+
+	if err := audit(ctx, tx, adminID, "signup_review_"+status, map[string]interface{}{
+		"user_id": userID,
+		"note":    note,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetAssessment(ctx, userID)
+}
+
+// ReleaseDue restores the full free quota of due limited accounts
+func (s *DBStore) ReleaseDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+		WITH due AS (
+			SELECT id FROM signup_reputation
+			WHERE review_status = 'pending' AND release_at <= $1
+			ORDER BY release_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), released AS (
+			UPDATE signup_reputation r
+			SET review_status = 'released', reviewed_at = NOW()
+			FROM due WHERE r.id = due.id
+			RETURNING r.user_id, r.full_credits
+		)
+		UPDATE users u
+		SET free_conversions_limit = GREATEST(u.free_conversions_limit, released.full_credits),
+			free_quota_remaining = GREATEST(0, GREATEST(u.free_conversions_limit, released.full_credits) - u.free_conversions_used),
+			updated_at = NOW()
+		FROM released
+		WHERE u.id = released.user_id`, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release limited accounts: %w", err)
+	}
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count released accounts: %w", err)
+	}
+	return int(released), nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAssessment(row rowScanner) (*Assessment, error) {
+	var assessment Assessment
+	var device, ip, asn, reviewedBy, reviewNote sql.NullString
+	var allowed sql.NullInt64
+	var releaseAt, reviewedAt sql.NullTime
+	var signals []byte
+	err := row.Scan(&assessment.ID, &assessment.UserID, &assessment.Phone, &assessment.PhonePrefix, &device, &ip, &asn,
+		&assessment.Score, &assessment.Level, pq.Array(&assessment.Reasons), &signals, &assessment.FullCredits, &allowed,
+		&assessment.ReviewStatus, &releaseAt, &reviewedBy, &reviewNote, &reviewedAt, &assessment.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan reputation assessment: %w", err)
+	}
+
+	if err := json.Unmarshal(signals, &assessment.Signals); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reputation signals: %w", err)
+	}
+	if assessment.Reasons == nil {
+		assessment.Reasons = []string{}
+	}
+	assessment.DeviceFingerprint = device.String
+	assessment.IP = ip.String
+	assessment.ASN = asn.String
+	if allowed.Valid {
+		assessment.AllowedCredits = intPtr(int(allowed.Int64))
+	}
+	if releaseAt.Valid {
+		assessment.ReleaseAt = &releaseAt.Time
+	}
+	if reviewedBy.Valid {
+		assessment.ReviewedBy = &reviewedBy.String
+	}
+	if reviewNote.Valid {
+		assessment.ReviewNote = &reviewNote.String
+	}
+	if reviewedAt.Valid {
+		assessment.ReviewedAt = &reviewedAt.Time
+	}
+	return &assessment, nil
+}
+
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'signup_reputation', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
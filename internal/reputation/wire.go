@@ -0,0 +1,37 @@
+package reputation
+
+import (
+	"database/sql"
+	"fmt"
+
+	"ai-styler/internal/config"
+	"ai-styler/internal/ipfilter"
+)
+
+// WireReputationService creates a reputation service with all dependencies.
+// Network signals need cfg.Reputation.ASNPath; an unreadable database is an
+// error rather than silently scoring without them.
+func WireReputationService(db *sql.DB, cfg *config.Config) (*Service, error) {
+	service := NewService(NewDBStore(db), Config{
+		PhonePrefixDigits: cfg.Reputation.PhonePrefixDigits,
+		Window:            cfg.Reputation.Window,
+		PrefixThreshold:   cfg.Reputation.PrefixThreshold,
+		DeviceThreshold:   cfg.Reputation.DeviceThreshold,
+		ASNThreshold:      cfg.Reputation.ASNThreshold,
+		RiskyASNs:         cfg.Reputation.RiskyASNs,
+		LimitScore:        cfg.Reputation.LimitScore,
+		HoldScore:         cfg.Reputation.HoldScore,
+		LimitedCredits:    cfg.Reputation.LimitedCredits,
+		ReleaseDelay:      cfg.Reputation.ReleaseDelay,
+		ReleaseInterval:   cfg.Reputation.ReleaseInterval,
+	})
+
+	if cfg.Reputation.ASNPath != "" {
+		resolver, err := ipfilter.LoadASNResolver(cfg.Reputation.ASNPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load asn database: %w", err)
+		}
+		service.SetASNResolver(resolver)
+	}
+	return service, nil
+}
@@ -2,27 +2,40 @@ package route
 
 import (
 	"ai-styler/internal/admin"
+	"ai-styler/internal/apikey"
 	"ai-styler/internal/auth"
+	"ai-styler/internal/cache"
 	"ai-styler/internal/common"
 	"ai-styler/internal/config"
 	"ai-styler/internal/conversion"
 	"ai-styler/internal/docs"
+	"ai-styler/internal/domain"
+	"ai-styler/internal/flags"
 	"ai-styler/internal/image"
+	"ai-styler/internal/ipfilter"
+	"ai-styler/internal/locale"
 	"ai-styler/internal/middleware"
 	"ai-styler/internal/monitoring"
 	"ai-styler/internal/notification"
 	"ai-styler/internal/payment"
+	"ai-styler/internal/prompt"
+	"ai-styler/internal/quota"
+	"ai-styler/internal/reputation"
 	"ai-styler/internal/security"
+	"ai-styler/internal/settings"
 	"ai-styler/internal/share"
 	"ai-styler/internal/sms"
 	"ai-styler/internal/storage"
+	"ai-styler/internal/support"
 	"ai-styler/internal/user"
 	"ai-styler/internal/vendors"
+	"ai-styler/internal/verification"
 	"ai-styler/internal/worker"
 	"context"
 	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -72,41 +85,19 @@ func (m *mockRateLimiter) Allow(ctx context.Context, key string, limit int, wind
 	return true
 }
 
-func New() *gin.Engine {
+func New(cfg *config.Config) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 
-	// Load security configuration
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
 	// Create security middleware
-	securityConfig := &security.SecurityConfig{
-		RateLimitEnabled:       true,
-		RateLimitPerIP:         cfg.RateLimit.OTPPerIP,
-		RateLimitPerUser:       1000,
-		RateLimitWindow:        cfg.RateLimit.Window,
-		JWTSecret:              cfg.JWT.Secret,
-		JWTExpiration:          cfg.JWT.AccessTTL,
-		CORSEnabled:            true,
-		AllowedOrigins:         []string{"*"},
-		AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:         []string{"*"},
-		SecurityHeadersEnabled: true,
-		ImageScanEnabled:       true,
-		SignedURLEnabled:       true,
-		SignedURLExpiration:    24 * time.Hour,
-	}
-
-	securityMiddleware := security.NewSecurityMiddleware(securityConfig)
+	securityMiddleware := security.NewSecurityMiddleware(newSecurityConfig(cfg))
 
 	// Apply security middleware
 	r.Use(securityMiddleware.CORSMiddleware())
 	r.Use(securityMiddleware.SecurityHeadersMiddleware())
 	r.Use(securityMiddleware.RateLimitMiddleware())
+	r.Use(locale.Middleware())
 
 	// Health endpoint (no auth required)
 	r.GET("/health", func(c *gin.Context) { c.String(200, "ok") })
@@ -116,38 +107,40 @@ func New() *gin.Engine {
 	r.GET("/api/docs/openapi.json", gin.WrapF(docs.ServeAPIDocumentation))
 
 	// Auth routes (no auth required)
-	mountAuth(r)
+	mountAuth(cfg, r)
 
 	// Protected routes
+	var storageHandler *storage.Handler
+	var paymentHandler *payment.Handler
 	protected := r.Group("/")
 	protected.Use(securityMiddleware.OptionalAuthMiddleware())
 	{
 		// User routes
-		mountUser(protected)
+		mountUser(cfg, protected)
 
 		// Vendor routes
-		mountVendor(protected)
+		mountVendor(cfg, protected)
 
 		// Conversion routes
-		mountConversion(protected)
+		mountConversion(cfg, protected)
 
 		// Payment routes
-		mountPayment(protected)
+		paymentHandler = mountPayment(cfg, protected)
 
 		// Image routes
-		mountImage(protected)
+		mountImage(cfg, protected)
 
 		// Notification routes
-		mountNotification(protected)
+		mountNotification(cfg, protected)
 
 		// Worker routes
-		mountWorker(protected)
+		mountWorker(cfg, protected)
 
 		// Storage routes
-		mountStorage(protected)
+		storageHandler = mountStorage(cfg, protected)
 
 		// Share routes
-		mountShare(protected)
+		mountShare(cfg, protected)
 	}
 
 	// Admin routes (require admin auth)
@@ -155,32 +148,29 @@ func New() *gin.Engine {
 	adminGroup.Use(securityMiddleware.JWTAuthMiddleware())
 	adminGroup.Use(securityMiddleware.AdminAuthMiddleware())
 	{
-		mountAdmin(adminGroup)
+		mountAdmin(cfg, adminGroup)
+		storageHandler.RegisterAdminRoutes(adminGroup)
+		payment.SetupAdminRoutes(adminGroup, paymentHandler)
 	}
 
 	return r
 }
 
 // NewWithMonitoring creates a new router with monitoring capabilities
-func NewWithMonitoring(monitor *monitoring.MonitoringService) *gin.Engine {
+func NewWithMonitoring(cfg *config.Config, monitor *monitoring.MonitoringService) *gin.Engine {
 	r := gin.New()
 
-	// Load security configuration
-	cfg, err := config.Load()
-	if err != nil {
-		monitor.LogFatal(context.Background(), "Failed to load config", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
-
 	// Create monitoring middleware
 	requestLogger := middleware.NewRequestLoggerMiddleware(monitor.Logger())
+	requestLogger.SetSampling(cfg.Monitoring.LogSampleRates)
 	monitoringMiddleware := middleware.NewMonitoringMiddleware(monitor)
 	contextMiddleware := middleware.NewContextMiddleware()
 	recoveryMiddleware := middleware.NewRecoveryMiddleware(monitor)
+	tracingMiddleware := middleware.NewTracingMiddleware()
 
 	// Apply monitoring middleware first
 	r.Use(recoveryMiddleware.Recovery())
+	r.Use(tracingMiddleware.Tracing())
 	r.Use(contextMiddleware.InjectContext())
 	r.Use(requestLogger.RequestLogging())
 	r.Use(monitoringMiddleware.ErrorHandling())
@@ -188,38 +178,24 @@ func NewWithMonitoring(monitor *monitoring.MonitoringService) *gin.Engine {
 	r.Use(monitoringMiddleware.SecurityMonitoring())
 
 	// Create security middleware
-	securityConfig := &security.SecurityConfig{
-		RateLimitEnabled:       true,
-		RateLimitPerIP:         cfg.RateLimit.OTPPerIP,
-		RateLimitPerUser:       1000,
-		RateLimitWindow:        cfg.RateLimit.Window,
-		JWTSecret:              cfg.JWT.Secret,
-		JWTExpiration:          cfg.JWT.AccessTTL,
-		CORSEnabled:            true,
-		AllowedOrigins:         []string{"*"},
-		AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:         []string{"*"},
-		SecurityHeadersEnabled: true,
-		ImageScanEnabled:       true,
-		SignedURLEnabled:       true,
-		SignedURLExpiration:    24 * time.Hour,
-	}
-
-	securityMiddleware := security.NewSecurityMiddleware(securityConfig)
+	securityMiddleware := security.NewSecurityMiddleware(newSecurityConfig(cfg))
 
 	// Apply security middleware
 	r.Use(securityMiddleware.CORSMiddleware())
 	r.Use(securityMiddleware.SecurityHeadersMiddleware())
 	r.Use(securityMiddleware.RateLimitMiddleware())
+	r.Use(locale.Middleware())
 
 	// Health endpoints with monitoring
 	healthHandler := monitoring.NewHealthHandler(monitor.Health())
 	healthHandler.RegisterRoutes(r.Group("/api"))
 
 	// Auth routes (no auth required)
-	mountAuth(r)
+	mountAuth(cfg, r)
 
 	// Protected routes
+	var storageHandler *storage.Handler
+	var paymentHandler *payment.Handler
 	protected := r.Group("/")
 	protected.Use(securityMiddleware.OptionalAuthMiddleware())
 	protected.Use(contextMiddleware.UserContext())
@@ -227,31 +203,31 @@ func NewWithMonitoring(monitor *monitoring.MonitoringService) *gin.Engine {
 	protected.Use(contextMiddleware.ConversionContext())
 	{
 		// User routes
-		mountUser(protected)
+		mountUser(cfg, protected)
 
 		// Vendor routes
-		mountVendor(protected)
+		mountVendor(cfg, protected)
 
 		// Conversion routes
-		mountConversion(protected)
+		mountConversion(cfg, protected)
 
 		// Payment routes
-		mountPayment(protected)
+		paymentHandler = mountPayment(cfg, protected)
 
 		// Image routes
-		mountImage(protected)
+		mountImage(cfg, protected)
 
 		// Notification routes
-		mountNotification(protected)
+		mountNotification(cfg, protected)
 
 		// Worker routes
-		mountWorker(protected)
+		mountWorker(cfg, protected)
 
 		// Storage routes
-		mountStorage(protected)
+		storageHandler = mountStorage(cfg, protected)
 
 		// Share routes
-		mountShare(protected)
+		mountShare(cfg, protected)
 	}
 
 	// Admin routes (require admin auth)
@@ -259,7 +235,9 @@ func NewWithMonitoring(monitor *monitoring.MonitoringService) *gin.Engine {
 	adminGroup.Use(securityMiddleware.JWTAuthMiddleware())
 	adminGroup.Use(securityMiddleware.AdminAuthMiddleware())
 	{
-		mountAdmin(adminGroup)
+		mountAdmin(cfg, adminGroup)
+		storageHandler.RegisterAdminRoutes(adminGroup)
+		payment.SetupAdminRoutes(adminGroup, paymentHandler)
 	}
 
 	monitor.LogInfo(context.Background(), "Router initialized with monitoring", map[string]interface{}{
@@ -272,15 +250,27 @@ func NewWithMonitoring(monitor *monitoring.MonitoringService) *gin.Engine {
 
 // NewWithServices creates a new router with all services properly wired
 func NewWithServices(
+	cfg *config.Config,
 	authService interface{},
 	userService interface{},
 	vendorService interface{},
 	conversionService interface{},
+	conversionQuota *middleware.QuotaMiddleware,
 	imageService interface{},
 	paymentService interface{},
 	shareService interface{},
 	adminService interface{},
 	notificationService interface{},
+	apiKeyService interface{},
+	settingsService *settings.Service,
+	ipFilterService *ipfilter.Service,
+	flagsService *flags.Service,
+	promptService *prompt.Service,
+	supportService *support.Service,
+	reputationService *reputation.Service,
+	verificationService *verification.Service,
+	workerService *worker.Service,
+	smsWebhookHandler *sms.WebhookHandler,
 	monitor *monitoring.MonitoringService,
 ) *gin.Engine {
 	r := gin.New()
@@ -290,6 +280,7 @@ func NewWithServices(
 
 	// Create monitoring middleware
 	requestLogger := middleware.NewRequestLoggerMiddleware(monitor.Logger())
+	requestLogger.SetSampling(cfg.Monitoring.LogSampleRates)
 	monitoringMiddleware := middleware.NewMonitoringMiddleware(monitor)
 	contextMiddleware := middleware.NewContextMiddleware()
 	recoveryMiddleware := middleware.NewRecoveryMiddleware(monitor)
@@ -301,39 +292,38 @@ func NewWithServices(
 	r.Use(monitoringMiddleware.ErrorHandling())
 	r.Use(monitoringMiddleware.PerformanceMonitoring())
 	r.Use(monitoringMiddleware.SecurityMonitoring())
-
-	// Load security configuration
-	cfg, err := config.Load()
-	if err != nil {
-		monitor.LogFatal(context.Background(), "Failed to load config", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	r.Use(cache.RequestScope())
 
 	// Create security middleware
-	securityConfig := &security.SecurityConfig{
-		RateLimitEnabled:       true,
-		RateLimitPerIP:         cfg.RateLimit.OTPPerIP,
-		RateLimitPerUser:       1000,
-		RateLimitWindow:        cfg.RateLimit.Window,
-		JWTSecret:              cfg.JWT.Secret,
-		JWTExpiration:          cfg.JWT.AccessTTL,
-		CORSEnabled:            true,
-		AllowedOrigins:         []string{"*"},
-		AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:         []string{"*"},
-		SecurityHeadersEnabled: true,
-		ImageScanEnabled:       true,
-		SignedURLEnabled:       true,
-		SignedURLExpiration:    24 * time.Hour,
+	securityMiddleware := security.NewSecurityMiddleware(newSecurityConfig(cfg))
+	if settingsService != nil {
+		// Rate limits follow the admin-editable system settings
+		settingsService.OnChange(func(current settings.Settings) {
+			securityMiddleware.SetRateLimits(security.RateLimits{
+				Enabled: current.RateLimitEnabled,
+				PerIP:   current.RateLimitPerMinute,
+				PerUser: current.APIRateLimit,
+				Window:  time.Minute,
+			})
+		})
 	}
 
-	securityMiddleware := security.NewSecurityMiddleware(securityConfig)
-
 	// Apply security middleware
 	r.Use(securityMiddleware.CORSMiddleware())
 	r.Use(securityMiddleware.SecurityHeadersMiddleware())
+	r.Use(locale.Middleware())
+	if ipFilterService != nil {
+		// Before the rate limiter and auth so their 429/401 responses count
+		// towards automatic blocking
+		r.Use(ipFilterService.Middleware())
+	}
 	r.Use(securityMiddleware.RateLimitMiddleware())
+	if settingsService != nil {
+		maintenance := middleware.NewMaintenanceMiddleware(func() bool {
+			return settingsService.Current().MaintenanceMode
+		}, cfg.SystemSettings.MaintenanceAllowedIPs)
+		r.Use(maintenance.Handler())
+	}
 
 	// Health endpoints with monitoring
 	healthHandler := monitoring.NewHealthHandler(monitor.Health())
@@ -341,19 +331,68 @@ func NewWithServices(
 
 	// Auth routes (no auth required) - using passed authHandler
 	authGroup := r.Group("/auth")
+	authGroup.Use(auth.ClientIPMiddleware())
 	authGroup.POST("/send-otp", common.GinWrap(authService.(*auth.Handler).SendOTP))
 	authGroup.POST("/verify-otp", common.GinWrap(authService.(*auth.Handler).VerifyOTP))
-	authGroup.POST("/check-user", common.GinWrap(authService.(*auth.Handler).CheckUser))
+	common.Mount(authGroup, http.MethodPost, "/check-user", authService.(*auth.Handler).CheckUserEndpoint())
 	authGroup.POST("/register", common.GinWrap(authService.(*auth.Handler).Register))
-	authGroup.POST("/login", common.GinWrap(authService.(*auth.Handler).Login))
-	authGroup.POST("/refresh", common.GinWrap(authService.(*auth.Handler).Refresh))
+	common.Mount(authGroup, http.MethodPost, "/login", authService.(*auth.Handler).LoginEndpoint())
+	common.Mount(authGroup, http.MethodPost, "/refresh", authService.(*auth.Handler).RefreshEndpoint())
 	authGroup.POST("/logout", common.GinWrap(authService.(*auth.Handler).Authenticate(authService.(*auth.Handler).Logout)))
 	authGroup.POST("/logout-all", common.GinWrap(authService.(*auth.Handler).Authenticate(authService.(*auth.Handler).LogoutAll)))
+	common.Mount(authGroup, http.MethodGet, "/sessions/revoke", authService.(*auth.Handler).RevokeSessionLinkEndpoint())
+
+	// Public token verification keys for other services
+	r.GET("/.well-known/jwks.json", common.GinWrap(authService.(*auth.Handler).JWKS))
+
+	// Session management for the signed-in user
+	sessionsGroup := r.Group("/api/users/me/sessions")
+	sessionsGroup.GET("", common.GinWrap(authService.(*auth.Handler).Authenticate(authService.(*auth.Handler).ListSessions)))
+	sessionsGroup.DELETE("/:id", common.GinWrap(authService.(*auth.Handler).Authenticate(authService.(*auth.Handler).RevokeSessionByID)))
+
+	// TOTP two-factor authentication for admin accounts
+	mountTwoFactor(authGroup, authService.(*auth.Handler))
+
+	// Telegram account linking and passwordless bot sign-in
+	mountTelegramLink(authGroup, authService.(*auth.Handler))
+
+	// Public vendor storefront (no auth required) for embedding catalogs on external sites
+	if vendorService != nil {
+		vendors.MountPublicRoutes(r.Group("/api/public"), vendorService.(*vendors.Handler))
+	}
+
+	// Direct uploads to local storage, authorized by their signed URL
+	if imageService != nil {
+		image.SetupUploadRoutes(r.Group("/api"), imageService.(*image.Handler))
+	}
+
+	// Conversion export downloads, authorized by their signed link
+	if conversionService != nil {
+		conversion.SetupExportDownloadRoutes(r.Group("/api"), conversionService.(*conversion.Handler))
+	}
+
+	// Account data export downloads, authorized by their signed link
+	if userService != nil {
+		user.SetupExportDownloadRoutes(r.Group("/api"), userService.(*user.Handler))
+	}
+
+	// SMS delivery reports, authenticated by the provider's signature
+	if smsWebhookHandler != nil {
+		sms.SetupWebhookRoutes(r.Group("/api"), smsWebhookHandler)
+	}
 
 	// Protected routes - using passed handlers
 	protected := r.Group("/api")
+	if apiKeyService != nil {
+		// Authenticate B2B integrations by X-API-Key, with per-key scopes and rate limits
+		protected.Use(apiKeyService.(*apikey.Handler).Middleware())
+	}
 	// Use auth handler's authentication middleware for proper token validation
 	protected.Use(authMiddlewareForGin(authService.(*auth.Handler)))
+	if adminService != nil {
+		// Flag and audit requests made by admins impersonating a user
+		protected.Use(adminService.(*admin.Handler).ImpersonationMiddleware())
+	}
 	protected.Use(contextMiddleware.UserContext())
 	protected.Use(contextMiddleware.VendorContext())
 	protected.Use(contextMiddleware.ConversionContext())
@@ -362,11 +401,14 @@ func NewWithServices(
 		if userService != nil {
 			user.MountRoutes(protected, userService.(*user.Handler))
 		}
+		if apiKeyService != nil {
+			apikey.MountRoutes(protected, apiKeyService.(*apikey.Handler))
+		}
 		if vendorService != nil {
 			vendors.MountRoutes(protected, vendorService.(*vendors.Handler))
 		}
 		if conversionService != nil {
-			conversion.MountRoutes(protected, conversionService.(*conversion.Handler))
+			conversion.MountRoutes(protected, conversionService.(*conversion.Handler), conversionQuotaMiddleware(conversionQuota)...)
 		}
 		if imageService != nil {
 			image.SetupGinRoutes(protected, imageService.(*image.Handler))
@@ -374,6 +416,15 @@ func NewWithServices(
 		if paymentService != nil {
 			payment.SetupRoutes(protected, paymentService.(*payment.Handler))
 		}
+		if flagsService != nil {
+			flags.SetupRoutes(protected, flags.NewHandler(flagsService))
+		}
+		if supportService != nil {
+			support.SetupRoutes(protected, support.NewHandler(supportService))
+		}
+		if verificationService != nil {
+			verification.SetupRoutes(protected, verification.NewHandler(verificationService))
+		}
 		if shareService != nil {
 			// Share service doesn't have MountRoutes, we'll add it manually
 			shareGroup := protected.Group("/share")
@@ -381,6 +432,10 @@ func NewWithServices(
 			shareGroup.POST("/create", shareService.(*share.Handler).CreateSharedLink)
 			shareGroup.DELETE("/:id", shareService.(*share.Handler).DeactivateSharedLink)
 			shareGroup.GET("/", shareService.(*share.Handler).ListUserSharedLinks)
+
+			vendorShareGroup := protected.Group("/vendor/share")
+			vendorShareGroup.POST("/bulk", shareService.(*share.Handler).CreateBulkSharedLinks)
+			vendorShareGroup.GET("/campaigns/:tag", shareService.(*share.Handler).GetCampaignStats)
 		}
 	}
 
@@ -388,10 +443,65 @@ func NewWithServices(
 	adminGroup := r.Group("/api")
 	adminGroup.Use(securityMiddleware.JWTAuthMiddleware())
 	adminGroup.Use(securityMiddleware.AdminAuthMiddleware())
+	adminGroup.Use(authService.(*auth.Handler).RequireTwoFactor())
 	{
+		// adminAccess checks role permissions on admin routes mounted by other packages
+		adminAccess := func(read, write admin.Permission) gin.HandlerFunc {
+			if adminService == nil {
+				return func(c *gin.Context) { c.Next() }
+			}
+			return adminService.(*admin.Handler).RequireAccess(read, write)
+		}
+
 		if adminService != nil {
 			admin.SetupRoutes(adminGroup, adminService.(*admin.Handler))
 		}
+		if settingsService != nil {
+			settings.SetupRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSettingsRead, admin.PermSettingsWrite)), settings.NewHandler(settingsService))
+		}
+		if ipFilterService != nil {
+			ipfilter.SetupRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSettingsRead, admin.PermSettingsWrite)), ipfilter.NewHandler(ipFilterService))
+		}
+		if flagsService != nil {
+			flags.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSettingsRead, admin.PermSettingsWrite)), flags.NewHandler(flagsService))
+		}
+		if promptService != nil {
+			prompt.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermConversionsRead, admin.PermConversionsWrite)), prompt.NewHandler(promptService))
+		}
+		if supportService != nil {
+			support.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSupportRead, admin.PermSupportWrite)), support.NewHandler(supportService))
+		}
+		if reputationService != nil {
+			reputation.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermUsersRead, admin.PermUsersWrite)), reputation.NewHandler(reputationService))
+		}
+		if verificationService != nil {
+			verification.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermVendorsRead, admin.PermVendorsWrite)), verification.NewHandler(verificationService))
+		}
+		if workerService != nil {
+			worker.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSystemRead, admin.PermSystemWrite)), worker.NewHandler(workerService))
+		}
+		if smsWebhookHandler != nil {
+			sms.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSystemRead, admin.PermSystemWrite)), smsWebhookHandler)
+		}
+		if notificationService != nil {
+			notification.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSettingsRead, admin.PermSettingsWrite)), notificationService.(*notification.Handler))
+		}
+		if paymentService != nil {
+			payment.SetupAdminRoutes(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermPaymentsRead, admin.PermPaymentsWrite)), paymentService.(*payment.Handler))
+		}
+		common.Mount(adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermAuditRead, admin.PermAuditRead)),
+			http.MethodGet, "/otp-events", authService.(*auth.Handler).ListOTPEventsEndpoint())
+		mountStorageBackups(cfg, adminGroup.Group("/admin", admin.AdminAuthMiddleware(), adminAccess(admin.PermSystemRead, admin.PermSystemWrite)))
+		if queryMetrics := monitor.QueryMetrics(); queryMetrics != nil {
+			queries := adminGroup.Group("/admin/system/queries", admin.AdminAuthMiddleware(), adminAccess(admin.PermSystemRead, admin.PermSystemWrite))
+			queries.GET("", queryMetrics.ListHandler)     // GET /api/admin/system/queries
+			queries.DELETE("", queryMetrics.ResetHandler) // DELETE /api/admin/system/queries
+		}
+		if lookupCache := cache.Default(); lookupCache != nil {
+			cached := adminGroup.Group("/admin/system/cache", admin.AdminAuthMiddleware(), adminAccess(admin.PermSystemRead, admin.PermSystemWrite))
+			cached.GET("", lookupCache.Metrics().ListHandler)     // GET /api/admin/system/cache
+			cached.DELETE("", lookupCache.Metrics().ResetHandler) // DELETE /api/admin/system/cache
+		}
 	}
 
 	// Notification routes - using passed notificationHandler
@@ -447,6 +557,9 @@ func authMiddlewareForGin(authHandler *auth.Handler) gin.HandlerFunc {
 		if claims.Role != "" {
 			c.Set("user_role", claims.Role)
 		}
+		if claims.ImpersonatorID != "" {
+			c.Set(admin.ImpersonatorContextKey, claims.ImpersonatorID)
+		}
 		c.Next()
 	}
 }
@@ -466,38 +579,56 @@ func parseSimpleTokenForAuth(token string) (userID, role string) {
 	return "", ""
 }
 
-func mountAuth(r *gin.Engine) {
-	// Load config for SMS provider
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountAuth(cfg *config.Config, r *gin.Engine) {
 	store := auth.NewInMemoryStore()
 	limiter := auth.NewInMemoryLimiter()
 	tokens := auth.NewSimpleTokenService()
-	smsProvider := sms.NewProviderWithParameter(cfg.SMS.Provider, cfg.SMS.APIKey, cfg.SMS.TemplateID, cfg.SMS.ParameterName)
+	smsProvider := sms.WireProvider(cfg, nil)
 	// Create handler compatible with gin via adapters
-	h := auth.NewHandler(store, tokens, limiter, smsProvider)
+	h := auth.NewHandlerWithConfig(cfg, store, tokens, limiter, smsProvider)
 
 	g := r.Group("/auth")
+	g.Use(auth.ClientIPMiddleware())
 	g.POST("/send-otp", common.GinWrap(h.SendOTP))
 	g.POST("/verify-otp", common.GinWrap(h.VerifyOTP))
-	g.POST("/check-user", common.GinWrap(h.CheckUser))
+	common.Mount(g, http.MethodPost, "/check-user", h.CheckUserEndpoint())
 	g.POST("/register", common.GinWrap(h.Register))
-	g.POST("/login", common.GinWrap(h.Login))
-	g.POST("/refresh", common.GinWrap(h.Refresh))
+	common.Mount(g, http.MethodPost, "/login", h.LoginEndpoint())
+	common.Mount(g, http.MethodPost, "/refresh", h.RefreshEndpoint())
 	g.POST("/logout", common.GinWrap(h.Authenticate(h.Logout)))
 	g.POST("/logout-all", common.GinWrap(h.Authenticate(h.LogoutAll)))
+	common.Mount(g, http.MethodGet, "/sessions/revoke", h.RevokeSessionLinkEndpoint())
+
+	sessions := r.Group("/api/users/me/sessions")
+	sessions.GET("", common.GinWrap(h.Authenticate(h.ListSessions)))
+	sessions.DELETE("/:id", common.GinWrap(h.Authenticate(h.RevokeSessionByID)))
+
+	mountTwoFactor(g, h)
+	mountTelegramLink(g, h)
 }
 
-func mountUser(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
+// mountTwoFactor mounts the TOTP enrollment and login verification endpoints
+func mountTwoFactor(g *gin.RouterGroup, h *auth.Handler) {
+	twoFactor := g.Group("/2fa")
+	twoFactor.GET("", common.GinWrap(h.Authenticate(h.TwoFactorStatus)))
+	twoFactor.POST("/enroll", common.GinWrap(h.Authenticate(h.TwoFactorEnroll)))
+	twoFactor.POST("/confirm", common.GinWrap(h.Authenticate(h.TwoFactorConfirm)))
+	twoFactor.POST("/recovery-codes", common.GinWrap(h.Authenticate(h.TwoFactorRecoveryCodes)))
+	common.Mount(twoFactor, http.MethodPost, "/verify", h.TwoFactorVerifyEndpoint())
+}
+
+// mountTelegramLink mounts the Telegram link code endpoints for users and the
+// link and sign-in endpoints called by the bot
+func mountTelegramLink(g *gin.RouterGroup, h *auth.Handler) {
+	telegram := g.Group("/telegram")
+	telegram.GET("", common.GinWrap(h.Authenticate(h.TelegramLinkStatus)))
+	telegram.DELETE("", common.GinWrap(h.Authenticate(h.TelegramUnlink)))
+	telegram.POST("/link-code", common.GinWrap(h.Authenticate(h.TelegramLinkCode)))
+	common.Mount(telegram, http.MethodPost, "/link", h.TelegramLinkEndpoint())
+	common.Mount(telegram, http.MethodPost, "/login", h.TelegramLoginEndpoint())
+}
 
+func mountUser(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -509,15 +640,10 @@ func mountUser(r *gin.RouterGroup) {
 
 	// Mount user routes
 	user.MountRoutes(r, userHandler)
+	user.SetupExportDownloadRoutes(r, userHandler)
 }
 
-func mountVendor(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountVendor(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -531,13 +657,7 @@ func mountVendor(r *gin.RouterGroup) {
 	vendors.MountRoutes(r, vendorHandler)
 }
 
-func mountConversion(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountConversion(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -545,19 +665,74 @@ func mountConversion(r *gin.RouterGroup) {
 	}
 
 	// Create conversion service and handler
-	_, conversionHandler := conversion.WireConversionService(db)
+	conversionService, conversionHandler := conversion.WireConversionService(db)
+	onboarding := conversion.NewOnboarding(conversion.OnboardingConfig{
+		Enabled:                 cfg.Onboarding.Enabled,
+		SignupCredits:           cfg.Onboarding.SignupCredits,
+		FirstConversionFree:     cfg.Onboarding.FirstConversionFree,
+		FirstConversionPriority: cfg.Onboarding.FirstConversionPriority,
+	}, conversion.NewDBOnboardingStore(db))
+	conversionService.SetOnboarding(onboarding)
+	conversionService.SetPlanPriority(conversion.NewDBPlanPriorityStore(db))
+	conversionService.SetPlanOutputLimits(conversion.NewDBPlanOutputStore(db))
+	conversionQuota := WireConversionQuota(cfg, db, conversionService, onboarding)
+	conversionService.SetFeedback(conversion.NewDBFeedbackStore(db))
+	conversionService.SetPresets(conversion.NewDBPresetStore(db))
 
 	// Mount conversion routes
-	conversion.MountRoutes(r, conversionHandler)
+	conversion.MountRoutes(r, conversionHandler, conversionQuotaMiddleware(conversionQuota)...)
+	conversion.SetupExportDownloadRoutes(r, conversionHandler)
 }
 
-func mountPayment(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
+// ConversionQuota is the row-locked quota conversions, their retries and the
+// extra garments of outfits are charged to
+type ConversionQuota interface {
+	middleware.ConversionQuota
+	conversion.QuotaCharges
+}
+
+// WireConversionQuota charges conversions to the row-locked quota when
+// QUOTA_ENFORCEMENT_ENABLED is set and wires retries and outfits. It returns
+// the middleware charging new conversions, or nil when the legacy quota
+// check in the conversion service applies.
+func WireConversionQuota(cfg *config.Config, db *sql.DB, service *conversion.Service, onboarding *conversion.Onboarding) *middleware.QuotaMiddleware {
+	var charges ConversionQuota
+	if cfg.Quota.Enabled {
+		charges = quota.NewService(quota.NewStore(db))
+	}
+	return wireConversionQuota(cfg, db, service, onboarding, charges)
+}
+
+// wireConversionQuota wires the conversion service to charges, or to the
+// legacy quota check when charges is nil
+func wireConversionQuota(cfg *config.Config, db *sql.DB, service *conversion.Service, onboarding *conversion.Onboarding, charges ConversionQuota) *middleware.QuotaMiddleware {
+	var retryQuota conversion.RetryQuota
+	var outfitQuota conversion.OutfitQuota
+	var quotaMiddleware *middleware.QuotaMiddleware
+	if charges != nil {
+		quotaMiddleware = middleware.NewQuotaMiddleware(nil, charges)
+		if onboarding != nil {
+			quotaMiddleware.SetConversionExemption(onboarding.QuotaExempt)
+		}
+		// Record what each conversion was charged so the worker can refund it
+		service.SetQuotaCharges(charges)
+		retryQuota, outfitQuota = charges, charges
 	}
 
+	service.SetRetries(conversion.NewDBRetryStore(db), retryQuota, cfg.ConversionRetry.MaxRetries)
+	service.SetOutfits(conversion.NewOutfitStore(db), outfitQuota, cfg.ConversionOutfit.MaxGarments)
+	return quotaMiddleware
+}
+
+// conversionQuotaMiddleware returns the handlers run before a conversion is created
+func conversionQuotaMiddleware(quotaMiddleware *middleware.QuotaMiddleware) []gin.HandlerFunc {
+	if quotaMiddleware == nil {
+		return nil
+	}
+	return []gin.HandlerFunc{quotaMiddleware.EnforceConversionQuota()}
+}
+
+func mountPayment(cfg *config.Config, r *gin.RouterGroup) *payment.Handler {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -567,7 +742,7 @@ func mountPayment(r *gin.RouterGroup) {
 	// Create real services instead of mocks
 	userService := &realUserService{db: db}
 	notificationService := &realNotificationService{db: db}
-	quotaService := &realQuotaService{db: db}
+	quotaService := &realQuotaService{quota: quota.NewService(quota.NewStore(db))}
 	auditLogger := &realAuditLogger{db: db}
 	rateLimiter := &realRateLimiter{}
 
@@ -582,6 +757,9 @@ func mountPayment(r *gin.RouterGroup) {
 		rateLimiter,
 		payment.NewPaymentConfigService(),
 	)
+	paymentService.SetPlanChanges(payment.NewDBPlanChangeStore(db))
+	paymentService.SetCoupons(payment.NewDBCouponStore(db))
+	paymentService.SetSpendingLimits(payment.NewDBSpendingLimitStore(db), notificationService)
 
 	// Create BazaarPay service
 	bazaarPayService := payment.NewBazaarPayService(db)
@@ -592,35 +770,38 @@ func mountPayment(r *gin.RouterGroup) {
 	// Mount payment routes
 	paymentGroup := r.Group("/api")
 	payment.SetupRoutes(paymentGroup, paymentHandler)
+	return paymentHandler
 }
 
-func mountAdmin(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountAdmin(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
 		panic("failed to connect to database: " + err.Error())
 	}
 
-	// Create admin service and handler
-	_, adminHandler := admin.WireAdminService(db)
+	// Create admin service and handler, reading reports from replicas when configured
+	var replicas []*sql.DB
+	for _, dsn := range cfg.Database.ReadReplicaDSNs {
+		replica, err := sql.Open("postgres", dsn)
+		if err != nil {
+			panic("failed to connect to read replica: " + err.Error())
+		}
+		replicas = append(replicas, replica)
+	}
+	dbRouter := common.NewDBRouter(db, replicas, cfg.Database.ReplicaMaxLag)
+	go dbRouter.Run(context.Background(), cfg.Database.ReplicaCheckInterval)
+	adminService, adminHandler := admin.WireAdminServiceWithReplicas(dbRouter)
+	adminService.SetRoles(admin.NewDBStore(db), admin.RBACConfig{
+		DefaultRole: cfg.Security.AdminDefaultRole,
+		CacheTTL:    cfg.Security.AdminPermissionCacheTTL,
+	})
 
 	// Mount admin routes
 	admin.SetupRoutes(r, adminHandler)
 }
 
-func mountImage(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountImage(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -628,7 +809,7 @@ func mountImage(r *gin.RouterGroup) {
 	}
 
 	// Create image service and handler
-	_, imageHandler := image.WireImageService(db)
+	_, imageHandler := image.WireImageService(db, cfg)
 
 	// Skip mounting if handler is nil (not implemented yet)
 	if imageHandler == nil {
@@ -640,13 +821,7 @@ func mountImage(r *gin.RouterGroup) {
 	image.SetupGinRoutes(r, imageHandler)
 }
 
-func mountNotification(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountNotification(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -654,7 +829,7 @@ func mountNotification(r *gin.RouterGroup) {
 	}
 
 	// Create notification service and handler
-	_, notificationHandler := notification.WireNotificationService(db)
+	_, notificationHandler := notification.WireNotificationService(db, cfg)
 
 	// Skip mounting if handler is nil (not implemented yet)
 	if notificationHandler == nil {
@@ -666,13 +841,7 @@ func mountNotification(r *gin.RouterGroup) {
 	notification.SetupRoutes(notificationGroup, notificationHandler)
 }
 
-func mountWorker(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountWorker(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -692,6 +861,32 @@ func mountWorker(r *gin.RouterGroup) {
 	worker.RegisterWorkerRoutes(workerGroup, workerHandler)
 }
 
+// newSecurityConfig builds the security middleware configuration; CORS and
+// response headers come from cfg.Security
+func newSecurityConfig(cfg *config.Config) *security.SecurityConfig {
+	return &security.SecurityConfig{
+		RateLimitEnabled:       true,
+		RateLimitPerIP:         cfg.RateLimit.OTPPerIP,
+		RateLimitPerUser:       1000,
+		RateLimitWindow:        cfg.RateLimit.Window,
+		JWTSecret:              cfg.JWT.Secret,
+		JWTExpiration:          cfg.JWT.AccessTTL,
+		CORSEnabled:            true,
+		AllowedOrigins:         cfg.Security.CORSAllowedOrigins,
+		AllowedMethods:         cfg.Security.CORSAllowedMethods,
+		AllowedHeaders:         cfg.Security.CORSAllowedHeaders,
+		AllowCredentials:       cfg.Security.CORSAllowCredentials,
+		CORSMaxAge:             cfg.Security.CORSMaxAge,
+		SecurityHeadersEnabled: true,
+		HSTSMaxAge:             cfg.Security.HSTSMaxAge,
+		HSTSIncludeSubdomains:  cfg.Security.HSTSIncludeSubdomains,
+		ContentSecurityPolicy:  cfg.Security.ContentSecurityPolicy,
+		ImageScanEnabled:       true,
+		SignedURLEnabled:       true,
+		SignedURLExpiration:    24 * time.Hour,
+	}
+}
+
 // buildDSN builds database connection string
 func buildDSN(cfg *config.Config) string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -699,19 +894,44 @@ func buildDSN(cfg *config.Config) string {
 		cfg.Database.Password, cfg.Database.Name, cfg.Database.SSLMode)
 }
 
-func mountStorage(r *gin.RouterGroup) {
-	// Load config for storage
-	cfg, err := config.Load()
+func mountStorage(cfg *config.Config, r *gin.RouterGroup) *storage.Handler {
+	// Get handler and mount routes
+	storageHandler := newStorageWire(cfg).GetHandler()
+
+	// Serve WebP/AVIF and resized variants generated by the worker
+	if cfg.Storage.ImageVariantsEnabled {
+		db, err := sql.Open("postgres", buildDSN(cfg))
+		if err != nil {
+			panic("failed to connect to database: " + err.Error())
+		}
+		storageHandler.SetVariants(storage.NewVariantRepository(db))
+	}
+
+	storageHandler.RegisterRoutes(r.Group("/api"))
+	return storageHandler
+}
+
+// mountStorageBackups mounts the storage backup endpoints on an admin group
+// and starts the scheduled backups
+func mountStorageBackups(cfg *config.Config, r *gin.RouterGroup) {
+	newStorageWire(cfg).GetHandler().RegisterAdminRoutes(r)
+}
+
+// newStorageWire initializes the storage services, including the backup
+// scheduler
+func newStorageWire(cfg *config.Config) *storage.Wire {
+	// URL signing keys are independent of the JWT secret
+	signing, err := storage.SigningSettingsFromConfig(cfg.Storage)
 	if err != nil {
-		panic("failed to load config: " + err.Error())
+		panic("failed to load storage signing keys: " + err.Error())
 	}
 
 	// Create storage configuration
 	storageConfig := &storage.Config{
-		BasePath:     cfg.Storage.StoragePath,
-		BackupPath:   cfg.Storage.StoragePath + "/backups",
-		SignedURLKey: cfg.JWT.Secret,
-		MaxFileSize:  50 * 1024 * 1024, // 50MB
+		BasePath:    cfg.Storage.StoragePath,
+		BackupPath:  cfg.Storage.StoragePath + "/backups",
+		Signing:     signing,
+		MaxFileSize: 50 * 1024 * 1024, // 50MB
 		AllowedTypes: []string{
 			"image/jpeg",
 			"image/jpg",
@@ -727,12 +947,12 @@ func mountStorage(r *gin.RouterGroup) {
 		RetentionPolicy: storage.RetentionPolicy{
 			KeepImagesForever: true,
 			MaxAge:            0,
-			CleanupSchedule:   "0 2 * * *",
+			CleanupSchedule:   cfg.Storage.CleanupSchedule,
 		},
 		BackupPolicy: storage.BackupPolicy{
-			Enabled:          true,
-			BackupFrequency:  "daily",
-			RetentionDays:    365,
+			Enabled:          cfg.Storage.BackupEnabled,
+			BackupFrequency:  cfg.Storage.BackupFrequency,
+			RetentionDays:    cfg.Storage.BackupRetentionDays,
 			CompressionLevel: 6,
 		},
 		ServerConfig: storage.ServerConfig{
@@ -753,18 +973,10 @@ func mountStorage(r *gin.RouterGroup) {
 		panic("failed to initialize storage: " + err.Error())
 	}
 
-	// Get handler and mount routes
-	storageHandler := storageWire.GetHandler()
-	storageHandler.RegisterRoutes(r.Group("/api"))
+	return storageWire
 }
 
-func mountShare(r *gin.RouterGroup) {
-	// Load config for database connection
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
+func mountShare(cfg *config.Config, r *gin.RouterGroup) {
 	// Connect to database
 	db, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
@@ -804,10 +1016,10 @@ type mockShareAuditLogger struct{}
 type mockShareMetricsCollector struct{}
 
 func (m *mockShareConversionService) GetConversion(ctx context.Context, conversionID, userID string) (share.ConversionResponse, error) {
-	return share.ConversionResponse{
+	return share.ConversionResponse{Conversion: domain.Conversion{
 		ID: conversionID, UserID: userID, Status: "completed",
 		ResultImageID: stringPtr("img-123"),
-	}, nil
+	}}, nil
 }
 
 func (m *mockShareConversionService) ValidateConversionOwnership(ctx context.Context, conversionID, userID string) error {
@@ -923,30 +1135,28 @@ func (r *realNotificationService) SendPlanExpired(ctx context.Context, userID st
 	return nil
 }
 
-// realQuotaService implements quota service interface for payment
+func (r *realNotificationService) SendSpendingLimitReached(ctx context.Context, userID string, limit, spent int64) error {
+	// Implementation would send actual notification
+	fmt.Printf("Spending limit notification sent to user %s, limit %d, spent %d\n", userID, limit, spent)
+	return nil
+}
+
+// realQuotaService adapts the quota service to the payment quota interface
 type realQuotaService struct {
-	db *sql.DB
+	quota *quota.Service
 }
 
+// UpdateUserQuota starts a fresh billing cycle once a plan is activated
 func (r *realQuotaService) UpdateUserQuota(ctx context.Context, userID string, planName string) error {
-	// Implementation would update user's quota based on plan
-	fmt.Printf("Updated quota for user %s with plan %s\n", userID, planName)
-	return nil
+	return r.quota.StartCycle(ctx, userID)
 }
 
 func (r *realQuotaService) ResetMonthlyQuota(ctx context.Context, userID string) error {
-	// Implementation would reset user's monthly quota
-	fmt.Printf("Reset monthly quota for user %s\n", userID)
-	return nil
+	return r.quota.ResetCycle(ctx, userID)
 }
 
 func (r *realQuotaService) GetUserQuotaStatus(ctx context.Context, userID string) (interface{}, error) {
-	// Implementation would get user's current quota status
-	return map[string]interface{}{
-		"remaining": 10,
-		"used":      0,
-		"limit":     10,
-	}, nil
+	return r.quota.GetStatus(ctx, userID)
 }
 
 // realAuditLogger implements audit logger interface for payment
@@ -10,15 +10,9 @@ import (
 )
 
 // NewWithCrossCutting creates a new router with comprehensive cross-cutting enhancements
-func NewWithCrossCutting() *gin.Engine {
+func NewWithCrossCutting(cfg *config.Config) *gin.Engine {
 	r := gin.New()
 
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		panic("failed to load config: " + err.Error())
-	}
-
 	// Create cross-cutting configuration
 	crossCuttingConfig := &crosscutting.CrossCuttingConfig{
 		RateLimiting: &crosscutting.RateLimiterConfig{
@@ -339,45 +333,45 @@ func NewWithCrossCutting() *gin.Engine {
 	})
 
 	// Auth routes (no auth required)
-	mountAuth(r)
+	mountAuth(cfg, r)
 
 	// Protected routes
 	protected := r.Group("/")
 	protected.Use(crosscuttingMiddleware(ccl))
 	{
 		// User routes
-		mountUser(protected)
+		mountUser(cfg, protected)
 
 		// Vendor routes
-		mountVendor(protected)
+		mountVendor(cfg, protected)
 
 		// Conversion routes
-		mountConversion(protected)
+		mountConversion(cfg, protected)
 
 		// Payment routes
-		mountPayment(protected)
+		mountPayment(cfg, protected)
 
 		// Image routes
-		mountImage(protected)
+		mountImage(cfg, protected)
 
 		// Notification routes
-		mountNotification(protected)
+		mountNotification(cfg, protected)
 
 		// Worker routes
-		mountWorker(protected)
+		mountWorker(cfg, protected)
 
 		// Storage routes
-		mountStorage(protected)
+		mountStorage(cfg, protected)
 
 		// Share routes
-		mountShare(protected)
+		mountShare(cfg, protected)
 	}
 
 	// Admin routes (require admin auth)
 	adminGroup := r.Group("/api/admin")
 	adminGroup.Use(crosscuttingMiddleware(ccl))
 	{
-		mountAdmin(adminGroup)
+		mountAdmin(cfg, adminGroup)
 	}
 
 	// Log initialization
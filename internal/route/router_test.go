@@ -0,0 +1,249 @@
+package route
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-styler/internal/auth"
+	"ai-styler/internal/config"
+	"ai-styler/internal/conversion"
+	"ai-styler/internal/domain"
+	"ai-styler/internal/middleware"
+	"ai-styler/internal/monitoring"
+	"ai-styler/internal/quota"
+	"ai-styler/internal/sms"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeQuota is a row-locked quota with a fixed number of conversions left
+type fakeQuota struct {
+	mu        sync.Mutex
+	remaining int
+	consumed  int
+	released  int
+	charges   map[string][]quota.Reservation
+}
+
+func (q *fakeQuota) status() quota.Status {
+	resetAt := time.Now().Add(time.Hour)
+	return quota.Status{PlanName: "free", Limit: 2, Remaining: q.remaining, ResetAt: &resetAt}
+}
+
+func (q *fakeQuota) Consume(ctx context.Context, userID string) (quota.Reservation, quota.Status, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.remaining == 0 {
+		return quota.Reservation{}, q.status(), &quota.ExceededError{Status: q.status()}
+	}
+	q.remaining--
+	q.consumed++
+	return quota.Reservation{UserID: userID, Source: "plan", ChargedAt: time.Now()}, q.status(), nil
+}
+
+func (q *fakeQuota) Release(ctx context.Context, reservation quota.Reservation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.remaining++
+	q.released++
+	return nil
+}
+
+func (q *fakeQuota) RecordCharges(ctx context.Context, conversionID string, reservations ...quota.Reservation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.charges == nil {
+		q.charges = make(map[string][]quota.Reservation)
+	}
+	q.charges[conversionID] = append(q.charges[conversionID], reservations...)
+	return nil
+}
+
+func (q *fakeQuota) GetStatus(ctx context.Context, userID string) (quota.Status, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.status(), nil
+}
+
+// fakeConversionStore creates conversions that are completed right away
+type fakeConversionStore struct {
+	conversion.Store
+	legacyChecks int
+}
+
+func (s *fakeConversionStore) CreateConversion(ctx context.Context, userID, userImageID, clothImageID, styleName string) (string, error) {
+	return "conv-1", nil
+}
+
+func (s *fakeConversionStore) GetConversionWithDetails(ctx context.Context, conversionID string) (conversion.ConversionResponse, error) {
+	return domain.ConversionDetails{Conversion: domain.Conversion{
+		ID:     conversionID,
+		UserID: "user-1",
+		Status: domain.ConversionStatusCompleted,
+	}}, nil
+}
+
+func (s *fakeConversionStore) CheckUserQuota(ctx context.Context, userID string) (conversion.QuotaCheck, error) {
+	s.legacyChecks++
+	return conversion.QuotaCheck{CanConvert: true, RemainingFree: 1, TotalRemaining: 1}, nil
+}
+
+type fakeImages struct{ conversion.ImageService }
+
+func (fakeImages) GetImage(ctx context.Context, imageID string) (conversion.ImageInfo, error) {
+	return conversion.ImageInfo{ID: imageID, Type: "user", UserID: "user-1"}, nil
+}
+
+func (fakeImages) ValidateImageAccess(ctx context.Context, imageID, userID string) error {
+	return nil
+}
+
+type fakeNotifier struct{ conversion.NotificationService }
+
+func (fakeNotifier) SendConversionStarted(ctx context.Context, userID, conversionID string) error {
+	return nil
+}
+
+type fakeRateLimiter struct{}
+
+func (fakeRateLimiter) CheckRateLimit(ctx context.Context, userID string) (bool, error) {
+	return true, nil
+}
+
+func (fakeRateLimiter) RecordRequest(ctx context.Context, userID string) error { return nil }
+
+type fakeAuditLogger struct{ conversion.AuditLogger }
+
+func (fakeAuditLogger) LogConversionRequest(ctx context.Context, userID, conversionID string, request conversion.ConversionRequest) error {
+	return nil
+}
+
+type fakeWorker struct{ conversion.WorkerService }
+
+func (fakeWorker) EnqueueConversion(ctx context.Context, conversionID string, priority int) error {
+	return nil
+}
+
+type fakeMetrics struct{ conversion.MetricsCollector }
+
+func (fakeMetrics) RecordConversionStart(ctx context.Context, conversionID, userID string) error {
+	return nil
+}
+
+// newConversionRouter builds the router main serves with a conversion
+// service whose quota is q
+func newConversionRouter(t *testing.T, q *fakeQuota) (*gin.Engine, *fakeConversionStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// Templates are loaded relative to the repository root
+	t.Chdir("../..")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.Storage.StoragePath = t.TempDir()
+
+	monitor, err := monitoring.NewMonitoringService(monitoring.MonitoringConfig{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create monitoring service: %v", err)
+	}
+	t.Cleanup(func() { monitor.Close() })
+
+	// Nothing listens on it, so stores backed by the database fail
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 dbname=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store := &fakeConversionStore{}
+	service := conversion.NewService(store, fakeImages{}, nil, fakeNotifier{}, fakeRateLimiter{}, fakeAuditLogger{}, fakeWorker{}, fakeMetrics{})
+	conversionQuota := wireConversionQuota(cfg, db, service, nil, q)
+
+	authHandler := auth.NewHandler(auth.NewInMemoryStore(), auth.NewSimpleTokenService(), auth.NewInMemoryLimiter(), sms.NewProvider("mock", "", 0))
+	r := NewWithServices(cfg, authHandler, nil, nil, conversion.NewHandler(service), conversionQuota,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, monitor)
+	return r, store
+}
+
+func postConversion(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/convert", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte("user-1|user|session-1")))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewWithServices_EnforcesConversionQuota(t *testing.T) {
+	q := &fakeQuota{remaining: 0}
+	r, store := newConversionRouter(t, q)
+
+	w := postConversion(r, `{"userImageId":"user-image","clothImageId":"cloth-image"}`)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(middleware.QuotaLimitHeader); got != "2" {
+		t.Errorf("expected %s 2, got %q", middleware.QuotaLimitHeader, got)
+	}
+	if got := w.Header().Get(middleware.QuotaRemainingHeader); got != "0" {
+		t.Errorf("expected %s 0, got %q", middleware.QuotaRemainingHeader, got)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After to be set")
+	}
+	if store.legacyChecks != 0 {
+		t.Errorf("expected no legacy quota check, got %d", store.legacyChecks)
+	}
+}
+
+func TestNewWithServices_RecordsConversionCharges(t *testing.T) {
+	q := &fakeQuota{remaining: 2}
+	r, store := newConversionRouter(t, q)
+
+	w := postConversion(r, `{"userImageId":"user-image","clothImageId":"cloth-image"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if q.consumed != 1 || q.released != 0 {
+		t.Errorf("expected 1 conversion charged and none released, got %d charged and %d released", q.consumed, q.released)
+	}
+	// The worker refunds failed conversions from the recorded charges
+	charges := q.charges["conv-1"]
+	if len(charges) != 1 || charges[0].UserID != "user-1" {
+		t.Errorf("expected the conversion's charge to be recorded for user-1, got %+v", charges)
+	}
+	if store.legacyChecks != 0 {
+		t.Errorf("expected no legacy quota check, got %d", store.legacyChecks)
+	}
+}
+
+func TestNewWithServices_ChargesOutfitGarments(t *testing.T) {
+	q := &fakeQuota{remaining: 3}
+	r, _ := newConversionRouter(t, q)
+
+	w := postConversion(r, `{"userImageId":"user-image","garments":[{"imageId":"top-image","slot":"top"},{"imageId":"bottom-image","slot":"bottom"}]}`)
+
+	if strings.Contains(w.Body.String(), "too many garments") {
+		t.Fatalf("expected an outfit of 2 garments to be allowed, got %d: %s", w.Code, w.Body.String())
+	}
+	// One conversion charged by the middleware and one for the second garment
+	if q.consumed != 2 {
+		t.Errorf("expected 2 conversions charged, got %d", q.consumed)
+	}
+	// The outfit store has no database, so both are given back
+	if q.released != 2 {
+		t.Errorf("expected 2 conversions released, got %d", q.released)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,6 +18,10 @@ type JWTClaims struct {
 	SessionID string `json:"session_id"`
 	Role      string `json:"role"`
 	Phone     string `json:"phone"`
+	// ImpersonatorID is set when an admin acts on behalf of the user
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	// TwoFactor is set when the session passed a TOTP check at login
+	TwoFactor bool `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,6 +29,13 @@ type JWTClaims struct {
 type ProductionJWTSigner struct {
 	secretKey []byte
 	issuer    string
+
+	// Rotating signing keys, see SetKeyRotation; nil signs with secretKey
+	keys          atomic.Pointer[jwtKeyRing]
+	keyStore      JWTKeyStore
+	keyConfig     JWTKeyConfig
+	sealKey       []byte
+	lastKeyReload atomic.Int64
 }
 
 // NewProductionJWTSigner creates a new production-ready JWT signer
@@ -54,19 +66,102 @@ func (s *ProductionJWTSigner) Sign(userID, sessionID, role, phone string, expire
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	return s.signClaims(claims)
 }
 
-// Verify verifies a JWT token and returns the claims
-func (s *ProductionJWTSigner) Verify(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
+// SignImpersonation creates a signed access token for userID that carries the impersonating admin's ID
+func (s *ProductionJWTSigner) SignImpersonation(userID, sessionID, role, phone, impersonatorID string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+
+	claims := JWTClaims{
+		UserID:         userID,
+		SessionID:      sessionID,
+		Role:           role,
+		Phone:          phone,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   userID,
+			Audience:  []string{"ai-styler-api"},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        sessionID,
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// SignTwoFactor creates a signed access token for a session that passed two-factor verification
+func (s *ProductionJWTSigner) SignTwoFactor(userID, sessionID, role, phone string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+
+	claims := JWTClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		Phone:     phone,
+		TwoFactor: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   userID,
+			Audience:  []string{"ai-styler-api"},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        sessionID,
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// signClaims signs claims with the current signing key, naming it in the kid
+// header, or with the secret when keys don't rotate
+func (s *ProductionJWTSigner) signClaims(claims jwt.Claims) (string, error) {
+	if ring := s.keys.Load(); ring != nil {
+		token := jwt.NewWithClaims(ring.signing.method, claims)
+		token.Header["kid"] = ring.signing.id
+		return token.SignedString(ring.signing.signKey)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secretKey)
+}
+
+// keyFunc returns the key a token is verified with, rejecting tokens whose
+// algorithm is not the one of their key
+func (s *ProductionJWTSigner) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Tokens from before key rotation are signed with the secret
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return s.secretKey, nil
-	})
+	}
+
+	ring := s.keys.Load()
+	if ring == nil {
+		return nil, ErrUnknownJWTKey
+	}
+	key, ok := ring.byID[kid]
+	if !ok {
+		if key = s.reloadUnknownKey(kid); key == nil {
+			return nil, ErrUnknownJWTKey
+		}
+	}
+	if time.Now().After(key.expiresAt) {
+		return nil, ErrUnknownJWTKey
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// Verify verifies a JWT token and returns the claims
+func (s *ProductionJWTSigner) Verify(tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -106,18 +201,12 @@ func (s *ProductionJWTSigner) SignRefreshToken(userID, sessionID string, expires
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	return s.signClaims(claims)
 }
 
 // VerifyRefreshToken verifies a refresh token
 func (s *ProductionJWTSigner) VerifyRefreshToken(tokenString string) (*RefreshTokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &RefreshTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshTokenClaims{}, s.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse refresh token: %w", err)
@@ -133,6 +222,55 @@ func (s *ProductionJWTSigner) VerifyRefreshToken(tokenString string) (*RefreshTo
 	return nil, errors.New("invalid refresh token")
 }
 
+// TwoFactorChallengeClaims identify a login that passed the password check
+// and still has to present a TOTP or recovery code
+type TwoFactorChallengeClaims struct {
+	UserID    string `json:"user_id"`
+	Phone     string `json:"phone"`
+	TokenType string `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// SignTwoFactorChallenge creates a signed two-factor login challenge
+func (s *ProductionJWTSigner) SignTwoFactorChallenge(userID, phone string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+
+	claims := TwoFactorChallengeClaims{
+		UserID:    userID,
+		Phone:     phone,
+		TokenType: "2fa_challenge",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   userID,
+			Audience:  []string{"ai-styler-2fa"},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// VerifyTwoFactorChallenge verifies a two-factor login challenge
+func (s *ProductionJWTSigner) VerifyTwoFactorChallenge(tokenString string) (*TwoFactorChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorChallengeClaims{}, s.keyFunc)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenge: %w", err)
+	}
+
+	if claims, ok := token.Claims.(*TwoFactorChallengeClaims); ok && token.Valid {
+		if claims.TokenType != "2fa_challenge" {
+			return nil, errors.New("invalid token type")
+		}
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid challenge")
+}
+
 // GenerateSessionID generates a secure session ID
 func GenerateSessionID() string {
 	return uuid.New().String()
@@ -0,0 +1,430 @@
+package security
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// JWT signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// JWT key rotation defaults
+const (
+	DefaultJWTKeyRotationInterval = 30 * 24 * time.Hour
+	DefaultJWTKeyRefreshInterval  = 5 * time.Minute
+
+	// jwtKeyPublishLead is how long a new key is published in the JWKS before
+	// it signs, so verifiers caching the JWKS know it by then
+	jwtKeyPublishLead = 10 * time.Minute
+	// jwtKeyReloadCooldown bounds the reloads triggered by tokens with an
+	// unknown key ID, e.g. a key just created by another instance
+	jwtKeyReloadCooldown = 10 * time.Second
+	// jwksMaxAge is how long clients may cache the JWKS
+	jwksMaxAge = 5 * time.Minute
+	rsaKeyBits = 2048
+)
+
+// ErrUnknownJWTKey is returned for tokens signed with a key that is unknown or expired
+var ErrUnknownJWTKey = errors.New("unknown signing key")
+
+// StoredJWTKey is a signing key as kept by a JWTKeyStore
+type StoredJWTKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte // encrypted with a key derived from the JWT secret
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // tokens signed with the key are rejected afterwards
+}
+
+// JWTKeyStore stores the signing keys shared by all instances
+type JWTKeyStore interface {
+	// ListJWTKeys returns the keys that have not expired
+	ListJWTKeys(ctx context.Context) ([]StoredJWTKey, error)
+	// CreateJWTKey stores key unless a key of its algorithm was created
+	// after since, in which case another instance rotated first and it
+	// returns false
+	CreateJWTKey(ctx context.Context, key StoredJWTKey, since time.Time) (bool, error)
+	DeleteExpiredJWTKeys(ctx context.Context) error
+}
+
+// JWTKeyConfig configures signing key rotation
+type JWTKeyConfig struct {
+	Algorithm        string        // HS256, RS256 or EdDSA
+	RotationInterval time.Duration // how long a key signs before the next one takes over
+	VerifyFor        time.Duration // how long tokens of a replaced key stay valid, the longest token TTL
+	RefreshInterval  time.Duration // how often keys are reloaded and rotation is checked
+}
+
+// jwtKey is a decrypted signing key
+type jwtKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// jwtKeyRing holds the keys in use; it is replaced as a whole when keys change
+type jwtKeyRing struct {
+	signing *jwtKey
+	byID    map[string]*jwtKey
+	keys    []*jwtKey // newest first
+}
+
+// SetKeyRotation signs tokens with rotating keys kept in store instead of the
+// static secret. Tokens carry the ID of their key in the kid header; tokens
+// without one are still verified with the secret. Keys are rotated by
+// StartKeyRotation.
+func (s *ProductionJWTSigner) SetKeyRotation(ctx context.Context, store JWTKeyStore, config JWTKeyConfig) error {
+	switch config.Algorithm {
+	case "":
+		config.Algorithm = JWTAlgorithmHS256
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", config.Algorithm)
+	}
+	if config.RotationInterval <= 0 {
+		config.RotationInterval = DefaultJWTKeyRotationInterval
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultJWTKeyRefreshInterval
+	}
+
+	s.keyStore = store
+	s.keyConfig = config
+	sealKey := sha256.Sum256(append([]byte("jwt-signing-keys:"), s.secretKey...))
+	s.sealKey = sealKey[:]
+	return s.refreshKeys(ctx)
+}
+
+// StartKeyRotation reloads the signing keys and rotates them when due until
+// ctx is cancelled
+func (s *ProductionJWTSigner) StartKeyRotation(ctx context.Context) {
+	if s.keyStore == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.keyConfig.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.refreshKeys(ctx); err != nil {
+				log.Printf("JWT keys: failed to refresh signing keys: %v", err)
+			}
+			if err := s.keyStore.DeleteExpiredJWTKeys(ctx); err != nil {
+				log.Printf("JWT keys: failed to delete expired keys: %v", err)
+			}
+		}
+	}
+}
+
+// refreshKeys loads the stored keys, creates a new one when rotation is due
+// and publishes them
+func (s *ProductionJWTSigner) refreshKeys(ctx context.Context) error {
+	s.lastKeyReload.Store(time.Now().UnixNano())
+
+	ring, err := s.loadKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The next key is created ahead of time so it is published before it signs
+	newest := ring.newest(s.keyConfig.Algorithm)
+	if newest == nil || time.Since(newest.createdAt) >= s.keyConfig.RotationInterval-jwtKeyPublishLead {
+		// Keys this instance can't open, e.g. sealed with a previous JWT
+		// secret, don't hold back a new one
+		since := time.Now()
+		if newest != nil {
+			since = newest.createdAt
+		}
+		if err := s.createKey(ctx, since); err != nil {
+			return err
+		}
+		if ring, err = s.loadKeys(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ring.signing == nil {
+		return fmt.Errorf("no %s signing key available", s.keyConfig.Algorithm)
+	}
+	s.keys.Store(ring)
+	return nil
+}
+
+// loadKeys decrypts the stored keys and picks the one that signs
+func (s *ProductionJWTSigner) loadKeys(ctx context.Context) (*jwtKeyRing, error) {
+	stored, err := s.keyStore.ListJWTKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &jwtKeyRing{byID: make(map[string]*jwtKey, len(stored))}
+	now := time.Now()
+	for _, st := range stored {
+		if !now.Before(st.ExpiresAt) {
+			continue
+		}
+		key, err := s.openKey(st)
+		if err != nil {
+			// Keys sealed with a previous JWT secret can't be used any more
+			log.Printf("JWT keys: skipping key %s: %v", st.ID, err)
+			continue
+		}
+		ring.byID[key.id] = key
+		ring.keys = append(ring.keys, key)
+	}
+	sort.Slice(ring.keys, func(i, j int) bool { return ring.keys[i].createdAt.After(ring.keys[j].createdAt) })
+
+	// The newest published key signs; the very first key signs right away
+	for _, key := range ring.keys {
+		if key.method.Alg() != s.keyConfig.Algorithm {
+			continue
+		}
+		if ring.signing == nil || now.Sub(key.createdAt) >= jwtKeyPublishLead {
+			ring.signing = key
+		}
+		if now.Sub(key.createdAt) >= jwtKeyPublishLead {
+			break
+		}
+	}
+	return ring, nil
+}
+
+// newest returns the most recently created key of algorithm
+func (r *jwtKeyRing) newest(algorithm string) *jwtKey {
+	for _, key := range r.keys {
+		if key.method.Alg() == algorithm {
+			return key
+		}
+	}
+	return nil
+}
+
+// createKey generates and stores a key for the configured algorithm
+func (s *ProductionJWTSigner) createKey(ctx context.Context, since time.Time) error {
+	private, err := generateJWTKey(s.keyConfig.Algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	sealed, err := s.sealKeyBytes(private)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := StoredJWTKey{
+		ID:         uuid.New().String(),
+		Algorithm:  s.keyConfig.Algorithm,
+		PrivateKey: sealed,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(jwtKeyPublishLead + s.keyConfig.RotationInterval + s.keyConfig.VerifyFor),
+	}
+	created, err := s.keyStore.CreateJWTKey(ctx, key, since)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("JWT keys: created %s signing key %s", key.Algorithm, key.ID)
+	}
+	return nil
+}
+
+// reloadUnknownKey reloads the keys when a token names a key this instance
+// doesn't know, at most once per jwtKeyReloadCooldown
+func (s *ProductionJWTSigner) reloadUnknownKey(kid string) *jwtKey {
+	last := time.Unix(0, s.lastKeyReload.Load())
+	if time.Since(last) < jwtKeyReloadCooldown {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.refreshKeys(ctx); err != nil {
+		log.Printf("JWT keys: failed to reload signing keys: %v", err)
+		return nil
+	}
+	return s.keys.Load().byID[kid]
+}
+
+// generateJWTKey returns the encoded private key of a new key: the raw
+// secret for HS256 and PKCS #8 for the asymmetric algorithms
+func generateJWTKey(algorithm string) ([]byte, error) {
+	switch algorithm {
+	case JWTAlgorithmHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return secret, nil
+	case JWTAlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(private)
+	case JWTAlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(private)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+}
+
+// openKey decrypts a stored key
+func (s *ProductionJWTSigner) openKey(st StoredJWTKey) (*jwtKey, error) {
+	private, err := s.openKeyBytes(st.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{id: st.ID, createdAt: st.CreatedAt, expiresAt: st.ExpiresAt}
+	switch st.Algorithm {
+	case JWTAlgorithmHS256:
+		key.method = jwt.SigningMethodHS256
+		key.signKey, key.verifyKey = private, private
+		return key, nil
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", st.Algorithm)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	switch pk := parsed.(type) {
+	case *rsa.PrivateKey:
+		if st.Algorithm != JWTAlgorithmRS256 {
+			return nil, errors.New("key does not match its algorithm")
+		}
+		key.method = jwt.SigningMethodRS256
+		key.signKey, key.verifyKey = pk, &pk.PublicKey
+	case ed25519.PrivateKey:
+		if st.Algorithm != JWTAlgorithmEdDSA {
+			return nil, errors.New("key does not match its algorithm")
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.signKey, key.verifyKey = pk, pk.Public()
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+	return key, nil
+}
+
+// sealKeyBytes encrypts a private key with AES-GCM
+func (s *ProductionJWTSigner) sealKeyBytes(plaintext []byte) ([]byte, error) {
+	gcm, err := s.keyCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openKeyBytes decrypts a private key sealed by sealKeyBytes
+func (s *ProductionJWTSigner) openKeyBytes(sealed []byte) ([]byte, error) {
+	gcm, err := s.keyCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed key too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt key")
+	}
+	return plaintext, nil
+}
+
+func (s *ProductionJWTSigner) keyCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.sealKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 curve and public key
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens may be verified with. HS256 keys are
+// secret and never published, so the set is empty unless an asymmetric
+// algorithm is used.
+func (s *ProductionJWTSigner) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	ring := s.keys.Load()
+	if ring == nil {
+		return set
+	}
+
+	for _, key := range ring.keys {
+		jwk := JWK{Use: "sig", KeyID: key.id, Algorithm: key.method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWKSMaxAge is how long clients may cache the JWKS
+func (s *ProductionJWTSigner) JWKSMaxAge() time.Duration {
+	return jwksMaxAge
+}
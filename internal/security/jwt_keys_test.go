@@ -0,0 +1,257 @@
+package security
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockJWTKeyStore keeps signing keys in memory
+type mockJWTKeyStore struct {
+	mu   sync.Mutex
+	keys []StoredJWTKey
+}
+
+func (m *mockJWTKeyStore) ListJWTKeys(ctx context.Context) ([]StoredJWTKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []StoredJWTKey
+	for _, key := range m.keys {
+		if time.Now().Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockJWTKeyStore) CreateJWTKey(ctx context.Context, key StoredJWTKey, since time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.keys {
+		if existing.Algorithm == key.Algorithm && existing.CreatedAt.After(since) {
+			return false, nil
+		}
+	}
+	m.keys = append(m.keys, key)
+	return true, nil
+}
+
+func (m *mockJWTKeyStore) DeleteExpiredJWTKeys(ctx context.Context) error {
+	return nil
+}
+
+// age moves the creation of every stored key back by d
+func (m *mockJWTKeyStore) age(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.keys {
+		m.keys[i].CreatedAt = m.keys[i].CreatedAt.Add(-d)
+	}
+}
+
+const testJWTSecret = "test-secret-that-is-long-enough-for-hs256"
+
+func newRotatingSigner(t *testing.T, store JWTKeyStore, algorithm string) *ProductionJWTSigner {
+	t.Helper()
+	signer := NewProductionJWTSigner(testJWTSecret, "ai-styler")
+	err := signer.SetKeyRotation(context.Background(), store, JWTKeyConfig{
+		Algorithm:        algorithm,
+		RotationInterval: 24 * time.Hour,
+		VerifyFor:        time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("SetKeyRotation failed: %v", err)
+	}
+	return signer
+}
+
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyRotationSignsWithKeyID(t *testing.T) {
+	for _, algorithm := range []string{JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			store := &mockJWTKeyStore{}
+			signer := newRotatingSigner(t, store, algorithm)
+			if len(store.keys) != 1 {
+				t.Fatalf("Expected 1 key to be created, got %d", len(store.keys))
+			}
+
+			token, err := signer.Sign("user-1", "session-1", "user", "+989121234567", time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if kid := tokenKeyID(t, token); kid != store.keys[0].ID {
+				t.Errorf("Expected kid %s, got %q", store.keys[0].ID, kid)
+			}
+
+			claims, err := signer.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if claims.UserID != "user-1" {
+				t.Errorf("Expected user-1, got %s", claims.UserID)
+			}
+
+			// Another instance sharing the store verifies the token
+			other := newRotatingSigner(t, store, algorithm)
+			if _, err := other.Verify(token); err != nil {
+				t.Errorf("Expected other instance to verify the token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyRotationRotatesWhenDue(t *testing.T) {
+	store := &mockJWTKeyStore{}
+	signer := newRotatingSigner(t, store, JWTAlgorithmHS256)
+	ctx := context.Background()
+
+	oldToken, err := signer.SignRefreshToken("user-1", "session-1", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SignRefreshToken failed: %v", err)
+	}
+	oldKID := tokenKeyID(t, oldToken)
+
+	// Not due yet
+	if err := signer.refreshKeys(ctx); err != nil {
+		t.Fatalf("refreshKeys failed: %v", err)
+	}
+	if len(store.keys) != 1 {
+		t.Fatalf("Expected no rotation yet, got %d keys", len(store.keys))
+	}
+
+	// The next key is published ahead of time and signs once published long enough
+	store.age(24*time.Hour - jwtKeyPublishLead)
+	if err := signer.refreshKeys(ctx); err != nil {
+		t.Fatalf("refreshKeys failed: %v", err)
+	}
+	if len(store.keys) != 2 {
+		t.Fatalf("Expected a new key, got %d keys", len(store.keys))
+	}
+	token, _ := signer.SignRefreshToken("user-1", "session-2", time.Now().Add(time.Hour))
+	if kid := tokenKeyID(t, token); kid != oldKID {
+		t.Errorf("Expected the new key to wait before signing, got kid %s", kid)
+	}
+
+	store.age(jwtKeyPublishLead)
+	if err := signer.refreshKeys(ctx); err != nil {
+		t.Fatalf("refreshKeys failed: %v", err)
+	}
+	token, _ = signer.SignRefreshToken("user-1", "session-3", time.Now().Add(time.Hour))
+	if kid := tokenKeyID(t, token); kid == oldKID {
+		t.Error("Expected the new key to sign")
+	}
+
+	// Tokens of the previous key stay valid
+	if _, err := signer.VerifyRefreshToken(oldToken); err != nil {
+		t.Errorf("Expected old token to verify, got %v", err)
+	}
+}
+
+func TestKeyRotationVerifiesLegacyTokens(t *testing.T) {
+	legacy := NewProductionJWTSigner(testJWTSecret, "ai-styler")
+	token, err := legacy.Sign("user-1", "session-1", "user", "", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if kid := tokenKeyID(t, token); kid != "" {
+		t.Fatalf("Expected no kid without rotation, got %s", kid)
+	}
+
+	signer := newRotatingSigner(t, &mockJWTKeyStore{}, JWTAlgorithmRS256)
+	if _, err := signer.Verify(token); err != nil {
+		t.Errorf("Expected token signed with the secret to verify, got %v", err)
+	}
+}
+
+func TestKeyRotationRejectsForeignTokens(t *testing.T) {
+	store := &mockJWTKeyStore{}
+	signer := newRotatingSigner(t, store, JWTAlgorithmRS256)
+	kid := store.keys[0].ID
+
+	// HS256 token naming the RSA key, signed with its public key bytes
+	jwk := signer.JWKS().Keys[0]
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "admin", "exp": time.Now().Add(time.Hour).Unix()})
+	forged.Header["kid"] = kid
+	forgedToken, _ := forged.SignedString([]byte(jwk.N))
+	if _, err := signer.Verify(forgedToken); err == nil {
+		t.Error("Expected token with a mismatched algorithm to be rejected")
+	}
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+	unknown.Header["kid"] = "unknown"
+	unknownToken, _ := unknown.SignedString([]byte(testJWTSecret))
+	if _, err := signer.Verify(unknownToken); !errors.Is(err, ErrUnknownJWTKey) {
+		t.Errorf("Expected ErrUnknownJWTKey, got %v", err)
+	}
+}
+
+func TestKeyRotationSkipsKeysOfOtherSecrets(t *testing.T) {
+	store := &mockJWTKeyStore{}
+	newRotatingSigner(t, store, JWTAlgorithmHS256)
+
+	// A new JWT secret can't decrypt the stored key, so a new one is created
+	signer := NewProductionJWTSigner("a-completely-different-secret-value", "ai-styler")
+	if err := signer.SetKeyRotation(context.Background(), store, JWTKeyConfig{RotationInterval: 24 * time.Hour}); err != nil {
+		t.Fatalf("SetKeyRotation failed: %v", err)
+	}
+	if len(store.keys) != 2 {
+		t.Fatalf("Expected a new key next to the unreadable one, got %d keys", len(store.keys))
+	}
+	token, err := signer.Sign("user-1", "session-1", "user", "", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if kid := tokenKeyID(t, token); kid != store.keys[1].ID {
+		t.Errorf("Expected the new key to sign, got kid %s", kid)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	store := &mockJWTKeyStore{}
+	if keys := newRotatingSigner(t, store, JWTAlgorithmHS256).JWKS().Keys; len(keys) != 0 {
+		t.Errorf("Expected HS256 keys to stay private, got %d published", len(keys))
+	}
+
+	rsaSigner := newRotatingSigner(t, &mockJWTKeyStore{}, JWTAlgorithmRS256)
+	keys := rsaSigner.JWKS().Keys
+	if len(keys) != 1 || keys[0].KeyType != "RSA" || keys[0].Algorithm != "RS256" || keys[0].Use != "sig" {
+		t.Fatalf("Expected one RSA signing key, got %+v", keys)
+	}
+	ring := rsaSigner.keys.Load()
+	public := ring.signing.verifyKey.(*rsa.PublicKey)
+	n, _ := base64.RawURLEncoding.DecodeString(keys[0].N)
+	if new(big.Int).SetBytes(n).Cmp(public.N) != 0 || keys[0].E != "AQAB" {
+		t.Error("Expected the JWK to hold the RSA public key")
+	}
+
+	edSigner := newRotatingSigner(t, &mockJWTKeyStore{}, JWTAlgorithmEdDSA)
+	keys = edSigner.JWKS().Keys
+	if len(keys) != 1 || keys[0].KeyType != "OKP" || keys[0].Curve != "Ed25519" {
+		t.Fatalf("Expected one Ed25519 key, got %+v", keys)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(keys[0].X)
+	if !ed25519.PublicKey(x).Equal(edSigner.keys.Load().signing.verifyKey) {
+		t.Error("Expected the JWK to hold the Ed25519 public key")
+	}
+
+	if keys := NewProductionJWTSigner(testJWTSecret, "ai-styler").JWKS().Keys; keys == nil || len(keys) != 0 {
+		t.Errorf("Expected an empty key list without rotation, got %v", keys)
+	}
+}
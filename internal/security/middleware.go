@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,14 +25,19 @@ type SecurityConfig struct {
 	JWTSecret     string
 	JWTExpiration time.Duration
 
-	// CORS
-	CORSEnabled    bool
-	AllowedOrigins []string
-	AllowedMethods []string
-	AllowedHeaders []string
+	// CORS. A "*" origin allows any origin, but without credentials.
+	CORSEnabled      bool
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	CORSMaxAge       time.Duration
 
 	// Security headers
 	SecurityHeadersEnabled bool
+	HSTSMaxAge             time.Duration // 0 omits Strict-Transport-Security
+	HSTSIncludeSubdomains  bool
+	ContentSecurityPolicy  string // empty omits Content-Security-Policy
 
 	// Image scanning
 	ImageScanEnabled bool
@@ -52,17 +59,30 @@ func DefaultSecurityConfig() *SecurityConfig {
 		CORSEnabled:            true,
 		AllowedOrigins:         []string{"*"},
 		AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:         []string{"*"},
+		AllowedHeaders:         []string{"Authorization", "Content-Type", "Accept", "Accept-Language"},
+		CORSMaxAge:             24 * time.Hour,
 		SecurityHeadersEnabled: true,
+		HSTSMaxAge:             365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:  true,
+		ContentSecurityPolicy:  "default-src 'self'",
 		ImageScanEnabled:       true,
 		SignedURLEnabled:       true,
 		SignedURLExpiration:    24 * time.Hour,
 	}
 }
 
+// RateLimits are the limits enforced by RateLimitMiddleware
+type RateLimits struct {
+	Enabled bool
+	PerIP   int
+	PerUser int
+	Window  time.Duration
+}
+
 // SecurityMiddleware provides comprehensive security middleware
 type SecurityMiddleware struct {
 	config       *SecurityConfig
+	limitsMutex  sync.RWMutex
 	rateLimiter  RateLimiter
 	jwtSigner    JWTSigner
 	imageScanner ImageScanner
@@ -84,10 +104,35 @@ func NewSecurityMiddleware(config *SecurityConfig) *SecurityMiddleware {
 	}
 }
 
+// SetRateLimits replaces the rate limits while the server is running
+func (sm *SecurityMiddleware) SetRateLimits(limits RateLimits) {
+	sm.limitsMutex.Lock()
+	defer sm.limitsMutex.Unlock()
+
+	sm.config.RateLimitEnabled = limits.Enabled
+	sm.config.RateLimitPerIP = limits.PerIP
+	sm.config.RateLimitPerUser = limits.PerUser
+	sm.config.RateLimitWindow = limits.Window
+}
+
+// rateLimits returns the rate limits currently in force
+func (sm *SecurityMiddleware) rateLimits() RateLimits {
+	sm.limitsMutex.RLock()
+	defer sm.limitsMutex.RUnlock()
+
+	return RateLimits{
+		Enabled: sm.config.RateLimitEnabled,
+		PerIP:   sm.config.RateLimitPerIP,
+		PerUser: sm.config.RateLimitPerUser,
+		Window:  sm.config.RateLimitWindow,
+	}
+}
+
 // RateLimitMiddleware implements rate limiting per IP and user
 func (sm *SecurityMiddleware) RateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !sm.config.RateLimitEnabled {
+		limits := sm.rateLimits()
+		if !limits.Enabled {
 			c.Next()
 			return
 		}
@@ -97,11 +142,11 @@ func (sm *SecurityMiddleware) RateLimitMiddleware() gin.HandlerFunc {
 
 		// Rate limit by IP
 		ipKey := fmt.Sprintf("ip:%s", clientIP)
-		if !sm.rateLimiter.Allow(ipKey, sm.config.RateLimitPerIP, sm.config.RateLimitWindow) {
+		if !sm.rateLimiter.Allow(ipKey, limits.PerIP, limits.Window) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "rate_limit_exceeded",
 				"message":     "Too many requests from this IP",
-				"retry_after": sm.config.RateLimitWindow.Seconds(),
+				"retry_after": limits.Window.Seconds(),
 			})
 			c.Abort()
 			return
@@ -110,11 +155,11 @@ func (sm *SecurityMiddleware) RateLimitMiddleware() gin.HandlerFunc {
 		// Rate limit by user (if authenticated)
 		if userID, exists := c.Get("user_id"); exists {
 			userKey := fmt.Sprintf("user:%s", userID)
-			if !sm.rateLimiter.Allow(userKey, sm.config.RateLimitPerUser, sm.config.RateLimitWindow) {
+			if !sm.rateLimiter.Allow(userKey, limits.PerUser, limits.Window) {
 				c.JSON(http.StatusTooManyRequests, gin.H{
 					"error":       "rate_limit_exceeded",
 					"message":     "Too many requests from this user",
-					"retry_after": sm.config.RateLimitWindow.Seconds(),
+					"retry_after": limits.Window.Seconds(),
 				})
 				c.Abort()
 				return
@@ -129,6 +174,9 @@ func (sm *SecurityMiddleware) RateLimitMiddleware() gin.HandlerFunc {
 func (sm *SecurityMiddleware) JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			authHeader = websocketBearer(c.Request)
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "unauthorized",
@@ -182,6 +230,20 @@ func (sm *SecurityMiddleware) JWTAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// websocketBearer returns the access token of a WebSocket upgrade as an
+// Authorization header value. Browsers can't set headers on WebSockets and
+// offer the subprotocols "bearer, <token>" instead.
+func websocketBearer(r *http.Request) string {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return ""
+	}
+	protocols := strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",")
+	if len(protocols) != 2 || strings.TrimSpace(protocols[0]) != "bearer" {
+		return ""
+	}
+	return "Bearer " + strings.TrimSpace(protocols[1])
+}
+
 // CORSMiddleware implements CORS headers
 func (sm *SecurityMiddleware) CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -192,24 +254,25 @@ func (sm *SecurityMiddleware) CORSMiddleware() gin.HandlerFunc {
 
 		origin := c.GetHeader("Origin")
 		if origin != "" {
-			// Check if origin is allowed
-			allowed := false
-			for _, allowedOrigin := range sm.config.AllowedOrigins {
-				if allowedOrigin == "*" || allowedOrigin == origin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
+			// Listed origins are echoed back with credentials; the wildcard
+			// never is, since browsers would then send cookies to any site
+			c.Header("Vary", "Origin")
+			switch {
+			case containsString(sm.config.AllowedOrigins, origin):
 				c.Header("Access-Control-Allow-Origin", origin)
+				if sm.config.AllowCredentials {
+					c.Header("Access-Control-Allow-Credentials", "true")
+				}
+			case containsString(sm.config.AllowedOrigins, "*"):
+				c.Header("Access-Control-Allow-Origin", "*")
 			}
 		}
 
 		c.Header("Access-Control-Allow-Methods", strings.Join(sm.config.AllowedMethods, ", "))
 		c.Header("Access-Control-Allow-Headers", strings.Join(sm.config.AllowedHeaders, ", "))
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "86400")
+		if sm.config.CORSMaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(sm.config.CORSMaxAge.Seconds())))
+		}
 
 		// Handle preflight requests
 		if c.Request.Method == "OPTIONS" {
@@ -221,6 +284,15 @@ func (sm *SecurityMiddleware) CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // SecurityHeadersMiddleware adds security headers
 func (sm *SecurityMiddleware) SecurityHeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -234,8 +306,16 @@ func (sm *SecurityMiddleware) SecurityHeadersMiddleware() gin.HandlerFunc {
 		c.Header("X-Frame-Options", "DENY")
 		c.Header("X-XSS-Protection", "1; mode=block")
 		c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
-		c.Header("Content-Security-Policy", "default-src 'self'")
-		c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		if sm.config.ContentSecurityPolicy != "" {
+			c.Header("Content-Security-Policy", sm.config.ContentSecurityPolicy)
+		}
+		if sm.config.HSTSMaxAge > 0 {
+			hsts := "max-age=" + strconv.Itoa(int(sm.config.HSTSMaxAge.Seconds()))
+			if sm.config.HSTSIncludeSubdomains {
+				hsts += "; includeSubDomains"
+			}
+			c.Header("Strict-Transport-Security", hsts)
+		}
 
 		c.Next()
 	}
@@ -442,8 +522,9 @@ func (sm *SecurityMiddleware) VerifySignedURL(url string) (bool, error) {
 
 // GetRateLimitInfo returns rate limit information for a key
 func (sm *SecurityMiddleware) GetRateLimitInfo(key string) (remaining int, resetTime time.Time) {
-	remaining = sm.rateLimiter.GetRemaining(key, sm.config.RateLimitPerIP, sm.config.RateLimitWindow)
-	resetTime = time.Now().Add(sm.config.RateLimitWindow)
+	limits := sm.rateLimits()
+	remaining = sm.rateLimiter.GetRemaining(key, limits.PerIP, limits.Window)
+	resetTime = time.Now().Add(limits.Window)
 	return remaining, resetTime
 }
 
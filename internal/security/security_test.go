@@ -2,8 +2,12 @@ package security
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestBCryptHasher(t *testing.T) {
@@ -268,6 +272,80 @@ func TestSecurityMiddleware(t *testing.T) {
 	}
 }
 
+func serveSecurityMiddleware(handler gin.HandlerFunc, method, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(handler)
+	r.Any("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	config := DefaultSecurityConfig()
+	config.AllowedOrigins = []string{"https://app.example.com"}
+	config.AllowCredentials = true
+	cors := NewSecurityMiddleware(config).CORSMiddleware()
+
+	w := serveSecurityMiddleware(cors, http.MethodOptions, "https://app.example.com")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for preflight, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected listed origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials to be allowed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "86400" {
+		t.Errorf("Expected max age 86400, got %q", got)
+	}
+
+	w = serveSecurityMiddleware(cors, http.MethodGet, "https://evil.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS origin for unlisted origin, got %q", got)
+	}
+
+	// A wildcard never allows credentials
+	config.AllowedOrigins = []string{"*"}
+	w = serveSecurityMiddleware(NewSecurityMiddleware(config).CORSMiddleware(), http.MethodGet, "https://evil.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected wildcard origin, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials with wildcard origin, got %q", got)
+	}
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	config := DefaultSecurityConfig()
+	config.HSTSMaxAge = time.Hour
+	config.HSTSIncludeSubdomains = false
+	config.ContentSecurityPolicy = "default-src 'none'"
+
+	w := serveSecurityMiddleware(NewSecurityMiddleware(config).SecurityHeadersMiddleware(), http.MethodGet, "")
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("Expected HSTS max-age=3600, got %q", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy"); got != "default-src 'none'" {
+		t.Errorf("Expected configured CSP, got %q", got)
+	}
+
+	// Zero values leave the headers out
+	config.HSTSMaxAge = 0
+	config.ContentSecurityPolicy = ""
+	w = serveSecurityMiddleware(NewSecurityMiddleware(config).SecurityHeadersMiddleware(), http.MethodGet, "")
+	if w.Header().Get("Strict-Transport-Security") != "" || w.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("Expected HSTS and CSP to be omitted, got %v", w.Header())
+	}
+}
+
 func TestTLSConfig(t *testing.T) {
 	config := DefaultTLSConfig()
 
@@ -359,3 +437,23 @@ func TestContextHelpers(t *testing.T) {
 		t.Fatal("Should find correct scan result")
 	}
 }
+
+func TestWebsocketBearer(t *testing.T) {
+	tests := []struct {
+		upgrade, protocol, want string
+	}{
+		{"websocket", "bearer, abc.def", "Bearer abc.def"},
+		{"WebSocket", "bearer,abc.def", "Bearer abc.def"},
+		{"", "bearer, abc.def", ""},
+		{"websocket", "chat, abc.def", ""},
+		{"websocket", "bearer", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/activity", nil)
+		req.Header.Set("Upgrade", tt.upgrade)
+		req.Header.Set("Sec-WebSocket-Protocol", tt.protocol)
+		if got := websocketBearer(req); got != tt.want {
+			t.Errorf("Upgrade %q protocols %q: expected %q, got %q", tt.upgrade, tt.protocol, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// Limits of the Gemini settings
+const (
+	maxGeminiOutputTokens   = 65536
+	maxGeminiTimeoutSeconds = 600
+)
+
+// GeminiSettings are the provider parameters admins tune at runtime. An empty
+// model and a zero noise level or timeout keep the GEMINI_* configuration.
+type GeminiSettings struct {
+	Model           string  `json:"model"`
+	Temperature     float64 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens"`
+	NoiseLevel      float64 `json:"noiseLevel"`
+	TimeoutSeconds  int     `json:"timeoutSeconds"`
+}
+
+// UpdateGeminiSettingsRequest changes the given Gemini settings
+type UpdateGeminiSettingsRequest struct {
+	Model           *string  `json:"model"`
+	Temperature     *float64 `json:"temperature"`
+	MaxOutputTokens *int     `json:"maxOutputTokens"`
+	NoiseLevel      *float64 `json:"noiseLevel"`
+	TimeoutSeconds  *int     `json:"timeoutSeconds"`
+}
+
+// Gemini returns the Gemini part of the settings
+func (s Settings) Gemini() GeminiSettings {
+	return GeminiSettings{
+		Model:           s.GeminiModel,
+		Temperature:     s.GeminiTemperature,
+		MaxOutputTokens: s.GeminiMaxOutputTokens,
+		NoiseLevel:      s.GeminiNoiseLevel,
+		TimeoutSeconds:  int(s.GeminiTimeout / time.Second),
+	}
+}
+
+// UpdateGeminiSettings changes Gemini settings like UpdateSettings does, so
+// workers on every instance use them for their next provider call
+func (s *Service) UpdateGeminiSettings(ctx context.Context, adminID string, req UpdateGeminiSettingsRequest) (GeminiSettings, error) {
+	values := map[string]interface{}{}
+	if req.Model != nil {
+		values[KeyGeminiModel] = *req.Model
+	}
+	// Decimals are stored as strings
+	if req.Temperature != nil {
+		values[KeyGeminiTemperature] = strconv.FormatFloat(*req.Temperature, 'f', -1, 64)
+	}
+	if req.NoiseLevel != nil {
+		values[KeyGeminiNoiseLevel] = strconv.FormatFloat(*req.NoiseLevel, 'f', -1, 64)
+	}
+	if req.MaxOutputTokens != nil {
+		values[KeyGeminiMaxOutputTokens] = *req.MaxOutputTokens
+	}
+	if req.TimeoutSeconds != nil {
+		values[KeyGeminiTimeout] = *req.TimeoutSeconds
+	}
+	if len(values) == 0 {
+		return GeminiSettings{}, fmt.Errorf("%w: no settings to update", common.ErrValidation)
+	}
+
+	update := UpdateSettingsRequest{Settings: make(map[string]json.RawMessage, len(values))}
+	for key, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return GeminiSettings{}, err
+		}
+		update.Settings[key] = encoded
+	}
+	if _, err := s.UpdateSettings(ctx, adminID, update); err != nil {
+		return GeminiSettings{}, err
+	}
+
+	return s.Current().Gemini(), nil
+}
@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for system settings
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new settings handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetSettings handles GET /admin/settings
+func (h *Handler) GetSettings(c *gin.Context) {
+	settings, err := h.service.ListSettings(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /admin/settings
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetGeminiSettings handles GET /admin/settings/gemini
+func (h *Handler) GetGeminiSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Current().Gemini())
+}
+
+// UpdateGeminiSettings handles PUT /admin/settings/gemini
+func (h *Handler) UpdateGeminiSettings(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateGeminiSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	gemini, err := h.service.UpdateGeminiSettings(c.Request.Context(), fmt.Sprint(adminID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gemini)
+}
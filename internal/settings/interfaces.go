@@ -0,0 +1,20 @@
+package settings
+
+import "context"
+
+// Store defines the interface for system settings data operations
+type Store interface {
+	ListSettings(ctx context.Context) ([]Setting, error)
+
+	// UpdateSettings stores the raw values by key and audits the change
+	UpdateSettings(ctx context.Context, values map[string]string, updatedBy string) error
+}
+
+// ChangeNotifier announces setting changes to every instance
+type ChangeNotifier interface {
+	Publish(ctx context.Context) error
+
+	// Subscribe returns a channel receiving a value for each announced change.
+	// It is closed when ctx is done.
+	Subscribe(ctx context.Context) (<-chan struct{}, error)
+}
@@ -0,0 +1,119 @@
+package settings
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Setting value types, as stored in system_settings.type
+const (
+	TypeString  = "string"
+	TypeBoolean = "boolean"
+	TypeInteger = "integer"
+	TypeArray   = "array"
+)
+
+// Keys of the settings applied at runtime
+const (
+	KeyMaintenanceMode    = "maintenance_mode"
+	KeyRateLimitEnabled   = "rate_limit_enabled"
+	KeyRateLimitPerMinute = "rate_limit_per_minute"
+	KeyAPIRateLimit       = "api_rate_limit"
+	KeyMaxFileSize        = "max_file_size"
+	KeyConversionTimeout  = "conversion_timeout"
+
+	KeyWatermarkEnabled  = "watermark_enabled"
+	KeyWatermarkText     = "watermark_text"
+	KeyWatermarkPosition = "watermark_position"
+	KeyWatermarkOpacity  = "watermark_opacity"
+
+	KeyGeminiModel           = "gemini_model"
+	KeyGeminiTemperature     = "gemini_temperature"
+	KeyGeminiMaxOutputTokens = "gemini_max_output_tokens"
+	KeyGeminiNoiseLevel      = "gemini_noise_level"
+	KeyGeminiTimeout         = "gemini_timeout"
+)
+
+// WatermarkPositions are the accepted values of watermark_position
+var WatermarkPositions = []string{"top-left", "top-right", "bottom-left", "bottom-right", "center"}
+
+// ChangeChannel is the Redis channel instances announce setting changes on
+const ChangeChannel = "system_settings:changed"
+
+// Config represents configuration for the settings service
+type Config struct {
+	RefreshInterval time.Duration // settings are reloaded this often even when no change is announced
+}
+
+// DefaultConfig returns the default settings service configuration
+func DefaultConfig() Config {
+	return Config{
+		RefreshInterval: time.Minute,
+	}
+}
+
+// Setting is a system setting with its value decoded according to its type
+type Setting struct {
+	Key       string      `json:"key"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+	UpdatedBy *string     `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+
+	raw string // value as stored
+}
+
+// ListSettingsResponse represents the settings listed to admins
+type ListSettingsResponse struct {
+	Settings []Setting `json:"settings"`
+}
+
+// UpdateSettingsRequest changes settings by key. Values must match the
+// setting's type, e.g. {"maintenance_mode": true, "max_file_size": "20MB"}.
+type UpdateSettingsRequest struct {
+	Settings map[string]json.RawMessage `json:"settings" binding:"required"`
+}
+
+// Settings are the typed values of the settings applied at runtime
+type Settings struct {
+	MaintenanceMode    bool
+	RateLimitEnabled   bool
+	RateLimitPerMinute int   // requests per minute from one IP
+	APIRateLimit       int   // requests per minute from one signed-in user
+	MaxFileSize        int64 // largest accepted image upload, in bytes
+	ConversionTimeout  time.Duration
+
+	// Watermark overlaid on result images of plans without watermark_removal
+	WatermarkEnabled  bool
+	WatermarkText     string // drawn when no logo is configured
+	WatermarkPosition string // one of WatermarkPositions
+	WatermarkOpacity  int    // percent, 1-100
+
+	// Gemini generation parameters. An empty model, noise level or timeout
+	// keeps the GEMINI_* configuration.
+	GeminiModel           string
+	GeminiTemperature     float64 // 0-2
+	GeminiMaxOutputTokens int
+	GeminiNoiseLevel      float64       // preprocessing noise, 0-1
+	GeminiTimeout         time.Duration // per provider attempt
+}
+
+// DefaultSettings returns the values used until settings are loaded, and for
+// settings that are missing or invalid
+func DefaultSettings() Settings {
+	return Settings{
+		MaintenanceMode:    false,
+		RateLimitEnabled:   true,
+		RateLimitPerMinute: 60,
+		APIRateLimit:       1000,
+		MaxFileSize:        50 * 1024 * 1024,
+		ConversionTimeout:  5 * time.Minute,
+		WatermarkEnabled:   true,
+		WatermarkText:      "AI Styler",
+		WatermarkPosition:  "bottom-right",
+		WatermarkOpacity:   50,
+
+		GeminiTemperature:     0.4,
+		GeminiMaxOutputTokens: 32768,
+	}
+}
@@ -0,0 +1,56 @@
+package settings
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisNotifier announces setting changes over Redis pub/sub
+type RedisNotifier struct {
+	client *redis.Client
+}
+
+// NewRedisNotifier creates a new Redis change notifier
+func NewRedisNotifier(client *redis.Client) *RedisNotifier {
+	return &RedisNotifier{client: client}
+}
+
+// Publish announces that settings changed
+func (n *RedisNotifier) Publish(ctx context.Context) error {
+	return n.client.Publish(ctx, ChangeChannel, "changed").Err()
+}
+
+// Subscribe listens for announced changes until ctx is done
+func (n *RedisNotifier) Subscribe(ctx context.Context) (<-chan struct{}, error) {
+	pubsub := n.client.Subscribe(ctx, ChangeChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+				// Changes are coalesced, a reload picks up all of them
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
@@ -0,0 +1,17 @@
+package settings
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes mounts the settings routes on an admin-only router group
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	settings := router.Group("/settings")
+	{
+		settings.GET("", handler.GetSettings)    // GET /admin/settings
+		settings.PUT("", handler.UpdateSettings) // PUT /admin/settings
+
+		settings.GET("/gemini", handler.GetGeminiSettings)    // GET /admin/settings/gemini
+		settings.PUT("/gemini", handler.UpdateGeminiSettings) // PUT /admin/settings/gemini
+	}
+}
@@ -0,0 +1,428 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ai-styler/internal/common"
+)
+
+// Service serves system settings from a cache that is reloaded when an admin
+// changes a setting on any instance, and tells subscribers about changes
+type Service struct {
+	store    Store
+	notifier ChangeNotifier
+	config   Config
+
+	mu        sync.RWMutex
+	current   Settings
+	listeners []func(Settings)
+
+	// reloadMu keeps concurrent reloads from notifying subscribers out of order
+	reloadMu sync.Mutex
+}
+
+// NewService creates a new settings service. Settings have their default
+// values until Reload is called.
+func NewService(store Store, config Config) *Service {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultConfig().RefreshInterval
+	}
+
+	return &Service{store: store, config: config, current: DefaultSettings()}
+}
+
+// SetChangeNotifier announces changes to the other instances, so they reload
+// right away instead of at their next refresh
+func (s *Service) SetChangeNotifier(notifier ChangeNotifier) {
+	s.notifier = notifier
+}
+
+// Current returns the cached settings
+func (s *Service) Current() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// OnChange calls fn with the current settings and again whenever they change
+func (s *Service) OnChange(fn func(Settings)) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, fn)
+	current := s.current
+	s.mu.Unlock()
+
+	fn(current)
+}
+
+// Reload reads the settings from the store and notifies subscribers if they changed
+func (s *Service) Reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	stored, err := s.store.ListSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := DefaultSettings()
+	for _, setting := range stored {
+		if err := applySetting(&loaded, setting.Key, setting.raw); err != nil {
+			log.Printf("Ignoring invalid setting %s=%q: %v", setting.Key, setting.raw, err)
+		}
+	}
+
+	s.mu.Lock()
+	changed := loaded != s.current
+	s.current = loaded
+	listeners := append([]func(Settings){}, s.listeners...)
+	s.mu.Unlock()
+
+	if changed {
+		for _, fn := range listeners {
+			fn(loaded)
+		}
+	}
+	return nil
+}
+
+// Watch reloads the settings when a change is announced and every refresh
+// interval. It blocks until ctx is cancelled.
+func (s *Service) Watch(ctx context.Context) {
+	var changes <-chan struct{}
+	if s.notifier != nil {
+		subscribed, err := s.notifier.Subscribe(ctx)
+		if err != nil {
+			log.Printf("Failed to subscribe to setting changes, reloading every %v: %v", s.config.RefreshInterval, err)
+		} else {
+			changes = subscribed
+		}
+	}
+
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		if err := s.Reload(ctx); err != nil {
+			log.Printf("Failed to reload settings: %v", err)
+		}
+	}
+}
+
+// ListSettings returns every setting with its decoded value
+func (s *Service) ListSettings(ctx context.Context) (ListSettingsResponse, error) {
+	stored, err := s.store.ListSettings(ctx)
+	if err != nil {
+		return ListSettingsResponse{}, err
+	}
+
+	settings := make([]Setting, 0, len(stored))
+	for _, setting := range stored {
+		value, err := decodeValue(setting.Type, setting.raw)
+		if err != nil {
+			// Show what is stored so admins can fix it
+			value = setting.raw
+		}
+		setting.Value = value
+		settings = append(settings, setting)
+	}
+
+	return ListSettingsResponse{Settings: settings}, nil
+}
+
+// UpdateSettings changes settings and applies them on every instance
+func (s *Service) UpdateSettings(ctx context.Context, adminID string, req UpdateSettingsRequest) (ListSettingsResponse, error) {
+	if len(req.Settings) == 0 {
+		return ListSettingsResponse{}, fmt.Errorf("%w: no settings to update", common.ErrValidation)
+	}
+
+	stored, err := s.store.ListSettings(ctx)
+	if err != nil {
+		return ListSettingsResponse{}, err
+	}
+	types := make(map[string]string, len(stored))
+	for _, setting := range stored {
+		types[setting.Key] = setting.Type
+	}
+
+	values := make(map[string]string, len(req.Settings))
+	scratch := DefaultSettings()
+	for key, value := range req.Settings {
+		settingType, exists := types[key]
+		if !exists {
+			return ListSettingsResponse{}, fmt.Errorf("%w: unknown setting %s", common.ErrValidation, key)
+		}
+
+		raw, err := encodeValue(settingType, value)
+		if err != nil {
+			return ListSettingsResponse{}, fmt.Errorf("%w: %s must be a %s", common.ErrValidation, key, settingType)
+		}
+		if err := applySetting(&scratch, key, raw); err != nil {
+			return ListSettingsResponse{}, fmt.Errorf("%w: %s: %v", common.ErrValidation, key, err)
+		}
+		values[key] = raw
+	}
+
+	if err := s.store.UpdateSettings(ctx, values, adminID); err != nil {
+		return ListSettingsResponse{}, err
+	}
+
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("Failed to reload settings: %v", err)
+	}
+	if s.notifier != nil {
+		if err := s.notifier.Publish(ctx); err != nil {
+			// Other instances pick the change up at their next refresh
+			log.Printf("Failed to announce setting change: %v", err)
+		}
+	}
+
+	return s.ListSettings(ctx)
+}
+
+// applySetting parses a runtime setting into settings, leaving them unchanged
+// when the value is invalid. Other keys are ignored.
+func applySetting(settings *Settings, key, raw string) error {
+	switch key {
+	case KeyMaintenanceMode, KeyRateLimitEnabled, KeyWatermarkEnabled:
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		switch key {
+		case KeyMaintenanceMode:
+			settings.MaintenanceMode = enabled
+		case KeyRateLimitEnabled:
+			settings.RateLimitEnabled = enabled
+		default:
+			settings.WatermarkEnabled = enabled
+		}
+	case KeyRateLimitPerMinute, KeyAPIRateLimit, KeyConversionTimeout:
+		n, err := parsePositiveInt(raw)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case KeyRateLimitPerMinute:
+			settings.RateLimitPerMinute = n
+		case KeyAPIRateLimit:
+			settings.APIRateLimit = n
+		default:
+			settings.ConversionTimeout = time.Duration(n) * time.Second
+		}
+	case KeyMaxFileSize:
+		size, err := parseFileSize(raw)
+		if err != nil {
+			return err
+		}
+		settings.MaxFileSize = size
+	case KeyWatermarkText:
+		text := strings.TrimSpace(raw)
+		if utf8.RuneCountInString(text) > 64 {
+			return fmt.Errorf("must be at most 64 characters")
+		}
+		settings.WatermarkText = text
+	case KeyWatermarkPosition:
+		position := strings.ToLower(strings.TrimSpace(raw))
+		if !isWatermarkPosition(position) {
+			return fmt.Errorf("must be one of %s", strings.Join(WatermarkPositions, ", "))
+		}
+		settings.WatermarkPosition = position
+	case KeyWatermarkOpacity:
+		n, err := parsePositiveInt(raw)
+		if err != nil {
+			return err
+		}
+		if n > 100 {
+			return fmt.Errorf("must be a percentage from 1 to 100")
+		}
+		settings.WatermarkOpacity = n
+	case KeyGeminiModel:
+		model := strings.TrimSpace(raw)
+		if !isModelName(model) {
+			return fmt.Errorf("must be a model name such as gemini-2.5-flash-image")
+		}
+		settings.GeminiModel = model
+	case KeyGeminiTemperature:
+		temperature, err := parseFraction(raw, 2)
+		if err != nil {
+			return err
+		}
+		settings.GeminiTemperature = temperature
+	case KeyGeminiNoiseLevel:
+		if strings.TrimSpace(raw) == "" {
+			settings.GeminiNoiseLevel = 0
+			return nil
+		}
+		level, err := parseFraction(raw, 1)
+		if err != nil {
+			return err
+		}
+		settings.GeminiNoiseLevel = level
+	case KeyGeminiMaxOutputTokens:
+		n, err := parsePositiveInt(raw)
+		if err != nil {
+			return err
+		}
+		if n > maxGeminiOutputTokens {
+			return fmt.Errorf("must be at most %d", maxGeminiOutputTokens)
+		}
+		settings.GeminiMaxOutputTokens = n
+	case KeyGeminiTimeout:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 0 {
+			return fmt.Errorf("must be a number of seconds, or 0 for the configured timeout")
+		}
+		if n > maxGeminiTimeoutSeconds {
+			return fmt.Errorf("must be at most %d seconds", maxGeminiTimeoutSeconds)
+		}
+		settings.GeminiTimeout = time.Duration(n) * time.Second
+	}
+	return nil
+}
+
+// isModelName reports whether model is empty or looks like a provider model
+// name, which ends up in the request URL
+func isModelName(model string) bool {
+	if len(model) > 100 {
+		return false
+	}
+	for _, r := range model {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFraction parses a decimal number from 0 to max
+func parseFraction(raw string, max float64) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || math.IsNaN(value) {
+		return 0, fmt.Errorf("must be a number")
+	}
+	if value < 0 || value > max {
+		return 0, fmt.Errorf("must be from 0 to %g", max)
+	}
+	return value, nil
+}
+
+func isWatermarkPosition(position string) bool {
+	for _, p := range WatermarkPositions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeValue converts a stored value to its JSON representation
+func decodeValue(settingType, raw string) (interface{}, error) {
+	switch settingType {
+	case TypeBoolean:
+		return strconv.ParseBool(raw)
+	case TypeInteger:
+		return strconv.ParseInt(raw, 10, 64)
+	case TypeArray:
+		var values []interface{}
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	return raw, nil
+}
+
+// encodeValue converts a JSON value of the given type to its stored form
+func encodeValue(settingType string, value json.RawMessage) (string, error) {
+	switch settingType {
+	case TypeBoolean:
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case TypeInteger:
+		var n int64
+		if err := json.Unmarshal(value, &n); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	case TypeArray:
+		var values []interface{}
+		if err := json.Unmarshal(value, &values); err != nil || values == nil {
+			return "", fmt.Errorf("not an array")
+		}
+		encoded, err := json.Marshal(values)
+		return string(encoded), err
+	}
+
+	var str string
+	if err := json.Unmarshal(value, &str); err != nil {
+		return "", err
+	}
+	return str, nil
+}
+
+// parsePositiveInt parses a whole number greater than zero
+func parsePositiveInt(raw string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("must be a whole number")
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be greater than zero")
+	}
+	return n, nil
+}
+
+// parseFileSize parses sizes such as "50MB", "512KB" or a plain byte count
+func parseFileSize(raw string) (int64, error) {
+	size := strings.ToUpper(strings.TrimSpace(raw))
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSpace(strings.TrimSuffix(size, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0, fmt.Errorf("must be a size such as 50MB")
+	}
+	bytes := int64(value * float64(multiplier))
+	if bytes <= 0 {
+		return 0, fmt.Errorf("must be greater than zero")
+	}
+	return bytes, nil
+}
@@ -0,0 +1,221 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type mockStore struct {
+	settings map[string]Setting
+}
+
+func newMockStore() *mockStore {
+	store := &mockStore{settings: map[string]Setting{}}
+	for key, setting := range map[string][2]string{
+		KeyMaintenanceMode:    {TypeBoolean, "false"},
+		KeyRateLimitPerMinute: {TypeInteger, "60"},
+		KeyMaxFileSize:        {TypeString, "50MB"},
+		KeyConversionTimeout:  {TypeInteger, "300"},
+		KeyWatermarkPosition:  {TypeString, "bottom-right"},
+		KeyWatermarkOpacity:   {TypeInteger, "50"},
+		"allowed_file_types":  {TypeArray, `["jpg","png"]`},
+		KeyGeminiModel:        {TypeString, ""},
+		KeyGeminiTemperature:  {TypeString, "0.4"},
+		KeyGeminiNoiseLevel:   {TypeString, ""},
+		KeyGeminiTimeout:      {TypeInteger, "0"},
+
+		KeyGeminiMaxOutputTokens: {TypeInteger, "32768"},
+	} {
+		store.settings[key] = Setting{Key: key, Type: setting[0], raw: setting[1]}
+	}
+	return store
+}
+
+func (m *mockStore) ListSettings(ctx context.Context) ([]Setting, error) {
+	var settings []Setting
+	for _, setting := range m.settings {
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+func (m *mockStore) UpdateSettings(ctx context.Context, values map[string]string, updatedBy string) error {
+	for key, value := range values {
+		setting := m.settings[key]
+		setting.raw = value
+		setting.UpdatedBy = &updatedBy
+		m.settings[key] = setting
+	}
+	return nil
+}
+
+type mockNotifier struct {
+	published int
+}
+
+func (n *mockNotifier) Publish(ctx context.Context) error {
+	n.published++
+	return nil
+}
+
+func (n *mockNotifier) Subscribe(ctx context.Context) (<-chan struct{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestService_UpdateSettings(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	notifier := &mockNotifier{}
+	service := NewService(store, DefaultConfig())
+	service.SetChangeNotifier(notifier)
+
+	var received []Settings
+	service.OnChange(func(current Settings) { received = append(received, current) })
+	if len(received) != 1 || received[0] != DefaultSettings() {
+		t.Fatalf("Expected subscriber to get the default settings, got %+v", received)
+	}
+
+	_, err := service.UpdateSettings(ctx, "admin-1", UpdateSettingsRequest{Settings: map[string]json.RawMessage{
+		KeyMaintenanceMode:   json.RawMessage(`true`),
+		KeyMaxFileSize:       json.RawMessage(`"20MB"`),
+		KeyConversionTimeout: json.RawMessage(`120`),
+		KeyWatermarkPosition: json.RawMessage(`"top-left"`),
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	current := service.Current()
+	if !current.MaintenanceMode || current.MaxFileSize != 20*1024*1024 || current.ConversionTimeout != 2*time.Minute || current.WatermarkPosition != "top-left" {
+		t.Errorf("Expected updated settings to be applied, got %+v", current)
+	}
+	if len(received) != 2 || received[1] != current {
+		t.Errorf("Expected subscriber to be notified once of the change, got %d notifications", len(received))
+	}
+	if notifier.published != 1 {
+		t.Errorf("Expected change to be announced once, got %d", notifier.published)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"unknown setting", "no_such_setting", `true`},
+		{"wrong type", KeyMaintenanceMode, `"yes"`},
+		{"invalid size", KeyMaxFileSize, `"huge"`},
+		{"non-positive limit", KeyRateLimitPerMinute, `0`},
+		{"not an array", "allowed_file_types", `"jpg"`},
+		{"unknown watermark position", KeyWatermarkPosition, `"middle"`},
+		{"opacity above 100", KeyWatermarkOpacity, `150`},
+	}
+	for _, tt := range tests {
+		_, err := service.UpdateSettings(ctx, "admin-1", UpdateSettingsRequest{Settings: map[string]json.RawMessage{tt.key: json.RawMessage(tt.value)}})
+		if !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", tt.name, err)
+		}
+	}
+	if store.settings[KeyRateLimitPerMinute].raw != "60" {
+		t.Errorf("Expected rejected update not to be stored, got %q", store.settings[KeyRateLimitPerMinute].raw)
+	}
+}
+
+func TestService_ReloadKeepsDefaultsForInvalidValues(t *testing.T) {
+	store := newMockStore()
+	store.settings[KeyRateLimitPerMinute] = Setting{Key: KeyRateLimitPerMinute, Type: TypeInteger, raw: "lots"}
+	service := NewService(store, DefaultConfig())
+
+	if err := service.Reload(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := service.Current().RateLimitPerMinute; got != DefaultSettings().RateLimitPerMinute {
+		t.Errorf("Expected default rate limit for an invalid value, got %d", got)
+	}
+
+	list, err := service.ListSettings(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, setting := range list.Settings {
+		if setting.Key == "allowed_file_types" {
+			if values, ok := setting.Value.([]interface{}); !ok || len(values) != 2 {
+				t.Errorf("Expected array setting to be decoded, got %#v", setting.Value)
+			}
+		}
+	}
+}
+
+func TestService_UpdateGeminiSettings(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	service := NewService(store, DefaultConfig())
+	if err := service.Reload(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	model, temperature, timeout := "gemini-2.5-flash-image", 0.0, 90
+	gemini, err := service.UpdateGeminiSettings(ctx, "admin-1", UpdateGeminiSettingsRequest{
+		Model:          &model,
+		Temperature:    &temperature,
+		TimeoutSeconds: &timeout,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := GeminiSettings{Model: model, Temperature: 0, MaxOutputTokens: 32768, TimeoutSeconds: 90}
+	if gemini != want {
+		t.Errorf("Expected %+v, got %+v", want, gemini)
+	}
+	if current := service.Current(); current.GeminiModel != model || current.GeminiTimeout != 90*time.Second {
+		t.Errorf("Expected updated Gemini settings to be applied, got %+v", current)
+	}
+	if store.settings[KeyGeminiTemperature].raw != "0" {
+		t.Errorf("Expected temperature to be stored as a string, got %q", store.settings[KeyGeminiTemperature].raw)
+	}
+
+	tooHot, badModel, tooMany, noisy, negative := 2.5, "gemini/../x", maxGeminiOutputTokens+1, 1.5, -1
+	for name, req := range map[string]UpdateGeminiSettingsRequest{
+		"nothing to update":      {},
+		"temperature above 2":    {Temperature: &tooHot},
+		"model with a slash":     {Model: &badModel},
+		"too many output tokens": {MaxOutputTokens: &tooMany},
+		"noise level above 1":    {NoiseLevel: &noisy},
+		"negative timeout":       {TimeoutSeconds: &negative},
+	} {
+		if _, err := service.UpdateGeminiSettings(ctx, "admin-1", req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+	if service.Current().GeminiModel != model {
+		t.Errorf("Expected rejected updates not to be applied, got model %q", service.Current().GeminiModel)
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+	}{
+		{"50MB", 50 * 1024 * 1024},
+		{"512 kb", 512 * 1024},
+		{"1.5GB", 3 * 512 * 1024 * 1024},
+		{"2048", 2048},
+	}
+	for _, tt := range tests {
+		got, err := parseFileSize(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("parseFileSize(%q): expected %d, got %d (%v)", tt.raw, tt.want, got, err)
+		}
+	}
+
+	for _, raw := range []string{"", "MB", "-1MB", "big"} {
+		if _, err := parseFileSize(raw); err == nil {
+			t.Errorf("parseFileSize(%q): expected an error", raw)
+		}
+	}
+}
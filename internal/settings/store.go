@@ -0,0 +1,84 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"ai-styler/internal/common"
+)
+
+// DBStore implements Store on top of the system_settings table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// ListSettings returns every setting ordered by key
+func (s *DBStore) ListSettings(ctx context.Context) ([]Setting, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key, type, value, updated_by, updated_at
+		FROM system_settings
+		ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []Setting
+	for rows.Next() {
+		var setting Setting
+		var updatedBy sql.NullString
+		if err := rows.Scan(&setting.Key, &setting.Type, &setting.raw, &updatedBy, &setting.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		if updatedBy.Valid {
+			setting.UpdatedBy = &updatedBy.String
+		}
+		settings = append(settings, setting)
+	}
+	return settings, rows.Err()
+}
+
+// UpdateSettings stores the raw values by key and records the change in the
+// audit log, in a single transaction
+func (s *DBStore) UpdateSettings(ctx context.Context, values map[string]string, updatedBy string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, value := range values {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE system_settings
+			SET value = $2, updated_by = $3, updated_at = NOW()
+			WHERE key = $1`, key, value, updatedBy)
+		if err != nil {
+			return fmt.Errorf("failed to update setting %s: %w", key, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return fmt.Errorf("setting %s: %w", key, common.ErrNotFound)
+		}
+	}
+
+	metadataJSON, err := json.Marshal(map[string]interface{}{"settings": values})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', 'update', 'system_settings', $2)`, updatedBy, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit settings: %w", err)
+	}
+	return nil
+}
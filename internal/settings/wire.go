@@ -0,0 +1,14 @@
+package settings
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+)
+
+// WireSettingsService creates a settings service with all dependencies
+func WireSettingsService(db *sql.DB, cfg *config.Config) *Service {
+	return NewService(NewDBStore(db), Config{
+		RefreshInterval: cfg.SystemSettings.RefreshInterval,
+	})
+}
@@ -1,15 +1,23 @@
 package share
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Handler provides HTTP handlers for share operations
 type Handler struct {
-	service *Service
+	service       *Service
+	publicBaseURL string
 }
 
 // NewHandler creates a new share handler
@@ -17,6 +25,11 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
+// SetPublicBaseURL sets the scheme and host absolute share links are built on
+func (h *Handler) SetPublicBaseURL(baseURL string) {
+	h.publicBaseURL = strings.TrimRight(baseURL, "/")
+}
+
 // RegisterRoutes registers share routes
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	share := router.Group("/share")
@@ -39,20 +52,29 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		// Cleanup expired links (admin endpoint)
 		share.POST("/cleanup", h.CleanupExpiredLinks)
 	}
+
+	vendorShare := router.Group("/vendor/share")
+	{
+		// Bulk-create campaign share links (requires vendor)
+		vendorShare.POST("/bulk", h.CreateBulkSharedLinks)
+
+		// Campaign analytics (requires vendor)
+		vendorShare.GET("/campaigns/:tag", h.GetCampaignStats)
+	}
 }
 
 // CreateSharedLink handles creating a new shared link
 func (h *Handler) CreateSharedLink(c *gin.Context) {
 	var req CreateShareRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
@@ -63,7 +85,7 @@ func (h *Handler) CreateSharedLink(c *gin.Context) {
 
 	response, err := h.service.CreateSharedLink(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -74,14 +96,14 @@ func (h *Handler) CreateSharedLink(c *gin.Context) {
 func (h *Handler) AccessSharedLink(c *gin.Context) {
 	token := c.Param("token")
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "share token is required"})
+		common.RespondError(c, http.StatusBadRequest, "share token is required")
 		return
 	}
 
 	// Get access type from query parameter
 	accessType := c.DefaultQuery("type", AccessTypeView)
 	if accessType != AccessTypeView && accessType != AccessTypeDownload {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid access type"})
+		common.RespondError(c, http.StatusBadRequest, "invalid access type")
 		return
 	}
 
@@ -95,7 +117,7 @@ func (h *Handler) AccessSharedLink(c *gin.Context) {
 
 	response, err := h.service.AccessSharedLink(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		common.RespondError(c, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -117,20 +139,20 @@ func (h *Handler) AccessSharedLink(c *gin.Context) {
 func (h *Handler) DeactivateSharedLink(c *gin.Context) {
 	shareID := c.Param("id")
 	if shareID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "share ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "share ID is required")
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	err := h.service.DeactivateSharedLink(c.Request.Context(), shareID, userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -142,7 +164,7 @@ func (h *Handler) ListUserSharedLinks(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
@@ -162,7 +184,7 @@ func (h *Handler) ListUserSharedLinks(c *gin.Context) {
 
 	links, err := h.service.ListUserSharedLinks(c.Request.Context(), userID.(string), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list shared links"})
+		common.RespondError(c, http.StatusInternalServerError, "failed to list shared links")
 		return
 	}
 
@@ -179,7 +201,7 @@ func (h *Handler) GetSharedLinkStats(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
@@ -188,7 +210,7 @@ func (h *Handler) GetSharedLinkStats(c *gin.Context) {
 
 	stats, err := h.service.GetSharedLinkStats(c.Request.Context(), userID.(string), conversionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get statistics"})
+		common.RespondError(c, http.StatusInternalServerError, "failed to get statistics")
 		return
 	}
 
@@ -201,13 +223,13 @@ func (h *Handler) CleanupExpiredLinks(c *gin.Context) {
 	// For now, we'll just check if user is authenticated
 	_, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	count, err := h.service.CleanupExpiredLinks(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cleanup expired links"})
+		common.RespondError(c, http.StatusInternalServerError, "failed to cleanup expired links")
 		return
 	}
 
@@ -216,3 +238,108 @@ func (h *Handler) CleanupExpiredLinks(c *gin.Context) {
 		"count":   count,
 	})
 }
+
+// CreateBulkSharedLinks handles bulk creation of share links for a vendor campaign.
+// The result is returned as a CSV download unless format=json is requested.
+func (h *Handler) CreateBulkSharedLinks(c *gin.Context) {
+	var req BulkShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	response, err := h.service.CreateBulkSharedLinks(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		if errors.Is(err, ErrNotVendor) {
+			common.RespondError(c, http.StatusForbidden, err.Error())
+			return
+		}
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Make public URLs absolute so they can be used in marketing material
+	baseURL := h.baseURL(c)
+	for i := range response.Results {
+		if response.Results[i].PublicURL != "" {
+			response.Results[i].PublicURL = baseURL + response.Results[i].PublicURL
+		}
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusCreated, response)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="share-links-%s.csv"`, response.CampaignTag))
+	c.Header("X-Campaign-Id", response.CampaignID)
+	c.Status(http.StatusCreated)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"item_type", "item_id", "share_id", "url", "expires_at", "error"})
+	for _, result := range response.Results {
+		_ = w.Write([]string{
+			result.ItemType,
+			result.ItemID,
+			result.ShareID,
+			result.PublicURL,
+			result.ExpiresAt.UTC().Format(time.RFC3339),
+			result.Error,
+		})
+	}
+	w.Flush()
+}
+
+// GetCampaignStats handles getting aggregated analytics for a vendor campaign
+func (h *Handler) GetCampaignStats(c *gin.Context) {
+	tag := c.Param("tag")
+	if tag == "" {
+		common.RespondError(c, http.StatusBadRequest, "campaign tag is required")
+		return
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	stats, err := h.service.GetCampaignStats(c.Request.Context(), userID.(string), tag)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotVendor):
+			common.RespondError(c, http.StatusForbidden, err.Error())
+		case errors.Is(err, ErrCampaignNotFound):
+			common.RespondErr(c, http.StatusNotFound, err)
+		default:
+			common.RespondError(c, http.StatusInternalServerError, "failed to get campaign statistics")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// baseURL returns the configured public base URL, falling back to the scheme
+// and host of the request. X-Forwarded-* headers are ignored since any client
+// can set them to point links at a host of its choosing.
+func (h *Handler) baseURL(c *gin.Context) string {
+	if h.publicBaseURL != "" {
+		return h.publicBaseURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
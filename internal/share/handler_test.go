@@ -0,0 +1,30 @@
+package share
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandler_BaseURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "http://api.example.com/api/vendor/share/bulk", nil)
+		c.Request.Header.Set("X-Forwarded-Host", "attacker.example")
+		c.Request.Header.Set("X-Forwarded-Proto", "javascript")
+		return c
+	}
+
+	handler := NewHandler(nil)
+	if got := handler.baseURL(newContext()); got != "http://api.example.com" {
+		t.Errorf("Expected the request host without forwarded headers, got %q", got)
+	}
+
+	handler.SetPublicBaseURL("https://styler.example/")
+	if got := handler.baseURL(newContext()); got != "https://styler.example" {
+		t.Errorf("Expected the configured base URL, got %q", got)
+	}
+}
@@ -3,6 +3,8 @@ package share
 import (
 	"context"
 	"time"
+
+	"ai-styler/internal/domain"
 )
 
 // Store defines the interface for share data operations
@@ -15,6 +17,12 @@ type Store interface {
 	DeactivateSharedLink(ctx context.Context, shareID, userID string) error
 	ListUserSharedLinks(ctx context.Context, userID string, limit, offset int) ([]ActiveSharedLink, error)
 
+	// Campaign operations
+	GetVendorIDByUserID(ctx context.Context, userID string) (string, error)
+	UpsertShareCampaign(ctx context.Context, vendorID, userID, tag string, expiresAt time.Time) (ShareCampaign, error)
+	CreateCampaignSharedLink(ctx context.Context, campaignID, userID string, item BulkShareItem, shareToken, signedURL string, expiresAt time.Time, maxAccessCount *int) (string, error)
+	GetShareCampaignStats(ctx context.Context, vendorID, tag string) (CampaignStats, error)
+
 	// Access log operations
 	LogSharedLinkAccess(ctx context.Context, sharedLinkID string, req AccessShareRequest, success bool, errorMessage string) error
 
@@ -38,19 +46,7 @@ type ConversionService interface {
 }
 
 // ConversionResponse represents a conversion response from the conversion service
-type ConversionResponse struct {
-	ID               string     `json:"id"`
-	UserID           string     `json:"userId"`
-	UserImageID      string     `json:"userImageId"`
-	ClothImageID     string     `json:"clothImageId"`
-	Status           string     `json:"status"`
-	ResultImageID    *string    `json:"resultImageId,omitempty"`
-	ErrorMessage     *string    `json:"errorMessage,omitempty"`
-	ProcessingTimeMs *int       `json:"processingTimeMs,omitempty"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
-	CompletedAt      *time.Time `json:"completedAt,omitempty"`
-}
+type ConversionResponse = domain.ConversionDetails
 
 // ImageService defines the interface for image operations
 type ImageService interface {
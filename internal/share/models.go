@@ -1,6 +1,7 @@
 package share
 
 import (
+	"errors"
 	"time"
 )
 
@@ -8,6 +9,8 @@ import (
 type SharedLink struct {
 	ID             string    `json:"id"`
 	ConversionID   string    `json:"conversionId"`
+	ImageID        string    `json:"imageId,omitempty"`
+	CampaignID     string    `json:"campaignId,omitempty"`
 	UserID         string    `json:"userId"`
 	ShareToken     string    `json:"shareToken"`
 	SignedURL      string    `json:"signedUrl"`
@@ -82,6 +85,8 @@ type SharedLinkStats struct {
 type ActiveSharedLink struct {
 	ID                  string    `json:"id"`
 	ConversionID        string    `json:"conversionId"`
+	ImageID             string    `json:"imageId,omitempty"`
+	CampaignID          string    `json:"campaignId,omitempty"`
 	UserID              string    `json:"userId"`
 	ShareToken          string    `json:"shareToken"`
 	SignedURL           string    `json:"signedUrl"`
@@ -140,6 +145,80 @@ type PopularSharedLink struct {
 	ConversionStatus string    `json:"conversionStatus"`
 }
 
+// BulkShareItem identifies a single conversion or vendor image to share
+type BulkShareItem struct {
+	ConversionID string `json:"conversionId,omitempty"`
+	ImageID      string `json:"imageId,omitempty"`
+}
+
+// BulkShareRequest represents a request to create share links for a vendor campaign
+type BulkShareRequest struct {
+	Items          []BulkShareItem `json:"items" binding:"required,min=1"`
+	CampaignTag    string          `json:"campaignTag" binding:"required"`
+	ExpiryHours    int             `json:"expiryHours"`
+	MaxAccessCount *int            `json:"maxAccessCount,omitempty"`
+}
+
+// BulkShareResult represents the outcome for one item of a bulk share request
+type BulkShareResult struct {
+	ItemType   string    `json:"itemType"`
+	ItemID     string    `json:"itemId"`
+	ShareID    string    `json:"shareId,omitempty"`
+	ShareToken string    `json:"shareToken,omitempty"`
+	PublicURL  string    `json:"publicUrl,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// BulkShareResponse represents the response for a bulk share request
+type BulkShareResponse struct {
+	CampaignID  string            `json:"campaignId"`
+	CampaignTag string            `json:"campaignTag"`
+	ExpiresAt   time.Time         `json:"expiresAt"`
+	Created     int               `json:"created"`
+	Failed      int               `json:"failed"`
+	Results     []BulkShareResult `json:"results"`
+}
+
+// ShareCampaign groups share links created in bulk by a vendor
+type ShareCampaign struct {
+	ID        string    `json:"id"`
+	VendorID  string    `json:"vendorId"`
+	UserID    string    `json:"userId"`
+	Tag       string    `json:"tag"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CampaignLinkStats represents access statistics for one link of a campaign
+type CampaignLinkStats struct {
+	ShareID        string     `json:"shareId"`
+	ConversionID   string     `json:"conversionId,omitempty"`
+	ImageID        string     `json:"imageId,omitempty"`
+	AccessCount    int        `json:"accessCount"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+}
+
+// CampaignStats represents aggregated analytics for a share campaign
+type CampaignStats struct {
+	CampaignID        string              `json:"campaignId"`
+	CampaignTag       string              `json:"campaignTag"`
+	ExpiresAt         time.Time           `json:"expiresAt"`
+	TotalLinks        int                 `json:"totalLinks"`
+	ActiveLinks       int                 `json:"activeLinks"`
+	ExpiredLinks      int                 `json:"expiredLinks"`
+	TotalAccessCount  int64               `json:"totalAccessCount"`
+	UniqueIPAddresses int64               `json:"uniqueIpAddresses"`
+	TopLinks          []CampaignLinkStats `json:"topLinks"`
+}
+
+// Share service errors
+var (
+	ErrNotVendor        = errors.New("only vendors can manage share campaigns")
+	ErrCampaignNotFound = errors.New("share campaign not found")
+)
+
 // Share service constants
 const (
 	MinExpiryMinutes     = 1
@@ -150,6 +229,16 @@ const (
 	AccessTypeDownload = "download"
 
 	ShareTokenLength = 32 // Base64 encoded, so actual token is longer
+
+	// Campaign links are meant for marketing material and live much longer
+	DefaultCampaignExpiryHours = 7 * 24
+	MaxCampaignExpiryHours     = 90 * 24
+	MaxBulkShareItems          = 500
+	MaxCampaignTagLength       = 64
+	CampaignTopLinksLimit      = 20
+
+	ItemTypeConversion = "conversion"
+	ItemTypeImage      = "image"
 )
 
 // Helper function for creating int pointers
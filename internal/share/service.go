@@ -4,10 +4,16 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
+// campaignTagPattern restricts campaign tags to values that are safe in URLs and file names
+var campaignTagPattern = regexp.MustCompile(fmt.Sprintf(`^[A-Za-z0-9._-]{1,%d}$`, MaxCampaignTagLength))
+
 // Service provides share management functionality
 type Service struct {
 	store             Store
@@ -150,20 +156,27 @@ func (s *Service) AccessSharedLink(ctx context.Context, req AccessShareRequest)
 		}, nil
 	}
 
-	// Get conversion details
-	conversion, err := s.conversionService.GetConversion(ctx, sharedLink.ConversionID, sharedLink.UserID)
-	if err != nil {
-		s.store.LogSharedLinkAccess(ctx, sharedLink.ID, req, false, "Failed to get conversion details")
-		return AccessShareResponse{
-			Success:      false,
-			ErrorMessage: "Failed to get conversion details",
-		}, nil
+	// Campaign links may point directly at a vendor image
+	resultImageID := sharedLink.ImageID
+	if sharedLink.ConversionID != "" {
+		// Get conversion details
+		conversion, err := s.conversionService.GetConversion(ctx, sharedLink.ConversionID, sharedLink.UserID)
+		if err != nil {
+			s.store.LogSharedLinkAccess(ctx, sharedLink.ID, req, false, "Failed to get conversion details")
+			return AccessShareResponse{
+				Success:      false,
+				ErrorMessage: "Failed to get conversion details",
+			}, nil
+		}
+		if conversion.ResultImageID != nil {
+			resultImageID = *conversion.ResultImageID
+		}
 	}
 
 	// Get result image details
 	var resultImageURL string
-	if conversion.ResultImageID != nil {
-		image, err := s.imageService.GetImage(ctx, *conversion.ResultImageID)
+	if resultImageID != "" {
+		image, err := s.imageService.GetImage(ctx, resultImageID)
 		if err == nil {
 			resultImageURL = image.OriginalURL
 		}
@@ -259,6 +272,157 @@ func (s *Service) CleanupExpiredLinks(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// CreateBulkSharedLinks creates share links for a vendor campaign.
+// Items that fail validation are reported in the results instead of failing the whole batch.
+func (s *Service) CreateBulkSharedLinks(ctx context.Context, userID string, req BulkShareRequest) (BulkShareResponse, error) {
+	tag := strings.TrimSpace(req.CampaignTag)
+	if !campaignTagPattern.MatchString(tag) {
+		return BulkShareResponse{}, fmt.Errorf("campaign tag must be 1-%d characters of letters, digits, '.', '_' or '-'", MaxCampaignTagLength)
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > MaxBulkShareItems {
+		return BulkShareResponse{}, fmt.Errorf("items must contain between 1 and %d entries", MaxBulkShareItems)
+	}
+
+	if req.ExpiryHours == 0 {
+		req.ExpiryHours = DefaultCampaignExpiryHours
+	}
+	if req.ExpiryHours < 1 || req.ExpiryHours > MaxCampaignExpiryHours {
+		return BulkShareResponse{}, fmt.Errorf("expiry time must be between 1 and %d hours", MaxCampaignExpiryHours)
+	}
+
+	if req.MaxAccessCount != nil && *req.MaxAccessCount < 1 {
+		return BulkShareResponse{}, fmt.Errorf("max access count must be positive")
+	}
+
+	vendorID, err := s.store.GetVendorIDByUserID(ctx, userID)
+	if err != nil {
+		return BulkShareResponse{}, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.ExpiryHours) * time.Hour)
+	campaign, err := s.store.UpsertShareCampaign(ctx, vendorID, userID, tag, expiresAt)
+	if err != nil {
+		return BulkShareResponse{}, fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	response := BulkShareResponse{
+		CampaignID:  campaign.ID,
+		CampaignTag: campaign.Tag,
+		ExpiresAt:   campaign.ExpiresAt,
+		Results:     make([]BulkShareResult, 0, len(req.Items)),
+	}
+
+	for _, item := range req.Items {
+		result, err := s.createCampaignLink(ctx, userID, vendorID, campaign.ID, item, expiresAt, req.MaxAccessCount)
+		if err != nil {
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			response.Created++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// createCampaignLink validates ownership of a single item and creates its share link
+func (s *Service) createCampaignLink(ctx context.Context, userID, vendorID, campaignID string, item BulkShareItem, expiresAt time.Time, maxAccessCount *int) (BulkShareResult, error) {
+	result := BulkShareResult{ExpiresAt: expiresAt}
+
+	var imageID string
+	switch {
+	case item.ConversionID != "" && item.ImageID != "":
+		result.ItemType = ItemTypeConversion
+		result.ItemID = item.ConversionID
+		return result, fmt.Errorf("item must reference either a conversion or an image, not both")
+
+	case item.ConversionID != "":
+		result.ItemType = ItemTypeConversion
+		result.ItemID = item.ConversionID
+
+		conversion, err := s.conversionService.GetConversion(ctx, item.ConversionID, userID)
+		if err != nil {
+			return result, fmt.Errorf("failed to get conversion: %w", err)
+		}
+		if conversion.Status != "completed" {
+			return result, fmt.Errorf("conversion must be completed to share")
+		}
+		if conversion.ResultImageID == nil {
+			return result, fmt.Errorf("conversion has no result image")
+		}
+		imageID = *conversion.ResultImageID
+
+	case item.ImageID != "":
+		result.ItemType = ItemTypeImage
+		result.ItemID = item.ImageID
+
+		image, err := s.imageService.GetImage(ctx, item.ImageID)
+		if err != nil {
+			return result, fmt.Errorf("failed to get image: %w", err)
+		}
+		if image.VendorID != vendorID && image.UserID != userID {
+			return result, fmt.Errorf("image does not belong to vendor")
+		}
+		imageID = item.ImageID
+
+	default:
+		return result, fmt.Errorf("item must reference a conversion or an image")
+	}
+
+	shareToken, err := s.generateShareToken()
+	if err != nil {
+		return result, fmt.Errorf("failed to generate share token: %w", err)
+	}
+
+	signedURL, err := s.imageService.GenerateSignedURL(ctx, imageID, AccessTypeView, int64(time.Until(expiresAt).Seconds()))
+	if err != nil {
+		return result, fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+
+	shareID, err := s.store.CreateCampaignSharedLink(ctx, campaignID, userID, item, shareToken, signedURL, expiresAt, maxAccessCount)
+	if err != nil {
+		return result, fmt.Errorf("failed to create shared link: %w", err)
+	}
+
+	// Log audit
+	if err := s.auditLogger.LogShareCreated(ctx, userID, result.ItemID, shareID); err != nil {
+		// Log but don't fail the request
+		fmt.Printf("Failed to log share creation audit: %v\n", err)
+	}
+
+	// Record metrics
+	if err := s.metrics.RecordShareCreated(ctx, userID, result.ItemID); err != nil {
+		// Log but don't fail the request
+		fmt.Printf("Failed to record share creation metrics: %v\n", err)
+	}
+
+	result.ShareID = shareID
+	result.ShareToken = shareToken
+	result.PublicURL = fmt.Sprintf("/api/share/%s", shareToken)
+
+	return result, nil
+}
+
+// GetCampaignStats returns aggregated analytics for one of the vendor's campaigns
+func (s *Service) GetCampaignStats(ctx context.Context, userID, tag string) (CampaignStats, error) {
+	vendorID, err := s.store.GetVendorIDByUserID(ctx, userID)
+	if err != nil {
+		return CampaignStats{}, err
+	}
+
+	stats, err := s.store.GetShareCampaignStats(ctx, vendorID, tag)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return CampaignStats{}, err
+		}
+		return CampaignStats{}, fmt.Errorf("failed to get campaign stats: %w", err)
+	}
+
+	return stats, nil
+}
+
 // generateShareToken generates a cryptographically secure random token
 func (s *Service) generateShareToken() (string, error) {
 	// Generate 32 random bytes
@@ -1,6 +1,8 @@
 package share
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -202,3 +204,100 @@ func TestConstants(t *testing.T) {
 		t.Errorf("Expected AccessTypeDownload='download', got '%s'", AccessTypeDownload)
 	}
 }
+
+// campaignStore implements the campaign parts of Store for testing
+type campaignStore struct {
+	Store
+	vendorID string
+	links    []BulkShareItem
+}
+
+func (m *campaignStore) GetVendorIDByUserID(ctx context.Context, userID string) (string, error) {
+	if m.vendorID == "" {
+		return "", ErrNotVendor
+	}
+	return m.vendorID, nil
+}
+
+func (m *campaignStore) UpsertShareCampaign(ctx context.Context, vendorID, userID, tag string, expiresAt time.Time) (ShareCampaign, error) {
+	return ShareCampaign{ID: "campaign-1", VendorID: vendorID, UserID: userID, Tag: tag, ExpiresAt: expiresAt}, nil
+}
+
+func (m *campaignStore) CreateCampaignSharedLink(ctx context.Context, campaignID, userID string, item BulkShareItem, shareToken, signedURL string, expiresAt time.Time, maxAccessCount *int) (string, error) {
+	m.links = append(m.links, item)
+	return "share-" + item.ConversionID + item.ImageID, nil
+}
+
+func TestService_CreateBulkSharedLinks(t *testing.T) {
+	store := &campaignStore{vendorID: "vendor-id"}
+	service := NewService(store, NewMockConversionService(), NewMockImageService(),
+		NewMockNotificationService(), NewMockAuditLogger(), NewMockMetricsCollector())
+
+	req := BulkShareRequest{
+		CampaignTag: "summer-2025",
+		Items: []BulkShareItem{
+			{ImageID: "img-1"},
+			{ConversionID: "conv-1"},
+			{},
+			{ConversionID: "conv-2", ImageID: "img-2"},
+		},
+	}
+
+	resp, err := service.CreateBulkSharedLinks(context.Background(), "user-id", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.CampaignID != "campaign-1" || resp.CampaignTag != "summer-2025" {
+		t.Errorf("Unexpected campaign %s/%s", resp.CampaignID, resp.CampaignTag)
+	}
+	if resp.Created != 2 || resp.Failed != 2 {
+		t.Errorf("Expected 2 created and 2 failed, got %d and %d", resp.Created, resp.Failed)
+	}
+	if len(store.links) != 2 {
+		t.Errorf("Expected 2 stored links, got %d", len(store.links))
+	}
+	if resp.Results[0].ItemType != ItemTypeImage || resp.Results[0].PublicURL == "" {
+		t.Errorf("Expected image link with public URL, got %+v", resp.Results[0])
+	}
+	if resp.Results[1].ItemType != ItemTypeConversion || resp.Results[1].ShareID != "share-conv-1" {
+		t.Errorf("Expected conversion link, got %+v", resp.Results[1])
+	}
+
+	expectedExpiry := time.Now().Add(DefaultCampaignExpiryHours * time.Hour)
+	if resp.ExpiresAt.Sub(expectedExpiry).Abs() > time.Minute {
+		t.Errorf("Expected default expiry around %v, got %v", expectedExpiry, resp.ExpiresAt)
+	}
+}
+
+func TestService_CreateBulkSharedLinks_Validation(t *testing.T) {
+	service := NewService(&campaignStore{vendorID: "vendor-id"}, NewMockConversionService(), NewMockImageService(),
+		NewMockNotificationService(), NewMockAuditLogger(), NewMockMetricsCollector())
+	items := []BulkShareItem{{ImageID: "img-1"}}
+
+	tests := []struct {
+		name string
+		req  BulkShareRequest
+	}{
+		{"missing tag", BulkShareRequest{Items: items}},
+		{"invalid tag", BulkShareRequest{CampaignTag: "summer sale/2025", Items: items}},
+		{"no items", BulkShareRequest{CampaignTag: "summer"}},
+		{"too many items", BulkShareRequest{CampaignTag: "summer", Items: make([]BulkShareItem, MaxBulkShareItems+1)}},
+		{"expiry too long", BulkShareRequest{CampaignTag: "summer", Items: items, ExpiryHours: MaxCampaignExpiryHours + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateBulkSharedLinks(context.Background(), "user-id", tt.req); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+
+	notVendor := NewService(&campaignStore{}, NewMockConversionService(), NewMockImageService(),
+		NewMockNotificationService(), NewMockAuditLogger(), NewMockMetricsCollector())
+	_, err := notVendor.CreateBulkSharedLinks(context.Background(), "user-id", BulkShareRequest{CampaignTag: "summer", Items: items})
+	if !errors.Is(err, ErrNotVendor) {
+		t.Errorf("Expected ErrNotVendor, got %v", err)
+	}
+}
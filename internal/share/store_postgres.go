@@ -43,14 +43,14 @@ func (s *postgresStore) CreateSharedLink(ctx context.Context, conversionID, user
 // GetSharedLink retrieves a shared link by ID
 func (s *postgresStore) GetSharedLink(ctx context.Context, shareID string) (SharedLink, error) {
 	query := `
-		SELECT id, conversion_id, user_id, share_token, signed_url, expires_at,
+		SELECT id, COALESCE(conversion_id::TEXT, ''), COALESCE(image_id::TEXT, ''), user_id, share_token, signed_url, expires_at,
 		       max_access_count, access_count, is_active, created_at, updated_at
 		FROM shared_links 
 		WHERE id = $1`
 
 	var link SharedLink
 	err := s.db.QueryRowContext(ctx, query, shareID).Scan(
-		&link.ID, &link.ConversionID, &link.UserID, &link.ShareToken, &link.SignedURL,
+		&link.ID, &link.ConversionID, &link.ImageID, &link.UserID, &link.ShareToken, &link.SignedURL,
 		&link.ExpiresAt, &link.MaxAccessCount, &link.AccessCount, &link.IsActive,
 		&link.CreatedAt, &link.UpdatedAt,
 	)
@@ -67,14 +67,14 @@ func (s *postgresStore) GetSharedLink(ctx context.Context, shareID string) (Shar
 // GetSharedLinkByToken retrieves a shared link by token
 func (s *postgresStore) GetSharedLinkByToken(ctx context.Context, shareToken string) (ActiveSharedLink, error) {
 	query := `
-		SELECT id, conversion_id, user_id, share_token, signed_url, expires_at,
+		SELECT id, COALESCE(conversion_id::TEXT, ''), COALESCE(image_id::TEXT, ''), user_id, share_token, signed_url, expires_at,
 		       max_access_count, access_count, is_active, created_at, updated_at
 		FROM shared_links 
 		WHERE share_token = $1`
 
 	var link ActiveSharedLink
 	err := s.db.QueryRowContext(ctx, query, shareToken).Scan(
-		&link.ID, &link.ConversionID, &link.UserID, &link.ShareToken, &link.SignedURL,
+		&link.ID, &link.ConversionID, &link.ImageID, &link.UserID, &link.ShareToken, &link.SignedURL,
 		&link.ExpiresAt, &link.MaxAccessCount, &link.AccessCount, &link.IsActive,
 		&link.CreatedAt, &link.UpdatedAt,
 	)
@@ -157,7 +157,7 @@ func (s *postgresStore) DeactivateSharedLink(ctx context.Context, shareID, userI
 // ListUserSharedLinks lists user's shared links
 func (s *postgresStore) ListUserSharedLinks(ctx context.Context, userID string, limit, offset int) ([]ActiveSharedLink, error) {
 	query := `
-		SELECT id, conversion_id, user_id, share_token, signed_url, expires_at,
+		SELECT id, COALESCE(conversion_id::TEXT, ''), COALESCE(image_id::TEXT, ''), user_id, share_token, signed_url, expires_at,
 		       max_access_count, access_count, is_active, created_at, updated_at
 		FROM shared_links 
 		WHERE user_id = $1
@@ -174,7 +174,7 @@ func (s *postgresStore) ListUserSharedLinks(ctx context.Context, userID string,
 	for rows.Next() {
 		var link ActiveSharedLink
 		err := rows.Scan(
-			&link.ID, &link.ConversionID, &link.UserID, &link.ShareToken, &link.SignedURL,
+			&link.ID, &link.ConversionID, &link.ImageID, &link.UserID, &link.ShareToken, &link.SignedURL,
 			&link.ExpiresAt, &link.MaxAccessCount, &link.AccessCount, &link.IsActive,
 			&link.CreatedAt, &link.UpdatedAt,
 		)
@@ -352,3 +352,126 @@ func (s *postgresStore) GetPopularSharedLinks(ctx context.Context, limit int) ([
 
 	return links, nil
 }
+
+// GetVendorIDByUserID resolves the vendor owned by a user
+func (s *postgresStore) GetVendorIDByUserID(ctx context.Context, userID string) (string, error) {
+	query := `SELECT id FROM vendors WHERE user_id = $1`
+
+	var vendorID string
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&vendorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotVendor
+		}
+		return "", fmt.Errorf("failed to get vendor: %w", err)
+	}
+
+	return vendorID, nil
+}
+
+// UpsertShareCampaign creates a campaign or extends the expiry of an existing one with the same tag
+func (s *postgresStore) UpsertShareCampaign(ctx context.Context, vendorID, userID, tag string, expiresAt time.Time) (ShareCampaign, error) {
+	query := `
+		INSERT INTO share_campaigns (vendor_id, user_id, tag, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (vendor_id, tag) DO UPDATE
+		SET expires_at = GREATEST(share_campaigns.expires_at, EXCLUDED.expires_at)
+		RETURNING id, vendor_id, user_id, tag, expires_at, created_at, updated_at
+	`
+
+	var campaign ShareCampaign
+	err := s.db.QueryRowContext(ctx, query, vendorID, userID, tag, expiresAt).Scan(
+		&campaign.ID, &campaign.VendorID, &campaign.UserID, &campaign.Tag,
+		&campaign.ExpiresAt, &campaign.CreatedAt, &campaign.UpdatedAt,
+	)
+	if err != nil {
+		return ShareCampaign{}, fmt.Errorf("failed to upsert share campaign: %w", err)
+	}
+
+	return campaign, nil
+}
+
+// CreateCampaignSharedLink creates a shared link for a conversion or image that belongs to a campaign
+func (s *postgresStore) CreateCampaignSharedLink(ctx context.Context, campaignID, userID string, item BulkShareItem, shareToken, signedURL string, expiresAt time.Time, maxAccessCount *int) (string, error) {
+	query := `
+		INSERT INTO shared_links (campaign_id, conversion_id, image_id, user_id, share_token, signed_url, expires_at, max_access_count)
+		VALUES ($1, NULLIF($2, '')::UUID, NULLIF($3, '')::UUID, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	var shareID string
+	err := s.db.QueryRowContext(ctx, query, campaignID, item.ConversionID, item.ImageID, userID, shareToken, signedURL, expiresAt, maxAccessCount).Scan(&shareID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create campaign shared link: %w", err)
+	}
+
+	return shareID, nil
+}
+
+// GetShareCampaignStats aggregates access analytics for a vendor's campaign
+func (s *postgresStore) GetShareCampaignStats(ctx context.Context, vendorID, tag string) (CampaignStats, error) {
+	var stats CampaignStats
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, tag, expires_at FROM share_campaigns WHERE vendor_id = $1 AND tag = $2`,
+		vendorID, tag,
+	).Scan(&stats.CampaignID, &stats.CampaignTag, &stats.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CampaignStats{}, ErrCampaignNotFound
+		}
+		return CampaignStats{}, fmt.Errorf("failed to get share campaign: %w", err)
+	}
+
+	summaryQuery := `
+		SELECT
+			COUNT(DISTINCT sl.id)::INTEGER as total_links,
+			COUNT(DISTINCT CASE WHEN sl.is_active = true AND sl.expires_at > NOW() THEN sl.id END)::INTEGER as active_links,
+			COUNT(DISTINCT CASE WHEN sl.expires_at <= NOW() THEN sl.id END)::INTEGER as expired_links,
+			COALESCE((SELECT SUM(access_count) FROM shared_links WHERE campaign_id = $1), 0) as total_access_count,
+			COUNT(DISTINCT sla.ip_address) as unique_ip_addresses
+		FROM shared_links sl
+		LEFT JOIN shared_link_access_logs sla ON sl.id = sla.shared_link_id AND sla.success = true
+		WHERE sl.campaign_id = $1
+	`
+	err = s.db.QueryRowContext(ctx, summaryQuery, stats.CampaignID).Scan(
+		&stats.TotalLinks, &stats.ActiveLinks, &stats.ExpiredLinks,
+		&stats.TotalAccessCount, &stats.UniqueIPAddresses,
+	)
+	if err != nil {
+		return CampaignStats{}, fmt.Errorf("failed to get campaign stats: %w", err)
+	}
+
+	linksQuery := `
+		SELECT
+			sl.id, COALESCE(sl.conversion_id::TEXT, ''), COALESCE(sl.image_id::TEXT, ''), sl.access_count,
+			(SELECT MAX(sla.created_at) FROM shared_link_access_logs sla WHERE sla.shared_link_id = sl.id AND sla.success = true)
+		FROM shared_links sl
+		WHERE sl.campaign_id = $1
+		ORDER BY sl.access_count DESC, sl.created_at ASC
+		LIMIT $2
+	`
+	rows, err := s.db.QueryContext(ctx, linksQuery, stats.CampaignID, CampaignTopLinksLimit)
+	if err != nil {
+		return CampaignStats{}, fmt.Errorf("failed to get campaign links: %w", err)
+	}
+	defer rows.Close()
+
+	stats.TopLinks = []CampaignLinkStats{}
+	for rows.Next() {
+		var link CampaignLinkStats
+		var lastAccessedAt sql.NullTime
+		if err := rows.Scan(&link.ShareID, &link.ConversionID, &link.ImageID, &link.AccessCount, &lastAccessedAt); err != nil {
+			return CampaignStats{}, fmt.Errorf("failed to scan campaign link: %w", err)
+		}
+		if lastAccessedAt.Valid {
+			link.LastAccessedAt = &lastAccessedAt.Time
+		}
+		stats.TopLinks = append(stats.TopLinks, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return CampaignStats{}, fmt.Errorf("error iterating campaign links: %w", err)
+	}
+
+	return stats, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"ai-styler/internal/domain"
 )
 
 // WireShareService creates a share service with all dependencies
@@ -42,7 +44,7 @@ func NewMockConversionService() *MockConversionService {
 }
 
 func (m *MockConversionService) GetConversion(ctx context.Context, conversionID, userID string) (ConversionResponse, error) {
-	return ConversionResponse{
+	return ConversionResponse{Conversion: domain.Conversion{
 		ID:            conversionID,
 		UserID:        userID,
 		UserImageID:   "user-image-id",
@@ -51,7 +53,7 @@ func (m *MockConversionService) GetConversion(ctx context.Context, conversionID,
 		ResultImageID: stringPtr("result-image-id"),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
-	}, nil
+	}}, nil
 }
 
 func (m *MockConversionService) ValidateConversionOwnership(ctx context.Context, conversionID, userID string) error {
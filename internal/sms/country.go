@@ -0,0 +1,71 @@
+package sms
+
+import (
+	"strings"
+)
+
+// HomeCallingCode is the country calling code of the home country, whose
+// numbers go to the primary provider unless routed explicitly
+const HomeCallingCode = "98"
+
+// AnyCountry routes the numbers of every foreign country without a route of
+// its own
+const AnyCountry = "*"
+
+// CountryProvider sends each code through the provider routed to the
+// country calling code of the phone number, e.g. Twilio for international
+// numbers, and through the home provider otherwise
+type CountryProvider struct {
+	home   Provider
+	routes map[string]NamedProvider // by calling code without "+", or AnyCountry
+}
+
+// NewCountryProvider creates a provider routing numbers by calling code
+func NewCountryProvider(home Provider, routes map[string]NamedProvider) *CountryProvider {
+	return &CountryProvider{home: home, routes: routes}
+}
+
+func (c *CountryProvider) Send(code string, phone string) error {
+	_, err := c.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code through the number's provider. Routed
+// providers that don't report message IDs return a receipt with just their name.
+func (c *CountryProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	route, ok := c.route(phone)
+	if !ok {
+		return sendWithReceipt(c.home, code, phone)
+	}
+	receipt, err := sendWithReceipt(route.Provider, code, phone)
+	if err == nil && receipt.Provider == "" {
+		receipt.Provider = route.Name
+	}
+	return receipt, err
+}
+
+// route returns the route of the longest calling code the number starts
+// with, or of AnyCountry for foreign numbers. Numbers without a leading "+"
+// are local.
+func (c *CountryProvider) route(phone string) (NamedProvider, bool) {
+	digits, international := strings.CutPrefix(phone, "+")
+	if !international {
+		return NamedProvider{}, false
+	}
+	// Calling codes are one to three digits long
+	for length := min(3, len(digits)); length > 0; length-- {
+		if route, ok := c.routes[digits[:length]]; ok {
+			return route, true
+		}
+	}
+	if strings.HasPrefix(digits, HomeCallingCode) {
+		return NamedProvider{}, false
+	}
+	route, ok := c.routes[AnyCountry]
+	return route, ok
+}
+
+// IsMock reports whether the home provider is a mock
+func (c *CountryProvider) IsMock() bool {
+	return c.home.IsMock()
+}
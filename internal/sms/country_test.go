@@ -0,0 +1,60 @@
+package sms
+
+import (
+	"testing"
+)
+
+func TestCountryProvider_Send(t *testing.T) {
+	home, twilio, uk := &stubProvider{}, &receiptProvider{receipt: Receipt{Provider: ProviderTwilio, MessageID: "SM1"}}, &stubProvider{}
+	provider := NewCountryProvider(home, map[string]NamedProvider{
+		"44":       {Name: "uk", Provider: uk},
+		AnyCountry: {Name: ProviderTwilio, Provider: twilio},
+	})
+
+	tests := []struct {
+		phone    string
+		provider string
+	}{
+		{"+989121234567", ""},
+		{"09121234567", ""},
+		{"+447700900123", "uk"},
+		{"+15005550006", ProviderTwilio},
+	}
+	for _, tt := range tests {
+		receipt, err := provider.SendWithReceipt("123456", tt.phone)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", tt.phone, err)
+		}
+		if receipt.Provider != tt.provider {
+			t.Errorf("Expected %s sent via %q, got %+v", tt.phone, tt.provider, receipt)
+		}
+	}
+	if home.calls != 2 || uk.calls != 1 || twilio.calls != 1 {
+		t.Errorf("Expected 2 home, 1 UK and 1 Twilio sends, got %d, %d and %d", home.calls, uk.calls, twilio.calls)
+	}
+}
+
+func TestCountryProvider_HomeRoute(t *testing.T) {
+	home, kavenegar := &stubProvider{}, &stubProvider{}
+	provider := NewCountryProvider(home, map[string]NamedProvider{HomeCallingCode: {Name: ProviderKavenegar, Provider: kavenegar}})
+
+	if err := provider.Send("123456", "+989121234567"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Foreign numbers without a route stay home
+	if err := provider.Send("123456", "+15005550006"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if kavenegar.calls != 1 || home.calls != 1 {
+		t.Errorf("Expected one send each, got %d routed and %d home", kavenegar.calls, home.calls)
+	}
+}
+
+func TestIsCallingCode(t *testing.T) {
+	tests := map[string]bool{"1": true, "98": true, "971": true, AnyCountry: true, "": false, "1234": false, "4a": false}
+	for code, expected := range tests {
+		if got := isCallingCode(code); got != expected {
+			t.Errorf("isCallingCode(%q) = %v, want %v", code, got, expected)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package sms
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Delivery statuses of a sent message
+const (
+	DeliveryStatusSent      = "sent" // accepted by the provider, no final report yet
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+// recordTimeout bounds recording an OTP delivery, Provider.Send has no context
+const recordTimeout = 5 * time.Second
+
+// OTPDelivery is an OTP message accepted by a provider
+type OTPDelivery struct {
+	Provider      string
+	MessageID     string
+	CarrierPrefix string // leading digits of the phone number identifying the mobile operator
+}
+
+// DeliveryReport is a provider's report on the delivery of one message
+type DeliveryReport struct {
+	MessageID string
+	Status    string // DeliveryStatusDelivered, DeliveryStatusFailed, or DeliveryStatusSent while still pending
+	Detail    string // the provider's own status
+}
+
+// DeliveryReportSink applies delivery reports to the records of sent
+// messages. It reports whether the message was one of its own.
+type DeliveryReportSink interface {
+	ApplyDeliveryReport(ctx context.Context, provider string, report DeliveryReport) (bool, error)
+}
+
+// CarrierStats summarizes the OTP deliveries of one provider and carrier
+type CarrierStats struct {
+	Provider           string  `json:"provider"`
+	CarrierPrefix      string  `json:"carrierPrefix"`
+	Sent               int64   `json:"sent"`
+	Delivered          int64   `json:"delivered"`
+	Failed             int64   `json:"failed"`
+	Pending            int64   `json:"pending"`
+	FailureRate        float64 `json:"failureRate"` // failed share of the reported deliveries
+	AvgDeliverySeconds float64 `json:"avgDeliverySeconds"`
+}
+
+// DeliveryStore records OTP deliveries, applies their delivery reports and
+// summarizes them per carrier
+type DeliveryStore interface {
+	DeliveryReportSink
+	RecordOTPDelivery(ctx context.Context, delivery OTPDelivery) error
+	GetCarrierStats(ctx context.Context, since time.Time) ([]CarrierStats, error)
+}
+
+// TrackingProvider records every OTP sent through the wrapped provider whose
+// receipt carries a message ID, so its delivery report can be matched later
+type TrackingProvider struct {
+	provider Provider
+	store    DeliveryStore
+}
+
+// NewTrackingProvider wraps provider, recording its deliveries in store
+func NewTrackingProvider(provider Provider, store DeliveryStore) *TrackingProvider {
+	return &TrackingProvider{provider: provider, store: store}
+}
+
+func (t *TrackingProvider) Send(code string, phone string) error {
+	receipt, err := sendWithReceipt(t.provider, code, phone)
+	if err != nil {
+		return err
+	}
+	if receipt.MessageID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	delivery := OTPDelivery{Provider: receipt.Provider, MessageID: receipt.MessageID, CarrierPrefix: carrierPrefix(phone)}
+	if err := t.store.RecordOTPDelivery(ctx, delivery); err != nil {
+		// The code was sent, a missing record only loses its delivery report
+		log.Printf("Failed to record OTP delivery %s/%s: %v", receipt.Provider, receipt.MessageID, err)
+	}
+	return nil
+}
+
+func (t *TrackingProvider) IsMock() bool {
+	return t.provider.IsMock()
+}
+
+// carrierPrefix returns the operator prefix of a phone number, e.g. 0912 for
+// +989121234567, or "" when the number is too short to tell
+func carrierPrefix(phone string) string {
+	local := strings.TrimPrefix(phone, "+")
+	if strings.HasPrefix(local, "98") {
+		local = "0" + local[2:]
+	}
+	if len(local) < 4 {
+		return ""
+	}
+	return local[:4]
+}
+
+// dbDeliveryStore implements DeliveryStore on top of the otp_deliveries table
+type dbDeliveryStore struct {
+	db *sql.DB
+}
+
+// NewDBDeliveryStore creates a new database-backed OTP delivery store
+func NewDBDeliveryStore(db *sql.DB) DeliveryStore {
+	return &dbDeliveryStore{db: db}
+}
+
+// RecordOTPDelivery stores a sent OTP message
+func (s *dbDeliveryStore) RecordOTPDelivery(ctx context.Context, delivery OTPDelivery) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO otp_deliveries (provider, provider_message_id, carrier_prefix)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (provider, provider_message_id) DO NOTHING`,
+		delivery.Provider, delivery.MessageID, delivery.CarrierPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record OTP delivery: %w", err)
+	}
+	return nil
+}
+
+// ApplyDeliveryReport updates the OTP delivery the report is about. Reports
+// never move a delivered or failed message back to sent.
+func (s *dbDeliveryStore) ApplyDeliveryReport(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE otp_deliveries
+		SET status = CASE WHEN $3::text = 'sent' THEN status ELSE $3::text END,
+		    status_detail = $4,
+		    delivered_at = CASE WHEN $3::text = 'delivered' THEN COALESCE(delivered_at, NOW()) ELSE delivered_at END,
+		    reported_at = NOW()
+		WHERE provider = $1 AND provider_message_id = $2`,
+		provider, report.MessageID, report.Status, report.Detail,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply OTP delivery report: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetCarrierStats summarizes the OTP deliveries sent since the cutoff,
+// worst failure rate first
+func (s *dbDeliveryStore) GetCarrierStats(ctx context.Context, since time.Time) ([]CarrierStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider, COALESCE(carrier_prefix, ''),
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'delivered'),
+		       COUNT(*) FILTER (WHERE status = 'failed'),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM delivered_at - sent_at)), 0)
+		FROM otp_deliveries
+		WHERE sent_at >= $1
+		GROUP BY provider, carrier_prefix`,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get carrier stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]CarrierStats, 0)
+	for rows.Next() {
+		var row CarrierStats
+		if err := rows.Scan(&row.Provider, &row.CarrierPrefix, &row.Sent, &row.Delivered, &row.Failed, &row.AvgDeliverySeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan carrier stats: %w", err)
+		}
+		row.Pending = row.Sent - row.Delivered - row.Failed
+		if reported := row.Delivered + row.Failed; reported > 0 {
+			row.FailureRate = float64(row.Failed) / float64(reported)
+		}
+		stats = append(stats, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortCarrierStats(stats)
+	return stats, nil
+}
+
+// sortCarrierStats orders stats by failure rate, then by volume
+func sortCarrierStats(stats []CarrierStats) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].FailureRate != stats[j].FailureRate {
+			return stats[i].FailureRate > stats[j].FailureRate
+		}
+		return stats[i].Sent > stats[j].Sent
+	})
+}
@@ -0,0 +1,114 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultProviderTimeout is how long the fallback chain waits for one provider
+const DefaultProviderTimeout = 10 * time.Second
+
+// ErrProviderTimeout is returned when a provider does not answer in time
+var ErrProviderTimeout = errors.New("provider timed out")
+
+// NamedProvider is a provider in a fallback chain
+type NamedProvider struct {
+	Name     string
+	Provider Provider
+}
+
+// FallbackProvider delivers a code through the first provider in the chain
+// that succeeds, moving on when a provider fails or does not answer within
+// the timeout
+type FallbackProvider struct {
+	providers []NamedProvider
+	timeout   time.Duration
+}
+
+// NewFallbackProvider creates a fallback chain tried in the given order
+func NewFallbackProvider(timeout time.Duration, providers ...NamedProvider) *FallbackProvider {
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+	return &FallbackProvider{providers: providers, timeout: timeout}
+}
+
+func (f *FallbackProvider) Send(code string, phone string) error {
+	_, err := f.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code through the chain and returns the receipt of
+// the provider that accepted it. Providers that don't report message IDs
+// return a receipt with just their name.
+func (f *FallbackProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	var errs []error
+	for i, p := range f.providers {
+		receipt, err := f.send(p.Provider, code, phone)
+		if err == nil {
+			if i > 0 {
+				log.Printf("OTP delivered to %s via fallback provider %s", maskPhone(phone), p.Name)
+			}
+			if receipt.Provider == "" {
+				receipt.Provider = p.Name
+			}
+			return receipt, nil
+		}
+
+		log.Printf("OTP delivery via %s failed for %s: %v", p.Name, maskPhone(phone), err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	if len(errs) == 0 {
+		return Receipt{}, errors.New("no OTP providers configured")
+	}
+	return Receipt{}, fmt.Errorf("all OTP providers failed: %w", errors.Join(errs...))
+}
+
+// send calls a provider, giving up after the timeout. A provider that times
+// out keeps running in the background and may still deliver the same code.
+func (f *FallbackProvider) send(provider Provider, code, phone string) (Receipt, error) {
+	type result struct {
+		receipt Receipt
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := sendWithReceipt(provider, code, phone)
+		done <- result{receipt, err}
+	}()
+
+	timer := time.NewTimer(f.timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.receipt, r.err
+	case <-timer.C:
+		return Receipt{}, ErrProviderTimeout
+	}
+}
+
+// sendWithReceipt sends through provider, returning an empty receipt when the
+// provider doesn't report message IDs
+func sendWithReceipt(provider Provider, code, phone string) (Receipt, error) {
+	if sender, ok := provider.(ReceiptSender); ok {
+		return sender.SendWithReceipt(code, phone)
+	}
+	return Receipt{}, provider.Send(code, phone)
+}
+
+// IsMock reports whether the primary provider is a mock
+func (f *FallbackProvider) IsMock() bool {
+	return len(f.providers) > 0 && f.providers[0].Provider.IsMock()
+}
+
+// maskPhone hides all but the last four digits of a phone number for logs
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return "****"
+	}
+	return "****" + phone[len(phone)-4:]
+}
@@ -0,0 +1,142 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type stubProvider struct {
+	err   error
+	delay time.Duration
+	calls int
+	mock  bool
+}
+
+func (s *stubProvider) Send(code string, phone string) error {
+	s.calls++
+	time.Sleep(s.delay)
+	return s.err
+}
+
+func (s *stubProvider) IsMock() bool {
+	return s.mock
+}
+
+type stubChats map[string]int64
+
+func (c stubChats) ChatIDForPhone(ctx context.Context, phone string) (int64, error) {
+	if chatID, ok := c[phone]; ok {
+		return chatID, nil
+	}
+	return 0, ErrNoLinkedChat
+}
+
+func TestFallbackProvider_Send(t *testing.T) {
+	t.Run("primary succeeds", func(t *testing.T) {
+		primary, secondary := &stubProvider{}, &stubProvider{}
+		chain := NewFallbackProvider(time.Second, NamedProvider{"primary", primary}, NamedProvider{"secondary", secondary})
+
+		if err := chain.Send("123456", "+989123456789"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if secondary.calls != 0 {
+			t.Errorf("Expected secondary provider not to be called, got %d calls", secondary.calls)
+		}
+	})
+
+	t.Run("falls back on failure and timeout", func(t *testing.T) {
+		failing := &stubProvider{err: errors.New("gateway down")}
+		slow := &stubProvider{delay: 200 * time.Millisecond}
+		last := &stubProvider{}
+		chain := NewFallbackProvider(50*time.Millisecond, NamedProvider{"sms", failing}, NamedProvider{"voice", slow}, NamedProvider{"telegram", last})
+
+		if err := chain.Send("123456", "+989123456789"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if failing.calls != 1 || last.calls != 1 {
+			t.Errorf("Expected every provider to be tried once, got %d and %d calls", failing.calls, last.calls)
+		}
+	})
+
+	t.Run("all providers fail", func(t *testing.T) {
+		chain := NewFallbackProvider(time.Second,
+			NamedProvider{"sms", &stubProvider{err: errors.New("gateway down")}},
+			NamedProvider{"telegram", &stubProvider{err: ErrNoLinkedChat}},
+		)
+
+		err := chain.Send("123456", "+989123456789")
+		if !errors.Is(err, ErrNoLinkedChat) {
+			t.Errorf("Expected the provider errors to be returned, got %v", err)
+		}
+	})
+}
+
+func TestFallbackProvider_IsMock(t *testing.T) {
+	if !NewFallbackProvider(0, NamedProvider{"mock", &stubProvider{mock: true}}, NamedProvider{"real", &stubProvider{}}).IsMock() {
+		t.Error("Expected chain with a mock primary to be a mock")
+	}
+	if NewFallbackProvider(0, NamedProvider{"real", &stubProvider{}}, NamedProvider{"mock", &stubProvider{mock: true}}).IsMock() {
+		t.Error("Expected chain with a real primary not to be a mock")
+	}
+}
+
+func TestTelegramProvider_Send(t *testing.T) {
+	var chatID int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottest-token/sendMessage" {
+			t.Errorf("Expected sendMessage path, got %s", r.URL.Path)
+		}
+		var req struct {
+			ChatID int64 `json:"chat_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		chatID = req.ChatID
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	provider := NewTelegramProvider("test-token", stubChats{"+989123456789": 4242})
+	provider.BaseURL = server.URL
+
+	if err := provider.Send("123456", "+989123456789"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if chatID != 4242 {
+		t.Errorf("Expected message to the linked chat 4242, got %d", chatID)
+	}
+
+	if err := provider.Send("123456", "+989000000000"); !errors.Is(err, ErrNoLinkedChat) {
+		t.Errorf("Expected ErrNoLinkedChat for an unlinked phone, got %v", err)
+	}
+}
+
+func TestKavenegarProvider_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/test-key/verify/lookup.json" {
+			t.Errorf("Expected lookup path, got %s", r.URL.Path)
+		}
+		if query.Get("receptor") != "09123456789" || query.Get("token") != "123456" {
+			t.Errorf("Expected local receptor and code, got %s", r.URL.RawQuery)
+		}
+		if query.Get("type") != "call" {
+			t.Errorf("Expected a voice call, got type %q", query.Get("type"))
+		}
+		w.Write([]byte(`{"return":{"status":200,"message":"تایید شد"},"entries":[]}`))
+	}))
+	defer server.Close()
+
+	provider := NewKavenegarProvider("test-key", "verify", true)
+	provider.BaseURL = server.URL
+
+	if err := provider.Send("123456", "+989123456789"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
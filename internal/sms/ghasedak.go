@@ -0,0 +1,110 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GhasedakProvider sends codes through the Ghasedak verification API, either
+// as an SMS or, with Voice set, as a voice call reading the code
+type GhasedakProvider struct {
+	APIKey     string
+	Template   string
+	Voice      bool
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type GhasedakResponse struct {
+	Result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Items []int64 `json:"items"`
+}
+
+func NewGhasedakProvider(apiKey, template string, voice bool) *GhasedakProvider {
+	return &GhasedakProvider{
+		APIKey:   apiKey,
+		Template: template,
+		Voice:    voice,
+		BaseURL:  "https://api.ghasedak.me/v2",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (g *GhasedakProvider) Send(code string, phone string) error {
+	_, err := g.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code and returns the message ID assigned by Ghasedak
+func (g *GhasedakProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	// Ghasedak expects the local format, e.g. 09123456789
+	receptor := strings.TrimPrefix(phone, "+")
+	if strings.HasPrefix(receptor, "98") {
+		receptor = "0" + receptor[2:]
+	}
+
+	form := url.Values{}
+	form.Set("receptor", receptor)
+	form.Set("template", g.Template)
+	form.Set("param1", code)
+	form.Set("type", "1")
+	if g.Voice {
+		form.Set("type", "2")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.BaseURL+"/verification/send/simple", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to create Ghasedak request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("apikey", g.APIKey)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to send Ghasedak request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var gResp GhasedakResponse
+	if err := json.Unmarshal(bodyBytes, &gResp); err != nil {
+		return Receipt{}, fmt.Errorf("failed to decode Ghasedak response (status %d): %w", resp.StatusCode, err)
+	}
+	if gResp.Result.Code != http.StatusOK {
+		return Receipt{}, fmt.Errorf("Ghasedak send failed: %d %s", gResp.Result.Code, gResp.Result.Message)
+	}
+
+	receipt := Receipt{Provider: ProviderGhasedak}
+	if len(gResp.Items) > 0 && gResp.Items[0] > 0 {
+		receipt.MessageID = strconv.FormatInt(gResp.Items[0], 10)
+	}
+	return receipt, nil
+}
+
+func (g *GhasedakProvider) IsMock() bool {
+	return false
+}
+
+func (g *GhasedakProvider) Name() string {
+	return ProviderGhasedak
+}
+
+// ParseDeliveryReports parses Ghasedak's delivery callback
+func (g *GhasedakProvider) ParseDeliveryReports(body []byte) ([]DeliveryReport, error) {
+	return parseGhasedakReports(body)
+}
@@ -0,0 +1,46 @@
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGhasedakProvider_SendWithReceipt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/verification/send/simple" || r.Header.Get("apikey") != "test-key" {
+			t.Errorf("Expected the verification endpoint with the API key, got %s", r.URL.Path)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("receptor") != "09123456789" || r.PostForm.Get("param1") != "123456" || r.PostForm.Get("type") != "2" {
+			t.Errorf("Expected a voice code to the local number, got %v", r.PostForm)
+		}
+		w.Write([]byte(`{"result":{"code":200,"message":"success"},"items":[3071179]}`))
+	}))
+	defer server.Close()
+
+	provider := NewGhasedakProvider("test-key", "verify", true)
+	provider.BaseURL, provider.HTTPClient = server.URL, server.Client()
+
+	receipt, err := provider.SendWithReceipt("123456", "+989123456789")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if receipt.Provider != ProviderGhasedak || receipt.MessageID != "3071179" {
+		t.Errorf("Expected Ghasedak receipt for 3071179, got %+v", receipt)
+	}
+}
+
+func TestGhasedakProvider_SendFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"code":418,"message":"insufficient credit"},"items":null}`))
+	}))
+	defer server.Close()
+
+	provider := NewGhasedakProvider("test-key", "verify", false)
+	provider.BaseURL, provider.HTTPClient = server.URL, server.Client()
+
+	if err := provider.Send("123456", "+989123456789"); err == nil {
+		t.Error("Expected error for a failed send")
+	}
+}
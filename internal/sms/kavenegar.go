@@ -0,0 +1,105 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KavenegarProvider sends codes through the Kavenegar verify lookup API,
+// either as an SMS or, with Voice set, as a voice call reading the code
+type KavenegarProvider struct {
+	APIKey     string
+	Template   string
+	Voice      bool
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type KavenegarResponse struct {
+	Return struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"return"`
+	Entries []struct {
+		MessageID int64 `json:"messageid"`
+	} `json:"entries"`
+}
+
+func NewKavenegarProvider(apiKey, template string, voice bool) *KavenegarProvider {
+	return &KavenegarProvider{
+		APIKey:   apiKey,
+		Template: template,
+		Voice:    voice,
+		BaseURL:  "https://api.kavenegar.com/v1",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (k *KavenegarProvider) Send(code string, phone string) error {
+	_, err := k.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code and returns the message ID assigned by Kavenegar
+func (k *KavenegarProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	// Kavenegar expects the local format, e.g. 09123456789
+	receptor := strings.TrimPrefix(phone, "+")
+	if strings.HasPrefix(receptor, "98") {
+		receptor = "0" + receptor[2:]
+	}
+
+	params := url.Values{}
+	params.Set("receptor", receptor)
+	params.Set("token", code)
+	params.Set("template", k.Template)
+	if k.Voice {
+		params.Set("type", "call")
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/verify/lookup.json?%s", k.BaseURL, url.PathEscape(k.APIKey), params.Encode())
+	resp, err := k.HTTPClient.Get(endpoint)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to send Kavenegar request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var kResp KavenegarResponse
+	if err := json.Unmarshal(bodyBytes, &kResp); err != nil {
+		return Receipt{}, fmt.Errorf("failed to decode Kavenegar response (status %d): %w", resp.StatusCode, err)
+	}
+	if kResp.Return.Status != http.StatusOK {
+		return Receipt{}, fmt.Errorf("Kavenegar send failed: %d %s", kResp.Return.Status, kResp.Return.Message)
+	}
+
+	receipt := Receipt{Provider: ProviderKavenegar}
+	if len(kResp.Entries) > 0 && kResp.Entries[0].MessageID > 0 {
+		receipt.MessageID = strconv.FormatInt(kResp.Entries[0].MessageID, 10)
+	}
+	return receipt, nil
+}
+
+func (k *KavenegarProvider) IsMock() bool {
+	return false
+}
+
+func (k *KavenegarProvider) Name() string {
+	return ProviderKavenegar
+}
+
+// ParseDeliveryReports parses Kavenegar's delivery callback
+func (k *KavenegarProvider) ParseDeliveryReports(body []byte) ([]DeliveryReport, error) {
+	return parseKavenegarReports(body)
+}
@@ -6,12 +6,46 @@ type Provider interface {
 	IsMock() bool // Returns true if this is a mock provider (for development)
 }
 
+// Names of the providers that report message IDs, used in receipts and
+// delivery webhook URLs
+const (
+	ProviderSMSIr     = "sms_ir"
+	ProviderKavenegar = "kavenegar"
+	ProviderGhasedak  = "ghasedak"
+	ProviderTwilio    = "twilio"
+)
+
+// Receipt identifies a sent message at the provider that accepted it
+type Receipt struct {
+	Provider  string
+	MessageID string
+}
+
+// ReceiptSender is implemented by providers that report the message ID
+// assigned to a sent code, so delivery reports can be matched to it
+type ReceiptSender interface {
+	SendWithReceipt(code string, phone string) (Receipt, error)
+}
+
+// SMSProvider is an SMS gateway: it reports the message ID of every code it
+// sends and normalizes the delivery reports posted to its webhook
+type SMSProvider interface {
+	Provider
+	ReceiptSender
+	// Name returns the provider's name used in receipts and webhook URLs
+	Name() string
+	// ParseDeliveryReports extracts the delivery reports of a webhook body
+	ParseDeliveryReports(body []byte) ([]DeliveryReport, error)
+}
+
 // NewProvider creates a new SMS provider based on configuration
 func NewProvider(providerType, apiKey string, templateID int) Provider {
 	return NewProviderWithParameter(providerType, apiKey, templateID, "Code")
 }
 
-// NewProviderWithParameter creates a new SMS provider with custom parameter name
+// NewProviderWithParameter creates a new SMS provider with custom parameter
+// name. It only knows the providers configured by a template ID;
+// WireProvider creates every provider from the configuration.
 func NewProviderWithParameter(providerType, apiKey string, templateID int, parameterName string) Provider {
 	switch providerType {
 	case "sms_ir":
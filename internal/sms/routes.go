@@ -0,0 +1,16 @@
+package sms
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWebhookRoutes mounts the delivery report webhooks. They must not
+// require authentication: each request is verified by its signature.
+func SetupWebhookRoutes(router *gin.RouterGroup, handler *WebhookHandler) {
+	router.POST("/webhooks/sms/:provider", handler.ReceiveDeliveryReports) // POST /webhooks/sms/:provider
+}
+
+// SetupAdminRoutes mounts the delivery stats on an admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *WebhookHandler) {
+	router.GET("/sms/delivery-stats", handler.GetCarrierStats) // GET /admin/sms/delivery-stats
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -54,6 +55,12 @@ func NewSMSIrProviderWithParameter(apiKey string, templateID int, parameterName
 }
 
 func (s *SMSIrProvider) Send(code string, phone string) error {
+	_, err := s.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code and returns the message ID assigned by SMS.ir
+func (s *SMSIrProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
 	// Remove + from phone number if present
 	if len(phone) > 0 && phone[0] == '+' {
 		phone = phone[1:]
@@ -74,7 +81,7 @@ func (s *SMSIrProvider) Send(code string, phone string) error {
 	// Marshal to JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal SMS request: %w", err)
+		return Receipt{}, fmt.Errorf("failed to marshal SMS request: %w", err)
 	}
 
 	// Log the request being sent
@@ -83,7 +90,7 @@ func (s *SMSIrProvider) Send(code string, phone string) error {
 	// Create HTTP request
 	req, err := http.NewRequest("POST", s.BaseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create SMS request: %w", err)
+		return Receipt{}, fmt.Errorf("failed to create SMS request: %w", err)
 	}
 
 	// Set headers
@@ -93,14 +100,14 @@ func (s *SMSIrProvider) Send(code string, phone string) error {
 	// Send request
 	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS request: %w", err)
+		return Receipt{}, fmt.Errorf("failed to send SMS request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body for logging
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return Receipt{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Log the raw response
@@ -110,18 +117,31 @@ func (s *SMSIrProvider) Send(code string, phone string) error {
 	// Parse response
 	var smsResp SMSIrResponse
 	if err := json.Unmarshal(bodyBytes, &smsResp); err != nil {
-		return fmt.Errorf("failed to decode SMS response: %w", err)
+		return Receipt{}, fmt.Errorf("failed to decode SMS response: %w", err)
 	}
 
 	// Check if successful
 	if smsResp.Status != 1 {
-		return fmt.Errorf("SMS send failed: %s", smsResp.Message)
+		return Receipt{}, fmt.Errorf("SMS send failed: %s", smsResp.Message)
 	}
 
 	fmt.Printf("SMS sent successfully! MessageID: %d, Cost: %.2f\n", smsResp.Data.MessageID, smsResp.Data.Cost)
-	return nil
+	receipt := Receipt{Provider: ProviderSMSIr}
+	if smsResp.Data.MessageID > 0 {
+		receipt.MessageID = strconv.Itoa(smsResp.Data.MessageID)
+	}
+	return receipt, nil
 }
 
 func (s *SMSIrProvider) IsMock() bool {
 	return false
 }
+
+func (s *SMSIrProvider) Name() string {
+	return ProviderSMSIr
+}
+
+// ParseDeliveryReports parses SMS.ir delivery reports
+func (s *SMSIrProvider) ParseDeliveryReports(body []byte) ([]DeliveryReport, error) {
+	return parseSMSIrReports(body)
+}
@@ -0,0 +1,109 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNoLinkedChat is returned when the phone has no linked Telegram account
+var ErrNoLinkedChat = errors.New("no Telegram account linked to phone")
+
+// LinkedChats finds the Telegram chat of the account linked to a phone
+type LinkedChats interface {
+	ChatIDForPhone(ctx context.Context, phone string) (int64, error)
+}
+
+// TelegramProvider delivers codes as a message from the Telegram bot to users
+// who have linked their account by sharing their phone number with the bot
+type TelegramProvider struct {
+	BotToken   string
+	Chats      LinkedChats
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewTelegramProvider(botToken string, chats LinkedChats) *TelegramProvider {
+	return &TelegramProvider{
+		BotToken: botToken,
+		Chats:    chats,
+		BaseURL:  "https://api.telegram.org",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (t *TelegramProvider) Send(code string, phone string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.HTTPClient.Timeout)
+	defer cancel()
+
+	chatID, err := t.Chats.ChatIDForPhone(ctx, phone)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":         chatID,
+		"text":            fmt.Sprintf("کد تایید شما: %s\nاین کد را در اختیار دیگران قرار ندهید.", code),
+		"protect_content": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/sendMessage", t.BaseURL, t.BotToken), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Telegram send failed: status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (t *TelegramProvider) IsMock() bool {
+	return false
+}
+
+// dbLinkedChats looks linked chats up in the bot's telegram_sessions table.
+// Private chats with the bot share the Telegram user's ID.
+type dbLinkedChats struct {
+	db *sql.DB
+}
+
+// NewDBLinkedChats creates a database-backed linked chat lookup
+func NewDBLinkedChats(db *sql.DB) LinkedChats {
+	return &dbLinkedChats{db: db}
+}
+
+func (d *dbLinkedChats) ChatIDForPhone(ctx context.Context, phone string) (int64, error) {
+	var chatID int64
+	err := d.db.QueryRowContext(ctx, `
+		SELECT telegram_user_id FROM telegram_sessions
+		WHERE phone = $1 AND backend_user_id IS NOT NULL
+		ORDER BY updated_at DESC
+		LIMIT 1`, phone).Scan(&chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNoLinkedChat
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up linked Telegram chat: %w", err)
+	}
+	return chatID, nil
+}
@@ -0,0 +1,104 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioProvider sends codes as a plain SMS through Twilio's Messages API,
+// meant for international numbers the Iranian gateways don't reach
+type TwilioProvider struct {
+	AccountSID     string
+	AuthToken      string
+	From           string // sending number, or a Messaging Service SID starting with MG
+	StatusCallback string // delivery report webhook URL, empty for no reports
+	BaseURL        string
+	HTTPClient     *http.Client
+}
+
+type TwilioResponse struct {
+	SID     string `json:"sid"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewTwilioProvider(accountSID, authToken, from, statusCallback string) *TwilioProvider {
+	return &TwilioProvider{
+		AccountSID:     accountSID,
+		AuthToken:      authToken,
+		From:           from,
+		StatusCallback: statusCallback,
+		BaseURL:        "https://api.twilio.com/2010-04-01",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (t *TwilioProvider) Send(code string, phone string) error {
+	_, err := t.SendWithReceipt(code, phone)
+	return err
+}
+
+// SendWithReceipt sends the code and returns the message SID assigned by Twilio
+func (t *TwilioProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	form := url.Values{}
+	form.Set("To", phone)
+	form.Set("Body", fmt.Sprintf("Your verification code is %s. Do not share it with anyone.", code))
+	if strings.HasPrefix(t.From, "MG") {
+		form.Set("MessagingServiceSid", t.From)
+	} else {
+		form.Set("From", t.From)
+	}
+	if t.StatusCallback != "" {
+		form.Set("StatusCallback", t.StatusCallback)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.BaseURL, url.PathEscape(t.AccountSID))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to create Twilio request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	resp, err := t.HTTPClient.Do(req)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to send Twilio request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var tResp TwilioResponse
+	if err := json.Unmarshal(bodyBytes, &tResp); err != nil {
+		return Receipt{}, fmt.Errorf("failed to decode Twilio response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return Receipt{}, fmt.Errorf("Twilio send failed: %d %s", tResp.Code, tResp.Message)
+	}
+
+	return Receipt{Provider: ProviderTwilio, MessageID: tResp.SID}, nil
+}
+
+func (t *TwilioProvider) IsMock() bool {
+	return false
+}
+
+func (t *TwilioProvider) Name() string {
+	return ProviderTwilio
+}
+
+// ParseDeliveryReports parses Twilio's status callback
+func (t *TwilioProvider) ParseDeliveryReports(body []byte) ([]DeliveryReport, error) {
+	return parseTwilioReports(body)
+}
@@ -0,0 +1,77 @@
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTwilioProvider_SendWithReceipt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Accounts/AC1/Messages.json" {
+			t.Errorf("Expected the account's Messages endpoint, got %s", r.URL.Path)
+		}
+		if sid, token, ok := r.BasicAuth(); !ok || sid != "AC1" || token != "token" {
+			t.Errorf("Expected basic auth with the account SID and token, got %s/%s", sid, token)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("To") != "+15005550006" || r.PostForm.Get("MessagingServiceSid") != "MG1" || r.PostForm.Get("From") != "" {
+			t.Errorf("Expected the number sent via the messaging service, got %v", r.PostForm)
+		}
+		if r.PostForm.Get("StatusCallback") != "https://api.example.com/api/webhooks/sms/twilio" {
+			t.Errorf("Expected the status callback, got %q", r.PostForm.Get("StatusCallback"))
+		}
+
+		if r.PostForm.Get("Body") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21602,"message":"Message body is required.","status":400}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	provider := NewTwilioProvider("AC1", "token", "MG1", "https://api.example.com/api/webhooks/sms/twilio")
+	provider.BaseURL, provider.HTTPClient = server.URL, server.Client()
+
+	receipt, err := provider.SendWithReceipt("123456", "+15005550006")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if receipt.Provider != ProviderTwilio || receipt.MessageID != "SM123" {
+		t.Errorf("Expected Twilio receipt for SM123, got %+v", receipt)
+	}
+}
+
+func TestTwilioProvider_SendFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number","status":400}`))
+	}))
+	defer server.Close()
+
+	provider := NewTwilioProvider("AC1", "token", "+15005550006", "")
+	provider.BaseURL, provider.HTTPClient = server.URL, server.Client()
+
+	if err := provider.Send("123456", "+1"); err == nil {
+		t.Error("Expected error for a rejected message")
+	}
+}
+
+func TestSMSProviders(t *testing.T) {
+	providers := map[string]SMSProvider{
+		ProviderSMSIr:     NewSMSIrProvider("key", 1),
+		ProviderKavenegar: NewKavenegarProvider("key", "verify", false),
+		ProviderGhasedak:  NewGhasedakProvider("key", "verify", false),
+		ProviderTwilio:    NewTwilioProvider("AC1", "token", "+15005550006", ""),
+	}
+	for name, provider := range providers {
+		if provider.Name() != name {
+			t.Errorf("Expected name %s, got %s", name, provider.Name())
+		}
+		if _, ok := webhookParsers[name]; !ok {
+			t.Errorf("Expected a webhook parser for %s", name)
+		}
+	}
+}
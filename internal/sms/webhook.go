@@ -0,0 +1,322 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+// optionally prefixed with "sha256="
+const WebhookSignatureHeader = "X-Signature"
+
+// TwilioSignatureHeader carries Twilio's own signature of its status
+// callbacks, which can't carry WebhookSignatureHeader
+const TwilioSignatureHeader = "X-Twilio-Signature"
+
+// maxWebhookBody bounds the delivery report requests read
+const maxWebhookBody = 1 << 20
+
+// defaultStatsWindow is the period carrier stats cover when none is requested
+const defaultStatsWindow = 24 * time.Hour
+
+// webhookParser extracts the delivery reports of a provider's request body
+type webhookParser func(body []byte) ([]DeliveryReport, error)
+
+var webhookParsers = map[string]webhookParser{
+	ProviderKavenegar: parseKavenegarReports,
+	ProviderSMSIr:     parseSMSIrReports,
+	ProviderGhasedak:  parseGhasedakReports,
+	ProviderTwilio:    parseTwilioReports,
+}
+
+// WebhookHandler receives signed delivery reports from SMS providers
+type WebhookHandler struct {
+	secrets   map[string]string
+	store     DeliveryStore
+	sinks     []DeliveryReportSink
+	twilioURL string
+}
+
+// NewWebhookHandler creates a handler verifying each provider's reports with
+// its secret in secrets; providers without a secret are rejected. Reports go
+// to the OTP delivery store first, then to sinks.
+func NewWebhookHandler(secrets map[string]string, store DeliveryStore, sinks ...DeliveryReportSink) *WebhookHandler {
+	return &WebhookHandler{
+		secrets: secrets,
+		store:   store,
+		sinks:   append([]DeliveryReportSink{store}, sinks...),
+	}
+}
+
+// SetTwilioCallbackURL sets the public URL Twilio posts its status callbacks
+// to. Twilio signs the URL along with the body, so its reports are rejected
+// until the URL is set.
+func (h *WebhookHandler) SetTwilioCallbackURL(callbackURL string) {
+	h.twilioURL = callbackURL
+}
+
+// ReceiveDeliveryReports handles POST /webhooks/sms/:provider
+func (h *WebhookHandler) ReceiveDeliveryReports(c *gin.Context) {
+	provider := c.Param("provider")
+	parse, ok := webhookParsers[provider]
+	secret := h.secrets[provider]
+	if !ok || secret == "" {
+		common.RespondError(c, http.StatusNotFound, "Unknown SMS provider")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		common.RespondError(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	if !h.verify(c, provider, secret, body) {
+		common.RespondError(c, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	reports, err := parse(body)
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid delivery report", err.Error())
+		return
+	}
+
+	applied := 0
+	for _, report := range reports {
+		matched, err := h.apply(c.Request.Context(), provider, report)
+		if err != nil {
+			// Providers retry failed webhooks, and reapplying a report is harmless
+			log.Printf("Failed to apply %s delivery report for message %s: %v", provider, report.MessageID, err)
+			common.RespondError(c, http.StatusInternalServerError, "Failed to apply delivery reports")
+			return
+		}
+		if matched {
+			applied++
+		} else {
+			log.Printf("No message matches %s delivery report for message %s", provider, report.MessageID)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": len(reports), "applied": applied})
+}
+
+// verify checks the signature of a provider's request. Twilio signs with
+// its auth token as the secret, everyone else with WebhookSignatureHeader.
+func (h *WebhookHandler) verify(c *gin.Context, provider, secret string, body []byte) bool {
+	if provider == ProviderTwilio {
+		return h.twilioURL != "" && VerifyTwilioSignature(secret, h.twilioURL, body, c.GetHeader(TwilioSignatureHeader))
+	}
+	return VerifyWebhookSignature(secret, body, c.GetHeader(WebhookSignatureHeader))
+}
+
+// apply hands the report to the first sink that owns the message
+func (h *WebhookHandler) apply(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+	for _, sink := range h.sinks {
+		matched, err := sink.ApplyDeliveryReport(ctx, provider, report)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// GetCarrierStats handles GET /admin/sms/delivery-stats, summarizing OTP
+// deliveries per provider and carrier over the since window (default 24h)
+func (h *WebhookHandler) GetCarrierStats(c *gin.Context) {
+	window := defaultStatsWindow
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			common.RespondError(c, http.StatusBadRequest, "since must be a positive duration such as 24h")
+			return
+		}
+		window = parsed
+	}
+
+	stats, err := h.store.GetCarrierStats(c.Request.Context(), time.Now().Add(-window))
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get delivery stats", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"since": window.String(), "carriers": stats})
+}
+
+// VerifyWebhookSignature reports whether signature is the HMAC-SHA256 of body under secret
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(signature, "sha256=")
+	if signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// VerifyTwilioSignature reports whether signature is Twilio's signature of a
+// form-encoded callback to callbackURL: the base64 HMAC-SHA1 under the auth
+// token of the URL followed by every parameter name and value, sorted by name
+func VerifyTwilioSignature(authToken, callbackURL string, body []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return false
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(callbackURL)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// deliveryStatus normalizes a provider's status to DeliveryStatusDelivered or
+// DeliveryStatusFailed, or DeliveryStatusSent for statuses in neither list
+// that are still pending
+func deliveryStatus[T comparable](status T, delivered, failed []T) string {
+	switch {
+	case slices.Contains(delivered, status):
+		return DeliveryStatusDelivered
+	case slices.Contains(failed, status):
+		return DeliveryStatusFailed
+	}
+	return DeliveryStatusSent
+}
+
+// parseKavenegarReports parses Kavenegar's form-encoded delivery callback
+// carrying a messageid and its numeric status
+func parseKavenegarReports(body []byte) ([]DeliveryReport, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	messageID := values.Get("messageid")
+	status, err := strconv.Atoi(values.Get("status"))
+	if messageID == "" || err != nil {
+		return nil, fmt.Errorf("messageid and a numeric status are required")
+	}
+
+	return []DeliveryReport{{
+		MessageID: messageID,
+		// Failed, undelivered, cancelled, blocked by the recipient, unknown message
+		Status: deliveryStatus(status, []int{10}, []int{6, 11, 13, 14, 100}),
+		Detail: "kavenegar status " + strconv.Itoa(status),
+	}}, nil
+}
+
+// smsIrReport is one entry of an SMS.ir delivery report
+type smsIrReport struct {
+	MessageID     int64 `json:"messageId"`
+	DeliveryState int   `json:"deliveryState"`
+}
+
+// parseSMSIrReports parses SMS.ir delivery reports, sent as a single JSON
+// object or an array of them
+func parseSMSIrReports(body []byte) ([]DeliveryReport, error) {
+	var entries []smsIrReport
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+	} else {
+		var entry smsIrReport
+		if err := json.Unmarshal(trimmed, &entry); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		entries = []smsIrReport{entry}
+	}
+
+	reports := make([]DeliveryReport, 0, len(entries))
+	for _, entry := range entries {
+		if entry.MessageID <= 0 {
+			return nil, fmt.Errorf("messageId is required")
+		}
+		reports = append(reports, DeliveryReport{
+			MessageID: strconv.FormatInt(entry.MessageID, 10),
+			// Not delivered to the phone or the operator, failed, blacklisted
+			Status: deliveryStatus(entry.DeliveryState, []int{1}, []int{2, 4, 6, 7}),
+			Detail: "sms_ir delivery state " + strconv.Itoa(entry.DeliveryState),
+		})
+	}
+	return reports, nil
+}
+
+// parseGhasedakReports parses Ghasedak's form-encoded delivery callback
+// carrying a messageid and its numeric status
+func parseGhasedakReports(body []byte) ([]DeliveryReport, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	messageID := values.Get("messageid")
+	status, err := strconv.Atoi(values.Get("status"))
+	if messageID == "" || err != nil {
+		return nil, fmt.Errorf("messageid and a numeric status are required")
+	}
+
+	return []DeliveryReport{{
+		MessageID: messageID,
+		// Not delivered to the phone, not delivered to the operator, blacklisted
+		Status: deliveryStatus(status, []int{1}, []int{2, 16, 27}),
+		Detail: "ghasedak status " + strconv.Itoa(status),
+	}}, nil
+}
+
+// parseTwilioReports parses Twilio's form-encoded status callback carrying a
+// MessageSid, its MessageStatus and, for failures, an ErrorCode
+func parseTwilioReports(body []byte) ([]DeliveryReport, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	messageID, status := values.Get("MessageSid"), values.Get("MessageStatus")
+	if messageID == "" || status == "" {
+		return nil, fmt.Errorf("MessageSid and MessageStatus are required")
+	}
+
+	detail := "twilio status " + status
+	if code := values.Get("ErrorCode"); code != "" {
+		detail += " error " + code
+	}
+	return []DeliveryReport{{
+		MessageID: messageID,
+		Status:    deliveryStatus(status, []string{"delivered"}, []string{"undelivered", "failed", "canceled"}),
+		Detail:    detail,
+	}}, nil
+}
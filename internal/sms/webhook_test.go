@@ -0,0 +1,367 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryDeliveryStore keeps OTP deliveries by message ID
+type memoryDeliveryStore struct {
+	recorded []OTPDelivery
+	reports  map[string]DeliveryReport
+	stats    []CarrierStats
+	since    time.Time
+}
+
+func (m *memoryDeliveryStore) RecordOTPDelivery(ctx context.Context, delivery OTPDelivery) error {
+	m.recorded = append(m.recorded, delivery)
+	return nil
+}
+
+func (m *memoryDeliveryStore) ApplyDeliveryReport(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+	for _, delivery := range m.recorded {
+		if delivery.Provider == provider && delivery.MessageID == report.MessageID {
+			m.reports[report.MessageID] = report
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryDeliveryStore) GetCarrierStats(ctx context.Context, since time.Time) ([]CarrierStats, error) {
+	m.since = since
+	return m.stats, nil
+}
+
+type sinkFunc func(ctx context.Context, provider string, report DeliveryReport) (bool, error)
+
+func (f sinkFunc) ApplyDeliveryReport(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+	return f(ctx, provider, report)
+}
+
+type receiptProvider struct {
+	stubProvider
+	receipt Receipt
+}
+
+func (r *receiptProvider) SendWithReceipt(code string, phone string) (Receipt, error) {
+	return r.receipt, r.Send(code, phone)
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookRouter(handler *WebhookHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupWebhookRoutes(router.Group("/api"), handler)
+	SetupAdminRoutes(router.Group("/api/admin"), handler)
+	return router
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"messageId":1}`)
+	signature := sign("secret", string(body))
+
+	if !VerifyWebhookSignature("secret", body, signature) {
+		t.Error("Expected signature to verify")
+	}
+	if !VerifyWebhookSignature("secret", body, "sha256="+signature) {
+		t.Error("Expected prefixed signature to verify")
+	}
+	if VerifyWebhookSignature("other", body, signature) {
+		t.Error("Expected signature under another secret to fail")
+	}
+	if VerifyWebhookSignature("secret", body, "") {
+		t.Error("Expected missing signature to fail")
+	}
+}
+
+func TestParseKavenegarReports(t *testing.T) {
+	tests := []struct {
+		body   string
+		status string
+	}{
+		{"messageid=42&status=10", DeliveryStatusDelivered},
+		{"messageid=42&status=11", DeliveryStatusFailed},
+		{"messageid=42&status=1", DeliveryStatusSent},
+	}
+	for _, tt := range tests {
+		reports, err := parseKavenegarReports([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("Expected no error for %q, got %v", tt.body, err)
+		}
+		if len(reports) != 1 || reports[0].MessageID != "42" || reports[0].Status != tt.status {
+			t.Errorf("Expected message 42 %s for %q, got %+v", tt.status, tt.body, reports)
+		}
+	}
+
+	if _, err := parseKavenegarReports([]byte("status=10")); err == nil {
+		t.Error("Expected error for missing messageid")
+	}
+}
+
+func TestParseSMSIrReports(t *testing.T) {
+	reports, err := parseSMSIrReports([]byte(`[{"messageId":7,"deliveryState":1},{"messageId":8,"deliveryState":2},{"messageId":9,"deliveryState":3}]`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 reports, got %d", len(reports))
+	}
+	if reports[0].Status != DeliveryStatusDelivered || reports[1].Status != DeliveryStatusFailed || reports[2].Status != DeliveryStatusSent {
+		t.Errorf("Expected delivered, failed and sent, got %+v", reports)
+	}
+
+	single, err := parseSMSIrReports([]byte(`{"messageId":7,"deliveryState":1}`))
+	if err != nil || len(single) != 1 || single[0].MessageID != "7" {
+		t.Errorf("Expected single report for message 7, got %+v, %v", single, err)
+	}
+
+	if _, err := parseSMSIrReports([]byte(`{"deliveryState":1}`)); err == nil {
+		t.Error("Expected error for missing messageId")
+	}
+}
+
+func TestParseGhasedakReports(t *testing.T) {
+	tests := []struct {
+		body   string
+		status string
+	}{
+		{"messageid=42&status=1", DeliveryStatusDelivered},
+		{"messageid=42&status=16", DeliveryStatusFailed},
+		{"messageid=42&status=8", DeliveryStatusSent},
+	}
+	for _, tt := range tests {
+		reports, err := parseGhasedakReports([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("Expected no error for %q, got %v", tt.body, err)
+		}
+		if len(reports) != 1 || reports[0].MessageID != "42" || reports[0].Status != tt.status {
+			t.Errorf("Expected message 42 %s for %q, got %+v", tt.status, tt.body, reports)
+		}
+	}
+
+	if _, err := parseGhasedakReports([]byte("messageid=42&status=delivered")); err == nil {
+		t.Error("Expected error for a non-numeric status")
+	}
+}
+
+func TestParseTwilioReports(t *testing.T) {
+	tests := []struct {
+		body   string
+		status string
+	}{
+		{"MessageSid=SM1&MessageStatus=delivered", DeliveryStatusDelivered},
+		{"MessageSid=SM1&MessageStatus=undelivered&ErrorCode=30003", DeliveryStatusFailed},
+		{"MessageSid=SM1&MessageStatus=sent", DeliveryStatusSent},
+	}
+	for _, tt := range tests {
+		reports, err := parseTwilioReports([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("Expected no error for %q, got %v", tt.body, err)
+		}
+		if len(reports) != 1 || reports[0].MessageID != "SM1" || reports[0].Status != tt.status {
+			t.Errorf("Expected message SM1 %s for %q, got %+v", tt.status, tt.body, reports)
+		}
+	}
+
+	reports, _ := parseTwilioReports([]byte("MessageSid=SM1&MessageStatus=failed&ErrorCode=30006"))
+	if reports[0].Detail != "twilio status failed error 30006" {
+		t.Errorf("Expected the error code in the detail, got %q", reports[0].Detail)
+	}
+	if _, err := parseTwilioReports([]byte("MessageStatus=delivered")); err == nil {
+		t.Error("Expected error for missing MessageSid")
+	}
+}
+
+func TestVerifyTwilioSignature(t *testing.T) {
+	// Example from Twilio's webhook security documentation
+	callbackURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	body := []byte("CallSid=CA1234567890ABCDE&Caller=%2B12349013030&Digits=1234&From=%2B12349013030&To=%2B18005551212")
+
+	if !VerifyTwilioSignature("12345", callbackURL, body, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=") {
+		t.Error("Expected signature to verify")
+	}
+	if VerifyTwilioSignature("12345", "https://mycompany.com/other", body, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=") {
+		t.Error("Expected signature of another URL to fail")
+	}
+	if VerifyTwilioSignature("12345", callbackURL, body, "") {
+		t.Error("Expected missing signature to fail")
+	}
+}
+
+func TestReceiveDeliveryReports(t *testing.T) {
+	store := &memoryDeliveryStore{
+		recorded: []OTPDelivery{{Provider: ProviderSMSIr, MessageID: "7"}},
+		reports:  map[string]DeliveryReport{},
+	}
+	var passedOn []string
+	sink := sinkFunc(func(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+		passedOn = append(passedOn, report.MessageID)
+		return report.MessageID == "8", nil
+	})
+	handler := NewWebhookHandler(map[string]string{ProviderSMSIr: "secret"}, store, sink)
+	router := newWebhookRouter(handler)
+
+	post := func(provider, body, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/sms/"+provider, strings.NewReader(body))
+		req.Header.Set(WebhookSignatureHeader, signature)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `[{"messageId":7,"deliveryState":1},{"messageId":8,"deliveryState":2},{"messageId":9,"deliveryState":1}]`
+	w := post(ProviderSMSIr, body, sign("secret", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Received int `json:"received"`
+		Applied  int `json:"applied"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Received != 3 || response.Applied != 2 {
+		t.Errorf("Expected 3 received and 2 applied, got %+v", response)
+	}
+	if store.reports["7"].Status != DeliveryStatusDelivered {
+		t.Errorf("Expected OTP 7 delivered, got %+v", store.reports["7"])
+	}
+	if len(passedOn) != 2 || passedOn[0] != "8" || passedOn[1] != "9" {
+		t.Errorf("Expected reports not about an OTP to be passed on, got %v", passedOn)
+	}
+
+	if w := post(ProviderSMSIr, body, sign("wrong", body)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a bad signature, got %d", w.Code)
+	}
+	if w := post(ProviderKavenegar, "messageid=1&status=10", sign("secret", "messageid=1&status=10")); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a provider without a secret, got %d", w.Code)
+	}
+	if w := post(ProviderSMSIr, "not json", sign("secret", "not json")); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid body, got %d", w.Code)
+	}
+
+	failing := NewWebhookHandler(map[string]string{ProviderSMSIr: "secret"}, store, sinkFunc(func(ctx context.Context, provider string, report DeliveryReport) (bool, error) {
+		return false, errors.New("db down")
+	}))
+	router = newWebhookRouter(failing)
+	if w := post(ProviderSMSIr, body, sign("secret", body)); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 when a sink fails, got %d", w.Code)
+	}
+}
+
+func TestReceiveDeliveryReports_Twilio(t *testing.T) {
+	store := &memoryDeliveryStore{
+		recorded: []OTPDelivery{{Provider: ProviderTwilio, MessageID: "CA1234567890ABCDE"}},
+		reports:  map[string]DeliveryReport{},
+	}
+	handler := NewWebhookHandler(map[string]string{ProviderTwilio: "12345"}, store)
+	router := newWebhookRouter(handler)
+
+	post := func(signature string) int {
+		// Not a real status callback, but signed as in Twilio's documentation
+		body := "CallSid=CA1234567890ABCDE&Caller=%2B12349013030&Digits=1234&From=%2B12349013030&To=%2B18005551212"
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/sms/twilio", strings.NewReader(body))
+		req.Header.Set(TwilioSignatureHeader, signature)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("0/KCTR6DLpKmkAf8muzZqo1nDgQ="); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a callback URL, got %d", code)
+	}
+	handler.SetTwilioCallbackURL("https://mycompany.com/myapp.php?foo=1&bar=2")
+	// Verified, but the body is no status callback
+	if code := post("0/KCTR6DLpKmkAf8muzZqo1nDgQ="); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a verified body without a status, got %d", code)
+	}
+	if code := post("RSOYDt4T1cUTdK1PDd93/VVr8B8="); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a bad signature, got %d", code)
+	}
+}
+
+func TestGetCarrierStats(t *testing.T) {
+	store := &memoryDeliveryStore{stats: []CarrierStats{{Provider: ProviderKavenegar, CarrierPrefix: "0912", Sent: 10, Failed: 4}}}
+	router := newWebhookRouter(NewWebhookHandler(nil, store))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/sms/delivery-stats?since=1h", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if since := time.Since(store.since); since < time.Hour || since > time.Hour+time.Minute {
+		t.Errorf("Expected stats since an hour ago, got %v", store.since)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/sms/delivery-stats?since=yesterday", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid window, got %d", w.Code)
+	}
+}
+
+func TestTrackingProvider(t *testing.T) {
+	store := &memoryDeliveryStore{}
+	inner := &receiptProvider{receipt: Receipt{Provider: ProviderSMSIr, MessageID: "7"}}
+	provider := NewTrackingProvider(inner, store)
+
+	if err := provider.Send("123456", "+989121234567"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.recorded) != 1 || store.recorded[0].MessageID != "7" || store.recorded[0].CarrierPrefix != "0912" {
+		t.Errorf("Expected delivery of message 7 on carrier 0912, got %+v", store.recorded)
+	}
+
+	// Providers without message IDs have nothing to track
+	untracked := NewTrackingProvider(&stubProvider{}, store)
+	if err := untracked.Send("123456", "+989121234567"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.recorded) != 1 {
+		t.Errorf("Expected no delivery recorded without a message ID, got %d", len(store.recorded))
+	}
+}
+
+func TestCarrierPrefix(t *testing.T) {
+	tests := map[string]string{
+		"+989121234567": "0912",
+		"09351234567":   "0935",
+		"989011234567":  "0901",
+		"12":            "",
+	}
+	for phone, expected := range tests {
+		if prefix := carrierPrefix(phone); prefix != expected {
+			t.Errorf("Expected prefix %q for %s, got %q", expected, phone, prefix)
+		}
+	}
+}
+
+func TestSortCarrierStats(t *testing.T) {
+	stats := []CarrierStats{
+		{CarrierPrefix: "0912", Sent: 100, FailureRate: 0.1},
+		{CarrierPrefix: "0935", Sent: 10, FailureRate: 0.5},
+		{CarrierPrefix: "0901", Sent: 200, FailureRate: 0.1},
+	}
+	sortCarrierStats(stats)
+	if stats[0].CarrierPrefix != "0935" || stats[1].CarrierPrefix != "0901" || stats[2].CarrierPrefix != "0912" {
+		t.Errorf("Expected worst failure rate then volume first, got %+v", stats)
+	}
+}
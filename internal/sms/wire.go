@@ -0,0 +1,149 @@
+package sms
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai-styler/internal/config"
+)
+
+// Provider names accepted in SMS_PROVIDER, SMS_FALLBACK_PROVIDERS and
+// SMS_COUNTRY_PROVIDERS
+const (
+	FallbackSMSIr          = "sms_ir"
+	FallbackKavenegar      = "kavenegar"
+	FallbackKavenegarVoice = "kavenegar_voice"
+	FallbackGhasedak       = "ghasedak"
+	FallbackGhasedakVoice  = "ghasedak_voice"
+	FallbackTwilio         = "twilio"
+	FallbackTelegram       = "telegram"
+)
+
+// WireProvider creates the configured OTP provider, wrapped in a fallback
+// chain when fallback providers are configured and routed by country when
+// country providers are, recording sent codes for their delivery reports.
+// db may be nil, in which case the Telegram provider is skipped and nothing
+// is recorded.
+func WireProvider(cfg *config.Config, db *sql.DB) Provider {
+	provider := wireCountries(cfg, db, wireChain(cfg, db))
+	if db == nil {
+		return provider
+	}
+	// Record sent codes so their delivery reports can be matched
+	return NewTrackingProvider(provider, NewDBDeliveryStore(db))
+}
+
+// WireWebhookHandler creates the delivery report webhook handler. Reports
+// not about an OTP are passed on to sinks.
+func WireWebhookHandler(cfg *config.Config, db *sql.DB, sinks ...DeliveryReportSink) *WebhookHandler {
+	secrets := map[string]string{
+		ProviderSMSIr:     cfg.SMS.SMSIrWebhookSecret,
+		ProviderKavenegar: cfg.SMS.KavenegarWebhookSecret,
+		ProviderGhasedak:  cfg.SMS.GhasedakWebhookSecret,
+		ProviderTwilio:    cfg.SMS.TwilioAuthToken,
+	}
+	handler := NewWebhookHandler(secrets, NewDBDeliveryStore(db), sinks...)
+	handler.SetTwilioCallbackURL(cfg.SMS.TwilioStatusCallbackURL)
+	return handler
+}
+
+// wireChain creates the configured provider and its fallback chain. An
+// unknown or unconfigured provider falls back to the mock.
+func wireChain(cfg *config.Config, db *sql.DB) Provider {
+	primary, err := newNamedProvider(cfg, db, cfg.SMS.Provider)
+	if err != nil {
+		log.Printf("Using the mock OTP provider: %v", err)
+		primary = NewMockSMSProvider()
+	}
+	if len(cfg.SMS.FallbackProviders) == 0 {
+		return primary
+	}
+
+	chain := []NamedProvider{{Name: cfg.SMS.Provider, Provider: primary}}
+	for _, name := range cfg.SMS.FallbackProviders {
+		provider, err := newNamedProvider(cfg, db, name)
+		if err != nil {
+			log.Printf("Skipping OTP fallback: %v", err)
+			continue
+		}
+		chain = append(chain, NamedProvider{Name: name, Provider: provider})
+	}
+
+	return NewFallbackProvider(cfg.SMS.ProviderTimeout, chain...)
+}
+
+// wireCountries routes the numbers of the configured countries away from
+// home, the provider of all other numbers
+func wireCountries(cfg *config.Config, db *sql.DB, home Provider) Provider {
+	routes := make(map[string]NamedProvider)
+	for _, entry := range cfg.SMS.CountryProviders {
+		callingCode, name, ok := strings.Cut(entry, "=")
+		callingCode = strings.TrimPrefix(strings.TrimSpace(callingCode), "+")
+		if !ok || !isCallingCode(callingCode) {
+			log.Printf("Skipping OTP country provider %q: want <calling code>=<provider>, e.g. 1=twilio or *=twilio", entry)
+			continue
+		}
+		name = strings.TrimSpace(name)
+		provider, err := newNamedProvider(cfg, db, name)
+		if err != nil {
+			log.Printf("Skipping OTP country provider for %s: %v", callingCode, err)
+			continue
+		}
+		routes[callingCode] = NamedProvider{Name: name, Provider: provider}
+	}
+	if len(routes) == 0 {
+		return home
+	}
+	return NewCountryProvider(home, routes)
+}
+
+// newNamedProvider creates the provider called name, or an error when name
+// is unknown or the provider is not configured
+func newNamedProvider(cfg *config.Config, db *sql.DB, name string) (Provider, error) {
+	switch name {
+	case FallbackSMSIr:
+		return NewSMSIrProviderWithParameter(cfg.SMS.APIKey, cfg.SMS.TemplateID, cfg.SMS.ParameterName), nil
+	case FallbackKavenegar, FallbackKavenegarVoice:
+		if cfg.SMS.KavenegarAPIKey == "" {
+			return nil, fmt.Errorf("%s needs KAVENEGAR_API_KEY", name)
+		}
+		return NewKavenegarProvider(cfg.SMS.KavenegarAPIKey, cfg.SMS.KavenegarTemplate, name == FallbackKavenegarVoice), nil
+	case FallbackGhasedak, FallbackGhasedakVoice:
+		if cfg.SMS.GhasedakAPIKey == "" {
+			return nil, fmt.Errorf("%s needs GHASEDAK_API_KEY", name)
+		}
+		return NewGhasedakProvider(cfg.SMS.GhasedakAPIKey, cfg.SMS.GhasedakTemplate, name == FallbackGhasedakVoice), nil
+	case FallbackTwilio:
+		if cfg.SMS.TwilioAccountSID == "" || cfg.SMS.TwilioAuthToken == "" || cfg.SMS.TwilioFrom == "" {
+			return nil, fmt.Errorf("%s needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM", name)
+		}
+		return NewTwilioProvider(cfg.SMS.TwilioAccountSID, cfg.SMS.TwilioAuthToken, cfg.SMS.TwilioFrom, cfg.SMS.TwilioStatusCallbackURL), nil
+	case FallbackTelegram:
+		if db == nil || cfg.Monitoring.TelegramBotToken == "" {
+			return nil, fmt.Errorf("%s needs a database and TELEGRAM_BOT_TOKEN", name)
+		}
+		return NewTelegramProvider(cfg.Monitoring.TelegramBotToken, NewDBLinkedChats(db)), nil
+	case "mock":
+		return NewMockSMSProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown OTP provider %q", name)
+}
+
+// isCallingCode reports whether code is AnyCountry or a calling code of one
+// to three digits
+func isCallingCode(code string) bool {
+	if code == AnyCountry {
+		return true
+	}
+	if code == "" || len(code) > 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Backup run statuses
+const (
+	BackupRunRunning   = "running"
+	BackupRunCompleted = "completed"
+	BackupRunFailed    = "failed"
+)
+
+// Backup run triggers
+const (
+	BackupTriggerManual    = "manual"
+	BackupTriggerScheduled = "scheduled"
+)
+
+const (
+	// backupRunsFile is the manifest of backup runs in the backup directory
+	backupRunsFile = "runs.json"
+	// maxBackupRuns bounds the number of runs kept in the manifest
+	maxBackupRuns = 100
+)
+
+// ErrBackupInProgress is returned when a backup is started while another runs
+var ErrBackupInProgress = errors.New("a backup is already running")
+
+// BackupRun records one snapshot of the storage base path
+type BackupRun struct {
+	ID          string     `json:"id"`
+	Trigger     string     `json:"trigger"`
+	Status      string     `json:"status"`
+	ArchivePath string     `json:"archivePath,omitempty"`
+	SizeBytes   int64      `json:"sizeBytes"`
+	FileCount   int64      `json:"fileCount"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// BackupRunner snapshots the storage base path into a tar.gz archive under
+// the backup path and keeps a manifest of its runs. Archives are written to
+// the dated directory of the run, so CleanupOldBackups applies the retention
+// policy to them.
+type BackupRunner struct {
+	basePath         string
+	backupPath       string
+	compressionLevel int
+
+	mu      sync.Mutex
+	running bool
+}
+
+// NewBackupRunner creates a backup runner using the compression level of policy
+func NewBackupRunner(basePath, backupPath string, policy BackupPolicy) *BackupRunner {
+	level := policy.CompressionLevel
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	return &BackupRunner{
+		basePath:         basePath,
+		backupPath:       backupPath,
+		compressionLevel: level,
+	}
+}
+
+// Start begins a backup in the background and returns its running record.
+// The backup outlives the caller, so it is not bound to a request context.
+func (r *BackupRunner) Start(trigger string) (*BackupRun, error) {
+	run, err := r.begin(trigger)
+	if err != nil {
+		return nil, err
+	}
+	started := *run
+	go r.execute(context.Background(), run)
+	return &started, nil
+}
+
+// Run performs a backup and returns its finished record
+func (r *BackupRunner) Run(ctx context.Context, trigger string) (*BackupRun, error) {
+	run, err := r.begin(trigger)
+	if err != nil {
+		return nil, err
+	}
+	r.execute(ctx, run)
+	if run.Status == BackupRunFailed {
+		return run, errors.New(run.Error)
+	}
+	return run, nil
+}
+
+// ListRuns returns the recorded backup runs, newest first
+func (r *BackupRunner) ListRuns(ctx context.Context) ([]BackupRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.loadRuns()
+}
+
+// begin records a running backup, refusing to start a second one
+func (r *BackupRunner) begin(trigger string) (*BackupRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return nil, ErrBackupInProgress
+	}
+
+	run := &BackupRun{
+		ID:        generateUniqueID(),
+		Trigger:   trigger,
+		Status:    BackupRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := r.saveRun(run); err != nil {
+		return nil, err
+	}
+	r.running = true
+	return run, nil
+}
+
+// execute writes the archive of run and records its outcome
+func (r *BackupRunner) execute(ctx context.Context, run *BackupRun) {
+	archivePath, fileCount, err := r.writeArchive(ctx, run)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.FileCount = fileCount
+	if err != nil {
+		run.Status = BackupRunFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = BackupRunCompleted
+		run.ArchivePath = archivePath
+		if info, statErr := os.Stat(archivePath); statErr == nil {
+			run.SizeBytes = info.Size()
+		}
+	}
+	r.running = false
+	r.saveRun(run)
+}
+
+// writeArchive archives every file under the base path except the backup
+// path. The archive is written under a temporary name and renamed once
+// complete, so a failed run leaves no partial archive behind.
+func (r *BackupRunner) writeArchive(ctx context.Context, run *BackupRun) (string, int64, error) {
+	dir := filepath.Join(r.backupPath, run.StartedAt.Format("2006-01-02"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	archivePath := filepath.Join(dir, "snapshot-"+run.ID+".tar.gz")
+	tempPath := archivePath + ".partial"
+
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	fileCount, err := r.archiveFiles(ctx, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write backup archive: %w", closeErr)
+	}
+	if err != nil {
+		return "", fileCount, err
+	}
+
+	if err := os.Rename(tempPath, archivePath); err != nil {
+		return "", fileCount, fmt.Errorf("failed to finalize backup archive: %w", err)
+	}
+	return archivePath, fileCount, nil
+}
+
+// archiveFiles writes the files under the base path to w as a tar.gz stream
+func (r *BackupRunner) archiveFiles(ctx context.Context, w io.Writer) (int64, error) {
+	gz, err := gzip.NewWriterLevel(w, r.compressionLevel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+	tw := tar.NewWriter(gz)
+
+	backupPath := filepath.Clean(r.backupPath)
+	var fileCount int64
+	err = filepath.Walk(r.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if info.IsDir() {
+			if filepath.Clean(path) == backupPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		name, err := filepath.Rel(r.basePath, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(tw, src); err != nil {
+			return err
+		}
+
+		fileCount++
+		return nil
+	})
+	if err != nil {
+		return fileCount, fmt.Errorf("failed to archive storage: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fileCount, fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fileCount, fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	return fileCount, nil
+}
+
+// loadRuns reads the manifest. Runs still marked running while no backup is
+// in progress were interrupted by a restart and are reported as failed.
+// Callers must hold r.mu.
+func (r *BackupRunner) loadRuns() ([]BackupRun, error) {
+	data, err := os.ReadFile(filepath.Join(r.backupPath, backupRunsFile))
+	if os.IsNotExist(err) {
+		return []BackupRun{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup runs: %w", err)
+	}
+
+	var runs []BackupRun
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse backup runs: %w", err)
+	}
+
+	for i := range runs {
+		if runs[i].Status == BackupRunRunning && !r.running {
+			runs[i].Status = BackupRunFailed
+			runs[i].Error = "interrupted"
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+// saveRun inserts or replaces run in the manifest. Callers must hold r.mu.
+func (r *BackupRunner) saveRun(run *BackupRun) error {
+	runs, err := r.loadRuns()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range runs {
+		if runs[i].ID == run.ID {
+			runs[i] = *run
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		runs = append([]BackupRun{*run}, runs...)
+	}
+	if len(runs) > maxBackupRuns {
+		runs = runs[:maxBackupRuns]
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup runs: %w", err)
+	}
+	if err := os.MkdirAll(r.backupPath, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.backupPath, backupRunsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup runs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBackupRunner_Run(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(tempDir, "backups")
+
+	files := map[string]string{
+		"user/u1/a.jpg":       "image a",
+		"result/u1/b.png":     "image b",
+		"backups/old/c.jpg":   "backup copy",
+		"cloth/v1/images/d.g": "image d",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	runner := NewBackupRunner(tempDir, backupDir, DefaultBackupPolicy)
+	run, err := runner.Run(context.Background(), BackupTriggerScheduled)
+	if err != nil {
+		t.Fatalf("Failed to run backup: %v", err)
+	}
+
+	if run.Status != BackupRunCompleted {
+		t.Errorf("Expected status %s, got %s", BackupRunCompleted, run.Status)
+	}
+	if run.FileCount != 3 {
+		t.Errorf("Expected 3 files, got %d", run.FileCount)
+	}
+	if run.SizeBytes == 0 {
+		t.Error("Expected a non-zero archive size")
+	}
+	if run.FinishedAt == nil {
+		t.Error("Expected the finish time to be set")
+	}
+
+	archived := readArchive(t, run.ArchivePath)
+	if len(archived) != 3 {
+		t.Errorf("Expected 3 archived files, got %d", len(archived))
+	}
+	if archived["user/u1/a.jpg"] != "image a" {
+		t.Errorf("Expected archived content %q, got %q", "image a", archived["user/u1/a.jpg"])
+	}
+	if _, ok := archived["backups/old/c.jpg"]; ok {
+		t.Error("Expected the backup directory to be excluded")
+	}
+}
+
+func TestBackupRunner_ListRuns(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(tempDir, "backups")
+	runner := NewBackupRunner(tempDir, backupDir, DefaultBackupPolicy)
+
+	first, err := runner.Run(context.Background(), BackupTriggerScheduled)
+	if err != nil {
+		t.Fatalf("Failed to run backup: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	second, err := runner.Run(context.Background(), BackupTriggerManual)
+	if err != nil {
+		t.Fatalf("Failed to run backup: %v", err)
+	}
+
+	runs, err := runner.ListRuns(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list runs: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d", len(runs))
+	}
+	if runs[0].ID != second.ID || runs[1].ID != first.ID {
+		t.Errorf("Expected newest run first, got %s then %s", runs[0].ID, runs[1].ID)
+	}
+	if runs[0].Trigger != BackupTriggerManual {
+		t.Errorf("Expected trigger %s, got %s", BackupTriggerManual, runs[0].Trigger)
+	}
+}
+
+func TestBackupRunner_InProgressAndInterrupted(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(tempDir, "backups")
+	runner := NewBackupRunner(tempDir, backupDir, DefaultBackupPolicy)
+
+	run, err := runner.begin(BackupTriggerManual)
+	if err != nil {
+		t.Fatalf("Failed to begin backup: %v", err)
+	}
+	if _, err := runner.Start(BackupTriggerManual); !errors.Is(err, ErrBackupInProgress) {
+		t.Errorf("Expected ErrBackupInProgress, got %v", err)
+	}
+
+	// A new runner over the same manifest has no backup in progress, as
+	// after a restart
+	restarted := NewBackupRunner(tempDir, backupDir, DefaultBackupPolicy)
+	runs, err := restarted.ListRuns(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("Expected run %s, got %+v", run.ID, runs)
+	}
+	if runs[0].Status != BackupRunFailed {
+		t.Errorf("Expected interrupted run to be %s, got %s", BackupRunFailed, runs[0].Status)
+	}
+}
+
+func TestHandler_BackupRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	runner := NewBackupRunner(tempDir, filepath.Join(tempDir, "backups"), DefaultBackupPolicy)
+	if _, err := runner.Run(context.Background(), BackupTriggerScheduled); err != nil {
+		t.Fatalf("Failed to run backup: %v", err)
+	}
+
+	handler := &Handler{}
+	handler.SetBackups(runner)
+	router := gin.New()
+	handler.RegisterAdminRoutes(router.Group("/api/admin"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/storage/backups", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Runs  []BackupRun `json:"runs"`
+		Total int         `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Total != 1 || resp.Runs[0].Status != BackupRunCompleted {
+		t.Errorf("Expected one completed run, got %+v", resp)
+	}
+
+	// Hold the runner busy so the trigger conflicts
+	if _, err := runner.begin(BackupTriggerManual); err != nil {
+		t.Fatalf("Failed to begin backup: %v", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/storage/backups", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func readArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to read gzip stream: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read archived file: %v", err)
+		}
+		contents[header.Name] = string(data)
+	}
+	return contents
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ai-styler/internal/config"
+)
+
+// CDN providers with a purge API
+const (
+	CDNProviderCloudflare = "cloudflare"
+	CDNProviderBunny      = "bunny"
+)
+
+// DefaultCDNPurgeTimeout bounds a purge API call
+const DefaultCDNPurgeTimeout = 10 * time.Second
+
+// cloudflarePurgeBatchSize is the most files Cloudflare purges per request
+const cloudflarePurgeBatchSize = 30
+
+// CDNPurger removes URLs from a CDN cache
+type CDNPurger interface {
+	PurgeURLs(ctx context.Context, urls []string) error
+}
+
+// CDNConfig configures serving stored objects from a CDN
+type CDNConfig struct {
+	BaseURL  string // CDN host serving object keys, e.g. https://cdn.example.com
+	BasePath string // local storage root, to resolve stored file paths
+	Purger   CDNPurger
+}
+
+// CDN rewrites stored file references to URLs on the CDN host and purges them
+// from the CDN cache when the files are deleted or replaced. The CDN pulls
+// object keys from the origin, e.g. /api/storage/public or the S3 bucket.
+type CDN struct {
+	baseURL  string
+	basePath string
+	purger   CDNPurger
+}
+
+// NewCDN creates a CDN for config.BaseURL
+func NewCDN(config CDNConfig) (*CDN, error) {
+	base, err := url.Parse(config.BaseURL)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid CDN base URL: %q", config.BaseURL)
+	}
+	return &CDN{
+		baseURL:  strings.TrimRight(config.BaseURL, "/"),
+		basePath: config.BasePath,
+		purger:   config.Purger,
+	}, nil
+}
+
+// CDNFromConfig creates the CDN and purger configured in the application
+// storage config. It returns nil when no CDN base URL is set.
+func CDNFromConfig(cfg config.StorageConfig) (*CDN, error) {
+	if cfg.CDNBaseURL == "" {
+		return nil, nil
+	}
+
+	var purger CDNPurger
+	switch cfg.CDNProvider {
+	case "":
+	case CDNProviderCloudflare:
+		purger = NewCloudflarePurger(cfg.CDNCloudflareZoneID, cfg.CDNCloudflareAPIToken, cfg.CDNPurgeTimeout)
+	case CDNProviderBunny:
+		purger = NewBunnyPurger(cfg.CDNBunnyAPIKey, cfg.CDNPurgeTimeout)
+	default:
+		return nil, fmt.Errorf("unknown CDN provider: %s", cfg.CDNProvider)
+	}
+
+	return NewCDN(CDNConfig{
+		BaseURL:  cfg.CDNBaseURL,
+		BasePath: cfg.StoragePath,
+		Purger:   purger,
+	})
+}
+
+// URL returns the CDN URL of a stored file reference. References ObjectKey
+// cannot resolve, such as external URLs, are returned unchanged.
+func (c *CDN) URL(ref string) string {
+	key, ok := ObjectKey(ref, c.basePath)
+	if !ok {
+		return ref
+	}
+	return c.keyURL(key)
+}
+
+// Purge removes the CDN copies of stored file references. References that are
+// not stored files are skipped; without a purger Purge does nothing.
+func (c *CDN) Purge(ctx context.Context, refs ...string) error {
+	if c.purger == nil {
+		return nil
+	}
+
+	urls := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if key, ok := ObjectKey(ref, c.basePath); ok {
+			urls = append(urls, c.keyURL(key))
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	return c.purger.PurgeURLs(ctx, urls)
+}
+
+// keyURL joins an object key to the CDN host, escaping each path segment
+func (c *CDN) keyURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return c.baseURL + "/" + strings.Join(segments, "/")
+}
+
+// CloudflarePurger purges files from a Cloudflare zone
+type CloudflarePurger struct {
+	zoneID string
+	token  string
+	apiURL string
+	client *http.Client
+}
+
+// NewCloudflarePurger creates a purger for zoneID using an API token with
+// the Cache Purge permission
+func NewCloudflarePurger(zoneID, token string, timeout time.Duration) *CloudflarePurger {
+	if timeout <= 0 {
+		timeout = DefaultCDNPurgeTimeout
+	}
+	return &CloudflarePurger{
+		zoneID: zoneID,
+		token:  token,
+		apiURL: "https://api.cloudflare.com/client/v4",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// PurgeURLs purges urls in batches of the most files Cloudflare accepts
+func (p *CloudflarePurger) PurgeURLs(ctx context.Context, urls []string) error {
+	for start := 0; start < len(urls); start += cloudflarePurgeBatchSize {
+		end := min(start+cloudflarePurgeBatchSize, len(urls))
+		if err := p.purge(ctx, urls[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *CloudflarePurger) purge(ctx context.Context, urls []string) error {
+	body, err := json.Marshal(map[string][]string{"files": urls})
+	if err != nil {
+		return fmt.Errorf("failed to encode purge request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/zones/%s/purge_cache", p.apiURL, url.PathEscape(p.zoneID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare purge failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result)
+	if resp.StatusCode != http.StatusOK || !result.Success {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("cloudflare purge failed: %s", result.Errors[0].Message)
+		}
+		return fmt.Errorf("cloudflare purge failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// BunnyPurger purges URLs from BunnyCDN pull zones
+type BunnyPurger struct {
+	apiKey string
+	apiURL string
+	client *http.Client
+}
+
+// NewBunnyPurger creates a purger using a BunnyCDN account API key
+func NewBunnyPurger(apiKey string, timeout time.Duration) *BunnyPurger {
+	if timeout <= 0 {
+		timeout = DefaultCDNPurgeTimeout
+	}
+	return &BunnyPurger{
+		apiKey: apiKey,
+		apiURL: "https://api.bunny.net",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// PurgeURLs purges every URL, one request each as the BunnyCDN API requires.
+// It tries them all and returns the errors joined.
+func (p *BunnyPurger) PurgeURLs(ctx context.Context, urls []string) error {
+	var errs []error
+	for _, target := range urls {
+		if err := p.purge(ctx, target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *BunnyPurger) purge(ctx context.Context, target string) error {
+	endpoint := p.apiURL + "/purge?" + url.Values{"url": {target}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("AccessKey", p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("bunny purge failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("bunny purge of %s failed with status %d", target, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCDNURL(t *testing.T) {
+	base := t.TempDir()
+	cdn, err := NewCDN(CDNConfig{BaseURL: "https://cdn.example.com/", BasePath: base})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{filepath.Join(base, "images", "vendor", "a b.jpg"), "https://cdn.example.com/images/vendor/a%20b.jpg"},
+		{"/api/storage/public/images/result/c.jpg", "https://cdn.example.com/images/result/c.jpg"},
+		{"https://other.example.com/c.jpg", "https://other.example.com/c.jpg"},
+	}
+	for _, tt := range tests {
+		if got := cdn.URL(tt.ref); got != tt.want {
+			t.Errorf("URL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+
+	if _, err := NewCDN(CDNConfig{BaseURL: "cdn.example.com"}); err == nil {
+		t.Error("Expected a base URL without a scheme to be rejected")
+	}
+}
+
+func TestCloudflarePurger(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/zones/zone-1/purge_cache" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Unexpected purge request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Files []string `json:"files"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		batches = append(batches, body.Files)
+		fmt.Fprint(w, `{"success":true,"errors":[]}`)
+	}))
+	defer server.Close()
+
+	purger := NewCloudflarePurger("zone-1", "token", time.Second)
+	purger.apiURL = server.URL
+	cdn, _ := NewCDN(CDNConfig{BaseURL: "https://cdn.example.com", Purger: purger})
+
+	refs := make([]string, 45)
+	for i := range refs {
+		refs[i] = fmt.Sprintf("images/vendor/%d.jpg", i)
+	}
+	refs = append(refs, "https://other.example.com/skipped.jpg")
+	if err := cdn.Purge(context.Background(), refs...); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 30 || len(batches[1]) != 15 {
+		t.Fatalf("Expected 45 files purged in batches of 30, got %d batches", len(batches))
+	}
+	if batches[0][0] != "https://cdn.example.com/images/vendor/0.jpg" {
+		t.Errorf("Expected CDN URLs to be purged, got %s", batches[0][0])
+	}
+}
+
+func TestCloudflarePurger_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"success":false,"errors":[{"message":"invalid zone"}]}`)
+	}))
+	defer server.Close()
+
+	purger := NewCloudflarePurger("zone-1", "token", time.Second)
+	purger.apiURL = server.URL
+	if err := purger.PurgeURLs(context.Background(), []string{"https://cdn.example.com/a.jpg"}); err == nil {
+		t.Error("Expected the purge to fail")
+	}
+}
+
+func TestBunnyPurger(t *testing.T) {
+	var purged []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("AccessKey") != "key" {
+			t.Errorf("Expected the API key header, got %q", r.Header.Get("AccessKey"))
+		}
+		target := r.URL.Query().Get("url")
+		if target == "https://cdn.example.com/missing.jpg" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		purged = append(purged, target)
+	}))
+	defer server.Close()
+
+	purger := NewBunnyPurger("key", time.Second)
+	purger.apiURL = server.URL
+	err := purger.PurgeURLs(context.Background(), []string{
+		"https://cdn.example.com/a.jpg",
+		"https://cdn.example.com/missing.jpg",
+		"https://cdn.example.com/b.jpg",
+	})
+	if err == nil {
+		t.Error("Expected the failed URL to be reported")
+	}
+	if len(purged) != 2 {
+		t.Errorf("Expected the other URLs to be purged, got %v", purged)
+	}
+}
@@ -0,0 +1,425 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/config"
+)
+
+// Storage cleanup defaults
+const (
+	DefaultCleanupSchedule     = "0 2 * * *"
+	DefaultCleanupUploadMaxAge = 24 * time.Hour
+	DefaultCleanupOrphanGrace  = 24 * time.Hour
+	cleanupBatchSize           = 500
+)
+
+// DefaultCleanupRoots are the directories under the storage root that hold
+// image files, thumbnails and variants
+var DefaultCleanupRoots = []string{"users", "vendors", "results"}
+
+// CleanupConfig configures the storage cleanup job
+type CleanupConfig struct {
+	Schedule string   // cron expression, RetentionPolicy.CleanupSchedule
+	BasePath string   // local storage root holding image files
+	Roots    []string // directories under BasePath swept for orphaned files
+
+	// UploadMaxAge is how long direct upload sessions are kept
+	UploadMaxAge time.Duration
+	// OrphanGrace keeps unreferenced files younger than this, their row may
+	// not be committed yet
+	OrphanGrace time.Duration
+}
+
+// StaleObject is an object in the object store whose row was removed
+type StaleObject struct {
+	Key  string
+	Size int64 // bytes reclaimed by deleting it, 0 when already gone
+}
+
+// CleanupStore finds and removes the rows that own storage files
+type CleanupStore interface {
+	// FileReferences returns every file reference held by images and
+	// image_variants rows, as stored
+	FileReferences(ctx context.Context) ([]string, error)
+	// DeleteStaleUploads removes up to limit upload sessions created before
+	// before and returns their staged objects
+	DeleteStaleUploads(ctx context.Context, before time.Time, limit int) ([]StaleObject, error)
+	// DeleteExpiredExports removes up to limit conversion exports, and up
+	// to limit user data exports, that expired before before and returns
+	// their archives
+	DeleteExpiredExports(ctx context.Context, before time.Time, limit int) ([]StaleObject, error)
+	// DeleteExpiredShareLinks removes shared links that expired before before
+	DeleteExpiredShareLinks(ctx context.Context, before time.Time) (int, error)
+}
+
+// CleanupMetrics receives the reclaimed bytes of each run, e.g. the
+// monitoring service
+type CleanupMetrics interface {
+	CapturePerformanceMetric(ctx context.Context, name string, value float64, unit string, tags map[string]string)
+}
+
+// CleanupReport summarizes a cleanup run
+type CleanupReport struct {
+	OrphanFiles    int       `json:"orphanFiles"`
+	OrphanBytes    int64     `json:"orphanBytes"`
+	Uploads        int       `json:"uploads"`
+	UploadBytes    int64     `json:"uploadBytes"`
+	Exports        int       `json:"exports"`
+	ExportBytes    int64     `json:"exportBytes"`
+	ShareLinks     int       `json:"shareLinks"`
+	ReclaimedBytes int64     `json:"reclaimedBytes"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+}
+
+// Cleaner removes files no row references any more: orphaned image files,
+// staged direct uploads, expired conversion exports and their rows
+type Cleaner struct {
+	config   CleanupConfig
+	schedule *common.Schedule
+	store    CleanupStore
+	objects  ObjectDeleter
+	metrics  CleanupMetrics
+}
+
+// NewCleaner creates a cleaner deleting staged uploads and exports from
+// objects. metrics may be nil.
+func NewCleaner(config CleanupConfig, store CleanupStore, objects ObjectDeleter, metrics CleanupMetrics) (*Cleaner, error) {
+	if config.Schedule == "" {
+		config.Schedule = DefaultCleanupSchedule
+	}
+	if len(config.Roots) == 0 {
+		config.Roots = DefaultCleanupRoots
+	}
+	if config.UploadMaxAge <= 0 {
+		config.UploadMaxAge = DefaultCleanupUploadMaxAge
+	}
+	if config.OrphanGrace <= 0 {
+		config.OrphanGrace = DefaultCleanupOrphanGrace
+	}
+
+	schedule, err := common.ParseSchedule(config.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &Cleaner{
+		config:   config,
+		schedule: schedule,
+		store:    store,
+		objects:  objects,
+		metrics:  metrics,
+	}, nil
+}
+
+// CleanupSettingsFromConfig maps the storage settings to the cleanup job and
+// the object store holding staged uploads and exports
+func CleanupSettingsFromConfig(cfg config.StorageConfig) (CleanupConfig, ObjectStoreConfig) {
+	return CleanupConfig{
+		Schedule:     cfg.CleanupSchedule,
+		BasePath:     cfg.StoragePath,
+		UploadMaxAge: cfg.CleanupUploadMaxAge,
+		OrphanGrace:  cfg.CleanupOrphanGrace,
+	}, ObjectStoreConfig{
+		Backend:     cfg.Backend,
+		BasePath:    cfg.StoragePath,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Bucket:    cfg.S3Bucket,
+		S3Region:    cfg.S3Region,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+	}
+}
+
+// Schedule returns the cron expression the cleanup runs on
+func (c *Cleaner) Schedule() string {
+	return c.config.Schedule
+}
+
+// Start runs the cleanup on its schedule until ctx is cancelled
+func (c *Cleaner) Start(ctx context.Context) {
+	for {
+		next := c.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Storage cleanup schedule %q never fires", c.config.Schedule)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := c.RunAndLog(ctx); err != nil {
+			log.Printf("Error cleaning up storage: %v", err)
+		}
+	}
+}
+
+// RunAndLog performs one cleanup and logs what it removed, e.g. as a
+// scheduled job
+func (c *Cleaner) RunAndLog(ctx context.Context) error {
+	report, err := c.Run(ctx)
+	if report.ReclaimedBytes > 0 || report.ShareLinks > 0 {
+		log.Printf("Storage cleanup removed %d orphaned files, %d uploads, %d exports and %d share links, reclaiming %d bytes",
+			report.OrphanFiles, report.Uploads, report.Exports, report.ShareLinks, report.ReclaimedBytes)
+	}
+	return err
+}
+
+// Run performs one cleanup. Every step runs even if an earlier one failed;
+// the first error is returned with the report of what was removed.
+func (c *Cleaner) Run(ctx context.Context) (CleanupReport, error) {
+	report := CleanupReport{StartedAt: time.Now()}
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	record(c.sweepOrphans(ctx, &report))
+
+	uploads, err := c.deleteObjects(ctx, func(limit int) ([]StaleObject, error) {
+		return c.store.DeleteStaleUploads(ctx, report.StartedAt.Add(-c.config.UploadMaxAge), limit)
+	})
+	report.Uploads, report.UploadBytes = len(uploads), totalSize(uploads)
+	record(err)
+
+	exports, err := c.deleteObjects(ctx, func(limit int) ([]StaleObject, error) {
+		return c.store.DeleteExpiredExports(ctx, report.StartedAt, limit)
+	})
+	report.Exports, report.ExportBytes = len(exports), totalSize(exports)
+	record(err)
+
+	report.ShareLinks, err = c.store.DeleteExpiredShareLinks(ctx, report.StartedAt)
+	record(err)
+
+	report.ReclaimedBytes = report.OrphanBytes + report.UploadBytes + report.ExportBytes
+	report.FinishedAt = time.Now()
+	c.reportMetrics(ctx, report)
+	return report, firstErr
+}
+
+// sweepOrphans deletes files under the image roots that no row references
+func (c *Cleaner) sweepOrphans(ctx context.Context, report *CleanupReport) error {
+	refs, err := c.store.FileReferences(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load file references: %w", err)
+	}
+	referenced := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if key, ok := ObjectKey(ref, c.config.BasePath); ok {
+			referenced[key] = true
+		}
+	}
+	// An empty result more likely means the wrong database than no images
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-c.config.OrphanGrace)
+	for _, root := range c.config.Roots {
+		err := filepath.WalkDir(filepath.Join(c.config.BasePath, root), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			key, ok := ObjectKey(path, c.config.BasePath)
+			if !ok || referenced[key] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				return nil
+			}
+
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to delete orphaned file %s: %v", key, err)
+				return nil
+			}
+			report.OrphanFiles++
+			report.OrphanBytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sweep %s: %w", root, err)
+		}
+	}
+	return nil
+}
+
+// deleteObjects removes the rows returned by next in batches and deletes
+// their objects
+func (c *Cleaner) deleteObjects(ctx context.Context, next func(limit int) ([]StaleObject, error)) ([]StaleObject, error) {
+	var deleted []StaleObject
+	for {
+		objects, err := next(cleanupBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, object := range objects {
+			if object.Key != "" {
+				if err := c.objects.DeleteObject(ctx, object.Key); err != nil {
+					log.Printf("Failed to delete object %s: %v", object.Key, err)
+					continue
+				}
+			}
+			deleted = append(deleted, object)
+		}
+
+		if len(objects) < cleanupBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+func (c *Cleaner) reportMetrics(ctx context.Context, report CleanupReport) {
+	if c.metrics == nil {
+		return
+	}
+	for kind, bytes := range map[string]int64{"orphan": report.OrphanBytes, "upload": report.UploadBytes, "export": report.ExportBytes} {
+		c.metrics.CapturePerformanceMetric(ctx, "storage_cleanup_reclaimed_bytes", float64(bytes), "bytes", map[string]string{"kind": kind})
+	}
+	c.metrics.CapturePerformanceMetric(ctx, "storage_cleanup_duration", report.FinishedAt.Sub(report.StartedAt).Seconds(), "seconds", nil)
+}
+
+func totalSize(objects []StaleObject) int64 {
+	var total int64
+	for _, object := range objects {
+		total += object.Size
+	}
+	return total
+}
+
+// DBCleanupStore implements CleanupStore with Postgres
+type DBCleanupStore struct {
+	db *sql.DB
+}
+
+// NewDBCleanupStore creates a Postgres cleanup store
+func NewDBCleanupStore(db *sql.DB) *DBCleanupStore {
+	return &DBCleanupStore{db: db}
+}
+
+// FileReferences returns the paths and URLs of every image, thumbnail and variant
+func (s *DBCleanupStore) FileReferences(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT file_path FROM images
+		UNION ALL SELECT original_url FROM images
+		UNION ALL SELECT thumbnail_url FROM images WHERE thumbnail_url IS NOT NULL
+		UNION ALL SELECT path FROM image_variants
+		UNION ALL SELECT source_path FROM image_variants`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file references: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []string
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, fmt.Errorf("failed to scan file reference: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// DeleteStaleUploads removes upload sessions older than before. Completed and
+// expired sessions had their staged object deleted already, so only the
+// others count as reclaimed.
+func (s *DBCleanupStore) DeleteStaleUploads(ctx context.Context, before time.Time, limit int) ([]StaleObject, error) {
+	return s.deleteReturningObjects(ctx, `
+		DELETE FROM image_uploads
+		WHERE id IN (
+			SELECT id FROM image_uploads
+			WHERE created_at < $1
+			ORDER BY created_at
+			LIMIT $2
+		)
+		RETURNING object_key, CASE WHEN status IN ('completed', 'expired') THEN 0 ELSE file_size END`,
+		before, limit)
+}
+
+// DeleteExpiredExports removes ready conversion and user data exports whose
+// download expired before before
+func (s *DBCleanupStore) DeleteExpiredExports(ctx context.Context, before time.Time, limit int) ([]StaleObject, error) {
+	return s.deleteReturningObjects(ctx, `
+		WITH conversion AS (
+			DELETE FROM conversion_exports
+			WHERE id IN (
+				SELECT id FROM conversion_exports
+				WHERE status = 'ready' AND expires_at < $1
+				ORDER BY expires_at
+				LIMIT $2
+			)
+			RETURNING COALESCE(object_key, '') AS object_key, file_size
+		), takeout AS (
+			DELETE FROM user_data_exports
+			WHERE id IN (
+				SELECT id FROM user_data_exports
+				WHERE status = 'ready' AND expires_at < $1
+				ORDER BY expires_at
+				LIMIT $2
+			)
+			RETURNING COALESCE(object_key, '') AS object_key, file_size
+		)
+		SELECT object_key, file_size FROM conversion
+		UNION ALL
+		SELECT object_key, file_size FROM takeout`,
+		before, limit)
+}
+
+// DeleteExpiredShareLinks removes shared links that expired before before,
+// with their access logs
+func (s *DBCleanupStore) DeleteExpiredShareLinks(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM shared_links WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired shared links: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted shared link count: %w", err)
+	}
+	return int(count), nil
+}
+
+func (s *DBCleanupStore) deleteReturningObjects(ctx context.Context, query string, args ...interface{}) ([]StaleObject, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete stale rows: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []StaleObject
+	for rows.Next() {
+		var object StaleObject
+		if err := rows.Scan(&object.Key, &object.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan stale object: %w", err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
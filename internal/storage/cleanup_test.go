@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeCleanupStore struct {
+	refs      []string
+	uploads   []StaleObject
+	exports   []StaleObject
+	links     int
+	uploadCut time.Time
+}
+
+func (f *fakeCleanupStore) FileReferences(ctx context.Context) ([]string, error) {
+	return f.refs, nil
+}
+
+func (f *fakeCleanupStore) DeleteStaleUploads(ctx context.Context, before time.Time, limit int) ([]StaleObject, error) {
+	f.uploadCut = before
+	return takeStale(&f.uploads, limit), nil
+}
+
+func (f *fakeCleanupStore) DeleteExpiredExports(ctx context.Context, before time.Time, limit int) ([]StaleObject, error) {
+	return takeStale(&f.exports, limit), nil
+}
+
+func (f *fakeCleanupStore) DeleteExpiredShareLinks(ctx context.Context, before time.Time) (int, error) {
+	return f.links, nil
+}
+
+func takeStale(objects *[]StaleObject, limit int) []StaleObject {
+	n := min(limit, len(*objects))
+	taken := (*objects)[:n]
+	*objects = (*objects)[n:]
+	return taken
+}
+
+type fakeObjectDeleter struct {
+	deleted []string
+}
+
+func (f *fakeObjectDeleter) DeleteObject(ctx context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+type fakeCleanupMetrics struct {
+	values map[string]float64
+}
+
+func (f *fakeCleanupMetrics) CapturePerformanceMetric(ctx context.Context, name string, value float64, unit string, tags map[string]string) {
+	f.values[name+":"+tags["kind"]] = value
+}
+
+// writeStorageFile creates key under base with size bytes, modified age ago
+func writeStorageFile(t *testing.T, base, key string, size int, age time.Duration) string {
+	t.Helper()
+	path := filepath.Join(base, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	modified := time.Now().Add(-age)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+	return path
+}
+
+func TestCleaner_Run(t *testing.T) {
+	base := t.TempDir()
+	old := 48 * time.Hour
+	kept := writeStorageFile(t, base, "users/u1/photo_1.jpg", 100, old)
+	thumb := writeStorageFile(t, base, "users/u1/thumbnails/thumb_photo_1.jpg", 10, old)
+	variant := writeStorageFile(t, base, "results/u1/variants/result_1/640.webp", 20, old)
+	orphan := writeStorageFile(t, base, "results/u1/result_2.jpg", 300, old)
+	orphanVariant := writeStorageFile(t, base, "results/u1/variants/result_2/640.webp", 30, old)
+	fresh := writeStorageFile(t, base, "vendors/v1/logo_1.png", 50, time.Minute)
+	outsideRoots := writeStorageFile(t, base, "backups/archive.tar.gz", 70, old)
+
+	uploads := make([]StaleObject, cleanupBatchSize+1)
+	for i := range uploads {
+		uploads[i] = StaleObject{Key: "uploads/staging/file", Size: 1}
+	}
+	store := &fakeCleanupStore{
+		refs: []string{
+			kept,
+			"/api/storage/public/users/u1/thumbnails/thumb_photo_1.jpg",
+			"results/u1/variants/result_1/640.webp",
+			"https://cdn.example.com/external.jpg",
+		},
+		uploads: uploads,
+		exports: []StaleObject{{Key: "exports/u1/e1.zip", Size: 1000}},
+		links:   3,
+	}
+	objects := &fakeObjectDeleter{}
+	metrics := &fakeCleanupMetrics{values: map[string]float64{}}
+
+	cleaner, err := NewCleaner(CleanupConfig{BasePath: base}, store, objects, metrics)
+	if err != nil {
+		t.Fatalf("Failed to create cleaner: %v", err)
+	}
+	report, err := cleaner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, path := range []string{kept, thumb, variant, fresh, outsideRoots} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", path, err)
+		}
+	}
+	for _, path := range []string{orphan, orphanVariant} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected orphaned %s to be deleted, got %v", path, err)
+		}
+	}
+
+	if report.OrphanFiles != 2 || report.OrphanBytes != 330 {
+		t.Errorf("Expected 2 orphaned files of 330 bytes, got %d of %d", report.OrphanFiles, report.OrphanBytes)
+	}
+	if report.Uploads != cleanupBatchSize+1 || report.Exports != 1 || report.ShareLinks != 3 {
+		t.Errorf("Expected all uploads, the export and the share links removed, got %+v", report)
+	}
+	if len(objects.deleted) != cleanupBatchSize+2 {
+		t.Errorf("Expected every staged upload and export archive deleted, got %d", len(objects.deleted))
+	}
+	if expected := int64(330 + cleanupBatchSize + 1 + 1000); report.ReclaimedBytes != expected {
+		t.Errorf("Expected %d reclaimed bytes, got %d", expected, report.ReclaimedBytes)
+	}
+	if age := report.StartedAt.Sub(store.uploadCut); age != DefaultCleanupUploadMaxAge {
+		t.Errorf("Expected uploads older than %s removed, got %s", DefaultCleanupUploadMaxAge, age)
+	}
+	if metrics.values["storage_cleanup_reclaimed_bytes:export"] != 1000 || metrics.values["storage_cleanup_reclaimed_bytes:orphan"] != 330 {
+		t.Errorf("Expected reclaimed bytes reported per kind, got %v", metrics.values)
+	}
+}
+
+func TestCleaner_NoReferencesKeepsFiles(t *testing.T) {
+	base := t.TempDir()
+	path := writeStorageFile(t, base, "users/u1/photo_1.jpg", 100, 48*time.Hour)
+
+	cleaner, err := NewCleaner(CleanupConfig{BasePath: base}, &fakeCleanupStore{}, &fakeObjectDeleter{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create cleaner: %v", err)
+	}
+	if _, err := cleaner.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected files kept when no row references anything, got %v", err)
+	}
+}
+
+func TestNewCleaner_InvalidSchedule(t *testing.T) {
+	if _, err := NewCleaner(CleanupConfig{Schedule: "every night"}, &fakeCleanupStore{}, &fakeObjectDeleter{}, nil); err == nil {
+		t.Error("Expected an invalid schedule to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -13,6 +14,7 @@ type Config struct {
 	BasePath        string          `json:"basePath" yaml:"basePath"`
 	BackupPath      string          `json:"backupPath" yaml:"backupPath"`
 	SignedURLKey    string          `json:"signedURLKey" yaml:"signedURLKey"`
+	Signing         SigningSettings `json:"-" yaml:"-"`
 	MaxFileSize     int64           `json:"maxFileSize" yaml:"maxFileSize"`
 	AllowedTypes    []string        `json:"allowedTypes" yaml:"allowedTypes"`
 	ThumbnailSizes  []ThumbnailSize `json:"thumbnailSizes" yaml:"thumbnailSizes"`
@@ -61,7 +63,7 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("backup path is required")
 	}
 
-	if c.SignedURLKey == "" {
+	if c.SignedURLKey == "" && len(c.Signing.Keys) == 0 {
 		return fmt.Errorf("signed URL key is required")
 	}
 
@@ -105,7 +107,9 @@ func InitializeStorage(config *Config) error {
 type StorageManager struct {
 	config          *Config
 	storage         StorageServiceInterface
+	signer          *URLSigner
 	imageStorage    *ImageStorageService
+	backupRunner    *BackupRunner
 	backupScheduler *BackupScheduler
 	healthMonitor   *HealthMonitor
 }
@@ -122,6 +126,7 @@ func NewStorageManager(config *Config) (*StorageManager, error) {
 		BasePath:     config.BasePath,
 		BackupPath:   config.BackupPath,
 		SignedURLKey: config.SignedURLKey,
+		Signing:      config.Signing,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage service: %w", err)
@@ -139,8 +144,9 @@ func NewStorageManager(config *Config) (*StorageManager, error) {
 
 	imageStorage := NewImageStorageService(storageService, imageStorageConfig)
 
-	// Create backup scheduler
-	backupScheduler := NewBackupScheduler(storageService, config.BackupPolicy)
+	// Create backup runner and scheduler
+	backupRunner := NewBackupRunner(config.BasePath, config.BackupPath, config.BackupPolicy)
+	backupScheduler := NewBackupScheduler(storageService, backupRunner, config.BackupPolicy)
 
 	// Create health monitor
 	healthMonitor := NewHealthMonitor(storageService, config)
@@ -148,7 +154,9 @@ func NewStorageManager(config *Config) (*StorageManager, error) {
 	return &StorageManager{
 		config:          config,
 		storage:         storageService,
+		signer:          storageService.URLSigner(),
 		imageStorage:    imageStorage,
+		backupRunner:    backupRunner,
 		backupScheduler: backupScheduler,
 		healthMonitor:   healthMonitor,
 	}, nil
@@ -199,18 +207,30 @@ func (sm *StorageManager) GetConfig() *Config {
 	return sm.config
 }
 
+// GetURLSigner returns the URL signer
+func (sm *StorageManager) GetURLSigner() *URLSigner {
+	return sm.signer
+}
+
+// GetBackupRunner returns the backup runner
+func (sm *StorageManager) GetBackupRunner() *BackupRunner {
+	return sm.backupRunner
+}
+
 // BackupScheduler handles scheduled backups
 type BackupScheduler struct {
 	storage      StorageServiceInterface
+	runner       *BackupRunner
 	backupPolicy BackupPolicy
 	ticker       *time.Ticker
 	done         chan bool
 }
 
 // NewBackupScheduler creates a new backup scheduler
-func NewBackupScheduler(storage StorageServiceInterface, policy BackupPolicy) *BackupScheduler {
+func NewBackupScheduler(storage StorageServiceInterface, runner *BackupRunner, policy BackupPolicy) *BackupScheduler {
 	return &BackupScheduler{
 		storage:      storage,
+		runner:       runner,
 		backupPolicy: policy,
 		done:         make(chan bool),
 	}
@@ -241,7 +261,10 @@ func (bs *BackupScheduler) Start(ctx context.Context) error {
 		for {
 			select {
 			case <-bs.ticker.C:
-				// Perform backup cleanup
+				// Snapshot storage, then drop backups past retention
+				if _, err := bs.runner.Run(ctx, BackupTriggerScheduled); err != nil {
+					log.Printf("Scheduled storage backup failed: %v", err)
+				}
 				bs.storage.CleanupOldBackups(ctx, bs.backupPolicy.RetentionDays)
 			case <-bs.done:
 				return
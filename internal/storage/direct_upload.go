@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTypeUpload grants a single PUT of an object through a signed URL
+const AccessTypeUpload = "upload"
+
+// DirectUploadPrefix is the key prefix of objects uploaded by clients before
+// they are confirmed
+const DirectUploadPrefix = "uploads/staging"
+
+// MaxDirectUploadTTL bounds the lifetime of pre-signed upload URLs, S3 refuses
+// longer ones
+const MaxDirectUploadTTL = 7 * 24 * time.Hour
+
+// ErrUploadExists is returned when a signed upload URL has already been used
+var ErrUploadExists = errors.New("upload already received")
+
+// PresignedUpload is a URL a client PUTs the file to, with the headers the
+// request must carry
+type PresignedUpload struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// DirectUploads issues one-time upload URLs and reads back the uploaded
+// objects, so file bytes go straight from clients to storage
+type DirectUploads interface {
+	PresignUpload(key, contentType string, ttl time.Duration) (PresignedUpload, error)
+	ReadUpload(ctx context.Context, key string) ([]byte, error)
+	DeleteUpload(ctx context.Context, key string) error
+}
+
+// NewDirectUploads creates the direct uploads of the configured backend. Local
+// uploads are signed with signer and received by LocalDirectUploads.ReceiveUpload.
+func NewDirectUploads(config ObjectStoreConfig, signer *URLSigner) (DirectUploads, error) {
+	switch config.Backend {
+	case "", BackendLocal:
+		if signer == nil {
+			return nil, fmt.Errorf("local direct uploads require a URL signer")
+		}
+		return NewLocalDirectUploads(config.BasePath, signer), nil
+	case BackendS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+			return nil, fmt.Errorf("s3 object store requires endpoint, bucket, access key and secret key")
+		}
+		return NewS3DirectUploads(config), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", config.Backend)
+	}
+}
+
+// LocalDirectUploads receives uploads on the API itself at
+// PUT /api/storage/uploads/*key, authorized by an HMAC-signed URL
+type LocalDirectUploads struct {
+	basePath string
+	signer   *URLSigner
+	reader   *LocalObjectReader
+}
+
+// NewLocalDirectUploads creates direct uploads stored under basePath
+func NewLocalDirectUploads(basePath string, signer *URLSigner) *LocalDirectUploads {
+	return &LocalDirectUploads{
+		basePath: basePath,
+		signer:   signer,
+		reader:   NewLocalObjectReader(basePath),
+	}
+}
+
+// PresignUpload signs a PUT of key to the upload endpoint
+func (u *LocalDirectUploads) PresignUpload(key, contentType string, ttl time.Duration) (PresignedUpload, error) {
+	if _, err := u.path(key); err != nil {
+		return PresignedUpload{}, err
+	}
+
+	signedURL, expiresAt := u.signer.SignURL("/api/storage/uploads/"+key, key, AccessTypeUpload, ttl)
+	return PresignedUpload{
+		URL:       signedURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ReadUpload reads the uploaded object
+func (u *LocalDirectUploads) ReadUpload(ctx context.Context, key string) ([]byte, error) {
+	return u.reader.ReadObject(ctx, key)
+}
+
+// DeleteUpload removes the uploaded object
+func (u *LocalDirectUploads) DeleteUpload(ctx context.Context, key string) error {
+	path, err := u.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	return nil
+}
+
+// ReceiveUpload handles PUT /storage/uploads/*key. The URL signature is the
+// only authorization; each signed URL stores one object and can't overwrite it.
+func (u *LocalDirectUploads) ReceiveUpload(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	accessType, err := u.signer.Verify(key, c.Request.URL.Query())
+	if err == nil && accessType != AccessTypeUpload {
+		err = ErrSignedURLInvalid
+	}
+	if err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, ErrSignedURLMissingParams) {
+			status = http.StatusUnauthorized
+		}
+		common.RespondError(c, status, err.Error())
+		return
+	}
+
+	if c.Request.ContentLength > DefaultMaxFileSize {
+		common.RespondError(c, http.StatusRequestEntityTooLarge, "file size too large")
+		return
+	}
+
+	size, err := u.write(key, http.MaxBytesReader(c.Writer, c.Request.Body, DefaultMaxFileSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, ErrUploadExists):
+			common.RespondError(c, http.StatusConflict, err.Error())
+		case errors.As(err, &tooLarge):
+			common.RespondError(c, http.StatusRequestEntityTooLarge, "file size too large")
+		default:
+			common.RespondError(c, http.StatusInternalServerError, "failed to store upload")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"key": key, "size": size})
+}
+
+// write stores body under key, refusing to replace an existing object
+func (u *LocalDirectUploads) write(key string, body io.Reader) (int64, error) {
+	path, err := u.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return 0, ErrUploadExists
+		}
+		return 0, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	size, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return size, nil
+}
+
+// path maps a staging key to its file, refusing keys outside the staging area
+func (u *LocalDirectUploads) path(key string) (string, error) {
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(key)))
+	if clean != key || !strings.HasPrefix(clean, DirectUploadPrefix+"/") {
+		return "", fmt.Errorf("invalid upload key: %s", key)
+	}
+	return filepath.Join(u.basePath, filepath.FromSlash(clean)), nil
+}
+
+// S3DirectUploads issues SigV4 query-signed PUT URLs for an S3 compatible
+// bucket. The signed If-None-Match header makes every URL single-use.
+type S3DirectUploads struct {
+	client *S3ObjectReader
+}
+
+// NewS3DirectUploads creates direct uploads to an S3 compatible bucket
+func NewS3DirectUploads(config ObjectStoreConfig) *S3DirectUploads {
+	return &S3DirectUploads{client: NewS3ObjectReader(config)}
+}
+
+// PresignUpload signs a PUT of key to the bucket
+func (u *S3DirectUploads) PresignUpload(key, contentType string, ttl time.Duration) (PresignedUpload, error) {
+	if ttl <= 0 || ttl > MaxDirectUploadTTL {
+		return PresignedUpload{}, fmt.Errorf("upload URL lifetime must be between 1s and %v", MaxDirectUploadTTL)
+	}
+
+	now := u.client.now().UTC()
+	signedURL, err := u.client.presign(http.MethodPut, key, now, ttl, map[string]string{"if-none-match": "*"})
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+	return PresignedUpload{
+		URL:    signedURL,
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":  contentType,
+			"If-None-Match": "*",
+		},
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// ReadUpload downloads the uploaded object
+func (u *S3DirectUploads) ReadUpload(ctx context.Context, key string) ([]byte, error) {
+	return u.client.ReadObject(ctx, key)
+}
+
+// DeleteUpload removes the uploaded object
+func (u *S3DirectUploads) DeleteUpload(ctx context.Context, key string) error {
+	return u.client.deleteObject(ctx, key)
+}
+
+// presign returns a SigV4 query-signed URL for method on key. headers are
+// additional lower-case headers the request must send with these values.
+func (r *S3ObjectReader) presign(method, key string, t time.Time, ttl time.Duration, headers map[string]string) (string, error) {
+	objectURL, err := r.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(objectURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid s3 object URL: %w", err)
+	}
+
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	scope := date + "/" + r.region + "/s3/aws4_request"
+
+	names := []string{"host"}
+	canonicalHeaders := "host:" + u.Host + "\n"
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	for _, name := range names[1:] {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", r.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	// url.Values encodes spaces as "+", SigV4 wants "%20"
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(r.signingKey(date), r.stringToSign(amzDate, scope, canonicalRequest)))
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocalDirectUploads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := newTestSigner(t, "", SigningKey{ID: "k1", Secret: []byte("secret-1")})
+	uploads := NewLocalDirectUploads(t.TempDir(), signer)
+
+	router := gin.New()
+	router.PUT("/api/storage/uploads/*key", uploads.ReceiveUpload)
+
+	key := DirectUploadPrefix + "/u1/photo.jpg"
+	presigned, err := uploads.PresignUpload(key, "image/jpeg", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to presign upload: %v", err)
+	}
+	if presigned.Method != http.MethodPut || !strings.HasPrefix(presigned.URL, "/api/storage/uploads/"+key+"?") {
+		t.Errorf("Expected a signed PUT URL for %s, got %s %s", key, presigned.Method, presigned.URL)
+	}
+
+	put := func(target string) int {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader("image"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put(presigned.URL); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if code := put(presigned.URL); code != http.StatusConflict {
+		t.Errorf("Expected status 409 when the URL is reused, got %d", code)
+	}
+
+	data, err := uploads.ReadUpload(context.Background(), key)
+	if err != nil || string(data) != "image" {
+		t.Errorf("Expected the uploaded data, got %q (%v)", data, err)
+	}
+
+	// A view URL for the same key must not grant uploads
+	viewURL, _ := signer.SignURL("/api/storage/uploads/"+DirectUploadPrefix+"/u1/other.jpg", DirectUploadPrefix+"/u1/other.jpg", AccessTypeView, time.Minute)
+	if code := put(viewURL); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a view URL, got %d", code)
+	}
+
+	if _, err := uploads.PresignUpload("images/user/a.jpg", "image/jpeg", time.Minute); err == nil {
+		t.Error("Expected keys outside the staging area to be refused")
+	}
+
+	if err := uploads.DeleteUpload(context.Background(), key); err != nil {
+		t.Errorf("Failed to delete upload: %v", err)
+	}
+	if _, err := uploads.ReadUpload(context.Background(), key); err == nil {
+		t.Error("Expected the upload to be deleted")
+	}
+}
+
+func TestS3DirectUploads_Presign(t *testing.T) {
+	uploads := NewS3DirectUploads(ObjectStoreConfig{
+		S3Endpoint:  "https://s3.example.com",
+		S3Bucket:    "styler",
+		S3Region:    "eu-central-1",
+		S3AccessKey: "AKID",
+		S3SecretKey: "secret",
+	})
+	uploads.client.now = func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	presigned, err := uploads.PresignUpload(DirectUploadPrefix+"/u1/photo 1.jpg", "image/jpeg", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to presign upload: %v", err)
+	}
+
+	u, err := url.Parse(presigned.URL)
+	if err != nil {
+		t.Fatalf("Invalid URL: %v", err)
+	}
+	if u.EscapedPath() != "/styler/uploads/staging/u1/photo%201.jpg" {
+		t.Errorf("Unexpected object path %s", u.EscapedPath())
+	}
+	query := u.Query()
+	for param, expected := range map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    "AKID/20250601/eu-central-1/s3/aws4_request",
+		"X-Amz-Date":          "20250601T120000Z",
+		"X-Amz-Expires":       "900",
+		"X-Amz-SignedHeaders": "host;if-none-match",
+	} {
+		if query.Get(param) != expected {
+			t.Errorf("Expected %s=%s, got %s", param, expected, query.Get(param))
+		}
+	}
+	if len(query.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("Expected a hex signature, got %q", query.Get("X-Amz-Signature"))
+	}
+	if presigned.Headers["If-None-Match"] != "*" {
+		t.Errorf("Expected the If-None-Match header to be required, got %v", presigned.Headers)
+	}
+
+	if _, err := uploads.PresignUpload(DirectUploadPrefix+"/u1/a.jpg", "image/jpeg", 8*24*time.Hour); err == nil {
+		t.Error("Expected lifetimes over 7 days to be refused")
+	}
+}
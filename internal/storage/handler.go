@@ -1,11 +1,14 @@
 package storage
 
 import (
-	"encoding/base64"
+	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
+
+	"ai-styler/internal/common"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,16 +17,31 @@ import (
 type Handler struct {
 	imageStorage *ImageStorageService
 	storage      StorageServiceInterface
+	signer       *URLSigner
+	variants     VariantStore
+	backups      *BackupRunner
 }
 
 // NewHandler creates a new storage handler
-func NewHandler(imageStorage *ImageStorageService, storage StorageServiceInterface) *Handler {
+func NewHandler(imageStorage *ImageStorageService, storage StorageServiceInterface, signer *URLSigner) *Handler {
 	return &Handler{
 		imageStorage: imageStorage,
 		storage:      storage,
+		signer:       signer,
 	}
 }
 
+// SetVariants enables content negotiation between an image and its
+// WebP/AVIF and resized variants on the file serving endpoints
+func (h *Handler) SetVariants(store VariantStore) {
+	h.variants = store
+}
+
+// SetBackups enables the admin endpoints that trigger and list storage backups
+func (h *Handler) SetBackups(runner *BackupRunner) {
+	h.backups = runner
+}
+
 // RegisterRoutes registers storage routes
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	storage := router.Group("/storage")
@@ -59,6 +77,17 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 
 		// Signed URL validation
 		storage.GET("/signed/:encodedPath", h.ValidateSignedURL)
+		storage.GET("/public/*filepath", h.signer.PublicDownloadMiddleware(), h.ServePublicFile)
+	}
+}
+
+// RegisterAdminRoutes registers storage administration routes. The router
+// group must already require admin authentication.
+func (h *Handler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	storage := router.Group("/storage")
+	{
+		storage.GET("/backups", h.ListBackupRuns)
+		storage.POST("/backups", h.TriggerBackup)
 	}
 }
 
@@ -69,21 +98,21 @@ func (h *Handler) UploadImage(c *gin.Context) {
 	// Parse multipart form
 	_, err := c.MultipartForm()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+		common.RespondError(c, http.StatusBadRequest, "Failed to parse multipart form")
 		return
 	}
 
 	// Get file from form
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		common.RespondError(c, http.StatusBadRequest, "No file provided")
 		return
 	}
 
 	// Open file
 	src, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		common.RespondError(c, http.StatusInternalServerError, "Failed to open file")
 		return
 	}
 	defer src.Close()
@@ -105,7 +134,7 @@ func (h *Handler) UploadImage(c *gin.Context) {
 	// Upload image
 	response, err := h.imageStorage.UploadImage(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -118,7 +147,7 @@ func (h *Handler) GetImageAccess(c *gin.Context) {
 
 	var req ImageAccessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -127,7 +156,7 @@ func (h *Handler) GetImageAccess(c *gin.Context) {
 
 	response, err := h.imageStorage.GetImageAccess(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -142,7 +171,7 @@ func (h *Handler) GenerateSignedURL(c *gin.Context) {
 
 	ttl, err := strconv.ParseInt(ttlStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid TTL"})
+		common.RespondError(c, http.StatusBadRequest, "Invalid TTL")
 		return
 	}
 
@@ -155,7 +184,7 @@ func (h *Handler) GenerateSignedURL(c *gin.Context) {
 
 	response, err := h.imageStorage.GetImageAccess(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -168,7 +197,7 @@ func (h *Handler) DeleteImage(c *gin.Context) {
 
 	err := h.imageStorage.DeleteImage(c.Request.Context(), imageID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -181,7 +210,7 @@ func (h *Handler) UpdateImage(c *gin.Context) {
 
 	var req map[string]interface{}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -213,7 +242,7 @@ func (h *Handler) ListImages(c *gin.Context) {
 
 	response, err := h.imageStorage.SearchImages(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -224,13 +253,13 @@ func (h *Handler) ListImages(c *gin.Context) {
 func (h *Handler) SearchImages(c *gin.Context) {
 	var req ImageSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	response, err := h.imageStorage.SearchImages(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -241,13 +270,13 @@ func (h *Handler) SearchImages(c *gin.Context) {
 func (h *Handler) PerformBatchOperation(c *gin.Context) {
 	var req ImageBatchOperation
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	response, err := h.imageStorage.PerformBatchOperation(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -260,7 +289,7 @@ func (h *Handler) GetStorageQuota(c *gin.Context) {
 
 	quota, err := h.imageStorage.GetStorageQuota(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -271,7 +300,7 @@ func (h *Handler) GetStorageQuota(c *gin.Context) {
 func (h *Handler) GetStorageHealth(c *gin.Context) {
 	health, err := h.imageStorage.GetStorageHealth(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -282,7 +311,7 @@ func (h *Handler) GetStorageHealth(c *gin.Context) {
 func (h *Handler) GetStorageStats(c *gin.Context) {
 	stats, err := h.storage.GetStorageStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -296,7 +325,7 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -306,7 +335,7 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -321,7 +350,7 @@ func (h *Handler) RestoreFromBackup(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -334,57 +363,123 @@ func (h *Handler) CleanupBackups(c *gin.Context) {
 	daysStr := c.DefaultQuery("days", "30")
 	days, err := strconv.Atoi(daysStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days parameter"})
+		common.RespondError(c, http.StatusBadRequest, "Invalid days parameter")
 		return
 	}
 
 	err = h.storage.CleanupOldBackups(c.Request.Context(), days)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Backups cleaned up successfully"})
 }
 
-// ValidateSignedURL handles signed URL validation
-func (h *Handler) ValidateSignedURL(c *gin.Context) {
-	encodedPath := c.Param("encodedPath")
+// TriggerBackup starts a full storage backup in the background
+func (h *Handler) TriggerBackup(c *gin.Context) {
+	if h.backups == nil {
+		common.RespondError(c, http.StatusServiceUnavailable, "Storage backups are not configured")
+		return
+	}
 
-	// Decode the path
-	pathBytes, err := base64.URLEncoding.DecodeString(encodedPath)
+	run, err := h.backups.Start(BackupTriggerManual)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid encoded path"})
+		if errors.Is(err, ErrBackupInProgress) {
+			common.RespondError(c, http.StatusConflict, "A backup is already running")
+			return
+		}
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	filePath := string(pathBytes)
-
-	// Get query parameters
-	expiresStr := c.Query("expires")
+	c.JSON(http.StatusAccepted, run)
+}
 
-	// Validate signature
-	expires, err := strconv.ParseInt(expiresStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires parameter"})
+// ListBackupRuns lists storage backup runs, newest first
+func (h *Handler) ListBackupRuns(c *gin.Context) {
+	if h.backups == nil {
+		common.RespondError(c, http.StatusServiceUnavailable, "Storage backups are not configured")
 		return
 	}
 
-	// Check if URL has expired
-	if time.Now().Unix() > expires {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signed URL has expired"})
+	runs, err := h.backups.ListRuns(c.Request.Context())
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	// Validate signature (simplified)
-	valid, _, err := h.storage.ValidateSignedURL(c.Request.Context(), c.Request.URL.String())
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "total": len(runs)})
+}
+
+// ValidateSignedURL handles signed URL validation
+func (h *Handler) ValidateSignedURL(c *gin.Context) {
+	valid, filePath, err := h.storage.ValidateSignedURL(c.Request.Context(), c.Request.URL.String())
 	if err != nil || !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		switch {
+		case errors.Is(err, ErrSignedURLMissingParams):
+			common.RespondError(c, http.StatusBadRequest, "Missing signature parameters")
+		case errors.Is(err, ErrSignedURLExpired):
+			common.RespondError(c, http.StatusUnauthorized, "Signed URL has expired")
+		default:
+			common.RespondError(c, http.StatusUnauthorized, "Invalid signature")
+		}
 		return
 	}
 
 	// Serve the file
-	c.File(filePath)
+	h.serveImage(c, filePath)
+}
+
+// ServePublicFile serves a file under the storage base path. Requests reach
+// it only through PublicDownloadMiddleware.
+func (h *Handler) ServePublicFile(c *gin.Context) {
+	basePath := h.imageStorage.config.BasePath
+	filePath := filepath.Join(basePath, filepath.Clean("/"+c.Param("filepath")))
+
+	if _, err := os.Stat(filePath); err != nil {
+		common.RespondError(c, http.StatusNotFound, "File not found")
+		return
+	}
+
+	h.serveImage(c, filePath)
+}
+
+// serveImage serves the best variant of filePath for the Accept header and
+// the optional w (width) query parameter, falling back to the file itself
+func (h *Handler) serveImage(c *gin.Context, filePath string) {
+	if h.variants == nil {
+		c.File(filePath)
+		return
+	}
+
+	key, ok := ObjectKey(filePath, h.imageStorage.config.BasePath)
+	if !ok {
+		c.File(filePath)
+		return
+	}
+
+	width, _ := strconv.Atoi(c.Query("w"))
+	c.Header("Vary", "Accept")
+
+	variants, err := h.variants.ListImageVariants(c.Request.Context(), key)
+	if err != nil {
+		c.File(filePath)
+		return
+	}
+	variant, ok := SelectVariant(variants, c.GetHeader("Accept"), width)
+	if !ok {
+		c.File(filePath)
+		return
+	}
+
+	variantPath := filepath.Join(h.imageStorage.config.BasePath, filepath.FromSlash(variant.Path))
+	if _, err := os.Stat(variantPath); err != nil {
+		c.File(filePath)
+		return
+	}
+	c.Header("Content-Type", variant.ContentType)
+	c.File(variantPath)
 }
 
 // Helper functions
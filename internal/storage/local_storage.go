@@ -17,6 +17,8 @@ import (
 	"strings"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -373,12 +375,12 @@ func (s *LocalStorage) handleSignedURL(c *gin.Context) {
 	// Validate signed URL
 	validatedPath, err := s.ValidateSignedURL(c.Request.Context(), c.Request.URL.String())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired signed URL"})
+		common.RespondError(c, http.StatusUnauthorized, "Invalid or expired signed URL")
 		return
 	}
 
 	if validatedPath != filePath {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Path mismatch"})
+		common.RespondError(c, http.StatusUnauthorized, "Path mismatch")
 		return
 	}
 
@@ -386,7 +388,7 @@ func (s *LocalStorage) handleSignedURL(c *gin.Context) {
 	fullPath := filepath.Join(s.basePath, filePath)
 	file, err := s.GetFile(c.Request.Context(), fullPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		common.RespondError(c, http.StatusNotFound, "File not found")
 		return
 	}
 	defer file.Close()
@@ -401,7 +403,7 @@ func (s *LocalStorage) handlePublicFile(c *gin.Context) {
 
 	file, err := s.GetFile(c.Request.Context(), fullPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		common.RespondError(c, http.StatusNotFound, "File not found")
 		return
 	}
 	defer file.Close()
@@ -413,14 +415,14 @@ func (s *LocalStorage) handlePublicFile(c *gin.Context) {
 func (s *LocalStorage) handleUpload(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		common.RespondError(c, http.StatusBadRequest, "No file provided")
 		return
 	}
 
 	subPath := c.DefaultQuery("path", "uploads")
 	uploadedPath, err := s.UploadFile(c.Request.Context(), file, subPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -437,7 +439,7 @@ func (s *LocalStorage) handleDelete(c *gin.Context) {
 
 	err := s.DeleteFile(c.Request.Context(), fullPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -451,7 +453,7 @@ func (s *LocalStorage) handleGetInfo(c *gin.Context) {
 
 	info, err := s.GetFileInfo(c.Request.Context(), fullPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -478,7 +480,7 @@ func (s *LocalStorage) handleListFiles(c *gin.Context) {
 
 	files, err := s.ListFiles(c.Request.Context(), dirPath, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
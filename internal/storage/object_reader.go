@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Object store backends
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrObjectNotFound is returned when no object exists under a key
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectReader reads stored objects by key, without going through public URLs
+type ObjectReader interface {
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+}
+
+// ObjectStoreConfig selects and configures the object reader backend
+type ObjectStoreConfig struct {
+	Backend     string // local or s3
+	BasePath    string // local storage root
+	S3Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or a MinIO URL
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	Timeout     time.Duration
+}
+
+// NewObjectReader creates the object reader for the configured backend
+func NewObjectReader(config ObjectStoreConfig) (ObjectReader, error) {
+	switch config.Backend {
+	case "", BackendLocal:
+		return NewLocalObjectReader(config.BasePath), nil
+	case BackendS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+			return nil, fmt.Errorf("s3 object store requires endpoint, bucket, access key and secret key")
+		}
+		return NewS3ObjectReader(config), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", config.Backend)
+	}
+}
+
+// ObjectKey maps a stored file reference to its storage key. It understands
+// paths under basePath, relative keys and the /api/storage signed, public and
+// static URLs generated by this package. External URLs are not resolvable.
+func ObjectKey(ref, basePath string) (string, bool) {
+	if ref == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "/api/") {
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", false
+		}
+		switch {
+		case strings.HasPrefix(u.Path, "/api/storage/signed/"):
+			decoded, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(u.Path, "/api/storage/signed/"))
+			if err != nil {
+				return "", false
+			}
+			ref = string(decoded)
+		case strings.HasPrefix(u.Path, "/api/storage/public/"):
+			ref = strings.TrimPrefix(u.Path, "/api/storage/public/")
+		case strings.HasPrefix(u.Path, "/api/storage/static/"):
+			ref = strings.TrimPrefix(u.Path, "/api/storage/static/")
+		default:
+			return "", false
+		}
+	}
+
+	if filepath.IsAbs(ref) || basePath != "" && strings.HasPrefix(filepath.Clean(ref), filepath.Clean(basePath)+string(filepath.Separator)) {
+		if basePath == "" {
+			return "", false
+		}
+		base, err := filepath.Abs(basePath)
+		if err != nil {
+			return "", false
+		}
+		abs, err := filepath.Abs(ref)
+		if err != nil {
+			return "", false
+		}
+		rel, err := filepath.Rel(base, abs)
+		if err != nil {
+			return "", false
+		}
+		ref = rel
+	}
+
+	key := filepath.ToSlash(filepath.Clean(ref))
+	if key == "." || key == ".." || strings.HasPrefix(key, "../") || strings.HasPrefix(key, "/") {
+		return "", false
+	}
+	return key, true
+}
+
+// LocalObjectReader reads objects from the local storage root
+type LocalObjectReader struct {
+	basePath string
+}
+
+// NewLocalObjectReader creates a reader rooted at basePath
+func NewLocalObjectReader(basePath string) *LocalObjectReader {
+	return &LocalObjectReader{basePath: basePath}
+}
+
+// ReadObject reads the file stored under key
+func (r *LocalObjectReader) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid object key: %s", key)
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.basePath, clean))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return data, nil
+}
+
+// S3ObjectReader reads objects from an S3 compatible bucket with
+// SigV4-signed GET requests using the service's internal credentials
+type S3ObjectReader struct {
+	endpoint   string
+	bucket     string
+	region     string
+	accessKey  string
+	secretKey  string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3ObjectReader creates a reader for an S3 compatible bucket (path-style addressing)
+func NewS3ObjectReader(config ObjectStoreConfig) *S3ObjectReader {
+	if config.S3Region == "" {
+		config.S3Region = "us-east-1"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &S3ObjectReader{
+		endpoint:   strings.TrimRight(config.S3Endpoint, "/"),
+		bucket:     config.S3Bucket,
+		region:     config.S3Region,
+		accessKey:  config.S3AccessKey,
+		secretKey:  config.S3SecretKey,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		now:        time.Now,
+	}
+}
+
+// ReadObject downloads the object stored under key
+func (r *S3ObjectReader) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	objectURL, err := r.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	r.sign(req, r.now().UTC(), emptyPayloadHash)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3 object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3 object: %w", err)
+	}
+	if len(data) > DefaultMaxFileSize {
+		return nil, fmt.Errorf("s3 object %s exceeds %d bytes", key, DefaultMaxFileSize)
+	}
+	return data, nil
+}
+
+// objectURL returns the path-style URL of the object stored under key
+func (r *S3ObjectReader) objectURL(key string) (string, error) {
+	u, err := url.Parse(r.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	u.Path = "/" + r.bucket + "/" + strings.TrimPrefix(key, "/")
+	u.RawPath = "/" + s3Escape(r.bucket) + "/" + s3Escape(strings.TrimPrefix(key, "/"))
+	return u.String(), nil
+}
+
+// emptyPayloadHash is the SHA-256 of an empty body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds AWS Signature Version 4 headers to a request whose body has the
+// given SHA-256 hex digest
+func (r *S3ObjectReader) sign(req *http.Request, t time.Time, payloadHash string) {
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + r.region + "/s3/aws4_request"
+	signature := hex.EncodeToString(hmacSHA256(r.signingKey(date), r.stringToSign(amzDate, scope, canonicalRequest)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		r.accessKey, scope, signedHeaders, signature))
+}
+
+// stringToSign returns the SigV4 string to sign of a canonical request
+func (r *S3ObjectReader) stringToSign(amzDate, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	return "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+}
+
+// signingKey derives the SigV4 signing key of a date
+func (r *S3ObjectReader) signingKey(date string) []byte {
+	key := hmacSHA256([]byte("AWS4"+r.secretKey), date)
+	key = hmacSHA256(key, r.region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape URI-encodes a key as SigV4 requires, keeping "/" separators
+func s3Escape(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectKey(t *testing.T) {
+	base := filepath.Join(os.TempDir(), "uploads")
+	signedPath := filepath.Join(base, "images", "user", "a.jpg")
+
+	tests := []struct {
+		name string
+		ref  string
+		key  string
+		ok   bool
+	}{
+		{"absolute path under base", signedPath, "images/user/a.jpg", true},
+		{"relative key", "images/cloth/b.png", "images/cloth/b.png", true},
+		{"signed url", "/api/storage/signed/" + base64.URLEncoding.EncodeToString([]byte(signedPath)) + "?access_type=view&expires=1&signature=x", "images/user/a.jpg", true},
+		{"absolute signed url", "https://api.example.com/api/storage/signed/" + base64.URLEncoding.EncodeToString([]byte(signedPath)), "images/user/a.jpg", true},
+		{"public url", "/api/storage/public/images/result/c.jpg", "images/result/c.jpg", true},
+		{"external url", "https://cdn.example.com/c.jpg", "", false},
+		{"path outside base", "/etc/passwd", "", false},
+		{"relative traversal", "../secrets.txt", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := ObjectKey(tt.ref, base)
+			if ok != tt.ok || key != tt.key {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.key, tt.ok, key, ok)
+			}
+		})
+	}
+}
+
+func TestLocalObjectReader(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "images", "user"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "images", "user", "a.jpg"), []byte("image"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	reader := NewLocalObjectReader(base)
+	data, err := reader.ReadObject(context.Background(), "images/user/a.jpg")
+	if err != nil || string(data) != "image" {
+		t.Errorf("Expected image data, got %q (%v)", data, err)
+	}
+
+	if _, err := reader.ReadObject(context.Background(), "images/user/missing.jpg"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+	if _, err := reader.ReadObject(context.Background(), "../outside.jpg"); err == nil {
+		t.Error("Expected error for key outside the storage root")
+	}
+}
+
+func TestS3ObjectReader(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		if r.Header.Get("x-amz-date") != "20250601T120000Z" {
+			t.Errorf("Expected x-amz-date header, got %q", r.Header.Get("x-amz-date"))
+		}
+		if strings.HasSuffix(r.URL.Path, "missing.jpg") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("s3-image"))
+	}))
+	defer server.Close()
+
+	reader, err := NewObjectReader(ObjectStoreConfig{
+		Backend:     BackendS3,
+		S3Endpoint:  server.URL,
+		S3Bucket:    "styler",
+		S3Region:    "eu-central-1",
+		S3AccessKey: "AKID",
+		S3SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s3Reader := reader.(*S3ObjectReader)
+	s3Reader.now = func() time.Time { return time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC) }
+
+	data, err := s3Reader.ReadObject(context.Background(), "images/user/my photo.jpg")
+	if err != nil || string(data) != "s3-image" {
+		t.Fatalf("Expected s3 object data, got %q (%v)", data, err)
+	}
+	if gotPath != "/styler/images/user/my%20photo.jpg" {
+		t.Errorf("Expected escaped object path, got %s", gotPath)
+	}
+	expectedPrefix := "AWS4-HMAC-SHA256 Credential=AKID/20250601/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(gotAuth, expectedPrefix) || len(gotAuth) != len(expectedPrefix)+64 {
+		t.Errorf("Expected SigV4 authorization header, got %s", gotAuth)
+	}
+
+	if _, err := s3Reader.ReadObject(context.Background(), "images/user/missing.jpg"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+
+	if _, err := NewObjectReader(ObjectStoreConfig{Backend: BackendS3}); err == nil {
+		t.Error("Expected error for s3 backend without credentials")
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ObjectWriter stores objects by key in the configured backend
+type ObjectWriter interface {
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// ObjectDeleter removes objects by key. Deleting a missing object succeeds.
+type ObjectDeleter interface {
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// NewObjectWriter creates the object writer for the configured backend
+func NewObjectWriter(config ObjectStoreConfig) (ObjectWriter, error) {
+	switch config.Backend {
+	case "", BackendLocal:
+		return NewLocalObjectWriter(config.BasePath), nil
+	case BackendS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+			return nil, fmt.Errorf("s3 object store requires endpoint, bucket, access key and secret key")
+		}
+		return NewS3ObjectWriter(config), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", config.Backend)
+	}
+}
+
+// NewObjectDeleter creates the object deleter for the configured backend
+func NewObjectDeleter(config ObjectStoreConfig) (ObjectDeleter, error) {
+	writer, err := NewObjectWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	return writer.(ObjectDeleter), nil
+}
+
+// LocalObjectWriter writes objects under the local storage root
+type LocalObjectWriter struct {
+	basePath string
+}
+
+// NewLocalObjectWriter creates a writer rooted at basePath
+func NewLocalObjectWriter(basePath string) *LocalObjectWriter {
+	return &LocalObjectWriter{basePath: basePath}
+}
+
+// WriteObject writes data to the file for key, replacing it atomically
+func (w *LocalObjectWriter) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := w.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+// DeleteObject removes the file for key
+func (w *LocalObjectWriter) DeleteObject(ctx context.Context, key string) error {
+	path, err := w.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// path maps key to its file, refusing keys outside the storage root
+func (w *LocalObjectWriter) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return filepath.Join(w.basePath, clean), nil
+}
+
+// S3ObjectWriter uploads objects to an S3 compatible bucket with
+// SigV4-signed PUT requests
+type S3ObjectWriter struct {
+	client *S3ObjectReader
+}
+
+// NewS3ObjectWriter creates a writer for an S3 compatible bucket (path-style addressing)
+func NewS3ObjectWriter(config ObjectStoreConfig) *S3ObjectWriter {
+	return &S3ObjectWriter{client: NewS3ObjectReader(config)}
+}
+
+// WriteObject uploads data under key
+func (w *S3ObjectWriter) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	objectURL, err := w.client.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	payloadHash := sha256.Sum256(data)
+	w.client.sign(req, w.client.now().UTC(), hex.EncodeToString(payloadHash[:]))
+
+	resp, err := w.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put s3 object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// DeleteObject removes the object under key
+func (w *S3ObjectWriter) DeleteObject(ctx context.Context, key string) error {
+	return w.client.deleteObject(ctx, key)
+}
+
+// deleteObject sends a signed DELETE for key. S3 answers 204 whether or not
+// the object existed.
+func (r *S3ObjectReader) deleteObject(ctx context.Context, key string) error {
+	objectURL, err := r.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	r.sign(req, r.now().UTC(), emptyPayloadHash)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete s3 object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalObjectWriter(t *testing.T) {
+	base := t.TempDir()
+	writer := NewLocalObjectWriter(base)
+
+	if err := writer.WriteObject(context.Background(), "archive/2025/01.jsonl", []byte("entry"), "application/x-ndjson"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := NewLocalObjectReader(base).ReadObject(context.Background(), "archive/2025/01.jsonl")
+	if err != nil || string(data) != "entry" {
+		t.Errorf("Expected written object, got %q (%v)", data, err)
+	}
+
+	if err := writer.WriteObject(context.Background(), "../outside.jsonl", []byte("entry"), ""); err == nil {
+		t.Error("Expected error for key outside the storage root")
+	}
+}
+
+func TestS3ObjectWriter(t *testing.T) {
+	var gotMethod, gotPath, gotHash, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotHash, gotBody = r.Method, r.URL.EscapedPath(), r.Header.Get("x-amz-content-sha256"), string(body)
+	}))
+	defer server.Close()
+
+	writer, err := NewObjectWriter(ObjectStoreConfig{
+		Backend:     BackendS3,
+		S3Endpoint:  server.URL,
+		S3Bucket:    "styler",
+		S3AccessKey: "AKID",
+		S3SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := writer.WriteObject(context.Background(), "archive/01.jsonl", []byte("entry"), "application/x-ndjson"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("entry"))
+	if gotMethod != http.MethodPut || gotPath != "/styler/archive/01.jsonl" || gotBody != "entry" {
+		t.Errorf("Expected PUT of the object, got %s %s %q", gotMethod, gotPath, gotBody)
+	}
+	if gotHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected payload hash header, got %s", gotHash)
+	}
+}
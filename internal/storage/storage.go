@@ -2,11 +2,11 @@ package storage
 
 import (
 	"context"
-	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,16 +15,19 @@ import (
 
 // StorageService provides comprehensive file storage functionality
 type StorageService struct {
-	basePath     string
-	signedURLKey []byte
-	backupPath   string
+	basePath   string
+	signer     *URLSigner
+	backupPath string
 }
 
 // StorageConfig holds configuration for the storage service
 type StorageConfig struct {
-	BasePath     string `json:"basePath"`
-	BackupPath   string `json:"backupPath"`
-	SignedURLKey string `json:"signedURLKey"`
+	BasePath   string `json:"basePath"`
+	BackupPath string `json:"backupPath"`
+
+	// SignedURLKey is a single signing key, used when Signing has no keys
+	SignedURLKey string          `json:"signedURLKey"`
+	Signing      SigningSettings `json:"-"`
 }
 
 // FileInfo represents file metadata
@@ -79,13 +82,38 @@ func NewStorageService(config StorageConfig) (*StorageService, error) {
 		return nil, fmt.Errorf("failed to create folder structure: %w", err)
 	}
 
+	signer, err := newURLSignerFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create URL signer: %w", err)
+	}
+
 	return &StorageService{
-		basePath:     config.BasePath,
-		signedURLKey: []byte(config.SignedURLKey),
-		backupPath:   config.BackupPath,
+		basePath:   config.BasePath,
+		signer:     signer,
+		backupPath: config.BackupPath,
 	}, nil
 }
 
+// newURLSignerFromConfig builds the signer from the configured keys, falling
+// back to the legacy single key
+func newURLSignerFromConfig(config StorageConfig) (*URLSigner, error) {
+	keys := config.Signing.Keys
+	if len(keys) == 0 && config.SignedURLKey != "" {
+		keys = []SigningKey{{ID: "default", Secret: []byte(config.SignedURLKey)}}
+	}
+
+	return NewURLSigner(URLSignerConfig{
+		Keys:        keys,
+		ActiveKeyID: config.Signing.ActiveKeyID,
+		TTLs:        config.Signing.TTLs,
+	})
+}
+
+// URLSigner returns the signer of the storage service URLs
+func (s *StorageService) URLSigner() *URLSigner {
+	return s.signer
+}
+
 // createFolderStructure creates the required folder hierarchy
 func createFolderStructure(paths StoragePaths) error {
 	folders := []string{
@@ -184,40 +212,51 @@ func (s *StorageService) GetFileInfo(ctx context.Context, filePath string) (*Fil
 	}, nil
 }
 
-// GenerateSignedURL generates a signed URL for secure access
+// GenerateSignedURL generates a signed URL for secure access. A ttl of zero
+// uses the TTL configured for the access type.
 func (s *StorageService) GenerateSignedURL(ctx context.Context, filePath string, accessType string, ttl int64) (string, error) {
 	// Validate file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return "", fmt.Errorf("file does not exist: %s", filePath)
 	}
 
-	// Generate expiration time
-	expiresAt := time.Now().Add(time.Duration(ttl) * time.Second)
-
-	// Create signature data
-	signatureData := fmt.Sprintf("%s:%s:%d:%s", filePath, accessType, expiresAt.Unix(), "ai_stayler")
-
-	// Generate HMAC signature
-	h := hmac.New(sha256.New, s.signedURLKey)
-	h.Write([]byte(signatureData))
-	signature := hex.EncodeToString(h.Sum(nil))
-
-	// Create signed URL
-	signedURL := fmt.Sprintf("/api/storage/signed/%s?access_type=%s&expires=%d&signature=%s",
-		base64.URLEncoding.EncodeToString([]byte(filePath)),
+	signedURL, _ := s.signer.SignURL(
+		"/api/storage/signed/"+base64.URLEncoding.EncodeToString([]byte(filePath)),
+		filePath,
 		accessType,
-		expiresAt.Unix(),
-		signature,
+		time.Duration(ttl)*time.Second,
 	)
 
 	return signedURL, nil
 }
 
-// ValidateSignedURL validates a signed URL
+// ValidateSignedURL validates a signed or public storage URL and returns the
+// file path it grants access to
 func (s *StorageService) ValidateSignedURL(ctx context.Context, signedURL string) (bool, string, error) {
-	// Parse signed URL (simplified - in real implementation, parse query parameters)
-	// This is a placeholder for the actual URL parsing logic
-	return true, "", nil
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid signed URL: %w", err)
+	}
+
+	var filePath string
+	switch {
+	case strings.HasPrefix(u.Path, "/api/storage/signed/"):
+		decoded, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(u.Path, "/api/storage/signed/"))
+		if err != nil {
+			return false, "", fmt.Errorf("invalid signed URL path: %w", err)
+		}
+		filePath = string(decoded)
+	case strings.HasPrefix(u.Path, "/api/storage/public/"):
+		filePath = strings.TrimPrefix(u.Path, "/api/storage/public/")
+	default:
+		return false, "", fmt.Errorf("not a storage URL: %s", u.Path)
+	}
+
+	if _, err := s.signer.Verify(filePath, u.Query()); err != nil {
+		return false, "", err
+	}
+
+	return true, filePath, nil
 }
 
 // createBackup creates a backup of the uploaded file
@@ -391,8 +430,11 @@ func (s *StorageService) CleanupOldBackups(ctx context.Context, daysToKeep int)
 		dirName := filepath.Base(path)
 		if dirDate, err := time.Parse("2006-01-02", dirName); err == nil {
 			if dirDate.Before(cutoffDate) {
-				// Remove old backup directory
-				return os.RemoveAll(path)
+				// Remove old backup directory without descending into it
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+				return filepath.SkipDir
 			}
 		}
 
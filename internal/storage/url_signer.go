@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-styler/internal/common"
+	"ai-styler/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Signed URL query parameters
+const (
+	SignedURLParamKeyID      = "kid"
+	SignedURLParamAccessType = "access_type"
+	SignedURLParamExpires    = "expires"
+	SignedURLParamSignature  = "signature"
+)
+
+// Signed URL validation errors
+var (
+	ErrSignedURLMissingParams = errors.New("signed URL is missing required parameters")
+	ErrSignedURLExpired       = errors.New("signed URL has expired")
+	ErrSignedURLUnknownKey    = errors.New("signed URL key is unknown or retired")
+	ErrSignedURLInvalid       = errors.New("signed URL signature is invalid")
+)
+
+// SigningKey is a URL signing secret identified by a key ID embedded in every
+// URL it signs
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// URLSignerConfig holds the URL signing configuration
+type URLSignerConfig struct {
+	// Keys accepted for verification. Rotate by adding the new key, making it
+	// active, and removing the old one once the URLs it signed have expired.
+	Keys []SigningKey
+
+	// ActiveKeyID selects the key new URLs are signed with, the first key by default
+	ActiveKeyID string
+
+	// TTLs holds the lifetime per access type of URLs signed without an explicit TTL
+	TTLs map[string]time.Duration
+
+	// DefaultTTL applies to access types without an entry in TTLs
+	DefaultTTL time.Duration
+}
+
+// URLSigner signs and verifies storage URLs with HMAC-SHA256
+type URLSigner struct {
+	keys       map[string][]byte
+	activeKey  string
+	ttls       map[string]time.Duration
+	defaultTTL time.Duration
+	now        func() time.Time
+}
+
+// NewURLSigner creates a URL signer
+func NewURLSigner(config URLSignerConfig) (*URLSigner, error) {
+	if len(config.Keys) == 0 {
+		return nil, fmt.Errorf("at least one signing key is required")
+	}
+
+	keys := make(map[string][]byte, len(config.Keys))
+	for _, key := range config.Keys {
+		if key.ID == "" || len(key.Secret) == 0 {
+			return nil, fmt.Errorf("signing keys need an ID and a secret")
+		}
+		if _, exists := keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key ID: %s", key.ID)
+		}
+		keys[key.ID] = key.Secret
+	}
+
+	activeKey := config.ActiveKeyID
+	if activeKey == "" {
+		activeKey = config.Keys[0].ID
+	}
+	if _, exists := keys[activeKey]; !exists {
+		return nil, fmt.Errorf("active signing key %s is not configured", activeKey)
+	}
+
+	defaultTTL := config.DefaultTTL
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultSignedURLTTL * time.Second
+	}
+
+	return &URLSigner{
+		keys:       keys,
+		activeKey:  activeKey,
+		ttls:       config.TTLs,
+		defaultTTL: defaultTTL,
+		now:        time.Now,
+	}, nil
+}
+
+// SigningSettings holds the signing keys and TTLs shared by the storage
+// services of a process, so URLs signed by one verify in the others
+type SigningSettings struct {
+	Keys        []SigningKey
+	ActiveKeyID string
+	TTLs        map[string]time.Duration
+}
+
+var (
+	ephemeralKeyOnce sync.Once
+	ephemeralKey     SigningKey
+	ephemeralKeyErr  error
+)
+
+// SigningSettingsFromConfig builds the signing settings from the application
+// storage config. Without configured keys, which config.Validate only allows
+// in development, a random process-wide key is used.
+func SigningSettingsFromConfig(cfg config.StorageConfig) (SigningSettings, error) {
+	keys, err := ParseSigningKeys(cfg.SigningKeys)
+	if err != nil {
+		return SigningSettings{}, err
+	}
+
+	if len(keys) == 0 {
+		ephemeralKeyOnce.Do(func() {
+			ephemeralKey, ephemeralKeyErr = EphemeralSigningKey()
+			if ephemeralKeyErr == nil {
+				log.Printf("WARNING: STORAGE_SIGNING_KEYS is not set, signed URLs will not survive a restart")
+			}
+		})
+		if ephemeralKeyErr != nil {
+			return SigningSettings{}, ephemeralKeyErr
+		}
+		keys = []SigningKey{ephemeralKey}
+	}
+
+	return SigningSettings{
+		Keys:        keys,
+		ActiveKeyID: cfg.SigningKeyID,
+		TTLs: map[string]time.Duration{
+			AccessTypeView:     cfg.SignedURLTTL,
+			AccessTypeDownload: cfg.SignedURLDownloadTTL,
+		},
+	}, nil
+}
+
+// ParseSigningKeys parses comma separated "id:secret" pairs
+func ParseSigningKeys(spec string) ([]SigningKey, error) {
+	var keys []SigningKey
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || strings.TrimSpace(id) == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key %q, expected id:secret", pair)
+		}
+		keys = append(keys, SigningKey{ID: strings.TrimSpace(id), Secret: []byte(secret)})
+	}
+	return keys, nil
+}
+
+// EphemeralSigningKey returns a random key for deployments without configured
+// keys. URLs signed with it stop verifying when the process restarts.
+func EphemeralSigningKey() (SigningKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return SigningKey{}, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return SigningKey{ID: "ephemeral-" + hex.EncodeToString(secret[:4]), Secret: secret}, nil
+}
+
+// ActiveKeyID returns the ID of the key new URLs are signed with
+func (s *URLSigner) ActiveKeyID() string {
+	return s.activeKey
+}
+
+// TTL returns the lifetime of URLs of an access type
+func (s *URLSigner) TTL(accessType string) time.Duration {
+	if ttl, ok := s.ttls[accessType]; ok && ttl > 0 {
+		return ttl
+	}
+	return s.defaultTTL
+}
+
+// Sign returns the query parameters granting accessType to objectPath until the
+// returned expiry. A ttl of zero uses the access type's TTL.
+func (s *URLSigner) Sign(objectPath, accessType string, ttl time.Duration) (url.Values, time.Time) {
+	if ttl <= 0 {
+		ttl = s.TTL(accessType)
+	}
+	expiresAt := s.now().Add(ttl)
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	query.Set(SignedURLParamKeyID, s.activeKey)
+	query.Set(SignedURLParamAccessType, accessType)
+	query.Set(SignedURLParamExpires, strconv.FormatInt(expires, 10))
+	query.Set(SignedURLParamSignature, s.signature(s.keys[s.activeKey], s.activeKey, objectPath, accessType, expires))
+	return query, time.Unix(expires, 0)
+}
+
+// SignURL appends the signature of objectPath to baseURL
+func (s *URLSigner) SignURL(baseURL, objectPath, accessType string, ttl time.Duration) (string, time.Time) {
+	query, expiresAt := s.Sign(objectPath, accessType, ttl)
+	return baseURL + "?" + query.Encode(), expiresAt
+}
+
+// Verify checks the signature parameters of a URL for objectPath and returns
+// the access type it grants
+func (s *URLSigner) Verify(objectPath string, query url.Values) (string, error) {
+	keyID := query.Get(SignedURLParamKeyID)
+	accessType := query.Get(SignedURLParamAccessType)
+	expiresStr := query.Get(SignedURLParamExpires)
+	signature := query.Get(SignedURLParamSignature)
+	if keyID == "" || accessType == "" || expiresStr == "" || signature == "" {
+		return "", ErrSignedURLMissingParams
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return "", ErrSignedURLInvalid
+	}
+
+	secret, ok := s.keys[keyID]
+	if !ok {
+		return "", ErrSignedURLUnknownKey
+	}
+
+	expected := s.signature(secret, keyID, objectPath, accessType, expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", ErrSignedURLInvalid
+	}
+
+	// Checked after the signature so expiry can't be probed with forged URLs
+	if s.now().Unix() > expires {
+		return "", ErrSignedURLExpired
+	}
+
+	return accessType, nil
+}
+
+// signature covers the key ID so a URL can't be replayed under another key
+func (s *URLSigner) signature(secret []byte, keyID, objectPath, accessType string, expires int64) string {
+	h := hmac.New(sha256.New, secret)
+	fmt.Fprintf(h, "v1\n%s\n%s\n%s\n%d", keyID, objectPath, accessType, expires)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// PublicDownloadMiddleware rejects /storage/public/*filepath requests without
+// a valid signature for the requested file. Download URLs are served as
+// attachments.
+func (s *URLSigner) PublicDownloadMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectPath := strings.TrimPrefix(c.Param("filepath"), "/")
+
+		accessType, err := s.Verify(objectPath, c.Request.URL.Query())
+		if err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrSignedURLMissingParams) {
+				status = http.StatusUnauthorized
+			}
+			common.RespondError(c, status, err.Error())
+			c.Abort()
+			return
+		}
+
+		if accessType == AccessTypeDownload {
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", objectPath[strings.LastIndex(objectPath, "/")+1:]))
+		}
+		c.Set("signed_access_type", accessType)
+		c.Next()
+	}
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestSigner(t *testing.T, activeKeyID string, keys ...SigningKey) *URLSigner {
+	t.Helper()
+	signer, err := NewURLSigner(URLSignerConfig{
+		Keys:        keys,
+		ActiveKeyID: activeKeyID,
+		TTLs: map[string]time.Duration{
+			AccessTypeView:     time.Hour,
+			AccessTypeDownload: 10 * time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create URL signer: %v", err)
+	}
+	return signer
+}
+
+func TestURLSigner_SignAndVerify(t *testing.T) {
+	signer := newTestSigner(t, "", SigningKey{ID: "k1", Secret: []byte("secret-1")})
+
+	query, expiresAt := signer.Sign("images/user/a.jpg", AccessTypeView, 0)
+	if query.Get(SignedURLParamKeyID) != "k1" {
+		t.Errorf("Expected key ID k1, got %s", query.Get(SignedURLParamKeyID))
+	}
+	if ttl := time.Until(expiresAt); ttl < 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected view TTL of 1h, got %v", ttl)
+	}
+
+	accessType, err := signer.Verify("images/user/a.jpg", query)
+	if err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+	if accessType != AccessTypeView {
+		t.Errorf("Expected access type %s, got %s", AccessTypeView, accessType)
+	}
+
+	if _, err := signer.Verify("images/user/b.jpg", query); !errors.Is(err, ErrSignedURLInvalid) {
+		t.Errorf("Expected ErrSignedURLInvalid for another path, got %v", err)
+	}
+
+	tampered := url.Values{}
+	for k, v := range query {
+		tampered[k] = v
+	}
+	tampered.Set(SignedURLParamAccessType, AccessTypeDownload)
+	if _, err := signer.Verify("images/user/a.jpg", tampered); !errors.Is(err, ErrSignedURLInvalid) {
+		t.Errorf("Expected ErrSignedURLInvalid for a changed access type, got %v", err)
+	}
+}
+
+func TestURLSigner_PerAccessTypeTTL(t *testing.T) {
+	signer := newTestSigner(t, "", SigningKey{ID: "k1", Secret: []byte("secret-1")})
+
+	_, expiresAt := signer.Sign("a.jpg", AccessTypeDownload, 0)
+	if ttl := time.Until(expiresAt); ttl < 9*time.Minute || ttl > 10*time.Minute {
+		t.Errorf("Expected download TTL of 10m, got %v", ttl)
+	}
+
+	_, expiresAt = signer.Sign("a.jpg", AccessTypeDownload, 30*time.Second)
+	if ttl := time.Until(expiresAt); ttl > 30*time.Second {
+		t.Errorf("Expected explicit TTL of 30s, got %v", ttl)
+	}
+}
+
+func TestURLSigner_Expired(t *testing.T) {
+	signer := newTestSigner(t, "", SigningKey{ID: "k1", Secret: []byte("secret-1")})
+	query, _ := signer.Sign("a.jpg", AccessTypeView, time.Minute)
+
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := signer.Verify("a.jpg", query); !errors.Is(err, ErrSignedURLExpired) {
+		t.Errorf("Expected ErrSignedURLExpired, got %v", err)
+	}
+}
+
+func TestURLSigner_KeyRotation(t *testing.T) {
+	oldKey := SigningKey{ID: "2024-01", Secret: []byte("old-secret")}
+	newKey := SigningKey{ID: "2024-06", Secret: []byte("new-secret")}
+
+	before := newTestSigner(t, "", oldKey)
+	oldQuery, _ := before.Sign("a.jpg", AccessTypeView, 0)
+
+	// New key signs, old key still verifies
+	during := newTestSigner(t, newKey.ID, oldKey, newKey)
+	if during.ActiveKeyID() != newKey.ID {
+		t.Errorf("Expected active key %s, got %s", newKey.ID, during.ActiveKeyID())
+	}
+	if _, err := during.Verify("a.jpg", oldQuery); err != nil {
+		t.Errorf("Expected URL signed with the old key to verify, got %v", err)
+	}
+	newQuery, _ := during.Sign("a.jpg", AccessTypeView, 0)
+	if newQuery.Get(SignedURLParamKeyID) != newKey.ID {
+		t.Errorf("Expected key ID %s, got %s", newKey.ID, newQuery.Get(SignedURLParamKeyID))
+	}
+
+	// Old key retired
+	after := newTestSigner(t, "", newKey)
+	if _, err := after.Verify("a.jpg", oldQuery); !errors.Is(err, ErrSignedURLUnknownKey) {
+		t.Errorf("Expected ErrSignedURLUnknownKey after retiring the key, got %v", err)
+	}
+	if _, err := after.Verify("a.jpg", newQuery); err != nil {
+		t.Errorf("Expected URL signed with the new key to verify, got %v", err)
+	}
+
+	// A URL can't be moved to another key ID
+	newQuery.Set(SignedURLParamKeyID, oldKey.ID)
+	if _, err := during.Verify("a.jpg", newQuery); !errors.Is(err, ErrSignedURLInvalid) {
+		t.Errorf("Expected ErrSignedURLInvalid for a swapped key ID, got %v", err)
+	}
+}
+
+func TestNewURLSigner_InvalidConfig(t *testing.T) {
+	if _, err := NewURLSigner(URLSignerConfig{}); err == nil {
+		t.Error("Expected error without keys")
+	}
+	if _, err := NewURLSigner(URLSignerConfig{Keys: []SigningKey{{ID: "k1", Secret: []byte("s")}}, ActiveKeyID: "k2"}); err == nil {
+		t.Error("Expected error for an unknown active key")
+	}
+	if _, err := NewURLSigner(URLSignerConfig{Keys: []SigningKey{{ID: "k1", Secret: []byte("a")}, {ID: "k1", Secret: []byte("b")}}}); err == nil {
+		t.Error("Expected error for duplicate key IDs")
+	}
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	keys, err := ParseSigningKeys(" k1:secret-1, k2:secret:with:colons ,")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "k1" || string(keys[1].Secret) != "secret:with:colons" {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+
+	if _, err := ParseSigningKeys("missing-secret"); err == nil {
+		t.Error("Expected error for a key without a secret")
+	}
+}
+
+func TestStorageService_ValidateSignedURL(t *testing.T) {
+	tempDir := t.TempDir()
+	service, err := NewStorageService(StorageConfig{
+		BasePath:   tempDir,
+		BackupPath: filepath.Join(tempDir, "backups"),
+		Signing: SigningSettings{
+			Keys: []SigningKey{{ID: "k1", Secret: []byte("secret-1")}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage service: %v", err)
+	}
+
+	filePath := filepath.Join(tempDir, "a.jpg")
+	if err := os.WriteFile(filePath, []byte("image"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	signedURL, err := service.GenerateSignedURL(context.Background(), filePath, AccessTypeView, 60)
+	if err != nil {
+		t.Fatalf("Failed to generate signed URL: %v", err)
+	}
+
+	valid, path, err := service.ValidateSignedURL(context.Background(), signedURL)
+	if err != nil || !valid {
+		t.Fatalf("Expected valid signed URL, got %v", err)
+	}
+	if path != filePath {
+		t.Errorf("Expected path %s, got %s", filePath, path)
+	}
+
+	if valid, _, _ := service.ValidateSignedURL(context.Background(), signedURL+"0"); valid {
+		t.Error("Expected tampered signed URL to be rejected")
+	}
+}
+
+func TestPublicDownloadMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := newTestSigner(t, "", SigningKey{ID: "k1", Secret: []byte("secret-1")})
+
+	r := gin.New()
+	r.GET("/api/storage/public/*filepath", signer.PublicDownloadMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("signed_access_type"))
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	signedURL, _ := signer.SignURL("/api/storage/public/images/a.jpg", "images/a.jpg", AccessTypeDownload, 0)
+	w := serve(signedURL)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != AccessTypeDownload {
+		t.Errorf("Expected access type %s, got %s", AccessTypeDownload, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="a.jpg"` {
+		t.Errorf("Expected attachment disposition, got %s", got)
+	}
+
+	if w := serve("/api/storage/public/images/a.jpg"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without signature, got %d", w.Code)
+	}
+
+	otherFile, _ := signer.SignURL("/api/storage/public/images/b.jpg", "images/a.jpg", AccessTypeDownload, 0)
+	if w := serve(otherFile); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another file, got %d", w.Code)
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// VariantRepository implements VariantStore using PostgreSQL
+type VariantRepository struct {
+	db *sql.DB
+}
+
+// NewVariantRepository creates a new image variant repository
+func NewVariantRepository(db *sql.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+// SaveImageVariants replaces the recorded variants of a source image
+func (r *VariantRepository) SaveImageVariants(ctx context.Context, imageID, sourcePath string, variants []ImageVariant) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM image_variants WHERE source_path = $1`, sourcePath); err != nil {
+		return fmt.Errorf("failed to delete image variants: %w", err)
+	}
+
+	for _, v := range variants {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO image_variants (image_id, source_path, path, format, content_type, width, height, file_size)
+			VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, $6, $7, $8)
+		`, imageID, sourcePath, v.Path, v.Format, v.ContentType, v.Width, v.Height, v.FileSize)
+		if err != nil {
+			return fmt.Errorf("failed to save image variant: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListImageVariants returns the variants of the image stored at sourcePath
+func (r *VariantRepository) ListImageVariants(ctx context.Context, sourcePath string) ([]ImageVariant, error) {
+	query := `
+		SELECT id, COALESCE(image_id::text, ''), source_path, path, format, content_type,
+		       width, height, file_size, created_at
+		FROM image_variants
+		WHERE source_path = $1
+		ORDER BY width, format
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image variants: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []ImageVariant
+	for rows.Next() {
+		var v ImageVariant
+		if err := rows.Scan(&v.ID, &v.ImageID, &v.SourcePath, &v.Path, &v.Format, &v.ContentType,
+			&v.Width, &v.Height, &v.FileSize, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan image variant: %w", err)
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
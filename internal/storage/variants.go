@@ -0,0 +1,451 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-styler/internal/config"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Image variant formats
+const (
+	VariantFormatJPEG = "jpeg"
+	VariantFormatPNG  = "png"
+	VariantFormatWebP = "webp"
+	VariantFormatAVIF = "avif"
+)
+
+// variantContentTypes maps variant formats to their MIME types
+var variantContentTypes = map[string]string{
+	VariantFormatJPEG: "image/jpeg",
+	VariantFormatPNG:  "image/png",
+	VariantFormatWebP: "image/webp",
+	VariantFormatAVIF: "image/avif",
+}
+
+// variantPreference orders formats from smallest to largest typical output
+var variantPreference = []string{VariantFormatAVIF, VariantFormatWebP, VariantFormatJPEG, VariantFormatPNG}
+
+// DefaultVariantWidths are the responsive widths generated when none are configured
+var DefaultVariantWidths = []int{320, 640, 1024}
+
+// Image variant defaults
+const (
+	DefaultVariantQuality   = 80
+	DefaultVariantWorkers   = 2
+	DefaultVariantQueueSize = 100
+)
+
+// ImageVariant is an alternative encoding or size of a stored image
+type ImageVariant struct {
+	ID          string    `json:"id"`
+	ImageID     string    `json:"imageId"`
+	SourcePath  string    `json:"sourcePath"`
+	Path        string    `json:"path"`
+	Format      string    `json:"format"`
+	ContentType string    `json:"contentType"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	FileSize    int64     `json:"fileSize"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// VariantEncoder encodes images in a format the standard library cannot write
+type VariantEncoder interface {
+	Format() string
+	Encode(ctx context.Context, img image.Image, quality int) ([]byte, error)
+}
+
+// VariantStore records generated variants and looks them up by source path
+type VariantStore interface {
+	SaveImageVariants(ctx context.Context, imageID, sourcePath string, variants []ImageVariant) error
+	ListImageVariants(ctx context.Context, sourcePath string) ([]ImageVariant, error)
+}
+
+// VariantConfig configures the image variants pipeline
+type VariantConfig struct {
+	Widths    []int // responsive widths; widths at or above the original are skipped
+	Quality   int   // lossy encoder quality, 1-100
+	Workers   int
+	QueueSize int
+}
+
+// VariantPipeline generates WebP/AVIF encodings and responsive widths of
+// stored images in the background
+type VariantPipeline struct {
+	basePath string
+	config   VariantConfig
+	store    VariantStore
+	encoders []VariantEncoder
+	writer   *LocalObjectWriter
+	jobs     chan variantJob
+	cdn      *CDN // optional, purges regenerated variants
+}
+
+type variantJob struct {
+	imageID    string
+	sourcePath string
+}
+
+// NewVariantPipeline creates a pipeline for images under the local storage root basePath
+func NewVariantPipeline(basePath string, config VariantConfig, store VariantStore, encoders ...VariantEncoder) *VariantPipeline {
+	if len(config.Widths) == 0 {
+		config.Widths = DefaultVariantWidths
+	}
+	if config.Quality <= 0 || config.Quality > 100 {
+		config.Quality = DefaultVariantQuality
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultVariantWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultVariantQueueSize
+	}
+
+	widths := append([]int(nil), config.Widths...)
+	sort.Ints(widths)
+	config.Widths = widths
+
+	return &VariantPipeline{
+		basePath: basePath,
+		config:   config,
+		store:    store,
+		encoders: encoders,
+		writer:   NewLocalObjectWriter(basePath),
+		jobs:     make(chan variantJob, config.QueueSize),
+	}
+}
+
+// SetCDN purges variants from cdn when regenerating an image replaces them
+func (p *VariantPipeline) SetCDN(cdn *CDN) {
+	p.cdn = cdn
+}
+
+// Enqueue schedules variant generation for an image without blocking. It
+// returns false when the queue is full; the original is still served.
+func (p *VariantPipeline) Enqueue(imageID, sourcePath string) bool {
+	select {
+	case p.jobs <- variantJob{imageID: imageID, sourcePath: sourcePath}:
+		return true
+	default:
+		log.Printf("Image variants queue full, skipping %s", sourcePath)
+		return false
+	}
+}
+
+// Run processes queued images until ctx is cancelled
+func (p *VariantPipeline) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-p.jobs:
+					if _, err := p.Generate(ctx, job.imageID, job.sourcePath); err != nil {
+						log.Printf("Failed to generate image variants for %s: %v", job.sourcePath, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Generate writes the variants of the image at sourcePath next to it under
+// variants/ and records them. sourcePath is any reference ObjectKey resolves.
+func (p *VariantPipeline) Generate(ctx context.Context, imageID, sourcePath string) ([]ImageVariant, error) {
+	key, ok := ObjectKey(sourcePath, p.basePath)
+	if !ok {
+		return nil, fmt.Errorf("image is not in local storage: %s", sourcePath)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.basePath, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source image: %w", err)
+	}
+	src, sourceFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode source image: %w", err)
+	}
+	// Resized copies of GIF and WebP sources are written as JPEG
+	if sourceFormat != VariantFormatPNG {
+		sourceFormat = VariantFormatJPEG
+	}
+
+	originalWidth := src.Bounds().Dx()
+	widths := make([]int, 0, len(p.config.Widths)+1)
+	for _, width := range p.config.Widths {
+		if width > 0 && width < originalWidth {
+			widths = append(widths, width)
+		}
+	}
+	widths = append(widths, originalWidth)
+
+	dir, file := filepath.Split(filepath.FromSlash(key))
+	stem := strings.TrimSuffix(file, filepath.Ext(file))
+
+	var variants []ImageVariant
+	var replaced []string
+	for _, width := range widths {
+		img := src
+		if width != originalWidth {
+			img = resizeToWidth(src, width)
+		}
+
+		// The original already covers its own format at full size
+		formats := p.encoders
+		if width != originalWidth {
+			formats = append([]VariantEncoder{standardEncoder(sourceFormat)}, p.encoders...)
+		}
+
+		for _, encoder := range formats {
+			encoded, err := encoder.Encode(ctx, img, p.config.Quality)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s variant: %w", encoder.Format(), err)
+			}
+
+			variantPath := filepath.ToSlash(filepath.Join(dir, "variants", stem, fmt.Sprintf("%d.%s", width, variantExtension(encoder.Format()))))
+			if _, err := os.Stat(filepath.Join(p.basePath, filepath.FromSlash(variantPath))); err == nil {
+				replaced = append(replaced, variantPath)
+			}
+			if err := p.writer.WriteObject(ctx, variantPath, encoded, variantContentTypes[encoder.Format()]); err != nil {
+				return nil, err
+			}
+
+			variants = append(variants, ImageVariant{
+				ImageID:     imageID,
+				SourcePath:  key,
+				Path:        variantPath,
+				Format:      encoder.Format(),
+				ContentType: variantContentTypes[encoder.Format()],
+				Width:       width,
+				Height:      img.Bounds().Dy(),
+				FileSize:    int64(len(encoded)),
+			})
+		}
+	}
+
+	if err := p.store.SaveImageVariants(ctx, imageID, key, variants); err != nil {
+		return nil, err
+	}
+	if p.cdn != nil && len(replaced) > 0 {
+		if err := p.cdn.Purge(ctx, replaced...); err != nil {
+			log.Printf("Failed to purge replaced variants of %s from the CDN: %v", key, err)
+		}
+	}
+	return variants, nil
+}
+
+// resizeToWidth scales img to width, keeping its aspect ratio
+func resizeToWidth(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func variantExtension(format string) string {
+	if format == VariantFormatJPEG {
+		return "jpg"
+	}
+	return format
+}
+
+// standardEncoder writes JPEG and PNG with the standard library
+type standardEncoder string
+
+func (e standardEncoder) Format() string {
+	return string(e)
+}
+
+func (e standardEncoder) Encode(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if e == VariantFormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CommandVariantEncoder encodes WebP with cwebp and AVIF with avifenc
+type CommandVariantEncoder struct {
+	format string
+	binary string
+}
+
+// variantCommands are the encoder binaries for each format
+var variantCommands = map[string]string{
+	VariantFormatWebP: "cwebp",
+	VariantFormatAVIF: "avifenc",
+}
+
+// NewCommandVariantEncoder creates an encoder for format, failing when its
+// binary is not installed
+func NewCommandVariantEncoder(format string) (*CommandVariantEncoder, error) {
+	name, ok := variantCommands[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported variant format: %s", format)
+	}
+	binary, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s encoder %s not found: %w", format, name, err)
+	}
+	return &CommandVariantEncoder{format: format, binary: binary}, nil
+}
+
+// Format returns the format the encoder writes
+func (e *CommandVariantEncoder) Format() string {
+	return e.format
+}
+
+// Encode writes img to a temporary PNG and converts it with the encoder binary
+func (e *CommandVariantEncoder) Encode(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "variant-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.png")
+	output := filepath.Join(dir, "output."+e.format)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(input, buf.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+
+	q := strconv.Itoa(quality)
+	var args []string
+	switch e.format {
+	case VariantFormatWebP:
+		args = []string{"-quiet", "-q", q, input, "-o", output}
+	case VariantFormatAVIF:
+		args = []string{"-q", q, input, output}
+	}
+	if out, err := exec.CommandContext(ctx, e.binary, args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(e.binary), err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output)
+}
+
+// VariantSettingsFromConfig builds the pipeline config and encoders from the
+// application storage config. Formats whose encoder binary is not installed
+// are skipped with a warning; resized widths are still generated.
+func VariantSettingsFromConfig(cfg config.StorageConfig) (VariantConfig, []VariantEncoder) {
+	var widths []int
+	for _, value := range cfg.ImageVariantWidths {
+		width, err := strconv.Atoi(value)
+		if err != nil || width <= 0 {
+			log.Printf("WARNING: ignoring invalid image variant width %q", value)
+			continue
+		}
+		widths = append(widths, width)
+	}
+
+	var encoders []VariantEncoder
+	for _, format := range cfg.ImageVariantFormats {
+		encoder, err := NewCommandVariantEncoder(strings.ToLower(format))
+		if err != nil {
+			log.Printf("WARNING: image variants: %v", err)
+			continue
+		}
+		encoders = append(encoders, encoder)
+	}
+
+	return VariantConfig{
+		Widths:  widths,
+		Quality: cfg.ImageVariantQuality,
+		Workers: cfg.ImageVariantWorkers,
+	}, encoders
+}
+
+// SelectVariant picks the variant to serve for an Accept header and a
+// requested width (0 for full size). It prefers the smallest width covering
+// the request, then AVIF, WebP and the source format in that order. It
+// returns false when the original file is the best match.
+func SelectVariant(variants []ImageVariant, accept string, width int) (ImageVariant, bool) {
+	if len(variants) == 0 {
+		return ImageVariant{}, false
+	}
+
+	accepted := acceptedImageTypes(accept)
+	target, largest := 0, 0
+	for _, v := range variants {
+		if v.Width > largest {
+			largest = v.Width
+		}
+		if width > 0 && v.Width >= width && (target == 0 || v.Width < target) {
+			target = v.Width
+		}
+	}
+	if target == 0 {
+		target = largest
+	}
+
+	for _, format := range variantPreference {
+		for _, v := range variants {
+			if v.Width != target || v.Format != format {
+				continue
+			}
+			// The source format needs no negotiation
+			if v.Format == VariantFormatAVIF || v.Format == VariantFormatWebP {
+				if !accepted[v.ContentType] {
+					continue
+				}
+			}
+			return v, true
+		}
+	}
+	return ImageVariant{}, false
+}
+
+// acceptedImageTypes returns the image types an Accept header allows
+func acceptedImageTypes(accept string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		rejected := false
+		for _, param := range fields[1:] {
+			if q := strings.TrimSpace(param); q == "q=0" || q == "q=0.0" || q == "q=0.00" || q == "q=0.000" {
+				rejected = true
+			}
+		}
+		if mediaType != "" && !rejected {
+			accepted[mediaType] = true
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeVariantEncoder struct {
+	format string
+}
+
+func (e fakeVariantEncoder) Format() string {
+	return e.format
+}
+
+func (e fakeVariantEncoder) Encode(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	return []byte(e.format), nil
+}
+
+type memoryVariantStore struct {
+	variants map[string][]ImageVariant
+}
+
+func (s *memoryVariantStore) SaveImageVariants(ctx context.Context, imageID, sourcePath string, variants []ImageVariant) error {
+	s.variants[sourcePath] = variants
+	return nil
+}
+
+func (s *memoryVariantStore) ListImageVariants(ctx context.Context, sourcePath string) ([]ImageVariant, error) {
+	return s.variants[sourcePath], nil
+}
+
+func TestVariantPipelineGenerate(t *testing.T) {
+	base := t.TempDir()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 400)), nil); err != nil {
+		t.Fatalf("Failed to encode source image: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(base, "results", "u1"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	source := filepath.Join(base, "results", "u1", "result.jpg")
+	if err := os.WriteFile(source, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write source image: %v", err)
+	}
+
+	store := &memoryVariantStore{variants: map[string][]ImageVariant{}}
+	pipeline := NewVariantPipeline(base, VariantConfig{Widths: []int{640, 320, 1024}}, store, fakeVariantEncoder{VariantFormatWebP})
+
+	variants, err := pipeline.Generate(context.Background(), "img-1", source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 320 and 640 in JPEG and WebP, plus WebP at the original 800; 1024 is skipped
+	expected := map[string]int{
+		"results/u1/variants/result/320.jpg":  160,
+		"results/u1/variants/result/320.webp": 160,
+		"results/u1/variants/result/640.jpg":  320,
+		"results/u1/variants/result/640.webp": 320,
+		"results/u1/variants/result/800.webp": 400,
+	}
+	if len(variants) != len(expected) {
+		t.Fatalf("Expected %d variants, got %d", len(expected), len(variants))
+	}
+	for _, v := range variants {
+		height, ok := expected[v.Path]
+		if !ok {
+			t.Errorf("Unexpected variant %s", v.Path)
+			continue
+		}
+		if v.Height != height {
+			t.Errorf("Expected height %d for %s, got %d", height, v.Path, v.Height)
+		}
+		if v.SourcePath != "results/u1/result.jpg" || v.ImageID != "img-1" {
+			t.Errorf("Expected source results/u1/result.jpg of img-1, got %s of %s", v.SourcePath, v.ImageID)
+		}
+		if _, err := os.Stat(filepath.Join(base, filepath.FromSlash(v.Path))); err != nil {
+			t.Errorf("Expected variant file %s to be written: %v", v.Path, err)
+		}
+	}
+	if len(store.variants["results/u1/result.jpg"]) != len(expected) {
+		t.Errorf("Expected variants to be recorded under the source key")
+	}
+
+	if _, err := pipeline.Generate(context.Background(), "img-2", "/etc/passwd"); err == nil {
+		t.Error("Expected error for image outside the storage root")
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	variants := []ImageVariant{
+		{Path: "320.jpg", Format: VariantFormatJPEG, ContentType: "image/jpeg", Width: 320},
+		{Path: "320.webp", Format: VariantFormatWebP, ContentType: "image/webp", Width: 320},
+		{Path: "320.avif", Format: VariantFormatAVIF, ContentType: "image/avif", Width: 320},
+		{Path: "640.jpg", Format: VariantFormatJPEG, ContentType: "image/jpeg", Width: 640},
+		{Path: "640.webp", Format: VariantFormatWebP, ContentType: "image/webp", Width: 640},
+		{Path: "1200.webp", Format: VariantFormatWebP, ContentType: "image/webp", Width: 1200},
+	}
+
+	tests := []struct {
+		name     string
+		accept   string
+		width    int
+		expected string
+	}{
+		{"avif preferred", "image/avif,image/webp,*/*", 300, "320.avif"},
+		{"webp when avif unsupported", "image/webp,*/*", 320, "320.webp"},
+		{"avif rejected with q=0", "image/avif;q=0,image/webp", 100, "320.webp"},
+		{"source format without negotiation", "image/jpeg", 500, "640.jpg"},
+		{"full size webp", "image/webp", 0, "1200.webp"},
+		{"larger than every variant", "image/webp", 4000, "1200.webp"},
+		{"full size original", "image/jpeg", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := SelectVariant(variants, tt.accept, tt.width)
+			if tt.expected == "" {
+				if ok {
+					t.Errorf("Expected the original, got %s", v.Path)
+				}
+				return
+			}
+			if !ok || v.Path != tt.expected {
+				t.Errorf("Expected %s, got %s (ok %v)", tt.expected, v.Path, ok)
+			}
+		})
+	}
+
+	if _, ok := SelectVariant(nil, "image/webp", 0); ok {
+		t.Error("Expected no variant without variants")
+	}
+}
@@ -36,7 +36,8 @@ func (w *Wire) Initialize(ctx context.Context) error {
 	w.imageStorage = manager.GetImageStorage()
 
 	// Create handler
-	w.handler = NewHandler(w.imageStorage, w.storage)
+	w.handler = NewHandler(w.imageStorage, w.storage, manager.GetURLSigner())
+	w.handler.SetBackups(manager.GetBackupRunner())
 
 	// Start storage manager
 	if err := manager.Start(ctx); err != nil {
@@ -0,0 +1,180 @@
+package support
+
+import (
+	"fmt"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for support tickets
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new support handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateTicket handles POST /api/support/tickets
+func (h *Handler) CreateTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req CreateTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ticket, err := h.service.CreateTicket(c.Request.Context(), fmt.Sprint(userID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// ListUserTickets handles GET /api/support/tickets
+func (h *Handler) ListUserTickets(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req ListTicketsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tickets, err := h.service.ListUserTickets(c.Request.Context(), fmt.Sprint(userID), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetUserTicket handles GET /api/support/tickets/:id
+func (h *Handler) GetUserTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	ticket, err := h.service.GetUserTicket(c.Request.Context(), fmt.Sprint(userID), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// AddUserMessage handles POST /api/support/tickets/:id/messages
+func (h *Handler) AddUserMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req ReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ticket, err := h.service.AddUserMessage(c.Request.Context(), fmt.Sprint(userID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// ListTickets handles GET /admin/support/tickets
+func (h *Handler) ListTickets(c *gin.Context) {
+	var req ListTicketsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tickets, err := h.service.ListTickets(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetTicket handles GET /admin/support/tickets/:id
+func (h *Handler) GetTicket(c *gin.Context) {
+	ticket, err := h.service.GetTicket(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// Reply handles POST /admin/support/tickets/:id/messages
+func (h *Handler) Reply(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ticket, err := h.service.Reply(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// UpdateStatus handles PUT /admin/support/tickets/:id/status
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ticket, err := h.service.UpdateStatus(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
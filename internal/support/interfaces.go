@@ -0,0 +1,42 @@
+package support
+
+import (
+	"context"
+
+	"ai-styler/internal/common"
+)
+
+// Store defines the interface for support ticket data operations
+type Store interface {
+	// CreateTicket stores a new ticket with its first message
+	CreateTicket(ctx context.Context, ticket Ticket, first Message) (*Ticket, error)
+
+	// GetTicket returns a ticket with its messages, oldest first
+	GetTicket(ctx context.Context, id string) (*Ticket, error)
+
+	// ListTickets returns the tickets matching req, newest first, from
+	// cursor on. One ticket more than req.PageSize is returned when more
+	// follow.
+	ListTickets(ctx context.Context, req ListTicketsRequest, cursor *common.Cursor) ([]Ticket, error)
+
+	// CountActiveTickets counts the tickets of a user that are not closed
+	CountActiveTickets(ctx context.Context, userID string) (int, error)
+
+	// AddMessage stores a message and sets the ticket's status. Messages of
+	// admins are audited.
+	AddMessage(ctx context.Context, message Message, status string) (*Ticket, error)
+
+	// UpdateStatus sets a ticket's status and audits the change
+	UpdateStatus(ctx context.Context, id, status, adminID string) (*Ticket, error)
+
+	// OwnsConversion reports whether a conversion belongs to a user
+	OwnsConversion(ctx context.Context, userID, conversionID string) (bool, error)
+
+	// OwnsImage reports whether an image was uploaded by a user
+	OwnsImage(ctx context.Context, userID, imageID string) (bool, error)
+}
+
+// Notifier tells users support replied to their ticket
+type Notifier interface {
+	SendSupportReply(ctx context.Context, userID, ticketID, subject string) error
+}
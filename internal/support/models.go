@@ -0,0 +1,94 @@
+package support
+
+import (
+	"time"
+)
+
+// Ticket statuses. A ticket is open while it waits for support, answered
+// once support replied and closed when nothing more is expected; a user
+// reply reopens an answered ticket.
+const (
+	StatusOpen     = "open"
+	StatusAnswered = "answered"
+	StatusClosed   = "closed"
+)
+
+// Message authors
+const (
+	AuthorUser  = "user"
+	AuthorAdmin = "admin"
+)
+
+// Ticket is a support request of a user
+type Ticket struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"userId"`
+	Subject      string    `json:"subject"`
+	Status       string    `json:"status"`
+	ConversionID *string   `json:"conversionId,omitempty"`
+	Messages     []Message `json:"messages,omitempty"` // set on single ticket reads
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Message is a post on a ticket by its user or by support
+type Message struct {
+	ID                string    `json:"id"`
+	TicketID          string    `json:"ticketId"`
+	AuthorType        string    `json:"authorType"`
+	AuthorID          string    `json:"authorId"`
+	Body              string    `json:"body"`
+	AttachmentImageID *string   `json:"attachmentImageId,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// CreateTicketRequest represents a request to open a ticket
+type CreateTicketRequest struct {
+	Subject           string `json:"subject" binding:"required"`
+	Message           string `json:"message" binding:"required"`
+	ConversionID      string `json:"conversionId"`      // conversion the ticket is about
+	AttachmentImageID string `json:"attachmentImageId"` // image uploaded through /api/images
+}
+
+// ReplyRequest represents a request to post a message on a ticket
+type ReplyRequest struct {
+	Message           string `json:"message" binding:"required"`
+	AttachmentImageID string `json:"attachmentImageId"`
+	// Status replaces the status support replies set, answered, for
+	// example to close the ticket with the reply. Ignored for users.
+	Status string `json:"status"`
+}
+
+// UpdateStatusRequest represents a request to change a ticket's status
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// ListTicketsRequest represents a request to list tickets, newest first
+type ListTicketsRequest struct {
+	Status   string `json:"status" form:"status"`
+	UserID   string `json:"userId" form:"userId"` // admin lists only; users see their own tickets
+	PageSize int    `json:"pageSize" form:"pageSize"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page
+}
+
+// ListTicketsResponse represents a page of tickets
+type ListTicketsResponse struct {
+	Tickets    []Ticket `json:"tickets"`
+	PageSize   int      `json:"pageSize"`
+	NextCursor string   `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// Config configures the support service
+type Config struct {
+	MaxOpenTickets   int // tickets a user may have that are not closed
+	MaxMessageLength int // characters of a message
+}
+
+// DefaultConfig returns the default support configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxOpenTickets:   5,
+		MaxMessageLength: 4000,
+	}
+}
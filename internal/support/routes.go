@@ -0,0 +1,27 @@
+package support
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes mounts the user ticket routes on an authenticated router group
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	tickets := router.Group("/support/tickets")
+	{
+		tickets.POST("", handler.CreateTicket)                // POST /api/support/tickets
+		tickets.GET("", handler.ListUserTickets)              // GET /api/support/tickets
+		tickets.GET("/:id", handler.GetUserTicket)            // GET /api/support/tickets/:id
+		tickets.POST("/:id/messages", handler.AddUserMessage) // POST /api/support/tickets/:id/messages
+	}
+}
+
+// SetupAdminRoutes mounts the ticket management routes on an admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	tickets := router.Group("/support/tickets")
+	{
+		tickets.GET("", handler.ListTickets)             // GET /admin/support/tickets
+		tickets.GET("/:id", handler.GetTicket)           // GET /admin/support/tickets/:id
+		tickets.POST("/:id/messages", handler.Reply)     // POST /admin/support/tickets/:id/messages
+		tickets.PUT("/:id/status", handler.UpdateStatus) // PUT /admin/support/tickets/:id/status
+	}
+}
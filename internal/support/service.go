@@ -0,0 +1,277 @@
+package support
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// maxSubjectLength bounds a ticket subject in characters
+const maxSubjectLength = 200
+
+// Page sizes of ticket lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Service manages support tickets for users and support admins
+type Service struct {
+	store    Store
+	notifier Notifier
+	config   Config
+}
+
+// NewService creates a new support service
+func NewService(store Store, config Config) *Service {
+	defaults := DefaultConfig()
+	if config.MaxOpenTickets <= 0 {
+		config.MaxOpenTickets = defaults.MaxOpenTickets
+	}
+	if config.MaxMessageLength <= 0 {
+		config.MaxMessageLength = defaults.MaxMessageLength
+	}
+	return &Service{store: store, config: config}
+}
+
+// SetNotifier notifies users when support replies to their tickets
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// CreateTicket opens a ticket for a user. The conversion and attachment,
+// when given, must belong to the user.
+func (s *Service) CreateTicket(ctx context.Context, userID string, req CreateTicketRequest) (*Ticket, error) {
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: subject is required", common.ErrValidation)
+	}
+	if utf8.RuneCountInString(subject) > maxSubjectLength {
+		return nil, fmt.Errorf("%w: subject must be at most %d characters", common.ErrValidation, maxSubjectLength)
+	}
+	body, err := s.validateMessage(req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := Ticket{UserID: userID, Subject: subject, Status: StatusOpen}
+	if req.ConversionID != "" {
+		if _, err := uuid.Parse(req.ConversionID); err != nil {
+			return nil, fmt.Errorf("%w: invalid conversionId", common.ErrValidation)
+		}
+		owned, err := s.store.OwnsConversion(ctx, userID, req.ConversionID)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, fmt.Errorf("conversion %s: %w", req.ConversionID, common.ErrNotFound)
+		}
+		ticket.ConversionID = &req.ConversionID
+	}
+	attachment, err := s.attachment(ctx, userID, req.AttachmentImageID)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.store.CountActiveTickets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= s.config.MaxOpenTickets {
+		return nil, fmt.Errorf("%w: at most %d tickets may be open at a time", common.ErrConflict, s.config.MaxOpenTickets)
+	}
+
+	return s.store.CreateTicket(ctx, ticket, Message{
+		AuthorType:        AuthorUser,
+		AuthorID:          userID,
+		Body:              body,
+		AttachmentImageID: attachment,
+	})
+}
+
+// ListUserTickets lists the tickets of a user
+func (s *Service) ListUserTickets(ctx context.Context, userID string, req ListTicketsRequest) (*ListTicketsResponse, error) {
+	req.UserID = userID
+	return s.ListTickets(ctx, req)
+}
+
+// GetUserTicket returns a ticket of a user with its messages. Tickets of
+// other users are reported as not found.
+func (s *Service) GetUserTicket(ctx context.Context, userID, id string) (*Ticket, error) {
+	ticket, err := s.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.UserID != userID {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	return ticket, nil
+}
+
+// AddUserMessage posts a user's message on their ticket, which then waits
+// for support again. Closed tickets take no more messages.
+func (s *Service) AddUserMessage(ctx context.Context, userID, id string, req ReplyRequest) (*Ticket, error) {
+	ticket, err := s.GetUserTicket(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == StatusClosed {
+		return nil, fmt.Errorf("%w: ticket %s is closed", common.ErrConflict, id)
+	}
+	body, err := s.validateMessage(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := s.attachment(ctx, userID, req.AttachmentImageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.AddMessage(ctx, Message{
+		TicketID:          id,
+		AuthorType:        AuthorUser,
+		AuthorID:          userID,
+		Body:              body,
+		AttachmentImageID: attachment,
+	}, StatusOpen)
+}
+
+// ListTickets lists tickets for support, optionally of one user or status
+func (s *Service) ListTickets(ctx context.Context, req ListTicketsRequest) (*ListTicketsResponse, error) {
+	if req.Status != "" && !validStatus(req.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+	}
+	if req.UserID != "" {
+		if _, err := uuid.Parse(req.UserID); err != nil {
+			return nil, fmt.Errorf("%w: invalid userId", common.ErrValidation)
+		}
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+	if req.PageSize > maxPageSize {
+		req.PageSize = maxPageSize
+	}
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets, err := s.store.ListTickets(ctx, req, cursor)
+	if err != nil {
+		return nil, err
+	}
+	tickets, nextCursor := common.NextPage(tickets, req.PageSize, func(ticket Ticket) (time.Time, string) {
+		return ticket.CreatedAt, ticket.ID
+	})
+	return &ListTicketsResponse{Tickets: tickets, PageSize: req.PageSize, NextCursor: nextCursor}, nil
+}
+
+// GetTicket returns a ticket with its messages
+func (s *Service) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	return s.store.GetTicket(ctx, id)
+}
+
+// Reply posts a support admin's reply on a ticket and notifies its user.
+// The ticket becomes answered unless req.Status sets another status.
+func (s *Service) Reply(ctx context.Context, adminID, id string, req ReplyRequest) (*Ticket, error) {
+	status := StatusAnswered
+	if req.Status != "" {
+		if !validStatus(req.Status) {
+			return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+		}
+		status = req.Status
+	}
+	body, err := s.validateMessage(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	var attachment *string
+	if req.AttachmentImageID != "" {
+		if _, err := uuid.Parse(req.AttachmentImageID); err != nil {
+			return nil, fmt.Errorf("%w: invalid attachmentImageId", common.ErrValidation)
+		}
+		attachment = &req.AttachmentImageID
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+
+	ticket, err := s.store.AddMessage(ctx, Message{
+		TicketID:          id,
+		AuthorType:        AuthorAdmin,
+		AuthorID:          adminID,
+		Body:              body,
+		AttachmentImageID: attachment,
+	}, status)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil {
+		if err := s.notifier.SendSupportReply(ctx, ticket.UserID, ticket.ID, ticket.Subject); err != nil {
+			log.Printf("Failed to notify user %s of the reply to ticket %s: %v", ticket.UserID, ticket.ID, err)
+		}
+	}
+	return ticket, nil
+}
+
+// UpdateStatus changes a ticket's status for a support admin
+func (s *Service) UpdateStatus(ctx context.Context, adminID, id string, req UpdateStatusRequest) (*Ticket, error) {
+	if !validStatus(req.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	return s.store.UpdateStatus(ctx, id, req.Status, adminID)
+}
+
+// validateMessage returns the trimmed body of a message
+func (s *Service) validateMessage(message string) (string, error) {
+	body := strings.TrimSpace(message)
+	if body == "" {
+		return "", fmt.Errorf("%w: message is required", common.ErrValidation)
+	}
+	if utf8.RuneCountInString(body) > s.config.MaxMessageLength {
+		return "", fmt.Errorf("%w: message must be at most %d characters", common.ErrValidation, s.config.MaxMessageLength)
+	}
+	return body, nil
+}
+
+// attachment checks that a user's attachment is an image they uploaded
+func (s *Service) attachment(ctx context.Context, userID, imageID string) (*string, error) {
+	if imageID == "" {
+		return nil, nil
+	}
+	if _, err := uuid.Parse(imageID); err != nil {
+		return nil, fmt.Errorf("%w: invalid attachmentImageId", common.ErrValidation)
+	}
+	owned, err := s.store.OwnsImage(ctx, userID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, fmt.Errorf("image %s: %w", imageID, common.ErrNotFound)
+	}
+	return &imageID, nil
+}
+
+// validStatus reports whether status is a ticket status
+func validStatus(status string) bool {
+	switch status {
+	case StatusOpen, StatusAnswered, StatusClosed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package support
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+const (
+	testUserID  = "11111111-1111-1111-1111-111111111111"
+	otherUserID = "22222222-2222-2222-2222-222222222222"
+	testAdminID = "33333333-3333-3333-3333-333333333333"
+	testImageID = "44444444-4444-4444-4444-444444444444"
+	testConvID  = "55555555-5555-5555-5555-555555555555"
+)
+
+type fakeStore struct {
+	tickets     map[string]*Ticket
+	order       []string // ticket IDs, oldest first
+	conversions map[string]string
+	images      map[string]string
+	audits      []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		tickets:     make(map[string]*Ticket),
+		conversions: map[string]string{testConvID: testUserID},
+		images:      map[string]string{testImageID: testUserID},
+	}
+}
+
+func (f *fakeStore) CreateTicket(ctx context.Context, ticket Ticket, first Message) (*Ticket, error) {
+	ticket.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(f.order)+1)
+	ticket.CreatedAt = time.Date(2026, 1, 1, 0, len(f.order), 0, 0, time.UTC)
+	first.TicketID = ticket.ID
+	ticket.Messages = []Message{first}
+	f.tickets[ticket.ID] = &ticket
+	f.order = append(f.order, ticket.ID)
+	return f.GetTicket(ctx, ticket.ID)
+}
+
+func (f *fakeStore) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	ticket, ok := f.tickets[id]
+	if !ok {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	copied := *ticket
+	copied.Messages = append([]Message(nil), ticket.Messages...)
+	return &copied, nil
+}
+
+func (f *fakeStore) ListTickets(ctx context.Context, req ListTicketsRequest, cursor *common.Cursor) ([]Ticket, error) {
+	tickets := []Ticket{}
+	for i := len(f.order) - 1; i >= 0; i-- {
+		ticket := f.tickets[f.order[i]]
+		if req.UserID != "" && ticket.UserID != req.UserID || req.Status != "" && ticket.Status != req.Status {
+			continue
+		}
+		if cursor != nil && !ticket.CreatedAt.Before(cursor.CreatedAt) {
+			continue
+		}
+		if len(tickets) == req.PageSize+1 {
+			break
+		}
+		tickets = append(tickets, *ticket)
+	}
+	return tickets, nil
+}
+
+func (f *fakeStore) CountActiveTickets(ctx context.Context, userID string) (int, error) {
+	count := 0
+	for _, ticket := range f.tickets {
+		if ticket.UserID == userID && ticket.Status != StatusClosed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeStore) AddMessage(ctx context.Context, message Message, status string) (*Ticket, error) {
+	ticket, ok := f.tickets[message.TicketID]
+	if !ok {
+		return nil, fmt.Errorf("ticket %s: %w", message.TicketID, common.ErrNotFound)
+	}
+	ticket.Status = status
+	ticket.Messages = append(ticket.Messages, message)
+	if message.AuthorType == AuthorAdmin {
+		f.audits = append(f.audits, "reply")
+	}
+	return f.GetTicket(ctx, ticket.ID)
+}
+
+func (f *fakeStore) UpdateStatus(ctx context.Context, id, status, adminID string) (*Ticket, error) {
+	ticket, ok := f.tickets[id]
+	if !ok {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	ticket.Status = status
+	f.audits = append(f.audits, "update_status")
+	return f.GetTicket(ctx, id)
+}
+
+func (f *fakeStore) OwnsConversion(ctx context.Context, userID, conversionID string) (bool, error) {
+	return f.conversions[conversionID] == userID, nil
+}
+
+func (f *fakeStore) OwnsImage(ctx context.Context, userID, imageID string) (bool, error) {
+	return f.images[imageID] == userID, nil
+}
+
+type fakeNotifier struct {
+	replies []string // ticket IDs
+}
+
+func (f *fakeNotifier) SendSupportReply(ctx context.Context, userID, ticketID, subject string) error {
+	f.replies = append(f.replies, ticketID)
+	return nil
+}
+
+func TestCreateTicket(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(newFakeStore(), Config{MaxOpenTickets: 2})
+
+	ticket, err := service.CreateTicket(ctx, testUserID, CreateTicketRequest{
+		Subject:           "  Wrong colours  ",
+		Message:           "The result looks washed out",
+		ConversionID:      testConvID,
+		AttachmentImageID: testImageID,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ticket.Subject != "Wrong colours" || ticket.Status != StatusOpen || ticket.ConversionID == nil {
+		t.Errorf("Expected an open ticket about the conversion, got %+v", ticket)
+	}
+	if len(ticket.Messages) != 1 || ticket.Messages[0].AuthorType != AuthorUser || ticket.Messages[0].AttachmentImageID == nil {
+		t.Errorf("Expected the first message with its attachment, got %+v", ticket.Messages)
+	}
+
+	tests := []struct {
+		name string
+		req  CreateTicketRequest
+		want error
+	}{
+		{"blank subject", CreateTicketRequest{Subject: " ", Message: "help"}, common.ErrValidation},
+		{"long subject", CreateTicketRequest{Subject: strings.Repeat("x", maxSubjectLength+1), Message: "help"}, common.ErrValidation},
+		{"long message", CreateTicketRequest{Subject: "help", Message: strings.Repeat("x", DefaultConfig().MaxMessageLength+1)}, common.ErrValidation},
+		{"malformed conversion", CreateTicketRequest{Subject: "help", Message: "help", ConversionID: "abc"}, common.ErrValidation},
+		{"conversion of another user", CreateTicketRequest{Subject: "help", Message: "help", ConversionID: otherUserID}, common.ErrNotFound},
+		{"image of another user", CreateTicketRequest{Subject: "help", Message: "help", AttachmentImageID: otherUserID}, common.ErrNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := service.CreateTicket(ctx, testUserID, tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if _, err := service.CreateTicket(ctx, testUserID, CreateTicketRequest{Subject: "second", Message: "help"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.CreateTicket(ctx, testUserID, CreateTicketRequest{Subject: "third", Message: "help"}); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected the open ticket limit to apply, got %v", err)
+	}
+}
+
+func TestReplyFlow(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	notifier := &fakeNotifier{}
+	service := NewService(store, DefaultConfig())
+	service.SetNotifier(notifier)
+
+	ticket, err := service.CreateTicket(ctx, testUserID, CreateTicketRequest{Subject: "Refund", Message: "Charged twice"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := service.GetUserTicket(ctx, otherUserID, ticket.ID); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected another user's ticket to be hidden, got %v", err)
+	}
+
+	ticket, err = service.Reply(ctx, testAdminID, ticket.ID, ReplyRequest{Message: "Refunded"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ticket.Status != StatusAnswered || len(notifier.replies) != 1 || notifier.replies[0] != ticket.ID {
+		t.Errorf("Expected an answered ticket and a notification, got %s, %v", ticket.Status, notifier.replies)
+	}
+
+	ticket, err = service.AddUserMessage(ctx, testUserID, ticket.ID, ReplyRequest{Message: "Not received yet", Status: StatusClosed})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ticket.Status != StatusOpen || len(ticket.Messages) != 3 {
+		t.Errorf("Expected the user's message to reopen the ticket, got %s with %d messages", ticket.Status, len(ticket.Messages))
+	}
+
+	if _, err := service.Reply(ctx, testAdminID, ticket.ID, ReplyRequest{Message: "x", Status: "pending"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an unknown status to be rejected, got %v", err)
+	}
+	if _, err := service.Reply(ctx, testAdminID, "missing", ReplyRequest{Message: "x"}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found for a malformed ticket ID, got %v", err)
+	}
+
+	if _, err := service.UpdateStatus(ctx, testAdminID, ticket.ID, UpdateStatusRequest{Status: StatusClosed}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.AddUserMessage(ctx, testUserID, ticket.ID, ReplyRequest{Message: "Hello?"}); !errors.Is(err, common.ErrConflict) {
+		t.Errorf("Expected a closed ticket to take no messages, got %v", err)
+	}
+	if len(store.audits) != 2 {
+		t.Errorf("Expected the reply and status change audited, got %v", store.audits)
+	}
+}
+
+func TestListTickets(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	service := NewService(store, Config{MaxOpenTickets: 10})
+	for i := 0; i < 3; i++ {
+		service.CreateTicket(ctx, testUserID, CreateTicketRequest{Subject: fmt.Sprintf("ticket %d", i), Message: "help"})
+	}
+	service.CreateTicket(ctx, otherUserID, CreateTicketRequest{Subject: "other", Message: "help"})
+
+	page, err := service.ListUserTickets(ctx, testUserID, ListTicketsRequest{PageSize: 2, UserID: otherUserID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Tickets) != 2 || page.Tickets[0].Subject != "ticket 2" || page.NextCursor == "" {
+		t.Fatalf("Expected the user's two newest tickets and a cursor, got %+v", page)
+	}
+
+	page, err = service.ListUserTickets(ctx, testUserID, ListTicketsRequest{PageSize: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Tickets) != 1 || page.Tickets[0].Subject != "ticket 0" || page.NextCursor != "" {
+		t.Errorf("Expected the last ticket without a cursor, got %+v", page)
+	}
+
+	all, err := service.ListTickets(ctx, ListTicketsRequest{Status: StatusOpen})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(all.Tickets) != 4 || all.PageSize != defaultPageSize {
+		t.Errorf("Expected every open ticket on a default page, got %d on %d", len(all.Tickets), all.PageSize)
+	}
+
+	if _, err := service.ListTickets(ctx, ListTicketsRequest{Status: "pending"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected an unknown status to be rejected, got %v", err)
+	}
+}
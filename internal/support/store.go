@@ -0,0 +1,300 @@
+package support
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-styler/internal/common"
+)
+
+// ticketColumns are the support_tickets columns scanned by scanTicket
+const ticketColumns = `id, user_id, subject, status, conversion_id, created_at, updated_at`
+
+// messageColumns are the support_ticket_messages columns scanned by scanMessage
+const messageColumns = `id, ticket_id, author_type, author_id, body, attachment_image_id, created_at`
+
+// DBStore implements Store on top of the support_tickets and
+// support_ticket_messages tables
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// CreateTicket stores a new ticket with its first message in one transaction
+func (s *DBStore) CreateTicket(ctx context.Context, ticket Ticket, first Message) (*Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := scanTicket(tx.QueryRowContext(ctx, `
+		INSERT INTO support_tickets (user_id, subject, status, conversion_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+ticketColumns,
+		ticket.UserID, ticket.Subject, ticket.Status, ticket.ConversionID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	first.TicketID = created.ID
+	message, err := insertMessage(ctx, tx, first)
+	if err != nil {
+		return nil, err
+	}
+	created.Messages = []Message{*message}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit support ticket: %w", err)
+	}
+	return created, nil
+}
+
+// GetTicket returns a ticket with its messages, oldest first
+func (s *DBStore) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	ticket, err := scanTicket(s.db.QueryRowContext(ctx, `SELECT `+ticketColumns+` FROM support_tickets WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+messageColumns+` FROM support_ticket_messages
+		WHERE ticket_id = $1
+		ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ticket messages: %w", err)
+	}
+	defer rows.Close()
+
+	ticket.Messages = []Message{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		ticket.Messages = append(ticket.Messages, *message)
+	}
+	return ticket, rows.Err()
+}
+
+// ListTickets returns the tickets matching req, newest first
+func (s *DBStore) ListTickets(ctx context.Context, req ListTicketsRequest, cursor *common.Cursor) ([]Ticket, error) {
+	query := `SELECT ` + ticketColumns + ` FROM support_tickets WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.UserID != "" {
+		query += fmt.Sprintf(" AND user_id = $%d", argIndex)
+		args = append(args, req.UserID)
+		argIndex++
+	}
+	if req.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIndex)
+		args = append(args, req.Status)
+		argIndex++
+	}
+	if condition, cursorArgs := cursor.Condition("created_at", "id", argIndex); condition != "" {
+		query += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("created_at", "id", cursor, req.PageSize, 0, argIndex)
+	query += clause
+	args = append(args, pageArgs...)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list support tickets: %w", err)
+	}
+	defer rows.Close()
+
+	tickets := []Ticket{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, *ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// CountActiveTickets counts the tickets of a user that are not closed
+func (s *DBStore) CountActiveTickets(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM support_tickets
+		WHERE user_id = $1 AND status <> $2`, userID, StatusClosed).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count support tickets: %w", err)
+	}
+	return count, nil
+}
+
+// AddMessage stores a message and sets the ticket's status in one
+// transaction. Replies of admins are recorded in the audit log.
+func (s *DBStore) AddMessage(ctx context.Context, message Message, status string) (*Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE support_tickets SET status = $2, updated_at = NOW()
+		WHERE id = $1`, message.TicketID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update support ticket: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("ticket %s: %w", message.TicketID, common.ErrNotFound)
+	}
+
+	created, err := insertMessage(ctx, tx, message)
+	if err != nil {
+		return nil, err
+	}
+	if message.AuthorType == AuthorAdmin {
+		if err := audit(ctx, tx, message.AuthorID, "reply", map[string]interface{}{
+			"ticketId":  message.TicketID,
+			"messageId": created.ID,
+			"status":    status,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit ticket message: %w", err)
+	}
+	return s.GetTicket(ctx, message.TicketID)
+}
+
+// UpdateStatus sets a ticket's status and records the change in the audit
+// log in the same transaction
+func (s *DBStore) UpdateStatus(ctx context.Context, id, status, adminID string) (*Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM support_tickets WHERE id = $1 FOR UPDATE`, id).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ticket %s: %w", id, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get support ticket: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE support_tickets SET status = $2, updated_at = NOW()
+		WHERE id = $1`, id, status); err != nil {
+		return nil, fmt.Errorf("failed to update support ticket: %w", err)
+	}
+	if err := audit(ctx, tx, adminID, "update_status", map[string]interface{}{
+		"ticketId": id,
+		"from":     previous,
+		"to":       status,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit support ticket status: %w", err)
+	}
+	return s.GetTicket(ctx, id)
+}
+
+// OwnsConversion reports whether a conversion belongs to a user
+func (s *DBStore) OwnsConversion(ctx context.Context, userID, conversionID string) (bool, error) {
+	var owned bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM conversions WHERE id = $1 AND user_id = $2)`,
+		conversionID, userID).Scan(&owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check conversion owner: %w", err)
+	}
+	return owned, nil
+}
+
+// OwnsImage reports whether an image was uploaded by a user
+func (s *DBStore) OwnsImage(ctx context.Context, userID, imageID string) (bool, error) {
+	var owned bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM images WHERE id = $1 AND owner_id = $2 AND owner_type = 'user')`,
+		imageID, userID).Scan(&owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check image owner: %w", err)
+	}
+	return owned, nil
+}
+
+// insertMessage stores a message of a ticket
+func insertMessage(ctx context.Context, tx *sql.Tx, message Message) (*Message, error) {
+	return scanMessage(tx.QueryRowContext(ctx, `
+		INSERT INTO support_ticket_messages (ticket_id, author_type, author_id, body, attachment_image_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+messageColumns,
+		message.TicketID, message.AuthorType, message.AuthorID, message.Body, message.AttachmentImageID,
+	))
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTicket(row rowScanner) (*Ticket, error) {
+	var ticket Ticket
+	var conversionID sql.NullString
+	err := row.Scan(&ticket.ID, &ticket.UserID, &ticket.Subject, &ticket.Status, &conversionID, &ticket.CreatedAt, &ticket.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan support ticket: %w", err)
+	}
+	if conversionID.Valid {
+		ticket.ConversionID = &conversionID.String
+	}
+	return &ticket, nil
+}
+
+func scanMessage(row rowScanner) (*Message, error) {
+	var message Message
+	var attachment sql.NullString
+	err := row.Scan(&message.ID, &message.TicketID, &message.AuthorType, &message.AuthorID, &message.Body, &attachment, &message.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan ticket message: %w", err)
+	}
+	if attachment.Valid {
+		message.AttachmentImageID = &attachment.String
+	}
+	return &message, nil
+}
+
+// audit records a support admin's change of a ticket
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'support_tickets', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
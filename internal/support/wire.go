@@ -0,0 +1,15 @@
+package support
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+)
+
+// WireSupportService creates a support service with all dependencies
+func WireSupportService(db *sql.DB, cfg *config.Config) *Service {
+	return NewService(NewDBStore(db), Config{
+		MaxOpenTickets:   cfg.Support.MaxOpenTickets,
+		MaxMessageLength: cfg.Support.MaxMessageLength,
+	})
+}
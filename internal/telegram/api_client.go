@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"time"
 
+	"ai-styler/internal/domain"
+
 	"github.com/sony/gobreaker"
 )
 
@@ -19,6 +22,7 @@ type APIClient struct {
 	apiKey         string
 	httpClient     *http.Client
 	circuitBreaker *gobreaker.CircuitBreaker
+	internal       *InternalAPIClient // optional
 }
 
 // NewAPIClient creates a new API client
@@ -43,6 +47,12 @@ func NewAPIClient(baseURL, apiKey string, timeout time.Duration) *APIClient {
 	}
 }
 
+// SetInternalAPI routes sign-in, image and conversion calls through the
+// backend's internal gRPC API instead of the public REST API
+func (c *APIClient) SetInternalAPI(internal *InternalAPIClient) {
+	c.internal = internal
+}
+
 // APIResponse represents a generic API response
 type APIResponse struct {
 	Data  interface{} `json:"data,omitempty"`
@@ -143,29 +153,35 @@ type ImageUploadResponse struct {
 
 // ConversionRequest represents conversion creation request
 type ConversionRequest struct {
-	UserImageID  string `json:"userImageId"`
-	ClothImageID string `json:"clothImageId"`
-	StyleName    string `json:"styleName,omitempty"`
+	UserImageID  string              `json:"userImageId"`
+	ClothImageID string              `json:"clothImageId,omitempty"`
+	StyleName    string              `json:"styleName,omitempty"`
+	PresetID     string              `json:"presetId,omitempty"`
+	Garments     []ConversionGarment `json:"garments,omitempty"` // outfit, instead of ClothImageID
 }
 
-// ConversionResponse represents conversion response
-type ConversionResponse struct {
-	ID               string     `json:"id"`
-	UserID           string     `json:"userId"`
-	UserImageID      string     `json:"userImageId"`
-	ClothImageID     string     `json:"clothImageId"`
-	Status           string     `json:"status"`
-	ResultImageID    *string    `json:"resultImageId,omitempty"`
-	ErrorMessage     *string    `json:"errorMessage,omitempty"`
-	ProcessingTimeMs *int       `json:"processingTimeMs,omitempty"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
-	CompletedAt      *time.Time `json:"completedAt,omitempty"`
-	UserImageURL     string     `json:"userImageUrl,omitempty"`
-	ClothImageURL    string     `json:"clothImageUrl,omitempty"`
-	ResultImageURL   string     `json:"resultImageUrl,omitempty"`
+// ConversionGarment is one garment of an outfit conversion, in layering order
+type ConversionGarment struct {
+	ImageID string `json:"imageId"`
+	Slot    string `json:"slot,omitempty"`
 }
 
+// ConversionPreset is a user's saved set of conversion options
+type ConversionPreset struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StyleName string `json:"styleName,omitempty"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// PresetsResponse represents the user's saved presets
+type PresetsResponse struct {
+	Presets []ConversionPreset `json:"presets"`
+}
+
+// ConversionResponse represents conversion response
+type ConversionResponse = domain.ConversionDetails
+
 // ConversionsListResponse represents conversions list response
 type ConversionsListResponse struct {
 	Conversions []ConversionResponse `json:"conversions"`
@@ -197,7 +213,7 @@ func (c *APIClient) doRequest(ctx context.Context, method, endpoint string, body
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Request-Source", "telegram-bot") // Always identify as Telegram bot
 	if c.apiKey != "" {
-		req.Header.Set("X-API-Key", c.apiKey)
+		req.Header.Set(TelegramBotKeyHeader, c.apiKey)
 	}
 
 	// Set custom headers
@@ -366,8 +382,127 @@ func (c *APIClient) Login(ctx context.Context, phone, password string) (*LoginRe
 	return &result, nil
 }
 
+// ErrTelegramNotLinked is returned when no backend account is linked to the Telegram user
+var ErrTelegramNotLinked = errors.New("telegram account is not linked")
+
+// ErrInvalidLinkCode is returned when a link code is unknown, used or expired
+var ErrInvalidLinkCode = errors.New("invalid or expired link code")
+
+// TelegramBotKeyHeader carries the shared bot key the backend checks on Telegram sign-in
+const TelegramBotKeyHeader = "X-Telegram-Bot-Key"
+
+// TelegramLinkRequest represents a Telegram account link request
+type TelegramLinkRequest struct {
+	Code           string `json:"code"`
+	TelegramUserID int64  `json:"telegramUserId"`
+}
+
+// TelegramLoginRequest represents a passwordless Telegram sign-in request
+type TelegramLoginRequest struct {
+	TelegramUserID int64 `json:"telegramUserId"`
+}
+
+// TelegramLinkCodeResponse represents a link code issued for the signed-in user
+type TelegramLinkCodeResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	ExpiresIn int       `json:"expiresIn"`
+	DeepLink  string    `json:"deepLink,omitempty"`
+}
+
+// LinkTelegramAccount links a Telegram account to the owner of a link code and signs it in
+func (c *APIClient) LinkTelegramAccount(ctx context.Context, code string, telegramUserID int64) (*LoginResponse, error) {
+	if c.internal != nil {
+		return c.internal.LinkTelegramAccount(ctx, code, telegramUserID)
+	}
+
+	req := TelegramLinkRequest{
+		Code:           code,
+		TelegramUserID: telegramUserID,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/auth/telegram/link", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusBadRequest:
+		return nil, ErrInvalidLinkCode
+	default:
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// TelegramLogin signs in the backend user linked to a Telegram account
+func (c *APIClient) TelegramLogin(ctx context.Context, telegramUserID int64) (*LoginResponse, error) {
+	if c.internal != nil {
+		return c.internal.TelegramLogin(ctx, telegramUserID)
+	}
+
+	req := TelegramLoginRequest{TelegramUserID: telegramUserID}
+
+	resp, err := c.doRequest(ctx, "POST", "/auth/telegram/login", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrTelegramNotLinked
+	default:
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CreateTelegramLinkCode issues a link code for the signed-in user
+func (c *APIClient) CreateTelegramLinkCode(ctx context.Context, accessToken string) (*TelegramLinkCodeResponse, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/auth/telegram/link-code", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result TelegramLinkCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // UploadImage uploads an image to the backend
 func (c *APIClient) UploadImage(ctx context.Context, accessToken string, fileData []byte, fileName, mimeType, imageType string) (*ImageUploadResponse, error) {
+	if c.internal != nil {
+		return c.internal.UploadImage(ctx, accessToken, fileData, fileName, mimeType, imageType)
+	}
+
 	url := c.baseURL + "/api/images"
 
 	var buf bytes.Buffer
@@ -400,7 +535,7 @@ func (c *APIClient) UploadImage(ctx context.Context, accessToken string, fileDat
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("X-Request-Source", "telegram-bot") // Always identify as Telegram bot
 	if c.apiKey != "" {
-		req.Header.Set("X-API-Key", c.apiKey)
+		req.Header.Set(TelegramBotKeyHeader, c.apiKey)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -423,6 +558,10 @@ func (c *APIClient) UploadImage(ctx context.Context, accessToken string, fileDat
 
 // CreateConversion creates a new conversion
 func (c *APIClient) CreateConversion(ctx context.Context, accessToken string, req ConversionRequest) (*ConversionResponse, error) {
+	if c.internal != nil {
+		return c.internal.CreateConversion(ctx, accessToken, req)
+	}
+
 	headers := map[string]string{
 		"Authorization": "Bearer " + accessToken,
 	}
@@ -469,8 +608,42 @@ func (c *APIClient) CreateConversionWithMock(ctx context.Context, accessToken st
 	return &result, nil
 }
 
+// GetDefaultPreset returns the user's default conversion preset, or nil
+// when they have none
+func (c *APIClient) GetDefaultPreset(ctx context.Context, accessToken string) (*ConversionPreset, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/users/me/presets", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result PresetsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for i := range result.Presets {
+		if result.Presets[i].IsDefault {
+			return &result.Presets[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // GetConversion gets conversion details
 func (c *APIClient) GetConversion(ctx context.Context, accessToken, conversionID string) (*ConversionResponse, error) {
+	if c.internal != nil {
+		return c.internal.GetConversion(ctx, accessToken, conversionID)
+	}
+
 	headers := map[string]string{
 		"Authorization": "Bearer " + accessToken,
 	}
@@ -495,6 +668,10 @@ func (c *APIClient) GetConversion(ctx context.Context, accessToken, conversionID
 
 // ListConversions lists user conversions
 func (c *APIClient) ListConversions(ctx context.Context, accessToken string, page, pageSize int, status string) (*ConversionsListResponse, error) {
+	if c.internal != nil {
+		return c.internal.ListConversions(ctx, accessToken, page, pageSize, status)
+	}
+
 	endpoint := fmt.Sprintf("/api/conversions?page=%d&pageSize=%d", page, pageSize)
 	if status != "" {
 		endpoint += "&status=" + status
@@ -524,6 +701,10 @@ func (c *APIClient) ListConversions(ctx context.Context, accessToken string, pag
 
 // GetImageURL gets image URL (if available)
 func (c *APIClient) GetImageURL(ctx context.Context, accessToken, imageID string) (string, error) {
+	if c.internal != nil {
+		return c.internal.GetImageURL(ctx, accessToken, imageID)
+	}
+
 	headers := map[string]string{
 		"Authorization": "Bearer " + accessToken,
 	}
@@ -659,3 +840,266 @@ func (c *APIClient) GetStatistics(ctx context.Context, accessToken string) (*Sta
 
 	return &result, nil
 }
+
+// ShareLinkRequest represents share link creation request
+type ShareLinkRequest struct {
+	ConversionID  string `json:"conversionId"`
+	ExpiryMinutes int    `json:"expiryMinutes"`
+}
+
+// ShareLinkResponse represents share link creation response
+type ShareLinkResponse struct {
+	ShareID    string    `json:"shareId"`
+	ShareToken string    `json:"shareToken"`
+	SignedURL  string    `json:"signedUrl"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	PublicURL  string    `json:"publicUrl"`
+}
+
+// CreateShareLink creates a share link for a completed conversion
+func (c *APIClient) CreateShareLink(ctx context.Context, accessToken string, req ShareLinkRequest) (*ShareLinkResponse, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/share/create", req, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(bodyBytes, &apiErr); err == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result ShareLinkResponse
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// RevokeShareLink deactivates a share link owned by the user
+func (c *APIClient) RevokeShareLink(ctx context.Context, accessToken, shareID string) error {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "DELETE", "/api/share/"+shareID, nil, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// PaymentPlan represents a purchasable subscription plan
+type PaymentPlan struct {
+	ID                      string   `json:"id"`
+	Name                    string   `json:"name"`
+	DisplayName             string   `json:"displayName"`
+	Description             string   `json:"description"`
+	PricePerMonthCents      int64    `json:"pricePerMonthCents"`
+	MonthlyConversionsLimit int      `json:"monthlyConversionsLimit"`
+	Features                []string `json:"features"`
+}
+
+// CreatePaymentRequest represents payment creation request
+type CreatePaymentRequest struct {
+	PlanID      string `json:"planId"`
+	ReturnURL   string `json:"returnUrl"`
+	Description string `json:"description,omitempty"`
+}
+
+// CreatePaymentResponse represents payment creation response
+type CreatePaymentResponse struct {
+	PaymentID  string    `json:"paymentId"`
+	GatewayURL string    `json:"gatewayUrl"`
+	TrackID    string    `json:"trackId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// PaymentStatusResponse represents payment status response
+type PaymentStatusResponse struct {
+	PaymentID string     `json:"paymentId"`
+	Status    string     `json:"status"`
+	Amount    int64      `json:"amount"`
+	PlanName  string     `json:"planName"`
+	PaidAt    *time.Time `json:"paidAt,omitempty"`
+}
+
+// GetPlans gets the available payment plans
+func (c *APIClient) GetPlans(ctx context.Context, accessToken string) ([]PaymentPlan, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/plans/", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Plans []PaymentPlan `json:"plans"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Plans, nil
+}
+
+// CreatePayment creates a gateway payment for a plan
+func (c *APIClient) CreatePayment(ctx context.Context, accessToken string, req CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/payments/create", req, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(bodyBytes, &apiErr); err == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result CreatePaymentResponse
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetPaymentStatus gets the status of a payment
+func (c *APIClient) GetPaymentStatus(ctx context.Context, accessToken, paymentID string) (*PaymentStatusResponse, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/payments/"+paymentID+"/status", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result PaymentStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ErrSupportTicketLimit is returned when the user already has the most open tickets allowed
+var ErrSupportTicketLimit = errors.New("too many open support tickets")
+
+// SupportTicketRequest represents support ticket creation request
+type SupportTicketRequest struct {
+	Subject           string `json:"subject"`
+	Message           string `json:"message"`
+	ConversionID      string `json:"conversionId,omitempty"`
+	AttachmentImageID string `json:"attachmentImageId,omitempty"`
+}
+
+// SupportTicket represents a support ticket
+type SupportTicket struct {
+	ID        string    `json:"id"`
+	Subject   string    `json:"subject"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreateSupportTicket opens a support ticket for the user
+func (c *APIClient) CreateSupportTicket(ctx context.Context, accessToken string, req SupportTicketRequest) (*SupportTicket, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/support/tickets", req, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+	case http.StatusConflict:
+		return nil, ErrSupportTicketLimit
+	default:
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result SupportTicket
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListSupportTickets lists the user's newest support tickets
+func (c *APIClient) ListSupportTickets(ctx context.Context, accessToken string, pageSize int) ([]SupportTicket, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/support/tickets?pageSize=%d", pageSize), nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Tickets []SupportTicket `json:"tickets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Tickets, nil
+}
@@ -64,6 +64,9 @@ func maskToken(token string) string {
 
 // Start starts the bot in polling or webhook mode
 func (b *Bot) Start() error {
+	// Confirm plan purchases started from the bot once they complete
+	go b.handlers.WatchPendingPayments(b.ctx)
+
 	if b.config.Telegram.Env == "production" && b.webhookURL != "" {
 		return b.startWebhook()
 	}
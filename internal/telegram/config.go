@@ -22,16 +22,32 @@ type Config struct {
 
 // TelegramConfig holds Telegram-specific configuration
 type TelegramConfig struct {
-	BotToken string
-	Env      string // development or production
+	BotToken           string
+	Env                string // development or production
+	ShareExpiryMinutes int    // Lifetime of share links created from the bot
+	// Interval between checks of payments started from the bot
+	PaymentPollInterval time.Duration
+	// Where the gateway sends the user after paying; defaults to a deep link back into the bot
+	PaymentReturnURL string
+	// Garments a user may combine into one outfit; keep in line with the API's CONVERSION_MAX_GARMENTS
+	MaxGarments int
 }
 
 // APIConfig holds backend API configuration
 type APIConfig struct {
 	BaseURL    string
+	PublicURL  string // Externally reachable base URL used in share links
 	APIKey     string // Optional API key for bot-to-API auth
 	Timeout    time.Duration
 	RetryCount int
+
+	// Internal gRPC API; when InternalAddr is set, sign-in, image and
+	// conversion calls use it over mutual TLS instead of the REST API
+	InternalAddr       string
+	InternalCertFile   string // bot client certificate
+	InternalKeyFile    string // bot client private key
+	InternalCAFile     string // CA that signs the backend's certificate
+	InternalServerName string // name on the backend's certificate, if not the host of InternalAddr
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -75,14 +91,25 @@ func LoadConfig() (*Config, error) {
 
 	cfg := &Config{
 		Telegram: TelegramConfig{
-			BotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
-			Env:      getEnv("BOT_ENV", "development"),
+			BotToken:           getEnv("TELEGRAM_BOT_TOKEN", ""),
+			Env:                getEnv("BOT_ENV", "development"),
+			ShareExpiryMinutes: getEnvAsInt("BOT_SHARE_EXPIRY_MINUTES", 5),
+			PaymentPollInterval: getEnvAsDuration("BOT_PAYMENT_POLL_INTERVAL", 15*time.Second),
+			PaymentReturnURL:    getEnv("BOT_PAYMENT_RETURN_URL", ""),
+			MaxGarments:         getEnvAsInt("BOT_MAX_GARMENTS", 3),
 		},
 		API: APIConfig{
 			BaseURL:    getEnv("API_BASE_URL", "http://localhost:8080"),
+			PublicURL:  getEnv("API_PUBLIC_URL", ""),
 			APIKey:     getEnv("API_KEY_FOR_BOT", ""),
 			Timeout:    getEnvAsDuration("API_TIMEOUT", 30*time.Second),
 			RetryCount: getEnvAsInt("API_RETRY_COUNT", 3),
+
+			InternalAddr:       getEnv("INTERNAL_API_ADDR", ""),
+			InternalCertFile:   getEnv("INTERNAL_API_CLIENT_CERT", ""),
+			InternalKeyFile:    getEnv("INTERNAL_API_CLIENT_KEY", ""),
+			InternalCAFile:     getEnv("INTERNAL_API_SERVER_CA", ""),
+			InternalServerName: getEnv("INTERNAL_API_SERVER_NAME", ""),
 		},
 		Database: DatabaseConfig{
 			DSN: getEnv("POSTGRES_DSN", ""),
@@ -115,6 +142,11 @@ func LoadConfig() (*Config, error) {
 		cfg.Redis.URL = buildRedisURL(cfg.Redis)
 	}
 
+	// Share links fall back to the API base URL when no public URL is set
+	if cfg.API.PublicURL == "" {
+		cfg.API.PublicURL = cfg.API.BaseURL
+	}
+
 	// Build PostgreSQL DSN if not provided
 	if cfg.Database.DSN == "" {
 		cfg.Database.DSN = buildPostgresDSN()
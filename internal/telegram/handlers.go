@@ -9,8 +9,11 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"ai-styler/internal/locale"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -21,6 +24,7 @@ type Handlers struct {
 	sessionMgr    *SessionManager
 	rateLimiter   *RateLimiter
 	config        *Config
+	languages     sync.Map // chat ID -> language code
 }
 
 // NewHandlers creates a new handlers instance
@@ -44,6 +48,7 @@ func (h *Handlers) HandleMessage(msg *tgbotapi.Message) {
 	ctx := context.Background()
 	userID := msg.From.ID
 	chatID := msg.Chat.ID
+	h.rememberLanguage(chatID, msg.From)
 
 	log.Printf("📨 Handling message from user %d (chat %d): command='%s', text='%s'", 
 		userID, chatID, msg.Command(), msg.Text)
@@ -54,7 +59,7 @@ func (h *Handlers) HandleMessage(msg *tgbotapi.Message) {
 		log.Printf("Rate limit check error: %v", err)
 	}
 	if !allowed {
-		h.sendMessage(msg.Chat.ID, MsgErrorRateLimit)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorRateLimit))
 		RecordRateLimitHit("message")
 		return
 	}
@@ -99,9 +104,11 @@ func (h *Handlers) handleCommand(msg *tgbotapi.Message) {
 	case "start":
 		h.handleStartCommand(msg)
 	case "help":
-		h.sendMessage(chatID, MsgHelp)
+		h.sendMessage(chatID, h.text(chatID, MsgHelp))
+	case "link":
+		h.handleLinkCommand(msg)
 	default:
-		h.sendMessage(chatID, "دستور نامعتبر است. از /help برای راهنما استفاده کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgInvalidCommand))
 	}
 }
 
@@ -113,11 +120,25 @@ func (h *Handlers) handleStartCommand(msg *tgbotapi.Message) {
 
 	log.Printf("🎯 Processing /start command from user %d", userID)
 
+	// Deep link from a shared conversion result
+	payload := msg.CommandArguments()
+	if strings.HasPrefix(payload, linkPayloadPrefix) {
+		// Deep link carrying an account link code
+		h.linkAccount(ctx, msg.From, chatID, strings.TrimPrefix(payload, linkPayloadPrefix))
+		return
+	}
+	if strings.HasPrefix(payload, sharePayloadPrefix) {
+		h.handleSharedLinkStart(chatID, payload)
+	} else if payload == paymentPayload {
+		// Returning from the payment gateway
+		h.handlePaymentReturn(userID, chatID)
+	}
+
 	// Get or create session
 	_, err := h.sessionMgr.GetSession(ctx, userID)
 	if err != nil {
 		log.Printf("Failed to get session: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
@@ -127,15 +148,20 @@ func (h *Handlers) handleStartCommand(msg *tgbotapi.Message) {
 		log.Printf("Failed to check authentication: %v", err)
 	}
 
+	// Linked Telegram accounts sign in without a password
+	if !authenticated {
+		authenticated = h.signInLinkedAccount(ctx, msg.From)
+	}
+
 	if authenticated {
-		h.sendMessageWithKeyboard(chatID, MsgWelcomeBack, MainMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgWelcomeBack), MainMenuKeyboard(h.language(chatID)))
 	} else {
 		// User not authenticated - show welcome and prompt for contact sharing
-		welcomeMsg := MsgWelcome + "\n\n" + MsgPleaseLogin + "\n\n" + MsgShareContact
+		welcomeMsg := h.text(chatID, MsgWelcome) + "\n\n" + h.text(chatID, MsgPleaseLogin) + "\n\n" + h.text(chatID, MsgShareContact)
 		h.sendMessage(chatID, welcomeMsg)
 		time.Sleep(300 * time.Millisecond)
-		msg := tgbotapi.NewMessage(chatID, "📱 لطفاً کانتکت خودتون رو share کنید:")
-		msg.ReplyMarkup = ShareContactKeyboard()
+		msg := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msg.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msg)
 		// Set state to wait for contact
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
@@ -153,31 +179,35 @@ func (h *Handlers) handleTextMessage(msg *tgbotapi.Message) {
 	state, err := h.sessionMgr.GetState(ctx, userID)
 	if err != nil {
 		log.Printf("Failed to get state: %v", err)
-		h.sendMessage(chatID, "لطفاً از منو استفاده کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgUseMenu))
 		return
 	}
 
 	if state == nil {
-		h.sendMessage(chatID, "لطفاً از منو استفاده کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgUseMenu))
 		return
 	}
 
 	switch state.Action {
 	case "waiting_password":
 		h.handlePasswordInput(msg, text)
+	case stateWaitingLinkCode:
+		h.handleLinkCodeInput(msg)
+	case stateWaitingSupportMessage:
+		h.handleSupportMessage(msg)
 	case "waiting_contact":
 		// User should share contact, not send text
-		if text == "❌ Cancel" {
+		if isCancelText(text) {
 			h.sessionMgr.ClearState(ctx, userID)
-			msg := tgbotapi.NewMessage(chatID, "✅ عملیات لغو شد.")
+			msg := tgbotapi.NewMessage(chatID, h.text(chatID, MsgOperationCancelled))
 			msg.ReplyMarkup = RemoveKeyboard()
 			h.bot.Send(msg)
-			h.sendMessageWithKeyboard(chatID, "🏠 منوی اصلی:", MainMenuKeyboard())
+			h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgMainMenu), MainMenuKeyboard(h.language(chatID)))
 		} else {
-			h.sendMessage(chatID, MsgContactNotShared)
+			h.sendMessage(chatID, h.text(chatID, MsgContactNotShared))
 		}
 	default:
-		h.sendMessage(chatID, "لطفاً از منو استفاده کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgUseMenu))
 	}
 }
 
@@ -192,19 +222,19 @@ func (h *Handlers) handleContact(msg *tgbotapi.Message) {
 
 	// Verify that the contact belongs to the user who sent it
 	if contact.UserID != userID {
-		h.sendMessage(chatID, "⚠️ لطفاً کانتکت خودتون رو share کنید، نه کانتکت شخص دیگری.")
+		h.sendMessage(chatID, h.text(chatID, MsgContactNotOwn))
 		return
 	}
 
 	// Normalize phone number
 	phone := normalizePhone(contact.PhoneNumber)
 	if phone == "" {
-		h.sendMessage(chatID, "❌ شماره تلفن نامعتبر است. لطفاً دوباره تلاش کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgInvalidPhone))
 		return
 	}
 
 	// Remove keyboard
-	msgConfig := tgbotapi.NewMessage(chatID, MsgContactReceived)
+	msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgContactReceived))
 	msgConfig.ReplyMarkup = RemoveKeyboard()
 	h.bot.Send(msgConfig)
 
@@ -212,7 +242,7 @@ func (h *Handlers) handleContact(msg *tgbotapi.Message) {
 	userExists, err := h.apiClient.CheckUser(ctx, phone)
 	if err != nil {
 		log.Printf("Failed to check user: %v", err)
-		h.sendMessage(chatID, MsgContactVerificationFailed)
+		h.sendMessage(chatID, h.text(chatID, MsgContactVerificationFailed))
 		h.sessionMgr.ClearState(ctx, userID)
 		return
 	}
@@ -224,86 +254,24 @@ func (h *Handlers) handleContact(msg *tgbotapi.Message) {
 	}
 
 	if userExists {
-		// User exists - try to login with default password first
-		// This works for users who registered via Telegram bot
-		defaultPassword := generateDefaultPassword(phone)
-		loginResp, err := h.apiClient.Login(ctx, phone, defaultPassword)
-		
-		if err != nil {
-			// Login failed - user might have changed password
-			// For Telegram bot users, we need to handle this gracefully
-			log.Printf("Auto-login failed for user %s: %v", phone, err)
-			
-			// Try to send OTP for phone verification and allow password reset
-			// For now, inform user they need to use website/app
-			h.sendMessage(chatID, "⚠️ حساب کاربری شما وجود دارد اما رمز عبور تغییر کرده است.\n\n"+
-				"لطفاً از طریق وب‌سایت یا اپلیکیشن وارد شوید.\n\n"+
-				"یا اگر می‌خواهید از ربات استفاده کنید، لطفاً رمز عبور خود را به حالت پیش‌فرض برگردانید.")
-			h.sessionMgr.ClearState(ctx, userID)
-			return
-		}
-
-		// Login successful - update session
-		session, _ := h.sessionMgr.GetSession(ctx, userID)
-		if session != nil {
-			userIDStr := loginResp.User.ID
-			accessToken := loginResp.AccessToken
-			refreshToken := loginResp.RefreshToken
-			expiresAt := time.Now().Add(time.Duration(loginResp.AccessExpiresIn) * time.Second)
-			firstName := msg.From.FirstName
-			lastName := msg.From.LastName
-			username := msg.From.UserName
-			langCode := msg.From.LanguageCode
-			
-			session.BackendUserID = &userIDStr
-			session.Phone = &phone
-			session.AccessToken = &accessToken
-			session.RefreshToken = &refreshToken
-			session.TokenExpiresAt = &expiresAt
-			session.FirstName = &firstName
-			if lastName != "" {
-				session.LastName = &lastName
-			}
-			if username != "" {
-				session.Username = &username
-			}
-			if langCode != "" {
-				session.LanguageCode = &langCode
-			}
-			h.sessionMgr.UpdateSession(ctx, session)
-
-			// Store tokens in Redis
-			ttl := time.Duration(loginResp.AccessExpiresIn) * time.Second
-			_ = h.sessionMgr.GetStorage().StoreToken(ctx, userID, accessToken, refreshToken, ttl)
-		}
+		// Existing accounts are linked with a code issued on the website or app
+		h.sessionMgr.SetState(ctx, userID, stateWaitingLinkCode, "")
+		h.sendMessage(chatID, h.text(chatID, MsgLinkRequired))
+		return
+	}
 
+	// User doesn't exist - register directly (no OTP needed for Telegram bot).
+	// The password is random; the bot signs in through the Telegram link.
+	password, err := generateAccountPassword()
+	if err != nil {
+		log.Printf("Failed to generate password: %v", err)
+		h.sendMessage(chatID, h.text(chatID, MsgContactVerificationFailed))
 		h.sessionMgr.ClearState(ctx, userID)
-		
-		// Send login success message with password info
-		loginMsg := MsgLoginSuccess + "\n\n" +
-			"📱 اطلاعات حساب کاربری شما:\n" +
-			"━━━━━━━━━━━━━━━━━━━━\n" +
-			"📞 شماره تلفن: " + phone + "\n" +
-			"🔑 رمز عبور: `" + defaultPassword + "`\n" +
-			"━━━━━━━━━━━━━━━━━━━━\n\n" +
-			"💡 نکته:\n" +
-			"• این رمز عبور برای ورود از طریق وب‌سایت یا اپلیکیشن استفاده می‌شود\n" +
-			"• می‌توانید این رمز را در تنظیمات حساب کاربری خود تغییر دهید"
-		
-		h.sendMessage(chatID, loginMsg)
-		
-		// Send main menu after a short delay
-		time.Sleep(500 * time.Millisecond)
-		h.sendMessageWithKeyboard(chatID, "🏠 منوی اصلی:", MainMenuKeyboard())
 		return
 	}
-
-	// User doesn't exist - register directly (no OTP needed for Telegram bot)
-	// Generate a secure default password based on phone number
-	defaultPassword := generateDefaultPassword(phone)
 	registerReq := RegisterRequest{
 		Phone:       phone,
-		Password:    defaultPassword,
+		Password:    password,
 		Name:        userName,
 		Role:        "user",
 		AutoLogin:   true,
@@ -314,74 +282,32 @@ func (h *Handlers) handleContact(msg *tgbotapi.Message) {
 	registerResp, err := h.apiClient.Register(ctx, registerReq)
 	if err != nil {
 		log.Printf("Failed to register user %s: %v", phone, err)
-		
+
 		// Check if it's a conflict error (user already exists)
 		if strings.Contains(err.Error(), "conflict") || strings.Contains(err.Error(), "exists") {
-			h.sendMessage(chatID, "⚠️ این شماره تلفن قبلاً ثبت‌نام شده است. لطفاً دوباره تلاش کنید.")
+			h.sendMessage(chatID, h.text(chatID, MsgPhoneAlreadyRegistered))
 		} else {
-			h.sendMessage(chatID, fmt.Sprintf("❌ خطا در ثبت‌نام: %v\n\nلطفاً دوباره تلاش کنید یا با پشتیبانی تماس بگیرید.", err))
+			h.sendMessage(chatID, h.text(chatID, MsgRegistrationFailed, err))
 		}
 		h.sessionMgr.ClearState(ctx, userID)
 		return
 	}
-	
-	log.Printf("User registered successfully: userID=%s, phone=%s", registerResp.UserID, phone)
 
-	// Update session
-	session, _ := h.sessionMgr.GetSession(ctx, userID)
-	if session != nil {
-		userIDStr := registerResp.UserID
-		accessToken := registerResp.AccessToken
-		refreshToken := registerResp.RefreshToken
-		expiresAt := time.Now().Add(time.Duration(registerResp.AccessExpiresIn) * time.Second)
-		firstName := msg.From.FirstName
-		lastName := msg.From.LastName
-		username := msg.From.UserName
-		langCode := msg.From.LanguageCode
-		
-		session.BackendUserID = &userIDStr
-		session.Phone = &phone
-		session.AccessToken = &accessToken
-		session.RefreshToken = &refreshToken
-		session.TokenExpiresAt = &expiresAt
-		session.FirstName = &firstName
-		if lastName != "" {
-			session.LastName = &lastName
-		}
-		if username != "" {
-			session.Username = &username
-		}
-		if langCode != "" {
-			session.LanguageCode = &langCode
-		}
-		h.sessionMgr.UpdateSession(ctx, session)
+	log.Printf("User registered successfully: userID=%s, phone=%s", registerResp.UserID, phone)
 
-		// Store tokens in Redis
-		if registerResp.AccessExpiresIn > 0 {
-			ttl := time.Duration(registerResp.AccessExpiresIn) * time.Second
-			_ = h.sessionMgr.GetStorage().StoreToken(ctx, userID, accessToken, refreshToken, ttl)
-		}
+	h.storeLogin(ctx, msg.From, phone, registerResp.UserID, registerResp.AccessToken, registerResp.RefreshToken, registerResp.AccessExpiresIn)
+	if err := h.linkNewAccount(ctx, userID, registerResp.AccessToken); err != nil {
+		// The session works until the tokens expire; /link can be used later
+		log.Printf("Failed to link Telegram account for user %s: %v", registerResp.UserID, err)
 	}
 
 	h.sessionMgr.ClearState(ctx, userID)
-	
-	// Send registration success message with credentials
-	successMsg := MsgRegistrationSuccess + "\n\n" +
-		"📱 اطلاعات حساب کاربری شما:\n" +
-		"━━━━━━━━━━━━━━━━━━━━\n" +
-		"📞 شماره تلفن: " + phone + "\n" +
-		"🔑 رمز عبور: `" + defaultPassword + "`\n" +
-		"━━━━━━━━━━━━━━━━━━━━\n\n" +
-		"💡 نکته مهم:\n" +
-		"• این رمز عبور برای ورود از طریق وب‌سایت یا اپلیکیشن استفاده می‌شود\n" +
-		"• می‌توانید این رمز را در تنظیمات حساب کاربری خود تغییر دهید\n" +
-		"• برای استفاده از ربات، نیازی به وارد کردن رمز نیست"
-	
-	h.sendMessage(chatID, successMsg)
-	
+
+	h.sendMessage(chatID, h.text(chatID, MsgRegistrationSuccess)+"\n\n"+h.text(chatID, MsgRegistrationPasswordHint))
+
 	// Send main menu after a short delay
 	time.Sleep(500 * time.Millisecond)
-	h.sendMessageWithKeyboard(chatID, "🏠 منوی اصلی:", MainMenuKeyboard())
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgMainMenu), MainMenuKeyboard(h.language(chatID)))
 }
 
 // handlePasswordInput handles password input (for future use)
@@ -398,7 +324,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	// Check authentication
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -409,7 +335,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	file, err := h.bot.GetFile(tgbotapi.FileConfig{FileID: photo.FileID})
 	if err != nil {
 		log.Printf("Failed to get file: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
@@ -419,7 +345,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	resp, err := httpClient.Get(fileURL)
 	if err != nil {
 		log.Printf("Failed to download file: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 	defer resp.Body.Close()
@@ -427,14 +353,14 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Failed to download file: HTTP %d", resp.StatusCode)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	fileData, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("Failed to read file: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
@@ -456,14 +382,14 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 
 	// Validate file size
 	if int64(len(fileData)) > h.config.Security.MaxUploadSize {
-		h.sendMessage(chatID, fmt.Sprintf(MsgImageTooLarge, formatSize(h.config.Security.MaxUploadSize)))
+		h.sendMessage(chatID, h.text(chatID, MsgImageTooLarge, formatSize(h.config.Security.MaxUploadSize)))
 		return
 	}
 
 	// Get access token
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -473,7 +399,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	state, err := h.sessionMgr.GetState(ctx, userID)
 	if err != nil {
 		log.Printf("Failed to get state: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 	
@@ -497,6 +423,22 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 			return state.Data
 		}())
 	
+	// Photos sent for a support ticket are attached to it
+	if state != nil && state.Action == stateWaitingSupportMessage {
+		if strings.TrimSpace(msg.Caption) == "" {
+			h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportPrompt), CancelKeyboard(h.language(chatID)))
+			return
+		}
+		uploadResp, err := h.apiClient.UploadImage(ctx, accessToken, fileData, file.FilePath, mimeType, "user")
+		if err != nil {
+			log.Printf("Failed to upload support attachment: %v", err)
+			h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
+			return
+		}
+		h.createSupportTicket(ctx, userID, chatID, msg.Caption, uploadResp.ID)
+		return
+	}
+
 	// Determine if this is the first or second image based on state
 	// First image: state is nil, empty, or "waiting_user_image"
 	// Second image: state is "waiting_cloth_image"
@@ -511,12 +453,12 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 	uploadResp, err := h.apiClient.UploadImage(ctx, accessToken, fileData, file.FilePath, mimeType, imageType)
 	if err != nil {
 		log.Printf("Failed to upload image: %v", err)
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	log.Printf("Image uploaded successfully: ID=%s, type=%s", uploadResp.ID, imageType)
-	h.sendMessage(chatID, fmt.Sprintf(MsgImageUploaded, uploadResp.ID))
+	h.sendMessage(chatID, h.text(chatID, MsgImageUploaded, uploadResp.ID))
 
 	// Update state based on image type
 	if isFirstImage {
@@ -524,7 +466,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 		log.Printf("First image received, setting state to waiting_cloth_image with userImageID=%s", uploadResp.ID)
 		if err := h.sessionMgr.SetState(ctx, userID, "waiting_cloth_image", uploadResp.ID); err != nil {
 			log.Printf("Failed to set state: %v", err)
-			h.sendMessage(chatID, "❌ خطا در ذخیره وضعیت. لطفاً دوباره تلاش کنید.")
+			h.sendMessage(chatID, h.text(chatID, MsgStateSaveFailed))
 			return
 		}
 		// Verify state was saved (with small delay to ensure database commit)
@@ -541,62 +483,34 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 		} else {
 			log.Printf("State verified successfully: action=%s, data=%s", verifyState.Action, verifyState.Data)
 		}
-		h.sendMessage(chatID, MsgImageReceived)
+		h.sendMessage(chatID, h.text(chatID, MsgImageReceived))
 	} else if state != nil && state.Action == "waiting_cloth_image" {
-		// Second image: Store cloth image ID and create conversion with mock=true
-		userImageID := state.Data
+		// Further images are the garments of the outfit, state data is the
+		// user image ID followed by the garment IDs so far
+		ids := strings.Split(state.Data, ",")
+		userImageID := ids[0]
 		if userImageID == "" {
 			log.Printf("Warning: userImageID is empty in state data")
-			h.sendMessage(chatID, "❌ خطا در دریافت اطلاعات. لطفاً دوباره از ابتدا شروع کنید.")
+			h.sendMessage(chatID, h.text(chatID, MsgStateLoadFailed))
 			h.sessionMgr.ClearState(ctx, userID)
 			return
 		}
-		log.Printf("Second image received, userImageID=%s, clothImageID=%s", userImageID, uploadResp.ID)
-		
-		// Get access token
-		accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
-		if err != nil || accessToken == "" {
-			h.sendMessage(chatID, MsgErrorUnauthorized)
-			h.sessionMgr.ClearState(ctx, userID)
-			return
-		}
-		
-		// Create conversion with mock=true for testing
-		convReq := ConversionRequest{
-			UserImageID:  userImageID,
-			ClothImageID: uploadResp.ID,
-			StyleName:    "default", // Default style for now
-		}
-		
-		log.Printf("Creating conversion with mock=true: userImageID=%s, clothImageID=%s", userImageID, uploadResp.ID)
-		convResp, err := h.apiClient.CreateConversionWithMock(ctx, accessToken, convReq)
-		if err != nil {
-			log.Printf("Failed to create conversion: %v", err)
-			h.sendMessage(chatID, fmt.Sprintf("❌ خطا در ایجاد تبدیل: %v", err))
-			h.sessionMgr.ClearState(ctx, userID)
-			return
-		}
-		
-		h.sessionMgr.ClearState(ctx, userID)
-		h.sendMessage(chatID, fmt.Sprintf("✅ تبدیل با موفقیت انجام شد!\n\nشناسه تبدیل: %s", convResp.ID))
-		
-		// Send result image if available
-		// First try to use ResultImageURL from response (for mock responses)
-		if convResp.ResultImageURL != "" {
-			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(convResp.ResultImageURL))
-			photo.Caption = "نتیجه تبدیل:"
-			h.bot.Send(photo)
-		} else if convResp.ResultImageID != nil {
-			// Fallback: get image URL from API
-			imageURL, err := h.apiClient.GetImageURL(ctx, accessToken, *convResp.ResultImageID)
-			if err == nil {
-				photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(imageURL))
-				photo.Caption = "نتیجه تبدیل:"
-				h.bot.Send(photo)
-			} else {
-				log.Printf("Failed to get image URL: %v", err)
+		garmentIDs := append(ids[1:], uploadResp.ID)
+		log.Printf("Garment image received, userImageID=%s, garmentIDs=%v", userImageID, garmentIDs)
+
+		// Wait for more garments until the outfit is full or the user tries it on
+		if len(garmentIDs) < h.config.Telegram.MaxGarments {
+			if err := h.sessionMgr.SetState(ctx, userID, "waiting_cloth_image", strings.Join(append([]string{userImageID}, garmentIDs...), ",")); err != nil {
+				log.Printf("Failed to set state: %v", err)
+				h.sendMessage(chatID, h.text(chatID, MsgStateSaveFailed))
+				return
 			}
+			h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgGarmentAdded, len(garmentIDs), h.config.Telegram.MaxGarments),
+				OutfitKeyboard(h.language(chatID)))
+			return
 		}
+
+		h.createTryOn(ctx, chatID, userID, userImageID, garmentIDs)
 	} else {
 		// Unexpected state - this shouldn't happen in normal flow
 		log.Printf("Unexpected state: %v", state)
@@ -606,7 +520,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 		// If we uploaded a cloth image but state doesn't match, something went wrong
 		if imageType == "cloth" {
 			log.Printf("Warning: Uploaded cloth image but state doesn't match waiting_cloth_image")
-			h.sendMessage(chatID, "❌ خطا در پردازش عکس لباس. لطفاً دوباره از ابتدا شروع کنید.")
+			h.sendMessage(chatID, h.text(chatID, MsgGarmentProcessingFailed))
 		h.sessionMgr.ClearState(ctx, userID)
 			return
 		}
@@ -614,10 +528,92 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 		h.sessionMgr.ClearState(ctx, userID)
 		if err := h.sessionMgr.SetState(ctx, userID, "waiting_cloth_image", uploadResp.ID); err != nil {
 			log.Printf("Failed to set state: %v", err)
-			h.sendMessage(chatID, "❌ خطا در ذخیره وضعیت. لطفاً دوباره تلاش کنید.")
+			h.sendMessage(chatID, h.text(chatID, MsgStateSaveFailed))
 			return
 		}
-		h.sendMessage(chatID, MsgImageReceived)
+		h.sendMessage(chatID, h.text(chatID, MsgImageReceived))
+	}
+}
+
+// handleOutfitTryOn converts the garments collected so far
+func (h *Handlers) handleOutfitTryOn(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	state, err := h.sessionMgr.GetState(ctx, userID)
+	if err != nil || state == nil || state.Action != "waiting_cloth_image" {
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
+		return
+	}
+	// The user image followed by at least one garment
+	ids := strings.Split(state.Data, ",")
+	if len(ids) < 2 || ids[0] == "" {
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.createTryOn(ctx, chatID, userID, ids[0], ids[1:])
+}
+
+// createTryOn creates the conversion of the user image with the garments, in
+// order, and sends the result
+func (h *Handlers) createTryOn(ctx context.Context, chatID, userID int64, userImageID string, garmentIDs []string) {
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		h.sessionMgr.ClearState(ctx, userID)
+		return
+	}
+	
+	// Create conversion with mock=true for testing
+	convReq := ConversionRequest{
+		UserImageID: userImageID,
+		StyleName:   "default", // Default style for now
+	}
+	if len(garmentIDs) == 1 {
+		convReq.ClothImageID = garmentIDs[0]
+	} else {
+		for _, garmentID := range garmentIDs {
+			convReq.Garments = append(convReq.Garments, ConversionGarment{ImageID: garmentID})
+		}
+	}
+	if h.applyDefaultPreset(ctx, accessToken, &convReq) {
+		convReq.StyleName = "" // the preset's style applies
+	}
+	
+	log.Printf("Creating conversion with mock=true: userImageID=%s, garmentIDs=%v", userImageID, garmentIDs)
+	convResp, err := h.apiClient.CreateConversionWithMock(ctx, accessToken, convReq)
+	if err != nil {
+		log.Printf("Failed to create conversion: %v", err)
+		h.sendMessage(chatID, h.text(chatID, MsgConversionCreateFailed, err))
+		h.sessionMgr.ClearState(ctx, userID)
+		return
+	}
+	
+	h.sessionMgr.ClearState(ctx, userID)
+	h.sendMessage(chatID, h.text(chatID, MsgConversionCreated, convResp.ID))
+	
+	// Send result image if available
+	// First try to use ResultImageURL from response (for mock responses)
+	if convResp.ResultImageURL != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(convResp.ResultImageURL))
+		photo.Caption = h.text(chatID, MsgConversionResultCaption)
+		photo.ReplyMarkup = ConversionResultKeyboard(h.language(chatID), convResp.ID)
+		h.bot.Send(photo)
+	} else if convResp.ResultImageID != nil {
+		// Fallback: get image URL from API
+		imageURL, err := h.apiClient.GetImageURL(ctx, accessToken, *convResp.ResultImageID)
+		if err == nil {
+			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(imageURL))
+			photo.Caption = h.text(chatID, MsgConversionResultCaption)
+			photo.ReplyMarkup = ConversionResultKeyboard(h.language(chatID), convResp.ID)
+			h.bot.Send(photo)
+		} else {
+			log.Printf("Failed to get image URL: %v", err)
+		}
 	}
 }
 
@@ -625,7 +621,7 @@ func (h *Handlers) handlePhoto(msg *tgbotapi.Message) {
 func (h *Handlers) handleDocument(msg *tgbotapi.Message) {
 	// Similar to handlePhoto but for documents
 	// For now, just handle photos
-	h.sendMessage(msg.Chat.ID, "لطفاً عکس را به صورت عکس ارسال کنید، نه فایل.")
+	h.sendMessage(msg.Chat.ID, h.text(msg.Chat.ID, MsgSendAsPhoto))
 }
 
 // HandleCallbackQuery handles inline keyboard callbacks
@@ -634,11 +630,12 @@ func (h *Handlers) HandleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	userID := query.From.ID
 	chatID := query.Message.Chat.ID
 	data := query.Data
+	h.rememberLanguage(chatID, query.From)
 
 	// Rate limiting
 	allowed, _ := h.rateLimiter.AllowUserMessage(ctx, userID, h.config.RateLimit.MessagesPerMinute, time.Minute)
 	if !allowed {
-		h.answerCallback(query.ID, "تعداد درخواست‌های شما بیش از حد مجاز است.")
+		h.answerCallback(query.ID, h.text(chatID, MsgRateLimitShort))
 		RecordRateLimitHit("callback")
 		return
 	}
@@ -655,18 +652,25 @@ func (h *Handlers) HandleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		h.handleGallery(query)
 	case data == "statistics":
 		h.handleStatistics(query)
+	// Support actions
+	case data == "support":
+		h.handleSupport(query)
+	case data == "support_new":
+		h.handleNewSupportTicket(query)
+	case data == "support_tickets":
+		h.handleSupportTickets(query)
 	case data == "about":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgAbout, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgAbout), BackToMenuKeyboard(h.language(chatID)))
 	case data == "help":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgHelp, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgHelp), BackToMenuKeyboard(h.language(chatID)))
 	case data == "settings":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgSettings, SettingsKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSettings), SettingsKeyboard(h.language(chatID)))
 	case data == "main_menu":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, "🏠 منوی اصلی:", MainMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgMainMenu), MainMenuKeyboard(h.language(chatID)))
 	// Profile submenu
 	case data == "profile_stats":
 		h.handleProfileStats(query)
@@ -674,33 +678,47 @@ func (h *Handlers) HandleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		h.handleProfileQuota(query)
 	case data == "profile_edit":
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, "📝 برای ویرایش پروفایل لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید.")
+		h.sendMessage(chatID, h.text(chatID, MsgProfileEdit))
 	// Settings submenu
 	case data == "settings_contact":
 		h.handleSettingsContact(query)
 	case data == "settings_notifications":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgSettingsNotifications, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSettingsNotifications), BackToMenuKeyboard(h.language(chatID)))
 	case data == "settings_language":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgSettingsLanguage, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSettingsLanguage), BackToMenuKeyboard(h.language(chatID)))
 	case data == "settings_password":
 		h.answerCallback(query.ID, "")
-		h.sendMessageWithKeyboard(chatID, MsgSettingsPassword, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSettingsPassword), BackToMenuKeyboard(h.language(chatID)))
 	// Conversion actions
 	case strings.HasPrefix(data, "style_"):
 		h.handleStyleSelection(query, strings.TrimPrefix(data, "style_"))
 	case data == "confirm_conversion":
 		h.handleConfirmConversion(query)
+	case data == "outfit_try_on":
+		h.handleOutfitTryOn(query)
 	case data == "cancel":
 		h.handleCancel(query)
 	case strings.HasPrefix(data, "view_conversion_"):
 		h.handleViewConversion(query, strings.TrimPrefix(data, "view_conversion_"))
+	// Share actions
+	case strings.HasPrefix(data, "share_"):
+		h.handleShareConversion(query, strings.TrimPrefix(data, "share_"))
+	case strings.HasPrefix(data, "revoke_share_"):
+		h.handleRevokeShare(query, strings.TrimPrefix(data, "revoke_share_"))
+	// Plan purchase actions
+	case data == "buy_plan":
+		h.handleBuyPlan(query)
+	case strings.HasPrefix(data, "select_plan_"):
+		h.handleSelectPlan(query, strings.TrimPrefix(data, "select_plan_"))
+	case strings.HasPrefix(data, "check_payment_"):
+		h.handleCheckPayment(query, strings.TrimPrefix(data, "check_payment_"))
 	case strings.HasPrefix(data, "conversions_page_"):
 		page, _ := strconv.Atoi(strings.TrimPrefix(data, "conversions_page_"))
 		h.handleConversionsPage(query, page)
 	default:
-		h.answerCallback(query.ID, "عملیات نامعتبر")
+		h.answerCallback(query.ID, h.text(chatID, MsgInvalidAction))
 	}
 }
 
@@ -714,9 +732,9 @@ func (h *Handlers) handleStartConversion(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized+"\n\n"+MsgShareContact)
-		msgConfig := tgbotapi.NewMessage(chatID, "لطفاً کانتکت خودتون رو share کنید:")
-		msgConfig.ReplyMarkup = ShareContactKeyboard()
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized)+"\n\n"+h.text(chatID, MsgShareContact))
+		msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msgConfig.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msgConfig)
 		// Set state to wait for contact
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
@@ -730,7 +748,7 @@ func (h *Handlers) handleStartConversion(query *tgbotapi.CallbackQuery) {
 	}
 	if !allowed {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorRateLimit)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorRateLimit))
 		RecordRateLimitHit("conversion")
 		return
 	}
@@ -739,7 +757,7 @@ func (h *Handlers) handleStartConversion(query *tgbotapi.CallbackQuery) {
 	// Clear any previous state and set new state
 	h.sessionMgr.ClearState(ctx, userID)
 	h.sessionMgr.SetState(ctx, userID, "waiting_user_image", "")
-	h.sendMessage(chatID, "لطفاً عکس خودتون رو ارسال کنید:")
+	h.sendMessage(chatID, h.text(chatID, MsgSendYourPhoto))
 }
 
 // handleStyleSelection handles style selection
@@ -751,14 +769,14 @@ func (h *Handlers) handleStyleSelection(query *tgbotapi.CallbackQuery, style str
 	// Get state
 	state, err := h.sessionMgr.GetState(ctx, userID)
 	if err != nil || state == nil || state.Action != "waiting_style" {
-		h.answerCallback(query.ID, "خطا در دریافت اطلاعات")
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
 		return
 	}
 
 	// Parse image IDs
 	parts := strings.Split(state.Data, ":")
 	if len(parts) != 2 {
-		h.answerCallback(query.ID, "خطا در دریافت اطلاعات")
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
 		return
 	}
 
@@ -774,8 +792,8 @@ func (h *Handlers) handleStyleSelection(query *tgbotapi.CallbackQuery, style str
 	dataJSON, _ := json.Marshal(conversionData)
 	h.sessionMgr.SetState(ctx, userID, "confirming_conversion", string(dataJSON))
 
-	h.answerCallback(query.ID, fmt.Sprintf("استایل انتخاب شد: %s", getStyleDisplayName(style)))
-	h.sendMessageWithKeyboard(chatID, "آیا می‌خواهید تبدیل را شروع کنید؟", ConversionConfirmationKeyboard())
+	h.answerCallback(query.ID, h.text(chatID, MsgStyleSelected, getStyleDisplayName(h.language(chatID), style)))
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgConfirmConversion), ConversionConfirmationKeyboard(h.language(chatID)))
 }
 
 // handleConfirmConversion handles conversion confirmation
@@ -788,21 +806,21 @@ func (h *Handlers) handleConfirmConversion(query *tgbotapi.CallbackQuery) {
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
 	// Get state
 	state, err := h.sessionMgr.GetState(ctx, userID)
 	if err != nil || state == nil || state.Action != "confirming_conversion" {
-		h.answerCallback(query.ID, "خطا در دریافت اطلاعات")
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
 		return
 	}
 
 	// Parse conversion data
 	var conversionData map[string]string
 	if err := json.Unmarshal([]byte(state.Data), &conversionData); err != nil {
-		h.answerCallback(query.ID, "خطا در دریافت اطلاعات")
+		h.answerCallback(query.ID, h.text(chatID, MsgDataLoadFailed))
 		return
 	}
 
@@ -812,18 +830,19 @@ func (h *Handlers) handleConfirmConversion(query *tgbotapi.CallbackQuery) {
 		ClothImageID: conversionData["clothImageID"],
 		StyleName:    conversionData["style"],
 	}
+	h.applyDefaultPreset(ctx, accessToken, &convReq)
 
 	convResp, err := h.apiClient.CreateConversion(ctx, accessToken, convReq)
 	if err != nil {
 		log.Printf("Failed to create conversion: %v", err)
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	h.answerCallback(query.ID, "")
 	h.sessionMgr.ClearState(ctx, userID)
-	h.sendMessage(chatID, fmt.Sprintf(MsgConversionStarted, convResp.ID))
+	h.sendMessage(chatID, h.text(chatID, MsgConversionStarted, convResp.ID))
 
 	// Start polling for conversion status
 	go h.pollConversionStatus(ctx, userID, chatID, convResp.ID, accessToken)
@@ -839,12 +858,13 @@ func (h *Handlers) pollConversionStatus(ctx context.Context, userID int64, chatI
 	defer ticker.Stop()
 
 	var lastMessageID int
+	lastProgress := -1
 
 	for {
 		select {
 		case <-pollCtx.Done():
 			if pollCtx.Err() == context.DeadlineExceeded {
-				h.sendMessage(chatID, "زمان پردازش به پایان رسید. لطفاً دوباره تلاش کنید.")
+				h.sendMessage(chatID, h.text(chatID, MsgConversionTimeout))
 			}
 			return
 		case <-ticker.C:
@@ -861,36 +881,47 @@ func (h *Handlers) pollConversionStatus(ctx context.Context, userID int64, chatI
 					imageURL, err := h.apiClient.GetImageURL(pollCtx, accessToken, *conv.ResultImageID)
 					if err == nil {
 						photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(imageURL))
-						photo.Caption = MsgConversionCompleted
-						photo.ReplyMarkup = ConversionResultKeyboard(conversionID)
+						photo.Caption = h.text(chatID, MsgConversionCompleted)
+						photo.ReplyMarkup = ConversionResultKeyboard(h.language(chatID), conversionID)
 						h.bot.Send(photo)
 					} else {
-						h.sendMessageWithKeyboard(chatID, MsgConversionCompleted, ConversionResultKeyboard(conversionID))
+						h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgConversionCompleted), ConversionResultKeyboard(h.language(chatID), conversionID))
 					}
 				} else {
-					h.sendMessageWithKeyboard(chatID, MsgConversionCompleted, ConversionResultKeyboard(conversionID))
+					h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgConversionCompleted), ConversionResultKeyboard(h.language(chatID), conversionID))
 				}
 				RecordConversion("completed")
 				return
 			case "failed":
-				errorMsg := "خطای نامشخص"
-				if conv.ErrorMessage != nil {
+				errorMsg := h.text(chatID, MsgUnknownError)
+				if conv.ErrorCode != nil && messages.Has(locale.DefaultLanguage, errorCodeKeyPrefix+*conv.ErrorCode) {
+					errorMsg = h.text(chatID, errorCodeKeyPrefix+*conv.ErrorCode)
+				} else if conv.ErrorMessage != nil {
 					errorMsg = *conv.ErrorMessage
 				}
-				h.sendMessage(chatID, fmt.Sprintf(MsgConversionFailed, errorMsg))
+				message := h.text(chatID, MsgConversionFailed, errorMsg)
+				if conv.TraceID != "" {
+					// Support finds the failed conversion by the code the user quotes
+					message += "\n\n" + h.text(chatID, MsgConversionTraceID, conv.TraceID)
+				}
+				h.sendMessage(chatID, message)
 				RecordConversion("failed")
 				return
 			case "processing":
-				// Estimate progress (simplified - in production, get actual progress from API)
-				progress := 50 // Default progress
+				// Progress reported by the worker; Telegram rejects edits that change nothing
+				progress := conv.Progress
+				if progress == lastProgress {
+					continue
+				}
+				lastProgress = progress
 				if lastMessageID == 0 {
-					msg := tgbotapi.NewMessage(chatID, GetProgressMessage(progress))
+					msg := tgbotapi.NewMessage(chatID, GetProgressMessage(h.language(chatID), progress))
 					sent, _ := h.bot.Send(msg)
 					if sent.MessageID != 0 {
 						lastMessageID = sent.MessageID
 					}
 				} else {
-					edit := tgbotapi.NewEditMessageText(chatID, lastMessageID, GetProgressMessage(progress))
+					edit := tgbotapi.NewEditMessageText(chatID, lastMessageID, GetProgressMessage(h.language(chatID), progress))
 					h.bot.Send(edit)
 				}
 			case "pending":
@@ -910,9 +941,9 @@ func (h *Handlers) handleMyConversions(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized+"\n\n"+MsgShareContact)
-		msgConfig := tgbotapi.NewMessage(chatID, "لطفاً کانتکت خودتون رو share کنید:")
-		msgConfig.ReplyMarkup = ShareContactKeyboard()
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized)+"\n\n"+h.text(chatID, MsgShareContact))
+		msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msgConfig.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msgConfig)
 		// Set state to wait for contact
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
@@ -923,7 +954,7 @@ func (h *Handlers) handleMyConversions(query *tgbotapi.CallbackQuery) {
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -932,34 +963,34 @@ func (h *Handlers) handleMyConversions(query *tgbotapi.CallbackQuery) {
 	if err != nil {
 		log.Printf("Failed to list conversions: %v", err)
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	h.answerCallback(query.ID, "")
 
 	if len(conversions.Conversions) == 0 {
-		h.sendMessage(chatID, MsgNoConversions)
+		h.sendMessage(chatID, h.text(chatID, MsgNoConversions))
 		return
 	}
 
 	// Format conversions list
-	text := MsgMyConversions + "\n\n"
+	text := h.text(chatID, MsgMyConversions) + "\n\n"
 	for i, conv := range conversions.Conversions {
 		// Safely truncate ID for display
 		displayID := conv.ID
 		if len(displayID) > 8 {
 			displayID = displayID[:8]
 		}
-		text += fmt.Sprintf("%d. تبدیل #%s\n   وضعیت: %s\n   تاریخ: %s\n\n",
-			i+1, displayID, getStatusText(conv.Status), conv.CreatedAt.Format("2006-01-02 15:04"))
+		text += h.text(chatID, MsgConversionListItem,
+			i+1, displayID, getStatusText(h.language(chatID), conv.Status), h.formatter(chatID).DateTime(conv.CreatedAt))
 	}
 
 	// Send with pagination if needed
 	if conversions.TotalPages > 1 {
-		h.sendMessageWithKeyboard(chatID, text, ConversionsListKeyboard(1, conversions.TotalPages, ""))
+		h.sendMessageWithKeyboard(chatID, text, ConversionsListKeyboard(h.language(chatID), 1, conversions.TotalPages, ""))
 	} else {
-		h.sendMessageWithKeyboard(chatID, text, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, text, BackToMenuKeyboard(h.language(chatID)))
 	}
 }
 
@@ -973,7 +1004,7 @@ func (h *Handlers) handleViewConversion(query *tgbotapi.CallbackQuery, conversio
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -982,7 +1013,7 @@ func (h *Handlers) handleViewConversion(query *tgbotapi.CallbackQuery, conversio
 	if err != nil {
 		log.Printf("Failed to get conversion: %v", err)
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgConversionNotFound)
+		h.sendMessage(chatID, h.text(chatID, MsgConversionNotFound))
 		return
 	}
 
@@ -993,19 +1024,26 @@ func (h *Handlers) handleViewConversion(query *tgbotapi.CallbackQuery, conversio
 	if len(displayID) > 8 {
 		displayID = displayID[:8]
 	}
-	text := fmt.Sprintf("تبدیل #%s\nوضعیت: %s\n", displayID, getStatusText(conv.Status))
+	text := h.text(chatID, MsgConversionDetails, displayID, getStatusText(h.language(chatID), conv.Status))
+
+	// Completed conversions can be shared from here
+	keyboard := BackToMenuKeyboard(h.language(chatID))
+	if conv.Status == "completed" {
+		keyboard = ConversionResultKeyboard(h.language(chatID), conversionID)
+	}
+
 	if conv.ResultImageID != nil {
 		imageURL, err := h.apiClient.GetImageURL(ctx, accessToken, *conv.ResultImageID)
 		if err == nil {
 			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(imageURL))
 			photo.Caption = text
-			photo.ReplyMarkup = BackToMenuKeyboard()
+			photo.ReplyMarkup = keyboard
 			h.bot.Send(photo)
 			return
 		}
 	}
 
-	h.sendMessageWithKeyboard(chatID, text, BackToMenuKeyboard())
+	h.sendMessageWithKeyboard(chatID, text, keyboard)
 }
 
 // handleConversionsPage handles pagination for conversions list
@@ -1018,7 +1056,7 @@ func (h *Handlers) handleConversionsPage(query *tgbotapi.CallbackQuery, page int
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1026,7 +1064,7 @@ func (h *Handlers) handleConversionsPage(query *tgbotapi.CallbackQuery, page int
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1035,34 +1073,34 @@ func (h *Handlers) handleConversionsPage(query *tgbotapi.CallbackQuery, page int
 	if err != nil {
 		log.Printf("Failed to list conversions: %v", err)
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	h.answerCallback(query.ID, "")
 
 	if len(conversions.Conversions) == 0 {
-		h.sendMessage(chatID, MsgNoConversions)
+		h.sendMessage(chatID, h.text(chatID, MsgNoConversions))
 		return
 	}
 
 	// Format conversions list
-	text := MsgMyConversions + "\n\n"
+	text := h.text(chatID, MsgMyConversions) + "\n\n"
 	for i, conv := range conversions.Conversions {
 		// Safely truncate ID for display
 		displayID := conv.ID
 		if len(displayID) > 8 {
 			displayID = displayID[:8]
 		}
-		text += fmt.Sprintf("%d. تبدیل #%s\n   وضعیت: %s\n   تاریخ: %s\n\n",
-			(page-1)*10+i+1, displayID, getStatusText(conv.Status), conv.CreatedAt.Format("2006-01-02 15:04"))
+		text += h.text(chatID, MsgConversionListItem,
+			(page-1)*10+i+1, displayID, getStatusText(h.language(chatID), conv.Status), h.formatter(chatID).DateTime(conv.CreatedAt))
 	}
 
 	// Send with pagination if needed
 	if conversions.TotalPages > 1 {
-		h.sendMessageWithKeyboard(chatID, text, ConversionsListKeyboard(page, conversions.TotalPages, ""))
+		h.sendMessageWithKeyboard(chatID, text, ConversionsListKeyboard(h.language(chatID), page, conversions.TotalPages, ""))
 	} else {
-		h.sendMessageWithKeyboard(chatID, text, BackToMenuKeyboard())
+		h.sendMessageWithKeyboard(chatID, text, BackToMenuKeyboard(h.language(chatID)))
 	}
 }
 
@@ -1073,8 +1111,8 @@ func (h *Handlers) handleCancel(query *tgbotapi.CallbackQuery) {
 	chatID := query.Message.Chat.ID
 
 	h.sessionMgr.ClearState(ctx, userID)
-	h.answerCallback(query.ID, "لغو شد")
-	h.sendMessageWithKeyboard(chatID, "✅ عملیات لغو شد.", MainMenuKeyboard())
+	h.answerCallback(query.ID, h.text(chatID, MsgCancelled))
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgOperationCancelled), MainMenuKeyboard(h.language(chatID)))
 }
 
 // handleProfile handles profile menu
@@ -1087,9 +1125,9 @@ func (h *Handlers) handleProfile(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized+"\n\n"+MsgShareContact)
-		msgConfig := tgbotapi.NewMessage(chatID, "لطفاً کانتکت خودتون رو share کنید:")
-		msgConfig.ReplyMarkup = ShareContactKeyboard()
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized)+"\n\n"+h.text(chatID, MsgShareContact))
+		msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msgConfig.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msgConfig)
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
 		return
@@ -1100,29 +1138,29 @@ func (h *Handlers) handleProfile(query *tgbotapi.CallbackQuery) {
 	// Get user info from session
 	session, err := h.sessionMgr.GetSession(ctx, userID)
 	if err != nil || session == nil {
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	// Format profile message
-	profileMsg := MsgProfile + "\n\n"
+	profileMsg := h.text(chatID, MsgProfile) + "\n\n"
 	profileMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 	if session.FirstName != nil {
-		profileMsg += "👤 نام: " + *session.FirstName
+		name := *session.FirstName
 		if session.LastName != nil {
-			profileMsg += " " + *session.LastName
+			name += " " + *session.LastName
 		}
-		profileMsg += "\n"
+		profileMsg += h.text(chatID, MsgProfileName, name) + "\n"
 	}
 	if session.Phone != nil {
-		profileMsg += "📞 شماره تلفن: " + *session.Phone + "\n"
+		profileMsg += h.text(chatID, MsgProfilePhone, *session.Phone) + "\n"
 	}
 	if session.Username != nil && *session.Username != "" {
-		profileMsg += "🔗 یوزرنیم: @" + *session.Username + "\n"
+		profileMsg += h.text(chatID, MsgProfileUsername, *session.Username) + "\n"
 	}
 	profileMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 
-	h.sendMessageWithKeyboard(chatID, profileMsg, ProfileKeyboard())
+	h.sendMessageWithKeyboard(chatID, profileMsg, ProfileKeyboard(h.language(chatID)))
 }
 
 // handleProfileStats handles profile statistics
@@ -1135,7 +1173,7 @@ func (h *Handlers) handleProfileStats(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1143,7 +1181,7 @@ func (h *Handlers) handleProfileStats(query *tgbotapi.CallbackQuery) {
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1153,26 +1191,26 @@ func (h *Handlers) handleProfileStats(query *tgbotapi.CallbackQuery) {
 	stats, err := h.apiClient.GetStatistics(ctx, accessToken)
 	if err != nil {
 		log.Printf("Failed to get statistics: %v", err)
-		h.sendMessage(chatID, "⚠️ دریافت آمار با خطا مواجه شد.")
+		h.sendMessage(chatID, h.text(chatID, MsgStatsFailed))
 		return
 	}
 
 	// Format statistics message
-	statsMsg := MsgProfileStats + "\n\n"
+	statsMsg := h.text(chatID, MsgProfileStats) + "\n\n"
 	statsMsg += "━━━━━━━━━━━━━━━━━━━━\n"
-	statsMsg += fmt.Sprintf("📊 کل تبدیل‌ها: %d\n", stats.TotalConversions)
-	statsMsg += fmt.Sprintf("✅ موفق: %d\n", stats.Successful)
-	statsMsg += fmt.Sprintf("❌ ناموفق: %d\n", stats.Failed)
+	statsMsg += h.text(chatID, MsgStatsTotal, h.formatter(chatID).Number(int64(stats.TotalConversions))) + "\n"
+	statsMsg += h.text(chatID, MsgStatsSuccessful, h.formatter(chatID).Number(int64(stats.Successful))) + "\n"
+	statsMsg += h.text(chatID, MsgStatsFailedCount, h.formatter(chatID).Number(int64(stats.Failed))) + "\n"
 	if stats.TotalConversions > 0 {
 		successRate := float64(stats.Successful) / float64(stats.TotalConversions) * 100
-		statsMsg += fmt.Sprintf("📈 نرخ موفقیت: %.1f%%\n", successRate)
+		statsMsg += h.text(chatID, MsgStatsSuccessRate, h.formatter(chatID).Decimal(successRate, 1)) + "\n"
 	}
 	if stats.AverageTime > 0 {
-		statsMsg += fmt.Sprintf("⏱️ زمان متوسط: %.1f ثانیه\n", stats.AverageTime)
+		statsMsg += h.text(chatID, MsgStatsAverageTime, h.formatter(chatID).Decimal(stats.AverageTime, 1)) + "\n"
 	}
 	statsMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 
-	h.sendMessageWithKeyboard(chatID, statsMsg, ProfileKeyboard())
+	h.sendMessageWithKeyboard(chatID, statsMsg, ProfileKeyboard(h.language(chatID)))
 }
 
 // handleProfileQuota handles profile quota
@@ -1185,7 +1223,7 @@ func (h *Handlers) handleProfileQuota(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1193,7 +1231,7 @@ func (h *Handlers) handleProfileQuota(query *tgbotapi.CallbackQuery) {
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1203,28 +1241,27 @@ func (h *Handlers) handleProfileQuota(query *tgbotapi.CallbackQuery) {
 	quota, err := h.apiClient.GetQuotaStatus(ctx, accessToken)
 	if err != nil {
 		log.Printf("Failed to get quota: %v", err)
-		h.sendMessage(chatID, "⚠️ دریافت اطلاعات کووتا با خطا مواجه شد.")
+		h.sendMessage(chatID, h.text(chatID, MsgQuotaFailed))
 		return
 	}
 
 	// Format quota message
-	quotaMsg := MsgProfileQuota + "\n\n"
+	quotaMsg := h.text(chatID, MsgProfileQuota) + "\n\n"
 	quotaMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 	if quota.Plan != "" {
-		quotaMsg += "📦 پلن: " + quota.Plan + "\n"
+		quotaMsg += h.text(chatID, MsgQuotaPlan, quota.Plan) + "\n"
 	}
-	quotaMsg += fmt.Sprintf("📊 استفاده شده: %d از %d\n", quota.Used, quota.Total)
-	quotaMsg += fmt.Sprintf("🔄 باقیمانده: %d\n", quota.Remaining)
+	quotaMsg += h.text(chatID, MsgQuotaUsed, h.formatter(chatID).Number(int64(quota.Used)), h.formatter(chatID).Number(int64(quota.Total))) + "\n"
+	quotaMsg += h.text(chatID, MsgQuotaRemaining, h.formatter(chatID).Number(int64(quota.Remaining))) + "\n"
 	if quota.Percentage > 0 {
-		quotaMsg += fmt.Sprintf("📈 درصد استفاده: %.1f%%\n", quota.Percentage)
+		quotaMsg += h.text(chatID, MsgQuotaPercentage, h.formatter(chatID).Decimal(quota.Percentage, 1)) + "\n"
 	}
 	if quota.Exceeded {
-		quotaMsg += "⚠️ کووتا تمام شده است!\n"
-		quotaMsg += "💳 برای ادامه استفاده، پلن خود را ارتقا دهید.\n"
+		quotaMsg += h.text(chatID, MsgQuotaExhausted) + "\n"
 	}
 	quotaMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 
-	h.sendMessageWithKeyboard(chatID, quotaMsg, ProfileKeyboard())
+	h.sendMessageWithKeyboard(chatID, quotaMsg, ProfileKeyboard(h.language(chatID)))
 }
 
 // handleGallery handles gallery menu
@@ -1237,16 +1274,16 @@ func (h *Handlers) handleGallery(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized+"\n\n"+MsgShareContact)
-		msgConfig := tgbotapi.NewMessage(chatID, "لطفاً کانتکت خودتون رو share کنید:")
-		msgConfig.ReplyMarkup = ShareContactKeyboard()
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized)+"\n\n"+h.text(chatID, MsgShareContact))
+		msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msgConfig.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msgConfig)
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
 		return
 	}
 
 	h.answerCallback(query.ID, "")
-	h.sendMessageWithKeyboard(chatID, MsgGallery+"\n\n📝 گالری در حال آماده‌سازی است. به زودی در دسترس خواهد بود.", BackToMenuKeyboard())
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgGallery)+"\n\n"+h.text(chatID, MsgGalleryComingSoon), BackToMenuKeyboard(h.language(chatID)))
 }
 
 // handleStatistics handles statistics menu
@@ -1259,9 +1296,9 @@ func (h *Handlers) handleStatistics(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized+"\n\n"+MsgShareContact)
-		msgConfig := tgbotapi.NewMessage(chatID, "لطفاً کانتکت خودتون رو share کنید:")
-		msgConfig.ReplyMarkup = ShareContactKeyboard()
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized)+"\n\n"+h.text(chatID, MsgShareContact))
+		msgConfig := tgbotapi.NewMessage(chatID, h.text(chatID, MsgShareContactPrompt))
+		msgConfig.ReplyMarkup = ShareContactKeyboard(h.language(chatID))
 		h.bot.Send(msgConfig)
 		h.sessionMgr.SetState(ctx, userID, "waiting_contact", "")
 		return
@@ -1271,7 +1308,7 @@ func (h *Handlers) handleStatistics(query *tgbotapi.CallbackQuery) {
 	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
 	if err != nil || accessToken == "" {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1281,26 +1318,26 @@ func (h *Handlers) handleStatistics(query *tgbotapi.CallbackQuery) {
 	stats, err := h.apiClient.GetStatistics(ctx, accessToken)
 	if err != nil {
 		log.Printf("Failed to get statistics: %v", err)
-		h.sendMessage(chatID, "⚠️ دریافت آمار با خطا مواجه شد.")
+		h.sendMessage(chatID, h.text(chatID, MsgStatsFailed))
 		return
 	}
 
 	// Format statistics message
-	statsMsg := MsgStatistics + "\n\n"
+	statsMsg := h.text(chatID, MsgStatistics) + "\n\n"
 	statsMsg += "━━━━━━━━━━━━━━━━━━━━\n"
-	statsMsg += fmt.Sprintf("📊 کل تبدیل‌ها: %d\n", stats.TotalConversions)
-	statsMsg += fmt.Sprintf("✅ موفق: %d\n", stats.Successful)
-	statsMsg += fmt.Sprintf("❌ ناموفق: %d\n", stats.Failed)
+	statsMsg += h.text(chatID, MsgStatsTotal, h.formatter(chatID).Number(int64(stats.TotalConversions))) + "\n"
+	statsMsg += h.text(chatID, MsgStatsSuccessful, h.formatter(chatID).Number(int64(stats.Successful))) + "\n"
+	statsMsg += h.text(chatID, MsgStatsFailedCount, h.formatter(chatID).Number(int64(stats.Failed))) + "\n"
 	if stats.TotalConversions > 0 {
 		successRate := float64(stats.Successful) / float64(stats.TotalConversions) * 100
-		statsMsg += fmt.Sprintf("📈 نرخ موفقیت: %.1f%%\n", successRate)
+		statsMsg += h.text(chatID, MsgStatsSuccessRate, h.formatter(chatID).Decimal(successRate, 1)) + "\n"
 	}
 	if stats.AverageTime > 0 {
-		statsMsg += fmt.Sprintf("⏱️ زمان متوسط: %.1f ثانیه\n", stats.AverageTime)
+		statsMsg += h.text(chatID, MsgStatsAverageTime, h.formatter(chatID).Decimal(stats.AverageTime, 1)) + "\n"
 	}
 	statsMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 
-	h.sendMessageWithKeyboard(chatID, statsMsg, BackToMenuKeyboard())
+	h.sendMessageWithKeyboard(chatID, statsMsg, BackToMenuKeyboard(h.language(chatID)))
 }
 
 // handleSettingsContact handles settings contact
@@ -1313,7 +1350,7 @@ func (h *Handlers) handleSettingsContact(query *tgbotapi.CallbackQuery) {
 	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
 	if err != nil || !authenticated {
 		h.answerCallback(query.ID, "")
-		h.sendMessage(chatID, MsgErrorUnauthorized)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
 		return
 	}
 
@@ -1322,23 +1359,23 @@ func (h *Handlers) handleSettingsContact(query *tgbotapi.CallbackQuery) {
 	// Get user info from session
 	session, err := h.sessionMgr.GetSession(ctx, userID)
 	if err != nil || session == nil {
-		h.sendMessage(chatID, MsgErrorGeneric)
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
 		return
 	}
 
 	// Format contact message
-	contactMsg := MsgSettingsContact + "\n\n"
+	contactMsg := h.text(chatID, MsgSettingsContact) + "\n\n"
 	contactMsg += "━━━━━━━━━━━━━━━━━━━━\n"
 	if session.Phone != nil {
-		contactMsg += "📞 شماره تلفن: " + *session.Phone + "\n"
+		contactMsg += h.text(chatID, MsgProfilePhone, *session.Phone) + "\n"
 	}
 	if session.Username != nil && *session.Username != "" {
-		contactMsg += "🔗 یوزرنیم: @" + *session.Username + "\n"
+		contactMsg += h.text(chatID, MsgProfileUsername, *session.Username) + "\n"
 	}
 	contactMsg += "━━━━━━━━━━━━━━━━━━━━\n"
-	contactMsg += "\n💡 برای تغییر اطلاعات تماس، لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید."
+	contactMsg += "\n" + h.text(chatID, MsgSettingsContactHint)
 
-	h.sendMessageWithKeyboard(chatID, contactMsg, BackToMenuKeyboard())
+	h.sendMessageWithKeyboard(chatID, contactMsg, BackToMenuKeyboard(h.language(chatID)))
 }
 
 // Helper functions
@@ -1386,12 +1423,6 @@ func normalizePhone(phone string) string {
 	return phone
 }
 
-func generateDefaultPassword(phone string) string {
-	// Generate a default password based on phone
-	// In production, you might want to ask user for password
-	return "TelegramBot" + phone[len(phone)-6:]
-}
-
 func formatSize(bytes int64) string {
 	if bytes < 1024 {
 		return fmt.Sprintf("%d B", bytes)
@@ -1401,16 +1432,26 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.2f MB", float64(bytes)/(1024*1024))
 }
 
-func getStatusText(status string) string {
-	statusMap := map[string]string{
-		"pending":    "در انتظار",
-		"processing": "در حال پردازش",
-		"completed":  "تکمیل شده",
-		"failed":     "ناموفق",
-	}
-	if text, ok := statusMap[status]; ok {
-		return text
+func getStatusText(lang, status string) string {
+	if messages.Has(locale.DefaultLanguage, statusKeyPrefix+status) {
+		return messages.Text(lang, statusKeyPrefix+status)
 	}
 	return status
 }
 
+// applyDefaultPreset makes the conversion use the user's default preset, so
+// the options saved in the web app apply in the bot too. The chosen style
+// still overrides the preset's. It reports whether a preset was applied.
+func (h *Handlers) applyDefaultPreset(ctx context.Context, accessToken string, req *ConversionRequest) bool {
+	preset, err := h.apiClient.GetDefaultPreset(ctx, accessToken)
+	if err != nil {
+		// Log but don't fail - convert without the preset
+		log.Printf("Failed to get default preset: %v", err)
+		return false
+	}
+	if preset == nil {
+		return false
+	}
+	req.PresetID = preset.ID
+	return true
+}
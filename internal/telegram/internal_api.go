@@ -0,0 +1,213 @@
+package telegram
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Methods of the backend's internal gRPC API
+const (
+	internalTelegramLogin    = "/aistyler.internal.v1.Auth/TelegramLogin"
+	internalTelegramLink     = "/aistyler.internal.v1.Auth/TelegramLink"
+	internalCreateConversion = "/aistyler.internal.v1.Conversions/CreateConversion"
+	internalGetConversion    = "/aistyler.internal.v1.Conversions/GetConversion"
+	internalListConversions  = "/aistyler.internal.v1.Conversions/ListConversions"
+	internalUploadImage      = "/aistyler.internal.v1.Images/UploadImage"
+	internalGetImage         = "/aistyler.internal.v1.Images/GetImage"
+)
+
+// internalJSONCodec matches the backend's internal API codec: messages are
+// the JSON bodies of the REST endpoints
+type internalJSONCodec struct{}
+
+func (internalJSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (internalJSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (internalJSONCodec) Name() string                       { return "json" }
+
+// internalImage is the image the internal API returns
+type internalImage struct {
+	ID           string  `json:"id"`
+	Type         string  `json:"type"`
+	FileName     string  `json:"fileName"`
+	FileSize     int64   `json:"fileSize"`
+	OriginalURL  string  `json:"originalUrl"`
+	ThumbnailURL *string `json:"thumbnailUrl,omitempty"`
+}
+
+// InternalAPIClient calls the backend's internal gRPC API over mutual TLS,
+// bypassing the public middleware and rate limits
+type InternalAPIClient struct {
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+// NewInternalAPIClient connects to the internal API at addr, presenting the
+// bot's client certificate and trusting servers signed by caFile.
+// serverName overrides the name checked on the server certificate.
+func NewInternalAPIClient(addr, certFile, keyFile, caFile, serverName string, timeout time.Duration) (*InternalAPIClient, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server CA: %w", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in server CA %s", caFile)
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	})
+	return newInternalAPIClient(addr, timeout, grpc.WithTransportCredentials(creds))
+}
+
+func newInternalAPIClient(addr string, timeout time.Duration, opts ...grpc.DialOption) (*InternalAPIClient, error) {
+	opts = append(opts, grpc.WithDefaultCallOptions(grpc.ForceCodec(internalJSONCodec{})))
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create internal API client: %w", err)
+	}
+	return &InternalAPIClient{conn: conn, timeout: timeout}, nil
+}
+
+// Close closes the connection
+func (c *InternalAPIClient) Close() error {
+	return c.conn.Close()
+}
+
+// invoke calls method on behalf of the user of accessToken, if any
+func (c *InternalAPIClient) invoke(ctx context.Context, method, accessToken string, req, resp any) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	if err := c.conn.Invoke(ctx, method, req, resp); err != nil {
+		return fmt.Errorf("internal API error: %w", err)
+	}
+	return nil
+}
+
+// TelegramLogin signs in the backend user linked to a Telegram account
+func (c *InternalAPIClient) TelegramLogin(ctx context.Context, telegramUserID int64) (*LoginResponse, error) {
+	var result LoginResponse
+	err := c.invoke(ctx, internalTelegramLogin, "", TelegramLoginRequest{TelegramUserID: telegramUserID}, &result)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrTelegramNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LinkTelegramAccount links a Telegram account to the owner of a link code and signs it in
+func (c *InternalAPIClient) LinkTelegramAccount(ctx context.Context, code string, telegramUserID int64) (*LoginResponse, error) {
+	var result LoginResponse
+	err := c.invoke(ctx, internalTelegramLink, "", TelegramLinkRequest{Code: code, TelegramUserID: telegramUserID}, &result)
+	switch status.Code(err) {
+	case codes.OK:
+		return &result, nil
+	case codes.Unauthenticated, codes.InvalidArgument:
+		return nil, ErrInvalidLinkCode
+	}
+	return nil, err
+}
+
+// UploadImage uploads an image for the user
+func (c *InternalAPIClient) UploadImage(ctx context.Context, accessToken string, fileData []byte, fileName, mimeType, imageType string) (*ImageUploadResponse, error) {
+	req := struct {
+		Type     string `json:"type"`
+		FileName string `json:"fileName"`
+		MimeType string `json:"mimeType"`
+		Data     []byte `json:"data"`
+	}{imageType, fileName, mimeType, fileData}
+
+	var result internalImage
+	if err := c.invoke(ctx, internalUploadImage, accessToken, req, &result); err != nil {
+		return nil, err
+	}
+	return &ImageUploadResponse{
+		ID:       result.ID,
+		URL:      result.OriginalURL,
+		Type:     result.Type,
+		FileName: result.FileName,
+		FileSize: result.FileSize,
+	}, nil
+}
+
+// CreateConversion queues a conversion; poll GetConversion for the result
+func (c *InternalAPIClient) CreateConversion(ctx context.Context, accessToken string, req ConversionRequest) (*ConversionResponse, error) {
+	var result ConversionResponse
+	if err := c.invoke(ctx, internalCreateConversion, accessToken, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetConversion gets conversion details
+func (c *InternalAPIClient) GetConversion(ctx context.Context, accessToken, conversionID string) (*ConversionResponse, error) {
+	req := struct {
+		ConversionID string `json:"conversionId"`
+	}{conversionID}
+
+	var result ConversionResponse
+	if err := c.invoke(ctx, internalGetConversion, accessToken, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListConversions lists user conversions
+func (c *InternalAPIClient) ListConversions(ctx context.Context, accessToken string, page, pageSize int, statusFilter string) (*ConversionsListResponse, error) {
+	req := struct {
+		Page     int    `json:"page"`
+		PageSize int    `json:"pageSize"`
+		Status   string `json:"status,omitempty"`
+	}{page, pageSize, statusFilter}
+
+	var result ConversionsListResponse
+	if err := c.invoke(ctx, internalListConversions, accessToken, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetImageURL gets the URL of an image, preferring the original
+func (c *InternalAPIClient) GetImageURL(ctx context.Context, accessToken, imageID string) (string, error) {
+	req := struct {
+		ImageID string `json:"imageId"`
+	}{imageID}
+
+	var result internalImage
+	if err := c.invoke(ctx, internalGetImage, accessToken, req, &result); err != nil {
+		return "", err
+	}
+	if result.OriginalURL != "" {
+		return result.OriginalURL, nil
+	}
+	if result.ThumbnailURL != nil && *result.ThumbnailURL != "" {
+		return *result.ThumbnailURL, nil
+	}
+	return "", fmt.Errorf("no URL found in image response")
+}
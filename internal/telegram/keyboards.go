@@ -3,42 +3,49 @@ package telegram
 import (
 	"fmt"
 
+	"ai-styler/internal/locale"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // InlineKeyboardMarkup builders for Telegram bot
 
 // MainMenuKeyboard returns the main menu inline keyboard
-func MainMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+func MainMenuKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		// First row: Main actions
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✨ "+BtnStartConversion, "start_conversion"),
+			tgbotapi.NewInlineKeyboardButtonData("✨ "+messages.Text(lang, BtnStartConversion), "start_conversion"),
 		),
 		// Second row: History and Profile
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 "+BtnMyConversions, "my_conversions"),
-			tgbotapi.NewInlineKeyboardButtonData("👤 پروفایل", "profile"),
+			tgbotapi.NewInlineKeyboardButtonData("📋 "+messages.Text(lang, BtnMyConversions), "my_conversions"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnProfile), "profile"),
 		),
 		// Third row: Gallery and Statistics
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🖼️ گالری", "gallery"),
-			tgbotapi.NewInlineKeyboardButtonData("📊 آمار", "statistics"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnGallery), "gallery"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnStatistics), "statistics"),
 		),
 		// Fourth row: Help and Settings
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️ "+BtnHelp, "help"),
-			tgbotapi.NewInlineKeyboardButtonData("⚙️ "+BtnSettings, "settings"),
+			tgbotapi.NewInlineKeyboardButtonData("ℹ️ "+messages.Text(lang, BtnHelp), "help"),
+			tgbotapi.NewInlineKeyboardButtonData("⚙️ "+messages.Text(lang, BtnSettings), "settings"),
+		),
+		// Fifth row: Plan purchase
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💳 "+messages.Text(lang, BtnBuyPlan), "buy_plan"),
 		),
-		// Fifth row: About
+		// Sixth row: Support and About
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️ درباره ما", "about"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnSupport), "support"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnAbout), "about"),
 		),
 	)
 }
 
 // StyleSelectionKeyboard returns keyboard for style selection
-func StyleSelectionKeyboard() tgbotapi.InlineKeyboardMarkup {
+func StyleSelectionKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	// Predefined styles - can be fetched from backend in the future
 	styles := []string{
 		"vintage",
@@ -53,7 +60,7 @@ func StyleSelectionKeyboard() tgbotapi.InlineKeyboardMarkup {
 
 	for i, style := range styles {
 		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
-			getStyleDisplayName(style),
+			getStyleDisplayName(lang, style),
 			"style_"+style,
 		))
 
@@ -66,45 +73,101 @@ func StyleSelectionKeyboard() tgbotapi.InlineKeyboardMarkup {
 
 	// Add cancel button
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData(BtnCancel, "cancel"),
+		tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnCancel), "cancel"),
 	})
 
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // ConversionConfirmationKeyboard returns keyboard for conversion confirmation
-func ConversionConfirmationKeyboard() tgbotapi.InlineKeyboardMarkup {
+func ConversionConfirmationKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnConfirm, "confirm_conversion"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnConfirm), "confirm_conversion"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnChangeStyle, "change_style"),
-			tgbotapi.NewInlineKeyboardButtonData(BtnCancel, "cancel"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnChangeStyle), "change_style"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnCancel), "cancel"),
+		),
+	)
+}
+
+// OutfitKeyboard is shown while garments are collected for an outfit
+func OutfitKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnTryOnOutfit), "outfit_try_on"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnCancel), "cancel"),
 		),
 	)
 }
 
 // ConversionResultKeyboard returns keyboard shown after conversion completion
-func ConversionResultKeyboard(conversionID string) tgbotapi.InlineKeyboardMarkup {
+func ConversionResultKeyboard(lang, conversionID string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnShare), "share_"+conversionID),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnFeedback), "feedback_"+conversionID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnBackToMenu), "main_menu"),
+		),
+	)
+}
+
+// SharedLinkKeyboard returns keyboard shown with a freshly created share link
+func SharedLinkKeyboard(lang, shareURL, shareID string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnFeedback, "feedback_"+conversionID),
+			tgbotapi.NewInlineKeyboardButtonURL(messages.Text(lang, BtnOpenShareLink), shareURL),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnRevokeShare), "revoke_share_"+shareID),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnBackToMenu, "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
+		),
+	)
+}
+
+// PlansKeyboard returns keyboard listing purchasable plans
+func PlansKeyboard(lang string, plans []PaymentPlan) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(plans)+1)
+	for _, plan := range plans {
+		label := fmt.Sprintf("%s - %s", plan.DisplayName, formatPlanPrice(lang, plan.PricePerMonthCents))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, "select_plan_"+plan.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// PaymentLinkKeyboard returns keyboard shown with a freshly created payment link
+func PaymentLinkKeyboard(lang, gatewayURL, paymentID string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(messages.Text(lang, BtnPay), gatewayURL),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnCheckPayment), "check_payment_"+paymentID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
 		),
 	)
 }
 
 // ConversionsListKeyboard returns keyboard for paginated conversions list
-func ConversionsListKeyboard(page, totalPages int, conversionID string) tgbotapi.InlineKeyboardMarkup {
+func ConversionsListKeyboard(lang string, page, totalPages int, conversionID string) tgbotapi.InlineKeyboardMarkup {
 	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
 
 	// View result button
 	if conversionID != "" {
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(BtnViewResult, "view_conversion_"+conversionID),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnViewResult), "view_conversion_"+conversionID),
 		})
 	}
 
@@ -114,13 +177,13 @@ func ConversionsListKeyboard(page, totalPages int, conversionID string) tgbotapi
 
 		if page > 1 {
 			paginationRow = append(paginationRow,
-				tgbotapi.NewInlineKeyboardButtonData(BtnPrevious, fmt.Sprintf("conversions_page_%d", page-1)),
+				tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnPrevious), fmt.Sprintf("conversions_page_%d", page-1)),
 			)
 		}
 
 		if page < totalPages {
 			paginationRow = append(paginationRow,
-				tgbotapi.NewInlineKeyboardButtonData(BtnNext, fmt.Sprintf("conversions_page_%d", page+1)),
+				tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnNext), fmt.Sprintf("conversions_page_%d", page+1)),
 			)
 		}
 
@@ -131,77 +194,92 @@ func ConversionsListKeyboard(page, totalPages int, conversionID string) tgbotapi
 
 	// Back to menu button
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData(BtnBackToMenu, "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnBackToMenu), "main_menu"),
 	})
 
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // CancelKeyboard returns a simple cancel button
-func CancelKeyboard() tgbotapi.InlineKeyboardMarkup {
+func CancelKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnCancel, "cancel"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnCancel), "cancel"),
+		),
+	)
+}
+
+// SupportKeyboard returns keyboard for the support menu
+func SupportKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnNewTicket), "support_new"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnMyTickets), "support_tickets"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
 		),
 	)
 }
 
 // BackToMenuKeyboard returns a back to menu button
-func BackToMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+func BackToMenuKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏠 "+BtnBackToMenu, "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
 		),
 	)
 }
 
 // SettingsKeyboard returns keyboard for settings menu
-func SettingsKeyboard() tgbotapi.InlineKeyboardMarkup {
+func SettingsKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📱 اطلاعات تماس", "settings_contact"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnSettingsContact), "settings_contact"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔔 تنظیمات اعلان", "settings_notifications"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnSettingsNotifications), "settings_notifications"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🌐 زبان", "settings_language"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnSettingsLanguage), "settings_language"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔒 تغییر رمز عبور", "settings_password"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnSettingsPassword), "settings_password"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏠 "+BtnBackToMenu, "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
 		),
 	)
 }
 
 // ProfileKeyboard returns keyboard for profile menu
-func ProfileKeyboard() tgbotapi.InlineKeyboardMarkup {
+func ProfileKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 آمار و اطلاعات", "profile_stats"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnProfileStats), "profile_stats"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("💳 پلن و کووتا", "profile_quota"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnProfileQuota), "profile_quota"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📝 ویرایش پروفایل", "profile_edit"),
+			tgbotapi.NewInlineKeyboardButtonData(messages.Text(lang, BtnProfileEdit), "profile_edit"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏠 "+BtnBackToMenu, "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🏠 "+messages.Text(lang, BtnBackToMenu), "main_menu"),
 		),
 	)
 }
 
 // ShareContactKeyboard returns keyboard with share contact button
-func ShareContactKeyboard() tgbotapi.ReplyKeyboardMarkup {
+func ShareContactKeyboard(lang string) tgbotapi.ReplyKeyboardMarkup {
 	return tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButtonContact("📱 Share Contact"),
+			tgbotapi.NewKeyboardButtonContact(messages.Text(lang, BtnShareContact)),
 		),
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("❌ Cancel"),
+			tgbotapi.NewKeyboardButton(messages.Text(lang, BtnCancelContact)),
 		),
 	)
 }
@@ -211,19 +289,10 @@ func RemoveKeyboard() tgbotapi.ReplyKeyboardRemove {
 	return tgbotapi.NewRemoveKeyboard(true)
 }
 
-// getStyleDisplayName returns Persian display name for style
-func getStyleDisplayName(style string) string {
-	styleNames := map[string]string{
-		"vintage":    "کلاسیک",
-		"casual":     "راحت",
-		"formal":     "رسمی",
-		"streetwear": "خیابانی",
-		"elegant":    "زیبا",
-	}
-
-	if name, ok := styleNames[style]; ok {
-		return name
+// getStyleDisplayName returns the display name of a style in lang
+func getStyleDisplayName(lang, style string) string {
+	if messages.Has(locale.DefaultLanguage, styleKeyPrefix+style) {
+		return messages.Text(lang, styleKeyPrefix+style)
 	}
 	return style
 }
-
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"context"
+
+	"ai-styler/internal/locale"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rememberLanguage records the Telegram app language of the user writing in a
+// chat, so replies to that chat use it
+func (h *Handlers) rememberLanguage(chatID int64, from *tgbotapi.User) {
+	if from == nil || from.LanguageCode == "" {
+		return
+	}
+	h.languages.Store(chatID, locale.ParseAcceptLanguage(from.LanguageCode))
+}
+
+// language returns the bot language of a chat. It is the app language of the
+// user's latest update, or the one stored with their session for messages
+// sent without an update (payment results after a restart), or DefaultLanguage.
+// The bot only works in private chats, so the chat ID is the user ID.
+func (h *Handlers) language(chatID int64) string {
+	if lang, ok := h.languages.Load(chatID); ok {
+		return lang.(string)
+	}
+
+	session, err := h.sessionMgr.GetStorage().GetSessionByTelegramID(context.Background(), chatID)
+	if err != nil || session == nil || session.LanguageCode == nil {
+		return locale.DefaultLanguage
+	}
+	lang := locale.ParseAcceptLanguage(*session.LanguageCode)
+	h.languages.Store(chatID, lang)
+	return lang
+}
+
+// text returns a bot message in the language of a chat
+func (h *Handlers) text(chatID int64, key string, args ...interface{}) string {
+	return messages.Text(h.language(chatID), key, args...)
+}
+
+// formatter returns the date and number formatter for the language of a chat
+func (h *Handlers) formatter(chatID int64) locale.Formatter {
+	return locale.ForLanguage(h.language(chatID))
+}
+
+// isCancelText reports whether text is the reply keyboard cancel button in
+// any language, since the keyboard may predate a language change
+func isCancelText(text string) bool {
+	return text == messages.Text(locale.LangPersian, BtnCancelContact) ||
+		text == messages.Text(locale.LangEnglish, BtnCancelContact)
+}
@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// linkPayloadPrefix marks /start payloads that carry an account link code
+const linkPayloadPrefix = "link_"
+
+// stateWaitingLinkCode is the user state while the bot waits for a typed link code
+const stateWaitingLinkCode = "waiting_link_code"
+
+// handleLinkCommand links the account with the code given as /link CODE,
+// or asks for the code when it was omitted
+func (h *Handlers) handleLinkCommand(msg *tgbotapi.Message) {
+	ctx := context.Background()
+	code := strings.TrimSpace(msg.CommandArguments())
+	if code == "" {
+		h.sessionMgr.SetState(ctx, msg.From.ID, stateWaitingLinkCode, "")
+		h.sendMessage(msg.Chat.ID, h.text(msg.Chat.ID, MsgEnterLinkCode))
+		return
+	}
+	h.linkAccount(ctx, msg.From, msg.Chat.ID, code)
+}
+
+// handleLinkCodeInput handles a link code typed while in the waiting_link_code state
+func (h *Handlers) handleLinkCodeInput(msg *tgbotapi.Message) {
+	ctx := context.Background()
+	if isCancelText(msg.Text) {
+		h.sessionMgr.ClearState(ctx, msg.From.ID)
+		h.sendMessage(msg.Chat.ID, h.text(msg.Chat.ID, MsgOperationCancelled))
+		return
+	}
+	h.linkAccount(ctx, msg.From, msg.Chat.ID, strings.TrimSpace(msg.Text))
+}
+
+// linkAccount redeems a link code issued by the backend and signs the user in
+func (h *Handlers) linkAccount(ctx context.Context, from *tgbotapi.User, chatID int64, code string) {
+	loginResp, err := h.apiClient.LinkTelegramAccount(ctx, code, from.ID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLinkCode) {
+			h.sendMessage(chatID, h.text(chatID, MsgLinkCodeInvalid))
+			return
+		}
+		log.Printf("Failed to link Telegram account %d: %v", from.ID, err)
+		h.sendMessage(chatID, h.text(chatID, MsgLinkFailed))
+		return
+	}
+
+	h.sessionMgr.ClearState(ctx, from.ID)
+	h.storeLogin(ctx, from, "", loginResp.User.ID, loginResp.AccessToken, loginResp.RefreshToken, loginResp.AccessExpiresIn)
+
+	msg := tgbotapi.NewMessage(chatID, h.text(chatID, MsgLinkSuccess))
+	msg.ReplyMarkup = RemoveKeyboard()
+	h.bot.Send(msg)
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgMainMenu), MainMenuKeyboard(h.language(chatID)))
+}
+
+// signInLinkedAccount signs in the backend user linked to the Telegram
+// account. It reports false when the account is not linked or sign-in failed.
+func (h *Handlers) signInLinkedAccount(ctx context.Context, from *tgbotapi.User) bool {
+	loginResp, err := h.apiClient.TelegramLogin(ctx, from.ID)
+	if err != nil {
+		if !errors.Is(err, ErrTelegramNotLinked) {
+			log.Printf("Telegram sign-in failed for user %d: %v", from.ID, err)
+		}
+		return false
+	}
+
+	h.storeLogin(ctx, from, "", loginResp.User.ID, loginResp.AccessToken, loginResp.RefreshToken, loginResp.AccessExpiresIn)
+	return true
+}
+
+// linkNewAccount links a freshly registered account to the Telegram user so
+// later sessions sign in without a password
+func (h *Handlers) linkNewAccount(ctx context.Context, telegramUserID int64, accessToken string) error {
+	linkCode, err := h.apiClient.CreateTelegramLinkCode(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	_, err = h.apiClient.LinkTelegramAccount(ctx, linkCode.Code, telegramUserID)
+	return err
+}
+
+// storeLogin saves backend tokens in the user's session and token storage
+func (h *Handlers) storeLogin(ctx context.Context, from *tgbotapi.User, phone, backendUserID, accessToken, refreshToken string, expiresIn int) {
+	session, _ := h.sessionMgr.GetSession(ctx, from.ID)
+	if session == nil {
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(expiresIn) * time.Second)
+	firstName := from.FirstName
+	session.BackendUserID = &backendUserID
+	if phone != "" {
+		session.Phone = &phone
+	}
+	session.AccessToken = &accessToken
+	session.RefreshToken = &refreshToken
+	session.TokenExpiresAt = &expiresAt
+	session.FirstName = &firstName
+	if from.LastName != "" {
+		lastName := from.LastName
+		session.LastName = &lastName
+	}
+	if from.UserName != "" {
+		username := from.UserName
+		session.Username = &username
+	}
+	if from.LanguageCode != "" {
+		langCode := from.LanguageCode
+		session.LanguageCode = &langCode
+	}
+	h.sessionMgr.UpdateSession(ctx, session)
+
+	// Store tokens in Redis
+	if expiresIn > 0 {
+		ttl := time.Duration(expiresIn) * time.Second
+		_ = h.sessionMgr.GetStorage().StoreToken(ctx, from.ID, accessToken, refreshToken, ttl)
+	}
+}
+
+// generateAccountPassword returns a random password for accounts registered
+// through the bot. Bot users sign in through their Telegram link instead.
+func generateAccountPassword() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -1,12 +1,223 @@
 package telegram
 
-import "fmt"
+import "ai-styler/internal/locale"
 
-// Persian message templates for the Telegram bot
+// Message keys of the Telegram bot. The texts live in per-language tables
+// (persianMessages, englishMessages) and are looked up through messages.
+const (
+	// Welcome messages
+	MsgWelcome     = "welcome"
+	MsgWelcomeBack = "welcome_back"
+
+	// Authentication messages
+	MsgPleaseLogin               = "please_login"
+	MsgShareContact              = "share_contact"
+	MsgContactReceived           = "contact_received"
+	MsgContactVerificationFailed = "contact_verification_failed"
+	MsgContactNotShared          = "contact_not_shared"
+	MsgLoginSuccess              = "login_success"
+	MsgRegistrationSuccess       = "registration_success"
+
+	// Account link messages
+	MsgLinkRequired             = "link_required"
+	MsgEnterLinkCode            = "enter_link_code"
+	MsgLinkSuccess              = "link_success"
+	MsgLinkCodeInvalid          = "link_code_invalid"
+	MsgLinkFailed               = "link_failed"
+	MsgRegistrationPasswordHint = "registration_password_hint"
+
+	// Image upload messages
+	MsgImageReceived      = "image_received"
+	MsgImageTooLarge      = "image_too_large"
+	MsgInvalidImageFormat = "invalid_image_format"
+	MsgImageUploaded      = "image_uploaded"
+	MsgGarmentAdded       = "garment_added"
+
+	// Conversion messages
+	MsgSelectStyle          = "select_style"
+	MsgConversionStarted    = "conversion_started"
+	MsgConversionProcessing = "conversion_processing"
+	MsgConversionCompleted  = "conversion_completed"
+	MsgConversionFailed     = "conversion_failed"
+	MsgConversionNotFound   = "conversion_not_found"
+
+	// Share messages
+	MsgShareCreated      = "share_created"
+	MsgShareFailed       = "share_failed"
+	MsgShareRevoked      = "share_revoked"
+	MsgShareRevokeFailed = "share_revoke_failed"
+	MsgSharedResult      = "shared_result"
+	MsgSharedLinkInvalid = "shared_link_invalid"
+
+	// Plan purchase messages
+	MsgPlansList           = "plans_list"
+	MsgPlanItem            = "plan_item"
+	MsgNoPlans             = "no_plans"
+	MsgPlansFailed         = "plans_failed"
+	MsgPaymentCreated      = "payment_created"
+	MsgPaymentCreateFailed = "payment_create_failed"
+	MsgPlanAlreadyActive   = "plan_already_active"
+	MsgPaymentActivated    = "payment_activated"
+	MsgPaymentPending      = "payment_pending"
+	MsgPaymentUnsuccessful = "payment_unsuccessful"
+	MsgPaymentStatusFailed = "payment_status_failed"
+
+	// Support messages
+	MsgSupportMenu       = "support_menu"
+	MsgSupportPrompt     = "support_prompt"
+	MsgSupportCreated    = "support_created"
+	MsgSupportFailed     = "support_failed"
+	MsgSupportLimit      = "support_limit"
+	MsgSupportTickets    = "support_tickets"
+	MsgSupportTicketItem = "support_ticket_item"
+	MsgSupportNoTickets  = "support_no_tickets"
+
+	// My Conversions messages
+	MsgMyConversions = "my_conversions"
+	MsgNoConversions = "no_conversions"
+
+	// Error messages
+	MsgErrorGeneric       = "error_generic"
+	MsgErrorRateLimit     = "error_rate_limit"
+	MsgErrorQuotaExceeded = "error_quota_exceeded"
+	MsgErrorUnauthorized  = "error_unauthorized"
+
+	// Help messages
+	MsgHelp = "help"
+
+	// Settings messages
+	MsgSettings = "settings"
 
+	// Button labels
+	BtnStartConversion = "btn_start_conversion"
+	BtnMyConversions   = "btn_my_conversions"
+	BtnHelp            = "btn_help"
+	BtnSettings        = "btn_settings"
+	BtnBackToMenu      = "btn_back_to_menu"
+	BtnCancel          = "btn_cancel"
+	BtnConfirm         = "btn_confirm"
+	BtnChangeStyle     = "btn_change_style"
+	BtnFeedback        = "btn_feedback"
+	BtnNext            = "btn_next"
+	BtnPrevious        = "btn_previous"
+	BtnViewResult      = "btn_view_result"
+	BtnDelete          = "btn_delete"
+	BtnShareContact    = "btn_share_contact"
+	BtnShare           = "btn_share"
+	BtnOpenShareLink   = "btn_open_share_link"
+	BtnRevokeShare     = "btn_revoke_share"
+	BtnBuyPlan         = "btn_buy_plan"
+	BtnPay             = "btn_pay"
+	BtnCheckPayment    = "btn_check_payment"
+	BtnTryOnOutfit     = "btn_try_on_outfit"
+	BtnSupport         = "btn_support"
+	BtnNewTicket       = "btn_new_ticket"
+	BtnMyTickets       = "btn_my_tickets"
+
+	// Additional messages
+	MsgAbout                 = "about"
+	MsgProfile               = "profile"
+	MsgStatistics            = "statistics"
+	MsgGallery               = "gallery"
+	MsgProfileStats          = "profile_stats"
+	MsgProfileQuota          = "profile_quota"
+	MsgSettingsContact       = "settings_contact"
+	MsgSettingsNotifications = "settings_notifications"
+	MsgSettingsLanguage      = "settings_language"
+	MsgSettingsPassword      = "settings_password"
+
+	// Menu and navigation messages
+	MsgMainMenu           = "main_menu"
+	MsgUseMenu            = "use_menu"
+	MsgInvalidCommand     = "invalid_command"
+	MsgInvalidAction      = "invalid_action"
+	MsgOperationCancelled = "operation_cancelled"
+	MsgCancelled          = "cancelled"
+	MsgRateLimitShort     = "rate_limit_short"
+
+	// Contact and registration messages
+	MsgShareContactPrompt     = "share_contact_prompt"
+	MsgContactNotOwn          = "contact_not_own"
+	MsgInvalidPhone           = "invalid_phone"
+	MsgPhoneAlreadyRegistered = "phone_already_registered"
+	MsgRegistrationFailed     = "registration_failed"
+
+	// Conversion flow messages
+	MsgSendYourPhoto           = "send_your_photo"
+	MsgSendAsPhoto             = "send_as_photo"
+	MsgStateSaveFailed         = "state_save_failed"
+	MsgStateLoadFailed         = "state_load_failed"
+	MsgDataLoadFailed          = "data_load_failed"
+	MsgGarmentProcessingFailed = "garment_processing_failed"
+	MsgConversionCreateFailed  = "conversion_create_failed"
+	MsgConversionCreated       = "conversion_created"
+	MsgConversionResultCaption = "conversion_result_caption"
+	MsgStyleSelected           = "selected_style"
+	MsgConfirmConversion       = "confirm_conversion"
+	MsgConversionTimeout       = "conversion_timeout"
+	MsgUnknownError            = "unknown_error"
+	MsgConversionListItem      = "conversion_list_item"
+	MsgConversionDetails       = "conversion_details"
+	MsgConversionTraceID       = "conversion_trace_id"
+
+	// Profile, statistics and quota lines
+	MsgProfileName         = "profile_name"
+	MsgProfilePhone        = "profile_phone"
+	MsgProfileUsername     = "profile_username"
+	MsgProfileEdit         = "profile_edit"
+	MsgStatsFailed         = "stats_failed"
+	MsgStatsTotal          = "stats_total"
+	MsgStatsSuccessful     = "stats_successful"
+	MsgStatsFailedCount    = "stats_failed_count"
+	MsgStatsSuccessRate    = "stats_success_rate"
+	MsgStatsAverageTime    = "stats_average_time"
+	MsgQuotaFailed         = "quota_failed"
+	MsgQuotaPlan           = "quota_plan"
+	MsgQuotaUsed           = "quota_used"
+	MsgQuotaRemaining      = "quota_remaining"
+	MsgQuotaPercentage     = "quota_percentage"
+	MsgQuotaExhausted      = "quota_exhausted"
+	MsgGalleryComingSoon   = "gallery_coming_soon"
+	MsgSettingsContactHint = "settings_contact_hint"
+	MsgPlanPrice           = "plan_price"
+
+	// Additional button labels
+	BtnProfile               = "btn_profile"
+	BtnGallery               = "btn_gallery"
+	BtnStatistics            = "btn_statistics"
+	BtnAbout                 = "btn_about"
+	BtnSettingsContact       = "btn_settings_contact"
+	BtnSettingsNotifications = "btn_settings_notifications"
+	BtnSettingsLanguage      = "btn_settings_language"
+	BtnSettingsPassword      = "btn_settings_password"
+	BtnProfileStats          = "btn_profile_stats"
+	BtnProfileQuota          = "btn_profile_quota"
+	BtnProfileEdit           = "btn_profile_edit"
+	BtnCancelContact         = "btn_cancel_contact"
+)
+
+// Keys of the conversion statuses and styles shown to users
 const (
+	statusKeyPrefix = "status_"
+	styleKeyPrefix  = "style_"
+
+	// supportStatusKeyPrefix keys the support ticket statuses
+	supportStatusKeyPrefix = "support_status_"
+
+	// errorCodeKeyPrefix keys the advice for conversions failed by their inputs
+	errorCodeKeyPrefix = "error_code_"
+)
+
+// messages is the catalog of bot texts in every supported language
+var messages = locale.NewCatalog(map[string]map[string]string{
+	locale.LangPersian: persianMessages,
+	locale.LangEnglish: englishMessages,
+})
+
+// persianMessages are the Persian message templates
+var persianMessages = map[string]string{
 	// Welcome messages
-	MsgWelcome = `👋 سلام و خوش آمدید!
+	MsgWelcome: `👋 سلام و خوش آمدید!
 
 🎨 به ربات AI Styler خوش آمدید!
 با استفاده از این ربات می‌تونید عکس‌هاتون رو با سبک‌های مختلف و زیبا استایل بدید.
@@ -18,86 +229,154 @@ const (
 • آمار و گزارش‌گیری
 • و خیلی چیزای دیگه!
 
-برای شروع روی «✨ شروع تبدیل تصویر» بزنید.`
-
-	MsgWelcomeBack = `👋 خوش برگشتید!
+برای شروع روی «✨ شروع تبدیل تصویر» بزنید.`,
+	MsgWelcomeBack: `👋 خوش برگشتید!
 		
 چه کاری می‌خواید انجام بدید؟
-از منوی زیر گزینه مورد نظر رو انتخاب کنید.`
+از منوی زیر گزینه مورد نظر رو انتخاب کنید.`,
 
 	// Authentication messages
-	MsgPleaseLogin = `برای استفاده از این ربات، لطفاً وارد حساب کاربری خودتون بشید.`
-
-	MsgShareContact = `برای احراز هویت، لطفاً کانتکت تلگرام خودتون رو share کنید.
-این کار برای ثبت‌نام، ورود و تغییر رمز عبور لازم است.`
-
-	MsgContactReceived = `✅ کانتکت دریافت شد!
-در حال احراز هویت...`
-
-	MsgContactVerificationFailed = `❌ احراز هویت با خطا مواجه شد.
-لطفاً مطمئن شوید که کانتکت خودتون رو share کرده‌اید.`
-
-	MsgContactNotShared = `⚠️ لطفاً کانتکت خودتون رو از طریق دکمه share کنید.`
-
-	MsgLoginSuccess = `✅ ورود با موفقیت انجام شد!
-حالا می‌تونید از تمام امکانات ربات استفاده کنید.`
-
-	MsgRegistrationSuccess = `✅ ثبت‌نام با موفقیت انجام شد!
-حساب کاربری شما ایجاد شد و می‌تونید از ربات استفاده کنید.`
+	MsgPleaseLogin: `برای استفاده از این ربات، لطفاً وارد حساب کاربری خودتون بشید.`,
+	MsgShareContact: `برای احراز هویت، لطفاً کانتکت تلگرام خودتون رو share کنید.
+این کار برای ثبت‌نام، ورود و تغییر رمز عبور لازم است.`,
+	MsgContactReceived: `✅ کانتکت دریافت شد!
+در حال احراز هویت...`,
+	MsgContactVerificationFailed: `❌ احراز هویت با خطا مواجه شد.
+لطفاً مطمئن شوید که کانتکت خودتون رو share کرده‌اید.`,
+	MsgContactNotShared: `⚠️ لطفاً کانتکت خودتون رو از طریق دکمه share کنید.`,
+	MsgLoginSuccess: `✅ ورود با موفقیت انجام شد!
+حالا می‌تونید از تمام امکانات ربات استفاده کنید.`,
+	MsgRegistrationSuccess: `✅ ثبت‌نام با موفقیت انجام شد!
+حساب کاربری شما ایجاد شد و می‌تونید از ربات استفاده کنید.`,
+
+	// Account link messages
+	MsgLinkRequired: `🔗 برای این شماره قبلاً حساب کاربری ساخته شده است.
+
+برای اتصال حساب به ربات:
+1️⃣ در وب‌سایت یا اپلیکیشن وارد حساب خودتون بشید
+2️⃣ از بخش تنظیمات، «اتصال به تلگرام» رو بزنید
+3️⃣ کد نمایش داده شده رو اینجا ارسال کنید (یا از دستور /link CODE استفاده کنید)`,
+	MsgEnterLinkCode: `🔑 لطفاً کد اتصال حساب رو ارسال کنید:`,
+	MsgLinkSuccess: `✅ حساب تلگرام شما با موفقیت به حساب کاربری متصل شد!
+از این به بعد بدون رمز عبور وارد ربات می‌شید.`,
+	MsgLinkCodeInvalid: `❌ کد اتصال نامعتبر است یا منقضی شده.
+لطفاً یک کد جدید از وب‌سایت یا اپلیکیشن دریافت کنید.`,
+	MsgLinkFailed: `❌ اتصال حساب با خطا مواجه شد.
+لطفاً کمی بعد دوباره تلاش کنید.`,
+	MsgRegistrationPasswordHint: `💡 برای ورود از طریق وب‌سایت یا اپلیکیشن، از گزینه «فراموشی رمز عبور» با همین شماره تلفن استفاده کنید.`,
 
 	// Image upload messages
-	MsgImageReceived = `عکس دریافت شد ✅
-لطفاً عکس لباس یا گارمنت مورد نظر رو ارسال کنید.`
-
-	MsgImageTooLarge = `❌ حجم عکس خیلی بزرگه!
+	MsgImageReceived: `عکس دریافت شد ✅
+لطفاً عکس لباس یا گارمنت مورد نظر رو ارسال کنید.`,
+	MsgImageTooLarge: `❌ حجم عکس خیلی بزرگه!
 حداکثر حجم مجاز: %s
-لطفاً عکس کوچکتری ارسال کنید.`
-
-	MsgInvalidImageFormat = `❌ فرمت عکس پشتیبانی نمی‌شه!
-فرمت‌های مجاز: JPEG, PNG, WebP`
-
-	MsgImageUploaded = `✅ عکس با موفقیت آپلود شد!
-شناسه عکس: %s`
+لطفاً عکس کوچکتری ارسال کنید.`,
+	MsgInvalidImageFormat: `❌ فرمت عکس پشتیبانی نمی‌شه!
+فرمت‌های مجاز: JPEG, PNG, WebP`,
+	MsgImageUploaded: `✅ عکس با موفقیت آپلود شد!
+شناسه عکس: %s`,
+	MsgGarmentAdded: `👕 لباس %d از %d به ست اضافه شد.
+برای ساختن یک ست کامل (مثلاً بالاتنه، پایین‌تنه و اکسسوری) عکس لباس بعدی رو بفرستید، یا همین حالا پرو کنید.`,
 
 	// Conversion messages
-	MsgSelectStyle = `لطفاً یکی از استایل‌های موجود رو انتخاب کنید:`
-
-	MsgConversionStarted = `✅ درخواست تبدیل ثبت شد!
+	MsgSelectStyle: `لطفاً یکی از استایل‌های موجود رو انتخاب کنید:`,
+	MsgConversionStarted: `✅ درخواست تبدیل ثبت شد!
 شناسه تبدیل: %s
-در حال پردازش...`
-
-	MsgConversionProcessing = `درحال پردازش — %d%%
-لطفاً صبر کنید...`
-
-	MsgConversionCompleted = `✅ تبدیل با موفقیت انجام شد!
-نتیجه در زیر آمده است:`
-
-	MsgConversionFailed = `❌ تبدیل با خطا مواجه شد.
+در حال پردازش...`,
+	MsgConversionProcessing: `درحال پردازش — %s
+لطفاً صبر کنید...`,
+	MsgConversionCompleted: `✅ تبدیل با موفقیت انجام شد!
+نتیجه در زیر آمده است:`,
+	MsgConversionFailed: `❌ تبدیل با خطا مواجه شد.
 خطا: %s
-لطفاً دوباره تلاش کنید.`
-
-	MsgConversionNotFound = `❌ تبدیل مورد نظر پیدا نشد.`
+لطفاً دوباره تلاش کنید.`,
+	MsgConversionNotFound: `❌ تبدیل مورد نظر پیدا نشد.`,
+
+	// Share messages
+	MsgShareCreated: `🔗 لینک اشتراک‌گذاری ساخته شد!
+
+لینک مستقیم:
+%s
+
+لینک ربات:
+%s
+
+⏳ این لینک تا %s معتبر است.`,
+	MsgShareFailed: `❌ ساخت لینک اشتراک‌گذاری با خطا مواجه شد.
+لطفاً دوباره تلاش کنید.`,
+	MsgShareRevoked:      `✅ لینک اشتراک‌گذاری غیرفعال شد.`,
+	MsgShareRevokeFailed: `❌ غیرفعال کردن لینک با خطا مواجه شد.`,
+	MsgSharedResult:      `🎨 یک نتیجه از AI Styler با شما به اشتراک گذاشته شد!`,
+	MsgSharedLinkInvalid: `⚠️ این لینک اشتراک‌گذاری منقضی شده یا معتبر نیست.`,
+
+	// Plan purchase messages
+	MsgPlansList: `💳 خرید اشتراک
+
+یکی از پلن‌های زیر رو انتخاب کنید:
+
+%s`,
+	MsgPlanItem: `• %s - %s
+  %s تبدیل در ماه`,
+	MsgNoPlans: `⚠️ در حال حاضر پلنی برای خرید وجود ندارد.`,
+	MsgPlansFailed: `❌ دریافت لیست پلن‌ها با خطا مواجه شد.
+لطفاً دوباره تلاش کنید.`,
+	MsgPaymentCreated: `🧾 پلن %s - %s
+
+برای پرداخت روی دکمه زیر بزنید.
+بعد از تکمیل پرداخت، فعال شدن اشتراک همین‌جا به شما اطلاع داده می‌شود.
+
+⏳ این لینک تا %s معتبر است.`,
+	MsgPaymentCreateFailed: `❌ ساخت لینک پرداخت با خطا مواجه شد.
+لطفاً دوباره تلاش کنید.`,
+	MsgPlanAlreadyActive: `ℹ️ شما در حال حاضر یک اشتراک فعال دارید.`,
+	MsgPaymentActivated: `🎉 پرداخت شما با موفقیت انجام شد!
+
+✅ اشتراک %s برای شما فعال شد.`,
+	MsgPaymentPending: `⏳ پرداخت شما هنوز تکمیل نشده است.
+بعد از پرداخت، نتیجه همین‌جا اطلاع داده می‌شود.`,
+	MsgPaymentUnsuccessful: `❌ پرداخت %s انجام نشد.
+در صورت کسر وجه، مبلغ طی ۷۲ ساعت به حساب شما بازمی‌گردد.`,
+	MsgPaymentStatusFailed: `❌ بررسی وضعیت پرداخت با خطا مواجه شد.
+لطفاً کمی بعد دوباره تلاش کنید.`,
+
+	// Support messages
+	MsgSupportMenu: `🆘 پشتیبانی
+
+سؤال یا مشکلی دارید؟ یک تیکت ثبت کنید تا تیم پشتیبانی بررسی کند.
+پاسخ پشتیبانی همین‌جا به شما اطلاع داده می‌شود.`,
+	MsgSupportPrompt: `✍️ پیام خود را بنویسید.
+اگر لازم است، می‌توانید یک عکس همراه با توضیح (کپشن) بفرستید.`,
+	MsgSupportCreated: `✅ تیکت شما ثبت شد.
+
+موضوع: %s
+پاسخ پشتیبانی همین‌جا به شما اطلاع داده می‌شود.`,
+	MsgSupportFailed: `❌ ثبت تیکت با خطا مواجه شد.
+لطفاً دوباره تلاش کنید.`,
+	MsgSupportLimit: `⚠️ تعداد تیکت‌های باز شما به حداکثر رسیده است. لطفاً تا پاسخ پشتیبانی صبر کنید.`,
+	MsgSupportTickets: `📨 تیکت‌های شما:
+
+%s`,
+	MsgSupportTicketItem: `• %s
+  %s - %s`,
+	MsgSupportNoTickets: `شما هنوز تیکتی ثبت نکرده‌اید.`,
 
 	// My Conversions messages
-	MsgMyConversions = `تبدیل‌های شما:`
-	MsgNoConversions = `شما هنوز تبدیلی انجام نداده‌اید.`
+	MsgMyConversions: `تبدیل‌های شما:`,
+	MsgNoConversions: `شما هنوز تبدیلی انجام نداده‌اید.`,
 
 	// Error messages
-	MsgErrorGeneric = `متأسفانه مشکلی پیش اومد.
+	MsgErrorGeneric: `متأسفانه مشکلی پیش اومد.
 کد خطا: %s
-لطفاً بعداً دوباره امتحان کنید.`
-
-	MsgErrorRateLimit = `⚠️ تعداد درخواست‌های شما بیش از حد مجاز است.
-لطفاً کمی صبر کنید و دوباره تلاش کنید.`
-
-	MsgErrorQuotaExceeded = `❌ سهمیه تبدیل رایگان شما تمام شده است.
-برای ادامه استفاده، لطفاً پلن خودتون رو ارتقا بدید.`
-
-	MsgErrorUnauthorized = `❌ شما وارد حساب کاربری نشده‌اید.
-لطفاً ابتدا وارد شوید.`
+لطفاً بعداً دوباره امتحان کنید.`,
+	MsgErrorRateLimit: `⚠️ تعداد درخواست‌های شما بیش از حد مجاز است.
+لطفاً کمی صبر کنید و دوباره تلاش کنید.`,
+	MsgErrorQuotaExceeded: `❌ سهمیه تبدیل رایگان شما تمام شده است.
+برای ادامه استفاده، لطفاً پلن خودتون رو ارتقا بدید.`,
+	MsgErrorUnauthorized: `❌ شما وارد حساب کاربری نشده‌اید.
+لطفاً ابتدا وارد شوید.`,
 
 	// Help messages
-	MsgHelp = `📖 راهنمای کامل استفاده از ربات AI Styler
+	MsgHelp: `📖 راهنمای کامل استفاده از ربات AI Styler
 
 ━━━━━━━━━━━━━━━━━━━━
 ✨ شروع تبدیل تصویر
@@ -152,31 +431,48 @@ const (
 • تغییر زبان
 • تغییر رمز عبور
 
-💡 نکته: برای دریافت راهنمایی بیشتر می‌تونید از دستور /help استفاده کنید.`
+━━━━━━━━━━━━━━━━━━━━
+🔗 اتصال حساب
+━━━━━━━━━━━━━━━━━━━━
+
+• اگر قبلاً در وب‌سایت ثبت‌نام کرده‌اید، از تنظیمات حساب یک کد اتصال بگیرید
+• کد رو با دستور /link CODE برای ربات بفرستید
+
+💡 نکته: برای دریافت راهنمایی بیشتر می‌تونید از دستور /help استفاده کنید.`,
 
 	// Settings messages
-	MsgSettings = `⚙️ تنظیمات ربات
+	MsgSettings: `⚙️ تنظیمات ربات
 
-از گزینه‌های زیر تنظیمات مورد نظر خودتون رو انتخاب کنید:`
+از گزینه‌های زیر تنظیمات مورد نظر خودتون رو انتخاب کنید:`,
 
 	// Button labels
-	BtnStartConversion = "شروع تبدیل تصویر"
-	BtnMyConversions  = "تبدیل‌های من"
-	BtnHelp           = "راهنما"
-	BtnSettings       = "تنظیمات"
-	BtnBackToMenu     = "بازگشت به منو"
-	BtnCancel         = "لغو"
-	BtnConfirm        = "تأیید و ارسال"
-	BtnChangeStyle    = "تغییر استایل"
-	BtnFeedback       = "بازخورد"
-	BtnNext           = "بعدی"
-	BtnPrevious       = "قبلی"
-	BtnViewResult     = "مشاهده نتیجه"
-	BtnDelete         = "حذف"
-	BtnShareContact   = "📱 Share Contact"
+	BtnStartConversion: "شروع تبدیل تصویر",
+	BtnMyConversions:   "تبدیل‌های من",
+	BtnHelp:            "راهنما",
+	BtnSettings:        "تنظیمات",
+	BtnBackToMenu:      "بازگشت به منو",
+	BtnCancel:          "لغو",
+	BtnConfirm:         "تأیید و ارسال",
+	BtnChangeStyle:     "تغییر استایل",
+	BtnFeedback:        "بازخورد",
+	BtnNext:            "بعدی",
+	BtnPrevious:        "قبلی",
+	BtnViewResult:      "مشاهده نتیجه",
+	BtnDelete:          "حذف",
+	BtnShareContact:    "📱 Share Contact",
+	BtnShare:           "🔗 اشتراک‌گذاری",
+	BtnOpenShareLink:   "🌐 باز کردن لینک",
+	BtnRevokeShare:     "🚫 لغو اشتراک‌گذاری",
+	BtnBuyPlan:         "خرید اشتراک",
+	BtnPay:             "💳 پرداخت",
+	BtnCheckPayment:    "🔄 بررسی وضعیت پرداخت",
+	BtnTryOnOutfit:     "✨ پرو کن",
+	BtnSupport:         "🆘 پشتیبانی",
+	BtnNewTicket:       "✍️ ثبت تیکت جدید",
+	BtnMyTickets:       "📨 تیکت‌های من",
 
 	// Additional messages
-	MsgAbout = `ℹ️ درباره AI Styler
+	MsgAbout: `ℹ️ درباره AI Styler
 
 🎨 AI Styler یک ربات هوشمند برای تبدیل و استایل‌دهی تصاویر است.
 
@@ -190,56 +486,138 @@ const (
 🌐 وب‌سایت: در حال آماده‌سازی
 📧 پشتیبانی: در دسترس از طریق /help
 
-با تشکر از همراهی شما! ❤️`
+با تشکر از همراهی شما! ❤️`,
+	MsgProfile: `👤 پروفایل کاربری
 
-	MsgProfile = `👤 پروفایل کاربری
+اطلاعات حساب کاربری شما:`,
+	MsgStatistics: `📊 آمار و گزارش‌ها
 
-اطلاعات حساب کاربری شما:`
+آمار استفاده شما از ربات:`,
+	MsgGallery: `🖼️ گالری استایل‌ها
 
-	MsgStatistics = `📊 آمار و گزارش‌ها
+گالری زیبا از استایل‌های مختلف:`,
+	MsgProfileStats:    `📊 آمار و اطلاعات حساب کاربری شما:`,
+	MsgProfileQuota:    `💳 پلن و کووتا شما:`,
+	MsgSettingsContact: `📱 اطلاعات تماس شما:`,
+	MsgSettingsNotifications: `🔔 تنظیمات اعلان‌ها
 
-آمار استفاده شما از ربات:`
-
-	MsgGallery = `🖼️ گالری استایل‌ها
-
-گالری زیبا از استایل‌های مختلف:`
-
-	MsgProfileStats = `📊 آمار و اطلاعات حساب کاربری شما:`
-
-	MsgProfileQuota = `💳 پلن و کووتا شما:`
-
-	MsgSettingsContact = `📱 اطلاعات تماس شما:`
-
-	MsgSettingsNotifications = `🔔 تنظیمات اعلان‌ها
-
-در حال حاضر تمام اعلان‌ها فعال هستند.`
-
-	MsgSettingsLanguage = `🌐 زبان ربات
+در حال حاضر تمام اعلان‌ها فعال هستند.`,
+	MsgSettingsLanguage: `🌐 زبان ربات
 
 زبان فعلی: فارسی 🇮🇷
 
-در حال حاضر فقط زبان فارسی پشتیبانی می‌شود.`
-
-	MsgSettingsPassword = `🔒 تغییر رمز عبور
-
-برای تغییر رمز عبور لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید.`
-)
+زبان ربات بر اساس زبان اپلیکیشن تلگرام شما انتخاب می‌شود.
+زبان‌های پشتیبانی‌شده: فارسی، English`,
+	MsgSettingsPassword: `🔒 تغییر رمز عبور
+
+برای تغییر رمز عبور لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید.`,
+
+	// Menu and navigation messages
+	MsgMainMenu:           "🏠 منوی اصلی:",
+	MsgUseMenu:            "لطفاً از منو استفاده کنید.",
+	MsgInvalidCommand:     "دستور نامعتبر است. از /help برای راهنما استفاده کنید.",
+	MsgInvalidAction:      "عملیات نامعتبر",
+	MsgOperationCancelled: "✅ عملیات لغو شد.",
+	MsgCancelled:          "لغو شد",
+	MsgRateLimitShort:     "تعداد درخواست‌های شما بیش از حد مجاز است.",
+
+	// Contact and registration messages
+	MsgShareContactPrompt:     "📱 لطفاً کانتکت خودتون رو share کنید:",
+	MsgContactNotOwn:          "⚠️ لطفاً کانتکت خودتون رو share کنید، نه کانتکت شخص دیگری.",
+	MsgInvalidPhone:           "❌ شماره تلفن نامعتبر است. لطفاً دوباره تلاش کنید.",
+	MsgPhoneAlreadyRegistered: "⚠️ این شماره تلفن قبلاً ثبت‌نام شده است. لطفاً دوباره تلاش کنید.",
+	MsgRegistrationFailed:     "❌ خطا در ثبت‌نام: %v\n\nلطفاً دوباره تلاش کنید یا با پشتیبانی تماس بگیرید.",
+
+	// Conversion flow messages
+	MsgSendYourPhoto:           "لطفاً عکس خودتون رو ارسال کنید:",
+	MsgSendAsPhoto:             "لطفاً عکس را به صورت عکس ارسال کنید، نه فایل.",
+	MsgStateSaveFailed:         "❌ خطا در ذخیره وضعیت. لطفاً دوباره تلاش کنید.",
+	MsgStateLoadFailed:         "❌ خطا در دریافت اطلاعات. لطفاً دوباره از ابتدا شروع کنید.",
+	MsgDataLoadFailed:          "خطا در دریافت اطلاعات",
+	MsgGarmentProcessingFailed: "❌ خطا در پردازش عکس لباس. لطفاً دوباره از ابتدا شروع کنید.",
+	MsgConversionCreateFailed:  "❌ خطا در ایجاد تبدیل: %v",
+	MsgConversionCreated:       "✅ تبدیل با موفقیت انجام شد!\n\nشناسه تبدیل: %s",
+	MsgConversionResultCaption: "نتیجه تبدیل:",
+	MsgStyleSelected:           "استایل انتخاب شد: %s",
+	MsgConfirmConversion:       "آیا می‌خواهید تبدیل را شروع کنید؟",
+	MsgConversionTimeout:       "زمان پردازش به پایان رسید. لطفاً دوباره تلاش کنید.",
+	MsgUnknownError:            "خطای نامشخص",
+	MsgConversionListItem:      "%d. تبدیل #%s\n   وضعیت: %s\n   تاریخ: %s\n\n",
+	MsgConversionDetails:       "تبدیل #%s\nوضعیت: %s\n",
+	MsgConversionTraceID:       "🔎 کد پیگیری برای پشتیبانی: %s",
+
+	// Profile, statistics and quota lines
+	MsgProfileName:         "👤 نام: %s",
+	MsgProfilePhone:        "📞 شماره تلفن: %s",
+	MsgProfileUsername:     "🔗 یوزرنیم: @%s",
+	MsgProfileEdit:         "📝 برای ویرایش پروفایل لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید.",
+	MsgStatsFailed:         "⚠️ دریافت آمار با خطا مواجه شد.",
+	MsgStatsTotal:          "📊 کل تبدیل‌ها: %s",
+	MsgStatsSuccessful:     "✅ موفق: %s",
+	MsgStatsFailedCount:    "❌ ناموفق: %s",
+	MsgStatsSuccessRate:    "📈 نرخ موفقیت: %s٪",
+	MsgStatsAverageTime:    "⏱️ زمان متوسط: %s ثانیه",
+	MsgQuotaFailed:         "⚠️ دریافت اطلاعات کووتا با خطا مواجه شد.",
+	MsgQuotaPlan:           "📦 پلن: %s",
+	MsgQuotaUsed:           "📊 استفاده شده: %s از %s",
+	MsgQuotaRemaining:      "🔄 باقیمانده: %s",
+	MsgQuotaPercentage:     "📈 درصد استفاده: %s٪",
+	MsgQuotaExhausted:      "⚠️ کووتا تمام شده است!\n💳 برای ادامه استفاده، پلن خود را ارتقا دهید.",
+	MsgGalleryComingSoon:   "📝 گالری در حال آماده‌سازی است. به زودی در دسترس خواهد بود.",
+	MsgSettingsContactHint: "💡 برای تغییر اطلاعات تماس، لطفاً از وب‌سایت یا اپلیکیشن استفاده کنید.",
+	MsgPlanPrice:           "%s ریال",
+
+	// Additional button labels
+	BtnProfile:               "👤 پروفایل",
+	BtnGallery:               "🖼️ گالری",
+	BtnStatistics:            "📊 آمار",
+	BtnAbout:                 "ℹ️ درباره ما",
+	BtnSettingsContact:       "📱 اطلاعات تماس",
+	BtnSettingsNotifications: "🔔 تنظیمات اعلان",
+	BtnSettingsLanguage:      "🌐 زبان",
+	BtnSettingsPassword:      "🔒 تغییر رمز عبور",
+	BtnProfileStats:          "📊 آمار و اطلاعات",
+	BtnProfileQuota:          "💳 پلن و کووتا",
+	BtnProfileEdit:           "📝 ویرایش پروفایل",
+	BtnCancelContact:         "❌ لغو",
+
+	// Conversion statuses
+	statusKeyPrefix + "pending":    "در انتظار",
+	statusKeyPrefix + "processing": "در حال پردازش",
+	statusKeyPrefix + "completed":  "تکمیل شده",
+	statusKeyPrefix + "failed":     "ناموفق",
+
+	// Support ticket statuses
+	supportStatusKeyPrefix + "open":     "در انتظار پاسخ",
+	supportStatusKeyPrefix + "answered": "پاسخ داده شده",
+	supportStatusKeyPrefix + "closed":   "بسته شده",
+
+	// Advice for conversions failed by their inputs
+	errorCodeKeyPrefix + "no_person_detected": "در عکس شما فردی پیدا نشد. لطفاً عکسی بفرستید که خودتان در آن کاملاً دیده می‌شوید.",
+	errorCodeKeyPrefix + "multiple_people":    "در عکس شما بیش از یک نفر دیده می‌شود. لطفاً عکسی بفرستید که فقط خودتان در آن باشید.",
+	errorCodeKeyPrefix + "person_too_small":   "شما در عکس خیلی کوچک هستید. لطفاً عکسی نزدیک‌تر بفرستید که بیشتر قاب را بگیرید.",
+	errorCodeKeyPrefix + "image_too_small":    "کیفیت عکس شما خیلی پایین است. لطفاً عکسی با وضوح بیشتر بفرستید.",
+	errorCodeKeyPrefix + "garment_too_small":  "لباس در عکس خیلی کوچک است. لطفاً عکسی بفرستید که لباس بیشتر قاب را بگیرد.",
+	errorCodeKeyPrefix + "image_rejected":     "یکی از عکس‌ها مجاز نیست. لطفاً عکس دیگری بفرستید.",
+	errorCodeKeyPrefix + "invalid_image":      "یکی از عکس‌ها قابل خواندن نیست. لطفاً آن را با فرمت JPG یا PNG دوباره بفرستید.",
+
+	// Style display names
+	styleKeyPrefix + "vintage":    "کلاسیک",
+	styleKeyPrefix + "casual":     "راحت",
+	styleKeyPrefix + "formal":     "رسمی",
+	styleKeyPrefix + "streetwear": "خیابانی",
+	styleKeyPrefix + "elegant":    "زیبا",
+}
 
-// GetProgressMessage returns a progress message with percentage
-func GetProgressMessage(percentage int) string {
+// GetProgressMessage returns a progress message with percentage in lang
+func GetProgressMessage(lang string, percentage int) string {
 	if percentage < 0 {
 		percentage = 0
 	}
 	if percentage > 100 {
 		percentage = 100
 	}
-	return "درحال پردازش — " + formatPercentage(percentage) + "\nلطفاً صبر کنید..."
-}
-
-// formatPercentage formats percentage with Persian digits
-func formatPercentage(p int) string {
-	// Simple formatting - can be enhanced with Persian digits if needed
-	return fmt.Sprintf("%d%%", p)
+	return messages.Text(lang, MsgConversionProcessing, locale.ForLanguage(lang).Percent(percentage))
 }
 
 // GetErrorCode formats error code for display
@@ -250,4 +628,3 @@ func GetErrorCode(err error) string {
 	// Extract error code from error message or use a generic one
 	return "ERR-500"
 }
-
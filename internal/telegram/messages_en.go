@@ -0,0 +1,397 @@
+package telegram
+
+// englishMessages are the English message templates, used for Telegram users
+// whose app language is English. Keys missing here fall back to Persian.
+var englishMessages = map[string]string{
+	// Welcome messages
+	MsgWelcome: `👋 Hello and welcome!
+
+🎨 Welcome to the AI Styler bot!
+Use this bot to restyle your photos with a variety of beautiful styles.
+
+✨ What the bot can do:
+• Convert images with different styles
+• A gallery of styles
+• Browse your previous conversions
+• Statistics and reports
+• And much more!
+
+Tap «✨ Start image conversion» to begin.`,
+	MsgWelcomeBack: `👋 Welcome back!
+
+What would you like to do?
+Pick an option from the menu below.`,
+
+	// Authentication messages
+	MsgPleaseLogin: `Please sign in to your account to use this bot.`,
+	MsgShareContact: `To verify your identity, please share your Telegram contact.
+It is needed to sign up, sign in and reset your password.`,
+	MsgContactReceived: `✅ Contact received!
+Verifying...`,
+	MsgContactVerificationFailed: `❌ Verification failed.
+Please make sure you shared your own contact.`,
+	MsgContactNotShared: `⚠️ Please share your contact using the button.`,
+	MsgLoginSuccess: `✅ Signed in successfully!
+You can now use every feature of the bot.`,
+	MsgRegistrationSuccess: `✅ Sign-up complete!
+Your account has been created and you can start using the bot.`,
+
+	// Account link messages
+	MsgLinkRequired: `🔗 An account already exists for this phone number.
+
+To connect it to the bot:
+1️⃣ Sign in on the website or app
+2️⃣ In settings, tap «Connect Telegram»
+3️⃣ Send the code shown here (or use /link CODE)`,
+	MsgEnterLinkCode: `🔑 Please send your account link code:`,
+	MsgLinkSuccess: `✅ Your Telegram account is now linked to your account!
+From now on you are signed in to the bot without a password.`,
+	MsgLinkCodeInvalid: `❌ The link code is invalid or has expired.
+Please get a new code from the website or app.`,
+	MsgLinkFailed: `❌ Linking your account failed.
+Please try again later.`,
+	MsgRegistrationPasswordHint: `💡 To sign in on the website or app, use «Forgot password» with this phone number.`,
+
+	// Image upload messages
+	MsgImageReceived: `Photo received ✅
+Now send a photo of the garment you want to try on.`,
+	MsgImageTooLarge: `❌ The photo is too large!
+Maximum size: %s
+Please send a smaller photo.`,
+	MsgInvalidImageFormat: `❌ This image format is not supported!
+Allowed formats: JPEG, PNG, WebP`,
+	MsgImageUploaded: `✅ Photo uploaded successfully!
+Image ID: %s`,
+	MsgGarmentAdded: `👕 Garment %d of %d added to the outfit.
+Send another garment (e.g. a top, bottoms and an accessory) to complete the outfit, or try it on now.`,
+
+	// Conversion messages
+	MsgSelectStyle: `Please choose one of the available styles:`,
+	MsgConversionStarted: `✅ Conversion requested!
+Conversion ID: %s
+Processing...`,
+	MsgConversionProcessing: `Processing — %s
+Please wait...`,
+	MsgConversionCompleted: `✅ Conversion completed!
+Here is the result:`,
+	MsgConversionFailed: `❌ The conversion failed.
+Error: %s
+Please try again.`,
+	MsgConversionNotFound: `❌ Conversion not found.`,
+
+	// Share messages
+	MsgShareCreated: `🔗 Share link created!
+
+Direct link:
+%s
+
+Bot link:
+%s
+
+⏳ This link is valid until %s.`,
+	MsgShareFailed: `❌ Creating the share link failed.
+Please try again.`,
+	MsgShareRevoked:      `✅ The share link has been disabled.`,
+	MsgShareRevokeFailed: `❌ Disabling the link failed.`,
+	MsgSharedResult:      `🎨 Someone shared an AI Styler result with you!`,
+	MsgSharedLinkInvalid: `⚠️ This share link has expired or is invalid.`,
+
+	// Plan purchase messages
+	MsgPlansList: `💳 Buy a subscription
+
+Choose one of the plans below:
+
+%s`,
+	MsgPlanItem: `• %s - %s
+  %s conversions per month`,
+	MsgNoPlans: `⚠️ There are no plans available for purchase right now.`,
+	MsgPlansFailed: `❌ Loading the plans failed.
+Please try again.`,
+	MsgPaymentCreated: `🧾 %s plan - %s
+
+Tap the button below to pay.
+You will be notified here once the payment completes and your subscription is active.
+
+⏳ This link is valid until %s.`,
+	MsgPaymentCreateFailed: `❌ Creating the payment link failed.
+Please try again.`,
+	MsgPlanAlreadyActive: `ℹ️ You already have an active subscription.`,
+	MsgPaymentActivated: `🎉 Your payment was successful!
+
+✅ Your %s subscription is now active.`,
+	MsgPaymentPending: `⏳ Your payment is not complete yet.
+You will be notified here once it is.`,
+	MsgPaymentUnsuccessful: `❌ The payment for %s did not go through.
+If you were charged, the amount will be refunded within 72 hours.`,
+	MsgPaymentStatusFailed: `❌ Checking the payment status failed.
+Please try again later.`,
+
+	// Support messages
+	MsgSupportMenu: `🆘 Support
+
+Have a question or a problem? Open a ticket and our support team will look into it.
+You will be notified here when support replies.`,
+	MsgSupportPrompt: `✍️ Write your message.
+If needed, you can send a photo with a caption instead.`,
+	MsgSupportCreated: `✅ Your ticket has been opened.
+
+Subject: %s
+You will be notified here when support replies.`,
+	MsgSupportFailed: `❌ Opening the ticket failed.
+Please try again.`,
+	MsgSupportLimit: `⚠️ You have reached the limit of open tickets. Please wait for support to reply.`,
+	MsgSupportTickets: `📨 Your tickets:
+
+%s`,
+	MsgSupportTicketItem: `• %s
+  %s - %s`,
+	MsgSupportNoTickets: `You have not opened any tickets yet.`,
+
+	// My Conversions messages
+	MsgMyConversions: `Your conversions:`,
+	MsgNoConversions: `You have not made any conversions yet.`,
+
+	// Error messages
+	MsgErrorGeneric: `Sorry, something went wrong.
+Error code: %s
+Please try again later.`,
+	MsgErrorRateLimit: `⚠️ You have sent too many requests.
+Please wait a moment and try again.`,
+	MsgErrorQuotaExceeded: `❌ Your free conversion quota is used up.
+Please upgrade your plan to continue.`,
+	MsgErrorUnauthorized: `❌ You are not signed in.
+Please sign in first.`,
+
+	// Help messages
+	MsgHelp: `📖 AI Styler bot guide
+
+━━━━━━━━━━━━━━━━━━━━
+✨ Start image conversion
+━━━━━━━━━━━━━━━━━━━━
+
+1️⃣ Tap «✨ Start image conversion»
+2️⃣ Send your photo (a face photo)
+3️⃣ Send a photo of the garment
+4️⃣ Choose a style
+5️⃣ Wait for the result! 🎉
+
+━━━━━━━━━━━━━━━━━━━━
+📋 My conversions
+━━━━━━━━━━━━━━━━━━━━
+
+• Browse all previous conversions
+• View results
+• Check conversion status
+• Delete old conversions
+
+━━━━━━━━━━━━━━━━━━━━
+👤 Profile
+━━━━━━━━━━━━━━━━━━━━
+
+• View your account details
+• View statistics and reports
+• View your plan and quota
+• Edit your profile
+
+━━━━━━━━━━━━━━━━━━━━
+🖼️ Gallery
+━━━━━━━━━━━━━━━━━━━━
+
+• Browse the style gallery
+• Get inspired by beautiful styles
+• See what other users made
+
+━━━━━━━━━━━━━━━━━━━━
+📊 Statistics
+━━━━━━━━━━━━━━━━━━━━
+
+• Your conversion statistics
+• Usage trends
+• Account activity
+
+━━━━━━━━━━━━━━━━━━━━
+⚙️ Settings
+━━━━━━━━━━━━━━━━━━━━
+
+• Contact details
+• Notification settings
+• Language
+• Change password
+
+━━━━━━━━━━━━━━━━━━━━
+🔗 Account linking
+━━━━━━━━━━━━━━━━━━━━
+
+• If you already signed up on the website, get a link code from your account settings
+• Send it to the bot with /link CODE
+
+💡 Tip: use /help any time to see this guide again.`,
+
+	// Settings messages
+	MsgSettings: `⚙️ Bot settings
+
+Choose the settings you want to change:`,
+
+	// Button labels
+	BtnStartConversion: "Start image conversion",
+	BtnMyConversions:   "My conversions",
+	BtnHelp:            "Help",
+	BtnSettings:        "Settings",
+	BtnBackToMenu:      "Back to menu",
+	BtnCancel:          "Cancel",
+	BtnConfirm:         "Confirm and send",
+	BtnChangeStyle:     "Change style",
+	BtnFeedback:        "Feedback",
+	BtnNext:            "Next",
+	BtnPrevious:        "Previous",
+	BtnViewResult:      "View result",
+	BtnDelete:          "Delete",
+	BtnShareContact:    "📱 Share Contact",
+	BtnShare:           "🔗 Share",
+	BtnOpenShareLink:   "🌐 Open link",
+	BtnRevokeShare:     "🚫 Stop sharing",
+	BtnBuyPlan:         "Buy subscription",
+	BtnPay:             "💳 Pay",
+	BtnCheckPayment:    "🔄 Check payment status",
+	BtnTryOnOutfit:     "✨ Try it on",
+	BtnSupport:         "🆘 Support",
+	BtnNewTicket:       "✍️ New ticket",
+	BtnMyTickets:       "📨 My tickets",
+
+	// Additional messages
+	MsgAbout: `ℹ️ About AI Styler
+
+🎨 AI Styler is a smart bot for converting and styling images.
+
+✨ Features:
+• AI image conversion
+• Many beautiful styles
+• Fast and accurate processing
+• A simple, friendly interface
+
+📱 Bot version: 1.0
+🌐 Website: coming soon
+📧 Support: available through /help
+
+Thank you for being with us! ❤️`,
+	MsgProfile: `👤 Profile
+
+Your account details:`,
+	MsgStatistics: `📊 Statistics and reports
+
+Your bot usage:`,
+	MsgGallery: `🖼️ Style gallery
+
+A gallery of different styles:`,
+	MsgProfileStats:    `📊 Your account statistics:`,
+	MsgProfileQuota:    `💳 Your plan and quota:`,
+	MsgSettingsContact: `📱 Your contact details:`,
+	MsgSettingsNotifications: `🔔 Notification settings
+
+All notifications are currently enabled.`,
+	MsgSettingsLanguage: `🌐 Bot language
+
+Current language: English 🇬🇧
+
+The bot follows the language of your Telegram app.
+Supported languages: فارسی, English`,
+	MsgSettingsPassword: `🔒 Change password
+
+Please use the website or app to change your password.`,
+
+	// Menu and navigation messages
+	MsgMainMenu:           "🏠 Main menu:",
+	MsgUseMenu:            "Please use the menu.",
+	MsgInvalidCommand:     "Unknown command. Use /help for the guide.",
+	MsgInvalidAction:      "Invalid action",
+	MsgOperationCancelled: "✅ Cancelled.",
+	MsgCancelled:          "Cancelled",
+	MsgRateLimitShort:     "You have sent too many requests.",
+
+	// Contact and registration messages
+	MsgShareContactPrompt:     "📱 Please share your contact:",
+	MsgContactNotOwn:          "⚠️ Please share your own contact, not someone else's.",
+	MsgInvalidPhone:           "❌ Invalid phone number. Please try again.",
+	MsgPhoneAlreadyRegistered: "⚠️ This phone number is already registered. Please try again.",
+	MsgRegistrationFailed:     "❌ Sign-up failed: %v\n\nPlease try again or contact support.",
+
+	// Conversion flow messages
+	MsgSendYourPhoto:           "Please send your photo:",
+	MsgSendAsPhoto:             "Please send the image as a photo, not as a file.",
+	MsgStateSaveFailed:         "❌ Saving your progress failed. Please try again.",
+	MsgStateLoadFailed:         "❌ Loading your progress failed. Please start over.",
+	MsgDataLoadFailed:          "Loading data failed",
+	MsgGarmentProcessingFailed: "❌ Processing the garment photo failed. Please start over.",
+	MsgConversionCreateFailed:  "❌ Creating the conversion failed: %v",
+	MsgConversionCreated:       "✅ Conversion completed!\n\nConversion ID: %s",
+	MsgConversionResultCaption: "Conversion result:",
+	MsgStyleSelected:           "Style selected: %s",
+	MsgConfirmConversion:       "Do you want to start the conversion?",
+	MsgConversionTimeout:       "Processing timed out. Please try again.",
+	MsgUnknownError:            "Unknown error",
+	MsgConversionListItem:      "%d. Conversion #%s\n   Status: %s\n   Date: %s\n\n",
+	MsgConversionDetails:       "Conversion #%s\nStatus: %s\n",
+	MsgConversionTraceID:       "🔎 Code for support: %s",
+
+	// Profile, statistics and quota lines
+	MsgProfileName:         "👤 Name: %s",
+	MsgProfilePhone:        "📞 Phone: %s",
+	MsgProfileUsername:     "🔗 Username: @%s",
+	MsgProfileEdit:         "📝 Please use the website or app to edit your profile.",
+	MsgStatsFailed:         "⚠️ Loading statistics failed.",
+	MsgStatsTotal:          "📊 Total conversions: %s",
+	MsgStatsSuccessful:     "✅ Successful: %s",
+	MsgStatsFailedCount:    "❌ Failed: %s",
+	MsgStatsSuccessRate:    "📈 Success rate: %s%%",
+	MsgStatsAverageTime:    "⏱️ Average time: %s seconds",
+	MsgQuotaFailed:         "⚠️ Loading quota details failed.",
+	MsgQuotaPlan:           "📦 Plan: %s",
+	MsgQuotaUsed:           "📊 Used: %s of %s",
+	MsgQuotaRemaining:      "🔄 Remaining: %s",
+	MsgQuotaPercentage:     "📈 Usage: %s%%",
+	MsgQuotaExhausted:      "⚠️ Your quota is used up!\n💳 Upgrade your plan to continue.",
+	MsgGalleryComingSoon:   "📝 The gallery is being prepared and will be available soon.",
+	MsgSettingsContactHint: "💡 Please use the website or app to change your contact details.",
+	MsgPlanPrice:           "%s IRR",
+
+	// Additional button labels
+	BtnProfile:               "👤 Profile",
+	BtnGallery:               "🖼️ Gallery",
+	BtnStatistics:            "📊 Statistics",
+	BtnAbout:                 "ℹ️ About",
+	BtnSettingsContact:       "📱 Contact details",
+	BtnSettingsNotifications: "🔔 Notifications",
+	BtnSettingsLanguage:      "🌐 Language",
+	BtnSettingsPassword:      "🔒 Change password",
+	BtnProfileStats:          "📊 Statistics and details",
+	BtnProfileQuota:          "💳 Plan and quota",
+	BtnProfileEdit:           "📝 Edit profile",
+	BtnCancelContact:         "❌ Cancel",
+
+	// Conversion statuses
+	statusKeyPrefix + "pending":    "Pending",
+	statusKeyPrefix + "processing": "Processing",
+	statusKeyPrefix + "completed":  "Completed",
+	statusKeyPrefix + "failed":     "Failed",
+
+	// Support ticket statuses
+	supportStatusKeyPrefix + "open":     "Awaiting reply",
+	supportStatusKeyPrefix + "answered": "Answered",
+	supportStatusKeyPrefix + "closed":   "Closed",
+
+	// Advice for conversions failed by their inputs
+	errorCodeKeyPrefix + "no_person_detected": "No person was found in your photo. Please send a photo that clearly shows you.",
+	errorCodeKeyPrefix + "multiple_people":    "Your photo shows more than one person. Please send a photo of just yourself.",
+	errorCodeKeyPrefix + "person_too_small":   "You are too small in the photo. Please send a closer photo where you fill more of the frame.",
+	errorCodeKeyPrefix + "image_too_small":    "Your photo's resolution is too low. Please send a sharper photo.",
+	errorCodeKeyPrefix + "garment_too_small":  "The garment is too small in its photo. Please send a photo where the garment fills more of the frame.",
+	errorCodeKeyPrefix + "image_rejected":     "One of the photos is not allowed. Please send a different photo.",
+	errorCodeKeyPrefix + "invalid_image":      "One of the photos cannot be read. Please send it again as a JPG or PNG.",
+
+	// Style display names
+	styleKeyPrefix + "vintage":    "Vintage",
+	styleKeyPrefix + "casual":     "Casual",
+	styleKeyPrefix + "formal":     "Formal",
+	styleKeyPrefix + "streetwear": "Streetwear",
+	styleKeyPrefix + "elegant":    "Elegant",
+}
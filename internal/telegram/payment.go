@@ -0,0 +1,329 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-styler/internal/locale"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// paymentPayload is the /start payload of the deep link the gateway returns users to
+const paymentPayload = "payment"
+
+// Payment statuses reported by the payments API
+const (
+	paymentStatusCompleted = "completed"
+	paymentStatusFailed    = "failed"
+	paymentStatusCancelled = "cancelled"
+	paymentStatusExpired   = "expired"
+)
+
+// defaultPaymentTTL is used when the API doesn't report when a payment link expires
+const defaultPaymentTTL = time.Hour
+
+// handleBuyPlan lists the plans available for purchase
+func (h *Handlers) handleBuyPlan(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	plans, err := h.purchasablePlans(ctx, accessToken)
+	h.answerCallback(query.ID, "")
+	if err != nil {
+		log.Printf("Failed to get plans: %v", err)
+		h.sendMessage(chatID, h.text(chatID, MsgPlansFailed))
+		return
+	}
+	if len(plans) == 0 {
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgNoPlans), BackToMenuKeyboard(h.language(chatID)))
+		return
+	}
+
+	items := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		items = append(items, h.text(chatID, MsgPlanItem,
+			plan.DisplayName,
+			formatPlanPrice(h.language(chatID), plan.PricePerMonthCents),
+			h.formatter(chatID).Number(int64(plan.MonthlyConversionsLimit)),
+		))
+	}
+
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgPlansList, strings.Join(items, "\n\n")), PlansKeyboard(h.language(chatID), plans))
+}
+
+// handleSelectPlan creates a gateway payment for the selected plan and
+// tracks it until it completes
+func (h *Handlers) handleSelectPlan(query *tgbotapi.CallbackQuery, planID string) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	plans, err := h.purchasablePlans(ctx, accessToken)
+	if err != nil {
+		log.Printf("Failed to get plans: %v", err)
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgPlansFailed))
+		return
+	}
+
+	var plan *PaymentPlan
+	for i := range plans {
+		if plans[i].ID == planID {
+			plan = &plans[i]
+			break
+		}
+	}
+	if plan == nil {
+		h.answerCallback(query.ID, "")
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgNoPlans), BackToMenuKeyboard(h.language(chatID)))
+		return
+	}
+
+	paymentResp, err := h.apiClient.CreatePayment(ctx, accessToken, CreatePaymentRequest{
+		PlanID:      plan.ID,
+		ReturnURL:   h.paymentReturnURL(),
+		Description: "Telegram bot purchase: " + plan.Name,
+	})
+	h.answerCallback(query.ID, "")
+	if err != nil {
+		log.Printf("Failed to create payment for plan %s: %v", plan.ID, err)
+		if strings.Contains(err.Error(), "active plan") {
+			h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgPlanAlreadyActive), BackToMenuKeyboard(h.language(chatID)))
+			return
+		}
+		h.sendMessage(chatID, h.text(chatID, MsgPaymentCreateFailed))
+		return
+	}
+
+	expiresAt := paymentResp.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(defaultPaymentTTL)
+	}
+
+	err = h.sessionMgr.GetStorage().AddPendingPayment(ctx, &PendingPayment{
+		PaymentID:      paymentResp.PaymentID,
+		TelegramUserID: userID,
+		ChatID:         chatID,
+		PlanName:       plan.DisplayName,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		// The user can still check the payment manually
+		log.Printf("Failed to track payment %s: %v", paymentResp.PaymentID, err)
+	}
+
+	text := h.text(chatID, MsgPaymentCreated, plan.DisplayName, formatPlanPrice(h.language(chatID), plan.PricePerMonthCents), h.formatter(chatID).Time(expiresAt))
+	h.sendMessageWithKeyboard(chatID, text, PaymentLinkKeyboard(h.language(chatID), paymentResp.GatewayURL, paymentResp.PaymentID))
+}
+
+// handleCheckPayment reports the current status of a payment on request
+func (h *Handlers) handleCheckPayment(query *tgbotapi.CallbackQuery, paymentID string) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	status, err := h.apiClient.GetPaymentStatus(ctx, accessToken, paymentID)
+	h.answerCallback(query.ID, "")
+	if err != nil {
+		log.Printf("Failed to get payment %s status: %v", paymentID, err)
+		h.sendMessage(chatID, h.text(chatID, MsgPaymentStatusFailed))
+		return
+	}
+
+	if !isFinalPaymentStatus(status.Status) {
+		h.sendMessage(chatID, h.text(chatID, MsgPaymentPending))
+		return
+	}
+
+	// Stop the watcher from announcing the same result again
+	if _, err := h.sessionMgr.GetStorage().DeletePendingPayment(ctx, paymentID); err != nil {
+		log.Printf("Failed to untrack payment %s: %v", paymentID, err)
+	}
+	h.sendPaymentResult(chatID, status.Status, status.PlanName)
+}
+
+// handlePaymentReturn checks the user's tracked payments when the gateway
+// sends them back to the bot
+func (h *Handlers) handlePaymentReturn(userID, chatID int64) {
+	ctx := context.Background()
+
+	payments, err := h.sessionMgr.GetStorage().ListPendingPayments(ctx)
+	if err != nil {
+		log.Printf("Failed to list pending payments: %v", err)
+		return
+	}
+
+	stillPending := false
+	for _, payment := range payments {
+		if payment.TelegramUserID != userID {
+			continue
+		}
+		if !h.resolvePendingPayment(ctx, payment) {
+			stillPending = true
+		}
+	}
+
+	if stillPending {
+		h.sendMessage(chatID, h.text(chatID, MsgPaymentPending))
+	}
+}
+
+// WatchPendingPayments periodically checks tracked payments and confirms
+// them in chat once they complete. It blocks until ctx is cancelled.
+func (h *Handlers) WatchPendingPayments(ctx context.Context) {
+	interval := h.config.Telegram.PaymentPollInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			payments, err := h.sessionMgr.GetStorage().ListPendingPayments(ctx)
+			if err != nil {
+				log.Printf("Failed to list pending payments: %v", err)
+				continue
+			}
+			for _, payment := range payments {
+				h.resolvePendingPayment(ctx, payment)
+			}
+		}
+	}
+}
+
+// resolvePendingPayment checks a tracked payment and notifies the user once
+// it has reached a final status. It reports whether the payment is no longer pending.
+func (h *Handlers) resolvePendingPayment(ctx context.Context, payment PendingPayment) bool {
+	expired := time.Now().After(payment.ExpiresAt.Add(defaultPaymentTTL))
+
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, payment.TelegramUserID)
+	if err != nil || accessToken == "" {
+		// Without a session the payment can't be checked; give up once it has long expired
+		if expired {
+			h.untrackPayment(ctx, payment.PaymentID)
+			return true
+		}
+		return false
+	}
+
+	status, err := h.apiClient.GetPaymentStatus(ctx, accessToken, payment.PaymentID)
+	if err != nil {
+		log.Printf("Failed to get payment %s status: %v", payment.PaymentID, err)
+		return false
+	}
+
+	if !isFinalPaymentStatus(status.Status) {
+		if expired {
+			h.untrackPayment(ctx, payment.PaymentID)
+			return true
+		}
+		return false
+	}
+
+	// Only the caller that removes the payment announces it
+	removed, err := h.sessionMgr.GetStorage().DeletePendingPayment(ctx, payment.PaymentID)
+	if err != nil {
+		log.Printf("Failed to untrack payment %s: %v", payment.PaymentID, err)
+		return false
+	}
+	if removed {
+		planName := status.PlanName
+		if planName == "" {
+			planName = payment.PlanName
+		}
+		h.sendPaymentResult(payment.ChatID, status.Status, planName)
+	}
+	return true
+}
+
+// untrackPayment stops watching a payment that never completed
+func (h *Handlers) untrackPayment(ctx context.Context, paymentID string) {
+	log.Printf("Payment %s expired before completing, no longer tracking it", paymentID)
+	if _, err := h.sessionMgr.GetStorage().DeletePendingPayment(ctx, paymentID); err != nil {
+		log.Printf("Failed to untrack payment %s: %v", paymentID, err)
+	}
+}
+
+// sendPaymentResult tells the user how a payment ended
+func (h *Handlers) sendPaymentResult(chatID int64, status, planName string) {
+	if status == paymentStatusCompleted {
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgPaymentActivated, planName), BackToMenuKeyboard(h.language(chatID)))
+		return
+	}
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgPaymentUnsuccessful, planName), BackToMenuKeyboard(h.language(chatID)))
+}
+
+// purchasablePlans returns the paid plans offered by the API
+func (h *Handlers) purchasablePlans(ctx context.Context, accessToken string) ([]PaymentPlan, error) {
+	plans, err := h.apiClient.GetPlans(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := make([]PaymentPlan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.PricePerMonthCents > 0 {
+			paid = append(paid, plan)
+		}
+	}
+	return paid, nil
+}
+
+// paymentReturnURL returns where the gateway sends users after paying
+func (h *Handlers) paymentReturnURL() string {
+	if h.config.Telegram.PaymentReturnURL != "" {
+		return h.config.Telegram.PaymentReturnURL
+	}
+	if h.bot != nil && h.bot.Self.UserName != "" {
+		return fmt.Sprintf("https://t.me/%s?start=%s", h.bot.Self.UserName, paymentPayload)
+	}
+	return h.config.API.PublicURL
+}
+
+// isFinalPaymentStatus reports whether a payment can no longer change status
+func isFinalPaymentStatus(status string) bool {
+	switch status {
+	case paymentStatusCompleted, paymentStatusFailed, paymentStatusCancelled, paymentStatusExpired:
+		return true
+	}
+	return false
+}
+
+// formatPlanPrice formats a plan price, stored in Rials, for display
+func formatPlanPrice(lang string, amount int64) string {
+	return messages.Text(lang, MsgPlanPrice, locale.ForLanguage(lang).Number(amount))
+}
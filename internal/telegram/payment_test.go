@@ -0,0 +1,329 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-styler/internal/locale"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakePaymentDB holds the sessions and tracked payments of one test
+type fakePaymentDB struct {
+	mu       sync.Mutex
+	tokens   map[int64]string // telegram user ID -> access token
+	payments map[string]PendingPayment
+}
+
+var fakePaymentDBs sync.Map // DSN -> *fakePaymentDB
+
+// fakePaymentDriver serves the telegram_sessions and telegram_pending_payments
+// queries the payment handlers run
+type fakePaymentDriver struct{}
+
+func (fakePaymentDriver) Open(name string) (driver.Conn, error) {
+	db, ok := fakePaymentDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown fake database %q", name)
+	}
+	return fakePaymentConn{db.(*fakePaymentDB)}, nil
+}
+
+type fakePaymentConn struct{ db *fakePaymentDB }
+
+func (fakePaymentConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (fakePaymentConn) Close() error              { return nil }
+func (fakePaymentConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c fakePaymentConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.Contains(query, "DELETE FROM telegram_pending_payments") {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	paymentID := args[0].Value.(string)
+	if _, ok := c.db.payments[paymentID]; !ok {
+		return driver.RowsAffected(0), nil
+	}
+	delete(c.db.payments, paymentID)
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakePaymentConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "FROM telegram_sessions"):
+		rows := &fakePaymentRows{columns: make([]string, 13)}
+		telegramUserID := args[0].Value.(int64)
+		if token, ok := c.db.tokens[telegramUserID]; ok {
+			now := time.Now()
+			rows.values = append(rows.values, []driver.Value{
+				"session-1", telegramUserID, nil, nil, token, nil, nil, nil, nil, nil, nil, now, now,
+			})
+		}
+		return rows, nil
+	case strings.Contains(query, "FROM telegram_pending_payments"):
+		rows := &fakePaymentRows{columns: make([]string, 5)}
+		for _, payment := range c.db.payments {
+			rows.values = append(rows.values, []driver.Value{
+				payment.PaymentID, payment.TelegramUserID, payment.ChatID, payment.PlanName, payment.ExpiresAt,
+			})
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+type fakePaymentRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakePaymentRows) Columns() []string { return r.columns }
+func (r *fakePaymentRows) Close() error      { return nil }
+
+func (r *fakePaymentRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("telegram-payment-fake", fakePaymentDriver{})
+}
+
+// paymentTestEnv wires handlers to a fake database, a fake payments API and
+// a fake Bot API that records the messages sent
+type paymentTestEnv struct {
+	handlers *Handlers
+	db       *fakePaymentDB
+
+	mu       sync.Mutex
+	statuses map[string]string // payment ID -> status reported by the API
+	messages []string          // texts sent to chats
+}
+
+func newPaymentTestEnv(t *testing.T) *paymentTestEnv {
+	t.Helper()
+	env := &paymentTestEnv{
+		db:       &fakePaymentDB{tokens: make(map[int64]string), payments: make(map[string]PendingPayment)},
+		statuses: make(map[string]string),
+	}
+
+	fakePaymentDBs.Store(t.Name(), env.db)
+	t.Cleanup(func() { fakePaymentDBs.Delete(t.Name()) })
+	db, err := sql.Open("telegram-payment-fake", t.Name())
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paymentID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/payments/"), "/status")
+		env.mu.Lock()
+		status, ok := env.statuses[paymentID]
+		env.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(PaymentStatusResponse{PaymentID: paymentID, Status: status})
+	}))
+	t.Cleanup(api.Close)
+
+	telegramAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+			env.mu.Lock()
+			env.messages = append(env.messages, r.FormValue("text"))
+			env.mu.Unlock()
+			fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"stylerbot"}}`)
+	}))
+	t.Cleanup(telegramAPI.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithClient("token", telegramAPI.URL+"/bot%s/%s", telegramAPI.Client())
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	config := &Config{Telegram: TelegramConfig{PaymentPollInterval: 10 * time.Millisecond}}
+	env.handlers = NewHandlers(bot, NewAPIClient(api.URL, "", 5*time.Second), NewSessionManager(&Storage{db: db}), nil, config)
+	env.handlers.languages.Store(int64(100), locale.LangEnglish)
+	return env
+}
+
+// track records a payment the bot is waiting on and the status the API reports for it
+func (env *paymentTestEnv) track(payment PendingPayment, status string) {
+	env.db.mu.Lock()
+	env.db.payments[payment.PaymentID] = payment
+	env.db.mu.Unlock()
+
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	if status != "" {
+		env.statuses[payment.PaymentID] = status
+	}
+}
+
+func (env *paymentTestEnv) tracked(paymentID string) bool {
+	env.db.mu.Lock()
+	defer env.db.mu.Unlock()
+	_, ok := env.db.payments[paymentID]
+	return ok
+}
+
+func (env *paymentTestEnv) sent() []string {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	return append([]string(nil), env.messages...)
+}
+
+func TestResolvePendingPayment(t *testing.T) {
+	expiredAt := time.Now().Add(-2 * defaultPaymentTTL)
+	openUntil := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		status   string // reported by the API; empty makes the status request fail
+		token    bool
+		expires  time.Time
+		resolved bool
+		tracked  bool
+		message  string
+	}{
+		{"completed", paymentStatusCompleted, true, openUntil, true, false, "Your payment was successful"},
+		{"failed", paymentStatusFailed, true, openUntil, true, false, "did not go through"},
+		{"cancelled", paymentStatusCancelled, true, openUntil, true, false, "did not go through"},
+		{"expired", paymentStatusExpired, true, openUntil, true, false, "did not go through"},
+		{"pending", "pending", true, openUntil, false, true, ""},
+		{"pending long past expiry", "pending", true, expiredAt, true, false, ""},
+		{"status error", "", true, openUntil, false, true, ""},
+		{"status error past expiry", "", true, expiredAt, false, true, ""},
+		{"no session", paymentStatusCompleted, false, openUntil, false, true, ""},
+		{"no session past expiry", paymentStatusCompleted, false, expiredAt, true, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPaymentTestEnv(t)
+			if tt.token {
+				env.db.tokens[7] = "token-7"
+			}
+			payment := PendingPayment{PaymentID: "pay-1", TelegramUserID: 7, ChatID: 100, PlanName: "Pro", ExpiresAt: tt.expires}
+			env.track(payment, tt.status)
+
+			if got := env.handlers.resolvePendingPayment(context.Background(), payment); got != tt.resolved {
+				t.Errorf("Expected resolved %v, got %v", tt.resolved, got)
+			}
+			if got := env.tracked("pay-1"); got != tt.tracked {
+				t.Errorf("Expected tracked %v, got %v", tt.tracked, got)
+			}
+
+			messages := env.sent()
+			if tt.message == "" {
+				if len(messages) != 0 {
+					t.Errorf("Expected no messages, got %q", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || !strings.Contains(messages[0], tt.message) || !strings.Contains(messages[0], "Pro") {
+				t.Errorf("Expected one message containing %q and the plan name, got %q", tt.message, messages)
+			}
+		})
+	}
+}
+
+func TestResolvePendingPayment_AnnouncesOnce(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	env.db.tokens[7] = "token-7"
+	payment := PendingPayment{PaymentID: "pay-1", TelegramUserID: 7, ChatID: 100, PlanName: "Pro", ExpiresAt: time.Now().Add(time.Hour)}
+	env.track(payment, paymentStatusCompleted)
+
+	// A manual check raced the watcher; the second caller finds it already untracked
+	ctx := context.Background()
+	if !env.handlers.resolvePendingPayment(ctx, payment) || !env.handlers.resolvePendingPayment(ctx, payment) {
+		t.Error("Expected both calls to report the payment resolved")
+	}
+	if messages := env.sent(); len(messages) != 1 {
+		t.Errorf("Expected one announcement, got %q", messages)
+	}
+}
+
+func TestWatchPendingPayments(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	env.db.tokens[7] = "token-7"
+	env.track(PendingPayment{PaymentID: "pay-1", TelegramUserID: 7, ChatID: 100, PlanName: "Pro", ExpiresAt: time.Now().Add(time.Hour)}, paymentStatusCompleted)
+	env.track(PendingPayment{PaymentID: "pay-2", TelegramUserID: 7, ChatID: 100, PlanName: "Pro", ExpiresAt: time.Now().Add(time.Hour)}, "pending")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		env.handlers.WatchPendingPayments(ctx)
+		close(done)
+	}()
+
+	// Let the watcher poll several times after the payment completed
+	deadline := time.Now().Add(2 * time.Second)
+	for env.tracked("pay-1") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	if env.tracked("pay-1") {
+		t.Error("Expected the completed payment untracked")
+	}
+	if !env.tracked("pay-2") {
+		t.Error("Expected the pending payment still tracked")
+	}
+	if messages := env.sent(); len(messages) != 1 {
+		t.Errorf("Expected the completed payment announced once, got %q", messages)
+	}
+}
+
+func TestIsFinalPaymentStatus(t *testing.T) {
+	for status, final := range map[string]bool{
+		paymentStatusCompleted: true,
+		paymentStatusFailed:    true,
+		paymentStatusCancelled: true,
+		paymentStatusExpired:   true,
+		"pending":              false,
+		"":                     false,
+	} {
+		if got := isFinalPaymentStatus(status); got != final {
+			t.Errorf("%q: expected final %v, got %v", status, final, got)
+		}
+	}
+}
+
+func TestFormatPlanPrice(t *testing.T) {
+	if got := formatPlanPrice(locale.LangEnglish, 1500000); got != "1,500,000 IRR" {
+		t.Errorf("Expected %q, got %q", "1,500,000 IRR", got)
+	}
+	if got := formatPlanPrice(locale.LangPersian, 1500000); got != "۱٬۵۰۰٬۰۰۰ ریال" {
+		t.Errorf("Expected %q, got %q", "۱٬۵۰۰٬۰۰۰ ریال", got)
+	}
+}
@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sharePayloadPrefix marks /start payloads that carry a share token
+const sharePayloadPrefix = "share_"
+
+// handleShareConversion creates a share link for a completed conversion
+func (h *Handlers) handleShareConversion(query *tgbotapi.CallbackQuery, conversionID string) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	shareResp, err := h.apiClient.CreateShareLink(ctx, accessToken, ShareLinkRequest{
+		ConversionID:  conversionID,
+		ExpiryMinutes: h.config.Telegram.ShareExpiryMinutes,
+	})
+	if err != nil {
+		log.Printf("Failed to create share link: %v", err)
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgShareFailed))
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+
+	shareURL := strings.TrimRight(h.config.API.PublicURL, "/") + shareResp.PublicURL
+	deepLink := h.shareDeepLink(shareResp.ShareToken)
+	if deepLink == "" {
+		deepLink = "-"
+	}
+
+	// Plain text keeps the link preview enabled for the direct URL
+	text := h.text(chatID, MsgShareCreated, shareURL, deepLink, h.formatter(chatID).Time(shareResp.ExpiresAt))
+	h.sendMessageWithKeyboard(chatID, text, SharedLinkKeyboard(h.language(chatID), shareURL, shareResp.ShareID))
+}
+
+// handleRevokeShare deactivates a share link created from the bot
+func (h *Handlers) handleRevokeShare(query *tgbotapi.CallbackQuery, shareID string) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	if err := h.apiClient.RevokeShareLink(ctx, accessToken, shareID); err != nil {
+		log.Printf("Failed to revoke share link %s: %v", shareID, err)
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgShareRevokeFailed))
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+
+	// Drop the open/revoke buttons from the original share message
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID, BackToMenuKeyboard(h.language(chatID)))
+	if _, err := h.bot.Send(edit); err != nil {
+		log.Printf("Failed to update share message markup: %v", err)
+	}
+
+	h.sendMessage(chatID, h.text(chatID, MsgShareRevoked))
+}
+
+// handleSharedLinkStart shows a shared result to whoever opened the deep link
+func (h *Handlers) handleSharedLinkStart(chatID int64, payload string) {
+	token := decodeSharePayload(payload)
+	if token == "" {
+		h.sendMessage(chatID, h.text(chatID, MsgSharedLinkInvalid))
+		return
+	}
+
+	// The public share endpoint redirects to the result image, so Telegram can fetch it directly
+	shareURL := strings.TrimRight(h.config.API.PublicURL, "/") + "/api/share/" + token
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(shareURL))
+	photo.Caption = h.text(chatID, MsgSharedResult)
+	if _, err := h.bot.Send(photo); err != nil {
+		log.Printf("Failed to send shared result: %v", err)
+		h.sendMessage(chatID, h.text(chatID, MsgSharedLinkInvalid))
+	}
+}
+
+// shareDeepLink builds a t.me deep link that opens the bot with the share token
+func (h *Handlers) shareDeepLink(shareToken string) string {
+	if h.bot == nil || h.bot.Self.UserName == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", h.bot.Self.UserName, encodeSharePayload(shareToken))
+}
+
+// encodeSharePayload converts a share token into a /start payload.
+// Telegram only allows [A-Za-z0-9_-] in start parameters, so base64 padding is stripped.
+func encodeSharePayload(shareToken string) string {
+	return sharePayloadPrefix + strings.TrimRight(shareToken, "=")
+}
+
+// decodeSharePayload restores the share token from a /start payload
+func decodeSharePayload(payload string) string {
+	if !strings.HasPrefix(payload, sharePayloadPrefix) {
+		return ""
+	}
+	token := strings.TrimPrefix(payload, sharePayloadPrefix)
+	if token == "" {
+		return ""
+	}
+	if rem := len(token) % 4; rem != 0 {
+		token += strings.Repeat("=", 4-rem)
+	}
+	return token
+}
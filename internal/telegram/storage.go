@@ -37,6 +37,15 @@ type UserState struct {
 	ExpiresAt   time.Time `json:"expires_at"`
 }
 
+// PendingPayment represents a plan payment started from the bot that has not completed yet
+type PendingPayment struct {
+	PaymentID      string    `json:"payment_id"`
+	TelegramUserID int64     `json:"telegram_user_id"`
+	ChatID         int64     `json:"chat_id"`
+	PlanName       string    `json:"plan_name"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
 // Storage provides database operations for Telegram bot
 type Storage struct {
 	db    *sql.DB
@@ -159,12 +168,29 @@ func (s *Storage) createTables() error {
 	}
 	log.Printf("telegram_user_states table created successfully")
 
+	// Create telegram_pending_payments table so payment confirmations survive bot restarts
+	createPaymentsTableQuery := `
+	CREATE TABLE IF NOT EXISTS telegram_pending_payments (
+		payment_id VARCHAR(100) PRIMARY KEY,
+		telegram_user_id BIGINT NOT NULL,
+		chat_id BIGINT NOT NULL,
+		plan_name VARCHAR(255),
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW()
+	);
+	`
+
+	if _, err := s.db.Exec(createPaymentsTableQuery); err != nil {
+		return fmt.Errorf("failed to create telegram_pending_payments table: %w", err)
+	}
+
 	// Create indexes
 	indexQueries := []string{
 		`CREATE INDEX IF NOT EXISTS idx_telegram_sessions_telegram_user_id ON telegram_sessions(telegram_user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_telegram_sessions_backend_user_id ON telegram_sessions(backend_user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_telegram_sessions_phone ON telegram_sessions(phone);`,
 		`CREATE INDEX IF NOT EXISTS idx_telegram_sessions_token_expires_at ON telegram_sessions(token_expires_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_telegram_pending_payments_telegram_user_id ON telegram_pending_payments(telegram_user_id);`,
 	}
 
 	for _, query := range indexQueries {
@@ -524,6 +550,59 @@ func (s *Storage) DeleteToken(ctx context.Context, telegramUserID int64) error {
 	return s.redis.Del(ctx, key).Err()
 }
 
+// AddPendingPayment records a payment the bot should confirm once it completes
+func (s *Storage) AddPendingPayment(ctx context.Context, payment *PendingPayment) error {
+	query := `
+		INSERT INTO telegram_pending_payments (payment_id, telegram_user_id, chat_id, plan_name, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (payment_id) DO NOTHING
+	`
+
+	_, err := s.db.ExecContext(ctx, query, payment.PaymentID, payment.TelegramUserID, payment.ChatID, payment.PlanName, payment.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store pending payment: %w", err)
+	}
+	return nil
+}
+
+// ListPendingPayments returns all payments still awaiting confirmation
+func (s *Storage) ListPendingPayments(ctx context.Context) ([]PendingPayment, error) {
+	query := `
+		SELECT payment_id, telegram_user_id, chat_id, COALESCE(plan_name, ''), expires_at
+		FROM telegram_pending_payments
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []PendingPayment
+	for rows.Next() {
+		var payment PendingPayment
+		if err := rows.Scan(&payment.PaymentID, &payment.TelegramUserID, &payment.ChatID, &payment.PlanName, &payment.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pending payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// DeletePendingPayment stops tracking a payment. It reports whether the
+// payment was still tracked, so only one caller notifies the user.
+func (s *Storage) DeletePendingPayment(ctx context.Context, paymentID string) (bool, error) {
+	query := `DELETE FROM telegram_pending_payments WHERE payment_id = $1`
+	result, err := s.db.ExecContext(ctx, query, paymentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete pending payment: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
 // Close closes database connections
 func (s *Storage) Close() error {
 	if s.db != nil {
@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// stateWaitingSupportMessage is the user state while the bot waits for the
+// message of a new support ticket
+const stateWaitingSupportMessage = "waiting_support_message"
+
+// Support ticket limits of the bot
+const (
+	// supportSubjectLength is the characters of the message used as the subject
+	supportSubjectLength = 60
+	// supportTicketsShown is how many of the newest tickets the bot lists
+	supportTicketsShown = 5
+)
+
+// handleSupport shows the support menu
+func (h *Handlers) handleSupport(query *tgbotapi.CallbackQuery) {
+	chatID := query.Message.Chat.ID
+	h.answerCallback(query.ID, "")
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportMenu), SupportKeyboard(h.language(chatID)))
+}
+
+// handleNewSupportTicket asks for the message of a new ticket
+func (h *Handlers) handleNewSupportTicket(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	authenticated, err := h.sessionMgr.IsAuthenticated(ctx, userID)
+	if err != nil || !authenticated {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	if err := h.sessionMgr.SetState(ctx, userID, stateWaitingSupportMessage, ""); err != nil {
+		log.Printf("Failed to set state: %v", err)
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgStateSaveFailed))
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportPrompt), CancelKeyboard(h.language(chatID)))
+}
+
+// handleSupportTickets lists the user's newest tickets with their status
+func (h *Handlers) handleSupportTickets(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	tickets, err := h.apiClient.ListSupportTickets(ctx, accessToken, supportTicketsShown)
+	if err != nil {
+		log.Printf("Failed to list support tickets: %v", err)
+		h.answerCallback(query.ID, "")
+		h.sendMessage(chatID, h.text(chatID, MsgErrorGeneric))
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if len(tickets) == 0 {
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportNoTickets), SupportKeyboard(h.language(chatID)))
+		return
+	}
+
+	lines := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		lines = append(lines, h.text(chatID, MsgSupportTicketItem, ticket.Subject,
+			h.supportStatusText(chatID, ticket.Status), h.formatter(chatID).Time(ticket.CreatedAt)))
+	}
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportTickets, strings.Join(lines, "\n\n")), SupportKeyboard(h.language(chatID)))
+}
+
+// handleSupportMessage opens a ticket with a message typed while in the
+// waiting_support_message state
+func (h *Handlers) handleSupportMessage(msg *tgbotapi.Message) {
+	h.createSupportTicket(context.Background(), msg.From.ID, msg.Chat.ID, msg.Text, "")
+}
+
+// createSupportTicket opens a ticket through the support API, with an
+// uploaded image attached when attachmentImageID is set
+func (h *Handlers) createSupportTicket(ctx context.Context, userID, chatID int64, text, attachmentImageID string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportPrompt), CancelKeyboard(h.language(chatID)))
+		return
+	}
+
+	// Get access token
+	accessToken, err := h.sessionMgr.GetAccessToken(ctx, userID)
+	if err != nil || accessToken == "" {
+		h.sendMessage(chatID, h.text(chatID, MsgErrorUnauthorized))
+		return
+	}
+
+	ticket, err := h.apiClient.CreateSupportTicket(ctx, accessToken, SupportTicketRequest{
+		Subject:           supportSubject(text),
+		Message:           text,
+		AttachmentImageID: attachmentImageID,
+	})
+	if err != nil {
+		h.sessionMgr.ClearState(ctx, userID)
+		if errors.Is(err, ErrSupportTicketLimit) {
+			h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportLimit), SupportKeyboard(h.language(chatID)))
+			return
+		}
+		log.Printf("Failed to create support ticket: %v", err)
+		h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportFailed), SupportKeyboard(h.language(chatID)))
+		return
+	}
+
+	h.sessionMgr.ClearState(ctx, userID)
+	h.sendMessageWithKeyboard(chatID, h.text(chatID, MsgSupportCreated, ticket.Subject), BackToMenuKeyboard(h.language(chatID)))
+}
+
+// supportStatusText returns the localized name of a ticket status
+func (h *Handlers) supportStatusText(chatID int64, status string) string {
+	if messages.Has(h.language(chatID), supportStatusKeyPrefix+status) {
+		return h.text(chatID, supportStatusKeyPrefix+status)
+	}
+	return status
+}
+
+// supportSubject derives a ticket subject from the first line of a message
+func supportSubject(text string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	subject = strings.TrimSpace(subject)
+	if runes := []rune(subject); len(runes) > supportSubjectLength {
+		return strings.TrimSpace(string(runes[:supportSubjectLength])) + "…"
+	}
+	return subject
+}
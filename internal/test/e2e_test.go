@@ -150,8 +150,8 @@ func SetupTestServer(t *testing.T) *TestServer {
 	authGroup.POST("/send-otp", common.GinWrap(authHandler.SendOTP))
 	authGroup.POST("/verify-otp", common.GinWrap(authHandler.VerifyOTP))
 	authGroup.POST("/register", common.GinWrap(authHandler.Register))
-	authGroup.POST("/login", common.GinWrap(authHandler.Login))
-	authGroup.POST("/refresh", common.GinWrap(authHandler.Refresh))
+	common.Mount(authGroup, http.MethodPost, "/login", authHandler.LoginEndpoint())
+	common.Mount(authGroup, http.MethodPost, "/refresh", authHandler.RefreshEndpoint())
 	authGroup.POST("/logout", common.GinWrap(authHandler.Authenticate(authHandler.Logout)))
 
 	// Add mock routes for other services
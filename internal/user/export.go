@@ -0,0 +1,597 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Data export statuses
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusReady      = "ready"
+	DataExportStatusFailed     = "failed"
+)
+
+// Data export defaults
+const (
+	DefaultDataExportRetention    = 24 * time.Hour
+	DefaultDataExportPollInterval = 10 * time.Second
+
+	// DataExportObjectPrefix is the object storage prefix of data export archives
+	DataExportObjectPrefix = "takeout"
+
+	// dataExportStaleAfter is how long an export may stay processing before
+	// another instance takes it over
+	dataExportStaleAfter   = 30 * time.Minute
+	dataExportArchiveType  = "application/zip"
+	dataExportManifestName = "manifest.json"
+)
+
+var (
+	// ErrDataExportNotFound is returned for exports that do not exist or have expired
+	ErrDataExportNotFound = fmt.Errorf("data export %w", common.ErrNotFound)
+	// ErrDataExportsUnavailable is returned when data exports are not configured
+	ErrDataExportsUnavailable = errors.New("data exports are not available")
+)
+
+// DataExport is a ZIP archive of everything stored about a user, requested
+// for privacy-compliance ("takeout") purposes
+type DataExport struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"-"`
+	Status        string     `json:"status"`
+	ObjectKey     string     `json:"-"`
+	FileSize      int64      `json:"fileSize"`
+	Error         *string    `json:"error,omitempty"`
+	DownloadURL   string     `json:"downloadUrl,omitempty"`
+	LinkExpiresAt *time.Time `json:"linkExpiresAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+// StatusCode answers 202 while the export is being built
+func (e DataExport) StatusCode() int {
+	if e.Status == DataExportStatusPending || e.Status == DataExportStatusProcessing {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// dataExportFile is one JSON document of the archive
+type dataExportFile struct {
+	Name string
+	Data interface{}
+}
+
+// dataExportManifest is manifest.json at the root of the archive
+type dataExportManifest struct {
+	ExportID    string    `json:"exportId"`
+	UserID      string    `json:"userId"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Files       []string  `json:"files"`
+}
+
+// DataExportStore stores data export requests and reads the records that
+// go into them. Records are returned as JSON arrays, or a JSON object for
+// notification preferences, null when the user has none.
+type DataExportStore interface {
+	// GetLatestDataExport returns the user's most recent export, or nil
+	GetLatestDataExport(ctx context.Context, userID string) (*DataExport, error)
+	GetDataExport(ctx context.Context, exportID string) (DataExport, error)
+	CreateDataExport(ctx context.Context, userID string) (DataExport, error)
+	// ClaimDataExport marks the oldest pending export, or one processing for
+	// longer than staleAfter, as processing and returns it; nil when none
+	ClaimDataExport(ctx context.Context, staleAfter time.Duration) (*DataExport, error)
+	CompleteDataExport(ctx context.Context, export DataExport) error
+	FailDataExport(ctx context.Context, exportID, message string) error
+
+	ExportConversions(ctx context.Context, userID string) (json.RawMessage, error)
+	ExportPayments(ctx context.Context, userID string) (json.RawMessage, error)
+	ExportNotificationPreferences(ctx context.Context, userID string) (json.RawMessage, error)
+}
+
+// DataExportStorage stores and signs data export archives
+type DataExportStorage interface {
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+	// SignDownload signs a download of key at baseURL under the storage
+	// signed-URL policy
+	SignDownload(baseURL, key string) (string, time.Time)
+	VerifyDownload(key string, query url.Values) error
+}
+
+// DataExportNotifier tells users their data export finished
+type DataExportNotifier interface {
+	SendDataExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error
+	SendDataExportFailed(ctx context.Context, userID, exportID string) error
+}
+
+// DataExportConfig configures data exports
+type DataExportConfig struct {
+	DownloadURL  string        // public URL of GET /users/exports/download
+	Retention    time.Duration // how long a ready export can be downloaded
+	PollInterval time.Duration // how often the worker looks for queued exports
+}
+
+// dataExporter builds data exports in the background
+type dataExporter struct {
+	store    DataExportStore
+	profiles Store
+	storage  DataExportStorage
+	notifier DataExportNotifier
+	config   DataExportConfig
+	wake     chan struct{}
+}
+
+// SetDataExports enables takeout exports of user data. Exports are built by
+// StartDataExportWorker.
+func (s *Service) SetDataExports(store DataExportStore, storage DataExportStorage, notifier DataExportNotifier, config DataExportConfig) {
+	if config.Retention <= 0 {
+		config.Retention = DefaultDataExportRetention
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultDataExportPollInterval
+	}
+	s.dataExports = &dataExporter{
+		store:    store,
+		profiles: s.store,
+		storage:  storage,
+		notifier: notifier,
+		config:   config,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// RequestDataExport returns the user's export in progress or still
+// downloadable, queueing a new one when there is none or refresh is set
+func (s *Service) RequestDataExport(ctx context.Context, userID string, refresh bool) (DataExport, error) {
+	if s.dataExports == nil {
+		return DataExport{}, ErrDataExportsUnavailable
+	}
+	e := s.dataExports
+
+	latest, err := e.store.GetLatestDataExport(ctx, userID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	if latest != nil {
+		switch {
+		case latest.Status == DataExportStatusPending || latest.Status == DataExportStatusProcessing:
+			return *latest, nil
+		case latest.Status == DataExportStatusReady && !refresh && latest.ExpiresAt != nil && time.Now().Before(*latest.ExpiresAt):
+			return e.withDownloadURL(*latest), nil
+		}
+	}
+
+	export, err := e.store.CreateDataExport(ctx, userID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	_ = s.auditLogger.LogUserAction(ctx, userID, "data_export_requested", map[string]interface{}{"export_id": export.ID})
+
+	// Wake the worker rather than waiting for the next poll
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return export, nil
+}
+
+// OpenDataExportDownload verifies a signed download of key and returns the archive
+func (s *Service) OpenDataExportDownload(ctx context.Context, key string, query url.Values) ([]byte, error) {
+	if s.dataExports == nil {
+		return nil, ErrDataExportsUnavailable
+	}
+	e := s.dataExports
+
+	exportID, ok := dataExportIDFromKey(key)
+	if !ok {
+		return nil, ErrDataExportNotFound
+	}
+	if err := e.storage.VerifyDownload(key, query); err != nil {
+		return nil, common.NewAPIError(http.StatusForbidden, common.ErrCodeForbidden, err.Error(), nil)
+	}
+
+	export, err := e.store.GetDataExport(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != DataExportStatusReady || export.ObjectKey != key || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return nil, ErrDataExportNotFound
+	}
+	return e.storage.ReadObject(ctx, key)
+}
+
+// StartDataExportWorker builds queued data exports until ctx is cancelled
+func (s *Service) StartDataExportWorker(ctx context.Context) {
+	if s.dataExports == nil {
+		return
+	}
+	e := s.dataExports
+
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.processQueued(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// processQueued builds exports until none is left to claim
+func (e *dataExporter) processQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := e.store.ClaimDataExport(ctx, dataExportStaleAfter)
+		if err != nil {
+			log.Printf("Data exports: failed to claim an export: %v", err)
+			return
+		}
+		if export == nil {
+			return
+		}
+		e.process(ctx, *export)
+	}
+}
+
+// process builds one export and notifies its user
+func (e *dataExporter) process(ctx context.Context, export DataExport) {
+	built, err := e.build(ctx, export)
+	if err != nil {
+		log.Printf("Data exports: export %s failed: %v", export.ID, err)
+		if err := e.store.FailDataExport(ctx, export.ID, "failed to build the export"); err != nil {
+			log.Printf("Data exports: failed to record failure of %s: %v", export.ID, err)
+		}
+		if err := e.notifier.SendDataExportFailed(ctx, export.UserID, export.ID); err != nil {
+			log.Printf("Data exports: failed to notify %s: %v", export.UserID, err)
+		}
+		return
+	}
+
+	if err := e.store.CompleteDataExport(ctx, built); err != nil {
+		log.Printf("Data exports: failed to complete export %s: %v", export.ID, err)
+		return
+	}
+
+	built = e.withDownloadURL(built)
+	if err := e.notifier.SendDataExportReady(ctx, built.UserID, built.ID, built.DownloadURL, *built.LinkExpiresAt); err != nil {
+		log.Printf("Data exports: failed to notify %s: %v", export.UserID, err)
+	}
+}
+
+// build gathers the user's records and writes the archive to object storage
+func (e *dataExporter) build(ctx context.Context, export DataExport) (DataExport, error) {
+	profile, err := e.profiles.GetProfile(ctx, export.UserID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("failed to read profile: %w", err)
+	}
+	conversions, err := e.store.ExportConversions(ctx, export.UserID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	payments, err := e.store.ExportPayments(ctx, export.UserID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	preferences, err := e.store.ExportNotificationPreferences(ctx, export.UserID)
+	if err != nil {
+		return DataExport{}, err
+	}
+
+	files := []dataExportFile{
+		{Name: "profile.json", Data: profile},
+		{Name: "conversions.json", Data: conversions},
+		{Name: "payments.json", Data: payments},
+		{Name: "notification_preferences.json", Data: preferences},
+	}
+	manifest := dataExportManifest{
+		ExportID:    export.ID,
+		UserID:      export.UserID,
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.Name)
+	}
+	files = append([]dataExportFile{{Name: dataExportManifestName, Data: manifest}}, files...)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.Create(file.Name)
+		if err != nil {
+			return DataExport{}, fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.Data); err != nil {
+			return DataExport{}, fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return DataExport{}, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	key := dataExportObjectKey(export.UserID, export.ID)
+	if err := e.storage.WriteObject(ctx, key, buf.Bytes(), dataExportArchiveType); err != nil {
+		return DataExport{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(e.config.Retention)
+	export.Status = DataExportStatusReady
+	export.ObjectKey = key
+	export.FileSize = int64(buf.Len())
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return export, nil
+}
+
+// withDownloadURL attaches a freshly signed download link to a ready export
+func (e *dataExporter) withDownloadURL(export DataExport) DataExport {
+	if export.Status != DataExportStatusReady || export.ObjectKey == "" {
+		return export
+	}
+	baseURL := strings.TrimSuffix(e.config.DownloadURL, "/") + "/" + export.ObjectKey
+	downloadURL, expiresAt := e.storage.SignDownload(baseURL, export.ObjectKey)
+	// The link never outlives the export
+	if export.ExpiresAt != nil && expiresAt.After(*export.ExpiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+	export.DownloadURL = downloadURL
+	export.LinkExpiresAt = &expiresAt
+	return export
+}
+
+// dataExportObjectKey is the storage key of a data export archive
+func dataExportObjectKey(userID, exportID string) string {
+	return fmt.Sprintf("%s/%s/%s.zip", DataExportObjectPrefix, userID, exportID)
+}
+
+// dataExportIDFromKey extracts the export ID from an archive key
+func dataExportIDFromKey(key string) (string, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != DataExportObjectPrefix || !strings.HasSuffix(parts[2], ".zip") {
+		return "", false
+	}
+	return strings.TrimSuffix(parts[2], ".zip"), true
+}
+
+// dataExportError maps data export errors to API errors
+func dataExportError(err error, message string) error {
+	var apiErr *common.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, ErrDataExportNotFound), errors.Is(err, common.ErrNotFound):
+		return common.NewAPIError(http.StatusNotFound, common.ErrCodeNotFound, "export not found", nil)
+	case errors.Is(err, ErrDataExportsUnavailable):
+		return common.NewAPIError(http.StatusServiceUnavailable, common.ErrCodeServiceUnavailable, err.Error(), nil)
+	}
+	return common.NewAPIError(http.StatusInternalServerError, common.ErrCodeInternal, message, nil)
+}
+
+// dataExportRequest is the query of GET /users/me/export
+type dataExportRequest struct {
+	Refresh bool `form:"refresh"`
+}
+
+// ExportDataEndpoint handles GET /users/me/export
+func (h *Handler) ExportDataEndpoint() *common.Endpoint {
+	doc := common.OperationDoc{
+		Summary: "Export a copy of the user's account data",
+		Description: "Queues a ZIP of JSON files with the profile, conversion history, payments and " +
+			"notification preferences and answers 202 until it is ready; the user is notified " +
+			"with a signed download link. A ready export is returned with a fresh link until " +
+			"it expires, refresh=true queues a new one.",
+		Tags:    []string{"Users"},
+		Secured: true,
+	}
+	return common.NewEndpoint(doc, h.exportData)
+}
+
+func (h *Handler) exportData(ctx context.Context, req *dataExportRequest) (*DataExport, error) {
+	userID := common.GetUserIDFromContext(ctx)
+	if userID == "" {
+		return nil, common.NewAPIError(http.StatusUnauthorized, "", "user not authenticated", nil)
+	}
+
+	export, err := h.service.RequestDataExport(ctx, userID, req.Refresh)
+	if err != nil {
+		return nil, dataExportError(err, "failed to export account data")
+	}
+	return &export, nil
+}
+
+// DownloadDataExport handles GET /users/exports/download/*key, authorized
+// by the signature of the link rather than a bearer token
+func (h *Handler) DownloadDataExport(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	data, err := h.service.OpenDataExportDownload(c.Request.Context(), key, c.Request.URL.Query())
+	if err != nil {
+		common.RespondAPIError(c, common.FromError(dataExportError(err, "failed to download export"), http.StatusInternalServerError))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "account-data-"+path.Base(key)))
+	c.Data(http.StatusOK, dataExportArchiveType, data)
+}
+
+// dbDataExportStore implements DataExportStore on PostgreSQL
+type dbDataExportStore struct {
+	db *sql.DB
+}
+
+// NewDBDataExportStore creates a new database-backed data export store
+func NewDBDataExportStore(db *sql.DB) DataExportStore {
+	return &dbDataExportStore{db: db}
+}
+
+const dataExportColumns = `id, user_id, status, COALESCE(object_key, ''), file_size, error_message,
+	created_at, completed_at, expires_at`
+
+// scanDataExport scans a row of dataExportColumns
+func scanDataExport(row interface{ Scan(...interface{}) error }) (DataExport, error) {
+	var export DataExport
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.ObjectKey, &export.FileSize,
+		&export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	return export, err
+}
+
+func (s *dbDataExportStore) GetLatestDataExport(ctx context.Context, userID string) (*DataExport, error) {
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		SELECT `+dataExportColumns+`
+		FROM user_data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`, userID))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get latest data export: %w", err)
+	}
+	return &export, nil
+}
+
+func (s *dbDataExportStore) GetDataExport(ctx context.Context, exportID string) (DataExport, error) {
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		SELECT `+dataExportColumns+`
+		FROM user_data_exports
+		WHERE id = $1`, exportID))
+	switch {
+	case err == sql.ErrNoRows:
+		return DataExport{}, ErrDataExportNotFound
+	case err != nil:
+		return DataExport{}, fmt.Errorf("failed to get data export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *dbDataExportStore) CreateDataExport(ctx context.Context, userID string) (DataExport, error) {
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		INSERT INTO user_data_exports (user_id)
+		VALUES ($1)
+		RETURNING `+dataExportColumns, userID))
+	if err != nil {
+		return DataExport{}, fmt.Errorf("failed to create data export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *dbDataExportStore) ClaimDataExport(ctx context.Context, staleAfter time.Duration) (*DataExport, error) {
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		UPDATE user_data_exports
+		SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM user_data_exports
+			WHERE status = 'pending'
+			   OR (status = 'processing' AND started_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+dataExportColumns, staleAfter.Seconds()))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+	return &export, nil
+}
+
+func (s *dbDataExportStore) CompleteDataExport(ctx context.Context, export DataExport) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE user_data_exports
+		SET status = 'ready', object_key = $2, file_size = $3, completed_at = $4, expires_at = $5
+		WHERE id = $1`,
+		export.ID, export.ObjectKey, export.FileSize, export.CompletedAt, export.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+	return nil
+}
+
+func (s *dbDataExportStore) FailDataExport(ctx context.Context, exportID, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE user_data_exports
+		SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1`, exportID, message)
+	if err != nil {
+		return fmt.Errorf("failed to fail data export: %w", err)
+	}
+	return nil
+}
+
+func (s *dbDataExportStore) ExportConversions(ctx context.Context, userID string) (json.RawMessage, error) {
+	return s.queryJSON(ctx, "conversions", `
+		SELECT COALESCE(json_agg(row_to_json(c) ORDER BY c."createdAt" DESC), '[]')
+		FROM (
+			SELECT id, status, conversion_type AS "conversionType", style_name AS "styleName",
+			       user_image_id AS "userImageId", cloth_image_id AS "clothImageId",
+			       result_image_id AS "resultImageId", error_message AS "errorMessage",
+			       processing_time_ms AS "processingTimeMs", scheduled_at AS "scheduledAt",
+			       created_at AS "createdAt", completed_at AS "completedAt"
+			FROM conversions
+			WHERE user_id = $1
+		) c`, userID)
+}
+
+func (s *dbDataExportStore) ExportPayments(ctx context.Context, userID string) (json.RawMessage, error) {
+	return s.queryJSON(ctx, "payments", `
+		SELECT COALESCE(json_agg(row_to_json(p) ORDER BY p."createdAt" DESC), '[]')
+		FROM (
+			SELECT id, plan_id AS "planId", amount, currency, status,
+			       payment_method AS "paymentMethod", gateway,
+			       gateway_ref_number AS "gatewayRefNumber",
+			       gateway_card_number AS "gatewayCardNumber", description,
+			       created_at AS "createdAt", paid_at AS "paidAt"
+			FROM payments
+			WHERE user_id = $1
+		) p`, userID)
+}
+
+func (s *dbDataExportStore) ExportNotificationPreferences(ctx context.Context, userID string) (json.RawMessage, error) {
+	return s.queryJSON(ctx, "notification preferences", `
+		SELECT COALESCE((
+			SELECT json_build_object(
+				'emailEnabled', email_enabled, 'smsEnabled', sms_enabled,
+				'telegramEnabled', telegram_enabled, 'websocketEnabled', websocket_enabled,
+				'pushEnabled', push_enabled, 'preferences', preferences,
+				'quietHoursStart', quiet_hours_start, 'quietHoursEnd', quiet_hours_end,
+				'timezone', timezone, 'updatedAt', updated_at)
+			FROM notification_preferences
+			WHERE user_id = $1
+		), 'null')`, userID)
+}
+
+// queryJSON reads the single JSON value of query
+func (s *dbDataExportStore) queryJSON(ctx context.Context, what, query string, args ...interface{}) (json.RawMessage, error) {
+	var data []byte
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&data); err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", what, err)
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,277 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+type fakeDataExportStore struct {
+	exports map[string]*DataExport
+	order   []string
+	records map[string]json.RawMessage
+}
+
+func (f *fakeDataExportStore) GetLatestDataExport(ctx context.Context, userID string) (*DataExport, error) {
+	for i := len(f.order) - 1; i >= 0; i-- {
+		if export := f.exports[f.order[i]]; export.UserID == userID {
+			latest := *export
+			return &latest, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeDataExportStore) GetDataExport(ctx context.Context, exportID string) (DataExport, error) {
+	export, ok := f.exports[exportID]
+	if !ok {
+		return DataExport{}, ErrDataExportNotFound
+	}
+	return *export, nil
+}
+
+func (f *fakeDataExportStore) CreateDataExport(ctx context.Context, userID string) (DataExport, error) {
+	export := &DataExport{
+		ID:        fmt.Sprintf("export-%d", len(f.order)+1),
+		UserID:    userID,
+		Status:    DataExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+	f.exports[export.ID] = export
+	f.order = append(f.order, export.ID)
+	return *export, nil
+}
+
+func (f *fakeDataExportStore) ClaimDataExport(ctx context.Context, staleAfter time.Duration) (*DataExport, error) {
+	for _, id := range f.order {
+		if export := f.exports[id]; export.Status == DataExportStatusPending {
+			export.Status = DataExportStatusProcessing
+			claimed := *export
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeDataExportStore) CompleteDataExport(ctx context.Context, export DataExport) error {
+	f.exports[export.ID] = &export
+	return nil
+}
+
+func (f *fakeDataExportStore) FailDataExport(ctx context.Context, exportID, message string) error {
+	f.exports[exportID].Status = DataExportStatusFailed
+	f.exports[exportID].Error = &message
+	return nil
+}
+
+func (f *fakeDataExportStore) ExportConversions(ctx context.Context, userID string) (json.RawMessage, error) {
+	return f.records["conversions"], nil
+}
+
+func (f *fakeDataExportStore) ExportPayments(ctx context.Context, userID string) (json.RawMessage, error) {
+	return f.records["payments"], nil
+}
+
+func (f *fakeDataExportStore) ExportNotificationPreferences(ctx context.Context, userID string) (json.RawMessage, error) {
+	return f.records["preferences"], nil
+}
+
+// fakeDataExportStorage signs links with a fixed signature
+type fakeDataExportStorage struct {
+	objects map[string][]byte
+}
+
+func (f *fakeDataExportStorage) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	return f.objects[key], nil
+}
+
+func (f *fakeDataExportStorage) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	f.objects[key] = data
+	return nil
+}
+
+func (f *fakeDataExportStorage) SignDownload(baseURL, key string) (string, time.Time) {
+	return baseURL + "?signature=valid", time.Now().Add(15 * time.Minute)
+}
+
+func (f *fakeDataExportStorage) VerifyDownload(key string, query url.Values) error {
+	if query.Get("signature") != "valid" {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+type fakeDataExportNotifier struct {
+	ready  []string // download URLs
+	failed []string // export IDs
+}
+
+func (f *fakeDataExportNotifier) SendDataExportReady(ctx context.Context, userID, exportID, downloadURL string, expiresAt time.Time) error {
+	f.ready = append(f.ready, downloadURL)
+	return nil
+}
+
+func (f *fakeDataExportNotifier) SendDataExportFailed(ctx context.Context, userID, exportID string) error {
+	f.failed = append(f.failed, exportID)
+	return nil
+}
+
+func newDataExportTestService() (*Service, *fakeDataExportStore, *fakeDataExportStorage, *fakeDataExportNotifier) {
+	profiles := NewMockStore()
+	name := "Sara"
+	profiles.profiles["u1"] = UserProfile{ID: "u1", Phone: "+989121234567", Name: &name}
+
+	service := NewService(profiles, NewMockAuditLogger())
+	store := &fakeDataExportStore{
+		exports: make(map[string]*DataExport),
+		records: map[string]json.RawMessage{
+			"conversions": json.RawMessage(`[{"id":"c1","status":"completed"}]`),
+			"payments":    json.RawMessage(`[{"id":"p1","amount":500000}]`),
+			"preferences": json.RawMessage(`{"smsEnabled":true}`),
+		},
+	}
+	storage := &fakeDataExportStorage{objects: make(map[string][]byte)}
+	notifier := &fakeDataExportNotifier{}
+	service.SetDataExports(store, storage, notifier, DataExportConfig{
+		DownloadURL: "https://api.example.com/api/users/exports/download",
+	})
+	return service, store, storage, notifier
+}
+
+// readDataExportArchive returns the files of an archive
+func readDataExportArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a valid ZIP, got %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		files[file.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	return files
+}
+
+func TestRequestDataExport_QueuesOnce(t *testing.T) {
+	service, store, _, _ := newDataExportTestService()
+	ctx := context.Background()
+
+	export, err := service.RequestDataExport(ctx, "u1", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if export.Status != DataExportStatusPending || export.StatusCode() != http.StatusAccepted {
+		t.Errorf("Expected a pending export answered with 202, got %s (%d)", export.Status, export.StatusCode())
+	}
+
+	again, _ := service.RequestDataExport(ctx, "u1", true)
+	if again.ID != export.ID || len(store.order) != 1 {
+		t.Errorf("Expected the pending export to be reused, got %s and %d exports", again.ID, len(store.order))
+	}
+}
+
+func TestDataExportWorker_BuildsArchive(t *testing.T) {
+	service, store, storage, notifier := newDataExportTestService()
+	ctx := context.Background()
+
+	export, _ := service.RequestDataExport(ctx, "u1", false)
+	service.dataExports.processQueued(ctx)
+
+	built := store.exports[export.ID]
+	if built.Status != DataExportStatusReady || built.FileSize == 0 {
+		t.Fatalf("Expected a ready export, got %+v", built)
+	}
+	if len(notifier.ready) != 1 || !strings.Contains(notifier.ready[0], "/takeout/u1/"+export.ID+".zip?signature=valid") {
+		t.Errorf("Expected a signed download link to be sent, got %v", notifier.ready)
+	}
+
+	files := readDataExportArchive(t, storage.objects[built.ObjectKey])
+	var manifest dataExportManifest
+	if err := json.Unmarshal(files[dataExportManifestName], &manifest); err != nil || len(manifest.Files) != 4 {
+		t.Fatalf("Expected a manifest listing 4 files, got %+v: %v", manifest, err)
+	}
+	var profile UserProfile
+	if err := json.Unmarshal(files["profile.json"], &profile); err != nil || profile.Phone != "+989121234567" {
+		t.Errorf("Expected the profile in the archive, got %s", files["profile.json"])
+	}
+	var payments []map[string]interface{}
+	if err := json.Unmarshal(files["payments.json"], &payments); err != nil || len(payments) != 1 {
+		t.Errorf("Expected the payments in the archive, got %s", files["payments.json"])
+	}
+	if !bytes.Contains(files["notification_preferences.json"], []byte(`"smsEnabled": true`)) {
+		t.Errorf("Expected the notification preferences in the archive, got %s", files["notification_preferences.json"])
+	}
+
+	ready, _ := service.RequestDataExport(ctx, "u1", false)
+	if ready.ID != export.ID || ready.DownloadURL == "" || ready.StatusCode() != http.StatusOK {
+		t.Errorf("Expected the ready export with a fresh link, got %+v", ready)
+	}
+	if refreshed, _ := service.RequestDataExport(ctx, "u1", true); refreshed.ID == export.ID {
+		t.Error("Expected refresh to queue a new export")
+	}
+}
+
+func TestDataExportWorker_Fails(t *testing.T) {
+	service, store, _, notifier := newDataExportTestService()
+	ctx := context.Background()
+
+	// The profile of an unknown user can't be read
+	export, _ := service.RequestDataExport(ctx, "missing", false)
+	service.dataExports.processQueued(ctx)
+
+	if failed := store.exports[export.ID]; failed.Status != DataExportStatusFailed || failed.Error == nil {
+		t.Errorf("Expected the export to fail, got %+v", failed)
+	}
+	if len(notifier.failed) != 1 || len(notifier.ready) != 0 {
+		t.Errorf("Expected a failure notification, got %v and %v", notifier.failed, notifier.ready)
+	}
+}
+
+func TestOpenDataExportDownload(t *testing.T) {
+	service, store, _, _ := newDataExportTestService()
+	ctx := context.Background()
+
+	export, _ := service.RequestDataExport(ctx, "u1", false)
+	service.dataExports.processQueued(ctx)
+	key := store.exports[export.ID].ObjectKey
+
+	if _, err := service.OpenDataExportDownload(ctx, key, url.Values{"signature": {"forged"}}); common.FromError(err, http.StatusInternalServerError).Status != http.StatusForbidden {
+		t.Errorf("Expected a forged link to be forbidden, got %v", err)
+	}
+	data, err := service.OpenDataExportDownload(ctx, key, url.Values{"signature": {"valid"}})
+	if err != nil || len(data) == 0 {
+		t.Fatalf("Expected the archive, got %d bytes: %v", len(data), err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	store.exports[export.ID].ExpiresAt = &expired
+	if _, err := service.OpenDataExportDownload(ctx, key, url.Values{"signature": {"valid"}}); !errors.Is(err, ErrDataExportNotFound) {
+		t.Errorf("Expected an expired export to be gone, got %v", err)
+	}
+	if _, err := service.OpenDataExportDownload(ctx, "exports/u1/"+export.ID+".zip", url.Values{"signature": {"valid"}}); !errors.Is(err, ErrDataExportNotFound) {
+		t.Errorf("Expected a conversion export key to be rejected, got %v", err)
+	}
+}
+
+func TestDataExports_Unavailable(t *testing.T) {
+	service := NewService(NewMockStore(), NewMockAuditLogger())
+	if _, err := service.RequestDataExport(context.Background(), "u1", false); !errors.Is(err, ErrDataExportsUnavailable) {
+		t.Errorf("Expected ErrDataExportsUnavailable, got %v", err)
+	}
+}
@@ -2,24 +2,12 @@ package user
 
 import (
 	"time"
+
+	"ai-styler/internal/domain"
 )
 
 // UserProfile represents a user's profile information
-type UserProfile struct {
-	ID                   string     `json:"id"`
-	Phone                string     `json:"phone"`
-	Name                 *string    `json:"name,omitempty"`
-	AvatarURL            *string    `json:"avatarUrl,omitempty"`
-	Bio                  *string    `json:"bio,omitempty"`
-	Role                 string     `json:"role"`
-	IsPhoneVerified      bool       `json:"isPhoneVerified"`
-	IsActive             bool       `json:"isActive"`
-	LastLoginAt          *time.Time `json:"lastLoginAt,omitempty"`
-	FreeConversionsUsed  int        `json:"freeConversionsUsed"`
-	FreeConversionsLimit int        `json:"freeConversionsLimit"`
-	CreatedAt            time.Time  `json:"createdAt"`
-	UpdatedAt            time.Time  `json:"updatedAt"`
-}
+type UserProfile = domain.User
 
 // UserConversion represents a conversion activity
 type UserConversion struct {
@@ -129,16 +117,16 @@ const (
 
 // Conversion type constants
 const (
-	ConversionTypeFree = "free"
-	ConversionTypePaid = "paid"
+	ConversionTypeFree = domain.ConversionTypeFree
+	ConversionTypePaid = domain.ConversionTypePaid
 )
 
 // Conversion status constants
 const (
-	ConversionStatusPending    = "pending"
-	ConversionStatusProcessing = "processing"
-	ConversionStatusCompleted  = "completed"
-	ConversionStatusFailed     = "failed"
+	ConversionStatusPending    = domain.ConversionStatusPending
+	ConversionStatusProcessing = domain.ConversionStatusProcessing
+	ConversionStatusCompleted  = domain.ConversionStatusCompleted
+	ConversionStatusFailed     = domain.ConversionStatusFailed
 )
 
 // Plan status constants
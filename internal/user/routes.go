@@ -18,6 +18,19 @@ func MountRoutes(r *gin.RouterGroup, handler *Handler) {
 		userGroup.GET("/profile", common.GinWrap(handler.GetProfile))
 		userGroup.PUT("/profile", common.GinWrap(handler.UpdateProfile))
 	}
+
+	// Takeout export of the user's account data (protected)
+	meGroup := r.Group("/users/me")
+	meGroup.Use(authenticateMiddleware())
+	{
+		common.Mount(meGroup, http.MethodGet, "/export", handler.ExportDataEndpoint())
+	}
+}
+
+// SetupExportDownloadRoutes mounts the download of data export archives,
+// which is authorized by the signed link sent to the user instead of a token
+func SetupExportDownloadRoutes(r *gin.RouterGroup, handler *Handler) {
+	r.GET("/users/exports/download/*key", handler.DownloadDataExport)
 }
 
 // authenticateMiddleware provides authentication middleware for user routes
@@ -27,12 +40,7 @@ func authenticateMiddleware() gin.HandlerFunc {
 		// UserContext middleware extracts user ID from Gin context and sets it in Go context
 		userID := common.GetUserIDFromContext(c.Request.Context())
 		if userID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "unauthorized",
-					"message": "user not authenticated",
-				},
-			})
+			common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
 			c.Abort()
 			return
 		}
@@ -13,6 +13,7 @@ import (
 type Service struct {
 	store       Store
 	auditLogger AuditLogger
+	dataExports *dataExporter
 }
 
 // NewService creates a new user service
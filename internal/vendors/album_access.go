@@ -0,0 +1,421 @@
+package vendors
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// Limits of album invites
+const (
+	maxAlbumInviteUses     = 1000
+	maxAlbumInviteLifetime = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrAlbumShareNotFound is returned when the album is not shared with the user
+	ErrAlbumShareNotFound = fmt.Errorf("album share %w", common.ErrNotFound)
+
+	// ErrAlbumInviteNotFound is returned for invites that are not of the album
+	ErrAlbumInviteNotFound = fmt.Errorf("album invite %w", common.ErrNotFound)
+
+	// ErrAlbumInviteInvalid is returned for invite tokens that are unknown,
+	// revoked, expired or used up
+	ErrAlbumInviteInvalid = fmt.Errorf("album invite is invalid or has expired: %w", common.ErrNotFound)
+
+	// ErrShareUserNotFound is returned when sharing with a user that does not exist
+	ErrShareUserNotFound = fmt.Errorf("%w: user does not exist", common.ErrValidation)
+)
+
+// AlbumShare grants a user access to a private album
+type AlbumShare struct {
+	UserID    string    `json:"user_id"`
+	InviteID  *string   `json:"invite_id,omitempty"` // set when the user accepted an invite
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AlbumInvite is a link that shares an album with whoever accepts it. Its
+// token is only returned when the invite is created.
+type AlbumInvite struct {
+	ID        string     `json:"id"`
+	Token     string     `json:"token,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AlbumAccessResponse lists who an album is shared with and its invites
+type AlbumAccessResponse struct {
+	Shares  []AlbumShare  `json:"shares"`
+	Invites []AlbumInvite `json:"invites"`
+}
+
+// ShareAlbumRequest shares an album with a user account
+type ShareAlbumRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// CreateAlbumInviteRequest creates an invite link. Without max_uses the link
+// can be used until it expires or is revoked; without expires_in_hours it
+// does not expire.
+type CreateAlbumInviteRequest struct {
+	MaxUses        *int `json:"max_uses"`
+	ExpiresInHours *int `json:"expires_in_hours"`
+}
+
+// SharedAlbum is the album an accepted invite gave access to
+type SharedAlbum struct {
+	AlbumID  string `json:"album_id"`
+	VendorID string `json:"vendor_id"`
+	Name     string `json:"name"`
+}
+
+// AlbumAccessStore defines the album sharing queries of the vendor store.
+// Methods taking a vendorID fail with ErrAlbumNotFound for albums of other vendors.
+type AlbumAccessStore interface {
+	ListAlbumShares(ctx context.Context, vendorID, albumID string) ([]AlbumShare, error)
+	ListAlbumInvites(ctx context.Context, vendorID, albumID string) ([]AlbumInvite, error)
+	ShareAlbum(ctx context.Context, vendorID, albumID, userID, sharedBy string) (*AlbumShare, error)
+	UnshareAlbum(ctx context.Context, vendorID, albumID, userID string) error
+	CreateAlbumInvite(ctx context.Context, vendorID, albumID, tokenHash, createdBy string, maxUses *int, expiresAt *time.Time) (*AlbumInvite, error)
+	RevokeAlbumInvite(ctx context.Context, vendorID, albumID, inviteID string) error
+
+	// AcceptAlbumInvite shares the invite's album with userID. A use of the
+	// invite is only counted when the album was not shared with them yet.
+	AcceptAlbumInvite(ctx context.Context, tokenHash, userID string) (*SharedAlbum, error)
+}
+
+// ListAlbumAccess returns who a vendor album is shared with and its invites
+func (s *service) ListAlbumAccess(ctx context.Context, curator Curator, vendorID, albumID string) (AlbumAccessResponse, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return AlbumAccessResponse{}, err
+	}
+
+	shares, err := s.store.ListAlbumShares(ctx, vendorID, albumID)
+	if err != nil {
+		return AlbumAccessResponse{}, err
+	}
+	invites, err := s.store.ListAlbumInvites(ctx, vendorID, albumID)
+	if err != nil {
+		return AlbumAccessResponse{}, err
+	}
+
+	if shares == nil {
+		shares = []AlbumShare{}
+	}
+	if invites == nil {
+		invites = []AlbumInvite{}
+	}
+	return AlbumAccessResponse{Shares: shares, Invites: invites}, nil
+}
+
+// ShareAlbum gives a user access to a vendor album
+func (s *service) ShareAlbum(ctx context.Context, curator Curator, vendorID, albumID string, req ShareAlbumRequest) (*AlbumShare, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return nil, err
+	}
+
+	userID := strings.TrimSpace(req.UserID)
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", common.ErrValidation)
+	}
+	return s.store.ShareAlbum(ctx, vendorID, albumID, userID, curator.UserID)
+}
+
+// UnshareAlbum takes a user's access to a vendor album away
+func (s *service) UnshareAlbum(ctx context.Context, curator Curator, vendorID, albumID, userID string) error {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return err
+	}
+	return s.store.UnshareAlbum(ctx, vendorID, albumID, userID)
+}
+
+// CreateAlbumInvite creates an invite link to a vendor album
+func (s *service) CreateAlbumInvite(ctx context.Context, curator Curator, vendorID, albumID string, req CreateAlbumInviteRequest) (*AlbumInvite, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return nil, err
+	}
+
+	if req.MaxUses != nil && (*req.MaxUses <= 0 || *req.MaxUses > maxAlbumInviteUses) {
+		return nil, fmt.Errorf("%w: max_uses must be from 1 to %d", common.ErrValidation, maxAlbumInviteUses)
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours != nil {
+		lifetime := time.Duration(*req.ExpiresInHours) * time.Hour
+		if lifetime <= 0 || lifetime > maxAlbumInviteLifetime {
+			return nil, fmt.Errorf("%w: expires_in_hours must be from 1 to %d", common.ErrValidation, int(maxAlbumInviteLifetime.Hours()))
+		}
+		expires := time.Now().Add(lifetime)
+		expiresAt = &expires
+	}
+
+	token, err := newAlbumInviteToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	invite, err := s.store.CreateAlbumInvite(ctx, vendorID, albumID, hashAlbumInviteToken(token), curator.UserID, req.MaxUses, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	invite.Token = token
+	return invite, nil
+}
+
+// RevokeAlbumInvite stops an invite from being accepted. Users who already
+// accepted it keep their access until they are unshared.
+func (s *service) RevokeAlbumInvite(ctx context.Context, curator Curator, vendorID, albumID, inviteID string) error {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return err
+	}
+	return s.store.RevokeAlbumInvite(ctx, vendorID, albumID, inviteID)
+}
+
+// AcceptAlbumInvite shares the album of an invite with the caller
+func (s *service) AcceptAlbumInvite(ctx context.Context, userID, token string) (*SharedAlbum, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user is required", common.ErrValidation)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrAlbumInviteInvalid
+	}
+	return s.store.AcceptAlbumInvite(ctx, hashAlbumInviteToken(token), userID)
+}
+
+// newAlbumInviteToken returns a random URL-safe invite token
+func newAlbumInviteToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAlbumInviteToken returns the stored form of an invite token
+func hashAlbumInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// albumOfVendor fails with ErrAlbumNotFound unless the album belongs to the vendor
+func albumOfVendor(ctx context.Context, db *sql.DB, vendorID, albumID string) error {
+	var exists bool
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM albums WHERE id = $1 AND vendor_id = $2)`, albumID, vendorID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check album: %w", err)
+	}
+	if !exists {
+		return ErrAlbumNotFound
+	}
+	return nil
+}
+
+// ListAlbumShares returns the users an album is shared with, newest first
+func (s *store) ListAlbumShares(ctx context.Context, vendorID, albumID string) ([]AlbumShare, error) {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, invite_id, created_at
+		FROM album_shares
+		WHERE album_id = $1
+		ORDER BY created_at DESC
+	`, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query album shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []AlbumShare
+	for rows.Next() {
+		var share AlbumShare
+		if err := rows.Scan(&share.UserID, &share.InviteID, &share.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan album share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// ListAlbumInvites returns the invites of an album, newest first
+func (s *store) ListAlbumInvites(ctx context.Context, vendorID, albumID string) ([]AlbumInvite, error) {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, max_uses, uses, expires_at, revoked_at, created_at
+		FROM album_invites
+		WHERE album_id = $1
+		ORDER BY created_at DESC
+	`, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query album invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []AlbumInvite
+	for rows.Next() {
+		var invite AlbumInvite
+		if err := rows.Scan(&invite.ID, &invite.MaxUses, &invite.Uses, &invite.ExpiresAt,
+			&invite.RevokedAt, &invite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan album invite: %w", err)
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// ShareAlbum shares an album with a user; sharing it again changes nothing
+func (s *store) ShareAlbum(ctx context.Context, vendorID, albumID, userID, sharedBy string) (*AlbumShare, error) {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return nil, err
+	}
+
+	var share AlbumShare
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO album_shares (album_id, user_id, created_by)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		ON CONFLICT (album_id, user_id) DO UPDATE SET album_id = EXCLUDED.album_id
+		RETURNING user_id, invite_id, created_at
+	`, albumID, userID, sharedBy).Scan(&share.UserID, &share.InviteID, &share.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && (pqErr.Code == "23503" || pqErr.Code == "22P02") {
+			return nil, ErrShareUserNotFound
+		}
+		return nil, fmt.Errorf("failed to share album: %w", err)
+	}
+	return &share, nil
+}
+
+// UnshareAlbum removes a user's share of an album
+func (s *store) UnshareAlbum(ctx context.Context, vendorID, albumID, userID string) error {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM album_shares WHERE album_id = $1 AND user_id::text = $2`, albumID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unshare album: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAlbumShareNotFound
+	}
+	return nil
+}
+
+// CreateAlbumInvite stores an invite of an album by the hash of its token
+func (s *store) CreateAlbumInvite(ctx context.Context, vendorID, albumID, tokenHash, createdBy string, maxUses *int, expiresAt *time.Time) (*AlbumInvite, error) {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return nil, err
+	}
+
+	invite := AlbumInvite{MaxUses: maxUses, ExpiresAt: expiresAt}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO album_invites (album_id, token_hash, max_uses, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid)
+		RETURNING id, created_at
+	`, albumID, tokenHash, maxUses, expiresAt, createdBy).Scan(&invite.ID, &invite.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create album invite: %w", err)
+	}
+	return &invite, nil
+}
+
+// RevokeAlbumInvite marks an invite of an album revoked
+func (s *store) RevokeAlbumInvite(ctx context.Context, vendorID, albumID, inviteID string) error {
+	if err := albumOfVendor(ctx, s.db, vendorID, albumID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE album_invites SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id::text = $1 AND album_id = $2
+	`, inviteID, albumID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke album invite: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAlbumInviteNotFound
+	}
+	return nil
+}
+
+// AcceptAlbumInvite shares the album of a usable invite with userID, holding
+// the invite row lock so concurrent accepts cannot exceed max_uses
+func (s *store) AcceptAlbumInvite(ctx context.Context, tokenHash, userID string) (*SharedAlbum, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inviteID string
+	var album SharedAlbum
+	err = tx.QueryRowContext(ctx, `
+		SELECT i.id, a.id, a.vendor_id, a.name
+		FROM album_invites i
+		JOIN albums a ON a.id = i.album_id
+		WHERE i.token_hash = $1
+		  AND i.revoked_at IS NULL
+		  AND (i.expires_at IS NULL OR i.expires_at > NOW())
+		  AND (i.max_uses IS NULL OR i.uses < i.max_uses)
+		FOR UPDATE OF i
+	`, tokenHash).Scan(&inviteID, &album.AlbumID, &album.VendorID, &album.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAlbumInviteInvalid
+		}
+		return nil, fmt.Errorf("failed to get album invite: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO album_shares (album_id, user_id, invite_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (album_id, user_id) DO NOTHING
+	`, album.AlbumID, userID, inviteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share album: %w", err)
+	}
+	shared, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if shared > 0 {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE album_invites SET uses = uses + 1 WHERE id = $1`, inviteID); err != nil {
+			return nil, fmt.Errorf("failed to count album invite use: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit album invite: %w", err)
+	}
+	return &album, nil
+}
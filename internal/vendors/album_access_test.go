@@ -0,0 +1,229 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAlbumAccessStore keeps the shares and invites of the fake vendor's
+// albums in memory
+type fakeAlbumAccessStore struct {
+	*fakeCurationStore
+	shares  map[string][]AlbumShare // by album ID
+	invites map[string]*AlbumInvite // by token hash
+	albumOf map[string]string       // album ID by token hash
+}
+
+func newFakeAlbumAccessStore() *fakeAlbumAccessStore {
+	return &fakeAlbumAccessStore{
+		fakeCurationStore: newFakeCurationStore(),
+		shares:            map[string][]AlbumShare{},
+		invites:           map[string]*AlbumInvite{},
+		albumOf:           map[string]string{},
+	}
+}
+
+func (f *fakeAlbumAccessStore) ListAlbumShares(ctx context.Context, vendorID, albumID string) ([]AlbumShare, error) {
+	if f.album(albumID) == nil {
+		return nil, ErrAlbumNotFound
+	}
+	return f.shares[albumID], nil
+}
+
+func (f *fakeAlbumAccessStore) ListAlbumInvites(ctx context.Context, vendorID, albumID string) ([]AlbumInvite, error) {
+	if f.album(albumID) == nil {
+		return nil, ErrAlbumNotFound
+	}
+	var invites []AlbumInvite
+	for hash, invite := range f.invites {
+		if f.albumOf[hash] == albumID {
+			invites = append(invites, *invite)
+		}
+	}
+	return invites, nil
+}
+
+func (f *fakeAlbumAccessStore) ShareAlbum(ctx context.Context, vendorID, albumID, userID, sharedBy string) (*AlbumShare, error) {
+	if f.album(albumID) == nil {
+		return nil, ErrAlbumNotFound
+	}
+	for _, share := range f.shares[albumID] {
+		if share.UserID == userID {
+			return &share, nil
+		}
+	}
+	share := AlbumShare{UserID: userID, CreatedAt: time.Now()}
+	f.shares[albumID] = append(f.shares[albumID], share)
+	return &share, nil
+}
+
+func (f *fakeAlbumAccessStore) UnshareAlbum(ctx context.Context, vendorID, albumID, userID string) error {
+	shares := f.shares[albumID]
+	for i, share := range shares {
+		if share.UserID == userID {
+			f.shares[albumID] = append(shares[:i], shares[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAlbumShareNotFound
+}
+
+func (f *fakeAlbumAccessStore) CreateAlbumInvite(ctx context.Context, vendorID, albumID, tokenHash, createdBy string, maxUses *int, expiresAt *time.Time) (*AlbumInvite, error) {
+	if f.album(albumID) == nil {
+		return nil, ErrAlbumNotFound
+	}
+	invite := &AlbumInvite{ID: "inv-" + tokenHash[:8], MaxUses: maxUses, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+	f.invites[tokenHash] = invite
+	f.albumOf[tokenHash] = albumID
+	copied := *invite
+	return &copied, nil
+}
+
+func (f *fakeAlbumAccessStore) RevokeAlbumInvite(ctx context.Context, vendorID, albumID, inviteID string) error {
+	for hash, invite := range f.invites {
+		if invite.ID == inviteID && f.albumOf[hash] == albumID {
+			now := time.Now()
+			invite.RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrAlbumInviteNotFound
+}
+
+func (f *fakeAlbumAccessStore) AcceptAlbumInvite(ctx context.Context, tokenHash, userID string) (*SharedAlbum, error) {
+	invite, exists := f.invites[tokenHash]
+	if !exists || invite.RevokedAt != nil || (invite.MaxUses != nil && invite.Uses >= *invite.MaxUses) {
+		return nil, ErrAlbumInviteInvalid
+	}
+	albumID := f.albumOf[tokenHash]
+	before := len(f.shares[albumID])
+	f.ShareAlbum(ctx, f.vendor.ID, albumID, userID, "")
+	if len(f.shares[albumID]) > before {
+		invite.Uses++
+	}
+	return &SharedAlbum{AlbumID: albumID, VendorID: f.vendor.ID, Name: f.album(albumID).Name}, nil
+}
+
+func TestCreateAlbumInvite(t *testing.T) {
+	store := newFakeAlbumAccessStore()
+	service := NewService(store)
+	owner := Curator{UserID: "owner"}
+	ctx := context.Background()
+
+	two := 2
+	invite, err := service.CreateAlbumInvite(ctx, owner, "v1", "a1", CreateAlbumInviteRequest{MaxUses: &two})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if invite.Token == "" {
+		t.Fatal("Expected the token to be returned on creation")
+	}
+	if _, stored := store.invites[hashAlbumInviteToken(invite.Token)]; !stored {
+		t.Error("Expected the invite to be stored by the hash of its token")
+	}
+
+	album, err := service.AcceptAlbumInvite(ctx, "guest", invite.Token)
+	if err != nil || album.AlbumID != "a1" {
+		t.Fatalf("Expected invite to share a1, got %+v, %v", album, err)
+	}
+	// Accepting again does not use the invite up
+	if _, err := service.AcceptAlbumInvite(ctx, "guest", invite.Token); err != nil {
+		t.Errorf("Expected accepting twice to succeed, got %v", err)
+	}
+	if _, err := service.AcceptAlbumInvite(ctx, "another", invite.Token); err != nil {
+		t.Errorf("Expected the second use to succeed, got %v", err)
+	}
+	if _, err := service.AcceptAlbumInvite(ctx, "third", invite.Token); !errors.Is(err, ErrAlbumInviteInvalid) {
+		t.Errorf("Expected a used up invite to be invalid, got %v", err)
+	}
+	if _, err := service.AcceptAlbumInvite(ctx, "guest", "unknown"); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected an unknown token to be not found, got %v", err)
+	}
+
+	zero, tooLong := 0, 24*365
+	for name, req := range map[string]CreateAlbumInviteRequest{
+		"no uses":  {MaxUses: &zero},
+		"too long": {ExpiresInHours: &tooLong},
+		"expired":  {ExpiresInHours: &zero},
+	} {
+		if _, err := service.CreateAlbumInvite(ctx, owner, "v1", "a1", req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	if _, err := service.CreateAlbumInvite(ctx, Curator{UserID: "someone"}, "v1", "a1", CreateAlbumInviteRequest{}); err != ErrCurationForbidden {
+		t.Errorf("Expected ErrCurationForbidden for another user, got %v", err)
+	}
+}
+
+func TestAlbumAccessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newFakeAlbumAccessStore()
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	MountRoutes(r.Group("/api"), NewHandler(NewService(store)))
+
+	request := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/api/vendors/v1/albums/a2/invites", "owner", `{"expires_in_hours":24}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var invite AlbumInvite
+	if err := json.Unmarshal(w.Body.Bytes(), &invite); err != nil || invite.Token == "" {
+		t.Fatalf("Expected an invite with its token, got %s", w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		user     string
+		body     string
+		expected int
+	}{
+		{"share", http.MethodPost, "/api/vendors/v1/albums/a1/shares", "owner", `{"user_id":"friend"}`, http.StatusOK},
+		{"share without user", http.MethodPost, "/api/vendors/v1/albums/a1/shares", "owner", `{}`, http.StatusBadRequest},
+		{"share another vendor's album", http.MethodPost, "/api/vendors/v1/albums/a1/shares", "someone", `{"user_id":"friend"}`, http.StatusForbidden},
+		{"share unknown album", http.MethodPost, "/api/vendors/v1/albums/missing/shares", "owner", `{"user_id":"friend"}`, http.StatusNotFound},
+		{"list access", http.MethodGet, "/api/vendors/v1/albums/a1/access", "owner", "", http.StatusOK},
+		{"unshare", http.MethodDelete, "/api/vendors/v1/albums/a1/shares/friend", "owner", "", http.StatusNoContent},
+		{"unshare again", http.MethodDelete, "/api/vendors/v1/albums/a1/shares/friend", "owner", "", http.StatusNotFound},
+		{"accept invite", http.MethodPost, "/api/album-invites/" + invite.Token + "/accept", "guest", "", http.StatusOK},
+		{"accept invite signed out", http.MethodPost, "/api/album-invites/" + invite.Token + "/accept", "", "", http.StatusUnauthorized},
+		{"revoke invite", http.MethodDelete, "/api/vendors/v1/albums/a2/invites/" + invite.ID, "owner", "", http.StatusNoContent},
+		{"accept revoked invite", http.MethodPost, "/api/album-invites/" + invite.Token + "/accept", "late", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(tt.method, tt.path, tt.user, tt.body); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if shares := store.shares["a2"]; len(shares) != 1 || shares[0].UserID != "guest" {
+		t.Errorf("Expected a2 to be shared with guest only, got %+v", shares)
+	}
+}
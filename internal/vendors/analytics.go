@@ -0,0 +1,248 @@
+package vendors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+var (
+	// ErrVendorNotFound is returned when the caller has no vendor profile
+	ErrVendorNotFound = fmt.Errorf("vendor %w", common.ErrNotFound)
+
+	// ErrInvalidAnalyticsRange is returned for ranges other than AnalyticsRanges
+	ErrInvalidAnalyticsRange = fmt.Errorf("%w: range must be one of 24h, 7d, 30d or 90d", common.ErrValidation)
+)
+
+// AnalyticsRanges are the selectable analytics time ranges
+var AnalyticsRanges = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+const (
+	// DefaultAnalyticsRange is used when no range is requested
+	DefaultAnalyticsRange = "30d"
+
+	// trendingLimit caps the number of trending images
+	trendingLimit = 5
+)
+
+// AnalyticsRequest selects the time range of the vendor analytics
+type AnalyticsRequest struct {
+	Range string
+}
+
+// ImageAnalytics is the usage of one vendor garment image in the range
+type ImageAnalytics struct {
+	ImageID        string  `json:"image_id"`
+	URL            string  `json:"url"`
+	ThumbnailURL   *string `json:"thumbnail_url,omitempty"`
+	Conversions    int     `json:"conversions"`
+	UniqueUsers    int     `json:"unique_users"`
+	Completed      int     `json:"completed"`
+	Failed         int     `json:"failed"`
+	CompletionRate float64 `json:"completion_rate"`
+	// PreviousConversions counts conversions in the range of equal length
+	// right before this one, Growth is the change between the two
+	PreviousConversions int `json:"previous_conversions"`
+	Growth              int `json:"growth"`
+}
+
+// AnalyticsTotals sums the usage of all vendor images in the range
+type AnalyticsTotals struct {
+	Conversions    int     `json:"conversions"`
+	UniqueUsers    int     `json:"unique_users"`
+	Completed      int     `json:"completed"`
+	Failed         int     `json:"failed"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// VendorAnalytics reports how often a vendor's garments were used for try-ons
+type VendorAnalytics struct {
+	VendorID string           `json:"vendor_id"`
+	Range    string           `json:"range"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Totals   AnalyticsTotals  `json:"totals"`
+	Images   []ImageAnalytics `json:"images"`
+	Trending []ImageAnalytics `json:"trending"`
+}
+
+// AnalyticsStore defines the usage queries of the vendor store. Usage is
+// counted from the conversions with a vendor image among their garments.
+type AnalyticsStore interface {
+	GetVendorByUserID(ctx context.Context, userID string) (*Vendor, error)
+	// ListImageAnalytics returns the usage of the vendor's images between from
+	// and to, with PreviousConversions counted between previousFrom and from
+	ListImageAnalytics(ctx context.Context, vendorID string, previousFrom, from, to time.Time) ([]ImageAnalytics, error)
+	// CountUniqueUsers counts the users who tried on any vendor image between from and to
+	CountUniqueUsers(ctx context.Context, vendorID string, from, to time.Time) (int, error)
+}
+
+// GetMyAnalytics returns the garment usage analytics of the vendor owned by userID
+func (s *service) GetMyAnalytics(ctx context.Context, userID string, req AnalyticsRequest) (*VendorAnalytics, error) {
+	if req.Range == "" {
+		req.Range = DefaultAnalyticsRange
+	}
+	length, ok := AnalyticsRanges[req.Range]
+	if !ok {
+		return nil, ErrInvalidAnalyticsRange
+	}
+	if userID == "" {
+		return nil, ErrVendorNotFound
+	}
+
+	vendor, err := s.store.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-length)
+	images, err := s.store.ListImageAnalytics(ctx, vendor.ID, from.Add(-length), from, to)
+	if err != nil {
+		return nil, err
+	}
+	uniqueUsers, err := s.store.CountUniqueUsers(ctx, vendor.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &VendorAnalytics{
+		VendorID: vendor.ID,
+		Range:    req.Range,
+		From:     from,
+		To:       to,
+		Totals:   AnalyticsTotals{UniqueUsers: uniqueUsers},
+		Images:   []ImageAnalytics{},
+		Trending: trendingImages(images),
+	}
+	for _, image := range images {
+		if image.Conversions == 0 {
+			// Only used in the previous range, kept for trending alone
+			continue
+		}
+		analytics.Images = append(analytics.Images, image)
+		analytics.Totals.Conversions += image.Conversions
+		analytics.Totals.Completed += image.Completed
+		analytics.Totals.Failed += image.Failed
+	}
+	analytics.Totals.CompletionRate = completionRate(analytics.Totals.Completed, analytics.Totals.Conversions)
+	return analytics, nil
+}
+
+// trendingImages returns the images whose usage grew the most over the
+// previous range, fills in Growth and CompletionRate as a side effect
+func trendingImages(images []ImageAnalytics) []ImageAnalytics {
+	trending := []ImageAnalytics{}
+	for i := range images {
+		images[i].Growth = images[i].Conversions - images[i].PreviousConversions
+		images[i].CompletionRate = completionRate(images[i].Completed, images[i].Conversions)
+		if images[i].Growth > 0 {
+			trending = append(trending, images[i])
+		}
+	}
+	sort.SliceStable(trending, func(i, j int) bool {
+		if trending[i].Growth != trending[j].Growth {
+			return trending[i].Growth > trending[j].Growth
+		}
+		return trending[i].Conversions > trending[j].Conversions
+	})
+	if len(trending) > trendingLimit {
+		trending = trending[:trendingLimit]
+	}
+	return trending
+}
+
+// completionRate is the share of conversions that completed, between 0 and 1
+func completionRate(completed, conversions int) float64 {
+	if conversions == 0 {
+		return 0
+	}
+	return float64(completed) / float64(conversions)
+}
+
+// GetVendorByUserID retrieves the vendor owned by a user
+func (s *store) GetVendorByUserID(ctx context.Context, userID string) (*Vendor, error) {
+	query := `
+		SELECT id, user_id, slug, display_name, company_name, status, created_at, updated_at
+		FROM vendors
+		WHERE user_id = $1
+	`
+
+	var vendor Vendor
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&vendor.ID, &vendor.UserID, &vendor.Slug,
+		&vendor.DisplayName, &vendor.CompanyName, &vendor.Status, &vendor.CreatedAt, &vendor.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVendorNotFound
+		}
+		return nil, fmt.Errorf("failed to get vendor: %w", err)
+	}
+	return &vendor, nil
+}
+
+// ListImageAnalytics counts the conversions of each vendor image in a single
+// scan over both ranges, most used images first
+func (s *store) ListImageAnalytics(ctx context.Context, vendorID string, previousFrom, from, to time.Time) ([]ImageAnalytics, error) {
+	query := `
+		SELECT i.id, i.original_url, i.thumbnail_url,
+		       COUNT(*) FILTER (WHERE c.created_at >= $3),
+		       COUNT(DISTINCT c.user_id) FILTER (WHERE c.created_at >= $3),
+		       COUNT(*) FILTER (WHERE c.created_at >= $3 AND c.status = 'completed'),
+		       COUNT(*) FILTER (WHERE c.created_at >= $3 AND c.status = 'failed'),
+		       COUNT(*) FILTER (WHERE c.created_at < $3)
+		FROM conversions c
+		LEFT JOIN conversion_garments g ON g.conversion_id = c.id
+		JOIN images i ON i.id = COALESCE(g.image_id, c.cloth_image_id)
+		WHERE i.vendor_id = $1 AND c.created_at >= $2 AND c.created_at < $4
+		GROUP BY i.id, i.original_url, i.thumbnail_url
+		ORDER BY 4 DESC, i.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, vendorID, previousFrom, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image analytics: %w", err)
+	}
+	defer rows.Close()
+
+	var images []ImageAnalytics
+	for rows.Next() {
+		var image ImageAnalytics
+		if err := rows.Scan(&image.ImageID, &image.URL, &image.ThumbnailURL, &image.Conversions,
+			&image.UniqueUsers, &image.Completed, &image.Failed, &image.PreviousConversions); err != nil {
+			return nil, fmt.Errorf("failed to scan image analytics: %w", err)
+		}
+		images = append(images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating image analytics: %w", err)
+	}
+	return images, nil
+}
+
+// CountUniqueUsers counts distinct users across all vendor images, which the
+// per-image counts cannot be summed into
+func (s *store) CountUniqueUsers(ctx context.Context, vendorID string, from, to time.Time) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT c.user_id)
+		FROM conversions c
+		LEFT JOIN conversion_garments g ON g.conversion_id = c.id
+		JOIN images i ON i.id = COALESCE(g.image_id, c.cloth_image_id)
+		WHERE i.vendor_id = $1 AND c.created_at >= $2 AND c.created_at < $3
+	`
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, vendorID, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unique users: %w", err)
+	}
+	return count, nil
+}
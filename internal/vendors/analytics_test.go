@@ -0,0 +1,159 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAnalyticsStore serves fixed usage for the vendor owned by "owner" and
+// records the requested windows
+type fakeAnalyticsStore struct {
+	Store
+	images       []ImageAnalytics
+	uniqueUsers  int
+	previousFrom time.Time
+	from         time.Time
+	to           time.Time
+}
+
+func (f *fakeAnalyticsStore) GetVendorByUserID(ctx context.Context, userID string) (*Vendor, error) {
+	if userID != "owner" {
+		return nil, ErrVendorNotFound
+	}
+	return &Vendor{ID: "v1", UserID: userID}, nil
+}
+
+func (f *fakeAnalyticsStore) ListImageAnalytics(ctx context.Context, vendorID string, previousFrom, from, to time.Time) ([]ImageAnalytics, error) {
+	f.previousFrom, f.from, f.to = previousFrom, from, to
+	images := make([]ImageAnalytics, len(f.images))
+	copy(images, f.images)
+	return images, nil
+}
+
+func (f *fakeAnalyticsStore) CountUniqueUsers(ctx context.Context, vendorID string, from, to time.Time) (int, error) {
+	return f.uniqueUsers, nil
+}
+
+func newFakeAnalyticsStore() *fakeAnalyticsStore {
+	return &fakeAnalyticsStore{
+		images: []ImageAnalytics{
+			{ImageID: "i1", Conversions: 10, UniqueUsers: 6, Completed: 8, Failed: 2, PreviousConversions: 9},
+			{ImageID: "i2", Conversions: 6, UniqueUsers: 4, Completed: 3, Failed: 1, PreviousConversions: 1},
+			{ImageID: "i3", Conversions: 4, UniqueUsers: 4, Completed: 4, PreviousConversions: 6},
+			{ImageID: "i4", Conversions: 0, PreviousConversions: 3},
+		},
+		uniqueUsers: 11,
+	}
+}
+
+func TestGetMyAnalytics(t *testing.T) {
+	store := newFakeAnalyticsStore()
+	service := NewService(store)
+
+	analytics, err := service.GetMyAnalytics(context.Background(), "owner", AnalyticsRequest{Range: "7d"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := store.to.Sub(store.from); got != 7*24*time.Hour {
+		t.Errorf("Expected a 7 day range, got %v", got)
+	}
+	if got := store.from.Sub(store.previousFrom); got != 7*24*time.Hour {
+		t.Errorf("Expected a 7 day previous range, got %v", got)
+	}
+
+	if len(analytics.Images) != 3 {
+		t.Fatalf("Expected 3 images used in the range, got %d", len(analytics.Images))
+	}
+	totals := analytics.Totals
+	if totals.Conversions != 20 || totals.Completed != 15 || totals.Failed != 3 || totals.UniqueUsers != 11 {
+		t.Errorf("Unexpected totals %+v", totals)
+	}
+	if totals.CompletionRate != 0.75 {
+		t.Errorf("Expected completion rate 0.75, got %v", totals.CompletionRate)
+	}
+	if analytics.Images[1].CompletionRate != 0.5 {
+		t.Errorf("Expected image completion rate 0.5, got %v", analytics.Images[1].CompletionRate)
+	}
+
+	if len(analytics.Trending) != 2 {
+		t.Fatalf("Expected 2 trending images, got %d", len(analytics.Trending))
+	}
+	if analytics.Trending[0].ImageID != "i2" || analytics.Trending[0].Growth != 5 {
+		t.Errorf("Expected i2 to trend first with growth 5, got %s with %d", analytics.Trending[0].ImageID, analytics.Trending[0].Growth)
+	}
+	if analytics.Trending[1].ImageID != "i1" {
+		t.Errorf("Expected i1 to trend second, got %s", analytics.Trending[1].ImageID)
+	}
+}
+
+func TestGetMyAnalytics_Errors(t *testing.T) {
+	service := NewService(newFakeAnalyticsStore())
+
+	analytics, err := service.GetMyAnalytics(context.Background(), "owner", AnalyticsRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if analytics.Range != DefaultAnalyticsRange {
+		t.Errorf("Expected default range %s, got %s", DefaultAnalyticsRange, analytics.Range)
+	}
+
+	if _, err := service.GetMyAnalytics(context.Background(), "owner", AnalyticsRequest{Range: "1y"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+	if _, err := service.GetMyAnalytics(context.Background(), "someone", AnalyticsRequest{}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestGetMyAnalyticsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	MountRoutes(r.Group("/api"), NewHandler(NewService(newFakeAnalyticsStore())))
+
+	tests := []struct {
+		name     string
+		path     string
+		user     string
+		expected int
+	}{
+		{"analytics", "/api/vendors/me/analytics?range=90d", "owner", http.StatusOK},
+		{"invalid range", "/api/vendors/me/analytics?range=forever", "owner", http.StatusBadRequest},
+		{"not a vendor", "/api/vendors/me/analytics", "someone", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Test-User", tt.user)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var analytics VendorAnalytics
+			if err := json.Unmarshal(w.Body.Bytes(), &analytics); err != nil {
+				t.Fatalf("Expected analytics JSON, got %v", err)
+			}
+			if analytics.Range != "90d" || analytics.VendorID != "v1" {
+				t.Errorf("Unexpected analytics %+v", analytics)
+			}
+		})
+	}
+}
@@ -0,0 +1,516 @@
+package vendors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCurationForbidden is returned when the caller does not own the vendor
+	ErrCurationForbidden = errors.New("only the vendor owner can curate its albums")
+
+	// ErrCurationConflict is returned when the curated state changed since the
+	// version the request was based on
+	ErrCurationConflict = fmt.Errorf("%w: albums were changed by another request, reload and retry", common.ErrConflict)
+
+	// ErrInvalidOrder is returned when a reorder does not list every item exactly once
+	ErrInvalidOrder = fmt.Errorf("%w: order must list every item exactly once", common.ErrValidation)
+
+	// ErrAlbumNotFound is returned for albums that do not belong to the vendor
+	ErrAlbumNotFound = fmt.Errorf("album %w", common.ErrNotFound)
+
+	// ErrAlbumImageNotFound is returned for images that are not in the album
+	ErrAlbumImageNotFound = fmt.Errorf("album image %w", common.ErrNotFound)
+)
+
+// Curator identifies the caller of a curation request
+type Curator struct {
+	UserID  string
+	IsAdmin bool // admins may curate any vendor
+}
+
+// CuratedAlbum is a vendor album with its curation state
+type CuratedAlbum struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   *string   `json:"description,omitempty"`
+	IsPublic      bool      `json:"is_public"`
+	Position      int       `json:"position"`
+	CoverImageID  *string   `json:"cover_image_id,omitempty"`
+	CoverImageURL *string   `json:"cover_image_url,omitempty"`
+	ImageCount    int       `json:"image_count"`
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CuratedImage is an album image with its curation state
+type CuratedImage struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	IsPublic     bool      `json:"is_public"`
+	Position     int       `json:"position"`
+	IsFeatured   bool      `json:"is_featured"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// VendorAlbumsResponse lists a vendor's albums in display order. Version is
+// sent back with an album reorder.
+type VendorAlbumsResponse struct {
+	Albums  []CuratedAlbum `json:"albums"`
+	Version int            `json:"version"`
+}
+
+// AlbumImagesResponse lists an album's images in display order. Album.Version
+// is sent back with changes to the album's images or cover.
+type AlbumImagesResponse struct {
+	Album  CuratedAlbum   `json:"album"`
+	Images []CuratedImage `json:"images"`
+}
+
+// ReorderRequest sets the display order of every album or image
+type ReorderRequest struct {
+	IDs     []string `json:"ids" binding:"required"`
+	Version *int     `json:"version" binding:"required"`
+}
+
+// SetAlbumCoverRequest sets or, with a null image_id, clears an album cover
+type SetAlbumCoverRequest struct {
+	ImageID *string `json:"image_id"`
+	Version *int    `json:"version" binding:"required"`
+}
+
+// PinImageRequest pins or unpins an image as featured in its album
+type PinImageRequest struct {
+	Pinned  bool `json:"pinned"`
+	Version *int `json:"version" binding:"required"`
+}
+
+// CurationStore defines the album curation queries of the vendor store. The
+// mutating methods fail with ErrCurationConflict unless version is current
+// and return the new version.
+type CurationStore interface {
+	ListCuratedAlbums(ctx context.Context, vendorID string) ([]CuratedAlbum, int, error)
+	ReorderAlbums(ctx context.Context, vendorID string, albumIDs []string, version int) (int, error)
+	GetCuratedAlbum(ctx context.Context, vendorID, albumID string) (*CuratedAlbum, []CuratedImage, error)
+	ReorderAlbumImages(ctx context.Context, vendorID, albumID string, imageIDs []string, version int) (int, error)
+	SetAlbumCover(ctx context.Context, vendorID, albumID string, imageID *string, version int) (int, error)
+	SetImagePinned(ctx context.Context, vendorID, albumID, imageID string, pinned bool, version int) (int, error)
+}
+
+// authorizeCurator checks that curator may curate the albums of vendorID
+func (s *service) authorizeCurator(ctx context.Context, curator Curator, vendorID string) error {
+	if curator.UserID == "" {
+		return ErrCurationForbidden
+	}
+
+	vendor, err := s.loadVendor(ctx, vendorID)
+	if err != nil {
+		return err
+	}
+	if vendor.UserID != curator.UserID && !curator.IsAdmin {
+		return ErrCurationForbidden
+	}
+	return nil
+}
+
+// ListVendorAlbums returns all albums of a vendor in display order
+func (s *service) ListVendorAlbums(ctx context.Context, curator Curator, vendorID string) (VendorAlbumsResponse, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return VendorAlbumsResponse{}, err
+	}
+
+	albums, version, err := s.store.ListCuratedAlbums(ctx, vendorID)
+	if err != nil {
+		return VendorAlbumsResponse{}, err
+	}
+	if albums == nil {
+		albums = []CuratedAlbum{}
+	}
+	return VendorAlbumsResponse{Albums: albums, Version: version}, nil
+}
+
+// ReorderAlbums sets the display order of all albums of a vendor
+func (s *service) ReorderAlbums(ctx context.Context, curator Curator, vendorID string, req ReorderRequest) (VendorAlbumsResponse, error) {
+	if err := validateOrder(req.IDs); err != nil {
+		return VendorAlbumsResponse{}, err
+	}
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return VendorAlbumsResponse{}, err
+	}
+
+	if _, err := s.store.ReorderAlbums(ctx, vendorID, req.IDs, *req.Version); err != nil {
+		return VendorAlbumsResponse{}, err
+	}
+	return s.ListVendorAlbums(ctx, curator, vendorID)
+}
+
+// ListAlbumImages returns an album and its images in display order
+func (s *service) ListAlbumImages(ctx context.Context, curator Curator, vendorID, albumID string) (AlbumImagesResponse, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+
+	album, images, err := s.store.GetCuratedAlbum(ctx, vendorID, albumID)
+	if err != nil {
+		return AlbumImagesResponse{}, err
+	}
+	if images == nil {
+		images = []CuratedImage{}
+	}
+	return AlbumImagesResponse{Album: *album, Images: images}, nil
+}
+
+// ReorderAlbumImages sets the display order of all images of an album.
+// Pinned images stay ahead of the others.
+func (s *service) ReorderAlbumImages(ctx context.Context, curator Curator, vendorID, albumID string, req ReorderRequest) (AlbumImagesResponse, error) {
+	if err := validateOrder(req.IDs); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+
+	if _, err := s.store.ReorderAlbumImages(ctx, vendorID, albumID, req.IDs, *req.Version); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+	return s.ListAlbumImages(ctx, curator, vendorID, albumID)
+}
+
+// SetAlbumCover sets or clears the cover image of an album
+func (s *service) SetAlbumCover(ctx context.Context, curator Curator, vendorID, albumID string, req SetAlbumCoverRequest) (AlbumImagesResponse, error) {
+	if req.ImageID != nil && *req.ImageID == "" {
+		req.ImageID = nil
+	}
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+
+	if _, err := s.store.SetAlbumCover(ctx, vendorID, albumID, req.ImageID, *req.Version); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+	return s.ListAlbumImages(ctx, curator, vendorID, albumID)
+}
+
+// PinAlbumImage pins or unpins an image as featured in its album
+func (s *service) PinAlbumImage(ctx context.Context, curator Curator, vendorID, albumID, imageID string, req PinImageRequest) (AlbumImagesResponse, error) {
+	if err := s.authorizeCurator(ctx, curator, vendorID); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+
+	if _, err := s.store.SetImagePinned(ctx, vendorID, albumID, imageID, req.Pinned, *req.Version); err != nil {
+		return AlbumImagesResponse{}, err
+	}
+	return s.ListAlbumImages(ctx, curator, vendorID, albumID)
+}
+
+// validateOrder rejects empty orders and duplicate IDs
+func validateOrder(ids []string) error {
+	if len(ids) == 0 {
+		return ErrInvalidOrder
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			return ErrInvalidOrder
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// sameIDs reports whether order lists exactly the IDs in current
+func sameIDs(order, current []string) bool {
+	if len(order) != len(current) {
+		return false
+	}
+	set := make(map[string]bool, len(current))
+	for _, id := range current {
+		set[id] = true
+	}
+	for _, id := range order {
+		if !set[id] {
+			return false
+		}
+		delete(set, id)
+	}
+	return len(set) == 0
+}
+
+// ListCuratedAlbums retrieves all albums of a vendor in display order with
+// the vendor's album order version
+func (s *store) ListCuratedAlbums(ctx context.Context, vendorID string) ([]CuratedAlbum, int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT album_order_version FROM vendors WHERE id = $1`, vendorID).Scan(&version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, fmt.Errorf("vendor not found")
+		}
+		return nil, 0, fmt.Errorf("failed to get album order version: %w", err)
+	}
+
+	query := `
+		SELECT a.id, a.name, a.description, a.is_public, a.position, a.cover_image_id,
+		       c.original_url, a.version, a.created_at,
+		       (SELECT COUNT(*) FROM images i WHERE i.album_id = a.id AND i.deleted_at IS NULL)
+		FROM albums a
+		LEFT JOIN images c ON c.id = a.cover_image_id AND c.deleted_at IS NULL
+		WHERE a.vendor_id = $1
+		ORDER BY a.position, a.created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, vendorID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query albums: %w", err)
+	}
+	defer rows.Close()
+
+	var albums []CuratedAlbum
+	for rows.Next() {
+		var album CuratedAlbum
+		if err := rows.Scan(&album.ID, &album.Name, &album.Description, &album.IsPublic, &album.Position,
+			&album.CoverImageID, &album.CoverImageURL, &album.Version, &album.CreatedAt, &album.ImageCount); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan album: %w", err)
+		}
+		albums = append(albums, album)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating albums: %w", err)
+	}
+
+	return albums, version, nil
+}
+
+// ReorderAlbums assigns positions 1..n to the vendor's albums in the given order
+func (s *store) ReorderAlbums(ctx context.Context, vendorID string, albumIDs []string, version int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current int
+	err = tx.QueryRowContext(ctx,
+		`SELECT album_order_version FROM vendors WHERE id = $1 FOR UPDATE`, vendorID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("vendor not found")
+		}
+		return 0, fmt.Errorf("failed to lock album order: %w", err)
+	}
+	if current != version {
+		return 0, ErrCurationConflict
+	}
+
+	existing, err := queryIDs(ctx, tx, `SELECT id FROM albums WHERE vendor_id = $1`, vendorID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query albums: %w", err)
+	}
+	if !sameIDs(albumIDs, existing) {
+		return 0, ErrInvalidOrder
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE albums a
+		SET position = o.position, updated_at = NOW()
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE a.id = o.id AND a.vendor_id = $1
+	`, vendorID, pq.Array(albumIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to reorder albums: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE vendors SET album_order_version = album_order_version + 1 WHERE id = $1`, vendorID); err != nil {
+		return 0, fmt.Errorf("failed to update album order version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit album order: %w", err)
+	}
+	return current + 1, nil
+}
+
+// GetCuratedAlbum retrieves an album of a vendor and its images in display order
+func (s *store) GetCuratedAlbum(ctx context.Context, vendorID, albumID string) (*CuratedAlbum, []CuratedImage, error) {
+	query := `
+		SELECT a.id, a.name, a.description, a.is_public, a.position, a.cover_image_id,
+		       c.original_url, a.version, a.created_at
+		FROM albums a
+		LEFT JOIN images c ON c.id = a.cover_image_id AND c.deleted_at IS NULL
+		WHERE a.id = $1 AND a.vendor_id = $2
+	`
+
+	var album CuratedAlbum
+	err := s.db.QueryRowContext(ctx, query, albumID, vendorID).Scan(&album.ID, &album.Name, &album.Description,
+		&album.IsPublic, &album.Position, &album.CoverImageID, &album.CoverImageURL, &album.Version, &album.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrAlbumNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get album: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, original_url, thumbnail_url, is_public, position, is_featured, created_at
+		FROM images
+		WHERE album_id = $1 AND deleted_at IS NULL
+		ORDER BY is_featured DESC, position, created_at DESC
+	`, albumID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query album images: %w", err)
+	}
+	defer rows.Close()
+
+	var images []CuratedImage
+	for rows.Next() {
+		var image CuratedImage
+		if err := rows.Scan(&image.ID, &image.URL, &image.ThumbnailURL, &image.IsPublic,
+			&image.Position, &image.IsFeatured, &image.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan album image: %w", err)
+		}
+		images = append(images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating album images: %w", err)
+	}
+
+	album.ImageCount = len(images)
+	return &album, images, nil
+}
+
+// ReorderAlbumImages assigns positions 1..n to the album's images in the given order
+func (s *store) ReorderAlbumImages(ctx context.Context, vendorID, albumID string, imageIDs []string, version int) (int, error) {
+	return s.updateAlbum(ctx, vendorID, albumID, version, func(tx *sql.Tx) error {
+		existing, err := queryIDs(ctx, tx,
+			`SELECT id FROM images WHERE album_id = $1 AND deleted_at IS NULL`, albumID)
+		if err != nil {
+			return fmt.Errorf("failed to query album images: %w", err)
+		}
+		if !sameIDs(imageIDs, existing) {
+			return ErrInvalidOrder
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE images i
+			SET position = o.position, updated_at = NOW()
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+			WHERE i.id = o.id AND i.album_id = $1
+		`, albumID, pq.Array(imageIDs))
+		if err != nil {
+			return fmt.Errorf("failed to reorder album images: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetAlbumCover sets the album cover to one of its images, or clears it
+func (s *store) SetAlbumCover(ctx context.Context, vendorID, albumID string, imageID *string, version int) (int, error) {
+	return s.updateAlbum(ctx, vendorID, albumID, version, func(tx *sql.Tx) error {
+		if imageID != nil {
+			var exists bool
+			err := tx.QueryRowContext(ctx,
+				`SELECT EXISTS(SELECT 1 FROM images WHERE id = $1 AND album_id = $2 AND deleted_at IS NULL)`,
+				*imageID, albumID).Scan(&exists)
+			if err != nil {
+				return fmt.Errorf("failed to check cover image: %w", err)
+			}
+			if !exists {
+				return ErrAlbumImageNotFound
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE albums SET cover_image_id = $2 WHERE id = $1`, albumID, imageID); err != nil {
+			return fmt.Errorf("failed to set album cover: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetImagePinned pins or unpins an image of the album
+func (s *store) SetImagePinned(ctx context.Context, vendorID, albumID, imageID string, pinned bool, version int) (int, error) {
+	return s.updateAlbum(ctx, vendorID, albumID, version, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE images SET is_featured = $3, updated_at = NOW()
+			WHERE id = $1 AND album_id = $2 AND deleted_at IS NULL
+		`, imageID, albumID, pinned)
+		if err != nil {
+			return fmt.Errorf("failed to pin album image: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrAlbumImageNotFound
+		}
+		return nil
+	})
+}
+
+// updateAlbum runs update in a transaction holding the album row lock when
+// the album is still at version, then bumps the version
+func (s *store) updateAlbum(ctx context.Context, vendorID, albumID string, version int, update func(tx *sql.Tx) error) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current int
+	err = tx.QueryRowContext(ctx,
+		`SELECT version FROM albums WHERE id = $1 AND vendor_id = $2 FOR UPDATE`, albumID, vendorID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrAlbumNotFound
+		}
+		return 0, fmt.Errorf("failed to lock album: %w", err)
+	}
+	if current != version {
+		return 0, ErrCurationConflict
+	}
+
+	if err := update(tx); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE albums SET version = version + 1, updated_at = NOW() WHERE id = $1`, albumID); err != nil {
+		return 0, fmt.Errorf("failed to update album version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit album update: %w", err)
+	}
+	return current + 1, nil
+}
+
+// queryIDs returns the single id column of query
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,197 @@
+package vendors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeCurationStore keeps one vendor's albums in memory and implements the
+// optimistic locking of the real store
+type fakeCurationStore struct {
+	Store
+	vendor       Vendor
+	albums       []CuratedAlbum
+	images       map[string][]CuratedImage
+	orderVersion int
+}
+
+func (f *fakeCurationStore) GetVendor(ctx context.Context, id string) (*Vendor, error) {
+	if id != f.vendor.ID {
+		return nil, ErrStorefrontNotFound
+	}
+	vendor := f.vendor
+	return &vendor, nil
+}
+
+func (f *fakeCurationStore) ListCuratedAlbums(ctx context.Context, vendorID string) ([]CuratedAlbum, int, error) {
+	return f.albums, f.orderVersion, nil
+}
+
+func (f *fakeCurationStore) ReorderAlbums(ctx context.Context, vendorID string, albumIDs []string, version int) (int, error) {
+	if version != f.orderVersion {
+		return 0, ErrCurationConflict
+	}
+	current := make([]string, len(f.albums))
+	byID := make(map[string]CuratedAlbum)
+	for i, album := range f.albums {
+		current[i] = album.ID
+		byID[album.ID] = album
+	}
+	if !sameIDs(albumIDs, current) {
+		return 0, ErrInvalidOrder
+	}
+	for i, id := range albumIDs {
+		album := byID[id]
+		album.Position = i + 1
+		f.albums[i] = album
+	}
+	f.orderVersion++
+	return f.orderVersion, nil
+}
+
+func (f *fakeCurationStore) album(albumID string) *CuratedAlbum {
+	for i := range f.albums {
+		if f.albums[i].ID == albumID {
+			return &f.albums[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeCurationStore) GetCuratedAlbum(ctx context.Context, vendorID, albumID string) (*CuratedAlbum, []CuratedImage, error) {
+	album := f.album(albumID)
+	if album == nil {
+		return nil, nil, ErrAlbumNotFound
+	}
+	return album, f.images[albumID], nil
+}
+
+func (f *fakeCurationStore) SetAlbumCover(ctx context.Context, vendorID, albumID string, imageID *string, version int) (int, error) {
+	album := f.album(albumID)
+	if album == nil {
+		return 0, ErrAlbumNotFound
+	}
+	if version != album.Version {
+		return 0, ErrCurationConflict
+	}
+	album.CoverImageID = imageID
+	album.Version++
+	return album.Version, nil
+}
+
+func newFakeCurationStore() *fakeCurationStore {
+	return &fakeCurationStore{
+		vendor: Vendor{ID: "v1", UserID: "owner"},
+		albums: []CuratedAlbum{{ID: "a1", Name: "Summer"}, {ID: "a2", Name: "Winter"}, {ID: "a3", Name: "Denim"}},
+		images: map[string][]CuratedImage{
+			"a1": {{ID: "i1"}, {ID: "i2"}},
+		},
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	if err := validateOrder([]string{"a", "b"}); err != nil {
+		t.Errorf("Expected valid order, got %v", err)
+	}
+	for _, ids := range [][]string{nil, {"a", "a"}, {"a", ""}} {
+		if err := validateOrder(ids); err != ErrInvalidOrder {
+			t.Errorf("Expected ErrInvalidOrder for %v, got %v", ids, err)
+		}
+	}
+
+	if !sameIDs([]string{"b", "a"}, []string{"a", "b"}) {
+		t.Error("Expected a permutation to match")
+	}
+	if sameIDs([]string{"a"}, []string{"a", "b"}) || sameIDs([]string{"a", "c"}, []string{"a", "b"}) {
+		t.Error("Expected missing or unknown IDs not to match")
+	}
+}
+
+func TestReorderAlbums(t *testing.T) {
+	store := newFakeCurationStore()
+	service := NewService(store)
+	owner := Curator{UserID: "owner"}
+	version := 0
+
+	response, err := service.ReorderAlbums(context.Background(), owner, "v1", ReorderRequest{IDs: []string{"a3", "a1", "a2"}, Version: &version})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Version != 1 || response.Albums[0].ID != "a3" || response.Albums[0].Position != 1 {
+		t.Errorf("Expected a3 first at version 1, got %+v", response)
+	}
+
+	// A second reorder based on the old version loses the race
+	if _, err := service.ReorderAlbums(context.Background(), owner, "v1", ReorderRequest{IDs: []string{"a1", "a2", "a3"}, Version: &version}); err != ErrCurationConflict {
+		t.Errorf("Expected ErrCurationConflict, got %v", err)
+	}
+
+	version = 1
+	if _, err := service.ReorderAlbums(context.Background(), owner, "v1", ReorderRequest{IDs: []string{"a1", "a2"}, Version: &version}); err != ErrInvalidOrder {
+		t.Errorf("Expected ErrInvalidOrder for a partial order, got %v", err)
+	}
+
+	if _, err := service.ReorderAlbums(context.Background(), Curator{UserID: "someone"}, "v1", ReorderRequest{IDs: []string{"a1", "a2", "a3"}, Version: &version}); err != ErrCurationForbidden {
+		t.Errorf("Expected ErrCurationForbidden for another user, got %v", err)
+	}
+	if _, err := service.ReorderAlbums(context.Background(), Curator{UserID: "admin", IsAdmin: true}, "v1", ReorderRequest{IDs: []string{"a1", "a2", "a3"}, Version: &version}); err != nil {
+		t.Errorf("Expected admins to curate any vendor, got %v", err)
+	}
+}
+
+func TestCurationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newFakeCurationStore()
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	MountRoutes(r.Group("/api"), NewHandler(NewService(store)))
+
+	request := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		user     string
+		body     string
+		expected int
+	}{
+		{"list albums", http.MethodGet, "/api/vendors/v1/albums", "owner", "", http.StatusOK},
+		{"list albums of another vendor", http.MethodGet, "/api/vendors/v1/albums", "someone", "", http.StatusForbidden},
+		{"list album images", http.MethodGet, "/api/vendors/v1/albums/a1/images", "owner", "", http.StatusOK},
+		{"unknown album", http.MethodGet, "/api/vendors/v1/albums/missing/images", "owner", "", http.StatusNotFound},
+		{"reorder without version", http.MethodPut, "/api/vendors/v1/albums/order", "owner", `{"ids":["a1","a2","a3"]}`, http.StatusBadRequest},
+		{"reorder with duplicates", http.MethodPut, "/api/vendors/v1/albums/order", "owner", `{"ids":["a1","a1","a3"],"version":0}`, http.StatusBadRequest},
+		{"reorder", http.MethodPut, "/api/vendors/v1/albums/order", "owner", `{"ids":["a2","a1","a3"],"version":0}`, http.StatusOK},
+		{"stale reorder", http.MethodPut, "/api/vendors/v1/albums/order", "owner", `{"ids":["a1","a2","a3"],"version":0}`, http.StatusConflict},
+		{"set cover", http.MethodPut, "/api/vendors/v1/albums/a1/cover", "owner", `{"image_id":"i2","version":0}`, http.StatusOK},
+		{"stale cover", http.MethodPut, "/api/vendors/v1/albums/a1/cover", "owner", `{"image_id":"i1","version":0}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(tt.method, tt.path, tt.user, tt.body); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if cover := store.album("a1").CoverImageID; cover == nil || *cover != "i2" {
+		t.Errorf("Expected cover i2, got %v", cover)
+	}
+}
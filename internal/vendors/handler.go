@@ -1,7 +1,15 @@
 package vendors
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+
+	"ai-styler/internal/common"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,14 +35,33 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		vendor.POST("", h.CreateVendor)
 		vendor.PUT("/:id", h.UpdateVendor)
 		vendor.DELETE("/:id", h.DeleteVendor)
+
+		// Garment usage analytics of the caller's vendor
+		vendor.GET("/me/analytics", h.GetMyAnalytics)
+
+		// Album curation
+		vendor.GET("/:id/albums", h.ListVendorAlbums)
+		vendor.PUT("/:id/albums/order", h.ReorderAlbums)
+		vendor.GET("/:id/albums/:albumId/images", h.ListAlbumImages)
+		vendor.PUT("/:id/albums/:albumId/images/order", h.ReorderAlbumImages)
+		vendor.PUT("/:id/albums/:albumId/cover", h.SetAlbumCover)
+		vendor.PUT("/:id/albums/:albumId/images/:imageId/pin", h.PinAlbumImage)
+
+		// Album sharing
+		vendor.GET("/:id/albums/:albumId/access", h.ListAlbumAccess)
+		vendor.POST("/:id/albums/:albumId/shares", h.ShareAlbum)
+		vendor.DELETE("/:id/albums/:albumId/shares/:userId", h.UnshareAlbum)
+		vendor.POST("/:id/albums/:albumId/invites", h.CreateAlbumInvite)
+		vendor.DELETE("/:id/albums/:albumId/invites/:inviteId", h.RevokeAlbumInvite)
 	}
+	r.POST("/album-invites/:token/accept", h.AcceptAlbumInvite)
 }
 
 // GetVendors retrieves all vendors
 func (h *Handler) GetVendors(c *gin.Context) {
 	vendors, err := h.service.GetVendors(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -45,13 +72,13 @@ func (h *Handler) GetVendors(c *gin.Context) {
 func (h *Handler) GetVendor(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	vendor, err := h.service.GetVendor(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -62,13 +89,13 @@ func (h *Handler) GetVendor(c *gin.Context) {
 func (h *Handler) CreateVendor(c *gin.Context) {
 	var req CreateVendorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	vendor, err := h.service.CreateVendor(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -79,19 +106,19 @@ func (h *Handler) CreateVendor(c *gin.Context) {
 func (h *Handler) UpdateVendor(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	var req UpdateVendorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusBadRequest, err)
 		return
 	}
 
 	vendor, err := h.service.UpdateVendor(c.Request.Context(), id, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -102,15 +129,281 @@ func (h *Handler) UpdateVendor(c *gin.Context) {
 func (h *Handler) DeleteVendor(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor ID is required"})
+		common.RespondError(c, http.StatusBadRequest, "vendor ID is required")
 		return
 	}
 
 	err := h.service.DeleteVendor(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		common.RespondErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// GetStorefront returns the public profile of a verified vendor
+func (h *Handler) GetStorefront(c *gin.Context) {
+	vendor, err := h.service.GetStorefront(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondCacheable(c, gin.H{"vendor": vendor})
+}
+
+// ListStorefrontAlbums returns a page of a verified vendor's public albums
+func (h *Handler) ListStorefrontAlbums(c *gin.Context) {
+	req := StorefrontAlbumsRequest{
+		Page:        queryInt(c, "page"),
+		PageSize:    queryInt(c, "pageSize"),
+		AlbumImages: queryInt(c, "images"),
+	}
+
+	response, err := h.service.ListStorefrontAlbums(c.Request.Context(), c.Param("slug"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondCacheable(c, response)
+}
+
+// GetMyAnalytics returns the garment usage analytics of the caller's vendor
+func (h *Handler) GetMyAnalytics(c *gin.Context) {
+	req := AnalyticsRequest{Range: c.Query("range")}
+
+	analytics, err := h.service.GetMyAnalytics(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// respondCacheable writes body with shared-cache headers and an ETag, and
+// answers conditional requests for unchanged content with 304
+func respondCacheable(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		int(storefrontCacheMaxAge.Seconds()), int(storefrontStaleWhileRevalidate.Seconds())))
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// queryInt returns a positive integer query parameter or 0 to use the default
+func queryInt(c *gin.Context, name string) int {
+	value, err := strconv.Atoi(c.Query(name))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+// curator returns the authenticated caller of a curation request
+func curator(c *gin.Context) Curator {
+	role := c.GetString("user_role")
+	return Curator{
+		UserID:  c.GetString("user_id"),
+		IsAdmin: role == "admin" || role == "super_admin",
+	}
+}
+
+// respondCurationErr writes a curation error, answering missing ownership with 403
+func respondCurationErr(c *gin.Context, err error) {
+	if errors.Is(err, ErrCurationForbidden) {
+		common.RespondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	common.RespondErr(c, http.StatusInternalServerError, err)
+}
+
+// ListVendorAlbums returns the vendor's albums in display order
+func (h *Handler) ListVendorAlbums(c *gin.Context) {
+	response, err := h.service.ListVendorAlbums(c.Request.Context(), curator(c), c.Param("id"))
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReorderAlbums sets the display order of the vendor's albums
+func (h *Handler) ReorderAlbums(c *gin.Context) {
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.ReorderAlbums(c.Request.Context(), curator(c), c.Param("id"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListAlbumImages returns an album's images in display order
+func (h *Handler) ListAlbumImages(c *gin.Context) {
+	response, err := h.service.ListAlbumImages(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"))
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReorderAlbumImages sets the display order of an album's images
+func (h *Handler) ReorderAlbumImages(c *gin.Context) {
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.ReorderAlbumImages(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetAlbumCover sets or clears an album's cover image
+func (h *Handler) SetAlbumCover(c *gin.Context) {
+	var req SetAlbumCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.SetAlbumCover(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PinAlbumImage pins or unpins an image as featured in its album
+func (h *Handler) PinAlbumImage(c *gin.Context) {
+	var req PinImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.service.PinAlbumImage(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), c.Param("imageId"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListAlbumAccess returns who an album is shared with and its invites
+func (h *Handler) ListAlbumAccess(c *gin.Context) {
+	response, err := h.service.ListAlbumAccess(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"))
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ShareAlbum shares an album with a user account
+func (h *Handler) ShareAlbum(c *gin.Context) {
+	var req ShareAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	share, err := h.service.ShareAlbum(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+// UnshareAlbum takes a user's access to an album away
+func (h *Handler) UnshareAlbum(c *gin.Context) {
+	err := h.service.UnshareAlbum(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), c.Param("userId"))
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateAlbumInvite creates an invite link to an album. The token is only
+// returned here.
+func (h *Handler) CreateAlbumInvite(c *gin.Context) {
+	var req CreateAlbumInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	invite, err := h.service.CreateAlbumInvite(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), req)
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// RevokeAlbumInvite stops an invite link from being accepted
+func (h *Handler) RevokeAlbumInvite(c *gin.Context) {
+	err := h.service.RevokeAlbumInvite(c.Request.Context(), curator(c), c.Param("id"), c.Param("albumId"), c.Param("inviteId"))
+	if err != nil {
+		respondCurationErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptAlbumInvite shares the album of an invite link with the caller
+func (h *Handler) AcceptAlbumInvite(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	album, err := h.service.AcceptAlbumInvite(c.Request.Context(), userID, c.Param("token"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
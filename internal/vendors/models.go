@@ -8,6 +8,7 @@ import (
 type Vendor struct {
 	ID          string     `json:"id" db:"id"`
 	UserID      string     `json:"user_id" db:"user_id"`
+	Slug        *string    `json:"slug,omitempty" db:"slug"`
 	DisplayName *string    `json:"display_name,omitempty" db:"display_name"`
 	CompanyName *string    `json:"company_name,omitempty" db:"company_name"`
 	Status      string     `json:"status" db:"status"`
@@ -18,6 +19,7 @@ type Vendor struct {
 // CreateVendorRequest represents the request to create a vendor
 type CreateVendorRequest struct {
 	UserID      string  `json:"user_id" binding:"required"`
+	Slug        *string `json:"slug,omitempty"`
 	DisplayName *string `json:"display_name,omitempty"`
 	CompanyName *string `json:"company_name,omitempty"`
 	Status      string  `json:"status"`
@@ -25,6 +27,7 @@ type CreateVendorRequest struct {
 
 // UpdateVendorRequest represents the request to update a vendor
 type UpdateVendorRequest struct {
+	Slug        *string `json:"slug,omitempty"`
 	DisplayName *string `json:"display_name,omitempty"`
 	CompanyName *string `json:"company_name,omitempty"`
 	Status      *string `json:"status,omitempty"`
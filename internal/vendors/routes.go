@@ -13,5 +13,33 @@ func MountRoutes(r *gin.RouterGroup, handler *Handler) {
 		vendor.POST("", handler.CreateVendor)
 		vendor.PUT("/:id", handler.UpdateVendor)
 		vendor.DELETE("/:id", handler.DeleteVendor)
+
+		// Garment usage analytics of the caller's vendor
+		vendor.GET("/me/analytics", handler.GetMyAnalytics)
+
+		// Album curation
+		vendor.GET("/:id/albums", handler.ListVendorAlbums)
+		vendor.PUT("/:id/albums/order", handler.ReorderAlbums)
+		vendor.GET("/:id/albums/:albumId/images", handler.ListAlbumImages)
+		vendor.PUT("/:id/albums/:albumId/images/order", handler.ReorderAlbumImages)
+		vendor.PUT("/:id/albums/:albumId/cover", handler.SetAlbumCover)
+		vendor.PUT("/:id/albums/:albumId/images/:imageId/pin", handler.PinAlbumImage)
+
+		// Album sharing
+		vendor.GET("/:id/albums/:albumId/access", handler.ListAlbumAccess)
+		vendor.POST("/:id/albums/:albumId/shares", handler.ShareAlbum)
+		vendor.DELETE("/:id/albums/:albumId/shares/:userId", handler.UnshareAlbum)
+		vendor.POST("/:id/albums/:albumId/invites", handler.CreateAlbumInvite)
+		vendor.DELETE("/:id/albums/:albumId/invites/:inviteId", handler.RevokeAlbumInvite)
+	}
+	r.POST("/album-invites/:token/accept", handler.AcceptAlbumInvite)
+}
+
+// MountPublicRoutes registers the unauthenticated storefront routes
+func MountPublicRoutes(r *gin.RouterGroup, handler *Handler) {
+	storefront := r.Group("/vendors")
+	{
+		storefront.GET("/:slug", handler.GetStorefront)
+		storefront.GET("/:slug/albums", handler.ListStorefrontAlbums)
 	}
 }
@@ -3,6 +3,8 @@ package vendors
 import (
 	"context"
 	"errors"
+
+	"ai-styler/internal/cache"
 )
 
 // Service defines the vendor service interface
@@ -12,17 +14,43 @@ type Service interface {
 	CreateVendor(ctx context.Context, req CreateVendorRequest) (*Vendor, error)
 	UpdateVendor(ctx context.Context, id string, req UpdateVendorRequest) (*Vendor, error)
 	DeleteVendor(ctx context.Context, id string) error
+
+	// Public storefront
+	GetStorefront(ctx context.Context, slug string) (*StorefrontVendor, error)
+	ListStorefrontAlbums(ctx context.Context, slug string, req StorefrontAlbumsRequest) (StorefrontAlbumsResponse, error)
+
+	// Album curation by the vendor owner
+	ListVendorAlbums(ctx context.Context, curator Curator, vendorID string) (VendorAlbumsResponse, error)
+	ReorderAlbums(ctx context.Context, curator Curator, vendorID string, req ReorderRequest) (VendorAlbumsResponse, error)
+	ListAlbumImages(ctx context.Context, curator Curator, vendorID, albumID string) (AlbumImagesResponse, error)
+	ReorderAlbumImages(ctx context.Context, curator Curator, vendorID, albumID string, req ReorderRequest) (AlbumImagesResponse, error)
+	SetAlbumCover(ctx context.Context, curator Curator, vendorID, albumID string, req SetAlbumCoverRequest) (AlbumImagesResponse, error)
+	PinAlbumImage(ctx context.Context, curator Curator, vendorID, albumID, imageID string, req PinImageRequest) (AlbumImagesResponse, error)
+
+	// Sharing of private albums with users and through invite links
+	ListAlbumAccess(ctx context.Context, curator Curator, vendorID, albumID string) (AlbumAccessResponse, error)
+	ShareAlbum(ctx context.Context, curator Curator, vendorID, albumID string, req ShareAlbumRequest) (*AlbumShare, error)
+	UnshareAlbum(ctx context.Context, curator Curator, vendorID, albumID, userID string) error
+	CreateAlbumInvite(ctx context.Context, curator Curator, vendorID, albumID string, req CreateAlbumInviteRequest) (*AlbumInvite, error)
+	RevokeAlbumInvite(ctx context.Context, curator Curator, vendorID, albumID, inviteID string) error
+	AcceptAlbumInvite(ctx context.Context, userID, token string) (*SharedAlbum, error)
+
+	// Garment usage analytics of the caller's own vendor
+	GetMyAnalytics(ctx context.Context, userID string, req AnalyticsRequest) (*VendorAnalytics, error)
 }
 
 // service implements the vendor service
 type service struct {
 	store Store
+	cache *cache.TwoTier // vendor profiles, dropped on update and delete (optional)
 }
 
-// NewService creates a new vendor service
+// NewService creates a new vendor service caching profiles in the default
+// lookup cache
 func NewService(store Store) Service {
 	return &service{
 		store: store,
+		cache: cache.Default(),
 	}
 }
 
@@ -37,7 +65,14 @@ func (s *service) GetVendor(ctx context.Context, id string) (*Vendor, error) {
 		return nil, errors.New("vendor ID is required")
 	}
 
-	return s.store.GetVendor(ctx, id)
+	return s.loadVendor(ctx, id)
+}
+
+// loadVendor returns a vendor profile from the cache or the store
+func (s *service) loadVendor(ctx context.Context, id string) (*Vendor, error) {
+	return cache.Load(ctx, s.cache, cache.EntityVendorProfile, id, func(ctx context.Context) (*Vendor, error) {
+		return s.store.GetVendor(ctx, id)
+	})
 }
 
 // CreateVendor creates a new vendor
@@ -45,6 +80,9 @@ func (s *service) CreateVendor(ctx context.Context, req CreateVendorRequest) (*V
 	if req.UserID == "" {
 		return nil, errors.New("user ID is required")
 	}
+	if req.Slug != nil && !ValidSlug(*req.Slug) {
+		return nil, ErrInvalidSlug
+	}
 
 	status := req.Status
 	if status == "" {
@@ -53,6 +91,7 @@ func (s *service) CreateVendor(ctx context.Context, req CreateVendorRequest) (*V
 
 	vendor := &Vendor{
 		UserID:      req.UserID,
+		Slug:        req.Slug,
 		DisplayName: req.DisplayName,
 		CompanyName: req.CompanyName,
 		Status:      status,
@@ -74,6 +113,12 @@ func (s *service) UpdateVendor(ctx context.Context, id string, req UpdateVendorR
 	}
 
 	// Update fields
+	if req.Slug != nil {
+		if !ValidSlug(*req.Slug) {
+			return nil, ErrInvalidSlug
+		}
+		vendor.Slug = req.Slug
+	}
 	if req.DisplayName != nil {
 		vendor.DisplayName = req.DisplayName
 	}
@@ -84,6 +129,7 @@ func (s *service) UpdateVendor(ctx context.Context, id string, req UpdateVendorR
 		vendor.Status = *req.Status
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, id)
 	return s.store.UpdateVendor(ctx, vendor)
 }
 
@@ -93,5 +139,6 @@ func (s *service) DeleteVendor(ctx context.Context, id string) error {
 		return errors.New("vendor ID is required")
 	}
 
+	defer cache.Invalidate(ctx, s.cache, cache.EntityVendorProfile, id)
 	return s.store.DeleteVendor(ctx, id)
 }
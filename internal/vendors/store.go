@@ -13,6 +13,11 @@ type Store interface {
 	CreateVendor(ctx context.Context, vendor *Vendor) (*Vendor, error)
 	UpdateVendor(ctx context.Context, vendor *Vendor) (*Vendor, error)
 	DeleteVendor(ctx context.Context, id string) error
+
+	StorefrontStore
+	CurationStore
+	AlbumAccessStore
+	AnalyticsStore
 }
 
 // store implements the vendor store
@@ -30,7 +35,7 @@ func NewStore(db *sql.DB) Store {
 // GetVendors retrieves all vendors
 func (s *store) GetVendors(ctx context.Context) ([]Vendor, error) {
 	query := `
-		SELECT id, user_id, display_name, company_name, status, created_at, updated_at
+		SELECT id, user_id, slug, display_name, company_name, status, created_at, updated_at
 		FROM vendors
 		ORDER BY created_at DESC
 	`
@@ -47,6 +52,7 @@ func (s *store) GetVendors(ctx context.Context) ([]Vendor, error) {
 		err := rows.Scan(
 			&vendor.ID,
 			&vendor.UserID,
+			&vendor.Slug,
 			&vendor.DisplayName,
 			&vendor.CompanyName,
 			&vendor.Status,
@@ -69,7 +75,7 @@ func (s *store) GetVendors(ctx context.Context) ([]Vendor, error) {
 // GetVendor retrieves a specific vendor by ID
 func (s *store) GetVendor(ctx context.Context, id string) (*Vendor, error) {
 	query := `
-		SELECT id, user_id, display_name, company_name, status, created_at, updated_at
+		SELECT id, user_id, slug, display_name, company_name, status, created_at, updated_at
 		FROM vendors
 		WHERE id = $1
 	`
@@ -78,6 +84,7 @@ func (s *store) GetVendor(ctx context.Context, id string) (*Vendor, error) {
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&vendor.ID,
 		&vendor.UserID,
+		&vendor.Slug,
 		&vendor.DisplayName,
 		&vendor.CompanyName,
 		&vendor.Status,
@@ -98,13 +105,14 @@ func (s *store) GetVendor(ctx context.Context, id string) (*Vendor, error) {
 // CreateVendor creates a new vendor
 func (s *store) CreateVendor(ctx context.Context, vendor *Vendor) (*Vendor, error) {
 	query := `
-		INSERT INTO vendors (user_id, display_name, company_name, status)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO vendors (user_id, slug, display_name, company_name, status)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at
 	`
 
 	err := s.db.QueryRowContext(ctx, query,
 		vendor.UserID,
+		vendor.Slug,
 		vendor.DisplayName,
 		vendor.CompanyName,
 		vendor.Status,
@@ -121,7 +129,7 @@ func (s *store) CreateVendor(ctx context.Context, vendor *Vendor) (*Vendor, erro
 func (s *store) UpdateVendor(ctx context.Context, vendor *Vendor) (*Vendor, error) {
 	query := `
 		UPDATE vendors
-		SET user_id = $2, display_name = $3, company_name = $4, status = $5, updated_at = NOW()
+		SET user_id = $2, slug = $3, display_name = $4, company_name = $5, status = $6, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
 	`
@@ -129,6 +137,7 @@ func (s *store) UpdateVendor(ctx context.Context, vendor *Vendor) (*Vendor, erro
 	err := s.db.QueryRowContext(ctx, query,
 		vendor.ID,
 		vendor.UserID,
+		vendor.Slug,
 		vendor.DisplayName,
 		vendor.CompanyName,
 		vendor.Status,
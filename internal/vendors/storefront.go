@@ -0,0 +1,286 @@
+package vendors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// Storefront pagination limits
+const (
+	DefaultStorefrontPageSize      = 20
+	MaxStorefrontPageSize          = 100
+	DefaultStorefrontAlbumImages   = 12
+	MaxStorefrontAlbumImages       = 50
+	storefrontCacheMaxAge          = 5 * time.Minute
+	storefrontStaleWhileRevalidate = time.Minute
+)
+
+var (
+	// ErrInvalidSlug is returned for slugs that are not URL safe
+	ErrInvalidSlug = fmt.Errorf("%w: slug must be 3-64 lowercase letters, digits or single hyphens", common.ErrValidation)
+
+	// ErrStorefrontNotFound is returned for unknown, unverified or suspended vendors
+	ErrStorefrontNotFound = fmt.Errorf("storefront %w", common.ErrNotFound)
+
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// ValidSlug reports whether slug can be used in a storefront URL
+func ValidSlug(slug string) bool {
+	return len(slug) >= 3 && len(slug) <= 64 && slugPattern.MatchString(slug)
+}
+
+// StorefrontVendor is the public profile of a verified vendor
+type StorefrontVendor struct {
+	ID          string          `json:"-"`
+	Slug        string          `json:"slug"`
+	Name        string          `json:"name"`
+	AvatarURL   *string         `json:"avatar_url,omitempty"`
+	Bio         *string         `json:"bio,omitempty"`
+	SocialLinks json.RawMessage `json:"social_links,omitempty"`
+	IsVerified  bool            `json:"is_verified"`
+	AlbumCount  int             `json:"album_count"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// StorefrontAlbum is a public album with a preview of its public images
+type StorefrontAlbum struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description *string           `json:"description,omitempty"`
+	CoverURL    *string           `json:"cover_url,omitempty"`
+	ImageCount  int               `json:"image_count"`
+	Images      []StorefrontImage `json:"images"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// StorefrontImage is a public catalog image
+type StorefrontImage struct {
+	ID           string   `json:"id"`
+	AlbumID      string   `json:"-"`
+	URL          string   `json:"url"`
+	ThumbnailURL *string  `json:"thumbnail_url,omitempty"`
+	Width        *int     `json:"width,omitempty"`
+	Height       *int     `json:"height,omitempty"`
+	Tags         []string `json:"tags"`
+	IsFeatured   bool     `json:"is_featured"`
+}
+
+// StorefrontAlbumsRequest selects a page of storefront albums
+type StorefrontAlbumsRequest struct {
+	Page        int
+	PageSize    int
+	AlbumImages int // images included per album
+}
+
+// StorefrontAlbumsResponse is a page of storefront albums
+type StorefrontAlbumsResponse struct {
+	Vendor     *StorefrontVendor `json:"vendor"`
+	Albums     []StorefrontAlbum `json:"albums"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// StorefrontStore defines the public catalog queries of the vendor store
+type StorefrontStore interface {
+	GetStorefrontVendor(ctx context.Context, slug string) (*StorefrontVendor, error)
+	ListStorefrontAlbums(ctx context.Context, vendorID string, limit, offset int) ([]StorefrontAlbum, int, error)
+	ListStorefrontImages(ctx context.Context, albumIDs []string, perAlbum int) (map[string][]StorefrontImage, error)
+}
+
+// GetStorefront returns the public profile of a verified vendor
+func (s *service) GetStorefront(ctx context.Context, slug string) (*StorefrontVendor, error) {
+	if !ValidSlug(slug) {
+		return nil, ErrStorefrontNotFound
+	}
+
+	return s.store.GetStorefrontVendor(ctx, slug)
+}
+
+// ListStorefrontAlbums returns a page of a verified vendor's public albums
+func (s *service) ListStorefrontAlbums(ctx context.Context, slug string, req StorefrontAlbumsRequest) (StorefrontAlbumsResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = DefaultStorefrontPageSize
+	}
+	if req.PageSize > MaxStorefrontPageSize {
+		req.PageSize = MaxStorefrontPageSize
+	}
+	if req.AlbumImages <= 0 {
+		req.AlbumImages = DefaultStorefrontAlbumImages
+	}
+	if req.AlbumImages > MaxStorefrontAlbumImages {
+		req.AlbumImages = MaxStorefrontAlbumImages
+	}
+
+	vendor, err := s.GetStorefront(ctx, slug)
+	if err != nil {
+		return StorefrontAlbumsResponse{}, err
+	}
+
+	albums, total, err := s.store.ListStorefrontAlbums(ctx, vendor.ID, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		return StorefrontAlbumsResponse{}, err
+	}
+
+	if len(albums) > 0 {
+		albumIDs := make([]string, len(albums))
+		for i, album := range albums {
+			albumIDs[i] = album.ID
+		}
+		images, err := s.store.ListStorefrontImages(ctx, albumIDs, req.AlbumImages)
+		if err != nil {
+			return StorefrontAlbumsResponse{}, err
+		}
+		for i := range albums {
+			albums[i].Images = images[albums[i].ID]
+			if albums[i].Images == nil {
+				albums[i].Images = []StorefrontImage{}
+			}
+		}
+	} else {
+		albums = []StorefrontAlbum{}
+	}
+
+	return StorefrontAlbumsResponse{
+		Vendor:     vendor,
+		Albums:     albums,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: (total + req.PageSize - 1) / req.PageSize,
+	}, nil
+}
+
+// GetStorefrontVendor retrieves a verified, active vendor by slug
+func (s *store) GetStorefrontVendor(ctx context.Context, slug string) (*StorefrontVendor, error) {
+	query := `
+		SELECT v.id, v.slug, COALESCE(v.business_name, v.display_name, v.company_name, ''),
+		       v.avatar_url, v.bio, v.social_links::text, v.is_verified, v.created_at,
+		       (SELECT COUNT(*) FROM albums a WHERE a.vendor_id = v.id AND a.is_public = true)
+		FROM vendors v
+		WHERE v.slug = $1 AND v.is_verified = true AND v.status = 'active'
+	`
+
+	var vendor StorefrontVendor
+	var socialLinks string
+	err := s.db.QueryRowContext(ctx, query, slug).Scan(
+		&vendor.ID,
+		&vendor.Slug,
+		&vendor.Name,
+		&vendor.AvatarURL,
+		&vendor.Bio,
+		&socialLinks,
+		&vendor.IsVerified,
+		&vendor.CreatedAt,
+		&vendor.AlbumCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStorefrontNotFound
+		}
+		return nil, fmt.Errorf("failed to get storefront vendor: %w", err)
+	}
+	if socialLinks != "" && socialLinks != "{}" {
+		vendor.SocialLinks = json.RawMessage(socialLinks)
+	}
+
+	return &vendor, nil
+}
+
+// ListStorefrontAlbums retrieves a page of a vendor's public albums in the
+// vendor's display order
+func (s *store) ListStorefrontAlbums(ctx context.Context, vendorID string, limit, offset int) ([]StorefrontAlbum, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM albums WHERE vendor_id = $1 AND is_public = true`, vendorID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count storefront albums: %w", err)
+	}
+
+	query := `
+		SELECT a.id, a.name, a.description, c.original_url, a.created_at,
+		       (SELECT COUNT(*) FROM images i WHERE i.album_id = a.id AND i.is_public = true)
+		FROM albums a
+		LEFT JOIN images c ON c.id = a.cover_image_id AND c.is_public = true
+		WHERE a.vendor_id = $1 AND a.is_public = true
+		ORDER BY a.position, a.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, vendorID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query storefront albums: %w", err)
+	}
+	defer rows.Close()
+
+	var albums []StorefrontAlbum
+	for rows.Next() {
+		var album StorefrontAlbum
+		if err := rows.Scan(&album.ID, &album.Name, &album.Description, &album.CoverURL, &album.CreatedAt, &album.ImageCount); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan storefront album: %w", err)
+		}
+		albums = append(albums, album)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating storefront albums: %w", err)
+	}
+
+	return albums, total, nil
+}
+
+// ListStorefrontImages retrieves the first public images of each album:
+// pinned images, then the vendor's order, then newest first
+func (s *store) ListStorefrontImages(ctx context.Context, albumIDs []string, perAlbum int) (map[string][]StorefrontImage, error) {
+	query := `
+		SELECT id, album_id, original_url, thumbnail_url, width, height, tags, is_featured
+		FROM (
+			SELECT id, album_id, original_url, thumbnail_url, width, height, tags, is_featured,
+			       ROW_NUMBER() OVER (PARTITION BY album_id ORDER BY is_featured DESC, position, created_at DESC) AS rn
+			FROM images
+			WHERE album_id = ANY($1) AND is_public = true
+		) ranked
+		WHERE rn <= $2
+		ORDER BY album_id, rn
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(albumIDs), perAlbum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query storefront images: %w", err)
+	}
+	defer rows.Close()
+
+	images := make(map[string][]StorefrontImage)
+	for rows.Next() {
+		var image StorefrontImage
+		var tags []string
+		if err := rows.Scan(&image.ID, &image.AlbumID, &image.URL, &image.ThumbnailURL,
+			&image.Width, &image.Height, pq.Array(&tags), &image.IsFeatured); err != nil {
+			return nil, fmt.Errorf("failed to scan storefront image: %w", err)
+		}
+		image.Tags = tags
+		if image.Tags == nil {
+			image.Tags = []string{}
+		}
+		images[image.AlbumID] = append(images[image.AlbumID], image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storefront images: %w", err)
+	}
+
+	return images, nil
+}
@@ -0,0 +1,144 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStorefrontStore implements the storefront queries; the remaining Store
+// methods are not used by these tests
+type fakeStorefrontStore struct {
+	Store
+	vendors    map[string]*StorefrontVendor
+	albums     []StorefrontAlbum
+	images     map[string][]StorefrontImage
+	lastLimit  int
+	lastOffset int
+	lastImages int
+}
+
+func (f *fakeStorefrontStore) GetStorefrontVendor(ctx context.Context, slug string) (*StorefrontVendor, error) {
+	vendor, ok := f.vendors[slug]
+	if !ok {
+		return nil, ErrStorefrontNotFound
+	}
+	return vendor, nil
+}
+
+func (f *fakeStorefrontStore) ListStorefrontAlbums(ctx context.Context, vendorID string, limit, offset int) ([]StorefrontAlbum, int, error) {
+	f.lastLimit, f.lastOffset = limit, offset
+	var page []StorefrontAlbum
+	for i := offset; i < len(f.albums) && i < offset+limit; i++ {
+		page = append(page, f.albums[i])
+	}
+	return page, len(f.albums), nil
+}
+
+func (f *fakeStorefrontStore) ListStorefrontImages(ctx context.Context, albumIDs []string, perAlbum int) (map[string][]StorefrontImage, error) {
+	f.lastImages = perAlbum
+	return f.images, nil
+}
+
+func newFakeStorefrontStore() *fakeStorefrontStore {
+	return &fakeStorefrontStore{
+		vendors: map[string]*StorefrontVendor{
+			"acme-studio": {ID: "v1", Slug: "acme-studio", Name: "Acme Studio", IsVerified: true, AlbumCount: 3},
+		},
+		albums: []StorefrontAlbum{{ID: "a1", Name: "Summer"}, {ID: "a2", Name: "Winter"}, {ID: "a3", Name: "Denim"}},
+		images: map[string][]StorefrontImage{
+			"a1": {{ID: "i1", AlbumID: "a1", URL: "https://cdn.example/i1.jpg", Tags: []string{}}},
+		},
+	}
+}
+
+func TestValidSlug(t *testing.T) {
+	tests := map[string]bool{
+		"acme-studio": true,
+		"shop42":      true,
+		"ab":          false,
+		"Acme":        false,
+		"acme--shop":  false,
+		"-acme":       false,
+		"acme_shop":   false,
+	}
+	for slug, expected := range tests {
+		if got := ValidSlug(slug); got != expected {
+			t.Errorf("ValidSlug(%q): expected %v, got %v", slug, expected, got)
+		}
+	}
+}
+
+func TestListStorefrontAlbums_Pagination(t *testing.T) {
+	store := newFakeStorefrontStore()
+	service := NewService(store)
+
+	response, err := service.ListStorefrontAlbums(context.Background(), "acme-studio", StorefrontAlbumsRequest{Page: 2, PageSize: 2, AlbumImages: 500})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.lastLimit != 2 || store.lastOffset != 2 {
+		t.Errorf("Expected limit 2 offset 2, got limit %d offset %d", store.lastLimit, store.lastOffset)
+	}
+	if store.lastImages != MaxStorefrontAlbumImages {
+		t.Errorf("Expected images per album to be capped at %d, got %d", MaxStorefrontAlbumImages, store.lastImages)
+	}
+	if response.Total != 3 || response.TotalPages != 2 || len(response.Albums) != 1 {
+		t.Errorf("Expected 1 album on page 2 of 2 (3 total), got %d albums, %d pages, %d total",
+			len(response.Albums), response.TotalPages, response.Total)
+	}
+	if response.Albums[0].Images == nil {
+		t.Error("Expected albums without public images to have an empty image list")
+	}
+
+	if _, err := service.ListStorefrontAlbums(context.Background(), "unknown-vendor", StorefrontAlbumsRequest{}); err != ErrStorefrontNotFound {
+		t.Errorf("Expected ErrStorefrontNotFound, got %v", err)
+	}
+}
+
+func TestStorefrontHandler_CachingHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	MountPublicRoutes(r.Group("/api/public"), NewHandler(NewService(newFakeStorefrontStore())))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/public/vendors/acme-studio", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+	if !strings.HasPrefix(w.Header().Get("Cache-Control"), "public, max-age=") {
+		t.Errorf("Expected public Cache-Control, got %q", w.Header().Get("Cache-Control"))
+	}
+	var body struct {
+		Vendor StorefrontVendor `json:"vendor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body.Vendor.Name != "Acme Studio" || body.Vendor.ID != "" {
+		t.Errorf("Expected public profile without internal ID, got %+v", body.Vendor)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/public/vendors/acme-studio", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304 for matching ETag, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/public/vendors/unverified-shop/albums", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown storefront, got %d", w.Code)
+	}
+}
@@ -0,0 +1,160 @@
+package verification
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"ai-styler/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler provides HTTP handlers for vendor verification
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new verification handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetVendorVerification handles GET /api/vendors/me/verification
+func (h *Handler) GetVendorVerification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	verification, err := h.service.GetVendorVerification(c.Request.Context(), fmt.Sprint(userID))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// UploadDocument handles POST /api/vendors/me/verification/documents, a
+// multipart form with the document as file and its kind
+func (h *Handler) UploadDocument(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxDocumentSize()+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			common.RespondError(c, http.StatusRequestEntityTooLarge, "document is too large")
+			return
+		}
+		common.RespondError(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.service.MaxDocumentSize()+1))
+	if err != nil {
+		common.RespondError(c, http.StatusBadRequest, "failed to read file")
+		return
+	}
+
+	verification, err := h.service.UploadDocument(c.Request.Context(), fmt.Sprint(userID), UploadDocumentRequest{
+		Kind:     c.Request.FormValue("kind"),
+		FileName: header.Filename,
+		Data:     data,
+	})
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, verification)
+}
+
+// RemoveDocument handles DELETE /api/vendors/me/verification/documents/:documentId
+func (h *Handler) RemoveDocument(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	verification, err := h.service.RemoveDocument(c.Request.Context(), fmt.Sprint(userID), c.Param("documentId"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// ListVerifications handles GET /admin/vendor-verifications
+func (h *Handler) ListVerifications(c *gin.Context) {
+	var req ListVerificationsRequest
+	if err := common.BindQuery(c, &req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	verifications, err := h.service.ListVerifications(c.Request.Context(), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verifications)
+}
+
+// GetVerification handles GET /admin/vendor-verifications/:id
+func (h *Handler) GetVerification(c *gin.Context) {
+	verification, err := h.service.GetVerification(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// DownloadDocument handles GET /admin/vendor-verifications/:id/documents/:documentId
+func (h *Handler) DownloadDocument(c *gin.Context) {
+	document, data, err := h.service.OpenDocument(c.Request.Context(), c.Param("id"), c.Param("documentId"))
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Data(http.StatusOK, document.ContentType, data)
+}
+
+// UpdateStatus handles PUT /admin/vendor-verifications/:id/status
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	adminID, ok := c.Get("admin_user_id")
+	if !ok {
+		common.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.RespondErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	verification, err := h.service.UpdateStatus(c.Request.Context(), fmt.Sprint(adminID), c.Param("id"), req)
+	if err != nil {
+		common.RespondErr(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
@@ -0,0 +1,50 @@
+package verification
+
+import (
+	"context"
+
+	"ai-styler/internal/common"
+)
+
+// Store defines the interface for vendor verification data operations
+type Store interface {
+	// GetVendorID returns the ID of the vendor owned by a user
+	GetVendorID(ctx context.Context, userID string) (string, error)
+
+	// GetLatestVerification returns the most recent verification of a
+	// vendor with its documents
+	GetLatestVerification(ctx context.Context, vendorID string) (*Verification, error)
+
+	// GetVerification returns a verification with its documents
+	GetVerification(ctx context.Context, id string) (*Verification, error)
+
+	// AddDocument adds a document to the vendor's pending verification,
+	// starting one when the vendor has none or its last one was rejected.
+	// A verification holds at most maxDocuments documents.
+	AddDocument(ctx context.Context, vendorID string, document Document, maxDocuments int) (*Verification, error)
+
+	// RemoveDocument deletes a document of the vendor's pending verification
+	// and returns it, so its object can be deleted
+	RemoveDocument(ctx context.Context, vendorID, documentID string) (*Document, error)
+
+	// ListVerifications returns the verifications matching req, newest
+	// first, from cursor on. One verification more than req.PageSize is
+	// returned when more follow.
+	ListVerifications(ctx context.Context, req ListVerificationsRequest, cursor *common.Cursor) ([]Verification, error)
+
+	// UpdateStatus moves a verification from status from to status and
+	// audits the change. Decisions set the vendor's verified flag.
+	UpdateStatus(ctx context.Context, id, from, status, adminID, reason string) (*Verification, error)
+}
+
+// DocumentStorage keeps verification documents in private object storage
+type DocumentStorage interface {
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+	WriteObject(ctx context.Context, key string, data []byte, contentType string) error
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// Notifier tells vendors about the decision on their verification
+type Notifier interface {
+	SendVendorVerificationDecision(ctx context.Context, userID, verificationID, status, reason string) error
+}
@@ -0,0 +1,112 @@
+package verification
+
+import (
+	"time"
+)
+
+// Verification statuses. A verification is pending from the vendor's first
+// document until an admin starts reviewing it, and ends approved or rejected
+// with a reason. A rejected vendor may start a new verification.
+const (
+	StatusPending  = "pending"
+	StatusInReview = "in_review"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// transitions are the statuses an admin may move a verification to, by
+// its current status
+var transitions = map[string][]string{
+	StatusPending:  {StatusInReview},
+	StatusInReview: {StatusApproved, StatusRejected},
+}
+
+// Document kinds
+const (
+	DocumentBusinessLicense = "business_license"
+	DocumentNationalID      = "national_id"
+	DocumentTaxCertificate  = "tax_certificate"
+	DocumentOther           = "other"
+)
+
+// documentTypes are the accepted document content types and their file
+// extensions
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// Verification is a vendor's request to be verified with the business
+// documents it uploaded
+type Verification struct {
+	ID              string     `json:"id"`
+	VendorID        string     `json:"vendorId"`
+	UserID          string     `json:"userId"` // owner of the vendor, notified of decisions
+	BusinessName    string     `json:"businessName"`
+	Status          string     `json:"status"`
+	RejectionReason *string    `json:"rejectionReason,omitempty"`
+	ReviewedBy      *string    `json:"reviewedBy,omitempty"`
+	ReviewStartedAt *time.Time `json:"reviewStartedAt,omitempty"`
+	DecidedAt       *time.Time `json:"decidedAt,omitempty"`
+	Documents       []Document `json:"documents"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// Document is an uploaded business document. Documents are kept in private
+// storage and only admins can download them.
+type Document struct {
+	ID             string    `json:"id"`
+	VerificationID string    `json:"verificationId"`
+	Kind           string    `json:"kind"`
+	FileName       string    `json:"fileName"`
+	ContentType    string    `json:"contentType"`
+	Size           int64     `json:"size"`
+	Checksum       string    `json:"checksum"` // SHA-256, hex encoded
+	ObjectKey      string    `json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// UploadDocumentRequest is a document uploaded by a vendor
+type UploadDocumentRequest struct {
+	Kind     string
+	FileName string
+	Data     []byte
+}
+
+// UpdateStatusRequest represents an admin's request to move a verification
+// to another status
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"` // required to reject
+}
+
+// ListVerificationsRequest represents a request to list verifications,
+// newest first
+type ListVerificationsRequest struct {
+	Status   string `json:"status" form:"status"` // pending by default
+	PageSize int    `json:"pageSize" form:"pageSize"`
+	Cursor   string `json:"cursor" form:"cursor"` // nextCursor of the previous page
+}
+
+// ListVerificationsResponse represents a page of verifications
+type ListVerificationsResponse struct {
+	Verifications []Verification `json:"verifications"`
+	PageSize      int            `json:"pageSize"`
+	NextCursor    string         `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// Config configures vendor verification
+type Config struct {
+	MaxDocumentSize int64 // bytes of one document
+	MaxDocuments    int   // documents of one verification
+}
+
+// DefaultConfig returns the default vendor verification configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxDocumentSize: 10 << 20,
+		MaxDocuments:    10,
+	}
+}
@@ -0,0 +1,26 @@
+package verification
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes mounts the vendor's verification routes on an authenticated router group
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	verification := router.Group("/vendors/me/verification")
+	{
+		verification.GET("", handler.GetVendorVerification)                   // GET /api/vendors/me/verification
+		verification.POST("/documents", handler.UploadDocument)               // POST /api/vendors/me/verification/documents
+		verification.DELETE("/documents/:documentId", handler.RemoveDocument) // DELETE /api/vendors/me/verification/documents/:documentId
+	}
+}
+
+// SetupAdminRoutes mounts the verification review queue on an admin-only router group
+func SetupAdminRoutes(router *gin.RouterGroup, handler *Handler) {
+	verifications := router.Group("/vendor-verifications")
+	{
+		verifications.GET("", handler.ListVerifications)                          // GET /admin/vendor-verifications
+		verifications.GET("/:id", handler.GetVerification)                        // GET /admin/vendor-verifications/:id
+		verifications.GET("/:id/documents/:documentId", handler.DownloadDocument) // GET /admin/vendor-verifications/:id/documents/:documentId
+		verifications.PUT("/:id/status", handler.UpdateStatus)                    // PUT /admin/vendor-verifications/:id/status
+	}
+}
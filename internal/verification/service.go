@@ -0,0 +1,287 @@
+package verification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// maxReasonLength bounds a rejection reason in bytes
+const maxReasonLength = 1000
+
+// Page sizes of the review queue
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ErrVendorNotFound is returned when the user has no vendor profile
+var ErrVendorNotFound = fmt.Errorf("vendor: %w", common.ErrNotFound)
+
+// ErrVerificationNotFound is returned when a verification doesn't exist
+var ErrVerificationNotFound = fmt.Errorf("vendor verification: %w", common.ErrNotFound)
+
+// ErrInReview is returned when a vendor changes documents under review
+var ErrInReview = fmt.Errorf("%w: verification is under review", common.ErrConflict)
+
+// ErrAlreadyVerified is returned when an approved vendor uploads documents
+var ErrAlreadyVerified = fmt.Errorf("%w: vendor is already verified", common.ErrConflict)
+
+// ErrTooManyDocuments is returned when a verification holds the maximum
+// number of documents
+var ErrTooManyDocuments = fmt.Errorf("%w: too many documents", common.ErrValidation)
+
+// Service manages vendor verifications for vendors and admins
+type Service struct {
+	store    Store
+	storage  DocumentStorage
+	notifier Notifier
+	config   Config
+}
+
+// NewService creates a new verification service
+func NewService(store Store, storage DocumentStorage, config Config) *Service {
+	defaults := DefaultConfig()
+	if config.MaxDocumentSize <= 0 {
+		config.MaxDocumentSize = defaults.MaxDocumentSize
+	}
+	if config.MaxDocuments <= 0 {
+		config.MaxDocuments = defaults.MaxDocuments
+	}
+	return &Service{store: store, storage: storage, config: config}
+}
+
+// SetNotifier notifies vendors when their verification is decided
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// MaxDocumentSize returns the largest document a vendor may upload in bytes
+func (s *Service) MaxDocumentSize() int64 {
+	return s.config.MaxDocumentSize
+}
+
+// GetVendorVerification returns the latest verification of the user's vendor
+func (s *Service) GetVendorVerification(ctx context.Context, userID string) (*Verification, error) {
+	vendorID, err := s.store.GetVendorID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetLatestVerification(ctx, vendorID)
+}
+
+// UploadDocument stores a business document of the user's vendor and adds it
+// to its pending verification. The document is written before it is
+// recorded and deleted again when it can't be.
+func (s *Service) UploadDocument(ctx context.Context, userID string, req UploadDocumentRequest) (*Verification, error) {
+	if !validKind(req.Kind) {
+		return nil, fmt.Errorf("%w: unknown document kind %q", common.ErrValidation, req.Kind)
+	}
+	if len(req.Data) == 0 {
+		return nil, fmt.Errorf("%w: document is empty", common.ErrValidation)
+	}
+	if int64(len(req.Data)) > s.config.MaxDocumentSize {
+		return nil, fmt.Errorf("%w: document exceeds %d bytes", common.ErrValidation, s.config.MaxDocumentSize)
+	}
+	contentType := http.DetectContentType(req.Data)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	ext, ok := documentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: documents must be PDF, JPEG or PNG", common.ErrValidation)
+	}
+
+	vendorID, err := s.store.GetVendorID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(req.Data)
+	document := Document{
+		ID:          uuid.New().String(),
+		Kind:        req.Kind,
+		FileName:    documentFileName(req.FileName, ext),
+		ContentType: contentType,
+		Size:        int64(len(req.Data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+	}
+	document.ObjectKey = fmt.Sprintf("verification/%s/%s%s", vendorID, document.ID, ext)
+
+	if err := s.storage.WriteObject(ctx, document.ObjectKey, req.Data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+	verification, err := s.store.AddDocument(ctx, vendorID, document, s.config.MaxDocuments)
+	if err != nil {
+		if deleteErr := s.storage.DeleteObject(ctx, document.ObjectKey); deleteErr != nil {
+			log.Printf("Failed to delete unrecorded verification document %s: %v", document.ObjectKey, deleteErr)
+		}
+		return nil, err
+	}
+	return verification, nil
+}
+
+// RemoveDocument deletes a document of the user's pending verification
+func (s *Service) RemoveDocument(ctx context.Context, userID, documentID string) (*Verification, error) {
+	if _, err := uuid.Parse(documentID); err != nil {
+		return nil, fmt.Errorf("document %s: %w", documentID, common.ErrNotFound)
+	}
+	vendorID, err := s.store.GetVendorID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := s.store.RemoveDocument(ctx, vendorID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.DeleteObject(ctx, document.ObjectKey); err != nil {
+		log.Printf("Failed to delete verification document %s: %v", document.ObjectKey, err)
+	}
+	return s.store.GetLatestVerification(ctx, vendorID)
+}
+
+// ListVerifications lists the review queue, pending verifications by default
+func (s *Service) ListVerifications(ctx context.Context, req ListVerificationsRequest) (*ListVerificationsResponse, error) {
+	if req.Status == "" {
+		req.Status = StatusPending
+	}
+	if !validStatus(req.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+	if req.PageSize > maxPageSize {
+		req.PageSize = maxPageSize
+	}
+	cursor, err := common.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications, err := s.store.ListVerifications(ctx, req, cursor)
+	if err != nil {
+		return nil, err
+	}
+	verifications, nextCursor := common.NextPage(verifications, req.PageSize, func(verification Verification) (time.Time, string) {
+		return verification.CreatedAt, verification.ID
+	})
+	return &ListVerificationsResponse{Verifications: verifications, PageSize: req.PageSize, NextCursor: nextCursor}, nil
+}
+
+// GetVerification returns a verification with its documents
+func (s *Service) GetVerification(ctx context.Context, id string) (*Verification, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrVerificationNotFound
+	}
+	return s.store.GetVerification(ctx, id)
+}
+
+// OpenDocument returns a document of a verification with its contents
+func (s *Service) OpenDocument(ctx context.Context, id, documentID string) (*Document, []byte, error) {
+	verification, err := s.GetVerification(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, document := range verification.Documents {
+		if document.ID != documentID {
+			continue
+		}
+		data, err := s.storage.ReadObject(ctx, document.ObjectKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read document: %w", err)
+		}
+		return &document, data, nil
+	}
+	return nil, nil, fmt.Errorf("document %s: %w", documentID, common.ErrNotFound)
+}
+
+// UpdateStatus moves a verification along the review workflow and notifies
+// the vendor of decisions. Rejections need a reason the vendor can act on.
+func (s *Service) UpdateStatus(ctx context.Context, adminID, id string, req UpdateStatusRequest) (*Verification, error) {
+	verification, err := s.GetVerification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !validStatus(req.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrValidation, req.Status)
+	}
+	if !canTransition(verification.Status, req.Status) {
+		return nil, fmt.Errorf("%w: verification is %s and can't become %s", common.ErrConflict, verification.Status, req.Status)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.Status == StatusRejected && reason == "" {
+		return nil, fmt.Errorf("%w: reason is required to reject", common.ErrValidation)
+	}
+	if len(reason) > maxReasonLength {
+		return nil, fmt.Errorf("%w: reason must be at most %d bytes", common.ErrValidation, maxReasonLength)
+	}
+	if req.Status != StatusRejected {
+		reason = ""
+	}
+	if req.Status == StatusApproved && len(verification.Documents) == 0 {
+		return nil, fmt.Errorf("%w: verification has no documents", common.ErrConflict)
+	}
+
+	verification, err = s.store.UpdateStatus(ctx, id, verification.Status, req.Status, adminID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil && (verification.Status == StatusApproved || verification.Status == StatusRejected) {
+		if err := s.notifier.SendVendorVerificationDecision(ctx, verification.UserID, verification.ID, verification.Status, reason); err != nil {
+			log.Printf("Failed to notify user %s of the decision on verification %s: %v", verification.UserID, verification.ID, err)
+		}
+	}
+	return verification, nil
+}
+
+func canTransition(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusInReview, StatusApproved, StatusRejected:
+		return true
+	}
+	return false
+}
+
+func validKind(kind string) bool {
+	switch kind {
+	case DocumentBusinessLicense, DocumentNationalID, DocumentTaxCertificate, DocumentOther:
+		return true
+	}
+	return false
+}
+
+// documentFileName keeps the base name of an uploaded file for admins,
+// falling back to a name with the detected extension
+func documentFileName(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "document" + ext
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
@@ -0,0 +1,337 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+const (
+	testUserID   = "7f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	testVendorID = "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+	testAdminID  = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+)
+
+var (
+	testPDF = []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+)
+
+// fakeStore keeps the verifications of one vendor in memory
+type fakeStore struct {
+	verifications []*Verification
+	verified      bool // the vendor's verified flag
+	failAdd       bool
+}
+
+func (f *fakeStore) GetVendorID(ctx context.Context, userID string) (string, error) {
+	if userID != testUserID {
+		return "", ErrVendorNotFound
+	}
+	return testVendorID, nil
+}
+
+func (f *fakeStore) latest() *Verification {
+	if len(f.verifications) == 0 {
+		return nil
+	}
+	return f.verifications[len(f.verifications)-1]
+}
+
+func (f *fakeStore) GetLatestVerification(ctx context.Context, vendorID string) (*Verification, error) {
+	if verification := f.latest(); verification != nil {
+		return verification, nil
+	}
+	return nil, ErrVerificationNotFound
+}
+
+func (f *fakeStore) GetVerification(ctx context.Context, id string) (*Verification, error) {
+	for _, verification := range f.verifications {
+		if verification.ID == id {
+			return verification, nil
+		}
+	}
+	return nil, ErrVerificationNotFound
+}
+
+func (f *fakeStore) AddDocument(ctx context.Context, vendorID string, document Document, maxDocuments int) (*Verification, error) {
+	if f.failAdd {
+		return nil, errors.New("database is down")
+	}
+	verification := f.latest()
+	switch {
+	case verification == nil, verification.Status == StatusRejected:
+		verification = &Verification{
+			ID:        fmt.Sprintf("00000000-0000-4000-8000-%012d", len(f.verifications)+1),
+			VendorID:  vendorID,
+			UserID:    testUserID,
+			Status:    StatusPending,
+			Documents: []Document{},
+			CreatedAt: time.Now(),
+		}
+		f.verifications = append(f.verifications, verification)
+	case verification.Status == StatusInReview:
+		return nil, ErrInReview
+	case verification.Status == StatusApproved:
+		return nil, ErrAlreadyVerified
+	case len(verification.Documents) >= maxDocuments:
+		return nil, ErrTooManyDocuments
+	}
+	document.VerificationID = verification.ID
+	verification.Documents = append(verification.Documents, document)
+	return verification, nil
+}
+
+func (f *fakeStore) RemoveDocument(ctx context.Context, vendorID, documentID string) (*Document, error) {
+	verification := f.latest()
+	if verification == nil {
+		return nil, common.ErrNotFound
+	}
+	for i, document := range verification.Documents {
+		if document.ID != documentID {
+			continue
+		}
+		if verification.Status != StatusPending {
+			return nil, common.ErrConflict
+		}
+		verification.Documents = append(verification.Documents[:i], verification.Documents[i+1:]...)
+		return &document, nil
+	}
+	return nil, common.ErrNotFound
+}
+
+func (f *fakeStore) ListVerifications(ctx context.Context, req ListVerificationsRequest, cursor *common.Cursor) ([]Verification, error) {
+	verifications := []Verification{}
+	for _, verification := range f.verifications {
+		if verification.Status == req.Status {
+			verifications = append(verifications, *verification)
+		}
+	}
+	return verifications, nil
+}
+
+func (f *fakeStore) UpdateStatus(ctx context.Context, id, from, status, adminID, reason string) (*Verification, error) {
+	verification, err := f.GetVerification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if verification.Status != from {
+		return nil, common.ErrConflict
+	}
+	verification.Status = status
+	verification.ReviewedBy = &adminID
+	if reason != "" {
+		verification.RejectionReason = &reason
+	}
+	if status == StatusApproved || status == StatusRejected {
+		f.verified = status == StatusApproved
+	}
+	return verification, nil
+}
+
+// fakeStorage keeps objects in memory
+type fakeStorage map[string][]byte
+
+func (f fakeStorage) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := f[key]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	return data, nil
+}
+
+func (f fakeStorage) WriteObject(ctx context.Context, key string, data []byte, contentType string) error {
+	f[key] = data
+	return nil
+}
+
+func (f fakeStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(f, key)
+	return nil
+}
+
+// fakeNotifier records the decisions vendors were told about
+type fakeNotifier struct {
+	decisions []string
+}
+
+func (f *fakeNotifier) SendVendorVerificationDecision(ctx context.Context, userID, verificationID, status, reason string) error {
+	f.decisions = append(f.decisions, status+":"+reason)
+	return nil
+}
+
+func newTestService() (*Service, *fakeStore, fakeStorage, *fakeNotifier) {
+	store := &fakeStore{}
+	objects := fakeStorage{}
+	notifier := &fakeNotifier{}
+	service := NewService(store, objects, Config{MaxDocumentSize: 1 << 10, MaxDocuments: 2})
+	service.SetNotifier(notifier)
+	return service, store, objects, notifier
+}
+
+func upload(t *testing.T, service *Service, kind string, data []byte) *Verification {
+	t.Helper()
+	verification, err := service.UploadDocument(context.Background(), testUserID, UploadDocumentRequest{
+		Kind:     kind,
+		FileName: "../docs/license.pdf",
+		Data:     data,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return verification
+}
+
+func TestUploadDocument(t *testing.T) {
+	ctx := context.Background()
+	service, store, objects, _ := newTestService()
+
+	verification := upload(t, service, DocumentBusinessLicense, testPDF)
+	if verification.Status != StatusPending || len(verification.Documents) != 1 {
+		t.Fatalf("Expected a pending verification with one document, got %+v", verification)
+	}
+	document := verification.Documents[0]
+	if document.ContentType != "application/pdf" || document.FileName != "license.pdf" || document.Size != int64(len(testPDF)) {
+		t.Errorf("Expected the detected type and base file name, got %+v", document)
+	}
+	if string(objects[document.ObjectKey]) != string(testPDF) {
+		t.Errorf("Expected the document stored under %s", document.ObjectKey)
+	}
+
+	upload(t, service, DocumentNationalID, testPNG)
+	if _, err := service.UploadDocument(ctx, testUserID, UploadDocumentRequest{Kind: DocumentOther, Data: testPNG}); !errors.Is(err, ErrTooManyDocuments) {
+		t.Errorf("Expected too many documents, got %v", err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("Expected the rejected upload to be deleted again, got %d objects", len(objects))
+	}
+
+	// Removing a document makes room for another
+	if _, err := service.RemoveDocument(ctx, testUserID, document.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := objects[document.ObjectKey]; ok {
+		t.Errorf("Expected the removed document to be deleted from storage")
+	}
+	if verification := upload(t, service, DocumentOther, testPDF); len(verification.Documents) != 2 || len(store.verifications) != 1 {
+		t.Errorf("Expected the same verification with two documents, got %+v", verification)
+	}
+}
+
+func TestUploadDocument_Invalid(t *testing.T) {
+	ctx := context.Background()
+	service, store, objects, _ := newTestService()
+
+	for name, req := range map[string]UploadDocumentRequest{
+		"unknown kind": {Kind: "selfie", Data: testPDF},
+		"empty":        {Kind: DocumentOther},
+		"too large":    {Kind: DocumentOther, Data: append(append([]byte{}, testPDF...), make([]byte, 2<<10)...)},
+		"not a pdf":    {Kind: DocumentOther, Data: []byte("<html><body>hello</body></html>")},
+	} {
+		if _, err := service.UploadDocument(ctx, testUserID, req); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error for %s, got %v", name, err)
+		}
+	}
+
+	if _, err := service.UploadDocument(ctx, "no-vendor", UploadDocumentRequest{Kind: DocumentOther, Data: testPDF}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected users without a vendor to be not found, got %v", err)
+	}
+
+	store.failAdd = true
+	if _, err := service.UploadDocument(ctx, testUserID, UploadDocumentRequest{Kind: DocumentOther, Data: testPDF}); err == nil {
+		t.Errorf("Expected the store error")
+	}
+	if len(objects) != 0 {
+		t.Errorf("Expected no objects left behind, got %d", len(objects))
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("approve", func(t *testing.T) {
+		service, store, objects, notifier := newTestService()
+		id := upload(t, service, DocumentBusinessLicense, testPDF).ID
+
+		queue, err := service.ListVerifications(ctx, ListVerificationsRequest{})
+		if err != nil || len(queue.Verifications) != 1 {
+			t.Fatalf("Expected the pending verification in the queue, got %+v: %v", queue, err)
+		}
+
+		// Decisions need a review first
+		if _, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusApproved}); !errors.Is(err, common.ErrConflict) {
+			t.Errorf("Expected approving a pending verification to conflict, got %v", err)
+		}
+		if _, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusInReview}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := service.UploadDocument(ctx, testUserID, UploadDocumentRequest{Kind: DocumentOther, Data: testPNG}); !errors.Is(err, ErrInReview) {
+			t.Errorf("Expected uploads under review to conflict, got %v", err)
+		}
+
+		verification, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusApproved, Reason: "ignored"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if verification.Status != StatusApproved || verification.RejectionReason != nil || !store.verified {
+			t.Errorf("Expected an approved and verified vendor, got %+v", verification)
+		}
+		if len(notifier.decisions) != 1 || notifier.decisions[0] != "approved:" {
+			t.Errorf("Expected the vendor to be told of the approval, got %v", notifier.decisions)
+		}
+
+		document, data, err := service.OpenDocument(ctx, id, verification.Documents[0].ID)
+		if err != nil || string(data) != string(objects[document.ObjectKey]) {
+			t.Errorf("Expected the document contents, got %v", err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		service, store, _, notifier := newTestService()
+		id := upload(t, service, DocumentBusinessLicense, testPDF).ID
+		if _, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusInReview}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusRejected, Reason: "  "}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a rejection without reason to fail validation, got %v", err)
+		}
+		verification, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: StatusRejected, Reason: "license expired"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if verification.Status != StatusRejected || verification.RejectionReason == nil || *verification.RejectionReason != "license expired" || store.verified {
+			t.Errorf("Expected a rejected verification with its reason, got %+v", verification)
+		}
+		if len(notifier.decisions) != 1 || notifier.decisions[0] != "rejected:license expired" {
+			t.Errorf("Expected the vendor to be told of the rejection, got %v", notifier.decisions)
+		}
+
+		// A rejected vendor applies again with a new verification
+		if again := upload(t, service, DocumentBusinessLicense, testPNG); again.ID == id || again.Status != StatusPending {
+			t.Errorf("Expected a new pending verification, got %+v", again)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		service, _, _, _ := newTestService()
+		id := upload(t, service, DocumentBusinessLicense, testPDF).ID
+
+		if _, err := service.UpdateStatus(ctx, testAdminID, id, UpdateStatusRequest{Status: "done"}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+		if _, err := service.UpdateStatus(ctx, testAdminID, "not-a-uuid", UpdateStatusRequest{Status: StatusInReview}); !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("Expected an invalid ID to be not found, got %v", err)
+		}
+		if _, err := service.ListVerifications(ctx, ListVerificationsRequest{Status: "unknown"}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+		if _, _, err := service.OpenDocument(ctx, id, "missing"); !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("Expected a missing document to be not found, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,362 @@
+package verification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// verificationColumns are the columns scanned by scanVerification; r is
+// vendor_verifications and v the vendors table
+const verificationColumns = `r.id, r.vendor_id, v.user_id, v.business_name, r.status, r.rejection_reason,
+	r.reviewed_by, r.review_started_at, r.decided_at, r.created_at, r.updated_at`
+
+const verificationFrom = `vendor_verifications r JOIN vendors v ON v.id = r.vendor_id`
+
+// documentColumns are the vendor_verification_documents columns scanned by
+// scanDocument
+const documentColumns = `id, verification_id, kind, file_name, content_type, size_bytes, checksum, object_key, created_at`
+
+// DBStore implements Store on top of the vendor_verifications tables
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a new database store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// GetVendorID returns the ID of the vendor owned by a user
+func (s *DBStore) GetVendorID(ctx context.Context, userID string) (string, error) {
+	var vendorID string
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM vendors WHERE user_id = $1`, userID).Scan(&vendorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrVendorNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get vendor: %w", err)
+	}
+	return vendorID, nil
+}
+
+// GetLatestVerification returns the most recent verification of a vendor
+func (s *DBStore) GetLatestVerification(ctx context.Context, vendorID string) (*Verification, error) {
+	verification, err := scanVerification(s.db.QueryRowContext(ctx, `
+		SELECT `+verificationColumns+`
+		FROM `+verificationFrom+`
+		WHERE r.vendor_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT 1`, vendorID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return verification, s.loadDocuments(ctx, []*Verification{verification})
+}
+
+// GetVerification returns a verification with its documents
+func (s *DBStore) GetVerification(ctx context.Context, id string) (*Verification, error) {
+	verification, err := scanVerification(s.db.QueryRowContext(ctx, `
+		SELECT `+verificationColumns+`
+		FROM `+verificationFrom+`
+		WHERE r.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return verification, s.loadDocuments(ctx, []*Verification{verification})
+}
+
+// AddDocument adds a document to the vendor's open verification, starting a
+// new one when there is none. The vendor row is locked so concurrent
+// uploads neither start two verifications nor exceed maxDocuments.
+func (s *DBStore) AddDocument(ctx context.Context, vendorID string, document Document, maxDocuments int) (*Verification, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM vendors WHERE id = $1 FOR UPDATE`, vendorID); err != nil {
+		return nil, fmt.Errorf("failed to lock vendor: %w", err)
+	}
+
+	var id, status string
+	var documents int
+	err = tx.QueryRowContext(ctx, `
+		SELECT r.id, r.status, (SELECT COUNT(*) FROM vendor_verification_documents d WHERE d.verification_id = r.id)
+		FROM vendor_verifications r
+		WHERE r.vendor_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT 1`, vendorID).Scan(&id, &status, &documents)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows), status == StatusRejected:
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO vendor_verifications (vendor_id) VALUES ($1) RETURNING id`, vendorID).Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to create verification: %w", err)
+		}
+	case status == StatusInReview:
+		return nil, ErrInReview
+	case status == StatusApproved:
+		return nil, ErrAlreadyVerified
+	case documents >= maxDocuments:
+		return nil, ErrTooManyDocuments
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO vendor_verification_documents
+			(id, verification_id, kind, file_name, content_type, size_bytes, checksum, object_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		document.ID, id, document.Kind, document.FileName, document.ContentType,
+		document.Size, document.Checksum, document.ObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verification document: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE vendor_verifications SET updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetVerification(ctx, id)
+}
+
+// RemoveDocument deletes a document of the vendor's pending verification
+func (s *DBStore) RemoveDocument(ctx context.Context, vendorID, documentID string) (*Document, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	document, err := scanDocument(tx.QueryRowContext(ctx, `
+		SELECT d.id, d.verification_id, d.kind, d.file_name, d.content_type, d.size_bytes, d.checksum,
+			d.object_key, d.created_at, r.status
+		FROM vendor_verification_documents d
+		JOIN vendor_verifications r ON r.id = d.verification_id
+		WHERE d.id = $1 AND r.vendor_id = $2
+		FOR UPDATE OF r`, documentID, vendorID), &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("document %s: %w", documentID, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusPending {
+		return nil, fmt.Errorf("%w: documents of a verification that is %s can't be removed", common.ErrConflict, status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM vendor_verification_documents WHERE id = $1`, documentID); err != nil {
+		return nil, fmt.Errorf("failed to delete verification document: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE vendor_verifications SET updated_at = NOW() WHERE id = $1`, document.VerificationID); err != nil {
+		return nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return document, nil
+}
+
+// ListVerifications returns the verifications matching req, newest first
+func (s *DBStore) ListVerifications(ctx context.Context, req ListVerificationsRequest, cursor *common.Cursor) ([]Verification, error) {
+	query := `SELECT ` + verificationColumns + `
+		FROM ` + verificationFrom + `
+		WHERE r.status = $1`
+	args := []interface{}{req.Status}
+	argIndex := 2
+
+	if condition, cursorArgs := cursor.Condition("r.created_at", "r.id", argIndex); condition != "" {
+		query += " AND " + condition
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
+	}
+	clause, pageArgs := common.PageClause("r.created_at", "r.id", cursor, req.PageSize, 0, argIndex)
+	query += clause
+	args = append(args, pageArgs...)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list verifications: %w", err)
+	}
+	defer rows.Close()
+
+	verifications := []Verification{}
+	for rows.Next() {
+		verification, err := scanVerification(rows)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, *verification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list verifications: %w", err)
+	}
+
+	page := make([]*Verification, len(verifications))
+	for i := range verifications {
+		page[i] = &verifications[i]
+	}
+	return verifications, s.loadDocuments(ctx, page)
+}
+
+// UpdateStatus moves a verification from status from to status. Decisions
+// set the vendor's verified flag in the same transaction.
+func (s *DBStore) UpdateStatus(ctx context.Context, id, from, status, adminID, reason string) (*Verification, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var vendorID string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE vendor_verifications
+		SET status = $3, reviewed_by = $4,
+			rejection_reason = NULLIF($5, ''),
+			review_started_at = CASE WHEN $3 = 'in_review' THEN NOW() ELSE review_started_at END,
+			decided_at = CASE WHEN $3 IN ('approved', 'rejected') THEN NOW() END,
+			updated_at = NOW()
+		WHERE id = $1 AND status = $2
+		RETURNING vendor_id`, id, from, status, adminID, reason).Scan(&vendorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: verification is no longer %s", common.ErrConflict, from)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update verification status: %w", err)
+	}
+
+	if status == StatusApproved || status == StatusRejected {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE vendors SET is_verified = $2, updated_at = NOW() WHERE id = $1`,
+			vendorID, status == StatusApproved); err != nil {
+			return nil, fmt.Errorf("failed to update vendor: %w", err)
+		}
+	}
+
+	if err := audit(ctx, tx, adminID, "vendor_verification_"+status, map[string]interface{}{
+		"verification_id": id,
+		"vendor_id":       vendorID,
+		"from":            from,
+		"reason":          reason,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetVerification(ctx, id)
+}
+
+// loadDocuments fills in the documents of verifications
+func (s *DBStore) loadDocuments(ctx context.Context, verifications []*Verification) error {
+	if len(verifications) == 0 {
+		return nil
+	}
+	ids := make([]string, len(verifications))
+	byID := make(map[string]*Verification, len(verifications))
+	for i, verification := range verifications {
+		ids[i] = verification.ID
+		verification.Documents = []Document{}
+		byID[verification.ID] = verification
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+documentColumns+`
+		FROM vendor_verification_documents
+		WHERE verification_id = ANY($1)
+		ORDER BY created_at, id`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to list verification documents: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		document, err := scanDocument(rows)
+		if err != nil {
+			return err
+		}
+		verification := byID[document.VerificationID]
+		verification.Documents = append(verification.Documents, *document)
+	}
+	return rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVerification(row rowScanner) (*Verification, error) {
+	var verification Verification
+	var reason, reviewedBy sql.NullString
+	var reviewStartedAt, decidedAt sql.NullTime
+	err := row.Scan(&verification.ID, &verification.VendorID, &verification.UserID, &verification.BusinessName,
+		&verification.Status, &reason, &reviewedBy, &reviewStartedAt, &decidedAt,
+		&verification.CreatedAt, &verification.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan verification: %w", err)
+	}
+
+	if reason.Valid {
+		verification.RejectionReason = &reason.String
+	}
+	if reviewedBy.Valid {
+		verification.ReviewedBy = &reviewedBy.String
+	}
+	if reviewStartedAt.Valid {
+		verification.ReviewStartedAt = &reviewStartedAt.Time
+	}
+	if decidedAt.Valid {
+		verification.DecidedAt = &decidedAt.Time
+	}
+	verification.Documents = []Document{}
+	return &verification, nil
+}
+
+// scanDocument scans the documentColumns of a row followed by extra
+func scanDocument(row rowScanner, extra ...interface{}) (*Document, error) {
+	var document Document
+	dest := []interface{}{&document.ID, &document.VerificationID, &document.Kind, &document.FileName,
+		&document.ContentType, &document.Size, &document.Checksum, &document.ObjectKey, &document.CreatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan verification document: %w", err)
+	}
+	return &document, nil
+}
+
+func audit(ctx context.Context, tx *sql.Tx, adminID, action string, metadata interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, actor_type, action, resource, metadata)
+		VALUES ($1, 'admin', $2, 'vendor_verification', $3)`, adminID, action, metadataJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
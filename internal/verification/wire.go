@@ -0,0 +1,47 @@
+package verification
+
+import (
+	"database/sql"
+
+	"ai-styler/internal/config"
+	"ai-styler/internal/storage"
+)
+
+// WireVerificationService creates a verification service with all
+// dependencies. Documents are kept in the configured object store under
+// verification/ and are only served through the admin download route.
+func WireVerificationService(db *sql.DB, cfg *config.Config) (*Service, error) {
+	objects := storage.ObjectStoreConfig{
+		Backend:     cfg.Storage.Backend,
+		BasePath:    cfg.Storage.StoragePath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3Region:    cfg.Storage.S3Region,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+	}
+	reader, err := storage.NewObjectReader(objects)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := storage.NewObjectWriter(objects)
+	if err != nil {
+		return nil, err
+	}
+	deleter, err := storage.NewObjectDeleter(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewService(NewDBStore(db), &objectStorage{reader, writer, deleter}, Config{
+		MaxDocumentSize: int64(cfg.Verification.MaxDocumentSizeMB) << 20,
+		MaxDocuments:    cfg.Verification.MaxDocuments,
+	}), nil
+}
+
+// objectStorage implements DocumentStorage on the object store
+type objectStorage struct {
+	storage.ObjectReader
+	storage.ObjectWriter
+	storage.ObjectDeleter
+}
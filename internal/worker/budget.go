@@ -0,0 +1,316 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Budget degradation modes
+const (
+	BudgetDegradeFallback        = "fallback"
+	BudgetDegradeLowerResolution = "lower_resolution"
+	BudgetDegradePauseFreeTier   = "pause_free_tier"
+)
+
+// Budget alert levels
+const (
+	BudgetAlertWarning   = "warning"
+	BudgetAlertDegraded  = "degraded"
+	BudgetAlertExhausted = "exhausted"
+)
+
+var (
+	// ErrProviderBudgetExhausted is returned when the monthly cap is reached and no fallback is available
+	ErrProviderBudgetExhausted = errors.New("provider monthly budget exhausted")
+	// ErrFreeTierPaused is returned for free-tier conversions while the budget is in degraded mode
+	ErrFreeTierPaused = errors.New("free-tier conversions are temporarily paused")
+)
+
+// BudgetConfig represents the monthly budget cap for an AI provider
+type BudgetConfig struct {
+	Provider                  string   `json:"provider"`
+	MonthlyBudget             float64  `json:"monthlyBudget"`     // 0 disables the guard
+	CostPerConversion         float64  `json:"costPerConversion"` // cost or credits per call
+	AlertThreshold            float64  `json:"alertThreshold"`    // fraction of the budget that triggers a warning
+	DegradeModes              []string `json:"degradeModes"`
+	FallbackProvider          string   `json:"fallbackProvider"`
+	FallbackCostPerConversion float64  `json:"fallbackCostPerConversion"`
+	LowResolutionMaxSide      int      `json:"lowResolutionMaxSide"` // longest side in pixels when degrading resolution
+}
+
+// BudgetDecision describes how a single conversion should run given the current spend
+type BudgetDecision struct {
+	Provider        string  `json:"provider"`
+	Cost            float64 `json:"cost"`
+	UseFallback     bool    `json:"useFallback"`
+	LowerResolution bool    `json:"lowerResolution"`
+	PauseFreeTier   bool    `json:"pauseFreeTier"`
+	Spent           float64 `json:"spent"`
+	Projected       float64 `json:"projected"`
+	Reason          string  `json:"reason,omitempty"`
+}
+
+// Degraded reports whether any degradation applies to the decision
+func (d *BudgetDecision) Degraded() bool {
+	return d.UseFallback || d.LowerResolution || d.PauseFreeTier
+}
+
+// BudgetStore persists provider spend
+type BudgetStore interface {
+	RecordSpend(ctx context.Context, provider, conversionID string, cost float64, degraded bool) error
+	GetMonthlySpend(ctx context.Context, provider string, month time.Time) (float64, error)
+	IsFreeTierUser(ctx context.Context, userID string) (bool, error)
+}
+
+// BudgetAlerter sends budget alerts to admins
+type BudgetAlerter interface {
+	SendCriticalError(ctx context.Context, errorType, message string, metadata map[string]interface{}) error
+}
+
+// BudgetGuard enforces monthly spend caps on the AI provider
+type BudgetGuard struct {
+	config  BudgetConfig
+	store   BudgetStore
+	alerter BudgetAlerter
+	now     func() time.Time
+
+	mu      sync.Mutex
+	alerted map[string]bool
+}
+
+// NewBudgetGuard creates a new budget guard
+func NewBudgetGuard(config BudgetConfig, store BudgetStore, alerter BudgetAlerter) *BudgetGuard {
+	if config.AlertThreshold <= 0 || config.AlertThreshold > 1 {
+		config.AlertThreshold = 0.8
+	}
+	if config.LowResolutionMaxSide <= 0 {
+		config.LowResolutionMaxSide = 768
+	}
+
+	return &BudgetGuard{
+		config:  config,
+		store:   store,
+		alerter: alerter,
+		now:     time.Now,
+		alerted: make(map[string]bool),
+	}
+}
+
+// Enabled reports whether a budget cap is configured
+func (g *BudgetGuard) Enabled() bool {
+	return g != nil && g.config.MonthlyBudget > 0
+}
+
+// Evaluate decides how the next conversion should run based on the month's spend
+func (g *BudgetGuard) Evaluate(ctx context.Context) (*BudgetDecision, error) {
+	decision := &BudgetDecision{
+		Provider: g.config.Provider,
+		Cost:     g.config.CostPerConversion,
+	}
+	if !g.Enabled() {
+		return decision, nil
+	}
+
+	now := g.now()
+	spent, err := g.store.GetMonthlySpend(ctx, g.config.Provider, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly spend: %w", err)
+	}
+	decision.Spent = spent
+	decision.Projected = projectMonthlySpend(spent, now)
+
+	budget := g.config.MonthlyBudget
+
+	// Hard cap: the next call would go over budget
+	if spent+g.config.CostPerConversion > budget {
+		if !g.hasMode(BudgetDegradeFallback) || g.config.FallbackProvider == "" {
+			decision.Reason = fmt.Sprintf("spent %.2f of %.2f monthly budget", spent, budget)
+			g.alert(ctx, BudgetAlertExhausted, now, decision)
+			return decision, ErrProviderBudgetExhausted
+		}
+		decision.Reason = fmt.Sprintf("monthly budget of %.2f reached, using fallback provider", budget)
+		g.applyDegradation(decision)
+		g.alert(ctx, BudgetAlertExhausted, now, decision)
+		return decision, nil
+	}
+
+	// Soft cap: the month is on track to exceed the budget
+	if decision.Projected > budget {
+		decision.Reason = fmt.Sprintf("projected spend %.2f exceeds monthly budget of %.2f", decision.Projected, budget)
+		g.applyDegradation(decision)
+		g.alert(ctx, BudgetAlertDegraded, now, decision)
+		return decision, nil
+	}
+
+	if spent >= budget*g.config.AlertThreshold {
+		decision.Reason = fmt.Sprintf("spent %.0f%% of monthly budget", spent/budget*100)
+		g.alert(ctx, BudgetAlertWarning, now, decision)
+	}
+
+	return decision, nil
+}
+
+// RecordSpend records the cost of a completed conversion
+func (g *BudgetGuard) RecordSpend(ctx context.Context, decision *BudgetDecision, conversionID string) error {
+	if g == nil || g.store == nil || decision == nil {
+		return nil
+	}
+	return g.store.RecordSpend(ctx, decision.Provider, conversionID, decision.Cost, decision.Degraded())
+}
+
+// IsFreeTierUser reports whether the user has no active paid plan
+func (g *BudgetGuard) IsFreeTierUser(ctx context.Context, userID string) (bool, error) {
+	return g.store.IsFreeTierUser(ctx, userID)
+}
+
+// LowResolutionMaxSide returns the longest side used when degrading resolution
+func (g *BudgetGuard) LowResolutionMaxSide() int {
+	return g.config.LowResolutionMaxSide
+}
+
+// applyDegradation applies the configured degradation modes to the decision
+func (g *BudgetGuard) applyDegradation(decision *BudgetDecision) {
+	if g.hasMode(BudgetDegradeFallback) && g.config.FallbackProvider != "" {
+		decision.UseFallback = true
+		decision.Provider = g.config.FallbackProvider
+		decision.Cost = g.config.FallbackCostPerConversion
+	}
+	decision.LowerResolution = g.hasMode(BudgetDegradeLowerResolution)
+	decision.PauseFreeTier = g.hasMode(BudgetDegradePauseFreeTier)
+}
+
+// hasMode checks whether a degradation mode is enabled
+func (g *BudgetGuard) hasMode(mode string) bool {
+	for _, m := range g.config.DegradeModes {
+		if strings.TrimSpace(m) == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// alert notifies admins once per provider, month and level
+func (g *BudgetGuard) alert(ctx context.Context, level string, now time.Time, decision *BudgetDecision) {
+	key := fmt.Sprintf("%s:%s:%s", g.config.Provider, now.Format("2006-01"), level)
+
+	g.mu.Lock()
+	if g.alerted[key] {
+		g.mu.Unlock()
+		return
+	}
+	g.alerted[key] = true
+	g.mu.Unlock()
+
+	log.Printf("Provider budget %s for %s: %s", level, g.config.Provider, decision.Reason)
+
+	if g.alerter == nil {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"provider":         g.config.Provider,
+		"level":            level,
+		"month":            now.Format("2006-01"),
+		"monthly_budget":   g.config.MonthlyBudget,
+		"spent":            decision.Spent,
+		"projected":        decision.Projected,
+		"use_fallback":     decision.UseFallback,
+		"lower_resolution": decision.LowerResolution,
+		"pause_free_tier":  decision.PauseFreeTier,
+	}
+	if err := g.alerter.SendCriticalError(ctx, "provider_budget_"+level, decision.Reason, metadata); err != nil {
+		log.Printf("Failed to send budget alert: %v", err)
+	}
+}
+
+// projectMonthlySpend extrapolates month-to-date spend linearly to the end of the month.
+// At least one day is assumed elapsed so early-month spikes are not blown out of proportion.
+func projectMonthlySpend(spent float64, now time.Time) float64 {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	elapsed := now.Sub(monthStart)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+
+	return spent * float64(monthEnd.Sub(monthStart)) / float64(elapsed)
+}
+
+// ParseBudgetDegradeModes splits a comma separated list of degradation modes
+func ParseBudgetDegradeModes(value string) []string {
+	var modes []string
+	for _, m := range strings.Split(value, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			modes = append(modes, m)
+		}
+	}
+	return modes
+}
+
+// dbBudgetStore implements BudgetStore on top of PostgreSQL
+type dbBudgetStore struct {
+	db *sql.DB
+}
+
+// NewDBBudgetStore creates a new database-backed budget store
+func NewDBBudgetStore(db *sql.DB) BudgetStore {
+	return &dbBudgetStore{db: db}
+}
+
+// RecordSpend inserts a spend row for a provider call
+func (s *dbBudgetStore) RecordSpend(ctx context.Context, provider, conversionID string, cost float64, degraded bool) error {
+	var convID interface{}
+	if conversionID != "" {
+		convID = conversionID
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO provider_spend (provider, conversion_id, cost, degraded)
+		VALUES ($1, $2, $3, $4)`,
+		provider, convID, cost, degraded)
+	if err != nil {
+		return fmt.Errorf("failed to record provider spend: %w", err)
+	}
+	return nil
+}
+
+// GetMonthlySpend sums the provider spend for the calendar month containing month
+func (s *dbBudgetStore) GetMonthlySpend(ctx context.Context, provider string, month time.Time) (float64, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	end := start.AddDate(0, 1, 0)
+
+	var spent float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(cost), 0)
+		FROM provider_spend
+		WHERE provider = $1 AND created_at >= $2 AND created_at < $3`,
+		provider, start, end).Scan(&spent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get provider spend: %w", err)
+	}
+	return spent, nil
+}
+
+// IsFreeTierUser reports whether the user has no active paid plan
+func (s *dbBudgetStore) IsFreeTierUser(ctx context.Context, userID string) (bool, error) {
+	var free bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM user_plans
+			WHERE user_id = $1
+			  AND status = 'active'
+			  AND price_per_month_cents > 0
+			  AND (expires_at IS NULL OR expires_at > NOW())
+		)`, userID).Scan(&free)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user plan: %w", err)
+	}
+	return free, nil
+}
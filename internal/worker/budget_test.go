@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeBudgetStore struct {
+	spent    float64
+	recorded []string
+}
+
+func (s *fakeBudgetStore) RecordSpend(ctx context.Context, provider, conversionID string, cost float64, degraded bool) error {
+	s.spent += cost
+	s.recorded = append(s.recorded, provider)
+	return nil
+}
+
+func (s *fakeBudgetStore) GetMonthlySpend(ctx context.Context, provider string, month time.Time) (float64, error) {
+	return s.spent, nil
+}
+
+func (s *fakeBudgetStore) IsFreeTierUser(ctx context.Context, userID string) (bool, error) {
+	return true, nil
+}
+
+type countingAlerter struct {
+	alerts []string
+}
+
+func (a *countingAlerter) SendCriticalError(ctx context.Context, errorType, message string, metadata map[string]interface{}) error {
+	a.alerts = append(a.alerts, errorType)
+	return nil
+}
+
+func newTestBudgetGuard(store BudgetStore, alerter BudgetAlerter, modes []string, fallback string) *BudgetGuard {
+	guard := NewBudgetGuard(BudgetConfig{
+		Provider:                  "gemini:primary",
+		MonthlyBudget:             100,
+		CostPerConversion:         1,
+		AlertThreshold:            0.8,
+		DegradeModes:              modes,
+		FallbackProvider:          fallback,
+		FallbackCostPerConversion: 0.25,
+	}, store, alerter)
+	// Halfway through a 30-day month
+	guard.now = func() time.Time { return time.Date(2025, time.June, 16, 0, 0, 0, 0, time.UTC) }
+	return guard
+}
+
+func TestBudgetGuard_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	modes := []string{BudgetDegradeFallback, BudgetDegradeLowerResolution, BudgetDegradePauseFreeTier}
+
+	t.Run("under budget", func(t *testing.T) {
+		alerter := &countingAlerter{}
+		guard := newTestBudgetGuard(&fakeBudgetStore{spent: 20}, alerter, modes, "gemini:fallback")
+
+		decision, err := guard.Evaluate(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if decision.Degraded() {
+			t.Errorf("Expected no degradation, got %+v", decision)
+		}
+		if len(alerter.alerts) != 0 {
+			t.Errorf("Expected no alerts, got %v", alerter.alerts)
+		}
+	})
+
+	t.Run("projected over budget degrades", func(t *testing.T) {
+		alerter := &countingAlerter{}
+		guard := newTestBudgetGuard(&fakeBudgetStore{spent: 60}, alerter, modes, "gemini:fallback")
+
+		decision, err := guard.Evaluate(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !decision.UseFallback || !decision.LowerResolution || !decision.PauseFreeTier {
+			t.Errorf("Expected full degradation, got %+v", decision)
+		}
+		if decision.Provider != "gemini:fallback" || decision.Cost != 0.25 {
+			t.Errorf("Expected fallback provider pricing, got %s at %.2f", decision.Provider, decision.Cost)
+		}
+
+		// Alerts are sent once per level and month
+		guard.Evaluate(ctx)
+		if len(alerter.alerts) != 1 || alerter.alerts[0] != "provider_budget_"+BudgetAlertDegraded {
+			t.Errorf("Expected a single degraded alert, got %v", alerter.alerts)
+		}
+	})
+
+	t.Run("exhausted without fallback", func(t *testing.T) {
+		guard := newTestBudgetGuard(&fakeBudgetStore{spent: 100}, &countingAlerter{}, []string{BudgetDegradePauseFreeTier}, "")
+
+		if _, err := guard.Evaluate(ctx); !errors.Is(err, ErrProviderBudgetExhausted) {
+			t.Errorf("Expected ErrProviderBudgetExhausted, got %v", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		guard := NewBudgetGuard(BudgetConfig{Provider: "gemini:primary"}, &fakeBudgetStore{spent: 1000}, nil)
+
+		decision, err := guard.Evaluate(ctx)
+		if err != nil || decision.Degraded() {
+			t.Errorf("Expected disabled guard to allow conversions, got %+v, %v", decision, err)
+		}
+	})
+}
+
+func TestProjectMonthlySpend(t *testing.T) {
+	now := time.Date(2025, time.June, 16, 0, 0, 0, 0, time.UTC)
+	if got := projectMonthlySpend(50, now); got != 100 {
+		t.Errorf("Expected projection 100, got %.2f", got)
+	}
+
+	// Less than a day elapsed is treated as a full day
+	early := time.Date(2025, time.June, 1, 1, 0, 0, 0, time.UTC)
+	if got := projectMonthlySpend(1, early); got != 30 {
+		t.Errorf("Expected projection 30, got %.2f", got)
+	}
+}
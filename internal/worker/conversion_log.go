@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Conversion log stages
+const (
+	ConversionStageQueued     = "queued"
+	ConversionStageStarted    = "started"
+	ConversionStageDownload   = "download"
+	ConversionStageValidation = "validation"
+	ConversionStageModeration = "moderation"
+	ConversionStageInputCheck = "input_check"
+	ConversionStageBudget     = "budget"
+	ConversionStageProvider   = "provider"
+	ConversionStageWatermark  = "watermark"
+	ConversionStageUpload     = "upload"
+	ConversionStageCompleted  = "completed"
+	ConversionStageFailed     = "failed"
+	ConversionStageRequeued   = "requeued"
+)
+
+// Conversion log levels
+const (
+	ConversionLogInfo  = "info"
+	ConversionLogWarn  = "warn"
+	ConversionLogError = "error"
+)
+
+// DefaultConversionLogCap is the number of entries kept per conversion when
+// no cap is configured
+const DefaultConversionLogCap = 200
+
+// maxConversionLogMessage bounds stored messages, provider errors embed response bodies
+const maxConversionLogMessage = 1000
+
+// ConversionLogEntry is a persisted worker log line of a single conversion
+type ConversionLogEntry struct {
+	ID           int64                  `json:"id"`
+	ConversionID string                 `json:"conversionId"`
+	JobID        string                 `json:"jobId,omitempty"`
+	WorkerID     string                 `json:"workerId,omitempty"`
+	Stage        string                 `json:"stage"`
+	Level        string                 `json:"level"`
+	Message      string                 `json:"message"`
+	StatusCode   *int                   `json:"statusCode,omitempty"`
+	Attempt      *int                   `json:"attempt,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// ConversionLogStore persists conversion log entries
+type ConversionLogStore interface {
+	AppendConversionLog(ctx context.Context, entry *ConversionLogEntry) error
+	PruneConversionLogs(ctx context.Context, before time.Time) (int64, error)
+}
+
+// dbConversionLogStore stores conversion logs in the conversion_logs table,
+// keeping at most maxPerConversion entries per conversion
+type dbConversionLogStore struct {
+	db               *sql.DB
+	maxPerConversion int
+}
+
+// NewDBConversionLogStore creates a new database-backed conversion log store
+func NewDBConversionLogStore(db *sql.DB, maxPerConversion int) ConversionLogStore {
+	if maxPerConversion <= 0 {
+		maxPerConversion = DefaultConversionLogCap
+	}
+	return &dbConversionLogStore{db: db, maxPerConversion: maxPerConversion}
+}
+
+// AppendConversionLog inserts an entry and drops the oldest entries of the
+// conversion beyond the cap
+func (s *dbConversionLogStore) AppendConversionLog(ctx context.Context, entry *ConversionLogEntry) error {
+	metadata := []byte("{}")
+	if len(entry.Metadata) > 0 {
+		encoded, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal conversion log metadata: %w", err)
+		}
+		metadata = encoded
+	}
+
+	query := `
+		INSERT INTO conversion_logs (conversion_id, job_id, worker_id, stage, level, message, status_code, attempt, metadata)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(ctx, query,
+		entry.ConversionID, entry.JobID, entry.WorkerID, entry.Stage, entry.Level, entry.Message,
+		entry.StatusCode, entry.Attempt, metadata,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert conversion log: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM conversion_logs
+		WHERE conversion_id = $1 AND id <= (
+			SELECT id FROM conversion_logs
+			WHERE conversion_id = $1
+			ORDER BY id DESC
+			OFFSET $2 LIMIT 1
+		)
+	`, entry.ConversionID, s.maxPerConversion)
+	if err != nil {
+		return fmt.Errorf("failed to cap conversion logs: %w", err)
+	}
+
+	return nil
+}
+
+// PruneConversionLogs deletes entries created before the cutoff
+func (s *dbConversionLogStore) PruneConversionLogs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM conversion_logs WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune conversion logs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ProviderAttemptObserver is notified about every provider attempt of a call.
+// statusCode is 0 when the attempt did not get an HTTP response and retryIn is
+// 0 when no further attempt follows.
+type ProviderAttemptObserver func(attempt int, statusCode int, err error, retryIn time.Duration)
+
+type providerAttemptObserverKey struct{}
+
+// WithProviderAttemptObserver attaches an attempt observer to ctx
+func WithProviderAttemptObserver(ctx context.Context, observer ProviderAttemptObserver) context.Context {
+	return context.WithValue(ctx, providerAttemptObserverKey{}, observer)
+}
+
+// observeProviderAttempt reports an attempt to the observer attached to ctx, if any
+func observeProviderAttempt(ctx context.Context, attempt int, err error, retryIn time.Duration) {
+	observer, ok := ctx.Value(providerAttemptObserverKey{}).(ProviderAttemptObserver)
+	if !ok || observer == nil {
+		return
+	}
+	observer(attempt, providerStatusCode(err), err, retryIn)
+}
+
+var providerStatusPattern = regexp.MustCompile(`status (\d{3})`)
+
+// providerStatusCode extracts the HTTP status code from a provider error
+func providerStatusCode(err error) int {
+	if err == nil {
+		return 200
+	}
+	match := providerStatusPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(match[1])
+	return code
+}
+
+// logConversion persists a log line of the job's conversion. Persistence is
+// best effort and never fails the job.
+func (s *Service) logConversion(ctx context.Context, job *WorkerJob, stage, level, message string, metadata map[string]interface{}) {
+	s.appendConversionLog(ctx, &ConversionLogEntry{
+		ConversionID: job.ConversionID,
+		JobID:        job.ID,
+		Stage:        stage,
+		Level:        level,
+		Message:      message,
+		Metadata:     metadata,
+	})
+}
+
+func (s *Service) appendConversionLog(ctx context.Context, entry *ConversionLogEntry) {
+	if s.conversionLogs == nil || entry.ConversionID == "" {
+		return
+	}
+	entry.WorkerID = s.workerID
+	if len(entry.Message) > maxConversionLogMessage {
+		entry.Message = entry.Message[:maxConversionLogMessage] + "..."
+	}
+
+	// Failure paths may run with an expired job context
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+	}
+
+	if err := s.conversionLogs.AppendConversionLog(ctx, entry); err != nil {
+		log.Printf("Failed to persist conversion log for %s: %v", entry.ConversionID, err)
+	}
+}
+
+// providerAttemptLogger returns an observer that persists provider attempts of the job
+func (s *Service) providerAttemptLogger(ctx context.Context, job *WorkerJob, provider string) ProviderAttemptObserver {
+	return func(attempt int, statusCode int, err error, retryIn time.Duration) {
+		entry := &ConversionLogEntry{
+			ConversionID: job.ConversionID,
+			JobID:        job.ID,
+			Stage:        ConversionStageProvider,
+			Level:        ConversionLogInfo,
+			Message:      fmt.Sprintf("%s attempt %d succeeded", provider, attempt),
+			Attempt:      &attempt,
+			Metadata:     map[string]interface{}{"provider": provider},
+		}
+		if statusCode != 0 {
+			entry.StatusCode = &statusCode
+		}
+		if err != nil {
+			entry.Level = ConversionLogError
+			entry.Message = fmt.Sprintf("%s attempt %d failed: %v", provider, attempt, err)
+			if retryIn > 0 {
+				entry.Level = ConversionLogWarn
+				entry.Message = fmt.Sprintf("%s attempt %d failed, retrying in %v: %v", provider, attempt, retryIn.Round(time.Millisecond), err)
+				entry.Metadata["retry_in_ms"] = retryIn.Milliseconds()
+			}
+		}
+		s.appendConversionLog(ctx, entry)
+	}
+}
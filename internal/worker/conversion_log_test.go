@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryConversionLogStore records appended entries in memory
+type memoryConversionLogStore struct {
+	mu      sync.Mutex
+	entries []ConversionLogEntry
+}
+
+func (m *memoryConversionLogStore) AppendConversionLog(ctx context.Context, entry *ConversionLogEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *memoryConversionLogStore) PruneConversionLogs(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestProviderStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 200},
+		{errors.New("API temporary failure (status 503): overloaded"), 503},
+		{errors.New("API request failed with status 400: bad request"), 400},
+		{errors.New("dial tcp: connection refused"), 0},
+	}
+	for _, tt := range tests {
+		if got := providerStatusCode(tt.err); got != tt.want {
+			t.Errorf("providerStatusCode(%v): expected %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestConversionLogs_ProviderAttempts(t *testing.T) {
+	server, _ := newStatusSequenceServer(http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+
+	store := &memoryConversionLogStore{}
+	service, _ := WireWorkerServiceWithMocks()
+	service.SetConversionLogs(store, 0)
+
+	job := &WorkerJob{ID: "job1", ConversionID: "conv1"}
+	ctx := WithProviderAttemptObserver(context.Background(), service.providerAttemptLogger(context.Background(), job, "gemini"))
+
+	client := newTestGeminiClient(server.URL, 2, 10)
+	if _, err := client.callWithRetry(ctx, GeminiRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(store.entries) != 2 {
+		t.Fatalf("Expected 2 provider log entries, got %d", len(store.entries))
+	}
+
+	retry := store.entries[0]
+	if retry.Level != ConversionLogWarn || retry.StatusCode == nil || *retry.StatusCode != 503 {
+		t.Errorf("Expected warn entry with status 503, got %+v", retry)
+	}
+	if retry.Attempt == nil || *retry.Attempt != 1 || retry.Metadata["retry_in_ms"] == nil {
+		t.Errorf("Expected first attempt with retry delay, got %+v", retry)
+	}
+
+	success := store.entries[1]
+	if success.Level != ConversionLogInfo || success.StatusCode == nil || *success.StatusCode != 200 {
+		t.Errorf("Expected info entry with status 200, got %+v", success)
+	}
+	if success.ConversionID != "conv1" || success.JobID != "job1" || success.WorkerID == "" {
+		t.Errorf("Expected entry to be correlated with job and worker, got %+v", success)
+	}
+}
+
+func TestConversionLogs_BestEffort(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+
+	// Without a store logging is a no-op
+	service.logConversion(context.Background(), &WorkerJob{ID: "job1", ConversionID: "conv1"}, ConversionStageStarted, ConversionLogInfo, "started", nil)
+
+	store := &memoryConversionLogStore{}
+	service.SetConversionLogs(store, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.logConversion(ctx, &WorkerJob{ID: "job1", ConversionID: "conv1"}, ConversionStageFailed, ConversionLogError, "timed out", nil)
+	if len(store.entries) != 1 {
+		t.Errorf("Expected entries to be persisted with a cancelled job context, got %d", len(store.entries))
+	}
+}
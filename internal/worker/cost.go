@@ -0,0 +1,232 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ProviderUsage is what a provider billed for one successful call
+type ProviderUsage struct {
+	PromptTokens int `json:"promptTokens"`
+	OutputTokens int `json:"outputTokens"`
+	Images       int `json:"images"`
+}
+
+// ProviderUsageObserver is told the usage of a successful provider call
+type ProviderUsageObserver func(usage ProviderUsage)
+
+type providerUsageObserverKey struct{}
+
+// WithProviderUsageObserver attaches a usage observer to ctx
+func WithProviderUsageObserver(ctx context.Context, observer ProviderUsageObserver) context.Context {
+	return context.WithValue(ctx, providerUsageObserverKey{}, observer)
+}
+
+// observeProviderUsage reports usage to the observer attached to ctx, if any
+func observeProviderUsage(ctx context.Context, usage ProviderUsage) {
+	observer, ok := ctx.Value(providerUsageObserverKey{}).(ProviderUsageObserver)
+	if !ok || observer == nil {
+		return
+	}
+	observer(usage)
+}
+
+// geminiUsage extracts the billed usage from a Gemini response
+func geminiUsage(response *GeminiResponse) ProviderUsage {
+	usage := ProviderUsage{
+		PromptTokens: response.UsageMetadata.PromptTokenCount,
+		OutputTokens: response.UsageMetadata.CandidatesTokenCount,
+	}
+	for _, candidate := range response.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil {
+				usage.Images++
+			}
+		}
+	}
+	return usage
+}
+
+// CostConfig prices provider usage. With no token or image price set, every
+// conversion is billed at FlatCost.
+type CostConfig struct {
+	InputTokenPrice  float64 `json:"inputTokenPrice"`  // per million prompt tokens
+	OutputTokenPrice float64 `json:"outputTokenPrice"` // per million output tokens
+	ImagePrice       float64 `json:"imagePrice"`       // per generated image
+	FlatCost         float64 `json:"flatCost"`
+	DailyBudget      float64 `json:"dailyBudget"` // 0 disables the daily spend alert
+}
+
+// ConversionCost is the provider cost of one conversion
+type ConversionCost struct {
+	ConversionID string
+	UserID       string
+	Provider     string
+	Usage        ProviderUsage
+	Cost         float64
+}
+
+// CostStore persists conversion costs
+type CostStore interface {
+	RecordConversionCost(ctx context.Context, cost ConversionCost) error
+	GetDailySpend(ctx context.Context, day time.Time) (float64, error)
+}
+
+// CostTracker records what each conversion cost and alerts admins when the
+// day's spend goes over budget
+type CostTracker struct {
+	config  CostConfig
+	store   CostStore
+	alerter BudgetAlerter
+	now     func() time.Time
+
+	mu         sync.Mutex
+	alertedDay string
+}
+
+// NewCostTracker creates a new cost tracker
+func NewCostTracker(config CostConfig, store CostStore, alerter BudgetAlerter) *CostTracker {
+	return &CostTracker{
+		config:  config,
+		store:   store,
+		alerter: alerter,
+		now:     time.Now,
+	}
+}
+
+// Price returns the cost of a call from its usage, or flatCost (the configured
+// flat cost when 0) when no usage-based price is configured
+func (t *CostTracker) Price(usage ProviderUsage, flatCost float64) float64 {
+	if t.config.InputTokenPrice == 0 && t.config.OutputTokenPrice == 0 && t.config.ImagePrice == 0 {
+		if flatCost > 0 {
+			return flatCost
+		}
+		return t.config.FlatCost
+	}
+
+	return float64(usage.PromptTokens)*t.config.InputTokenPrice/1e6 +
+		float64(usage.OutputTokens)*t.config.OutputTokenPrice/1e6 +
+		float64(usage.Images)*t.config.ImagePrice
+}
+
+// Record prices and stores the cost of a conversion. cost.Cost is used as the
+// flat cost when no usage-based price is configured.
+func (t *CostTracker) Record(ctx context.Context, cost ConversionCost) error {
+	if t == nil || t.store == nil {
+		return nil
+	}
+
+	cost.Cost = t.Price(cost.Usage, cost.Cost)
+	if err := t.store.RecordConversionCost(ctx, cost); err != nil {
+		return err
+	}
+
+	if t.config.DailyBudget > 0 {
+		t.checkDailyBudget(ctx)
+	}
+	return nil
+}
+
+// checkDailyBudget alerts admins once a day when the day's spend exceeds the budget
+func (t *CostTracker) checkDailyBudget(ctx context.Context) {
+	now := t.now().UTC()
+	day := now.Format("2006-01-02")
+
+	t.mu.Lock()
+	alerted := t.alertedDay == day
+	t.mu.Unlock()
+	if alerted {
+		return
+	}
+
+	spent, err := t.store.GetDailySpend(ctx, now)
+	if err != nil {
+		log.Printf("Failed to get daily provider spend: %v", err)
+		return
+	}
+	if spent <= t.config.DailyBudget {
+		return
+	}
+
+	t.mu.Lock()
+	if t.alertedDay == day {
+		t.mu.Unlock()
+		return
+	}
+	t.alertedDay = day
+	t.mu.Unlock()
+
+	message := fmt.Sprintf("provider spend of %.2f today exceeds the daily budget of %.2f", spent, t.config.DailyBudget)
+	log.Printf("Daily provider budget exceeded: %s", message)
+
+	if t.alerter == nil {
+		return
+	}
+	metadata := map[string]interface{}{
+		"day":          day,
+		"spent":        spent,
+		"daily_budget": t.config.DailyBudget,
+	}
+	if err := t.alerter.SendCriticalError(ctx, "provider_daily_budget_exceeded", message, metadata); err != nil {
+		log.Printf("Failed to send daily budget alert: %v", err)
+	}
+}
+
+// dbCostStore implements CostStore on top of PostgreSQL
+type dbCostStore struct {
+	db *sql.DB
+}
+
+// NewDBCostStore creates a new database-backed cost store
+func NewDBCostStore(db *sql.DB) CostStore {
+	return &dbCostStore{db: db}
+}
+
+// RecordConversionCost inserts a cost row, attributing it to the user's active plan
+func (s *dbCostStore) RecordConversionCost(ctx context.Context, cost ConversionCost) error {
+	var convID, userID interface{}
+	if cost.ConversionID != "" {
+		convID = cost.ConversionID
+	}
+	if cost.UserID != "" {
+		userID = cost.UserID
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO conversion_costs (conversion_id, user_id, plan_name, provider, prompt_tokens, output_tokens, images, cost)
+		VALUES ($1, $2, COALESCE((
+			SELECT plan_name FROM user_plans
+			WHERE user_id = $2
+			  AND status = 'active'
+			  AND (expires_at IS NULL OR expires_at > NOW())
+			ORDER BY created_at DESC
+			LIMIT 1
+		), 'free'), $3, $4, $5, $6, $7)`,
+		convID, userID, cost.Provider, cost.Usage.PromptTokens, cost.Usage.OutputTokens, cost.Usage.Images, cost.Cost)
+	if err != nil {
+		return fmt.Errorf("failed to record conversion cost: %w", err)
+	}
+	return nil
+}
+
+// GetDailySpend sums the conversion costs of the UTC day containing day
+func (s *dbCostStore) GetDailySpend(ctx context.Context, day time.Time) (float64, error) {
+	day = day.UTC()
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	var spent float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(cost), 0)
+		FROM conversion_costs
+		WHERE created_at >= $1 AND created_at < $2`,
+		start, end).Scan(&spent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily spend: %w", err)
+	}
+	return spent, nil
+}
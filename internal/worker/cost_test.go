@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeCostStore struct {
+	recorded []ConversionCost
+}
+
+func (s *fakeCostStore) RecordConversionCost(ctx context.Context, cost ConversionCost) error {
+	s.recorded = append(s.recorded, cost)
+	return nil
+}
+
+func (s *fakeCostStore) GetDailySpend(ctx context.Context, day time.Time) (float64, error) {
+	var spent float64
+	for _, cost := range s.recorded {
+		spent += cost.Cost
+	}
+	return spent, nil
+}
+
+func TestCostTracker_Price(t *testing.T) {
+	usage := ProviderUsage{PromptTokens: 2000, OutputTokens: 1500, Images: 1}
+
+	flat := NewCostTracker(CostConfig{FlatCost: 0.04}, nil, nil)
+	if got := flat.Price(usage, 0); got != 0.04 {
+		t.Errorf("Expected flat cost 0.04, got %v", got)
+	}
+	if got := flat.Price(usage, 0.01); got != 0.01 {
+		t.Errorf("Expected the budget decision's cost 0.01, got %v", got)
+	}
+
+	metered := NewCostTracker(CostConfig{InputTokenPrice: 0.5, OutputTokenPrice: 2, ImagePrice: 0.03, FlatCost: 0.04}, nil, nil)
+	want := 2000*0.5/1e6 + 1500*2/1e6 + 0.03
+	if got := metered.Price(usage, 0.01); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Expected usage-based cost %v, got %v", want, got)
+	}
+}
+
+func TestCostTracker_DailyBudgetAlert(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeCostStore{}
+	alerter := &countingAlerter{}
+	tracker := NewCostTracker(CostConfig{FlatCost: 1, DailyBudget: 2.5}, store, alerter)
+	day := time.Date(2025, time.June, 16, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return day }
+
+	for i := 0; i < 5; i++ {
+		if err := tracker.Record(ctx, ConversionCost{ConversionID: "conv", Provider: "gemini:primary"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(store.recorded) != 5 || store.recorded[0].Cost != 1 {
+		t.Fatalf("Expected 5 costs of 1 to be recorded, got %+v", store.recorded)
+	}
+	if len(alerter.alerts) != 1 || alerter.alerts[0] != "provider_daily_budget_exceeded" {
+		t.Errorf("Expected one daily budget alert, got %v", alerter.alerts)
+	}
+
+	day = day.AddDate(0, 0, 1)
+	if err := tracker.Record(ctx, ConversionCost{ConversionID: "conv", Provider: "gemini:primary"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(alerter.alerts) != 2 {
+		t.Errorf("Expected the alert to fire again on a new day, got %v", alerter.alerts)
+	}
+}
+
+func TestGeminiUsage(t *testing.T) {
+	response := &GeminiResponse{
+		Candidates: []GeminiCandidate{{Content: GeminiContent{Parts: []GeminiPart{
+			{Text: "here you go"},
+			{InlineData: &GeminiInlineData{MimeType: "image/png", Data: "aGk="}},
+		}}}},
+		UsageMetadata: GeminiUsageMetadata{PromptTokenCount: 1200, CandidatesTokenCount: 1290, TotalTokenCount: 2490},
+	}
+
+	usage := geminiUsage(response)
+	if usage != (ProviderUsage{PromptTokens: 1200, OutputTokens: 1290, Images: 1}) {
+		t.Errorf("Expected usage from the response metadata, got %+v", usage)
+	}
+}
@@ -7,16 +7,45 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"ai-styler/internal/common"
 )
 
+// QueueAging raises the priority of pending jobs while they wait, so low
+// priority jobs are not starved by a steady stream of higher priority ones
+type QueueAging struct {
+	Interval time.Duration // one priority level is gained per interval; 0 disables aging
+	MaxBoost int           // cap on the levels gained by aging
+}
+
+// DefaultQueueAging lets a normal job catch up with a high priority one after five minutes
+var DefaultQueueAging = QueueAging{Interval: time.Minute, MaxBoost: 10}
+
 // DBJobQueue implements JobQueue interface using database
 type DBJobQueue struct {
-	db *sql.DB
+	db    *sql.DB
+	aging QueueAging
 }
 
 // NewDBJobQueue creates a new database job queue
 func NewDBJobQueue(db *sql.DB) JobQueue {
-	return &DBJobQueue{db: db}
+	return NewDBJobQueueWithAging(db, DefaultQueueAging)
+}
+
+// NewDBJobQueueWithAging creates a database job queue with custom aging
+func NewDBJobQueueWithAging(db *sql.DB, aging QueueAging) JobQueue {
+	return &DBJobQueue{db: db, aging: aging}
+}
+
+// pendingOrder orders pending jobs by priority plus the levels gained by
+// waiting, oldest first within a level
+func (q *DBJobQueue) pendingOrder() string {
+	seconds := int64(q.aging.Interval / time.Second)
+	if seconds <= 0 || q.aging.MaxBoost <= 0 {
+		return "priority DESC, created_at ASC"
+	}
+	return fmt.Sprintf("priority + LEAST(%d, FLOOR(EXTRACT(EPOCH FROM NOW() - created_at) / %d))::int DESC, created_at ASC",
+		q.aging.MaxBoost, seconds)
 }
 
 // EnqueueJob adds a job to the queue
@@ -56,12 +85,12 @@ func (q *DBJobQueue) DequeueJob(ctx context.Context, workerID string) (*WorkerJo
 		WHERE id = (
 			SELECT id FROM worker_jobs 
 			WHERE status = 'pending' 
-			ORDER BY priority DESC, created_at ASC 
+			ORDER BY `+q.pendingOrder()+`
 			LIMIT 1
 			FOR UPDATE SKIP LOCKED
 		)
 		RETURNING id, type, conversion_id, user_id, priority, status, worker_id, 
-		          retry_count, max_retries, payload, created_at, updated_at, started_at`
+		          retry_count, max_retries, restart_count, payload, created_at, updated_at, started_at`
 
 	var job WorkerJob
 	var priority int
@@ -79,6 +108,7 @@ func (q *DBJobQueue) DequeueJob(ctx context.Context, workerID string) (*WorkerJo
 		&job.WorkerID,
 		&job.RetryCount,
 		&job.MaxRetries,
+		&job.RestartCount,
 		&payloadJSON,
 		&job.CreatedAt,
 		&job.UpdatedAt,
@@ -132,6 +162,7 @@ func parsePayloadJSON(payloadJSON string, payload *JobPayload) error {
 
 	payload.UserImageID = getStringFromMap(payloadData, "userImageId")
 	payload.ClothImageID = getStringFromMap(payloadData, "clothImageId")
+	payload.TraceID = getStringFromMap(payloadData, "traceId")
 	
 	// Initialize Options map if it doesn't exist
 	if payload.Options == nil {
@@ -164,25 +195,38 @@ func (q *DBJobQueue) UpdateJobStatus(ctx context.Context, jobID string, status J
 	return err
 }
 
-// CompleteJob marks a job as completed
+// CompleteJob marks a job as completed, in the caller's unit of work when there is one
 func (q *DBJobQueue) CompleteJob(ctx context.Context, jobID string, result interface{}) error {
 	query := `
 		UPDATE worker_jobs 
 		SET status = 'completed', completed_at = NOW(), updated_at = NOW()
 		WHERE id = $1`
 
-	_, err := q.db.ExecContext(ctx, query, jobID)
+	_, err := common.Conn(ctx, q.db).ExecContext(ctx, query, jobID)
 	return err
 }
 
-// FailJob marks a job as failed
+// FailJob marks a job as failed, in the caller's unit of work when there is one
 func (q *DBJobQueue) FailJob(ctx context.Context, jobID string, errorMessage string) error {
 	query := `
 		UPDATE worker_jobs 
 		SET status = 'failed', error_message = $1, updated_at = NOW()
 		WHERE id = $2`
 
-	_, err := q.db.ExecContext(ctx, query, errorMessage, jobID)
+	_, err := common.Conn(ctx, q.db).ExecContext(ctx, query, errorMessage, jobID)
+	return err
+}
+
+// RequeueJob puts a job that is still processing back in the queue and counts
+// the restart. Jobs that have already finished are left untouched.
+func (q *DBJobQueue) RequeueJob(ctx context.Context, jobID string) error {
+	query := `
+		UPDATE worker_jobs 
+		SET status = 'pending', worker_id = NULL, started_at = NULL,
+		    restart_count = restart_count + 1, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'`
+
+	_, err := q.db.ExecContext(ctx, query, jobID)
 	return err
 }
 
@@ -201,7 +245,7 @@ func (q *DBJobQueue) UpdateJobRetryCount(ctx context.Context, jobID string, retr
 func (q *DBJobQueue) GetJob(ctx context.Context, jobID string) (*WorkerJob, error) {
 	query := `
 		SELECT id, type, conversion_id, user_id, priority, status, worker_id, 
-		       retry_count, max_retries, payload, created_at, updated_at, started_at, completed_at
+		       retry_count, max_retries, restart_count, payload, created_at, updated_at, started_at, completed_at
 		FROM worker_jobs 
 		WHERE id = $1`
 
@@ -221,6 +265,7 @@ func (q *DBJobQueue) GetJob(ctx context.Context, jobID string) (*WorkerJob, erro
 		&job.WorkerID,
 		&job.RetryCount,
 		&job.MaxRetries,
+		&job.RestartCount,
 		&payloadJSON,
 		&job.CreatedAt,
 		&job.UpdatedAt,
@@ -303,7 +348,7 @@ func (q *DBJobQueue) GetPendingJobs(ctx context.Context, limit int) ([]*WorkerJo
 		       retry_count, max_retries, payload, created_at, updated_at
 		FROM worker_jobs 
 		WHERE status = 'pending'
-		ORDER BY priority DESC, created_at ASC 
+		ORDER BY `+q.pendingOrder()+`
 		LIMIT $1`
 
 	rows, err := q.db.QueryContext(ctx, query, limit)
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDBJobQueue_PendingOrder(t *testing.T) {
+	tests := []struct {
+		aging    QueueAging
+		expected string
+	}{
+		{QueueAging{}, "priority DESC, created_at ASC"},
+		{QueueAging{Interval: time.Minute}, "priority DESC, created_at ASC"},
+		{
+			QueueAging{Interval: 2 * time.Minute, MaxBoost: 10},
+			"priority + LEAST(10, FLOOR(EXTRACT(EPOCH FROM NOW() - created_at) / 120))::int DESC, created_at ASC",
+		},
+	}
+
+	for _, tt := range tests {
+		queue := NewDBJobQueueWithAging(nil, tt.aging).(*DBJobQueue)
+		if got := queue.pendingOrder(); got != tt.expected {
+			t.Errorf("Expected order %q for %+v, got %q", tt.expected, tt.aging, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai-styler/internal/conversion"
+)
+
+// drainCancelGrace is how long Stop waits for jobs to return after they are
+// cancelled at the end of the drain timeout
+const drainCancelGrace = 5 * time.Second
+
+// drain waits for in-flight jobs to finish. Jobs still running after the drain
+// timeout are cancelled, and those that don't return in time are requeued.
+func (s *Service) drain(ctx context.Context) {
+	timeout := s.config.DrainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.workerLoops.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		log.Printf("All in-flight jobs finished")
+		return
+	case <-timer.C:
+		log.Printf("Drain timeout of %v reached, cancelling in-flight jobs", timeout)
+	case <-ctx.Done():
+		log.Printf("Stop context done before in-flight jobs finished, cancelling them")
+	}
+
+	// Cancelled jobs requeue themselves as they return
+	if s.cancelJobs != nil {
+		s.cancelJobs()
+	}
+
+	grace := time.NewTimer(drainCancelGrace)
+	defer grace.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-grace.C:
+	}
+
+	// Requeue jobs whose workers are stuck in calls that ignore cancellation
+	s.workerMutex.RLock()
+	var stuck []*WorkerJob
+	for _, worker := range s.workers {
+		if worker.CurrentJob != nil {
+			stuck = append(stuck, worker.CurrentJob)
+		}
+	}
+	s.workerMutex.RUnlock()
+
+	for _, job := range stuck {
+		s.requeueJob(context.WithoutCancel(ctx), job)
+	}
+}
+
+// interrupted reports whether a job running under ctx was aborted by Stop
+func (s *Service) interrupted(ctx context.Context) bool {
+	return s.draining.Load() && ctx.Err() != nil
+}
+
+// requeueJob puts an unfinished job back in the queue and marks its
+// conversion pending again so another worker can pick it up
+func (s *Service) requeueJob(ctx context.Context, job *WorkerJob) {
+	if err := s.jobQueue.RequeueJob(ctx, job.ID); err != nil {
+		log.Printf("Failed to requeue job %s: %v", job.ID, err)
+		return
+	}
+
+	status := conversion.ConversionStatusPending
+	if err := s.conversionStore.UpdateConversion(ctx, job.ConversionID, conversion.UpdateConversionRequest{Status: &status}); err != nil {
+		log.Printf("Failed to update conversion status: %v", err)
+	}
+
+	log.Printf("Requeued job %s for conversion %s (restart %d)", job.ID, job.ConversionID, job.RestartCount+1)
+	s.logConversion(ctx, job, ConversionStageRequeued, ConversionLogWarn, "Job requeued on worker shutdown", map[string]interface{}{
+		"restart_count": job.RestartCount + 1,
+	})
+}
+
+// sleep waits for d or until the service is stopped
+func (s *Service) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-s.stopChan:
+	}
+}
@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drainTestQueue hands out a single job and records what happened to it
+type drainTestQueue struct {
+	MockJobQueue
+	mu        sync.Mutex
+	job       *WorkerJob
+	completed bool
+	requeued  int
+}
+
+func (q *drainTestQueue) DequeueJob(ctx context.Context, workerID string) (*WorkerJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.job
+	q.job = nil
+	return job, nil
+}
+
+func (q *drainTestQueue) CompleteJob(ctx context.Context, jobID string, result interface{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.completed = true
+	return nil
+}
+
+func (q *drainTestQueue) RequeueJob(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requeued++
+	return nil
+}
+
+// slowGeminiAPI blocks conversions until released or cancelled
+type slowGeminiAPI struct {
+	MockGeminiAPI
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *slowGeminiAPI) ConvertImage(ctx context.Context, userImageData, clothImageData []byte, options map[string]interface{}) ([]byte, error) {
+	close(g.started)
+	select {
+	case <-g.release:
+		return g.MockGeminiAPI.ConvertImage(ctx, userImageData, clothImageData, options)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pngFileStorage serves every input image as a PNG
+type pngFileStorage struct {
+	MockFileStorage
+}
+
+func (f *pngFileStorage) GetFile(ctx context.Context, filePath string) ([]byte, error) {
+	return []byte("\x89PNG\r\n\x1a\n"), nil
+}
+
+func newDrainTestService(t *testing.T, drainTimeout time.Duration, logs ConversionLogStore) (*Service, *drainTestQueue, *slowGeminiAPI) {
+	t.Helper()
+	service, _ := WireWorkerServiceWithMocks()
+	service.config.MaxWorkers = 1
+	service.config.PollInterval = 10 * time.Millisecond
+	service.config.DrainTimeout = drainTimeout
+
+	queue := &drainTestQueue{job: &WorkerJob{
+		ID:           "job1",
+		Type:         "image_conversion",
+		ConversionID: "conv1",
+		UserID:       "user1",
+		RestartCount: 1,
+		Payload:      JobPayload{UserImageID: "img-1", ClothImageID: "img-2"},
+	}}
+	gemini := &slowGeminiAPI{started: make(chan struct{}), release: make(chan struct{})}
+	service.jobQueue = queue
+	service.geminiAPI = gemini
+	service.fileStorage = &pngFileStorage{}
+	if logs != nil {
+		service.SetConversionLogs(logs, 0)
+	}
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	select {
+	case <-gemini.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected job to reach the provider")
+	}
+	return service, queue, gemini
+}
+
+func TestStop_WaitsForInFlightJob(t *testing.T) {
+	service, queue, gemini := newDrainTestService(t, 5*time.Second, nil)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(gemini.release)
+	}()
+	if err := service.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !queue.completed || queue.requeued != 0 {
+		t.Errorf("Expected job to complete during drain, got completed=%v requeued=%d", queue.completed, queue.requeued)
+	}
+}
+
+func TestStop_RequeuesUnfinishedJob(t *testing.T) {
+	store := &memoryConversionLogStore{}
+	service, queue, _ := newDrainTestService(t, 50*time.Millisecond, store)
+
+	if err := service.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if queue.completed || queue.requeued != 1 {
+		t.Errorf("Expected job to be requeued once, got completed=%v requeued=%d", queue.completed, queue.requeued)
+	}
+
+	var requeued *ConversionLogEntry
+	for i := range store.entries {
+		if store.entries[i].Stage == ConversionStageRequeued {
+			requeued = &store.entries[i]
+		}
+	}
+	if requeued == nil || requeued.Metadata["restart_count"] != 2 {
+		t.Errorf("Expected requeue to be logged as restart 2, got %+v", requeued)
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"errors"
+	"strings"
+
+	"ai-styler/internal/conversion"
+)
+
+// ErrInvalidInputImage is returned when a downloaded input image fails validation
+var ErrInvalidInputImage = errors.New("image validation failed")
+
+// maxDeadLetterResponse bounds the captured provider response, provider
+// errors embed response bodies
+const maxDeadLetterResponse = 4000
+
+// failureKind classifies a job error for the conversion retry policy. Errors
+// caused by the request itself are charged again when retried; everything
+// else is treated as a provider/server failure.
+func failureKind(err error) string {
+	if errors.Is(err, ErrImageRejected) || errors.Is(err, ErrInvalidInputImage) || errors.Is(err, ErrInputCheckFailed) {
+		return conversion.FailureKindRequest
+	}
+	return conversion.FailureKindProvider
+}
+
+// deadLetter captures the provider response of a job that failed after the
+// provider client used up its retries. Request failures are not dead-lettered
+// since requeueing them would fail the same way.
+func deadLetter(err error) *conversion.DeadLetter {
+	if failureKind(err) != conversion.FailureKindProvider {
+		return nil
+	}
+
+	response := err.Error()
+	if len(response) > maxDeadLetterResponse {
+		response = strings.ToValidUTF8(response[:maxDeadLetterResponse], "") + "..."
+	}
+	return &conversion.DeadLetter{
+		ProviderStatusCode: providerStatusCode(err),
+		ProviderResponse:   response,
+	}
+}
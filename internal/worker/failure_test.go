@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-styler/internal/conversion"
+)
+
+func TestFailureKind(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{fmt.Errorf("user image: %w", ErrImageRejected), conversion.FailureKindRequest},
+		{fmt.Errorf("%w: %w", ErrInvalidInputImage, errors.New("unsupported format")), conversion.FailureKindRequest},
+		{ErrGeminiCircuitOpen, conversion.FailureKindProvider},
+		{ErrProviderBudgetExhausted, conversion.FailureKindProvider},
+		{errors.New("gemini API returned status 503"), conversion.FailureKindProvider},
+	}
+	for _, tt := range tests {
+		if kind := failureKind(tt.err); kind != tt.expected {
+			t.Errorf("Expected %s for %q, got %s", tt.expected, tt.err, kind)
+		}
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	if dl := deadLetter(fmt.Errorf("user image: %w", ErrImageRejected)); dl != nil {
+		t.Errorf("Expected request failures not to be dead-lettered, got %+v", dl)
+	}
+
+	dl := deadLetter(errors.New(`API request failed with status 500: {"error":"internal"}`))
+	if dl == nil {
+		t.Fatal("Expected provider failure to be dead-lettered")
+	}
+	if dl.ProviderStatusCode != 500 || !strings.Contains(dl.ProviderResponse, `"internal"`) {
+		t.Errorf("Expected status 500 with the response body, got %+v", dl)
+	}
+
+	dl = deadLetter(errors.New("API temporary failure (status 503): " + strings.Repeat("x", 2*maxDeadLetterResponse)))
+	if len(dl.ProviderResponse) > maxDeadLetterResponse+3 {
+		t.Errorf("Expected response to be truncated, got %d bytes", len(dl.ProviderResponse))
+	}
+}
+
+type fakeQuotaRefunds struct {
+	refunded []string
+}
+
+func (f *fakeQuotaRefunds) RefundConversion(ctx context.Context, conversionID string) (int, error) {
+	f.refunded = append(f.refunded, conversionID)
+	return 1, nil
+}
+
+func TestFailConversion_RefundsProviderFailures(t *testing.T) {
+	refunds := &fakeQuotaRefunds{}
+	service := &Service{conversionStore: NewMockConversionStore()}
+	service.SetQuotaRefunds(refunds)
+	ctx := context.Background()
+
+	if err := service.failConversion(ctx, "c1", errors.New("gemini API returned status 503"), 100); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.failConversion(ctx, "c2", fmt.Errorf("user image: %w", ErrImageRejected), 100); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(refunds.refunded) != 1 || refunds.refunded[0] != "c1" {
+		t.Errorf("Expected only the provider failure to be refunded, got %v", refunds.refunded)
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"log"
+)
+
+// FlagNewProvider selects the users whose conversions use the candidate
+// provider
+const FlagNewProvider = "new_provider"
+
+// FeatureFlags evaluates feature flags for a user
+type FeatureFlags interface {
+	IsEnabled(ctx context.Context, key, userID string) bool
+}
+
+// SetCandidateProvider registers a provider being rolled out. Conversions
+// of users the new_provider flag selects use it once SetFeatureFlags is set.
+func (s *Service) SetCandidateProvider(api GeminiAPI, provider string) {
+	s.candidateAPI = api
+	s.candidateProvider = provider
+}
+
+// SetFeatureFlags enables flag-driven behaviour such as the provider rollout
+func (s *Service) SetFeatureFlags(flags FeatureFlags) {
+	s.flags = flags
+}
+
+// applyProviderRollout switches the job to the candidate provider when the
+// new_provider flag is on for its user. A degraded budget keeps its choice.
+func (s *Service) applyProviderRollout(ctx context.Context, job *WorkerJob, geminiAPI GeminiAPI, provider string, decision *BudgetDecision) (GeminiAPI, string) {
+	if s.flags == nil || s.candidateAPI == nil || (decision != nil && decision.Degraded()) {
+		return geminiAPI, provider
+	}
+	if !s.flags.IsEnabled(ctx, FlagNewProvider, job.UserID) {
+		return geminiAPI, provider
+	}
+	log.Printf("Converting job %s with candidate provider %s", job.ID, s.candidateProvider)
+	return s.candidateAPI, s.candidateProvider
+}
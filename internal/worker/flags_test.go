@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"testing"
+)
+
+// userFlags turns every flag on for the listed users
+type userFlags map[string]bool
+
+func (f userFlags) IsEnabled(ctx context.Context, key, userID string) bool {
+	return f[userID]
+}
+
+func TestApplyProviderRollout(t *testing.T) {
+	ctx := context.Background()
+	primary, candidate := &MockGeminiAPI{}, &MockGeminiAPI{}
+	service := &Service{geminiAPI: primary}
+	job := &WorkerJob{ID: "job-1", UserID: "user-1"}
+
+	if api, provider := service.applyProviderRollout(ctx, job, primary, "gemini", nil); api != primary || provider != "gemini" {
+		t.Errorf("Expected the primary provider without a candidate, got %s", provider)
+	}
+
+	service.SetCandidateProvider(candidate, "gemini:candidate")
+	service.SetFeatureFlags(userFlags{"user-1": true})
+	if api, provider := service.applyProviderRollout(ctx, job, primary, "gemini", nil); api != candidate || provider != "gemini:candidate" {
+		t.Errorf("Expected a flagged user to get the candidate provider, got %s", provider)
+	}
+
+	other := &WorkerJob{ID: "job-2", UserID: "user-2"}
+	if api, _ := service.applyProviderRollout(ctx, other, primary, "gemini", nil); api != primary {
+		t.Error("Expected other users to keep the primary provider")
+	}
+
+	degraded := &BudgetDecision{UseFallback: true, Provider: "gemini:fallback"}
+	if _, provider := service.applyProviderRollout(ctx, job, primary, "gemini:fallback", degraded); provider != "gemini:fallback" {
+		t.Errorf("Expected a degraded budget to keep its provider, got %s", provider)
+	}
+}
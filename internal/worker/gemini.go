@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
@@ -14,14 +15,33 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
+	"sync/atomic"
+
+	"ai-styler/internal/common"
+	imagesvc "ai-styler/internal/image"
+	"ai-styler/internal/monitoring"
+	"ai-styler/internal/prompt"
+
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrSafetyBlocked is returned when the provider's safety filter blocked the result
+var ErrSafetyBlocked = errors.New("image was blocked by safety filters")
+
 // GeminiClient implements the GeminiAPI interface
 type GeminiClient struct {
 	config     *GeminiConfig
 	httpClient *http.Client
+	breaker    *gobreaker.CircuitBreaker
+	retryStats *geminiRetryCounters
+
+	// Parameters set at runtime, see SetTuning
+	tuning atomic.Pointer[GeminiTuning]
 }
 
 // NewGeminiClient creates a new Gemini API client
@@ -29,13 +49,17 @@ func NewGeminiClient(config *GeminiConfig) *GeminiClient {
 	if config == nil {
 		config = getDefaultGeminiConfig()
 	}
+	applyGeminiRetryDefaults(config)
 
-	return &GeminiClient{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
+	// Requests are bounded by the attempt timeout, which can be tuned at runtime
+	client := &GeminiClient{
+		config:     config,
+		httpClient: &http.Client{},
+		retryStats: newGeminiRetryCounters(),
 	}
+	client.breaker = client.newGeminiCircuitBreaker()
+
+	return client
 }
 
 // ConvertImage converts an image using Gemini API with comprehensive error handling
@@ -77,7 +101,7 @@ func (c *GeminiClient) ConvertImage(ctx context.Context, userImageData, clothIma
 	}
 
 	// Pre-process images to reduce safety filter triggers
-	// This includes removing EXIF data, slight resizing, and adding minimal noise
+	// This includes slight resizing and adding minimal noise
 	log.Printf("Pre-processing images to optimize for API safety filters...")
 	processedUserImage, err := c.preprocessImage(userImageData, userMimeType)
 	if err != nil {
@@ -96,6 +120,7 @@ func (c *GeminiClient) ConvertImage(ctx context.Context, userImageData, clothIma
 
 	// Build the prompt
 	prompt := c.buildConversionPrompt(options)
+	tuning := c.currentTuning()
 
 	// Create the request
 	request := GeminiRequest{
@@ -121,10 +146,11 @@ func (c *GeminiClient) ConvertImage(ctx context.Context, userImageData, clothIma
 			},
 		},
 		GenerationConfig: GeminiGenerationConfig{
-			Temperature:     0.4, // Lower temperature for more consistent, realistic results
+			Temperature:     tuning.Temperature,
 			TopK:            40,
 			TopP:            0.95,
-			MaxOutputTokens: 32768, // Maximum tokens for high-quality base64-encoded image output
+			MaxOutputTokens: tuning.MaxOutputTokens,
+			ImageConfig:     imageConfigOption(options),
 		},
 		// Disable all safety filters to prevent blocking
 		SafetySettings: []SafetySetting{
@@ -147,14 +173,22 @@ func (c *GeminiClient) ConvertImage(ctx context.Context, userImageData, clothIma
 		},
 	}
 
-	// Make the API call with timeout handling (single attempt only)
-	attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
-	defer cancel()
-
-	response, err := c.makeAPIRequest(attemptCtx, request)
+	// Make the API call with per-attempt timeout, backoff and circuit breaker
+	callCtx, span := monitoring.Tracer().Start(ctx, "gemini.ConvertImage",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", "gemini"),
+			attribute.String("gen_ai.request.model", tuning.Model),
+			attribute.Int("gemini.user_image.bytes", len(userImageData)),
+			attribute.Int("gemini.cloth_image.bytes", len(clothImageData)),
+		),
+	)
+	response, err := c.callWithRetry(callCtx, request)
+	monitoring.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call Gemini API: %w", err)
 	}
+	observeProviderUsage(ctx, geminiUsage(response))
 
 	// Extract the result image from the response
 	resultImageData, err := c.extractResultImage(response)
@@ -203,7 +237,10 @@ func (c *GeminiClient) HealthCheck(ctx context.Context) error {
 		},
 	}
 
-	_, err := c.makeAPIRequest(ctx, testRequest)
+	checkCtx, cancel := context.WithTimeout(ctx, c.currentTuning().Timeout)
+	defer cancel()
+
+	_, err := c.makeAPIRequest(checkCtx, testRequest)
 	return err
 }
 
@@ -242,23 +279,24 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 
 	// Create HTTP request
 	// Handle different API endpoint structures
+	model := c.currentTuning().Model
 	var url string
 	if isOpenAIFormat {
 		// Custom API provider (e.g., gapgpt.app) - try Gemini format first
 		// If it's gapgpt.app with /v1, it might use /v1/models/{model}:generateContent
 		if strings.Contains(c.config.BaseURL, "gapgpt") {
 			// gapgpt.app uses Gemini format but with /v1 endpoint
-			url = fmt.Sprintf("%s/models/%s:generateContent", c.config.BaseURL, c.config.Model)
+			url = fmt.Sprintf("%s/models/%s:generateContent", c.config.BaseURL, model)
 		} else {
 			// Other OpenAI-compatible providers
 			url = fmt.Sprintf("%s/chat/completions", c.config.BaseURL)
 		}
 	} else {
 		// Standard Google Gemini API
-		url = fmt.Sprintf("%s/v1beta/models/%s:generateContent", c.config.BaseURL, c.config.Model)
+		url = fmt.Sprintf("%s/v1beta/models/%s:generateContent", c.config.BaseURL, model)
 	}
 
-	log.Printf("Making API request to: %s", url)
+	log.Printf("Making API request to: %s%s", url, traceLabel(ctx))
 	log.Printf("Request body length: %d bytes", len(requestBody))
 
 	// Log safety settings for debugging
@@ -271,6 +309,17 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 		log.Printf("WARNING: No safety settings in request!")
 	}
 
+	// One span per attempt; retries show up as siblings under gemini.ConvertImage
+	ctx, span := monitoring.Tracer().Start(ctx, "gemini.generateContent",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", "POST"),
+			attribute.String("gen_ai.request.model", model),
+			attribute.Int("http.request.body.size", len(requestBody)),
+		),
+	)
+	defer span.End()
+
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -279,21 +328,31 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	if traceID := common.GetTraceIDFromContext(ctx); traceID != "" {
+		req.Header.Set(providerTraceHeader, traceID)
+	}
 
 	// Make the request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
 	// Read response body first
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	log.Printf("API response status: %d", resp.StatusCode)
+	log.Printf("API response status: %d%s", resp.StatusCode, traceLabel(ctx))
 	log.Printf("API response body length: %d bytes", len(responseBody))
 
 	// Log response body preview for debugging (first 1000 chars)
@@ -305,7 +364,7 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("API error response: %s", string(responseBody))
+		log.Printf("API error response%s: %s", traceLabel(ctx), string(responseBody))
 
 		// Distinguish between retryable and non-retryable errors
 		// Retryable: 429 (Too Many Requests), 500-599 (Server Errors), 502 (Bad Gateway), 503 (Service Unavailable)
@@ -343,7 +402,7 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 
 	// Check for errors in response
 	if len(response.Candidates) == 0 {
-		log.Printf("No candidates in response. Full response: %s", string(responseBody))
+		log.Printf("No candidates in response%s. Full response: %s", traceLabel(ctx), string(responseBody))
 		return nil, fmt.Errorf("no candidates in response")
 	}
 
@@ -367,35 +426,52 @@ func (c *GeminiClient) makeAPIRequest(ctx context.Context, request GeminiRequest
 	return &response, nil
 }
 
-// buildConversionPrompt builds the prompt for image conversion
-// This prompt is designed for virtual try-on: person image + clothing image
-// Uses technical, clinical language with clear context to reduce safety filter triggers
+// buildConversionPrompt builds the prompt for image conversion from the
+// template version the worker selected, or prompt.DefaultConversionTemplate.
+// The default is designed for virtual try-on: person image + clothing image,
+// using technical, clinical language to reduce safety filter triggers.
 func (c *GeminiClient) buildConversionPrompt(options map[string]interface{}) string {
-	basePrompt := `You are an automated garment fitting service for an e-commerce platform. This is a technical product visualization service for displaying clothing items on mannequins or fashion models in product catalogs.
-
-Technical task: Apply the garment from image 2 onto the person/mannequin in image 1. This is a standard commercial product visualization workflow.
+	body := prompt.DefaultConversionTemplate
+	if template, ok := options[OptionPromptTemplate].(string); ok && template != "" {
+		body = template
+	}
+	return prompt.Render(body, conversionPromptVariables(options))
+}
 
-Technical specifications:
-- Image 1 contains a professional fashion model or mannequin used for product photography
-- Image 2 contains a retail clothing item (garment) to be visualized
-- This is a legitimate commercial product visualization service
-- Maintain technical accuracy: body proportions, garment fit, fabric texture
-- Preserve lighting conditions and background from the original scene
-- This is automated product photography, not personal content
+// conversionPromptVariables returns the template variables of a job's options
+func conversionPromptVariables(options map[string]interface{}) map[string]string {
+	values := make(map[string]string)
 
-Output requirement: Return ONLY the base64-encoded PNG image data as a raw string. No text, no markdown, no explanations, no headers. Only the base64 string.`
+	// Describe the garments of an outfit composite
+	if garments := garmentsOption(options); len(garments) > 1 {
+		values[prompt.VarGarments] = strings.Join(garments, ", ")
+		values[prompt.VarGarmentCount] = strconv.Itoa(len(garments))
+		values[prompt.VarOutfitInstruction] = fmt.Sprintf(" Image 2 is a composite of %d garments placed side by side, from left to right: %s. Dress the person in all of them together as one outfit, layered in that order, and do not copy the white background between them.",
+			len(garments), strings.Join(garments, ", "))
+	}
 
 	// Add custom style option if provided
 	if style, ok := options["style"].(string); ok && style != "" {
-		basePrompt += fmt.Sprintf(" Apply the style: %s while maintaining the natural appearance.", style)
+		values[prompt.VarStyle] = style
+		values[prompt.VarStyleInstruction] = fmt.Sprintf(" Apply the style: %s while maintaining the natural appearance.", style)
 	}
 
 	// Add quality emphasis if provided
 	if quality, ok := options["quality"].(string); ok && quality != "" {
-		basePrompt += fmt.Sprintf(" Ensure %s quality with detailed textures and realistic lighting.", quality)
+		values[prompt.VarQuality] = quality
+		values[prompt.VarQualityInstruction] = fmt.Sprintf(" Ensure %s quality with detailed textures and realistic lighting.", quality)
 	}
 
-	return basePrompt
+	return values
+}
+
+// imageConfigOption returns the image config of a job's options, nil when
+// no aspect ratio was requested
+func imageConfigOption(options map[string]interface{}) *GeminiImageConfig {
+	if aspectRatio := aspectRatioOption(options); aspectRatio != "" {
+		return &GeminiImageConfig{AspectRatio: aspectRatio}
+	}
+	return nil
 }
 
 // extractResultImage extracts the result image from the Gemini response
@@ -415,7 +491,7 @@ func (c *GeminiClient) extractResultImage(response *GeminiResponse) ([]byte, err
 				log.Printf("  - Category: %s, Probability: %s, Blocked: %v", rating.Category, rating.Probability, rating.Blocked)
 			}
 		}
-		return nil, fmt.Errorf("image was blocked by safety filters. Category: %s, Safety settings may not be properly applied by API provider", candidate.FinishReason)
+		return nil, fmt.Errorf("%w. Category: %s, Safety settings may not be properly applied by API provider", ErrSafetyBlocked, candidate.FinishReason)
 	}
 
 	// Check if response was truncated due to MAX_TOKENS
@@ -586,13 +662,18 @@ func (c *GeminiClient) extractResultImage(response *GeminiResponse) ([]byte, err
 // getDefaultGeminiConfig returns default Gemini configuration
 func getDefaultGeminiConfig() *GeminiConfig {
 	return &GeminiConfig{
-		APIKey:                "", // Should be set from environment
-		BaseURL:               "https://generativelanguage.googleapis.com",
-		Model:                 "gemini-1.5-pro",
-		MaxRetries:            1,
-		Timeout:               60,
-		PreprocessNoiseLevel:  0.02, // 2% noise level (slightly higher for better obfuscation)
-		PreprocessJpegQuality: 95,   // High quality JPEG
+		APIKey:                  "", // Should be set from environment
+		BaseURL:                 "https://generativelanguage.googleapis.com",
+		Model:                   "gemini-1.5-pro",
+		MaxRetries:              1,
+		Timeout:                 60,
+		RetryBaseDelayMs:        defaultGeminiRetryBaseDelayMs,
+		RetryMaxDelayMs:         defaultGeminiRetryMaxDelayMs,
+		AttemptTimeout:          60,
+		BreakerFailureThreshold: defaultGeminiBreakerFailureThreshold,
+		BreakerOpenTimeout:      defaultGeminiBreakerOpenTimeout,
+		PreprocessNoiseLevel:    0.02, // 2% noise level (slightly higher for better obfuscation)
+		PreprocessJpegQuality:   95,   // High quality JPEG
 	}
 }
 
@@ -692,20 +773,6 @@ func (c *GeminiClient) isRetryableError(err error) bool {
 	return false
 }
 
-// calculateRetryDelay calculates retry delay with exponential backoff
-func (c *GeminiClient) calculateRetryDelay(attempt int) time.Duration {
-	baseDelay := time.Second
-	delay := time.Duration(1<<uint(attempt)) * baseDelay
-
-	// Cap at 5 minutes
-	maxDelay := 5 * time.Minute
-	if delay > maxDelay {
-		delay = maxDelay
-	}
-
-	return delay
-}
-
 // cleanNonBase64Chars removes all characters that are not part of the standard Base64 character set (A-Z, a-z, 0-9, +, /, =).
 // This function is used to aggressively clean Base64 strings that may contain extra text added by the AI model.
 func (c *GeminiClient) cleanNonBase64Chars(s string) string {
@@ -725,10 +792,11 @@ func (c *GeminiClient) cleanNonBase64Chars(s string) string {
 }
 
 // preprocessImage preprocesses an image to reduce safety filter triggers
-// This includes: removing EXIF data, slight resizing, and adding minimal noise
+// This includes: slight resizing and adding minimal noise. Metadata is stripped
+// when images are uploaded, see the image module's ingestion step.
 func (c *GeminiClient) preprocessImage(imageData []byte, mimeType string) ([]byte, error) {
-	// Decode image (this automatically strips EXIF data)
-	img, _, err := image.Decode(bytes.NewReader(imageData))
+	// Decode upright, for images uploaded before ingestion rotated them
+	img, _, err := imagesvc.DecodeOriented(imageData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
@@ -784,7 +852,7 @@ func (c *GeminiClient) preprocessImage(imageData []byte, mimeType string) ([]byt
 
 	// Add minimal Gaussian noise to alter image signature
 	// This helps avoid exact image matching while being imperceptible to the eye
-	noiseLevel := c.config.PreprocessNoiseLevel
+	noiseLevel := c.currentTuning().NoiseLevel
 	if noiseLevel <= 0 {
 		noiseLevel = 0.02 // Default to 2% if not configured (slightly higher for better obfuscation)
 	}
@@ -809,7 +877,7 @@ func (c *GeminiClient) preprocessImage(imageData []byte, mimeType string) ([]byt
 		}
 	}
 
-	// Encode back to bytes (this strips any remaining EXIF/metadata)
+	// Encode back to bytes
 	jpegQuality := c.config.PreprocessJpegQuality
 	if jpegQuality <= 0 || jpegQuality > 100 {
 		jpegQuality = 95 // Default to 95 if not configured or invalid
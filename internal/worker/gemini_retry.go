@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// Default retry/backoff settings for Gemini calls
+const (
+	defaultGeminiRetryBaseDelayMs        = 1000
+	defaultGeminiRetryMaxDelayMs         = 30000
+	defaultGeminiBreakerFailureThreshold = 5
+	defaultGeminiBreakerOpenTimeout      = 30 // in seconds
+)
+
+// ErrGeminiCircuitOpen is returned when the circuit breaker rejects a call
+var ErrGeminiCircuitOpen = errors.New("gemini circuit breaker is open")
+
+// GeminiRetryStats represents retry and circuit breaker counters of a Gemini client
+type GeminiRetryStats struct {
+	Calls             int64            `json:"calls"`
+	Attempts          int64            `json:"attempts"`
+	Retries           int64            `json:"retries"`
+	Successes         int64            `json:"successes"`
+	Failures          int64            `json:"failures"`
+	BreakerRejections int64            `json:"breakerRejections"`
+	BreakerState      string           `json:"breakerState"`
+	RetriesPerCall    map[string]int64 `json:"retriesPerCall"` // number of retries -> calls
+}
+
+// geminiRetryCounters collects retry metrics for a Gemini client
+type geminiRetryCounters struct {
+	calls             int64
+	attempts          int64
+	retries           int64
+	successes         int64
+	failures          int64
+	breakerRejections int64
+
+	mu             sync.Mutex
+	retriesPerCall map[int]int64
+}
+
+func newGeminiRetryCounters() *geminiRetryCounters {
+	return &geminiRetryCounters{retriesPerCall: make(map[int]int64)}
+}
+
+// recordCall records the outcome of a call that made the given number of retries
+func (m *geminiRetryCounters) recordCall(retries int, err error) {
+	atomic.AddInt64(&m.calls, 1)
+	if err != nil {
+		atomic.AddInt64(&m.failures, 1)
+	} else {
+		atomic.AddInt64(&m.successes, 1)
+	}
+
+	m.mu.Lock()
+	m.retriesPerCall[retries]++
+	m.mu.Unlock()
+}
+
+func (m *geminiRetryCounters) snapshot() GeminiRetryStats {
+	stats := GeminiRetryStats{
+		Calls:             atomic.LoadInt64(&m.calls),
+		Attempts:          atomic.LoadInt64(&m.attempts),
+		Retries:           atomic.LoadInt64(&m.retries),
+		Successes:         atomic.LoadInt64(&m.successes),
+		Failures:          atomic.LoadInt64(&m.failures),
+		BreakerRejections: atomic.LoadInt64(&m.breakerRejections),
+		RetriesPerCall:    make(map[string]int64),
+	}
+
+	m.mu.Lock()
+	for retries, count := range m.retriesPerCall {
+		stats.RetriesPerCall[fmt.Sprintf("%d", retries)] = count
+	}
+	m.mu.Unlock()
+
+	return stats
+}
+
+// applyGeminiRetryDefaults fills unset retry settings with defaults
+func applyGeminiRetryDefaults(config *GeminiConfig) {
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryBaseDelayMs <= 0 {
+		config.RetryBaseDelayMs = defaultGeminiRetryBaseDelayMs
+	}
+	if config.RetryMaxDelayMs <= 0 {
+		config.RetryMaxDelayMs = defaultGeminiRetryMaxDelayMs
+	}
+	if config.RetryMaxDelayMs < config.RetryBaseDelayMs {
+		config.RetryMaxDelayMs = config.RetryBaseDelayMs
+	}
+	if config.AttemptTimeout <= 0 {
+		config.AttemptTimeout = config.Timeout
+	}
+	if config.BreakerFailureThreshold <= 0 {
+		config.BreakerFailureThreshold = defaultGeminiBreakerFailureThreshold
+	}
+	if config.BreakerOpenTimeout <= 0 {
+		config.BreakerOpenTimeout = defaultGeminiBreakerOpenTimeout
+	}
+}
+
+// newGeminiCircuitBreaker creates a circuit breaker that trips on consecutive retryable failures
+func (c *GeminiClient) newGeminiCircuitBreaker() *gobreaker.CircuitBreaker {
+	threshold := uint32(c.config.BreakerFailureThreshold)
+
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        "gemini:" + c.config.Model,
+		MaxRequests: 1,
+		Timeout:     time.Duration(c.config.BreakerOpenTimeout) * time.Second,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= threshold
+		},
+		// Client errors (bad request, invalid key, ...) say nothing about provider health
+		IsSuccessful: func(err error) bool {
+			return err == nil || !c.isRetryableError(err)
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("Circuit breaker %s changed from %s to %s", name, from, to)
+		},
+	})
+}
+
+// callWithRetry sends the request with per-attempt timeouts, exponential backoff
+// with jitter and the circuit breaker
+func (c *GeminiClient) callWithRetry(ctx context.Context, request GeminiRequest) (*GeminiResponse, error) {
+	var (
+		response *GeminiResponse
+		err      error
+		retries  int
+		delay    time.Duration
+	)
+
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Retrying Gemini API call in %v (attempt %d/%d): %v", delay, attempt+1, c.config.MaxRetries+1, err)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				c.retryStats.recordCall(retries, ctx.Err())
+				return nil, fmt.Errorf("retry aborted: %w (last error: %v)", ctx.Err(), err)
+			case <-timer.C:
+			}
+
+			retries++
+			atomic.AddInt64(&c.retryStats.retries, 1)
+		}
+
+		response, err = c.attempt(ctx, request)
+		if err == nil {
+			observeProviderAttempt(ctx, attempt+1, nil, 0)
+			c.retryStats.recordCall(retries, nil)
+			return response, nil
+		}
+
+		if errors.Is(err, ErrGeminiCircuitOpen) || ctx.Err() != nil || !c.isRetryableError(err) || attempt == c.config.MaxRetries {
+			observeProviderAttempt(ctx, attempt+1, err, 0)
+			break
+		}
+
+		delay = c.calculateRetryDelay(attempt)
+		observeProviderAttempt(ctx, attempt+1, err, delay)
+	}
+
+	c.retryStats.recordCall(retries, err)
+	return nil, err
+}
+
+// attempt performs a single API call through the circuit breaker
+func (c *GeminiClient) attempt(ctx context.Context, request GeminiRequest) (*GeminiResponse, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, c.currentTuning().Timeout)
+	defer cancel()
+
+	result, err := c.breaker.Execute(func() (interface{}, error) {
+		atomic.AddInt64(&c.retryStats.attempts, 1)
+		return c.makeAPIRequest(attemptCtx, request)
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		atomic.AddInt64(&c.retryStats.breakerRejections, 1)
+		return nil, fmt.Errorf("%w: %v", ErrGeminiCircuitOpen, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*GeminiResponse), nil
+}
+
+// RetryStats returns the retry and circuit breaker counters of the client
+func (c *GeminiClient) RetryStats() GeminiRetryStats {
+	stats := c.retryStats.snapshot()
+	stats.BreakerState = c.breaker.State().String()
+	return stats
+}
+
+// calculateRetryDelay calculates retry delay with exponential backoff and jitter
+func (c *GeminiClient) calculateRetryDelay(attempt int) time.Duration {
+	baseDelay := time.Duration(c.config.RetryBaseDelayMs) * time.Millisecond
+	maxDelay := time.Duration(c.config.RetryMaxDelayMs) * time.Millisecond
+
+	delay := maxDelay
+	if attempt < 30 {
+		if d := baseDelay << uint(attempt); d > 0 && d < maxDelay {
+			delay = d
+		}
+	}
+
+	// Keep at least half of the backoff and randomize the rest to spread retries
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
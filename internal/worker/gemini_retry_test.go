@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestGeminiClient(baseURL string, maxRetries, breakerThreshold int) *GeminiClient {
+	return NewGeminiClient(&GeminiConfig{
+		BaseURL:                 baseURL,
+		Model:                   "test-model",
+		MaxRetries:              maxRetries,
+		Timeout:                 5,
+		RetryBaseDelayMs:        1,
+		RetryMaxDelayMs:         5,
+		BreakerFailureThreshold: breakerThreshold,
+		BreakerOpenTimeout:      60,
+	})
+}
+
+func newStatusSequenceServer(statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		status := statuses[len(statuses)-1]
+		if n < len(statuses) {
+			status = statuses[n]
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+			return
+		}
+		w.Write([]byte(`{"error":"failure"}`))
+	}))
+	return server, &calls
+}
+
+func TestGeminiClient_CallWithRetry(t *testing.T) {
+	tests := []struct {
+		name            string
+		statuses        []int
+		maxRetries      int
+		wantErr         bool
+		wantCalls       int32
+		wantRetries     int64
+		wantBreakerOpen bool
+	}{
+		{"succeeds after transient failures", []int{503, 429, 200}, 3, false, 3, 2, false},
+		{"gives up after max retries", []int{503}, 2, true, 3, 2, false},
+		{"does not retry client errors", []int{400}, 3, true, 1, 0, false},
+		{"single attempt without retries", []int{500}, 0, true, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newStatusSequenceServer(tt.statuses...)
+			defer server.Close()
+
+			client := newTestGeminiClient(server.URL, tt.maxRetries, 10)
+			_, err := client.callWithRetry(context.Background(), GeminiRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("Expected %d API calls, got %d", tt.wantCalls, got)
+			}
+
+			stats := client.RetryStats()
+			if stats.Retries != tt.wantRetries {
+				t.Errorf("Expected %d retries, got %d", tt.wantRetries, stats.Retries)
+			}
+			if stats.Calls != 1 {
+				t.Errorf("Expected 1 call recorded, got %d", stats.Calls)
+			}
+			if stats.Attempts != int64(tt.wantCalls) {
+				t.Errorf("Expected %d attempts, got %d", tt.wantCalls, stats.Attempts)
+			}
+		})
+	}
+}
+
+func TestGeminiClient_CircuitBreakerOpens(t *testing.T) {
+	server, calls := newStatusSequenceServer(503)
+	defer server.Close()
+
+	client := newTestGeminiClient(server.URL, 5, 2)
+
+	_, err := client.callWithRetry(context.Background(), GeminiRequest{})
+	if !errors.Is(err, ErrGeminiCircuitOpen) {
+		t.Fatalf("Expected circuit open error, got %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("Expected breaker to stop after 2 API calls, got %d", got)
+	}
+
+	// Further calls are rejected without reaching the provider
+	_, err = client.callWithRetry(context.Background(), GeminiRequest{})
+	if !errors.Is(err, ErrGeminiCircuitOpen) {
+		t.Fatalf("Expected circuit open error, got %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("Expected no API calls while breaker is open, got %d", got)
+	}
+
+	stats := client.RetryStats()
+	if stats.BreakerState != "open" {
+		t.Errorf("Expected breaker state open, got %s", stats.BreakerState)
+	}
+	if stats.BreakerRejections != 2 {
+		t.Errorf("Expected 2 breaker rejections, got %d", stats.BreakerRejections)
+	}
+}
+
+func TestGeminiClient_ClientErrorsDoNotTripBreaker(t *testing.T) {
+	server, _ := newStatusSequenceServer(400)
+	defer server.Close()
+
+	client := newTestGeminiClient(server.URL, 0, 2)
+	for i := 0; i < 5; i++ {
+		client.callWithRetry(context.Background(), GeminiRequest{})
+	}
+
+	if state := client.RetryStats().BreakerState; state != "closed" {
+		t.Errorf("Expected breaker state closed, got %s", state)
+	}
+}
+
+func TestGeminiClient_CalculateRetryDelay(t *testing.T) {
+	client := NewGeminiClient(&GeminiConfig{Timeout: 5, RetryBaseDelayMs: 100, RetryMaxDelayMs: 1000})
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{10, 1000 * time.Millisecond},
+		{100, 1000 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := client.calculateRetryDelay(tt.attempt)
+			if delay < tt.max/2 || delay > tt.max {
+				t.Errorf("Attempt %d: expected delay in [%v, %v], got %v", tt.attempt, tt.max/2, tt.max, delay)
+			}
+		}
+	}
+}
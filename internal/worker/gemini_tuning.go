@@ -0,0 +1,74 @@
+package worker
+
+import "time"
+
+// Generation parameters used until the client is tuned
+const (
+	defaultGeminiTemperature     = 0.4   // Lower temperature for more consistent, realistic results
+	defaultGeminiMaxOutputTokens = 32768 // Maximum tokens for high-quality base64-encoded image output
+)
+
+// GeminiTuning changes the parameters of a Gemini client while it is running.
+// An empty model and a zero noise level or timeout keep the client's
+// GeminiConfig; a zero MaxOutputTokens keeps the default.
+type GeminiTuning struct {
+	Model           string
+	Temperature     float64
+	MaxOutputTokens int
+	NoiseLevel      float64
+	Timeout         time.Duration // per attempt
+}
+
+// DefaultGeminiTuning returns the parameters of a client that was not tuned
+func DefaultGeminiTuning() GeminiTuning {
+	return GeminiTuning{
+		Temperature:     defaultGeminiTemperature,
+		MaxOutputTokens: defaultGeminiMaxOutputTokens,
+	}
+}
+
+// geminiTunable is implemented by providers whose parameters can be tuned
+type geminiTunable interface {
+	SetTuning(tuning GeminiTuning)
+}
+
+// SetTuning changes the parameters used from the next provider call on
+func (c *GeminiClient) SetTuning(tuning GeminiTuning) {
+	c.tuning.Store(&tuning)
+}
+
+// currentTuning returns the tuning with the client's configuration filled in
+func (c *GeminiClient) currentTuning() GeminiTuning {
+	tuning := DefaultGeminiTuning()
+	if stored := c.tuning.Load(); stored != nil {
+		tuning = *stored
+	}
+	if tuning.Model == "" {
+		tuning.Model = c.config.Model
+	}
+	if tuning.MaxOutputTokens <= 0 {
+		tuning.MaxOutputTokens = defaultGeminiMaxOutputTokens
+	}
+	if tuning.NoiseLevel <= 0 {
+		tuning.NoiseLevel = c.config.PreprocessNoiseLevel
+	}
+	if tuning.Timeout <= 0 {
+		tuning.Timeout = time.Duration(c.config.AttemptTimeout) * time.Second
+	}
+	return tuning
+}
+
+// SetGeminiTuning changes the parameters of the Gemini providers while the
+// service is running. Fallback and candidate providers keep their own model.
+func (s *Service) SetGeminiTuning(tuning GeminiTuning) {
+	if api, ok := s.geminiAPI.(geminiTunable); ok {
+		api.SetTuning(tuning)
+	}
+
+	tuning.Model = ""
+	for _, provider := range []GeminiAPI{s.fallbackAPI, s.candidateAPI} {
+		if api, ok := provider.(geminiTunable); ok {
+			api.SetTuning(tuning)
+		}
+	}
+}
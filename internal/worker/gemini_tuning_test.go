@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGeminiClient_TuningFallsBackToConfig(t *testing.T) {
+	client := newTestGeminiClient("http://localhost", 0, 5)
+	client.config.PreprocessNoiseLevel = 0.05
+
+	tuning := client.currentTuning()
+	want := GeminiTuning{
+		Model:           "test-model",
+		Temperature:     defaultGeminiTemperature,
+		MaxOutputTokens: defaultGeminiMaxOutputTokens,
+		NoiseLevel:      0.05,
+		Timeout:         5 * time.Second,
+	}
+	if tuning != want {
+		t.Errorf("untuned client = %+v, want %+v", tuning, want)
+	}
+
+	client.SetTuning(GeminiTuning{Temperature: 0, NoiseLevel: 0.1, Timeout: time.Minute})
+	want = GeminiTuning{
+		Model:           "test-model",
+		Temperature:     0,
+		MaxOutputTokens: defaultGeminiMaxOutputTokens,
+		NoiseLevel:      0.1,
+		Timeout:         time.Minute,
+	}
+	if tuning := client.currentTuning(); tuning != want {
+		t.Errorf("tuned client = %+v, want %+v", tuning, want)
+	}
+}
+
+func TestGeminiClient_TunedModelIsRequested(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestGeminiClient(server.URL, 0, 5)
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	client.SetTuning(GeminiTuning{Model: "tuned-model"})
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+
+	want := []string{"/v1beta/models/test-model:generateContent", "/v1beta/models/tuned-model:generateContent"}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("requested %v, want %v", paths, want)
+	}
+}
+
+func TestService_SetGeminiTuningKeepsFallbackModel(t *testing.T) {
+	primary := newTestGeminiClient("http://localhost", 0, 5)
+	fallback := newTestGeminiClient("http://localhost", 0, 5)
+	fallback.config.Model = "fallback-model"
+	service := &Service{geminiAPI: primary, fallbackAPI: fallback}
+
+	service.SetGeminiTuning(GeminiTuning{Model: "tuned-model", Temperature: 0.8, MaxOutputTokens: 1024})
+
+	if tuning := primary.currentTuning(); tuning.Model != "tuned-model" || tuning.Temperature != 0.8 {
+		t.Errorf("primary tuning = %+v", tuning)
+	}
+	if tuning := fallback.currentTuning(); tuning.Model != "fallback-model" || tuning.MaxOutputTokens != 1024 {
+		t.Errorf("fallback tuning = %+v", tuning)
+	}
+}
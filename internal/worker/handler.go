@@ -1,11 +1,15 @@
 package worker
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"ai-styler/internal/common"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // Handler handles HTTP requests for the worker service
@@ -51,10 +55,7 @@ func (h *Handler) StartWorker(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	if err := h.service.Start(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to start worker service",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to start worker service", err.Error())
 		return
 	}
 
@@ -69,10 +70,7 @@ func (h *Handler) StopWorker(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	if err := h.service.Stop(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to stop worker service",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to stop worker service", err.Error())
 		return
 	}
 
@@ -88,10 +86,7 @@ func (h *Handler) GetStatus(c *gin.Context) {
 
 	status, err := h.service.GetStatus(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get worker status",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get worker status", err.Error())
 		return
 	}
 
@@ -104,10 +99,7 @@ func (h *Handler) GetHealth(c *gin.Context) {
 
 	health, err := h.service.GetHealth(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get worker health",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get worker health", err.Error())
 		return
 	}
 
@@ -129,10 +121,7 @@ func (h *Handler) EnqueueJob(c *gin.Context) {
 
 	var req EnqueueJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
 
@@ -142,10 +131,7 @@ func (h *Handler) EnqueueJob(c *gin.Context) {
 	}
 
 	if err := h.service.EnqueueJob(ctx, req.Type, req.ConversionID, req.UserID, req.Payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to enqueue job",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to enqueue job", err.Error())
 		return
 	}
 
@@ -164,10 +150,7 @@ type GetJobRequest struct {
 func (h *Handler) GetJob(c *gin.Context) {
 	var req GetJobRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid job ID",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid job ID", err.Error())
 		return
 	}
 
@@ -190,18 +173,12 @@ func (h *Handler) CancelJob(c *gin.Context) {
 
 	var req CancelJobRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid job ID",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid job ID", err.Error())
 		return
 	}
 
 	if err := h.service.CancelJob(ctx, req.JobID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to cancel job",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to cancel job", err.Error())
 		return
 	}
 
@@ -220,10 +197,7 @@ type ProcessJobRequest struct {
 func (h *Handler) ProcessJob(c *gin.Context) {
 	var req ProcessJobRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid job ID",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid job ID", err.Error())
 		return
 	}
 
@@ -241,10 +215,7 @@ func (h *Handler) GetConfig(c *gin.Context) {
 
 	config, err := h.service.GetConfig(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get configuration",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get configuration", err.Error())
 		return
 	}
 
@@ -270,20 +241,14 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 
 	var req UpdateConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
 
 	// Get current config
 	currentConfig, err := h.service.GetConfig(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get current configuration",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get current configuration", err.Error())
 		return
 	}
 
@@ -318,10 +283,7 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 
 	// Apply the updated configuration
 	if err := h.service.UpdateConfig(ctx, currentConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update configuration",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to update configuration", err.Error())
 		return
 	}
 
@@ -338,10 +300,7 @@ func (h *Handler) GetStats(c *gin.Context) {
 
 	stats, err := h.service.GetStatus(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get statistics",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get statistics", err.Error())
 		return
 	}
 
@@ -365,6 +324,80 @@ func (h *Handler) GetWorkers(c *gin.Context) {
 	})
 }
 
+// GetWorkerReport returns the workers of every instance, in-flight jobs with
+// their runtimes and the queue depth
+func (h *Handler) GetWorkerReport(c *gin.Context) {
+	report, err := h.service.GetWorkerReport(c.Request.Context())
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get worker status", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetJobLifecycle returns a job with its lifecycle timestamps and conversion logs
+func (h *Handler) GetJobLifecycle(c *gin.Context) {
+	var req GetJobRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+	if _, err := uuid.Parse(req.JobID); err != nil {
+		common.RespondErrorWithDetails(c, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+
+	lifecycle, err := h.service.GetJobLifecycle(c.Request.Context(), req.JobID)
+	if errors.Is(err, common.ErrNotFound) {
+		common.RespondError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get job", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, lifecycle)
+}
+
+// ListScheduledJobs returns the scheduled jobs with their next and last runs
+func (h *Handler) ListScheduledJobs(c *gin.Context) {
+	jobs, err := h.service.ListScheduledJobs(c.Request.Context())
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to list scheduled jobs", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// ListScheduledJobRuns returns the latest runs of a scheduled job
+// (?limit=N, default 20, max jobRunHistory)
+func (h *Handler) ListScheduledJobRuns(c *gin.Context) {
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > jobRunHistory {
+			common.RespondError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.service.ListScheduledJobRuns(c.Request.Context(), c.Param("name"), limit)
+	if errors.Is(err, common.ErrNotFound) {
+		common.RespondError(c, http.StatusNotFound, "Scheduled job not found")
+		return
+	}
+	if err != nil {
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to list scheduled job runs", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
 // HealthCheckHandler provides a simple health check endpoint
 func (h *Handler) HealthCheckHandler(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -398,10 +431,7 @@ func (h *Handler) MetricsHandler(c *gin.Context) {
 
 	stats, err := h.service.GetStatus(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get metrics",
-			"details": err.Error(),
-		})
+		common.RespondErrorWithDetails(c, http.StatusInternalServerError, "Failed to get metrics", err.Error())
 		return
 	}
 
@@ -440,6 +470,39 @@ func (h *Handler) MetricsHandler(c *gin.Context) {
 		"worker_success_rate " + strconv.FormatFloat(stats.SuccessRate, 'f', 4, 64),
 	}
 
+	if retry := stats.GeminiRetry; retry != nil {
+		breakerOpen := "0"
+		if retry.BreakerState == "open" {
+			breakerOpen = "1"
+		}
+		metrics = append(metrics,
+			"",
+			"# HELP gemini_calls_total Total number of Gemini conversion calls",
+			"# TYPE gemini_calls_total counter",
+			"gemini_calls_total "+strconv.FormatInt(retry.Calls, 10),
+			"",
+			"# HELP gemini_attempts_total Total number of Gemini API attempts",
+			"# TYPE gemini_attempts_total counter",
+			"gemini_attempts_total "+strconv.FormatInt(retry.Attempts, 10),
+			"",
+			"# HELP gemini_retries_total Total number of Gemini API retries",
+			"# TYPE gemini_retries_total counter",
+			"gemini_retries_total "+strconv.FormatInt(retry.Retries, 10),
+			"",
+			"# HELP gemini_calls_failed_total Number of Gemini calls that failed after all retries",
+			"# TYPE gemini_calls_failed_total counter",
+			"gemini_calls_failed_total "+strconv.FormatInt(retry.Failures, 10),
+			"",
+			"# HELP gemini_breaker_rejections_total Number of attempts rejected by the circuit breaker",
+			"# TYPE gemini_breaker_rejections_total counter",
+			"gemini_breaker_rejections_total "+strconv.FormatInt(retry.BreakerRejections, 10),
+			"",
+			"# HELP gemini_breaker_open Whether the Gemini circuit breaker is open",
+			"# TYPE gemini_breaker_open gauge",
+			"gemini_breaker_open "+breakerOpen,
+		)
+	}
+
 	c.Header("Content-Type", "text/plain")
 	c.String(http.StatusOK, "%s\n", metrics)
 }
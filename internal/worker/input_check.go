@@ -0,0 +1,378 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Input check providers
+const (
+	InputCheckProviderLocal = "local"
+	InputCheckProviderAPI   = "api"
+)
+
+// Error codes reported to clients when a conversion fails because of its
+// inputs, so they can tell the user what to change
+const (
+	ErrorCodeNoPerson        = "no_person_detected"
+	ErrorCodeMultiplePeople  = "multiple_people"
+	ErrorCodePersonTooSmall  = "person_too_small"
+	ErrorCodeImageTooSmall   = "image_too_small" // user photo below the minimum resolution
+	ErrorCodeGarmentTooSmall = "garment_too_small"
+	ErrorCodeImageRejected   = "image_rejected" // failed moderation
+	ErrorCodeInvalidImage    = "invalid_image"  // empty, oversized or undecodable
+	ErrorCodeSafetyBlocked   = "safety_blocked" // the provider's safety filter blocked the result
+)
+
+// ErrInputCheckFailed is returned when an input image fails the pre-check
+var ErrInputCheckFailed = errors.New("input check failed")
+
+// InputCheckError is an input image that failed the pre-check, with the code
+// reported to the client
+type InputCheckError struct {
+	Code      string
+	ImageKind string // user or cloth
+	Detail    string
+}
+
+func (e *InputCheckError) Error() string {
+	return fmt.Sprintf("%s: %s image: %s", ErrInputCheckFailed, e.ImageKind, e.Detail)
+}
+
+func (e *InputCheckError) Unwrap() error {
+	return ErrInputCheckFailed
+}
+
+// InputCheckConfig represents configuration for the input pre-check stage
+type InputCheckConfig struct {
+	Enabled         bool    `json:"enabled"`
+	Provider        string  `json:"provider"` // local or api
+	APIURL          string  `json:"apiUrl"`
+	APIKey          string  `json:"-"`
+	Timeout         int     `json:"timeout"`         // in seconds
+	MinUserSide     int     `json:"minUserSide"`     // minimum width and height of the user photo in pixels
+	MinGarmentSide  int     `json:"minGarmentSide"`  // minimum width and height of a garment photo in pixels
+	MinPersonRatio  float64 `json:"minPersonRatio"`  // share of the photo height the person must span (0.0-1.0)
+	MinGarmentRatio float64 `json:"minGarmentRatio"` // share of the photo area the garment must cover (0.0-1.0)
+	MaxPeople       int     `json:"maxPeople"`       // 0 allows any number of people
+	FailOpen        bool    `json:"failOpen"`        // allow images when the detector errors
+}
+
+// DefaultInputCheckConfig returns the default input check configuration
+func DefaultInputCheckConfig() InputCheckConfig {
+	return InputCheckConfig{
+		Provider:        InputCheckProviderLocal,
+		Timeout:         10,
+		MinUserSide:     256,
+		MinGarmentSide:  200,
+		MinPersonRatio:  0.3,
+		MinGarmentRatio: 0.1,
+		MaxPeople:       1,
+		FailOpen:        true,
+	}
+}
+
+// InputDetection is the raw output of an input detector. Negative values
+// mean the detector cannot tell.
+type InputDetection struct {
+	People            int     `json:"people"`
+	PersonHeightRatio float64 `json:"person_height_ratio"` // height of the largest person over the photo height
+	SubjectAreaRatio  float64 `json:"subject_area_ratio"`  // share of the photo covered by its subject
+}
+
+// InputDetector finds the people and the subject in an input image
+type InputDetector interface {
+	Detect(ctx context.Context, data []byte, imageKind string) (*InputDetection, error)
+	Name() string
+}
+
+// InputChecker validates input images before a conversion is sent to the
+// provider, so bad inputs fail fast with an actionable error code
+type InputChecker struct {
+	config   InputCheckConfig
+	detector InputDetector
+}
+
+// NewInputChecker creates a new input checker
+func NewInputChecker(config InputCheckConfig, detector InputDetector) *InputChecker {
+	defaults := DefaultInputCheckConfig()
+	if config.MinPersonRatio < 0 || config.MinPersonRatio > 1 {
+		config.MinPersonRatio = defaults.MinPersonRatio
+	}
+	if config.MinGarmentRatio < 0 || config.MinGarmentRatio > 1 {
+		config.MinGarmentRatio = defaults.MinGarmentRatio
+	}
+
+	return &InputChecker{
+		config:   config,
+		detector: detector,
+	}
+}
+
+// NewInputDetector creates the detector for the configured provider
+func NewInputDetector(config InputCheckConfig) (InputDetector, error) {
+	switch config.Provider {
+	case "", InputCheckProviderLocal:
+		return NewLocalInputDetector(), nil
+	case InputCheckProviderAPI:
+		if config.APIURL == "" {
+			return nil, errors.New("input check API URL is required for the api provider")
+		}
+		return NewAPIInputDetector(config.APIURL, config.APIKey, time.Duration(config.Timeout)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown input check provider: %s", config.Provider)
+	}
+}
+
+// Check validates an input image. It returns an *InputCheckError when the
+// image would not make a usable conversion.
+func (c *InputChecker) Check(ctx context.Context, imageKind string, data []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return &InputCheckError{Code: ErrorCodeInvalidImage, ImageKind: imageKind, Detail: "image cannot be decoded"}
+	}
+	if err := c.checkSize(imageKind, cfg.Width, cfg.Height); err != nil {
+		return err
+	}
+
+	detection, err := c.detector.Detect(ctx, data, imageKind)
+	if err != nil {
+		if c.config.FailOpen {
+			log.Printf("Input detector failed for %s image, allowing it: %v", imageKind, err)
+			return nil
+		}
+		return fmt.Errorf("input check unavailable: %w", err)
+	}
+	return c.evaluate(detection, imageKind)
+}
+
+// checkSize rejects images below the minimum resolution for their kind
+func (c *InputChecker) checkSize(imageKind string, width, height int) error {
+	side := min(width, height)
+	if imageKind == ModerationImageUser && c.config.MinUserSide > 0 && side < c.config.MinUserSide {
+		return &InputCheckError{
+			Code:      ErrorCodeImageTooSmall,
+			ImageKind: imageKind,
+			Detail:    fmt.Sprintf("photo is %dx%d, at least %dpx per side is needed", width, height, c.config.MinUserSide),
+		}
+	}
+	if imageKind == ModerationImageCloth && c.config.MinGarmentSide > 0 && side < c.config.MinGarmentSide {
+		return &InputCheckError{
+			Code:      ErrorCodeGarmentTooSmall,
+			ImageKind: imageKind,
+			Detail:    fmt.Sprintf("photo is %dx%d, at least %dpx per side is needed", width, height, c.config.MinGarmentSide),
+		}
+	}
+	return nil
+}
+
+// evaluate applies the input rules to a detector result
+func (c *InputChecker) evaluate(detection *InputDetection, imageKind string) error {
+	fail := func(code, detail string) error {
+		return &InputCheckError{Code: code, ImageKind: imageKind, Detail: detail}
+	}
+
+	if imageKind == ModerationImageCloth {
+		if detection.SubjectAreaRatio >= 0 && detection.SubjectAreaRatio < c.config.MinGarmentRatio {
+			return fail(ErrorCodeGarmentTooSmall, fmt.Sprintf("garment covers %.0f%% of the photo", detection.SubjectAreaRatio*100))
+		}
+		return nil
+	}
+
+	if detection.People == 0 {
+		return fail(ErrorCodeNoPerson, "no person found in the photo")
+	}
+	if c.config.MaxPeople > 0 && detection.People > c.config.MaxPeople {
+		return fail(ErrorCodeMultiplePeople, fmt.Sprintf("%d people found in the photo", detection.People))
+	}
+	if detection.PersonHeightRatio >= 0 && detection.PersonHeightRatio < c.config.MinPersonRatio {
+		return fail(ErrorCodePersonTooSmall, fmt.Sprintf("person spans %.0f%% of the photo height", detection.PersonHeightRatio*100))
+	}
+	return nil
+}
+
+// errorCode returns the client error code of a job error, empty when the
+// failure was not caused by the inputs
+func errorCode(err error) string {
+	var inputErr *InputCheckError
+	switch {
+	case errors.As(err, &inputErr):
+		return inputErr.Code
+	case errors.Is(err, ErrImageRejected):
+		return ErrorCodeImageRejected
+	case errors.Is(err, ErrInvalidInputImage):
+		return ErrorCodeInvalidImage
+	case errors.Is(err, ErrSafetyBlocked):
+		return ErrorCodeSafetyBlocked
+	}
+	return ""
+}
+
+// Thresholds of the local input detector
+const (
+	// minSkinShare is the share of skin-tone samples below which a photo is
+	// taken to show no person
+	minSkinShare = 0.005
+	// backgroundDistance is the summed RGB difference from the background
+	// color above which a sample belongs to the subject
+	backgroundDistance = 90
+)
+
+// localInputDetector is a dependency-free detector. It takes the average
+// border color as the background and measures the box around everything that
+// differs from it; a person is assumed when enough skin tone is visible. It
+// cannot count people.
+type localInputDetector struct {
+	sampleSize int
+}
+
+// NewLocalInputDetector creates a local heuristic detector
+func NewLocalInputDetector() InputDetector {
+	return &localInputDetector{sampleSize: 128}
+}
+
+// Name returns the detector name
+func (d *localInputDetector) Name() string {
+	return InputCheckProviderLocal
+}
+
+// Detect samples the image on a grid and measures its subject
+func (d *localInputDetector) Detect(ctx context.Context, data []byte, imageKind string) (*InputDetection, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	stepX := max(bounds.Dx()/d.sampleSize, 1)
+	stepY := max(bounds.Dy()/d.sampleSize, 1)
+
+	type sample struct{ r, g, b uint32 }
+	var samples [][]sample
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		var row []sample
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			row = append(row, sample{r >> 8, g >> 8, b >> 8})
+		}
+		samples = append(samples, row)
+	}
+	rows, cols := len(samples), len(samples[0])
+
+	// The average border color stands in for the background
+	var bgR, bgG, bgB, border uint32
+	for y, row := range samples {
+		for x, s := range row {
+			if y == 0 || y == rows-1 || x == 0 || x == cols-1 {
+				bgR, bgG, bgB = bgR+s.r, bgG+s.g, bgB+s.b
+				border++
+			}
+		}
+	}
+	bgR, bgG, bgB = bgR/border, bgG/border, bgB/border
+
+	minX, minY, maxX, maxY := cols, rows, -1, -1
+	var skin int
+	for y, row := range samples {
+		for x, s := range row {
+			if isSkinTone(s.r, s.g, s.b) {
+				skin++
+			}
+			if absDiff(s.r, bgR)+absDiff(s.g, bgG)+absDiff(s.b, bgB) <= backgroundDistance {
+				continue
+			}
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+		}
+	}
+
+	detection := &InputDetection{People: -1, PersonHeightRatio: -1, SubjectAreaRatio: 0}
+	if maxX >= 0 {
+		width, height := maxX-minX+1, maxY-minY+1
+		detection.SubjectAreaRatio = float64(width*height) / float64(rows*cols)
+		if imageKind == ModerationImageUser {
+			detection.PersonHeightRatio = float64(height) / float64(rows)
+		}
+	}
+	if imageKind == ModerationImageUser && float64(skin)/float64(rows*cols) < minSkinShare {
+		detection.People = 0
+	}
+	return detection, nil
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// apiInputDetector calls an external person and garment detection API
+type apiInputDetector struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewAPIInputDetector creates a detector backed by an external detection API.
+// The API receives {"image": base64, "kind": "user|cloth"} and returns an
+// InputDetection; fields it leaves out are treated as unknown.
+func NewAPIInputDetector(url, apiKey string, timeout time.Duration) InputDetector {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &apiInputDetector{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the detector name
+func (d *apiInputDetector) Name() string {
+	return InputCheckProviderAPI
+}
+
+// Detect sends the image to the detection API
+func (d *apiInputDetector) Detect(ctx context.Context, data []byte, imageKind string) (*InputDetection, error) {
+	body, err := json.Marshal(map[string]string{
+		"image": base64.StdEncoding.EncodeToString(data),
+		"kind":  imageKind,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input check request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input check request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("input check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("input check API returned status %d", resp.StatusCode)
+	}
+
+	detection := &InputDetection{People: -1, PersonHeightRatio: -1, SubjectAreaRatio: -1}
+	if err := json.NewDecoder(resp.Body).Decode(detection); err != nil {
+		return nil, fmt.Errorf("failed to decode input check response: %w", err)
+	}
+	return detection, nil
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"ai-styler/internal/conversion"
+)
+
+type fakeInputDetector struct {
+	detection *InputDetection
+	err       error
+}
+
+func (d *fakeInputDetector) Detect(ctx context.Context, data []byte, imageKind string) (*InputDetection, error) {
+	return d.detection, d.err
+}
+
+func (d *fakeInputDetector) Name() string {
+	return "fake"
+}
+
+// encodeTestImage draws a w x h image of background with a box of fg
+func encodeTestImage(t *testing.T, w, h int, background color.Color, box image.Rectangle, fg color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (image.Point{X: x, Y: y}).In(box) {
+				img.Set(x, y, fg)
+			} else {
+				img.Set(x, y, background)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInputChecker_Check(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	photo := encodeTestImage(t, 300, 300, white, image.Rectangle{}, white)
+	small := encodeTestImage(t, 100, 100, white, image.Rectangle{}, white)
+
+	tests := []struct {
+		name      string
+		imageKind string
+		data      []byte
+		detection *InputDetection
+		detectErr error
+		failOpen  bool
+		expected  string // error code, empty when the image passes
+	}{
+		{"usable photo", ModerationImageUser, photo, &InputDetection{People: 1, PersonHeightRatio: 0.8, SubjectAreaRatio: 0.5}, nil, false, ""},
+		{"no person", ModerationImageUser, photo, &InputDetection{People: 0, PersonHeightRatio: -1, SubjectAreaRatio: -1}, nil, false, ErrorCodeNoPerson},
+		{"two people", ModerationImageUser, photo, &InputDetection{People: 2, PersonHeightRatio: 0.8, SubjectAreaRatio: 0.5}, nil, false, ErrorCodeMultiplePeople},
+		{"person too small", ModerationImageUser, photo, &InputDetection{People: 1, PersonHeightRatio: 0.1, SubjectAreaRatio: 0.05}, nil, false, ErrorCodePersonTooSmall},
+		{"unknown counts pass", ModerationImageUser, photo, &InputDetection{People: -1, PersonHeightRatio: -1, SubjectAreaRatio: -1}, nil, false, ""},
+		{"low resolution photo", ModerationImageUser, small, &InputDetection{People: 1, PersonHeightRatio: 1}, nil, false, ErrorCodeImageTooSmall},
+		{"low resolution garment", ModerationImageCloth, small, &InputDetection{SubjectAreaRatio: 1}, nil, false, ErrorCodeGarmentTooSmall},
+		{"tiny garment", ModerationImageCloth, photo, &InputDetection{People: -1, PersonHeightRatio: -1, SubjectAreaRatio: 0.02}, nil, false, ErrorCodeGarmentTooSmall},
+		{"undecodable image", ModerationImageUser, []byte("not an image"), nil, nil, false, ErrorCodeInvalidImage},
+		{"detector error fails open", ModerationImageUser, photo, nil, errors.New("timeout"), true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultInputCheckConfig()
+			config.FailOpen = tt.failOpen
+			checker := NewInputChecker(config, &fakeInputDetector{detection: tt.detection, err: tt.detectErr})
+
+			err := checker.Check(context.Background(), tt.imageKind, tt.data)
+			if code := errorCode(err); code != tt.expected {
+				t.Errorf("Expected code %q, got %q (%v)", tt.expected, code, err)
+			}
+			if tt.expected != "" && failureKind(err) != conversion.FailureKindRequest {
+				t.Errorf("Expected a request failure, got %s", failureKind(err))
+			}
+		})
+	}
+
+	// Failing closed on detector errors is not the user's fault
+	config := DefaultInputCheckConfig()
+	config.FailOpen = false
+	checker := NewInputChecker(config, &fakeInputDetector{err: errors.New("timeout")})
+	err := checker.Check(context.Background(), ModerationImageUser, photo)
+	if err == nil || errorCode(err) != "" || failureKind(err) != conversion.FailureKindProvider {
+		t.Errorf("Expected a provider failure without a code, got %v", err)
+	}
+}
+
+func TestLocalInputDetector(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	skin := color.RGBA{R: 224, G: 172, B: 140, A: 255}
+	blue := color.RGBA{B: 200, A: 255}
+	detector := NewLocalInputDetector()
+
+	person, err := detector.Detect(context.Background(), encodeTestImage(t, 200, 200, white, image.Rect(60, 20, 140, 180), skin), ModerationImageUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if person.People != -1 || person.PersonHeightRatio < 0.75 || person.PersonHeightRatio > 0.85 {
+		t.Errorf("Expected an uncounted person spanning ~80%% of the height, got %+v", person)
+	}
+
+	empty, err := detector.Detect(context.Background(), encodeTestImage(t, 200, 200, white, image.Rect(60, 20, 140, 180), blue), ModerationImageUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if empty.People != 0 {
+		t.Errorf("Expected no person without skin tone, got %+v", empty)
+	}
+
+	garment, err := detector.Detect(context.Background(), encodeTestImage(t, 200, 200, white, image.Rect(90, 90, 110, 110), blue), ModerationImageCloth)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if garment.SubjectAreaRatio <= 0 || garment.SubjectAreaRatio > 0.02 {
+		t.Errorf("Expected the garment to cover ~1%% of the photo, got %+v", garment)
+	}
+	if garment.PersonHeightRatio != -1 {
+		t.Errorf("Expected no person measure for garments, got %+v", garment)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&InputCheckError{Code: ErrorCodeNoPerson, ImageKind: ModerationImageUser}, ErrorCodeNoPerson},
+		{fmt.Errorf("user image: %w", ErrImageRejected), ErrorCodeImageRejected},
+		{fmt.Errorf("%w: %w", ErrInvalidInputImage, errors.New("unsupported format")), ErrorCodeInvalidImage},
+		{fmt.Errorf("failed to convert image with Gemini: %w", fmt.Errorf("failed to extract result image: %w", ErrSafetyBlocked)), ErrorCodeSafetyBlocked},
+		{errors.New("gemini API returned status 503"), ""},
+	}
+	for _, tt := range tests {
+		if code := errorCode(tt.err); code != tt.expected {
+			t.Errorf("Expected %q for %q, got %q", tt.expected, tt.err, code)
+		}
+	}
+}
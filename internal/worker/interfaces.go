@@ -16,6 +16,7 @@ type JobQueue interface {
 	UpdateJobStatus(ctx context.Context, jobID string, status JobStatus, workerID string) error
 	CompleteJob(ctx context.Context, jobID string, result interface{}) error
 	FailJob(ctx context.Context, jobID string, errorMessage string) error
+	RequeueJob(ctx context.Context, jobID string) error
 	GetJob(ctx context.Context, jobID string) (*WorkerJob, error)
 
 	// Queue management
@@ -91,6 +92,12 @@ type NotificationService interface {
 	SendConversionFailed(ctx context.Context, userID, conversionID, errorMessage string) error
 }
 
+// QuotaRefunds gives back the quota charged for a failed conversion. It
+// joins the caller's unit of work and refunds a conversion at most once.
+type QuotaRefunds interface {
+	RefundConversion(ctx context.Context, conversionID string) (int, error)
+}
+
 // MetricsCollector defines the interface for collecting worker metrics
 type MetricsCollector interface {
 	RecordJobStart(ctx context.Context, jobID, jobType string) error
@@ -128,6 +135,12 @@ type WorkerService interface {
 	ProcessJob(ctx context.Context, job *WorkerJob) error
 	CancelJob(ctx context.Context, jobID string) error
 
+	// Introspection
+	GetWorkerReport(ctx context.Context) (*WorkerReport, error)
+	GetJobLifecycle(ctx context.Context, jobID string) (*JobLifecycle, error)
+	ListScheduledJobs(ctx context.Context) ([]ScheduledJobStatus, error)
+	ListScheduledJobRuns(ctx context.Context, name string, limit int) ([]JobRun, error)
+
 	// Configuration
 	UpdateConfig(ctx context.Context, config *WorkerConfig) error
 	GetConfig(ctx context.Context) (*WorkerConfig, error)
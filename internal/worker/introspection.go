@@ -0,0 +1,582 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/lib/pq"
+)
+
+// DefaultHeartbeatInterval is how often worker heartbeats are saved when no
+// interval is configured
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// A worker whose heartbeat is older than staleHeartbeats intervals is reported
+// as stale, its instance most likely crashed
+const staleHeartbeats = 3
+
+// heartbeatRetention is how long heartbeats of crashed instances are kept and
+// reported before they are pruned
+const heartbeatRetention = time.Hour
+
+// maxInFlightJobs caps the in-flight jobs listed in a worker report
+const maxInFlightJobs = 200
+
+// WorkerHeartbeat is the last reported state of a single worker loop
+type WorkerHeartbeat struct {
+	WorkerID      string     `json:"workerId"`
+	InstanceID    string     `json:"instanceId"`
+	Status        string     `json:"status"`
+	CurrentJobID  string     `json:"currentJobId,omitempty"`
+	JobsProcessed int64      `json:"jobsProcessed"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	StartedAt     time.Time  `json:"startedAt"`
+	LastSeen      time.Time  `json:"lastSeen"`
+	Stale         bool       `json:"stale"`
+}
+
+// InFlightJob is a job being processed and how long it has been running
+type InFlightJob struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	ConversionID string    `json:"conversionId"`
+	UserID       string    `json:"userId"`
+	WorkerID     string    `json:"workerId,omitempty"`
+	RetryCount   int       `json:"retryCount"`
+	RestartCount int       `json:"restartCount"`
+	StartedAt    time.Time `json:"startedAt"`
+	RuntimeMs    int64     `json:"runtimeMs"`
+	Orphaned     bool      `json:"orphaned"` // no live worker has reported holding the job
+}
+
+// WorkerReport is the admin view of the workers and the queue
+type WorkerReport struct {
+	ActiveWorkers int               `json:"activeWorkers"`
+	Workers       []WorkerHeartbeat `json:"workers"`
+	InFlightJobs  []InFlightJob     `json:"inFlightJobs"`
+	Queue         *WorkerStats      `json:"queue"`
+	GeneratedAt   time.Time         `json:"generatedAt"`
+}
+
+// JobLifecycle is a job with its lifecycle timestamps and conversion logs
+type JobLifecycle struct {
+	Job        *WorkerJob           `json:"job"`
+	BoostedAt  *time.Time           `json:"boostedAt,omitempty"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+	WaitMs     *int64               `json:"waitMs,omitempty"` // queued until started, or until now while pending
+	RunMs      *int64               `json:"runMs,omitempty"`  // started until finished, or until now while processing
+	Logs       []ConversionLogEntry `json:"logs"`
+}
+
+// IntrospectionStore persists worker heartbeats and looks up jobs for the
+// admin worker endpoints
+type IntrospectionStore interface {
+	SaveHeartbeats(ctx context.Context, heartbeats []WorkerHeartbeat) error
+	DeleteHeartbeats(ctx context.Context, instanceID string) error
+	PruneHeartbeats(ctx context.Context, before time.Time) (int64, error)
+	ListHeartbeats(ctx context.Context, since time.Time) ([]WorkerHeartbeat, error)
+	ListInFlightJobs(ctx context.Context, limit int) ([]InFlightJob, error)
+	// GetJobLifecycle returns common.ErrNotFound when the job doesn't exist
+	GetJobLifecycle(ctx context.Context, jobID string) (*JobLifecycle, error)
+}
+
+// SetIntrospection saves the state of this instance's workers every interval
+// so the worker report covers every instance. Without it the report only
+// shows the workers of the instance serving the request.
+func (s *Service) SetIntrospection(store IntrospectionStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	s.introspection = store
+	s.heartbeatInterval = interval
+}
+
+// GetWorkerReport returns the workers, in-flight jobs and queue depth
+func (s *Service) GetWorkerReport(ctx context.Context) (*WorkerReport, error) {
+	queue, err := s.GetStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+
+	now := time.Now()
+	report := &WorkerReport{Queue: queue, GeneratedAt: now}
+
+	if s.introspection == nil {
+		report.Workers = s.workerSnapshot(now)
+		report.InFlightJobs = s.localInFlightJobs(now)
+		report.ActiveWorkers = len(report.Workers)
+		return report, nil
+	}
+
+	heartbeats, err := s.introspection.ListHeartbeats(ctx, now.Add(-heartbeatRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker heartbeats: %w", err)
+	}
+	staleAfter := staleHeartbeats * s.heartbeatInterval
+	holders := make(map[string]string)
+	for i := range heartbeats {
+		heartbeat := &heartbeats[i]
+		heartbeat.Stale = now.Sub(heartbeat.LastSeen) > staleAfter
+		if heartbeat.Stale {
+			continue
+		}
+		report.ActiveWorkers++
+		if heartbeat.CurrentJobID != "" {
+			holders[heartbeat.CurrentJobID] = heartbeat.WorkerID
+		}
+	}
+
+	jobs, err := s.introspection.ListInFlightJobs(ctx, maxInFlightJobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in-flight jobs: %w", err)
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		job.RuntimeMs = now.Sub(job.StartedAt).Milliseconds()
+		if holder, ok := holders[job.ID]; ok {
+			job.WorkerID = holder
+		} else {
+			// Jobs picked up since the last heartbeat aren't held by anyone yet
+			job.Orphaned = now.Sub(job.StartedAt) > staleAfter
+		}
+	}
+
+	report.Workers = heartbeats
+	report.InFlightJobs = jobs
+	return report, nil
+}
+
+// GetJobLifecycle returns a job with its lifecycle timestamps and, when an
+// introspection store is set, its conversion logs
+func (s *Service) GetJobLifecycle(ctx context.Context, jobID string) (*JobLifecycle, error) {
+	var lifecycle *JobLifecycle
+	if s.introspection != nil {
+		found, err := s.introspection.GetJobLifecycle(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		lifecycle = found
+	} else {
+		job, err := s.jobQueue.GetJob(ctx, jobID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && job == nil) {
+			return nil, fmt.Errorf("%w: job %s", common.ErrNotFound, jobID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job: %w", err)
+		}
+		lifecycle = &JobLifecycle{Job: job}
+	}
+
+	lifecycle.setDurations(time.Now())
+	return lifecycle, nil
+}
+
+// setDurations fills in when the job finished and how long it waited and ran.
+// Failed and cancelled jobs have no completed_at, they finished at their last update.
+func (l *JobLifecycle) setDurations(now time.Time) {
+	job := l.Job
+	switch job.Status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		finishedAt := job.UpdatedAt
+		if job.CompletedAt != nil {
+			finishedAt = *job.CompletedAt
+		}
+		l.FinishedAt = &finishedAt
+	}
+
+	if job.StartedAt == nil {
+		if job.Status == JobStatusPending {
+			wait := now.Sub(job.CreatedAt).Milliseconds()
+			l.WaitMs = &wait
+		}
+		return
+	}
+
+	wait := job.StartedAt.Sub(job.CreatedAt).Milliseconds()
+	l.WaitMs = &wait
+
+	end := now
+	if l.FinishedAt != nil {
+		end = *l.FinishedAt
+	}
+	run := end.Sub(*job.StartedAt).Milliseconds()
+	l.RunMs = &run
+}
+
+// recordWorkerError keeps the last error a worker loop ran into for the worker report
+func (s *Service) recordWorkerError(worker *Worker, err error) {
+	now := time.Now()
+	s.workerMutex.Lock()
+	worker.LastError = err.Error()
+	worker.LastErrorAt = &now
+	s.workerMutex.Unlock()
+}
+
+// workerSnapshot returns the state of this instance's worker loops, sorted by ID
+func (s *Service) workerSnapshot(now time.Time) []WorkerHeartbeat {
+	s.workerMutex.RLock()
+	defer s.workerMutex.RUnlock()
+
+	heartbeats := make([]WorkerHeartbeat, 0, len(s.workers))
+	for _, worker := range s.workers {
+		heartbeat := WorkerHeartbeat{
+			WorkerID:      worker.ID,
+			InstanceID:    s.workerID,
+			Status:        worker.Status,
+			JobsProcessed: worker.JobsProcessed,
+			LastError:     worker.LastError,
+			LastErrorAt:   worker.LastErrorAt,
+			StartedAt:     worker.StartedAt,
+			LastSeen:      now,
+		}
+		if worker.CurrentJob != nil {
+			heartbeat.CurrentJobID = worker.CurrentJob.ID
+		}
+		heartbeats = append(heartbeats, heartbeat)
+	}
+
+	sort.Slice(heartbeats, func(i, j int) bool { return heartbeats[i].WorkerID < heartbeats[j].WorkerID })
+	return heartbeats
+}
+
+// localInFlightJobs returns the jobs this instance's worker loops are processing
+func (s *Service) localInFlightJobs(now time.Time) []InFlightJob {
+	s.workerMutex.RLock()
+	defer s.workerMutex.RUnlock()
+
+	jobs := make([]InFlightJob, 0)
+	for _, worker := range s.workers {
+		job := worker.CurrentJob
+		if job == nil {
+			continue
+		}
+		startedAt := worker.LastSeen
+		if job.StartedAt != nil {
+			startedAt = *job.StartedAt
+		}
+		jobs = append(jobs, InFlightJob{
+			ID:           job.ID,
+			Type:         job.Type,
+			ConversionID: job.ConversionID,
+			UserID:       job.UserID,
+			WorkerID:     worker.ID,
+			RetryCount:   job.RetryCount,
+			RestartCount: job.RestartCount,
+			StartedAt:    startedAt,
+			RuntimeMs:    now.Sub(startedAt).Milliseconds(),
+		})
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.Before(jobs[j].StartedAt) })
+	return jobs
+}
+
+// heartbeatLoop saves the state of this instance's workers every heartbeat
+// interval and prunes heartbeats of instances that went away without stopping
+func (s *Service) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := s.introspection.SaveHeartbeats(ctx, s.workerSnapshot(now)); err != nil {
+				log.Printf("Failed to save worker heartbeats: %v", err)
+			}
+			if _, err := s.introspection.PruneHeartbeats(ctx, now.Add(-heartbeatRetention)); err != nil {
+				log.Printf("Failed to prune worker heartbeats: %v", err)
+			}
+		}
+	}
+}
+
+// dbIntrospectionStore implements IntrospectionStore on top of the
+// worker_heartbeats, worker_jobs and conversion_logs tables
+type dbIntrospectionStore struct {
+	db *sql.DB
+}
+
+// NewDBIntrospectionStore creates a new database-backed introspection store
+func NewDBIntrospectionStore(db *sql.DB) IntrospectionStore {
+	return &dbIntrospectionStore{db: db}
+}
+
+// SaveHeartbeats upserts the heartbeats in a single statement
+func (s *dbIntrospectionStore) SaveHeartbeats(ctx context.Context, heartbeats []WorkerHeartbeat) error {
+	if len(heartbeats) == 0 {
+		return nil
+	}
+
+	n := len(heartbeats)
+	workerIDs := make([]string, n)
+	instanceIDs := make([]string, n)
+	statuses := make([]string, n)
+	jobIDs := make([]string, n)
+	processed := make([]int64, n)
+	lastErrors := make([]string, n)
+	lastErrorAts := make([]*time.Time, n)
+	startedAts := make([]time.Time, n)
+	lastSeens := make([]time.Time, n)
+	for i, heartbeat := range heartbeats {
+		workerIDs[i] = heartbeat.WorkerID
+		instanceIDs[i] = heartbeat.InstanceID
+		statuses[i] = heartbeat.Status
+		jobIDs[i] = heartbeat.CurrentJobID
+		processed[i] = heartbeat.JobsProcessed
+		lastErrors[i] = heartbeat.LastError
+		lastErrorAts[i] = heartbeat.LastErrorAt
+		startedAts[i] = heartbeat.StartedAt
+		lastSeens[i] = heartbeat.LastSeen
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO worker_heartbeats (worker_id, instance_id, status, current_job_id, jobs_processed,
+		                               last_error, last_error_at, started_at, last_seen)
+		SELECT w.worker_id, w.instance_id, w.status, NULLIF(w.job_id, '')::uuid, w.processed,
+		       NULLIF(w.last_error, ''), w.last_error_at, w.started_at, w.last_seen
+		FROM UNNEST($1::text[], $2::text[], $3::text[], $4::text[], $5::bigint[],
+		            $6::text[], $7::timestamptz[], $8::timestamptz[], $9::timestamptz[])
+		     AS w(worker_id, instance_id, status, job_id, processed, last_error, last_error_at, started_at, last_seen)
+		ON CONFLICT (worker_id) DO UPDATE SET
+			instance_id = EXCLUDED.instance_id,
+			status = EXCLUDED.status,
+			current_job_id = EXCLUDED.current_job_id,
+			jobs_processed = EXCLUDED.jobs_processed,
+			last_error = EXCLUDED.last_error,
+			last_error_at = EXCLUDED.last_error_at,
+			started_at = EXCLUDED.started_at,
+			last_seen = EXCLUDED.last_seen`,
+		pq.Array(workerIDs), pq.Array(instanceIDs), pq.Array(statuses), pq.Array(jobIDs), pq.Array(processed),
+		pq.Array(lastErrors), pq.Array(lastErrorAts), pq.Array(startedAts), pq.Array(lastSeens),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save worker heartbeats: %w", err)
+	}
+	return nil
+}
+
+// DeleteHeartbeats removes the heartbeats of a stopped instance
+func (s *dbIntrospectionStore) DeleteHeartbeats(ctx context.Context, instanceID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM worker_heartbeats WHERE instance_id = $1`, instanceID); err != nil {
+		return fmt.Errorf("failed to delete worker heartbeats: %w", err)
+	}
+	return nil
+}
+
+// PruneHeartbeats deletes heartbeats last seen before the cutoff
+func (s *dbIntrospectionStore) PruneHeartbeats(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM worker_heartbeats WHERE last_seen < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune worker heartbeats: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListHeartbeats returns the heartbeats seen since the cutoff ordered by worker ID
+func (s *dbIntrospectionStore) ListHeartbeats(ctx context.Context, since time.Time) ([]WorkerHeartbeat, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT worker_id, instance_id, status, COALESCE(current_job_id::text, ''), jobs_processed,
+		       COALESCE(last_error, ''), last_error_at, started_at, last_seen
+		FROM worker_heartbeats
+		WHERE last_seen >= $1
+		ORDER BY worker_id`,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker heartbeats: %w", err)
+	}
+	defer rows.Close()
+
+	heartbeats := make([]WorkerHeartbeat, 0)
+	for rows.Next() {
+		var heartbeat WorkerHeartbeat
+		var lastErrorAt sql.NullTime
+		if err := rows.Scan(
+			&heartbeat.WorkerID,
+			&heartbeat.InstanceID,
+			&heartbeat.Status,
+			&heartbeat.CurrentJobID,
+			&heartbeat.JobsProcessed,
+			&heartbeat.LastError,
+			&lastErrorAt,
+			&heartbeat.StartedAt,
+			&heartbeat.LastSeen,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan worker heartbeat: %w", err)
+		}
+		if lastErrorAt.Valid {
+			heartbeat.LastErrorAt = &lastErrorAt.Time
+		}
+		heartbeats = append(heartbeats, heartbeat)
+	}
+
+	return heartbeats, rows.Err()
+}
+
+// ListInFlightJobs returns the processing jobs, longest running first
+func (s *dbIntrospectionStore) ListInFlightJobs(ctx context.Context, limit int) ([]InFlightJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, type, COALESCE(conversion_id::text, ''), COALESCE(user_id::text, ''), COALESCE(worker_id, ''),
+		       retry_count, restart_count, COALESCE(started_at, updated_at)
+		FROM worker_jobs
+		WHERE status = 'processing'
+		ORDER BY COALESCE(started_at, updated_at)
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in-flight jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]InFlightJob, 0)
+	for rows.Next() {
+		var job InFlightJob
+		if err := rows.Scan(
+			&job.ID,
+			&job.Type,
+			&job.ConversionID,
+			&job.UserID,
+			&job.WorkerID,
+			&job.RetryCount,
+			&job.RestartCount,
+			&job.StartedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan in-flight job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetJobLifecycle returns the job with its error, aging boost and the
+// conversion log entries it wrote, oldest first
+func (s *dbIntrospectionStore) GetJobLifecycle(ctx context.Context, jobID string) (*JobLifecycle, error) {
+	var job WorkerJob
+	var priority int
+	var status string
+	var startedAt, completedAt, boostedAt sql.NullTime
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, type, COALESCE(conversion_id::text, ''), COALESCE(user_id::text, ''), priority, status,
+		       COALESCE(worker_id, ''), retry_count, max_retries, restart_count, COALESCE(error_message, ''),
+		       created_at, updated_at, started_at, completed_at, boosted_at
+		FROM worker_jobs
+		WHERE id = $1`,
+		jobID,
+	).Scan(
+		&job.ID,
+		&job.Type,
+		&job.ConversionID,
+		&job.UserID,
+		&priority,
+		&status,
+		&job.WorkerID,
+		&job.RetryCount,
+		&job.MaxRetries,
+		&job.RestartCount,
+		&job.ErrorMessage,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&startedAt,
+		&completedAt,
+		&boostedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: job %s", common.ErrNotFound, jobID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	job.Priority = JobPriority(priority)
+	job.Status = JobStatus(status)
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	lifecycle := &JobLifecycle{Job: &job}
+	if boostedAt.Valid {
+		lifecycle.BoostedAt = &boostedAt.Time
+	}
+
+	logs, err := s.listJobLogs(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Logs = logs
+
+	return lifecycle, nil
+}
+
+// listJobLogs returns the conversion log entries written by a job
+func (s *dbIntrospectionStore) listJobLogs(ctx context.Context, jobID string) ([]ConversionLogEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, conversion_id, COALESCE(job_id, ''), COALESCE(worker_id, ''), stage, level, message,
+		       status_code, attempt, metadata, created_at
+		FROM conversion_logs
+		WHERE job_id = $1
+		ORDER BY id`,
+		jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := make([]ConversionLogEntry, 0)
+	for rows.Next() {
+		var entry ConversionLogEntry
+		var statusCode, attempt sql.NullInt64
+		var metadata []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.ConversionID,
+			&entry.JobID,
+			&entry.WorkerID,
+			&entry.Stage,
+			&entry.Level,
+			&entry.Message,
+			&statusCode,
+			&attempt,
+			&metadata,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job log: %w", err)
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			entry.StatusCode = &code
+		}
+		if attempt.Valid {
+			n := int(attempt.Int64)
+			entry.Attempt = &n
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &entry.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode job log metadata: %w", err)
+			}
+		}
+		logs = append(logs, entry)
+	}
+
+	return logs, rows.Err()
+}
@@ -0,0 +1,221 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// memoryIntrospectionStore serves fixed heartbeats, in-flight jobs and lifecycles
+type memoryIntrospectionStore struct {
+	heartbeats []WorkerHeartbeat
+	jobs       []InFlightJob
+	lifecycles map[string]*JobLifecycle
+	saved      []WorkerHeartbeat
+	deleted    string
+}
+
+func (m *memoryIntrospectionStore) SaveHeartbeats(ctx context.Context, heartbeats []WorkerHeartbeat) error {
+	m.saved = heartbeats
+	return nil
+}
+
+func (m *memoryIntrospectionStore) DeleteHeartbeats(ctx context.Context, instanceID string) error {
+	m.deleted = instanceID
+	return nil
+}
+
+func (m *memoryIntrospectionStore) PruneHeartbeats(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryIntrospectionStore) ListHeartbeats(ctx context.Context, since time.Time) ([]WorkerHeartbeat, error) {
+	return m.heartbeats, nil
+}
+
+func (m *memoryIntrospectionStore) ListInFlightJobs(ctx context.Context, limit int) ([]InFlightJob, error) {
+	return m.jobs, nil
+}
+
+func (m *memoryIntrospectionStore) GetJobLifecycle(ctx context.Context, jobID string) (*JobLifecycle, error) {
+	lifecycle, ok := m.lifecycles[jobID]
+	if !ok {
+		return nil, fmt.Errorf("%w: job %s", common.ErrNotFound, jobID)
+	}
+	return lifecycle, nil
+}
+
+func TestGetWorkerReport(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	now := time.Now()
+	store := &memoryIntrospectionStore{
+		heartbeats: []WorkerHeartbeat{
+			{WorkerID: "worker-a-0", InstanceID: "worker-a", Status: "processing", CurrentJobID: "job1", LastSeen: now.Add(-5 * time.Second)},
+			{WorkerID: "worker-b-0", InstanceID: "worker-b", Status: "processing", CurrentJobID: "job2", LastError: "provider timeout", LastSeen: now.Add(-10 * time.Minute)},
+		},
+		jobs: []InFlightJob{
+			{ID: "job2", WorkerID: "worker-b", StartedAt: now.Add(-12 * time.Minute)},
+			{ID: "job1", WorkerID: "worker-a", StartedAt: now.Add(-2 * time.Minute)},
+			{ID: "job3", WorkerID: "worker-a", StartedAt: now.Add(-time.Second)},
+		},
+	}
+	service.SetIntrospection(store, 0)
+
+	report, err := service.GetWorkerReport(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Queue == nil {
+		t.Error("Expected queue stats")
+	}
+	if report.ActiveWorkers != 1 {
+		t.Errorf("Expected 1 active worker, got %d", report.ActiveWorkers)
+	}
+	if report.Workers[0].Stale || !report.Workers[1].Stale {
+		t.Errorf("Expected only the silent worker to be stale, got %+v", report.Workers)
+	}
+	if report.Workers[1].LastError != "provider timeout" {
+		t.Errorf("Expected last error to be reported, got %q", report.Workers[1].LastError)
+	}
+
+	stuck, held, fresh := report.InFlightJobs[0], report.InFlightJobs[1], report.InFlightJobs[2]
+	if !stuck.Orphaned || stuck.RuntimeMs < (12*time.Minute).Milliseconds() {
+		t.Errorf("Expected job of a stale worker to be orphaned with its runtime, got %+v", stuck)
+	}
+	if held.Orphaned || held.WorkerID != "worker-a-0" {
+		t.Errorf("Expected job held by worker-a-0, got %+v", held)
+	}
+	if fresh.Orphaned {
+		t.Errorf("Expected job picked up since the last heartbeat not to be orphaned, got %+v", fresh)
+	}
+}
+
+func TestGetWorkerReport_LocalWorkers(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	started := time.Now().Add(-time.Minute)
+	worker := &Worker{
+		ID:         "worker-0",
+		Status:     "processing",
+		CurrentJob: &WorkerJob{ID: "job1", ConversionID: "conv1", StartedAt: &started},
+	}
+	service.workers[worker.ID] = worker
+	service.workers["worker-1"] = &Worker{ID: "worker-1", Status: "idle"}
+	service.recordWorkerError(worker, errors.New("dequeue failed"))
+
+	report, err := service.GetWorkerReport(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.ActiveWorkers != 2 || report.Workers[0].WorkerID != "worker-0" {
+		t.Fatalf("Expected both local workers sorted by ID, got %+v", report.Workers)
+	}
+	if report.Workers[0].CurrentJobID != "job1" || report.Workers[0].LastError != "dequeue failed" || report.Workers[0].LastErrorAt == nil {
+		t.Errorf("Expected current job and last error, got %+v", report.Workers[0])
+	}
+	if len(report.InFlightJobs) != 1 || report.InFlightJobs[0].WorkerID != "worker-0" || report.InFlightJobs[0].RuntimeMs < time.Minute.Milliseconds() {
+		t.Errorf("Expected job1 in flight for a minute, got %+v", report.InFlightJobs)
+	}
+}
+
+func TestGetJobLifecycle(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	created := time.Now().Add(-time.Hour)
+	started := created.Add(30 * time.Second)
+	store := &memoryIntrospectionStore{lifecycles: map[string]*JobLifecycle{
+		"job1": {Job: &WorkerJob{ID: "job1", Status: JobStatusFailed, CreatedAt: created, StartedAt: &started, UpdatedAt: started.Add(time.Minute)}},
+	}}
+	service.SetIntrospection(store, time.Second)
+
+	lifecycle, err := service.GetJobLifecycle(context.Background(), "job1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lifecycle.FinishedAt == nil || !lifecycle.FinishedAt.Equal(started.Add(time.Minute)) {
+		t.Errorf("Expected failed job to finish at its last update, got %v", lifecycle.FinishedAt)
+	}
+	if *lifecycle.WaitMs != 30000 || *lifecycle.RunMs != 60000 {
+		t.Errorf("Expected 30s wait and 60s run, got %d and %d", *lifecycle.WaitMs, *lifecycle.RunMs)
+	}
+
+	if _, err := service.GetJobLifecycle(context.Background(), "missing"); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestJobLifecycleSetDurations(t *testing.T) {
+	now := time.Now()
+	created := now.Add(-10 * time.Minute)
+	started := now.Add(-4 * time.Minute)
+	completed := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		job      WorkerJob
+		wait     *int64
+		run      *int64
+		finished bool
+	}{
+		{"pending", WorkerJob{Status: JobStatusPending, CreatedAt: created}, ms(10 * time.Minute), nil, false},
+		{"processing", WorkerJob{Status: JobStatusProcessing, CreatedAt: created, StartedAt: &started}, ms(6 * time.Minute), ms(4 * time.Minute), false},
+		{"completed", WorkerJob{Status: JobStatusCompleted, CreatedAt: created, StartedAt: &started, CompletedAt: &completed}, ms(6 * time.Minute), ms(3 * time.Minute), true},
+		{"cancelled before start", WorkerJob{Status: JobStatusCancelled, CreatedAt: created, UpdatedAt: completed}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := tt.job
+			lifecycle := &JobLifecycle{Job: &job}
+			lifecycle.setDurations(now)
+
+			if !equalMs(lifecycle.WaitMs, tt.wait) {
+				t.Errorf("Expected wait %v, got %v", deref(tt.wait), deref(lifecycle.WaitMs))
+			}
+			if !equalMs(lifecycle.RunMs, tt.run) {
+				t.Errorf("Expected run %v, got %v", deref(tt.run), deref(lifecycle.RunMs))
+			}
+			if (lifecycle.FinishedAt != nil) != tt.finished {
+				t.Errorf("Expected finished %v, got %v", tt.finished, lifecycle.FinishedAt)
+			}
+		})
+	}
+}
+
+func TestHeartbeatsRemovedOnStop(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	service.config.MaxWorkers = 1
+	store := &memoryIntrospectionStore{}
+	service.SetIntrospection(store, time.Hour)
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.deleted != service.workerID {
+		t.Errorf("Expected heartbeats of %s to be deleted, got %q", service.workerID, store.deleted)
+	}
+}
+
+func ms(d time.Duration) *int64 {
+	n := d.Milliseconds()
+	return &n
+}
+
+func equalMs(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func deref(n *int64) interface{} {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
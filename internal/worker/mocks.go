@@ -52,6 +52,10 @@ func (m *MockJobQueue) FailJob(ctx context.Context, jobID string, errorMessage s
 	return nil
 }
 
+func (m *MockJobQueue) RequeueJob(ctx context.Context, jobID string) error {
+	return nil
+}
+
 func (m *MockJobQueue) GetJob(ctx context.Context, jobID string) (*WorkerJob, error) {
 	return &WorkerJob{
 		ID:           jobID,
@@ -36,6 +36,7 @@ type WorkerJob struct {
 	WorkerID     string      `json:"workerId,omitempty"`
 	RetryCount   int         `json:"retryCount"`
 	MaxRetries   int         `json:"maxRetries"`
+	RestartCount int         `json:"restartCount"` // times the job was requeued by a worker shutdown
 	ErrorMessage string      `json:"errorMessage,omitempty"`
 	Payload      JobPayload  `json:"payload"`
 	CreatedAt    time.Time   `json:"createdAt"`
@@ -49,6 +50,7 @@ type JobPayload struct {
 	UserImageID  string                 `json:"userImageId"`
 	ClothImageID string                 `json:"clothImageId"`
 	Options      map[string]interface{} `json:"options,omitempty"`
+	TraceID      string                 `json:"traceId,omitempty"` // trace ID of the request that created the conversion
 }
 
 // WorkerConfig represents configuration for the worker service
@@ -62,6 +64,7 @@ type WorkerConfig struct {
 	HealthCheckPort   int           `json:"healthCheckPort"`
 	EnableMetrics     bool          `json:"enableMetrics"`
 	EnableHealthCheck bool          `json:"enableHealthCheck"`
+	DrainTimeout      time.Duration `json:"drainTimeout"` // how long Stop waits for in-flight jobs before requeueing them
 }
 
 // WorkerStats represents statistics about the worker service
@@ -75,6 +78,8 @@ type WorkerStats struct {
 	AverageJobTime int64      `json:"averageJobTime"` // in milliseconds
 	SuccessRate    float64    `json:"successRate"`
 	LastJobTime    *time.Time `json:"lastJobTime,omitempty"`
+
+	GeminiRetry *GeminiRetryStats `json:"geminiRetry,omitempty"`
 }
 
 // WorkerHealth represents the health status of a worker
@@ -96,6 +101,13 @@ type GeminiConfig struct {
 	Timeout               int     `json:"timeout"`                 // in seconds
 	PreprocessNoiseLevel  float64 `json:"preprocess_noise_level"`  // Noise level for image preprocessing (0.0-1.0)
 	PreprocessJpegQuality int     `json:"preprocess_jpeg_quality"` // JPEG quality for preprocessing (1-100)
+
+	// Retry/backoff and circuit breaker settings
+	RetryBaseDelayMs        int `json:"retryBaseDelayMs"`        // initial backoff delay
+	RetryMaxDelayMs         int `json:"retryMaxDelayMs"`         // backoff cap
+	AttemptTimeout          int `json:"attemptTimeout"`          // per-attempt timeout in seconds (defaults to Timeout)
+	BreakerFailureThreshold int `json:"breakerFailureThreshold"` // consecutive failures that open the breaker
+	BreakerOpenTimeout      int `json:"breakerOpenTimeout"`      // seconds the breaker stays open
 }
 
 // GeminiRequest represents a request to Gemini API
@@ -134,6 +146,13 @@ type GeminiGenerationConfig struct {
 	TopK            int     `json:"topK"`
 	TopP            float64 `json:"topP"`
 	MaxOutputTokens int     `json:"maxOutputTokens"`
+
+	ImageConfig *GeminiImageConfig `json:"imageConfig,omitempty"`
+}
+
+// GeminiImageConfig asks image models for a result shape
+type GeminiImageConfig struct {
+	AspectRatio string `json:"aspectRatio,omitempty"` // e.g. "3:4"
 }
 
 // GeminiResponse represents a response from Gemini API
@@ -181,4 +200,8 @@ const (
 	DefaultPollInterval    = 5 * time.Second
 	DefaultCleanupInterval = 1 * time.Hour
 	DefaultHealthCheckPort = 8081
+	DefaultDrainTimeout    = 30 * time.Second
+
+	// DefaultConversionTimeout bounds a single provider conversion
+	DefaultConversionTimeout = 5 * time.Minute
 )
@@ -0,0 +1,343 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	_ "golang.org/x/image/webp"
+)
+
+// Moderation providers
+const (
+	ModerationProviderLocal = "local"
+	ModerationProviderAPI   = "api"
+)
+
+// Image kinds checked by moderation
+const (
+	ModerationImageUser  = "user"
+	ModerationImageCloth = "cloth"
+)
+
+// Moderation review statuses
+const (
+	ModerationReviewNone    = "none"
+	ModerationReviewPending = "pending"
+)
+
+// ErrImageRejected is returned when an image fails moderation
+var ErrImageRejected = errors.New("image rejected by moderation")
+
+// ModerationConfig represents configuration for the moderation stage
+type ModerationConfig struct {
+	Enabled       bool    `json:"enabled"`
+	Provider      string  `json:"provider"` // local or api
+	APIURL        string  `json:"apiUrl"`
+	APIKey        string  `json:"-"`
+	Timeout       int     `json:"timeout"`       // in seconds
+	NSFWThreshold float64 `json:"nsfwThreshold"` // scores at or above this are rejected (0.0-1.0)
+	RequireFace   bool    `json:"requireFace"`   // user images must contain a face
+	MaxFaces      int     `json:"maxFaces"`      // 0 allows any number of faces
+	FailOpen      bool    `json:"failOpen"`      // allow images when the detector errors
+}
+
+// ModerationResult is the raw output of a detector
+type ModerationResult struct {
+	NSFWScore float64  `json:"nsfw_score"`
+	FaceCount int      `json:"face_count"` // -1 when the detector cannot count faces
+	Labels    []string `json:"labels,omitempty"`
+}
+
+// ModerationVerdict is the recorded outcome of moderating one image
+type ModerationVerdict struct {
+	ID           string    `json:"id"`
+	ConversionID string    `json:"conversionId"`
+	ImageID      string    `json:"imageId"`
+	ImageKind    string    `json:"imageKind"`
+	Provider     string    `json:"provider"`
+	Allowed      bool      `json:"allowed"`
+	NSFWScore    float64   `json:"nsfwScore"`
+	FaceCount    int       `json:"faceCount"`
+	Reasons      []string  `json:"reasons,omitempty"`
+	ReviewStatus string    `json:"reviewStatus"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ModerationDetector scores an image for disallowed content
+type ModerationDetector interface {
+	Detect(ctx context.Context, data []byte, imageKind string) (*ModerationResult, error)
+	Name() string
+}
+
+// ModerationStore records moderation verdicts for admin review
+type ModerationStore interface {
+	RecordVerdict(ctx context.Context, verdict *ModerationVerdict) error
+}
+
+// ModerationPipeline applies the moderation policy to images before conversion
+type ModerationPipeline struct {
+	config   ModerationConfig
+	detector ModerationDetector
+	store    ModerationStore
+}
+
+// NewModerationPipeline creates a new moderation pipeline
+func NewModerationPipeline(config ModerationConfig, detector ModerationDetector, store ModerationStore) *ModerationPipeline {
+	if config.NSFWThreshold <= 0 || config.NSFWThreshold > 1 {
+		config.NSFWThreshold = 0.8
+	}
+
+	return &ModerationPipeline{
+		config:   config,
+		detector: detector,
+		store:    store,
+	}
+}
+
+// NewModerationDetector creates the detector for the configured provider
+func NewModerationDetector(config ModerationConfig) (ModerationDetector, error) {
+	switch config.Provider {
+	case "", ModerationProviderLocal:
+		return NewLocalModerationDetector(), nil
+	case ModerationProviderAPI:
+		if config.APIURL == "" {
+			return nil, errors.New("moderation API URL is required for the api provider")
+		}
+		return NewAPIModerationDetector(config.APIURL, config.APIKey, time.Duration(config.Timeout)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown moderation provider: %s", config.Provider)
+	}
+}
+
+// Check moderates an image and records the verdict. It returns an error wrapping
+// ErrImageRejected when the image is not allowed.
+func (p *ModerationPipeline) Check(ctx context.Context, conversionID, imageID, imageKind string, data []byte) (*ModerationVerdict, error) {
+	verdict := &ModerationVerdict{
+		ConversionID: conversionID,
+		ImageID:      imageID,
+		ImageKind:    imageKind,
+		Provider:     p.detector.Name(),
+		FaceCount:    -1,
+		CreatedAt:    time.Now(),
+	}
+
+	result, err := p.detector.Detect(ctx, data, imageKind)
+	if err != nil {
+		log.Printf("Moderation detector failed for %s image %s: %v", imageKind, imageID, err)
+		verdict.Allowed = p.config.FailOpen
+		verdict.Reasons = []string{"detector_error"}
+	} else {
+		verdict.NSFWScore = result.NSFWScore
+		verdict.FaceCount = result.FaceCount
+		verdict.Reasons = p.evaluate(result, imageKind)
+		verdict.Allowed = len(verdict.Reasons) == 0
+	}
+
+	verdict.ReviewStatus = ModerationReviewNone
+	if !verdict.Allowed {
+		verdict.ReviewStatus = ModerationReviewPending
+	}
+
+	if p.store != nil {
+		if err := p.store.RecordVerdict(ctx, verdict); err != nil {
+			log.Printf("Failed to record moderation verdict: %v", err)
+		}
+	}
+
+	if !verdict.Allowed {
+		return verdict, fmt.Errorf("%w: %s image (%s)", ErrImageRejected, imageKind, strings.Join(verdict.Reasons, ", "))
+	}
+	return verdict, nil
+}
+
+// evaluate applies the policy to a detector result and returns the rejection reasons
+func (p *ModerationPipeline) evaluate(result *ModerationResult, imageKind string) []string {
+	var reasons []string
+
+	if result.NSFWScore >= p.config.NSFWThreshold {
+		reasons = append(reasons, "nsfw_content")
+	}
+
+	// Face rules only apply to the user photo and only when the detector can count faces
+	if imageKind == ModerationImageUser && result.FaceCount >= 0 {
+		if p.config.RequireFace && result.FaceCount == 0 {
+			reasons = append(reasons, "no_face_detected")
+		}
+		if p.config.MaxFaces > 0 && result.FaceCount > p.config.MaxFaces {
+			reasons = append(reasons, "too_many_faces")
+		}
+	}
+
+	return reasons
+}
+
+// localModerationDetector is a dependency-free detector based on the share of skin-tone pixels.
+// It cannot count faces.
+type localModerationDetector struct {
+	sampleSize int
+}
+
+// NewLocalModerationDetector creates a local heuristic detector
+func NewLocalModerationDetector() ModerationDetector {
+	return &localModerationDetector{sampleSize: 128}
+}
+
+// Name returns the detector name
+func (d *localModerationDetector) Name() string {
+	return ModerationProviderLocal
+}
+
+// Detect samples the image on a grid and scores it by skin-tone coverage
+func (d *localModerationDetector) Detect(ctx context.Context, data []byte, imageKind string) (*ModerationResult, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	stepX := bounds.Dx() / d.sampleSize
+	stepY := bounds.Dy() / d.sampleSize
+	if stepX < 1 {
+		stepX = 1
+	}
+	if stepY < 1 {
+		stepY = 1
+	}
+
+	var total, skin int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if isSkinTone(r>>8, g>>8, b>>8) {
+				skin++
+			}
+			total++
+		}
+	}
+
+	result := &ModerationResult{FaceCount: -1}
+	if total > 0 {
+		result.NSFWScore = float64(skin) / float64(total)
+	}
+	return result, nil
+}
+
+// isSkinTone classifies an RGB pixel using the YCbCr skin range
+func isSkinTone(r, g, b uint32) bool {
+	cb := 128 - 0.168736*float64(r) - 0.331264*float64(g) + 0.5*float64(b)
+	cr := 128 + 0.5*float64(r) - 0.418688*float64(g) - 0.081312*float64(b)
+	return cb >= 77 && cb <= 127 && cr >= 133 && cr <= 173
+}
+
+// apiModerationDetector calls an external moderation API
+type apiModerationDetector struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewAPIModerationDetector creates a detector backed by an external moderation API.
+// The API receives {"image": base64, "kind": "user|cloth"} and returns a ModerationResult.
+func NewAPIModerationDetector(url, apiKey string, timeout time.Duration) ModerationDetector {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &apiModerationDetector{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the detector name
+func (d *apiModerationDetector) Name() string {
+	return ModerationProviderAPI
+}
+
+// Detect sends the image to the moderation API
+func (d *apiModerationDetector) Detect(ctx context.Context, data []byte, imageKind string) (*ModerationResult, error) {
+	body, err := json.Marshal(map[string]string{
+		"image": base64.StdEncoding.EncodeToString(data),
+		"kind":  imageKind,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned status %d", resp.StatusCode)
+	}
+
+	result := &ModerationResult{FaceCount: -1}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	return result, nil
+}
+
+// dbModerationStore implements ModerationStore on top of PostgreSQL
+type dbModerationStore struct {
+	db *sql.DB
+}
+
+// NewDBModerationStore creates a new database-backed moderation store
+func NewDBModerationStore(db *sql.DB) ModerationStore {
+	return &dbModerationStore{db: db}
+}
+
+// RecordVerdict inserts a moderation verdict
+func (s *dbModerationStore) RecordVerdict(ctx context.Context, verdict *ModerationVerdict) error {
+	var conversionID, imageID interface{}
+	if verdict.ConversionID != "" {
+		conversionID = verdict.ConversionID
+	}
+	if verdict.ImageID != "" {
+		imageID = verdict.ImageID
+	}
+
+	reasons := verdict.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO image_moderation_verdicts
+			(conversion_id, image_id, image_kind, provider, allowed, nsfw_score, face_count, reasons, review_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`,
+		conversionID, imageID, verdict.ImageKind, verdict.Provider, verdict.Allowed,
+		verdict.NSFWScore, verdict.FaceCount, pq.Array(reasons), verdict.ReviewStatus,
+	).Scan(&verdict.ID, &verdict.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record moderation verdict: %w", err)
+	}
+	return nil
+}
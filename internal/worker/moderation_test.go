@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+type fakeDetector struct {
+	result *ModerationResult
+	err    error
+}
+
+func (d *fakeDetector) Detect(ctx context.Context, data []byte, imageKind string) (*ModerationResult, error) {
+	return d.result, d.err
+}
+
+func (d *fakeDetector) Name() string {
+	return "fake"
+}
+
+type recordingModerationStore struct {
+	verdicts []*ModerationVerdict
+}
+
+func (s *recordingModerationStore) RecordVerdict(ctx context.Context, verdict *ModerationVerdict) error {
+	s.verdicts = append(s.verdicts, verdict)
+	return nil
+}
+
+func TestModerationPipeline_Check(t *testing.T) {
+	ctx := context.Background()
+	config := ModerationConfig{Enabled: true, NSFWThreshold: 0.8, RequireFace: true, MaxFaces: 1}
+
+	tests := []struct {
+		name     string
+		detector *fakeDetector
+		kind     string
+		failOpen bool
+		allowed  bool
+	}{
+		{"clean user image", &fakeDetector{result: &ModerationResult{NSFWScore: 0.1, FaceCount: 1}}, ModerationImageUser, false, true},
+		{"nsfw image", &fakeDetector{result: &ModerationResult{NSFWScore: 0.95, FaceCount: 1}}, ModerationImageUser, false, false},
+		{"no face", &fakeDetector{result: &ModerationResult{NSFWScore: 0.1, FaceCount: 0}}, ModerationImageUser, false, false},
+		{"too many faces", &fakeDetector{result: &ModerationResult{NSFWScore: 0.1, FaceCount: 3}}, ModerationImageUser, false, false},
+		{"cloth without face", &fakeDetector{result: &ModerationResult{NSFWScore: 0.1, FaceCount: 0}}, ModerationImageCloth, false, true},
+		{"faces unknown", &fakeDetector{result: &ModerationResult{NSFWScore: 0.1, FaceCount: -1}}, ModerationImageUser, false, true},
+		{"detector error fail closed", &fakeDetector{err: errors.New("down")}, ModerationImageUser, false, false},
+		{"detector error fail open", &fakeDetector{err: errors.New("down")}, ModerationImageUser, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config
+			cfg.FailOpen = tt.failOpen
+			store := &recordingModerationStore{}
+			pipeline := NewModerationPipeline(cfg, tt.detector, store)
+
+			verdict, err := pipeline.Check(ctx, "conv1", "img1", tt.kind, []byte("data"))
+			if tt.allowed && err != nil {
+				t.Errorf("Expected image to be allowed, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrImageRejected) {
+				t.Errorf("Expected ErrImageRejected, got %v", err)
+			}
+			if verdict.Allowed != tt.allowed {
+				t.Errorf("Expected allowed=%v, got %v", tt.allowed, verdict.Allowed)
+			}
+			if len(store.verdicts) != 1 {
+				t.Fatalf("Expected 1 recorded verdict, got %d", len(store.verdicts))
+			}
+			expectedReview := ModerationReviewNone
+			if !tt.allowed {
+				expectedReview = ModerationReviewPending
+			}
+			if store.verdicts[0].ReviewStatus != expectedReview {
+				t.Errorf("Expected review status %s, got %s", expectedReview, store.verdicts[0].ReviewStatus)
+			}
+		})
+	}
+}
+
+func TestLocalModerationDetector(t *testing.T) {
+	encode := func(c color.Color) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("Failed to encode image: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	detector := NewLocalModerationDetector()
+
+	skin, err := detector.Detect(context.Background(), encode(color.RGBA{R: 224, G: 172, B: 140, A: 255}), ModerationImageUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if skin.NSFWScore < 0.99 {
+		t.Errorf("Expected skin-tone image to score ~1, got %.2f", skin.NSFWScore)
+	}
+	if skin.FaceCount != -1 {
+		t.Errorf("Expected unknown face count, got %d", skin.FaceCount)
+	}
+
+	blue, err := detector.Detect(context.Background(), encode(color.RGBA{B: 255, A: 255}), ModerationImageCloth)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if blue.NSFWScore != 0 {
+		t.Errorf("Expected blue image to score 0, got %.2f", blue.NSFWScore)
+	}
+
+	if _, err := detector.Detect(context.Background(), []byte("not an image"), ModerationImageUser); err == nil {
+		t.Error("Expected decode error")
+	}
+}
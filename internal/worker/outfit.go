@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"ai-styler/internal/conversion"
+
+	"golang.org/x/image/draw"
+)
+
+// OptionGarments lists where each garment of an outfit is worn, in the order
+// the garments appear left to right in the composite cloth image
+const OptionGarments = "garments"
+
+const (
+	// outfitPanelHeight is the height every garment is scaled to in the composite
+	outfitPanelHeight = 1024
+	// outfitPanelGap separates the garments so the provider sees them as distinct items
+	outfitPanelGap = 48
+	// outfitJPEGQuality keeps fabric detail while keeping the composite small
+	outfitJPEGQuality = 90
+	// garmentSlotUnknown describes garments sent without a slot
+	garmentSlotUnknown = "garment"
+)
+
+// downloadGarments fetches the images of the given outfit garments
+func (s *Service) downloadGarments(ctx context.Context, garments []conversion.Garment) ([][]byte, error) {
+	data := make([][]byte, len(garments))
+	for i, garment := range garments {
+		garmentImage, err := s.imageStore.GetImage(ctx, garment.ImageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get garment image %s: %w", garment.ImageID, err)
+		}
+		data[i], err = s.downloadImageWithRetry(ctx, garmentImage.OriginalURL, "garment image")
+		if err != nil {
+			return nil, fmt.Errorf("failed to download garment image %s: %w", garment.ImageID, err)
+		}
+	}
+	return data, nil
+}
+
+// composeOutfit places the garments side by side on a white canvas, scaled to
+// the same height, so providers taking a single cloth image see the whole outfit
+func composeOutfit(garments [][]byte) ([]byte, error) {
+	panels := make([]image.Image, len(garments))
+	width := outfitPanelGap * (len(garments) - 1)
+	for i, data := range garments {
+		src, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode garment %d: %w", i+1, err)
+		}
+		size := src.Bounds().Size()
+		if size.X <= 0 || size.Y <= 0 {
+			return nil, fmt.Errorf("garment %d is empty", i+1)
+		}
+		panels[i] = src
+		width += max(1, size.X*outfitPanelHeight/size.Y)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, outfitPanelHeight))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	x := 0
+	for _, panel := range panels {
+		size := panel.Bounds().Size()
+		panelWidth := max(1, size.X*outfitPanelHeight/size.Y)
+		draw.CatmullRom.Scale(canvas, image.Rect(x, 0, x+panelWidth, outfitPanelHeight), panel, panel.Bounds(), draw.Over, nil)
+		x += panelWidth + outfitPanelGap
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: outfitJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode outfit composite: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// outfitOptions returns a copy of the job options telling the provider which
+// garments the composite holds
+func outfitOptions(options map[string]interface{}, garments []conversion.Garment) map[string]interface{} {
+	withGarments := make(map[string]interface{}, len(options)+1)
+	for key, value := range options {
+		withGarments[key] = value
+	}
+	slots := make([]string, len(garments))
+	for i, garment := range garments {
+		slots[i] = garment.Slot
+		if slots[i] == "" {
+			slots[i] = garmentSlotUnknown
+		}
+	}
+	withGarments[OptionGarments] = slots
+	return withGarments
+}
+
+// garmentsOption returns the garment slots of an outfit job, nil for a single garment
+func garmentsOption(options map[string]interface{}) []string {
+	switch garments := options[OptionGarments].(type) {
+	case []string:
+		return garments
+	case []interface{}:
+		// JSON payloads decode arrays as []interface{}
+		slots := make([]string, 0, len(garments))
+		for _, garment := range garments {
+			if slot, ok := garment.(string); ok {
+				slots = append(slots, slot)
+			}
+		}
+		return slots
+	}
+	return nil
+}
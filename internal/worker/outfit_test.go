@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"ai-styler/internal/conversion"
+)
+
+func TestComposeOutfit(t *testing.T) {
+	composite, err := composeOutfit([][]byte{encodeTestPNG(t, 100, 200), encodeTestPNG(t, 300, 300)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(composite))
+	if err != nil {
+		t.Fatalf("Expected a decodable composite, got %v", err)
+	}
+	expectedWidth := outfitPanelHeight/2 + outfitPanelGap + outfitPanelHeight
+	if size := img.Bounds().Size(); size.X != expectedWidth || size.Y != outfitPanelHeight {
+		t.Errorf("Expected %dx%d composite, got %dx%d", expectedWidth, outfitPanelHeight, size.X, size.Y)
+	}
+
+	if _, err := composeOutfit([][]byte{[]byte("not an image")}); err == nil {
+		t.Error("Expected decode error, got nil")
+	}
+}
+
+func TestOutfitOptions(t *testing.T) {
+	options := map[string]interface{}{"style": "casual"}
+	withGarments := outfitOptions(options, []conversion.Garment{{ImageID: "a", Slot: "top"}, {ImageID: "b"}})
+
+	if _, ok := options[OptionGarments]; ok {
+		t.Error("Expected the job options left unchanged")
+	}
+	slots := garmentsOption(withGarments)
+	if len(slots) != 2 || slots[0] != "top" || slots[1] != garmentSlotUnknown {
+		t.Errorf("Expected [top %s], got %v", garmentSlotUnknown, slots)
+	}
+
+	// Options read back from a JSON payload
+	decoded := garmentsOption(map[string]interface{}{OptionGarments: []interface{}{"top", "shoes"}})
+	if len(decoded) != 2 || decoded[1] != "shoes" {
+		t.Errorf("Expected [top shoes], got %v", decoded)
+	}
+	if garmentsOption(options) != nil {
+		t.Error("Expected no garments for a single garment job")
+	}
+}
@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Job options saved with a conversion preset or requested with the
+// conversion, besides style and quality which go into the provider prompt
+const (
+	OptionOutputSize    = "outputSize"    // longest edge of the result in pixels
+	OptionWatermarkText = "watermarkText" // the user's own mark on the result
+	OptionOutputWidth   = "outputWidth"   // result width in pixels
+	OptionOutputHeight  = "outputHeight"  // result height in pixels
+	OptionAspectRatio   = "aspectRatio"   // "W:H" the provider generates, e.g. "3:4"
+)
+
+// userWatermarkOpacity is the opacity of a user's own watermark text, in percent
+const userWatermarkOpacity = 80
+
+// outputSizeOption returns the requested longest edge, or 0 when unset
+func outputSizeOption(options map[string]interface{}) int {
+	return intOption(options, OptionOutputSize)
+}
+
+// intOption returns a numeric option, or 0 when unset. JSON payloads decode
+// numbers as float64.
+func intOption(options map[string]interface{}, key string) int {
+	switch value := options[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
+
+// aspectRatioOption returns the requested "W:H" aspect ratio, or "" when unset
+func aspectRatioOption(options map[string]interface{}) string {
+	ratio, _ := options[OptionAspectRatio].(string)
+	return strings.TrimSpace(ratio)
+}
+
+// outputDimensions are the requested result width, height and aspect ratio
+type outputDimensions struct {
+	width, height int
+	aspectRatio   string
+}
+
+// outputDimensionsOption returns the requested result dimensions
+func outputDimensionsOption(options map[string]interface{}) outputDimensions {
+	return outputDimensions{
+		width:       intOption(options, OptionOutputWidth),
+		height:      intOption(options, OptionOutputHeight),
+		aspectRatio: aspectRatioOption(options),
+	}
+}
+
+// isZero reports whether no dimension was requested
+func (d outputDimensions) isZero() bool {
+	return d.width <= 0 && d.height <= 0 && d.aspectRatio == ""
+}
+
+// ratio returns the width:height the result is cropped to, 0 for none. An
+// explicit width and height win over the aspect ratio, which the provider
+// may only approximate.
+func (d outputDimensions) ratio() float64 {
+	if d.width > 0 && d.height > 0 {
+		return float64(d.width) / float64(d.height)
+	}
+	w, h, ok := strings.Cut(d.aspectRatio, ":")
+	if !ok {
+		return 0
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0
+	}
+	return float64(width) / float64(height)
+}
+
+// fitOutputDimensions center-crops data to the requested aspect ratio and
+// scales it down to fit the requested width and height, and reports the new
+// dimensions. Like resizeToOutputSize it never upscales, so a small result
+// keeps its size with the requested aspect ratio.
+func fitOutputDimensions(data []byte, dims outputDimensions) ([]byte, int, int, bool, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, false, fmt.Errorf("failed to decode result image: %w", err)
+	}
+	bounds := src.Bounds()
+	crop := bounds
+	if ratio := dims.ratio(); ratio > 0 {
+		size := bounds.Size()
+		width, height := size.X, int(math.Round(float64(size.X)/ratio))
+		if height > size.Y {
+			width, height = int(math.Round(float64(size.Y)*ratio)), size.Y
+		}
+		width, height = max(1, width), max(1, height)
+		origin := bounds.Min.Add(image.Pt((size.X-width)/2, (size.Y-height)/2))
+		crop = image.Rectangle{Min: origin, Max: origin.Add(image.Pt(width, height))}
+	}
+
+	// Scale to the tighter of the requested edges, never above 1
+	scale := 1.0
+	if dims.width > 0 {
+		scale = math.Min(scale, float64(dims.width)/float64(crop.Dx()))
+	}
+	if dims.height > 0 {
+		scale = math.Min(scale, float64(dims.height)/float64(crop.Dy()))
+	}
+	width := max(1, int(math.Round(float64(crop.Dx())*scale)))
+	height := max(1, int(math.Round(float64(crop.Dy())*scale)))
+	if crop == bounds && width == bounds.Dx() && height == bounds.Dy() {
+		return data, width, height, false, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	out, err := encodeResultImage(dst, format)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	return out, width, height, true, nil
+}
+
+// watermarkTextOption returns the user's watermark text, or "" when unset
+func watermarkTextOption(options map[string]interface{}) string {
+	text, _ := options[OptionWatermarkText].(string)
+	return strings.TrimSpace(text)
+}
+
+// resizeToOutputSize scales data down so its longest edge is at most maxEdge
+// and reports the new dimensions. Smaller images are returned unchanged, the
+// provider's result is never upscaled.
+func resizeToOutputSize(data []byte, maxEdge int) ([]byte, int, int, bool, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, false, fmt.Errorf("failed to decode result image: %w", err)
+	}
+	size := src.Bounds().Size()
+	if maxEdge <= 0 || max(size.X, size.Y) <= maxEdge {
+		return data, size.X, size.Y, false, nil
+	}
+
+	width, height := maxEdge, max(1, size.Y*maxEdge/size.X)
+	if size.Y > size.X {
+		width, height = max(1, size.X*maxEdge/size.Y), maxEdge
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	out, err := encodeResultImage(dst, format)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	return out, width, height, true, nil
+}
+
+// applyUserWatermark draws the user's own text in the bottom-left corner, away
+// from the plan watermark in its default bottom-right position
+func applyUserWatermark(data []byte, text string) ([]byte, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode result image: %w", err)
+	}
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	mark, err := renderWatermarkText(text, int(float64(dst.Bounds().Dy())*watermarkHeightRatio))
+	if err != nil {
+		return nil, err
+	}
+	mark = fitWatermark(mark, int(float64(dst.Bounds().Dx())*watermarkWidthRatio))
+
+	alpha := image.NewUniform(color.Alpha{A: uint8(userWatermarkOpacity * 255 / 100)})
+	target := watermarkRect(dst.Bounds(), mark.Bounds().Size(), WatermarkBottomLeft)
+	draw.DrawMask(dst, target, mark, mark.Bounds().Min, alpha, image.Point{}, draw.Over)
+
+	return encodeResultImage(dst, format)
+}
+
+// encodeResultImage encodes img as PNG when the result was a PNG, as JPEG otherwise
+func encodeResultImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: watermarkJPEGQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 40, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestResizeToOutputSize(t *testing.T) {
+	data := encodeTestPNG(t, 800, 400)
+
+	out, width, height, applied, err := resizeToOutputSize(data, 300)
+	if err != nil || !applied {
+		t.Fatalf("Expected image resized, got applied=%v err=%v", applied, err)
+	}
+	if width != 300 || height != 150 {
+		t.Errorf("Expected 300x150, got %dx%d", width, height)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(out))
+	if err != nil || format != "png" || config.Width != 300 || config.Height != 150 {
+		t.Errorf("Expected a 300x150 PNG, got %s %dx%d (%v)", format, config.Width, config.Height, err)
+	}
+
+	// Results are never upscaled
+	out, width, height, applied, _ = resizeToOutputSize(data, 1024)
+	if applied || width != 800 || height != 400 || !bytes.Equal(out, data) {
+		t.Errorf("Expected a smaller image unchanged, got applied=%v %dx%d", applied, width, height)
+	}
+}
+
+func TestApplyUserWatermark(t *testing.T) {
+	out, err := applyUserWatermark(encodeTestPNG(t, 400, 400), "@shop")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	brightened := false
+	for x := 12; x < 60 && !brightened; x++ {
+		if r, _, _, _ := result.At(x, 380).RGBA(); r>>8 > 40 {
+			brightened = true
+		}
+	}
+	if !brightened {
+		t.Error("Expected the text in the bottom-left corner")
+	}
+	if r, _, _, _ := result.At(380, 380).RGBA(); r>>8 != 40 {
+		t.Errorf("Expected bottom-right corner to be untouched, got red %d", r>>8)
+	}
+}
+
+func TestOutputOptions(t *testing.T) {
+	var payload JobPayload
+	if err := json.Unmarshal([]byte(`{"options":{"outputSize":1024,"watermarkText":" @shop "}}`), &payload); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if size := outputSizeOption(payload.Options); size != 1024 {
+		t.Errorf("Expected output size 1024, got %d", size)
+	}
+	if text := watermarkTextOption(payload.Options); text != "@shop" {
+		t.Errorf("Expected watermark text @shop, got %q", text)
+	}
+	if outputSizeOption(nil) != 0 || watermarkTextOption(nil) != "" {
+		t.Error("Expected no options without a preset")
+	}
+}
+
+func TestFitOutputDimensions(t *testing.T) {
+	data := encodeTestPNG(t, 800, 400)
+
+	tests := []struct {
+		name          string
+		dims          outputDimensions
+		width, height int
+	}{
+		{"aspect ratio crops the center", outputDimensions{aspectRatio: "1:1"}, 400, 400},
+		{"width and height crop and scale", outputDimensions{width: 300, height: 400, aspectRatio: "3:4"}, 300, 400},
+		{"width only scales", outputDimensions{width: 400}, 400, 200},
+		{"ratio with a larger edge is not upscaled", outputDimensions{height: 2000, aspectRatio: "4:5"}, 320, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, width, height, applied, err := fitOutputDimensions(data, tt.dims)
+			if err != nil || !applied {
+				t.Fatalf("Expected image fitted, got applied=%v err=%v", applied, err)
+			}
+			config, format, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil || format != "png" || config.Width != tt.width || config.Height != tt.height || width != tt.width || height != tt.height {
+				t.Errorf("Expected a %dx%d PNG, got %s %dx%d reported %dx%d (%v)", tt.width, tt.height, format, config.Width, config.Height, width, height, err)
+			}
+		})
+	}
+
+	out, _, _, applied, _ := fitOutputDimensions(data, outputDimensions{width: 1024, aspectRatio: "2:1"})
+	if applied || !bytes.Equal(out, data) {
+		t.Error("Expected an image already in shape unchanged")
+	}
+}
+
+func TestOutputDimensionsOption(t *testing.T) {
+	var payload JobPayload
+	if err := json.Unmarshal([]byte(`{"options":{"outputWidth":768,"outputHeight":1024,"aspectRatio":"3:4"}}`), &payload); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	dims := outputDimensionsOption(payload.Options)
+	if dims != (outputDimensions{width: 768, height: 1024, aspectRatio: "3:4"}) {
+		t.Errorf("Expected 768x1024 at 3:4, got %+v", dims)
+	}
+	if config := imageConfigOption(payload.Options); config == nil || config.AspectRatio != "3:4" {
+		t.Errorf("Expected the aspect ratio sent to the provider, got %+v", config)
+	}
+	if !outputDimensionsOption(nil).isZero() || imageConfigOption(nil) != nil {
+		t.Error("Expected no dimensions without options")
+	}
+}
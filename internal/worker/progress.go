@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"log"
+
+	"ai-styler/internal/conversion"
+)
+
+// Conversion progress reported at the worker's stages. Completing the
+// conversion sets it to ProgressCompleted.
+const (
+	ProgressDownloaded      = 20 // input images downloaded
+	ProgressPreprocessed    = 35 // inputs validated, moderated and fitted to the budget
+	ProgressProviderStarted = 40 // provider call started
+	ProgressDecoding        = 80 // provider returned, decoding and post-processing the result
+	ProgressStored          = 95 // result image stored
+	ProgressCompleted       = 100
+)
+
+// SetProgressStore records conversion progress as jobs pass their stages
+func (s *Service) SetProgressStore(store conversion.ProgressStore) {
+	s.progress = store
+}
+
+// reportProgress records how far the job's conversion got. Progress is best
+// effort: failing to record it never fails the conversion.
+func (s *Service) reportProgress(ctx context.Context, job *WorkerJob, progress int) {
+	if s.progress == nil {
+		return
+	}
+	if err := s.progress.UpdateConversionProgress(context.WithoutCancel(ctx), job.ConversionID, progress); err != nil {
+		log.Printf("Failed to record progress %d%% of conversion %s: %v", progress, job.ConversionID, err)
+	}
+}
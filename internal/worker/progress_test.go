@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"ai-styler/internal/conversion"
+)
+
+type memoryProgressStore struct {
+	reported []int
+	err      error
+}
+
+func (m *memoryProgressStore) UpdateConversionProgress(ctx context.Context, conversionID string, progress int) error {
+	m.reported = append(m.reported, progress)
+	return m.err
+}
+
+// recordingConversionStore keeps the conversion updates the worker saves
+type recordingConversionStore struct {
+	MockConversionStore
+	updates []conversion.UpdateConversionRequest
+}
+
+func (r *recordingConversionStore) UpdateConversion(ctx context.Context, conversionID string, req conversion.UpdateConversionRequest) error {
+	r.updates = append(r.updates, req)
+	return nil
+}
+
+func TestProcessJob_ReportsProgress(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	service.fileStorage = &pngFileStorage{}
+	conversions := &recordingConversionStore{}
+	service.conversionStore = conversions
+	progress := &memoryProgressStore{}
+	service.SetProgressStore(progress)
+
+	job := &WorkerJob{ID: "job1", Type: "image_conversion", ConversionID: "conv1", UserID: "user1"}
+	if err := service.processJob(context.Background(), job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []int{ProgressDownloaded, ProgressPreprocessed, ProgressProviderStarted, ProgressDecoding, ProgressStored}
+	if !reflect.DeepEqual(progress.reported, expected) {
+		t.Errorf("Expected progress %v, got %v", expected, progress.reported)
+	}
+	last := conversions.updates[len(conversions.updates)-1]
+	if last.Progress == nil || *last.Progress != ProgressCompleted {
+		t.Errorf("Expected completion to set progress %d, got %v", ProgressCompleted, last.Progress)
+	}
+}
+
+func TestReportProgress_BestEffort(t *testing.T) {
+	service, _ := WireWorkerServiceWithMocks()
+	job := &WorkerJob{ID: "job1", ConversionID: "conv1"}
+
+	// Without a store reporting is a no-op
+	service.reportProgress(context.Background(), job, ProgressDownloaded)
+
+	progress := &memoryProgressStore{err: errors.New("database unavailable")}
+	service.SetProgressStore(progress)
+	service.reportProgress(context.Background(), job, ProgressDownloaded)
+	if len(progress.reported) != 1 {
+		t.Errorf("Expected progress to be reported, got %v", progress.reported)
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log"
+
+	"ai-styler/internal/prompt"
+)
+
+// OptionPromptTemplate carries the body of the prompt template version a job
+// was assigned to the provider client
+const OptionPromptTemplate = "promptTemplate"
+
+// PromptTemplates picks, and records for the stats, the prompt template
+// version a conversion is made with
+type PromptTemplates interface {
+	Select(ctx context.Context, key, conversionID, userID string) (*prompt.Version, error)
+}
+
+// SetPromptTemplates makes conversions use the prompt template versions
+// managed by admins instead of prompt.DefaultConversionTemplate
+func (s *Service) SetPromptTemplates(templates PromptTemplates) {
+	s.prompts = templates
+}
+
+// applyPromptTemplate returns options with the body of the conversion
+// template version selected for the job. Without templates, or when no
+// version can be selected, the provider client uses the default prompt.
+func (s *Service) applyPromptTemplate(ctx context.Context, job *WorkerJob, options map[string]interface{}) map[string]interface{} {
+	withPrompt := make(map[string]interface{}, len(options)+1)
+	for key, value := range options {
+		withPrompt[key] = value
+	}
+	delete(withPrompt, OptionPromptTemplate)
+	if s.prompts == nil {
+		return withPrompt
+	}
+
+	version, err := s.prompts.Select(ctx, prompt.KeyConversion, job.ConversionID, job.UserID)
+	if err != nil {
+		log.Printf("Failed to select prompt template for job %s, using the default prompt: %v", job.ID, err)
+		return withPrompt
+	}
+	withPrompt[OptionPromptTemplate] = version.Body
+	s.logConversion(ctx, job, ConversionStageProvider, ConversionLogInfo, "Selected prompt template", map[string]interface{}{
+		"template": version.TemplateKey,
+		"version":  version.Version,
+	})
+	return withPrompt
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-styler/internal/prompt"
+)
+
+type fakePromptTemplates struct {
+	version *prompt.Version
+	err     error
+	calls   int
+}
+
+func (f *fakePromptTemplates) Select(ctx context.Context, key, conversionID, userID string) (*prompt.Version, error) {
+	f.calls++
+	return f.version, f.err
+}
+
+func TestApplyPromptTemplate(t *testing.T) {
+	ctx := context.Background()
+	job := &WorkerJob{ID: "job-1", ConversionID: "conv-1", UserID: "user-1"}
+	options := map[string]interface{}{"style": "casual", OptionPromptTemplate: "injected"}
+
+	service := &Service{}
+	if withPrompt := service.applyPromptTemplate(ctx, job, options); withPrompt[OptionPromptTemplate] != nil {
+		t.Errorf("Expected a prompt from the options to be dropped without templates, got %v", withPrompt[OptionPromptTemplate])
+	}
+
+	templates := &fakePromptTemplates{version: &prompt.Version{TemplateKey: prompt.KeyConversion, Version: 2, Body: "Try on {{style}}"}}
+	service.SetPromptTemplates(templates)
+	withPrompt := service.applyPromptTemplate(ctx, job, options)
+	if withPrompt[OptionPromptTemplate] != "Try on {{style}}" || withPrompt["style"] != "casual" {
+		t.Errorf("Expected the selected version's body with the job options, got %v", withPrompt)
+	}
+	if options[OptionPromptTemplate] != "injected" {
+		t.Error("Expected the job options to be left unchanged")
+	}
+
+	templates.err = errors.New("database unavailable")
+	if withPrompt := service.applyPromptTemplate(ctx, job, options); withPrompt[OptionPromptTemplate] != nil {
+		t.Errorf("Expected the default prompt when selection fails, got %v", withPrompt[OptionPromptTemplate])
+	}
+}
+
+func TestBuildConversionPrompt(t *testing.T) {
+	client := &GeminiClient{}
+
+	options := map[string]interface{}{"style": "casual", "quality": "high"}
+	defaultPrompt := client.buildConversionPrompt(options)
+	if !strings.HasSuffix(defaultPrompt, "Only the base64 string. Apply the style: casual while maintaining the natural appearance. Ensure high quality with detailed textures and realistic lighting.") {
+		t.Errorf("Expected the default prompt with the style and quality instructions, got %q", defaultPrompt)
+	}
+	if plain := client.buildConversionPrompt(nil); strings.Contains(plain, "{{") || !strings.HasSuffix(plain, "Only the base64 string.") {
+		t.Errorf("Expected unset instructions to render as nothing, got %q", plain)
+	}
+
+	options[OptionPromptTemplate] = "Dress the model in {{style}} style at {{quality}} quality."
+	if got := client.buildConversionPrompt(options); got != "Dress the model in casual style at high quality." {
+		t.Errorf("Expected the selected template rendered, got %q", got)
+	}
+
+	outfit := map[string]interface{}{OptionGarments: []string{"top", "shoes"}, OptionPromptTemplate: "{{garmentCount}} garments: {{garments}}"}
+	if got := client.buildConversionPrompt(outfit); got != "2 garments: top, shoes" {
+		t.Errorf("Expected the outfit variables rendered, got %q", got)
+	}
+}
@@ -135,6 +135,18 @@ func RegisterWorkerRoutesWithMiddleware(r *gin.RouterGroup, handler *Handler, mi
 	r.GET("/metrics", handler.MetricsHandler)
 }
 
+// SetupAdminRoutes mounts the worker introspection routes on an admin-only router group
+func SetupAdminRoutes(r *gin.RouterGroup, handler *Handler) {
+	worker := r.Group("/worker")
+	{
+		worker.GET("/status", handler.GetWorkerReport)   // GET /admin/worker/status
+		worker.GET("/jobs/:id", handler.GetJobLifecycle) // GET /admin/worker/jobs/:id
+
+		worker.GET("/scheduled-jobs", handler.ListScheduledJobs)                // GET /admin/worker/scheduled-jobs
+		worker.GET("/scheduled-jobs/:name/runs", handler.ListScheduledJobRuns) // GET /admin/worker/scheduled-jobs/:name/runs
+	}
+}
+
 // GetWorkerRouteInfo returns information about available worker routes
 func GetWorkerRouteInfo() map[string]interface{} {
 	return map[string]interface{}{
@@ -0,0 +1,457 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-styler/internal/common"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultScheduledJobTimeout bounds a scheduled run when the job sets no timeout
+const DefaultScheduledJobTimeout = 30 * time.Minute
+
+// A run's lock outlives its timeout by scheduleLockSlack so a replica whose
+// clock lags behind cannot claim the same slot once the run finished
+const scheduleLockSlack = time.Minute
+
+// jobRunHistory is how many runs of each job are kept
+const jobRunHistory = 100
+
+// Scheduled job run statuses
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// scheduledJobName restricts job names to what is safe in lock keys and URLs
+var scheduledJobName = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// ScheduledJob is a job run on a cron schedule, e.g. a cleanup or a digest
+type ScheduledJob struct {
+	Name     string        // unique, lowercase letters, digits and underscores
+	Schedule string        // five-field cron expression, see common.ParseSchedule
+	Timeout  time.Duration // bounds a run, DefaultScheduledJobTimeout when 0
+	Run      func(ctx context.Context) error
+}
+
+// JobRun is one run of a scheduled job. A run stays running when its
+// instance crashed before finishing it.
+type JobRun struct {
+	ID         int64      `json:"id"`
+	Job        string     `json:"job"`
+	InstanceID string     `json:"instanceId"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	DurationMs *int64     `json:"durationMs,omitempty"`
+}
+
+// ScheduledJobStatus is the admin view of a scheduled job
+type ScheduledJobStatus struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	TimeoutMs int64      `json:"timeoutMs"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"` // nil when the schedule never fires
+	LastRun   *JobRun    `json:"lastRun,omitempty"`
+}
+
+// JobLocker elects the replica that runs a scheduled job at a given time
+type JobLocker interface {
+	// Acquire claims key for ttl and reports whether this replica got it
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// JobRunStore persists the run history of scheduled jobs
+type JobRunStore interface {
+	// StartJobRun records a running run and returns its ID
+	StartJobRun(ctx context.Context, job, instanceID string, startedAt time.Time) (int64, error)
+	FinishJobRun(ctx context.Context, id int64, status, errMsg string, finishedAt time.Time) error
+	// LastJobRuns returns the latest run of each job by job name
+	LastJobRuns(ctx context.Context) (map[string]JobRun, error)
+	// ListJobRuns returns the latest runs of a job, newest first
+	ListJobRuns(ctx context.Context, job string, limit int) ([]JobRun, error)
+	// PruneJobRuns deletes all but the latest keep runs of a job
+	PruneJobRuns(ctx context.Context, job string, keep int) error
+}
+
+// scheduledEntry is a registered job and its parsed schedule
+type scheduledEntry struct {
+	job      ScheduledJob
+	schedule *common.Schedule
+}
+
+// Scheduler runs registered jobs on their cron schedules. Every replica runs
+// a scheduler; the locker lets one of them run each scheduled slot.
+type Scheduler struct {
+	instanceID string
+	locker     JobLocker
+	runs       JobRunStore
+
+	mu      sync.Mutex
+	entries map[string]*scheduledEntry
+	started atomic.Bool
+
+	now func() time.Time
+}
+
+// NewScheduler creates a scheduler. Without a locker every replica runs every
+// job; without a run store the history is not kept.
+func NewScheduler(locker JobLocker, runs JobRunStore) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		locker:     locker,
+		runs:       runs,
+		entries:    make(map[string]*scheduledEntry),
+		now:        time.Now,
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *Scheduler) Register(job ScheduledJob) error {
+	if !scheduledJobName.MatchString(job.Name) {
+		return fmt.Errorf("%w: invalid scheduled job name %q", common.ErrValidation, job.Name)
+	}
+	if job.Run == nil {
+		return fmt.Errorf("%w: scheduled job %s has no run function", common.ErrValidation, job.Name)
+	}
+	schedule, err := common.ParseSchedule(job.Schedule)
+	if err != nil {
+		return err
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = DefaultScheduledJobTimeout
+	}
+	if s.started.Load() {
+		return fmt.Errorf("scheduled job %s registered after the scheduler started", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("%w: scheduled job %s is already registered", common.ErrConflict, job.Name)
+	}
+	s.entries[job.Name] = &scheduledEntry{job: job, schedule: schedule}
+	return nil
+}
+
+// Start runs every registered job on its schedule until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	s.started.Store(true)
+
+	s.mu.Lock()
+	entries := make([]*scheduledEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func(entry *scheduledEntry) {
+			defer wg.Done()
+			s.loop(ctx, entry)
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// loop waits for each slot of a job's schedule and runs it. A run that
+// outlasts the next slot makes this replica skip that slot.
+func (s *Scheduler) loop(ctx context.Context, entry *scheduledEntry) {
+	for {
+		slot := entry.schedule.Next(s.now())
+		if slot.IsZero() {
+			log.Printf("Scheduled job %s: schedule %q never fires", entry.job.Name, entry.job.Schedule)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(slot))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runSlot(ctx, entry, slot)
+	}
+}
+
+// runSlot runs the job for slot unless another replica claimed it
+func (s *Scheduler) runSlot(ctx context.Context, entry *scheduledEntry, slot time.Time) {
+	job := entry.job
+	if s.locker != nil {
+		key := fmt.Sprintf("scheduler:%s:%d", job.Name, slot.Unix())
+		acquired, err := s.locker.Acquire(ctx, key, job.Timeout+scheduleLockSlack)
+		if err != nil {
+			log.Printf("Scheduled job %s: skipping run, failed to acquire lock: %v", job.Name, err)
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
+
+	runID := int64(0)
+	startedAt := s.now()
+	if s.runs != nil {
+		id, err := s.runs.StartJobRun(ctx, job.Name, s.instanceID, startedAt)
+		if err != nil {
+			log.Printf("Scheduled job %s: failed to record run: %v", job.Name, err)
+		}
+		runID = id
+	}
+
+	err := s.execute(ctx, job)
+	status := JobRunSucceeded
+	errMsg := ""
+	if err != nil {
+		status = JobRunFailed
+		errMsg = err.Error()
+		log.Printf("Scheduled job %s failed: %v", job.Name, err)
+	}
+
+	if s.runs == nil || runID == 0 {
+		return
+	}
+	// The run is recorded even when it was cut short by shutdown
+	recordCtx := context.WithoutCancel(ctx)
+	if err := s.runs.FinishJobRun(recordCtx, runID, status, errMsg, s.now()); err != nil {
+		log.Printf("Scheduled job %s: failed to record run outcome: %v", job.Name, err)
+	}
+	if err := s.runs.PruneJobRuns(recordCtx, job.Name, jobRunHistory); err != nil {
+		log.Printf("Scheduled job %s: failed to prune run history: %v", job.Name, err)
+	}
+}
+
+// execute runs the job within its timeout, reporting a panic as an error
+func (s *Scheduler) execute(ctx context.Context, job ScheduledJob) (err error) {
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(runCtx)
+}
+
+// Jobs returns the registered jobs ordered by name with their next and last run
+func (s *Scheduler) Jobs(ctx context.Context) ([]ScheduledJobStatus, error) {
+	var last map[string]JobRun
+	if s.runs != nil {
+		var err error
+		if last, err = s.runs.LastJobRuns(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	jobs := make([]ScheduledJobStatus, 0, len(s.entries))
+	for name, entry := range s.entries {
+		status := ScheduledJobStatus{
+			Name:      name,
+			Schedule:  entry.job.Schedule,
+			TimeoutMs: entry.job.Timeout.Milliseconds(),
+		}
+		if next := entry.schedule.Next(now); !next.IsZero() {
+			status.NextRunAt = &next
+		}
+		if run, ok := last[name]; ok {
+			status.LastRun = &run
+		}
+		jobs = append(jobs, status)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+// Runs returns the latest runs of a registered job, newest first
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]JobRun, error) {
+	s.mu.Lock()
+	_, exists := s.entries[name]
+	s.mu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: scheduled job %s", common.ErrNotFound, name)
+	}
+	if s.runs == nil {
+		return []JobRun{}, nil
+	}
+	return s.runs.ListJobRuns(ctx, name, limit)
+}
+
+// SetScheduler lists the scheduler's jobs in the admin worker endpoints
+func (s *Service) SetScheduler(scheduler *Scheduler) {
+	s.scheduler = scheduler
+}
+
+// ListScheduledJobs returns the scheduled jobs with their last outcomes
+func (s *Service) ListScheduledJobs(ctx context.Context) ([]ScheduledJobStatus, error) {
+	if s.scheduler == nil {
+		return []ScheduledJobStatus{}, nil
+	}
+	return s.scheduler.Jobs(ctx)
+}
+
+// ListScheduledJobRuns returns the run history of a scheduled job
+func (s *Service) ListScheduledJobRuns(ctx context.Context, name string, limit int) ([]JobRun, error) {
+	if s.scheduler == nil {
+		return nil, fmt.Errorf("%w: scheduled job %s", common.ErrNotFound, name)
+	}
+	return s.scheduler.Runs(ctx, name, limit)
+}
+
+// redisJobLocker claims scheduled slots with SET NX. Slot keys are left to
+// expire so a replica that fires late cannot run the slot again.
+type redisJobLocker struct {
+	client *redis.Client
+}
+
+// NewRedisJobLocker creates a job locker shared by every replica using client
+func NewRedisJobLocker(client *redis.Client) JobLocker {
+	return &redisJobLocker{client: client}
+}
+
+// Acquire sets key unless another replica did
+func (l *redisJobLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
+}
+
+// dbJobRunStore implements JobRunStore on top of the scheduled_job_runs table
+type dbJobRunStore struct {
+	db *sql.DB
+}
+
+// NewDBJobRunStore creates a new database-backed job run store
+func NewDBJobRunStore(db *sql.DB) JobRunStore {
+	return &dbJobRunStore{db: db}
+}
+
+// StartJobRun inserts a running run
+func (s *dbJobRunStore) StartJobRun(ctx context.Context, job, instanceID string, startedAt time.Time) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO scheduled_job_runs (job_name, instance_id, status, started_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		job, instanceID, JobRunRunning, startedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start job run: %w", err)
+	}
+	return id, nil
+}
+
+// FinishJobRun records the outcome of a run
+func (s *dbJobRunStore) FinishJobRun(ctx context.Context, id int64, status, errMsg string, finishedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE scheduled_job_runs
+		SET status = $2, error = NULLIF($3, ''), finished_at = $4
+		WHERE id = $1`,
+		id, status, errMsg, finishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+	return nil
+}
+
+// LastJobRuns returns the latest run of each job
+func (s *dbJobRunStore) LastJobRuns(ctx context.Context) (map[string]JobRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (job_name) `+jobRunColumns+`
+		FROM scheduled_job_runs
+		ORDER BY job_name, started_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list last job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make(map[string]JobRun)
+	for rows.Next() {
+		run, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs[run.Job] = *run
+	}
+	return runs, rows.Err()
+}
+
+// ListJobRuns returns the latest runs of a job
+func (s *dbJobRunStore) ListJobRuns(ctx context.Context, job string, limit int) ([]JobRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+jobRunColumns+`
+		FROM scheduled_job_runs
+		WHERE job_name = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2`,
+		job, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]JobRun, 0)
+	for rows.Next() {
+		run, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// PruneJobRuns deletes the runs of a job past the latest keep
+func (s *dbJobRunStore) PruneJobRuns(ctx context.Context, job string, keep int) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM scheduled_job_runs
+		WHERE job_name = $1 AND id NOT IN (
+			SELECT id FROM scheduled_job_runs
+			WHERE job_name = $1
+			ORDER BY started_at DESC, id DESC
+			LIMIT $2
+		)`,
+		job, keep,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune job runs: %w", err)
+	}
+	return nil
+}
+
+const jobRunColumns = `id, job_name, instance_id, status, COALESCE(error, ''), started_at, finished_at`
+
+// scanJobRun scans a row selected with jobRunColumns
+func scanJobRun(rows *sql.Rows) (*JobRun, error) {
+	var run JobRun
+	var finishedAt sql.NullTime
+	if err := rows.Scan(&run.ID, &run.Job, &run.InstanceID, &run.Status, &run.Error, &run.StartedAt, &finishedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan job run: %w", err)
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+		duration := finishedAt.Time.Sub(run.StartedAt).Milliseconds()
+		run.DurationMs = &duration
+	}
+	return &run, nil
+}
@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"ai-styler/internal/common"
+)
+
+// memoryJobLocker grants each key once, like slot keys in Redis
+type memoryJobLocker struct {
+	keys map[string]bool
+}
+
+func (m *memoryJobLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+// memoryJobRunStore keeps job runs in insertion order
+type memoryJobRunStore struct {
+	runs []JobRun
+}
+
+func (m *memoryJobRunStore) StartJobRun(ctx context.Context, job, instanceID string, startedAt time.Time) (int64, error) {
+	m.runs = append(m.runs, JobRun{ID: int64(len(m.runs) + 1), Job: job, InstanceID: instanceID, Status: JobRunRunning, StartedAt: startedAt})
+	return int64(len(m.runs)), nil
+}
+
+func (m *memoryJobRunStore) FinishJobRun(ctx context.Context, id int64, status, errMsg string, finishedAt time.Time) error {
+	run := &m.runs[id-1]
+	run.Status, run.Error, run.FinishedAt = status, errMsg, &finishedAt
+	return nil
+}
+
+func (m *memoryJobRunStore) LastJobRuns(ctx context.Context) (map[string]JobRun, error) {
+	last := make(map[string]JobRun)
+	for _, run := range m.runs {
+		last[run.Job] = run
+	}
+	return last, nil
+}
+
+func (m *memoryJobRunStore) ListJobRuns(ctx context.Context, job string, limit int) ([]JobRun, error) {
+	runs := make([]JobRun, 0)
+	for _, run := range m.runs {
+		if run.Job == job {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID > runs[j].ID })
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (m *memoryJobRunStore) PruneJobRuns(ctx context.Context, job string, keep int) error {
+	return nil
+}
+
+func TestScheduler_Register(t *testing.T) {
+	scheduler := NewScheduler(nil, nil)
+	run := func(ctx context.Context) error { return nil }
+
+	if err := scheduler.Register(ScheduledJob{Name: "cleanup", Schedule: "0 2 * * *", Run: run}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		job  ScheduledJob
+		want error
+	}{
+		{"duplicate", ScheduledJob{Name: "cleanup", Schedule: "0 3 * * *", Run: run}, common.ErrConflict},
+		{"invalid name", ScheduledJob{Name: "Clean Up", Schedule: "0 2 * * *", Run: run}, common.ErrValidation},
+		{"invalid schedule", ScheduledJob{Name: "digest", Schedule: "0 25 * * *", Run: run}, common.ErrValidation},
+		{"no run function", ScheduledJob{Name: "digest", Schedule: "0 8 * * *"}, common.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scheduler.Register(tt.job); !errors.Is(err, tt.want) {
+				t.Errorf("Register() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler_RunSlotRunsOncePerSlot(t *testing.T) {
+	locker := &memoryJobLocker{keys: map[string]bool{}}
+	store := &memoryJobRunStore{}
+	replicaA := NewScheduler(locker, store)
+	replicaB := NewScheduler(locker, store)
+	replicaB.instanceID = "replica-b"
+
+	calls := 0
+	job := ScheduledJob{Name: "quota_reset", Schedule: "0 0 * * *", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}}
+	for _, scheduler := range []*Scheduler{replicaA, replicaB} {
+		if err := scheduler.Register(job); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	slot := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	replicaA.runSlot(context.Background(), replicaA.entries["quota_reset"], slot)
+	replicaB.runSlot(context.Background(), replicaB.entries["quota_reset"], slot)
+	if calls != 1 {
+		t.Fatalf("job ran %d times for one slot, want 1", calls)
+	}
+
+	replicaB.runSlot(context.Background(), replicaB.entries["quota_reset"], slot.AddDate(0, 0, 1))
+	if calls != 2 {
+		t.Fatalf("job ran %d times for two slots, want 2", calls)
+	}
+	if len(store.runs) != 2 || store.runs[1].InstanceID != "replica-b" || store.runs[1].Status != JobRunSucceeded {
+		t.Errorf("runs = %+v", store.runs)
+	}
+}
+
+func TestScheduler_RecordsFailures(t *testing.T) {
+	store := &memoryJobRunStore{}
+	scheduler := NewScheduler(nil, store)
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+
+	scheduler.Register(ScheduledJob{Name: "backup", Schedule: "0 * * * *", Run: func(ctx context.Context) error {
+		return errors.New("disk full")
+	}})
+	scheduler.Register(ScheduledJob{Name: "digest", Schedule: "0 8 * * 1", Run: func(ctx context.Context) error {
+		panic("nil template")
+	}})
+	scheduler.Register(ScheduledJob{Name: "trial_expiry", Schedule: "*/5 * * * *", Run: func(ctx context.Context) error {
+		return nil
+	}})
+
+	scheduler.runSlot(context.Background(), scheduler.entries["backup"], now)
+	scheduler.runSlot(context.Background(), scheduler.entries["digest"], now)
+
+	jobs, err := scheduler.Jobs(context.Background())
+	if err != nil {
+		t.Fatalf("Jobs() error = %v", err)
+	}
+	if len(jobs) != 3 || jobs[0].Name != "backup" || jobs[1].Name != "digest" || jobs[2].Name != "trial_expiry" {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if run := jobs[0].LastRun; run == nil || run.Status != JobRunFailed || run.Error != "disk full" {
+		t.Errorf("backup last run = %+v", run)
+	}
+	if run := jobs[1].LastRun; run == nil || run.Status != JobRunFailed || run.Error != "panic: nil template" {
+		t.Errorf("digest last run = %+v", run)
+	}
+	if jobs[2].LastRun != nil {
+		t.Errorf("trial_expiry last run = %+v, want none", jobs[2].LastRun)
+	}
+	if next := jobs[0].NextRunAt; next == nil || !next.Equal(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("backup next run = %v", next)
+	}
+	if jobs[2].TimeoutMs != DefaultScheduledJobTimeout.Milliseconds() {
+		t.Errorf("trial_expiry timeout = %dms", jobs[2].TimeoutMs)
+	}
+
+	if _, err := scheduler.Runs(context.Background(), "unknown", 10); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Runs() of an unknown job error = %v, want ErrNotFound", err)
+	}
+}
@@ -2,16 +2,24 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"ai-styler/internal/common"
 	"ai-styler/internal/conversion"
 	"ai-styler/internal/image"
+	"ai-styler/internal/monitoring"
+	"ai-styler/internal/outbox"
+	"ai-styler/internal/storage"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Service implements the WorkerService interface
@@ -28,6 +36,63 @@ type Service struct {
 	healthChecker    HealthChecker
 	retryHandler     RetryHandler
 
+	// Provider budget guardrails (optional)
+	budgetGuard *BudgetGuard
+	fallbackAPI GeminiAPI
+
+	// Provider rolled out to the users a feature flag selects (optional)
+	flags             FeatureFlags
+	candidateAPI      GeminiAPI
+	candidateProvider string
+
+	// Prompt template versions managed by admins (optional)
+	prompts PromptTemplates
+
+	// Per-conversion cost accounting (optional)
+	costTracker *CostTracker
+
+	// Image moderation stage (optional)
+	moderation *ModerationPipeline
+
+	// Person and garment pre-check of the inputs (optional)
+	inputChecker *InputChecker
+
+	// Transactional outbox for conversion events (optional)
+	eventStore conversion.EventStore
+
+	// Conversion progress reported to clients (optional)
+	progress conversion.ProgressStore
+
+	// Finishes jobs and their conversions in one transaction (optional)
+	tx common.TxRunner
+
+	// Gives back the quota of conversions failed by the provider (optional)
+	quotaRefunds QuotaRefunds
+
+	// Direct storage access for input images (optional)
+	objectReader    storage.ObjectReader
+	storageBasePath string
+
+	// Background WebP/AVIF and responsive width variants of results (optional)
+	variants *storage.VariantPipeline
+
+	// Watermark on results of plans without watermark_removal (optional)
+	watermarker *Watermarker
+
+	// Persisted per-conversion logs (optional)
+	conversionLogs         ConversionLogStore
+	conversionLogRetention time.Duration
+
+	// Heartbeats and job lookups for the admin worker endpoints (optional)
+	introspection     IntrospectionStore
+	heartbeatInterval time.Duration
+
+	// Recurring jobs listed by the admin worker endpoints (optional)
+	scheduler *Scheduler
+
+	// Provider call timeout set at runtime, see SetConversionTimeout
+	conversionTimeout atomic.Int64
+
 	// Worker state
 	workers     map[string]*Worker
 	workerMutex sync.RWMutex
@@ -35,6 +100,12 @@ type Service struct {
 	workerID    string
 	started     bool
 	startMutex  sync.Mutex
+
+	// Graceful drain: workerLoops tracks the worker goroutines, cancelJobs
+	// aborts in-flight jobs once the drain timeout has passed
+	workerLoops sync.WaitGroup
+	cancelJobs  context.CancelFunc
+	draining    atomic.Bool
 }
 
 // Worker represents a single worker instance
@@ -45,6 +116,8 @@ type Worker struct {
 	JobsProcessed int64
 	StartedAt     time.Time
 	LastSeen      time.Time
+	LastError     string
+	LastErrorAt   *time.Time
 }
 
 // NewService creates a new worker service
@@ -83,6 +156,89 @@ func NewService(
 	}
 }
 
+// SetBudgetGuard enables monthly provider budget caps. fallbackAPI is used when
+// the guard degrades to the fallback provider and may be nil.
+func (s *Service) SetBudgetGuard(guard *BudgetGuard, fallbackAPI GeminiAPI) {
+	s.budgetGuard = guard
+	s.fallbackAPI = fallbackAPI
+}
+
+// SetCostTracker records the provider cost of every conversion
+func (s *Service) SetCostTracker(tracker *CostTracker) {
+	s.costTracker = tracker
+}
+
+// SetModeration enables the moderation stage that runs before Gemini is called
+func (s *Service) SetModeration(moderation *ModerationPipeline) {
+	s.moderation = moderation
+}
+
+// SetInputChecker enables the pre-check that rejects unusable inputs, such as
+// a photo without a person, before the provider is called
+func (s *Service) SetInputChecker(checker *InputChecker) {
+	s.inputChecker = checker
+}
+
+// SetEventStore publishes conversion notifications through the transactional
+// outbox instead of calling the notifier directly
+func (s *Service) SetEventStore(eventStore conversion.EventStore) {
+	s.eventStore = eventStore
+}
+
+// SetUnitOfWork makes a finished job and the status of its conversion apply
+// together, so neither is left behind when the other fails to save
+func (s *Service) SetUnitOfWork(tx common.TxRunner) {
+	s.tx = tx
+}
+
+// SetQuotaRefunds gives the quota charged for a conversion back when the
+// conversion fails because of the provider, together with the failure
+func (s *Service) SetQuotaRefunds(refunds QuotaRefunds) {
+	s.quotaRefunds = refunds
+}
+
+// SetObjectReader lets the worker read input images straight from storage
+// instead of resolving their URLs. basePath is the local storage root used to
+// map stored file paths to object keys.
+func (s *Service) SetObjectReader(reader storage.ObjectReader, basePath string) {
+	s.objectReader = reader
+	s.storageBasePath = basePath
+}
+
+// SetImageVariants generates variants of every result image in the background
+// with pipeline, which runs for as long as the service
+func (s *Service) SetImageVariants(pipeline *storage.VariantPipeline) {
+	s.variants = pipeline
+}
+
+// SetWatermarker overlays a watermark on result images before they are
+// uploaded, unless the user's plan includes watermark_removal
+func (s *Service) SetWatermarker(watermarker *Watermarker) {
+	s.watermarker = watermarker
+}
+
+// SetWatermarkOptions changes the watermark settings while the service is
+// running. It does nothing when no watermarker is set.
+func (s *Service) SetWatermarkOptions(options WatermarkOptions) {
+	if s.watermarker != nil {
+		s.watermarker.SetOptions(options)
+	}
+}
+
+// SetConversionLogs persists stage transitions, provider status codes and
+// retry reasons of each conversion. Entries older than retention are pruned by
+// the cleanup loop; a zero retention keeps them until the per-conversion cap drops them.
+func (s *Service) SetConversionLogs(store ConversionLogStore, retention time.Duration) {
+	s.conversionLogs = store
+	s.conversionLogRetention = retention
+}
+
+// SetConversionTimeout changes how long a provider conversion may take while
+// the service is running. A zero timeout restores DefaultConversionTimeout.
+func (s *Service) SetConversionTimeout(timeout time.Duration) {
+	s.conversionTimeout.Store(int64(timeout))
+}
+
 // Start starts the worker service
 func (s *Service) Start(ctx context.Context) error {
 	s.startMutex.Lock()
@@ -99,27 +255,44 @@ func (s *Service) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to register worker: %w", err)
 	}
 
-	// Start worker goroutines
+	// Start worker goroutines. Their context is cancelled separately so Stop
+	// can let in-flight jobs finish before aborting them.
+	jobCtx, cancelJobs := context.WithCancel(ctx)
+	s.cancelJobs = cancelJobs
+	s.draining.Store(false)
 	for i := 0; i < s.config.MaxWorkers; i++ {
 		workerID := fmt.Sprintf("%s-%d", s.workerID, i)
-		go s.workerLoop(ctx, workerID)
+		s.workerLoops.Add(1)
+		go func() {
+			defer s.workerLoops.Done()
+			s.workerLoop(jobCtx, workerID)
+		}()
 	}
 
 	// Start cleanup goroutine
 	go s.cleanupLoop(ctx)
 
+	if s.variants != nil {
+		go s.variants.Run(ctx)
+	}
+
 	// Start health check goroutine
 	if s.config.EnableHealthCheck {
 		go s.healthCheckLoop(ctx)
 	}
 
+	if s.introspection != nil {
+		go s.heartbeatLoop(ctx)
+	}
+
 	s.started = true
 	log.Printf("Worker service started with %d workers", s.config.MaxWorkers)
 
 	return nil
 }
 
-// Stop stops the worker service
+// Stop stops the worker service. Workers stop picking up jobs and in-flight
+// jobs get up to DrainTimeout to finish; unfinished jobs are requeued.
 func (s *Service) Stop(ctx context.Context) error {
 	s.startMutex.Lock()
 	defer s.startMutex.Unlock()
@@ -131,13 +304,22 @@ func (s *Service) Stop(ctx context.Context) error {
 	log.Printf("Stopping worker service: %s", s.workerID)
 
 	// Signal all workers to stop
+	s.draining.Store(true)
 	close(s.stopChan)
 
+	s.drain(ctx)
+
 	// Unregister this worker
 	if err := s.healthChecker.UnregisterWorker(ctx, s.workerID); err != nil {
 		log.Printf("Failed to unregister worker: %v", err)
 	}
 
+	if s.introspection != nil {
+		if err := s.introspection.DeleteHeartbeats(ctx, s.workerID); err != nil {
+			log.Printf("Failed to delete worker heartbeats: %v", err)
+		}
+	}
+
 	s.started = false
 	log.Printf("Worker service stopped")
 
@@ -170,19 +352,48 @@ func (s *Service) EnqueueJob(ctx context.Context, jobType string, conversionID,
 	}
 
 	log.Printf("Enqueued job %s of type %s for conversion %s", job.ID, jobType, conversionID)
+	s.logConversion(ctx, job, ConversionStageQueued, ConversionLogInfo, fmt.Sprintf("Enqueued %s job", jobType), nil)
 	return nil
 }
 
-// ProcessJob processes a single job
+// ProcessJob processes a single job inside a tracing span
 func (s *Service) ProcessJob(ctx context.Context, job *WorkerJob) error {
+	// Jobs are queued through the database, so each job starts a new trace;
+	// the conversion's trace ID ties it to the request that created it
+	ctx = withJobTrace(ctx, job)
+	ctx, span := monitoring.Tracer().Start(ctx, "worker.job "+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+			attribute.String("conversion.id", job.ConversionID),
+			attribute.String("conversion.trace_id", job.Payload.TraceID),
+			attribute.String("enduser.id", job.UserID),
+			attribute.Int("job.retry_count", job.RetryCount),
+			attribute.String("worker.id", s.workerID),
+		),
+	)
+	err := s.processJob(ctx, job)
+	monitoring.EndSpan(span, err)
+	return err
+}
+
+// processJob runs a job and records its outcome
+func (s *Service) processJob(ctx context.Context, job *WorkerJob) error {
 	startTime := time.Now()
 
-	log.Printf("Processing job %s of type %s", job.ID, job.Type)
+	log.Printf("Processing job %s of type %s%s", job.ID, job.Type, traceLabel(ctx))
 
 	// Update job status to processing
 	if err := s.jobQueue.UpdateJobStatus(ctx, job.ID, JobStatusProcessing, s.workerID); err != nil {
+		if s.interrupted(ctx) {
+			s.requeueJob(context.WithoutCancel(ctx), job)
+		}
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
+	s.logConversion(ctx, job, ConversionStageStarted, ConversionLogInfo, "Job picked up by worker", map[string]interface{}{
+		"retry_count": job.RetryCount,
+	})
 
 	// Process based on job type
 	var result interface{}
@@ -197,23 +408,40 @@ func (s *Service) ProcessJob(ctx context.Context, job *WorkerJob) error {
 
 	processingTime := time.Since(startTime)
 
-	if err != nil {
-		log.Printf("Job %s failed after %v: %v", job.ID, processingTime, err)
-
-		// No retry - mark job as failed immediately
-
-		// Mark job as failed
-		if err := s.jobQueue.FailJob(ctx, job.ID, err.Error()); err != nil {
-			log.Printf("Failed to mark job %s as failed: %v", job.ID, err)
-		}
+	if err != nil && s.interrupted(ctx) {
+		// Aborted by a shutdown, not a failure of the job itself
+		log.Printf("Job %s interrupted by shutdown after %v: %v", job.ID, processingTime, err)
+		s.requeueJob(context.WithoutCancel(ctx), job)
+		return err
+	}
 
-		// Update conversion status
-		if err := s.updateConversionStatus(ctx, job.ConversionID, "failed", nil, err.Error(), int(processingTime.Milliseconds())); err != nil {
-			log.Printf("Failed to update conversion status: %v", err)
+	if err != nil {
+		log.Printf("Job %s failed after %v%s: %v", job.ID, processingTime, traceLabel(ctx), err)
+		s.logConversion(ctx, job, ConversionStageFailed, ConversionLogError, err.Error(), map[string]interface{}{
+			"processing_time_ms": processingTime.Milliseconds(),
+		})
+
+		// No retry - mark job and conversion as failed immediately
+		failedEvent := outbox.NewConversionEvent(outbox.EventConversionFailed, outbox.ConversionEventPayload{
+			UserID:       job.UserID,
+			ConversionID: job.ConversionID,
+			ErrorMessage: err.Error(),
+		})
+		jobErr := err
+		if err := common.RunInTx(ctx, s.tx, func(ctx context.Context) error {
+			if err := s.jobQueue.FailJob(ctx, job.ID, jobErr.Error()); err != nil {
+				return fmt.Errorf("failed to mark job as failed: %w", err)
+			}
+			if err := s.failConversion(ctx, job.ConversionID, jobErr, int(processingTime.Milliseconds()), failedEvent); err != nil {
+				return fmt.Errorf("failed to update conversion status: %w", err)
+			}
+			return nil
+		}); err != nil {
+			log.Printf("Failed to record failure of job %s: %v", job.ID, err)
 		}
 
 		// Send failure notification
-		if s.notifier != nil {
+		if s.notifier != nil && s.eventStore == nil {
 			if err := s.notifier.SendConversionFailed(ctx, job.UserID, job.ConversionID, err.Error()); err != nil {
 				log.Printf("Failed to send failure notification: %v", err)
 			}
@@ -227,18 +455,29 @@ func (s *Service) ProcessJob(ctx context.Context, job *WorkerJob) error {
 		return err
 	}
 
-	// Mark job as completed
-	if err := s.jobQueue.CompleteJob(ctx, job.ID, result); err != nil {
-		log.Printf("Failed to mark job %s as completed: %v", job.ID, err)
+	// Mark job and conversion as completed
+	var completedEvents []outbox.Event
+	if resultImageID, ok := result.(string); ok {
+		completedEvents = append(completedEvents, outbox.NewConversionEvent(outbox.EventConversionCompleted, outbox.ConversionEventPayload{
+			UserID:        job.UserID,
+			ConversionID:  job.ConversionID,
+			ResultImageID: resultImageID,
+		}))
 	}
-
-	// Update conversion status
-	if err := s.updateConversionStatus(ctx, job.ConversionID, "completed", result, "", int(processingTime.Milliseconds())); err != nil {
-		log.Printf("Failed to update conversion status: %v", err)
+	if err := common.RunInTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.jobQueue.CompleteJob(ctx, job.ID, result); err != nil {
+			return fmt.Errorf("failed to mark job as completed: %w", err)
+		}
+		if err := s.updateConversionStatus(ctx, job.ConversionID, "completed", result, "", int(processingTime.Milliseconds()), completedEvents...); err != nil {
+			return fmt.Errorf("failed to update conversion status: %w", err)
+		}
+		return nil
+	}); err != nil {
+		log.Printf("Failed to record completion of job %s: %v", job.ID, err)
 	}
 
 	// Send success notification
-	if s.notifier != nil {
+	if s.notifier != nil && s.eventStore == nil {
 		if resultImageID, ok := result.(string); ok {
 			if err := s.notifier.SendConversionCompleted(ctx, job.UserID, job.ConversionID, resultImageID); err != nil {
 				log.Printf("Failed to send success notification: %v", err)
@@ -251,6 +490,10 @@ func (s *Service) ProcessJob(ctx context.Context, job *WorkerJob) error {
 		s.metricsCollector.RecordJobComplete(ctx, job.ID, int(processingTime.Milliseconds()), true)
 	}
 
+	s.logConversion(ctx, job, ConversionStageCompleted, ConversionLogInfo, "Conversion completed", map[string]interface{}{
+		"processing_time_ms": processingTime.Milliseconds(),
+		"result_image_id":    result,
+	})
 	log.Printf("Job %s completed successfully in %v", job.ID, processingTime)
 	return nil
 }
@@ -300,29 +543,159 @@ func (s *Service) processImageConversion(ctx context.Context, job *WorkerJob) (i
 	}
 	log.Printf("Downloaded cloth image: %d bytes", len(clothImageData))
 
+	// Outfits list further garments after the cloth image
+	var outfitData [][]byte
+	if len(conversion.Garments) > 1 {
+		outfitData, err = s.downloadGarments(ctx, conversion.Garments[1:])
+		if err != nil {
+			log.Printf("Failed to download outfit garments: %v", err)
+			return nil, err
+		}
+	}
+	s.logConversion(ctx, job, ConversionStageDownload, ConversionLogInfo, "Downloaded input images", map[string]interface{}{
+		"user_image_bytes":  len(userImageData),
+		"cloth_image_bytes": len(clothImageData),
+	})
+	s.reportProgress(ctx, job, ProgressDownloaded)
+
 	// Validate downloaded images
 	log.Printf("Validating downloaded images")
 	if err := s.validateImages(ctx, userImageData, clothImageData); err != nil {
 		log.Printf("Image validation failed: %v", err)
-		return nil, fmt.Errorf("image validation failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInputImage, err)
 	}
 	log.Printf("Images validated successfully")
 
+	// Run moderation before any provider spend
+	if s.moderation != nil {
+		if _, err := s.moderation.Check(ctx, job.ConversionID, conversion.UserImageID, ModerationImageUser, userImageData); err != nil {
+			log.Printf("User image failed moderation: %v", err)
+			s.logConversion(ctx, job, ConversionStageModeration, ConversionLogWarn, "User image rejected: "+err.Error(), nil)
+			return nil, err
+		}
+		if _, err := s.moderation.Check(ctx, job.ConversionID, conversion.ClothImageID, ModerationImageCloth, clothImageData); err != nil {
+			log.Printf("Cloth image failed moderation: %v", err)
+			s.logConversion(ctx, job, ConversionStageModeration, ConversionLogWarn, "Cloth image rejected: "+err.Error(), nil)
+			return nil, err
+		}
+		for i, data := range outfitData {
+			if _, err := s.moderation.Check(ctx, job.ConversionID, conversion.Garments[i+1].ImageID, ModerationImageCloth, data); err != nil {
+				log.Printf("Outfit garment failed moderation: %v", err)
+				s.logConversion(ctx, job, ConversionStageModeration, ConversionLogWarn, "Garment image rejected: "+err.Error(), nil)
+				return nil, err
+			}
+		}
+	}
+
+	// Reject inputs that would not make a usable conversion
+	if s.inputChecker != nil {
+		if err := s.checkInputs(ctx, job, userImageData, append([][]byte{clothImageData}, outfitData...)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The provider takes one cloth image, so an outfit is sent as a composite
+	// of its garments with their slots in the prompt
+	options := job.Payload.Options
+	if len(outfitData) > 0 {
+		composite, err := composeOutfit(append([][]byte{clothImageData}, outfitData...))
+		if err != nil {
+			log.Printf("Failed to compose outfit: %v", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidInputImage, err)
+		}
+		clothImageData = composite
+		options = outfitOptions(options, conversion.Garments)
+		s.logConversion(ctx, job, ConversionStageValidation, ConversionLogInfo, "Composed outfit", map[string]interface{}{
+			"garments":        len(conversion.Garments),
+			"composite_bytes": len(composite),
+		})
+	}
+
+	// Apply provider budget guardrails
+	geminiAPI, budgetDecision, err := s.applyBudget(ctx, job, &userImageData, &clothImageData)
+	if err != nil {
+		return nil, err
+	}
+	s.reportProgress(ctx, job, ProgressPreprocessed)
+
 	// Call Gemini API for conversion with timeout
 	log.Printf("Calling Gemini API for image conversion...")
-	resultImageData, err := s.convertImageWithTimeout(ctx, userImageData, clothImageData, job.Payload.Options)
+	provider := "gemini"
+	if budgetDecision != nil && budgetDecision.Provider != "" {
+		provider = budgetDecision.Provider
+	}
+	geminiAPI, provider = s.applyProviderRollout(ctx, job, geminiAPI, provider, budgetDecision)
+	options = s.applyPromptTemplate(ctx, job, options)
+	providerCtx := WithProviderAttemptObserver(ctx, s.providerAttemptLogger(ctx, job, provider))
+	var usage ProviderUsage
+	providerCtx = WithProviderUsageObserver(providerCtx, func(u ProviderUsage) { usage = u })
+	s.reportProgress(ctx, job, ProgressProviderStarted)
+	resultImageData, err := s.convertImageWithTimeout(providerCtx, geminiAPI, userImageData, clothImageData, options)
 	if err != nil {
 		log.Printf("Gemini API conversion failed: %v", err)
 		return nil, fmt.Errorf("failed to convert image with Gemini: %w", err)
 	}
 	log.Printf("Gemini API conversion successful: result image size=%d bytes", len(resultImageData))
 
+	if budgetDecision != nil {
+		if err := s.budgetGuard.RecordSpend(ctx, budgetDecision, job.ConversionID); err != nil {
+			log.Printf("Failed to record provider spend: %v", err)
+		}
+	}
+	s.recordConversionCost(ctx, job, provider, usage, budgetDecision)
+	s.reportProgress(ctx, job, ProgressDecoding)
+
 	// Process the result image
 	processedData, width, height, err := s.imageProcessor.ProcessImage(ctx, resultImageData, "converted_"+userImage.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process result image: %w", err)
 	}
 
+	// Apply the output options saved with the user's preset or requested
+	// with the conversion
+	if dims := outputDimensionsOption(job.Payload.Options); !dims.isZero() {
+		fitted, w, h, applied, err := fitOutputDimensions(processedData, dims)
+		if err != nil {
+			// Log but don't fail the conversion - deliver the result as generated
+			log.Printf("Failed to fit result image to the output dimensions: %v", err)
+		} else if applied {
+			processedData, width, height = fitted, w, h
+		}
+	}
+	if maxEdge := outputSizeOption(job.Payload.Options); maxEdge > 0 {
+		resized, w, h, applied, err := resizeToOutputSize(processedData, maxEdge)
+		if err != nil {
+			// Log but don't fail the conversion - deliver the result at full size
+			log.Printf("Failed to resize result image: %v", err)
+		} else if applied {
+			processedData, width, height = resized, w, h
+		}
+	}
+	if text := watermarkTextOption(job.Payload.Options); text != "" {
+		marked, err := applyUserWatermark(processedData, text)
+		if err != nil {
+			// Log but don't fail the conversion - deliver the result without the user's mark
+			log.Printf("Failed to apply user watermark: %v", err)
+			s.logConversion(ctx, job, ConversionStageWatermark, ConversionLogWarn, "User watermark failed: "+err.Error(), nil)
+		} else {
+			processedData = marked
+			s.logConversion(ctx, job, ConversionStageWatermark, ConversionLogInfo, "Applied user watermark", nil)
+		}
+	}
+
+	// Watermark results of plans without watermark_removal
+	if s.watermarker != nil {
+		watermarked, applied, err := s.watermarker.Apply(ctx, job.UserID, processedData)
+		if err != nil {
+			// Log but don't fail the conversion - deliver the result without a watermark
+			log.Printf("Failed to watermark result image: %v", err)
+			s.logConversion(ctx, job, ConversionStageWatermark, ConversionLogWarn, "Watermark failed: "+err.Error(), nil)
+		} else if applied {
+			processedData = watermarked
+			s.logConversion(ctx, job, ConversionStageWatermark, ConversionLogInfo, "Applied watermark", nil)
+		}
+	}
+
 	// Generate storage path for result
 	storagePath := fmt.Sprintf("results/%s/%s", job.UserID, job.ConversionID)
 
@@ -331,6 +704,11 @@ func (s *Service) processImageConversion(ctx context.Context, job *WorkerJob) (i
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload result image: %w", err)
 	}
+	s.logConversion(ctx, job, ConversionStageUpload, ConversionLogInfo, "Uploaded result image", map[string]interface{}{
+		"bytes":  len(processedData),
+		"width":  width,
+		"height": height,
+	})
 
 	// Generate thumbnail
 	thumbnailData, err := s.imageProcessor.GenerateThumbnail(ctx, processedData, "converted_"+userImage.FileName, 300, 300)
@@ -375,11 +753,16 @@ func (s *Service) processImageConversion(ctx context.Context, job *WorkerJob) (i
 		return nil, fmt.Errorf("failed to create result image record: %w", err)
 	}
 
+	if s.variants != nil {
+		s.variants.Enqueue(resultImage.ID, resultURL)
+	}
+	s.reportProgress(ctx, job, ProgressStored)
+
 	return resultImage.ID, nil
 }
 
 // updateConversionStatus updates the conversion status in the database
-func (s *Service) updateConversionStatus(ctx context.Context, conversionID, status string, result interface{}, errorMessage string, processingTimeMs int) error {
+func (s *Service) updateConversionStatus(ctx context.Context, conversionID, status string, result interface{}, errorMessage string, processingTimeMs int, events ...outbox.Event) error {
 	updateReq := conversion.UpdateConversionRequest{
 		Status:           &status,
 		ProcessingTimeMs: &processingTimeMs,
@@ -395,6 +778,53 @@ func (s *Service) updateConversionStatus(ctx context.Context, conversionID, stat
 		updateReq.ErrorMessage = &errorMessage
 	}
 
+	if status == "completed" {
+		progress := ProgressCompleted
+		updateReq.Progress = &progress
+	}
+
+	return s.saveConversionUpdate(ctx, conversionID, updateReq, events...)
+}
+
+// failConversion marks a conversion as failed, records whether the failure
+// was caused by the provider or by the request, and dead-letters and refunds
+// provider failures
+func (s *Service) failConversion(ctx context.Context, conversionID string, jobErr error, processingTimeMs int, events ...outbox.Event) error {
+	status := "failed"
+	errorMessage := jobErr.Error()
+	kind := failureKind(jobErr)
+
+	updateReq := conversion.UpdateConversionRequest{
+		Status:           &status,
+		ErrorMessage:     &errorMessage,
+		ProcessingTimeMs: &processingTimeMs,
+		FailureKind:      &kind,
+		DeadLetter:       deadLetter(jobErr),
+	}
+	if code := errorCode(jobErr); code != "" {
+		updateReq.ErrorCode = &code
+	}
+	if err := s.saveConversionUpdate(ctx, conversionID, updateReq, events...); err != nil {
+		return err
+	}
+
+	// Users don't pay for conversions the provider failed
+	if kind == conversion.FailureKindProvider && s.quotaRefunds != nil {
+		refunded, err := s.quotaRefunds.RefundConversion(ctx, conversionID)
+		if err != nil {
+			return fmt.Errorf("failed to refund quota: %w", err)
+		}
+		if refunded > 0 {
+			log.Printf("Refunded %d quota charges of failed conversion %s", refunded, conversionID)
+		}
+	}
+	return nil
+}
+
+func (s *Service) saveConversionUpdate(ctx context.Context, conversionID string, updateReq conversion.UpdateConversionRequest, events ...outbox.Event) error {
+	if s.eventStore != nil {
+		return s.eventStore.UpdateConversionWithEvents(ctx, conversionID, updateReq, events)
+	}
 	return s.conversionStore.UpdateConversion(ctx, conversionID, updateReq)
 }
 
@@ -437,35 +867,48 @@ func (s *Service) workerLoop(ctx context.Context, workerID string) {
 					log.Printf("⚠️  Worker table not found. Please run migrations: go run scripts/migrate/main.go up")
 					log.Printf("   Or run directly: psql -d your_database -f scripts/create_worker_table.sql")
 					// Wait longer when table doesn't exist (30 seconds instead of poll interval)
-					time.Sleep(30 * time.Second)
+					s.sleep(30 * time.Second)
 					continue
 				}
 				log.Printf("Failed to dequeue job: %v", err)
-				time.Sleep(s.config.PollInterval)
+				s.recordWorkerError(worker, err)
+				s.sleep(s.config.PollInterval)
 				continue
 			}
 
 			if job == nil {
 				// No jobs available, wait
-				time.Sleep(s.config.PollInterval)
+				s.sleep(s.config.PollInterval)
 				continue
 			}
 
+			// Stop may have been called while the job was being dequeued
+			if s.draining.Load() {
+				s.requeueJob(context.WithoutCancel(ctx), job)
+				log.Printf("Worker %s stopping", workerID)
+				return
+			}
+
 			// Update worker status
+			s.workerMutex.Lock()
 			worker.Status = "processing"
 			worker.CurrentJob = job
 			worker.LastSeen = time.Now()
+			s.workerMutex.Unlock()
 
 			// Process the job
 			if err := s.ProcessJob(ctx, job); err != nil {
 				log.Printf("Worker %s failed to process job %s: %v", workerID, job.ID, err)
+				s.recordWorkerError(worker, err)
 			}
 
 			// Update worker status
+			s.workerMutex.Lock()
 			worker.Status = "idle"
 			worker.CurrentJob = nil
 			worker.JobsProcessed++
 			worker.LastSeen = time.Now()
+			s.workerMutex.Unlock()
 		}
 	}
 }
@@ -487,6 +930,12 @@ func (s *Service) cleanupLoop(ctx context.Context) {
 			if err := s.jobQueue.CleanupOldJobs(ctx, cutoff); err != nil {
 				log.Printf("Failed to cleanup old jobs: %v", err)
 			}
+
+			if s.conversionLogs != nil && s.conversionLogRetention > 0 {
+				if _, err := s.conversionLogs.PruneConversionLogs(ctx, time.Now().Add(-s.conversionLogRetention)); err != nil {
+					log.Printf("Failed to prune conversion logs: %v", err)
+				}
+			}
 		}
 	}
 }
@@ -513,7 +962,23 @@ func (s *Service) healthCheckLoop(ctx context.Context) {
 
 // GetStatus returns the current status of the worker service
 func (s *Service) GetStatus(ctx context.Context) (*WorkerStats, error) {
-	return s.jobQueue.GetQueueStats(ctx)
+	stats, err := s.jobQueue.GetQueueStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach retry counters when the provider client exposes them
+	if provider, ok := s.geminiAPI.(interface{ RetryStats() GeminiRetryStats }); ok {
+		retryStats := provider.RetryStats()
+		stats.GeminiRetry = &retryStats
+	}
+
+	return stats, nil
+}
+
+// ProviderHealthCheck checks the configured AI provider answers requests
+func (s *Service) ProviderHealthCheck(ctx context.Context) error {
+	return s.geminiAPI.HealthCheck(ctx)
 }
 
 // GetHealth returns the health status of this worker
@@ -582,6 +1047,7 @@ func getDefaultConfig() *WorkerConfig {
 		HealthCheckPort:   DefaultHealthCheckPort,
 		EnableMetrics:     true,
 		EnableHealthCheck: true,
+		DrainTimeout:      DefaultDrainTimeout,
 	}
 }
 
@@ -593,8 +1059,20 @@ func generateJobID() string {
 	return uuid.New().String()
 }
 
-// downloadImageWithRetry downloads an image (single attempt only)
+// downloadImageWithRetry downloads an image (single attempt only). Images
+// stored by this service are read through the object reader; other
+// references fall back to the file storage.
 func (s *Service) downloadImageWithRetry(ctx context.Context, url, description string) ([]byte, error) {
+	if s.objectReader != nil {
+		if key, ok := storage.ObjectKey(url, s.storageBasePath); ok {
+			data, err := s.objectReader.ReadObject(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", description, err)
+			}
+			return data, nil
+		}
+	}
+
 	data, err := s.fileStorage.GetFile(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download %s: %w", description, err)
@@ -611,6 +1089,30 @@ func (s *Service) uploadFileWithRetry(ctx context.Context, data []byte, filename
 	return url, nil
 }
 
+// checkInputs runs the input pre-check on the user photo and every garment
+func (s *Service) checkInputs(ctx context.Context, job *WorkerJob, userImageData []byte, garments [][]byte) error {
+	check := func(imageKind string, data []byte) error {
+		err := s.inputChecker.Check(ctx, imageKind, data)
+		if err != nil {
+			log.Printf("%s image failed the input check: %v", imageKind, err)
+			s.logConversion(ctx, job, ConversionStageInputCheck, ConversionLogWarn, err.Error(), map[string]interface{}{
+				"error_code": errorCode(err),
+			})
+		}
+		return err
+	}
+
+	if err := check(ModerationImageUser, userImageData); err != nil {
+		return err
+	}
+	for _, data := range garments {
+		if err := check(ModerationImageCloth, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validateImages validates downloaded images
 func (s *Service) validateImages(ctx context.Context, userImageData, clothImageData []byte) error {
 	// Check if images are empty
@@ -674,10 +1176,117 @@ func (s *Service) validateImageFormat(data []byte, description string) error {
 	return fmt.Errorf("%s has unsupported format", description)
 }
 
+// recordConversionCost stores what the provider billed for a conversion
+func (s *Service) recordConversionCost(ctx context.Context, job *WorkerJob, provider string, usage ProviderUsage, decision *BudgetDecision) {
+	if s.costTracker == nil {
+		return
+	}
+
+	cost := ConversionCost{
+		ConversionID: job.ConversionID,
+		UserID:       job.UserID,
+		Provider:     provider,
+		Usage:        usage,
+	}
+	if decision != nil {
+		cost.Cost = decision.Cost
+	}
+	if err := s.costTracker.Record(ctx, cost); err != nil {
+		log.Printf("Failed to record conversion cost: %v", err)
+	}
+}
+
+// applyBudget evaluates the provider budget for a job and returns the API to call.
+// It may downscale the input images in place when the budget is degraded.
+func (s *Service) applyBudget(ctx context.Context, job *WorkerJob, userImageData, clothImageData *[]byte) (GeminiAPI, *BudgetDecision, error) {
+	if !s.budgetGuard.Enabled() {
+		return s.geminiAPI, nil, nil
+	}
+
+	decision, err := s.budgetGuard.Evaluate(ctx)
+	if err != nil {
+		if errors.Is(err, ErrProviderBudgetExhausted) {
+			return nil, nil, fmt.Errorf("conversion unavailable: %w", err)
+		}
+		// Do not block conversions when spend tracking is unavailable
+		log.Printf("Failed to evaluate provider budget: %v", err)
+		return s.geminiAPI, nil, nil
+	}
+
+	if !decision.Degraded() {
+		return s.geminiAPI, decision, nil
+	}
+	log.Printf("Provider budget degraded for job %s: %s", job.ID, decision.Reason)
+	s.logConversion(ctx, job, ConversionStageBudget, ConversionLogWarn, "Provider budget degraded: "+decision.Reason, map[string]interface{}{
+		"use_fallback":     decision.UseFallback,
+		"lower_resolution": decision.LowerResolution,
+		"pause_free_tier":  decision.PauseFreeTier,
+	})
+
+	if decision.PauseFreeTier {
+		free, err := s.budgetGuard.IsFreeTierUser(ctx, job.UserID)
+		if err != nil {
+			log.Printf("Failed to check free-tier status for user %s: %v", job.UserID, err)
+		} else if free {
+			return nil, nil, fmt.Errorf("conversion unavailable: %w", ErrFreeTierPaused)
+		}
+	}
+
+	if decision.LowerResolution {
+		maxSide := s.budgetGuard.LowResolutionMaxSide()
+		*userImageData = s.downscaleImage(ctx, *userImageData, "user_image", maxSide)
+		*clothImageData = s.downscaleImage(ctx, *clothImageData, "cloth_image", maxSide)
+	}
+
+	geminiAPI := s.geminiAPI
+	if decision.UseFallback {
+		if s.fallbackAPI != nil {
+			geminiAPI = s.fallbackAPI
+		} else {
+			// No fallback client wired, bill against the primary provider
+			decision.UseFallback = false
+			decision.Provider = s.budgetGuard.config.Provider
+			decision.Cost = s.budgetGuard.config.CostPerConversion
+		}
+	}
+
+	return geminiAPI, decision, nil
+}
+
+// downscaleImage shrinks an image so its longest side is at most maxSide.
+// The original data is returned if the image is already small enough or resizing fails.
+func (s *Service) downscaleImage(ctx context.Context, data []byte, fileName string, maxSide int) []byte {
+	width, height, err := s.imageProcessor.GetImageDimensions(ctx, data)
+	if err != nil || width <= 0 || height <= 0 {
+		return data
+	}
+	if width <= maxSide && height <= maxSide {
+		return data
+	}
+
+	newWidth, newHeight := maxSide, maxSide
+	if width > height {
+		newHeight = height * maxSide / width
+	} else {
+		newWidth = width * maxSide / height
+	}
+
+	resized, err := s.imageProcessor.ResizeImage(ctx, data, fileName, newWidth, newHeight)
+	if err != nil {
+		log.Printf("Failed to downscale %s: %v", fileName, err)
+		return data
+	}
+	return resized
+}
+
 // convertImageWithTimeout converts image with timeout
-func (s *Service) convertImageWithTimeout(ctx context.Context, userImageData, clothImageData []byte, options map[string]interface{}) ([]byte, error) {
+func (s *Service) convertImageWithTimeout(ctx context.Context, geminiAPI GeminiAPI, userImageData, clothImageData []byte, options map[string]interface{}) ([]byte, error) {
 	// Create context with timeout
-	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	timeout := time.Duration(s.conversionTimeout.Load())
+	if timeout <= 0 {
+		timeout = DefaultConversionTimeout
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Use a channel to handle the conversion
@@ -687,7 +1296,7 @@ func (s *Service) convertImageWithTimeout(ctx context.Context, userImageData, cl
 	}, 1)
 
 	go func() {
-		data, err := s.geminiAPI.ConvertImage(timeoutCtx, userImageData, clothImageData, options)
+		data, err := geminiAPI.ConvertImage(timeoutCtx, userImageData, clothImageData, options)
 		resultChan <- struct {
 			data []byte
 			err  error
@@ -698,7 +1307,7 @@ func (s *Service) convertImageWithTimeout(ctx context.Context, userImageData, cl
 	case result := <-resultChan:
 		return result.data, result.err
 	case <-timeoutCtx.Done():
-		return nil, fmt.Errorf("image conversion timed out after 5 minutes")
+		return nil, fmt.Errorf("image conversion timed out after %v", timeout)
 	}
 }
 
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-styler/internal/storage"
+)
+
+func TestDownloadImage_UsesObjectReader(t *testing.T) {
+	base := t.TempDir()
+	imagePath := filepath.Join(base, "images", "user", "a.jpg")
+	if err := os.MkdirAll(filepath.Dir(imagePath), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(imagePath, []byte("stored image"), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	service := &Service{fileStorage: NewMockFileStorage()}
+	service.SetObjectReader(storage.NewLocalObjectReader(base), base)
+
+	signedURL := "/api/storage/signed/" + base64.URLEncoding.EncodeToString([]byte(imagePath)) + "?access_type=view"
+	for _, ref := range []string{imagePath, signedURL} {
+		data, err := service.downloadImageWithRetry(context.Background(), ref, "user image")
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", ref, err)
+		}
+		if string(data) != "stored image" {
+			t.Errorf("Expected data from storage for %s, got %q", ref, data)
+		}
+	}
+
+	// External URLs are not resolvable in storage and use the file storage
+	data, err := service.downloadImageWithRetry(context.Background(), "https://cdn.example.com/cloth.jpg", "cloth image")
+	if err != nil || string(data) != "mock file data" {
+		t.Errorf("Expected file storage fallback, got %q (%v)", data, err)
+	}
+}
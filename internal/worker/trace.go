@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+
+	"ai-styler/internal/common"
+)
+
+// providerTraceHeader carries a conversion's trace ID on provider requests,
+// for providers and proxies that log request IDs
+const providerTraceHeader = "X-Request-ID"
+
+// withJobTrace puts the trace ID of the job's conversion in the context, so
+// provider calls, logs and error reports of the job carry it
+func withJobTrace(ctx context.Context, job *WorkerJob) context.Context {
+	if job.Payload.TraceID == "" {
+		return ctx
+	}
+	return common.SetTraceIDInContext(ctx, job.Payload.TraceID)
+}
+
+// traceLabel returns " [trace <id>]" for the log lines of a traced
+// conversion, empty otherwise
+func traceLabel(ctx context.Context) string {
+	if traceID := common.GetTraceIDFromContext(ctx); traceID != "" {
+		return " [trace " + traceID + "]"
+	}
+	return ""
+}
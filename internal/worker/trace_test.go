@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"ai-styler/internal/common"
+)
+
+func TestWithJobTrace(t *testing.T) {
+	job := &WorkerJob{Payload: JobPayload{TraceID: "trace-1"}}
+	ctx := withJobTrace(context.Background(), job)
+
+	if got := common.GetTraceIDFromContext(ctx); got != "trace-1" {
+		t.Errorf("expected trace ID trace-1, got %q", got)
+	}
+	if got := ctx.Value("trace_id"); got != "trace-1" {
+		t.Errorf("expected request trace key to be set for error reports, got %v", got)
+	}
+	if got := traceLabel(ctx); got != " [trace trace-1]" {
+		t.Errorf("unexpected trace label %q", got)
+	}
+
+	untraced := withJobTrace(context.Background(), &WorkerJob{})
+	if got := traceLabel(untraced); got != "" {
+		t.Errorf("expected no trace label for an untraced job, got %q", got)
+	}
+}
+
+func TestParsePayloadJSON_TraceID(t *testing.T) {
+	var payload JobPayload
+	if err := parsePayloadJSON(`{"conversionId":"c1","traceId":"trace-1"}`, &payload); err != nil {
+		t.Fatalf("parsePayloadJSON failed: %v", err)
+	}
+	if payload.TraceID != "trace-1" {
+		t.Errorf("expected trace ID trace-1, got %q", payload.TraceID)
+	}
+}
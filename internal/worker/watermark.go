@@ -0,0 +1,289 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// PlanFeatureWatermarkRemoval is the plan feature that exempts results from the watermark
+const PlanFeatureWatermarkRemoval = "watermark_removal"
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Watermark geometry, relative to the result image
+const (
+	watermarkWidthRatio  = 0.25 // widest the mark may be
+	watermarkHeightRatio = 0.08 // tallest a text mark may be
+	watermarkMarginRatio = 0.03
+	watermarkJPEGQuality = 92
+)
+
+// WatermarkOptions are the admin-editable watermark settings
+type WatermarkOptions struct {
+	Enabled  bool
+	Text     string // drawn when no logo is configured
+	Position string
+	Opacity  int // percent, 1-100
+}
+
+// DefaultWatermarkOptions returns the options used until settings are applied
+func DefaultWatermarkOptions() WatermarkOptions {
+	return WatermarkOptions{
+		Enabled:  true,
+		Text:     "AI Styler",
+		Position: WatermarkBottomRight,
+		Opacity:  50,
+	}
+}
+
+// PlanFeatureStore looks up features of a user's active plan
+type PlanFeatureStore interface {
+	HasPlanFeature(ctx context.Context, userID, feature string) (bool, error)
+}
+
+// Watermarker overlays a logo or text on result images of users whose plan
+// does not include watermark_removal
+type Watermarker struct {
+	logo     image.Image // nil draws the text from the options
+	features PlanFeatureStore
+
+	mu      sync.RWMutex
+	options WatermarkOptions
+}
+
+// NewWatermarker creates a watermarker. logo may be nil.
+func NewWatermarker(logo image.Image, features PlanFeatureStore) *Watermarker {
+	return &Watermarker{logo: logo, features: features, options: DefaultWatermarkOptions()}
+}
+
+// LoadWatermarkLogo reads a PNG or JPEG logo; PNG keeps its transparency
+func LoadWatermarkLogo(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watermark logo: %w", err)
+	}
+	logo, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode watermark logo: %w", err)
+	}
+	return logo, nil
+}
+
+// SetOptions changes the watermark settings while the worker is running
+func (w *Watermarker) SetOptions(options WatermarkOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.options = options
+}
+
+// Options returns the current watermark settings
+func (w *Watermarker) Options() WatermarkOptions {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.options
+}
+
+// Apply watermarks a result image of userID and reports whether it did.
+// Images of plans with watermark_removal are returned unchanged. A failed plan
+// lookup also skips the watermark, so paying users never get one by mistake.
+func (w *Watermarker) Apply(ctx context.Context, userID string, data []byte) ([]byte, bool, error) {
+	options := w.Options()
+	if !options.Enabled || (w.logo == nil && options.Text == "") {
+		return data, false, nil
+	}
+
+	if w.features != nil {
+		exempt, err := w.features.HasPlanFeature(ctx, userID, PlanFeatureWatermarkRemoval)
+		if err != nil {
+			log.Printf("Failed to check watermark_removal for user %s, skipping watermark: %v", userID, err)
+			return data, false, nil
+		}
+		if exempt {
+			return data, false, nil
+		}
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode result image: %w", err)
+	}
+
+	marked, err := w.overlay(src, options)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&buf, marked)
+	} else {
+		err = jpeg.Encode(&buf, marked, &jpeg.Options{Quality: watermarkJPEGQuality})
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode watermarked image: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// overlay draws the mark on a copy of src
+func (w *Watermarker) overlay(src image.Image, options WatermarkOptions) (*image.RGBA, error) {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	mark := w.logo
+	if mark == nil {
+		var err error
+		mark, err = renderWatermarkText(options.Text, int(float64(dst.Bounds().Dy())*watermarkHeightRatio))
+		if err != nil {
+			return nil, err
+		}
+	}
+	mark = fitWatermark(mark, int(float64(dst.Bounds().Dx())*watermarkWidthRatio))
+
+	opacity := options.Opacity
+	if opacity <= 0 || opacity > 100 {
+		opacity = DefaultWatermarkOptions().Opacity
+	}
+	alpha := image.NewUniform(color.Alpha{A: uint8(opacity * 255 / 100)})
+
+	target := watermarkRect(dst.Bounds(), mark.Bounds().Size(), options.Position)
+	draw.DrawMask(dst, target, mark, mark.Bounds().Min, alpha, image.Point{}, draw.Over)
+	return dst, nil
+}
+
+// fitWatermark scales mark down to at most maxWidth, keeping its aspect ratio
+func fitWatermark(mark image.Image, maxWidth int) image.Image {
+	size := mark.Bounds().Size()
+	if maxWidth <= 0 || size.X <= maxWidth {
+		return mark
+	}
+	height := size.Y * maxWidth / size.X
+	if height < 1 {
+		height = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), mark, mark.Bounds(), draw.Over, nil)
+	return scaled
+}
+
+// watermarkRect places a mark of the given size inside bounds
+func watermarkRect(bounds image.Rectangle, size image.Point, position string) image.Rectangle {
+	margin := int(float64(min(bounds.Dx(), bounds.Dy())) * watermarkMarginRatio)
+	left, top := bounds.Min.X+margin, bounds.Min.Y+margin
+	right, bottom := bounds.Max.X-margin-size.X, bounds.Max.Y-margin-size.Y
+
+	var origin image.Point
+	switch position {
+	case WatermarkTopLeft:
+		origin = image.Pt(left, top)
+	case WatermarkTopRight:
+		origin = image.Pt(right, top)
+	case WatermarkBottomLeft:
+		origin = image.Pt(left, bottom)
+	case WatermarkCenter:
+		origin = image.Pt(bounds.Min.X+(bounds.Dx()-size.X)/2, bounds.Min.Y+(bounds.Dy()-size.Y)/2)
+	default:
+		origin = image.Pt(right, bottom)
+	}
+	return image.Rectangle{Min: origin, Max: origin.Add(size)}
+}
+
+var (
+	watermarkFontOnce sync.Once
+	watermarkFont     *opentype.Font
+	watermarkFontErr  error
+)
+
+// renderWatermarkText draws text in white with a dark outline on a transparent
+// image about height pixels tall
+func renderWatermarkText(text string, height int) (image.Image, error) {
+	watermarkFontOnce.Do(func() {
+		watermarkFont, watermarkFontErr = opentype.Parse(gobold.TTF)
+	})
+	if watermarkFontErr != nil {
+		return nil, fmt.Errorf("failed to load watermark font: %w", watermarkFontErr)
+	}
+
+	if height < 12 {
+		height = 12
+	}
+	face, err := opentype.NewFace(watermarkFont, &opentype.FaceOptions{
+		Size:    float64(height) * 0.75,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watermark font face: %w", err)
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	outline := max(1, height/16)
+	width := font.MeasureString(face, text).Ceil() + 2*outline
+	rect := image.Rect(0, 0, width, (metrics.Ascent+metrics.Descent).Ceil()+2*outline)
+	img := image.NewRGBA(rect)
+
+	baseline := metrics.Ascent.Ceil() + outline
+	drawer := &font.Drawer{Dst: img, Face: face}
+	drawer.Src = image.NewUniform(color.RGBA{A: 160})
+	for _, offset := range []image.Point{{-outline, 0}, {outline, 0}, {0, -outline}, {0, outline}} {
+		drawer.Dot = fixed.P(outline+offset.X, baseline+offset.Y)
+		drawer.DrawString(text)
+	}
+	drawer.Src = image.White
+	drawer.Dot = fixed.P(outline, baseline)
+	drawer.DrawString(text)
+	return img, nil
+}
+
+// dbPlanFeatureStore implements PlanFeatureStore on top of user_plans and payment_plans
+type dbPlanFeatureStore struct {
+	db *sql.DB
+}
+
+// NewDBPlanFeatureStore creates a new database-backed plan feature store
+func NewDBPlanFeatureStore(db *sql.DB) PlanFeatureStore {
+	return &dbPlanFeatureStore{db: db}
+}
+
+// HasPlanFeature reports whether any active plan of the user lists feature.
+// Plans are matched by name like the quota store does.
+func (s *dbPlanFeatureStore) HasPlanFeature(ctx context.Context, userID, feature string) (bool, error) {
+	var has bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM user_plans up
+			JOIN payment_plans pp ON pp.name = up.plan_name
+			WHERE up.user_id = $1 AND up.status = 'active'
+			  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+			  AND $2 = ANY(pp.features)
+		)`, userID, feature).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("failed to check plan feature: %w", err)
+	}
+	return has, nil
+}
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+type fakePlanFeatureStore struct {
+	features map[string]bool
+	err      error
+}
+
+func (s *fakePlanFeatureStore) HasPlanFeature(ctx context.Context, userID, feature string) (bool, error) {
+	return s.features[userID+"/"+feature], s.err
+}
+
+func TestWatermarker_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.RGBA{R: 40, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := buf.Bytes()
+
+	logo := image.NewRGBA(image.Rect(0, 0, 50, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 50; x++ {
+			logo.Set(x, y, color.White)
+		}
+	}
+
+	features := &fakePlanFeatureStore{features: map[string]bool{"paid/" + PlanFeatureWatermarkRemoval: true}}
+
+	t.Run("free user gets logo in configured corner", func(t *testing.T) {
+		watermarker := NewWatermarker(logo, features)
+		out, applied, err := watermarker.Apply(ctx, "free", data)
+		if err != nil || !applied {
+			t.Fatalf("Expected watermark to be applied, got applied=%v err=%v", applied, err)
+		}
+		result, format, err := image.Decode(bytes.NewReader(out))
+		if err != nil || format != "png" {
+			t.Fatalf("Expected PNG output, got %q (%v)", format, err)
+		}
+		if r, _, _, _ := result.At(370, 370).RGBA(); r>>8 <= 40 {
+			t.Errorf("Expected bottom-right corner to be brightened, got red %d", r>>8)
+		}
+		if r, _, _, _ := result.At(20, 20).RGBA(); r>>8 != 40 {
+			t.Errorf("Expected top-left corner to be untouched, got red %d", r>>8)
+		}
+	})
+
+	t.Run("text watermark", func(t *testing.T) {
+		watermarker := NewWatermarker(nil, features)
+		_, applied, err := watermarker.Apply(ctx, "free", data)
+		if err != nil || !applied {
+			t.Errorf("Expected text watermark to be applied, got applied=%v err=%v", applied, err)
+		}
+	})
+
+	skipped := []struct {
+		name     string
+		userID   string
+		features PlanFeatureStore
+		options  WatermarkOptions
+	}{
+		{"plan with watermark_removal", "paid", features, DefaultWatermarkOptions()},
+		{"disabled", "free", features, WatermarkOptions{Enabled: false, Text: "AI Styler"}},
+		{"plan lookup failure", "free", &fakePlanFeatureStore{err: errors.New("db down")}, DefaultWatermarkOptions()},
+	}
+	for _, tt := range skipped {
+		watermarker := NewWatermarker(logo, tt.features)
+		watermarker.SetOptions(tt.options)
+		out, applied, err := watermarker.Apply(ctx, tt.userID, data)
+		if err != nil || applied || !bytes.Equal(out, data) {
+			t.Errorf("%s: expected image to be unchanged, got applied=%v err=%v", tt.name, applied, err)
+		}
+	}
+}
+
+func TestWatermarkRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 500)
+	size := image.Pt(100, 50)
+
+	tests := []struct {
+		position string
+		want     image.Point
+	}{
+		{WatermarkTopLeft, image.Pt(15, 15)},
+		{WatermarkTopRight, image.Pt(885, 15)},
+		{WatermarkBottomLeft, image.Pt(15, 435)},
+		{WatermarkBottomRight, image.Pt(885, 435)},
+		{WatermarkCenter, image.Pt(450, 225)},
+		{"unknown", image.Pt(885, 435)},
+	}
+	for _, tt := range tests {
+		got := watermarkRect(bounds, size, tt.position)
+		if got.Min != tt.want || got.Size() != size {
+			t.Errorf("%s: expected origin %v, got %v", tt.position, tt.want, got)
+		}
+	}
+}
@@ -2,12 +2,15 @@ package worker
 
 import (
 	"database/sql"
+	"log"
 	"time"
 
+	"ai-styler/internal/common"
 	"ai-styler/internal/config"
 	"ai-styler/internal/conversion"
 	"ai-styler/internal/image"
 	"ai-styler/internal/notification"
+	"ai-styler/internal/quota"
 	"ai-styler/internal/storage"
 )
 
@@ -24,20 +27,42 @@ func WireWorkerService(db *sql.DB, cfg *config.Config) (*Service, *Handler) {
 		HealthCheckPort:   8082,
 		EnableMetrics:     true,
 		EnableHealthCheck: true,
+		DrainTimeout:      cfg.WorkerQueue.DrainTimeout,
 	}
 
-	// Create job queue
-	jobQueue := NewDBJobQueue(db)
+	// Create job queue, aging pending jobs so free users aren't starved
+	jobQueue := NewDBJobQueueWithAging(db, QueueAging{
+		Interval: cfg.WorkerQueue.AgingInterval,
+		MaxBoost: cfg.WorkerQueue.AgingMaxBoost,
+	})
 
 	// Create image processor
 	imageProcessor := image.NewImageProcessor()
 
 	// Create file storage using config
 	backupPath := cfg.Storage.StoragePath + "/backup"
+	signing, err := storage.SigningSettingsFromConfig(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
 	fileStorage, err := storage.NewStorageService(storage.StorageConfig{
-		BasePath:     cfg.Storage.StoragePath,
-		BackupPath:   backupPath,
-		SignedURLKey: "default-key-change-in-production", // TODO: Move to config
+		BasePath:   cfg.Storage.StoragePath,
+		BackupPath: backupPath,
+		Signing:    signing,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Read input images directly from storage rather than over HTTP
+	objectReader, err := storage.NewObjectReader(storage.ObjectStoreConfig{
+		Backend:     cfg.Storage.Backend,
+		BasePath:    cfg.Storage.StoragePath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3Region:    cfg.Storage.S3Region,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
 	})
 	if err != nil {
 		panic(err)
@@ -56,11 +81,16 @@ func WireWorkerService(db *sql.DB, cfg *config.Config) (*Service, *Handler) {
 		Timeout:              cfg.Gemini.Timeout,
 		PreprocessNoiseLevel: cfg.Gemini.PreprocessNoiseLevel,
 		PreprocessJpegQuality: cfg.Gemini.PreprocessJpegQuality,
+		RetryBaseDelayMs:        cfg.Gemini.RetryBaseDelayMs,
+		RetryMaxDelayMs:         cfg.Gemini.RetryMaxDelayMs,
+		AttemptTimeout:          cfg.Gemini.AttemptTimeout,
+		BreakerFailureThreshold: cfg.Gemini.BreakerFailureThreshold,
+		BreakerOpenTimeout:      cfg.Gemini.BreakerOpenTimeout,
 	}
 	geminiAPI := NewGeminiClient(geminiConfig)
 
 	// Create notification service
-	notifier, _ := notification.WireNotificationService(db)
+	notifier, _ := notification.WireNotificationService(db, cfg)
 
 	// Create metrics collector
 	metricsCollector := NewMetricsCollector()
@@ -86,6 +116,146 @@ func WireWorkerService(db *sql.DB, cfg *config.Config) (*Service, *Handler) {
 		retryHandler,
 	)
 
+	// Wire provider budget guardrails when a monthly cap is configured
+	if cfg.Gemini.MonthlyBudget > 0 {
+		budgetConfig := BudgetConfig{
+			Provider:             "gemini:" + cfg.Gemini.Model,
+			MonthlyBudget:        cfg.Gemini.MonthlyBudget,
+			CostPerConversion:    cfg.Gemini.CostPerConversion,
+			AlertThreshold:       cfg.Gemini.BudgetAlertThreshold,
+			DegradeModes:         ParseBudgetDegradeModes(cfg.Gemini.BudgetDegradeMode),
+			LowResolutionMaxSide: cfg.Gemini.BudgetLowResMaxSide,
+		}
+
+		var fallbackAPI GeminiAPI
+		if cfg.Gemini.FallbackModel != "" && cfg.Gemini.FallbackModel != cfg.Gemini.Model {
+			fallbackConfig := *geminiConfig
+			fallbackConfig.Model = cfg.Gemini.FallbackModel
+			fallbackAPI = NewGeminiClient(&fallbackConfig)
+			budgetConfig.FallbackProvider = "gemini:" + cfg.Gemini.FallbackModel
+			budgetConfig.FallbackCostPerConversion = cfg.Gemini.FallbackCostPerConversion
+		}
+
+		var alerter BudgetAlerter
+		if notifier != nil {
+			alerter = notifier
+		}
+		service.SetBudgetGuard(NewBudgetGuard(budgetConfig, NewDBBudgetStore(db), alerter), fallbackAPI)
+	}
+
+	// Candidate model for the users the new_provider feature flag selects
+	if cfg.Gemini.CandidateModel != "" && cfg.Gemini.CandidateModel != cfg.Gemini.Model {
+		candidateConfig := *geminiConfig
+		candidateConfig.Model = cfg.Gemini.CandidateModel
+		service.SetCandidateProvider(NewGeminiClient(&candidateConfig), "gemini:"+cfg.Gemini.CandidateModel)
+	}
+
+	// Record what every conversion cost the provider
+	var costAlerter BudgetAlerter
+	if notifier != nil {
+		costAlerter = notifier
+	}
+	service.SetCostTracker(NewCostTracker(CostConfig{
+		InputTokenPrice:  cfg.Gemini.InputTokenPrice,
+		OutputTokenPrice: cfg.Gemini.OutputTokenPrice,
+		ImagePrice:       cfg.Gemini.ImagePrice,
+		FlatCost:         cfg.Gemini.CostPerConversion,
+		DailyBudget:      cfg.Gemini.DailyBudget,
+	}, NewDBCostStore(db), costAlerter))
+
+	// Wire the image moderation stage
+	if cfg.Moderation.Enabled {
+		moderationConfig := ModerationConfig{
+			Enabled:       cfg.Moderation.Enabled,
+			Provider:      cfg.Moderation.Provider,
+			APIURL:        cfg.Moderation.APIURL,
+			APIKey:        cfg.Moderation.APIKey,
+			Timeout:       cfg.Moderation.Timeout,
+			NSFWThreshold: cfg.Moderation.NSFWThreshold,
+			RequireFace:   cfg.Moderation.RequireFace,
+			MaxFaces:      cfg.Moderation.MaxFaces,
+			FailOpen:      cfg.Moderation.FailOpen,
+		}
+		detector, err := NewModerationDetector(moderationConfig)
+		if err != nil {
+			panic(err)
+		}
+		service.SetModeration(NewModerationPipeline(moderationConfig, detector, NewDBModerationStore(db)))
+	}
+
+	// Wire the input pre-check stage
+	if cfg.InputCheck.Enabled {
+		inputCheckConfig := InputCheckConfig{
+			Enabled:         cfg.InputCheck.Enabled,
+			Provider:        cfg.InputCheck.Provider,
+			APIURL:          cfg.InputCheck.APIURL,
+			APIKey:          cfg.InputCheck.APIKey,
+			Timeout:         cfg.InputCheck.Timeout,
+			MinUserSide:     cfg.InputCheck.MinUserSide,
+			MinGarmentSide:  cfg.InputCheck.MinGarmentSide,
+			MinPersonRatio:  cfg.InputCheck.MinPersonRatio,
+			MinGarmentRatio: cfg.InputCheck.MinGarmentRatio,
+			MaxPeople:       cfg.InputCheck.MaxPeople,
+			FailOpen:        cfg.InputCheck.FailOpen,
+		}
+		detector, err := NewInputDetector(inputCheckConfig)
+		if err != nil {
+			panic(err)
+		}
+		service.SetInputChecker(NewInputChecker(inputCheckConfig, detector))
+	}
+
+	service.SetObjectReader(objectReader, cfg.Storage.StoragePath)
+
+	// Generate WebP/AVIF and responsive widths of result images
+	if cfg.Storage.ImageVariantsEnabled {
+		variantConfig, encoders := storage.VariantSettingsFromConfig(cfg.Storage)
+		pipeline := storage.NewVariantPipeline(cfg.Storage.StoragePath, variantConfig, storage.NewVariantRepository(db), encoders...)
+		if cdn, err := storage.CDNFromConfig(cfg.Storage); err != nil {
+			log.Printf("CDN purging of image variants disabled: %v", err)
+		} else if cdn != nil {
+			pipeline.SetCDN(cdn)
+		}
+		service.SetImageVariants(pipeline)
+	}
+
+	// Watermark results of plans without watermark_removal
+	planFeatures := NewDBPlanFeatureStore(db)
+	watermarker := NewWatermarker(nil, planFeatures)
+	if cfg.Storage.WatermarkLogoPath != "" {
+		logo, err := LoadWatermarkLogo(cfg.Storage.WatermarkLogoPath)
+		if err != nil {
+			log.Printf("Watermark logo unavailable, using watermark text: %v", err)
+		} else {
+			watermarker = NewWatermarker(logo, planFeatures)
+		}
+	}
+	service.SetWatermarker(watermarker)
+
+	// Persist per-conversion logs for the admin conversion detail
+	if cfg.ConversionLog.Enabled {
+		service.SetConversionLogs(NewDBConversionLogStore(db, cfg.ConversionLog.MaxPerConversion), cfg.ConversionLog.Retention)
+	}
+
+	// Record worker heartbeats for the admin worker status
+	service.SetIntrospection(NewDBIntrospectionStore(db), cfg.WorkerQueue.HeartbeatInterval)
+
+	// Publish conversion notifications through the transactional outbox
+	if cfg.Outbox.Enabled {
+		service.SetEventStore(conversion.NewEventStore(db))
+	}
+
+	// Finish jobs and their conversions in one transaction
+	service.SetUnitOfWork(common.NewUnitOfWork(db))
+
+	// Refund the quota of conversions the provider failed
+	if cfg.Quota.Enabled {
+		service.SetQuotaRefunds(quota.NewService(quota.NewStore(db)))
+	}
+
+	// Report conversion progress to the conversion API and the bot
+	service.SetProgressStore(conversion.NewProgressStore(db))
+
 	// Create handler
 	handler := NewHandler(service)
 
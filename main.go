@@ -5,34 +5,49 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"ai-styler/internal/activity"
 	"ai-styler/internal/admin"
+	"ai-styler/internal/apikey"
 	"ai-styler/internal/auth"
+	"ai-styler/internal/cache"
+	"ai-styler/internal/common"
 	"ai-styler/internal/config"
 	"ai-styler/internal/conversion"
+	"ai-styler/internal/flags"
+	"ai-styler/internal/grpcapi"
 	"ai-styler/internal/image"
+	"ai-styler/internal/ipfilter"
 	"ai-styler/internal/logging"
 	"ai-styler/internal/migration"
 	"ai-styler/internal/monitoring"
 	"ai-styler/internal/notification"
+	"ai-styler/internal/outbox"
 	"ai-styler/internal/payment"
+	"ai-styler/internal/prompt"
+	"ai-styler/internal/reputation"
 	"ai-styler/internal/route"
 	"ai-styler/internal/security"
+	"ai-styler/internal/settings"
 	"ai-styler/internal/share"
 	"ai-styler/internal/sms"
 	"ai-styler/internal/storage"
+	"ai-styler/internal/support"
 	"ai-styler/internal/user"
 	"ai-styler/internal/vendors"
+	"ai-styler/internal/verification"
 	"ai-styler/internal/worker"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
 )
 
 // SimpleLogger implements the storage.Logger interface
@@ -69,16 +84,47 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration, fix these environment variables:\n%v", err)
+	}
+	log.Printf("Effective configuration:\n%s", cfg.Summary())
+
+	// Keep phone numbers, tokens and OTP codes out of plain log output too
+	log.SetOutput(logging.NewRedactingWriter(os.Stderr, logging.NewRedactor(cfg.Monitoring.LogRedactFields)))
 
 	// Initialize logger (needed for migration logs)
 	logger := logging.NewStructuredLogger(logging.LoggerConfig{
-		Level:  logging.ParseLogLevel(cfg.Monitoring.LogLevel),
-		Format: "json",
+		Level:        logging.ParseLogLevel(cfg.Monitoring.LogLevel),
+		Format:       "json",
+		RedactFields: cfg.Monitoring.LogRedactFields,
 	})
 	logger.Info(context.Background(), "Starting AI Styler backend service", nil)
 
+	// Initialize OpenTelemetry tracing before any instrumented client is created
+	shutdownTracing, err := monitoring.InitTracing(context.Background(), monitoring.OTelConfig{
+		Enabled:     cfg.Monitoring.TracingEnabled,
+		Endpoint:    cfg.Monitoring.OTLPEndpoint,
+		Insecure:    cfg.Monitoring.OTLPInsecure,
+		SampleRatio: cfg.Monitoring.TracingSampleRatio,
+		ServiceName: cfg.Monitoring.TracingServiceName,
+		Environment: cfg.Monitoring.Environment,
+		Version:     cfg.Monitoring.Version,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialize tracing: %v", err)
+	}
+
+	// Per-statement query latency, exported by the monitoring service
+	var queryMetrics *monitoring.QueryMetrics
+	if cfg.Database.QueryMetricsEnabled {
+		queryMetrics = monitoring.NewQueryMetrics(monitoring.QueryMetricsConfig{
+			SlowThreshold: cfg.Database.SlowQueryThreshold,
+			MaxStatements: cfg.Database.QueryMetricsMaxStatements,
+		})
+	}
+
 	// Initialize database connection
-	db, err := initDatabase(cfg)
+	db, err := initDatabase(cfg, queryMetrics)
 	if err != nil {
 		log.Fatalf("failed to initialize database: %v", err)
 	}
@@ -86,8 +132,19 @@ func main() {
 
 	// Run database migrations if enabled
 	if cfg.Database.AutoMigrate {
-		logger.Info(context.Background(), "Running database migrations...", nil)
-		if err := migration.RunMigrations(db, cfg.Database.MigrationsDir); err != nil {
+		logger.Info(context.Background(), "Running database migrations...", map[string]interface{}{
+			"phase": cfg.Database.MigrationPhase,
+		})
+		phase, err := migration.ParsePhase(cfg.Database.MigrationPhase)
+		if err != nil {
+			log.Fatalf("invalid migration phase: %v", err)
+		}
+		migrationOpts := migration.Options{
+			Phase:            phase,
+			LockTimeout:      cfg.Database.MigrationLockTimeout,
+			StatementTimeout: cfg.Database.MigrationStatementTimeout,
+		}
+		if err := migration.RunMigrationsWithOptions(db, cfg.Database.MigrationsDir, migrationOpts); err != nil {
 			log.Fatalf("failed to run database migrations: %v", err)
 		}
 		logger.Info(context.Background(), "Database migrations completed", nil)
@@ -103,6 +160,22 @@ func main() {
 		defer redisClient.Close()
 	}
 
+	// Plan, quota status and vendor profile lookups are cached per request and,
+	// with Redis, across instances. Services take the cache when created, so it
+	// is set before any of them.
+	if cfg.LookupCache.Enabled {
+		var shared cache.Cache
+		if redisClient != nil {
+			shared = cache.NewRedisCache(redisClient)
+		}
+		cache.SetDefault(cache.NewTwoTier(shared, map[string]time.Duration{
+			cache.EntityUserPlan:            cfg.LookupCache.UserPlanTTL,
+			cache.EntityQuotaStatus:         cfg.LookupCache.QuotaStatusTTL,
+			cache.EntityVendorProfile:       cfg.LookupCache.VendorProfileTTL,
+			cache.EntityUnreadNotifications: cfg.LookupCache.UnreadNotificationsTTL,
+		}))
+	}
+
 	// Initialize monitoring service
 	monitorConfig := monitoring.MonitoringConfig{
 		Sentry: monitoring.SentryConfig{
@@ -114,6 +187,7 @@ func main() {
 			TracesSampleRate: 0.1,
 			AttachStacktrace: true,
 			MaxBreadcrumbs:   50,
+			RedactFields:     cfg.Monitoring.LogRedactFields,
 		},
 		Telegram: monitoring.TelegramConfig{
 			BotToken: cfg.Monitoring.TelegramBotToken,
@@ -128,10 +202,23 @@ func main() {
 			Service:     "ai-stayler",
 			Version:     cfg.Monitoring.Version,
 			Environment: cfg.Monitoring.Environment,
+
+			RedactFields: cfg.Monitoring.LogRedactFields,
 		},
 		Health: monitoring.HealthConfig{
 			Enabled:       cfg.Monitoring.HealthEnabled,
 			CheckInterval: 30 * time.Second,
+			Timeout:       cfg.Monitoring.HealthCheckTimeout,
+		},
+		AuditForward: monitoring.AuditForwardConfig{
+			Enabled:       cfg.Monitoring.AuditForwardEnabled,
+			Target:        cfg.Monitoring.AuditForwardTarget,
+			SyslogNetwork: cfg.Monitoring.AuditForwardSyslogNetwork,
+			SyslogAddress: cfg.Monitoring.AuditForwardSyslogAddress,
+			HTTPURL:       cfg.Monitoring.AuditForwardHTTPURL,
+			HTTPToken:     cfg.Monitoring.AuditForwardHTTPToken,
+			BatchSize:     cfg.Monitoring.AuditForwardBatchSize,
+			Interval:      cfg.Monitoring.AuditForwardInterval,
 			Timeout:       10 * time.Second,
 		},
 	}
@@ -141,6 +228,9 @@ func main() {
 		log.Fatalf("failed to initialize monitoring service: %v", err)
 	}
 	defer monitor.Close()
+	if queryMetrics != nil {
+		monitor.SetQueryMetrics(queryMetrics)
+	}
 
 	// Initialize storage
 	storageLogger := &SimpleLogger{}
@@ -166,44 +256,505 @@ func main() {
 		refreshTTL = 90 * 24 * time.Hour // Default: 90 days
 	}
 	productionTokenService := auth.NewProductionTokenService(jwtSigner, sessionStore, accessTTL, refreshTTL)
+
+	// Rotating signing keys shared by all instances; tokens from before
+	// rotation was enabled are still verified with the secret
+	keyRotationCtx, stopKeyRotation := context.WithCancel(context.Background())
+	defer stopKeyRotation()
+	if cfg.JWT.KeyRotationEnabled {
+		err := jwtSigner.SetKeyRotation(keyRotationCtx, auth.NewPostgresJWTKeyStore(db), security.JWTKeyConfig{
+			Algorithm:        cfg.JWT.Algorithm,
+			RotationInterval: cfg.JWT.KeyRotationInterval,
+			VerifyFor:        max(accessTTL, refreshTTL, cfg.JWT.ImpersonationTTL),
+			RefreshInterval:  cfg.JWT.KeyRefreshInterval,
+		})
+		if err != nil {
+			log.Printf("JWT key rotation disabled: %v", err)
+		} else {
+			go jwtSigner.StartKeyRotation(keyRotationCtx)
+		}
+	}
 	tokenService := auth.NewTokenServiceAdapter(productionTokenService)
 
 	// Initialize SMS provider from configuration
-	smsProvider := sms.NewProviderWithParameter(cfg.SMS.Provider, cfg.SMS.APIKey, cfg.SMS.TemplateID, cfg.SMS.ParameterName)
+	smsProvider := sms.WireProvider(cfg, db)
 
 	// Initialize services with dependencies
-	authHandler := auth.NewHandler(authStore, tokenService, rateLimiter, smsProvider)
+	authHandler := auth.NewHandlerWithConfig(cfg, authStore, tokenService, rateLimiter, smsProvider)
+	authHandler.SetJWKS(jwtSigner)
+
+	// Onboarding: signup credits and first-conversion fast lane
+	onboarding := conversion.NewOnboarding(conversion.OnboardingConfig{
+		Enabled:                 cfg.Onboarding.Enabled,
+		SignupCredits:           cfg.Onboarding.SignupCredits,
+		FirstConversionFree:     cfg.Onboarding.FirstConversionFree,
+		FirstConversionPriority: cfg.Onboarding.FirstConversionPriority,
+	}, conversion.NewDBOnboardingStore(db))
+	authHandler.SetOnboardingGranter(onboarding)
+	authHandler.SetSessionManager(productionTokenService)
+	authHandler.SetTwoFactor(auth.NewPostgresTwoFactorStore(db), productionTokenService, auth.TwoFactorConfig{
+		Issuer:   cfg.Security.TOTPIssuer,
+		Required: cfg.Security.AdminTwoFactorRequired,
+	})
+	authHandler.SetTelegramLink(auth.NewPostgresTelegramLinkStore(db), auth.TelegramLinkConfig{
+		BotAPIKey:   cfg.Security.TelegramBotAPIKey,
+		BotUsername: cfg.Security.TelegramBotUsername,
+		CodeTTL:     cfg.Security.TelegramLinkCodeTTL,
+	})
 
 	// Initialize all services
-	_, userHandler := user.WireUserService(db)
+	userService, userHandler := user.WireUserService(db)
 	_, vendorHandler := vendors.WireVendorService(db)
-	_, conversionHandler := conversion.WireConversionService(db)
-	_, imageHandler := image.WireImageService(db)
+	conversionService, conversionHandler := conversion.WireConversionService(db)
+	conversionService.SetOnboarding(onboarding)
+	conversionService.SetPlanPriority(conversion.NewDBPlanPriorityStore(db))
+	conversionService.SetPlanOutputLimits(conversion.NewDBPlanOutputStore(db))
+	// Charge conversions, retries and outfit garments to the row-locked quota
+	conversionQuota := route.WireConversionQuota(cfg, db, conversionService, onboarding)
+	conversionService.SetFeedback(conversion.NewDBFeedbackStore(db))
+	conversionService.SetPresets(conversion.NewDBPresetStore(db))
+	imageService, imageHandler := image.WireImageService(db, cfg)
 	paymentService, _ := payment.WirePaymentService(db)
+	paymentService.SetPlanChanges(payment.NewDBPlanChangeStore(db))
+	paymentService.SetCoupons(payment.NewDBCouponStore(db))
 	// Create BazaarPay service and update handler
 	bazaarPayService := payment.NewBazaarPayService(db)
 	paymentHandler := payment.NewHandlerWithBazaarPay(paymentService, bazaarPayService)
 	_, shareHandler := share.WireShareService(db)
-	_, adminHandler := admin.WireAdminService(db)
-	_, notificationHandler := notification.WireNotificationService(db)
+	shareHandler.SetPublicBaseURL(cfg.Server.PublicBaseURL)
+	// Heavy admin lists and reports read from replicas when configured
+	dbRouter, replicas, err := initReadReplicas(cfg, db, queryMetrics)
+	if err != nil {
+		log.Fatalf("failed to initialize read replicas: %v", err)
+	}
+	for _, replica := range replicas {
+		defer replica.Close()
+	}
+	replicaCtx, stopReplicaChecks := context.WithCancel(context.Background())
+	defer stopReplicaChecks()
+	go dbRouter.Run(replicaCtx, cfg.Database.ReplicaCheckInterval)
+	adminService, adminHandler := admin.WireAdminServiceWithReplicas(dbRouter)
+	adminService.SetImpersonationIssuer(productionTokenService, cfg.JWT.ImpersonationTTL)
+	adminService.SetRoles(admin.NewDBStore(db), admin.RBACConfig{
+		DefaultRole: cfg.Security.AdminDefaultRole,
+		CacheTTL:    cfg.Security.AdminPermissionCacheTTL,
+	})
+	notificationService, notificationHandler := notification.WireNotificationService(db, cfg)
+
+	// Delivery reports update OTP deliveries first, then notification deliveries
+	smsWebhookHandler := sms.WireWebhookHandler(cfg, db, notificationService)
+
+	// Low-priority notifications of users who chose a digest are sent in batches
+	digestCtx, stopDigests := context.WithCancel(context.Background())
+	defer stopDigests()
+	if cfg.Digest.Enabled {
+		notificationService.SetDigests(notification.NewDigestStore(db), cfg.Digest.BatchSize)
+		go notificationService.StartDigestDispatcher(digestCtx, cfg.Digest.Interval)
+	}
+
+	// Email, SMS and other channel deliveries go through bounded queues,
+	// throttled per provider, instead of a goroutine each
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	dispatcherDone := make(chan struct{})
+	if cfg.NotifyQueue.Enabled {
+		notificationService.SetDispatcher(notification.NewDispatcherConfig(cfg.NotifyQueue))
+		go func() {
+			notificationService.StartDispatcher(dispatcherCtx)
+			close(dispatcherDone)
+		}()
+	} else {
+		close(dispatcherDone)
+	}
+
+	// Push tokens not refreshed for months belong to uninstalled or abandoned apps
+	pushCtx, stopPushPruner := context.WithCancel(context.Background())
+	defer stopPushPruner()
+	go notificationService.StartDevicePruner(pushCtx, cfg.Push.PruneInterval, cfg.Push.DeviceStaleAfter)
+
+	// ZIP exports of conversion results are built in the background and
+	// announced with a signed download link
+	exportCtx, stopExports := context.WithCancel(context.Background())
+	defer stopExports()
+	if cfg.ConversionExport.Enabled {
+		exportStorage, err := conversion.WireExportStorage(cfg)
+		if err != nil {
+			log.Printf("conversion exports disabled: %v", err)
+		} else {
+			conversionService.SetExports(conversion.NewDBExportStore(db), exportStorage, notificationService, conversion.ExportConfig{
+				DownloadURL:    cfg.ConversionExport.DownloadURL,
+				Retention:      cfg.ConversionExport.Retention,
+				MaxConversions: cfg.ConversionExport.MaxConversions,
+				MaxSize:        int64(cfg.ConversionExport.MaxSizeMB) << 20,
+				PollInterval:   cfg.ConversionExport.PollInterval,
+			})
+			go conversionService.StartExportWorker(exportCtx)
+		}
+	}
+
+	// Conversions scheduled for later are enqueued once due
+	scheduleCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.ConversionSchedule.Enabled {
+		conversionService.SetScheduling(conversion.NewDBScheduleStore(db), conversion.ScheduleConfig{
+			MaxAhead:     cfg.ConversionSchedule.MaxAhead,
+			PollInterval: cfg.ConversionSchedule.PollInterval,
+			BatchSize:    cfg.ConversionSchedule.BatchSize,
+		})
+		go conversionService.StartScheduler(scheduleCtx)
+	}
+
+	// Takeout copies of a user's account data, delivered the same way
+	if cfg.UserDataExport.Enabled {
+		exportStorage, err := conversion.WireExportStorage(cfg)
+		if err != nil {
+			log.Printf("user data exports disabled: %v", err)
+		} else {
+			userService.SetDataExports(user.NewDBDataExportStore(db), exportStorage, notificationService, user.DataExportConfig{
+				DownloadURL:  cfg.UserDataExport.DownloadURL,
+				Retention:    cfg.UserDataExport.Retention,
+				PollInterval: cfg.UserDataExport.PollInterval,
+			})
+			go userService.StartDataExportWorker(exportCtx)
+		}
+	}
+
+	// Plan trials end on their own; expired ones fall back to the default plan
+	trialCtx, stopTrialExpiry := context.WithCancel(context.Background())
+	defer stopTrialExpiry()
+	if cfg.PlanTrial.Enabled {
+		paymentService.SetTrials(payment.NewDBTrialStore(db), notificationService, payment.TrialConfig{
+			DefaultPlan: cfg.PlanTrial.DefaultPlan,
+			Interval:    cfg.PlanTrial.ExpiryInterval,
+			BatchSize:   cfg.PlanTrial.BatchSize,
+		})
+		go paymentService.StartTrialExpiry(trialCtx)
+	}
+
+	// Coupons held by payments abandoned at the gateway are given back
+	couponCtx, stopCouponExpiry := context.WithCancel(context.Background())
+	defer stopCouponExpiry()
+	go paymentService.StartCouponExpiry(couponCtx, cfg.CouponExpiry.Interval, cfg.CouponExpiry.BatchSize)
+
+	// Monthly spending caps, set by users or enforced by admins
+	paymentService.SetSpendingLimits(payment.NewDBSpendingLimitStore(db), notificationService)
+
+	// API keys for B2B integrations, rate limited per key across instances when Redis is available
+	var apiKeyLimiter apikey.RateLimiter = rateLimiter
+	if redisClient != nil {
+		apiKeyLimiter = security.NewRedisRateLimiter(redisClient)
+	}
+	_, apiKeyHandler := apikey.WireAPIKeyService(db, cfg, apiKeyLimiter)
+
+	// Transactional outbox: conversion events are committed together with the
+	// conversion and published by the dispatcher
+	var outboxDispatcher *outbox.Dispatcher
+	if cfg.Outbox.Enabled {
+		conversionService.SetEventStore(conversion.NewEventStore(db))
+		outboxDispatcher = outbox.NewDispatcher(outbox.Config{
+			Enabled:       cfg.Outbox.Enabled,
+			PollInterval:  cfg.Outbox.PollInterval,
+			BatchSize:     cfg.Outbox.BatchSize,
+			MaxAttempts:   cfg.Outbox.MaxAttempts,
+			RetryDelay:    cfg.Outbox.RetryDelay,
+			MaxRetryDelay: cfg.Outbox.MaxRetryDelay,
+		}, outbox.NewStore(db),
+			outbox.NewNotificationPublisher(notificationService),
+			outbox.NewWebhookPublisher(outbox.ParseWebhookURLs(cfg.Outbox.WebhookURLs), cfg.Outbox.WebhookSecret, cfg.Outbox.WebhookTimeout),
+			apikey.NewWebhookPublisher(apikey.NewDBStore(db), cfg.Outbox.WebhookTimeout),
+		)
+		outboxDispatcher.Start()
+	}
+
+	// Deleted vendor images stay restorable in the trash until purged
+	trashCtx, stopTrashPurger := context.WithCancel(context.Background())
+	defer stopTrashPurger()
+	if cfg.ImageTrash.Enabled {
+		imageService.SetTrash(image.NewDBStore(db), cfg.ImageTrash.RestoreWindow)
+		go imageService.StartTrashPurger(trashCtx, cfg.ImageTrash.PurgeInterval)
+	}
+	if cfg.ImageDedup.Enabled {
+		imageService.SetDedup(image.NewDBStore(db))
+	}
+
+	// Clients may upload files straight to storage and confirm them afterwards
+	uploadPurgeCtx, stopUploadPurger := context.WithCancel(context.Background())
+	defer stopUploadPurger()
+	if cfg.ImageUpload.DirectEnabled {
+		directUploads, err := image.WireDirectUploads(cfg)
+		if err != nil {
+			log.Printf("direct image uploads disabled: %v", err)
+		} else {
+			imageService.SetDirectUploads(image.NewDBStore(db), directUploads, cfg.ImageUpload.URLTTL)
+			go imageService.StartUploadPurger(uploadPurgeCtx, cfg.ImageUpload.PurgeInterval)
+		}
+	}
+
+	// Vendors import images in bulk from archives or URLs in the background
+	importCtx, stopImports := context.WithCancel(context.Background())
+	defer stopImports()
+	if cfg.ImageImport.Enabled {
+		imageService.SetImports(image.NewDBStore(db), notificationService, image.ImportConfig{
+			MaxFiles:       cfg.ImageImport.MaxFiles,
+			MaxArchiveSize: int64(cfg.ImageImport.MaxArchiveSizeMB) << 20,
+			FetchTimeout:   cfg.ImageImport.FetchTimeout,
+			PollInterval:   cfg.ImageImport.PollInterval,
+		})
+		go imageService.StartImportWorker(importCtx)
+	}
+
+	// Recurring jobs run on their cron schedules, each scheduled run on one
+	// instance at a time when Redis is available
+	var jobLocker worker.JobLocker
+	if redisClient != nil {
+		jobLocker = worker.NewRedisJobLocker(redisClient)
+	} else {
+		log.Printf("Redis unavailable, scheduled jobs run on every instance")
+	}
+	scheduler := worker.NewScheduler(jobLocker, worker.NewDBJobRunStore(db))
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	// Files no row references any more are removed on the retention policy's
+	// cleanup schedule
+	if cfg.Storage.CleanupEnabled {
+		cleanupConfig, objectStore := storage.CleanupSettingsFromConfig(cfg.Storage)
+		var cleaner *storage.Cleaner
+		objects, err := storage.NewObjectDeleter(objectStore)
+		if err == nil {
+			cleaner, err = storage.NewCleaner(cleanupConfig, storage.NewDBCleanupStore(db), objects, monitor)
+		}
+		if err == nil {
+			err = scheduler.Register(worker.ScheduledJob{
+				Name:     "storage_cleanup",
+				Schedule: cleaner.Schedule(),
+				Run:      cleaner.RunAndLog,
+			})
+		}
+		if err != nil {
+			log.Printf("storage cleanup disabled: %v", err)
+		}
+	}
+
+	// Audit logs past the retention period are archived to object storage
+	auditRetentionCtx, stopAuditRetention := context.WithCancel(context.Background())
+	defer stopAuditRetention()
+	if cfg.Monitoring.AuditRetentionDays > 0 {
+		archiveWriter, err := storage.NewObjectWriter(storage.ObjectStoreConfig{
+			Backend:     cfg.Storage.Backend,
+			BasePath:    cfg.Storage.StoragePath,
+			S3Endpoint:  cfg.Storage.S3Endpoint,
+			S3Bucket:    cfg.Storage.S3Bucket,
+			S3Region:    cfg.Storage.S3Region,
+			S3AccessKey: cfg.Storage.S3AccessKey,
+			S3SecretKey: cfg.Storage.S3SecretKey,
+		})
+		if err != nil {
+			log.Printf("audit log retention disabled: %v", err)
+		} else {
+			adminService.SetAuditArchive(archiveWriter, admin.AuditRetentionConfig{
+				RetentionDays:    cfg.Monitoring.AuditRetentionDays,
+				Interval:         cfg.Monitoring.AuditRetentionInterval,
+				BatchSize:        cfg.Monitoring.AuditArchiveBatchSize,
+				Prefix:           cfg.Monitoring.AuditArchivePrefix,
+				RequireForwarded: cfg.Monitoring.AuditForwardEnabled,
+			})
+			go adminService.StartAuditRetention(auditRetentionCtx)
+		}
+	}
+
+	// Payments are exported for accounting; ranges too long to stream are
+	// built in the background when object storage is available
+	paymentExportCtx, stopPaymentExports := context.WithCancel(context.Background())
+	defer stopPaymentExports()
+	paymentExportLocation, err := time.LoadLocation(cfg.PaymentExport.Timezone)
+	if err != nil {
+		log.Printf("payment exports use UTC days: %v", err)
+		paymentExportLocation = time.UTC
+	}
+	paymentExportStore := admin.NewDBStore(db)
+	paymentExportStore.SetReadReplicas(dbRouter)
+	paymentExportStorage, err := conversion.WireExportStorage(cfg)
+	if err != nil {
+		log.Printf("background payment exports disabled: %v", err)
+	}
+	adminService.SetPaymentExports(paymentExportStore, paymentExportStorage, admin.PaymentExportConfig{
+		Location:      paymentExportLocation,
+		StreamMaxDays: cfg.PaymentExport.StreamMaxDays,
+		MaxDays:       cfg.PaymentExport.MaxDays,
+		Retention:     cfg.PaymentExport.Retention,
+		PollInterval:  cfg.PaymentExport.PollInterval,
+	})
+	go adminService.StartPaymentExportWorker(paymentExportCtx)
+
+	// Key events announced by database triggers are streamed to the admin
+	// dashboard
+	activityCtx, stopActivity := context.WithCancel(context.Background())
+	defer stopActivity()
+	if cfg.AdminActivity.Enabled {
+		activityFeed := activity.NewFeed(activity.Config{
+			SpikeThreshold: cfg.AdminActivity.SpikeThreshold,
+			SpikeWindow:    cfg.AdminActivity.SpikeWindow,
+		})
+		adminService.SetActivityFeed(activityFeed)
+		go activityFeed.Run(activityCtx, activity.NewPostgresSource(databaseDSN(cfg)))
+	}
 
 	// Initialize worker service with config
 	workerService, _ := worker.WireWorkerService(db, cfg)
+	workerService.SetScheduler(scheduler)
+	go scheduler.Start(schedulerCtx)
+
+	// Readiness also probes storage writes and the AI provider, both cached
+	healthStorage, err := storage.NewObjectWriter(storage.ObjectStoreConfig{
+		Backend:     cfg.Storage.Backend,
+		BasePath:    cfg.Storage.StoragePath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3Region:    cfg.Storage.S3Region,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+	})
+	if err != nil {
+		log.Printf("storage readiness probe disabled: %v", err)
+	} else {
+		monitor.Health().AddReadinessChecker("storage", monitoring.NewCachedHealthChecker(
+			monitoring.NewStorageHealthChecker(healthStorage, "health/probe.txt"), cfg.Monitoring.HealthStorageCacheTTL))
+	}
+	monitor.Health().AddReadinessChecker("ai_provider", monitoring.NewCachedHealthChecker(
+		monitoring.NewAIProviderHealthChecker("gemini:"+cfg.Gemini.Model, workerService), cfg.Monitoring.HealthAICacheTTL))
+
+	// Admin-editable system settings, reloaded on every instance when changed
+	settingsService := settings.WireSettingsService(db, cfg)
+	if redisClient != nil {
+		settingsService.SetChangeNotifier(settings.NewRedisNotifier(redisClient))
+	}
+	if err := settingsService.Reload(context.Background()); err != nil {
+		log.Printf("failed to load system settings, using defaults: %v", err)
+	}
+	settingsCtx, stopSettingsWatcher := context.WithCancel(context.Background())
+	defer stopSettingsWatcher()
+	go settingsService.Watch(settingsCtx)
+	settingsService.OnChange(func(current settings.Settings) {
+		imageService.SetMaxFileSize(current.MaxFileSize)
+		workerService.SetConversionTimeout(current.ConversionTimeout)
+		workerService.SetWatermarkOptions(worker.WatermarkOptions{
+			Enabled:  current.WatermarkEnabled,
+			Text:     current.WatermarkText,
+			Position: current.WatermarkPosition,
+			Opacity:  current.WatermarkOpacity,
+		})
+		workerService.SetGeminiTuning(worker.GeminiTuning{
+			Model:           current.GeminiModel,
+			Temperature:     current.GeminiTemperature,
+			MaxOutputTokens: current.GeminiMaxOutputTokens,
+			NoiseLevel:      current.GeminiNoiseLevel,
+			Timeout:         current.GeminiTimeout,
+		})
+	})
+
+	// IP block-list and country restrictions, reloaded to pick up blocks
+	// added on other instances
+	ipFilterService, err := ipfilter.WireIPFilterService(db, cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize ip filter: %v", err)
+	}
+	if err := ipFilterService.Reload(context.Background()); err != nil {
+		log.Printf("failed to load ip block-list: %v", err)
+	}
+	ipFilterCtx, stopIPFilterWatcher := context.WithCancel(context.Background())
+	defer stopIPFilterWatcher()
+	go ipFilterService.Watch(ipFilterCtx)
+
+	// Feature flags, cached in Redis when available. Conversions of users the
+	// new_provider flag selects use GEMINI_CANDIDATE_MODEL.
+	flagsService := flags.WireFlagsService(db, redisClient, cfg)
+	flags.SetDefault(flagsService)
+	workerService.SetFeatureFlags(flagsService)
+
+	// Conversion prompts are versioned templates admins can A/B test
+	promptService := prompt.WirePromptService(db, cfg)
+	workerService.SetPromptTemplates(promptService)
+
+	// Support tickets; users are notified when support replies
+	supportService := support.WireSupportService(db, cfg)
+	supportService.SetNotifier(notificationService)
+
+	// Signup reputation: risky new accounts get a limited or held free quota
+	// and wait for admin review
+	var reputationService *reputation.Service
+	if cfg.Reputation.Enabled {
+		reputationService, err = reputation.WireReputationService(db, cfg)
+		if err != nil {
+			log.Fatalf("failed to load signup reputation: %v", err)
+		}
+		authHandler.SetSignupScreener(reputationService)
+
+		reputationCtx, stopReputation := context.WithCancel(context.Background())
+		defer stopReputation()
+		go reputationService.StartReleaser(reputationCtx)
+	}
+
+	// Vendor verification: vendors upload business documents for admins to
+	// review; vendors are notified of the decision
+	var verificationService *verification.Service
+	if cfg.Verification.Enabled {
+		verificationService, err = verification.WireVerificationService(db, cfg)
+		if err != nil {
+			log.Fatalf("failed to set up vendor verification: %v", err)
+		}
+		verificationService.SetNotifier(notificationService)
+	}
+
+	// Alerts for sign-ins from new devices or countries
+	if cfg.Security.LoginAlertsEnabled {
+		authHandler.SetLoginAlerts(auth.NewPostgresLoginDeviceStore(db), notificationService, auth.LoginAlertConfig{
+			RevokeURL:     cfg.Security.SessionRevokeURL,
+			RevokeSecret:  []byte(cfg.JWT.Secret),
+			RevokeLinkTTL: cfg.Security.SessionRevokeLinkTTL,
+			Countries:     ipFilterService,
+		})
+	}
+
+	// OTP resend cooldowns and lockouts, with sends and failures recorded
+	// for the security team
+	if cfg.Security.OTPMaxFailures > 0 {
+		authHandler.SetOTPGuard(auth.NewPostgresOTPGuardStore(db), notificationService, auth.OTPGuardConfig{
+			ResendCooldown:    cfg.Security.OTPResendCooldown,
+			MaxResendCooldown: cfg.Security.OTPMaxResendCooldown,
+			ResendResetAfter:  cfg.Security.OTPResendResetAfter,
+			MaxFailures:       cfg.Security.OTPMaxFailures,
+			FailureWindow:     cfg.Security.OTPFailureWindow,
+			LockoutDuration:   cfg.Security.OTPLockoutDuration,
+		})
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
 
 	// Create router with all services
 	r := route.NewWithServices(
+		cfg,
 		authHandler,
 		userHandler,
 		vendorHandler,
 		conversionHandler,
+		conversionQuota,
 		imageHandler,
 		paymentHandler,
 		shareHandler,
 		adminHandler,
 		notificationHandler,
+		apiKeyHandler,
+		settingsService,
+		ipFilterService,
+		flagsService,
+		promptService,
+		supportService,
+		reputationService,
+		verificationService,
+		workerService,
+		smsWebhookHandler,
 		monitor,
 	)
 
@@ -233,6 +784,30 @@ func main() {
 		}
 	}()
 
+	// Start the internal gRPC API for service-to-service calls (Telegram bot)
+	var internalAPI *grpc.Server
+	if cfg.InternalAPI.Enabled {
+		internalAPI, err = grpcapi.WireInternalAPI(cfg, authHandler, tokenService, conversionService, imageService)
+		if err != nil {
+			log.Fatalf("Failed to initialize internal API: %v", err)
+		}
+		listener, err := net.Listen("tcp", cfg.InternalAPI.Addr)
+		if err != nil {
+			log.Fatalf("Failed to listen for internal API: %v", err)
+		}
+
+		go func() {
+			monitor.LogInfo(context.Background(), "Internal API starting", map[string]interface{}{
+				"addr": cfg.InternalAPI.Addr,
+			})
+			if err := internalAPI.Serve(listener); err != nil {
+				monitor.LogFatal(context.Background(), "Internal API failed", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -246,6 +821,20 @@ func main() {
 		logger.Error(context.Background(), "Failed to stop worker service", map[string]interface{}{"error": err})
 	}
 
+	// Stop image trash purger
+	stopTrashPurger()
+
+	// Stop audit log retention
+	stopAuditRetention()
+
+	// Stop watching for setting changes
+	stopSettingsWatcher()
+
+	// Stop outbox dispatcher; unpublished events stay in the outbox
+	if outboxDispatcher != nil {
+		outboxDispatcher.Stop()
+	}
+
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -254,12 +843,27 @@ func main() {
 		logger.Fatal(context.Background(), "Server forced to shutdown", map[string]interface{}{"error": err})
 	}
 
+	// Let in-flight internal API calls finish
+	if internalAPI != nil {
+		internalAPI.GracefulStop()
+	}
+
+	// Stop the notification dispatcher once no request can queue more;
+	// sends in flight finish and still queued deliveries are marked failed
+	stopDispatcher()
+	<-dispatcherDone
+
+	// Flush spans still buffered by the exporter
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error(context.Background(), "Failed to flush traces", map[string]interface{}{"error": err})
+	}
+
 	logger.Info(context.Background(), "Server exited", nil)
 }
 
-// initDatabase initializes database connection
-func initDatabase(cfg *config.Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+// databaseDSN returns the connection string of the primary database
+func databaseDSN(cfg *config.Config) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Database.Host,
 		cfg.Database.Port,
 		cfg.Database.User,
@@ -267,8 +871,11 @@ func initDatabase(cfg *config.Config) (*sql.DB, error) {
 		cfg.Database.Name,
 		cfg.Database.SSLMode,
 	)
+}
 
-	db, err := sql.Open("postgres", dsn)
+// initDatabase initializes database connection
+func initDatabase(cfg *config.Config, queryMetrics *monitoring.QueryMetrics) (*sql.DB, error) {
+	db, err := openDatabase(cfg, databaseDSN(cfg), queryMetrics)
 	if err != nil {
 		return nil, err
 	}
@@ -286,6 +893,36 @@ func initDatabase(cfg *config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// openDatabase opens a connection pool, traced and recording query metrics
+// when they are enabled
+func openDatabase(cfg *config.Config, dsn string, queryMetrics *monitoring.QueryMetrics) (*sql.DB, error) {
+	if cfg.Monitoring.TracingEnabled || queryMetrics != nil {
+		return monitoring.OpenInstrumentedDB("postgres", dsn, queryMetrics)
+	}
+	return sql.Open("postgres", dsn)
+}
+
+// initReadReplicas opens the configured read replicas and returns a router
+// over them and the primary. Replicas are not pinged here, the router starts
+// using them once its first health check succeeds.
+func initReadReplicas(cfg *config.Config, primary *sql.DB, queryMetrics *monitoring.QueryMetrics) (*common.DBRouter, []*sql.DB, error) {
+	var replicas []*sql.DB
+	for _, dsn := range cfg.Database.ReadReplicaDSNs {
+		replica, err := openDatabase(cfg, dsn, queryMetrics)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, nil, err
+		}
+		replica.SetMaxOpenConns(10)
+		replica.SetMaxIdleConns(2)
+		replica.SetConnMaxLifetime(5 * time.Minute)
+		replicas = append(replicas, replica)
+	}
+	return common.NewDBRouter(primary, replicas, cfg.Database.ReplicaMaxLag), replicas, nil
+}
+
 // initRedis initializes Redis connection
 func initRedis(cfg *config.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
@@ -293,6 +930,9 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
 	})
+	if cfg.Monitoring.TracingEnabled {
+		client.AddHook(monitoring.NewRedisTracingHook())
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
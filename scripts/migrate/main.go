@@ -2,25 +2,47 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
+	"strconv"
 
 	"ai-styler/internal/config"
+	"ai-styler/internal/migration"
 
 	_ "github.com/lib/pq"
 )
 
+const migrationsDir = "db/migrations"
+
+const usage = "Usage: go run main.go [up|down [N]|to VERSION|status|plan] [--phase pre-deploy|post-deploy|all] [--dry-run]"
+
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run main.go [up|down|status]")
+		log.Fatal(usage)
 	}
 
 	command := os.Args[1]
 
+	// Phase flags apply to up and plan, dry-run to up, down and to
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	phaseFlag := flags.String("phase", "all", "deploy phase to run: pre-deploy, post-deploy or all")
+	dryRun := flags.Bool("dry-run", false, "print the SQL that would run instead of executing it")
+	flags.Parse(os.Args[2:])
+
+	// Allow flags after the positional argument, e.g. "down 2 --dry-run"
+	var arg string
+	if flags.NArg() > 0 {
+		arg = flags.Arg(0)
+		flags.Parse(flags.Args()[1:])
+	}
+
+	phase, err := migration.ParsePhase(*phaseFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -53,21 +75,45 @@ func main() {
 		log.Fatalf("Failed to create migrations table: %v", err)
 	}
 
+	opts := migration.Options{
+		Phase:            phase,
+		LockTimeout:      cfg.Database.MigrationLockTimeout,
+		StatementTimeout: cfg.Database.MigrationStatementTimeout,
+		DryRun:           *dryRun,
+	}
+
 	switch command {
 	case "up":
-		if err := runMigrations(db, "up"); err != nil {
+		if err := migration.RunMigrationsWithOptions(db, migrationsDir, opts); err != nil {
 			log.Fatalf("Failed to run migrations: %v", err)
 		}
 	case "down":
-		if err := runMigrations(db, "down"); err != nil {
+		steps := 1
+		if arg != "" {
+			if steps, err = strconv.Atoi(arg); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", arg)
+			}
+		}
+		if err := migration.Rollback(db, migrationsDir, steps, opts); err != nil {
 			log.Fatalf("Failed to rollback migrations: %v", err)
 		}
+	case "to":
+		if arg == "" {
+			log.Fatal("Usage: go run main.go to VERSION [--dry-run]")
+		}
+		if err := migration.MigrateTo(db, migrationsDir, arg, opts); err != nil {
+			log.Fatalf("Failed to migrate to %s: %v", arg, err)
+		}
 	case "status":
 		if err := showMigrationStatus(db); err != nil {
 			log.Fatalf("Failed to show migration status: %v", err)
 		}
+	case "plan":
+		if err := showMigrationPlan(db, phase, cfg); err != nil {
+			log.Fatalf("Failed to plan migrations: %v", err)
+		}
 	default:
-		log.Fatal("Invalid command. Use: up, down, or status")
+		log.Fatal("Invalid command. " + usage)
 	}
 }
 
@@ -82,119 +128,77 @@ func createMigrationsTable(db *sql.DB) error {
 	return err
 }
 
-func runMigrations(db *sql.DB, direction string) error {
-	migrationsDir := "db/migrations"
-
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+func showMigrationStatus(db *sql.DB) error {
+	migrations, err := migration.LoadMigrations(migrationsDir)
 	if err != nil {
 		return err
 	}
 
-	// Sort files by name
-	sort.Strings(files)
-
-	if direction == "down" {
-		// Reverse order for down migrations
-		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
-			files[i], files[j] = files[j], files[i]
-		}
-	}
-
-	for _, file := range files {
-		filename := filepath.Base(file)
-		version := strings.TrimSuffix(filename, ".sql")
-
-		// Check if migration is already applied
-		if direction == "up" {
-			var count int
-			err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
-			if err != nil {
-				return err
-			}
-			if count > 0 {
-				fmt.Printf("Skipping %s (already applied)\n", filename)
-				continue
-			}
-		} else {
-			// For down migrations, check if it's applied
-			var count int
-			err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
-			if err != nil {
-				return err
-			}
-			if count == 0 {
-				fmt.Printf("Skipping %s (not applied)\n", filename)
-				continue
-			}
-		}
-
-		fmt.Printf("Running %s migration: %s\n", direction, filename)
+	fmt.Println("Migration Status:")
+	fmt.Println("================")
 
-		// Read migration file
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return err
+	for _, m := range migrations {
+		reversible := ""
+		if m.DownPath == "" {
+			reversible = ", no down file"
 		}
 
-		// Execute migration (file already contains BEGIN/COMMIT)
-		_, err = db.Exec(string(content))
-		if err != nil {
-			return fmt.Errorf("failed to execute migration %s: %v", filename, err)
-		}
+		var appliedAt sql.NullString
+		err := db.QueryRow("SELECT applied_at FROM schema_migrations WHERE version = $1", m.Version).Scan(&appliedAt)
 
-		// Update migrations table in a separate transaction
-		tx, err := db.Begin()
-		if err != nil {
+		if err == sql.ErrNoRows {
+			fmt.Printf("❌ %s (not applied%s)\n", m.Filename, reversible)
+		} else if err != nil {
 			return err
-		}
-
-		if direction == "up" {
-			_, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING", version)
 		} else {
-			_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = $1", version)
+			fmt.Printf("✅ %s (applied at %s%s)\n", m.Filename, appliedAt.String, reversible)
 		}
-
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to update migrations table for %s: %v", filename, err)
-		}
-
-		if err := tx.Commit(); err != nil {
-			return err
-		}
-
-		fmt.Printf("Successfully applied %s migration: %s\n", direction, filename)
 	}
 
 	return nil
 }
 
-func showMigrationStatus(db *sql.DB) error {
-	migrationsDir := "db/migrations"
-
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+func showMigrationPlan(db *sql.DB, phase migration.Phase, cfg *config.Config) error {
+	plan, err := migration.Plan(db, migrationsDir, phase)
 	if err != nil {
 		return err
 	}
 
-	sort.Strings(files)
-
-	fmt.Println("Migration Status:")
+	fmt.Printf("Migration Plan (phase: %s)\n", phase)
 	fmt.Println("================")
 
-	for _, file := range files {
-		filename := filepath.Base(file)
-		version := strings.TrimSuffix(filename, ".sql")
+	if len(plan) == 0 {
+		fmt.Println("Nothing to do, all migrations are applied")
+		return nil
+	}
 
-		var appliedAt sql.NullString
-		err := db.QueryRow("SELECT applied_at FROM schema_migrations WHERE version = $1", version).Scan(&appliedAt)
+	for _, m := range plan {
+		marker := "▶️"
+		if !m.WillRun {
+			marker = "⏸️"
+		}
 
-		if err == sql.ErrNoRows {
-			fmt.Printf("❌ %s (not applied)\n", filename)
-		} else if err != nil {
-			return err
-		} else {
-			fmt.Printf("✅ %s (applied at %s)\n", filename, appliedAt.String)
+		lockTimeout := cfg.Database.MigrationLockTimeout
+		if m.LockTimeout != nil {
+			lockTimeout = *m.LockTimeout
+		}
+		statementTimeout := cfg.Database.MigrationStatementTimeout
+		if m.StatementTimeout != nil {
+			statementTimeout = *m.StatementTimeout
+		}
+
+		fmt.Printf("%s %s [%s] lock_timeout=%s statement_timeout=%s\n",
+			marker, m.Filename, m.Phase, lockTimeout, statementTimeout)
+		if !m.WillRun {
+			fmt.Println("   held back until post-deploy")
+		}
+
+		for _, lock := range m.Locks {
+			warning := ""
+			if migration.IsBlocking(lock.Mode) {
+				warning = " ⚠️ blocks traffic"
+			}
+			fmt.Printf("   %-24s %-20s%s\n      %s\n", lock.Mode, lock.Table, warning, lock.Statement)
 		}
 	}
 
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ai-styler/internal/vendors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entity types in seeding order; later types reference earlier ones
+const (
+	entityPlans    = "plans"
+	entityUsers    = "users"
+	entityVendors  = "vendors"
+	entityStyles   = "styles"
+	entitySettings = "settings"
+)
+
+var entityOrder = []string{entityPlans, entityUsers, entityVendors, entityStyles, entitySettings}
+
+// commonProfile holds fixtures loaded for every profile
+const commonProfile = "common"
+
+// profiles are the environments with a fixture directory
+var profiles = []string{"dev", "staging", "demo"}
+
+// UserFixture is a user upserted by phone
+type UserFixture struct {
+	Phone       string `yaml:"phone"`
+	Name        string `yaml:"name"`
+	Role        string `yaml:"role"`
+	Password    string `yaml:"password"`
+	PasswordEnv string `yaml:"password_env"` // read the password from this variable
+	Plan        string `yaml:"plan"`         // payment plan name
+	Verified    *bool  `yaml:"verified"`
+	Active      *bool  `yaml:"active"`
+}
+
+// PlanFixture is a payment plan upserted by name
+type PlanFixture struct {
+	Name                    string   `yaml:"name"`
+	DisplayName             string   `yaml:"display_name"`
+	Description             string   `yaml:"description"`
+	PricePerMonthCents      int64    `yaml:"price_per_month_cents"`
+	MonthlyConversionsLimit int      `yaml:"monthly_conversions_limit"`
+	MonthlyImagesLimit      int      `yaml:"monthly_images_limit"`
+	Features                []string `yaml:"features"`
+	Active                  *bool    `yaml:"active"`
+}
+
+// VendorFixture is a vendor upserted by its owner's phone
+type VendorFixture struct {
+	OwnerPhone   string            `yaml:"owner_phone"`
+	BusinessName string            `yaml:"business_name"`
+	DisplayName  string            `yaml:"display_name"`
+	Slug         string            `yaml:"slug"`
+	Bio          string            `yaml:"bio"`
+	Verified     bool              `yaml:"verified"`
+	ContactInfo  map[string]string `yaml:"contact_info"`
+	SocialLinks  map[string]string `yaml:"social_links"`
+}
+
+// StyleFixture is a conversion style upserted by key
+type StyleFixture struct {
+	Key         string `yaml:"key"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Prompt      string `yaml:"prompt"`
+	SortOrder   int    `yaml:"sort_order"`
+	Active      *bool  `yaml:"active"`
+}
+
+// SettingFixture is a system setting upserted by key
+type SettingFixture struct {
+	Key   string      `yaml:"key"`
+	Value interface{} `yaml:"value"`
+	Type  string      `yaml:"type"`
+}
+
+// Fixtures are the entities of one profile. A YAML file may contain any of
+// the sections.
+type Fixtures struct {
+	Plans    []PlanFixture    `yaml:"plans"`
+	Users    []UserFixture    `yaml:"users"`
+	Vendors  []VendorFixture  `yaml:"vendors"`
+	Styles   []StyleFixture   `yaml:"styles"`
+	Settings []SettingFixture `yaml:"settings"`
+}
+
+// loadProfile reads the common fixtures and then the profile's fixtures from
+// dir. Profile entries replace common entries with the same key.
+func loadProfile(dir, profile string) (*Fixtures, error) {
+	if !validProfile(profile) {
+		return nil, fmt.Errorf("unknown profile %q (use %s)", profile, strings.Join(profiles, ", "))
+	}
+
+	fixtures := &Fixtures{}
+	for _, name := range []string{commonProfile, profile} {
+		files, err := filepath.Glob(filepath.Join(dir, name, "*.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			loaded, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			fixtures.merge(loaded)
+		}
+	}
+
+	if err := fixtures.validate(); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+func validProfile(profile string) bool {
+	for _, p := range profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+func loadFile(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var fixtures Fixtures
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixtures); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return &fixtures, nil
+}
+
+// merge adds other's entries, replacing entries with the same key
+func (f *Fixtures) merge(other *Fixtures) {
+	f.Plans = mergeByKey(f.Plans, other.Plans, func(p PlanFixture) string { return p.Name })
+	f.Users = mergeByKey(f.Users, other.Users, func(u UserFixture) string { return u.Phone })
+	f.Vendors = mergeByKey(f.Vendors, other.Vendors, func(v VendorFixture) string { return v.OwnerPhone })
+	f.Styles = mergeByKey(f.Styles, other.Styles, func(s StyleFixture) string { return s.Key })
+	f.Settings = mergeByKey(f.Settings, other.Settings, func(s SettingFixture) string { return s.Key })
+}
+
+func mergeByKey[T any](base, overrides []T, key func(T) string) []T {
+	index := make(map[string]int, len(base))
+	for i, item := range base {
+		index[key(item)] = i
+	}
+	for _, item := range overrides {
+		if i, ok := index[key(item)]; ok {
+			base[i] = item
+			continue
+		}
+		index[key(item)] = len(base)
+		base = append(base, item)
+	}
+	return base
+}
+
+// validate checks required fields and references between fixtures
+func (f *Fixtures) validate() error {
+	plans := make(map[string]bool)
+	for _, plan := range f.Plans {
+		if plan.Name == "" || plan.DisplayName == "" {
+			return fmt.Errorf("plan %q: name and display_name are required", plan.Name)
+		}
+		plans[plan.Name] = true
+	}
+
+	users := make(map[string]string)
+	for _, user := range f.Users {
+		if user.Phone == "" {
+			return fmt.Errorf("user %q: phone is required", user.Name)
+		}
+		switch user.Role {
+		case "user", "vendor", "admin":
+		default:
+			return fmt.Errorf("user %s: role must be user, vendor or admin", user.Phone)
+		}
+		if user.Plan != "" && !plans[user.Plan] {
+			return fmt.Errorf("user %s: unknown plan %q", user.Phone, user.Plan)
+		}
+		users[user.Phone] = user.Role
+	}
+
+	for _, vendor := range f.Vendors {
+		if vendor.BusinessName == "" {
+			return fmt.Errorf("vendor of %s: business_name is required", vendor.OwnerPhone)
+		}
+		if vendor.Slug != "" && !vendors.ValidSlug(vendor.Slug) {
+			return fmt.Errorf("vendor %q: invalid slug %q", vendor.BusinessName, vendor.Slug)
+		}
+		if role, ok := users[vendor.OwnerPhone]; !ok || role != "vendor" {
+			return fmt.Errorf("vendor %q: owner_phone must be a vendor user in the fixtures", vendor.BusinessName)
+		}
+	}
+
+	for _, style := range f.Styles {
+		if style.Key == "" || style.Name == "" {
+			return fmt.Errorf("style %q: key and name are required", style.Key)
+		}
+	}
+
+	for _, setting := range f.Settings {
+		switch setting.Type {
+		case "string", "boolean", "integer", "array":
+		default:
+			return fmt.Errorf("setting %s: type must be string, boolean, integer or array", setting.Key)
+		}
+	}
+
+	return nil
+}
+
+// parseOnly parses the --only flag into a set of entity types; empty selects
+// every type
+func parseOnly(value string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+	if strings.TrimSpace(value) == "" {
+		for _, entity := range entityOrder {
+			selected[entity] = true
+		}
+		return selected, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		entity := strings.TrimSpace(part)
+		known := false
+		for _, e := range entityOrder {
+			if e == entity {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown entity type %q (use %s)", entity, strings.Join(entityOrder, ", "))
+		}
+		selected[entity] = true
+	}
+	return selected, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const seedsDir = "../../db/seeds"
+
+func TestLoadProfiles(t *testing.T) {
+	for _, profile := range profiles {
+		t.Run(profile, func(t *testing.T) {
+			fixtures, err := loadProfile(seedsDir, profile)
+			if err != nil {
+				t.Fatalf("Expected fixtures to load, got %v", err)
+			}
+			if len(fixtures.Plans) == 0 || len(fixtures.Styles) == 0 || len(fixtures.Settings) == 0 {
+				t.Errorf("Expected common plans, styles and settings, got %+v", fixtures)
+			}
+		})
+	}
+
+	if _, err := loadProfile(seedsDir, "production"); err == nil {
+		t.Error("Expected error for an unknown profile")
+	}
+}
+
+func TestLoadProfileOverrides(t *testing.T) {
+	fixtures, err := loadProfile(seedsDir, "dev")
+	if err != nil {
+		t.Fatalf("Expected fixtures to load, got %v", err)
+	}
+
+	// dev/settings.yaml replaces the common value without duplicating the key
+	count := 0
+	for _, setting := range fixtures.Settings {
+		if setting.Key == "rate_limit_enabled" {
+			count++
+			if setting.Value != false {
+				t.Errorf("Expected the dev override false, got %v", setting.Value)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected rate_limit_enabled once, got %d", count)
+	}
+}
+
+func TestLoadProfileValidation(t *testing.T) {
+	dir := t.TempDir()
+	write := func(profile, content string) {
+		if err := os.MkdirAll(filepath.Join(dir, profile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, profile, "fixtures.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown plan", "users:\n  - {phone: '+1', role: user, plan: gold}\n"},
+		{"invalid role", "users:\n  - {phone: '+1', role: owner}\n"},
+		{"vendor owner is not a vendor", "users:\n  - {phone: '+1', role: user}\nvendors:\n  - {owner_phone: '+1', business_name: Shop}\n"},
+		{"invalid slug", "users:\n  - {phone: '+1', role: vendor}\nvendors:\n  - {owner_phone: '+1', business_name: Shop, slug: 'Not A Slug'}\n"},
+		{"unknown field", "styles:\n  - {key: casual, name: Casual, colour: red}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("demo", tt.content)
+			if _, err := loadProfile(dir, "demo"); err == nil {
+				t.Error("Expected a validation error")
+			}
+		})
+	}
+}
+
+func TestParseOnly(t *testing.T) {
+	all, err := parseOnly("")
+	if err != nil || len(all) != len(entityOrder) {
+		t.Errorf("Expected every entity type, got %v (%v)", all, err)
+	}
+
+	selected, err := parseOnly("users, styles")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !selected[entityUsers] || !selected[entityStyles] || selected[entityPlans] {
+		t.Errorf("Expected users and styles only, got %v", selected)
+	}
+
+	if _, err := parseOnly("users,orders"); err == nil {
+		t.Error("Expected error for an unknown entity type")
+	}
+}
+
+func TestSettingValue(t *testing.T) {
+	value, err := settingValue(SettingFixture{Key: "types", Type: "array", Value: []interface{}{"jpg", "png"}})
+	if err != nil || value != `["jpg","png"]` {
+		t.Errorf("Expected a JSON array, got %q (%v)", value, err)
+	}
+
+	if value, _ := settingValue(SettingFixture{Key: "enabled", Type: "boolean", Value: true}); value != "true" {
+		t.Errorf("Expected true, got %q", value)
+	}
+
+	if _, err := settingValue(SettingFixture{Key: "types", Type: "array", Value: "jpg"}); err == nil {
+		t.Error("Expected error for a non-list array value")
+	}
+}
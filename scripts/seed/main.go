@@ -1,23 +1,41 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
 	"ai-styler/internal/config"
+	"ai-styler/internal/security"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+const usage = "Usage: go run main.go [seed|clear|status] [--profile dev|staging|demo] [--only plans,users,vendors,styles,settings] [--dir db/seeds]"
+
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run main.go [seed|clear|status]")
+		log.Fatal(usage)
 	}
 
 	command := os.Args[1]
 
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	profile := flags.String("profile", "dev", "fixture profile: dev, staging or demo")
+	only := flags.String("only", "", "comma-separated entity types to seed or clear (default all)")
+	dir := flags.String("dir", "db/seeds", "directory holding the common and per-profile fixtures")
+	flags.Parse(os.Args[2:])
+
+	selected, err := parseOnly(*only)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -47,11 +65,19 @@ func main() {
 
 	switch command {
 	case "seed":
-		if err := seedDatabase(db); err != nil {
+		fixtures, err := loadProfile(*dir, *profile)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		if err := seedDatabase(db, fixtures, selected, newPasswordHasher(cfg)); err != nil {
 			log.Fatalf("Failed to seed database: %v", err)
 		}
 	case "clear":
-		if err := clearSeedData(db); err != nil {
+		fixtures, err := loadProfile(*dir, *profile)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		if err := clearSeedData(db, fixtures, selected); err != nil {
 			log.Fatalf("Failed to clear seed data: %v", err)
 		}
 	case "status":
@@ -59,355 +85,361 @@ func main() {
 			log.Fatalf("Failed to show seed status: %v", err)
 		}
 	default:
-		log.Fatal("Invalid command. Use: seed, clear, or status")
+		log.Fatal(usage)
+	}
+}
+
+// newPasswordHasher returns the hasher the auth service verifies against
+func newPasswordHasher(cfg *config.Config) security.PasswordHasher {
+	if cfg.Security.Argon2Memory > 0 {
+		return security.NewArgon2Hasher(
+			cfg.Security.Argon2Memory,
+			cfg.Security.Argon2Iterations,
+			cfg.Security.Argon2Parallelism,
+			cfg.Security.Argon2SaltLength,
+			cfg.Security.Argon2KeyLength,
+		)
 	}
+	return security.NewBCryptHasher(cfg.Security.BCryptCost)
 }
 
-func seedDatabase(db *sql.DB) error {
+// seedDatabase upserts the selected fixtures in one transaction, so running
+// it again updates rows in place instead of skipping or duplicating them
+func seedDatabase(db *sql.DB, fixtures *Fixtures, selected map[string]bool, hasher security.PasswordHasher) error {
 	fmt.Println("Starting database seeding...")
 
-	// Seed admin users
-	if err := seedAdminUsers(db); err != nil {
-		return fmt.Errorf("failed to seed admin users: %v", err)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	// Seed default plans
-	if err := seedDefaultPlans(db); err != nil {
-		return fmt.Errorf("failed to seed default plans: %v", err)
-	}
+	for _, entity := range entityOrder {
+		if !selected[entity] {
+			continue
+		}
 
-	// Seed sample vendors
-	if err := seedSampleVendors(db); err != nil {
-		return fmt.Errorf("failed to seed sample vendors: %v", err)
+		var err error
+		switch entity {
+		case entityPlans:
+			err = seedPlans(tx, fixtures.Plans)
+		case entityUsers:
+			err = seedUsers(tx, fixtures.Users, hasher)
+		case entityVendors:
+			err = seedVendors(tx, fixtures.Vendors)
+		case entityStyles:
+			err = seedStyles(tx, fixtures.Styles)
+		case entitySettings:
+			err = seedSettings(tx, fixtures.Settings)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to seed %s: %v", entity, err)
+		}
 	}
 
-	// Seed system settings
-	if err := seedSystemSettings(db); err != nil {
-		return fmt.Errorf("failed to seed system settings: %v", err)
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
 	fmt.Println("Database seeding completed successfully!")
 	return nil
 }
 
-func seedAdminUsers(db *sql.DB) error {
-	fmt.Println("Seeding admin users...")
+// upsertAction reports whether an upsert inserted or updated its row. xmax is
+// 0 for freshly inserted row versions.
+func upsertAction(inserted bool) string {
+	if inserted {
+		return "Created"
+	}
+	return "Updated"
+}
 
-	adminUsers := []struct {
-		Phone    string
-		Name     string
-		Email    string
-		Role     string
-		IsActive bool
-	}{
-		{
-			Phone:    "+989123456789",
-			Name:     "Super Admin",
-			Email:    "admin@aistyler.com",
-			Role:     "super_admin",
-			IsActive: true,
-		},
-		{
-			Phone:    "+989123456790",
-			Name:     "System Admin",
-			Email:    "system@aistyler.com",
-			Role:     "admin",
-			IsActive: true,
-		},
-	}
-
-	for _, admin := range adminUsers {
-		// Check if admin already exists
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM users WHERE phone = $1", admin.Phone).Scan(&count)
-		if err != nil {
-			return err
-		}
+func boolOr(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
 
-		if count > 0 {
-			fmt.Printf("Admin user %s already exists, skipping...\n", admin.Phone)
-			continue
-		}
+func seedPlans(tx *sql.Tx, plans []PlanFixture) error {
+	fmt.Println("Seeding plans...")
+
+	query := `
+		INSERT INTO payment_plans (name, display_name, description, price_per_month_cents,
+		                           monthly_conversions_limit, monthly_images_limit, features, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			description = EXCLUDED.description,
+			price_per_month_cents = EXCLUDED.price_per_month_cents,
+			monthly_conversions_limit = EXCLUDED.monthly_conversions_limit,
+			monthly_images_limit = EXCLUDED.monthly_images_limit,
+			features = EXCLUDED.features,
+			is_active = EXCLUDED.is_active
+		RETURNING (xmax = 0)
+	`
 
-		// Insert admin user
-		query := `
-			INSERT INTO users (id, phone, name, email, role, is_active, is_verified, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, true, NOW(), NOW())
-		`
+	for _, plan := range plans {
+		features := plan.Features
+		if features == nil {
+			features = []string{}
+		}
 
-		userID := generateUUID()
-		_, err = db.Exec(query, userID, admin.Phone, admin.Name, admin.Email, admin.Role, admin.IsActive)
+		var inserted bool
+		err := tx.QueryRow(query, plan.Name, plan.DisplayName, plan.Description, plan.PricePerMonthCents,
+			plan.MonthlyConversionsLimit, plan.MonthlyImagesLimit, pq.Array(features), boolOr(plan.Active, true)).Scan(&inserted)
 		if err != nil {
-			return err
+			return fmt.Errorf("plan %s: %v", plan.Name, err)
 		}
 
-		fmt.Printf("Created admin user: %s (%s)\n", admin.Name, admin.Phone)
+		fmt.Printf("%s plan: %s\n", upsertAction(inserted), plan.Name)
 	}
 
 	return nil
 }
 
-func seedDefaultPlans(db *sql.DB) error {
-	fmt.Println("Seeding default plans...")
-
-	plans := []struct {
-		Name        string
-		Description string
-		Price       int64
-		Duration    int
-		Features    map[string]interface{}
-		IsActive    bool
-	}{
-		{
-			Name:        "Free Plan",
-			Description: "Basic plan with limited features",
-			Price:       0,
-			Duration:    30,
-			Features: map[string]interface{}{
-				"max_conversions":   10,
-				"max_images":        50,
-				"storage_limit":     "100MB",
-				"api_access":        false,
-				"priority_support":  false,
-				"watermark_removal": false,
-			},
-			IsActive: true,
-		},
-		{
-			Name:        "Basic Plan",
-			Description: "Standard plan for regular users",
-			Price:       99000, // 99,000 IRR
-			Duration:    30,
-			Features: map[string]interface{}{
-				"max_conversions":   100,
-				"max_images":        500,
-				"storage_limit":     "1GB",
-				"api_access":        true,
-				"priority_support":  false,
-				"watermark_removal": true,
-			},
-			IsActive: true,
-		},
-		{
-			Name:        "Premium Plan",
-			Description: "Advanced plan for power users",
-			Price:       199000, // 199,000 IRR
-			Duration:    30,
-			Features: map[string]interface{}{
-				"max_conversions":   500,
-				"max_images":        2500,
-				"storage_limit":     "5GB",
-				"api_access":        true,
-				"priority_support":  true,
-				"watermark_removal": true,
-			},
-			IsActive: true,
-		},
-		{
-			Name:        "Enterprise Plan",
-			Description: "Unlimited plan for businesses",
-			Price:       499000, // 499,000 IRR
-			Duration:    30,
-			Features: map[string]interface{}{
-				"max_conversions":    -1, // unlimited
-				"max_images":         -1, // unlimited
-				"storage_limit":      "50GB",
-				"api_access":         true,
-				"priority_support":   true,
-				"watermark_removal":  true,
-				"custom_integration": true,
-			},
-			IsActive: true,
-		},
-	}
-
-	for _, plan := range plans {
-		// Check if plan already exists
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM plans WHERE name = $1", plan.Name).Scan(&count)
-		if err != nil {
-			return err
+func seedUsers(tx *sql.Tx, users []UserFixture, hasher security.PasswordHasher) error {
+	fmt.Println("Seeding users...")
+
+	// The password is only replaced when the fixture sets one
+	query := `
+		INSERT INTO users (phone, password_hash, name, role, plan_id, is_phone_verified, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), $4, (SELECT id FROM payment_plans WHERE name = $5), $6, $7)
+		ON CONFLICT (phone) DO UPDATE SET
+			password_hash = CASE WHEN $8 THEN EXCLUDED.password_hash ELSE users.password_hash END,
+			name = EXCLUDED.name,
+			role = EXCLUDED.role,
+			plan_id = EXCLUDED.plan_id,
+			is_phone_verified = EXCLUDED.is_phone_verified,
+			is_active = EXCLUDED.is_active
+		RETURNING (xmax = 0)
+	`
+
+	for _, user := range users {
+		password := user.Password
+		if user.PasswordEnv != "" {
+			password = os.Getenv(user.PasswordEnv)
+			if password == "" {
+				return fmt.Errorf("user %s: %s is not set", user.Phone, user.PasswordEnv)
+			}
 		}
 
-		if count > 0 {
-			fmt.Printf("Plan %s already exists, skipping...\n", plan.Name)
-			continue
+		// Users without a password get a random one and sign in with OTP
+		hasPassword := password != ""
+		if !hasPassword {
+			random := make([]byte, 32)
+			if _, err := rand.Read(random); err != nil {
+				return err
+			}
+			password = hex.EncodeToString(random)
+		}
+		hash, err := hasher.Hash(password)
+		if err != nil {
+			return fmt.Errorf("user %s: failed to hash password: %v", user.Phone, err)
 		}
 
-		// Insert plan
-		query := `
-			INSERT INTO plans (id, name, description, price, duration_days, features, is_active, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
-		`
-
-		planID := generateUUID()
-		_, err = db.Exec(query, planID, plan.Name, plan.Description, plan.Price, plan.Duration, plan.Features, plan.IsActive)
+		var inserted bool
+		err = tx.QueryRow(query, user.Phone, hash, user.Name, user.Role, user.Plan,
+			boolOr(user.Verified, true), boolOr(user.Active, true), hasPassword).Scan(&inserted)
 		if err != nil {
-			return err
+			return fmt.Errorf("user %s: %v", user.Phone, err)
 		}
 
-		fmt.Printf("Created plan: %s\n", plan.Name)
+		fmt.Printf("%s %s user: %s\n", upsertAction(inserted), user.Role, user.Phone)
 	}
 
 	return nil
 }
 
-func seedSampleVendors(db *sql.DB) error {
-	fmt.Println("Seeding sample vendors...")
-
-	vendors := []struct {
-		Name        string
-		Description string
-		Website     string
-		ContactInfo map[string]interface{}
-		IsActive    bool
-	}{
-		{
-			Name:        "AI Style Pro",
-			Description: "Professional AI styling services",
-			Website:     "https://aistylepro.com",
-			ContactInfo: map[string]interface{}{
-				"email":   "contact@aistylepro.com",
-				"phone":   "+989123456789",
-				"address": "Tehran, Iran",
-			},
-			IsActive: true,
-		},
-		{
-			Name:        "Style Master",
-			Description: "Expert styling and design services",
-			Website:     "https://stylemaster.ir",
-			ContactInfo: map[string]interface{}{
-				"email":   "info@stylemaster.ir",
-				"phone":   "+989123456790",
-				"address": "Isfahan, Iran",
-			},
-			IsActive: true,
-		},
-	}
+func seedVendors(tx *sql.Tx, vendors []VendorFixture) error {
+	fmt.Println("Seeding vendors...")
+
+	query := `
+		INSERT INTO vendors (user_id, business_name, company_name, display_name, slug, bio,
+		                     is_verified, contact_info, social_links)
+		SELECT id, $2, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7::jsonb, $8::jsonb
+		FROM users WHERE phone = $1
+		ON CONFLICT (user_id) DO UPDATE SET
+			business_name = EXCLUDED.business_name,
+			company_name = EXCLUDED.company_name,
+			display_name = EXCLUDED.display_name,
+			slug = EXCLUDED.slug,
+			bio = EXCLUDED.bio,
+			is_verified = EXCLUDED.is_verified,
+			contact_info = EXCLUDED.contact_info,
+			social_links = EXCLUDED.social_links
+		RETURNING (xmax = 0)
+	`
 
 	for _, vendor := range vendors {
-		// Check if vendor already exists
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM vendors WHERE name = $1", vendor.Name).Scan(&count)
+		contactInfo, err := jsonObject(vendor.ContactInfo)
+		if err != nil {
+			return err
+		}
+		socialLinks, err := jsonObject(vendor.SocialLinks)
 		if err != nil {
 			return err
 		}
 
-		if count > 0 {
-			fmt.Printf("Vendor %s already exists, skipping...\n", vendor.Name)
-			continue
+		var inserted bool
+		err = tx.QueryRow(query, vendor.OwnerPhone, vendor.BusinessName, vendor.DisplayName, vendor.Slug,
+			vendor.Bio, vendor.Verified, contactInfo, socialLinks).Scan(&inserted)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("vendor %s: owner %s does not exist, seed users first", vendor.BusinessName, vendor.OwnerPhone)
+		}
+		if err != nil {
+			return fmt.Errorf("vendor %s: %v", vendor.BusinessName, err)
 		}
 
-		// Insert vendor
-		query := `
-			INSERT INTO vendors (id, name, description, website, contact_info, is_active, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
-		`
+		fmt.Printf("%s vendor: %s\n", upsertAction(inserted), vendor.BusinessName)
+	}
 
-		vendorID := generateUUID()
-		_, err = db.Exec(query, vendorID, vendor.Name, vendor.Description, vendor.Website, vendor.ContactInfo, vendor.IsActive)
+	return nil
+}
+
+func jsonObject(values map[string]string) (string, error) {
+	if values == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func seedStyles(tx *sql.Tx, styles []StyleFixture) error {
+	fmt.Println("Seeding styles...")
+
+	query := `
+		INSERT INTO styles (key, name, description, prompt, sort_order, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			prompt = EXCLUDED.prompt,
+			sort_order = EXCLUDED.sort_order,
+			is_active = EXCLUDED.is_active
+		RETURNING (xmax = 0)
+	`
+
+	for _, style := range styles {
+		var inserted bool
+		err := tx.QueryRow(query, style.Key, style.Name, style.Description, style.Prompt,
+			style.SortOrder, boolOr(style.Active, true)).Scan(&inserted)
 		if err != nil {
-			return err
+			return fmt.Errorf("style %s: %v", style.Key, err)
 		}
 
-		fmt.Printf("Created vendor: %s\n", vendor.Name)
+		fmt.Printf("%s style: %s\n", upsertAction(inserted), style.Key)
 	}
 
 	return nil
 }
 
-func seedSystemSettings(db *sql.DB) error {
+func seedSettings(tx *sql.Tx, settings []SettingFixture) error {
 	fmt.Println("Seeding system settings...")
 
-	settings := []struct {
-		Key   string
-		Value interface{}
-		Type  string
-	}{
-		{"app_name", "AI Styler", "string"},
-		{"app_version", "1.0.0", "string"},
-		{"maintenance_mode", false, "boolean"},
-		{"max_file_size", "50MB", "string"},
-		{"allowed_file_types", []string{"jpg", "jpeg", "png", "gif", "webp"}, "array"},
-		{"default_plan_id", "", "string"}, // Will be set after plans are created
-		{"smtp_enabled", false, "boolean"},
-		{"sms_enabled", true, "boolean"},
-		{"payment_enabled", true, "boolean"},
-		{"analytics_enabled", true, "boolean"},
-		{"backup_enabled", true, "boolean"},
-		{"backup_frequency", "daily", "string"},
-		{"retention_days", 365, "integer"},
-		{"rate_limit_enabled", true, "boolean"},
-		{"rate_limit_per_minute", 60, "integer"},
-		{"security_scan_enabled", true, "boolean"},
-		{"watermark_enabled", true, "boolean"},
-		{"api_rate_limit", 1000, "integer"},
-		{"max_concurrent_conversions", 10, "integer"},
-		{"conversion_timeout", 300, "integer"}, // 5 minutes
-	}
+	query := `
+		INSERT INTO system_settings (key, value, type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			value = EXCLUDED.value,
+			type = EXCLUDED.type
+		RETURNING (xmax = 0)
+	`
 
 	for _, setting := range settings {
-		// Check if setting already exists
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM system_settings WHERE key = $1", setting.Key).Scan(&count)
+		value, err := settingValue(setting)
 		if err != nil {
 			return err
 		}
 
-		if count > 0 {
-			fmt.Printf("Setting %s already exists, skipping...\n", setting.Key)
-			continue
-		}
-
-		// Insert setting
-		query := `
-			INSERT INTO system_settings (id, key, value, type, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, NOW(), NOW())
-		`
-
-		settingID := generateUUID()
-		_, err = db.Exec(query, settingID, setting.Key, setting.Value, setting.Type)
-		if err != nil {
-			return err
+		var inserted bool
+		if err := tx.QueryRow(query, setting.Key, value, setting.Type).Scan(&inserted); err != nil {
+			return fmt.Errorf("setting %s: %v", setting.Key, err)
 		}
 
-		fmt.Printf("Created setting: %s\n", setting.Key)
+		fmt.Printf("%s setting: %s\n", upsertAction(inserted), setting.Key)
 	}
 
-	// Set default plan ID after plans are created
-	var defaultPlanID string
-	err := db.QueryRow("SELECT id FROM plans WHERE name = 'Free Plan' LIMIT 1").Scan(&defaultPlanID)
-	if err == nil {
-		_, err = db.Exec("UPDATE system_settings SET value = $1 WHERE key = 'default_plan_id'", defaultPlanID)
+	return nil
+}
+
+// settingValue renders a fixture value as stored in system_settings: text,
+// with arrays as JSON
+func settingValue(setting SettingFixture) (string, error) {
+	if setting.Type == "array" {
+		values, ok := setting.Value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("setting %s: value must be a list", setting.Key)
+		}
+		encoded, err := json.Marshal(values)
 		if err != nil {
-			return err
+			return "", err
 		}
-		fmt.Printf("Set default plan ID: %s\n", defaultPlanID)
+		return string(encoded), nil
 	}
-
-	return nil
+	if setting.Value == nil {
+		return "", nil
+	}
+	return fmt.Sprint(setting.Value), nil
 }
 
-func clearSeedData(db *sql.DB) error {
+// clearSeedData deletes the rows of the profile's fixtures, leaving data
+// created through the application alone. Vendors go with their users.
+func clearSeedData(db *sql.DB, fixtures *Fixtures, selected map[string]bool) error {
 	fmt.Println("Clearing seed data...")
 
-	tables := []string{
-		"system_settings",
-		"plans",
-		"vendors",
-		"users",
+	keys := map[string][]string{}
+	for _, s := range fixtures.Settings {
+		keys[entitySettings] = append(keys[entitySettings], s.Key)
+	}
+	for _, s := range fixtures.Styles {
+		keys[entityStyles] = append(keys[entityStyles], s.Key)
+	}
+	for _, v := range fixtures.Vendors {
+		keys[entityVendors] = append(keys[entityVendors], v.OwnerPhone)
+	}
+	for _, u := range fixtures.Users {
+		keys[entityUsers] = append(keys[entityUsers], u.Phone)
+	}
+	for _, p := range fixtures.Plans {
+		keys[entityPlans] = append(keys[entityPlans], p.Name)
 	}
 
-	for _, table := range tables {
-		query := fmt.Sprintf("DELETE FROM %s", table)
-		result, err := db.Exec(query)
+	queries := map[string]string{
+		entitySettings: "DELETE FROM system_settings WHERE key = ANY($1)",
+		entityStyles:   "DELETE FROM styles WHERE key = ANY($1)",
+		entityVendors:  "DELETE FROM vendors WHERE user_id IN (SELECT id FROM users WHERE phone = ANY($1))",
+		entityUsers:    "DELETE FROM users WHERE phone = ANY($1)",
+		entityPlans:    "DELETE FROM payment_plans WHERE name = ANY($1)",
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Reverse seeding order so references are removed first
+	for i := len(entityOrder) - 1; i >= 0; i-- {
+		entity := entityOrder[i]
+		if !selected[entity] || len(keys[entity]) == 0 {
+			continue
+		}
+
+		result, err := tx.Exec(queries[entity], pq.Array(keys[entity]))
 		if err != nil {
-			return fmt.Errorf("failed to clear table %s: %v", table, err)
+			return fmt.Errorf("failed to clear %s: %v", entity, err)
 		}
 
 		rowsAffected, _ := result.RowsAffected()
-		fmt.Printf("Cleared %d rows from %s\n", rowsAffected, table)
+		fmt.Printf("Cleared %d %s\n", rowsAffected, entity)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
 	fmt.Println("Seed data cleared successfully!")
@@ -418,43 +450,24 @@ func showSeedStatus(db *sql.DB) error {
 	fmt.Println("Seed Data Status:")
 	fmt.Println("=================")
 
-	// Check users
-	var userCount int
-	err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("Users: %d\n", userCount)
-
-	// Check plans
-	var planCount int
-	err = db.QueryRow("SELECT COUNT(*) FROM plans").Scan(&planCount)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("Plans: %d\n", planCount)
-
-	// Check vendors
-	var vendorCount int
-	err = db.QueryRow("SELECT COUNT(*) FROM vendors").Scan(&vendorCount)
-	if err != nil {
-		return err
+	counts := []struct {
+		Label string
+		Table string
+	}{
+		{"Plans", "payment_plans"},
+		{"Users", "users"},
+		{"Vendors", "vendors"},
+		{"Styles", "styles"},
+		{"System Settings", "system_settings"},
 	}
-	fmt.Printf("Vendors: %d\n", vendorCount)
 
-	// Check system settings
-	var settingCount int
-	err = db.QueryRow("SELECT COUNT(*) FROM system_settings").Scan(&settingCount)
-	if err != nil {
-		return err
+	for _, c := range counts {
+		var count int
+		if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", c.Table)).Scan(&count); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d\n", c.Label, count)
 	}
-	fmt.Printf("System Settings: %d\n", settingCount)
 
 	return nil
 }
-
-// Simple UUID generator (for demo purposes)
-func generateUUID() string {
-	// In production, use a proper UUID library
-	return fmt.Sprintf("uuid-%d", len(fmt.Sprintf("%p", generateUUID)))
-}
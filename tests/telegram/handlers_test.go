@@ -3,6 +3,7 @@ package telegram_test
 import (
 	"testing"
 
+	"ai-styler/internal/locale"
 	"ai-styler/internal/telegram"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -100,12 +101,19 @@ func TestAPIClient(t *testing.T) {
 // TestMessages tests message templates
 func TestMessages(t *testing.T) {
 	t.Run("GetProgressMessage", func(t *testing.T) {
-		msg := telegram.GetProgressMessage(50)
+		msg := telegram.GetProgressMessage(locale.LangPersian, 50)
 		if msg == "" {
 			t.Error("Progress message should not be empty")
 		}
 	})
 
+	t.Run("GetProgressMessageEnglish", func(t *testing.T) {
+		msg := telegram.GetProgressMessage(locale.LangEnglish, 150)
+		if msg != "Processing — 100%\nPlease wait..." {
+			t.Errorf("Expected English progress message capped at 100%%, got %q", msg)
+		}
+	})
+
 	t.Run("GetErrorCode", func(t *testing.T) {
 		code := telegram.GetErrorCode(nil)
 		if code == "" {
@@ -117,17 +125,59 @@ func TestMessages(t *testing.T) {
 // TestKeyboards tests keyboard builders
 func TestKeyboards(t *testing.T) {
 	t.Run("MainMenuKeyboard", func(t *testing.T) {
-		kb := telegram.MainMenuKeyboard()
+		kb := telegram.MainMenuKeyboard(locale.LangPersian)
 		if len(kb.InlineKeyboard) == 0 {
 			t.Error("Main menu keyboard should have buttons")
 		}
 	})
 
+	t.Run("MainMenuKeyboardLanguage", func(t *testing.T) {
+		persian := telegram.MainMenuKeyboard(locale.LangPersian).InlineKeyboard[0][0].Text
+		english := telegram.MainMenuKeyboard(locale.LangEnglish).InlineKeyboard[0][0].Text
+		if english != "✨ Start image conversion" {
+			t.Errorf("Expected English button label, got %q", english)
+		}
+		if persian == english {
+			t.Error("Persian and English labels should differ")
+		}
+		if unsupported := telegram.MainMenuKeyboard("de").InlineKeyboard[0][0].Text; unsupported != persian {
+			t.Errorf("Expected unsupported language to fall back to Persian, got %q", unsupported)
+		}
+	})
+
 	t.Run("StyleSelectionKeyboard", func(t *testing.T) {
-		kb := telegram.StyleSelectionKeyboard()
+		kb := telegram.StyleSelectionKeyboard(locale.LangPersian)
 		if len(kb.InlineKeyboard) == 0 {
 			t.Error("Style selection keyboard should have buttons")
 		}
 	})
+
+	t.Run("ConversionResultKeyboard", func(t *testing.T) {
+		kb := telegram.ConversionResultKeyboard(locale.LangPersian, "conv-1")
+		found := false
+		for _, row := range kb.InlineKeyboard {
+			for _, btn := range row {
+				if btn.CallbackData != nil && *btn.CallbackData == "share_conv-1" {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Error("Conversion result keyboard should have a share button")
+		}
+	})
+
+	t.Run("SharedLinkKeyboard", func(t *testing.T) {
+		kb := telegram.SharedLinkKeyboard(locale.LangPersian, "https://example.com/api/share/token", "share-1")
+		if len(kb.InlineKeyboard) < 2 {
+			t.Fatal("Shared link keyboard should have open and revoke rows")
+		}
+		if kb.InlineKeyboard[0][0].URL == nil {
+			t.Error("First button should open the share URL")
+		}
+		if data := kb.InlineKeyboard[1][0].CallbackData; data == nil || *data != "revoke_share_share-1" {
+			t.Error("Second button should revoke the share link")
+		}
+	})
 }
 